@@ -0,0 +1,142 @@
+// Package ast defines the syntax tree of prompty template bodies, for tools
+// that analyze or rewrite templates.
+//
+// Obtain a tree with prompty's Template.AST, inspect it with Walk or
+// Inspect, rewrite it with Transform, and turn it back into template source
+// with prompty.PrintAST:
+//
+//	tmpl, _ := engine.Parse(source)
+//	root := ast.Transform(tmpl.AST(), func(n ast.Node) ast.Node {
+//	    if tag, ok := n.(*ast.Tag); ok && tag.Name == "legacy.greeting" {
+//	        tag.Name = "prompty.var"
+//	    }
+//	    return n
+//	})
+//	migrated := prompty.PrintAST(root)
+//
+// Trees are plain values: they do not share state with the template they
+// were taken from, so they can be modified freely.
+package ast
+
+// Position is a location in template source.
+type Position struct {
+	Offset int // Byte offset from start
+	Line   int // 1-indexed line number
+	Column int // 1-indexed column number
+}
+
+// Node is a node of the syntax tree: *Root, *Text, *Tag, *Raw,
+// *Conditional, *For, *Switch or *Block.
+type Node interface {
+	// Pos returns the position of the node in the source.
+	Pos() Position
+	node()
+}
+
+// Root is the top of a template body.
+type Root struct {
+	Children []Node
+}
+
+// Text is literal text. Escaped delimiters are unescaped.
+type Text struct {
+	Position Position
+	Content  string
+}
+
+// Tag is a tag, such as prompty.var, prompty.include, prompty.message or a
+// custom resolver's tag. Self-closing tags have no children.
+type Tag struct {
+	Position   Position
+	Name       string
+	Attributes map[string]string
+	SelfClose  bool
+	Children   []Node
+}
+
+// Raw is a prompty.raw block, whose content is not processed.
+type Raw struct {
+	Position Position
+	Content  string
+}
+
+// Conditional is a prompty.if tag with its elseif and else branches.
+type Conditional struct {
+	Position Position
+	Branches []*Branch
+}
+
+// Branch is one branch of a Conditional.
+type Branch struct {
+	Position  Position
+	Condition string // Expression; empty for else
+	Else      bool
+	Children  []Node
+}
+
+// For is a prompty.for loop.
+type For struct {
+	Position Position
+	Item     string // Variable of the current element
+	Index    string // Variable of the index (optional)
+	In       string // Data path of the collection
+	Limit    int    // Maximum iterations (0 = engine default)
+	Children []Node
+}
+
+// Switch is a prompty.switch tag with its cases.
+type Switch struct {
+	Position   Position
+	Expression string
+	Cases      []*Case
+	Default    *Case // nil if none
+}
+
+// Case is a case of a Switch.
+type Case struct {
+	Position    Position
+	Value       string // Value compared with the switch expression
+	Eval        string // Boolean expression, instead of Value
+	Fallthrough bool
+	Children    []Node
+}
+
+// Block is an overridable prompty.block of template inheritance.
+type Block struct {
+	Position Position
+	Name     string
+	Children []Node
+}
+
+// Pos returns the start of the body.
+func (n *Root) Pos() Position { return Position{Line: 1, Column: 1} }
+
+// Pos returns the position of the text.
+func (n *Text) Pos() Position { return n.Position }
+
+// Pos returns the position of the tag.
+func (n *Tag) Pos() Position { return n.Position }
+
+// Pos returns the position of the raw block.
+func (n *Raw) Pos() Position { return n.Position }
+
+// Pos returns the position of the prompty.if tag.
+func (n *Conditional) Pos() Position { return n.Position }
+
+// Pos returns the position of the loop.
+func (n *For) Pos() Position { return n.Position }
+
+// Pos returns the position of the switch.
+func (n *Switch) Pos() Position { return n.Position }
+
+// Pos returns the position of the block.
+func (n *Block) Pos() Position { return n.Position }
+
+func (*Root) node()        {}
+func (*Text) node()        {}
+func (*Tag) node()         {}
+func (*Raw) node()         {}
+func (*Conditional) node() {}
+func (*For) node()         {}
+func (*Switch) node()      {}
+func (*Block) node()       {}
@@ -0,0 +1,114 @@
+package ast
+
+// Visitor is called by Walk for each node. If Visit returns a non-nil
+// visitor w, Walk visits the children of the node with w, followed by a
+// call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses the tree in depth-first order, starting with node.
+// Children of conditional branches and switch cases are visited in source
+// order, with the default case last.
+func Walk(node Node, v Visitor) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	for _, child := range Children(node) {
+		Walk(child, v)
+	}
+	v.Visit(nil)
+}
+
+// inspector adapts a function to a Visitor.
+type inspector func(Node) bool
+
+// Visit calls the function and continues into children while it returns true.
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the tree in depth-first order, calling f for each node
+// and then f(nil) after its children. The children of a node are skipped
+// when f returns false.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(node, inspector(f))
+}
+
+// Children returns the nodes nested directly in node, including those of
+// all branches of a Conditional and all cases of a Switch.
+func Children(node Node) []Node {
+	switch n := node.(type) {
+	case *Root:
+		return n.Children
+	case *Tag:
+		return n.Children
+	case *Block:
+		return n.Children
+	case *For:
+		return n.Children
+	case *Conditional:
+		var children []Node
+		for _, branch := range n.Branches {
+			children = append(children, branch.Children...)
+		}
+		return children
+	case *Switch:
+		var children []Node
+		for _, c := range n.Cases {
+			children = append(children, c.Children...)
+		}
+		if n.Default != nil {
+			children = append(children, n.Default.Children...)
+		}
+		return children
+	}
+	return nil
+}
+
+// Transform rewrites the tree bottom-up: the children of each node are
+// transformed first, then fn is called with the node and its result takes
+// the node's place. Returning the node keeps it (possibly modified in
+// place), returning another node replaces it, and returning nil removes
+// it. fn is not called for the root itself. The tree is modified in place
+// and returned.
+func Transform(root *Root, fn func(Node) Node) *Root {
+	if root == nil {
+		return nil
+	}
+	root.Children = transformNodes(root.Children, fn)
+	return root
+}
+
+// transformNodes transforms each node of a child list.
+func transformNodes(nodes []Node, fn func(Node) Node) []Node {
+	result := nodes[:0]
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *Tag:
+			n.Children = transformNodes(n.Children, fn)
+		case *Block:
+			n.Children = transformNodes(n.Children, fn)
+		case *For:
+			n.Children = transformNodes(n.Children, fn)
+		case *Conditional:
+			for _, branch := range n.Branches {
+				branch.Children = transformNodes(branch.Children, fn)
+			}
+		case *Switch:
+			for _, c := range n.Cases {
+				c.Children = transformNodes(c.Children, fn)
+			}
+			if n.Default != nil {
+				n.Default.Children = transformNodes(n.Default.Children, fn)
+			}
+		}
+		if replaced := fn(node); replaced != nil {
+			result = append(result, replaced)
+		}
+	}
+	return result
+}
@@ -0,0 +1,91 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTree returns: text, if(a){tag}else{text}, for{block{text}}, switch{case{text} default{text}}
+func testTree() *Root {
+	return &Root{Children: []Node{
+		&Text{Content: "Hi "},
+		&Conditional{Branches: []*Branch{
+			{Condition: "a", Children: []Node{&Tag{Name: "prompty.var", Attributes: map[string]string{"name": "x"}, SelfClose: true}}},
+			{Else: true, Children: []Node{&Text{Content: "none"}}},
+		}},
+		&For{Item: "i", In: "items", Children: []Node{
+			&Block{Name: "b", Children: []Node{&Text{Content: "in block"}}},
+		}},
+		&Switch{Expression: "s",
+			Cases:   []*Case{{Value: "1", Children: []Node{&Text{Content: "one"}}}},
+			Default: &Case{Children: []Node{&Text{Content: "other"}}},
+		},
+	}}
+}
+
+type countingVisitor struct {
+	counts map[string]int
+	ends   int
+}
+
+func (v *countingVisitor) Visit(node Node) Visitor {
+	switch n := node.(type) {
+	case nil:
+		v.ends++
+	case *Text:
+		v.counts["text"]++
+	case *Tag:
+		v.counts[n.Name]++
+	case *Block:
+		v.counts["block"]++
+	}
+	return v
+}
+
+func TestWalk(t *testing.T) {
+	v := &countingVisitor{counts: make(map[string]int)}
+	Walk(testTree(), v)
+	assert.Equal(t, map[string]int{"text": 5, "prompty.var": 1, "block": 1}, v.counts)
+	assert.Equal(t, 11, v.ends, "one end call per visited node")
+}
+
+func TestInspect(t *testing.T) {
+	var texts []string
+	Inspect(testTree(), func(n Node) bool {
+		if _, ok := n.(*For); ok {
+			return false // skip loop bodies
+		}
+		if text, ok := n.(*Text); ok {
+			texts = append(texts, text.Content)
+		}
+		return true
+	})
+	assert.Equal(t, []string{"Hi ", "none", "one", "other"}, texts)
+}
+
+func TestTransform(t *testing.T) {
+	root := Transform(testTree(), func(n Node) Node {
+		switch n := n.(type) {
+		case *Text:
+			if n.Content == "none" {
+				return nil
+			}
+			n.Content += "!"
+		case *Tag:
+			return &Text{Position: n.Position, Content: "X"}
+		}
+		return n
+	})
+
+	cond := root.Children[1].(*Conditional)
+	assert.Equal(t, "X", cond.Branches[0].Children[0].(*Text).Content)
+	assert.Empty(t, cond.Branches[1].Children)
+	block := root.Children[2].(*For).Children[0].(*Block)
+	assert.Equal(t, "in block!", block.Children[0].(*Text).Content)
+	sw := root.Children[3].(*Switch)
+	assert.Equal(t, "one!", sw.Cases[0].Children[0].(*Text).Content)
+	assert.Equal(t, "other!", sw.Default.Children[0].(*Text).Content)
+
+	assert.Nil(t, Transform(nil, func(n Node) Node { return n }))
+}
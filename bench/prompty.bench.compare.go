@@ -0,0 +1,89 @@
+package bench
+
+// Budget is the maximum allowed relative increase of each metric over the
+// baseline, e.g. 0.10 for 10%. Allocation counts are deterministic and
+// usually warrant a tighter budget than timings.
+type Budget struct {
+	NsPerOp     float64 `json:"ns_per_op"`
+	AllocsPerOp float64 `json:"allocs_per_op"`
+}
+
+// DefaultBudget returns the default regression budget.
+func DefaultBudget() Budget {
+	return Budget{
+		NsPerOp:     DefaultNsBudget,
+		AllocsPerOp: DefaultAllocsBudget,
+	}
+}
+
+// Delta compares one workload between a baseline and a current run.
+// Changes are relative: +0.25 means 25% slower (or more allocations).
+type Delta struct {
+	Workload        string  `json:"workload"`
+	Baseline        Result  `json:"baseline"`
+	Current         Result  `json:"current"`
+	NsChange        float64 `json:"ns_change"`
+	AllocsChange    float64 `json:"allocs_change"`
+	NsRegressed     bool    `json:"ns_regressed"`
+	AllocsRegressed bool    `json:"allocs_regressed"`
+}
+
+// Regressed reports whether the workload exceeded its budget.
+func (d Delta) Regressed() bool {
+	return d.NsRegressed || d.AllocsRegressed
+}
+
+// Comparison is the result of Compare.
+type Comparison struct {
+	Budget Budget  `json:"budget"`
+	Deltas []Delta `json:"deltas"`
+	// Missing lists current workloads without a baseline result
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Regressed reports whether any workload exceeded the budget.
+func (c *Comparison) Regressed() bool {
+	for _, d := range c.Deltas {
+		if d.Regressed() {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare compares each workload of current with the same workload in
+// baseline. Workloads missing from the baseline are listed, not compared.
+func Compare(baseline, current *Report, budget Budget) *Comparison {
+	comparison := &Comparison{Budget: budget, Deltas: make([]Delta, 0, len(current.Results))}
+	for _, cur := range current.Results {
+		base, ok := baseline.Result(cur.Workload)
+		if !ok {
+			comparison.Missing = append(comparison.Missing, cur.Workload)
+			continue
+		}
+
+		d := Delta{
+			Workload:     cur.Workload,
+			Baseline:     base,
+			Current:      cur,
+			NsChange:     relativeChange(base.NsPerOp, cur.NsPerOp),
+			AllocsChange: relativeChange(float64(base.AllocsPerOp), float64(cur.AllocsPerOp)),
+		}
+		d.NsRegressed = d.NsChange > budget.NsPerOp
+		d.AllocsRegressed = d.AllocsChange > budget.AllocsPerOp
+		comparison.Deltas = append(comparison.Deltas, d)
+	}
+	return comparison
+}
+
+// relativeChange returns (current-baseline)/baseline. Growth from a zero
+// baseline counts as a 100% increase.
+func relativeChange(baseline, current float64) float64 {
+	if baseline == 0 {
+		if current == 0 {
+			return 0
+		}
+		return 1
+	}
+	return (current - baseline) / baseline
+}
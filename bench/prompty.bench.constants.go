@@ -0,0 +1,59 @@
+package bench
+
+import "time"
+
+// Standard workload names
+const (
+	WorkloadSmallVars    = "small-vars"
+	WorkloadLoopHeavy    = "loop-heavy"
+	WorkloadDeepInclude  = "deep-include"
+	WorkloadAgentCompile = "agent-compile"
+	WorkloadLargeParse   = "large-parse"
+)
+
+// Workload sizes
+const (
+	SmallVarsCount   = 20  // Variables rendered by small-vars
+	LoopHeavyItems   = 500 // Items iterated by loop-heavy
+	DeepIncludeDepth = 10  // Include nesting depth of deep-include
+	// LargeParseBytes is the approximate template size parsed by large-parse
+	LargeParseBytes = 2 << 20
+)
+
+// Measurement defaults
+const (
+	DefaultBenchTime = time.Second
+	MaxIterations    = 1_000_000_000
+	// iterationHeadroom over-predicts the next round so it reaches BenchTime
+	iterationHeadroom = 1.2
+	// maxGrowthFactor caps how fast the iteration count grows between rounds
+	maxGrowthFactor = 100
+)
+
+// Default regression budgets (maximum relative increase over the baseline)
+const (
+	DefaultNsBudget     = 0.15
+	DefaultAllocsBudget = 0.10
+)
+
+// Workload descriptions, formatted with the workload size
+const (
+	descSmallVars    = "Execute a parsed template with %d variables"
+	descLoopHeavy    = "Execute a loop with a conditional over %d items"
+	descDeepInclude  = "Execute a chain of %d nested includes"
+	descAgentCompile = "Parse and compile an agent with a skill catalog to messages"
+	descLargeParse   = "Parse a generated context template of %d MB"
+)
+
+// Error codes and metadata keys
+const (
+	ErrCodeBench    = "PROMPTY_BENCH"
+	MetaKeyWorkload = "workload"
+)
+
+// Error messages
+const (
+	ErrMsgUnknownWorkload = "unknown workload"
+	ErrMsgWorkloadSetup   = "workload setup failed"
+	ErrMsgWorkloadFailed  = "workload failed"
+)
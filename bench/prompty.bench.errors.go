@@ -0,0 +1,26 @@
+package bench
+
+import (
+	"errors"
+
+	"github.com/itsatony/go-cuserr"
+)
+
+// ErrUnknownWorkload is matched with errors.Is by the error Run returns for
+// a workload name not in Workloads()
+var ErrUnknownWorkload = errors.New(ErrMsgUnknownWorkload)
+
+// NewUnknownWorkloadError creates an error for a workload name not in Workloads().
+func NewUnknownWorkloadError(name string) error {
+	err := cuserr.NewValidationError(ErrCodeBench, ErrMsgUnknownWorkload).
+		WithMetadata(MetaKeyWorkload, name)
+	err.Sentinel = ErrUnknownWorkload
+	return err
+}
+
+// NewWorkloadError creates an error for a workload whose setup or operation
+// failed.
+func NewWorkloadError(msg, name string, cause error) error {
+	return cuserr.WrapStdError(cause, ErrCodeBench, msg).
+		WithMetadata(MetaKeyWorkload, name)
+}
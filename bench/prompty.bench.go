@@ -0,0 +1,163 @@
+// Package bench runs standardized prompty workloads against the current
+// engine and compares the results with a baseline run, so performance
+// regressions between versions can fail CI.
+//
+//	report, err := bench.Run(ctx, bench.WithBenchTime(2*time.Second))
+//	comparison := bench.Compare(baseline, report, bench.DefaultBudget())
+//	if comparison.Regressed() { ... }
+//
+// Reports marshal to JSON, so a baseline can be stored and reloaded.
+package bench
+
+import (
+	"context"
+	"runtime"
+	"time"
+)
+
+// Report is the result of a benchmark run.
+type Report struct {
+	Version   string        `json:"version,omitempty"` // prompty version, set by the caller
+	GoVersion string        `json:"go_version"`
+	GOOS      string        `json:"goos"`
+	GOARCH    string        `json:"goarch"`
+	BenchTime time.Duration `json:"bench_time_ns"`
+	Results   []Result      `json:"results"`
+}
+
+// Result holds the measurements of one workload.
+type Result struct {
+	Workload    string  `json:"workload"`
+	Iterations  int     `json:"iterations"`
+	NsPerOp     float64 `json:"ns_per_op"`
+	BytesPerOp  uint64  `json:"bytes_per_op"`
+	AllocsPerOp uint64  `json:"allocs_per_op"`
+}
+
+// Result returns the result of the named workload.
+func (r *Report) Result(workload string) (Result, bool) {
+	for _, res := range r.Results {
+		if res.Workload == workload {
+			return res, true
+		}
+	}
+	return Result{}, false
+}
+
+// config holds Run settings
+type config struct {
+	benchTime time.Duration
+	workloads []string
+}
+
+// Option configures Run.
+type Option func(*config)
+
+// WithBenchTime sets the minimum measured time per workload.
+// Default: DefaultBenchTime
+func WithBenchTime(d time.Duration) Option {
+	return func(c *config) {
+		c.benchTime = d
+	}
+}
+
+// WithWorkloads restricts the run to the named standard workloads.
+// Default: all of Workloads()
+func WithWorkloads(names ...string) Option {
+	return func(c *config) {
+		c.workloads = names
+	}
+}
+
+// Run measures the selected workloads in order. Each workload runs once
+// unmeasured to validate it, then in rounds of growing iteration counts
+// until a round takes at least the bench time.
+func Run(ctx context.Context, opts ...Option) (*Report, error) {
+	cfg := &config{benchTime: DefaultBenchTime}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	workloads, err := lookupWorkloads(cfg.workloads)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		GoVersion: runtime.Version(),
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+		BenchTime: cfg.benchTime,
+		Results:   make([]Result, 0, len(workloads)),
+	}
+	for _, w := range workloads {
+		result, err := RunWorkload(ctx, w, cfg.benchTime)
+		if err != nil {
+			return nil, err
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+// RunWorkload measures a single workload, which need not be a standard one.
+func RunWorkload(ctx context.Context, w Workload, benchTime time.Duration) (Result, error) {
+	op, err := w.Setup()
+	if err != nil {
+		return Result{}, NewWorkloadError(ErrMsgWorkloadSetup, w.Name, err)
+	}
+	if err := op(ctx); err != nil {
+		return Result{}, NewWorkloadError(ErrMsgWorkloadFailed, w.Name, err)
+	}
+
+	n := 1
+	for {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+
+		elapsed, mallocs, bytes, err := measure(ctx, op, n)
+		if err != nil {
+			return Result{}, NewWorkloadError(ErrMsgWorkloadFailed, w.Name, err)
+		}
+		if elapsed >= benchTime || n >= MaxIterations {
+			return Result{
+				Workload:    w.Name,
+				Iterations:  n,
+				NsPerOp:     float64(elapsed.Nanoseconds()) / float64(n),
+				BytesPerOp:  bytes / uint64(n),
+				AllocsPerOp: mallocs / uint64(n),
+			}, nil
+		}
+		n = predictIterations(n, elapsed, benchTime)
+	}
+}
+
+// measure runs op n times and returns the elapsed time and heap allocations.
+func measure(ctx context.Context, op Op, n int) (time.Duration, uint64, uint64, error) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		if err := op(ctx); err != nil {
+			return 0, 0, 0, err
+		}
+	}
+	elapsed := time.Since(start)
+
+	runtime.ReadMemStats(&after)
+	return elapsed, after.Mallocs - before.Mallocs, after.TotalAlloc - before.TotalAlloc, nil
+}
+
+// predictIterations estimates the iteration count for the next round from
+// the last one, growing by at least one and at most maxGrowthFactor.
+func predictIterations(n int, elapsed, benchTime time.Duration) int {
+	next := n * maxGrowthFactor
+	if elapsed > 0 {
+		next = int(float64(n) * float64(benchTime) / float64(elapsed) * iterationHeadroom)
+	}
+	next = min(next, n*maxGrowthFactor, MaxIterations)
+	return max(next, n+1)
+}
@@ -0,0 +1,213 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// Op is a single benchmarked operation.
+type Op func(ctx context.Context) error
+
+// Workload is a named, self-contained benchmark. Setup prepares the engine
+// and inputs outside the measured region and returns the operation to time.
+type Workload struct {
+	Name        string
+	Description string
+	Setup       func() (Op, error)
+}
+
+// Workloads returns the standard workloads in run order.
+func Workloads() []Workload {
+	return []Workload{
+		{
+			Name:        WorkloadSmallVars,
+			Description: fmt.Sprintf(descSmallVars, SmallVarsCount),
+			Setup:       setupSmallVars,
+		},
+		{
+			Name:        WorkloadLoopHeavy,
+			Description: fmt.Sprintf(descLoopHeavy, LoopHeavyItems),
+			Setup:       setupLoopHeavy,
+		},
+		{
+			Name:        WorkloadDeepInclude,
+			Description: fmt.Sprintf(descDeepInclude, DeepIncludeDepth),
+			Setup:       setupDeepInclude,
+		},
+		{
+			Name:        WorkloadAgentCompile,
+			Description: descAgentCompile,
+			Setup:       setupAgentCompile,
+		},
+		{
+			Name:        WorkloadLargeParse,
+			Description: fmt.Sprintf(descLargeParse, LargeParseBytes>>20),
+			Setup:       setupLargeParse,
+		},
+	}
+}
+
+// lookupWorkloads returns the standard workloads with the given names, or
+// all of them when names is empty.
+func lookupWorkloads(names []string) ([]Workload, error) {
+	all := Workloads()
+	if len(names) == 0 {
+		return all, nil
+	}
+
+	selected := make([]Workload, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, w := range all {
+			if w.Name == name {
+				selected = append(selected, w)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, NewUnknownWorkloadError(name)
+		}
+	}
+	return selected, nil
+}
+
+// Line formats of the small-vars template and its data keys
+const (
+	smallVarsKeyFormat  = "field%d"
+	smallVarsLineFormat = "%s: {~prompty.var name=\"%s.value\" /~}\n"
+)
+
+func setupSmallVars() (Op, error) {
+	var source strings.Builder
+	data := make(map[string]any, SmallVarsCount)
+	for i := 0; i < SmallVarsCount; i++ {
+		key := fmt.Sprintf(smallVarsKeyFormat, i)
+		data[key] = map[string]any{"value": i}
+		fmt.Fprintf(&source, smallVarsLineFormat, key, key)
+	}
+
+	tmpl, err := prompty.MustNew().Parse(source.String())
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) error {
+		_, err := tmpl.Execute(ctx, data)
+		return err
+	}, nil
+}
+
+// loopHeavySource is the template executed by loop-heavy
+const loopHeavySource = `{~prompty.for item="item" index="i" in="items"~}` +
+	`{~prompty.var name="i" /~}. {~prompty.var name="item.name" /~}` +
+	`{~prompty.if eval="item.score > 5"~} (high){~/prompty.if~}` + "\n" +
+	`{~/prompty.for~}`
+
+// loopHeavyItemFormat names the items iterated by loop-heavy
+const loopHeavyItemFormat = "item-%d"
+
+func setupLoopHeavy() (Op, error) {
+	items := make([]any, LoopHeavyItems)
+	for i := range items {
+		items[i] = map[string]any{"name": fmt.Sprintf(loopHeavyItemFormat, i), "score": i % 10}
+	}
+	data := map[string]any{"items": items}
+
+	tmpl, err := prompty.MustNew().Parse(loopHeavySource)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) error {
+		_, err := tmpl.Execute(ctx, data)
+		return err
+	}, nil
+}
+
+// Templates of the deep-include chain, formatted with their depth
+const (
+	deepIncludeNameFormat   = "level-%d"
+	deepIncludeBodyFormat   = `level %d: {~prompty.var name="label" /~}`
+	deepIncludeNestedFormat = "\n" + `{~prompty.include template="level-%d" label="nested" /~}`
+	deepIncludeEntrySource  = `{~prompty.include template="level-1" label="root" /~}`
+)
+
+func setupDeepInclude() (Op, error) {
+	engine := prompty.MustNew()
+	for depth := DeepIncludeDepth; depth > 0; depth-- {
+		body := fmt.Sprintf(deepIncludeBodyFormat, depth)
+		if depth < DeepIncludeDepth {
+			body += fmt.Sprintf(deepIncludeNestedFormat, depth+1)
+		}
+		if err := engine.RegisterTemplate(fmt.Sprintf(deepIncludeNameFormat, depth), body); err != nil {
+			return nil, err
+		}
+	}
+
+	tmpl, err := engine.Parse(deepIncludeEntrySource)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) error {
+		_, err := tmpl.Execute(ctx, nil)
+		return err
+	}, nil
+}
+
+// agentSource is the agent compiled by the agent-compile workload
+const agentSource = `---
+name: bench-agent
+description: Agent used by the agent-compile benchmark
+type: agent
+execution:
+  provider: openai
+  model: gpt-4o
+  temperature: 0.2
+skills:
+  - slug: summarize
+    injection: none
+  - slug: translate
+    injection: none
+messages:
+  - role: system
+    content: |
+      You are {~prompty.var name="input.persona" default="an assistant" /~}.
+      {~prompty.skills_catalog /~}
+  - role: user
+    content: '{~prompty.var name="input.query" /~}'
+---
+Agent body.
+`
+
+func setupAgentCompile() (Op, error) {
+	executor := prompty.NewAgentExecutor()
+	data := map[string]any{"persona": "a careful analyst", "query": "Summarize the report."}
+	return func(ctx context.Context) error {
+		_, err := executor.Execute(ctx, agentSource, data)
+		return err
+	}, nil
+}
+
+// largeParseRecord is one record of the context block parsed by large-parse
+const largeParseRecord = `Record {~prompty.var name="id" /~}: the quick brown fox jumps over the lazy dog.
+Observed at {~prompty.var name="time" default="unknown" /~} with no anomalies reported.
+`
+
+func setupLargeParse() (Op, error) {
+	var source strings.Builder
+	source.Grow(LargeParseBytes + len(largeParseRecord))
+	source.WriteString("<context>\n")
+	for source.Len() < LargeParseBytes {
+		source.WriteString(largeParseRecord)
+	}
+	source.WriteString("</context>\n")
+	body := source.String()
+
+	engine := prompty.MustNew()
+	return func(ctx context.Context) error {
+		_, err := engine.Parse(body)
+		return err
+	}, nil
+}
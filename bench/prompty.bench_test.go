@@ -0,0 +1,161 @@
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/itsatony/go-prompty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_AllWorkloads(t *testing.T) {
+	report, err := Run(context.Background(), WithBenchTime(time.Millisecond))
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, report.GoVersion)
+	assert.Equal(t, time.Millisecond, report.BenchTime)
+	require.Len(t, report.Results, len(Workloads()))
+	for i, w := range Workloads() {
+		res := report.Results[i]
+		assert.Equal(t, w.Name, res.Workload)
+		assert.Positive(t, res.Iterations, w.Name)
+		assert.Positive(t, res.NsPerOp, w.Name)
+		assert.Positive(t, res.AllocsPerOp, w.Name)
+		assert.Positive(t, res.BytesPerOp, w.Name)
+	}
+}
+
+func TestRun_SelectedWorkloads(t *testing.T) {
+	report, err := Run(context.Background(), WithBenchTime(time.Millisecond), WithWorkloads(WorkloadLoopHeavy, WorkloadSmallVars))
+	require.NoError(t, err)
+
+	require.Len(t, report.Results, 2)
+	assert.Equal(t, WorkloadLoopHeavy, report.Results[0].Workload)
+	assert.Equal(t, WorkloadSmallVars, report.Results[1].Workload)
+
+	_, ok := report.Result(WorkloadSmallVars)
+	assert.True(t, ok)
+	_, ok = report.Result(WorkloadDeepInclude)
+	assert.False(t, ok)
+}
+
+func TestRun_UnknownWorkload(t *testing.T) {
+	_, err := Run(context.Background(), WithWorkloads("nope"))
+	require.ErrorIs(t, err, ErrUnknownWorkload)
+	assert.Equal(t, "nope", prompty.ErrorDetailsOf(err).Metadata[MetaKeyWorkload])
+}
+
+func TestRunWorkload_Errors(t *testing.T) {
+	boom := errors.New("boom")
+
+	t.Run("setup", func(t *testing.T) {
+		_, err := RunWorkload(context.Background(), Workload{
+			Name:  "broken",
+			Setup: func() (Op, error) { return nil, boom },
+		}, time.Millisecond)
+		require.ErrorIs(t, err, boom)
+		assert.Contains(t, err.Error(), ErrMsgWorkloadSetup)
+		details := prompty.ErrorDetailsOf(err)
+		assert.Equal(t, ErrCodeBench, details.Code)
+		assert.Equal(t, "broken", details.Metadata[MetaKeyWorkload])
+	})
+
+	t.Run("operation", func(t *testing.T) {
+		_, err := RunWorkload(context.Background(), Workload{
+			Name:  "failing",
+			Setup: func() (Op, error) { return func(context.Context) error { return boom }, nil },
+		}, time.Millisecond)
+		require.ErrorIs(t, err, boom)
+		assert.Contains(t, err.Error(), ErrMsgWorkloadFailed)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := RunWorkload(ctx, Workload{
+			Name:  "noop",
+			Setup: func() (Op, error) { return func(context.Context) error { return nil }, nil },
+		}, time.Hour)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestPredictIterations(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        int
+		elapsed  time.Duration
+		expected int
+	}{
+		{name: "scales to bench time with headroom", n: 100, elapsed: 100 * time.Millisecond, expected: 1200},
+		{name: "growth capped", n: 10, elapsed: time.Nanosecond, expected: 1000},
+		{name: "zero elapsed", n: 5, elapsed: 0, expected: 500},
+		{name: "grows by at least one", n: 10, elapsed: 2 * time.Second, expected: 11},
+		{name: "capped at max iterations", n: MaxIterations / 2, elapsed: time.Nanosecond, expected: MaxIterations},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, predictIterations(tt.n, tt.elapsed, time.Second))
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := &Report{Results: []Result{
+		{Workload: "a", NsPerOp: 1000, AllocsPerOp: 10},
+		{Workload: "b", NsPerOp: 1000, AllocsPerOp: 10},
+		{Workload: "c", NsPerOp: 1000, AllocsPerOp: 0},
+	}}
+	current := &Report{Results: []Result{
+		{Workload: "a", NsPerOp: 1100, AllocsPerOp: 10}, // within budget
+		{Workload: "b", NsPerOp: 900, AllocsPerOp: 12},  // faster but more allocations
+		{Workload: "c", NsPerOp: 1300, AllocsPerOp: 1},  // slower, allocates from zero
+		{Workload: "new", NsPerOp: 1},
+	}}
+
+	comparison := Compare(baseline, current, DefaultBudget())
+
+	require.Len(t, comparison.Deltas, 3)
+	a, b, c := comparison.Deltas[0], comparison.Deltas[1], comparison.Deltas[2]
+
+	assert.InDelta(t, 0.10, a.NsChange, 1e-9)
+	assert.False(t, a.Regressed())
+
+	assert.InDelta(t, -0.10, b.NsChange, 1e-9)
+	assert.InDelta(t, 0.20, b.AllocsChange, 1e-9)
+	assert.False(t, b.NsRegressed)
+	assert.True(t, b.AllocsRegressed)
+
+	assert.True(t, c.NsRegressed)
+	assert.InDelta(t, 1.0, c.AllocsChange, 1e-9)
+	assert.Equal(t, float64(1000), c.Baseline.NsPerOp)
+	assert.Equal(t, float64(1300), c.Current.NsPerOp)
+
+	assert.Equal(t, []string{"new"}, comparison.Missing)
+	assert.True(t, comparison.Regressed())
+
+	loose := Compare(baseline, current, Budget{NsPerOp: 0.5, AllocsPerOp: 2})
+	assert.False(t, loose.Regressed())
+}
+
+func TestReport_JSONRoundTrip(t *testing.T) {
+	report := &Report{
+		Version:   "v2.9.0",
+		GoVersion: "go1.24",
+		BenchTime: time.Second,
+		Results:   []Result{{Workload: WorkloadSmallVars, Iterations: 10, NsPerOp: 12.5, BytesPerOp: 64, AllocsPerOp: 2}},
+	}
+
+	raw, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"ns_per_op":12.5`)
+
+	var decoded Report
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.Equal(t, *report, decoded)
+}
@@ -26,8 +26,40 @@ func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 		return runValidate(cmdArgs, stdin, stdout, stderr)
 	case CmdNameLint:
 		return runLint(cmdArgs, stdin, stdout, stderr)
+	case CmdNameFmt:
+		return runFmt(cmdArgs, stdin, stdout, stderr)
+	case CmdNameConvert:
+		return runConvert(cmdArgs, stdin, stdout, stderr)
 	case CmdNameDebug:
 		return runDebug(cmdArgs, stdin, stdout, stderr)
+	case CmdNameDryRun:
+		return runDryRun(cmdArgs, stdin, stdout, stderr)
+	case CmdNameExplain:
+		return runExplain(cmdArgs, stdin, stdout, stderr)
+	case CmdNameRepl:
+		return runRepl(cmdArgs, stdin, stdout, stderr)
+	case CmdNameLSP:
+		return runLSP(cmdArgs, stdin, stdout, stderr)
+	case CmdNameCompile:
+		return runCompile(cmdArgs, stdin, stdout, stderr)
+	case CmdNameRun:
+		return runRun(cmdArgs, stdin, stdout, stderr)
+	case CmdNameBench:
+		return runBench(cmdArgs, stdin, stdout, stderr)
+	case CmdNameTest:
+		return runTest(cmdArgs, stdin, stdout, stderr)
+	case CmdNameEval:
+		return runEval(cmdArgs, stdin, stdout, stderr)
+	case CmdNameCompare:
+		return runCompare(cmdArgs, stdin, stdout, stderr)
+	case CmdNameReplay:
+		return runReplay(cmdArgs, stdin, stdout, stderr)
+	case CmdNameStore:
+		return runStore(cmdArgs, stdin, stdout, stderr)
+	case CmdNameSkill:
+		return runSkill(cmdArgs, stdin, stdout, stderr)
+	case CmdNameAgent:
+		return runAgent(cmdArgs, stdin, stdout, stderr)
 	case CmdNameVersion:
 		return runVersion(cmdArgs, stdout, stderr)
 	case CmdNameHelp:
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// agentConfig holds parsed agent command configuration
+type agentConfig struct {
+	path         string
+	data         string
+	dataFilePath string
+	provider     string
+	driver       string
+	dsn          string
+	format       string
+	color        string
+}
+
+// agentInspectOutput represents JSON output for agent inspect
+type agentInspectOutput struct {
+	Name          string                     `json:"name"`
+	Description   string                     `json:"description"`
+	Skills        []agentSkill               `json:"skills"`
+	Tools         *prompty.ToolsConfig       `json:"tools,omitempty"`
+	Constraints   *prompty.ConstraintsConfig `json:"constraints,omitempty"`
+	Compatibility agentCompatibility         `json:"compatibility"`
+	Compilation   agentCompilation           `json:"compilation"`
+}
+
+type agentSkill struct {
+	Slug      string `json:"slug"`
+	Version   string `json:"version,omitempty"`
+	Inline    bool   `json:"inline,omitempty"`
+	Injection string `json:"injection,omitempty"`
+}
+
+type agentCompatibility struct {
+	Declared string                    `json:"declared,omitempty"`
+	Provider string                    `json:"provider,omitempty"`
+	Model    string                    `json:"model,omitempty"`
+	Issues   []agentCompatibilityIssue `json:"issues"`
+}
+
+type agentCompatibilityIssue struct {
+	Field    string `json:"field"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+type agentCompilation struct {
+	OK       bool                     `json:"ok"`
+	Messages int                      `json:"messages"`
+	Error    string                   `json:"error,omitempty"`
+	Warnings []prompty.CompileWarning `json:"warnings,omitempty"`
+}
+
+func runAgent(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, ErrMsgNoAgentSubcommand)
+		fmt.Fprintln(stderr, HelpAgentUsage)
+		return ExitCodeUsageError
+	}
+	if args[0] != AgentCmdInspect {
+		fmt.Fprintf(stderr, FmtErrorWithDetail, ErrMsgUnknownAgentSubcommand, args[0])
+		fmt.Fprintln(stderr, HelpAgentUsage)
+		return ExitCodeUsageError
+	}
+	return runAgentInspect(args[1:], stdin, stdout, stderr)
+}
+
+func parseAgentFlags(args []string) (*agentConfig, error) {
+	fs := flag.NewFlagSet(CmdNameAgent+" "+AgentCmdInspect, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	cfg := &agentConfig{}
+	var jsonOutput bool
+
+	fs.StringVar(&cfg.path, FlagTemplate, "", "")
+	fs.StringVar(&cfg.path, FlagTemplateShort, "", "")
+	fs.StringVar(&cfg.data, FlagData, "", "")
+	fs.StringVar(&cfg.data, FlagDataShort, "", "")
+	fs.StringVar(&cfg.dataFilePath, FlagDataFile, "", "")
+	fs.StringVar(&cfg.dataFilePath, FlagDataFileShort, "", "")
+	fs.StringVar(&cfg.provider, FlagProvider, "", "")
+	fs.StringVar(&cfg.provider, FlagProviderShort, "", "")
+	fs.StringVar(&cfg.driver, FlagDriver, envOrDefault(EnvStoreDriver, prompty.StorageDriverNameFilesystem), "")
+	fs.StringVar(&cfg.dsn, FlagDSN, os.Getenv(EnvStoreDSN), "")
+	fs.StringVar(&cfg.format, FlagFormat, FlagDefaultFormat, "")
+	fs.StringVar(&cfg.format, FlagFormatShort, FlagDefaultFormat, "")
+	fs.BoolVar(&jsonOutput, FlagJSON, false, "")
+	fs.StringVar(&cfg.color, FlagColor, ColorModeAuto, "")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.path == "" && len(positional) == 1 {
+		cfg.path = positional[0]
+	}
+	if cfg.path == "" {
+		return nil, errors.New(ErrMsgMissingAgent)
+	}
+
+	if jsonOutput {
+		cfg.format = OutputFormatJSON
+	}
+	if cfg.format != OutputFormatText && cfg.format != OutputFormatJSON {
+		return nil, errors.New(ErrMsgInvalidFormat)
+	}
+
+	return cfg, nil
+}
+
+// runAgentInspect reports an agent's skills, tools, constraints and
+// provider compatibility, and compiles it to check that it works.
+func runAgentInspect(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parseAgentFlags(args)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidAgentArgs, err)
+		return ExitCodeUsageError
+	}
+	colors, err := newColorizer(cfg.color, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidAgentArgs, err)
+		return ExitCodeUsageError
+	}
+
+	result, code := importSkillDocument(cfg.path, stdin, stderr)
+	if code != ExitCodeSuccess {
+		return code
+	}
+	agent := result.Prompt
+	if !agent.IsAgent() {
+		fmt.Fprintf(stderr, FmtFileErrorFormat+FmtNewline, cfg.path, ErrMsgNotAnAgent, agent.EffectiveType())
+		return ExitCodeValidationError
+	}
+
+	data := agent.Sample
+	if cfg.data != "" || cfg.dataFilePath != "" {
+		data, err = loadDataArg(cfg.data, cfg.dataFilePath)
+		if err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidJSON, err)
+			return ExitCodeInputError
+		}
+	}
+
+	opts := &prompty.CompileOptions{}
+	if cfg.dsn != "" {
+		storage, err := prompty.OpenStorage(cfg.driver, cfg.dsn)
+		if err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgOpenStorageFailed, err)
+			return ExitCodeError
+		}
+		defer storage.Close()
+		opts.Resolver = prompty.NewStorageDocumentResolver(storage)
+	}
+
+	output := buildAgentInspectOutput(context.Background(), agent, data, opts, cfg.provider)
+	if cfg.format == OutputFormatJSON {
+		jsonBytes, _ := json.MarshalIndent(output, "", "  ")
+		fmt.Fprintln(stdout, string(jsonBytes))
+	} else {
+		outputAgentInspectText(output, colors, stdout)
+	}
+
+	if !output.Compilation.OK || output.Compatibility.hasErrors() {
+		return ExitCodeValidationError
+	}
+	return ExitCodeSuccess
+}
+
+func buildAgentInspectOutput(ctx context.Context, agent *prompty.Prompt, data map[string]any, opts *prompty.CompileOptions, provider string) *agentInspectOutput {
+	output := &agentInspectOutput{
+		Name:        agent.Name,
+		Description: agent.Description,
+		Skills:      make([]agentSkill, 0, len(agent.Skills)),
+		Tools:       agent.Tools,
+		Constraints: agent.Constraints,
+	}
+	for _, ref := range agent.Skills {
+		skill := agentSkill{
+			Slug:      ref.GetSlug(),
+			Inline:    ref.IsInline(),
+			Injection: string(ref.Injection),
+		}
+		if !skill.Inline {
+			skill.Version = ref.GetVersion()
+		}
+		output.Skills = append(output.Skills, skill)
+	}
+
+	// Compatibility is checked on the compiled (effective) execution config
+	execution := agent.Execution
+	compiled, err := agent.CompileAgent(ctx, data, opts)
+	if err != nil {
+		output.Compilation.Error = err.Error()
+	} else {
+		output.Compilation = agentCompilation{
+			OK:       true,
+			Messages: len(compiled.Messages),
+			Warnings: compiled.Warnings,
+		}
+		execution = compiled.Execution
+	}
+
+	output.Compatibility = agentCompatibility{
+		Declared: agent.Compatibility,
+		Provider: provider,
+		Issues:   make([]agentCompatibilityIssue, 0),
+	}
+	if execution != nil {
+		output.Compatibility.Model = execution.Model
+		if output.Compatibility.Provider == "" {
+			output.Compatibility.Provider = execution.GetEffectiveProvider()
+		}
+	}
+	if output.Compatibility.Provider != "" {
+		for _, issue := range prompty.CheckCompatibility(execution, output.Compatibility.Provider) {
+			output.Compatibility.Issues = append(output.Compatibility.Issues, agentCompatibilityIssue{
+				Field:    issue.Field,
+				Severity: issue.Severity.String(),
+				Message:  issue.Message,
+			})
+		}
+	}
+	return output
+}
+
+// hasErrors reports whether a setting the provider drops changes the output.
+func (c agentCompatibility) hasErrors() bool {
+	for _, issue := range c.Issues {
+		if issue.Severity == prompty.SeverityNameError {
+			return true
+		}
+	}
+	return false
+}
+
+func outputAgentInspectText(output *agentInspectOutput, c *colorizer, stdout io.Writer) {
+	fmt.Fprintf(stdout, AgentTextHeader+FmtNewline, c.bold(output.Name))
+	if output.Description != "" {
+		fmt.Fprintf(stdout, AgentTextDescription+FmtNewline, c.dim(output.Description))
+	}
+
+	writeSectionHeader(stdout, c, AgentTextSkills, len(output.Skills))
+	for _, skill := range output.Skills {
+		if skill.Inline {
+			fmt.Fprintf(stdout, AgentTextSkillInline, c.cyan(skill.Slug))
+		} else {
+			fmt.Fprintf(stdout, AgentTextSkillRef, c.cyan(skill.Slug), skill.Version)
+		}
+		if skill.Injection != "" {
+			fmt.Fprintf(stdout, AgentTextInjection, skill.Injection)
+		}
+		fmt.Fprintln(stdout)
+	}
+
+	writeAgentTools(output.Tools, c, stdout)
+	writeAgentConstraints(output.Constraints, c, stdout)
+
+	fmt.Fprintln(stdout)
+	fmt.Fprintln(stdout, c.bold(AgentTextCompatibility))
+	if output.Compatibility.Declared != "" {
+		fmt.Fprintf(stdout, AgentTextDeclared+FmtNewline, output.Compatibility.Declared)
+	}
+	switch {
+	case output.Compatibility.Provider == "":
+		fmt.Fprintln(stdout, c.dim(AgentTextNoProvider))
+	case len(output.Compatibility.Issues) == 0:
+		fmt.Fprintf(stdout, AgentTextProvider+FmtNewline, c.green(MarkOK), output.Compatibility.Provider)
+		fmt.Fprintln(stdout, AgentTextCompatible)
+	default:
+		fmt.Fprintf(stdout, AgentTextProvider+FmtNewline, c.yellow(MarkWarn), output.Compatibility.Provider)
+		for _, issue := range output.Compatibility.Issues {
+			mark := c.yellow(MarkWarn)
+			if issue.Severity == prompty.SeverityNameError {
+				mark = c.red(MarkFail)
+			}
+			fmt.Fprintf(stdout, AgentTextWarning+FmtNewline, ExplainTextIndent+mark, issue.Message)
+		}
+	}
+
+	fmt.Fprintln(stdout)
+	fmt.Fprintln(stdout, c.bold(AgentTextCompilation))
+	if !output.Compilation.OK {
+		fmt.Fprintf(stdout, AgentTextCompileFailed+FmtNewline, c.red(MarkFail), output.Compilation.Error)
+		return
+	}
+	fmt.Fprintf(stdout, AgentTextCompiled+FmtNewline, c.green(MarkOK), output.Compilation.Messages)
+	for _, warning := range output.Compilation.Warnings {
+		fmt.Fprintf(stdout, AgentTextWarning+FmtNewline, c.yellow(MarkWarn), warning.Message)
+	}
+}
+
+func writeAgentTools(tools *prompty.ToolsConfig, c *colorizer, stdout io.Writer) {
+	count := 0
+	if tools != nil {
+		count = len(tools.Functions) + len(tools.MCPServers)
+	}
+	writeSectionHeader(stdout, c, AgentTextTools, count)
+	if tools == nil {
+		return
+	}
+	for _, fn := range tools.Functions {
+		fmt.Fprintf(stdout, AgentTextFunction, c.cyan(fn.Name))
+		if fn.Description != "" {
+			fmt.Fprint(stdout, ExplainTextDetailPrefix+c.dim(fn.Description))
+		}
+		fmt.Fprintln(stdout)
+	}
+	for _, server := range tools.MCPServers {
+		fmt.Fprintf(stdout, AgentTextMCPServer, c.cyan(server.Name), server.URL)
+		if len(server.Tools) > 0 {
+			fmt.Fprintf(stdout, AgentTextMCPTools, strings.Join(server.Tools, AgentTextListSep))
+		}
+		fmt.Fprintln(stdout)
+	}
+	if tools.ToolChoice != "" {
+		fmt.Fprintf(stdout, AgentTextToolChoice+FmtNewline, tools.ToolChoice)
+	}
+}
+
+func writeAgentConstraints(constraints *prompty.ConstraintsConfig, c *colorizer, stdout io.Writer) {
+	fmt.Fprintln(stdout)
+	fmt.Fprintln(stdout, c.bold(AgentTextConstraints))
+	if constraints == nil {
+		fmt.Fprintln(stdout, c.dim(AgentTextNone))
+		return
+	}
+	if len(constraints.Behavioral) > 0 {
+		fmt.Fprintf(stdout, AgentTextBehavioral+FmtNewline, strings.Join(constraints.Behavioral, AgentTextListSep))
+	}
+	if len(constraints.Safety) > 0 {
+		fmt.Fprintf(stdout, AgentTextSafety+FmtNewline, strings.Join(constraints.Safety, AgentTextListSep))
+	}
+	if op := constraints.Operational; op != nil {
+		if op.MaxTurns != nil {
+			fmt.Fprintf(stdout, AgentTextMaxTurns+FmtNewline, *op.MaxTurns)
+		}
+		if op.MaxTokensPerTurn != nil {
+			fmt.Fprintf(stdout, AgentTextMaxTokens+FmtNewline, *op.MaxTokensPerTurn)
+		}
+		if len(op.AllowedDomains) > 0 {
+			fmt.Fprintf(stdout, AgentTextAllowedDomains+FmtNewline, strings.Join(op.AllowedDomains, AgentTextListSep))
+		}
+		if len(op.BlockedDomains) > 0 {
+			fmt.Fprintf(stdout, AgentTextBlockedDomains+FmtNewline, strings.Join(op.BlockedDomains, AgentTextListSep))
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testInspectAgentContent = `---
+name: support-agent
+description: Answers support questions
+type: agent
+compatibility: Requires network access
+execution:
+  provider: openai
+  model: gpt-4
+  top_k: 40
+skills:
+  - inline:
+      slug: triage
+      body: Sort the question into a queue.
+    injection: system_prompt
+tools:
+  functions:
+    - name: lookup_order
+      description: Finds an order by id
+  mcp_servers:
+    - name: docs
+      url: https://docs.example.com/mcp
+      tools: [search]
+constraints:
+  behavioral:
+    - Be concise
+  operational:
+    max_turns: 5
+    allowed_domains: [example.com]
+messages:
+  - role: system
+    content: You are a support agent.
+  - role: user
+    content: '{~prompty.var name="input.query" /~}'
+sample:
+  query: Where is my order?
+---
+`
+
+func writeInspectAgent(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "AGENT.md")
+	require.NoError(t, os.WriteFile(path, []byte(testInspectAgentContent), FilePermissions))
+	return path
+}
+
+func TestAgentInspect_Text(t *testing.T) {
+	path := writeInspectAgent(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runAgent([]string{AgentCmdInspect, path, "--color", ColorModeNever}, nil, &stdout, &stderr)
+
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "Agent: support-agent")
+	assert.Contains(t, out, "Skills (1):\n  triage (inline)  injection: system_prompt")
+	assert.Contains(t, out, "  lookup_order (function)  Finds an order by id")
+	assert.Contains(t, out, "  docs (mcp: https://docs.example.com/mcp)  tools: search")
+	assert.Contains(t, out, "  behavioral: Be concise")
+	assert.Contains(t, out, "  max turns: 5")
+	assert.Contains(t, out, "  declared: Requires network access")
+	assert.Contains(t, out, "! openai")
+	assert.Contains(t, out, "top_k")
+	assert.Contains(t, out, "✓ 2 message(s) compiled with sample data")
+}
+
+func TestAgentInspect_JSON(t *testing.T) {
+	path := writeInspectAgent(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runAgent([]string{AgentCmdInspect, "--json", path, "--provider", "anthropic"}, nil, &stdout, &stderr)
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+
+	var output agentInspectOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &output))
+	assert.Equal(t, "support-agent", output.Name)
+	require.Len(t, output.Skills, 1)
+	assert.True(t, output.Skills[0].Inline)
+	require.NotNil(t, output.Tools)
+	assert.Len(t, output.Tools.Functions, 1)
+	assert.Equal(t, "anthropic", output.Compatibility.Provider)
+	assert.Equal(t, "gpt-4", output.Compatibility.Model)
+	assert.Empty(t, output.Compatibility.Issues)
+	assert.True(t, output.Compilation.OK)
+	assert.Equal(t, 2, output.Compilation.Messages)
+}
+
+func TestAgentInspect_Failures(t *testing.T) {
+	t.Run("compilation fails", func(t *testing.T) {
+		path := writeInspectAgent(t)
+		var stdout, stderr bytes.Buffer
+		code := runAgent([]string{AgentCmdInspect, path, "-d", `{}`, "--color", ColorModeNever}, nil, &stdout, &stderr)
+		assert.Equal(t, ExitCodeValidationError, code)
+		assert.Contains(t, stdout.String(), "Compilation:\n  ✗ ")
+	})
+
+	t.Run("unsupported setting", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "AGENT.md")
+		content := "---\nname: extractor\ndescription: Extracts data\ntype: agent\nexecution:\n  provider: vllm\n" +
+			"  response_format:\n    type: json_object\n---\nExtract.\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), FilePermissions))
+
+		var stdout, stderr bytes.Buffer
+		code := runAgent([]string{AgentCmdInspect, path, "--color", ColorModeNever}, nil, &stdout, &stderr)
+		assert.Equal(t, ExitCodeValidationError, code)
+		assert.Contains(t, stdout.String(), "✗ ")
+		assert.Contains(t, stdout.String(), "response_format")
+	})
+
+	t.Run("not an agent", func(t *testing.T) {
+		document, _ := writeSkillFiles(t)
+		var stdout, stderr bytes.Buffer
+		code := runAgent([]string{AgentCmdInspect, document}, nil, &stdout, &stderr)
+		assert.Equal(t, ExitCodeValidationError, code)
+		assert.Contains(t, stderr.String(), ErrMsgNotAnAgent)
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, ExitCodeUsageError, runAgent(nil, nil, &stdout, &stderr))
+		assert.Equal(t, ExitCodeUsageError, runAgent([]string{"run"}, nil, &stdout, &stderr))
+		assert.Equal(t, ExitCodeUsageError, runAgent([]string{AgentCmdInspect}, nil, &stdout, &stderr))
+		assert.Contains(t, stderr.String(), ErrMsgUnknownAgentSubcommand)
+	})
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/itsatony/go-prompty/v2"
+	"github.com/itsatony/go-prompty/v2/bench"
+)
+
+// benchConfig holds parsed bench command configuration
+type benchConfig struct {
+	workloads   []string
+	benchTime   time.Duration
+	jsonOutput  bool
+	outputPath  string
+	comparePath string
+	budget      bench.Budget
+	list        bool
+}
+
+// benchOutput is the JSON output of bench, also read back by --compare
+type benchOutput struct {
+	Report     *bench.Report     `json:"report"`
+	Comparison *bench.Comparison `json:"comparison,omitempty"`
+}
+
+func runBench(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parseBenchFlags(args)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidBenchArgs, err)
+		return ExitCodeUsageError
+	}
+
+	if cfg.list {
+		for _, w := range bench.Workloads() {
+			fmt.Fprintf(stdout, BenchTextListFormat+FmtNewline, w.Name, w.Description)
+		}
+		return ExitCodeSuccess
+	}
+
+	var baseline *bench.Report
+	if cfg.comparePath != "" {
+		baseline, err = loadBaseline(cfg.comparePath)
+		if err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReadBaselineFailed, err)
+			return ExitCodeInputError
+		}
+	}
+
+	report, err := bench.Run(context.Background(), bench.WithBenchTime(cfg.benchTime), bench.WithWorkloads(cfg.workloads...))
+	if err != nil {
+		if errors.Is(err, bench.ErrUnknownWorkload) {
+			workload := prompty.ErrorDetailsOf(err).Metadata[bench.MetaKeyWorkload]
+			fmt.Fprintf(stderr, FmtErrorWithDetail, bench.ErrMsgUnknownWorkload, workload)
+			return ExitCodeUsageError
+		}
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgBenchFailed, err)
+		return ExitCodeError
+	}
+	report.Version = getVersionInfo().Version
+
+	output := &benchOutput{Report: report}
+	if baseline != nil {
+		output.Comparison = bench.Compare(baseline, report, cfg.budget)
+	}
+
+	var out []byte
+	if cfg.jsonOutput {
+		out, _ = json.MarshalIndent(output, "", "  ")
+		out = append(out, FmtNewline...)
+	} else {
+		out = formatBenchText(output)
+	}
+	if err := writeOutput(cfg.outputPath, out, stdout); err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgWriteOutputFailed, err)
+		return ExitCodeError
+	}
+
+	if output.Comparison != nil && output.Comparison.Regressed() {
+		return ExitCodeValidationError
+	}
+	return ExitCodeSuccess
+}
+
+func parseBenchFlags(args []string) (*benchConfig, error) {
+	fs := flag.NewFlagSet(CmdNameBench, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	cfg := &benchConfig{}
+	var nsBudget, allocsBudget float64
+
+	fs.DurationVar(&cfg.benchTime, FlagBenchTime, bench.DefaultBenchTime, "")
+	fs.BoolVar(&cfg.jsonOutput, FlagJSON, false, "")
+	fs.StringVar(&cfg.outputPath, FlagOutput, FlagDefaultOutput, "")
+	fs.StringVar(&cfg.outputPath, FlagOutputShort, FlagDefaultOutput, "")
+	fs.StringVar(&cfg.comparePath, FlagCompare, "", "")
+	fs.Float64Var(&nsBudget, FlagBudget, bench.DefaultNsBudget*BenchPercent, "")
+	fs.Float64Var(&allocsBudget, FlagAllocBudget, bench.DefaultAllocsBudget*BenchPercent, "")
+	fs.BoolVar(&cfg.list, FlagList, false, "")
+	fs.BoolVar(&cfg.list, FlagListShort, false, "")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if nsBudget < 0 || allocsBudget < 0 {
+		return nil, errors.New(ErrMsgInvalidBudget)
+	}
+
+	cfg.workloads = positional
+	cfg.budget = bench.Budget{NsPerOp: nsBudget / BenchPercent, AllocsPerOp: allocsBudget / BenchPercent}
+	return cfg, nil
+}
+
+// loadBaseline reads a report written by "prompty bench --json".
+func loadBaseline(path string) (*bench.Report, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var output benchOutput
+	if err := json.Unmarshal(raw, &output); err != nil {
+		return nil, err
+	}
+	if output.Report == nil {
+		return nil, errors.New(ErrMsgJSONUnmarshalFailed)
+	}
+	return output.Report, nil
+}
+
+// formatBenchText renders results, and the comparison if any, as tables.
+func formatBenchText(output *benchOutput) []byte {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, BenchTabPadding, ' ', 0)
+
+	if output.Comparison == nil {
+		fmt.Fprintln(tw, BenchTextHeader)
+		for _, r := range output.Report.Results {
+			fmt.Fprintf(tw, BenchTextRow+FmtNewline, r.Workload, r.Iterations, r.NsPerOp, r.BytesPerOp, r.AllocsPerOp)
+		}
+		_ = tw.Flush()
+		return buf.Bytes()
+	}
+
+	c := output.Comparison
+	fmt.Fprintln(tw, BenchCompareHeader)
+	regressed := 0
+	for _, d := range c.Deltas {
+		if d.Regressed() {
+			regressed++
+		}
+		fmt.Fprintf(tw, BenchCompareRow+FmtNewline, d.Workload,
+			d.Baseline.NsPerOp, d.Current.NsPerOp, formatBenchChange(d.NsChange, d.NsRegressed),
+			d.Baseline.AllocsPerOp, d.Current.AllocsPerOp, formatBenchChange(d.AllocsChange, d.AllocsRegressed))
+	}
+	_ = tw.Flush()
+
+	for _, name := range c.Missing {
+		fmt.Fprintf(&buf, BenchTextMissing+FmtNewline, name)
+	}
+	buf.WriteString(FmtNewline)
+	if regressed > 0 {
+		fmt.Fprintf(&buf, BenchTextRegressed+FmtNewline, regressed, c.Budget.NsPerOp*BenchPercent, c.Budget.AllocsPerOp*BenchPercent)
+	} else {
+		fmt.Fprintf(&buf, BenchTextWithinBudget+FmtNewline, c.Budget.NsPerOp*BenchPercent, c.Budget.AllocsPerOp*BenchPercent)
+	}
+	return buf.Bytes()
+}
+
+// formatBenchChange formats a relative change as a signed percentage,
+// marked when it exceeds the budget.
+func formatBenchChange(change float64, regressed bool) string {
+	s := fmt.Sprintf(BenchCompareChange, change*BenchPercent)
+	if regressed {
+		s += BenchRegressionMarker
+	}
+	return s
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/itsatony/go-prompty/v2/bench"
+)
+
+// writeBaseline writes a bench JSON report with the given per-op numbers
+// for the small-vars workload and returns its path.
+func writeBaseline(t *testing.T, nsPerOp float64, allocsPerOp uint64) string {
+	t.Helper()
+	raw, err := json.Marshal(benchOutput{Report: &bench.Report{Results: []bench.Result{
+		{Workload: bench.WorkloadSmallVars, Iterations: 1, NsPerOp: nsPerOp, AllocsPerOp: allocsPerOp},
+	}}})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	require.NoError(t, os.WriteFile(path, raw, FilePermissions))
+	return path
+}
+
+func TestBench_List(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{CmdNameBench, "--list"}, nil, &stdout, &stderr)
+
+	require.Equal(t, ExitCodeSuccess, code)
+	for _, w := range bench.Workloads() {
+		assert.Contains(t, stdout.String(), w.Name)
+	}
+}
+
+func TestBench_Text(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := runBench([]string{"--benchtime", "1ms", bench.WorkloadSmallVars, bench.WorkloadLoopHeavy}, nil, &stdout, &stderr)
+
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+	lines := strings.Split(strings.TrimSpace(stdout.String()), FmtNewline)
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "WORKLOAD"))
+	assert.True(t, strings.HasPrefix(lines[1], bench.WorkloadSmallVars))
+	assert.True(t, strings.HasPrefix(lines[2], bench.WorkloadLoopHeavy))
+}
+
+func TestBench_JSONToFileAndCompare(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+
+	var stdout, stderr bytes.Buffer
+	code := runBench([]string{"--benchtime", "1ms", "--json", "-o", path, bench.WorkloadSmallVars}, nil, &stdout, &stderr)
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+	assert.Empty(t, stdout.String())
+
+	report, err := loadBaseline(path)
+	require.NoError(t, err)
+	require.Len(t, report.Results, 1)
+	assert.Equal(t, bench.WorkloadSmallVars, report.Results[0].Workload)
+	assert.NotEmpty(t, report.Version)
+
+	// A run compared with itself under an unlimited budget never regresses
+	stdout.Reset()
+	code = runBench([]string{"--benchtime", "1ms", "--json", "--compare", path, "--budget", "100000", "--alloc-budget", "100000", bench.WorkloadSmallVars}, nil, &stdout, &stderr)
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+
+	var output benchOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &output))
+	require.NotNil(t, output.Comparison)
+	require.Len(t, output.Comparison.Deltas, 1)
+	assert.False(t, output.Comparison.Regressed())
+}
+
+func TestBench_CompareWithinBudget(t *testing.T) {
+	baseline := writeBaseline(t, 1e12, 1_000_000)
+
+	var stdout, stderr bytes.Buffer
+	code := runBench([]string{"--benchtime", "1ms", "--compare", baseline, bench.WorkloadSmallVars, bench.WorkloadLoopHeavy}, nil, &stdout, &stderr)
+
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "BASE NS/OP")
+	assert.Contains(t, out, "-100.0%")
+	assert.Contains(t, out, bench.WorkloadLoopHeavy+": no baseline result")
+	assert.Contains(t, out, "No regressions beyond budget (ns/op +15%, allocs/op +10%)")
+}
+
+func TestBench_CompareRegression(t *testing.T) {
+	baseline := writeBaseline(t, 0.001, 0)
+
+	var stdout, stderr bytes.Buffer
+	code := runBench([]string{"--benchtime", "1ms", "--compare", baseline, "--budget", "50", bench.WorkloadSmallVars}, nil, &stdout, &stderr)
+
+	require.Equal(t, ExitCodeValidationError, code)
+	out := stdout.String()
+	assert.Contains(t, out, BenchRegressionMarker)
+	assert.Contains(t, out, "1 workload(s) regressed beyond budget (ns/op +50%, allocs/op +10%)")
+}
+
+func TestBench_Errors(t *testing.T) {
+	invalidBaseline := filepath.Join(t.TempDir(), "bad.json")
+	require.NoError(t, os.WriteFile(invalidBaseline, []byte(`{"results": []}`), FilePermissions))
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{name: "unknown workload", args: []string{"--benchtime", "1ms", "nope"}, code: ExitCodeUsageError},
+		{name: "negative budget", args: []string{"--budget", "-1"}, code: ExitCodeUsageError},
+		{name: "unknown flag", args: []string{"--bogus"}, code: ExitCodeUsageError},
+		{name: "missing baseline", args: []string{"--compare", filepath.Join(t.TempDir(), "missing.json")}, code: ExitCodeInputError},
+		{name: "baseline without report", args: []string{"--compare", invalidBaseline}, code: ExitCodeInputError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runBench(tt.args, nil, &stdout, &stderr)
+			assert.Equal(t, tt.code, code)
+			assert.NotEmpty(t, stderr.String())
+		})
+	}
+}
+
+func TestBench_UnknownWorkloadNamed(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := runBench([]string{"--benchtime", "1ms", "nope"}, nil, &stdout, &stderr)
+
+	assert.Equal(t, ExitCodeUsageError, code)
+	assert.Equal(t, "unknown workload: nope\n", stderr.String())
+}
+
+func TestBench_Help(t *testing.T) {
+	var stdout bytes.Buffer
+	code := runHelp([]string{CmdNameBench}, &stdout)
+
+	assert.Equal(t, ExitCodeSuccess, code)
+	assert.Contains(t, stdout.String(), "--compare")
+}
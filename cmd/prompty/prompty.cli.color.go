@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// colorizer wraps text in ANSI escape codes when color output is enabled.
+type colorizer struct {
+	enabled bool
+}
+
+// newColorizer resolves a --color mode. In auto mode, color is used when
+// stdout is a terminal and neither NO_COLOR is set nor TERM is "dumb".
+func newColorizer(mode string, stdout io.Writer) (*colorizer, error) {
+	switch mode {
+	case ColorModeAlways:
+		return &colorizer{enabled: true}, nil
+	case ColorModeNever:
+		return &colorizer{}, nil
+	case ColorModeAuto:
+		return &colorizer{enabled: isTerminal(stdout)}, nil
+	default:
+		return nil, errors.New(ErrMsgInvalidColorMode)
+	}
+}
+
+// isTerminal reports whether w is a character device that accepts color.
+func isTerminal(w io.Writer) bool {
+	if _, noColor := os.LookupEnv(EnvNoColor); noColor || os.Getenv(EnvTerm) == TermDumb {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func (c *colorizer) paint(code, text string) string {
+	if !c.enabled || text == "" {
+		return text
+	}
+	return code + text + AnsiReset
+}
+
+func (c *colorizer) bold(text string) string   { return c.paint(AnsiBold, text) }
+func (c *colorizer) dim(text string) string    { return c.paint(AnsiDim, text) }
+func (c *colorizer) red(text string) string    { return c.paint(AnsiRed, text) }
+func (c *colorizer) green(text string) string  { return c.paint(AnsiGreen, text) }
+func (c *colorizer) yellow(text string) string { return c.paint(AnsiYellow, text) }
+func (c *colorizer) cyan(text string) string   { return c.paint(AnsiCyan, text) }
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/itsatony/go-prompty/v2"
+	"github.com/itsatony/go-prompty/v2/eval"
+)
+
+// compareConfig holds parsed compare command configuration
+type compareConfig struct {
+	evalOptions
+	name             string
+	baseVersion      int
+	candidateVersion int
+	thresholds       eval.Thresholds
+	format           string
+	outputPath       string
+}
+
+// junitTestSuites is the root element of a JUnit XML report
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite holds one test case per evaluator
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is one evaluator's threshold check
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+// junitFailure describes why a test case failed
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func runCompare(args []string, _ io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parseCompareFlags(args)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidCompareArgs, err)
+		return ExitCodeUsageError
+	}
+	ctx := context.Background()
+
+	dataset, err := loadEvalDataset(ctx, &cfg.evalOptions)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgLoadDatasetFailed, err)
+		return ExitCodeInputError
+	}
+	if dataset, err = selectEvalExamples(dataset, &cfg.evalOptions); err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidCompareArgs, err)
+		return ExitCodeUsageError
+	}
+	evaluators, err := buildEvaluators(&cfg.evalOptions)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidEvaluator, err)
+		return ExitCodeUsageError
+	}
+
+	storage, err := prompty.OpenStorage(cfg.driver, cfg.dsn)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgOpenStorageFailed, err)
+		return ExitCodeError
+	}
+	engine, err := prompty.NewStorageEngine(prompty.StorageEngineConfig{Storage: storage})
+	if err != nil {
+		storage.Close()
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgOpenStorageFailed, err)
+		return ExitCodeError
+	}
+	defer engine.Close()
+
+	opts := []eval.Option{eval.WithThresholds(cfg.thresholds), eval.WithConcurrency(cfg.concurrency)}
+	if cfg.provider != "" {
+		opts = append(opts, eval.WithVersionTarget(func(tmpl *prompty.StoredTemplate) (eval.Target, error) {
+			prompt, err := prompty.Parse([]byte(tmpl.Source))
+			if err != nil {
+				return nil, err
+			}
+			return evalTarget(prompt, &cfg.evalOptions), nil
+		}))
+	}
+	report, err := eval.CompareVersions(ctx, engine, cfg.name, cfg.baseVersion, cfg.candidateVersion, dataset, evaluators, opts...)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgCompareFailed, err)
+		return ExitCodeError
+	}
+
+	var output []byte
+	switch cfg.format {
+	case OutputFormatJSON:
+		output, _ = json.MarshalIndent(report, "", "  ")
+		output = append(output, FmtNewline...)
+	case OutputFormatJUnit:
+		output = formatCompareJUnit(report)
+	default:
+		output = formatCompareText(report)
+	}
+	if err := writeOutput(cfg.outputPath, output, stdout); err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgWriteOutputFailed, err)
+		return ExitCodeError
+	}
+
+	if !report.Passed {
+		return ExitCodeValidationError
+	}
+	return ExitCodeSuccess
+}
+
+func parseCompareFlags(args []string) (*compareConfig, error) {
+	fs := flag.NewFlagSet(CmdNameCompare, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	cfg := &compareConfig{}
+	var jsonOutput bool
+
+	registerEvalFlags(fs, &cfg.evalOptions)
+	fs.StringVar(&cfg.name, FlagName, "", "")
+	fs.StringVar(&cfg.name, FlagNameShort, "", "")
+	fs.IntVar(&cfg.baseVersion, FlagBase, 0, "")
+	fs.IntVar(&cfg.candidateVersion, FlagCandidate, 0, "")
+	fs.Float64Var(&cfg.thresholds.MaxMeanDrop, FlagMaxMeanDrop, 0, "")
+	fs.Float64Var(&cfg.thresholds.MaxPassRateDrop, FlagMaxPassRateDrop, 0, "")
+	fs.Float64Var(&cfg.thresholds.MinPassRate, FlagMinPassRate, 0, "")
+	fs.StringVar(&cfg.format, FlagFormat, FlagDefaultFormat, "")
+	fs.StringVar(&cfg.format, FlagFormatShort, FlagDefaultFormat, "")
+	fs.BoolVar(&jsonOutput, FlagJSON, false, "")
+	fs.StringVar(&cfg.outputPath, FlagOutput, FlagDefaultOutput, "")
+	fs.StringVar(&cfg.outputPath, FlagOutputShort, FlagDefaultOutput, "")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.name == "" && len(positional) == 1 {
+		cfg.name = positional[0]
+	}
+	if cfg.name == "" {
+		return nil, errors.New(ErrMsgMissingCompareName)
+	}
+	if cfg.baseVersion <= 0 {
+		return nil, errors.New(ErrMsgMissingBaseVersion)
+	}
+	if cfg.candidateVersion < 0 {
+		return nil, fmt.Errorf(FmtDetail, ErrMsgInvalidVersionArg, strconv.Itoa(cfg.candidateVersion))
+	}
+	if !cfg.hasStore() {
+		return nil, errors.New(ErrMsgMissingDSN)
+	}
+	if err := cfg.evalOptions.validate(); err != nil {
+		return nil, err
+	}
+	for _, v := range []float64{cfg.thresholds.MaxMeanDrop, cfg.thresholds.MaxPassRateDrop, cfg.thresholds.MinPassRate} {
+		if v < 0 || v > 1 {
+			return nil, errors.New(ErrMsgInvalidThreshold)
+		}
+	}
+
+	if jsonOutput {
+		cfg.format = OutputFormatJSON
+	}
+	if cfg.format != OutputFormatText && cfg.format != OutputFormatJSON && cfg.format != OutputFormatJUnit {
+		return nil, errors.New(ErrMsgInvalidCompareFmt)
+	}
+
+	return cfg, nil
+}
+
+// formatCompareText renders the score changes as a table, followed by the
+// changed examples and the threshold failures.
+func formatCompareText(report *eval.VersionReport) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, CompareTextTitle+FmtNewline+FmtNewline, report.Name, report.BaseVersion, report.CandidateVersion,
+		report.Dataset, len(report.Candidate.Rows))
+
+	tw := tabwriter.NewWriter(&buf, 0, 0, CompareTabPadding, ' ', 0)
+	fmt.Fprintln(tw, CompareTextHeader)
+	for _, d := range report.Comparison.Deltas {
+		fmt.Fprintf(tw, CompareTextRow+FmtNewline, d.Evaluator, d.Baseline.Mean, d.Candidate.Mean, d.MeanChange,
+			d.Baseline.PassRate*EvalPercent, d.Candidate.PassRate*EvalPercent, d.PassRateChange*EvalPercent)
+	}
+	_ = tw.Flush()
+
+	if len(report.Rows) > 0 {
+		buf.WriteString(FmtNewline + CompareTextChanged + FmtNewline)
+		for _, row := range report.Rows {
+			fmt.Fprintf(&buf, CompareTextRowOutput+FmtNewline, row.ID, row.BaselineOutput, row.CandidateOutput)
+			for _, score := range row.Scores {
+				if score.Baseline != score.Candidate {
+					fmt.Fprintf(&buf, CompareTextRowScore+FmtNewline, score.Evaluator, score.Baseline.Value, score.Candidate.Value)
+				}
+			}
+		}
+	}
+
+	buf.WriteString(FmtNewline)
+	for _, failure := range report.Failures {
+		fmt.Fprintf(&buf, CompareTextFailure+FmtNewline, failure)
+	}
+	if report.Passed {
+		buf.WriteString(CompareTextPassed + FmtNewline)
+	} else {
+		fmt.Fprintf(&buf, CompareTextFailed+FmtNewline, len(report.Failures))
+	}
+	return buf.Bytes()
+}
+
+// formatCompareJUnit renders one JUnit test case per evaluator, failed
+// when the evaluator fails a threshold.
+func formatCompareJUnit(report *eval.VersionReport) []byte {
+	suite := junitTestSuite{
+		Name: fmt.Sprintf(CompareJUnitSuiteName, report.Name, report.BaseVersion, report.CandidateVersion, report.Dataset),
+		Time: fmt.Sprintf(CompareJUnitTime, (report.Baseline.Duration + report.Candidate.Duration).Seconds()),
+	}
+	for _, d := range report.Comparison.Deltas {
+		tc := junitTestCase{Name: d.Evaluator, ClassName: report.Name}
+		if failures := report.Thresholds.Check(d); len(failures) > 0 {
+			tc.Failure = &junitFailure{Message: strings.Join(failures, CompareJUnitSeparator)}
+			if len(d.Regressions) > 0 {
+				tc.Failure.Text = fmt.Sprintf(CompareJUnitRegressions, strings.Join(d.Regressions, ListSeparator))
+			}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Tests = len(suite.Cases)
+
+	data, _ := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  ")
+	return append([]byte(xml.Header), append(data, FmtNewline...)...)
+}
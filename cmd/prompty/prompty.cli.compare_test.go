@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/itsatony/go-prompty/v2"
+	"github.com/itsatony/go-prompty/v2/eval"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const compareCmdDataset = `{"id": "fr", "input": {"capital": "Paris"}, "reference": "Paris"}
+{"id": "it", "input": {"capital": "Rome"}, "reference": "Rome"}
+`
+
+func writeCompareStore(t *testing.T) (store, datasetPath string) {
+	t.Helper()
+	dir := t.TempDir()
+	store = filepath.Join(dir, "store")
+	datasetPath = filepath.Join(dir, "capitals.jsonl")
+	require.NoError(t, os.WriteFile(datasetPath, []byte(compareCmdDataset), FilePermissions))
+
+	storage, err := prompty.OpenStorage(prompty.StorageDriverNameFilesystem, store)
+	require.NoError(t, err)
+	engine, err := prompty.NewStorageEngine(prompty.StorageEngineConfig{Storage: storage})
+	require.NoError(t, err)
+	defer engine.Close()
+	for _, source := range []string{
+		`The capital is {~prompty.var name="capital" /~}.`,
+		`{~prompty.if eval="capital == 'Paris'"~}Paris{~prompty.else~}No idea{~/prompty.if~}`,
+	} {
+		require.NoError(t, engine.Save(context.Background(), &prompty.StoredTemplate{Name: "capital", Source: source}))
+	}
+	return store, datasetPath
+}
+
+func TestCompare_Text(t *testing.T) {
+	store, datasetPath := writeCompareStore(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runCompare([]string{"--dsn", store, "--dataset", datasetPath, "--base", "1", "capital"}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeValidationError, code, stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "capital v1 -> v2 on capitals (2 example(s))")
+	assert.Contains(t, out, "reference  1.000      0.500  -0.500")
+	assert.Contains(t, out, `it: "The capital is Rome." -> "No idea"`)
+	assert.Contains(t, out, "reference 1.000 -> 0.000")
+	assert.Contains(t, out, "FAIL reference: pass rate dropped by 0.500 (allowed 0.000)")
+	assert.Contains(t, out, "Candidate failed 2 threshold(s)")
+
+	stdout.Reset()
+	code = runCompare([]string{"--dsn", store, "--dataset", datasetPath, "--base", "1", "--candidate", "1", "-n", "capital"}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeSuccess, code, stderr.String())
+	assert.Contains(t, stdout.String(), CompareTextPassed)
+}
+
+func TestCompare_JSON(t *testing.T) {
+	store, datasetPath := writeCompareStore(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runCompare([]string{"--json", "--dsn", store, "--dataset", datasetPath, "--base", "1",
+		"--max-mean-drop", "0.5", "--max-pass-rate-drop", "0.5", "capital"}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeSuccess, code, stderr.String())
+
+	var report eval.VersionReport
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &report))
+	assert.True(t, report.Passed)
+	assert.Equal(t, 2, report.CandidateVersion)
+	assert.Equal(t, 0.5, report.Thresholds.MaxMeanDrop)
+	assert.Len(t, report.Rows, 2)
+}
+
+func TestCompare_JUnit(t *testing.T) {
+	store, datasetPath := writeCompareStore(t)
+	outPath := filepath.Join(t.TempDir(), "compare.xml")
+
+	var stdout, stderr bytes.Buffer
+	code := runCompare([]string{"-F", "junit", "-o", outPath, "--dsn", store, "--dataset", datasetPath,
+		"--base", "1", "--regex", "\\.$", "--reference", "capital"}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeValidationError, code, stderr.String())
+
+	data, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(data, &suites))
+	require.Len(t, suites.Suites, 1)
+	suite := suites.Suites[0]
+	assert.Equal(t, "capital v1 -> v2 (capitals)", suite.Name)
+	assert.Equal(t, 2, suite.Tests)
+	assert.Equal(t, 2, suite.Failures)
+	require.Len(t, suite.Cases, 2)
+	assert.Equal(t, "regex", suite.Cases[0].Name)
+	require.NotNil(t, suite.Cases[1].Failure)
+	assert.Equal(t, "reference: mean score dropped by 0.500 (allowed 0.000); reference: pass rate dropped by 0.500 (allowed 0.000)",
+		suite.Cases[1].Failure.Message)
+	assert.Equal(t, "regressed examples: it", suite.Cases[1].Failure.Text)
+}
+
+func TestCompare_Errors(t *testing.T) {
+	store, datasetPath := writeCompareStore(t)
+
+	tests := []struct {
+		name string
+		args []string
+		msg  string
+	}{
+		{"no name", []string{"--dsn", store, "--dataset", datasetPath, "--base", "1"}, ErrMsgMissingCompareName},
+		{"no base", []string{"--dsn", store, "--dataset", datasetPath, "capital"}, ErrMsgMissingBaseVersion},
+		{"no store", []string{"--dsn", "", "--dataset", datasetPath, "--base", "1", "capital"}, ErrMsgMissingDSN},
+		{"no dataset", []string{"--dsn", store, "--base", "1", "capital"}, ErrMsgMissingDataset},
+		{"bad threshold", []string{"--dsn", store, "--dataset", datasetPath, "--base", "1", "--max-mean-drop", "2", "capital"}, ErrMsgInvalidThreshold},
+		{"bad format", []string{"--dsn", store, "--dataset", datasetPath, "--base", "1", "-F", "yaml", "capital"}, ErrMsgInvalidCompareFmt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, ExitCodeUsageError, runCompare(tt.args, nil, &stdout, &stderr))
+			assert.Contains(t, stderr.String(), tt.msg)
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := runCompare([]string{"--dsn", store, "--dataset", datasetPath, "--base", "1", "--candidate", "7", "capital"}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeError, code)
+	assert.Contains(t, stderr.String(), ErrMsgCompareFailed)
+}
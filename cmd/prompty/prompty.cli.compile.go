@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// compileConfig holds parsed compile/run command configuration
+type compileConfig struct {
+	agentPath    string
+	dataJSON     string
+	dataFilePath string
+	provider     string
+	model        string
+	skill        string
+	format       string
+	outputPath   string
+	driver       string
+	dsn          string
+	rawOutput    bool
+	timeout      time.Duration
+}
+
+// compiledMessageOutput is the JSON form of a compiled message
+type compiledMessageOutput struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	Cache   bool   `json:"cache,omitempty"`
+}
+
+// compileOutput is the JSON output of compile without --provider
+type compileOutput struct {
+	Messages    []compiledMessageOutput         `json:"messages"`
+	Execution   *prompty.ExecutionConfig        `json:"execution,omitempty"`
+	Tools       *prompty.ToolsConfig            `json:"tools,omitempty"`
+	Constraints *prompty.OperationalConstraints `json:"constraints,omitempty"`
+}
+
+func runCompile(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parseCompileFlags(CmdNameCompile, args)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgMissingAgent, err)
+		return ExitCodeUsageError
+	}
+
+	compiled, code := compileAgent(context.Background(), cfg, stdin, stderr)
+	if code != ExitCodeSuccess {
+		return code
+	}
+
+	var output []byte
+	switch {
+	case cfg.provider != "":
+		payload, err := compiled.ToProviderPayload(cfg.provider)
+		if err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgProviderPayloadFailed, err)
+			return ExitCodeUsageError
+		}
+		// Report settings the provider's payload leaves out
+		for _, issue := range prompty.CheckCompatibility(compiled.Execution, cfg.provider) {
+			fmt.Fprintln(stderr, issue.String())
+		}
+		output, _ = json.MarshalIndent(payload, "", "  ")
+		output = append(output, FmtNewline...)
+	case cfg.format == OutputFormatText:
+		output = []byte(formatCompiledText(compiled))
+	default:
+		output, _ = json.MarshalIndent(toCompileOutput(compiled), "", "  ")
+		output = append(output, FmtNewline...)
+	}
+
+	if err := writeOutput(cfg.outputPath, output, stdout); err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgWriteOutputFailed, err)
+		return ExitCodeError
+	}
+	return ExitCodeSuccess
+}
+
+func parseCompileFlags(name string, args []string) (*compileConfig, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	cfg := &compileConfig{}
+
+	fs.StringVar(&cfg.agentPath, FlagTemplate, "", "")
+	fs.StringVar(&cfg.agentPath, FlagTemplateShort, "", "")
+	fs.StringVar(&cfg.dataJSON, FlagData, "", "")
+	fs.StringVar(&cfg.dataJSON, FlagDataShort, "", "")
+	fs.StringVar(&cfg.dataFilePath, FlagDataFile, "", "")
+	fs.StringVar(&cfg.dataFilePath, FlagDataFileShort, "", "")
+	fs.StringVar(&cfg.provider, FlagProvider, "", "")
+	fs.StringVar(&cfg.provider, FlagProviderShort, "", "")
+	fs.StringVar(&cfg.model, FlagModel, "", "")
+	fs.StringVar(&cfg.skill, FlagSkill, "", "")
+	fs.StringVar(&cfg.format, FlagFormat, OutputFormatJSON, "")
+	fs.StringVar(&cfg.format, FlagFormatShort, OutputFormatJSON, "")
+	fs.StringVar(&cfg.outputPath, FlagOutput, FlagDefaultOutput, "")
+	fs.StringVar(&cfg.outputPath, FlagOutputShort, FlagDefaultOutput, "")
+	fs.StringVar(&cfg.driver, FlagDriver, envOrDefault(EnvStoreDriver, prompty.StorageDriverNameFilesystem), "")
+	fs.StringVar(&cfg.dsn, FlagDSN, os.Getenv(EnvStoreDSN), "")
+	fs.BoolVar(&cfg.rawOutput, FlagRaw, false, "")
+	fs.DurationVar(&cfg.timeout, FlagTimeout, RunDefaultTimeout, "")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.agentPath == "" && len(positional) == 1 {
+		cfg.agentPath = positional[0]
+	}
+
+	if cfg.agentPath == "" {
+		return nil, errors.New(ErrMsgMissingAgent)
+	}
+	if cfg.format != OutputFormatJSON && cfg.format != OutputFormatText {
+		return nil, fmt.Errorf(FmtDetail, ErrMsgInvalidFormat, cfg.format)
+	}
+
+	return cfg, nil
+}
+
+// compileAgent reads, parses and compiles the agent (or activates --skill).
+// Skill references are resolved from the storage backend when --dsn is set.
+func compileAgent(ctx context.Context, cfg *compileConfig, stdin io.Reader, stderr io.Writer) (*prompty.CompiledPrompt, int) {
+	source, err := readInput(cfg.agentPath, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReadFileFailed, err)
+		return nil, ExitCodeInputError
+	}
+
+	data, err := loadData(cfg.dataJSON, cfg.dataFilePath)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidJSON, err)
+		return nil, ExitCodeInputError
+	}
+
+	var options []prompty.AgentExecutorOption
+	if cfg.dsn != "" {
+		storage, err := prompty.OpenStorage(cfg.driver, cfg.dsn)
+		if err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgOpenStorageFailed, err)
+			return nil, ExitCodeError
+		}
+		defer storage.Close()
+		options = append(options, prompty.WithAgentResolver(prompty.NewStorageDocumentResolver(storage)))
+	}
+	executor := prompty.NewAgentExecutor(options...)
+
+	var compiled *prompty.CompiledPrompt
+	if cfg.skill != "" {
+		compiled, err = executor.ActivateSkill(ctx, string(source), cfg.skill, data, nil)
+	} else {
+		compiled, err = executor.Execute(ctx, string(source), data)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgCompileFailed, err)
+		return nil, ExitCodeValidationError
+	}
+
+	if cfg.model != "" {
+		if compiled.Execution == nil {
+			compiled.Execution = &prompty.ExecutionConfig{}
+		}
+		compiled.Execution.Model = cfg.model
+	}
+	return compiled, ExitCodeSuccess
+}
+
+// toCompileOutput converts a compiled prompt to its JSON output form.
+func toCompileOutput(compiled *prompty.CompiledPrompt) *compileOutput {
+	output := &compileOutput{
+		Messages:    make([]compiledMessageOutput, 0, len(compiled.Messages)),
+		Execution:   compiled.Execution,
+		Tools:       compiled.Tools,
+		Constraints: compiled.Constraints,
+	}
+	for _, msg := range compiled.Messages {
+		output.Messages = append(output.Messages, compiledMessageOutput{Role: msg.Role, Content: msg.Content, Cache: msg.Cache})
+	}
+	return output
+}
+
+// formatCompiledText renders compiled messages for reading in a terminal.
+func formatCompiledText(compiled *prompty.CompiledPrompt) string {
+	var out []byte
+	for i, msg := range compiled.Messages {
+		if i > 0 {
+			out = append(out, FmtNewline...)
+		}
+		out = fmt.Appendf(out, CompileTextMessageHeader+FmtNewline, msg.Role)
+		out = append(out, msg.Content...)
+		out = append(out, FmtNewline...)
+	}
+	return string(out)
+}
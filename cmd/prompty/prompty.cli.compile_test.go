@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAgentContent = `---
+name: test-agent
+description: A test agent
+type: agent
+execution:
+  provider: openai
+  model: gpt-4
+  temperature: 0.5
+messages:
+  - role: system
+    content: You are a helpful assistant.
+  - role: user
+    content: 'Question: {~prompty.var name="input.query" /~}'
+---
+Agent body.
+`
+
+// writeAgentFile writes testAgentContent to a temp dir and returns its path.
+func writeAgentFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "agent.md")
+	require.NoError(t, os.WriteFile(path, []byte(testAgentContent), FilePermissions))
+	return path
+}
+
+func TestCompile_Messages(t *testing.T) {
+	agentPath := writeAgentFile(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runCompile([]string{agentPath, "-d", `{"query": "why?"}`}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+
+	var output compileOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &output))
+	require.Len(t, output.Messages, 2)
+	assert.Equal(t, "system", output.Messages[0].Role)
+	assert.Equal(t, "Question: why?", output.Messages[1].Content)
+	require.NotNil(t, output.Execution)
+	assert.Equal(t, "gpt-4", output.Execution.Model)
+}
+
+func TestCompile_TextFormat(t *testing.T) {
+	agentPath := writeAgentFile(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runCompile([]string{"-t", agentPath, "-d", `{"query": "why?"}`, "-F", "text"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+	assert.Contains(t, stdout.String(), "=== user ===\nQuestion: why?\n")
+}
+
+func TestCompile_ProviderPayload(t *testing.T) {
+	agentPath := writeAgentFile(t)
+
+	tests := []struct {
+		name  string
+		args  []string
+		check func(t *testing.T, payload map[string]any)
+	}{
+		{
+			name: "openai",
+			args: []string{"--provider", "openai"},
+			check: func(t *testing.T, payload map[string]any) {
+				assert.Equal(t, "gpt-4", payload["model"])
+				assert.Len(t, payload["messages"], 2)
+			},
+		},
+		{
+			name: "anthropic with model override",
+			args: []string{"-p", "anthropic", "--model", "claude-sonnet-4-5"},
+			check: func(t *testing.T, payload map[string]any) {
+				assert.Equal(t, "claude-sonnet-4-5", payload["model"])
+				assert.Equal(t, "You are a helpful assistant.", payload["system"])
+				assert.Len(t, payload["messages"], 1)
+			},
+		},
+		{
+			name: "gemini",
+			args: []string{"--provider", "gemini"},
+			check: func(t *testing.T, payload map[string]any) {
+				assert.Contains(t, payload, "contents")
+				assert.Contains(t, payload, "system_instruction")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{agentPath, "-d", `{"query": "why?"}`}, tt.args...)
+			var stdout, stderr bytes.Buffer
+			code := runCompile(args, strings.NewReader(""), &stdout, &stderr)
+			require.Equal(t, ExitCodeSuccess, code, stderr.String())
+
+			var payload map[string]any
+			require.NoError(t, json.Unmarshal(stdout.Bytes(), &payload))
+			tt.check(t, payload)
+		})
+	}
+}
+
+func TestCompile_ProviderCompatibility(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.md")
+	require.NoError(t, os.WriteFile(path, []byte(`---
+name: topk-agent
+description: Agent using top_k
+type: agent
+execution:
+  provider: anthropic
+  model: claude-sonnet-4-5
+  top_k: 40
+---
+Body.
+`), FilePermissions))
+
+	var stdout, stderr bytes.Buffer
+	code := runCompile([]string{path, "-p", "openai"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+	assert.Equal(t, "warning: top_k is not supported by openai and will be dropped\n", stderr.String())
+
+	stdout.Reset()
+	stderr.Reset()
+	code = runCompile([]string{path, "-p", "anthropic"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, ExitCodeSuccess, code)
+	assert.Empty(t, stderr.String())
+}
+
+func TestCompile_Stdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{CmdNameCompile, "-t", "-", "-d", `{"query": "hi"}`}, strings.NewReader(testAgentContent), &stdout, &stderr)
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Question: hi")
+}
+
+func TestCompile_Errors(t *testing.T) {
+	agentPath := writeAgentFile(t)
+	tmpDir := setupTestData(t)
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{"missing agent", nil, ExitCodeUsageError, ErrMsgMissingAgent},
+		{"invalid format", []string{agentPath, "-F", "yaml"}, ExitCodeUsageError, ErrMsgInvalidFormat},
+		{"agent not found", []string{filepath.Join(tmpDir, "nope.md")}, ExitCodeInputError, ErrMsgReadFileFailed},
+		{"invalid data", []string{agentPath, "-d", "{"}, ExitCodeInputError, ErrMsgInvalidJSON},
+		{"not an agent", []string{filepath.Join(tmpDir, "template.txt")}, ExitCodeValidationError, ErrMsgCompileFailed},
+		{"unknown provider", []string{agentPath, "-d", `{"query": "x"}`, "--provider", "nope"}, ExitCodeUsageError, ErrMsgProviderPayloadFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runCompile(tt.args, strings.NewReader(""), &stdout, &stderr)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Contains(t, stderr.String(), tt.wantErr)
+		})
+	}
+}
@@ -1,28 +1,97 @@
 package main
 
+import "time"
+
 // Command names
 const (
 	CmdNameRender   = "render"
 	CmdNameValidate = "validate"
 	CmdNameLint     = "lint"
+	CmdNameFmt      = "fmt"
+	CmdNameConvert  = "convert"
 	CmdNameDebug    = "debug"
+	CmdNameDryRun   = "dryrun"
+	CmdNameExplain  = "explain"
+	CmdNameStore    = "store"
+	CmdNameSkill    = "skill"
+	CmdNameAgent    = "agent"
+	CmdNameRepl     = "repl"
+	CmdNameCompile  = "compile"
+	CmdNameRun      = "run"
+	CmdNameLSP      = "lsp"
+	CmdNameBench    = "bench"
+	CmdNameTest     = "test"
+	CmdNameEval     = "eval"
+	CmdNameCompare  = "compare"
+	CmdNameReplay   = "replay"
 	CmdNameVersion  = "version"
 	CmdNameHelp     = "help"
 )
 
 // Flag names - long form
 const (
-	FlagTemplate   = "template"
-	FlagData       = "data"
-	FlagDataFile   = "data-file"
-	FlagOutput     = "output"
-	FlagQuiet      = "quiet"
-	FlagFormat     = "format"
-	FlagStrictMode = "strict"
-	FlagRules      = "rules"
-	FlagIgnore     = "ignore"
-	FlagTrace      = "trace"
-	FlagVerbose    = "verbose"
+	FlagTemplate        = "template"
+	FlagData            = "data"
+	FlagDataFile        = "data-file"
+	FlagOutput          = "output"
+	FlagQuiet           = "quiet"
+	FlagFormat          = "format"
+	FlagStrictMode      = "strict"
+	FlagRules           = "rules"
+	FlagIgnore          = "ignore"
+	FlagTrace           = "trace"
+	FlagVerbose         = "verbose"
+	FlagJSON            = "json"
+	FlagWrite           = "write"
+	FlagList            = "list"
+	FlagCheck           = "check"
+	FlagDriver          = "driver"
+	FlagDSN             = "dsn"
+	FlagName            = "name"
+	FlagVersion         = "version"
+	FlagPrefix          = "prefix"
+	FlagTags            = "tags"
+	FlagStatus          = "status"
+	FlagWatch           = "watch"
+	FlagInterval        = "interval"
+	FlagProvider        = "provider"
+	FlagModel           = "model"
+	FlagSkill           = "skill"
+	FlagRaw             = "raw"
+	FlagTimeout         = "timeout"
+	FlagTemplates       = "templates"
+	FlagStdio           = "stdio"
+	FlagBenchTime       = "benchtime"
+	FlagCompare         = "compare"
+	FlagBudget          = "budget"
+	FlagAllocBudget     = "alloc-budget"
+	FlagInheritance     = "inheritance"
+	FlagFrom            = "from"
+	FlagDataset         = "dataset"
+	FlagDatasetName     = "dataset-name"
+	FlagDatasetVersion  = "dataset-version"
+	FlagSaveDataset     = "save-dataset"
+	FlagSample          = "sample"
+	FlagSeed            = "seed"
+	FlagSplit           = "split"
+	FlagSplitRatio      = "split-ratio"
+	FlagRegex           = "regex"
+	FlagJSONValid       = "json-valid"
+	FlagMinLength       = "min-length"
+	FlagMaxLength       = "max-length"
+	FlagFlagged         = "flagged"
+	FlagReference       = "reference"
+	FlagConcurrency     = "concurrency"
+	FlagMinPassRate     = "min-pass-rate"
+	FlagBase            = "base"
+	FlagCandidate       = "candidate"
+	FlagMaxMeanDrop     = "max-mean-drop"
+	FlagMaxPassRateDrop = "max-pass-rate-drop"
+	FlagLive            = "live"
+	FlagColor           = "color"
+	FlagResources       = "resources"
+	FlagOut             = "out"
+	FlagTo              = "to"
 )
 
 // Flag names - short form
@@ -36,6 +105,10 @@ const (
 	FlagRulesShort    = "r"
 	FlagIgnoreShort   = "i"
 	FlagVerboseShort  = "v"
+	FlagWriteShort    = "w"
+	FlagListShort     = "l"
+	FlagNameShort     = "n"
+	FlagProviderShort = "p"
 )
 
 // Flag default values
@@ -44,10 +117,47 @@ const (
 	FlagDefaultFormat = "text"
 )
 
+// Watch mode defaults
+const (
+	DefaultWatchInterval = 500 * time.Millisecond
+)
+
+// Color modes for --color
+const (
+	ColorModeAuto   = "auto"
+	ColorModeAlways = "always"
+	ColorModeNever  = "never"
+)
+
+// Environment variables and ANSI escape codes for colored output
+const (
+	EnvNoColor = "NO_COLOR"
+	EnvTerm    = "TERM"
+	TermDumb   = "dumb"
+
+	AnsiReset  = "\033[0m"
+	AnsiBold   = "\033[1m"
+	AnsiDim    = "\033[2m"
+	AnsiRed    = "\033[31m"
+	AnsiGreen  = "\033[32m"
+	AnsiYellow = "\033[33m"
+	AnsiCyan   = "\033[36m"
+)
+
 // Output formats
 const (
-	OutputFormatText = "text"
-	OutputFormatJSON = "json"
+	OutputFormatText  = "text"
+	OutputFormatJSON  = "json"
+	OutputFormatJUnit = "junit"
+)
+
+// Eval command defaults and split names
+const (
+	EvalDefaultSeed        = 1
+	EvalDefaultSplitRatio  = 0.8
+	EvalDefaultMinPassRate = 1.0
+	EvalSplitTrain         = "train"
+	EvalSplitTest          = "test"
 )
 
 // Exit codes
@@ -62,6 +172,40 @@ const (
 // Input source indicators
 const (
 	InputSourceStdin = "-"
+	GlobMetaChars    = "*?["
+	ListSeparator    = ","
+	JSONObjectPrefix = "{"
+)
+
+// Store subcommand names
+const (
+	StoreCmdPush     = "push"
+	StoreCmdPull     = "pull"
+	StoreCmdList     = "list"
+	StoreCmdVersions = "versions"
+	StoreCmdRollback = "rollback"
+	StoreCmdDelete   = "delete"
+	StoreCmdDiff     = "diff"
+)
+
+// Skill and agent subcommand names
+const (
+	SkillCmdExport  = "export"
+	SkillCmdImport  = "import"
+	AgentCmdInspect = "inspect"
+)
+
+// Skill packaging settings
+const (
+	SkillImportTargetStorage = "storage" // --to value that saves to the store
+	SkillArchiveExtension    = ".zip"
+	SkillDirPermissions      = 0o755
+)
+
+// Environment variables providing store defaults
+const (
+	EnvStoreDriver = "PROMPTY_STORE_DRIVER"
+	EnvStoreDSN    = "PROMPTY_STORE_DSN"
 )
 
 // Error messages - ALL must be constants
@@ -80,6 +224,150 @@ const (
 	ErrMsgCreateFileFailed    = "failed to create output file"
 	ErrMsgJSONMarshalFailed   = "failed to marshal JSON"
 	ErrMsgJSONUnmarshalFailed = "failed to unmarshal JSON"
+	ErrMsgNoInputFiles        = "no input files specified"
+	ErrMsgNoFilesMatched      = "no files match pattern"
+	ErrMsgInvalidGlob         = "invalid glob pattern"
+	ErrMsgFormatFailed        = "template formatting failed"
+	ErrMsgWriteStdin          = "cannot write stdin in place"
+
+	ErrMsgConvertArgs            = "convert requires exactly one input file"
+	ErrMsgConvertDialectRequired = "--from is required when the dialect cannot be inferred from the file extension"
+	ErrMsgConvertFailed          = "template conversion failed"
+
+	ErrMsgNoStoreSubcommand        = "no store subcommand specified"
+	ErrMsgUnknownStoreSubcommand   = "unknown store subcommand"
+	ErrMsgInvalidStoreArgs         = "invalid store arguments"
+	ErrMsgMissingDSN               = "storage DSN required (--dsn or " + EnvStoreDSN + ")"
+	ErrMsgInvalidVersionArg        = "invalid version"
+	ErrMsgInvalidStatusArg         = "invalid deployment status"
+	ErrMsgOpenStorageFailed        = "failed to open storage"
+	ErrMsgNameWithMultipleFiles    = "--name cannot be used with multiple files"
+	ErrMsgNameRequiredStdin        = "--name is required when pushing from stdin"
+	ErrMsgStoreNameRequired        = "template name required"
+	ErrMsgStoreNameVersionRequired = "template name and version required"
+	ErrMsgDiffArgs                 = "usage: prompty store diff <name> <old-version> [new-version]"
+	ErrMsgStorePushFailed          = "failed to save template"
+	ErrMsgStoreGetFailed           = "failed to get template"
+	ErrMsgStoreListFailed          = "failed to list templates"
+	ErrMsgStoreRollbackFailed      = "failed to roll back template"
+	ErrMsgStoreDeleteFailed        = "failed to delete template"
+
+	ErrMsgWatchStdin         = "--watch requires a template file, not stdin"
+	ErrMsgInvalidInterval    = "--interval must be positive"
+	ErrMsgReplStdin          = "repl reads commands from stdin; the template must be a file"
+	ErrMsgReplUnknownCommand = "unknown command (type \"help\")"
+	ErrMsgReplSetSyntax      = "usage: set <path>=<value>"
+	ErrMsgReplUnsetSyntax    = "usage: unset <path>"
+	ErrMsgReplLoadSyntax     = "usage: load <data.json>"
+
+	ErrMsgInvalidLSPArgs    = "invalid lsp arguments"
+	ErrMsgLSPMethodNotFound = "method not found"
+	ErrMsgLSPInvalidParams  = "invalid params"
+	ErrMsgLSPParseFailed    = "invalid JSON-RPC message"
+	ErrMsgLSPReadFailed     = "failed to read LSP message"
+	ErrMsgLSPExitNoShutdown = "exit received before shutdown"
+
+	ErrMsgMissingAgent           = "agent file required"
+	ErrMsgCompileFailed          = "agent compilation failed"
+	ErrMsgProviderPayloadFailed  = "failed to build provider payload"
+	ErrMsgRunUnsupportedProvider = "unsupported provider for run (use --provider openai, anthropic, gemini, mistral or vllm)"
+	ErrMsgRunRequestFailed       = "provider request failed"
+	ErrMsgRunMissingAPIKey       = "missing API key; set"
+	ErrMsgRunMissingBaseURL      = "missing base URL; set"
+	ErrMsgRunMissingModel        = "execution.model (or --model) is required for this provider"
+
+	ErrMsgInvalidBenchArgs   = "invalid bench arguments"
+	ErrMsgBenchFailed        = "benchmark failed"
+	ErrMsgReadBaselineFailed = "failed to read baseline report"
+	ErrMsgInvalidBudget      = "budgets must not be negative"
+
+	ErrMsgSelfTestFailed = "prompt tests could not run"
+
+	ErrMsgInvalidEvalArgs   = "invalid eval arguments"
+	ErrMsgMissingPrompt     = "prompt file required"
+	ErrMsgMissingDataset    = "--dataset or --dataset-name is required"
+	ErrMsgDatasetConflict   = "use either --dataset or --dataset-name"
+	ErrMsgInvalidSplit      = "--split must be train or test"
+	ErrMsgInvalidPassRate   = "--min-pass-rate must be between 0 and 1"
+	ErrMsgSaveDatasetNoDSN  = "--save-dataset requires --dataset and a store (--dsn)"
+	ErrMsgLoadDatasetFailed = "failed to load dataset"
+	ErrMsgSaveDatasetFailed = "failed to save dataset"
+	ErrMsgInvalidEvaluator  = "invalid evaluator"
+	ErrMsgEvalFailed        = "evaluation failed"
+
+	ErrMsgInvalidCompareArgs = "invalid compare arguments"
+	ErrMsgMissingCompareName = "template name required (--name or argument)"
+	ErrMsgMissingBaseVersion = "--base version required"
+	ErrMsgInvalidThreshold   = "thresholds must be between 0 and 1"
+	ErrMsgCompareFailed      = "version comparison failed"
+	ErrMsgInvalidCompareFmt  = "invalid format (use text, json or junit)"
+
+	ErrMsgInvalidReplayArgs   = "invalid replay arguments"
+	ErrMsgMissingRecording    = "recording file required"
+	ErrMsgLoadRecordingFailed = "failed to load recording"
+	ErrMsgReplayFailed        = "replay failed"
+
+	ErrMsgInvalidExplainArgs = "invalid explain arguments"
+	ErrMsgExplainFailed      = "inheritance resolution failed"
+	ErrMsgInvalidDryRunArgs  = "invalid dryrun arguments"
+
+	ErrMsgNoSkillSubcommand      = "no skill subcommand specified"
+	ErrMsgUnknownSkillSubcommand = "unknown skill subcommand"
+	ErrMsgInvalidSkillArgs       = "invalid skill arguments"
+	ErrMsgMissingDocument        = "document file required"
+	ErrMsgMissingImportTarget    = "--to is required (a directory or \"" + SkillImportTargetStorage + "\")"
+	ErrMsgImportDocumentFailed   = "failed to import document"
+	ErrMsgReadResourcesFailed    = "failed to read resources"
+	ErrMsgSkillExportFailed      = "failed to export skill"
+	ErrMsgWriteImportFailed      = "failed to write imported files"
+	ErrMsgUnsafeResourcePath     = "resource path is not local to the target directory"
+
+	ErrMsgNoAgentSubcommand      = "no agent subcommand specified"
+	ErrMsgUnknownAgentSubcommand = "unknown agent subcommand"
+	ErrMsgInvalidAgentArgs       = "invalid agent arguments"
+	ErrMsgNotAnAgent             = "document is not an agent (type: agent)"
+	ErrMsgInvalidColorMode       = "--color must be auto, always or never"
+)
+
+// Provider API settings for the run command
+const (
+	RunDefaultTimeout            = 120 * time.Second
+	RunDefaultAnthropicMaxTokens = 1024 // Anthropic requires max_tokens
+
+	RunOpenAIBaseURL    = "https://api.openai.com/v1"
+	RunMistralBaseURL   = "https://api.mistral.ai/v1"
+	RunAnthropicBaseURL = "https://api.anthropic.com/v1"
+	RunGeminiBaseURL    = "https://generativelanguage.googleapis.com/v1beta"
+
+	RunPathChatCompletions   = "/chat/completions"
+	RunPathAnthropicMessages = "/messages"
+	RunPathGeminiGenerate    = "/models/%s:generateContent"
+
+	RunHeaderContentType      = "Content-Type"
+	RunHeaderAuthorization    = "Authorization"
+	RunHeaderAnthropicKey     = "x-api-key"
+	RunHeaderAnthropicVersion = "anthropic-version"
+	RunHeaderGeminiKey        = "x-goog-api-key"
+	RunAnthropicAPIVersion    = "2023-06-01"
+	RunAuthBearerPrefix       = "Bearer "
+	RunContentTypeJSON        = "application/json"
+	RunStatusErrorFormat      = "status %d: %s"
+	RunEnvListSeparator       = " or "
+)
+
+// Environment variables for provider credentials and endpoints
+const (
+	EnvOpenAIAPIKey     = "OPENAI_API_KEY"
+	EnvOpenAIBaseURL    = "OPENAI_BASE_URL"
+	EnvAnthropicAPIKey  = "ANTHROPIC_API_KEY"
+	EnvAnthropicBaseURL = "ANTHROPIC_BASE_URL"
+	EnvGeminiAPIKey     = "GEMINI_API_KEY"
+	EnvGoogleAPIKey     = "GOOGLE_API_KEY"
+	EnvGeminiBaseURL    = "GEMINI_BASE_URL"
+	EnvMistralAPIKey    = "MISTRAL_API_KEY"
+	EnvMistralBaseURL   = "MISTRAL_BASE_URL"
+	EnvVLLMAPIKey       = "VLLM_API_KEY"
+	EnvVLLMBaseURL      = "VLLM_BASE_URL"
 )
 
 // Help text templates
@@ -93,7 +381,23 @@ Commands:
     render      Render a template with data
     validate    Validate a template without executing
     lint        Check template for style issues and best practices
+    fmt         Format templates canonically
+    convert     Convert a Jinja2 or Handlebars template to prompty syntax
     debug       Analyze template without executing (dry-run)
+    dryrun      Check a template against data without executing it
+    explain     Trace a template's execution or explain its inheritance chain
+    repl        Interactively edit data and re-render a template
+    lsp         Start the language server (stdio)
+    bench       Run performance workloads and compare with a baseline
+    test        Run the tests declared in prompt frontmatter
+    eval        Score a prompt's outputs over a dataset
+    compare     Compare two stored template versions over a dataset
+    replay      Re-execute a recorded execution to reproduce its output
+    compile     Compile an agent to messages or a provider payload
+    run         Compile an agent and send it to the LLM provider
+    store       Manage templates in a storage backend
+    skill       Export and import skill archives
+    agent       Inspect an agent's skills, tools and constraints
     version     Show version information
     help        Show help for a command
 
@@ -110,12 +414,297 @@ Options:
     -f, --data-file <file>  JSON data file
     -o, --output <file>     Output file (default: stdout)
     -q, --quiet             Suppress non-error output
+    --watch                 Re-render when the template or data file changes
+                            and print a diff of the output (Ctrl+C to stop)
+    --interval <duration>   Polling interval for --watch (default: 500ms)
 
 Examples:
     prompty render -t template.txt -d '{"name": "Alice"}'
     prompty render -t template.txt -f data.json
     cat template.txt | prompty render -t - -d '{"name": "Bob"}'
-    prompty render -t template.txt -f data.json -o output.txt`
+    prompty render -t template.txt -f data.json -o output.txt
+    prompty render -t template.txt -f data.json --watch`
+
+	HelpLSPUsage = `Start the Language Server Protocol server on stdin/stdout
+
+Usage:
+    prompty lsp [options]
+
+Features:
+    Diagnostics   validation and lint findings (as "prompty lint")
+    Hover         documentation for tag and attribute names
+    Completion    tag names, attribute names, template names for
+                  include/extends, and data paths for var/for/if/switch
+    Definition    go to the file of an included or extended template
+
+Template names are the file names (without extension) of .prompty and .tmpl
+files under the workspace root and --templates directories. Data paths come
+from the document's frontmatter inputs and sample data, the --data-file
+sample, and a sibling <template>.json file.
+
+Options:
+    --templates <dirs>      Extra template directories (comma-separated)
+    -f, --data-file <file>  Sample data JSON used for data path completion
+    --stdio                 Accepted for editor compatibility (always stdio)
+
+Editor setup (Neovim example):
+    vim.lsp.start({ name = "prompty", cmd = { "prompty", "lsp" } })`
+
+	HelpTestUsage = `Run the output contract tests declared in the tests section of
+prompt frontmatter
+
+Usage:
+    prompty test [options] [files...]
+
+Files may be glob patterns (e.g. "prompts/*.prompty"); use "-" for stdin.
+Each test renders the prompt with its inputs (and variant) and checks the
+output against its expectations: contains, not_contains, matches (regular
+expressions), json (JSON path to value) and error.
+
+Options:
+    -t, --template <file>   Template file (use "-" for stdin)
+    -F, --format <format>   Output format: text, json (default: text)
+    --json                  Shorthand for --format json
+    -v, --verbose           Print the output of passing tests too
+
+Exit Codes:
+    0    All tests passed
+    1    A document or its tests could not be parsed
+    3    At least one test failed
+
+Examples:
+    prompty test prompts/support.prompty
+    prompty test --json "prompts/*.prompty"`
+
+	HelpEvalUsage = `Score a prompt's outputs over a dataset
+
+Usage:
+    prompty eval [options] <prompt>
+
+Each dataset example is rendered with the prompt (or, with --provider, sent
+to the model) and the output is scored by the selected evaluators. Datasets
+are JSONL files ({"id", "input", "reference"} per line) or CSV files whose
+"id" and "reference" columns are special and other columns are inputs.
+Without evaluator options, outputs are checked against the reference.
+
+Options:
+    -t, --template <file>     Prompt file
+    --dataset <file>          Dataset file (.jsonl or .csv)
+    --dataset-name <name>     Dataset from the store (see --driver/--dsn)
+    --dataset-version <n>     Stored dataset version (default: latest)
+    --save-dataset            Save --dataset to the store, linked to the prompt
+    --driver <name>           Storage driver (default: filesystem, or $PROMPTY_STORE_DRIVER)
+    --dsn <dsn>               Storage connection string (default: $PROMPTY_STORE_DSN)
+    --sample <n>              Evaluate n randomly chosen examples
+    --split <train|test>      Evaluate one part of a seeded train/test split
+    --split-ratio <ratio>     Share of examples in the train split (default: 0.8)
+    --seed <n>                Seed for --sample and --split (default: 1)
+    --regex <pattern>         Output must match the regular expression
+    --json-valid              Output must be valid JSON
+    --min-length <n>          Output must have at least n characters
+    --max-length <n>          Output must have at most n characters
+    --flagged <words>         Output must not contain these comma-separated words
+    --reference               Output must contain the example's reference
+    -p, --provider <name>     Call the model (openai, anthropic, gemini, mistral
+                              or vllm) instead of only rendering the prompt
+    --model <model>           Override execution.model
+    --timeout <duration>      Timeout of each model call (default: 2m)
+    --concurrency <n>         Examples evaluated at once (default: 1)
+    --min-pass-rate <ratio>   Pass rate each evaluator must reach (default: 1)
+    -F, --format <format>     Output format: text, json (default: text)
+    --json                    Shorthand for --format json
+
+Exit Codes:
+    0  Every evaluator reached the minimum pass rate
+    1  The prompt could not be evaluated
+    2  Invalid arguments
+    3  An evaluator is below the minimum pass rate or failed on an example
+    4  The prompt or dataset could not be read
+
+Examples:
+    prompty eval --dataset capitals.jsonl --reference prompts/capital.prompty
+    prompty eval --dataset cases.csv --json-valid --max-length 500 --sample 50 prompt.prompty
+    prompty eval --dsn ./store --dataset-name capitals --split test -p openai --min-pass-rate 0.9 capital.prompty`
+
+	HelpCompareUsage = `Compare two versions of a stored template over a dataset
+
+Usage:
+    prompty compare [options] --base <version> <name>
+
+Both versions are evaluated over the dataset (see "prompty help eval" for
+the dataset and evaluator options) and the candidate is checked against the
+thresholds. The report lists score changes per evaluator and every example
+whose output or scores changed.
+
+Options:
+    -n, --name <name>             Template name
+    --base <version>              Baseline version
+    --candidate <version>         Candidate version (default: latest)
+    --driver <name>               Storage driver (default: filesystem, or $PROMPTY_STORE_DRIVER)
+    --dsn <dsn>                   Storage connection string (default: $PROMPTY_STORE_DSN)
+    --dataset <file>              Dataset file (.jsonl or .csv)
+    --dataset-name <name>         Dataset from the store
+    --max-mean-drop <delta>       Allowed drop of an evaluator's mean score (default: 0)
+    --max-pass-rate-drop <delta>  Allowed drop of an evaluator's pass rate (default: 0)
+    --min-pass-rate <ratio>       Pass rate the candidate must reach (default: 0)
+    -p, --provider <name>         Send both versions to the model instead of rendering
+    -F, --format <format>         Output format: text, json, junit (default: text)
+    --json                        Shorthand for --format json
+    -o, --output <file>           Output file (default: stdout)
+
+Exit Codes:
+    0  The candidate passed all thresholds
+    1  The versions could not be evaluated
+    2  Invalid arguments
+    3  The candidate failed a threshold
+    4  The dataset could not be read
+
+Examples:
+    prompty compare --dsn ./store --dataset capitals.jsonl --base 3 capital
+    prompty compare --dsn ./store --dataset-name capitals --base 3 --candidate 4 \
+        --max-mean-drop 0.05 -F junit -o compare.xml capital`
+
+	HelpReplayUsage = `Re-execute a recorded execution to reproduce its output
+
+Usage:
+    prompty replay [options] <recording>
+
+The recording is the JSON written by prompty.SaveRecording from an
+ExecutionRecorder. The template runs again with the recorded data, and
+every resolver call returns its recorded result, so custom resolvers,
+includes and environment variables behave as they did when recorded. The
+replayed output is written; the command reports on stderr whether it
+matches the recorded output.
+
+Options:
+    --live <tags>           Run these built-in tags instead of replaying
+                            their recorded results (comma-separated)
+    -F, --format <format>   Output format: text, json (default: text)
+    --json                  Shorthand for --format json
+    -o, --output <file>     Output file (default: stdout)
+
+Exit Codes:
+    0  The replay matches the recording
+    1  The recording could not be replayed
+    2  Invalid arguments
+    3  The replay differs from the recording
+    4  The recording could not be read
+
+Examples:
+    prompty replay bad-render.json
+    prompty replay --json bad-render.json
+    prompty replay --live prompty.env bad-render.json`
+
+	HelpBenchUsage = `Run standardized performance workloads against this engine
+
+Usage:
+    prompty bench [options] [workloads...]
+
+Workloads (default: all):
+    small-vars      Execute a parsed template with 20 variables
+    loop-heavy      Execute a loop with a conditional over 500 items
+    deep-include    Execute a chain of 10 nested includes
+    agent-compile   Parse and compile an agent with a skill catalog to messages
+
+Options:
+    --benchtime <duration>  Minimum measured time per workload (default: 1s)
+    --json                  JSON output (the format read by --compare)
+    -o, --output <file>     Output file (default: stdout)
+    --compare <file>        Compare with a baseline JSON report and fail on
+                            regressions beyond the budgets
+    --budget <percent>      Allowed ns/op increase (default: 15)
+    --alloc-budget <percent> Allowed allocs/op increase (default: 10)
+    -l, --list              List workloads and exit
+
+Exit Codes:
+    0  Success (no regression beyond budget)
+    1  A workload failed
+    2  Invalid arguments
+    3  Regression beyond budget
+    4  Baseline could not be read
+
+Examples:
+    prompty bench
+    prompty bench --json -o baseline.json
+    prompty bench --compare baseline.json --budget 10
+    prompty bench --benchtime 3s loop-heavy deep-include`
+
+	HelpCompileUsage = `Compile an agent to messages or a provider payload
+
+Usage:
+    prompty compile [options] <agent.md>
+
+Without --provider the compiled messages, execution config, tools and
+constraints are printed as JSON. With --provider the complete request body
+for that provider is printed (messages, parameters, tools).
+
+Options:
+    -t, --template <file>   Agent file (use "-" for stdin)
+    -d, --data <json>       Input data as JSON string
+    -f, --data-file <file>  Input data JSON file
+    -p, --provider <name>   openai, openai_responses, azure, anthropic, bedrock,
+                            gemini, google, vertex, mistral, vllm, cohere,
+                            ollama, llamacpp, openrouter, litellm
+    --model <model>         Override execution.model
+    --skill <slug>          Activate a skill instead of compiling the agent
+    -F, --format <format>   Output format without --provider: json, text (default: json)
+    -o, --output <file>     Output file (default: stdout)
+    --driver, --dsn         Resolve skill references from a storage backend
+                            (see "prompty help store")
+
+Examples:
+    prompty compile agent.md -f data.json
+    prompty compile agent.md -f data.json --provider anthropic
+    prompty compile agent.md --skill search -F text`
+
+	HelpRunUsage = `Compile an agent and send it to the LLM provider
+
+Usage:
+    prompty run [options] <agent.md>
+
+The provider is --provider or the agent's execution.provider (inferred from
+the model name when unset). The reply text is printed; use --raw for the full
+JSON response. Streaming is disabled for run. Failed requests are retried
+and fall back to other models as configured in execution.fallback.
+
+Options:
+    Same as "prompty compile", plus:
+    --raw                   Print the raw JSON response
+    --timeout <duration>    Request timeout (default: 2m0s)
+
+Environment:
+    OPENAI_API_KEY, ANTHROPIC_API_KEY, GEMINI_API_KEY (or GOOGLE_API_KEY),
+    MISTRAL_API_KEY, VLLM_API_KEY (optional)
+    OPENAI_BASE_URL, ANTHROPIC_BASE_URL, GEMINI_BASE_URL, MISTRAL_BASE_URL,
+    VLLM_BASE_URL (required for vllm) override the API endpoints
+
+Exit Codes:
+    0  Success
+    1  Provider request failed
+    2  Invalid arguments or unsupported provider
+    3  Agent failed to compile
+    4  Input file could not be read
+
+Examples:
+    prompty run agent.md -d '{"query": "hello"}'
+    prompty run agent.md -f data.json --provider anthropic --model claude-sonnet-4-5
+    VLLM_BASE_URL=http://localhost:8000/v1 prompty run agent.md --provider vllm`
+
+	HelpReplUsage = `Interactively edit data and re-render a template
+
+Usage:
+    prompty repl [options] <template>
+
+Options:
+    -t, --template <file>   Template file
+    -d, --data <json>       Initial JSON data string
+    -f, --data-file <file>  Initial JSON data file
+
+` + ReplTextHelp + `
+
+Examples:
+    prompty repl prompt.prompty
+    prompty repl -t prompt.prompty -f data.json`
 
 	HelpValidateUsage = `Validate a template without executing
 
@@ -149,17 +738,27 @@ Commands:
     render      Show help for render command
     validate    Show help for validate command
     lint        Show help for lint command
+    fmt         Show help for fmt command
     debug       Show help for debug command
+    repl        Show help for repl command
+    lsp         Show help for lsp command
+    compile     Show help for compile command
+    run         Show help for run command
+    store       Show help for store command
     version     Show help for version command`
 
 	HelpLintUsage = `Check template for style issues and best practices
 
 Usage:
-    prompty lint [options]
+    prompty lint [options] [files...]
+
+Files may be glob patterns (e.g. "prompts/*.prompty"); use "-" for stdin.
+When several files are linted, issues are reported as file:line:column.
 
 Options:
     -t, --template <file>   Template file (use "-" for stdin)
     -F, --format <format>   Output format: text, json (default: text)
+    --json                  Shorthand for --format json
     -r, --rules <rules>     Comma-separated list of rules to check
     -i, --ignore <rules>    Comma-separated list of rules to ignore
     --strict                Treat warnings as errors
@@ -167,17 +766,82 @@ Options:
 Lint Rules:
     VAR001    Variable name uses non-standard casing
     VAR002    Variable without default might be missing
+    VAR003    Default value looks like a placeholder
     TAG001    Unknown or unregistered tag
+    TAG002    Deprecated tag
     LOOP001   Loop without limit attribute
     LOOP002   Deeply nested loops (> 2 levels)
+    LOOP003   Loop variable shadows an enclosing loop variable
     EXPR001   Complex expression (> 3 operators)
     INC001    Include references non-existent template
+    BLK001    Block in a child template that is never used
+    COND001   Deeply nested conditionals (> 3 levels)
+    TEXT001   Oversized inline text (> 4096 bytes)
+    META001   Frontmatter or input without description
+
+Exit Codes:
+    0  No errors (warnings allowed unless --strict)
+    3  Errors found, or warnings with --strict
+    4  Input file could not be read or a pattern matched nothing
 
 Examples:
     prompty lint -t template.txt
     prompty lint -t template.txt --strict
     prompty lint -t template.txt --ignore VAR002,LOOP001
-    prompty lint -t template.txt -F json`
+    prompty lint -t template.txt -F json
+    prompty lint --json 'prompts/*.prompty'`
+
+	HelpFmtUsage = `Format templates canonically
+
+Usage:
+    prompty fmt [options] <files...>
+
+Files may be glob patterns (e.g. "prompts/*.prompty"); use "-" for stdin.
+Without options the formatted templates are written to stdout.
+
+Options:
+    -w, --write             Write formatted output back to the files
+    -l, --list              List files whose formatting differs
+    --check                 List unformatted files and exit with code 3 if any
+    --json                  Report results as JSON
+
+Exit Codes:
+    0  Success (and, with --check, all files formatted)
+    3  A template failed to parse, or --check found unformatted files
+    4  Input file could not be read or a pattern matched nothing
+
+Examples:
+    prompty fmt template.prompty
+    prompty fmt -w 'prompts/*.prompty'
+    prompty fmt --check prompts/*.prompty
+    cat template.prompty | prompty fmt -`
+
+	HelpConvertUsage = `Convert a Jinja2 or Handlebars template to prompty syntax
+
+Usage:
+    prompty convert [options] <file>
+
+Variables, conditionals, loops, includes, comments and common filters are
+converted. Constructs without a prompty equivalent are kept as is and
+reported on stderr as "file:line:column: message". Use "-" for stdin.
+
+Options:
+    --from <dialect>        Source dialect: jinja2, handlebars or mustache
+                            (inferred from .j2, .jinja, .jinja2, .hbs,
+                            .handlebars and .mustache extensions)
+    -o, --output <file>     Output file (default: stdout)
+    --json                  Print the converted template and warnings as JSON
+
+Exit Codes:
+    0  Success (warnings do not fail the conversion)
+    2  Invalid arguments or unknown dialect
+    3  The template could not be converted, e.g. an unclosed block
+    4  Input file could not be read
+
+Examples:
+    prompty convert greeting.j2 -o greeting.prompty
+    prompty convert --from handlebars card.txt
+    cat prompt.jinja | prompty convert --from jinja2 -`
 
 	HelpDebugUsage = `Analyze template without executing (dry-run)
 
@@ -204,6 +868,191 @@ Examples:
     prompty debug -t template.txt -f data.json
     prompty debug -t template.txt -f data.json --trace
     prompty debug -t template.txt -f data.json -F json`
+
+	HelpDryRunUsage = `Check a template against data without executing it
+
+Usage:
+    prompty dryrun [options] <file>
+
+Reports each variable reference as found in the data, covered by its default
+or missing (with suggestions), and the resolvers, includes, loops and
+functions the template uses, followed by a preview of the output with
+placeholders for dynamic content. Included templates are the files (named
+without extension) with a .prompty or .tmpl extension under the template's
+directory and the --templates directories.
+
+Options:
+    -t, --template <file>   Template file (use "-" for stdin)
+    -d, --data <data>       JSON data file, or an inline JSON object
+    -f, --data-file <file>  JSON data file
+    --templates <dirs>      Extra template directories (comma-separated)
+    -F, --format <format>   Output format: text, json (default: text)
+    --json                  Shorthand for --format json
+    --color <mode>          Color output: auto, always, never (default: auto;
+                            auto disables color when NO_COLOR is set or
+                            stdout is not a terminal)
+
+Exit Codes:
+    0    No missing variables or structural errors
+    2    Invalid arguments
+    3    Missing variables (without defaults) or structural errors
+    4    Template or data could not be read
+
+Examples:
+    prompty dryrun greeting.prompty --data data.json
+    prompty dryrun greeting.prompty -d '{"user": {"name": "Alice"}}'
+    prompty dryrun --json prompts/support.prompty --data sample.json`
+
+	HelpExplainUsage = `Explain how a template is executed or put together
+
+Usage:
+    prompty explain [options] <file>
+    prompty explain --inheritance [options] <file>
+
+Without a mode, the template is executed with the data and explain shows the
+execution trace (every node with its position, duration, cache status and
+error), the status of each variable reference and the output.
+
+Modes:
+    --inheritance           Show the chain of extended templates, the blocks
+                            each level defines or overrides, and where
+                            prompty.parent is called
+
+Extended and included templates are the files (named without extension) with
+a .prompty or .tmpl extension under the template's directory and the
+--templates directories.
+
+Options:
+    -t, --template <file>   Template file (use "-" for stdin)
+    -d, --data <data>       JSON data file, or an inline JSON object
+    -f, --data-file <file>  JSON data file
+    --templates <dirs>      Extra template directories (comma-separated)
+    -F, --format <format>   Output format: text, json (default: text)
+    --json                  Shorthand for --format json
+    --color <mode>          Color output: auto, always, never (default: auto)
+
+Exit Codes:
+    0    Success
+    2    Invalid arguments
+    3    Missing variables (without defaults), a failed execution, or an
+         unresolvable inheritance chain
+    4    Template or data could not be read
+
+Examples:
+    prompty explain greeting.prompty --data data.json
+    prompty explain --json greeting.prompty -d '{"name": "Alice"}'
+    prompty explain --inheritance -t pages/checkout.prompty
+    prompty explain --inheritance -t page.prompty --templates layouts -F json`
+
+	HelpSkillUsage = `Export and import skill archives
+
+Usage:
+    prompty skill export [options] <file>
+    prompty skill import [options] <file>
+
+An archive holds the document as SKILL.md, AGENT.md or PROMPT.md (by its
+type) and its resource files. Import also accepts single documents
+(.md, .json, and Microsoft .prompty or LangChain files, which are converted;
+conversion warnings go to stderr).
+
+Export options:
+    --resources <dir>       Directory of resource files to include (paths are
+                            kept relative to it; hidden files are skipped)
+    -o, --out <file>        Archive file (default: <name>.zip; "-" for stdout)
+
+Import options:
+    --to <dir|storage>      Write the document and resources into a directory,
+                            or save the document in the storage backend
+    -n, --name <name>       Stored template name (default: the document name)
+    --tags <tags>           Tags for the stored template (comma-separated)
+    --status <status>       Deployment status for the stored template
+    --driver <name>         Storage driver (default: filesystem, or $PROMPTY_STORE_DRIVER)
+    --dsn <dsn>             Storage connection string (default: $PROMPTY_STORE_DSN)
+
+Exit Codes:
+    0    Success
+    2    Invalid arguments
+    3    The document is invalid or could not be imported
+    4    Input files could not be read
+
+Examples:
+    prompty skill export research/SKILL.md --resources research --out research.zip
+    prompty skill import research.zip --to ./skills/research
+    prompty skill import research.zip --to storage --dsn ./store`
+
+	HelpAgentUsage = `Inspect an agent
+
+Usage:
+    prompty agent inspect [options] <file>
+
+Shows the agent's skills, tools (functions and MCP servers) and constraints,
+checks its execution settings against the provider, and compiles it with its
+sample data (or --data) to report the messages and compile warnings.
+
+Options:
+    -d, --data <data>       JSON data file, or an inline JSON object
+                            (default: the document's sample data)
+    -p, --provider <name>   Provider to check compatibility against
+                            (default: the execution config's provider)
+    --driver <name>         Storage driver for skill references
+    --dsn <dsn>             Storage connection string; resolves skill
+                            references from the store
+    -F, --format <format>   Output format: text, json (default: text)
+    --json                  Shorthand for --format json
+    --color <mode>          Color output: auto, always, never (default: auto)
+
+Exit Codes:
+    0    Success
+    2    Invalid arguments
+    3    Not an agent, compilation failed, or an execution setting the
+         provider does not support changes the output
+    4    Input files could not be read
+
+Examples:
+    prompty agent inspect agents/support.md
+    prompty agent inspect --provider anthropic --json agents/support.md
+    prompty agent inspect agents/support.md --dsn ./store`
+
+	HelpStoreUsage = `Manage templates in a storage backend
+
+Usage:
+    prompty store <subcommand> [options] [args]
+
+Subcommands:
+    push <files...>                 Save files as new template versions
+    pull <name>                     Print a template's source
+    list                            List templates (latest versions)
+    versions <name>                 Show version history
+    rollback <name> <version>       Create a new draft version from an old one
+    delete <name>                   Delete a template (or one version)
+    diff <name> <old> [new]         Compare two versions (new defaults to latest)
+
+Options:
+    --driver <name>         Storage driver: filesystem, postgres, http, memory
+                            (default: filesystem, or $PROMPTY_STORE_DRIVER)
+    --dsn <dsn>             Driver connection string: directory, database URL
+                            or server base URL (default: $PROMPTY_STORE_DSN)
+    -n, --name <name>       push: template name (default: file name without extension)
+    --tags <tags>           push/list: comma-separated tags
+    --status <status>       push/list: deployment status (draft, active, ...)
+    --version <n>           pull/delete: specific version
+    -o, --output <file>     pull: output file (default: stdout)
+    --prefix <prefix>       list: name prefix filter
+    --json                  list/versions: JSON output
+
+Exit Codes:
+    0  Success
+    1  Storage operation failed
+    2  Invalid arguments
+    3  Template failed validation on push
+    4  Input file could not be read
+
+Examples:
+    prompty store push --dsn ./store 'prompts/*.prompty'
+    prompty store pull --dsn ./store greeting --version 2
+    prompty store list --driver postgres --dsn "$DATABASE_URL" --json
+    prompty store rollback --driver http --dsn https://prompts.example.com greeting 3
+    prompty store diff --dsn ./store greeting 1 3`
 )
 
 // Version output format templates
@@ -220,6 +1069,63 @@ const (
 	ValidationTextErrorSummary = "%d error(s), %d warning(s)"
 )
 
+// Test output format templates
+const (
+	TestTextFileHeader      = "%s"
+	TestTextNoTests         = "  no tests"
+	TestTextResultFormat    = "  %s %s (%s)"
+	TestTextFailureFormat   = "      %s"
+	TestTextOutputFormat    = "      output: %q"
+	TestTextSummary         = "%d passed, %d failed"
+	TestTextFileErrorFormat = "%s (%s): %v\n"
+	TestStatusPass          = "PASS"
+	TestStatusFail          = "FAIL"
+)
+
+// Eval output format templates
+const (
+	EvalTextTitle        = "%s: %d example(s) from %s"
+	EvalTextSaved        = "saved dataset %s v%d"
+	EvalTextHeader       = "EVALUATOR\tCOUNT\tERRORS\tMEAN\tPASS RATE"
+	EvalTextRow          = "%s\t%d\t%d\t%.3f\t%.1f%%"
+	EvalTextRowFailure   = "  %s %s %s: %s"
+	EvalTextRowError     = "  %s: %s"
+	EvalTextPassed       = "All evaluators met the minimum pass rate (%.0f%%)"
+	EvalTextFailed       = "%d evaluator(s) below the minimum pass rate (%.0f%%) or with errors"
+	EvalTextExampleIndex = "#%d"
+	EvalTabPadding       = 2
+	EvalPercent          = 100
+)
+
+// Compare output format templates
+const (
+	CompareTextTitle        = "%s v%d -> v%d on %s (%d example(s))"
+	CompareTextHeader       = "EVALUATOR\tBASE MEAN\tMEAN\tDELTA\tBASE PASS\tPASS\tDELTA"
+	CompareTextRow          = "%s\t%.3f\t%.3f\t%+.3f\t%.1f%%\t%.1f%%\t%+.1f%%"
+	CompareTextChanged      = "Changed examples:"
+	CompareTextRowOutput    = "  %s: %q -> %q"
+	CompareTextRowScore     = "      %s %.3f -> %.3f"
+	CompareTextFailure      = "FAIL %s"
+	CompareTextPassed       = "Candidate passed all thresholds"
+	CompareTextFailed       = "Candidate failed %d threshold(s)"
+	CompareJUnitSuiteName   = "%s v%d -> v%d (%s)"
+	CompareJUnitTime        = "%.3f"
+	CompareJUnitSeparator   = "; "
+	CompareJUnitRegressions = "regressed examples: %s"
+	CompareTabPadding       = 2
+)
+
+// Replay output format templates
+const (
+	ReplayTextMatched   = "Replay matches the recording"
+	ReplayTextDiffers   = "Replay differs from the recording"
+	ReplayTextOutput    = "  recorded: %q\n  replayed: %q\n"
+	ReplayTextError     = "  recorded error: %q\n  replay error:   %q\n"
+	ReplayTextUnmatched = "  no recorded result for %s %s\n"
+	ReplayTextAttribute = "%s=%q"
+	ReplayAttrSeparator = " "
+)
+
 // Lint rule IDs
 const (
 	LintRuleVAR001  = "VAR001"  // Variable name non-standard casing
@@ -233,10 +1139,11 @@ const (
 
 // Lint output format templates
 const (
-	LintTextNoIssues     = "No lint issues found"
-	LintTextIssueHeader  = "Lint issues:"
-	LintTextIssueFormat  = "  [%s] %s: %s (line %d, column %d)"
-	LintTextIssueSummary = "%d issue(s) found"
+	LintTextNoIssues        = "No lint issues found"
+	LintTextIssueHeader     = "Lint issues:"
+	LintTextIssueFormat     = "  [%s] %s: %s (line %d, column %d)"
+	LintTextFileIssueFormat = "  %s:%d:%d: [%s] %s: %s"
+	LintTextIssueSummary    = "%d issue(s) found"
 )
 
 // Lint rule descriptions
@@ -291,6 +1198,68 @@ const (
 	LintMaxExpressionOperators = 3
 )
 
+// Explain output format templates
+const (
+	ExplainTextChain         = "Inheritance chain: %s"
+	ExplainTextLevelExtends  = "%s (extends %s)"
+	ExplainTextLevelBase     = "%s (base)"
+	ExplainTextBlock         = "  %-20s [line %d]"
+	ExplainTextNoBlocks      = "  (no blocks)"
+	ExplainTextDetailPrefix  = "  "
+	ExplainTextDetailSep     = ", "
+	ExplainTextOverrides     = "overrides"
+	ExplainTextOverridden    = "overridden"
+	ExplainTextParentCalls   = "prompty.parent at line %s"
+	ExplainStdinTemplateName = "(stdin)"
+	ExplainTextExecution     = "Execution of %s (%s)"
+	ExplainTextTrace         = "Trace (%d nodes):"
+	ExplainTextIndent        = "  "
+	ExplainTextQuoted        = `"%s"`
+	ExplainTextNodePosition  = "[line %d:%d] %s"
+	ExplainTextNodeCache     = "cache %s"
+	ExplainTextOutput        = "Output:"
+	ExplainTextFailed        = "Execution failed: %s"
+	ExplainTextSummary       = "%d missing variable(s), %d tag error(s)"
+)
+
+// Dry run output format templates
+const (
+	DryRunTextHeader        = "Dry run of %s: %s"
+	DryRunTextValid         = "valid"
+	DryRunTextInvalid       = "invalid"
+	DryRunTextVariables     = "Variables (%d):"
+	DryRunTextResolvers     = "Resolvers (%d):"
+	DryRunTextIncludes      = "Includes (%d):"
+	DryRunTextLoops         = "Loops (%d):"
+	DryRunTextFunctions     = "Functions (%d):"
+	DryRunTextErrors        = "Errors (%d):"
+	DryRunTextWarnings      = "Warnings (%d):"
+	DryRunTextUnused        = "Unused data (%d):"
+	DryRunTextOutput        = "Output preview:"
+	DryRunTextItem          = "  %s %-20s [line %d:%d]"
+	DryRunTextListItem      = "  %s %s"
+	DryRunTextExpression    = " (in %s)"
+	DryRunTextDefault       = "default %q"
+	DryRunTextMissing       = "missing"
+	DryRunTextNotRegistered = "not registered"
+	DryRunTextNotFound      = "not found"
+	DryRunTextNotInData     = "not in data"
+	DryRunTextSuggestions   = ", did you mean %s?"
+	DryRunTextSummary       = "%d missing variable(s), %d error(s), %d warning(s)"
+)
+
+// Status markers and variable statuses for dryrun and explain output
+const (
+	MarkOK     = "✓"
+	MarkWarn   = "!"
+	MarkFail   = "✗"
+	MarkUnused = "-"
+
+	VarStatusFound   = "found"
+	VarStatusDefault = "default"
+	VarStatusMissing = "missing"
+)
+
 // Debug command thresholds
 const (
 	DebugMaxLevenshteinDistance = 2
@@ -304,4 +1273,176 @@ const (
 	FmtErrorWithDetail = "%s: %s\n"
 	FmtErrorWithCause  = "%s: %v\n"
 	FmtNewline         = "\n"
+	FmtDetail          = "%s: %s"
+	FmtFileErrorFormat = "%s: %s: %v"
+	FmtFilePosition    = "%s:%d:%d: %s\n"
+)
+
+// Watch output format templates
+const (
+	WatchTextWatching  = "--- watching %s (Ctrl+C to stop)"
+	WatchTextChanged   = "--- %s %s changed:"
+	WatchTextUnchanged = "--- %s %s changed, output unchanged"
+	DiffPrefixAdded    = "+ "
+	DiffPrefixRemoved  = "- "
+	DiffMaxCells       = 4_000_000 // LCS table limit before falling back to full replacement
+)
+
+// REPL commands and output
+const (
+	ReplCmdSet    = "set"
+	ReplCmdUnset  = "unset"
+	ReplCmdData   = "data"
+	ReplCmdLoad   = "load"
+	ReplCmdReload = "reload"
+	ReplCmdRender = "render"
+	ReplCmdHelp   = "help"
+	ReplCmdQuit   = "quit"
+	ReplCmdExit   = "exit"
+
+	ReplPrompt        = "prompty> "
+	ReplAssign        = "="
+	ReplPathSeparator = "."
+	ReplTextWelcome   = "prompty repl - type \"help\" for commands"
+	ReplTextUnchanged = "(output unchanged)"
+	ReplTextHelp      = `Commands:
+    set <path>=<value>      Set a data value (JSON or plain text; dotted paths nest)
+    unset <path>            Remove a data value
+    data                    Show the current data as JSON
+    load <file>             Replace the data with a JSON file
+    reload                  Re-read the template file
+    render                  Print the full output
+    help                    Show this help
+    quit, exit              Leave the REPL
+
+After set, unset, load and reload the output diff is printed.`
+)
+
+// Language server (JSON-RPC over stdio)
+const (
+	LSPHeaderContentLength = "Content-Length"
+	LSPHeaderFormat        = "Content-Length: %d\r\n\r\n"
+	LSPHeaderSeparator     = ":"
+	LSPJSONRPCVersion      = "2.0"
+	LSPServerName          = "prompty"
+	LSPMarkupKindMarkdown  = "markdown"
+	LSPFileScheme          = "file"
+	LSPHiddenPrefix        = "."
+
+	LSPMethodInitialize     = "initialize"
+	LSPMethodShutdown       = "shutdown"
+	LSPMethodExit           = "exit"
+	LSPMethodDidOpen        = "textDocument/didOpen"
+	LSPMethodDidChange      = "textDocument/didChange"
+	LSPMethodDidClose       = "textDocument/didClose"
+	LSPMethodHover          = "textDocument/hover"
+	LSPMethodCompletion     = "textDocument/completion"
+	LSPMethodDefinition     = "textDocument/definition"
+	LSPMethodPublishDiags   = "textDocument/publishDiagnostics"
+	LSPTextDocumentSyncFull = 1
+
+	// JSON-RPC error codes
+	LSPErrorCodeParse          = -32700
+	LSPErrorCodeMethodNotFound = -32601
+	LSPErrorCodeInvalidParams  = -32602
+
+	// Diagnostic severities
+	LSPSeverityError       = 1
+	LSPSeverityWarning     = 2
+	LSPSeverityInformation = 3
+
+	// Completion item kinds
+	LSPCompletionKindProperty = 10
+	LSPCompletionKindVariable = 6
+	LSPCompletionKindKeyword  = 14
+	LSPCompletionKindFile     = 17
+
+	LSPDocAttrFormat     = "`%s`%s — %s"
+	LSPDocAttrRequired   = " (required)"
+	LSPDocTagFormat      = "**%s**\n\n%s"
+	LSPDocTemplateFormat = "Template `%s`"
+	LSPAttrInsertFormat  = `%s="`
+	LSPPathSeparator     = "."
+	LSPTagNamePrefix     = "prompty."
+	LSPSampleDataSuffix  = ".json"
+)
+
+// LSPTemplateExtensions lists the file extensions indexed as templates
+var LSPTemplateExtensions = []string{".prompty", ".tmpl"}
+
+// LSPCompletionTriggers are the characters that trigger completion
+var LSPCompletionTriggers = []string{"~", ".", " ", `"`}
+
+// Compile output format templates
+const (
+	CompileTextMessageHeader = "=== %s ==="
+)
+
+// Skill output format templates
+const (
+	SkillTextExported         = "exported %s with %d resource(s) to %s"
+	SkillTextImported         = "imported %s with %d resource(s) to %s"
+	SkillTextResourcesSkipped = "warning: %d resource(s) not saved; storage holds only the document (use --to <dir>)"
+	SkillTextConversionIssue  = "%s: %s\n"
+)
+
+// Agent inspect output format templates
+const (
+	AgentTextHeader         = "Agent: %s"
+	AgentTextDescription    = "  %s"
+	AgentTextSkills         = "Skills (%d):"
+	AgentTextSkillRef       = "  %s @%s"
+	AgentTextSkillInline    = "  %s (inline)"
+	AgentTextInjection      = "  injection: %s"
+	AgentTextTools          = "Tools (%d):"
+	AgentTextFunction       = "  %s (function)"
+	AgentTextMCPServer      = "  %s (mcp: %s)"
+	AgentTextMCPTools       = "  tools: %s"
+	AgentTextToolChoice     = "  tool choice: %s"
+	AgentTextConstraints    = "Constraints:"
+	AgentTextBehavioral     = "  behavioral: %s"
+	AgentTextSafety         = "  safety: %s"
+	AgentTextMaxTurns       = "  max turns: %d"
+	AgentTextMaxTokens      = "  max tokens per turn: %d"
+	AgentTextAllowedDomains = "  allowed domains: %s"
+	AgentTextBlockedDomains = "  blocked domains: %s"
+	AgentTextCompatibility  = "Compatibility:"
+	AgentTextDeclared       = "  declared: %s"
+	AgentTextProvider       = "  %s %s"
+	AgentTextCompatible     = "    all execution settings are supported"
+	AgentTextNoProvider     = "  no provider configured (use --provider)"
+	AgentTextCompilation    = "Compilation:"
+	AgentTextCompiled       = "  %s %d message(s) compiled with sample data"
+	AgentTextCompileFailed  = "  %s %v"
+	AgentTextWarning        = "  %s %s"
+	AgentTextNone           = "  (none)"
+	AgentTextListSep        = ", "
+)
+
+// Bench output format templates
+const (
+	BenchTextListFormat   = "%-15s %s"
+	BenchTextHeader       = "WORKLOAD\tITERATIONS\tNS/OP\tB/OP\tALLOCS/OP"
+	BenchTextRow          = "%s\t%d\t%.1f\t%d\t%d"
+	BenchCompareHeader    = "WORKLOAD\tBASE NS/OP\tNS/OP\tDELTA\tBASE ALLOCS/OP\tALLOCS/OP\tDELTA"
+	BenchCompareRow       = "%s\t%.1f\t%.1f\t%s\t%d\t%d\t%s"
+	BenchCompareChange    = "%+.1f%%"
+	BenchRegressionMarker = " !"
+	BenchTextMissing      = "%s: no baseline result"
+	BenchTextWithinBudget = "No regressions beyond budget (ns/op +%.0f%%, allocs/op +%.0f%%)"
+	BenchTextRegressed    = "%d workload(s) regressed beyond budget (ns/op +%.0f%%, allocs/op +%.0f%%)"
+	BenchTabPadding       = 2
+	BenchPercent          = 100
+)
+
+// Store output format templates
+const (
+	StoreTextPushed         = "pushed %s v%d"
+	StoreTextNoTemplates    = "No templates found"
+	StoreTextListFormat     = "%-30s v%-4d %-10s %s"
+	StoreTextVersionFormat  = "v%-4d %-10s %s %s%s"
+	StoreTextCurrentMarker  = " (current)"
+	StoreTextRolledBack     = "rolled back %s to v%d as v%d"
+	StoreTextDeleted        = "deleted %s"
+	StoreTextDeletedVersion = "deleted %s v%d"
 )
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// convertDialectExtensions maps file extensions to the dialect they imply
+var convertDialectExtensions = map[string]string{
+	".j2":         prompty.DialectJinja2,
+	".jinja":      prompty.DialectJinja2,
+	".jinja2":     prompty.DialectJinja2,
+	".hbs":        prompty.DialectHandlebars,
+	".handlebars": prompty.DialectHandlebars,
+	".mustache":   prompty.DialectMustache,
+}
+
+// convertConfig holds parsed convert command configuration
+type convertConfig struct {
+	path       string
+	dialect    string
+	outputPath string
+	jsonOutput bool
+}
+
+// convertWarning is a conversion warning in JSON output
+type convertWarning struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// convertOutput represents JSON output for convert
+type convertOutput struct {
+	Dialect  string           `json:"dialect"`
+	Template string           `json:"template"`
+	Warnings []convertWarning `json:"warnings"`
+}
+
+func runConvert(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parseConvertFlags(args)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgConvertFailed, err)
+		return ExitCodeUsageError
+	}
+
+	source, err := readInput(cfg.path, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReadFileFailed, err)
+		return ExitCodeInputError
+	}
+
+	converted, report, err := prompty.ConvertFrom(cfg.dialect, string(source))
+	if err != nil {
+		fmt.Fprintf(stderr, FmtFileErrorFormat+FmtNewline, cfg.path, ErrMsgConvertFailed, err)
+		return ExitCodeValidationError
+	}
+
+	if cfg.jsonOutput {
+		output := convertOutput{
+			Dialect:  cfg.dialect,
+			Template: converted,
+			Warnings: make([]convertWarning, 0, len(report.Warnings)),
+		}
+		for _, w := range report.Warnings {
+			output.Warnings = append(output.Warnings, convertWarning{
+				Line:    w.Position.Line,
+				Column:  w.Position.Column,
+				Message: w.Message,
+			})
+		}
+		jsonBytes, _ := json.MarshalIndent(output, "", "  ")
+		fmt.Fprintln(stdout, string(jsonBytes))
+		return ExitCodeSuccess
+	}
+
+	for _, w := range report.Warnings {
+		fmt.Fprintf(stderr, FmtFilePosition, cfg.path, w.Position.Line, w.Position.Column, w.Message)
+	}
+	if err := writeOutput(cfg.outputPath, []byte(converted), stdout); err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgWriteOutputFailed, err)
+		return ExitCodeError
+	}
+	return ExitCodeSuccess
+}
+
+func parseConvertFlags(args []string) (*convertConfig, error) {
+	fs := flag.NewFlagSet(CmdNameConvert, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	cfg := &convertConfig{}
+
+	fs.StringVar(&cfg.dialect, FlagFrom, "", "")
+	fs.StringVar(&cfg.outputPath, FlagOutput, FlagDefaultOutput, "")
+	fs.StringVar(&cfg.outputPath, FlagOutputShort, FlagDefaultOutput, "")
+	fs.BoolVar(&cfg.jsonOutput, FlagJSON, false, "")
+
+	paths, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) != 1 {
+		return nil, errors.New(ErrMsgConvertArgs)
+	}
+	cfg.path = paths[0]
+
+	if cfg.dialect == "" {
+		cfg.dialect = convertDialectExtensions[strings.ToLower(filepath.Ext(cfg.path))]
+	}
+	switch strings.ToLower(cfg.dialect) {
+	case prompty.DialectJinja2, prompty.DialectHandlebars, prompty.DialectMustache:
+	case "":
+		return nil, errors.New(ErrMsgConvertDialectRequired)
+	default:
+		return nil, fmt.Errorf(FmtDetail, prompty.ErrMsgConvertUnknownDialect, cfg.dialect)
+	}
+
+	return cfg, nil
+}
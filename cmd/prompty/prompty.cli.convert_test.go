@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert_InferredDialect(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "greeting.j2")
+	require.NoError(t, os.WriteFile(path, []byte("Hi {{ name }}\n{{ name | title }}"), FilePermissions))
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{CmdNameConvert, path}, nil, &stdout, &stderr)
+
+	assert.Equal(t, ExitCodeSuccess, code)
+	assert.Equal(t, "Hi {~prompty.var name=\"name\" /~}\n{~prompty.var name=\"name\" /~}", stdout.String())
+	assert.Equal(t, path+":2:1: filter \"title\" has no prompty equivalent and is dropped\n", stderr.String())
+}
+
+func TestConvert_OutputFileAndJSON(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "card.prompty")
+
+	var stdout, stderr bytes.Buffer
+	code := runConvert([]string{"--from", "handlebars", "-o", out, "-"}, strings.NewReader("{{#if a}}{{b}}{{/if}}"), &stdout, &stderr)
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+	content, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, `{~prompty.if eval="a"~}{~prompty.var name="b" /~}{~/prompty.if~}`, string(content))
+
+	stdout.Reset()
+	code = runConvert([]string{"--from", "handlebars", "--json", "-"}, strings.NewReader("{{shout x}}"), &stdout, &stderr)
+	require.Equal(t, ExitCodeSuccess, code)
+	var output convertOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &output))
+	assert.Equal(t, "{{shout x}}", output.Template)
+	require.Len(t, output.Warnings, 1)
+	assert.Equal(t, 1, output.Warnings[0].Column)
+}
+
+func TestConvert_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		input    string
+		wantCode int
+	}{
+		{"no input", nil, "", ExitCodeUsageError},
+		{"dialect not inferred", []string{"-"}, "x", ExitCodeUsageError},
+		{"unknown dialect", []string{"--from", "liquid", "-"}, "x", ExitCodeUsageError},
+		{"missing file", []string{"missing.j2"}, "", ExitCodeInputError},
+		{"unclosed block", []string{"--from", "jinja2", "-"}, "{% if a %}", ExitCodeValidationError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runConvert(tt.args, strings.NewReader(tt.input), &stdout, &stderr)
+			assert.Equal(t, tt.wantCode, code)
+			assert.NotEmpty(t, stderr.String())
+		})
+	}
+}
@@ -83,9 +83,8 @@ Email: {~prompty.var name="contact.email" /~}`
 	})
 
 	t.Run("VariablesInLoops", func(t *testing.T) {
-		// Note: debug command does static analysis - loop variables like user.name
-		// are detected as variables but reported as "missing" since they're bound
-		// at runtime by the loop, not present in the data context
+		// Loop variables like user.name are bound at runtime by the loop, so
+		// static analysis reports them as found rather than missing
 		template := `{~prompty.for item="user" in="users"~}
 {~prompty.var name="user.name" /~}: {~prompty.var name="user.email" /~}
 {~/prompty.for~}`
@@ -104,13 +103,11 @@ Email: {~prompty.var name="contact.email" /~}`
 			"-d", toJSON(t, data),
 		}, nil, &stdout, &stderr)
 
-		// Static analysis reports loop variables as missing (they're bound at runtime)
-		assert.Equal(t, ExitCodeValidationError, exitCode)
+		assert.Equal(t, ExitCodeSuccess, exitCode)
 		output := stdout.String()
-		// Variables are detected but shown as missing
 		assert.Contains(t, output, "user.name")
 		assert.Contains(t, output, "user.email")
-		assert.Contains(t, output, "MISSING")
+		assert.NotContains(t, output, "MISSING")
 	})
 }
 
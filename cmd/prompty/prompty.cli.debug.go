@@ -201,7 +201,7 @@ func buildDebugOutput(result *prompty.DryRunResult, data map[string]any, engine
 		output.Includes = append(output.Includes, debugInclude{
 			Name:   inc.TemplateName,
 			Line:   inc.Line,
-			Exists: engine.HasTemplate(inc.TemplateName),
+			Exists: inc.Storage || inc.Expression != "" || engine.HasTemplate(inc.TemplateName),
 		})
 	}
 
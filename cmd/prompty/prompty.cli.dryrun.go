@@ -0,0 +1,370 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// dryRunConfig holds parsed dryrun command configuration
+type dryRunConfig struct {
+	templatePath string
+	data         string
+	dataFilePath string
+	templateDirs []string
+	format       string
+	color        string
+}
+
+// dryRunOutput represents JSON output for dryrun
+type dryRunOutput struct {
+	Template         string           `json:"template"`
+	Valid            bool             `json:"valid"`
+	Variables        []dryRunVariable `json:"variables"`
+	Resolvers        []dryRunResolver `json:"resolvers"`
+	Includes         []dryRunInclude  `json:"includes"`
+	Loops            []dryRunLoop     `json:"loops"`
+	Functions        []dryRunFunction `json:"functions"`
+	Errors           []string         `json:"errors"`
+	Warnings         []string         `json:"warnings"`
+	MissingVariables []string         `json:"missing_variables"`
+	UnusedData       []string         `json:"unused_data"`
+	Output           string           `json:"output"`
+}
+
+type dryRunVariable struct {
+	Name        string   `json:"name"`
+	Line        int      `json:"line"`
+	Column      int      `json:"column"`
+	Status      string   `json:"status"`
+	Default     string   `json:"default,omitempty"`
+	Expression  string   `json:"expression,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+type dryRunResolver struct {
+	Tag        string `json:"tag"`
+	Line       int    `json:"line"`
+	Column     int    `json:"column"`
+	Registered bool   `json:"registered"`
+}
+
+type dryRunInclude struct {
+	Template string `json:"template"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Exists   bool   `json:"exists"`
+}
+
+type dryRunLoop struct {
+	Source string `json:"source"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	InData bool   `json:"in_data"`
+}
+
+type dryRunFunction struct {
+	Name        string   `json:"name"`
+	Line        int      `json:"line"`
+	Column      int      `json:"column"`
+	Registered  bool     `json:"registered"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+func runDryRun(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parseDryRunFlags(args)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidDryRunArgs, err)
+		return ExitCodeUsageError
+	}
+	colors, err := newColorizer(cfg.color, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidDryRunArgs, err)
+		return ExitCodeUsageError
+	}
+
+	templateSource, err := readInput(cfg.templatePath, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReadFileFailed, err)
+		return ExitCodeInputError
+	}
+	data, err := loadDataArg(cfg.data, cfg.dataFilePath)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidJSON, err)
+		return ExitCodeInputError
+	}
+
+	engine := newTemplateDirEngine(cfg.templatePath, cfg.templateDirs)
+	tmpl, err := engine.Parse(string(templateSource))
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgParseTemplateFailed, err)
+		return ExitCodeInputError
+	}
+	output := buildDryRunOutput(explainTemplateName(cfg.templatePath), tmpl.DryRun(context.Background(), data))
+
+	if cfg.format == OutputFormatJSON {
+		jsonBytes, _ := json.MarshalIndent(output, "", "  ")
+		fmt.Fprintln(stdout, string(jsonBytes))
+	} else {
+		outputDryRunText(output, colors, stdout)
+	}
+
+	if len(output.MissingVariables) > 0 || !output.Valid {
+		return ExitCodeValidationError
+	}
+	return ExitCodeSuccess
+}
+
+func parseDryRunFlags(args []string) (*dryRunConfig, error) {
+	fs := flag.NewFlagSet(CmdNameDryRun, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	cfg := &dryRunConfig{}
+	var templateDirs string
+	var jsonOutput bool
+
+	fs.StringVar(&cfg.templatePath, FlagTemplate, "", "")
+	fs.StringVar(&cfg.templatePath, FlagTemplateShort, "", "")
+	fs.StringVar(&cfg.data, FlagData, "", "")
+	fs.StringVar(&cfg.data, FlagDataShort, "", "")
+	fs.StringVar(&cfg.dataFilePath, FlagDataFile, "", "")
+	fs.StringVar(&cfg.dataFilePath, FlagDataFileShort, "", "")
+	fs.StringVar(&templateDirs, FlagTemplates, "", "")
+	fs.StringVar(&cfg.format, FlagFormat, FlagDefaultFormat, "")
+	fs.StringVar(&cfg.format, FlagFormatShort, FlagDefaultFormat, "")
+	fs.BoolVar(&jsonOutput, FlagJSON, false, "")
+	fs.StringVar(&cfg.color, FlagColor, ColorModeAuto, "")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.templatePath == "" && len(positional) == 1 {
+		cfg.templatePath = positional[0]
+	}
+	if cfg.templatePath == "" {
+		return nil, errors.New(ErrMsgMissingTemplate)
+	}
+	cfg.templateDirs = splitList(templateDirs)
+
+	if jsonOutput {
+		cfg.format = OutputFormatJSON
+	}
+	if cfg.format != OutputFormatText && cfg.format != OutputFormatJSON {
+		return nil, errors.New(ErrMsgInvalidFormat)
+	}
+
+	return cfg, nil
+}
+
+func buildDryRunOutput(name string, result *prompty.DryRunResult) *dryRunOutput {
+	output := &dryRunOutput{
+		Template:         name,
+		Valid:            result.Valid,
+		Variables:        buildDryRunVariables(result),
+		Resolvers:        make([]dryRunResolver, 0, len(result.Resolvers)),
+		Includes:         make([]dryRunInclude, 0, len(result.Includes)),
+		Loops:            make([]dryRunLoop, 0, len(result.Loops)),
+		Functions:        make([]dryRunFunction, 0, len(result.Functions)),
+		Errors:           result.Errors,
+		Warnings:         result.Warnings,
+		MissingVariables: result.MissingVariables,
+		UnusedData:       result.UnusedVariables,
+		Output:           result.Output,
+	}
+	for _, r := range result.Resolvers {
+		output.Resolvers = append(output.Resolvers, dryRunResolver{
+			Tag:        r.TagName,
+			Line:       r.Line,
+			Column:     r.Column,
+			Registered: r.Registered,
+		})
+	}
+	for _, inc := range result.Includes {
+		name := inc.TemplateName
+		if inc.Expression != "" {
+			name = inc.Expression
+		}
+		output.Includes = append(output.Includes, dryRunInclude{
+			Template: name,
+			Line:     inc.Line,
+			Column:   inc.Column,
+			// Stored and dynamic includes are only resolved at execution time
+			Exists: inc.Exists || inc.Storage || inc.Expression != "",
+		})
+	}
+	for _, loop := range result.Loops {
+		output.Loops = append(output.Loops, dryRunLoop{
+			Source: loop.Source,
+			Line:   loop.Line,
+			Column: loop.Column,
+			InData: loop.InData,
+		})
+	}
+	for _, fn := range result.Functions {
+		output.Functions = append(output.Functions, dryRunFunction{
+			Name:        fn.Name,
+			Line:        fn.Line,
+			Column:      fn.Column,
+			Registered:  fn.Registered,
+			Suggestions: fn.Suggestions,
+		})
+	}
+	return output
+}
+
+// buildDryRunVariables reports each variable reference as found in the
+// data, covered by its default, or missing.
+func buildDryRunVariables(result *prompty.DryRunResult) []dryRunVariable {
+	variables := make([]dryRunVariable, 0, len(result.Variables))
+	for _, v := range result.Variables {
+		dv := dryRunVariable{
+			Name:       v.Name,
+			Line:       v.Line,
+			Column:     v.Column,
+			Status:     VarStatusFound,
+			Expression: v.Expression,
+		}
+		switch {
+		case v.InData:
+		case v.HasDefault:
+			dv.Status = VarStatusDefault
+			dv.Default = v.Default
+		default:
+			dv.Status = VarStatusMissing
+			dv.Suggestions = v.Suggestions
+		}
+		variables = append(variables, dv)
+	}
+	return variables
+}
+
+func outputDryRunText(output *dryRunOutput, c *colorizer, stdout io.Writer) {
+	status := c.green(DryRunTextValid)
+	if !output.Valid {
+		status = c.red(DryRunTextInvalid)
+	}
+	fmt.Fprintf(stdout, DryRunTextHeader+FmtNewline, c.bold(output.Template), status)
+
+	writeDryRunVariables(output.Variables, c, stdout)
+
+	if len(output.Resolvers) > 0 {
+		writeSectionHeader(stdout, c, DryRunTextResolvers, len(output.Resolvers))
+		for _, r := range output.Resolvers {
+			if r.Registered {
+				writeDryRunItem(stdout, c.green(MarkOK), r.Tag, r.Line, r.Column, "")
+			} else {
+				writeDryRunItem(stdout, c.red(MarkFail), r.Tag, r.Line, r.Column, c.red(DryRunTextNotRegistered))
+			}
+		}
+	}
+
+	if len(output.Includes) > 0 {
+		writeSectionHeader(stdout, c, DryRunTextIncludes, len(output.Includes))
+		for _, inc := range output.Includes {
+			if inc.Exists {
+				writeDryRunItem(stdout, c.green(MarkOK), inc.Template, inc.Line, inc.Column, "")
+			} else {
+				writeDryRunItem(stdout, c.yellow(MarkWarn), inc.Template, inc.Line, inc.Column, c.yellow(DryRunTextNotFound))
+			}
+		}
+	}
+
+	if len(output.Loops) > 0 {
+		writeSectionHeader(stdout, c, DryRunTextLoops, len(output.Loops))
+		for _, loop := range output.Loops {
+			if loop.InData {
+				writeDryRunItem(stdout, c.green(MarkOK), loop.Source, loop.Line, loop.Column, "")
+			} else {
+				writeDryRunItem(stdout, c.yellow(MarkWarn), loop.Source, loop.Line, loop.Column, c.yellow(DryRunTextNotInData))
+			}
+		}
+	}
+
+	if len(output.Functions) > 0 {
+		writeSectionHeader(stdout, c, DryRunTextFunctions, len(output.Functions))
+		for _, fn := range output.Functions {
+			if fn.Registered {
+				writeDryRunItem(stdout, c.green(MarkOK), fn.Name, fn.Line, fn.Column, "")
+			} else {
+				writeDryRunItem(stdout, c.red(MarkFail), fn.Name, fn.Line, fn.Column,
+					c.red(DryRunTextNotRegistered)+suggestionText(fn.Suggestions))
+			}
+		}
+	}
+
+	writeMessageList(stdout, c, DryRunTextErrors, output.Errors, c.red(MarkFail))
+	writeMessageList(stdout, c, DryRunTextWarnings, output.Warnings, c.yellow(MarkWarn))
+	writeMessageList(stdout, c, DryRunTextUnused, output.UnusedData, c.dim(MarkUnused))
+
+	fmt.Fprintln(stdout)
+	fmt.Fprintln(stdout, c.bold(DryRunTextOutput))
+	fmt.Fprintln(stdout, output.Output)
+
+	fmt.Fprintln(stdout)
+	summary := fmt.Sprintf(DryRunTextSummary, len(output.MissingVariables), len(output.Errors), len(output.Warnings))
+	if len(output.MissingVariables) > 0 || !output.Valid {
+		summary = c.red(summary)
+	} else {
+		summary = c.green(summary)
+	}
+	fmt.Fprintln(stdout, summary)
+}
+
+// writeDryRunVariables prints the variable references with their status.
+func writeDryRunVariables(variables []dryRunVariable, c *colorizer, stdout io.Writer) {
+	writeSectionHeader(stdout, c, DryRunTextVariables, len(variables))
+	for _, v := range variables {
+		name := v.Name
+		if v.Expression != "" {
+			name += c.dim(fmt.Sprintf(DryRunTextExpression, v.Expression))
+		}
+		switch v.Status {
+		case VarStatusFound:
+			writeDryRunItem(stdout, c.green(MarkOK), name, v.Line, v.Column, "")
+		case VarStatusDefault:
+			writeDryRunItem(stdout, c.yellow(MarkWarn), name, v.Line, v.Column,
+				c.yellow(fmt.Sprintf(DryRunTextDefault, v.Default)))
+		default:
+			writeDryRunItem(stdout, c.red(MarkFail), name, v.Line, v.Column,
+				c.red(DryRunTextMissing)+suggestionText(v.Suggestions))
+		}
+	}
+}
+
+func writeSectionHeader(stdout io.Writer, c *colorizer, format string, count int) {
+	fmt.Fprintln(stdout)
+	fmt.Fprintln(stdout, c.bold(fmt.Sprintf(format, count)))
+}
+
+func writeDryRunItem(stdout io.Writer, mark, name string, line, column int, detail string) {
+	fmt.Fprintf(stdout, DryRunTextItem, mark, name, line, column)
+	if detail != "" {
+		fmt.Fprint(stdout, ExplainTextDetailPrefix+detail)
+	}
+	fmt.Fprintln(stdout)
+}
+
+func writeMessageList(stdout io.Writer, c *colorizer, format string, messages []string, mark string) {
+	if len(messages) == 0 {
+		return
+	}
+	writeSectionHeader(stdout, c, format, len(messages))
+	for _, message := range messages {
+		fmt.Fprintf(stdout, DryRunTextListItem+FmtNewline, mark, message)
+	}
+}
+
+// suggestionText formats "did you mean" suggestions, or "" for none.
+func suggestionText(suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(DryRunTextSuggestions, strings.Join(suggestions, ExplainTextDetailSep))
+}
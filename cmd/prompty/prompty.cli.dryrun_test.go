@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun_Text(t *testing.T) {
+	template, dataFile := writeExecutionTemplate(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runDryRun([]string{template, "--data", dataFile, "--color", ColorModeNever}, nil, &stdout, &stderr)
+
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "Dry run of greeting: valid")
+	assert.Contains(t, out, "✓ user.name            [line 1:7]")
+	assert.Contains(t, out, `! plan                 [line 2:1]  default "free"`)
+	assert.Contains(t, out, "Includes (1):\n  ✓ footer")
+	assert.Contains(t, out, "Output preview:")
+	assert.Contains(t, out, "0 missing variable(s), 0 error(s), 0 warning(s)")
+	assert.NotContains(t, out, AnsiReset)
+}
+
+func TestDryRun_MissingVariables(t *testing.T) {
+	template, _ := writeExecutionTemplate(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runDryRun([]string{template, "-d", `{"usr": {"name": "Alice"}}`, "--color", ColorModeAlways}, nil, &stdout, &stderr)
+
+	assert.Equal(t, ExitCodeValidationError, code)
+	out := stdout.String()
+	assert.Contains(t, out, AnsiRed+MarkFail+AnsiReset+" user.name")
+	assert.Contains(t, out, "did you mean")
+	assert.Contains(t, out, "Unused data (1):")
+	assert.Contains(t, out, "1 missing variable(s)")
+}
+
+func TestDryRun_JSON(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "report.prompty")
+	source := `{~prompty.for item="row" in="rows"~}{~prompty.var name="row.id" /~}{~/prompty.for~}` +
+		`{~prompty.if eval="uper(title) != ''"~}x{~/prompty.if~}{~prompty.include template="missing" /~}`
+	require.NoError(t, os.WriteFile(template, []byte(source), FilePermissions))
+
+	var stdout, stderr bytes.Buffer
+	code := runDryRun([]string{"--json", template}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeValidationError, code)
+
+	var output dryRunOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &output))
+	assert.Equal(t, "report", output.Template)
+	assert.False(t, output.Valid)
+	require.Len(t, output.Loops, 1)
+	assert.False(t, output.Loops[0].InData)
+	require.Len(t, output.Functions, 1)
+	assert.False(t, output.Functions[0].Registered)
+	assert.Contains(t, output.Functions[0].Suggestions, "upper")
+	require.Len(t, output.Includes, 1)
+	assert.False(t, output.Includes[0].Exists)
+	assert.Contains(t, output.MissingVariables, "title")
+	assert.NotEmpty(t, output.Errors)
+	assert.NotEmpty(t, output.Warnings)
+}
+
+func TestDryRun_Errors(t *testing.T) {
+	template, _ := writeExecutionTemplate(t)
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+		msg  string
+	}{
+		{"template required", nil, ExitCodeUsageError, ErrMsgMissingTemplate},
+		{"invalid format", []string{template, "-F", "yaml"}, ExitCodeUsageError, ErrMsgInvalidFormat},
+		{"invalid color", []string{template, "--color", "rainbow"}, ExitCodeUsageError, ErrMsgInvalidColorMode},
+		{"invalid data", []string{template, "-d", "{oops"}, ExitCodeInputError, ErrMsgInvalidJSON},
+		{"missing data file", []string{template, "--data", "missing.json"}, ExitCodeInputError, ErrMsgInvalidJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runDryRun(tt.args, nil, &stdout, &stderr)
+			assert.Equal(t, tt.code, code)
+			assert.Contains(t, stderr.String(), tt.msg)
+		})
+	}
+
+	t.Run("stdin", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		stdin := strings.NewReader(`Hi {~prompty.var name="name" /~}`)
+		code := runDryRun([]string{"-t", "-", "-d", `{"name": "Bob"}`}, stdin, &stdout, &stderr)
+		assert.Equal(t, ExitCodeSuccess, code, stderr.String())
+		assert.Contains(t, stdout.String(), "Dry run of (stdin): valid")
+	})
+}
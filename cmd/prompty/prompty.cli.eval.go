@@ -0,0 +1,377 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/itsatony/go-prompty/v2"
+	"github.com/itsatony/go-prompty/v2/eval"
+)
+
+// evalOptions holds the dataset, evaluator and model options shared by the
+// commands that evaluate prompts
+type evalOptions struct {
+	datasetPath    string
+	datasetName    string
+	datasetVersion int
+	driver         string
+	dsn            string
+	sample         int
+	seed           int64
+	split          string
+	splitRatio     float64
+
+	regex     string
+	jsonValid bool
+	minLength int
+	maxLength int
+	flagged   string
+	reference bool
+
+	provider    string
+	model       string
+	timeout     time.Duration
+	concurrency int
+}
+
+// evalConfig holds parsed eval command configuration
+type evalConfig struct {
+	evalOptions
+	promptPath  string
+	saveDataset bool
+	minPassRate float64
+	format      string
+}
+
+// evalOutput represents JSON output for eval
+type evalOutput struct {
+	Dataset string       `json:"dataset"`
+	Passed  bool         `json:"passed"`
+	Report  *eval.Report `json:"report"`
+}
+
+func runEval(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parseEvalFlags(args)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidEvalArgs, err)
+		return ExitCodeUsageError
+	}
+	ctx := context.Background()
+
+	source, err := readInput(cfg.promptPath, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReadFileFailed, err)
+		return ExitCodeInputError
+	}
+	prompt, err := prompty.Parse(source)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgEvalFailed, err)
+		return ExitCodeError
+	}
+
+	dataset, err := loadEvalDataset(ctx, &cfg.evalOptions)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgLoadDatasetFailed, err)
+		return ExitCodeInputError
+	}
+	if cfg.saveDataset {
+		dataset.Prompt = prompt.Name
+		version, err := saveEvalDataset(ctx, &cfg.evalOptions, dataset)
+		if err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgSaveDatasetFailed, err)
+			return ExitCodeError
+		}
+		fmt.Fprintf(stderr, EvalTextSaved+FmtNewline, dataset.Name, version)
+	}
+	if dataset, err = selectEvalExamples(dataset, &cfg.evalOptions); err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidEvalArgs, err)
+		return ExitCodeUsageError
+	}
+
+	evaluators, err := buildEvaluators(&cfg.evalOptions)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidEvaluator, err)
+		return ExitCodeUsageError
+	}
+	report, err := eval.Run(ctx, prompt.Name, evalTarget(prompt, &cfg.evalOptions), dataset.Examples, evaluators, eval.WithConcurrency(cfg.concurrency))
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgEvalFailed, err)
+		return ExitCodeError
+	}
+
+	output := evalOutput{Dataset: dataset.Name, Passed: evalFailedCount(report, cfg.minPassRate) == 0, Report: report}
+	if cfg.format == OutputFormatJSON {
+		jsonBytes, _ := json.MarshalIndent(output, "", "  ")
+		fmt.Fprintln(stdout, string(jsonBytes))
+	} else {
+		stdout.Write(formatEvalText(output, cfg.minPassRate))
+	}
+
+	if !output.Passed {
+		return ExitCodeValidationError
+	}
+	return ExitCodeSuccess
+}
+
+func parseEvalFlags(args []string) (*evalConfig, error) {
+	fs := flag.NewFlagSet(CmdNameEval, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	cfg := &evalConfig{}
+	var jsonOutput bool
+
+	registerEvalFlags(fs, &cfg.evalOptions)
+	fs.StringVar(&cfg.promptPath, FlagTemplate, "", "")
+	fs.StringVar(&cfg.promptPath, FlagTemplateShort, "", "")
+	fs.BoolVar(&cfg.saveDataset, FlagSaveDataset, false, "")
+	fs.Float64Var(&cfg.minPassRate, FlagMinPassRate, EvalDefaultMinPassRate, "")
+	fs.StringVar(&cfg.format, FlagFormat, FlagDefaultFormat, "")
+	fs.StringVar(&cfg.format, FlagFormatShort, FlagDefaultFormat, "")
+	fs.BoolVar(&jsonOutput, FlagJSON, false, "")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.promptPath == "" && len(positional) == 1 {
+		cfg.promptPath = positional[0]
+	}
+	if cfg.promptPath == "" {
+		return nil, errors.New(ErrMsgMissingPrompt)
+	}
+
+	if err := cfg.evalOptions.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.saveDataset && (cfg.datasetPath == "" || !cfg.hasStore()) {
+		return nil, errors.New(ErrMsgSaveDatasetNoDSN)
+	}
+	if cfg.minPassRate < 0 || cfg.minPassRate > 1 {
+		return nil, errors.New(ErrMsgInvalidPassRate)
+	}
+	if jsonOutput {
+		cfg.format = OutputFormatJSON
+	}
+	if cfg.format != OutputFormatText && cfg.format != OutputFormatJSON {
+		return nil, errors.New(ErrMsgInvalidFormat)
+	}
+
+	return cfg, nil
+}
+
+// registerEvalFlags adds the dataset, evaluator and model flags to fs.
+func registerEvalFlags(fs *flag.FlagSet, o *evalOptions) {
+	fs.StringVar(&o.datasetPath, FlagDataset, "", "")
+	fs.StringVar(&o.datasetName, FlagDatasetName, "", "")
+	fs.IntVar(&o.datasetVersion, FlagDatasetVersion, 0, "")
+	fs.StringVar(&o.driver, FlagDriver, envOrDefault(EnvStoreDriver, prompty.StorageDriverNameFilesystem), "")
+	fs.StringVar(&o.dsn, FlagDSN, os.Getenv(EnvStoreDSN), "")
+	fs.IntVar(&o.sample, FlagSample, 0, "")
+	fs.Int64Var(&o.seed, FlagSeed, EvalDefaultSeed, "")
+	fs.StringVar(&o.split, FlagSplit, "", "")
+	fs.Float64Var(&o.splitRatio, FlagSplitRatio, EvalDefaultSplitRatio, "")
+
+	fs.StringVar(&o.regex, FlagRegex, "", "")
+	fs.BoolVar(&o.jsonValid, FlagJSONValid, false, "")
+	fs.IntVar(&o.minLength, FlagMinLength, 0, "")
+	fs.IntVar(&o.maxLength, FlagMaxLength, 0, "")
+	fs.StringVar(&o.flagged, FlagFlagged, "", "")
+	fs.BoolVar(&o.reference, FlagReference, false, "")
+
+	fs.StringVar(&o.provider, FlagProvider, "", "")
+	fs.StringVar(&o.provider, FlagProviderShort, "", "")
+	fs.StringVar(&o.model, FlagModel, "", "")
+	fs.DurationVar(&o.timeout, FlagTimeout, RunDefaultTimeout, "")
+	fs.IntVar(&o.concurrency, FlagConcurrency, eval.DefaultConcurrency, "")
+}
+
+// validate checks the flag combination.
+func (o *evalOptions) validate() error {
+	if o.datasetPath == "" && o.datasetName == "" {
+		return errors.New(ErrMsgMissingDataset)
+	}
+	if o.datasetPath != "" && o.datasetName != "" {
+		return errors.New(ErrMsgDatasetConflict)
+	}
+	if o.datasetName != "" && !o.hasStore() {
+		return errors.New(ErrMsgMissingDSN)
+	}
+	if o.datasetVersion < 0 {
+		return fmt.Errorf(FmtDetail, ErrMsgInvalidVersionArg, strconv.Itoa(o.datasetVersion))
+	}
+	if o.split != "" && o.split != EvalSplitTrain && o.split != EvalSplitTest {
+		return errors.New(ErrMsgInvalidSplit)
+	}
+	if o.provider != "" {
+		if _, ok := providerEndpoints[o.provider]; !ok {
+			return fmt.Errorf(FmtDetail, ErrMsgRunUnsupportedProvider, o.provider)
+		}
+	}
+	return nil
+}
+
+// hasStore reports whether a storage backend is configured.
+func (o *evalOptions) hasStore() bool {
+	return o.dsn != "" || o.driver == prompty.StorageDriverNameMemory
+}
+
+// loadEvalDataset reads --dataset, or --dataset-name from the store.
+func loadEvalDataset(ctx context.Context, o *evalOptions) (*eval.Dataset, error) {
+	if o.datasetPath != "" {
+		return eval.LoadDataset(o.datasetPath)
+	}
+	storage, err := prompty.OpenStorage(o.driver, o.dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer storage.Close()
+	return eval.LoadStoredDataset(ctx, storage, o.datasetName, o.datasetVersion)
+}
+
+// saveEvalDataset stores the dataset as a new version.
+func saveEvalDataset(ctx context.Context, o *evalOptions, dataset *eval.Dataset) (int, error) {
+	storage, err := prompty.OpenStorage(o.driver, o.dsn)
+	if err != nil {
+		return 0, err
+	}
+	defer storage.Close()
+	return eval.SaveDataset(ctx, storage, dataset)
+}
+
+// selectEvalExamples applies --split and then --sample.
+func selectEvalExamples(dataset *eval.Dataset, o *evalOptions) (*eval.Dataset, error) {
+	if o.split != "" {
+		train, test, err := dataset.Split(o.splitRatio, o.seed)
+		if err != nil {
+			return nil, err
+		}
+		dataset = test
+		if o.split == EvalSplitTrain {
+			dataset = train
+		}
+	}
+	return dataset.Sample(o.sample, o.seed), nil
+}
+
+// buildEvaluators creates the evaluators selected by flags, defaulting to
+// the reference check.
+func buildEvaluators(o *evalOptions) ([]eval.Evaluator, error) {
+	var evaluators []eval.Evaluator
+	if o.regex != "" {
+		re, err := eval.Regex(o.regex)
+		if err != nil {
+			return nil, err
+		}
+		evaluators = append(evaluators, re)
+	}
+	if o.jsonValid {
+		evaluators = append(evaluators, eval.JSONValid())
+	}
+	if o.minLength != 0 || o.maxLength != 0 {
+		length, err := eval.Length(o.minLength, o.maxLength)
+		if err != nil {
+			return nil, err
+		}
+		evaluators = append(evaluators, length)
+	}
+	if o.flagged != "" {
+		toxicity, err := eval.Toxicity(splitList(o.flagged))
+		if err != nil {
+			return nil, err
+		}
+		evaluators = append(evaluators, toxicity)
+	}
+	if o.reference || len(evaluators) == 0 {
+		evaluators = append(evaluators, eval.Reference())
+	}
+	return evaluators, nil
+}
+
+// evalTarget renders the prompt, or with --provider sends it to the model.
+func evalTarget(prompt *prompty.Prompt, o *evalOptions) eval.Target {
+	if o.provider == "" {
+		return eval.CompileTarget(prompt, &prompty.CompileOptions{Engine: prompty.MustNew()})
+	}
+	if prompt.Execution == nil {
+		prompt.Execution = &prompty.ExecutionConfig{}
+	}
+	prompt.Execution.Provider = o.provider
+	if o.model != "" {
+		prompt.Execution.Model = o.model
+	}
+	return eval.CompletionTarget(prompt, o.provider, providerModel(o.provider, o.timeout), nil)
+}
+
+// providerModel calls the provider's API with each payload and returns the
+// text of the reply.
+func providerModel(provider string, timeout time.Duration) eval.Model {
+	endpoint := providerEndpoints[provider]
+	return func(ctx context.Context, payload map[string]any) (string, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		response, err := callProvider(ctx, endpoint, provider, payload)
+		if err != nil {
+			return "", err
+		}
+		return endpoint.extractText(response), nil
+	}
+}
+
+// evalFailedCount returns the number of evaluators with errors or below
+// the minimum pass rate.
+func evalFailedCount(report *eval.Report, minPassRate float64) int {
+	failed := 0
+	for _, s := range report.Summaries {
+		if s.Errors > 0 || s.PassRate < minPassRate {
+			failed++
+		}
+	}
+	return failed
+}
+
+// formatEvalText renders the summaries as a table, followed by the failed
+// scores and errors of each example.
+func formatEvalText(output evalOutput, minPassRate float64) []byte {
+	var buf bytes.Buffer
+	report := output.Report
+	fmt.Fprintf(&buf, EvalTextTitle+FmtNewline+FmtNewline, report.Name, len(report.Rows), output.Dataset)
+
+	tw := tabwriter.NewWriter(&buf, 0, 0, EvalTabPadding, ' ', 0)
+	fmt.Fprintln(tw, EvalTextHeader)
+	for _, s := range report.Summaries {
+		fmt.Fprintf(tw, EvalTextRow+FmtNewline, s.Evaluator, s.Count, s.Errors, s.Mean, s.PassRate*EvalPercent)
+	}
+	_ = tw.Flush()
+
+	for i, row := range report.Rows {
+		id := row.ID
+		if id == "" {
+			id = fmt.Sprintf(EvalTextExampleIndex, i)
+		}
+		if row.Error != "" {
+			fmt.Fprintf(&buf, EvalTextRowError+FmtNewline, id, row.Error)
+		}
+		for _, score := range row.Scores {
+			if !score.Passed {
+				fmt.Fprintf(&buf, EvalTextRowFailure+FmtNewline, TestStatusFail, id, score.Evaluator, score.Reason)
+			}
+		}
+	}
+
+	buf.WriteString(FmtNewline)
+	if failed := evalFailedCount(report, minPassRate); failed > 0 {
+		fmt.Fprintf(&buf, EvalTextFailed+FmtNewline, failed, minPassRate*EvalPercent)
+	} else {
+		fmt.Fprintf(&buf, EvalTextPassed+FmtNewline, minPassRate*EvalPercent)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const evalCmdPrompt = `---
+name: capital
+description: Names a capital
+type: prompt
+---
+The capital of {~prompty.var name="country" /~} is {~prompty.var name="capital" default="unknown" /~}.`
+
+const evalCmdDataset = `{"id": "fr", "input": {"country": "France", "capital": "Paris"}, "reference": "Paris"}
+{"id": "it", "input": {"country": "Italy", "capital": "Rome"}, "reference": "Rome"}
+{"id": "de", "input": {"country": "Germany"}, "reference": "Berlin"}
+`
+
+func writeEvalFiles(t *testing.T) (dir, promptPath, datasetPath string) {
+	t.Helper()
+	dir = t.TempDir()
+	promptPath = filepath.Join(dir, "capital.prompty")
+	datasetPath = filepath.Join(dir, "capitals.jsonl")
+	require.NoError(t, os.WriteFile(promptPath, []byte(evalCmdPrompt), FilePermissions))
+	require.NoError(t, os.WriteFile(datasetPath, []byte(evalCmdDataset), FilePermissions))
+	return dir, promptPath, datasetPath
+}
+
+func TestEval_Text(t *testing.T) {
+	_, promptPath, datasetPath := writeEvalFiles(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runEval([]string{"--dataset", datasetPath, "--reference", "--max-length", "33", promptPath}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeValidationError, code, stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "capital: 3 example(s) from capitals")
+	assert.Contains(t, out, "reference  3      0       0.667  66.7%")
+	assert.Contains(t, out, "FAIL de length: length 34 is greater than 33")
+	assert.Contains(t, out, "FAIL de reference: output does not contain the reference")
+	assert.Contains(t, out, "2 evaluator(s) below the minimum pass rate (100%) or with errors")
+
+	stdout.Reset()
+	code = runEval([]string{"--dataset", datasetPath, "--min-pass-rate", "0.6", "--min-length", "10", promptPath}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeSuccess, code, stderr.String())
+	assert.Contains(t, stdout.String(), "All evaluators met the minimum pass rate (60%)")
+}
+
+func TestEval_JSONWithSplitAndSample(t *testing.T) {
+	_, promptPath, datasetPath := writeEvalFiles(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runEval([]string{"--json", "--dataset", datasetPath, "--split", "train", "--split-ratio", "0.67", "--sample", "1", "--regex", "^The capital", promptPath}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeSuccess, code, stderr.String())
+
+	var output evalOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &output))
+	assert.True(t, output.Passed)
+	assert.Equal(t, "capitals", output.Dataset)
+	require.Len(t, output.Report.Rows, 1)
+	require.Len(t, output.Report.Summaries, 1)
+	assert.Equal(t, "regex", output.Report.Summaries[0].Evaluator)
+}
+
+func TestEval_StoredDataset(t *testing.T) {
+	dir, promptPath, datasetPath := writeEvalFiles(t)
+	store := filepath.Join(dir, "store")
+
+	var stdout, stderr bytes.Buffer
+	code := runEval([]string{"--dsn", store, "--dataset", datasetPath, "--save-dataset", "--min-pass-rate", "0.5", promptPath}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeSuccess, code, stderr.String())
+	assert.Contains(t, stderr.String(), "saved dataset capitals v1")
+
+	stdout.Reset()
+	code = runEval([]string{"--json", "--dsn", store, "--dataset-name", "capitals", "--dataset-version", "1", promptPath}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeValidationError, code, stderr.String())
+	var output evalOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &output))
+	assert.Len(t, output.Report.Rows, 3)
+
+	stderr.Reset()
+	code = runEval([]string{"--dsn", store, "--dataset-name", "missing", promptPath}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeInputError, code)
+	assert.Contains(t, stderr.String(), ErrMsgLoadDatasetFailed)
+}
+
+func TestEval_Provider(t *testing.T) {
+	_, promptPath, datasetPath := writeEvalFiles(t)
+	server, rec := newProviderServer(t, http.StatusOK, `{"choices": [{"message": {"role": "assistant", "content": "Paris, Rome or Berlin"}}]}`)
+	t.Setenv(EnvOpenAIBaseURL, server.URL)
+	t.Setenv(EnvOpenAIAPIKey, "secret")
+
+	var stdout, stderr bytes.Buffer
+	code := runEval([]string{"--dataset", datasetPath, "-p", "openai", "--model", "gpt-4o-mini", "--concurrency", "2", promptPath}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeSuccess, code, stderr.String())
+	assert.Equal(t, "gpt-4o-mini", rec.body["model"])
+	assert.Contains(t, stdout.String(), "reference  3      0       1.000  100.0%")
+}
+
+func TestEval_UsageErrors(t *testing.T) {
+	_, promptPath, datasetPath := writeEvalFiles(t)
+
+	tests := []struct {
+		name string
+		args []string
+		msg  string
+	}{
+		{"no prompt", []string{"--dataset", datasetPath}, ErrMsgMissingPrompt},
+		{"no dataset", []string{promptPath}, ErrMsgMissingDataset},
+		{"both datasets", []string{"--dataset", datasetPath, "--dataset-name", "x", "--dsn", "s", promptPath}, ErrMsgDatasetConflict},
+		{"stored without dsn", []string{"--dataset-name", "x", "--dsn", "", promptPath}, ErrMsgMissingDSN},
+		{"bad split", []string{"--dataset", datasetPath, "--split", "dev", promptPath}, ErrMsgInvalidSplit},
+		{"bad pass rate", []string{"--dataset", datasetPath, "--min-pass-rate", "2", promptPath}, ErrMsgInvalidPassRate},
+		{"save without store", []string{"--dataset", datasetPath, "--save-dataset", "--dsn", "", promptPath}, ErrMsgSaveDatasetNoDSN},
+		{"bad provider", []string{"--dataset", datasetPath, "-p", "nope", promptPath}, ErrMsgRunUnsupportedProvider},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, ExitCodeUsageError, runEval(tt.args, nil, &stdout, &stderr))
+			assert.Contains(t, stderr.String(), tt.msg)
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, ExitCodeUsageError, runEval([]string{"--dataset", datasetPath, "--regex", "(", promptPath}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), ErrMsgInvalidEvaluator)
+	assert.Equal(t, ExitCodeUsageError, runEval([]string{"--dataset", datasetPath, "--split", "test", "--split-ratio", "1", promptPath}, nil, &stdout, &stderr))
+}
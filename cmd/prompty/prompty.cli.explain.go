@@ -0,0 +1,384 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// explainConfig holds parsed explain command configuration
+type explainConfig struct {
+	templatePath string
+	data         string
+	dataFilePath string
+	templateDirs []string
+	format       string
+	color        string
+	inheritance  bool
+}
+
+// explainExecutionOutput represents JSON output for explain without a mode:
+// the execution trace with the status of each variable reference
+type explainExecutionOutput struct {
+	Template string `json:"template"`
+	*prompty.ExecutionTrace
+	Variables        []dryRunVariable `json:"variables"`
+	MissingVariables []string         `json:"missing_variables"`
+}
+
+// explainOutput represents JSON output for explain --inheritance
+type explainOutput struct {
+	Chain  []string       `json:"chain"`
+	Levels []explainLevel `json:"levels"`
+}
+
+type explainLevel struct {
+	Name    string         `json:"name"`
+	Extends string         `json:"extends,omitempty"`
+	Blocks  []explainBlock `json:"blocks"`
+}
+
+type explainBlock struct {
+	Name        string `json:"name"`
+	Line        int    `json:"line"`
+	Column      int    `json:"column"`
+	Overrides   bool   `json:"overrides"`
+	Overridden  bool   `json:"overridden"`
+	ParentCalls []int  `json:"parent_calls,omitempty"`
+}
+
+func runExplain(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parseExplainFlags(args)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidExplainArgs, err)
+		return ExitCodeUsageError
+	}
+	colors, err := newColorizer(cfg.color, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidExplainArgs, err)
+		return ExitCodeUsageError
+	}
+
+	templateSource, err := readInput(cfg.templatePath, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReadFileFailed, err)
+		return ExitCodeInputError
+	}
+	data, err := loadDataArg(cfg.data, cfg.dataFilePath)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidJSON, err)
+		return ExitCodeInputError
+	}
+
+	engine := newTemplateDirEngine(cfg.templatePath, cfg.templateDirs)
+	tmpl, err := engine.Parse(string(templateSource))
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgParseTemplateFailed, err)
+		return ExitCodeInputError
+	}
+	name := explainTemplateName(cfg.templatePath)
+
+	if !cfg.inheritance {
+		return explainExecution(name, tmpl, data, cfg.format, colors, stdout)
+	}
+
+	levels, err := tmpl.InheritanceChain()
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgExplainFailed, err)
+		return ExitCodeValidationError
+	}
+
+	output := buildExplainOutput(name, levels)
+	if cfg.format == OutputFormatJSON {
+		jsonBytes, _ := json.MarshalIndent(output, "", "  ")
+		fmt.Fprintln(stdout, string(jsonBytes))
+		return ExitCodeSuccess
+	}
+	outputExplainText(output, stdout)
+	return ExitCodeSuccess
+}
+
+// explainExecution executes the template with data and reports its trace
+// and variables. Missing variables and execution errors fail the command.
+func explainExecution(name string, tmpl *prompty.Template, data map[string]any, format string, c *colorizer, stdout io.Writer) int {
+	ctx := context.Background()
+	dryRun := tmpl.DryRun(ctx, data)
+	output := &explainExecutionOutput{
+		Template:         name,
+		ExecutionTrace:   tmpl.ExplainTrace(ctx, data),
+		Variables:        buildDryRunVariables(dryRun),
+		MissingVariables: dryRun.MissingVariables,
+	}
+
+	if format == OutputFormatJSON {
+		jsonBytes, _ := json.MarshalIndent(output, "", "  ")
+		fmt.Fprintln(stdout, string(jsonBytes))
+	} else {
+		outputExplainExecutionText(output, c, stdout)
+	}
+
+	if output.Error != "" || len(output.MissingVariables) > 0 {
+		return ExitCodeValidationError
+	}
+	return ExitCodeSuccess
+}
+
+func parseExplainFlags(args []string) (*explainConfig, error) {
+	fs := flag.NewFlagSet(CmdNameExplain, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	cfg := &explainConfig{}
+	var templateDirs string
+	var jsonOutput bool
+
+	fs.StringVar(&cfg.templatePath, FlagTemplate, "", "")
+	fs.StringVar(&cfg.templatePath, FlagTemplateShort, "", "")
+	fs.StringVar(&cfg.data, FlagData, "", "")
+	fs.StringVar(&cfg.data, FlagDataShort, "", "")
+	fs.StringVar(&cfg.dataFilePath, FlagDataFile, "", "")
+	fs.StringVar(&cfg.dataFilePath, FlagDataFileShort, "", "")
+	fs.StringVar(&templateDirs, FlagTemplates, "", "")
+	fs.StringVar(&cfg.format, FlagFormat, FlagDefaultFormat, "")
+	fs.StringVar(&cfg.format, FlagFormatShort, FlagDefaultFormat, "")
+	fs.BoolVar(&jsonOutput, FlagJSON, false, "")
+	fs.StringVar(&cfg.color, FlagColor, ColorModeAuto, "")
+	fs.BoolVar(&cfg.inheritance, FlagInheritance, false, "")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.templatePath == "" && len(positional) == 1 {
+		cfg.templatePath = positional[0]
+	}
+	cfg.templateDirs = splitList(templateDirs)
+
+	if cfg.templatePath == "" {
+		return nil, errors.New(ErrMsgMissingTemplate)
+	}
+	if jsonOutput {
+		cfg.format = OutputFormatJSON
+	}
+	if cfg.format != OutputFormatText && cfg.format != OutputFormatJSON {
+		return nil, errors.New(ErrMsgInvalidFormat)
+	}
+
+	return cfg, nil
+}
+
+// newTemplateDirEngine creates an engine with the templates next to
+// templatePath and under dirs registered, for extends and includes.
+func newTemplateDirEngine(templatePath string, dirs []string) *prompty.Engine {
+	engine := prompty.MustNew()
+	if templatePath != InputSourceStdin {
+		dirs = append([]string{filepath.Dir(templatePath)}, dirs...)
+	}
+	registerTemplateDirs(engine, dirs)
+	return engine
+}
+
+// registerTemplateDirs registers the template files under dirs by file name
+// without extension. The first file found for a name wins; files that fail
+// to read or register are skipped, since they only matter if extended.
+func registerTemplateDirs(engine *prompty.Engine, dirs []string) {
+	for _, dir := range dirs {
+		_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if path != dir && strings.HasPrefix(d.Name(), LSPHiddenPrefix) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			name, ok := templateFileName(path)
+			if !ok || engine.HasTemplate(name) {
+				return nil
+			}
+			source, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			_ = engine.RegisterTemplate(name, string(source))
+			return nil
+		})
+	}
+}
+
+// templateFileName returns the template name of a template file.
+func templateFileName(path string) (string, bool) {
+	ext := filepath.Ext(path)
+	for _, allowed := range LSPTemplateExtensions {
+		if ext == allowed {
+			return strings.TrimSuffix(filepath.Base(path), ext), true
+		}
+	}
+	return "", false
+}
+
+// explainTemplateName names the inspected template in the output.
+func explainTemplateName(path string) string {
+	if path == InputSourceStdin {
+		return ExplainStdinTemplateName
+	}
+	if name, ok := templateFileName(path); ok {
+		return name
+	}
+	return filepath.Base(path)
+}
+
+func buildExplainOutput(name string, levels []prompty.InheritanceLevel) *explainOutput {
+	output := &explainOutput{
+		Chain:  make([]string, 0, len(levels)),
+		Levels: make([]explainLevel, 0, len(levels)),
+	}
+	for i, level := range levels {
+		if i == 0 {
+			level.Name = name
+		}
+		el := explainLevel{
+			Name:    level.Name,
+			Extends: level.Extends,
+			Blocks:  make([]explainBlock, 0, len(level.Blocks)),
+		}
+		for _, block := range level.Blocks {
+			eb := explainBlock{
+				Name:       block.Name,
+				Line:       block.Position.Line,
+				Column:     block.Position.Column,
+				Overrides:  block.Overrides,
+				Overridden: block.Overridden,
+			}
+			for _, call := range block.ParentCalls {
+				eb.ParentCalls = append(eb.ParentCalls, call.Line)
+			}
+			el.Blocks = append(el.Blocks, eb)
+		}
+		output.Chain = append(output.Chain, level.Name)
+		output.Levels = append(output.Levels, el)
+	}
+	return output
+}
+
+func outputExplainText(output *explainOutput, stdout io.Writer) {
+	fmt.Fprintf(stdout, ExplainTextChain+FmtNewline, strings.Join(output.Chain, prompty.IncludeChainSeparator))
+
+	for _, level := range output.Levels {
+		fmt.Fprintln(stdout)
+		if level.Extends != "" {
+			fmt.Fprintf(stdout, ExplainTextLevelExtends+FmtNewline, level.Name, level.Extends)
+		} else {
+			fmt.Fprintf(stdout, ExplainTextLevelBase+FmtNewline, level.Name)
+		}
+		if len(level.Blocks) == 0 {
+			fmt.Fprintln(stdout, ExplainTextNoBlocks)
+			continue
+		}
+
+		for _, block := range level.Blocks {
+			var details []string
+			if block.Overrides {
+				details = append(details, ExplainTextOverrides)
+			}
+			if block.Overridden {
+				details = append(details, ExplainTextOverridden)
+			}
+			if len(block.ParentCalls) > 0 {
+				details = append(details, fmt.Sprintf(ExplainTextParentCalls, formatIntSlice(block.ParentCalls)))
+			}
+
+			fmt.Fprintf(stdout, ExplainTextBlock, block.Name, block.Line)
+			if len(details) > 0 {
+				fmt.Fprint(stdout, ExplainTextDetailPrefix+strings.Join(details, ExplainTextDetailSep))
+			}
+			fmt.Fprintln(stdout)
+		}
+	}
+}
+
+func outputExplainExecutionText(output *explainExecutionOutput, c *colorizer, stdout io.Writer) {
+	fmt.Fprintf(stdout, ExplainTextExecution+FmtNewline, c.bold(output.Template), c.dim(output.Duration.String()))
+
+	writeSectionHeader(stdout, c, ExplainTextTrace, countTraceNodes(output.Nodes))
+	writeTraceNodes(output.Nodes, 1, c, stdout)
+
+	writeDryRunVariables(output.Variables, c, stdout)
+
+	fmt.Fprintln(stdout)
+	fmt.Fprintln(stdout, c.bold(ExplainTextOutput))
+	fmt.Fprintln(stdout, output.Output)
+
+	fmt.Fprintln(stdout)
+	if output.Error != "" {
+		fmt.Fprintln(stdout, c.red(fmt.Sprintf(ExplainTextFailed, output.Error)))
+	}
+	summary := fmt.Sprintf(ExplainTextSummary, len(output.MissingVariables), countTraceErrors(output.Nodes))
+	if output.Error != "" || len(output.MissingVariables) > 0 {
+		summary = c.red(summary)
+	} else {
+		summary = c.green(summary)
+	}
+	fmt.Fprintln(stdout, summary)
+}
+
+// writeTraceNodes prints trace nodes as an indented tree with their position,
+// duration, cache status and error.
+func writeTraceNodes(nodes []*prompty.TraceNode, depth int, c *colorizer, stdout io.Writer) {
+	indent := strings.Repeat(ExplainTextIndent, depth)
+	for _, node := range nodes {
+		kind := string(node.Type)
+		if node.Tag != "" {
+			kind = node.Tag
+		}
+		label := node.Label
+		if node.Type == prompty.TraceNodeTypeText {
+			label = fmt.Sprintf(ExplainTextQuoted, label)
+		}
+
+		line := indent + c.cyan(kind)
+		if label != "" {
+			line += " " + label
+		}
+		line += " " + c.dim(fmt.Sprintf(ExplainTextNodePosition, node.Line, node.Column, node.Duration.Round(time.Microsecond)))
+		if node.Cache != "" {
+			line += " " + c.yellow(fmt.Sprintf(ExplainTextNodeCache, node.Cache))
+		}
+		fmt.Fprintln(stdout, line)
+		if node.Error != "" {
+			fmt.Fprintln(stdout, indent+ExplainTextIndent+c.red(MarkFail+" "+node.Error))
+		}
+		writeTraceNodes(node.Children, depth+1, c, stdout)
+	}
+}
+
+func countTraceNodes(nodes []*prompty.TraceNode) int {
+	count := len(nodes)
+	for _, node := range nodes {
+		count += countTraceNodes(node.Children)
+	}
+	return count
+}
+
+// countTraceErrors counts the nodes that failed, including errors handled
+// by an error strategy.
+func countTraceErrors(nodes []*prompty.TraceNode) int {
+	count := 0
+	for _, node := range nodes {
+		if node.Error != "" {
+			count++
+		}
+		count += countTraceErrors(node.Children)
+	}
+	return count
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeExplainTemplates writes an inheritance chain: page.prompty extends
+// layouts/layout.prompty, which extends layouts/base.tmpl.
+func writeExplainTemplates(t *testing.T) (page, layouts string) {
+	t.Helper()
+	dir := t.TempDir()
+	layouts = filepath.Join(dir, "layouts")
+	require.NoError(t, os.Mkdir(layouts, 0o755))
+
+	files := map[string]string{
+		filepath.Join(layouts, "base.tmpl"): `{~prompty.block name="header"~}Base{~/prompty.block~}
+{~prompty.block name="footer"~}Bye{~/prompty.block~}`,
+		filepath.Join(layouts, "layout.prompty"): `{~prompty.extends template="base" /~}
+{~prompty.block name="header"~}Layout {~prompty.parent /~}{~/prompty.block~}`,
+		filepath.Join(dir, "page.prompty"): `{~prompty.extends template="layout" /~}
+{~prompty.block name="header"~}Page {~prompty.parent /~}{~/prompty.block~}`,
+	}
+	for path, content := range files {
+		require.NoError(t, os.WriteFile(path, []byte(content), FilePermissions))
+	}
+	return filepath.Join(dir, "page.prompty"), layouts
+}
+
+func TestExplain_InheritanceText(t *testing.T) {
+	page, layouts := writeExplainTemplates(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runExplain([]string{"--inheritance", "-t", page, "--templates", layouts}, nil, &stdout, &stderr)
+
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "Inheritance chain: page -> layout -> base")
+	assert.Contains(t, out, "layout (extends base)")
+	assert.Contains(t, out, "base (base)")
+	assert.Contains(t, out, "overrides, overridden, prompty.parent at line 2")
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(out), "footer               [line 2]"))
+}
+
+func TestExplain_InheritanceJSON(t *testing.T) {
+	page, layouts := writeExplainTemplates(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runExplain([]string{"--inheritance", "-t", page, "--templates", layouts, "-F", OutputFormatJSON}, nil, &stdout, &stderr)
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+
+	var output explainOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &output))
+	assert.Equal(t, []string{"page", "layout", "base"}, output.Chain)
+	require.Len(t, output.Levels, 3)
+	require.Len(t, output.Levels[0].Blocks, 1)
+	assert.True(t, output.Levels[0].Blocks[0].Overrides)
+	assert.Equal(t, []int{2}, output.Levels[0].Blocks[0].ParentCalls)
+}
+
+func TestExplain_Errors(t *testing.T) {
+	page, _ := writeExplainTemplates(t)
+
+	t.Run("template required", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := runExplain([]string{"--inheritance"}, nil, &stdout, &stderr)
+		assert.Equal(t, ExitCodeUsageError, code)
+		assert.Contains(t, stderr.String(), ErrMsgMissingTemplate)
+	})
+
+	t.Run("invalid color", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := runExplain([]string{page, "--color", "rainbow"}, nil, &stdout, &stderr)
+		assert.Equal(t, ExitCodeUsageError, code)
+		assert.Contains(t, stderr.String(), ErrMsgInvalidColorMode)
+	})
+
+	t.Run("parent not found", func(t *testing.T) {
+		orphan := filepath.Join(t.TempDir(), "orphan.prompty")
+		require.NoError(t, os.WriteFile(orphan, []byte(`{~prompty.extends template="missing" /~}`), FilePermissions))
+
+		var stdout, stderr bytes.Buffer
+		code := runExplain([]string{"--inheritance", "-t", orphan}, nil, &stdout, &stderr)
+		assert.Equal(t, ExitCodeValidationError, code)
+		assert.Contains(t, stderr.String(), ErrMsgExplainFailed)
+	})
+
+	t.Run("stdin without extends", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		stdin := strings.NewReader(`{~prompty.block name="only"~}x{~/prompty.block~}`)
+		code := runExplain([]string{"--inheritance", "-t", "-"}, stdin, &stdout, &stderr)
+		assert.Equal(t, ExitCodeSuccess, code)
+		assert.Contains(t, stdout.String(), "Inheritance chain: (stdin)")
+	})
+}
+
+// writeExecutionTemplate writes greeting.prompty, which includes footer.tmpl
+// from the same directory, and a data file for it.
+func writeExecutionTemplate(t *testing.T) (template, dataFile string) {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		filepath.Join(dir, "greeting.prompty"): "Hello {~prompty.var name=\"user.name\" /~}!\n" +
+			`{~prompty.var name="plan" default="free" /~} {~prompty.include template="footer" /~}`,
+		filepath.Join(dir, "footer.tmpl"): `-- {~prompty.var name="company" default="Acme" /~}`,
+		filepath.Join(dir, "data.json"):   `{"user": {"name": "Alice"}}`,
+	}
+	for path, content := range files {
+		require.NoError(t, os.WriteFile(path, []byte(content), FilePermissions))
+	}
+	return filepath.Join(dir, "greeting.prompty"), filepath.Join(dir, "data.json")
+}
+
+func TestExplain_ExecutionText(t *testing.T) {
+	template, dataFile := writeExecutionTemplate(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runExplain([]string{template, "--data", dataFile, "--color", ColorModeNever}, nil, &stdout, &stderr)
+
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "Execution of greeting (")
+	assert.Contains(t, out, "  prompty.var user.name [line 1:7]")
+	assert.Contains(t, out, "  prompty.include footer [line 2:46]")
+	assert.Contains(t, out, "    prompty.var company [line 1:4]")
+	assert.Contains(t, out, `  text "!\n" [line 1:41]`)
+	assert.Contains(t, out, "✓ user.name")
+	assert.Contains(t, out, `! plan                 [line 2:1]  default "free"`)
+	assert.Contains(t, out, "Output:\nHello Alice!\nfree -- Acme\n")
+	assert.Contains(t, out, "0 missing variable(s), 0 tag error(s)")
+	assert.NotContains(t, out, AnsiReset)
+}
+
+func TestExplain_ExecutionFailures(t *testing.T) {
+	template, _ := writeExecutionTemplate(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runExplain([]string{template, "--color", ColorModeAlways}, nil, &stdout, &stderr)
+
+	assert.Equal(t, ExitCodeValidationError, code)
+	out := stdout.String()
+	assert.Contains(t, out, AnsiRed+MarkFail+" ")
+	assert.Contains(t, out, "missing")
+	assert.Contains(t, out, "Execution failed:")
+	assert.Contains(t, out, "1 missing variable(s), 1 tag error(s)")
+}
+
+func TestExplain_ExecutionJSON(t *testing.T) {
+	template, _ := writeExecutionTemplate(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runExplain([]string{"--json", "-t", template, "-d", `{"user": {"name": "Bob"}, "plan": "pro"}`}, nil, &stdout, &stderr)
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+
+	var output struct {
+		Template         string           `json:"template"`
+		Output           string           `json:"output"`
+		Error            string           `json:"error"`
+		Nodes            []map[string]any `json:"nodes"`
+		Variables        []dryRunVariable `json:"variables"`
+		MissingVariables []string         `json:"missing_variables"`
+	}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &output))
+	assert.Equal(t, "greeting", output.Template)
+	assert.Equal(t, "Hello Bob!\npro -- Acme", output.Output)
+	assert.Empty(t, output.Error)
+	assert.NotEmpty(t, output.Nodes)
+	require.Len(t, output.Variables, 2)
+	assert.Equal(t, VarStatusFound, output.Variables[1].Status)
+	assert.Empty(t, output.MissingVariables)
+}
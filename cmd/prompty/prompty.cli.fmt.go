@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// fmtConfig holds parsed fmt command configuration
+type fmtConfig struct {
+	paths      []string
+	write      bool
+	list       bool
+	check      bool
+	jsonOutput bool
+}
+
+// fmtFileResult represents the formatting result of a single file
+type fmtFileResult struct {
+	File    string `json:"file"`
+	Changed bool   `json:"changed"`
+	Error   string `json:"error,omitempty"`
+}
+
+// fmtOutput represents JSON output for fmt
+type fmtOutput struct {
+	Formatted bool            `json:"formatted"`
+	Files     []fmtFileResult `json:"files"`
+}
+
+func runFmt(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parseFmtFlags(args)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgNoInputFiles, err)
+		return ExitCodeUsageError
+	}
+
+	paths, err := expandInputPaths(cfg.paths)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReadFileFailed, err)
+		return ExitCodeInputError
+	}
+
+	results := make([]fmtFileResult, 0, len(paths))
+	exitCode := ExitCodeSuccess
+	for _, path := range paths {
+		if cfg.write && path == InputSourceStdin {
+			fmt.Fprintln(stderr, ErrMsgWriteStdin)
+			return ExitCodeUsageError
+		}
+
+		source, err := readInput(path, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReadFileFailed, err)
+			return ExitCodeInputError
+		}
+
+		result := fmtFileResult{File: path}
+		formatted, err := prompty.Format(string(source))
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			if !cfg.jsonOutput {
+				fmt.Fprintf(stderr, FmtFileErrorFormat+FmtNewline, path, ErrMsgFormatFailed, err)
+			}
+			exitCode = ExitCodeValidationError
+			continue
+		}
+		result.Changed = formatted != string(source)
+		results = append(results, result)
+
+		switch {
+		case cfg.write:
+			if result.Changed {
+				if err := writeOutput(path, []byte(formatted), stdout); err != nil {
+					fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgWriteOutputFailed, err)
+					return ExitCodeError
+				}
+			}
+		case cfg.list, cfg.check, cfg.jsonOutput:
+			// Report only
+		default:
+			fmt.Fprint(stdout, formatted)
+		}
+
+		if result.Changed && (cfg.list || cfg.check) && !cfg.jsonOutput {
+			fmt.Fprintln(stdout, path)
+		}
+		if result.Changed && cfg.check {
+			exitCode = ExitCodeValidationError
+		}
+	}
+
+	if cfg.jsonOutput {
+		output := fmtOutput{
+			Formatted: exitCode == ExitCodeSuccess,
+			Files:     results,
+		}
+		jsonBytes, _ := json.MarshalIndent(output, "", "  ")
+		fmt.Fprintln(stdout, string(jsonBytes))
+	}
+
+	return exitCode
+}
+
+func parseFmtFlags(args []string) (*fmtConfig, error) {
+	fs := flag.NewFlagSet(CmdNameFmt, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	cfg := &fmtConfig{}
+
+	fs.BoolVar(&cfg.write, FlagWrite, false, "")
+	fs.BoolVar(&cfg.write, FlagWriteShort, false, "")
+	fs.BoolVar(&cfg.list, FlagList, false, "")
+	fs.BoolVar(&cfg.list, FlagListShort, false, "")
+	fs.BoolVar(&cfg.check, FlagCheck, false, "")
+	fs.BoolVar(&cfg.jsonOutput, FlagJSON, false, "")
+
+	paths, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, errors.New(ErrMsgNoInputFiles)
+	}
+	cfg.paths = paths
+
+	return cfg, nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	fmtTestUnformatted = "{~prompty.var default='Guest' name='user'/~}\n"
+	fmtTestFormatted   = "{~prompty.var name=\"user\" default=\"Guest\" /~}\n"
+)
+
+// writeFmtTestFiles creates named template files in a temp dir and returns the dir
+func writeFmtTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), FilePermissions))
+	}
+	return dir
+}
+
+func TestFmt_Stdout(t *testing.T) {
+	dir := writeFmtTestFiles(t, map[string]string{"a.prompty": fmtTestUnformatted})
+	path := filepath.Join(dir, "a.prompty")
+
+	var stdout, stderr bytes.Buffer
+	code := runFmt([]string{path}, nil, &stdout, &stderr)
+
+	assert.Equal(t, ExitCodeSuccess, code)
+	assert.Equal(t, fmtTestFormatted, stdout.String())
+
+	// File untouched without -w
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, fmtTestUnformatted, string(content))
+}
+
+func TestFmt_Stdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := runFmt([]string{"-"}, strings.NewReader(fmtTestUnformatted), &stdout, &stderr)
+
+	assert.Equal(t, ExitCodeSuccess, code)
+	assert.Equal(t, fmtTestFormatted, stdout.String())
+}
+
+func TestFmt_WriteGlob(t *testing.T) {
+	dir := writeFmtTestFiles(t, map[string]string{
+		"a.prompty": fmtTestUnformatted,
+		"b.prompty": fmtTestFormatted,
+		"c.txt":     fmtTestUnformatted,
+	})
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{CmdNameFmt, "-w", filepath.Join(dir, "*.prompty")}, nil, &stdout, &stderr)
+
+	assert.Equal(t, ExitCodeSuccess, code)
+	assert.Empty(t, stdout.String())
+
+	for name, want := range map[string]string{
+		"a.prompty": fmtTestFormatted,
+		"b.prompty": fmtTestFormatted,
+		"c.txt":     fmtTestUnformatted, // not matched by the glob
+	} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		assert.Equal(t, want, string(content), name)
+	}
+}
+
+func TestFmt_CheckAndList(t *testing.T) {
+	dir := writeFmtTestFiles(t, map[string]string{
+		"a.prompty": fmtTestUnformatted,
+		"b.prompty": fmtTestFormatted,
+	})
+	a, b := filepath.Join(dir, "a.prompty"), filepath.Join(dir, "b.prompty")
+
+	t.Run("check fails on unformatted files", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := runFmt([]string{"--check", a, b}, nil, &stdout, &stderr)
+
+		assert.Equal(t, ExitCodeValidationError, code)
+		assert.Equal(t, a+"\n", stdout.String())
+	})
+
+	t.Run("check passes on formatted files", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := runFmt([]string{b, "--check"}, nil, &stdout, &stderr)
+
+		assert.Equal(t, ExitCodeSuccess, code)
+		assert.Empty(t, stdout.String())
+	})
+
+	t.Run("list does not fail", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := runFmt([]string{"-l", a, b}, nil, &stdout, &stderr)
+
+		assert.Equal(t, ExitCodeSuccess, code)
+		assert.Equal(t, a+"\n", stdout.String())
+	})
+}
+
+func TestFmt_JSON(t *testing.T) {
+	dir := writeFmtTestFiles(t, map[string]string{
+		"a.prompty":   fmtTestUnformatted,
+		"bad.prompty": `{~prompty.if eval="x"~}unclosed`,
+	})
+
+	var stdout, stderr bytes.Buffer
+	code := runFmt([]string{"--json", filepath.Join(dir, "*.prompty")}, nil, &stdout, &stderr)
+
+	assert.Equal(t, ExitCodeValidationError, code)
+
+	var output fmtOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &output))
+	assert.False(t, output.Formatted)
+	require.Len(t, output.Files, 2)
+	assert.True(t, output.Files[0].Changed)
+	assert.Empty(t, output.Files[0].Error)
+	assert.NotEmpty(t, output.Files[1].Error)
+}
+
+func TestFmt_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{name: "no files", args: []string{}, code: ExitCodeUsageError},
+		{name: "unknown flag", args: []string{"--bogus", "x"}, code: ExitCodeUsageError},
+		{name: "write stdin", args: []string{"-w", "-"}, code: ExitCodeUsageError},
+		{name: "missing file", args: []string{"/nonexistent/file.prompty"}, code: ExitCodeInputError},
+		{name: "glob matches nothing", args: []string{"/nonexistent/*.prompty"}, code: ExitCodeInputError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runFmt(tt.args, strings.NewReader(""), &stdout, &stderr)
+			assert.Equal(t, tt.code, code)
+			assert.NotEmpty(t, stderr.String())
+		})
+	}
+}
+
+func TestFmt_ParseError(t *testing.T) {
+	dir := writeFmtTestFiles(t, map[string]string{"bad.prompty": `{~prompty.if eval="x"~}unclosed`})
+
+	var stdout, stderr bytes.Buffer
+	code := runFmt([]string{filepath.Join(dir, "bad.prompty")}, nil, &stdout, &stderr)
+
+	assert.Equal(t, ExitCodeValidationError, code)
+	assert.Contains(t, stderr.String(), ErrMsgFormatFailed)
+}
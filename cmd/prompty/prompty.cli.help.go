@@ -19,8 +19,40 @@ func runHelp(args []string, stdout io.Writer) int {
 		fmt.Fprintln(stdout, HelpValidateUsage)
 	case CmdNameLint:
 		fmt.Fprintln(stdout, HelpLintUsage)
+	case CmdNameFmt:
+		fmt.Fprintln(stdout, HelpFmtUsage)
+	case CmdNameConvert:
+		fmt.Fprintln(stdout, HelpConvertUsage)
 	case CmdNameDebug:
 		fmt.Fprintln(stdout, HelpDebugUsage)
+	case CmdNameDryRun:
+		fmt.Fprintln(stdout, HelpDryRunUsage)
+	case CmdNameExplain:
+		fmt.Fprintln(stdout, HelpExplainUsage)
+	case CmdNameRepl:
+		fmt.Fprintln(stdout, HelpReplUsage)
+	case CmdNameLSP:
+		fmt.Fprintln(stdout, HelpLSPUsage)
+	case CmdNameCompile:
+		fmt.Fprintln(stdout, HelpCompileUsage)
+	case CmdNameRun:
+		fmt.Fprintln(stdout, HelpRunUsage)
+	case CmdNameBench:
+		fmt.Fprintln(stdout, HelpBenchUsage)
+	case CmdNameTest:
+		fmt.Fprintln(stdout, HelpTestUsage)
+	case CmdNameEval:
+		fmt.Fprintln(stdout, HelpEvalUsage)
+	case CmdNameCompare:
+		fmt.Fprintln(stdout, HelpCompareUsage)
+	case CmdNameReplay:
+		fmt.Fprintln(stdout, HelpReplayUsage)
+	case CmdNameStore:
+		fmt.Fprintln(stdout, HelpStoreUsage)
+	case CmdNameSkill:
+		fmt.Fprintln(stdout, HelpSkillUsage)
+	case CmdNameAgent:
+		fmt.Fprintln(stdout, HelpAgentUsage)
 	case CmdNameVersion:
 		fmt.Fprintln(stdout, HelpVersionUsage)
 	case CmdNameHelp:
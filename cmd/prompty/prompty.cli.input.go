@@ -1,8 +1,13 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 // readInput reads content from a file or stdin
@@ -23,3 +28,64 @@ func writeOutput(path string, data []byte, stdout io.Writer) error {
 
 	return os.WriteFile(path, data, FilePermissions)
 }
+
+// parseInterspersed parses flags that may appear before, between or after
+// positional arguments and returns the positional arguments in order.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// expandInputPaths expands glob patterns in paths. Plain paths and stdin ("-")
+// are kept as-is; a pattern that matches no file is an error so that CI runs
+// do not silently check nothing.
+func expandInputPaths(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, errors.New(ErrMsgNoInputFiles)
+	}
+
+	expanded := make([]string, 0, len(paths))
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		matches := []string{path}
+		if path != InputSourceStdin && strings.ContainsAny(path, GlobMetaChars) {
+			var err error
+			matches, err = filepath.Glob(path)
+			if err != nil {
+				return nil, fmt.Errorf(FmtDetail, ErrMsgInvalidGlob, path)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf(FmtDetail, ErrMsgNoFilesMatched, path)
+			}
+		}
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				expanded = append(expanded, match)
+			}
+		}
+	}
+	return expanded, nil
+}
+
+// loadDataArg loads data given as a JSON file path or inline JSON object
+// (value) or as a JSON data file (filePath).
+func loadDataArg(value, filePath string) (map[string]any, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), JSONObjectPrefix) {
+		return loadData(value, "")
+	}
+	if value != "" {
+		return loadData("", value)
+	}
+	return loadData("", filePath)
+}
@@ -15,15 +15,16 @@ import (
 
 // lintConfig holds parsed lint command configuration
 type lintConfig struct {
-	templatePath string
-	format       string
-	rules        string
-	ignore       string
-	strict       bool
+	templatePaths []string
+	format        string
+	rules         string
+	ignore        string
+	strict        bool
 }
 
 // lintIssue represents a single lint issue
 type lintIssue struct {
+	File     string `json:"file,omitempty"`
 	RuleID   string `json:"rule_id"`
 	Severity string `json:"severity"`
 	Message  string `json:"message"`
@@ -55,6 +56,9 @@ func newLintRuleSet(rules, ignore string) *lintRuleSet {
 			LintRuleINC001:  true,
 		},
 	}
+	for _, rule := range prompty.LintRules() {
+		rs.enabledRules[rule] = true
+	}
 
 	// If specific rules requested, only enable those
 	if rules != "" {
@@ -89,8 +93,7 @@ func runLint(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 		return ExitCodeUsageError
 	}
 
-	// Read template
-	templateSource, err := readInput(cfg.templatePath, stdin)
+	paths, err := expandInputPaths(cfg.templatePaths)
 	if err != nil {
 		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReadFileFailed, err)
 		return ExitCodeInputError
@@ -99,8 +102,23 @@ func runLint(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 	// Create rule set
 	ruleSet := newLintRuleSet(cfg.rules, cfg.ignore)
 
-	// Run lint checks
-	issues := lintTemplate(string(templateSource), ruleSet)
+	// Run lint checks on every input; file names are reported when linting several
+	var issues []lintIssue
+	for _, path := range paths {
+		templateSource, err := readInput(path, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReadFileFailed, err)
+			return ExitCodeInputError
+		}
+
+		fileIssues := lintTemplate(string(templateSource), ruleSet)
+		if len(paths) > 1 {
+			for i := range fileIssues {
+				fileIssues[i].File = path
+			}
+		}
+		issues = append(issues, fileIssues...)
+	}
 
 	// Output based on format
 	if cfg.format == OutputFormatJSON {
@@ -114,9 +132,11 @@ func parseLintFlags(args []string) (*lintConfig, error) {
 	fs.SetOutput(io.Discard)
 
 	cfg := &lintConfig{}
+	var templatePath string
+	var jsonOutput bool
 
-	fs.StringVar(&cfg.templatePath, FlagTemplate, "", "")
-	fs.StringVar(&cfg.templatePath, FlagTemplateShort, "", "")
+	fs.StringVar(&templatePath, FlagTemplate, "", "")
+	fs.StringVar(&templatePath, FlagTemplateShort, "", "")
 	fs.StringVar(&cfg.format, FlagFormat, FlagDefaultFormat, "")
 	fs.StringVar(&cfg.format, FlagFormatShort, FlagDefaultFormat, "")
 	fs.StringVar(&cfg.rules, FlagRules, "", "")
@@ -124,15 +144,25 @@ func parseLintFlags(args []string) (*lintConfig, error) {
 	fs.StringVar(&cfg.ignore, FlagIgnore, "", "")
 	fs.StringVar(&cfg.ignore, FlagIgnoreShort, "", "")
 	fs.BoolVar(&cfg.strict, FlagStrictMode, false, "")
+	fs.BoolVar(&jsonOutput, FlagJSON, false, "")
 
-	if err := fs.Parse(args); err != nil {
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
 		return nil, err
 	}
 
-	if cfg.templatePath == "" {
+	if templatePath != "" {
+		cfg.templatePaths = append(cfg.templatePaths, templatePath)
+	}
+	cfg.templatePaths = append(cfg.templatePaths, files...)
+	if len(cfg.templatePaths) == 0 {
 		return nil, errors.New(ErrMsgMissingTemplate)
 	}
 
+	if jsonOutput {
+		cfg.format = OutputFormatJSON
+	}
+
 	if cfg.format != OutputFormatText && cfg.format != OutputFormatJSON {
 		return nil, errors.New(ErrMsgInvalidFormat)
 	}
@@ -183,10 +213,41 @@ func lintTemplate(source string, ruleSet *lintRuleSet) []lintIssue {
 	}
 	issues = append(issues, checkLoopRules(source, ruleSet)...)
 	issues = append(issues, checkExpressionRules(source, ruleSet)...)
+	issues = append(issues, checkLibraryRules(source, ruleSet)...)
 
 	return issues
 }
 
+// checkLibraryRules runs the prompty.Lint rules (BLK001, LOOP003, VAR003,
+// COND001, TEXT001, TAG002, META001) that are enabled in the rule set
+func checkLibraryRules(source string, ruleSet *lintRuleSet) []lintIssue {
+	var disabled []string
+	for _, rule := range prompty.LintRules() {
+		if !ruleSet.isEnabled(rule) {
+			disabled = append(disabled, rule)
+		}
+	}
+
+	result, err := prompty.Lint(source, prompty.LintConfig{DisabledRules: disabled})
+	if err != nil {
+		// Structural errors are already reported through TAG001
+		return nil
+	}
+
+	issues := make([]lintIssue, 0, len(result.Findings()))
+	for _, f := range result.Findings() {
+		issues = append(issues, lintIssue{
+			RuleID:   f.RuleID,
+			Severity: severityToName(f.Severity),
+			Message:  f.Message,
+			Line:     f.Position.Line,
+			Column:   f.Position.Column,
+			TagName:  f.TagName,
+		})
+	}
+	return issues
+}
+
 // checkVariableRules checks VAR001 and VAR002 rules
 func checkVariableRules(result *prompty.DryRunResult, ruleSet *lintRuleSet) []lintIssue {
 	var issues []lintIssue
@@ -357,7 +418,7 @@ func checkIncludeRules(result *prompty.DryRunResult, ruleSet *lintRuleSet, engin
 	}
 
 	for _, inc := range result.Includes {
-		if !engine.HasTemplate(inc.TemplateName) {
+		if !inc.Storage && inc.Expression == "" && !engine.HasTemplate(inc.TemplateName) {
 			issues = append(issues, lintIssue{
 				RuleID:   LintRuleINC001,
 				Severity: SeverityNameWarning,
@@ -401,8 +462,13 @@ func outputLintText(issues []lintIssue, strict bool, stdout io.Writer) int {
 	hasWarnings := false
 
 	for _, issue := range issues {
-		fmt.Fprintf(stdout, LintTextIssueFormat+FmtNewline,
-			issue.RuleID, issue.Severity, issue.Message, issue.Line, issue.Column)
+		if issue.File != "" {
+			fmt.Fprintf(stdout, LintTextFileIssueFormat+FmtNewline,
+				issue.File, issue.Line, issue.Column, issue.RuleID, issue.Severity, issue.Message)
+		} else {
+			fmt.Fprintf(stdout, LintTextIssueFormat+FmtNewline,
+				issue.RuleID, issue.Severity, issue.Message, issue.Line, issue.Column)
+		}
 		if issue.Severity == SeverityNameError {
 			hasErrors = true
 		}
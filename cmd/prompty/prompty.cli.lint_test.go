@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -256,3 +257,54 @@ func createTempFile(t *testing.T, content string) string {
 	require.NoError(t, err)
 	return tmpFile
 }
+
+func TestLint_MultipleFilesAndGlob(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.prompty"), []byte(`{~prompty.var name="x" default="TODO" /~}`), FilePermissions))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.prompty"), []byte(`{~prompty.var name="y" default="ok" /~}`), FilePermissions))
+
+	t.Run("text output prefixes file names", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := runLint([]string{filepath.Join(dir, "*.prompty")}, nil, &stdout, &stderr)
+
+		assert.Equal(t, ExitCodeSuccess, code)
+		assert.Contains(t, stdout.String(), filepath.Join(dir, "a.prompty")+":1:1: [VAR003]")
+	})
+
+	t.Run("json flag after positional args", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := runLint([]string{filepath.Join(dir, "a.prompty"), filepath.Join(dir, "b.prompty"), "--json", "--strict"}, nil, &stdout, &stderr)
+
+		assert.Equal(t, ExitCodeValidationError, code)
+
+		var output lintOutput
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &output))
+		assert.False(t, output.Valid)
+		require.Len(t, output.Issues, 1)
+		assert.Equal(t, filepath.Join(dir, "a.prompty"), output.Issues[0].File)
+		assert.Equal(t, "VAR003", output.Issues[0].RuleID)
+	})
+
+	t.Run("glob matching nothing", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := runLint([]string{filepath.Join(dir, "*.missing")}, nil, &stdout, &stderr)
+
+		assert.Equal(t, ExitCodeInputError, code)
+		assert.Contains(t, stderr.String(), ErrMsgNoFilesMatched)
+	})
+}
+
+func TestLint_LibraryRules(t *testing.T) {
+	tmpFile := createTempFile(t, `{~prompty.for item="x" in="a" limit="5"~}{~prompty.for item="x" in="b" limit="5"~}{~/prompty.for~}{~/prompty.for~}`)
+	defer os.Remove(tmpFile)
+
+	var stdout, stderr bytes.Buffer
+	code := runLint([]string{"-t", tmpFile}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeSuccess, code)
+	assert.Contains(t, stdout.String(), "LOOP003")
+
+	stdout.Reset()
+	code = runLint([]string{"-t", tmpFile, "--ignore", "LOOP003"}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeSuccess, code)
+	assert.NotContains(t, stdout.String(), "LOOP003")
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// lspAttrDoc documents one tag attribute for hover and completion
+type lspAttrDoc struct {
+	name     string
+	required bool
+	doc      string
+}
+
+// lspTagDoc documents a built-in tag for hover and completion
+type lspTagDoc struct {
+	doc   string
+	attrs []lspAttrDoc
+}
+
+// attr returns the documentation for the named attribute, if any.
+func (d lspTagDoc) attr(name string) (lspAttrDoc, bool) {
+	for _, a := range d.attrs {
+		if a.name == name {
+			return a, true
+		}
+	}
+	return lspAttrDoc{}, false
+}
+
+// onErrorAttrDoc is shared by all tags that accept a per-tag error strategy
+var onErrorAttrDoc = lspAttrDoc{
+	name: prompty.AttrOnError,
+	doc:  "Error strategy override: `throw`, `default`, `remove`, `keepraw`, `log`, `collect` or `include:<template>` to render a fallback template",
+}
+
+// escapeAttrDoc is shared by all tags whose value is escaped in the output mode
+var escapeAttrDoc = lspAttrDoc{
+	name: prompty.AttrEscape,
+	doc:  "Output mode of this value, overriding the enclosing `prompty.escape` or engine mode (`text` disables escaping)",
+}
+
+// lspTagDocs documents the built-in tags, keyed by tag name
+var lspTagDocs = map[string]lspTagDoc{
+	prompty.TagNameVar: {
+		doc: "Outputs the value at a data path.\n\n`{~prompty.var name=\"user.name\" default=\"Guest\" /~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrName, required: true, doc: "Dot-notation data path (e.g. `user.settings.theme`)"},
+			{name: prompty.AttrDefault, doc: "Fallback value if the path is not found"},
+			escapeAttrDoc,
+			onErrorAttrDoc,
+		},
+	},
+	prompty.TagNameEscape: {
+		doc: "Escapes the values interpolated in its body for an output format.\n\n`{~prompty.escape mode=\"json\"~}...{~/prompty.escape~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrMode, required: true, doc: "`text`, `html`, `json`, `markdown`, `shell` or a mode added with `WithEscaper`"},
+			onErrorAttrDoc,
+		},
+	},
+	prompty.TagNameRaw: {
+		doc: "Outputs its body verbatim without processing tags.\n\n`{~prompty.raw~}{~not.parsed~}{~/prompty.raw~}`",
+	},
+	prompty.TagNameComment: {
+		doc: "Template comment; produces no output.\n\n`{~prompty.comment~}note{~/prompty.comment~}`",
+	},
+	prompty.TagNameInclude: {
+		doc: "Renders another registered template in place.\n\n`{~prompty.include template=\"header\" with=\"user\" /~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrTemplate, required: true, doc: "Registered template name"},
+			{name: prompty.AttrTemplateExpr, doc: "Expression computing the template name, instead of `template`"},
+			{name: prompty.AttrWith, doc: "Use the value at this path as the context root"},
+			{name: prompty.AttrIsolate, doc: "`\"true\"` to not inherit the parent context"},
+			{name: prompty.AttrSource, doc: "`\"storage\"` to include a stored template through a StorageEngine"},
+			{name: prompty.AttrVersion, doc: "Stored template version to include with `source=\"storage\"`"},
+			onErrorAttrDoc,
+		},
+	},
+	prompty.TagNameImport: {
+		doc: "Imports the blocks of a registered template as a library; must be a top-level tag.\n\n`{~prompty.import template=\"shared-blocks\" as=\"lib\" /~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrTemplate, required: true, doc: "Registered template defining the blocks"},
+			{name: prompty.AttrAs, required: true, doc: "Alias to qualify the imported blocks with"},
+		},
+	},
+	prompty.TagNameUse: {
+		doc: "Renders a block of an imported library in the current context.\n\n`{~prompty.use block=\"lib.disclaimer\" /~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrBlock, required: true, doc: "Qualified block name: `alias.block`"},
+		},
+	},
+	prompty.TagNameIf: {
+		doc: "Renders its body when the expression is truthy; may be followed by `elseif`/`else`.\n\n`{~prompty.if eval=\"len(items) > 0\"~}...{~/prompty.if~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrEval, required: true, doc: "Boolean expression"},
+		},
+	},
+	prompty.TagNameElseIf: {
+		doc: "Alternative branch of a `prompty.if`, taken when its expression is truthy.",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrEval, required: true, doc: "Boolean expression"},
+		},
+	},
+	prompty.TagNameElse: {
+		doc: "Final branch of a `prompty.if`, taken when no other branch matched.",
+	},
+	prompty.TagNameFor: {
+		doc: "Repeats its body for each element of a collection.\n\n`{~prompty.for item=\"x\" index=\"i\" in=\"items\" limit=\"10\"~}...{~/prompty.for~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrItem, required: true, doc: "Variable name for the current element"},
+			{name: prompty.AttrIn, required: true, doc: "Data path of the collection"},
+			{name: prompty.AttrIndex, doc: "Variable name for the 0-based index"},
+			{name: prompty.AttrLimit, doc: "Maximum number of iterations"},
+		},
+	},
+	prompty.TagNameChoice: {
+		doc: "Outputs a random element of a collection; reproducible with `WithDeterministic`.\n\n`{~prompty.choice from=\"greetings\" /~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrFrom, required: true, doc: "Data path of the collection"},
+			{name: prompty.AttrDefault, doc: "Output if the collection is missing or empty"},
+			escapeAttrDoc,
+			onErrorAttrDoc,
+		},
+	},
+	prompty.TagNameShuffle: {
+		doc: "Repeats its body for the elements of a collection in random order; reproducible with `WithDeterministic`.\n\n`{~prompty.shuffle in=\"examples\" limit=\"3\"~}...{~/prompty.shuffle~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrIn, required: true, doc: "Data path of the collection"},
+			{name: prompty.AttrItem, doc: "Variable name for the current element (default `item`)"},
+			{name: prompty.AttrIndex, doc: "Variable name for the 0-based index"},
+			{name: prompty.AttrLimit, doc: "Number of elements to sample"},
+			onErrorAttrDoc,
+		},
+	},
+	prompty.TagNameHistory: {
+		doc: "Renders a conversation from the data (entries with `role` and `content`), dropping the oldest messages first.\n\n`{~prompty.history in=\"conversation\" limit=\"20\" window=\"tokens:2000\" /~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrIn, required: true, doc: "Data path of the message collection"},
+			{name: prompty.AttrLimit, doc: "Maximum number of most recent messages"},
+			{name: prompty.AttrWindow, doc: "Token budget of the most recent messages: `tokens:N`"},
+			{name: prompty.AttrFormat, doc: "`messages` (structured messages, default), `chatml` or `plain`"},
+			onErrorAttrDoc,
+		},
+	},
+	prompty.TagNameToolResults: {
+		doc: "Renders tool call outputs from the data (entries with `name`, `call_id`, `content` and `is_error`) as text.\n\n`{~prompty.tool_results in=\"tool_results\" format=\"xml\" /~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrIn, doc: "Data path of the tool results (default `tool_results`)"},
+			{name: prompty.AttrFormat, doc: "`plain` (default), `xml` or `json`"},
+			onErrorAttrDoc,
+		},
+	},
+	prompty.TagNameImage: {
+		doc: "Adds an image to the enclosing `prompty.message`, serialized as a multimodal content part.\n\n`{~prompty.image url=\"https://example.com/chart.png\" detail=\"high\" /~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrURL, doc: "Image URL (http(s) or base64 `data:` URL)"},
+			{name: prompty.AttrFrom, doc: "Data path of the image URL, instead of `url`"},
+			{name: prompty.AttrDetail, doc: "Detail level: `low`, `high` or `auto`"},
+			{name: prompty.AttrMediaType, doc: "MIME type, inferred from the URL if omitted"},
+			onErrorAttrDoc,
+		},
+	},
+	prompty.TagNameFile: {
+		doc: "Adds a file (PDF, audio, ...) to the enclosing `prompty.message`, serialized as a multimodal content part.\n\n`{~prompty.file name=\"report.pdf\" url=\"https://example.com/report.pdf\" /~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrName, doc: "File name"},
+			{name: prompty.AttrURL, doc: "File URL (http(s) or base64 `data:` URL)"},
+			{name: prompty.AttrFrom, doc: "Data path of the file URL, instead of `url`"},
+			{name: prompty.AttrID, doc: "File ID issued by the provider's files API"},
+			{name: prompty.AttrMediaType, doc: "MIME type, inferred from the name or URL if omitted"},
+			onErrorAttrDoc,
+		},
+	},
+	prompty.TagNameCacheBreakpoint: {
+		doc: "Ends the prompt-cache prefix after the content before it in the enclosing `prompty.message` (Anthropic `cache_control`, OpenAI `prompt_cache_key`). Renders nothing outside a message.\n\n`{~prompty.cache_breakpoint /~}`",
+	},
+	prompty.TagNameSwitch: {
+		doc: "Renders the first `prompty.case` matching the expression, or the default case.\n\n`{~prompty.switch eval=\"status\"~}...{~/prompty.switch~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrEval, required: true, doc: "Expression to switch on"},
+		},
+	},
+	prompty.TagNameCase: {
+		doc: "Case of a `prompty.switch`, matched by literal value or boolean expression.",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrValue, doc: "Literal value to compare against"},
+			{name: prompty.AttrEval, doc: "Boolean expression evaluated instead of a value match"},
+			{name: prompty.AttrFallthrough, doc: "`\"true\"` to also render the following case body"},
+		},
+	},
+	prompty.TagNameCaseDefault: {
+		doc: "Default case of a `prompty.switch`, rendered when no case matched.",
+	},
+	prompty.TagNameDefault: {
+		doc: "Alias for `prompty.casedefault` inside a `prompty.switch`.",
+	},
+	prompty.TagNameEnv: {
+		doc: "Outputs an environment variable.\n\n`{~prompty.env name=\"HOME\" default=\"/tmp\" /~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrName, required: true, doc: "Environment variable name"},
+			{name: prompty.AttrDefault, doc: "Fallback value if not set"},
+			{name: prompty.AttrRequired, doc: "`\"true\"` to fail if not set and no default is given"},
+			escapeAttrDoc,
+			onErrorAttrDoc,
+		},
+	},
+	prompty.TagNameConfig: {
+		doc: "Legacy JSON inference configuration block. Deprecated: use YAML frontmatter.",
+	},
+	prompty.TagNameExtends: {
+		doc: "Inherits from a parent template; must be the first tag.\n\n`{~prompty.extends template=\"base\" /~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrTemplate, required: true, doc: "Registered parent template name"},
+		},
+	},
+	prompty.TagNameBlock: {
+		doc: "Named section that child templates can override.\n\n`{~prompty.block name=\"content\"~}...{~/prompty.block~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrName, required: true, doc: "Unique block identifier"},
+		},
+	},
+	prompty.TagNameParent: {
+		doc: "Inserts the parent template's content for the enclosing block.",
+	},
+	prompty.TagNameMessage: {
+		doc: "Conversation message for chat APIs.\n\n`{~prompty.message role=\"system\"~}...{~/prompty.message~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrRole, required: true, doc: "Message role: `system`, `user`, `assistant` or `tool`"},
+			{name: prompty.AttrCache, doc: "`\"true\"` or `\"ephemeral\"` to mark the message as a prompt-cache breakpoint"},
+		},
+	},
+	prompty.TagNameRef: {
+		doc: "Inserts the body of another prompt by slug.\n\n`{~prompty.ref slug=\"safety-rules\" version=\"2\" /~}`",
+		attrs: []lspAttrDoc{
+			{name: prompty.AttrSlug, required: true, doc: "Prompt slug identifier"},
+			{name: prompty.AttrVersion, doc: "Specific version, defaults to `latest`"},
+		},
+	},
+	prompty.TagNameSkillsCatalog: {
+		doc: "Outputs the catalog of the agent's skills (format is set by the agent executor).",
+	},
+	prompty.TagNameToolsCatalog: {
+		doc: "Outputs the catalog of the agent's tools (format is set by the agent executor).",
+	},
+}
@@ -0,0 +1,820 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/itsatony/go-prompty/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// lspConfig holds parsed lsp command configuration
+type lspConfig struct {
+	templateDirs []string
+	dataFilePath string
+}
+
+// lspRequest is an incoming JSON-RPC request or notification
+type lspRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// lspResponse is an outgoing JSON-RPC response
+type lspResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *lspError       `json:"error,omitempty"`
+}
+
+// lspNotification is an outgoing JSON-RPC notification
+type lspNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// lspError is a JSON-RPC error object
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// LSP protocol structures (the subset used by this server)
+type (
+	lspPosition struct {
+		Line      int `json:"line"`
+		Character int `json:"character"`
+	}
+	lspRange struct {
+		Start lspPosition `json:"start"`
+		End   lspPosition `json:"end"`
+	}
+	lspLocation struct {
+		URI   string   `json:"uri"`
+		Range lspRange `json:"range"`
+	}
+	lspDocumentID struct {
+		URI string `json:"uri"`
+	}
+	lspPositionParams struct {
+		TextDocument lspDocumentID `json:"textDocument"`
+		Position     lspPosition   `json:"position"`
+	}
+	lspDidOpenParams struct {
+		TextDocument struct {
+			URI  string `json:"uri"`
+			Text string `json:"text"`
+		} `json:"textDocument"`
+	}
+	lspDidChangeParams struct {
+		TextDocument   lspDocumentID `json:"textDocument"`
+		ContentChanges []struct {
+			Text string `json:"text"`
+		} `json:"contentChanges"`
+	}
+	lspDidCloseParams struct {
+		TextDocument lspDocumentID `json:"textDocument"`
+	}
+	lspInitializeParams struct {
+		RootURI          string          `json:"rootUri"`
+		RootPath         string          `json:"rootPath"`
+		WorkspaceFolders []lspDocumentID `json:"workspaceFolders"`
+	}
+	lspInitializeResult struct {
+		Capabilities lspServerCapabilities `json:"capabilities"`
+		ServerInfo   lspServerInfo         `json:"serverInfo"`
+	}
+	lspServerCapabilities struct {
+		TextDocumentSync   int                  `json:"textDocumentSync"`
+		HoverProvider      bool                 `json:"hoverProvider"`
+		DefinitionProvider bool                 `json:"definitionProvider"`
+		CompletionProvider lspCompletionOptions `json:"completionProvider"`
+	}
+	lspCompletionOptions struct {
+		TriggerCharacters []string `json:"triggerCharacters"`
+	}
+	lspServerInfo struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	lspDiagnostic struct {
+		Range    lspRange `json:"range"`
+		Severity int      `json:"severity"`
+		Code     string   `json:"code,omitempty"`
+		Source   string   `json:"source"`
+		Message  string   `json:"message"`
+	}
+	lspPublishDiagnosticsParams struct {
+		URI         string          `json:"uri"`
+		Diagnostics []lspDiagnostic `json:"diagnostics"`
+	}
+	lspMarkupContent struct {
+		Kind  string `json:"kind"`
+		Value string `json:"value"`
+	}
+	lspHover struct {
+		Contents lspMarkupContent `json:"contents"`
+		Range    lspRange         `json:"range"`
+	}
+	lspTextEdit struct {
+		Range   lspRange `json:"range"`
+		NewText string   `json:"newText"`
+	}
+	lspCompletionItem struct {
+		Label         string            `json:"label"`
+		Kind          int               `json:"kind"`
+		Detail        string            `json:"detail,omitempty"`
+		Documentation *lspMarkupContent `json:"documentation,omitempty"`
+		TextEdit      lspTextEdit       `json:"textEdit"`
+	}
+)
+
+// lspServer serves one editor session over a JSON-RPC stream
+type lspServer struct {
+	out      io.Writer
+	docs     map[string]string // open documents by URI
+	roots    []string          // directories indexed for template names
+	sample   map[string]any    // --data-file sample data
+	shutdown bool
+}
+
+// runLSP runs the language server on stdin/stdout until the client exits.
+func runLSP(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parseLSPFlags(args)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidLSPArgs, err)
+		return ExitCodeUsageError
+	}
+
+	server := &lspServer{out: stdout, docs: make(map[string]string), roots: cfg.templateDirs}
+	if cfg.dataFilePath != "" {
+		if server.sample, err = loadData("", cfg.dataFilePath); err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidJSON, err)
+			return ExitCodeInputError
+		}
+	}
+
+	return server.serve(bufio.NewReader(stdin), stderr)
+}
+
+func parseLSPFlags(args []string) (*lspConfig, error) {
+	fs := flag.NewFlagSet(CmdNameLSP, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	cfg := &lspConfig{}
+	var templateDirs string
+	var stdio bool
+
+	fs.StringVar(&templateDirs, FlagTemplates, "", "")
+	fs.StringVar(&cfg.dataFilePath, FlagDataFile, "", "")
+	fs.StringVar(&cfg.dataFilePath, FlagDataFileShort, "", "")
+	fs.BoolVar(&stdio, FlagStdio, true, "")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	cfg.templateDirs = splitList(templateDirs)
+	return cfg, nil
+}
+
+// serve reads and dispatches messages until exit or end of input.
+func (s *lspServer) serve(r *bufio.Reader, stderr io.Writer) int {
+	for {
+		body, err := readLSPMessage(r)
+		if errors.Is(err, io.EOF) {
+			return ExitCodeSuccess
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgLSPReadFailed, err)
+			return ExitCodeError
+		}
+
+		var req lspRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.respond(nil, nil, &lspError{Code: LSPErrorCodeParse, Message: ErrMsgLSPParseFailed})
+			continue
+		}
+
+		if req.Method == LSPMethodExit {
+			if !s.shutdown {
+				fmt.Fprintln(stderr, ErrMsgLSPExitNoShutdown)
+				return ExitCodeError
+			}
+			return ExitCodeSuccess
+		}
+		s.handle(&req)
+	}
+}
+
+// handle dispatches one request or notification.
+func (s *lspServer) handle(req *lspRequest) {
+	var result any
+	var rpcErr *lspError
+
+	switch req.Method {
+	case LSPMethodInitialize:
+		result, rpcErr = s.initialize(req.Params)
+	case LSPMethodShutdown:
+		s.shutdown = true
+	case LSPMethodDidOpen:
+		var params lspDidOpenParams
+		if rpcErr = decodeLSPParams(req.Params, &params); rpcErr == nil {
+			s.docs[params.TextDocument.URI] = params.TextDocument.Text
+			s.publishDiagnostics(params.TextDocument.URI)
+		}
+	case LSPMethodDidChange:
+		var params lspDidChangeParams
+		if rpcErr = decodeLSPParams(req.Params, &params); rpcErr == nil && len(params.ContentChanges) > 0 {
+			// Full sync: the last change holds the complete text
+			s.docs[params.TextDocument.URI] = params.ContentChanges[len(params.ContentChanges)-1].Text
+			s.publishDiagnostics(params.TextDocument.URI)
+		}
+	case LSPMethodDidClose:
+		var params lspDidCloseParams
+		if rpcErr = decodeLSPParams(req.Params, &params); rpcErr == nil {
+			delete(s.docs, params.TextDocument.URI)
+			s.publishDiagnostics(params.TextDocument.URI)
+		}
+	case LSPMethodHover:
+		result, rpcErr = s.withPosition(req.Params, s.hover)
+	case LSPMethodCompletion:
+		result, rpcErr = s.withPosition(req.Params, s.completion)
+	case LSPMethodDefinition:
+		result, rpcErr = s.withPosition(req.Params, s.definition)
+	default:
+		rpcErr = &lspError{Code: LSPErrorCodeMethodNotFound, Message: fmt.Sprintf(FmtDetail, ErrMsgLSPMethodNotFound, req.Method)}
+	}
+
+	// Notifications (no id) never get a response
+	if len(req.ID) == 0 {
+		return
+	}
+	s.respond(req.ID, result, rpcErr)
+}
+
+// initialize records the workspace roots and advertises capabilities.
+func (s *lspServer) initialize(raw json.RawMessage) (any, *lspError) {
+	var params lspInitializeParams
+	if rpcErr := decodeLSPParams(raw, &params); rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	switch {
+	case len(params.WorkspaceFolders) > 0:
+		for _, folder := range params.WorkspaceFolders {
+			s.roots = append(s.roots, uriToPath(folder.URI))
+		}
+	case params.RootURI != "":
+		s.roots = append(s.roots, uriToPath(params.RootURI))
+	case params.RootPath != "":
+		s.roots = append(s.roots, params.RootPath)
+	}
+
+	return lspInitializeResult{
+		Capabilities: lspServerCapabilities{
+			TextDocumentSync:   LSPTextDocumentSyncFull,
+			HoverProvider:      true,
+			DefinitionProvider: true,
+			CompletionProvider: lspCompletionOptions{TriggerCharacters: LSPCompletionTriggers},
+		},
+		ServerInfo: lspServerInfo{Name: LSPServerName, Version: getVersionInfo().Version},
+	}, nil
+}
+
+// withPosition decodes text document position params and calls fn with the
+// document text and the byte offset of the position.
+func (s *lspServer) withPosition(raw json.RawMessage, fn func(uri, text string, offset int) any) (any, *lspError) {
+	var params lspPositionParams
+	if rpcErr := decodeLSPParams(raw, &params); rpcErr != nil {
+		return nil, rpcErr
+	}
+	text, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return nil, nil
+	}
+	return fn(params.TextDocument.URI, text, lspOffset(text, params.Position)), nil
+}
+
+// publishDiagnostics sends validation and lint findings for a document.
+// Closed documents get an empty list, clearing their diagnostics.
+func (s *lspServer) publishDiagnostics(uri string) {
+	diagnostics := []lspDiagnostic{}
+	if text, ok := s.docs[uri]; ok {
+		for _, issue := range lintTemplate(text, newLintRuleSet("", "")) {
+			start := lineColumnOffset(text, issue.Line, issue.Column)
+			diagnostics = append(diagnostics, lspDiagnostic{
+				Range:    lspRange{Start: lspPositionAt(text, start), End: lspPositionAt(text, tagEnd(text, start))},
+				Severity: lspSeverity(issue.Severity),
+				Code:     issue.RuleID,
+				Source:   LSPServerName,
+				Message:  issue.Message,
+			})
+		}
+	}
+	s.notify(LSPMethodPublishDiags, lspPublishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+}
+
+// hover documents the tag or attribute name under the cursor.
+func (s *lspServer) hover(_, text string, offset int) any {
+	tc, ok := findTagContext(text, offset)
+	if !ok || tc.inValue {
+		return nil
+	}
+	start, end := wordBounds(text, offset)
+	word := text[start:end]
+
+	var value string
+	if tc.inName {
+		doc, ok := lspTagDocs[word]
+		if !ok {
+			return nil
+		}
+		value = fmt.Sprintf(LSPDocTagFormat, word, doc.doc)
+	} else {
+		attr, ok := lspTagDocs[tc.name].attr(word)
+		if !ok {
+			return nil
+		}
+		value = formatAttrDoc(attr)
+	}
+
+	return lspHover{
+		Contents: lspMarkupContent{Kind: LSPMarkupKindMarkdown, Value: value},
+		Range:    lspRange{Start: lspPositionAt(text, start), End: lspPositionAt(text, end)},
+	}
+}
+
+// completion offers tag names, attribute names, template names and data paths
+// depending on where the cursor is inside a tag.
+func (s *lspServer) completion(uri, text string, offset int) any {
+	items := []lspCompletionItem{}
+	tc, ok := findTagContext(text, offset)
+	if !ok {
+		return items
+	}
+
+	editRange := lspRange{Start: lspPositionAt(text, tc.wordStart), End: lspPositionAt(text, offset)}
+	add := func(label string, kind int, newText, detail string, doc *lspMarkupContent) {
+		if strings.HasPrefix(label, tc.word) {
+			items = append(items, lspCompletionItem{
+				Label:         label,
+				Kind:          kind,
+				Detail:        detail,
+				Documentation: doc,
+				TextEdit:      lspTextEdit{Range: editRange, NewText: newText},
+			})
+		}
+	}
+
+	switch {
+	case tc.inName:
+		for _, name := range sortedTagNames() {
+			add(name, LSPCompletionKindKeyword, name, "", &lspMarkupContent{Kind: LSPMarkupKindMarkdown, Value: lspTagDocs[name].doc})
+		}
+	case tc.inValue && tc.attr == prompty.AttrTemplate:
+		index := s.templateIndex()
+		for _, name := range sortedKeys(index) {
+			add(name, LSPCompletionKindFile, name, index[name], nil)
+		}
+	case tc.inValue && isDataPathAttr(tc.name, tc.attr):
+		for _, path := range s.dataPaths(uri, text) {
+			add(path, LSPCompletionKindVariable, path, "", nil)
+		}
+	case !tc.inValue:
+		for _, attr := range lspTagDocs[tc.name].attrs {
+			add(attr.name, LSPCompletionKindProperty, fmt.Sprintf(LSPAttrInsertFormat, attr.name), "", &lspMarkupContent{Kind: LSPMarkupKindMarkdown, Value: formatAttrDoc(attr)})
+		}
+	}
+	return items
+}
+
+// definition resolves the template named by an include/extends tag.
+func (s *lspServer) definition(_, text string, offset int) any {
+	tc, ok := findTagContext(text, offset)
+	if !ok || !tc.inValue || tc.attr != prompty.AttrTemplate {
+		return nil
+	}
+	if tc.name != prompty.TagNameInclude && tc.name != prompty.TagNameExtends {
+		return nil
+	}
+
+	end := strings.IndexByte(text[offset:], '"')
+	if end < 0 {
+		return nil
+	}
+	path, ok := s.templateIndex()[text[tc.valueStart:offset+end]]
+	if !ok {
+		return nil
+	}
+	return lspLocation{URI: pathToURI(path)}
+}
+
+// templateIndex maps template names to files: open documents and template
+// files under the workspace roots and --templates directories.
+func (s *lspServer) templateIndex() map[string]string {
+	index := make(map[string]string)
+	add := func(path string) {
+		ext := filepath.Ext(path)
+		for _, allowed := range LSPTemplateExtensions {
+			if ext == allowed {
+				name := strings.TrimSuffix(filepath.Base(path), ext)
+				if _, exists := index[name]; !exists {
+					index[name] = path
+				}
+				return
+			}
+		}
+	}
+
+	for uri := range s.docs {
+		add(uriToPath(uri))
+	}
+	for _, root := range s.roots {
+		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if path != root && strings.HasPrefix(d.Name(), LSPHiddenPrefix) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			add(path)
+			return nil
+		})
+	}
+	return index
+}
+
+// dataPaths lists data paths known for a document: frontmatter inputs and
+// sample data, the --data-file sample and a sibling <template>.json file.
+func (s *lspServer) dataPaths(uri, text string) []string {
+	seen := make(map[string]bool)
+	frontmatter := parseLSPFrontmatter(text)
+	for name := range frontmatter.Inputs {
+		seen[name] = true
+	}
+	collectDataPaths(frontmatter.Sample, "", seen)
+	collectDataPaths(s.sample, "", seen)
+
+	if path := uriToPath(uri); path != "" {
+		sibling := strings.TrimSuffix(path, filepath.Ext(path)) + LSPSampleDataSuffix
+		if data, err := loadData("", sibling); err == nil {
+			collectDataPaths(data, "", seen)
+		}
+	}
+	return sortedKeys(seen)
+}
+
+// lspFrontmatter holds the frontmatter fields used for data path completion
+type lspFrontmatter struct {
+	Inputs map[string]any `yaml:"inputs"`
+	Sample map[string]any `yaml:"sample"`
+}
+
+// parseLSPFrontmatter decodes the frontmatter of a document being edited.
+// Unlike prompty.Parse it does not validate, so incomplete documents still
+// provide their inputs and sample data.
+func parseLSPFrontmatter(text string) lspFrontmatter {
+	var frontmatter lspFrontmatter
+	if !strings.HasPrefix(text, prompty.YAMLFrontmatterDelimiter) {
+		return frontmatter
+	}
+	rest := text[len(prompty.YAMLFrontmatterDelimiter):]
+	if end := strings.Index(rest, FmtNewline+prompty.YAMLFrontmatterDelimiter); end >= 0 {
+		_ = yaml.Unmarshal([]byte(rest[:end]), &frontmatter)
+	}
+	return frontmatter
+}
+
+// collectDataPaths adds every key path of data (descending into objects)
+// to seen.
+func collectDataPaths(data map[string]any, prefix string, seen map[string]bool) {
+	for key, value := range data {
+		path := prefix + key
+		seen[path] = true
+		if nested, ok := value.(map[string]any); ok {
+			collectDataPaths(nested, path+LSPPathSeparator, seen)
+		}
+	}
+}
+
+// isDataPathAttr reports whether the attribute holds a data path or an
+// expression over data.
+func isDataPathAttr(tagName, attr string) bool {
+	switch {
+	case tagName == prompty.TagNameVar && attr == prompty.AttrName:
+		return true
+	case tagName == prompty.TagNameFor && attr == prompty.AttrIn:
+		return true
+	case tagName == prompty.TagNameInclude && attr == prompty.AttrWith:
+		return true
+	case attr == prompty.AttrEval:
+		return true
+	}
+	return false
+}
+
+// lspTagContext describes the template tag around the cursor
+type lspTagContext struct {
+	name       string // tag name (empty while it is being typed)
+	inName     bool   // cursor is on the tag name
+	inValue    bool   // cursor is inside a quoted attribute value
+	attr       string // attribute owning the value (when inValue)
+	valueStart int    // offset of the value's first character (when inValue)
+	word       string // partial word before the cursor
+	wordStart  int    // offset where word starts
+}
+
+// findTagContext analyses the open tag containing offset, if any.
+func findTagContext(text string, offset int) (*lspTagContext, bool) {
+	open := strings.LastIndex(text[:offset], prompty.DefaultOpenDelim)
+	if open < 0 || strings.Contains(text[open:offset], prompty.DefaultCloseDelim) {
+		return nil, false
+	}
+
+	nameStart := open + len(prompty.DefaultOpenDelim)
+	if strings.HasPrefix(text[nameStart:offset], "/") {
+		nameStart++
+	}
+	inner := text[nameStart:offset]
+	nameEnd := strings.IndexAny(inner, " \t\r\n")
+	if nameEnd < 0 {
+		return &lspTagContext{inName: true, word: inner, wordStart: nameStart}, true
+	}
+
+	tc := &lspTagContext{name: inner[:nameEnd]}
+	inQuote, escaped := false, false
+	attrStart, lastAttr := -1, ""
+	for i := nameEnd; i < len(inner); i++ {
+		c := inner[i]
+		switch {
+		case inQuote && escaped:
+			escaped = false
+		case inQuote && c == '\\':
+			escaped = true
+		case c == '"':
+			inQuote = !inQuote
+			if inQuote {
+				tc.attr, tc.valueStart = lastAttr, nameStart+i+1
+			}
+		case inQuote:
+		case c == '=':
+			if attrStart >= 0 {
+				lastAttr = strings.TrimSpace(inner[attrStart:i])
+			}
+			attrStart = -1
+		case isWordChar(c):
+			if attrStart < 0 {
+				attrStart = i
+			}
+		default:
+			attrStart = -1
+		}
+	}
+
+	if inQuote {
+		tc.inValue = true
+		tc.wordStart = tc.valueStart
+		if isDataPathAttr(tc.name, tc.attr) {
+			// Complete the path token under the cursor (eval holds expressions)
+			tc.wordStart = offset
+			for tc.wordStart > tc.valueStart && isPathChar(text[tc.wordStart-1]) {
+				tc.wordStart--
+			}
+		}
+	} else {
+		tc.wordStart = offset
+		for tc.wordStart > nameStart+nameEnd && isWordChar(text[tc.wordStart-1]) {
+			tc.wordStart--
+		}
+	}
+	tc.word = text[tc.wordStart:offset]
+	return tc, true
+}
+
+// wordBounds returns the extent of the tag or attribute name around offset.
+func wordBounds(text string, offset int) (int, int) {
+	start, end := offset, offset
+	for start > 0 && isPathChar(text[start-1]) {
+		start--
+	}
+	for end < len(text) && isPathChar(text[end]) {
+		end++
+	}
+	return start, end
+}
+
+// isWordChar reports whether c may appear in an attribute name.
+func isWordChar(c byte) bool {
+	return c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// isPathChar reports whether c may appear in a tag name or data path.
+func isPathChar(c byte) bool {
+	return isWordChar(c) || c == '.'
+}
+
+// tagEnd returns the offset after the tag starting at start, or start when
+// no tag starts there.
+func tagEnd(text string, start int) int {
+	if !strings.HasPrefix(text[start:], prompty.DefaultOpenDelim) {
+		return start
+	}
+	if end := strings.Index(text[start:], prompty.DefaultCloseDelim); end >= 0 {
+		return start + end + len(prompty.DefaultCloseDelim)
+	}
+	return start
+}
+
+// formatAttrDoc renders attribute documentation as markdown.
+func formatAttrDoc(attr lspAttrDoc) string {
+	required := ""
+	if attr.required {
+		required = LSPDocAttrRequired
+	}
+	return fmt.Sprintf(LSPDocAttrFormat, attr.name, required, attr.doc)
+}
+
+// lspSeverity maps lint severity names to LSP diagnostic severities.
+func lspSeverity(name string) int {
+	switch name {
+	case SeverityNameError:
+		return LSPSeverityError
+	case SeverityNameWarning:
+		return LSPSeverityWarning
+	default:
+		return LSPSeverityInformation
+	}
+}
+
+// sortedTagNames returns the documented tag names in order.
+func sortedTagNames() []string {
+	names := make([]string, 0, len(lspTagDocs))
+	for name := range lspTagDocs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lspOffset converts an LSP position (UTF-16 columns) to a byte offset.
+func lspOffset(text string, pos lspPosition) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+	units := 0
+	for i, r := range text[offset:] {
+		if units >= pos.Character || r == '\n' {
+			return offset + i
+		}
+		units += utf16.RuneLen(r)
+	}
+	return len(text)
+}
+
+// lspPositionAt converts a byte offset to an LSP position (UTF-16 columns).
+func lspPositionAt(text string, offset int) lspPosition {
+	offset = min(max(offset, 0), len(text))
+	prefix := text[:offset]
+	lineStart := strings.LastIndexByte(prefix, '\n') + 1
+	character := 0
+	for _, r := range prefix[lineStart:] {
+		character += utf16.RuneLen(r)
+	}
+	return lspPosition{Line: strings.Count(prefix, "\n"), Character: character}
+}
+
+// lineColumnOffset converts a 1-based line and byte column to an offset,
+// clamped to the text.
+func lineColumnOffset(text string, line, column int) int {
+	offset := 0
+	for l := 1; l < line; l++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+	lineEnd := strings.IndexByte(text[offset:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(text) - offset
+	}
+	return offset + min(max(column-1, 0), lineEnd)
+}
+
+// uriToPath converts a file:// URI to a local path ("" for other schemes).
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != LSPFileScheme {
+		return ""
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// pathToURI converts a local path to a file:// URI.
+func pathToURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: LSPFileScheme, Path: filepath.ToSlash(path)}).String()
+}
+
+// decodeLSPParams unmarshals request params into v.
+func decodeLSPParams(raw json.RawMessage, v any) *lspError {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &lspError{Code: LSPErrorCodeInvalidParams, Message: fmt.Sprintf(FmtDetail, ErrMsgLSPInvalidParams, err)}
+	}
+	return nil
+}
+
+// readLSPMessage reads one Content-Length framed message body.
+func readLSPMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, LSPHeaderSeparator)
+		if ok && strings.EqualFold(strings.TrimSpace(name), LSPHeaderContentLength) {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if length < 0 {
+		return nil, errors.New(ErrMsgLSPParseFailed)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// respond writes a response for request id.
+func (s *lspServer) respond(id json.RawMessage, result any, rpcErr *lspError) {
+	resp := lspResponse{JSONRPC: LSPJSONRPCVersion, ID: id, Error: rpcErr}
+	if id == nil {
+		resp.ID = json.RawMessage("null")
+	}
+	if rpcErr == nil {
+		resp.Result, _ = json.Marshal(result)
+	}
+	s.write(resp)
+}
+
+// notify writes a server-to-client notification.
+func (s *lspServer) notify(method string, params any) {
+	s.write(lspNotification{JSONRPC: LSPJSONRPCVersion, Method: method, Params: params})
+}
+
+// write frames and writes one message.
+func (s *lspServer) write(msg any) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(s.out, LSPHeaderFormat, len(body))
+	_, _ = s.out.Write(body)
+}
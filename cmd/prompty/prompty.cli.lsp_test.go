@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lspSession builds a framed client message stream for runLSP
+type lspSession struct {
+	buf    bytes.Buffer
+	nextID int
+}
+
+// request appends a request and returns its id.
+func (s *lspSession) request(method string, params any) int {
+	s.nextID++
+	s.write(map[string]any{"jsonrpc": "2.0", "id": s.nextID, "method": method, "params": params})
+	return s.nextID
+}
+
+// notify appends a notification.
+func (s *lspSession) notify(method string, params any) {
+	s.write(map[string]any{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+func (s *lspSession) write(msg map[string]any) {
+	body, _ := json.Marshal(msg)
+	fmt.Fprintf(&s.buf, LSPHeaderFormat, len(body))
+	s.buf.Write(body)
+}
+
+// lspReply is a decoded server message
+type lspReply struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *lspError       `json:"error"`
+}
+
+// runLSPSession runs the server over the session and decodes its output.
+func runLSPSession(t *testing.T, s *lspSession, args ...string) (int, []lspReply, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := runLSP(args, &s.buf, &stdout, &stderr)
+
+	var replies []lspReply
+	r := bufio.NewReader(&stdout)
+	for {
+		body, err := readLSPMessage(r)
+		if err != nil {
+			break
+		}
+		var reply lspReply
+		require.NoError(t, json.Unmarshal(body, &reply))
+		replies = append(replies, reply)
+	}
+	return code, replies, stderr.String()
+}
+
+// findReply returns the response to request id.
+func findReply(t *testing.T, replies []lspReply, id int) lspReply {
+	t.Helper()
+	for _, r := range replies {
+		if r.ID != nil && *r.ID == id {
+			return r
+		}
+	}
+	require.Failf(t, "no reply", "request %d", id)
+	return lspReply{}
+}
+
+// positionOf returns the LSP position just after marker in text.
+func positionOf(text, marker string) map[string]int {
+	pos := lspPositionAt(text, strings.Index(text, marker)+len(marker))
+	return map[string]int{"line": pos.Line, "character": pos.Character}
+}
+
+func openDoc(s *lspSession, uri, text string) {
+	s.notify(LSPMethodDidOpen, map[string]any{"textDocument": map[string]any{"uri": uri, "languageId": "prompty", "version": 1, "text": text}})
+}
+
+func positionParams(uri string, pos map[string]int) map[string]any {
+	return map[string]any{"textDocument": map[string]any{"uri": uri}, "position": pos}
+}
+
+func completionLabels(t *testing.T, reply lspReply) []string {
+	t.Helper()
+	var items []lspCompletionItem
+	require.NoError(t, json.Unmarshal(reply.Result, &items))
+	labels := make([]string, 0, len(items))
+	for _, item := range items {
+		labels = append(labels, item.Label)
+	}
+	return labels
+}
+
+func TestLSP_Lifecycle(t *testing.T) {
+	s := &lspSession{}
+	initID := s.request(LSPMethodInitialize, map[string]any{"rootUri": pathToURI(t.TempDir())})
+	s.notify("initialized", map[string]any{})
+	unknownID := s.request("workspace/unknown", nil)
+	shutdownID := s.request(LSPMethodShutdown, nil)
+	s.notify(LSPMethodExit, nil)
+
+	code, replies, stderr := runLSPSession(t, s)
+	require.Equal(t, ExitCodeSuccess, code, stderr)
+
+	var init lspInitializeResult
+	require.NoError(t, json.Unmarshal(findReply(t, replies, initID).Result, &init))
+	assert.True(t, init.Capabilities.HoverProvider)
+	assert.True(t, init.Capabilities.DefinitionProvider)
+	assert.Equal(t, LSPTextDocumentSyncFull, init.Capabilities.TextDocumentSync)
+	assert.Equal(t, LSPServerName, init.ServerInfo.Name)
+
+	unknown := findReply(t, replies, unknownID)
+	require.NotNil(t, unknown.Error)
+	assert.Equal(t, LSPErrorCodeMethodNotFound, unknown.Error.Code)
+
+	assert.Nil(t, findReply(t, replies, shutdownID).Error)
+}
+
+func TestLSP_ExitWithoutShutdown(t *testing.T) {
+	s := &lspSession{}
+	s.notify(LSPMethodExit, nil)
+
+	code, _, stderr := runLSPSession(t, s)
+	assert.Equal(t, ExitCodeError, code)
+	assert.Contains(t, stderr, ErrMsgLSPExitNoShutdown)
+}
+
+func TestLSP_Diagnostics(t *testing.T) {
+	uri := pathToURI(filepath.Join(t.TempDir(), "doc.prompty"))
+	s := &lspSession{}
+	s.request(LSPMethodInitialize, map[string]any{})
+	openDoc(s, uri, "Hello {~prompty.if eval=\"x\"~}unclosed")
+	s.notify(LSPMethodDidChange, map[string]any{
+		"textDocument":   map[string]any{"uri": uri, "version": 2},
+		"contentChanges": []map[string]any{{"text": `Hello {~prompty.var name="user" default="x" /~}`}},
+	})
+	s.notify(LSPMethodDidClose, map[string]any{"textDocument": map[string]any{"uri": uri}})
+
+	_, replies, _ := runLSPSession(t, s)
+
+	var published []lspPublishDiagnosticsParams
+	for _, r := range replies {
+		if r.Method == LSPMethodPublishDiags {
+			var params lspPublishDiagnosticsParams
+			require.NoError(t, json.Unmarshal(r.Params, &params))
+			published = append(published, params)
+		}
+	}
+	require.Len(t, published, 3)
+
+	// Unclosed block is an error
+	require.NotEmpty(t, published[0].Diagnostics)
+	assert.Equal(t, LSPSeverityError, published[0].Diagnostics[0].Severity)
+	assert.Equal(t, LSPServerName, published[0].Diagnostics[0].Source)
+
+	// Fixed document and closed document have no diagnostics
+	assert.Empty(t, published[1].Diagnostics)
+	assert.Equal(t, uri, published[2].URI)
+	assert.Empty(t, published[2].Diagnostics)
+}
+
+func TestLSP_Hover(t *testing.T) {
+	uri := pathToURI(filepath.Join(t.TempDir(), "doc.prompty"))
+	text := `{~prompty.for item="x" in="items" limit="3"~}{~/prompty.for~}`
+
+	s := &lspSession{}
+	openDoc(s, uri, text)
+	tagID := s.request(LSPMethodHover, positionParams(uri, positionOf(text, "{~prompty.f")))
+	attrID := s.request(LSPMethodHover, positionParams(uri, positionOf(text, " i")))
+	textID := s.request(LSPMethodHover, positionParams(uri, positionOf(text, `in="ite`)))
+
+	_, replies, _ := runLSPSession(t, s)
+
+	var hover lspHover
+	require.NoError(t, json.Unmarshal(findReply(t, replies, tagID).Result, &hover))
+	assert.Equal(t, LSPMarkupKindMarkdown, hover.Contents.Kind)
+	assert.Contains(t, hover.Contents.Value, "**prompty.for**")
+	assert.Equal(t, 2, hover.Range.Start.Character)
+	assert.Equal(t, 13, hover.Range.End.Character)
+
+	require.NoError(t, json.Unmarshal(findReply(t, replies, attrID).Result, &hover))
+	assert.Contains(t, hover.Contents.Value, "`item` (required)")
+
+	assert.Equal(t, "null", string(findReply(t, replies, textID).Result))
+}
+
+func TestLSP_Completion(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "header.prompty"), []byte("Header"), FilePermissions))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "partials"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "partials", "footer.tmpl"), []byte("Footer"), FilePermissions))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), FilePermissions))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "doc.json"), []byte(`{"order": {"id": 1}}`), FilePermissions))
+	sampleFile := filepath.Join(dir, "sample.data")
+	require.NoError(t, os.WriteFile(sampleFile, []byte(`{"session": "abc"}`), FilePermissions))
+
+	uri := pathToURI(filepath.Join(dir, "doc.prompty"))
+	text := `---
+name: doc
+inputs:
+  query:
+    type: string
+sample:
+  user:
+    name: Alice
+---
+{~prompty.v
+{~prompty.for it
+{~prompty.include template="he
+{~prompty.var name="user.
+{~prompty.if eval="len(or
+`
+
+	s := &lspSession{}
+	s.request(LSPMethodInitialize, map[string]any{"rootUri": pathToURI(dir)})
+	openDoc(s, uri, text)
+	tagID := s.request(LSPMethodCompletion, positionParams(uri, positionOf(text, "{~prompty.v")))
+	attrID := s.request(LSPMethodCompletion, positionParams(uri, positionOf(text, "for it")))
+	templateID := s.request(LSPMethodCompletion, positionParams(uri, positionOf(text, `template="`)))
+	pathID := s.request(LSPMethodCompletion, positionParams(uri, positionOf(text, `name="user.`)))
+	evalID := s.request(LSPMethodCompletion, positionParams(uri, positionOf(text, "len(or")))
+	textID := s.request(LSPMethodCompletion, positionParams(uri, map[string]int{"line": 1, "character": 2}))
+
+	_, replies, stderr := runLSPSession(t, s, "--data-file", sampleFile)
+	require.Empty(t, stderr)
+
+	assert.Equal(t, []string{"prompty.var"}, completionLabels(t, findReply(t, replies, tagID)))
+	assert.Equal(t, []string{"item"}, completionLabels(t, findReply(t, replies, attrID)))
+	assert.Equal(t, []string{"doc", "footer", "header"}, completionLabels(t, findReply(t, replies, templateID)))
+	assert.Equal(t, []string{"user.name"}, completionLabels(t, findReply(t, replies, pathID)))
+	assert.Equal(t, []string{"order", "order.id"}, completionLabels(t, findReply(t, replies, evalID)))
+	assert.Empty(t, completionLabels(t, findReply(t, replies, textID)))
+
+	// Text edits replace only the typed prefix
+	var items []lspCompletionItem
+	require.NoError(t, json.Unmarshal(findReply(t, replies, attrID).Result, &items))
+	assert.Equal(t, `item="`, items[0].TextEdit.NewText)
+	assert.Equal(t, 14, items[0].TextEdit.Range.Start.Character)
+
+	// Data paths come from inputs, sample, --data-file and the sibling JSON file
+	all := &lspServer{sample: map[string]any{"session": "abc"}}
+	paths := all.dataPaths(uri, text)
+	assert.Equal(t, []string{"order", "order.id", "query", "session", "user", "user.name"}, paths)
+}
+
+func TestLSP_Definition(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.prompty")
+	require.NoError(t, os.WriteFile(basePath, []byte("Base"), FilePermissions))
+
+	uri := pathToURI(filepath.Join(dir, "child.prompty"))
+	text := `{~prompty.extends template="base" /~}{~prompty.include template="missing" /~}`
+
+	s := &lspSession{}
+	openDoc(s, uri, text)
+	foundID := s.request(LSPMethodDefinition, positionParams(uri, positionOf(text, `template="ba`)))
+	missingID := s.request(LSPMethodDefinition, positionParams(uri, positionOf(text, `template="mis`)))
+
+	_, replies, _ := runLSPSession(t, s, "--templates", dir)
+
+	var loc lspLocation
+	require.NoError(t, json.Unmarshal(findReply(t, replies, foundID).Result, &loc))
+	assert.Equal(t, pathToURI(basePath), loc.URI)
+	assert.Equal(t, "null", string(findReply(t, replies, missingID).Result))
+}
+
+func TestLSP_PositionConversion(t *testing.T) {
+	text := "aé😀b\nxy"
+
+	tests := []struct {
+		pos    lspPosition
+		offset int
+	}{
+		{lspPosition{Line: 0, Character: 0}, 0},
+		{lspPosition{Line: 0, Character: 2}, 3},
+		{lspPosition{Line: 0, Character: 4}, 7},
+		{lspPosition{Line: 0, Character: 99}, 8},
+		{lspPosition{Line: 1, Character: 1}, 10},
+		{lspPosition{Line: 5, Character: 0}, len(text)},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.offset, lspOffset(text, tt.pos), "%+v", tt.pos)
+	}
+	assert.Equal(t, lspPosition{Line: 0, Character: 4}, lspPositionAt(text, 7))
+	assert.Equal(t, 9, lineColumnOffset(text, 2, 1))
+	assert.Equal(t, 8, lineColumnOffset(text, 1, 99))
+}
+
+func TestLSP_InvalidArgs(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := runLSP([]string{"--bogus"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitCodeUsageError, code)
+	assert.Contains(t, stderr.String(), ErrMsgInvalidLSPArgs)
+
+	stderr.Reset()
+	code = runLSP([]string{"-f", filepath.Join(t.TempDir(), "missing.json")}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitCodeInputError, code)
+}
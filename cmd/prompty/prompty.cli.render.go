@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"time"
 
 	"github.com/itsatony/go-prompty/v2"
 )
@@ -19,6 +21,8 @@ type renderConfig struct {
 	dataFilePath string
 	outputPath   string
 	quiet        bool
+	watch        bool
+	interval     time.Duration
 }
 
 func runRender(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
@@ -28,6 +32,16 @@ func runRender(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 		return ExitCodeUsageError
 	}
 
+	if cfg.watch {
+		if cfg.templatePath == InputSourceStdin {
+			fmt.Fprintln(stderr, ErrMsgWatchStdin)
+			return ExitCodeUsageError
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		return watchRender(ctx, cfg, stdout, stderr)
+	}
+
 	// Read template
 	templateSource, err := readInput(cfg.templatePath, stdin)
 	if err != nil {
@@ -75,6 +89,8 @@ func parseRenderFlags(args []string) (*renderConfig, error) {
 	fs.StringVar(&cfg.outputPath, FlagOutputShort, FlagDefaultOutput, "")
 	fs.BoolVar(&cfg.quiet, FlagQuiet, false, "")
 	fs.BoolVar(&cfg.quiet, FlagQuietShort, false, "")
+	fs.BoolVar(&cfg.watch, FlagWatch, false, "")
+	fs.DurationVar(&cfg.interval, FlagInterval, DefaultWatchInterval, "")
 
 	if err := fs.Parse(args); err != nil {
 		return nil, err
@@ -84,6 +100,9 @@ func parseRenderFlags(args []string) (*renderConfig, error) {
 	if cfg.templatePath == "" {
 		return nil, errors.New(ErrMsgMissingTemplate)
 	}
+	if cfg.interval <= 0 {
+		return nil, errors.New(ErrMsgInvalidInterval)
+	}
 
 	return cfg, nil
 }
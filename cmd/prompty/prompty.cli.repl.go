@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// replConfig holds parsed repl command configuration
+type replConfig struct {
+	templatePath string
+	dataJSON     string
+	dataFilePath string
+}
+
+// replSession holds the state of an interactive session
+type replSession struct {
+	cfg      *replConfig
+	engine   *prompty.Engine
+	source   string
+	data     map[string]any
+	previous string
+	rendered bool
+	stdout   io.Writer
+	stderr   io.Writer
+}
+
+// runRepl loads a template and reads commands from stdin that edit the data
+// and re-render, printing a diff of the output after every change.
+func runRepl(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parseReplFlags(args)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgMissingTemplate, err)
+		return ExitCodeUsageError
+	}
+
+	session := &replSession{cfg: cfg, engine: prompty.MustNew(), stdout: stdout, stderr: stderr}
+	if err := session.loadTemplate(); err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReadFileFailed, err)
+		return ExitCodeInputError
+	}
+	session.data, err = loadData(cfg.dataJSON, cfg.dataFilePath)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidJSON, err)
+		return ExitCodeInputError
+	}
+
+	fmt.Fprintln(stdout, ReplTextWelcome)
+	session.renderFull()
+
+	scanner := bufio.NewScanner(stdin)
+	for {
+		fmt.Fprint(stdout, ReplPrompt)
+		if !scanner.Scan() {
+			fmt.Fprintln(stdout)
+			return ExitCodeSuccess
+		}
+		if !session.handle(strings.TrimSpace(scanner.Text())) {
+			return ExitCodeSuccess
+		}
+	}
+}
+
+func parseReplFlags(args []string) (*replConfig, error) {
+	fs := flag.NewFlagSet(CmdNameRepl, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	cfg := &replConfig{}
+
+	fs.StringVar(&cfg.templatePath, FlagTemplate, "", "")
+	fs.StringVar(&cfg.templatePath, FlagTemplateShort, "", "")
+	fs.StringVar(&cfg.dataJSON, FlagData, "", "")
+	fs.StringVar(&cfg.dataJSON, FlagDataShort, "", "")
+	fs.StringVar(&cfg.dataFilePath, FlagDataFile, "", "")
+	fs.StringVar(&cfg.dataFilePath, FlagDataFileShort, "", "")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.templatePath == "" && len(positional) == 1 {
+		cfg.templatePath = positional[0]
+	}
+
+	if cfg.templatePath == "" {
+		return nil, errors.New(ErrMsgMissingTemplate)
+	}
+	if cfg.templatePath == InputSourceStdin {
+		return nil, errors.New(ErrMsgReplStdin)
+	}
+
+	return cfg, nil
+}
+
+// handle executes one command line. It returns false when the session ends.
+func (s *replSession) handle(line string) bool {
+	cmd, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch cmd {
+	case "":
+		// Empty line - nothing to do
+	case ReplCmdQuit, ReplCmdExit:
+		return false
+	case ReplCmdHelp:
+		fmt.Fprintln(s.stdout, ReplTextHelp)
+	case ReplCmdRender:
+		s.renderFull()
+	case ReplCmdData:
+		jsonBytes, _ := json.MarshalIndent(s.data, "", "  ")
+		fmt.Fprintln(s.stdout, string(jsonBytes))
+	case ReplCmdSet:
+		path, raw, ok := strings.Cut(arg, ReplAssign)
+		path = strings.TrimSpace(path)
+		if !ok || path == "" {
+			fmt.Fprintln(s.stderr, ErrMsgReplSetSyntax)
+			return true
+		}
+		setDataPath(s.data, path, parseReplValue(strings.TrimSpace(raw)))
+		s.renderDiff()
+	case ReplCmdUnset:
+		if arg == "" {
+			fmt.Fprintln(s.stderr, ErrMsgReplUnsetSyntax)
+			return true
+		}
+		unsetDataPath(s.data, arg)
+		s.renderDiff()
+	case ReplCmdLoad:
+		if arg == "" {
+			fmt.Fprintln(s.stderr, ErrMsgReplLoadSyntax)
+			return true
+		}
+		data, err := loadData("", arg)
+		if err != nil {
+			fmt.Fprintf(s.stderr, FmtErrorWithCause, ErrMsgInvalidJSON, err)
+			return true
+		}
+		s.data = data
+		s.renderDiff()
+	case ReplCmdReload:
+		if err := s.loadTemplate(); err != nil {
+			fmt.Fprintf(s.stderr, FmtErrorWithCause, ErrMsgReadFileFailed, err)
+			return true
+		}
+		s.renderDiff()
+	default:
+		fmt.Fprintf(s.stderr, FmtErrorWithDetail, ErrMsgReplUnknownCommand, cmd)
+	}
+	return true
+}
+
+// loadTemplate (re-)reads the template file.
+func (s *replSession) loadTemplate() error {
+	source, err := os.ReadFile(s.cfg.templatePath)
+	if err != nil {
+		return err
+	}
+	s.source = string(source)
+	return nil
+}
+
+// render executes the template against the current data.
+func (s *replSession) render() (string, bool) {
+	result, err := s.engine.Execute(context.Background(), s.source, s.data)
+	if err != nil {
+		fmt.Fprintf(s.stderr, FmtErrorWithCause, ErrMsgExecuteFailed, err)
+		return "", false
+	}
+	return result, true
+}
+
+// renderFull prints the complete rendered output.
+func (s *replSession) renderFull() {
+	result, ok := s.render()
+	if !ok {
+		return
+	}
+	fmt.Fprintln(s.stdout, result)
+	s.previous, s.rendered = result, true
+}
+
+// renderDiff prints the difference to the previously rendered output.
+func (s *replSession) renderDiff() {
+	result, ok := s.render()
+	if !ok {
+		return
+	}
+	switch {
+	case !s.rendered:
+		fmt.Fprintln(s.stdout, result)
+	case result == s.previous:
+		fmt.Fprintln(s.stdout, ReplTextUnchanged)
+	default:
+		fmt.Fprint(s.stdout, diffLines(s.previous, result))
+	}
+	s.previous, s.rendered = result, true
+}
+
+// parseReplValue interprets raw as JSON (numbers, booleans, arrays, objects,
+// quoted strings) and falls back to the literal text.
+func parseReplValue(raw string) any {
+	var value any
+	if err := json.Unmarshal([]byte(raw), &value); err == nil {
+		return value
+	}
+	return raw
+}
+
+// setDataPath assigns value at a dotted path, creating intermediate maps.
+func setDataPath(data map[string]any, path string, value any) {
+	parts := strings.Split(path, ReplPathSeparator)
+	current := data
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			current[part] = next
+		}
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+// unsetDataPath removes the value at a dotted path if present.
+func unsetDataPath(data map[string]any, path string) {
+	parts := strings.Split(path, ReplPathSeparator)
+	current := data
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]any)
+		if !ok {
+			return
+		}
+		current = next
+	}
+	delete(current, parts[len(parts)-1])
+}
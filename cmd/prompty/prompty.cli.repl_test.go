@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepl_Session(t *testing.T) {
+	tmpDir := setupTestData(t)
+	templatePath := filepath.Join(tmpDir, "template.txt")
+
+	script := strings.Join([]string{
+		"set user=Bob",
+		"set user=Bob",
+		`set profile.age=42`,
+		"data",
+		"unset user",
+		"render",
+		"bogus",
+		"quit",
+		"set user=Never",
+	}, "\n")
+
+	var stdout, stderr bytes.Buffer
+	code := runRepl([]string{templatePath, "-d", testDataJSON}, strings.NewReader(script), &stdout, &stderr)
+
+	assert.Equal(t, ExitCodeSuccess, code)
+	out := stdout.String()
+	assert.Contains(t, out, ReplTextWelcome)
+	assert.Contains(t, out, testExpectedOutput)
+	assert.Contains(t, out, "- Hello, Alice!\n+ Hello, Bob!\n")
+	assert.Contains(t, out, ReplTextUnchanged)
+	assert.Contains(t, out, `"age": 42`)
+	assert.NotContains(t, out, "Never")
+	// Unsetting a required variable makes rendering fail; the session continues
+	assert.Contains(t, stderr.String(), ErrMsgExecuteFailed)
+	assert.Contains(t, stderr.String(), ErrMsgReplUnknownCommand)
+}
+
+func TestRepl_ReloadAndLoad(t *testing.T) {
+	tmpDir := setupTestData(t)
+	templatePath := filepath.Join(tmpDir, "template.txt")
+	otherData := filepath.Join(tmpDir, "other.json")
+	require.NoError(t, os.WriteFile(otherData, []byte(`{"user": "Carol"}`), FilePermissions))
+
+	// Rewrite the template before the reload command is read
+	reader := &funcReader{lines: []func() string{
+		func() string { return "load " + otherData + "\n" },
+		func() string {
+			require.NoError(t, os.WriteFile(templatePath, []byte(`Bye, {~prompty.var name="user" /~}.`), FilePermissions))
+			return "reload\n"
+		},
+	}}
+
+	var stdout, stderr bytes.Buffer
+	code := runRepl([]string{"-t", templatePath}, reader, &stdout, &stderr)
+
+	assert.Equal(t, ExitCodeSuccess, code, stderr.String())
+	// Initial render fails without data; the first successful render is printed in full
+	assert.Contains(t, stdout.String(), "prompty> Hello, Carol!\n")
+	assert.Contains(t, stdout.String(), "+ Bye, Carol.")
+}
+
+func TestRepl_Errors(t *testing.T) {
+	tmpDir := setupTestData(t)
+
+	tests := []struct {
+		name     string
+		args     []string
+		script   string
+		wantCode int
+		wantErr  string
+	}{
+		{"missing template", nil, "", ExitCodeUsageError, ErrMsgMissingTemplate},
+		{"stdin template", []string{"-t", "-"}, "", ExitCodeUsageError, ErrMsgReplStdin},
+		{"template not found", []string{filepath.Join(tmpDir, "nope.txt")}, "", ExitCodeInputError, ErrMsgReadFileFailed},
+		{"invalid data", []string{filepath.Join(tmpDir, "template.txt"), "-d", "{"}, "", ExitCodeInputError, ErrMsgInvalidJSON},
+		{"bad set syntax", []string{filepath.Join(tmpDir, "template.txt")}, "set nothing", ExitCodeSuccess, ErrMsgReplSetSyntax},
+		{"execution error", []string{filepath.Join(tmpDir, "invalid.txt")}, "", ExitCodeSuccess, ErrMsgExecuteFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runRepl(tt.args, strings.NewReader(tt.script), &stdout, &stderr)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Contains(t, stderr.String(), tt.wantErr)
+		})
+	}
+}
+
+func TestRepl_DataPaths(t *testing.T) {
+	data := map[string]any{"a": "scalar"}
+
+	setDataPath(data, "a.b.c", parseReplValue("[1, 2]"))
+	setDataPath(data, "flag", parseReplValue("true"))
+	setDataPath(data, "text", parseReplValue("hello world"))
+
+	assert.Equal(t, map[string]any{
+		"a":    map[string]any{"b": map[string]any{"c": []any{float64(1), float64(2)}}},
+		"flag": true,
+		"text": "hello world",
+	}, data)
+
+	unsetDataPath(data, "a.b.c")
+	unsetDataPath(data, "missing.path")
+	assert.Equal(t, map[string]any{}, data["a"].(map[string]any)["b"])
+}
+
+// funcReader yields one line per call, running the producing func lazily so
+// tests can act between commands.
+type funcReader struct {
+	lines []func() string
+	buf   string
+}
+
+func (r *funcReader) Read(p []byte) (int, error) {
+	if r.buf == "" {
+		if len(r.lines) == 0 {
+			return 0, io.EOF
+		}
+		r.buf = r.lines[0]()
+		r.lines = r.lines[1:]
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
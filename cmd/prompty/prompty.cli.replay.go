@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// replayConfig holds parsed replay command configuration
+type replayConfig struct {
+	recordingPath string
+	live          []string
+	format        string
+	outputPath    string
+}
+
+// replayOutput represents JSON output for replay
+type replayOutput struct {
+	Template       string `json:"template,omitempty"`
+	Version        int    `json:"version,omitempty"`
+	RecordedOutput string `json:"recorded_output"`
+	RecordedError  string `json:"recorded_error,omitempty"`
+	*prompty.ReplayResult
+}
+
+func runReplay(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parseReplayFlags(args)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidReplayArgs, err)
+		return ExitCodeUsageError
+	}
+
+	data, err := readInput(cfg.recordingPath, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgLoadRecordingFailed, err)
+		return ExitCodeInputError
+	}
+	recording, err := prompty.ReadRecording(bytes.NewReader(data))
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgLoadRecordingFailed, err)
+		return ExitCodeInputError
+	}
+
+	replay, err := prompty.NewReplayEngine()
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReplayFailed, err)
+		return ExitCodeError
+	}
+	replay.Live(cfg.live...)
+	result, err := replay.Replay(context.Background(), recording)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReplayFailed, err)
+		return ExitCodeError
+	}
+
+	var output []byte
+	if cfg.format == OutputFormatJSON {
+		output, _ = json.MarshalIndent(replayOutput{
+			Template:       recording.Template,
+			Version:        recording.Version,
+			RecordedOutput: recording.Output,
+			RecordedError:  recording.Error,
+			ReplayResult:   result,
+		}, "", "  ")
+		output = append(output, FmtNewline...)
+	} else {
+		output = []byte(result.Output)
+		reportReplay(recording, result, stderr)
+	}
+	if err := writeOutput(cfg.outputPath, output, stdout); err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgWriteOutputFailed, err)
+		return ExitCodeError
+	}
+
+	if !result.Matched {
+		return ExitCodeValidationError
+	}
+	return ExitCodeSuccess
+}
+
+func parseReplayFlags(args []string) (*replayConfig, error) {
+	fs := flag.NewFlagSet(CmdNameReplay, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	cfg := &replayConfig{}
+	var live string
+	var jsonOutput bool
+
+	fs.StringVar(&live, FlagLive, "", "")
+	fs.StringVar(&cfg.format, FlagFormat, FlagDefaultFormat, "")
+	fs.StringVar(&cfg.format, FlagFormatShort, FlagDefaultFormat, "")
+	fs.BoolVar(&jsonOutput, FlagJSON, false, "")
+	fs.StringVar(&cfg.outputPath, FlagOutput, FlagDefaultOutput, "")
+	fs.StringVar(&cfg.outputPath, FlagOutputShort, FlagDefaultOutput, "")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) != 1 {
+		return nil, errors.New(ErrMsgMissingRecording)
+	}
+	cfg.recordingPath = positional[0]
+	cfg.live = splitList(live)
+
+	if jsonOutput {
+		cfg.format = OutputFormatJSON
+	}
+	if cfg.format != OutputFormatText && cfg.format != OutputFormatJSON {
+		return nil, errors.New(ErrMsgInvalidFormat)
+	}
+
+	return cfg, nil
+}
+
+// reportReplay tells whether the replay matches the recording and, if not,
+// how it differs.
+func reportReplay(recording *prompty.ExecutionRecording, result *prompty.ReplayResult, w io.Writer) {
+	if result.Matched {
+		fmt.Fprintln(w, ReplayTextMatched)
+		return
+	}
+	fmt.Fprintln(w, ReplayTextDiffers)
+	if result.Output != recording.Output {
+		fmt.Fprintf(w, ReplayTextOutput, recording.Output, result.Output)
+	}
+	if result.Error != recording.Error {
+		fmt.Fprintf(w, ReplayTextError, recording.Error, result.Error)
+	}
+	for _, call := range result.Unmatched {
+		fmt.Fprintf(w, ReplayTextUnmatched, call.Tag, formatReplayAttributes(call.Attributes))
+	}
+}
+
+// formatReplayAttributes formats tag attributes sorted by name.
+func formatReplayAttributes(attrs map[string]string) string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf(ReplayTextAttribute, name, attrs[name]))
+	}
+	return strings.Join(parts, ReplayAttrSeparator)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/itsatony/go-prompty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRecording records a template that uses a custom resolver and an
+// environment variable, and saves the recording.
+func writeRecording(t *testing.T) string {
+	t.Helper()
+	t.Setenv("PROMPTY_REPLAY_REGION", "eu")
+	engine := prompty.MustNew()
+	engine.MustRegister(prompty.NewResolverFunc("Weather", func(ctx context.Context, execCtx *prompty.Context, attrs prompty.Attributes) (string, error) {
+		return "sunny", nil
+	}, nil))
+	tmpl, err := engine.Parse(`{~prompty.var name="city" /~} ({~prompty.env name="PROMPTY_REPLAY_REGION" /~}): {~Weather /~}`)
+	require.NoError(t, err)
+
+	recorder := prompty.NewExecutionRecorder()
+	_, err = tmpl.Execute(prompty.WithExecutionRecorder(context.Background(), recorder), map[string]any{"city": "Oslo"})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "recording.json")
+	require.NoError(t, prompty.SaveRecording(path, recorder.Recording()))
+	return path
+}
+
+func TestReplay_Text(t *testing.T) {
+	path := writeRecording(t)
+	t.Setenv("PROMPTY_REPLAY_REGION", "us")
+
+	var stdout, stderr bytes.Buffer
+	code := runReplay([]string{path}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeSuccess, code, stderr.String())
+	assert.Equal(t, "Oslo (eu): sunny", stdout.String())
+	assert.Contains(t, stderr.String(), ReplayTextMatched)
+
+	// A live environment lookup sees the changed variable
+	stdout.Reset()
+	stderr.Reset()
+	code = runReplay([]string{"--live", "prompty.env", path}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeValidationError, code)
+	assert.Equal(t, "Oslo (us): sunny", stdout.String())
+	assert.Contains(t, stderr.String(), ReplayTextDiffers)
+	assert.Contains(t, stderr.String(), `replayed: "Oslo (us): sunny"`)
+}
+
+func TestReplay_JSON(t *testing.T) {
+	path := writeRecording(t)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var stdout, stderr bytes.Buffer
+	code := runReplay([]string{"--json", "-"}, bytes.NewReader(data), &stdout, &stderr)
+	assert.Equal(t, ExitCodeSuccess, code, stderr.String())
+
+	var output replayOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &output))
+	assert.True(t, output.Matched)
+	assert.Equal(t, "Oslo (eu): sunny", output.RecordedOutput)
+	assert.Equal(t, output.RecordedOutput, output.Output)
+}
+
+func TestReplay_Unmatched(t *testing.T) {
+	path := writeRecording(t)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	changed := strings.Replace(string(data), `{~Weather /~}`, `{~Weather day=\"monday\" /~}`, 1)
+
+	var stdout, stderr bytes.Buffer
+	code := runReplay([]string{"-"}, strings.NewReader(changed), &stdout, &stderr)
+	assert.Equal(t, ExitCodeValidationError, code)
+	assert.Contains(t, stderr.String(), `no recorded result for Weather day="monday"`)
+}
+
+func TestReplay_Errors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, ExitCodeUsageError, runReplay(nil, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), ErrMsgMissingRecording)
+
+	stderr.Reset()
+	assert.Equal(t, ExitCodeUsageError, runReplay([]string{"-F", "yaml", "r.json"}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), ErrMsgInvalidFormat)
+
+	stderr.Reset()
+	assert.Equal(t, ExitCodeInputError, runReplay([]string{filepath.Join(t.TempDir(), "missing.json")}, nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), ErrMsgLoadRecordingFailed)
+
+	stderr.Reset()
+	assert.Equal(t, ExitCodeInputError, runReplay([]string{"-"}, strings.NewReader(`{"format_version": "0"}`), &stdout, &stderr))
+	assert.Contains(t, stderr.String(), prompty.ErrMsgRecordingFormatVersion)
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// providerEndpoint describes how to call a provider's chat API
+type providerEndpoint struct {
+	defaultBaseURL string
+	baseURLEnv     string
+	apiKeyEnvs     []string // first non-empty wins
+	path           string   // may contain %s for the model (Gemini)
+	modelInPath    bool
+	authHeader     string
+	authPrefix     string
+	extraHeaders   map[string]string
+	extractText    func(body map[string]any) string
+}
+
+// providerEndpoints lists the providers supported by `prompty run`
+var providerEndpoints = map[string]providerEndpoint{
+	prompty.ProviderOpenAI: {
+		defaultBaseURL: RunOpenAIBaseURL,
+		baseURLEnv:     EnvOpenAIBaseURL,
+		apiKeyEnvs:     []string{EnvOpenAIAPIKey},
+		path:           RunPathChatCompletions,
+		authHeader:     RunHeaderAuthorization,
+		authPrefix:     RunAuthBearerPrefix,
+		extractText:    extractChatCompletionText,
+	},
+	prompty.ProviderMistral: {
+		defaultBaseURL: RunMistralBaseURL,
+		baseURLEnv:     EnvMistralBaseURL,
+		apiKeyEnvs:     []string{EnvMistralAPIKey},
+		path:           RunPathChatCompletions,
+		authHeader:     RunHeaderAuthorization,
+		authPrefix:     RunAuthBearerPrefix,
+		extractText:    extractChatCompletionText,
+	},
+	prompty.ProviderVLLM: {
+		baseURLEnv:  EnvVLLMBaseURL,
+		apiKeyEnvs:  []string{EnvVLLMAPIKey},
+		path:        RunPathChatCompletions,
+		authHeader:  RunHeaderAuthorization,
+		authPrefix:  RunAuthBearerPrefix,
+		extractText: extractChatCompletionText,
+	},
+	prompty.ProviderAnthropic: {
+		defaultBaseURL: RunAnthropicBaseURL,
+		baseURLEnv:     EnvAnthropicBaseURL,
+		apiKeyEnvs:     []string{EnvAnthropicAPIKey},
+		path:           RunPathAnthropicMessages,
+		authHeader:     RunHeaderAnthropicKey,
+		extraHeaders:   map[string]string{RunHeaderAnthropicVersion: RunAnthropicAPIVersion},
+		extractText:    extractAnthropicText,
+	},
+	prompty.ProviderGemini: {
+		defaultBaseURL: RunGeminiBaseURL,
+		baseURLEnv:     EnvGeminiBaseURL,
+		apiKeyEnvs:     []string{EnvGeminiAPIKey, EnvGoogleAPIKey},
+		path:           RunPathGeminiGenerate,
+		modelInPath:    true,
+		authHeader:     RunHeaderGeminiKey,
+		extractText:    extractGeminiText,
+	},
+}
+
+func init() {
+	providerEndpoints[prompty.ProviderGoogle] = providerEndpoints[prompty.ProviderGemini]
+}
+
+// runRun compiles an agent, sends it to the provider and prints the reply.
+func runRun(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parseCompileFlags(CmdNameRun, args)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgMissingAgent, err)
+		return ExitCodeUsageError
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+
+	compiled, code := compileAgent(ctx, cfg, stdin, stderr)
+	if code != ExitCodeSuccess {
+		return code
+	}
+
+	if compiled.Execution == nil {
+		compiled.Execution = &prompty.ExecutionConfig{}
+	}
+	if cfg.provider != "" {
+		compiled.Execution.Provider = cfg.provider
+	}
+	provider := compiled.Execution.GetEffectiveProvider()
+	if _, ok := providerEndpoints[provider]; !ok {
+		fmt.Fprintf(stderr, FmtErrorWithDetail, ErrMsgRunUnsupportedProvider, provider)
+		return ExitCodeUsageError
+	}
+
+	// Honor the retry and fallback policy of execution.fallback
+	var response map[string]any
+	var endpoint providerEndpoint
+	err = prompty.ExecuteWithFallback(ctx, compiled.Execution, func(ctx context.Context, exec *prompty.ExecutionConfig) error {
+		attempt := *compiled
+		attempt.Execution = exec
+		provider := exec.GetEffectiveProvider()
+		var ok bool
+		if endpoint, ok = providerEndpoints[provider]; !ok {
+			return fmt.Errorf(FmtDetail, ErrMsgRunUnsupportedProvider, provider)
+		}
+		payload, err := attempt.ToProviderPayload(provider)
+		if err != nil {
+			return fmt.Errorf(FmtDetail, ErrMsgProviderPayloadFailed, err)
+		}
+		response, err = callProvider(ctx, endpoint, provider, payload)
+		return err
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgRunRequestFailed, err)
+		return ExitCodeError
+	}
+
+	var output []byte
+	if cfg.rawOutput {
+		output, _ = json.MarshalIndent(response, "", "  ")
+		output = append(output, FmtNewline...)
+	} else {
+		output = []byte(endpoint.extractText(response) + FmtNewline)
+	}
+
+	if err := writeOutput(cfg.outputPath, output, stdout); err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgWriteOutputFailed, err)
+		return ExitCodeError
+	}
+	return ExitCodeSuccess
+}
+
+// callProvider posts payload to the provider and decodes the JSON response.
+func callProvider(ctx context.Context, endpoint providerEndpoint, provider string, payload map[string]any) (map[string]any, error) {
+	baseURL := envOrDefault(endpoint.baseURLEnv, endpoint.defaultBaseURL)
+	if baseURL == "" {
+		return nil, fmt.Errorf(FmtDetail, ErrMsgRunMissingBaseURL, endpoint.baseURLEnv)
+	}
+
+	apiKey := ""
+	for _, env := range endpoint.apiKeyEnvs {
+		if apiKey = os.Getenv(env); apiKey != "" {
+			break
+		}
+	}
+	if apiKey == "" && endpoint.defaultBaseURL != "" {
+		return nil, fmt.Errorf(FmtDetail, ErrMsgRunMissingAPIKey, strings.Join(endpoint.apiKeyEnvs, RunEnvListSeparator))
+	}
+
+	// Streaming responses are not supported; the reply is read as one JSON document
+	delete(payload, prompty.ParamKeyStream)
+
+	path := endpoint.path
+	if endpoint.modelInPath {
+		model, _ := payload[prompty.ParamKeyModel].(string)
+		if model == "" {
+			return nil, errors.New(ErrMsgRunMissingModel)
+		}
+		delete(payload, prompty.ParamKeyModel)
+		path = fmt.Sprintf(path, model)
+	}
+	if provider == prompty.ProviderAnthropic {
+		if _, ok := payload[prompty.ParamKeyMaxTokens]; !ok {
+			payload[prompty.ParamKeyMaxTokens] = RunDefaultAnthropicMaxTokens
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(baseURL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(RunHeaderContentType, RunContentTypeJSON)
+	if apiKey != "" {
+		req.Header.Set(endpoint.authHeader, endpoint.authPrefix+apiKey)
+	}
+	for k, v := range endpoint.extraHeaders {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, &prompty.ProviderCallError{Condition: prompty.RetryOnConnection, Err: err}
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, prompty.NewProviderStatusError(resp.StatusCode,
+			fmt.Errorf(RunStatusErrorFormat, resp.StatusCode, strings.TrimSpace(string(data))))
+	}
+
+	var result map[string]any
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// extractChatCompletionText returns choices[0].message.content.
+func extractChatCompletionText(body map[string]any) string {
+	choices, _ := body["choices"].([]any)
+	if len(choices) == 0 {
+		return ""
+	}
+	choice, _ := choices[0].(map[string]any)
+	message, _ := choice["message"].(map[string]any)
+	content, _ := message["content"].(string)
+	return content
+}
+
+// extractAnthropicText joins the text blocks of an Anthropic message.
+func extractAnthropicText(body map[string]any) string {
+	blocks, _ := body["content"].([]any)
+	var parts []string
+	for _, b := range blocks {
+		block, _ := b.(map[string]any)
+		if text, ok := block["text"].(string); ok {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// extractGeminiText joins the text parts of the first Gemini candidate.
+func extractGeminiText(body map[string]any) string {
+	candidates, _ := body["candidates"].([]any)
+	if len(candidates) == 0 {
+		return ""
+	}
+	candidate, _ := candidates[0].(map[string]any)
+	content, _ := candidate["content"].(map[string]any)
+	rawParts, _ := content["parts"].([]any)
+	var parts []string
+	for _, p := range rawParts {
+		part, _ := p.(map[string]any)
+		if text, ok := part["text"].(string); ok {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "")
+}
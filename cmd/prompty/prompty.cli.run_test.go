@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// providerRecorder captures the last request received by a fake provider
+type providerRecorder struct {
+	path    string
+	headers http.Header
+	body    map[string]any
+}
+
+// newProviderServer starts a fake provider API that records requests and
+// replies with response.
+func newProviderServer(t *testing.T, status int, response string) (*httptest.Server, *providerRecorder) {
+	t.Helper()
+	rec := &providerRecorder{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec.path = r.URL.Path
+		rec.headers = r.Header.Clone()
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &rec.body)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server, rec
+}
+
+func TestRun_Providers(t *testing.T) {
+	agentPath := writeAgentFile(t)
+
+	tests := []struct {
+		name     string
+		provider string
+		baseEnv  string
+		keyEnv   string
+		response string
+		wantPath string
+		check    func(t *testing.T, rec *providerRecorder)
+	}{
+		{
+			name:     "openai",
+			provider: "",
+			baseEnv:  EnvOpenAIBaseURL,
+			keyEnv:   EnvOpenAIAPIKey,
+			response: `{"choices": [{"message": {"role": "assistant", "content": "Because."}}]}`,
+			wantPath: RunPathChatCompletions,
+			check: func(t *testing.T, rec *providerRecorder) {
+				assert.Equal(t, RunAuthBearerPrefix+"secret", rec.headers.Get(RunHeaderAuthorization))
+				assert.Equal(t, "gpt-4", rec.body["model"])
+			},
+		},
+		{
+			name:     "anthropic",
+			provider: "anthropic",
+			baseEnv:  EnvAnthropicBaseURL,
+			keyEnv:   EnvAnthropicAPIKey,
+			response: `{"content": [{"type": "text", "text": "Because."}]}`,
+			wantPath: RunPathAnthropicMessages,
+			check: func(t *testing.T, rec *providerRecorder) {
+				assert.Equal(t, "secret", rec.headers.Get(RunHeaderAnthropicKey))
+				assert.Equal(t, RunAnthropicAPIVersion, rec.headers.Get(RunHeaderAnthropicVersion))
+				assert.Equal(t, float64(RunDefaultAnthropicMaxTokens), rec.body["max_tokens"])
+			},
+		},
+		{
+			name:     "gemini",
+			provider: "gemini",
+			baseEnv:  EnvGeminiBaseURL,
+			keyEnv:   EnvGoogleAPIKey,
+			response: `{"candidates": [{"content": {"parts": [{"text": "Because."}]}}]}`,
+			wantPath: "/models/gpt-4:generateContent",
+			check: func(t *testing.T, rec *providerRecorder) {
+				assert.Equal(t, "secret", rec.headers.Get(RunHeaderGeminiKey))
+				assert.NotContains(t, rec.body, "model")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, rec := newProviderServer(t, http.StatusOK, tt.response)
+			t.Setenv(tt.baseEnv, server.URL)
+			t.Setenv(EnvGeminiAPIKey, "")
+			t.Setenv(tt.keyEnv, "secret")
+
+			args := []string{agentPath, "-d", `{"query": "why?"}`}
+			if tt.provider != "" {
+				args = append(args, "--provider", tt.provider)
+			}
+
+			var stdout, stderr bytes.Buffer
+			code := runRun(args, strings.NewReader(""), &stdout, &stderr)
+			require.Equal(t, ExitCodeSuccess, code, stderr.String())
+			assert.Equal(t, "Because.\n", stdout.String())
+			assert.Equal(t, tt.wantPath, rec.path)
+			assert.Equal(t, RunContentTypeJSON, rec.headers.Get(RunHeaderContentType))
+			tt.check(t, rec)
+		})
+	}
+}
+
+func TestRun_RawAndVLLM(t *testing.T) {
+	agentPath := writeAgentFile(t)
+	server, rec := newProviderServer(t, http.StatusOK, `{"id": "x", "choices": []}`)
+	t.Setenv(EnvVLLMBaseURL, server.URL)
+	t.Setenv(EnvVLLMAPIKey, "")
+
+	var stdout, stderr bytes.Buffer
+	code := runRun([]string{agentPath, "-d", `{"query": "why?"}`, "-p", "vllm", "--model", "llama", "--raw"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+	assert.Contains(t, stdout.String(), `"id": "x"`)
+	assert.Equal(t, "llama", rec.body["model"])
+	// vLLM needs no API key
+	assert.Empty(t, rec.headers.Get(RunHeaderAuthorization))
+}
+
+func TestRun_Errors(t *testing.T) {
+	agentPath := writeAgentFile(t)
+	data := `{"query": "why?"}`
+	server, _ := newProviderServer(t, http.StatusUnauthorized, `{"error": "bad key"}`)
+
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		wantCode int
+		wantErr  string
+	}{
+		{"missing agent", nil, nil, ExitCodeUsageError, ErrMsgMissingAgent},
+		{"unsupported provider", []string{agentPath, "-d", data, "-p", "cohere"}, nil, ExitCodeUsageError, ErrMsgRunUnsupportedProvider},
+		{"missing api key", []string{agentPath, "-d", data}, map[string]string{EnvOpenAIAPIKey: ""}, ExitCodeError, EnvOpenAIAPIKey},
+		{"missing vllm base url", []string{agentPath, "-d", data, "-p", "vllm"}, map[string]string{EnvVLLMBaseURL: ""}, ExitCodeError, EnvVLLMBaseURL},
+		{"error status", []string{agentPath, "-d", data}, map[string]string{EnvOpenAIBaseURL: server.URL, EnvOpenAIAPIKey: "wrong"}, ExitCodeError, "status 401"},
+		{"compile failure", []string{agentPath}, nil, ExitCodeValidationError, ErrMsgCompileFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			var stdout, stderr bytes.Buffer
+			code := runRun(tt.args, strings.NewReader(""), &stdout, &stderr)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Contains(t, stderr.String(), tt.wantErr)
+		})
+	}
+}
+
+func TestRun_Fallback(t *testing.T) {
+	source := strings.Replace(testAgentContent, "  temperature: 0.5\n", `  temperature: 0.5
+  fallback:
+    targets:
+      - {model: gpt-4o-mini}
+    max_retries: 1
+    initial_backoff_seconds: 0
+`, 1)
+	agentPath := filepath.Join(t.TempDir(), "agent.md")
+	require.NoError(t, os.WriteFile(agentPath, []byte(source), FilePermissions))
+
+	var models []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &body)
+		model, _ := body["model"].(string)
+		models = append(models, model)
+		if model == "gpt-4" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "Because."}}]}`))
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv(EnvOpenAIBaseURL, server.URL)
+	t.Setenv(EnvOpenAIAPIKey, "secret")
+
+	var stdout, stderr bytes.Buffer
+	code := runRun([]string{agentPath, "-d", `{"query": "why?"}`}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+	assert.Equal(t, "Because.\n", stdout.String())
+	assert.Equal(t, []string{"gpt-4", "gpt-4", "gpt-4o-mini"}, models)
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// skillConfig holds parsed skill command configuration
+type skillConfig struct {
+	path         string
+	resourcesDir string
+	output       string
+	to           string
+	name         string
+	tags         string
+	status       string
+	driver       string
+	dsn          string
+}
+
+func runSkill(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, ErrMsgNoSkillSubcommand)
+		fmt.Fprintln(stderr, HelpSkillUsage)
+		return ExitCodeUsageError
+	}
+
+	var sub func(cfg *skillConfig, stdin io.Reader, stdout, stderr io.Writer) int
+	switch args[0] {
+	case SkillCmdExport:
+		sub = runSkillExport
+	case SkillCmdImport:
+		sub = runSkillImport
+	default:
+		fmt.Fprintf(stderr, FmtErrorWithDetail, ErrMsgUnknownSkillSubcommand, args[0])
+		fmt.Fprintln(stderr, HelpSkillUsage)
+		return ExitCodeUsageError
+	}
+
+	cfg, err := parseSkillFlags(args[0], args[1:])
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidSkillArgs, err)
+		return ExitCodeUsageError
+	}
+	return sub(cfg, stdin, stdout, stderr)
+}
+
+func parseSkillFlags(subcommand string, args []string) (*skillConfig, error) {
+	fs := flag.NewFlagSet(CmdNameSkill+" "+subcommand, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	cfg := &skillConfig{}
+
+	fs.StringVar(&cfg.resourcesDir, FlagResources, "", "")
+	fs.StringVar(&cfg.output, FlagOut, "", "")
+	fs.StringVar(&cfg.output, FlagOutput, "", "")
+	fs.StringVar(&cfg.output, FlagOutputShort, "", "")
+	fs.StringVar(&cfg.to, FlagTo, "", "")
+	fs.StringVar(&cfg.name, FlagName, "", "")
+	fs.StringVar(&cfg.name, FlagNameShort, "", "")
+	fs.StringVar(&cfg.tags, FlagTags, "", "")
+	fs.StringVar(&cfg.status, FlagStatus, "", "")
+	fs.StringVar(&cfg.driver, FlagDriver, envOrDefault(EnvStoreDriver, prompty.StorageDriverNameFilesystem), "")
+	fs.StringVar(&cfg.dsn, FlagDSN, os.Getenv(EnvStoreDSN), "")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) != 1 {
+		return nil, errors.New(ErrMsgMissingDocument)
+	}
+	cfg.path = positional[0]
+
+	if subcommand == SkillCmdImport {
+		if cfg.to == "" {
+			return nil, errors.New(ErrMsgMissingImportTarget)
+		}
+		if cfg.to == SkillImportTargetStorage && cfg.dsn == "" && cfg.driver != prompty.StorageDriverNameMemory {
+			return nil, errors.New(ErrMsgMissingDSN)
+		}
+		if cfg.status != "" && !prompty.DeploymentStatus(cfg.status).IsValid() {
+			return nil, fmt.Errorf(FmtDetail, ErrMsgInvalidStatusArg, cfg.status)
+		}
+	}
+
+	return cfg, nil
+}
+
+// runSkillExport packages a document and the files of --resources as a
+// skill archive.
+func runSkillExport(cfg *skillConfig, stdin io.Reader, stdout, stderr io.Writer) int {
+	result, code := importSkillDocument(cfg.path, stdin, stderr)
+	if code != ExitCodeSuccess {
+		return code
+	}
+
+	resources := result.Resources
+	if cfg.resourcesDir != "" {
+		dirResources, err := readResourceDir(cfg.resourcesDir)
+		if err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReadResourcesFailed, err)
+			return ExitCodeInputError
+		}
+		for name, content := range dirResources {
+			resources[name] = content
+		}
+	}
+
+	archive, err := prompty.ExportSkillDirectory(result.Prompt, resources)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgSkillExportFailed, err)
+		return ExitCodeError
+	}
+
+	output := cfg.output
+	if output == "" {
+		output = result.Prompt.Name + SkillArchiveExtension
+	}
+	if err := writeOutput(output, archive, stdout); err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgWriteOutputFailed, err)
+		return ExitCodeError
+	}
+	if output != FlagDefaultOutput {
+		fmt.Fprintf(stdout, SkillTextExported+FmtNewline, result.Prompt.Name, len(resources), output)
+	}
+	return ExitCodeSuccess
+}
+
+// runSkillImport imports a skill archive or document into a directory or,
+// with --to storage, the storage backend.
+func runSkillImport(cfg *skillConfig, stdin io.Reader, stdout, stderr io.Writer) int {
+	result, code := importSkillDocument(cfg.path, stdin, stderr)
+	if code != ExitCodeSuccess {
+		return code
+	}
+	document, err := result.Prompt.ExportFull()
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgImportDocumentFailed, err)
+		return ExitCodeValidationError
+	}
+
+	if cfg.to != SkillImportTargetStorage {
+		if err := writeSkillDirectory(cfg.to, skillDocumentFilename(result.Prompt), document, result.Resources); err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgWriteImportFailed, err)
+			return ExitCodeError
+		}
+		fmt.Fprintf(stdout, SkillTextImported+FmtNewline, result.Prompt.Name, len(result.Resources), cfg.to)
+		return ExitCodeSuccess
+	}
+
+	engine, err := openStorageEngine(cfg.driver, cfg.dsn)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgOpenStorageFailed, err)
+		return ExitCodeError
+	}
+	defer engine.Close()
+
+	name := cfg.name
+	if name == "" {
+		name = result.Prompt.Name
+	}
+	tmpl := &prompty.StoredTemplate{
+		Name:   name,
+		Source: string(document),
+		Tags:   splitList(cfg.tags),
+		Status: prompty.DeploymentStatus(cfg.status),
+	}
+	if err := engine.Save(context.Background(), tmpl); err != nil {
+		fmt.Fprintf(stderr, FmtFileErrorFormat+FmtNewline, cfg.path, ErrMsgStorePushFailed, err)
+		return ExitCodeValidationError
+	}
+	fmt.Fprintf(stdout, StoreTextPushed+FmtNewline, tmpl.Name, tmpl.Version)
+	if len(result.Resources) > 0 {
+		fmt.Fprintf(stderr, SkillTextResourcesSkipped+FmtNewline, len(result.Resources))
+	}
+	return ExitCodeSuccess
+}
+
+// importSkillDocument reads and imports a document or archive (which
+// validates it), reporting conversion warnings.
+func importSkillDocument(path string, stdin io.Reader, stderr io.Writer) (*prompty.ImportResult, int) {
+	source, err := readInput(path, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReadFileFailed, err)
+		return nil, ExitCodeInputError
+	}
+
+	result, err := prompty.Import(source, path)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtFileErrorFormat+FmtNewline, path, ErrMsgImportDocumentFailed, err)
+		return nil, ExitCodeValidationError
+	}
+	if result.Report != nil {
+		for _, w := range result.Report.Warnings {
+			fmt.Fprintf(stderr, SkillTextConversionIssue, path, w.String())
+		}
+	}
+	if result.Resources == nil {
+		result.Resources = make(map[string][]byte)
+	}
+	return result, ExitCodeSuccess
+}
+
+// readResourceDir reads the files under dir by their slash-separated path
+// relative to dir. Hidden files and directories are skipped.
+func readResourceDir(dir string) (map[string][]byte, error) {
+	resources := make(map[string][]byte)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), LSPHiddenPrefix) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		resources[filepath.ToSlash(rel)] = content
+		return nil
+	})
+	return resources, err
+}
+
+// writeSkillDirectory writes the document and its resources into dir. All
+// resource paths are checked before anything is written, so an archive
+// cannot write outside dir.
+func writeSkillDirectory(dir, filename string, document []byte, resources map[string][]byte) error {
+	for name := range resources {
+		if !filepath.IsLocal(filepath.FromSlash(name)) {
+			return fmt.Errorf(FmtDetail, ErrMsgUnsafeResourcePath, name)
+		}
+	}
+
+	if err := os.MkdirAll(dir, SkillDirPermissions); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, filename), document, FilePermissions); err != nil {
+		return err
+	}
+	for name, content := range resources {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), SkillDirPermissions); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, content, FilePermissions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skillDocumentFilename names the document of a skill directory by its type.
+func skillDocumentFilename(prompt *prompty.Prompt) string {
+	switch prompt.EffectiveType() {
+	case prompty.DocumentTypeAgent:
+		return prompty.DocumentFilenameAgent
+	case prompty.DocumentTypePrompt:
+		return prompty.DocumentFilenamePrompt
+	default:
+		return prompty.DocumentFilenameSkill
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/itsatony/go-prompty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSkillContent = `---
+name: research
+description: Researches a topic
+---
+Research {~prompty.var name="topic" default="anything" /~}.
+`
+
+// writeSkillFiles writes a skill document and a resources directory with a
+// nested file and a hidden file.
+func writeSkillFiles(t *testing.T) (document, resources string) {
+	t.Helper()
+	dir := t.TempDir()
+	document = filepath.Join(dir, "SKILL.md")
+	resources = filepath.Join(dir, "resources")
+	require.NoError(t, os.MkdirAll(filepath.Join(resources, "scripts"), 0o755))
+	files := map[string]string{
+		document: testSkillContent,
+		filepath.Join(resources, "scripts", "fetch.py"): "print('fetch')",
+		filepath.Join(resources, "notes.md"):            "# Notes",
+		filepath.Join(resources, ".DS_Store"):           "junk",
+	}
+	for path, content := range files {
+		require.NoError(t, os.WriteFile(path, []byte(content), FilePermissions))
+	}
+	return document, resources
+}
+
+// exportTestSkill exports the test skill and returns the archive path.
+func exportTestSkill(t *testing.T) string {
+	t.Helper()
+	document, resources := writeSkillFiles(t)
+	archive := filepath.Join(t.TempDir(), "research.zip")
+
+	var stdout, stderr bytes.Buffer
+	code := runSkill([]string{SkillCmdExport, document, "--resources", resources, "--out", archive}, nil, &stdout, &stderr)
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+	assert.Contains(t, stdout.String(), "exported research with 2 resource(s) to "+archive)
+	return archive
+}
+
+func TestSkill_Export(t *testing.T) {
+	archive := exportTestSkill(t)
+
+	reader, err := zip.OpenReader(archive)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	names := make([]string, 0, len(reader.File))
+	for _, f := range reader.File {
+		names = append(names, f.Name)
+	}
+	assert.ElementsMatch(t, []string{prompty.DocumentFilenameSkill, "scripts/fetch.py", "notes.md"}, names)
+}
+
+func TestSkill_ImportDirectory(t *testing.T) {
+	archive := exportTestSkill(t)
+	target := filepath.Join(t.TempDir(), "skills", "research")
+
+	var stdout, stderr bytes.Buffer
+	code := runSkill([]string{SkillCmdImport, archive, "--to", target}, nil, &stdout, &stderr)
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+	assert.Contains(t, stdout.String(), "imported research with 2 resource(s)")
+
+	script, err := os.ReadFile(filepath.Join(target, "scripts", "fetch.py"))
+	require.NoError(t, err)
+	assert.Equal(t, "print('fetch')", string(script))
+
+	document, err := os.ReadFile(filepath.Join(target, prompty.DocumentFilenameSkill))
+	require.NoError(t, err)
+	prompt, err := prompty.Parse(document)
+	require.NoError(t, err)
+	assert.Equal(t, "research", prompt.Name)
+	assert.Contains(t, prompt.Body, `{~prompty.var name="topic"`)
+}
+
+func TestSkill_ImportStorage(t *testing.T) {
+	archive := exportTestSkill(t)
+	dsn := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+	code := runSkill([]string{SkillCmdImport, archive, "--to", SkillImportTargetStorage, "--dsn", dsn, "--tags", "research"}, nil, &stdout, &stderr)
+	require.Equal(t, ExitCodeSuccess, code, stderr.String())
+	assert.Contains(t, stdout.String(), "pushed research v1")
+	assert.Contains(t, stderr.String(), "2 resource(s) not saved")
+
+	storage, err := prompty.OpenStorage(prompty.StorageDriverNameFilesystem, dsn)
+	require.NoError(t, err)
+	defer storage.Close()
+	stored, err := storage.Get(context.Background(), "research")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"research"}, stored.Tags)
+	assert.Contains(t, stored.Source, "description: Researches a topic")
+}
+
+func TestSkill_ImportRejectsUnsafePaths(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range map[string]string{prompty.DocumentFilenameSkill: testSkillContent, "../escape.txt": "x"} {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "evil.zip")
+	require.NoError(t, os.WriteFile(archive, buf.Bytes(), FilePermissions))
+	target := filepath.Join(dir, "out")
+
+	var stdout, stderr bytes.Buffer
+	code := runSkill([]string{SkillCmdImport, archive, "--to", target}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeError, code)
+	assert.Contains(t, stderr.String(), ErrMsgUnsafeResourcePath)
+	assert.NoDirExists(t, target)
+	assert.NoFileExists(t, filepath.Join(dir, "escape.txt"))
+}
+
+func TestSkill_Errors(t *testing.T) {
+	document, _ := writeSkillFiles(t)
+	invalid := filepath.Join(t.TempDir(), "SKILL.md")
+	require.NoError(t, os.WriteFile(invalid, []byte("---\nname: Not A Slug\ndescription: x\n---\nbody"), FilePermissions))
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+		msg  string
+	}{
+		{"no subcommand", nil, ExitCodeUsageError, ErrMsgNoSkillSubcommand},
+		{"unknown subcommand", []string{"publish"}, ExitCodeUsageError, ErrMsgUnknownSkillSubcommand},
+		{"document required", []string{SkillCmdExport}, ExitCodeUsageError, ErrMsgMissingDocument},
+		{"import target required", []string{SkillCmdImport, document}, ExitCodeUsageError, ErrMsgMissingImportTarget},
+		{"storage dsn required", []string{SkillCmdImport, document, "--to", SkillImportTargetStorage, "--dsn", ""}, ExitCodeUsageError, ErrMsgMissingDSN},
+		{"missing file", []string{SkillCmdExport, "missing.md"}, ExitCodeInputError, ErrMsgReadFileFailed},
+		{"missing resources", []string{SkillCmdExport, document, "--resources", "missing"}, ExitCodeInputError, ErrMsgReadResourcesFailed},
+		{"invalid document", []string{SkillCmdExport, invalid}, ExitCodeValidationError, ErrMsgImportDocumentFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runSkill(tt.args, nil, &stdout, &stderr)
+			assert.Equal(t, tt.code, code)
+			assert.Contains(t, stderr.String(), tt.msg)
+		})
+	}
+}
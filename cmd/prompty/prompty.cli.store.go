@@ -0,0 +1,416 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// storeConfig holds parsed store command configuration
+type storeConfig struct {
+	driver     string
+	dsn        string
+	name       string
+	version    int
+	output     string
+	prefix     string
+	tags       string
+	status     string
+	jsonOutput bool
+	args       []string
+}
+
+// storeListEntry represents a stored template in JSON list output
+type storeListEntry struct {
+	Name        string `json:"name"`
+	Version     int    `json:"version"`
+	Status      string `json:"status,omitempty"`
+	CreatedBy   string `json:"created_by,omitempty"`
+	UpdatedAt   string `json:"updated_at"`
+	ContentHash string `json:"content_hash,omitempty"`
+}
+
+// storeVersionEntry represents a template version in JSON versions output
+type storeVersionEntry struct {
+	Version     int      `json:"version"`
+	Status      string   `json:"status,omitempty"`
+	CreatedBy   string   `json:"created_by,omitempty"`
+	CreatedAt   string   `json:"created_at"`
+	Labels      []string `json:"labels"`
+	Current     bool     `json:"current"`
+	ContentHash string   `json:"content_hash,omitempty"`
+}
+
+// storeSubcommand runs one store subcommand against an opened engine
+type storeSubcommand func(ctx context.Context, engine *prompty.StorageEngine, cfg *storeConfig, stdin io.Reader, stdout, stderr io.Writer) int
+
+// storeSubcommands maps subcommand names to their implementations
+var storeSubcommands = map[string]storeSubcommand{
+	StoreCmdPush:     runStorePush,
+	StoreCmdPull:     runStorePull,
+	StoreCmdList:     runStoreList,
+	StoreCmdVersions: runStoreVersions,
+	StoreCmdRollback: runStoreRollback,
+	StoreCmdDelete:   runStoreDelete,
+	StoreCmdDiff:     runStoreDiff,
+}
+
+func runStore(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, ErrMsgNoStoreSubcommand)
+		fmt.Fprintln(stderr, HelpStoreUsage)
+		return ExitCodeUsageError
+	}
+
+	sub, ok := storeSubcommands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, FmtErrorWithDetail, ErrMsgUnknownStoreSubcommand, args[0])
+		fmt.Fprintln(stderr, HelpStoreUsage)
+		return ExitCodeUsageError
+	}
+
+	cfg, err := parseStoreFlags(args[0], args[1:])
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidStoreArgs, err)
+		return ExitCodeUsageError
+	}
+
+	engine, err := openStorageEngine(cfg.driver, cfg.dsn)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgOpenStorageFailed, err)
+		return ExitCodeError
+	}
+	defer engine.Close()
+
+	return sub(context.Background(), engine, cfg, stdin, stdout, stderr)
+}
+
+// openStorageEngine opens the storage backend of driver and dsn.
+func openStorageEngine(driver, dsn string) (*prompty.StorageEngine, error) {
+	storage, err := prompty.OpenStorage(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	engine, err := prompty.NewStorageEngine(prompty.StorageEngineConfig{Storage: storage})
+	if err != nil {
+		storage.Close()
+		return nil, err
+	}
+	return engine, nil
+}
+
+func parseStoreFlags(subcommand string, args []string) (*storeConfig, error) {
+	fs := flag.NewFlagSet(CmdNameStore+" "+subcommand, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	cfg := &storeConfig{}
+
+	fs.StringVar(&cfg.driver, FlagDriver, envOrDefault(EnvStoreDriver, prompty.StorageDriverNameFilesystem), "")
+	fs.StringVar(&cfg.dsn, FlagDSN, os.Getenv(EnvStoreDSN), "")
+	fs.StringVar(&cfg.name, FlagName, "", "")
+	fs.StringVar(&cfg.name, FlagNameShort, "", "")
+	fs.IntVar(&cfg.version, FlagVersion, 0, "")
+	fs.StringVar(&cfg.output, FlagOutput, FlagDefaultOutput, "")
+	fs.StringVar(&cfg.output, FlagOutputShort, FlagDefaultOutput, "")
+	fs.StringVar(&cfg.prefix, FlagPrefix, "", "")
+	fs.StringVar(&cfg.tags, FlagTags, "", "")
+	fs.StringVar(&cfg.status, FlagStatus, "", "")
+	fs.BoolVar(&cfg.jsonOutput, FlagJSON, false, "")
+
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	cfg.args = args
+
+	if cfg.dsn == "" && cfg.driver != prompty.StorageDriverNameMemory {
+		return nil, errors.New(ErrMsgMissingDSN)
+	}
+	if cfg.version < 0 {
+		return nil, fmt.Errorf(FmtDetail, ErrMsgInvalidVersionArg, strconv.Itoa(cfg.version))
+	}
+	if cfg.status != "" && !prompty.DeploymentStatus(cfg.status).IsValid() {
+		return nil, fmt.Errorf(FmtDetail, ErrMsgInvalidStatusArg, cfg.status)
+	}
+
+	return cfg, nil
+}
+
+// envOrDefault returns the environment variable value or def when unset.
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// runStorePush saves each template file as a new version. The template name
+// is --name (single file only) or the file name without its extension.
+func runStorePush(ctx context.Context, engine *prompty.StorageEngine, cfg *storeConfig, stdin io.Reader, stdout, stderr io.Writer) int {
+	paths, err := expandInputPaths(cfg.args)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReadFileFailed, err)
+		return ExitCodeInputError
+	}
+	if cfg.name != "" && len(paths) > 1 {
+		fmt.Fprintln(stderr, ErrMsgNameWithMultipleFiles)
+		return ExitCodeUsageError
+	}
+
+	for _, path := range paths {
+		name := cfg.name
+		if name == "" {
+			if path == InputSourceStdin {
+				fmt.Fprintln(stderr, ErrMsgNameRequiredStdin)
+				return ExitCodeUsageError
+			}
+			name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+
+		source, err := readInput(path, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReadFileFailed, err)
+			return ExitCodeInputError
+		}
+
+		tmpl := &prompty.StoredTemplate{
+			Name:   name,
+			Source: string(source),
+			Tags:   splitList(cfg.tags),
+			Status: prompty.DeploymentStatus(cfg.status),
+		}
+		if err := engine.Save(ctx, tmpl); err != nil {
+			fmt.Fprintf(stderr, FmtFileErrorFormat+FmtNewline, path, ErrMsgStorePushFailed, err)
+			return ExitCodeValidationError
+		}
+		fmt.Fprintf(stdout, StoreTextPushed+FmtNewline, tmpl.Name, tmpl.Version)
+	}
+	return ExitCodeSuccess
+}
+
+// runStorePull writes the source of the latest (or --version) template.
+func runStorePull(ctx context.Context, engine *prompty.StorageEngine, cfg *storeConfig, _ io.Reader, stdout, stderr io.Writer) int {
+	name, code := requireStoreName(cfg, stderr)
+	if code != ExitCodeSuccess {
+		return code
+	}
+
+	tmpl, err := getStoredTemplate(ctx, engine, name, cfg.version)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgStoreGetFailed, err)
+		return ExitCodeError
+	}
+
+	if err := writeOutput(cfg.output, []byte(tmpl.Source), stdout); err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgWriteOutputFailed, err)
+		return ExitCodeError
+	}
+	return ExitCodeSuccess
+}
+
+// runStoreList lists the latest version of each stored template.
+func runStoreList(ctx context.Context, engine *prompty.StorageEngine, cfg *storeConfig, _ io.Reader, stdout, stderr io.Writer) int {
+	query := &prompty.TemplateQuery{
+		NamePrefix: cfg.prefix,
+		Tags:       splitList(cfg.tags),
+		Status:     prompty.DeploymentStatus(cfg.status),
+	}
+	templates, err := engine.List(ctx, query)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgStoreListFailed, err)
+		return ExitCodeError
+	}
+
+	if cfg.jsonOutput {
+		entries := make([]storeListEntry, 0, len(templates))
+		for _, tmpl := range templates {
+			entries = append(entries, storeListEntry{
+				Name:        tmpl.Name,
+				Version:     tmpl.Version,
+				Status:      string(tmpl.Status),
+				CreatedBy:   tmpl.CreatedBy,
+				UpdatedAt:   tmpl.UpdatedAt.Format(time.RFC3339),
+				ContentHash: tmpl.ContentHash,
+			})
+		}
+		jsonBytes, _ := json.MarshalIndent(entries, "", "  ")
+		fmt.Fprintln(stdout, string(jsonBytes))
+		return ExitCodeSuccess
+	}
+
+	if len(templates) == 0 {
+		fmt.Fprintln(stdout, StoreTextNoTemplates)
+		return ExitCodeSuccess
+	}
+	for _, tmpl := range templates {
+		fmt.Fprintf(stdout, StoreTextListFormat+FmtNewline, tmpl.Name, tmpl.Version, tmpl.Status, tmpl.UpdatedAt.Format(time.RFC3339))
+	}
+	return ExitCodeSuccess
+}
+
+// runStoreVersions shows the version history of a template.
+func runStoreVersions(ctx context.Context, engine *prompty.StorageEngine, cfg *storeConfig, _ io.Reader, stdout, stderr io.Writer) int {
+	name, code := requireStoreName(cfg, stderr)
+	if code != ExitCodeSuccess {
+		return code
+	}
+
+	history, err := engine.GetVersionHistory(ctx, name)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgStoreGetFailed, err)
+		return ExitCodeError
+	}
+
+	if cfg.jsonOutput {
+		entries := make([]storeVersionEntry, 0, len(history.Versions))
+		for _, v := range history.Versions {
+			entries = append(entries, storeVersionEntry{
+				Version:     v.Version,
+				Status:      string(v.Status),
+				CreatedBy:   v.CreatedBy,
+				CreatedAt:   v.CreatedAt.Format(time.RFC3339),
+				Labels:      v.Labels,
+				Current:     v.IsCurrent,
+				ContentHash: v.ContentHash,
+			})
+		}
+		jsonBytes, _ := json.MarshalIndent(entries, "", "  ")
+		fmt.Fprintln(stdout, string(jsonBytes))
+		return ExitCodeSuccess
+	}
+
+	for _, v := range history.Versions {
+		marker := ""
+		if v.IsCurrent {
+			marker = StoreTextCurrentMarker
+		}
+		fmt.Fprintf(stdout, StoreTextVersionFormat+FmtNewline, v.Version, v.Status, v.CreatedAt.Format(time.RFC3339), strings.Join(v.Labels, ","), marker)
+	}
+	return ExitCodeSuccess
+}
+
+// runStoreRollback creates a new (draft) version from an older version.
+func runStoreRollback(ctx context.Context, engine *prompty.StorageEngine, cfg *storeConfig, _ io.Reader, stdout, stderr io.Writer) int {
+	name, version, code := requireStoreNameVersion(cfg, stderr)
+	if code != ExitCodeSuccess {
+		return code
+	}
+
+	tmpl, err := engine.RollbackToVersion(ctx, name, version)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgStoreRollbackFailed, err)
+		return ExitCodeError
+	}
+	fmt.Fprintf(stdout, StoreTextRolledBack+FmtNewline, name, version, tmpl.Version)
+	return ExitCodeSuccess
+}
+
+// runStoreDelete deletes a template, or a single version with --version.
+func runStoreDelete(ctx context.Context, engine *prompty.StorageEngine, cfg *storeConfig, _ io.Reader, stdout, stderr io.Writer) int {
+	name, code := requireStoreName(cfg, stderr)
+	if code != ExitCodeSuccess {
+		return code
+	}
+
+	if cfg.version > 0 {
+		if err := engine.DeleteVersion(ctx, name, cfg.version); err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgStoreDeleteFailed, err)
+			return ExitCodeError
+		}
+		fmt.Fprintf(stdout, StoreTextDeletedVersion+FmtNewline, name, cfg.version)
+		return ExitCodeSuccess
+	}
+
+	if err := engine.Delete(ctx, name); err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgStoreDeleteFailed, err)
+		return ExitCodeError
+	}
+	fmt.Fprintf(stdout, StoreTextDeleted+FmtNewline, name)
+	return ExitCodeSuccess
+}
+
+// runStoreDiff compares two versions; the new version defaults to the latest.
+func runStoreDiff(ctx context.Context, engine *prompty.StorageEngine, cfg *storeConfig, _ io.Reader, stdout, stderr io.Writer) int {
+	if len(cfg.args) < 2 || len(cfg.args) > 3 {
+		fmt.Fprintln(stderr, ErrMsgDiffArgs)
+		return ExitCodeUsageError
+	}
+	name := cfg.args[0]
+	versions := make([]int, 0, 2)
+	for _, arg := range cfg.args[1:] {
+		v, err := strconv.Atoi(arg)
+		if err != nil || v < 1 {
+			fmt.Fprintf(stderr, FmtErrorWithDetail, ErrMsgInvalidVersionArg, arg)
+			return ExitCodeUsageError
+		}
+		versions = append(versions, v)
+	}
+	if len(versions) == 1 {
+		latest, err := engine.Get(ctx, name)
+		if err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgStoreGetFailed, err)
+			return ExitCodeError
+		}
+		versions = append(versions, latest.Version)
+	}
+
+	diff, err := engine.CompareVersions(ctx, name, versions[0], versions[1])
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgStoreGetFailed, err)
+		return ExitCodeError
+	}
+	fmt.Fprint(stdout, diff.String())
+	return ExitCodeSuccess
+}
+
+// requireStoreName returns the single template name argument.
+func requireStoreName(cfg *storeConfig, stderr io.Writer) (string, int) {
+	if len(cfg.args) != 1 {
+		fmt.Fprintln(stderr, ErrMsgStoreNameRequired)
+		return "", ExitCodeUsageError
+	}
+	return cfg.args[0], ExitCodeSuccess
+}
+
+// requireStoreNameVersion returns the template name and version arguments.
+func requireStoreNameVersion(cfg *storeConfig, stderr io.Writer) (string, int, int) {
+	if len(cfg.args) != 2 {
+		fmt.Fprintln(stderr, ErrMsgStoreNameVersionRequired)
+		return "", 0, ExitCodeUsageError
+	}
+	version, err := strconv.Atoi(cfg.args[1])
+	if err != nil || version < 1 {
+		fmt.Fprintf(stderr, FmtErrorWithDetail, ErrMsgInvalidVersionArg, cfg.args[1])
+		return "", 0, ExitCodeUsageError
+	}
+	return cfg.args[0], version, ExitCodeSuccess
+}
+
+// getStoredTemplate returns the latest version, or a specific one if version > 0.
+func getStoredTemplate(ctx context.Context, engine *prompty.StorageEngine, name string, version int) (*prompty.StoredTemplate, error) {
+	if version > 0 {
+		return engine.GetVersion(ctx, name, version)
+	}
+	return engine.Get(ctx, name)
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ListSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/itsatony/go-prompty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	storeTestV1 = "Hello {~prompty.var name=\"user\" /~}\n"
+	storeTestV2 = "Hi {~prompty.var name=\"user\" default=\"there\" /~}\n"
+)
+
+// runStoreCmd runs a store subcommand and returns exit code, stdout and stderr
+func runStoreCmd(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(append([]string{CmdNameStore}, args...), strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestStore_Workflow(t *testing.T) {
+	storeDir := t.TempDir()
+	dsn := "--dsn=" + storeDir
+	dir := writeFmtTestFiles(t, map[string]string{"greeting.prompty": storeTestV1})
+	path := filepath.Join(dir, "greeting.prompty")
+
+	// push v1 from file, v2 from stdin
+	code, stdout, stderr := runStoreCmd(t, "", StoreCmdPush, dsn, path)
+	require.Equal(t, ExitCodeSuccess, code, stderr)
+	assert.Contains(t, stdout, "pushed greeting v1")
+
+	code, stdout, stderr = runStoreCmd(t, storeTestV2, StoreCmdPush, dsn, "--name", "greeting", "-")
+	require.Equal(t, ExitCodeSuccess, code, stderr)
+	assert.Contains(t, stdout, "pushed greeting v2")
+
+	// pull latest and a specific version
+	code, stdout, _ = runStoreCmd(t, "", StoreCmdPull, dsn, "greeting")
+	require.Equal(t, ExitCodeSuccess, code)
+	assert.Equal(t, storeTestV2, stdout)
+
+	code, stdout, _ = runStoreCmd(t, "", StoreCmdPull, dsn, "greeting", "--version", "1")
+	require.Equal(t, ExitCodeSuccess, code)
+	assert.Equal(t, storeTestV1, stdout)
+
+	// list
+	code, stdout, _ = runStoreCmd(t, "", StoreCmdList, dsn, "--json")
+	require.Equal(t, ExitCodeSuccess, code)
+	var entries []storeListEntry
+	require.NoError(t, json.Unmarshal([]byte(stdout), &entries))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "greeting", entries[0].Name)
+	assert.Equal(t, 2, entries[0].Version)
+
+	// versions
+	code, stdout, _ = runStoreCmd(t, "", StoreCmdVersions, dsn, "greeting")
+	require.Equal(t, ExitCodeSuccess, code)
+	assert.Contains(t, stdout, "v1")
+	assert.Contains(t, stdout, "v2")
+	assert.Contains(t, stdout, StoreTextCurrentMarker)
+
+	// diff against latest
+	code, stdout, _ = runStoreCmd(t, "", StoreCmdDiff, dsn, "greeting", "1")
+	require.Equal(t, ExitCodeSuccess, code)
+	assert.Contains(t, stdout, "Version 1 -> 2")
+
+	// rollback creates v3 from v1
+	code, stdout, stderr = runStoreCmd(t, "", StoreCmdRollback, dsn, "greeting", "1")
+	require.Equal(t, ExitCodeSuccess, code, stderr)
+	assert.Contains(t, stdout, "rolled back greeting to v1 as v3")
+
+	code, stdout, _ = runStoreCmd(t, "", StoreCmdPull, dsn, "greeting")
+	require.Equal(t, ExitCodeSuccess, code)
+	assert.Equal(t, storeTestV1, stdout)
+
+	// delete a version, then the template
+	code, _, _ = runStoreCmd(t, "", StoreCmdDelete, dsn, "greeting", "--version", "2")
+	require.Equal(t, ExitCodeSuccess, code)
+	code, _, _ = runStoreCmd(t, "", StoreCmdPull, dsn, "greeting", "--version", "2")
+	assert.Equal(t, ExitCodeError, code)
+
+	code, _, _ = runStoreCmd(t, "", StoreCmdDelete, dsn, "greeting")
+	require.Equal(t, ExitCodeSuccess, code)
+	code, stdout, _ = runStoreCmd(t, "", StoreCmdList, dsn)
+	require.Equal(t, ExitCodeSuccess, code)
+	assert.Contains(t, stdout, StoreTextNoTemplates)
+}
+
+func TestStore_PushGlob(t *testing.T) {
+	storeDir := t.TempDir()
+	dir := writeFmtTestFiles(t, map[string]string{
+		"a.prompty": storeTestV1,
+		"b.prompty": storeTestV2,
+	})
+
+	code, stdout, stderr := runStoreCmd(t, "", StoreCmdPush, "--dsn", storeDir, "--tags", "team,public", filepath.Join(dir, "*.prompty"))
+	require.Equal(t, ExitCodeSuccess, code, stderr)
+	assert.Contains(t, stdout, "pushed a v1")
+	assert.Contains(t, stdout, "pushed b v1")
+
+	code, stdout, _ = runStoreCmd(t, "", StoreCmdList, "--dsn", storeDir, "--tags", "public")
+	require.Equal(t, ExitCodeSuccess, code)
+	assert.Contains(t, stdout, "a ")
+	assert.Contains(t, stdout, "b ")
+}
+
+func TestStore_EnvironmentDefaults(t *testing.T) {
+	storeDir := t.TempDir()
+	t.Setenv(EnvStoreDriver, prompty.StorageDriverNameFilesystem)
+	t.Setenv(EnvStoreDSN, storeDir)
+
+	code, _, stderr := runStoreCmd(t, storeTestV1, StoreCmdPush, "-n", "env", "-")
+	require.Equal(t, ExitCodeSuccess, code, stderr)
+
+	code, stdout, _ := runStoreCmd(t, "", StoreCmdPull, "env")
+	require.Equal(t, ExitCodeSuccess, code)
+	assert.Equal(t, storeTestV1, stdout)
+}
+
+func TestStore_HTTPDriver(t *testing.T) {
+	backend := prompty.NewMemoryStorage()
+	server := httptest.NewServer(prompty.NewStorageHTTPHandler(backend))
+	defer server.Close()
+
+	code, _, stderr := runStoreCmd(t, storeTestV1, StoreCmdPush, "--driver", prompty.StorageDriverNameHTTP, "--dsn", server.URL, "-n", "remote", "-")
+	require.Equal(t, ExitCodeSuccess, code, stderr)
+
+	code, stdout, _ := runStoreCmd(t, "", StoreCmdPull, "--driver", prompty.StorageDriverNameHTTP, "--dsn", server.URL, "remote")
+	require.Equal(t, ExitCodeSuccess, code)
+	assert.Equal(t, storeTestV1, stdout)
+}
+
+func TestStore_Errors(t *testing.T) {
+	storeDir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		wantCode int
+		wantErr  string
+	}{
+		{"no subcommand", nil, "", ExitCodeUsageError, ErrMsgNoStoreSubcommand},
+		{"unknown subcommand", []string{"bogus"}, "", ExitCodeUsageError, ErrMsgUnknownStoreSubcommand},
+		{"missing dsn", []string{StoreCmdList, "--driver", prompty.StorageDriverNameFilesystem}, "", ExitCodeUsageError, ErrMsgMissingDSN},
+		{"unknown driver", []string{StoreCmdList, "--driver", "nope", "--dsn", "x"}, "", ExitCodeError, ErrMsgOpenStorageFailed},
+		{"invalid status", []string{StoreCmdList, "--dsn", storeDir, "--status", "bogus"}, "", ExitCodeUsageError, ErrMsgInvalidStatusArg},
+		{"pull without name", []string{StoreCmdPull, "--dsn", storeDir}, "", ExitCodeUsageError, ErrMsgStoreNameRequired},
+		{"pull missing template", []string{StoreCmdPull, "--dsn", storeDir, "missing"}, "", ExitCodeError, ErrMsgStoreGetFailed},
+		{"push stdin without name", []string{StoreCmdPush, "--dsn", storeDir, "-"}, storeTestV1, ExitCodeUsageError, ErrMsgNameRequiredStdin},
+		{"push invalid template", []string{StoreCmdPush, "--dsn", storeDir, "-n", "bad", "-"}, "{~prompty.if eval=\"x\"~}", ExitCodeValidationError, ErrMsgStorePushFailed},
+		{"push missing file", []string{StoreCmdPush, "--dsn", storeDir, "/nonexistent/x.prompty"}, "", ExitCodeInputError, ErrMsgReadFileFailed},
+		{"rollback bad version", []string{StoreCmdRollback, "--dsn", storeDir, "x", "zero"}, "", ExitCodeUsageError, ErrMsgInvalidVersionArg},
+		{"diff missing args", []string{StoreCmdDiff, "--dsn", storeDir, "x"}, "", ExitCodeUsageError, ErrMsgDiffArgs},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvStoreDSN, "")
+			code, _, stderr := runStoreCmd(t, tt.stdin, tt.args...)
+			assert.Equal(t, tt.wantCode, code, stderr)
+			assert.Contains(t, stderr, tt.wantErr)
+		})
+	}
+}
+
+func TestStore_Help(t *testing.T) {
+	var stdout bytes.Buffer
+	code := runHelp([]string{CmdNameStore}, &stdout)
+	assert.Equal(t, ExitCodeSuccess, code)
+	assert.Contains(t, stdout.String(), StoreCmdRollback)
+}
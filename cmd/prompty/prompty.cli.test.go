@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// testConfig holds parsed test command configuration
+type testConfig struct {
+	templatePaths []string
+	format        string
+	verbose       bool
+}
+
+// testFileReport is the self-test report of one file
+type testFileReport struct {
+	File string `json:"file"`
+	*prompty.SelfTestReport
+}
+
+// testOutput represents JSON output for test
+type testOutput struct {
+	Passed  bool             `json:"passed"`
+	Reports []testFileReport `json:"reports"`
+}
+
+func runTest(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parseTestFlags(args)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgMissingTemplate, err)
+		return ExitCodeUsageError
+	}
+
+	paths, err := expandInputPaths(cfg.templatePaths)
+	if err != nil {
+		fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReadFileFailed, err)
+		return ExitCodeInputError
+	}
+
+	output := testOutput{Passed: true, Reports: make([]testFileReport, 0, len(paths))}
+	for _, path := range paths {
+		templateSource, err := readInput(path, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReadFileFailed, err)
+			return ExitCodeInputError
+		}
+
+		report, err := runSelfTests(string(templateSource))
+		if err != nil {
+			fmt.Fprintf(stderr, TestTextFileErrorFormat, ErrMsgSelfTestFailed, path, err)
+			return ExitCodeError
+		}
+		output.Passed = output.Passed && report.OK()
+		output.Reports = append(output.Reports, testFileReport{File: path, SelfTestReport: report})
+	}
+
+	if cfg.format == OutputFormatJSON {
+		jsonBytes, _ := json.MarshalIndent(output, "", "  ")
+		fmt.Fprintln(stdout, string(jsonBytes))
+	} else {
+		outputTestText(output, cfg.verbose, stdout)
+	}
+
+	if !output.Passed {
+		return ExitCodeValidationError
+	}
+	return ExitCodeSuccess
+}
+
+func parseTestFlags(args []string) (*testConfig, error) {
+	fs := flag.NewFlagSet(CmdNameTest, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	cfg := &testConfig{}
+	var templatePath string
+	var jsonOutput bool
+
+	fs.StringVar(&templatePath, FlagTemplate, "", "")
+	fs.StringVar(&templatePath, FlagTemplateShort, "", "")
+	fs.StringVar(&cfg.format, FlagFormat, FlagDefaultFormat, "")
+	fs.StringVar(&cfg.format, FlagFormatShort, FlagDefaultFormat, "")
+	fs.BoolVar(&jsonOutput, FlagJSON, false, "")
+	fs.BoolVar(&cfg.verbose, FlagVerbose, false, "")
+	fs.BoolVar(&cfg.verbose, FlagVerboseShort, false, "")
+
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if templatePath != "" {
+		cfg.templatePaths = append(cfg.templatePaths, templatePath)
+	}
+	cfg.templatePaths = append(cfg.templatePaths, files...)
+	if len(cfg.templatePaths) == 0 {
+		return nil, errors.New(ErrMsgMissingTemplate)
+	}
+
+	if jsonOutput {
+		cfg.format = OutputFormatJSON
+	}
+	if cfg.format != OutputFormatText && cfg.format != OutputFormatJSON {
+		return nil, errors.New(ErrMsgInvalidFormat)
+	}
+
+	return cfg, nil
+}
+
+// runSelfTests parses a prompt document and runs the tests in its
+// frontmatter. Documents without a frontmatter have no tests.
+func runSelfTests(source string) (*prompty.SelfTestReport, error) {
+	prompt, err := prompty.Parse([]byte(source))
+	if err != nil {
+		return nil, err
+	}
+	return prompt.RunSelfTests(context.Background(), prompty.MustNew())
+}
+
+// outputTestText prints one line per test, the failures of failed tests
+// and, with verbose, the output of every test.
+func outputTestText(output testOutput, verbose bool, stdout io.Writer) {
+	passed, failed := 0, 0
+	for _, report := range output.Reports {
+		fmt.Fprintf(stdout, TestTextFileHeader+FmtNewline, report.File)
+		if len(report.Results) == 0 {
+			fmt.Fprintln(stdout, TestTextNoTests)
+		}
+		for _, result := range report.Results {
+			status := TestStatusPass
+			if !result.Passed {
+				status = TestStatusFail
+			}
+			fmt.Fprintf(stdout, TestTextResultFormat+FmtNewline, status, result.Name, result.Duration)
+			for _, failure := range result.Failures {
+				fmt.Fprintf(stdout, TestTextFailureFormat+FmtNewline, failure)
+			}
+			if verbose || !result.Passed {
+				fmt.Fprintf(stdout, TestTextOutputFormat+FmtNewline, result.Output)
+			}
+		}
+		passed += report.Passed
+		failed += report.Failed
+	}
+	fmt.Fprintf(stdout, TestTextSummary+FmtNewline, passed, failed)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCmdPrompt = `---
+name: greeter
+description: Greets a user
+type: prompt
+tests:
+  - name: greets by name
+    inputs: {user: Ada}
+    expect:
+      contains: ["Hello Ada"]
+%s---
+Hello {~prompty.var name="user" default="you" /~}!`
+
+const testCmdFailing = `  - name: expects bye
+    expect:
+      matches: ['^Bye']
+`
+
+func writeTestPrompt(t *testing.T, dir, name, extraTests string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	content := []byte(fmt.Sprintf(testCmdPrompt, extraTests))
+	require.NoError(t, os.WriteFile(path, content, FilePermissions))
+	return path
+}
+
+func TestTest_Passing(t *testing.T) {
+	path := writeTestPrompt(t, t.TempDir(), "greeter.prompty", "")
+
+	var stdout, stderr bytes.Buffer
+	code := runTest([]string{path}, nil, &stdout, &stderr)
+
+	assert.Equal(t, ExitCodeSuccess, code, stderr.String())
+	assert.Contains(t, stdout.String(), TestStatusPass+" greets by name")
+	assert.Contains(t, stdout.String(), "1 passed, 0 failed")
+}
+
+func TestTest_FailingJSON(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPrompt(t, dir, "a.prompty", "")
+	writeTestPrompt(t, dir, "b.prompty", testCmdFailing)
+
+	var stdout, stderr bytes.Buffer
+	code := runTest([]string{"--json", filepath.Join(dir, "*.prompty")}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeValidationError, code, stderr.String())
+
+	var output struct {
+		Passed  bool `json:"passed"`
+		Reports []struct {
+			File    string `json:"file"`
+			Passed  int    `json:"passed"`
+			Failed  int    `json:"failed"`
+			Results []struct {
+				Name     string   `json:"name"`
+				Passed   bool     `json:"passed"`
+				Failures []string `json:"failures"`
+			} `json:"results"`
+		} `json:"reports"`
+	}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &output))
+	assert.False(t, output.Passed)
+	require.Len(t, output.Reports, 2)
+	assert.Equal(t, 1, output.Reports[0].Passed)
+	assert.Equal(t, 1, output.Reports[1].Failed)
+	assert.Equal(t, "expects bye", output.Reports[1].Results[1].Name)
+	assert.NotEmpty(t, output.Reports[1].Results[1].Failures)
+
+	stdout.Reset()
+	code = runTest([]string{filepath.Join(dir, "b.prompty")}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeValidationError, code)
+	assert.Contains(t, stdout.String(), TestStatusFail+" expects bye")
+	assert.Contains(t, stdout.String(), `output: "Hello you!"`)
+}
+
+func TestTest_Errors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, ExitCodeUsageError, runTest(nil, nil, &stdout, &stderr))
+
+	path := writeTestPrompt(t, t.TempDir(), "bad.prompty", "  - expect: {contains: [x]}\n")
+	code := runTest([]string{path}, nil, &stdout, &stderr)
+	assert.Equal(t, ExitCodeError, code)
+	assert.Contains(t, stderr.String(), ErrMsgSelfTestFailed)
+}
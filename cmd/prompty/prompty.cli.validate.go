@@ -24,6 +24,7 @@ type validationOutput struct {
 }
 
 type validationIssueOutput struct {
+	Code     string `json:"code,omitempty"`
 	Severity string `json:"severity"`
 	Message  string `json:"message"`
 	Line     int    `json:"line"`
@@ -122,6 +123,7 @@ func outputValidationJSON(result *prompty.ValidationResult, strict bool, stdout
 
 	for _, issue := range issues {
 		output.Issues = append(output.Issues, validationIssueOutput{
+			Code:     issue.Code,
 			Severity: severityToName(issue.Severity),
 			Message:  issue.Message,
 			Line:     issue.Position.Line,
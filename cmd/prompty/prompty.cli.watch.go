@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// fileStamp identifies a version of a file on disk
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// statFile returns the current stamp of path; missing files yield a zero stamp.
+func statFile(path string) fileStamp {
+	if path == "" {
+		return fileStamp{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+// watchRender renders the template, then polls the template and data file
+// for changes and re-renders on every change, printing a line diff of the
+// output. It returns when ctx is cancelled.
+func watchRender(ctx context.Context, cfg *renderConfig, stdout, stderr io.Writer) int {
+	engine := prompty.MustNew()
+
+	render := func() (string, bool) {
+		source, err := os.ReadFile(cfg.templatePath)
+		if err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgReadFileFailed, err)
+			return "", false
+		}
+		data, err := loadData(cfg.dataJSON, cfg.dataFilePath)
+		if err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgInvalidJSON, err)
+			return "", false
+		}
+		result, err := engine.Execute(ctx, string(source), data)
+		if err != nil {
+			fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgExecuteFailed, err)
+			return "", false
+		}
+		if cfg.outputPath != FlagDefaultOutput {
+			if err := writeOutput(cfg.outputPath, []byte(result), stdout); err != nil {
+				fmt.Fprintf(stderr, FmtErrorWithCause, ErrMsgWriteOutputFailed, err)
+				return "", false
+			}
+		}
+		return result, true
+	}
+
+	templateStamp := statFile(cfg.templatePath)
+	dataStamp := statFile(cfg.dataFilePath)
+
+	previous, ok := render()
+	if ok && cfg.outputPath == FlagDefaultOutput {
+		fmt.Fprintln(stdout, previous)
+	}
+	if !cfg.quiet {
+		fmt.Fprintf(stdout, WatchTextWatching+FmtNewline, cfg.templatePath)
+	}
+
+	ticker := time.NewTicker(cfg.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ExitCodeSuccess
+		case <-ticker.C:
+		}
+
+		changed := ""
+		if stamp := statFile(cfg.templatePath); stamp != templateStamp {
+			templateStamp = stamp
+			changed = cfg.templatePath
+		}
+		if stamp := statFile(cfg.dataFilePath); stamp != dataStamp {
+			dataStamp = stamp
+			changed = cfg.dataFilePath
+		}
+		if changed == "" {
+			continue
+		}
+
+		result, ok := render()
+		if !ok {
+			continue
+		}
+		if result == previous {
+			if !cfg.quiet {
+				fmt.Fprintf(stdout, WatchTextUnchanged+FmtNewline, time.Now().Format(time.TimeOnly), changed)
+			}
+			continue
+		}
+		if !cfg.quiet {
+			fmt.Fprintf(stdout, WatchTextChanged+FmtNewline, time.Now().Format(time.TimeOnly), changed)
+			fmt.Fprint(stdout, diffLines(previous, result))
+		}
+		previous = result
+	}
+}
+
+// diffLines returns a line diff of two texts: removed lines prefixed with
+// "- ", added lines with "+ ". Unchanged lines are omitted. Inputs whose
+// line-count product exceeds DiffMaxCells are reported as full replacement.
+func diffLines(oldText, newText string) string {
+	oldLines := strings.Split(oldText, FmtNewline)
+	newLines := strings.Split(newText, FmtNewline)
+
+	var sb strings.Builder
+	if len(oldLines)*len(newLines) > DiffMaxCells {
+		for _, line := range oldLines {
+			sb.WriteString(DiffPrefixRemoved + line + FmtNewline)
+		}
+		for _, line := range newLines {
+			sb.WriteString(DiffPrefixAdded + line + FmtNewline)
+		}
+		return sb.String()
+	}
+
+	// lcs[i][j] = length of the longest common subsequence of oldLines[i:] and newLines[j:]
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			i++
+			j++
+		case i < len(oldLines) && (j == len(newLines) || lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString(DiffPrefixRemoved + oldLines[i] + FmtNewline)
+			i++
+		default:
+			sb.WriteString(DiffPrefixAdded + newLines[j] + FmtNewline)
+			j++
+		}
+	}
+	return sb.String()
+}
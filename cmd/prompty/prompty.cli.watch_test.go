@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes and reads
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRender_Watch(t *testing.T) {
+	tmpDir := setupTestData(t)
+	templatePath := filepath.Join(tmpDir, "template.txt")
+	dataPath := filepath.Join(tmpDir, "data.json")
+
+	cfg := &renderConfig{
+		templatePath: templatePath,
+		dataFilePath: dataPath,
+		outputPath:   FlagDefaultOutput,
+		interval:     10 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stdout, stderr := &syncBuffer{}, &syncBuffer{}
+	done := make(chan int)
+	go func() { done <- watchRender(ctx, cfg, stdout, stderr) }()
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(stdout.String(), "--- watching")
+	}, time.Second, 5*time.Millisecond)
+	assert.Contains(t, stdout.String(), testExpectedOutput)
+
+	// Data change produces a diff (size changes so the stamp always differs)
+	require.NoError(t, os.WriteFile(dataPath, []byte(`{"user": "Bartholomew"}`), FilePermissions))
+	require.Eventually(t, func() bool {
+		return strings.Contains(stdout.String(), "+ Hello, Bartholomew!")
+	}, 2*time.Second, 5*time.Millisecond)
+	assert.Contains(t, stdout.String(), "- Hello, Alice!")
+
+	// Broken template reports an error and keeps watching
+	require.NoError(t, os.WriteFile(templatePath, []byte(testInvalidContent), FilePermissions))
+	require.Eventually(t, func() bool {
+		return strings.Contains(stderr.String(), ErrMsgExecuteFailed)
+	}, 2*time.Second, 5*time.Millisecond)
+
+	cancel()
+	assert.Equal(t, ExitCodeSuccess, <-done)
+}
+
+func TestRender_WatchFlags(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := runRender([]string{"-t", "-", "--watch"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitCodeUsageError, code)
+	assert.Contains(t, stderr.String(), ErrMsgWatchStdin)
+
+	stderr.Reset()
+	code = runRender([]string{"-t", "x", "--watch", "--interval", "0s"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitCodeUsageError, code)
+	assert.Contains(t, stderr.String(), ErrMsgInvalidInterval)
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		expected string
+	}{
+		{"identical", "a\nb", "a\nb", ""},
+		{"changed line", "a\nb\nc", "a\nx\nc", "- b\n+ x\n"},
+		{"added line", "a\nc", "a\nb\nc", "+ b\n"},
+		{"removed line", "a\nb\nc", "a\nc", "- b\n"},
+		{"empty to text", "", "a", "- \n+ a\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, diffLines(tt.old, tt.new))
+		})
+	}
+}
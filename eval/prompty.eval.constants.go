@@ -0,0 +1,130 @@
+package eval
+
+import "github.com/itsatony/go-prompty/v2"
+
+// Built-in evaluator names, reported in Score.Evaluator
+const (
+	EvaluatorRegex     = "regex"
+	EvaluatorJSONValid = "json_valid"
+	EvaluatorLength    = "length"
+	EvaluatorToxicity  = "toxicity"
+	EvaluatorReference = "reference"
+	EvaluatorJudge     = "judge"
+)
+
+// Scoring defaults
+const (
+	// DefaultJudgeThreshold is the judge score at which a sample passes
+	DefaultJudgeThreshold = 0.5
+	// DefaultJudgeProvider is the judge payload format when neither the
+	// provider nor the execution config names one
+	DefaultJudgeProvider = prompty.ProviderOpenAI
+	// DefaultConcurrency is the number of examples evaluated at once
+	DefaultConcurrency = 1
+	scorePass          = 1.0
+	scoreFail          = 0.0
+)
+
+// Dataset storage and file formats
+const (
+	// DatasetNamePrefix prefixes the storage name of datasets, keeping them
+	// apart from templates
+	DatasetNamePrefix = "dataset."
+	// DatasetTag tags stored datasets
+	DatasetTag = "dataset"
+	// MetaKeyDatasetPrompt and MetaKeyDatasetPromptVersion link a stored
+	// dataset to the prompt (and version) it evaluates
+	MetaKeyDatasetPrompt        = "dataset_prompt"
+	MetaKeyDatasetPromptVersion = "dataset_prompt_version"
+	// MetaKeyDatasetRows records the number of examples
+	MetaKeyDatasetRows = "dataset_rows"
+
+	DatasetExtJSONL    = ".jsonl"
+	DatasetExtCSV      = ".csv"
+	csvColumnID        = "id"
+	csvColumnReference = "reference"
+)
+
+// Judge template data keys
+const (
+	judgeDataCriteria  = "criteria"
+	judgeDataInput     = "input"
+	judgeDataOutput    = "output"
+	judgeDataReference = "reference"
+)
+
+// judgeTemplate renders the judge's messages. The reply must be a JSON
+// object with a score between 0 and 1 and a reason.
+const judgeTemplate = `{~prompty.message role="system"~}You are an impartial evaluator of AI-generated output. Judge the output only against the criteria. Reply with a JSON object and nothing else: {"score": <number from 0 to 1>, "reason": "<one sentence>"}.{~/prompty.message~}
+{~prompty.message role="user"~}Criteria:
+{~prompty.var name="criteria" /~}
+
+Input:
+{~prompty.var name="input" /~}
+{~prompty.if eval="reference"~}
+Reference answer:
+{~prompty.var name="reference" /~}
+{~/prompty.if~}
+Output to evaluate:
+{~prompty.var name="output" /~}{~/prompty.message~}`
+
+// Reasons reported in Score.Reason
+const (
+	reasonNoMatch      = "output does not match %q"
+	reasonInvalidJSON  = "output is not valid JSON: %v"
+	reasonTooShort     = "length %d is less than %d"
+	reasonTooLong      = "length %d is greater than %d"
+	reasonFlaggedWords = "output contains flagged words: %s"
+	reasonNoReference  = "output does not contain the reference"
+)
+
+// Version comparison labels and threshold failures
+const (
+	versionLabel     = "%s@v%d"
+	failMeanDrop     = "%s: mean score dropped by %.3f (allowed %.3f)"
+	failPassRateDrop = "%s: pass rate dropped by %.3f (allowed %.3f)"
+	failMinPassRate  = "%s: pass rate %.3f is below %.3f"
+	failErrors       = "%s: %d example(s) could not be scored (baseline: %d)"
+)
+
+// Error codes
+const (
+	ErrCodeEval    = "PROMPTY_EVAL"
+	ErrCodeDataset = "PROMPTY_EVAL_DATASET"
+	ErrCodeJudge   = "PROMPTY_EVAL_JUDGE"
+)
+
+// Error metadata keys
+const (
+	MetaKeyMinLength = "min_length"
+	MetaKeyMaxLength = "max_length"
+	MetaKeyReply     = "reply"
+)
+
+// Error messages
+const (
+	ErrMsgNoEvaluators      = "at least one evaluator is required"
+	ErrMsgNoTarget          = "target is required"
+	ErrMsgInvalidPattern    = "invalid regex pattern"
+	ErrMsgInvalidLength     = "length bounds must not be negative, and max must not be less than min"
+	ErrMsgNoWords           = "at least one flagged word is required"
+	ErrMsgJudgeNoCriteria   = "judge requires criteria"
+	ErrMsgJudgeNoModel      = "judge requires a model"
+	ErrMsgJudgeRender       = "judge prompt rendering failed"
+	ErrMsgJudgePayload      = "judge payload building failed"
+	ErrMsgJudgeCall         = "judge model call failed"
+	ErrMsgJudgeInvalidReply = "judge reply has no score"
+	ErrMsgTargetFailed      = "target failed"
+	ErrMsgEvaluatorFailed   = "evaluator failed"
+
+	ErrMsgDatasetNoName      = "dataset name is required"
+	ErrMsgDatasetFormat      = "unsupported dataset format (use .jsonl or .csv)"
+	ErrMsgDatasetInvalidLine = "invalid dataset line"
+	ErrMsgDatasetInvalidCSV  = "invalid dataset CSV"
+	ErrMsgDatasetSplitRatio  = "split ratio must be between 0 and 1"
+	ErrMsgDatasetNotDataset  = "stored template is not a dataset"
+
+	ErrMsgNoEngine    = "storage engine is required"
+	ErrMsgNoDataset   = "dataset is required"
+	ErrMsgVersionLoad = "failed to load template version"
+)
@@ -0,0 +1,234 @@
+package eval
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// Dataset is a named set of examples, optionally linked to the prompt it
+// evaluates.
+type Dataset struct {
+	Name string `json:"name"`
+	// Prompt and PromptVersion name the prompt the examples were written
+	// for (0 means any version).
+	Prompt        string    `json:"prompt,omitempty"`
+	PromptVersion int       `json:"prompt_version,omitempty"`
+	Examples      []Example `json:"examples"`
+}
+
+// LoadDataset reads a JSONL or CSV file, chosen by extension, into a
+// dataset named after the file.
+func LoadDataset(path string) (*Dataset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ext := strings.ToLower(filepath.Ext(path))
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	switch ext {
+	case DatasetExtJSONL:
+		return ParseJSONL(name, f)
+	case DatasetExtCSV:
+		return ParseCSV(name, f)
+	}
+	return nil, NewDatasetFormatError(path)
+}
+
+// ParseJSONL reads one Example object per line:
+//
+//	{"id": "paris", "input": {"country": "France"}, "reference": "Paris"}
+//
+// Blank lines are skipped.
+func ParseJSONL(name string, r io.Reader) (*Dataset, error) {
+	d := &Dataset{Name: name}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var example Example
+		if err := json.Unmarshal(text, &example); err != nil {
+			return nil, NewDatasetLineError(line, err)
+		}
+		d.Examples = append(d.Examples, example)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ParseCSV reads a CSV file with a header row. The "id" and "reference"
+// columns fill Example.ID and Example.Reference; every other column is an
+// input. Values are strings; Prompt.CoerceInputs converts them to the types
+// a prompt declares.
+func ParseCSV(name string, r io.Reader) (*Dataset, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, NewDatasetCSVError(err)
+	}
+	d := &Dataset{Name: name}
+	if len(records) == 0 {
+		return d, nil
+	}
+
+	header := records[0]
+	for _, record := range records[1:] {
+		example := Example{Input: make(map[string]any, len(header))}
+		for i, column := range header {
+			switch column {
+			case csvColumnID:
+				example.ID = record[i]
+			case csvColumnReference:
+				example.Reference = record[i]
+			default:
+				example.Input[column] = record[i]
+			}
+		}
+		d.Examples = append(d.Examples, example)
+	}
+	return d, nil
+}
+
+// WriteJSONL writes the examples in the format ParseJSONL reads.
+func (d *Dataset) WriteJSONL(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, example := range d.Examples {
+		if err := enc.Encode(example); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sample returns a dataset of n examples chosen at random, keeping their
+// order. The same seed yields the same sample. n of 0 or at least the
+// dataset size returns all examples.
+func (d *Dataset) Sample(n int, seed int64) *Dataset {
+	if n <= 0 || n >= len(d.Examples) {
+		return d.withExamples(slices.Clone(d.Examples))
+	}
+	indexes := rand.New(rand.NewSource(seed)).Perm(len(d.Examples))[:n]
+	slices.Sort(indexes)
+	return d.withExamples(d.pick(indexes))
+}
+
+// Split shuffles the examples with seed and divides them into a train set
+// with ratio of the examples and a test set with the rest, each keeping
+// the original order. The same seed yields the same split.
+func (d *Dataset) Split(ratio float64, seed int64) (train, test *Dataset, err error) {
+	if ratio <= 0 || ratio >= 1 {
+		return nil, nil, NewSplitRatioError(ratio)
+	}
+	indexes := rand.New(rand.NewSource(seed)).Perm(len(d.Examples))
+	cut := int(float64(len(indexes))*ratio + 0.5)
+	trainIdx, testIdx := indexes[:cut], indexes[cut:]
+	slices.Sort(trainIdx)
+	slices.Sort(testIdx)
+	return d.withExamples(d.pick(trainIdx)), d.withExamples(d.pick(testIdx)), nil
+}
+
+// withExamples returns a copy of the dataset holding examples.
+func (d *Dataset) withExamples(examples []Example) *Dataset {
+	return &Dataset{Name: d.Name, Prompt: d.Prompt, PromptVersion: d.PromptVersion, Examples: examples}
+}
+
+// pick returns the examples at indexes.
+func (d *Dataset) pick(indexes []int) []Example {
+	examples := make([]Example, len(indexes))
+	for i, idx := range indexes {
+		examples[i] = d.Examples[idx]
+	}
+	return examples
+}
+
+// SaveDataset stores the dataset as a new version of the template named
+// DatasetNamePrefix + d.Name, in JSONL and tagged DatasetTag, with its
+// prompt link in the metadata. It returns the stored version.
+func SaveDataset(ctx context.Context, storage prompty.TemplateStorage, d *Dataset) (int, error) {
+	if d.Name == "" {
+		return 0, NewConfigError(ErrMsgDatasetNoName)
+	}
+	var buf bytes.Buffer
+	if err := d.WriteJSONL(&buf); err != nil {
+		return 0, err
+	}
+
+	metadata := map[string]string{MetaKeyDatasetRows: strconv.Itoa(len(d.Examples))}
+	if d.Prompt != "" {
+		metadata[MetaKeyDatasetPrompt] = d.Prompt
+	}
+	if d.PromptVersion > 0 {
+		metadata[MetaKeyDatasetPromptVersion] = strconv.Itoa(d.PromptVersion)
+	}
+	tmpl := &prompty.StoredTemplate{
+		Name:     DatasetNamePrefix + d.Name,
+		Source:   buf.String(),
+		Metadata: metadata,
+		Tags:     []string{DatasetTag},
+	}
+	if err := storage.Save(ctx, tmpl); err != nil {
+		return 0, err
+	}
+	return tmpl.Version, nil
+}
+
+// LoadStoredDataset loads a dataset saved with SaveDataset. A version of 0
+// loads the latest.
+func LoadStoredDataset(ctx context.Context, storage prompty.TemplateStorage, name string, version int) (*Dataset, error) {
+	var tmpl *prompty.StoredTemplate
+	var err error
+	if version > 0 {
+		tmpl, err = storage.GetVersion(ctx, DatasetNamePrefix+name, version)
+	} else {
+		tmpl, err = storage.Get(ctx, DatasetNamePrefix+name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(tmpl.Tags, DatasetTag) {
+		return nil, NewNotDatasetError(tmpl.Name)
+	}
+
+	d, err := ParseJSONL(name, strings.NewReader(tmpl.Source))
+	if err != nil {
+		return nil, err
+	}
+	d.Prompt = tmpl.Metadata[MetaKeyDatasetPrompt]
+	d.PromptVersion, _ = strconv.Atoi(tmpl.Metadata[MetaKeyDatasetPromptVersion])
+	return d, nil
+}
+
+// ListDatasets returns the names of the stored datasets, or, with a prompt
+// name, of the datasets linked to that prompt.
+func ListDatasets(ctx context.Context, storage prompty.TemplateStorage, prompt string) ([]string, error) {
+	query := &prompty.TemplateQuery{NamePrefix: DatasetNamePrefix, Tags: []string{DatasetTag}}
+	if prompt != "" {
+		query.Metadata = map[string]string{MetaKeyDatasetPrompt: prompt}
+	}
+	templates, err := storage.List(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(templates))
+	for i, tmpl := range templates {
+		names[i] = strings.TrimPrefix(tmpl.Name, DatasetNamePrefix)
+	}
+	return names, nil
+}
@@ -0,0 +1,168 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/itsatony/go-prompty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const datasetJSONL = `{"id": "fr", "input": {"country": "France", "population": 68}, "reference": "Paris"}
+
+{"id": "it", "input": {"country": "Italy"}, "reference": "Rome"}
+`
+
+const datasetCSV = `id,country,reference
+fr,France,Paris
+it,"Italy",Rome
+`
+
+func numberedDataset(n int) *Dataset {
+	d := &Dataset{Name: "numbers"}
+	for i := 0; i < n; i++ {
+		d.Examples = append(d.Examples, Example{ID: strconv.Itoa(i)})
+	}
+	return d
+}
+
+func exampleIDs(d *Dataset) []string {
+	ids := make([]string, len(d.Examples))
+	for i, e := range d.Examples {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+func TestLoadDataset(t *testing.T) {
+	dir := t.TempDir()
+	jsonlPath := filepath.Join(dir, "capitals.jsonl")
+	csvPath := filepath.Join(dir, "capitals.csv")
+	require.NoError(t, os.WriteFile(jsonlPath, []byte(datasetJSONL), 0o644))
+	require.NoError(t, os.WriteFile(csvPath, []byte(datasetCSV), 0o644))
+
+	fromJSONL, err := LoadDataset(jsonlPath)
+	require.NoError(t, err)
+	assert.Equal(t, "capitals", fromJSONL.Name)
+	require.Len(t, fromJSONL.Examples, 2)
+	assert.Equal(t, Example{ID: "fr", Input: map[string]any{"country": "France", "population": float64(68)}, Reference: "Paris"}, fromJSONL.Examples[0])
+
+	fromCSV, err := LoadDataset(csvPath)
+	require.NoError(t, err)
+	assert.Equal(t, "capitals", fromCSV.Name)
+	assert.Equal(t, []Example{
+		{ID: "fr", Input: map[string]any{"country": "France"}, Reference: "Paris"},
+		{ID: "it", Input: map[string]any{"country": "Italy"}, Reference: "Rome"},
+	}, fromCSV.Examples)
+
+	var buf bytes.Buffer
+	require.NoError(t, fromCSV.WriteJSONL(&buf))
+	roundTrip, err := ParseJSONL("capitals", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, fromCSV, roundTrip)
+}
+
+func TestLoadDataset_Errors(t *testing.T) {
+	dir := t.TempDir()
+	txt := filepath.Join(dir, "data.txt")
+	require.NoError(t, os.WriteFile(txt, nil, 0o644))
+	_, err := LoadDataset(txt)
+	assert.ErrorContains(t, err, ErrMsgDatasetFormat)
+
+	_, err = LoadDataset(filepath.Join(dir, "missing.jsonl"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = ParseJSONL("x", strings.NewReader("{\"id\": \"a\"}\n{oops"))
+	assert.ErrorContains(t, err, ErrMsgDatasetInvalidLine)
+	details := prompty.ErrorDetailsOf(err)
+	assert.Equal(t, ErrCodeDataset, details.Code)
+	assert.Equal(t, "2", details.Metadata[prompty.MetaKeyLine])
+
+	_, err = ParseCSV("x", strings.NewReader("id,country\na\n"))
+	assert.ErrorContains(t, err, ErrMsgDatasetInvalidCSV)
+}
+
+func TestDataset_SampleAndSplit(t *testing.T) {
+	d := numberedDataset(10)
+
+	sample := d.Sample(4, 7)
+	require.Len(t, sample.Examples, 4)
+	assert.Equal(t, exampleIDs(sample), exampleIDs(d.Sample(4, 7)))
+	assert.IsIncreasing(t, mustAtoi(t, exampleIDs(sample)))
+	assert.Len(t, d.Sample(0, 1).Examples, 10)
+	assert.Len(t, d.Sample(50, 1).Examples, 10)
+
+	train, test, err := d.Split(0.8, 3)
+	require.NoError(t, err)
+	assert.Len(t, train.Examples, 8)
+	assert.Len(t, test.Examples, 2)
+	assert.ElementsMatch(t, exampleIDs(d), append(exampleIDs(train), exampleIDs(test)...))
+	train2, _, err := d.Split(0.8, 3)
+	require.NoError(t, err)
+	assert.Equal(t, train, train2)
+
+	for _, ratio := range []float64{0, 1, -0.5} {
+		_, _, err := d.Split(ratio, 1)
+		assert.ErrorContains(t, err, ErrMsgDatasetSplitRatio)
+	}
+}
+
+func mustAtoi(t *testing.T, ids []string) []int {
+	t.Helper()
+	n := make([]int, len(ids))
+	for i, id := range ids {
+		var err error
+		n[i], err = strconv.Atoi(id)
+		require.NoError(t, err)
+	}
+	return n
+}
+
+func TestDataset_Storage(t *testing.T) {
+	ctx := context.Background()
+	storage := prompty.NewMemoryStorage()
+	defer storage.Close()
+
+	d, err := ParseJSONL("capitals", strings.NewReader(datasetJSONL))
+	require.NoError(t, err)
+	d.Prompt, d.PromptVersion = "capital-finder", 3
+
+	version, err := SaveDataset(ctx, storage, d)
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+	version, err = SaveDataset(ctx, storage, d.Sample(1, 1))
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+	_, err = SaveDataset(ctx, storage, &Dataset{Name: "other"})
+	require.NoError(t, err)
+
+	latest, err := LoadStoredDataset(ctx, storage, "capitals", 0)
+	require.NoError(t, err)
+	assert.Len(t, latest.Examples, 1)
+	first, err := LoadStoredDataset(ctx, storage, "capitals", 1)
+	require.NoError(t, err)
+	assert.Equal(t, d, first)
+
+	stored, err := storage.Get(ctx, DatasetNamePrefix+"capitals")
+	require.NoError(t, err)
+	assert.Equal(t, "1", stored.Metadata[MetaKeyDatasetRows])
+
+	names, err := ListDatasets(ctx, storage, "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"capitals", "other"}, names)
+	names, err = ListDatasets(ctx, storage, "capital-finder")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"capitals"}, names)
+
+	require.NoError(t, storage.Save(ctx, &prompty.StoredTemplate{Name: DatasetNamePrefix + "fake", Source: "Hi"}))
+	_, err = LoadStoredDataset(ctx, storage, "fake", 0)
+	assert.ErrorContains(t, err, ErrMsgDatasetNotDataset)
+	_, err = SaveDataset(ctx, storage, &Dataset{})
+	assert.ErrorContains(t, err, ErrMsgDatasetNoName)
+}
@@ -0,0 +1,79 @@
+package eval
+
+import (
+	"strconv"
+
+	"github.com/itsatony/go-cuserr"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// NewConfigError creates an error for a missing or invalid argument.
+func NewConfigError(msg string) error {
+	return cuserr.NewValidationError(ErrCodeEval, msg)
+}
+
+// NewPatternError creates an error for a regex pattern that does not compile.
+func NewPatternError(pattern string, cause error) error {
+	err := cuserr.WrapStdError(cause, ErrCodeEval, ErrMsgInvalidPattern)
+	err.Category = cuserr.ErrorCategoryValidation
+	return err.WithMetadata(prompty.MetaKeyPattern, pattern)
+}
+
+// NewLengthError creates an error for invalid Length bounds.
+func NewLengthError(min, max int) error {
+	return cuserr.NewValidationError(ErrCodeEval, ErrMsgInvalidLength).
+		WithMetadata(MetaKeyMinLength, strconv.Itoa(min)).
+		WithMetadata(MetaKeyMaxLength, strconv.Itoa(max))
+}
+
+// NewDatasetFormatError creates an error for a dataset file of unsupported format.
+func NewDatasetFormatError(path string) error {
+	return cuserr.NewValidationError(ErrCodeDataset, ErrMsgDatasetFormat).
+		WithMetadata(prompty.MetaKeyPath, path)
+}
+
+// NewDatasetLineError creates an error for a JSONL line that is not an example.
+func NewDatasetLineError(line int, cause error) error {
+	err := cuserr.WrapStdError(cause, ErrCodeDataset, ErrMsgDatasetInvalidLine)
+	err.Category = cuserr.ErrorCategoryValidation
+	return err.WithMetadata(prompty.MetaKeyLine, strconv.Itoa(line))
+}
+
+// NewDatasetCSVError creates an error for a malformed CSV dataset.
+func NewDatasetCSVError(cause error) error {
+	err := cuserr.WrapStdError(cause, ErrCodeDataset, ErrMsgDatasetInvalidCSV)
+	err.Category = cuserr.ErrorCategoryValidation
+	return err
+}
+
+// NewSplitRatioError creates an error for a split ratio outside (0, 1).
+func NewSplitRatioError(ratio float64) error {
+	return cuserr.NewValidationError(ErrCodeDataset, ErrMsgDatasetSplitRatio).
+		WithMetadata(prompty.MetaKeyValue, strconv.FormatFloat(ratio, 'g', -1, 64))
+}
+
+// NewNotDatasetError creates an error for a stored template without DatasetTag.
+func NewNotDatasetError(name string) error {
+	return cuserr.NewValidationError(ErrCodeDataset, ErrMsgDatasetNotDataset).
+		WithMetadata(prompty.MetaKeyTemplateName, name)
+}
+
+// NewJudgeError creates an error for a failed step of a judge evaluation.
+func NewJudgeError(msg string, cause error) error {
+	return cuserr.WrapStdError(cause, ErrCodeJudge, msg)
+}
+
+// NewJudgeReplyError creates an error for a judge reply without a score.
+func NewJudgeReplyError(reply string) error {
+	return cuserr.NewValidationError(ErrCodeJudge, ErrMsgJudgeInvalidReply).
+		WithMetadata(MetaKeyReply, reply)
+}
+
+// NewVersionLoadError creates an error for a template version that could
+// not be loaded or turned into a target.
+func NewVersionLoadError(name string, version int, cause error) error {
+	return cuserr.WrapStdError(cause, ErrCodeEval, ErrMsgVersionLoad).
+		WithMetadata(prompty.MetaKeyTemplateName, name).
+		WithMetadata(prompty.MetaKeyVersion, strconv.Itoa(version))
+}
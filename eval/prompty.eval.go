@@ -0,0 +1,199 @@
+// Package eval scores prompt outputs with heuristic and LLM-judge
+// evaluators and aggregates the scores over a set of examples, so prompt
+// versions can be compared quantitatively:
+//
+//	judge, _ := eval.NewJudge(eval.JudgeConfig{Criteria: "Is the answer polite?", Model: callModel})
+//	base, _ := eval.Run(ctx, "v1", eval.RenderTarget(v1), examples, []eval.Evaluator{eval.JSONValid(), judge})
+//	candidate, _ := eval.Run(ctx, "v2", eval.RenderTarget(v2), examples, []eval.Evaluator{eval.JSONValid(), judge})
+//	comparison := eval.Compare(base, candidate)
+//
+// Reports marshal to JSON, so results can be stored and compared later.
+package eval
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// Sample is one output to score, with the input it was produced from and
+// the reference answer, if any.
+type Sample struct {
+	Input     map[string]any
+	Output    string
+	Reference string
+}
+
+// Score is an evaluator's judgement of a sample. Value is between 0 and 1.
+type Score struct {
+	Evaluator string  `json:"evaluator"`
+	Value     float64 `json:"value"`
+	Passed    bool    `json:"passed"`
+	Reason    string  `json:"reason,omitempty"`
+}
+
+// Evaluator scores samples. Errors mean the sample could not be scored,
+// e.g. because a judge model failed, not that it scored badly.
+type Evaluator interface {
+	Name() string
+	Evaluate(ctx context.Context, sample Sample) (Score, error)
+}
+
+// Example is one input to evaluate a prompt with, and optionally the
+// expected answer.
+type Example struct {
+	ID        string         `json:"id,omitempty"`
+	Input     map[string]any `json:"input"`
+	Reference string         `json:"reference,omitempty"`
+}
+
+// Target produces the output to score for an input, e.g. by rendering a
+// template version (RenderTarget) or calling a model with it
+// (CompletionTarget).
+type Target func(ctx context.Context, input map[string]any) (string, error)
+
+// Model sends a provider payload, built by CompiledPrompt.ToProviderPayload,
+// to an LLM and returns the text of its reply. Transport, authentication
+// and retries are left to the caller.
+type Model func(ctx context.Context, payload map[string]any) (string, error)
+
+// config holds Run and CompareVersions settings
+type config struct {
+	concurrency   int
+	thresholds    Thresholds
+	versionTarget VersionTargetFunc
+}
+
+// Option configures Run and CompareVersions.
+type Option func(*config)
+
+// WithConcurrency evaluates up to n examples at once. Results keep the
+// order of the examples.
+// Default: DefaultConcurrency
+func WithConcurrency(n int) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// Run produces the target's output for each example and scores it with
+// every evaluator. Target and evaluator failures are recorded in the rows
+// of the report rather than returned; the error is non-nil for invalid
+// arguments or when ctx is done. name labels the report, e.g. the template
+// version.
+func Run(ctx context.Context, name string, target Target, examples []Example, evaluators []Evaluator, opts ...Option) (*Report, error) {
+	if target == nil {
+		return nil, NewConfigError(ErrMsgNoTarget)
+	}
+	if len(evaluators) == 0 {
+		return nil, NewConfigError(ErrMsgNoEvaluators)
+	}
+	cfg := &config{concurrency: DefaultConcurrency}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	start := time.Now()
+	rows := make([]Row, len(examples))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				rows[i] = evaluateExample(ctx, examples[i], target, evaluators)
+			}
+		}()
+	}
+feed:
+	for i := range examples {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return newReport(name, rows, evaluators, time.Since(start)), nil
+}
+
+// evaluateExample produces and scores the output of one example.
+func evaluateExample(ctx context.Context, example Example, target Target, evaluators []Evaluator) Row {
+	row := Row{ID: example.ID, Input: example.Input, Reference: example.Reference}
+	output, err := target(ctx, example.Input)
+	if err != nil {
+		row.Error = fmt.Sprintf("%s: %v", ErrMsgTargetFailed, err)
+		return row
+	}
+	row.Output = output
+
+	sample := Sample{Input: example.Input, Output: output, Reference: example.Reference}
+	row.Scores = make([]Score, 0, len(evaluators))
+	for _, evaluator := range evaluators {
+		score, err := evaluator.Evaluate(ctx, sample)
+		if err != nil {
+			row.Error = fmt.Sprintf("%s: %s: %v", ErrMsgEvaluatorFailed, evaluator.Name(), err)
+			continue
+		}
+		score.Evaluator = evaluator.Name()
+		row.Scores = append(row.Scores, score)
+	}
+	return row
+}
+
+// RenderTarget renders a template with each input, to evaluate the prompt
+// text itself without calling a model.
+func RenderTarget(tmpl *prompty.Template) Target {
+	return func(ctx context.Context, input map[string]any) (string, error) {
+		return tmpl.Execute(ctx, input)
+	}
+}
+
+// CompileTarget compiles a prompt document's body with each input (see
+// prompty.Prompt.Compile), without calling a model.
+func CompileTarget(prompt *prompty.Prompt, opts *prompty.CompileOptions) Target {
+	return func(ctx context.Context, input map[string]any) (string, error) {
+		return prompt.Compile(ctx, input, opts)
+	}
+}
+
+// CompletionTarget compiles a prompt with each input, sends it to model in
+// the payload format of provider and returns the reply. Agents are compiled
+// with CompileAgent; other prompts are sent as a single user message with
+// the prompt's execution config. An empty provider uses the prompt's.
+func CompletionTarget(prompt *prompty.Prompt, provider string, model Model, opts *prompty.CompileOptions) Target {
+	return func(ctx context.Context, input map[string]any) (string, error) {
+		var compiled *prompty.CompiledPrompt
+		if prompt.IsAgent() {
+			var err error
+			if compiled, err = prompt.CompileAgent(ctx, input, opts); err != nil {
+				return "", err
+			}
+		} else {
+			text, err := prompt.Compile(ctx, input, opts)
+			if err != nil {
+				return "", err
+			}
+			compiled = &prompty.CompiledPrompt{
+				Messages:  []prompty.CompiledMessage{{Role: prompty.RoleUser, Content: text}},
+				Execution: prompt.Execution,
+			}
+		}
+		payload, err := compiled.ToProviderPayload(provider)
+		if err != nil {
+			return "", err
+		}
+		return model(ctx, payload)
+	}
+}
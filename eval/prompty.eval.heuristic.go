@@ -0,0 +1,106 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// evaluatorFunc adapts a scoring function to the Evaluator interface.
+type evaluatorFunc struct {
+	name string
+	fn   func(sample Sample) Score
+}
+
+func (e *evaluatorFunc) Name() string { return e.name }
+
+func (e *evaluatorFunc) Evaluate(_ context.Context, sample Sample) (Score, error) {
+	return e.fn(sample), nil
+}
+
+// passFail scores 1 when passed and 0 otherwise.
+func passFail(passed bool, reason string) Score {
+	if passed {
+		return Score{Value: scorePass, Passed: true}
+	}
+	return Score{Value: scoreFail, Reason: reason}
+}
+
+// Regex passes outputs that match pattern.
+func Regex(pattern string) (Evaluator, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, NewPatternError(pattern, err)
+	}
+	return &evaluatorFunc{name: EvaluatorRegex, fn: func(sample Sample) Score {
+		return passFail(re.MatchString(sample.Output), fmt.Sprintf(reasonNoMatch, pattern))
+	}}, nil
+}
+
+// JSONValid passes outputs that are valid JSON.
+func JSONValid() Evaluator {
+	return &evaluatorFunc{name: EvaluatorJSONValid, fn: func(sample Sample) Score {
+		var v any
+		err := json.Unmarshal([]byte(sample.Output), &v)
+		return passFail(err == nil, fmt.Sprintf(reasonInvalidJSON, err))
+	}}
+}
+
+// Length passes outputs of min to max characters. A max of 0 means no
+// upper bound.
+func Length(min, max int) (Evaluator, error) {
+	if min < 0 || max < 0 || (max > 0 && max < min) {
+		return nil, NewLengthError(min, max)
+	}
+	return &evaluatorFunc{name: EvaluatorLength, fn: func(sample Sample) Score {
+		n := utf8.RuneCountInString(sample.Output)
+		switch {
+		case n < min:
+			return passFail(false, fmt.Sprintf(reasonTooShort, n, min))
+		case max > 0 && n > max:
+			return passFail(false, fmt.Sprintf(reasonTooLong, n, max))
+		}
+		return passFail(true, "")
+	}}, nil
+}
+
+// Toxicity fails outputs that contain any of the flagged words, matched
+// case-insensitively as whole words. The score is the share of flagged
+// words that do not occur.
+func Toxicity(words []string) (Evaluator, error) {
+	if len(words) == 0 {
+		return nil, NewConfigError(ErrMsgNoWords)
+	}
+	patterns := make([]*regexp.Regexp, len(words))
+	for i, word := range words {
+		patterns[i] = regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(word) + `\b`)
+	}
+	return &evaluatorFunc{name: EvaluatorToxicity, fn: func(sample Sample) Score {
+		var found []string
+		for i, re := range patterns {
+			if re.MatchString(sample.Output) {
+				found = append(found, words[i])
+			}
+		}
+		if len(found) == 0 {
+			return passFail(true, "")
+		}
+		return Score{
+			Value:  1 - float64(len(found))/float64(len(words)),
+			Reason: fmt.Sprintf(reasonFlaggedWords, strings.Join(found, ", ")),
+		}
+	}}, nil
+}
+
+// Reference passes outputs that contain the sample's reference answer,
+// ignoring case and surrounding whitespace. Samples without a reference
+// pass.
+func Reference() Evaluator {
+	return &evaluatorFunc{name: EvaluatorReference, fn: func(sample Sample) Score {
+		ref := strings.ToLower(strings.TrimSpace(sample.Reference))
+		return passFail(strings.Contains(strings.ToLower(sample.Output), ref), reasonNoReference)
+	}}
+}
@@ -0,0 +1,123 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// JudgeConfig configures an LLM-judge evaluator.
+type JudgeConfig struct {
+	// Name reported in Score.Evaluator. Default: EvaluatorJudge
+	Name string
+	// Criteria the judge scores outputs against, e.g. "The answer is
+	// factually correct and cites its source."
+	Criteria string
+	// Provider selects the payload format (see
+	// prompty.CompiledPrompt.ToProviderPayload). Empty uses Execution's, or
+	// DefaultJudgeProvider if Execution names none.
+	Provider string
+	// Execution holds the judge's model and parameters.
+	Execution *prompty.ExecutionConfig
+	// Model sends the payload to the judge model.
+	Model Model
+	// Threshold is the score at which a sample passes.
+	// Default: DefaultJudgeThreshold
+	Threshold float64
+}
+
+// judge scores samples by asking a model to rate them against criteria.
+type judge struct {
+	cfg  JudgeConfig
+	tmpl *prompty.Template
+}
+
+// judgeReply is the JSON object the judge replies with
+type judgeReply struct {
+	Score  *float64 `json:"score"`
+	Reason string   `json:"reason"`
+}
+
+// NewJudge creates an evaluator that renders the sample into a judge prompt,
+// sends it to cfg.Model in the payload format of cfg.Provider and reads a
+// {"score": 0..1, "reason": "..."} object from the reply.
+func NewJudge(cfg JudgeConfig) (Evaluator, error) {
+	if strings.TrimSpace(cfg.Criteria) == "" {
+		return nil, NewConfigError(ErrMsgJudgeNoCriteria)
+	}
+	if cfg.Model == nil {
+		return nil, NewConfigError(ErrMsgJudgeNoModel)
+	}
+	if cfg.Name == "" {
+		cfg.Name = EvaluatorJudge
+	}
+	if cfg.Provider == "" && cfg.Execution.GetEffectiveProvider() == "" {
+		cfg.Provider = DefaultJudgeProvider
+	}
+	if cfg.Threshold == 0 {
+		cfg.Threshold = DefaultJudgeThreshold
+	}
+	tmpl, err := prompty.MustNew().Parse(judgeTemplate)
+	if err != nil {
+		return nil, NewJudgeError(ErrMsgJudgeRender, err)
+	}
+	return &judge{cfg: cfg, tmpl: tmpl}, nil
+}
+
+func (j *judge) Name() string { return j.cfg.Name }
+
+func (j *judge) Evaluate(ctx context.Context, sample Sample) (Score, error) {
+	input, err := json.Marshal(sample.Input)
+	if err != nil {
+		return Score{}, NewJudgeError(ErrMsgJudgeRender, err)
+	}
+	messages, err := j.tmpl.ExecuteAndExtractMessages(ctx, map[string]any{
+		judgeDataCriteria:  j.cfg.Criteria,
+		judgeDataInput:     string(input),
+		judgeDataOutput:    sample.Output,
+		judgeDataReference: sample.Reference,
+	})
+	if err != nil {
+		return Score{}, NewJudgeError(ErrMsgJudgeRender, err)
+	}
+
+	compiled := &prompty.CompiledPrompt{
+		Messages:  make([]prompty.CompiledMessage, len(messages)),
+		Execution: j.cfg.Execution,
+	}
+	for i, m := range messages {
+		compiled.Messages[i] = prompty.CompiledMessage{Role: m.Role, Content: m.Content}
+	}
+	payload, err := compiled.ToProviderPayload(j.cfg.Provider)
+	if err != nil {
+		return Score{}, NewJudgeError(ErrMsgJudgePayload, err)
+	}
+
+	reply, err := j.cfg.Model(ctx, payload)
+	if err != nil {
+		return Score{}, NewJudgeError(ErrMsgJudgeCall, err)
+	}
+	parsed, err := parseJudgeReply(reply)
+	if err != nil {
+		return Score{}, err
+	}
+
+	value := min(max(*parsed.Score, scoreFail), scorePass)
+	return Score{Value: value, Passed: value >= j.cfg.Threshold, Reason: parsed.Reason}, nil
+}
+
+// parseJudgeReply reads the JSON object in a judge reply, tolerating text
+// or code fences around it.
+func parseJudgeReply(reply string) (*judgeReply, error) {
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, NewJudgeReplyError(reply)
+	}
+	var parsed judgeReply
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &parsed); err != nil || parsed.Score == nil {
+		return nil, NewJudgeReplyError(reply)
+	}
+	return &parsed, nil
+}
@@ -0,0 +1,183 @@
+package eval
+
+import (
+	"strconv"
+	"time"
+)
+
+// Report is the result of Run.
+type Report struct {
+	Name      string        `json:"name"`
+	Duration  time.Duration `json:"duration_ns"`
+	Rows      []Row         `json:"rows"`
+	Summaries []Summary     `json:"summaries"`
+}
+
+// Row holds the output and scores of one example. Error is set when the
+// target failed, or an evaluator failed; Scores then lacks those scores.
+type Row struct {
+	ID        string         `json:"id,omitempty"`
+	Input     map[string]any `json:"input,omitempty"`
+	Reference string         `json:"reference,omitempty"`
+	Output    string         `json:"output"`
+	Scores    []Score        `json:"scores"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// Score returns the row's score from the named evaluator.
+func (r Row) Score(evaluator string) (Score, bool) {
+	for _, s := range r.Scores {
+		if s.Evaluator == evaluator {
+			return s, true
+		}
+	}
+	return Score{}, false
+}
+
+// Summary aggregates an evaluator's scores over all rows. Rows the
+// evaluator could not score count as Errors and are left out of Mean and
+// PassRate.
+type Summary struct {
+	Evaluator string  `json:"evaluator"`
+	Count     int     `json:"count"`
+	Errors    int     `json:"errors"`
+	Mean      float64 `json:"mean"`
+	PassRate  float64 `json:"pass_rate"`
+}
+
+// Summary returns the summary of the named evaluator.
+func (r *Report) Summary(evaluator string) (Summary, bool) {
+	for _, s := range r.Summaries {
+		if s.Evaluator == evaluator {
+			return s, true
+		}
+	}
+	return Summary{}, false
+}
+
+// Passed reports whether every row was scored and passed every evaluator.
+func (r *Report) Passed() bool {
+	for _, s := range r.Summaries {
+		if s.Errors > 0 || s.PassRate < 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// newReport aggregates rows into a report with one summary per evaluator,
+// in the order of evaluators.
+func newReport(name string, rows []Row, evaluators []Evaluator, duration time.Duration) *Report {
+	report := &Report{Name: name, Duration: duration, Rows: rows, Summaries: make([]Summary, len(evaluators))}
+	for i, evaluator := range evaluators {
+		summary := Summary{Evaluator: evaluator.Name()}
+		var total float64
+		passed := 0
+		for _, row := range rows {
+			score, ok := row.Score(summary.Evaluator)
+			if !ok {
+				summary.Errors++
+				continue
+			}
+			summary.Count++
+			total += score.Value
+			if score.Passed {
+				passed++
+			}
+		}
+		if summary.Count > 0 {
+			summary.Mean = total / float64(summary.Count)
+			summary.PassRate = float64(passed) / float64(summary.Count)
+		}
+		report.Summaries[i] = summary
+	}
+	return report
+}
+
+// Delta compares one evaluator between a baseline and a candidate report.
+// Changes are absolute: -0.25 means the mean score dropped by 0.25.
+type Delta struct {
+	Evaluator      string  `json:"evaluator"`
+	Baseline       Summary `json:"baseline"`
+	Candidate      Summary `json:"candidate"`
+	MeanChange     float64 `json:"mean_change"`
+	PassRateChange float64 `json:"pass_rate_change"`
+	// Regressions lists the IDs (or indexes) of examples that passed the
+	// evaluator in the baseline and fail it in the candidate.
+	Regressions []string `json:"regressions,omitempty"`
+}
+
+// Regressed reports whether the candidate scored worse than the baseline.
+func (d Delta) Regressed() bool {
+	return d.MeanChange < 0 || d.PassRateChange < 0 || len(d.Regressions) > 0
+}
+
+// Comparison is the result of Compare.
+type Comparison struct {
+	Baseline  string  `json:"baseline"`
+	Candidate string  `json:"candidate"`
+	Deltas    []Delta `json:"deltas"`
+	// Missing lists candidate evaluators without a baseline summary
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Regressed reports whether the candidate scored worse on any evaluator.
+func (c *Comparison) Regressed() bool {
+	for _, d := range c.Deltas {
+		if d.Regressed() {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare compares the summaries of two reports over the same examples,
+// e.g. two versions of a template. Examples are matched by ID, or by
+// position when they have none.
+func Compare(baseline, candidate *Report) *Comparison {
+	comparison := &Comparison{
+		Baseline:  baseline.Name,
+		Candidate: candidate.Name,
+		Deltas:    make([]Delta, 0, len(candidate.Summaries)),
+	}
+	baseRows := make(map[string]Row, len(baseline.Rows))
+	for i, row := range baseline.Rows {
+		baseRows[rowKey(i, row)] = row
+	}
+
+	for _, cur := range candidate.Summaries {
+		base, ok := baseline.Summary(cur.Evaluator)
+		if !ok {
+			comparison.Missing = append(comparison.Missing, cur.Evaluator)
+			continue
+		}
+
+		d := Delta{
+			Evaluator:      cur.Evaluator,
+			Baseline:       base,
+			Candidate:      cur,
+			MeanChange:     cur.Mean - base.Mean,
+			PassRateChange: cur.PassRate - base.PassRate,
+		}
+		for i, row := range candidate.Rows {
+			key := rowKey(i, row)
+			before, ok := baseRows[key].Score(cur.Evaluator)
+			if !ok || !before.Passed {
+				continue
+			}
+			if after, ok := row.Score(cur.Evaluator); !ok || !after.Passed {
+				d.Regressions = append(d.Regressions, key)
+			}
+		}
+		comparison.Deltas = append(comparison.Deltas, d)
+	}
+	return comparison
+}
+
+// rowKey identifies a row by its example ID, or its index without one.
+func rowKey(i int, row Row) string {
+	if row.ID != "" {
+		return row.ID
+	}
+	return strconv.Itoa(i)
+}
@@ -0,0 +1,212 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+// Thresholds decide whether a candidate version passes a comparison. The
+// zero value allows no drop in any evaluator's mean score or pass rate and
+// no additional unscored examples.
+type Thresholds struct {
+	// MaxMeanDrop is the allowed decrease of an evaluator's mean score.
+	MaxMeanDrop float64 `json:"max_mean_drop"`
+	// MaxPassRateDrop is the allowed decrease of an evaluator's pass rate.
+	MaxPassRateDrop float64 `json:"max_pass_rate_drop"`
+	// MinPassRate is the pass rate every evaluator must reach on the
+	// candidate, regardless of the baseline.
+	MinPassRate float64 `json:"min_pass_rate"`
+}
+
+// Check returns the reasons the delta fails the thresholds, if any.
+func (t Thresholds) Check(d Delta) []string {
+	var failures []string
+	if -d.MeanChange > t.MaxMeanDrop {
+		failures = append(failures, fmt.Sprintf(failMeanDrop, d.Evaluator, -d.MeanChange, t.MaxMeanDrop))
+	}
+	if -d.PassRateChange > t.MaxPassRateDrop {
+		failures = append(failures, fmt.Sprintf(failPassRateDrop, d.Evaluator, -d.PassRateChange, t.MaxPassRateDrop))
+	}
+	if d.Candidate.PassRate < t.MinPassRate {
+		failures = append(failures, fmt.Sprintf(failMinPassRate, d.Evaluator, d.Candidate.PassRate, t.MinPassRate))
+	}
+	if d.Candidate.Errors > d.Baseline.Errors {
+		failures = append(failures, fmt.Sprintf(failErrors, d.Evaluator, d.Candidate.Errors, d.Baseline.Errors))
+	}
+	return failures
+}
+
+// VersionTargetFunc builds the target that produces the outputs of a
+// stored template version.
+type VersionTargetFunc func(tmpl *prompty.StoredTemplate) (Target, error)
+
+// WithThresholds sets the thresholds CompareVersions checks.
+// Default: the zero Thresholds
+func WithThresholds(t Thresholds) Option {
+	return func(c *config) {
+		c.thresholds = t
+	}
+}
+
+// WithVersionTarget makes CompareVersions produce outputs with fn, e.g. to
+// send each version to a model with CompletionTarget.
+// Default: render the version with the storage engine
+func WithVersionTarget(fn VersionTargetFunc) Option {
+	return func(c *config) {
+		c.versionTarget = fn
+	}
+}
+
+// VersionReport is the result of CompareVersions. Passed is false when any
+// evaluator fails the thresholds.
+type VersionReport struct {
+	Name             string      `json:"name"`
+	BaseVersion      int         `json:"base_version"`
+	CandidateVersion int         `json:"candidate_version"`
+	Dataset          string      `json:"dataset"`
+	Thresholds       Thresholds  `json:"thresholds"`
+	Baseline         *Report     `json:"baseline"`
+	Candidate        *Report     `json:"candidate"`
+	Comparison       *Comparison `json:"comparison"`
+	// Rows lists the examples whose output, error or scores changed
+	Rows     []RowDiff `json:"rows,omitempty"`
+	Failures []string  `json:"failures,omitempty"`
+	Passed   bool      `json:"passed"`
+}
+
+// RowDiff shows how one example changed between the versions.
+type RowDiff struct {
+	ID              string      `json:"id"`
+	BaselineOutput  string      `json:"baseline_output"`
+	CandidateOutput string      `json:"candidate_output"`
+	BaselineError   string      `json:"baseline_error,omitempty"`
+	CandidateError  string      `json:"candidate_error,omitempty"`
+	Scores          []ScoreDiff `json:"scores"`
+}
+
+// ScoreDiff compares an evaluator's scores of one example. A missing
+// score, when the target or evaluator failed, is the zero Score.
+type ScoreDiff struct {
+	Evaluator string  `json:"evaluator"`
+	Baseline  Score   `json:"baseline"`
+	Candidate Score   `json:"candidate"`
+	Change    float64 `json:"change"`
+}
+
+// CompareVersions evaluates two versions of a stored template over the
+// dataset and checks the candidate against the thresholds (WithThresholds).
+// A version of 0 selects the latest. Outputs are rendered by engine unless
+// WithVersionTarget is given.
+func CompareVersions(ctx context.Context, engine *prompty.StorageEngine, name string, baseVersion, candidateVersion int, dataset *Dataset, evaluators []Evaluator, opts ...Option) (*VersionReport, error) {
+	if engine == nil {
+		return nil, NewConfigError(ErrMsgNoEngine)
+	}
+	if dataset == nil {
+		return nil, NewConfigError(ErrMsgNoDataset)
+	}
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	base, err := loadVersion(ctx, engine, name, baseVersion)
+	if err != nil {
+		return nil, err
+	}
+	candidate, err := loadVersion(ctx, engine, name, candidateVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &VersionReport{
+		Name:             name,
+		BaseVersion:      base.Version,
+		CandidateVersion: candidate.Version,
+		Dataset:          dataset.Name,
+		Thresholds:       cfg.thresholds,
+	}
+	if report.Baseline, err = runVersion(ctx, engine, base, dataset, evaluators, cfg, opts); err != nil {
+		return nil, err
+	}
+	if report.Candidate, err = runVersion(ctx, engine, candidate, dataset, evaluators, cfg, opts); err != nil {
+		return nil, err
+	}
+
+	report.Comparison = Compare(report.Baseline, report.Candidate)
+	for _, d := range report.Comparison.Deltas {
+		report.Failures = append(report.Failures, cfg.thresholds.Check(d)...)
+	}
+	report.Rows = diffRows(report.Baseline, report.Candidate)
+	report.Passed = len(report.Failures) == 0
+	return report, nil
+}
+
+// loadVersion loads a template version, or the latest for version 0.
+func loadVersion(ctx context.Context, engine *prompty.StorageEngine, name string, version int) (*prompty.StoredTemplate, error) {
+	var tmpl *prompty.StoredTemplate
+	var err error
+	if version > 0 {
+		tmpl, err = engine.GetVersion(ctx, name, version)
+	} else {
+		tmpl, err = engine.Get(ctx, name)
+	}
+	if err != nil {
+		return nil, NewVersionLoadError(name, version, err)
+	}
+	return tmpl, nil
+}
+
+// runVersion evaluates one version with the configured target.
+func runVersion(ctx context.Context, engine *prompty.StorageEngine, tmpl *prompty.StoredTemplate, dataset *Dataset, evaluators []Evaluator, cfg *config, opts []Option) (*Report, error) {
+	var target Target = func(ctx context.Context, input map[string]any) (string, error) {
+		return engine.ExecuteVersion(ctx, tmpl.Name, tmpl.Version, input)
+	}
+	if cfg.versionTarget != nil {
+		var err error
+		if target, err = cfg.versionTarget(tmpl); err != nil {
+			return nil, NewVersionLoadError(tmpl.Name, tmpl.Version, err)
+		}
+	}
+	return Run(ctx, fmt.Sprintf(versionLabel, tmpl.Name, tmpl.Version), target, dataset.Examples, evaluators, opts...)
+}
+
+// diffRows lists the examples whose output, error or scores differ.
+func diffRows(baseline, candidate *Report) []RowDiff {
+	baseRows := make(map[string]Row, len(baseline.Rows))
+	for i, row := range baseline.Rows {
+		baseRows[rowKey(i, row)] = row
+	}
+
+	var diffs []RowDiff
+	for i, row := range candidate.Rows {
+		key := rowKey(i, row)
+		base := baseRows[key]
+		diff := RowDiff{
+			ID:              key,
+			BaselineOutput:  base.Output,
+			CandidateOutput: row.Output,
+			BaselineError:   base.Error,
+			CandidateError:  row.Error,
+		}
+		changed := base.Output != row.Output || base.Error != row.Error
+		for _, summary := range candidate.Summaries {
+			before, _ := base.Score(summary.Evaluator)
+			after, _ := row.Score(summary.Evaluator)
+			if before != after {
+				changed = true
+			}
+			diff.Scores = append(diff.Scores, ScoreDiff{
+				Evaluator: summary.Evaluator,
+				Baseline:  before,
+				Candidate: after,
+				Change:    after.Value - before.Value,
+			})
+		}
+		if changed {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs
+}
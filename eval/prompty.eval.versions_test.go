@@ -0,0 +1,103 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/itsatony/go-prompty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newVersionEngine(t *testing.T, sources ...string) *prompty.StorageEngine {
+	t.Helper()
+	engine, err := prompty.NewStorageEngine(prompty.StorageEngineConfig{Storage: prompty.NewMemoryStorage()})
+	require.NoError(t, err)
+	t.Cleanup(func() { engine.Close() })
+	for _, source := range sources {
+		require.NoError(t, engine.Save(context.Background(), &prompty.StoredTemplate{Name: "capital", Source: source}))
+	}
+	return engine
+}
+
+var versionDataset = &Dataset{Name: "capitals", Examples: []Example{
+	{ID: "fr", Input: map[string]any{"capital": "Paris"}, Reference: "Paris"},
+	{ID: "it", Input: map[string]any{"capital": "Rome"}, Reference: "Rome"},
+}}
+
+func TestCompareVersions(t *testing.T) {
+	engine := newVersionEngine(t,
+		`The capital is {~prompty.var name="capital" /~}.`,
+		`{~prompty.if eval="capital == 'Paris'"~}Paris{~prompty.else~}No idea{~/prompty.if~}`,
+	)
+	ctx := context.Background()
+
+	report, err := CompareVersions(ctx, engine, "capital", 1, 0, versionDataset, []Evaluator{Reference()})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.BaseVersion)
+	assert.Equal(t, 2, report.CandidateVersion)
+	assert.Equal(t, "capitals", report.Dataset)
+	assert.Equal(t, "capital@v1", report.Baseline.Name)
+	assert.Equal(t, "capital@v2", report.Candidate.Name)
+	assert.False(t, report.Passed)
+	assert.Equal(t, []string{
+		"reference: mean score dropped by 0.500 (allowed 0.000)",
+		"reference: pass rate dropped by 0.500 (allowed 0.000)",
+	}, report.Failures)
+
+	require.Len(t, report.Rows, 2)
+	assert.Equal(t, "The capital is Paris.", report.Rows[0].BaselineOutput)
+	assert.Equal(t, "Paris", report.Rows[0].CandidateOutput)
+	assert.Zero(t, report.Rows[0].Scores[0].Change)
+	assert.Equal(t, "it", report.Rows[1].ID)
+	assert.Equal(t, -1.0, report.Rows[1].Scores[0].Change)
+	assert.Equal(t, []string{"it"}, report.Comparison.Deltas[0].Regressions)
+
+	lenient, err := CompareVersions(ctx, engine, "capital", 1, 2, versionDataset, []Evaluator{Reference()},
+		WithThresholds(Thresholds{MaxMeanDrop: 0.5, MaxPassRateDrop: 0.5, MinPassRate: 0.75}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"reference: pass rate 0.500 is below 0.750"}, lenient.Failures)
+
+	same, err := CompareVersions(ctx, engine, "capital", 1, 1, versionDataset, []Evaluator{Reference()})
+	require.NoError(t, err)
+	assert.True(t, same.Passed)
+	assert.Empty(t, same.Rows)
+}
+
+func TestCompareVersions_VersionTarget(t *testing.T) {
+	engine := newVersionEngine(t, "one", "two")
+
+	var versions []int
+	target := func(tmpl *prompty.StoredTemplate) (Target, error) {
+		versions = append(versions, tmpl.Version)
+		return func(context.Context, map[string]any) (string, error) {
+			if tmpl.Version == 2 {
+				return "", errors.New("model down")
+			}
+			return strings.ToUpper(tmpl.Source), nil
+		}, nil
+	}
+	report, err := CompareVersions(context.Background(), engine, "capital", 1, 2, versionDataset, []Evaluator{JSONValid()},
+		WithVersionTarget(target), WithThresholds(Thresholds{MaxMeanDrop: 1, MaxPassRateDrop: 1}))
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, versions)
+	assert.Equal(t, "ONE", report.Rows[0].BaselineOutput)
+	assert.Contains(t, report.Rows[0].CandidateError, "model down")
+	assert.Equal(t, []string{"json_valid: 2 example(s) could not be scored (baseline: 0)"}, report.Failures)
+}
+
+func TestCompareVersions_Errors(t *testing.T) {
+	engine := newVersionEngine(t, "one")
+	ctx := context.Background()
+
+	_, err := CompareVersions(ctx, nil, "capital", 1, 2, versionDataset, []Evaluator{Reference()})
+	assert.ErrorContains(t, err, ErrMsgNoEngine)
+	_, err = CompareVersions(ctx, engine, "capital", 1, 2, nil, []Evaluator{Reference()})
+	assert.ErrorContains(t, err, ErrMsgNoDataset)
+	_, err = CompareVersions(ctx, engine, "capital", 1, 5, versionDataset, []Evaluator{Reference()})
+	assert.ErrorContains(t, err, ErrMsgVersionLoad)
+	_, err = CompareVersions(ctx, engine, "capital", 1, 1, versionDataset, nil)
+	assert.ErrorContains(t, err, ErrMsgNoEvaluators)
+}
@@ -0,0 +1,245 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/itsatony/go-prompty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func evaluate(t *testing.T, e Evaluator, output, reference string) Score {
+	t.Helper()
+	score, err := e.Evaluate(context.Background(), Sample{Output: output, Reference: reference})
+	require.NoError(t, err)
+	return score
+}
+
+func TestHeuristicEvaluators(t *testing.T) {
+	re, err := Regex(`^\d+$`)
+	require.NoError(t, err)
+	assert.True(t, evaluate(t, re, "42", "").Passed)
+	failed := evaluate(t, re, "forty-two", "")
+	assert.False(t, failed.Passed)
+	assert.Equal(t, scoreFail, failed.Value)
+	assert.Equal(t, `output does not match "^\\d+$"`, failed.Reason)
+
+	assert.True(t, evaluate(t, JSONValid(), `{"a": [1, 2]}`, "").Passed)
+	assert.Contains(t, evaluate(t, JSONValid(), `{"a":`, "").Reason, "not valid JSON")
+
+	length, err := Length(2, 4)
+	require.NoError(t, err)
+	assert.True(t, evaluate(t, length, "héé", "").Passed)
+	assert.Equal(t, "length 1 is less than 2", evaluate(t, length, "a", "").Reason)
+	assert.Equal(t, "length 5 is greater than 4", evaluate(t, length, "abcde", "").Reason)
+	unbounded, err := Length(1, 0)
+	require.NoError(t, err)
+	assert.True(t, evaluate(t, unbounded, strings.Repeat("a", 1000), "").Passed)
+
+	toxicity, err := Toxicity([]string{"idiot", "stupid"})
+	require.NoError(t, err)
+	assert.True(t, evaluate(t, toxicity, "Idiomatic code is stupendous", "").Passed)
+	flagged := evaluate(t, toxicity, "What an IDIOT.", "")
+	assert.False(t, flagged.Passed)
+	assert.Equal(t, 0.5, flagged.Value)
+	assert.Equal(t, "output contains flagged words: idiot", flagged.Reason)
+
+	assert.True(t, evaluate(t, Reference(), "The capital is Paris.", " paris ").Passed)
+	assert.False(t, evaluate(t, Reference(), "The capital is Lyon.", "Paris").Passed)
+	assert.True(t, evaluate(t, Reference(), "anything", "").Passed)
+}
+
+func TestHeuristicEvaluators_InvalidConfig(t *testing.T) {
+	_, err := Regex("(")
+	assert.ErrorContains(t, err, ErrMsgInvalidPattern)
+	_, err = Length(5, 2)
+	assert.ErrorContains(t, err, ErrMsgInvalidLength)
+	_, err = Length(-1, 0)
+	assert.ErrorContains(t, err, ErrMsgInvalidLength)
+	_, err = Toxicity(nil)
+	assert.ErrorContains(t, err, ErrMsgNoWords)
+}
+
+func TestJudge(t *testing.T) {
+	var payload map[string]any
+	model := func(_ context.Context, p map[string]any) (string, error) {
+		payload = p
+		return "Sure!\n```json\n{\"score\": 0.8, \"reason\": \"polite\"}\n```", nil
+	}
+	judge, err := NewJudge(JudgeConfig{
+		Criteria:  "The reply is polite.",
+		Provider:  prompty.ProviderOpenAI,
+		Execution: &prompty.ExecutionConfig{Model: "gpt-4o-mini"},
+		Model:     model,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, EvaluatorJudge, judge.Name())
+
+	score, err := judge.Evaluate(context.Background(), Sample{
+		Input:  map[string]any{"question": "Hi?"},
+		Output: "Hello, how can I help?",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0.8, score.Value)
+	assert.True(t, score.Passed)
+	assert.Equal(t, "polite", score.Reason)
+
+	assert.Equal(t, "gpt-4o-mini", payload["model"])
+	data, err := json.Marshal(payload["messages"])
+	require.NoError(t, err)
+	var messages []struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	require.NoError(t, json.Unmarshal(data, &messages))
+	require.Len(t, messages, 2)
+	assert.Equal(t, prompty.RoleSystem, messages[0].Role)
+	assert.Equal(t, prompty.RoleUser, messages[1].Role)
+	assert.Contains(t, messages[1].Content, "The reply is polite.")
+	assert.Contains(t, messages[1].Content, `{"question":"Hi?"}`)
+	assert.Contains(t, messages[1].Content, "Hello, how can I help?")
+	assert.NotContains(t, messages[1].Content, "Reference answer")
+
+	_, err = judge.Evaluate(context.Background(), Sample{Output: "x", Reference: "Paris"})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(mustMarshal(t, payload["messages"]), &messages))
+	assert.Contains(t, messages[1].Content, "Reference answer:\nParis")
+}
+
+func mustMarshal(t *testing.T, v any) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return data
+}
+
+func TestJudge_Errors(t *testing.T) {
+	reply := func(s string, err error) Model {
+		return func(context.Context, map[string]any) (string, error) { return s, err }
+	}
+
+	_, err := NewJudge(JudgeConfig{Model: reply("", nil)})
+	assert.ErrorContains(t, err, ErrMsgJudgeNoCriteria)
+	_, err = NewJudge(JudgeConfig{Criteria: "c"})
+	assert.ErrorContains(t, err, ErrMsgJudgeNoModel)
+
+	tests := []struct {
+		name  string
+		model Model
+		msg   string
+	}{
+		{"call", reply("", errors.New("timeout")), ErrMsgJudgeCall},
+		{"no json", reply("great", nil), ErrMsgJudgeInvalidReply},
+		{"no score", reply(`{"reason": "x"}`, nil), ErrMsgJudgeInvalidReply},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			judge, err := NewJudge(JudgeConfig{Criteria: "c", Model: tt.model})
+			require.NoError(t, err)
+			_, err = judge.Evaluate(context.Background(), Sample{Output: "x"})
+			assert.ErrorContains(t, err, tt.msg)
+			assert.Equal(t, ErrCodeJudge, prompty.ErrorDetailsOf(err).Code)
+		})
+	}
+
+	judge, err := NewJudge(JudgeConfig{Criteria: "c", Threshold: 0.9, Model: reply(`{"score": 1.5}`, nil)})
+	require.NoError(t, err)
+	score, err := judge.Evaluate(context.Background(), Sample{Output: "x"})
+	require.NoError(t, err)
+	assert.Equal(t, scorePass, score.Value)
+}
+
+func TestRun_AndCompare(t *testing.T) {
+	engine := prompty.MustNew()
+	v1, err := engine.Parse(`{"answer": "{~prompty.var name="city" /~}"}`)
+	require.NoError(t, err)
+	v2, err := engine.Parse(`The answer is {~prompty.var name="city" /~}`)
+	require.NoError(t, err)
+
+	examples := []Example{
+		{ID: "paris", Input: map[string]any{"city": "Paris"}, Reference: "paris"},
+		{ID: "rome", Input: map[string]any{"city": "Rome"}, Reference: "Rome"},
+		{ID: "missing", Input: map[string]any{}},
+	}
+	evaluators := []Evaluator{JSONValid(), Reference()}
+
+	base, err := Run(context.Background(), "v1", RenderTarget(v1), examples, evaluators, WithConcurrency(2))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", base.Name)
+	require.Len(t, base.Rows, 3)
+	assert.Equal(t, `{"answer": "Paris"}`, base.Rows[0].Output)
+	assert.Contains(t, base.Rows[2].Error, ErrMsgTargetFailed)
+
+	jsonSummary, ok := base.Summary(EvaluatorJSONValid)
+	require.True(t, ok)
+	assert.Equal(t, Summary{Evaluator: EvaluatorJSONValid, Count: 2, Errors: 1, Mean: 1, PassRate: 1}, jsonSummary)
+	assert.False(t, base.Passed())
+
+	candidate, err := Run(context.Background(), "v2", RenderTarget(v2), examples, evaluators)
+	require.NoError(t, err)
+
+	comparison := Compare(base, candidate)
+	assert.Equal(t, "v1", comparison.Baseline)
+	assert.Equal(t, "v2", comparison.Candidate)
+	require.Len(t, comparison.Deltas, 2)
+	assert.True(t, comparison.Regressed())
+
+	jsonDelta := comparison.Deltas[0]
+	assert.Equal(t, -1.0, jsonDelta.MeanChange)
+	assert.Equal(t, []string{"paris", "rome"}, jsonDelta.Regressions)
+	refDelta := comparison.Deltas[1]
+	assert.False(t, refDelta.Regressed())
+	assert.Zero(t, refDelta.MeanChange)
+
+	data, err := json.Marshal(comparison)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"mean_change":-1`)
+}
+
+func TestRun_Errors(t *testing.T) {
+	target := func(context.Context, map[string]any) (string, error) { return "ok", nil }
+
+	_, err := Run(context.Background(), "x", nil, nil, []Evaluator{JSONValid()})
+	assert.ErrorContains(t, err, ErrMsgNoTarget)
+	_, err = Run(context.Background(), "x", target, nil, nil)
+	assert.ErrorContains(t, err, ErrMsgNoEvaluators)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Run(ctx, "x", target, []Example{{}}, []Evaluator{JSONValid()})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	failing, err := NewJudge(JudgeConfig{Criteria: "c", Model: func(context.Context, map[string]any) (string, error) {
+		return "", errors.New("down")
+	}})
+	require.NoError(t, err)
+	report, err := Run(context.Background(), "x", target, []Example{{}}, []Evaluator{JSONValid(), failing})
+	require.NoError(t, err)
+	assert.Contains(t, report.Rows[0].Error, ErrMsgEvaluatorFailed+": judge")
+	summary, _ := report.Summary(EvaluatorJudge)
+	assert.Equal(t, 1, summary.Errors)
+}
+
+func TestCompletionTarget(t *testing.T) {
+	prompt := &prompty.Prompt{
+		Name:        "capital",
+		Description: "Asks for a capital",
+		Execution:   &prompty.ExecutionConfig{Provider: prompty.ProviderAnthropic, Model: "claude-x"},
+		Body:        `Capital of {~prompty.var name="country" /~}?`,
+	}
+	var payload map[string]any
+	target := CompletionTarget(prompt, "", func(_ context.Context, p map[string]any) (string, error) {
+		payload = p
+		return "Paris", nil
+	}, nil)
+
+	output, err := target(context.Background(), map[string]any{"country": "France"})
+	require.NoError(t, err)
+	assert.Equal(t, "Paris", output)
+	assert.Equal(t, "claude-x", payload["model"])
+	assert.Contains(t, string(mustMarshal(t, payload["messages"])), "Capital of France?")
+}
@@ -1,5 +1,7 @@
 package internal
 
+import "strings"
+
 // TokenType represents the type of a lexical token
 type TokenType string
 
@@ -17,6 +19,26 @@ const (
 	TokenTypeEOF        TokenType = "EOF"
 )
 
+// HighlightKind classifies a span of template source for syntax highlighting
+type HighlightKind string
+
+// Highlight kind constants
+const (
+	HighlightKindText        HighlightKind = "text"
+	HighlightKindFrontmatter HighlightKind = "frontmatter"
+	HighlightKindEscape      HighlightKind = "escape"
+	HighlightKindTagOpen     HighlightKind = "tag-open"
+	HighlightKindTagClose    HighlightKind = "tag-close"
+	HighlightKindTagName     HighlightKind = "tag-name"
+	HighlightKindAttribute   HighlightKind = "attribute"
+	HighlightKindEquals      HighlightKind = "equals"
+	HighlightKindString      HighlightKind = "string"
+	HighlightKindExpression  HighlightKind = "expression"
+	HighlightKindComment     HighlightKind = "comment"
+	HighlightKindWhitespace  HighlightKind = "whitespace"
+	HighlightKindInvalid     HighlightKind = "invalid"
+)
+
 // NodeType identifies AST node types
 type NodeType int
 
@@ -84,6 +106,7 @@ const (
 	CharTab         = '\t'
 	CharCarriageRet = '\r'
 	CharNullByte    = "\x00" // String for use with strings.ReplaceAll (security: marker sanitization)
+	CharUnderscore  = "_"    // String for use with strings.ReplaceAll
 )
 
 // String constants for delimiter matching
@@ -93,6 +116,12 @@ const (
 	StrSelfClose  = "/~}"
 	StrBlockClose = "{~/"
 	StrEscapeOpen = "\\{~"
+
+	// StrWhitespaceChars is the set of characters skipped between tag tokens
+	StrWhitespaceChars = " \t\n\r"
+
+	// StrNewline is CharNewline as a string, for counting lines in spans
+	StrNewline = "\n"
 )
 
 // Delimiter lengths
@@ -104,22 +133,28 @@ const (
 	LenEscapeOpen = 3 // \{~
 )
 
+// estimatedTokensPerTag sizes the lexer's token slice: open, name, one
+// attribute (name, equals, value), close, and the text that follows
+const estimatedTokensPerTag = 7
+
 // Log message constants
 const (
-	LogMsgLexerCreated       = "lexer created"
-	LogMsgTokenizerStart     = "starting tokenization"
-	LogMsgTokenizerEnd       = "tokenization complete"
-	LogMsgParserCreated      = "parser created"
-	LogMsgParserStart        = "starting parse"
-	LogMsgParserEnd          = "parse complete"
-	LogMsgExecutorCreated    = "executor created"
-	LogMsgExecutorStart      = "starting execution"
-	LogMsgExecutorEnd        = "execution complete"
-	LogMsgResolverInvoked    = "resolver invoked"
-	LogMsgResolverComplete   = "resolver complete"
-	LogMsgRegistryCreated    = "registry created"
-	LogMsgResolverRegistered = "resolver registered"
-	LogMsgResolverCollision  = "resolver registration collision - first-come-wins"
+	LogMsgLexerCreated         = "lexer created"
+	LogMsgTokenizerStart       = "starting tokenization"
+	LogMsgTokenizerEnd         = "tokenization complete"
+	LogMsgParserCreated        = "parser created"
+	LogMsgParserStart          = "starting parse"
+	LogMsgParserEnd            = "parse complete"
+	LogMsgExecutorCreated      = "executor created"
+	LogMsgExecutorStart        = "starting execution"
+	LogMsgExecutorEnd          = "execution complete"
+	LogMsgResolverInvoked      = "resolver invoked"
+	LogMsgResolverComplete     = "resolver complete"
+	LogMsgRegistryCreated      = "registry created"
+	LogMsgResolverRegistered   = "resolver registered"
+	LogMsgResolverUnregistered = "resolver unregistered"
+	LogMsgResolverCollision    = "resolver registration collision - first-come-wins"
+	LogMsgRegistryFrozen       = "registry snapshot is read-only, wrapper ignored"
 )
 
 // Log field names
@@ -144,45 +179,79 @@ const (
 
 // Built-in tag names (mirror public constants for internal use)
 const (
-	TagNameVar           = "prompty.var"
-	TagNameRaw           = "prompty.raw"
-	TagNameInclude       = "prompty.include"
-	TagNameIf            = "prompty.if"
-	TagNameElseIf        = "prompty.elseif"
-	TagNameElse          = "prompty.else"
-	TagNameComment       = "prompty.comment"        // Phase 3
-	TagNameFor           = "prompty.for"            // Phase 4
-	TagNameSwitch        = "prompty.switch"         // Phase 5
-	TagNameCase          = "prompty.case"           // Phase 5
-	TagNameCaseDefault   = "prompty.casedefault"    // Phase 5
-	TagNameEnv           = "prompty.env"            // Environment variable resolver
-	TagNameConfig        = "prompty.config"         // Legacy inference configuration block (JSON)
-	TagNameExtends       = "prompty.extends"        // Template inheritance - extends parent
-	TagNameBlock         = "prompty.block"          // Template inheritance - overridable block
-	TagNameParent        = "prompty.parent"         // Template inheritance - call parent block content
-	TagNameRef           = "prompty.ref"            // v2.0: Prompt reference resolver
-	TagNameSkillsCatalog = "prompty.skills_catalog" // v2.1: Skills catalog generator
-	TagNameToolsCatalog  = "prompty.tools_catalog"  // v2.1: Tools catalog generator
+	TagNameVar             = "prompty.var"
+	TagNameRaw             = "prompty.raw"
+	TagNameInclude         = "prompty.include"
+	TagNameIf              = "prompty.if"
+	TagNameElseIf          = "prompty.elseif"
+	TagNameElse            = "prompty.else"
+	TagNameComment         = "prompty.comment"          // Phase 3
+	TagNameFor             = "prompty.for"              // Phase 4
+	TagNameSwitch          = "prompty.switch"           // Phase 5
+	TagNameCase            = "prompty.case"             // Phase 5
+	TagNameCaseDefault     = "prompty.casedefault"      // Phase 5
+	TagNameDefault         = "prompty.default"          // Alias for prompty.casedefault inside a switch
+	TagNameEnv             = "prompty.env"              // Environment variable resolver
+	TagNameConfig          = "prompty.config"           // Legacy inference configuration block (JSON)
+	TagNameExtends         = "prompty.extends"          // Template inheritance - extends parent
+	TagNameBlock           = "prompty.block"            // Template inheritance - overridable block
+	TagNameParent          = "prompty.parent"           // Template inheritance - call parent block content
+	TagNameRef             = "prompty.ref"              // v2.0: Prompt reference resolver
+	TagNameSkillsCatalog   = "prompty.skills_catalog"   // v2.1: Skills catalog generator
+	TagNameToolsCatalog    = "prompty.tools_catalog"    // v2.1: Tools catalog generator
+	TagNameImport          = "prompty.import"           // Block library import
+	TagNameUse             = "prompty.use"              // Render an imported block
+	TagNameChoice          = "prompty.choice"           // Random element of a collection
+	TagNameShuffle         = "prompty.shuffle"          // Body repeated over a random sample of a collection
+	TagNameHistory         = "prompty.history"          // Conversation history from data
+	TagNameImage           = "prompty.image"            // Image content part of a message
+	TagNameFile            = "prompty.file"             // File content part of a message
+	TagNameCacheBreakpoint = "prompty.cache_breakpoint" // Prompt-cache boundary inside a message
+	TagNameToolResults     = "prompty.tool_results"     // Tool call outputs from data
+	TagNameEscape          = "prompty.escape"           // Output mode of interpolated values in its body
 	// TagNameMessage is defined separately in the message tag constants section
 )
 
 // Attribute name constants
 const (
-	AttrName     = "name"
-	AttrDefault  = "default"
-	AttrTemplate = "template"
-	AttrWith     = "with"
-	AttrIsolate  = "isolate"
-	AttrEval     = "eval"     // Condition expression for if/elseif
-	AttrOnError  = "onerror"  // Per-tag error strategy override
-	AttrItem     = "item"     // Loop variable name (Phase 4)
-	AttrIndex    = "index"    // Loop index variable name (Phase 4)
-	AttrIn       = "in"       // Loop collection path (Phase 4)
-	AttrLimit    = "limit"    // Loop iteration limit (Phase 4)
-	AttrValue    = "value"    // Case value for switch/case (Phase 5)
-	AttrRequired = "required" // Required flag for env resolver
-	AttrSlug     = "slug"     // v2.0: Prompt slug for reference
-	AttrVersion  = "version"  // v2.0: Prompt version for reference
+	AttrName         = "name"
+	AttrDefault      = "default"
+	AttrTemplate     = "template"
+	AttrTemplateExpr = "template_expr" // Include template name computed from an expression
+	AttrWith         = "with"
+	AttrIsolate      = "isolate"
+	AttrSource       = "source"      // Include template source: registered or storage
+	AttrEval         = "eval"        // Condition expression for if/elseif
+	AttrOnError      = "onerror"     // Per-tag error strategy override
+	AttrItem         = "item"        // Loop variable name (Phase 4)
+	AttrIndex        = "index"       // Loop index variable name (Phase 4)
+	AttrIn           = "in"          // Loop collection path (Phase 4)
+	AttrLimit        = "limit"       // Loop iteration limit (Phase 4)
+	AttrValue        = "value"       // Case value for switch/case (Phase 5)
+	AttrFallthrough  = "fallthrough" // Continue into the next case body after a match
+	AttrRequired     = "required"    // Required flag for env resolver
+	AttrSlug         = "slug"        // v2.0: Prompt slug for reference
+	AttrVersion      = "version"     // v2.0: Prompt version for reference
+	AttrAs           = "as"          // Import alias for a block library
+	AttrCacheKey     = "cache_key"   // Data paths keying a tag's cached result
+	AttrFrom         = "from"        // Collection path for prompty.choice, URL path for content parts
+	AttrWindow       = "window"      // Token budget of prompty.history: "tokens:N"
+	AttrFormat       = "format"      // Output format of prompty.history
+	AttrBlock        = "block"       // Qualified block name for use: alias.block
+	AttrURL          = "url"         // URL of a content part (http(s) or data:)
+	AttrDetail       = "detail"      // Image detail level: low, high or auto
+	AttrID           = "id"          // Provider file ID of a content part
+	AttrMediaType    = "media_type"  // MIME type of a content part
+	AttrSelection    = "selection"   // Skills catalog selection: all or relevant
+	AttrQueryFrom    = "query_from"  // Data path of the relevance query of a skills catalog
+	AttrEscape       = "escape"      // Output mode of a single interpolated value
+	AttrMode         = "mode"        // Output mode of prompty.escape
+)
+
+// Include source attribute values
+const (
+	AttrValueRegistered = "registered"
+	AttrValueStorage    = "storage"
 )
 
 // Boolean attribute values
@@ -191,22 +260,123 @@ const (
 	AttrValueFalse = "false"
 )
 
+// AttrValueEphemeral is the Anthropic-style spelling of cache="true" on
+// prompty.message
+const AttrValueEphemeral = "ephemeral"
+
 // Error message constants for include resolver
 const (
 	ErrMsgMissingTemplateAttr = "missing required 'template' attribute"
 	ErrMsgEngineNotAvailable  = "engine not available in context"
 	ErrMsgDepthExceeded       = "maximum template inclusion depth exceeded"
+	ErrMsgIncludeWithInvalid  = "invalid 'with' mapping, expected comma-separated key=expression pairs"
+	ErrMsgIncludeWithEval     = "failed to evaluate 'with' mapping expression"
+	ErrMsgIncludeBadSource    = "invalid 'source' attribute, expected \"registered\" or \"storage\""
+	ErrMsgIncludeBadVersion   = "invalid 'version' attribute, expected a positive integer"
+	ErrMsgStorageNotAvailable = "template storage not available, execute through a StorageEngine"
+	ErrMsgIncludeTemplateBoth = "use either 'template' or 'template_expr', not both"
+	ErrMsgIncludeExprEval     = "failed to evaluate 'template_expr' expression"
+	ErrMsgIncludeExprNotName  = "'template_expr' must evaluate to a non-empty string"
+	ErrMsgIncludeNotAllowed   = "template is not allowed for dynamic include"
+)
+
+// Random selection constants
+const (
+	DefaultShuffleItemVar = "item" // Loop variable of prompty.shuffle without an item attribute
+
+	ErrMsgChoiceMissingFrom = "missing required 'from' attribute"
+)
+
+// Output escaping constants
+const (
+	OutputModeText     = "text" // No escaping
+	OutputModeHTML     = "html"
+	OutputModeJSON     = "json"
+	OutputModeMarkdown = "markdown"
+	OutputModeShell    = "shell"
+
+	ErrMsgEscapeMissingMode = "missing required 'mode' attribute"
+	ErrMsgEscapeUnknownMode = "unknown output mode"
+)
+
+// Conversation history constants
+const (
+	HistoryFormatMessages     = "messages" // Message markers for ExtractMessages
+	HistoryFormatChatML       = "chatml"
+	HistoryFormatPlain        = "plain"
+	HistoryWindowTokensPrefix = "tokens:"
+	HistoryFieldRole          = "role"
+	HistoryFieldContent       = "content"
+	HistoryPlainSep           = ": "
+	HistoryCharsPerToken      = 4.0 // Token estimate of the window, as for GPT and Claude models
+	ChatMLStart               = "<|im_start|>"
+	ChatMLEnd                 = "<|im_end|>"
+
+	ErrMsgHistoryInvalidWindow  = "invalid 'window' attribute, expected \"tokens:N\" with a positive N"
+	ErrMsgHistoryInvalidFormat  = "invalid 'format' attribute, expected \"messages\", \"chatml\" or \"plain\""
+	ErrMsgHistoryInvalidMessage = "history entry must have a role of system, user, assistant or tool"
+)
+
+// Tool results constants
+const (
+	ToolResultsFormatPlain = "plain"
+	ToolResultsFormatXML   = "xml"
+	ToolResultsFormatJSON  = "json"
+	DefaultToolResultsIn   = "tool_results" // Collection path of prompty.tool_results without in
+	ToolResultFieldCallID  = "call_id"
+	ToolResultFieldName    = "name"
+	ToolResultFieldContent = "content"
+	ToolResultFieldIsError = "is_error"
+	ToolResultPlainOK      = "Tool %s returned:\n%s"
+	ToolResultPlainError   = "Tool %s failed:\n%s"
+	ToolResultXMLOpen      = "<tool_result"
+	ToolResultXMLClose     = "</tool_result>"
+	ToolResultXMLTagEnd    = ">"
+	ToolResultXMLAttr      = ` %s="%s"`
+	ToolResultXMLAttrError = "error"
+	ToolResultsSeparator   = "\n\n"
+
+	ErrMsgToolResultsInvalidFormat = "invalid 'format' attribute, expected \"plain\", \"xml\" or \"json\""
+	ErrMsgToolResultInvalid        = "tool result entry must have a name"
+)
+
+// Skills catalog selection constants
+const (
+	SkillsSelectionAll      = "all"
+	SkillsSelectionRelevant = "relevant"
+	DefaultSkillsQueryFrom  = "input.query" // Query path of selection="relevant" without query_from
+
+	ErrMsgSkillsInvalidSelection = "invalid 'selection' attribute, expected \"all\" or \"relevant\""
+	ErrMsgSkillsInvalidLimit     = "invalid 'limit' attribute, expected a positive integer"
+	ErrMsgSkillsSelectionFailed  = "relevant skill selection failed"
+)
+
+// Error message constants for tag result caching
+const (
+	ErrMsgInvalidTagCacheTTL = "invalid 'cache' attribute, expected a positive duration such as \"5m\""
+)
+
+// Include 'with' mapping syntax: with="key1=expr1,key2=expr2"
+const (
+	IncludeWithSeparator = ","
+	IncludeWithAssign    = "="
 )
 
 // Meta key constants for internal data passing and error metadata
 const (
 	MetaKeyParentDepth  = "_parentDepth"
+	MetaKeyIncludeChain = "_includeChain" // Chain of included template keys for cycle detection
 	MetaKeyValue        = "_value"
 	MetaKeyPath         = "path"
 	MetaKeyTemplateName = "template_name"
 	MetaKeyFromType     = "from_type"
 	MetaKeyToType       = "to_type"
 	MetaKeyIterableType = "iterable_type"
+	MetaKeyBinding      = "binding"
+	MetaKeyReason       = "reason"
+	MetaKeyExpression   = "expression"
+	MetaKeyAlias        = "alias"
+	MetaKeyBlock        = "block"
 )
 
 // Log messages for template operations
@@ -266,6 +436,8 @@ const (
 	ErrorStrategyRemove
 	ErrorStrategyKeepRaw
 	ErrorStrategyLog
+	ErrorStrategyCollect
+	ErrorStrategyInclude
 )
 
 // ErrorStrategyNotSet is a sentinel value indicating no strategy override
@@ -278,10 +450,19 @@ const (
 	ErrorStrategyNameRemove  = "remove"
 	ErrorStrategyNameKeepRaw = "keepraw"
 	ErrorStrategyNameLog     = "log"
+	ErrorStrategyNameCollect = "collect"
+	ErrorStrategyNameInclude = "include"
+
+	// ErrorStrategyIncludePrefix prefixes the fallback template name in
+	// onerror="include:name"
+	ErrorStrategyIncludePrefix = ErrorStrategyNameInclude + ":"
 )
 
 // ParseErrorStrategy parses a string into an ErrorStrategy.
 func ParseErrorStrategy(s string) ErrorStrategy {
+	if strings.HasPrefix(s, ErrorStrategyIncludePrefix) {
+		return ErrorStrategyInclude
+	}
 	switch s {
 	case ErrorStrategyNameDefault:
 		return ErrorStrategyDefault
@@ -291,6 +472,10 @@ func ParseErrorStrategy(s string) ErrorStrategy {
 		return ErrorStrategyKeepRaw
 	case ErrorStrategyNameLog:
 		return ErrorStrategyLog
+	case ErrorStrategyNameCollect:
+		return ErrorStrategyCollect
+	case ErrorStrategyNameInclude:
+		return ErrorStrategyInclude
 	case ErrorStrategyNameThrow:
 		return ErrorStrategyThrow
 	default:
@@ -311,6 +496,10 @@ func (s ErrorStrategy) String() string {
 		return ErrorStrategyNameKeepRaw
 	case ErrorStrategyLog:
 		return ErrorStrategyNameLog
+	case ErrorStrategyCollect:
+		return ErrorStrategyNameCollect
+	case ErrorStrategyInclude:
+		return ErrorStrategyNameInclude
 	default:
 		return ErrorStrategyNameThrow
 	}
@@ -320,6 +509,8 @@ func (s ErrorStrategy) String() string {
 const (
 	LogMsgErrorStrategyApplied = "error strategy applied"
 	LogMsgErrorLogged          = "error logged and execution continued"
+	LogMsgErrorNotCollected    = "error not collected outside a collecting execution"
+	LogMsgErrorNoFallback      = "error strategy include without a fallback template"
 	LogFieldStrategy           = "strategy"
 	LogFieldErrorMsg           = "error_message"
 )
@@ -366,22 +557,27 @@ const (
 
 // Error messages for switch/case (Phase 5)
 const (
-	ErrMsgSwitchMissingEval      = "missing required 'eval' attribute for switch"
-	ErrMsgSwitchMissingValue     = "case requires 'value' or 'eval' attribute"
-	ErrMsgSwitchNotClosed        = "switch block not closed"
-	ErrMsgSwitchCaseNotClosed    = "case block not closed"
-	ErrMsgSwitchDefaultNotLast   = "default case must be last in switch"
-	ErrMsgSwitchDuplicateDefault = "only one default case allowed in switch"
-	ErrMsgSwitchInvalidCaseTag   = "unexpected tag inside switch block"
+	ErrMsgSwitchMissingEval        = "missing required 'eval' attribute for switch"
+	ErrMsgSwitchMissingValue       = "case requires 'value' or 'eval' attribute"
+	ErrMsgSwitchNotClosed          = "switch block not closed"
+	ErrMsgSwitchCaseNotClosed      = "case block not closed"
+	ErrMsgSwitchDefaultNotLast     = "default case must be last in switch"
+	ErrMsgSwitchDuplicateDefault   = "only one default case allowed in switch"
+	ErrMsgSwitchInvalidCaseTag     = "unexpected tag inside switch block"
+	ErrMsgSwitchValueAndEval       = "case cannot have both 'value' and 'eval' attributes"
+	ErrMsgSwitchInvalidFallthrough = "invalid 'fallthrough' attribute value (expected \"true\" or \"false\")"
+	ErrMsgSwitchDefaultFallthrough = "default case cannot use 'fallthrough'"
+	ErrMsgSwitchCaseOutsideSwitch  = "case tag used outside of a switch block"
 )
 
 // Log messages for switch/case operations (Phase 5)
 const (
-	LogMsgSwitchEval    = "evaluating switch expression"
-	LogMsgSwitchCase    = "evaluating switch case"
-	LogMsgCaseMatch     = "switch case matched"
-	LogMsgCaseDefault   = "switch default case selected"
-	LogMsgSwitchNoMatch = "no switch case matched"
+	LogMsgSwitchEval      = "evaluating switch expression"
+	LogMsgSwitchCase      = "evaluating switch case"
+	LogMsgCaseMatch       = "switch case matched"
+	LogMsgCaseDefault     = "switch default case selected"
+	LogMsgSwitchNoMatch   = "no switch case matched"
+	LogMsgCaseFallthrough = "switch case falling through"
 )
 
 // Log field names for switch/case (Phase 5)
@@ -472,6 +668,50 @@ const (
 	LogMsgMessageRole      = "message_role"
 )
 
+// Content part constants for prompty.image and prompty.file
+const (
+	ContentPartTypeText  = "text"
+	ContentPartTypeImage = "image"
+	ContentPartTypeFile  = "file"
+	// ContentPartTypeCacheBreakpoint marks the end of the cached prefix; it
+	// only sets Cache on the preceding part and never appears in Parts
+	ContentPartTypeCacheBreakpoint = "cache_breakpoint"
+	ImageDetailLow                 = "low"
+	ImageDetailHigh                = "high"
+	ImageDetailAuto                = "auto"
+
+	// ContentPartMarker starts an encoded content part in message output.
+	// Format: \x00PART:<json>\x00
+	ContentPartMarker = "\x00PART:"
+	DataURLPrefix     = "data:"
+	DataURLBase64     = ";base64,"
+
+	ErrMsgContentPartOutsideMessage = "content part tags must be inside a prompty.message block"
+	ErrMsgImageMissingURL           = "missing required 'url' or 'from' attribute"
+	ErrMsgImageInvalidDetail        = "invalid 'detail' attribute, expected \"low\", \"high\" or \"auto\""
+	ErrMsgFileMissingSource         = "missing 'name', 'url', 'from' or 'id' attribute"
+	ErrMsgContentPartURLPath        = "content part URL path not found in context"
+)
+
+// Error messages for block imports
+const (
+	ErrMsgImportMissingTemplate = "missing required 'template' attribute for import"
+	ErrMsgImportMissingAlias    = "missing required 'as' attribute for import"
+	ErrMsgImportInvalidAlias    = "invalid 'as' attribute, expected a name without dots"
+	ErrMsgImportDuplicateAlias  = "duplicate import alias"
+	ErrMsgImportNotTopLevel     = "import must be a top-level tag of a template executed through an engine"
+	ErrMsgCircularImport        = "circular block import detected"
+	ErrMsgImportDepthExceeded   = "block import depth exceeded"
+	ErrMsgUseMissingBlock       = "missing required 'block' attribute for use"
+	ErrMsgUseInvalidBlock       = "invalid 'block' attribute, expected alias.block"
+	ErrMsgUseUnknownAlias       = "block library not imported"
+	ErrMsgUseBlockNotFound      = "block not found in imported template"
+)
+
+// BlockPathSeparator separates the import alias from the block name in
+// {~prompty.use block="alias.block" /~}
+const BlockPathSeparator = "."
+
 // Error messages for template inheritance
 const (
 	ErrMsgExtendsNotFirst          = "extends must be first tag in template"
@@ -524,9 +764,10 @@ const (
 
 // v2.1 Context keys used during agent compilation
 const (
-	ContextKeySelfBody = "_selfBody"
-	ContextKeySkills   = "skills"
-	ContextKeyTools    = "tools"
+	ContextKeySelfBody       = "_selfBody"
+	ContextKeySkills         = "skills"
+	ContextKeyTools          = "tools"
+	ContextKeySkillsSelector = "_skillsSelector"
 )
 
 // Error messages for reference resolver (v2.0)
@@ -559,3 +800,46 @@ const (
 	MetaKeyRefDepth     = "_refDepth"     // Internal key for tracking reference depth
 	MetaKeyRefChainList = "_refChainList" // Internal key for tracking reference chain ([]string)
 )
+
+// Compiled template binary format
+const (
+	CompiledMagic         = "PRTYC"
+	CompiledFormatVersion = 1
+	MaxCompiledNodeDepth  = 1000 // Nesting limit when decoding, guards against crafted input
+)
+
+// Compiled template node kinds
+const (
+	compiledNodeText byte = iota + 1
+	compiledNodeTag
+	compiledNodeConditional
+	compiledNodeFor
+	compiledNodeSwitch
+	compiledNodeBlock
+)
+
+// Compiled template tag flags
+const (
+	compiledFlagSelfClose byte = 1 << iota
+	compiledFlagChildren
+	compiledFlagAttributes
+)
+
+// Error messages for compiled templates
+const (
+	ErrMsgCompiledBadMagic     = "not a compiled prompty template"
+	ErrMsgCompiledVersion      = "unsupported compiled template format version"
+	ErrMsgCompiledTruncated    = "compiled template data is truncated"
+	ErrMsgCompiledTrailingData = "unexpected data after compiled template"
+	ErrMsgCompiledNodeKind     = "unknown compiled node kind"
+	ErrMsgCompiledTooDeep      = "compiled template nesting exceeds limit"
+	ErrMsgCompiledUnknownNode  = "cannot encode unknown AST node type"
+)
+
+// Error codes classifying compiled template decoding failures
+const (
+	ErrCodeCompiledBadMagic = "PROMPTY_COMPILED_MAGIC"
+	ErrCodeCompiledVersion  = "PROMPTY_COMPILED_VERSION"
+	ErrCodeCompiledCorrupt  = "PROMPTY_COMPILED_CORRUPT" // Truncated or malformed data
+	ErrCodeCompiledTrailing = "PROMPTY_COMPILED_TRAILING"
+)
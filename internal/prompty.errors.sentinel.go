@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"errors"
+
+	"github.com/itsatony/go-cuserr"
+)
+
+// Sentinel error messages
+const (
+	ErrMsgCycleDetected    = "cycle detected"
+	ErrMsgExecutionTimeout = "execution timed out"
+)
+
+// Sentinel errors matched by errors.Is. The public package re-exports them.
+var (
+	ErrNotFound         = cuserr.ErrNotFound
+	ErrValidation       = cuserr.ErrInvalidInput
+	ErrTemplateNotFound = NewSentinelError(ErrMsgTemplateNotFound, ErrNotFound)
+	ErrCycleDetected    = NewSentinelError(ErrMsgCycleDetected, ErrValidation)
+	ErrExecutionTimeout = errors.New(ErrMsgExecutionTimeout)
+)
+
+// SentinelError is a sentinel error refining a broader one: errors.Is
+// matches it against itself and its parent.
+type SentinelError struct {
+	msg    string
+	parent error
+}
+
+// NewSentinelError creates a sentinel error refining parent.
+func NewSentinelError(msg string, parent error) *SentinelError {
+	return &SentinelError{msg: msg, parent: parent}
+}
+
+// Error implements the error interface.
+func (e *SentinelError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the broader sentinel.
+func (e *SentinelError) Unwrap() error {
+	return e.parent
+}
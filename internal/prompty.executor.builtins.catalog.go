@@ -2,10 +2,17 @@ package internal
 
 import (
 	"context"
+	"strconv"
 )
 
+// SkillsSelector renders a catalog of the limit skills most relevant to
+// query. CompileAgent stores one under ContextKeySkillsSelector when its
+// document resolver supports semantic search; limit <= 0 selects the default.
+type SkillsSelector func(ctx context.Context, query string, limit int) (string, error)
+
 // SkillsCatalogResolver handles the prompty.skills_catalog built-in tag.
-// It reads pre-generated skills catalog from the execution context.
+// It reads pre-generated skills catalog from the execution context, or with
+// selection="relevant" renders the skills most relevant to a query.
 type SkillsCatalogResolver struct{}
 
 // NewSkillsCatalogResolver creates a new SkillsCatalogResolver.
@@ -25,6 +32,12 @@ func (r *SkillsCatalogResolver) Resolve(ctx context.Context, execCtx interface{}
 		return "", NewBuiltinError(ErrMsgInvalidContext, TagNameSkillsCatalog)
 	}
 
+	if attrs.GetDefault(AttrSelection, SkillsSelectionAll) == SkillsSelectionRelevant {
+		if catalog, ok, err := r.resolveRelevant(ctx, accessor, attrs); ok || err != nil {
+			return catalog, err
+		}
+	}
+
 	// Read from context key where CompileAgent stores the catalog
 	val, found := accessor.Get(ContextKeySkills)
 	if !found {
@@ -38,8 +51,49 @@ func (r *SkillsCatalogResolver) Resolve(ctx context.Context, execCtx interface{}
 	return "", nil
 }
 
-// Validate checks attributes (no required attributes).
+// resolveRelevant renders the relevant skills catalog. It reports false
+// when there is no selector or query, so the full catalog is used instead.
+func (r *SkillsCatalogResolver) resolveRelevant(ctx context.Context, accessor ContextAccessor, attrs Attributes) (string, bool, error) {
+	val, found := accessor.Get(ContextKeySkillsSelector)
+	if !found {
+		return "", false, nil
+	}
+	selector, ok := val.(SkillsSelector)
+	if !ok {
+		return "", false, nil
+	}
+
+	query := accessor.GetString(attrs.GetDefault(AttrQueryFrom, DefaultSkillsQueryFrom))
+	if query == "" {
+		return "", false, nil
+	}
+
+	limit := 0
+	if limitStr, ok := attrs.Get(AttrLimit); ok {
+		limit, _ = strconv.Atoi(limitStr)
+	}
+
+	catalog, err := selector(ctx, query, limit)
+	if err != nil {
+		return "", true, NewBuiltinError(ErrMsgSkillsSelectionFailed, TagNameSkillsCatalog).
+			WithMetadata(MetaKeyReason, err.Error())
+	}
+	return catalog, true, nil
+}
+
+// Validate checks the optional selection and limit attributes.
 func (r *SkillsCatalogResolver) Validate(attrs Attributes) error {
+	if selection, ok := attrs.Get(AttrSelection); ok &&
+		selection != SkillsSelectionAll && selection != SkillsSelectionRelevant {
+		return NewBuiltinError(ErrMsgSkillsInvalidSelection, TagNameSkillsCatalog).
+			WithMetadata(AttrSelection, selection)
+	}
+	if limitStr, ok := attrs.Get(AttrLimit); ok {
+		if limit, err := strconv.Atoi(limitStr); err != nil || limit <= 0 {
+			return NewBuiltinError(ErrMsgSkillsInvalidLimit, TagNameSkillsCatalog).
+				WithMetadata(AttrLimit, limitStr)
+		}
+	}
 	return nil
 }
 
@@ -135,6 +135,65 @@ func TestSkillsCatalogResolver_Resolve(t *testing.T) {
 	})
 }
 
+func TestSkillsCatalogResolver_ValidateSelection(t *testing.T) {
+	resolver := NewSkillsCatalogResolver()
+
+	assert.NoError(t, resolver.Validate(Attributes{AttrSelection: SkillsSelectionRelevant, AttrLimit: "3"}))
+	assert.ErrorContains(t, resolver.Validate(Attributes{AttrSelection: "random"}), ErrMsgSkillsInvalidSelection)
+	assert.ErrorContains(t, resolver.Validate(Attributes{AttrLimit: "0"}), ErrMsgSkillsInvalidLimit)
+}
+
+func TestSkillsCatalogResolver_ResolveRelevant(t *testing.T) {
+	resolver := NewSkillsCatalogResolver()
+	attrs := Attributes{AttrSelection: SkillsSelectionRelevant, AttrQueryFrom: "q", AttrLimit: "2"}
+
+	var gotQuery string
+	var gotLimit int
+	selector := SkillsSelector(func(ctx context.Context, query string, limit int) (string, error) {
+		gotQuery, gotLimit = query, limit
+		return "relevant catalog", nil
+	})
+
+	t.Run("uses the selector", func(t *testing.T) {
+		ctx := newMockContextAccessor(map[string]any{
+			ContextKeySkills:         "full catalog",
+			ContextKeySkillsSelector: selector,
+			"q":                      "refund",
+		})
+		result, err := resolver.Resolve(context.Background(), ctx, attrs)
+		require.NoError(t, err)
+		assert.Equal(t, "relevant catalog", result)
+		assert.Equal(t, "refund", gotQuery)
+		assert.Equal(t, 2, gotLimit)
+	})
+
+	t.Run("falls back without query or selector", func(t *testing.T) {
+		ctx := newMockContextAccessor(map[string]any{
+			ContextKeySkills:         "full catalog",
+			ContextKeySkillsSelector: selector,
+		})
+		result, err := resolver.Resolve(context.Background(), ctx, attrs)
+		require.NoError(t, err)
+		assert.Equal(t, "full catalog", result)
+
+		ctx = newMockContextAccessor(map[string]any{ContextKeySkills: "full catalog", "q": "refund"})
+		result, err = resolver.Resolve(context.Background(), ctx, attrs)
+		require.NoError(t, err)
+		assert.Equal(t, "full catalog", result)
+	})
+
+	t.Run("reports selector errors", func(t *testing.T) {
+		ctx := newMockContextAccessor(map[string]any{
+			ContextKeySkillsSelector: SkillsSelector(func(ctx context.Context, query string, limit int) (string, error) {
+				return "", assert.AnError
+			}),
+			"q": "refund",
+		})
+		_, err := resolver.Resolve(context.Background(), ctx, attrs)
+		assert.ErrorContains(t, err, ErrMsgSkillsSelectionFailed)
+	})
+}
+
 // ---------------------------------------------------------------------------
 // ToolsCatalogResolver tests
 // ---------------------------------------------------------------------------
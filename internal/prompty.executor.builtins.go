@@ -2,6 +2,7 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 )
@@ -37,7 +38,8 @@ type ErrorStrategyAccessor interface {
 }
 
 // ChildContextCreator extends ContextAccessor with the ability to create child contexts.
-// This is used by the executor for loop iterations to create scoped contexts.
+// This is used by the executor to create scoped contexts for loop iterations
+// and block tags.
 type ChildContextCreator interface {
 	ContextAccessor
 	// Child creates a child context with additional data.
@@ -171,6 +173,16 @@ func RegisterBuiltins(registry *Registry) {
 	registry.MustRegister(NewRefResolver())
 	registry.MustRegister(NewSkillsCatalogResolver())
 	registry.MustRegister(NewToolsCatalogResolver())
+	registry.MustRegister(NewImportResolver())
+	registry.MustRegister(NewUseResolver())
+	registry.MustRegister(NewChoiceResolver())
+	registry.MustRegister(NewShuffleResolver())
+	registry.MustRegister(NewHistoryResolver())
+	registry.MustRegister(NewImageResolver())
+	registry.MustRegister(NewFileResolver())
+	registry.MustRegister(NewCacheBreakpointResolver())
+	registry.MustRegister(NewToolResultsResolver())
+	registry.MustRegister(NewEscapeResolver())
 }
 
 // BuiltinError represents an error from a built-in resolver.
@@ -178,6 +190,8 @@ type BuiltinError struct {
 	Message  string
 	TagName  string
 	Metadata map[string]string
+	Sentinel error // Optional, matched by errors.Is
+	Cause    error // Optional underlying error
 }
 
 // NewBuiltinError creates a new builtin error.
@@ -198,6 +212,29 @@ func (e *BuiltinError) WithMetadata(key, value string) *BuiltinError {
 	return e
 }
 
+// WithSentinel sets the sentinel error the error matches and returns the
+// error for chaining.
+func (e *BuiltinError) WithSentinel(sentinel error) *BuiltinError {
+	e.Sentinel = sentinel
+	return e
+}
+
+// WithCause sets the underlying error and returns the error for chaining.
+func (e *BuiltinError) WithCause(cause error) *BuiltinError {
+	e.Cause = cause
+	return e
+}
+
+// Unwrap returns the underlying error.
+func (e *BuiltinError) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is the error's sentinel or one it refines.
+func (e *BuiltinError) Is(target error) bool {
+	return e.Sentinel != nil && errors.Is(e.Sentinel, target)
+}
+
 // Error implements the error interface.
 func (e *BuiltinError) Error() string {
 	base := fmt.Sprintf(ErrFmtTagMessage, e.TagName, e.Message)
@@ -217,6 +254,7 @@ func NewVariableNotFoundBuiltinError(path string, showHint bool) *BuiltinError {
 		message = AppendHint(message, HintVarNotFound)
 	}
 	return NewBuiltinError(message, TagNameVar).
+		WithSentinel(ErrNotFound).
 		WithMetadata(MetaKeyPath, path)
 }
 
@@ -232,6 +270,7 @@ func NewVariableNotFoundWithSuggestionsError(path string, suggestions []string,
 		message = AppendHint(message, HintVarNotFound)
 	}
 	return NewBuiltinError(message, TagNameVar).
+		WithSentinel(ErrNotFound).
 		WithMetadata(MetaKeyPath, path)
 }
 
@@ -247,12 +286,14 @@ func NewVariableNotFoundWithAvailableKeysError(path string, availableKeys []stri
 		message = AppendHint(message, HintVarNotFound)
 	}
 	return NewBuiltinError(message, TagNameVar).
+		WithSentinel(ErrNotFound).
 		WithMetadata(MetaKeyPath, path)
 }
 
 // NewTemplateNotFoundBuiltinError creates an error for template not found.
 func NewTemplateNotFoundBuiltinError(name string) *BuiltinError {
 	return NewBuiltinError(ErrMsgTemplateNotFound, TagNameInclude).
+		WithSentinel(ErrTemplateNotFound).
 		WithMetadata(MetaKeyTemplateName, name)
 }
 
@@ -260,6 +301,7 @@ func NewTemplateNotFoundBuiltinError(name string) *BuiltinError {
 func NewTemplateNotFoundWithHintError(name string) *BuiltinError {
 	message := AppendHint(ErrMsgTemplateNotFound, HintTemplateNotFound)
 	return NewBuiltinError(message, TagNameInclude).
+		WithSentinel(ErrTemplateNotFound).
 		WithMetadata(MetaKeyTemplateName, name)
 }
 
@@ -0,0 +1,214 @@
+package internal
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// HistoryResolver handles the prompty.history built-in tag, which renders a
+// conversation stored in the data: a collection of messages with role and
+// content (maps, or structs with Role and Content fields).
+//
+// The oldest messages are dropped first to honor limit (a message count)
+// and window (a token budget, "tokens:2000"). The format selects the output:
+//   - "messages" (default): message markers, so ExtractMessages returns one
+//     structured message per history entry. Use it outside prompty.message.
+//   - "chatml": <|im_start|>role ... <|im_end|> text
+//   - "plain": "Role: content" lines
+//
+// A missing or empty collection renders nothing.
+//
+// Usage:
+//
+//	{~prompty.history in="conversation" limit="20" window="tokens:2000" /~}
+type HistoryResolver struct{}
+
+// historyMessage is one entry of a rendered history.
+type historyMessage struct {
+	role    string
+	content string
+}
+
+// NewHistoryResolver creates a new HistoryResolver.
+func NewHistoryResolver() *HistoryResolver {
+	return &HistoryResolver{}
+}
+
+// TagName returns the tag name for this resolver.
+func (r *HistoryResolver) TagName() string {
+	return TagNameHistory
+}
+
+// Resolve renders the most recent messages of the history.
+func (r *HistoryResolver) Resolve(ctx context.Context, execCtx interface{}, attrs Attributes) (string, error) {
+	accessor, ok := execCtx.(ContextAccessor)
+	if !ok {
+		return "", NewBuiltinError(ErrMsgInvalidContext, TagNameHistory)
+	}
+	in, limit, window, format, err := historyAttrs(attrs)
+	if err != nil {
+		return "", err
+	}
+
+	collection, found := accessor.Get(in)
+	if !found {
+		return "", nil
+	}
+	items, err := toIterableSlice(collection)
+	if err != nil {
+		return "", NewBuiltinError(ErrMsgForNotIterable, TagNameHistory).WithMetadata(AttrIn, in)
+	}
+
+	messages := make([]historyMessage, 0, len(items))
+	for i, item := range items {
+		msg, err := toHistoryMessage(item)
+		if err != nil {
+			return "", err.WithMetadata(AttrIndex, strconv.Itoa(i))
+		}
+		messages = append(messages, msg)
+	}
+
+	return formatHistory(truncateHistory(messages, limit, window), format), nil
+}
+
+// Validate checks the in, limit, window and format attributes.
+func (r *HistoryResolver) Validate(attrs Attributes) error {
+	_, _, _, _, err := historyAttrs(attrs)
+	return err
+}
+
+// historyAttrs returns the collection path, message limit, token window
+// (0 for no limit) and format of a prompty.history tag.
+func historyAttrs(attrs Attributes) (in string, limit, window int, format string, err error) {
+	in, ok := attrs.Get(AttrIn)
+	if !ok {
+		return "", 0, 0, "", NewBuiltinError(ErrMsgForMissingIn, TagNameHistory)
+	}
+
+	if limitStr, ok := attrs.Get(AttrLimit); ok {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return "", 0, 0, "", NewBuiltinError(ErrMsgForInvalidLimit, TagNameHistory).WithMetadata(AttrLimit, limitStr)
+		}
+	}
+
+	if windowStr, ok := attrs.Get(AttrWindow); ok {
+		tokens, found := strings.CutPrefix(windowStr, HistoryWindowTokensPrefix)
+		if found {
+			window, err = strconv.Atoi(tokens)
+		}
+		if !found || err != nil || window <= 0 {
+			return "", 0, 0, "", NewBuiltinError(ErrMsgHistoryInvalidWindow, TagNameHistory).WithMetadata(AttrWindow, windowStr)
+		}
+	}
+
+	format = strings.ToLower(attrs.GetDefault(AttrFormat, HistoryFormatMessages))
+	switch format {
+	case HistoryFormatMessages, HistoryFormatChatML, HistoryFormatPlain:
+	default:
+		return "", 0, 0, "", NewBuiltinError(ErrMsgHistoryInvalidFormat, TagNameHistory).WithMetadata(AttrFormat, format)
+	}
+
+	return in, limit, window, format, nil
+}
+
+// toHistoryMessage reads the role and content of a history entry.
+func toHistoryMessage(item any) (historyMessage, *BuiltinError) {
+	role, hasRole := messageField(item, HistoryFieldRole)
+	content, _ := messageField(item, HistoryFieldContent)
+	roleStr := strings.ToLower(valueToString(role))
+	if !hasRole || !isValidRole(roleStr) {
+		return historyMessage{}, NewBuiltinError(ErrMsgHistoryInvalidMessage, TagNameHistory).
+			WithMetadata(AttrRole, roleStr)
+	}
+
+	// Strip null bytes so content cannot inject message markers
+	return historyMessage{
+		role:    roleStr,
+		content: strings.ReplaceAll(valueToString(content), CharNullByte, ""),
+	}, nil
+}
+
+// messageField returns the named field of a map or struct history entry.
+// Struct fields match case-insensitively and without underscores, so
+// "call_id" matches CallID.
+func messageField(item any, name string) (any, bool) {
+	switch m := item.(type) {
+	case map[string]any:
+		v, ok := m[name]
+		return v, ok
+	case map[string]string:
+		v, ok := m[name]
+		return v, ok
+	}
+
+	v := reflect.ValueOf(item)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+	fieldName := strings.ReplaceAll(name, CharUnderscore, "")
+	field := v.FieldByNameFunc(func(field string) bool {
+		return strings.EqualFold(field, fieldName)
+	})
+	if !field.IsValid() || !field.CanInterface() {
+		return nil, false
+	}
+	return field.Interface(), true
+}
+
+// truncateHistory keeps the most recent messages within limit messages and
+// window estimated tokens; zero means no limit.
+func truncateHistory(messages []historyMessage, limit, window int) []historyMessage {
+	if limit > 0 && len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	if window <= 0 {
+		return messages
+	}
+
+	start := len(messages)
+	tokens := 0
+	for start > 0 {
+		tokens += estimateHistoryTokens(messages[start-1].content)
+		if tokens > window {
+			break
+		}
+		start--
+	}
+	return messages[start:]
+}
+
+// estimateHistoryTokens approximates the token count of content.
+func estimateHistoryTokens(content string) int {
+	return int(math.Ceil(float64(utf8.RuneCountInString(content)) / HistoryCharsPerToken))
+}
+
+// formatHistory renders messages in the given format.
+func formatHistory(messages []historyMessage, format string) string {
+	var sb strings.Builder
+	for i, msg := range messages {
+		switch format {
+		case HistoryFormatChatML:
+			if i > 0 {
+				sb.WriteRune(CharNewline)
+			}
+			sb.WriteString(ChatMLStart + msg.role + string(CharNewline) + msg.content + ChatMLEnd)
+		case HistoryFormatPlain:
+			if i > 0 {
+				sb.WriteRune(CharNewline)
+			}
+			sb.WriteString(strings.ToUpper(msg.role[:1]) + msg.role[1:] + HistoryPlainSep + msg.content)
+		default:
+			sb.WriteString(MessageStartMarker + msg.role + MessageFieldSep + AttrValueFalse + MessageFieldSep +
+				msg.content + MessageEndMarker)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testChatMessage struct {
+	Role    string
+	Content string
+}
+
+func TestHistoryResolver_Formats(t *testing.T) {
+	resolver := NewHistoryResolver()
+	data := newMockContextAccessor(map[string]any{
+		"conversation": []any{
+			map[string]any{"role": "user", "content": "Hi"},
+			testChatMessage{Role: "Assistant", Content: "Hello!"},
+			&testChatMessage{Role: "user", Content: "Bye\x00"},
+		},
+	})
+
+	result, err := resolver.Resolve(context.Background(), data, Attributes{AttrIn: "conversation", AttrFormat: HistoryFormatPlain})
+	require.NoError(t, err)
+	assert.Equal(t, "User: Hi\nAssistant: Hello!\nUser: Bye", result)
+
+	result, err = resolver.Resolve(context.Background(), data, Attributes{AttrIn: "conversation", AttrFormat: HistoryFormatChatML, AttrLimit: "1"})
+	require.NoError(t, err)
+	assert.Equal(t, "<|im_start|>user\nBye<|im_end|>", result)
+
+	result, err = resolver.Resolve(context.Background(), data, Attributes{AttrIn: "conversation"})
+	require.NoError(t, err)
+	messages := ExtractMessages(result)
+	require.Len(t, messages, 3)
+	assert.Equal(t, MessageInfo{Role: RoleAssistant, Content: "Hello!"}, messages[1])
+	assert.Equal(t, "Bye", messages[2].Content)
+}
+
+func TestHistoryResolver_Window(t *testing.T) {
+	resolver := NewHistoryResolver()
+	data := newMockContextAccessor(map[string]any{
+		"conversation": []map[string]any{
+			{"role": "user", "content": strings.Repeat("a", 40)},      // 10 tokens
+			{"role": "assistant", "content": strings.Repeat("b", 20)}, // 5 tokens
+			{"role": "user", "content": strings.Repeat("c", 16)},      // 4 tokens
+		},
+	})
+
+	result, err := resolver.Resolve(context.Background(), data, Attributes{AttrIn: "conversation", AttrWindow: "tokens:10", AttrFormat: HistoryFormatPlain})
+	require.NoError(t, err)
+	assert.Equal(t, "Assistant: "+strings.Repeat("b", 20)+"\nUser: "+strings.Repeat("c", 16), result)
+
+	result, err = resolver.Resolve(context.Background(), data, Attributes{AttrIn: "conversation", AttrWindow: "tokens:3"})
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func TestHistoryResolver_Errors(t *testing.T) {
+	resolver := NewHistoryResolver()
+	data := newMockContextAccessor(map[string]any{
+		"bad": []any{map[string]any{"role": "narrator", "content": "x"}},
+	})
+
+	result, err := resolver.Resolve(context.Background(), data, Attributes{AttrIn: "missing"})
+	require.NoError(t, err)
+	assert.Empty(t, result)
+
+	_, err = resolver.Resolve(context.Background(), data, Attributes{AttrIn: "bad"})
+	assert.ErrorContains(t, err, ErrMsgHistoryInvalidMessage)
+
+	assert.ErrorContains(t, resolver.Validate(Attributes{}), ErrMsgForMissingIn)
+	assert.ErrorContains(t, resolver.Validate(Attributes{AttrIn: "c", AttrWindow: "2000"}), ErrMsgHistoryInvalidWindow)
+	assert.ErrorContains(t, resolver.Validate(Attributes{AttrIn: "c", AttrFormat: "xml"}), ErrMsgHistoryInvalidFormat)
+	assert.ErrorContains(t, resolver.Validate(Attributes{AttrIn: "c", AttrLimit: "-1"}), ErrMsgForInvalidLimit)
+}
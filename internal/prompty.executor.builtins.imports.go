@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"context"
+)
+
+// ImportResolver handles the prompty.import built-in tag.
+// Top-level imports are resolved on the AST before execution (see
+// BlockImportResolver), so reaching Resolve means the import was nested or
+// the template runs without an engine.
+//
+// Usage:
+//
+//	{~prompty.import template="shared-blocks" as="lib" /~}
+type ImportResolver struct{}
+
+// NewImportResolver creates a new ImportResolver.
+func NewImportResolver() *ImportResolver {
+	return &ImportResolver{}
+}
+
+// TagName returns the tag name for this resolver.
+func (r *ImportResolver) TagName() string {
+	return TagNameImport
+}
+
+// Resolve reports an import that was not resolved before execution.
+func (r *ImportResolver) Resolve(ctx context.Context, execCtx interface{}, attrs Attributes) (string, error) {
+	return "", NewBuiltinError(ErrMsgImportNotTopLevel, TagNameImport)
+}
+
+// Validate checks that the template and alias attributes are present.
+func (r *ImportResolver) Validate(attrs Attributes) error {
+	_, _, err := importAttrs(attrs)
+	return err
+}
+
+// UseResolver handles the prompty.use built-in tag.
+// Use tags are replaced by the imported block before execution, so reaching
+// Resolve means the block's library was not imported.
+//
+// Usage:
+//
+//	{~prompty.use block="lib.disclaimer" /~}
+type UseResolver struct{}
+
+// NewUseResolver creates a new UseResolver.
+func NewUseResolver() *UseResolver {
+	return &UseResolver{}
+}
+
+// TagName returns the tag name for this resolver.
+func (r *UseResolver) TagName() string {
+	return TagNameUse
+}
+
+// Resolve reports a use tag whose block library was not imported.
+func (r *UseResolver) Resolve(ctx context.Context, execCtx interface{}, attrs Attributes) (string, error) {
+	alias, _, err := useAttrs(attrs)
+	if err != nil {
+		return "", err
+	}
+	return "", NewBuiltinError(ErrMsgUseUnknownAlias, TagNameUse).
+		WithMetadata(MetaKeyAlias, alias)
+}
+
+// Validate checks that the block attribute names an alias and a block.
+func (r *UseResolver) Validate(attrs Attributes) error {
+	_, _, err := useAttrs(attrs)
+	return err
+}
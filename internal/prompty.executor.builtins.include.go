@@ -2,6 +2,8 @@ package internal
 
 import (
 	"context"
+	"strconv"
+	"strings"
 )
 
 // TemplateExecutor is the interface for executing nested templates.
@@ -13,6 +15,26 @@ type TemplateExecutor interface {
 	GetTemplateSource(name string) (string, bool)
 }
 
+// StoredTemplateExecutor is implemented by executors that can include
+// templates from a template storage, such as the StorageEngine.
+type StoredTemplateExecutor interface {
+	// ExecuteStoredTemplate executes a stored template by name with the given
+	// data. Version 0 selects the latest version.
+	ExecuteStoredTemplate(ctx context.Context, name string, version int, data map[string]any) (string, error)
+}
+
+// IncludeChainAccessor provides the chain of templates being included, used
+// to detect include cycles.
+type IncludeChainAccessor interface {
+	IncludeChain() []string
+}
+
+// DynamicIncludePolicy is implemented by executors that restrict which
+// templates a template_expr include may select.
+type DynamicIncludePolicy interface {
+	AllowsDynamicInclude(name string) bool
+}
+
 // IncludeResolver handles the prompty.include built-in tag.
 // It executes registered templates and inserts their output.
 type IncludeResolver struct{}
@@ -36,12 +58,6 @@ func (r *IncludeResolver) Resolve(ctx context.Context, execCtx interface{}, attr
 		return "", NewBuiltinError(ErrMsgEngineNotAvailable, TagNameInclude)
 	}
 
-	// Get required 'template' attribute
-	templateName, ok := attrs.Get(AttrTemplate)
-	if !ok {
-		return "", NewBuiltinError(ErrMsgMissingTemplateAttr, TagNameInclude)
-	}
-
 	// Get the engine from context
 	engineInterface := tmplCtx.Engine()
 	if engineInterface == nil {
@@ -53,8 +69,25 @@ func (r *IncludeResolver) Resolve(ctx context.Context, execCtx interface{}, attr
 		return "", NewBuiltinError(ErrMsgEngineNotAvailable, TagNameInclude)
 	}
 
-	// Check if template exists
-	if !engine.HasTemplate(templateName) {
+	// Get the 'template' attribute or compute the name from 'template_expr'
+	templateName, err := r.templateName(ctx, tmplCtx, engineInterface, attrs)
+	if err != nil {
+		return "", err
+	}
+
+	source, version, err := includeSource(attrs)
+	if err != nil {
+		return "", err
+	}
+
+	var stored StoredTemplateExecutor
+	if source == AttrValueStorage {
+		if stored, ok = engineInterface.(StoredTemplateExecutor); !ok {
+			return "", NewBuiltinError(ErrMsgStorageNotAvailable, TagNameInclude).
+				WithMetadata(MetaKeyTemplateName, templateName)
+		}
+	} else if !engine.HasTemplate(templateName) {
+		// Check if template exists
 		return "", NewTemplateNotFoundWithHintError(templateName)
 	}
 
@@ -66,37 +99,90 @@ func (r *IncludeResolver) Resolve(ctx context.Context, execCtx interface{}, attr
 	}
 
 	// Build context data for child template
-	childData := r.buildChildData(tmplCtx, attrs)
+	childData, err := r.buildChildData(ctx, tmplCtx, attrs)
+	if err != nil {
+		return "", err
+	}
 
 	// Execute the template
 	// Note: The engine's ExecuteTemplate will create a new context with depth+1
-	result, err := engine.ExecuteTemplate(ctx, templateName, childData)
+	var result string
+	if stored != nil {
+		result, err = stored.ExecuteStoredTemplate(ctx, templateName, version, childData)
+	} else {
+		result, err = engine.ExecuteTemplate(ctx, templateName, childData)
+	}
 	if err != nil {
-		return "", NewBuiltinError(err.Error(), TagNameInclude)
+		return "", NewBuiltinError(err.Error(), TagNameInclude).WithCause(err)
 	}
 
 	return result, nil
 }
 
+// templateName returns the name of the template to include. A name computed
+// from 'template_expr' must be permitted by the executor's
+// DynamicIncludePolicy, if it has one.
+func (r *IncludeResolver) templateName(ctx context.Context, tmplCtx TemplateContextAccessor, engine interface{}, attrs Attributes) (string, error) {
+	expr, dynamic := attrs.Get(AttrTemplateExpr)
+	if !dynamic {
+		name, ok := attrs.Get(AttrTemplate)
+		if !ok {
+			return "", NewBuiltinError(ErrMsgMissingTemplateAttr, TagNameInclude)
+		}
+		return name, nil
+	}
+	if attrs.Has(AttrTemplate) {
+		return "", NewBuiltinError(ErrMsgIncludeTemplateBoth, TagNameInclude)
+	}
+
+	val, err := EvaluateExpressionWithContext(ctx, expr, funcsFrom(ctx), tmplCtx)
+	if err != nil {
+		return "", NewBuiltinError(ErrMsgIncludeExprEval, TagNameInclude).
+			WithMetadata(MetaKeyExpression, expr).
+			WithMetadata(MetaKeyReason, err.Error())
+	}
+	name, ok := val.(string)
+	if !ok || name == "" {
+		return "", NewBuiltinError(ErrMsgIncludeExprNotName, TagNameInclude).
+			WithMetadata(MetaKeyExpression, expr)
+	}
+	if policy, ok := engine.(DynamicIncludePolicy); ok && !policy.AllowsDynamicInclude(name) {
+		return "", NewBuiltinError(ErrMsgIncludeNotAllowed, TagNameInclude).
+			WithMetadata(MetaKeyTemplateName, name)
+	}
+	return name, nil
+}
+
 // buildChildData creates the data map for the child template context.
-func (r *IncludeResolver) buildChildData(tmplCtx TemplateContextAccessor, attrs Attributes) map[string]any {
+func (r *IncludeResolver) buildChildData(ctx context.Context, tmplCtx TemplateContextAccessor, attrs Attributes) (map[string]any, error) {
 	// Check for isolate mode
 	isolate := attrs.GetDefault(AttrIsolate, AttrValueFalse) == AttrValueTrue
 
 	childData := make(map[string]any)
 
-	// If not isolated, we need to pass parent data
-	// However, since we're using ExecuteTemplate which creates a fresh context,
-	// we need to explicitly copy relevant data.
-	// For now, we'll pass attributes as the child data and rely on the
-	// engine to handle context inheritance if needed.
-
-	// Check for 'with' attribute - scopes context to a path
-	withPath, hasWith := attrs.Get(AttrWith)
+	// ExecuteTemplate creates a fresh context, so the child sees only what is
+	// passed explicitly: the 'with' data and the non-reserved attributes.
+	// Isolated includes ignore 'with' and receive the attributes alone.
+	withValue, hasWith := attrs.Get(AttrWith)
 	if hasWith && !isolate {
-		// Get the value at the 'with' path and use it as root context
-		val, found := tmplCtx.Get(withPath)
-		if found {
+		bindings, isMapping, err := ParseIncludeBindings(withValue)
+		if err != nil {
+			return nil, err
+		}
+		if isMapping {
+			// Bind each key to its expression evaluated in the parent context
+			funcs := funcsFrom(ctx)
+			for _, b := range bindings {
+				val, err := EvaluateExpressionWithContext(ctx, b.Expr, funcs, tmplCtx)
+				if err != nil {
+					return nil, NewBuiltinError(ErrMsgIncludeWithEval, TagNameInclude).
+						WithMetadata(MetaKeyBinding, b.Key).
+						WithMetadata(MetaKeyReason, err.Error())
+				}
+				childData[b.Key] = val
+			}
+		} else if val, found := tmplCtx.Get(withValue); found {
+			// Get the value at the 'with' path and use it as root context
 			// If it's a map, use it directly
 			if m, ok := val.(map[string]any); ok {
 				for k, v := range m {
@@ -110,9 +196,12 @@ func (r *IncludeResolver) buildChildData(tmplCtx TemplateContextAccessor, attrs
 	}
 
 	// Add all non-reserved attributes as context variables
-	// Reserved attributes: template, with, isolate
+	// Reserved attributes: template, template_expr, with, isolate, source,
+	// cache, cache_key, and version for storage includes
+	storage := attrs.GetDefault(AttrSource, "") == AttrValueStorage
 	for _, key := range attrs.Keys() {
-		if key == AttrTemplate || key == AttrWith || key == AttrIsolate {
+		if key == AttrTemplate || key == AttrTemplateExpr || key == AttrWith || key == AttrIsolate ||
+			key == AttrSource || key == AttrCache || key == AttrCacheKey || (storage && key == AttrVersion) {
 			continue
 		}
 		val, _ := attrs.Get(key)
@@ -122,14 +211,128 @@ func (r *IncludeResolver) buildChildData(tmplCtx TemplateContextAccessor, attrs
 	// Include current depth for nested tracking
 	// This will be incremented by the engine when creating the child context
 	childData[MetaKeyParentDepth] = tmplCtx.Depth()
+	if chain, ok := tmplCtx.(IncludeChainAccessor); ok && len(chain.IncludeChain()) > 0 {
+		childData[MetaKeyIncludeChain] = chain.IncludeChain()
+	}
+
+	return childData, nil
+}
+
+// includeSource returns the template source of an include and, for storage
+// includes, the requested version (0 for latest). The version attribute is
+// only meaningful with source="storage".
+func includeSource(attrs Attributes) (source string, version int, err error) {
+	source = attrs.GetDefault(AttrSource, AttrValueRegistered)
+	if source != AttrValueRegistered && source != AttrValueStorage {
+		return "", 0, NewBuiltinError(ErrMsgIncludeBadSource, TagNameInclude).
+			WithMetadata(AttrSource, source)
+	}
+	if source != AttrValueStorage {
+		return source, 0, nil
+	}
+
+	if v, ok := attrs.Get(AttrVersion); ok {
+		version, err = strconv.Atoi(v)
+		if err != nil || version < 1 {
+			return "", 0, NewBuiltinError(ErrMsgIncludeBadVersion, TagNameInclude).
+				WithMetadata(AttrVersion, v)
+		}
+	}
+	return source, version, nil
+}
 
-	return childData
+// IncludeBinding is one key=expression pair of an include 'with' mapping.
+type IncludeBinding struct {
+	Key  string
+	Expr string
+}
+
+// ParseIncludeBindings parses an include 'with' value of the form
+// "key1=expr1,key2=expr2". isMapping is false when the value is a plain
+// context path (it contains no '='). Commas inside parentheses or quotes do
+// not separate pairs, so expressions may contain function calls.
+func ParseIncludeBindings(with string) (bindings []IncludeBinding, isMapping bool, err error) {
+	if !strings.Contains(with, IncludeWithAssign) {
+		return nil, false, nil
+	}
+
+	for _, entry := range splitTopLevel(with) {
+		key, expr, _ := strings.Cut(entry, IncludeWithAssign)
+		key = strings.TrimSpace(key)
+		expr = strings.TrimSpace(expr)
+		if !isBindingKey(key) || expr == "" {
+			return nil, true, NewBuiltinError(ErrMsgIncludeWithInvalid, TagNameInclude).
+				WithMetadata(MetaKeyBinding, strings.TrimSpace(entry))
+		}
+		bindings = append(bindings, IncludeBinding{Key: key, Expr: expr})
+	}
+	return bindings, true, nil
+}
+
+// splitTopLevel splits s at commas outside parentheses and quoted strings.
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case ch == IncludeWithSeparator[0] && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// isBindingKey reports whether key is a valid variable name.
+func isBindingKey(key string) bool {
+	if key == "" || isDigit(key[0]) {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if !isLetter(key[i]) && !isDigit(key[i]) && key[i] != '_' {
+			return false
+		}
+	}
+	return true
 }
 
 // Validate checks that the required attributes are present.
 func (r *IncludeResolver) Validate(attrs Attributes) error {
-	if !attrs.Has(AttrTemplate) {
+	expr, dynamic := attrs.Get(AttrTemplateExpr)
+	switch {
+	case dynamic && attrs.Has(AttrTemplate):
+		return NewBuiltinError(ErrMsgIncludeTemplateBoth, TagNameInclude)
+	case dynamic:
+		if _, err := ParseExpression(expr); err != nil {
+			return NewBuiltinError(ErrMsgIncludeExprEval, TagNameInclude).
+				WithMetadata(MetaKeyExpression, expr).
+				WithMetadata(MetaKeyReason, err.Error())
+		}
+	case !attrs.Has(AttrTemplate):
 		return NewBuiltinError(ErrMsgMissingTemplateAttr, TagNameInclude)
 	}
+	if _, _, err := includeSource(attrs); err != nil {
+		return err
+	}
+	if with, ok := attrs.Get(AttrWith); ok {
+		if _, _, err := ParseIncludeBindings(with); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -333,7 +333,8 @@ func TestIncludeResolver_BuildChildData(t *testing.T) {
 			"count":      "42",
 		}
 
-		data := resolver.buildChildData(ctx, attrs)
+		data, err := resolver.buildChildData(context.Background(), ctx, attrs)
+		require.NoError(t, err)
 
 		assert.Equal(t, "Alice", data["user"])
 		assert.Equal(t, "42", data["count"])
@@ -351,7 +352,8 @@ func TestIncludeResolver_BuildChildData(t *testing.T) {
 			"custom":     "value",
 		}
 
-		data := resolver.buildChildData(ctx, attrs)
+		data, err := resolver.buildChildData(context.Background(), ctx, attrs)
+		require.NoError(t, err)
 
 		assert.Equal(t, "value", data["custom"])
 		_, hasTemplate := data[AttrTemplate]
@@ -374,7 +376,8 @@ func TestIncludeResolver_BuildChildData(t *testing.T) {
 			AttrWith:     "user",
 		}
 
-		data := resolver.buildChildData(ctx, attrs)
+		data, err := resolver.buildChildData(context.Background(), ctx, attrs)
+		require.NoError(t, err)
 
 		assert.Equal(t, "Alice", data["name"])
 		assert.Equal(t, 30, data["age"])
@@ -389,7 +392,8 @@ func TestIncludeResolver_BuildChildData(t *testing.T) {
 			AttrWith:     "username",
 		}
 
-		data := resolver.buildChildData(ctx, attrs)
+		data, err := resolver.buildChildData(context.Background(), ctx, attrs)
+		require.NoError(t, err)
 
 		assert.Equal(t, "Alice", data[MetaKeyValue])
 	})
@@ -401,7 +405,8 @@ func TestIncludeResolver_BuildChildData(t *testing.T) {
 			AttrWith:     "nonexistent",
 		}
 
-		data := resolver.buildChildData(ctx, attrs)
+		data, err := resolver.buildChildData(context.Background(), ctx, attrs)
+		require.NoError(t, err)
 
 		// Should still work, just no data from with
 		_, hasValue := data[MetaKeyValue]
@@ -420,7 +425,8 @@ func TestIncludeResolver_BuildChildData(t *testing.T) {
 			AttrIsolate:  AttrValueTrue,
 		}
 
-		data := resolver.buildChildData(ctx, attrs)
+		data, err := resolver.buildChildData(context.Background(), ctx, attrs)
+		require.NoError(t, err)
 
 		// With attribute should be ignored when isolate is true
 		_, hasName := data["name"]
@@ -431,10 +437,108 @@ func TestIncludeResolver_BuildChildData(t *testing.T) {
 		ctx := newMockTemplateContextAccessor(nil).WithDepth(5)
 		attrs := Attributes{AttrTemplate: "test"}
 
-		data := resolver.buildChildData(ctx, attrs)
+		data, err := resolver.buildChildData(context.Background(), ctx, attrs)
+		require.NoError(t, err)
 
 		assert.Equal(t, 5, data[MetaKeyParentDepth])
 	})
+
+	t.Run("with mapping binds renamed expressions", func(t *testing.T) {
+		ctx := newMockTemplateContextAccessor(map[string]any{
+			"customer": "Ada",
+			"items":    []any{"a", "b"},
+			"secret":   "hidden",
+		})
+		attrs := Attributes{
+			AttrTemplate: "test",
+			AttrWith:     "name=customer, count=len(items), greeting='hi, there', label=\"x\"",
+		}
+
+		data, err := resolver.buildChildData(context.Background(), ctx, attrs)
+		require.NoError(t, err)
+
+		assert.Equal(t, "Ada", data["name"])
+		assert.EqualValues(t, 2, data["count"])
+		assert.Equal(t, "hi, there", data["greeting"])
+		assert.Equal(t, "x", data["label"])
+		_, hasSecret := data["secret"]
+		assert.False(t, hasSecret)
+		_, hasValue := data[MetaKeyValue]
+		assert.False(t, hasValue)
+	})
+
+	t.Run("with mapping missing variable binds nil", func(t *testing.T) {
+		ctx := newMockTemplateContextAccessor(nil)
+		attrs := Attributes{AttrTemplate: "test", AttrWith: "name=missing"}
+
+		data, err := resolver.buildChildData(context.Background(), ctx, attrs)
+		require.NoError(t, err)
+		val, has := data["name"]
+		assert.True(t, has)
+		assert.Nil(t, val)
+	})
+
+	t.Run("with mapping evaluation error", func(t *testing.T) {
+		ctx := newMockTemplateContextAccessor(nil)
+		attrs := Attributes{AttrTemplate: "test", AttrWith: "n=nosuchfunc(1)"}
+
+		_, err := resolver.buildChildData(context.Background(), ctx, attrs)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMsgIncludeWithEval)
+		assert.Contains(t, err.Error(), "binding=n")
+	})
+
+	t.Run("isolate mode ignores with mapping", func(t *testing.T) {
+		ctx := newMockTemplateContextAccessor(map[string]any{"customer": "Ada"})
+		attrs := Attributes{AttrTemplate: "test", AttrWith: "name=customer", AttrIsolate: AttrValueTrue}
+
+		data, err := resolver.buildChildData(context.Background(), ctx, attrs)
+		require.NoError(t, err)
+		_, hasName := data["name"]
+		assert.False(t, hasName)
+	})
+}
+
+func TestParseIncludeBindings(t *testing.T) {
+	tests := []struct {
+		name     string
+		with     string
+		mapping  bool
+		bindings []IncludeBinding
+		wantErr  bool
+	}{
+		{name: "plain path", with: "user.profile", mapping: false},
+		{name: "single", with: "u=user", mapping: true, bindings: []IncludeBinding{{Key: "u", Expr: "user"}}},
+		{name: "comparison", with: "admin=role == 'admin'", mapping: true, bindings: []IncludeBinding{{Key: "admin", Expr: "role == 'admin'"}}},
+		{name: "call with commas", with: "a=max(x, y), b=z", mapping: true, bindings: []IncludeBinding{{Key: "a", Expr: "max(x, y)"}, {Key: "b", Expr: "z"}}},
+		{name: "escaped quote", with: `s='it\'s, ok',t=u`, mapping: true, bindings: []IncludeBinding{{Key: "s", Expr: `'it\'s, ok'`}, {Key: "t", Expr: "u"}}},
+		{name: "empty entry", with: "a=b,,c=d", mapping: true, wantErr: true},
+		{name: "missing expression", with: "a=", mapping: true, wantErr: true},
+		{name: "invalid key", with: "user.name=x", mapping: true, wantErr: true},
+		{name: "key starts with digit", with: "1a=x", mapping: true, wantErr: true},
+		{name: "entry without assign", with: "a=b,c", mapping: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bindings, mapping, err := ParseIncludeBindings(tt.with)
+			assert.Equal(t, tt.mapping, mapping)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), ErrMsgIncludeWithInvalid)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.bindings, bindings)
+		})
+	}
+}
+
+func TestIncludeResolver_ValidateWithMapping(t *testing.T) {
+	resolver := NewIncludeResolver()
+	assert.NoError(t, resolver.Validate(Attributes{AttrTemplate: "t", AttrWith: "a=b"}))
+	assert.NoError(t, resolver.Validate(Attributes{AttrTemplate: "t", AttrWith: "user"}))
+	assert.Error(t, resolver.Validate(Attributes{AttrTemplate: "t", AttrWith: "a=b,=c"}))
 }
 
 // TestIncludeResolver_EngineInterfaceCheck tests engine interface validation
@@ -486,3 +590,150 @@ func (m *mockTemplateContextAccessorWithInvalidEngine) Engine() interface{} {
 func (m *mockTemplateContextAccessorWithInvalidEngine) Depth() int {
 	return 0
 }
+
+// mockStoredTemplateExecutor adds StoredTemplateExecutor to mockTemplateExecutor
+type mockStoredTemplateExecutor struct {
+	*mockTemplateExecutor
+	lastName    string
+	lastVersion int
+}
+
+func (m *mockStoredTemplateExecutor) ExecuteStoredTemplate(ctx context.Context, name string, version int, data map[string]any) (string, error) {
+	m.lastName = name
+	m.lastVersion = version
+	m.lastData = data
+	return "stored", nil
+}
+
+func TestIncludeResolver_StorageSource(t *testing.T) {
+	resolver := NewIncludeResolver()
+
+	t.Run("storage include with version", func(t *testing.T) {
+		engine := &mockStoredTemplateExecutor{mockTemplateExecutor: newMockTemplateExecutor()}
+		ctx := newMockTemplateContextAccessor(nil).WithEngine(engine)
+		attrs := Attributes{AttrTemplate: "greeting", AttrSource: AttrValueStorage, AttrVersion: "3", "who": "Ada"}
+
+		result, err := resolver.Resolve(context.Background(), ctx, attrs)
+		require.NoError(t, err)
+		assert.Equal(t, "stored", result)
+		assert.Equal(t, "greeting", engine.lastName)
+		assert.Equal(t, 3, engine.lastVersion)
+		assert.Equal(t, "Ada", engine.lastData["who"])
+		assert.NotContains(t, engine.lastData, AttrVersion)
+		assert.NotContains(t, engine.lastData, AttrSource)
+		assert.False(t, engine.executeCalled)
+	})
+
+	t.Run("storage include defaults to latest", func(t *testing.T) {
+		engine := &mockStoredTemplateExecutor{mockTemplateExecutor: newMockTemplateExecutor()}
+		ctx := newMockTemplateContextAccessor(nil).WithEngine(engine)
+
+		_, err := resolver.Resolve(context.Background(), ctx, Attributes{AttrTemplate: "greeting", AttrSource: AttrValueStorage})
+		require.NoError(t, err)
+		assert.Equal(t, 0, engine.lastVersion)
+	})
+
+	t.Run("registered include keeps version as variable", func(t *testing.T) {
+		engine := newMockTemplateExecutor()
+		engine.RegisterTemplate("badge", "b")
+		ctx := newMockTemplateContextAccessor(nil).WithEngine(engine)
+
+		_, err := resolver.Resolve(context.Background(), ctx, Attributes{AttrTemplate: "badge", AttrVersion: "beta"})
+		require.NoError(t, err)
+		assert.Equal(t, "beta", engine.lastData[AttrVersion])
+	})
+
+	t.Run("engine without storage", func(t *testing.T) {
+		ctx := newMockTemplateContextAccessor(nil).WithEngine(newMockTemplateExecutor())
+
+		_, err := resolver.Resolve(context.Background(), ctx, Attributes{AttrTemplate: "x", AttrSource: AttrValueStorage})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMsgStorageNotAvailable)
+	})
+
+	t.Run("invalid attributes", func(t *testing.T) {
+		for _, attrs := range []Attributes{
+			{AttrTemplate: "x", AttrSource: "disk"},
+			{AttrTemplate: "x", AttrSource: AttrValueStorage, AttrVersion: "0"},
+			{AttrTemplate: "x", AttrSource: AttrValueStorage, AttrVersion: "v2"},
+		} {
+			assert.Error(t, resolver.Validate(attrs))
+			_, err := resolver.Resolve(context.Background(), newMockTemplateContextAccessor(nil).WithEngine(newMockTemplateExecutor()), attrs)
+			assert.Error(t, err)
+		}
+	})
+}
+
+// mockPolicyTemplateExecutor allows dynamic includes of templates in allowed
+type mockPolicyTemplateExecutor struct {
+	*mockTemplateExecutor
+	allowed map[string]bool
+}
+
+func (m *mockPolicyTemplateExecutor) AllowsDynamicInclude(name string) bool {
+	return m.allowed[name]
+}
+
+func TestIncludeResolver_TemplateExpr(t *testing.T) {
+	resolver := NewIncludeResolver()
+
+	t.Run("name computed from context", func(t *testing.T) {
+		engine := newMockTemplateExecutor()
+		engine.RegisterTemplate("intro-de", "Hallo")
+		ctx := newMockTemplateContextAccessor(map[string]any{"lang": "de"}).WithEngine(engine)
+
+		result, err := resolver.Resolve(context.Background(), ctx, Attributes{AttrTemplateExpr: `"intro-" + lower(lang)`, "x": "1"})
+		require.NoError(t, err)
+		assert.Equal(t, "Hallo", result)
+		assert.Equal(t, "1", engine.lastData["x"])
+		assert.NotContains(t, engine.lastData, AttrTemplateExpr)
+	})
+
+	t.Run("policy rejects name", func(t *testing.T) {
+		engine := &mockPolicyTemplateExecutor{
+			mockTemplateExecutor: newMockTemplateExecutor(),
+			allowed:              map[string]bool{"intro-en": true},
+		}
+		engine.RegisterTemplate("intro-en", "Hi")
+		engine.RegisterTemplate("secret", "s")
+		ctx := newMockTemplateContextAccessor(map[string]any{"name": "secret"}).WithEngine(engine)
+
+		_, err := resolver.Resolve(context.Background(), ctx, Attributes{AttrTemplateExpr: "name"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMsgIncludeNotAllowed)
+		assert.False(t, engine.executeCalled)
+
+		// A literal template attribute is not subject to the policy
+		_, err = resolver.Resolve(context.Background(), ctx, Attributes{AttrTemplate: "secret"})
+		require.NoError(t, err)
+	})
+
+	t.Run("expression errors", func(t *testing.T) {
+		engine := newMockTemplateExecutor()
+		ctx := newMockTemplateContextAccessor(map[string]any{"n": 3}).WithEngine(engine)
+
+		tests := []struct {
+			name  string
+			attrs Attributes
+			want  string
+		}{
+			{name: "not a string", attrs: Attributes{AttrTemplateExpr: "n"}, want: ErrMsgIncludeExprNotName},
+			{name: "empty", attrs: Attributes{AttrTemplateExpr: "missing"}, want: ErrMsgIncludeExprNotName},
+			{name: "unknown function", attrs: Attributes{AttrTemplateExpr: "nope(n)"}, want: ErrMsgIncludeExprEval},
+			{name: "both attributes", attrs: Attributes{AttrTemplate: "a", AttrTemplateExpr: `"a"`}, want: ErrMsgIncludeTemplateBoth},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := resolver.Resolve(context.Background(), ctx, tt.attrs)
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.want)
+			})
+		}
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		assert.NoError(t, resolver.Validate(Attributes{AttrTemplateExpr: `"intro-" + lang`}))
+		assert.Error(t, resolver.Validate(Attributes{AttrTemplateExpr: `"intro-" +`}))
+		assert.Error(t, resolver.Validate(Attributes{AttrTemplate: "a", AttrTemplateExpr: `"a"`}))
+	})
+}
@@ -18,7 +18,7 @@ type MessageResolver struct{}
 //   - \x00 (null byte): Non-printable delimiter to prevent collision with prompt content
 //   - MSG_START: Literal marker identifier
 //   - <role>: Message role (system|user|assistant|tool), always lowercase
-//   - <cache>: Cache hint (true|false)
+//   - <cache>: Cache hint (true|false; cache="ephemeral" is stored as true)
 //   - <content>: Executed template content (may contain newlines, sanitized of null bytes)
 //   - MSG_END: Literal end marker
 //
@@ -84,7 +84,7 @@ func (r *MessageResolver) Resolve(ctx context.Context, execCtx interface{}, attr
 
 	// Build cache flag string
 	cacheFlag := "false"
-	if hasCache && strings.EqualFold(cache, AttrValueTrue) {
+	if hasCache && (strings.EqualFold(cache, AttrValueTrue) || strings.EqualFold(cache, AttrValueEphemeral)) {
 		cacheFlag = "true"
 	}
 
@@ -108,9 +108,10 @@ func isValidRole(role string) bool {
 
 // MessageInfo represents extracted message information.
 type MessageInfo struct {
-	Role    string // Message role: system, user, assistant, or tool
-	Content string // Message content with leading/trailing whitespace trimmed
-	Cache   bool   // Cache hint for this message
+	Role    string            // Message role: system, user, assistant, or tool
+	Content string            // Message text with leading/trailing whitespace trimmed
+	Cache   bool              // Cache hint for this message
+	Parts   []ContentPartInfo // All parts in order, if the message holds images or files
 }
 
 // ExtractMessages parses the executed template output and extracts structured messages.
@@ -180,12 +181,13 @@ func ExtractMessages(output string) []MessageInfo {
 			break
 		}
 
-		content := remaining[contentStart : contentStart+endIdx]
+		content, parts := SplitContentParts(remaining[contentStart : contentStart+endIdx])
 
 		messages = append(messages, MessageInfo{
 			Role:    role,
-			Content: strings.TrimSpace(content),
+			Content: content,
 			Cache:   cache,
+			Parts:   parts,
 		})
 
 		// Move past this message
@@ -0,0 +1,324 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand/v2"
+	"mime"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ContentPartInfo is one part of a multimodal message: text, an image or a
+// file. Images and files are located by URL (http(s) or data:) or by a
+// provider file ID.
+type ContentPartInfo struct {
+	Type      string `json:"type"` // text, image or file
+	Text      string `json:"text,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+	Name      string `json:"name,omitempty"`
+	FileID    string `json:"file_id,omitempty"`
+	MediaType string `json:"media_type,omitempty"`
+	Cache     bool   `json:"cache,omitempty"` // Prompt-cache breakpoint after this part
+}
+
+// ContentParts collects the image and file parts of one message while its
+// body executes. Part tags leave a placeholder carrying a per-message nonce
+// in the output; Encode turns the placeholders into part markers. Template
+// data cannot forge a part, since it cannot guess the nonce and its null
+// bytes are stripped.
+type ContentParts struct {
+	nonce string
+	parts []ContentPartInfo
+}
+
+// contentPartsKey is the context key for the ContentParts of the message
+// being executed.
+type contentPartsKey struct{}
+
+// WithContentParts returns ctx collecting the content parts of a message.
+func WithContentParts(ctx context.Context) (context.Context, *ContentParts) {
+	parts := &ContentParts{nonce: strconv.FormatUint(rand.Uint64(), 36)}
+	return context.WithValue(ctx, contentPartsKey{}, parts), parts
+}
+
+// Len returns the number of parts collected so far.
+func (c *ContentParts) Len() int {
+	return len(c.parts)
+}
+
+// add records part and returns its placeholder.
+func (c *ContentParts) add(part ContentPartInfo) string {
+	c.parts = append(c.parts, part)
+	return ContentPartMarker + c.nonce + MessageFieldSep + strconv.Itoa(len(c.parts)-1) + CharNullByte
+}
+
+// Encode strips null bytes from the executed message body, replacing the
+// placeholders of collected parts with part markers.
+func (c *ContentParts) Encode(output string) string {
+	prefix := ContentPartMarker + c.nonce + MessageFieldSep
+	var sb strings.Builder
+	for {
+		start := strings.Index(output, prefix)
+		if start == -1 {
+			break
+		}
+		indexStart := start + len(prefix)
+		end := strings.Index(output[indexStart:], CharNullByte)
+		if end == -1 {
+			break
+		}
+		index, err := strconv.Atoi(output[indexStart : indexStart+end])
+		if err != nil || index < 0 || index >= len(c.parts) {
+			sb.WriteString(strings.ReplaceAll(output[:indexStart], CharNullByte, ""))
+			output = output[indexStart:]
+			continue
+		}
+
+		sb.WriteString(strings.ReplaceAll(output[:start], CharNullByte, ""))
+		encoded, _ := json.Marshal(c.parts[index])
+		sb.WriteString(ContentPartMarker + string(encoded) + CharNullByte)
+		output = output[indexStart+end+1:]
+	}
+	sb.WriteString(strings.ReplaceAll(output, CharNullByte, ""))
+	return sb.String()
+}
+
+// SplitContentParts separates message content into its text and, when it
+// holds image, file or cache breakpoint parts, the ordered list of all parts.
+// Text parts are trimmed and empty ones dropped; a cache breakpoint sets
+// Cache on the part before it. Text-only content returns nil parts.
+func SplitContentParts(content string) (string, []ContentPartInfo) {
+	if !strings.Contains(content, ContentPartMarker) {
+		return strings.TrimSpace(content), nil
+	}
+
+	var text strings.Builder
+	var parts []ContentPartInfo
+	addText := func(s string) {
+		text.WriteString(s)
+		if trimmed := strings.TrimSpace(s); trimmed != "" {
+			parts = append(parts, ContentPartInfo{Type: ContentPartTypeText, Text: trimmed})
+		}
+	}
+
+	for {
+		start := strings.Index(content, ContentPartMarker)
+		if start == -1 {
+			break
+		}
+		jsonStart := start + len(ContentPartMarker)
+		end := strings.Index(content[jsonStart:], CharNullByte)
+		if end == -1 {
+			break
+		}
+		addText(content[:start])
+
+		var part ContentPartInfo
+		if err := json.Unmarshal([]byte(content[jsonStart:jsonStart+end]), &part); err == nil {
+			if part.Type != ContentPartTypeCacheBreakpoint {
+				parts = append(parts, part)
+			} else if len(parts) > 0 {
+				parts[len(parts)-1].Cache = true
+			}
+		}
+		content = content[jsonStart+end+1:]
+	}
+	addText(content)
+
+	return strings.TrimSpace(text.String()), parts
+}
+
+// ImageResolver handles the prompty.image built-in tag, which adds an image
+// to the enclosing prompty.message. The image is located by url or by the
+// data path in from.
+//
+// Usage:
+//
+//	{~prompty.image url="https://example.com/chart.png" detail="high" /~}
+type ImageResolver struct{}
+
+// NewImageResolver creates a new ImageResolver.
+func NewImageResolver() *ImageResolver {
+	return &ImageResolver{}
+}
+
+// TagName returns the tag name for this resolver.
+func (r *ImageResolver) TagName() string {
+	return TagNameImage
+}
+
+// Resolve adds the image to the message being executed.
+func (r *ImageResolver) Resolve(ctx context.Context, execCtx interface{}, attrs Attributes) (string, error) {
+	if err := r.Validate(attrs); err != nil {
+		return "", err
+	}
+	partURL, err := contentPartURL(execCtx, attrs, TagNameImage)
+	if err != nil {
+		return "", err
+	}
+	return addContentPart(ctx, TagNameImage, ContentPartInfo{
+		Type:      ContentPartTypeImage,
+		URL:       partURL,
+		Detail:    strings.ToLower(attrs.GetDefault(AttrDetail, "")),
+		MediaType: contentPartMediaType(attrs, partURL),
+	})
+}
+
+// Validate checks that the image has a source and a valid detail level.
+func (r *ImageResolver) Validate(attrs Attributes) error {
+	if !attrs.Has(AttrURL) && !attrs.Has(AttrFrom) {
+		return NewBuiltinError(ErrMsgImageMissingURL, TagNameImage)
+	}
+	if detail, ok := attrs.Get(AttrDetail); ok {
+		switch strings.ToLower(detail) {
+		case ImageDetailLow, ImageDetailHigh, ImageDetailAuto:
+		default:
+			return NewBuiltinError(ErrMsgImageInvalidDetail, TagNameImage).WithMetadata(AttrDetail, detail)
+		}
+	}
+	return nil
+}
+
+// FileResolver handles the prompty.file built-in tag, which adds a file
+// (a PDF, an audio clip, ...) to the enclosing prompty.message. The file is
+// located by url, by the data path in from, or by a provider file id; name
+// is its file name.
+//
+// Usage:
+//
+//	{~prompty.file name="report.pdf" url="https://example.com/report.pdf" /~}
+type FileResolver struct{}
+
+// NewFileResolver creates a new FileResolver.
+func NewFileResolver() *FileResolver {
+	return &FileResolver{}
+}
+
+// TagName returns the tag name for this resolver.
+func (r *FileResolver) TagName() string {
+	return TagNameFile
+}
+
+// Resolve adds the file to the message being executed.
+func (r *FileResolver) Resolve(ctx context.Context, execCtx interface{}, attrs Attributes) (string, error) {
+	if err := r.Validate(attrs); err != nil {
+		return "", err
+	}
+	partURL, err := contentPartURL(execCtx, attrs, TagNameFile)
+	if err != nil {
+		return "", err
+	}
+	name := attrs.GetDefault(AttrName, "")
+	mediaSource := partURL
+	if name != "" {
+		mediaSource = name
+	}
+	return addContentPart(ctx, TagNameFile, ContentPartInfo{
+		Type:      ContentPartTypeFile,
+		URL:       partURL,
+		Name:      name,
+		FileID:    attrs.GetDefault(AttrID, ""),
+		MediaType: contentPartMediaType(attrs, mediaSource),
+	})
+}
+
+// Validate checks that the file has a name or a source.
+func (r *FileResolver) Validate(attrs Attributes) error {
+	if !attrs.Has(AttrName) && !attrs.Has(AttrURL) && !attrs.Has(AttrFrom) && !attrs.Has(AttrID) {
+		return NewBuiltinError(ErrMsgFileMissingSource, TagNameFile)
+	}
+	return nil
+}
+
+// addContentPart records part in the enclosing message and returns its
+// placeholder.
+func addContentPart(ctx context.Context, tagName string, part ContentPartInfo) (string, error) {
+	parts, ok := ctx.Value(contentPartsKey{}).(*ContentParts)
+	if !ok {
+		return "", NewBuiltinError(ErrMsgContentPartOutsideMessage, tagName)
+	}
+	return parts.add(part), nil
+}
+
+// contentPartURL returns the url attribute of a part tag, or the value at
+// its from data path.
+func contentPartURL(execCtx interface{}, attrs Attributes, tagName string) (string, error) {
+	from, ok := attrs.Get(AttrFrom)
+	if !ok {
+		return attrs.GetDefault(AttrURL, ""), nil
+	}
+	accessor, ok := execCtx.(ContextAccessor)
+	if !ok {
+		return "", NewBuiltinError(ErrMsgInvalidContext, tagName)
+	}
+	value, found := accessor.Get(from)
+	if !found {
+		return "", NewBuiltinError(ErrMsgContentPartURLPath, tagName).WithMetadata(AttrFrom, from)
+	}
+	return valueToString(value), nil
+}
+
+// contentPartMediaType returns the media_type attribute, or the type of a
+// data URL, or the type implied by the extension of source (a URL or file
+// name). It returns "" when the type is unknown.
+func contentPartMediaType(attrs Attributes, source string) string {
+	if mediaType, ok := attrs.Get(AttrMediaType); ok {
+		return mediaType
+	}
+	if mediaType, _, ok := ParseDataURL(source); ok {
+		return mediaType
+	}
+	if parsed, err := url.Parse(source); err == nil {
+		source = parsed.Path
+	}
+	mediaType, _, _ := mime.ParseMediaType(mime.TypeByExtension(path.Ext(source)))
+	return mediaType
+}
+
+// ParseDataURL splits a base64 data URL ("data:image/png;base64,...") into
+// its media type and data.
+func ParseDataURL(dataURL string) (mediaType, data string, ok bool) {
+	rest, found := strings.CutPrefix(dataURL, DataURLPrefix)
+	if !found {
+		return "", "", false
+	}
+	return strings.Cut(rest, DataURLBase64)
+}
+
+// CacheBreakpointResolver handles the prompty.cache_breakpoint built-in tag,
+// which ends the prompt-cache prefix after the content before it in the
+// enclosing prompty.message. Outside a message it renders nothing, since
+// only structured messages carry cache directives.
+//
+// Usage:
+//
+//	{~prompty.cache_breakpoint /~}
+type CacheBreakpointResolver struct{}
+
+// NewCacheBreakpointResolver creates a new CacheBreakpointResolver.
+func NewCacheBreakpointResolver() *CacheBreakpointResolver {
+	return &CacheBreakpointResolver{}
+}
+
+// TagName returns the tag name for this resolver.
+func (r *CacheBreakpointResolver) TagName() string {
+	return TagNameCacheBreakpoint
+}
+
+// Resolve adds the breakpoint to the message being executed, if any.
+func (r *CacheBreakpointResolver) Resolve(ctx context.Context, execCtx interface{}, attrs Attributes) (string, error) {
+	parts, ok := ctx.Value(contentPartsKey{}).(*ContentParts)
+	if !ok {
+		return "", nil
+	}
+	return parts.add(ContentPartInfo{Type: ContentPartTypeCacheBreakpoint}), nil
+}
+
+// Validate accepts any attributes.
+func (r *CacheBreakpointResolver) Validate(attrs Attributes) error {
+	return nil
+}
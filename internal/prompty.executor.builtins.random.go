@@ -0,0 +1,162 @@
+package internal
+
+import (
+	"context"
+	"strconv"
+)
+
+// ChoiceResolver handles the prompty.choice built-in tag, which outputs a
+// random element of a collection. Randomness comes from the execution's
+// entropy, so WithDeterministic makes the choice reproducible.
+//
+// Usage:
+//
+//	{~prompty.choice from="greetings" default="Hello" /~}
+type ChoiceResolver struct{}
+
+// NewChoiceResolver creates a new ChoiceResolver.
+func NewChoiceResolver() *ChoiceResolver {
+	return &ChoiceResolver{}
+}
+
+// TagName returns the tag name for this resolver.
+func (r *ChoiceResolver) TagName() string {
+	return TagNameChoice
+}
+
+// Resolve outputs a random element of the collection at the from path. A
+// missing or empty collection outputs the default attribute, if present.
+func (r *ChoiceResolver) Resolve(ctx context.Context, execCtx interface{}, attrs Attributes) (string, error) {
+	accessor, ok := execCtx.(ContextAccessor)
+	if !ok {
+		return "", NewBuiltinError(ErrMsgInvalidContext, TagNameChoice)
+	}
+	from, ok := attrs.Get(AttrFrom)
+	if !ok {
+		return "", NewBuiltinError(ErrMsgChoiceMissingFrom, TagNameChoice)
+	}
+	defaultVal, hasDefault := attrs.Get(AttrDefault)
+
+	collection, found := accessor.Get(from)
+	if !found {
+		if hasDefault {
+			return defaultVal, nil
+		}
+		return "", NewBuiltinError(ErrMsgForCollectionPath, TagNameChoice).WithMetadata(AttrFrom, from)
+	}
+	items, err := toIterableSlice(collection)
+	if err != nil {
+		return "", NewBuiltinError(ErrMsgForNotIterable, TagNameChoice).WithMetadata(AttrFrom, from)
+	}
+	if len(items) == 0 {
+		return defaultVal, nil
+	}
+
+	return valueToString(items[EntropyFrom(ctx).Rand.IntN(len(items))]), nil
+}
+
+// Validate checks that the from attribute is present.
+func (r *ChoiceResolver) Validate(attrs Attributes) error {
+	if !attrs.Has(AttrFrom) {
+		return NewBuiltinError(ErrMsgChoiceMissingFrom, TagNameChoice)
+	}
+	return nil
+}
+
+// ShuffleResolver handles the prompty.shuffle built-in block tag, which
+// repeats its body for the elements of a collection in random order,
+// optionally sampling at most limit of them. The executor renders the body
+// (see Executor.executeShuffle); the resolver only validates the tag.
+//
+// Usage:
+//
+//	{~prompty.shuffle in="examples" item="ex" limit="3"~}...{~/prompty.shuffle~}
+type ShuffleResolver struct{}
+
+// NewShuffleResolver creates a new ShuffleResolver.
+func NewShuffleResolver() *ShuffleResolver {
+	return &ShuffleResolver{}
+}
+
+// TagName returns the tag name for this resolver.
+func (r *ShuffleResolver) TagName() string {
+	return TagNameShuffle
+}
+
+// Resolve validates the tag; the body is rendered by the executor.
+func (r *ShuffleResolver) Resolve(ctx context.Context, execCtx interface{}, attrs Attributes) (string, error) {
+	return "", r.Validate(attrs)
+}
+
+// Validate checks that the in attribute is present and the limit is valid.
+func (r *ShuffleResolver) Validate(attrs Attributes) error {
+	_, _, err := shuffleAttrs(attrs)
+	return err
+}
+
+// shuffleAttrs returns the collection path and sample limit (0 for all
+// elements) of a prompty.shuffle tag.
+func shuffleAttrs(attrs Attributes) (string, int, error) {
+	in, ok := attrs.Get(AttrIn)
+	if !ok {
+		return "", 0, NewBuiltinError(ErrMsgForMissingIn, TagNameShuffle)
+	}
+	limit := 0
+	if limitStr, ok := attrs.Get(AttrLimit); ok {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n <= 0 {
+			return "", 0, NewBuiltinError(ErrMsgForInvalidLimit, TagNameShuffle).WithMetadata(AttrLimit, limitStr)
+		}
+		limit = n
+	}
+	return in, limit, nil
+}
+
+// executeShuffle renders the body of a prompty.shuffle tag for a random
+// sample of its collection. Failing to read the collection is handled by
+// the tag's error strategy.
+func (e *Executor) executeShuffle(ctx context.Context, tag *TagNode, execCtx ContextAccessor, depth int) (string, error) {
+	items, err := shuffledItems(ctx, tag, execCtx)
+	if err != nil {
+		err = NewExecutorErrorWithCause(ErrMsgResolverFailed, tag.Name, tag.Pos(), err)
+		setTraceError(ctx, err)
+		return e.handleTagError(ctx, tag, execCtx, err)
+	}
+
+	itemVar := tag.Attributes.GetDefault(AttrItem, DefaultShuffleItemVar)
+	indexVar := tag.Attributes.GetDefault(AttrIndex, "")
+	return e.executeIterations(ctx, TagNameShuffle, tag.Pos(), items, itemVar, indexVar, tag.Children, execCtx, depth)
+}
+
+// shuffledItems returns the collection of a prompty.shuffle tag in random
+// order, cut to its limit.
+func shuffledItems(ctx context.Context, tag *TagNode, execCtx ContextAccessor) ([]any, error) {
+	in, limit, err := shuffleAttrs(tag.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	collection, found := execCtx.Get(in)
+	if !found {
+		return nil, NewBuiltinError(ErrMsgForCollectionPath, TagNameShuffle).WithMetadata(AttrIn, in)
+	}
+	source, err := toIterableSlice(collection)
+	if err != nil {
+		return nil, NewBuiltinError(ErrMsgForNotIterable, TagNameShuffle).WithMetadata(AttrIn, in)
+	}
+
+	// Shuffle a copy; the collection belongs to the caller's data
+	items := make([]any, len(source))
+	copy(items, source)
+	EntropyFrom(ctx).Rand.Shuffle(len(items), func(i, j int) {
+		items[i], items[j] = items[j], items[i]
+	})
+
+	if limit <= 0 || limit > DefaultMaxLoopIterations {
+		limit = DefaultMaxLoopIterations
+	}
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChoiceResolver(t *testing.T) {
+	resolver := NewChoiceResolver()
+	data := newMockContextAccessor(map[string]any{
+		"greetings": []string{"hi", "hello", "hey"},
+		"empty":     []any{},
+		"scalar":    42,
+	})
+
+	result, err := resolver.Resolve(context.Background(), data, Attributes{AttrFrom: "greetings"})
+	require.NoError(t, err)
+	assert.Contains(t, []string{"hi", "hello", "hey"}, result)
+
+	result, err = resolver.Resolve(context.Background(), data, Attributes{AttrFrom: "empty", AttrDefault: "none"})
+	require.NoError(t, err)
+	assert.Equal(t, "none", result)
+
+	result, err = resolver.Resolve(context.Background(), data, Attributes{AttrFrom: "missing", AttrDefault: "none"})
+	require.NoError(t, err)
+	assert.Equal(t, "none", result)
+
+	_, err = resolver.Resolve(context.Background(), data, Attributes{AttrFrom: "missing"})
+	assert.ErrorContains(t, err, ErrMsgForCollectionPath)
+
+	_, err = resolver.Resolve(context.Background(), data, Attributes{AttrFrom: "scalar"})
+	assert.ErrorContains(t, err, ErrMsgForNotIterable)
+
+	assert.Error(t, resolver.Validate(Attributes{}))
+}
+
+func TestShuffleResolver_Validate(t *testing.T) {
+	resolver := NewShuffleResolver()
+	assert.NoError(t, resolver.Validate(Attributes{AttrIn: "items", AttrLimit: "3"}))
+	assert.ErrorContains(t, resolver.Validate(Attributes{}), ErrMsgForMissingIn)
+	assert.ErrorContains(t, resolver.Validate(Attributes{AttrIn: "items", AttrLimit: "0"}), ErrMsgForInvalidLimit)
+}
+
+func TestExecutor_Shuffle(t *testing.T) {
+	registry := NewRegistry(nil)
+	RegisterBuiltins(registry)
+	executor := NewExecutor(registry, DefaultExecutorConfig(), nil)
+	executor.SetSeed(7)
+
+	items := []any{"a", "b", "c", "d", "e"}
+	data := map[string]any{"items": items}
+	root := parseForImports(t, `{~prompty.shuffle in="items" index="i" limit="3"~}{~prompty.var name="i" /~}{~prompty.var name="item" /~};{~/prompty.shuffle~}`)
+
+	execute := func() string {
+		out, err := executor.Execute(context.Background(), root, newMockContextAccessorWithChild(data))
+		require.NoError(t, err)
+		return out
+	}
+	out := execute()
+	assert.Equal(t, out, execute(), "the seed fixes the sample")
+	assert.Regexp(t, `^0[a-e];1[a-e];2[a-e];$`, out)
+	assert.Equal(t, []any{"a", "b", "c", "d", "e"}, items, "the caller's collection is not reordered")
+
+	// A missing collection is handled by the tag's error strategy
+	root = parseForImports(t, `x{~prompty.shuffle in="missing" onerror="remove"~}{~prompty.var name="item" /~}{~/prompty.shuffle~}y`)
+	out, err := executor.Execute(context.Background(), root, newMockContextAccessorWithChild(data))
+	require.NoError(t, err)
+	assert.Equal(t, "xy", out)
+}
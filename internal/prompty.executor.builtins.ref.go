@@ -72,6 +72,7 @@ func (r *RefResolver) Resolve(ctx context.Context, execCtx interface{}, attrs At
 	body, err := resolver.ResolvePromptBody(ctx, slug, version)
 	if err != nil {
 		return "", NewBuiltinError(AppendHint(ErrMsgRefNotFound, HintRefNotFound), TagNameRef).
+			WithCause(err).
 			WithMetadata(LogFieldPromptSlug, slug).
 			WithMetadata(LogFieldPromptVersion, version)
 	}
@@ -161,6 +162,7 @@ func isValidPromptSlug(slug string) bool {
 func NewRefCircularError(slug string, chain []string) *BuiltinError {
 	chainStr := strings.Join(chain, " -> ")
 	return NewBuiltinError(ErrMsgRefCircular, TagNameRef).
+		WithSentinel(ErrCycleDetected).
 		WithMetadata(LogFieldPromptSlug, slug).
 		WithMetadata(LogFieldRefChain, chainStr)
 }
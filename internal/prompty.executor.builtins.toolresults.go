@@ -0,0 +1,159 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// ToolResultsResolver handles the prompty.tool_results built-in tag, which
+// renders the outputs of tool calls stored in the data: a collection of
+// entries with name, call_id, content and is_error (maps, or structs with
+// Name, CallID, Content and IsError fields).
+//
+// Use it for models without native tool messages, or to restate tool output
+// in a prompt. The format selects the output:
+//   - "plain" (default): "Tool name returned:" followed by the content
+//   - "xml": <tool_result name="..." id="..."> elements
+//   - "json": a JSON array of the entries
+//
+// A missing or empty collection renders nothing.
+//
+// Usage:
+//
+//	{~prompty.tool_results /~}
+//	{~prompty.tool_results in="input.tool_results" format="xml" /~}
+type ToolResultsResolver struct{}
+
+// ToolResultInfo is one tool result rendered by prompty.tool_results.
+type ToolResultInfo struct {
+	CallID  string `json:"call_id,omitempty"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	IsError bool   `json:"is_error,omitempty"`
+}
+
+// NewToolResultsResolver creates a new ToolResultsResolver.
+func NewToolResultsResolver() *ToolResultsResolver {
+	return &ToolResultsResolver{}
+}
+
+// TagName returns the tag name for this resolver.
+func (r *ToolResultsResolver) TagName() string {
+	return TagNameToolResults
+}
+
+// Resolve renders the tool results.
+func (r *ToolResultsResolver) Resolve(ctx context.Context, execCtx interface{}, attrs Attributes) (string, error) {
+	accessor, ok := execCtx.(ContextAccessor)
+	if !ok {
+		return "", NewBuiltinError(ErrMsgInvalidContext, TagNameToolResults)
+	}
+	format, err := toolResultsFormat(attrs)
+	if err != nil {
+		return "", err
+	}
+	in := attrs.GetDefault(AttrIn, DefaultToolResultsIn)
+
+	collection, found := accessor.Get(in)
+	if !found {
+		return "", nil
+	}
+	items, iterErr := toIterableSlice(collection)
+	if iterErr != nil {
+		return "", NewBuiltinError(ErrMsgForNotIterable, TagNameToolResults).WithMetadata(AttrIn, in)
+	}
+
+	results := make([]ToolResultInfo, 0, len(items))
+	for i, item := range items {
+		result, err := toToolResult(item)
+		if err != nil {
+			return "", err.WithMetadata(AttrIndex, strconv.Itoa(i))
+		}
+		results = append(results, result)
+	}
+	return FormatToolResults(results, format), nil
+}
+
+// Validate checks the format attribute.
+func (r *ToolResultsResolver) Validate(attrs Attributes) error {
+	_, err := toolResultsFormat(attrs)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// toolResultsFormat returns the format of a prompty.tool_results tag.
+func toolResultsFormat(attrs Attributes) (string, *BuiltinError) {
+	format := strings.ToLower(attrs.GetDefault(AttrFormat, ToolResultsFormatPlain))
+	switch format {
+	case ToolResultsFormatPlain, ToolResultsFormatXML, ToolResultsFormatJSON:
+		return format, nil
+	default:
+		return "", NewBuiltinError(ErrMsgToolResultsInvalidFormat, TagNameToolResults).WithMetadata(AttrFormat, format)
+	}
+}
+
+// toToolResult reads a tool result entry.
+func toToolResult(item any) (ToolResultInfo, *BuiltinError) {
+	name, _ := messageField(item, ToolResultFieldName)
+	result := ToolResultInfo{Name: valueToString(name)}
+	if result.Name == "" {
+		return ToolResultInfo{}, NewBuiltinError(ErrMsgToolResultInvalid, TagNameToolResults)
+	}
+	if callID, ok := messageField(item, ToolResultFieldCallID); ok {
+		result.CallID = valueToString(callID)
+	}
+	if content, ok := messageField(item, ToolResultFieldContent); ok {
+		result.Content = valueToString(content)
+	}
+	if isError, ok := messageField(item, ToolResultFieldIsError); ok {
+		result.IsError = isTruthy(isError)
+	}
+	return result, nil
+}
+
+// FormatToolResults renders tool results as plain text, XML elements or a
+// JSON array.
+func FormatToolResults(results []ToolResultInfo, format string) string {
+	if len(results) == 0 {
+		return ""
+	}
+	if format == ToolResultsFormatJSON {
+		data, err := json.Marshal(results)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+
+	parts := make([]string, len(results))
+	for i, r := range results {
+		if format == ToolResultsFormatXML {
+			var sb strings.Builder
+			sb.WriteString(ToolResultXMLOpen)
+			sb.WriteString(fmt.Sprintf(ToolResultXMLAttr, AttrName, html.EscapeString(r.Name)))
+			if r.CallID != "" {
+				sb.WriteString(fmt.Sprintf(ToolResultXMLAttr, AttrID, html.EscapeString(r.CallID)))
+			}
+			if r.IsError {
+				sb.WriteString(fmt.Sprintf(ToolResultXMLAttr, ToolResultXMLAttrError, AttrValueTrue))
+			}
+			sb.WriteString(ToolResultXMLTagEnd)
+			sb.WriteString(r.Content)
+			sb.WriteString(ToolResultXMLClose)
+			parts[i] = sb.String()
+			continue
+		}
+		if r.IsError {
+			parts[i] = fmt.Sprintf(ToolResultPlainError, r.Name, r.Content)
+		} else {
+			parts[i] = fmt.Sprintf(ToolResultPlainOK, r.Name, r.Content)
+		}
+	}
+	return strings.Join(parts, ToolResultsSeparator)
+}
@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testToolResult struct {
+	CallID  string
+	Name    string
+	Content string
+	IsError bool
+}
+
+func TestToolResultsResolver_Formats(t *testing.T) {
+	resolver := NewToolResultsResolver()
+	data := newMockContextAccessor(map[string]any{
+		"tool_results": []any{
+			map[string]any{"call_id": "call_1", "name": "search", "content": "3 hits"},
+			testToolResult{CallID: "call_2", Name: "fetch", Content: "timeout", IsError: true},
+		},
+	})
+	ctx := context.Background()
+
+	result, err := resolver.Resolve(ctx, data, Attributes{})
+	require.NoError(t, err)
+	assert.Equal(t, "Tool search returned:\n3 hits\n\nTool fetch failed:\ntimeout", result)
+
+	result, err = resolver.Resolve(ctx, data, Attributes{AttrFormat: ToolResultsFormatXML})
+	require.NoError(t, err)
+	assert.Equal(t, `<tool_result name="search" id="call_1">3 hits</tool_result>`+"\n\n"+
+		`<tool_result name="fetch" id="call_2" error="true">timeout</tool_result>`, result)
+
+	result, err = resolver.Resolve(ctx, data, Attributes{AttrFormat: ToolResultsFormatJSON})
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"call_id":"call_1","name":"search","content":"3 hits"},{"call_id":"call_2","name":"fetch","content":"timeout","is_error":true}]`, result)
+
+	result, err = resolver.Resolve(ctx, data, Attributes{AttrIn: "missing"})
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func TestToolResultsResolver_Errors(t *testing.T) {
+	resolver := NewToolResultsResolver()
+	ctx := context.Background()
+
+	err := resolver.Validate(Attributes{AttrFormat: "yaml"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgToolResultsInvalidFormat)
+	assert.NoError(t, resolver.Validate(Attributes{}))
+
+	data := newMockContextAccessor(map[string]any{
+		"tool_results": []any{map[string]any{"content": "orphan"}},
+		"scalar":       42,
+	})
+	_, err = resolver.Resolve(ctx, data, Attributes{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgToolResultInvalid)
+
+	_, err = resolver.Resolve(ctx, data, Attributes{AttrIn: "scalar"})
+	require.Error(t, err)
+
+	_, err = resolver.Resolve(ctx, "not a context", Attributes{})
+	require.Error(t, err)
+}
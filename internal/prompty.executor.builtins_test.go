@@ -250,7 +250,17 @@ func TestRegisterBuiltins(t *testing.T) {
 	assert.True(t, registry.Has(TagNameRef))
 	assert.True(t, registry.Has(TagNameSkillsCatalog))
 	assert.True(t, registry.Has(TagNameToolsCatalog))
-	assert.Equal(t, 8, registry.Count())
+	assert.True(t, registry.Has(TagNameImport))
+	assert.True(t, registry.Has(TagNameUse))
+	assert.True(t, registry.Has(TagNameChoice))
+	assert.True(t, registry.Has(TagNameShuffle))
+	assert.True(t, registry.Has(TagNameHistory))
+	assert.True(t, registry.Has(TagNameImage))
+	assert.True(t, registry.Has(TagNameFile))
+	assert.True(t, registry.Has(TagNameCacheBreakpoint))
+	assert.True(t, registry.Has(TagNameToolResults))
+	assert.True(t, registry.Has(TagNameEscape))
+	assert.Equal(t, 18, registry.Count())
 
 	// Verify we can get them
 	varResolver, ok := registry.Get(TagNameVar)
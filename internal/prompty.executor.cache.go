@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TagCache stores the results of tags carrying the cache attribute.
+type TagCache interface {
+	// Get returns the cached result for key, if present and not expired.
+	Get(ctx context.Context, key string) (string, bool)
+
+	// Set stores a result for key for the given time to live.
+	Set(ctx context.Context, key, value string, ttl time.Duration)
+}
+
+// Tag cache key construction
+const (
+	tagCacheKeySep    = "\x00"
+	tagCacheKeyAssign = "="
+)
+
+// SetTagCache sets the cache backing the cache attribute. A nil cache
+// disables tag caching; cache attributes are then ignored. Set the cache
+// before executing templates.
+func (e *Executor) SetTagCache(cache TagCache) {
+	e.tagCache = cache
+}
+
+// ParseTagCacheTTL parses the value of a cache attribute, which must be a
+// positive duration.
+func ParseTagCacheTTL(value string) (time.Duration, bool) {
+	ttl, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || ttl <= 0 {
+		return 0, false
+	}
+	return ttl, true
+}
+
+// IsTagCached reports whether the cache attribute of tag requests result
+// caching. On prompty.message, cache marks a prompt-cache breakpoint instead;
+// content parts are never cached, as their output is bound to one message.
+func IsTagCached(tag *TagNode) bool {
+	return IsCacheableTag(tag.Name) && tag.Attributes.Has(AttrCache)
+}
+
+// IsCacheableTag reports whether tags of the name accept the cache
+// attribute for result caching.
+func IsCacheableTag(tagName string) bool {
+	switch tagName {
+	case TagNameMessage, TagNameImage, TagNameFile, TagNameCacheBreakpoint:
+		return false
+	}
+	return true
+}
+
+// resolveCached invokes the resolver of tag, serving and storing its result
+// through the tag cache when the tag carries the cache attribute. Cache
+// hits skip the resolver and the resolve hook.
+func (e *Executor) resolveCached(ctx context.Context, resolver InternalResolver, tag *TagNode, execCtx ContextAccessor) (string, error) {
+	if e.tagCache == nil || !IsTagCached(tag) {
+		return e.resolve(ctx, resolver, tag, execCtx)
+	}
+
+	ttlValue, _ := tag.Attributes.Get(AttrCache)
+	ttl, ok := ParseTagCacheTTL(ttlValue)
+	if !ok {
+		return "", NewBuiltinError(ErrMsgInvalidTagCacheTTL, tag.Name).WithMetadata(AttrCache, ttlValue)
+	}
+
+	key := tagCacheKey(tag, execCtx)
+	if result, ok := e.tagCache.Get(ctx, key); ok {
+		return result, nil
+	}
+
+	result, err := e.resolve(ctx, resolver, tag, execCtx)
+	if err == nil {
+		e.tagCache.Set(ctx, key, result, ttl)
+	}
+	return result, err
+}
+
+// tagCacheKey hashes the tag name, its attributes other than cache and
+// cache_key, and the current values of the data paths listed in cache_key.
+func tagCacheKey(tag *TagNode, execCtx ContextAccessor) string {
+	hasher := sha256.New()
+	hasher.Write([]byte(tag.Name))
+
+	for _, key := range tag.Attributes.Keys() {
+		if key == AttrCache || key == AttrCacheKey {
+			continue
+		}
+		value, _ := tag.Attributes.Get(key)
+		hasher.Write([]byte(tagCacheKeySep + key + tagCacheKeyAssign + value))
+	}
+
+	if paths, ok := tag.Attributes.Get(AttrCacheKey); ok {
+		for _, path := range strings.Split(paths, IncludeWithSeparator) {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				continue
+			}
+			value, _ := execCtx.Get(path)
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				encoded = []byte(fmt.Sprintf("%v", value))
+			}
+			hasher.Write([]byte(tagCacheKeySep + path + tagCacheKeyAssign))
+			hasher.Write(encoded)
+		}
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil))
+}
@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagCacheKey(t *testing.T) {
+	tag := func(attrs Attributes) *TagNode {
+		return &TagNode{Name: TagNameVar, Attributes: attrs}
+	}
+	data := newMockContextAccessor(map[string]any{"user.id": 7, "lang": "en"})
+
+	base := tagCacheKey(tag(Attributes{AttrName: "x", AttrCache: "5m"}), data)
+	assert.Equal(t, base, tagCacheKey(tag(Attributes{AttrName: "x", AttrCache: "1h"}), data), "the TTL is not part of the key")
+	assert.NotEqual(t, base, tagCacheKey(tag(Attributes{AttrName: "y", AttrCache: "5m"}), data))
+
+	keyed := tagCacheKey(tag(Attributes{AttrName: "x", AttrCacheKey: "user.id, lang"}), data)
+	assert.NotEqual(t, base, keyed)
+	other := newMockContextAccessor(map[string]any{"user.id": 8, "lang": "en"})
+	assert.NotEqual(t, keyed, tagCacheKey(tag(Attributes{AttrName: "x", AttrCacheKey: "user.id, lang"}), other))
+}
+
+func TestParseTagCacheTTL(t *testing.T) {
+	for _, value := range []string{"5m", " 1h30m ", "250ms"} {
+		_, ok := ParseTagCacheTTL(value)
+		assert.True(t, ok, value)
+	}
+	for _, value := range []string{"", "0s", "-1m", "true", "5"} {
+		_, ok := ParseTagCacheTTL(value)
+		assert.False(t, ok, value)
+	}
+}
@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"context"
+	"sync"
+)
+
+// CollectedError is a tag failure recorded under ErrorStrategyCollect.
+type CollectedError struct {
+	TagName string
+	Pos     Position
+	Err     error
+}
+
+// ErrorCollector records the tag failures of an execution under
+// ErrorStrategyCollect, including those of nested template executions.
+// It is safe for concurrent use.
+type ErrorCollector struct {
+	mu     sync.Mutex
+	errors []CollectedError
+}
+
+// errorCollectorKey is the context key for the ErrorCollector of the
+// execution.
+type errorCollectorKey struct{}
+
+// WithErrorCollector returns ctx collecting tag failures.
+func WithErrorCollector(ctx context.Context) (context.Context, *ErrorCollector) {
+	collector := &ErrorCollector{}
+	return context.WithValue(ctx, errorCollectorKey{}, collector), collector
+}
+
+// ErrorCollectorFrom returns the ErrorCollector of ctx, or nil.
+func ErrorCollectorFrom(ctx context.Context) *ErrorCollector {
+	collector, _ := ctx.Value(errorCollectorKey{}).(*ErrorCollector)
+	return collector
+}
+
+// add records the failure of tag.
+func (c *ErrorCollector) add(tag *TagNode, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errors = append(c.errors, CollectedError{TagName: tag.Name, Pos: tag.Pos(), Err: err})
+}
+
+// Errors returns the failures recorded so far, in execution order.
+func (c *ErrorCollector) Errors() []CollectedError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]CollectedError(nil), c.errors...)
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Entropy supplies the time and randomness of one execution. Nested
+// executions (includes) share the entropy of the top-level execution, so a
+// fixed clock and seed make the whole render reproducible.
+type Entropy struct {
+	// Now returns the current time.
+	Now func() time.Time
+
+	// Rand is the random source. It is not safe for concurrent use.
+	Rand *rand.Rand
+}
+
+// entropyKey is the context key for the Entropy of the running execution.
+type entropyKey struct{}
+
+// EntropyFrom returns the entropy of the execution running ctx. Outside an
+// execution it returns the wall clock and a randomly seeded source.
+func EntropyFrom(ctx context.Context) *Entropy {
+	if entropy, ok := ctx.Value(entropyKey{}).(*Entropy); ok {
+		return entropy
+	}
+	return &Entropy{Now: time.Now, Rand: rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))}
+}
+
+// SetClock sets the clock used by now() and exposed to resolvers through
+// EntropyFrom. A nil clock restores the wall clock. Set the clock before
+// executing templates.
+func (e *Executor) SetClock(clock func() time.Time) {
+	if clock == nil {
+		clock = time.Now
+	}
+	e.clock = clock
+	e.funcs.replace(newNowFunc(clock))
+}
+
+// SetSeed seeds the random source of every execution with seed, so random
+// choices repeat from one render to the next. Set the seed before executing
+// templates.
+func (e *Executor) SetSeed(seed uint64) {
+	e.seed = seed
+	e.seeded = true
+}
+
+// withEntropy returns ctx carrying the executor's entropy, unless an
+// enclosing execution already provides one.
+func (e *Executor) withEntropy(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(entropyKey{}).(*Entropy); ok {
+		return ctx
+	}
+
+	var source rand.Source
+	if e.seeded {
+		source = rand.NewPCG(e.seed, e.seed)
+	} else {
+		source = rand.NewPCG(rand.Uint64(), rand.Uint64())
+	}
+	return context.WithValue(ctx, entropyKey{}, &Entropy{Now: e.clock, Rand: rand.New(source)})
+}
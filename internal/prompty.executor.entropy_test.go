@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor_SetClock(t *testing.T) {
+	registry := NewRegistry(nil)
+	RegisterBuiltins(registry)
+	executor := NewExecutor(registry, DefaultExecutorConfig(), nil)
+	fixed := time.Date(2001, time.February, 3, 0, 0, 0, 0, time.UTC)
+	executor.SetClock(func() time.Time { return fixed })
+
+	now, err := executor.funcs.Call(FuncNameNow, nil)
+	require.NoError(t, err)
+	assert.Equal(t, fixed, now)
+
+	executor.SetClock(nil)
+	now, err = executor.funcs.Call(FuncNameNow, nil)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), now.(time.Time), time.Minute)
+}
+
+func TestExecutor_WithEntropy(t *testing.T) {
+	executor := NewExecutor(NewRegistry(nil), DefaultExecutorConfig(), nil)
+	executor.SetSeed(99)
+
+	draw := func(ctx context.Context) uint64 {
+		return EntropyFrom(executor.withEntropy(ctx)).Rand.Uint64()
+	}
+	assert.Equal(t, draw(context.Background()), draw(context.Background()))
+
+	// Nested executions keep the enclosing entropy
+	outer := executor.withEntropy(context.Background())
+	assert.Same(t, EntropyFrom(outer), EntropyFrom(executor.withEntropy(outer)))
+}
@@ -753,6 +753,9 @@ func TestParseErrorStrategy_AllValues(t *testing.T) {
 		{ErrorStrategyNameRemove, ErrorStrategyRemove},
 		{ErrorStrategyNameKeepRaw, ErrorStrategyKeepRaw},
 		{ErrorStrategyNameLog, ErrorStrategyLog},
+		{ErrorStrategyNameCollect, ErrorStrategyCollect},
+		{ErrorStrategyNameInclude, ErrorStrategyInclude},
+		{"include:fallback", ErrorStrategyInclude},
 		{"", ErrorStrategyThrow},
 		{"invalid", ErrorStrategyThrow},
 		{"THROW", ErrorStrategyThrow},
@@ -777,6 +780,8 @@ func TestErrorStrategy_StringRepresentation(t *testing.T) {
 		{ErrorStrategyRemove, ErrorStrategyNameRemove},
 		{ErrorStrategyKeepRaw, ErrorStrategyNameKeepRaw},
 		{ErrorStrategyLog, ErrorStrategyNameLog},
+		{ErrorStrategyCollect, ErrorStrategyNameCollect},
+		{ErrorStrategyInclude, ErrorStrategyNameInclude},
 		{ErrorStrategy(99), ErrorStrategyNameThrow},
 	}
 
@@ -786,3 +791,66 @@ func TestErrorStrategy_StringRepresentation(t *testing.T) {
 		})
 	}
 }
+
+// recordingTagErrorHook records the tag failures it is notified of.
+type recordingTagErrorHook struct {
+	events []TagErrorEvent
+}
+
+func (h *recordingTagErrorHook) OnTagError(_ context.Context, event TagErrorEvent) {
+	h.events = append(h.events, event)
+}
+
+// TestHandleTagError_NotifiesTagErrorHook verifies that the hook sees failures
+// the strategy recovers from, with the strategy applied.
+func TestHandleTagError_NotifiesTagErrorHook(t *testing.T) {
+	registry := NewRegistry(nil)
+	RegisterBuiltins(registry)
+	executor := NewExecutor(registry, DefaultExecutorConfig(), nil)
+	hook := &recordingTagErrorHook{}
+	executor.SetTagErrorHook(hook)
+
+	tag := NewSelfClosingTag(TagNameVar, Attributes{
+		AttrName:    "nonexistent",
+		AttrOnError: ErrorStrategyNameRemove,
+	}, Position{Line: 2, Column: 5})
+
+	result, err := executor.Execute(context.Background(), &RootNode{Children: []Node{tag}}, newMockContextAccessor(nil))
+	require.NoError(t, err)
+	assert.Empty(t, result)
+
+	require.Len(t, hook.events, 1)
+	assert.Equal(t, TagNameVar, hook.events[0].TagName)
+	assert.Equal(t, Position{Line: 2, Column: 5}, hook.events[0].Pos)
+	assert.Equal(t, ErrorStrategyRemove, hook.events[0].Strategy)
+	assert.ErrorIs(t, hook.events[0].Err, ErrNotFound)
+}
+
+// TestHandleTagError_IncludeStrategyWithoutTemplate verifies that a bare
+// onerror="include" behaves like the default strategy.
+func TestHandleTagError_IncludeStrategyWithoutTemplate(t *testing.T) {
+	registry := NewRegistry(nil)
+	RegisterBuiltins(registry)
+	executor := NewExecutor(registry, DefaultExecutorConfig(), nil)
+
+	tag := NewSelfClosingTag(TagNameVar, Attributes{
+		AttrName:    "nonexistent",
+		AttrOnError: ErrorStrategyNameInclude,
+		AttrDefault: "fallback",
+	}, Position{Line: 1, Column: 1})
+
+	result, err := executor.Execute(context.Background(), &RootNode{Children: []Node{tag}}, newMockContextAccessor(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "fallback", result)
+}
+
+func TestErrorFallbackTemplate(t *testing.T) {
+	name, ok := ErrorFallbackTemplate("include:fallback")
+	assert.True(t, ok)
+	assert.Equal(t, "fallback", name)
+
+	for _, value := range []string{"include:", ErrorStrategyNameInclude, ErrorStrategyNameDefault, ""} {
+		_, ok := ErrorFallbackTemplate(value)
+		assert.False(t, ok, value)
+	}
+}
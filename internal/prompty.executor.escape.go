@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"html"
+	"strings"
+)
+
+// Escaper escapes an interpolated value for the format of the surrounding
+// output.
+type Escaper func(string) string
+
+// escapedTags lists the tags whose output is an interpolated value and is
+// escaped in the current output mode.
+var escapedTags = map[string]bool{
+	TagNameVar:    true,
+	TagNameEnv:    true,
+	TagNameChoice: true,
+}
+
+// markdownEscaper backslash-escapes characters that start Markdown
+// formatting, links or HTML.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+	`<`, `\<`, `>`, `\>`, `|`, `\|`, `~`, `\~`, `#`, `\#`,
+)
+
+// builtinEscapers returns the escapers of the built-in output modes.
+func builtinEscapers() map[string]Escaper {
+	return map[string]Escaper{
+		OutputModeText:     func(s string) string { return s },
+		OutputModeHTML:     html.EscapeString,
+		OutputModeJSON:     escapeJSON,
+		OutputModeMarkdown: markdownEscaper.Replace,
+		OutputModeShell:    escapeShell,
+	}
+}
+
+// escapeJSON escapes a value for use inside a JSON string literal.
+func escapeJSON(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return s
+	}
+	out := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	return string(out[1 : len(out)-1])
+}
+
+// escapeShell quotes a value as a single POSIX shell word.
+func escapeShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// outputModeKey is the context key of the output mode in effect.
+type outputModeKey struct{}
+
+// SetOutputMode sets the output mode of executions that are not inside a
+// prompty.escape region ("" or "text" for none). Set it before executing
+// templates.
+func (e *Executor) SetOutputMode(mode string) {
+	e.outputMode = mode
+}
+
+// RegisterEscaper adds or replaces the escaper of an output mode. Register
+// escapers before executing templates.
+func (e *Executor) RegisterEscaper(mode string, escaper Escaper) {
+	e.escapers[mode] = escaper
+}
+
+// HasEscaper reports whether an escaper is registered for the output mode.
+func (e *Executor) HasEscaper(mode string) bool {
+	_, ok := e.escapers[mode]
+	return ok
+}
+
+// withOutputMode returns ctx carrying the executor's output mode, unless an
+// enclosing execution or escape region already sets one.
+func (e *Executor) withOutputMode(ctx context.Context) context.Context {
+	if e.outputMode == "" {
+		return ctx
+	}
+	if _, ok := ctx.Value(outputModeKey{}).(string); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, outputModeKey{}, e.outputMode)
+}
+
+// escapeValue escapes the output of a value tag in the output mode of its
+// escape attribute, or else of the context.
+func (e *Executor) escapeValue(ctx context.Context, tag *TagNode, value string) (string, error) {
+	if !escapedTags[tag.Name] {
+		return value, nil
+	}
+	mode, ok := tag.Attributes.Get(AttrEscape)
+	if !ok {
+		mode, _ = ctx.Value(outputModeKey{}).(string)
+	}
+	if mode == "" || mode == OutputModeText {
+		return value, nil
+	}
+	escaper, ok := e.escapers[mode]
+	if !ok {
+		return "", NewBuiltinError(ErrMsgEscapeUnknownMode, tag.Name).WithMetadata(AttrMode, mode)
+	}
+	return escaper(value), nil
+}
+
+// EscapeResolver handles the prompty.escape built-in block tag, which sets
+// the output mode of values interpolated in its body. The executor renders
+// the body (see Executor.executeEscape); the resolver only validates the tag.
+//
+// Usage:
+//
+//	{"note": "{~prompty.escape mode="json"~}{~prompty.var name="note" /~}{~/prompty.escape~}"}
+type EscapeResolver struct{}
+
+// NewEscapeResolver creates a new EscapeResolver.
+func NewEscapeResolver() *EscapeResolver {
+	return &EscapeResolver{}
+}
+
+// TagName returns the tag name for this resolver.
+func (r *EscapeResolver) TagName() string {
+	return TagNameEscape
+}
+
+// Resolve validates the tag; the body is rendered by the executor.
+func (r *EscapeResolver) Resolve(ctx context.Context, execCtx interface{}, attrs Attributes) (string, error) {
+	return "", r.Validate(attrs)
+}
+
+// Validate checks that the mode attribute is present.
+func (r *EscapeResolver) Validate(attrs Attributes) error {
+	if mode, ok := attrs.Get(AttrMode); !ok || mode == "" {
+		return NewBuiltinError(ErrMsgEscapeMissingMode, TagNameEscape)
+	}
+	return nil
+}
+
+// executeEscape renders the body of a prompty.escape tag in its output
+// mode. An unknown mode is handled by the tag's error strategy.
+func (e *Executor) executeEscape(ctx context.Context, tag *TagNode, execCtx ContextAccessor, depth int) (string, error) {
+	mode := tag.Attributes.GetDefault(AttrMode, "")
+	if !e.HasEscaper(mode) {
+		err := NewExecutorErrorWithCause(ErrMsgResolverFailed, tag.Name, tag.Pos(),
+			NewBuiltinError(ErrMsgEscapeUnknownMode, TagNameEscape).WithMetadata(AttrMode, mode))
+		setTraceError(ctx, err)
+		return e.handleTagError(ctx, tag, execCtx, err)
+	}
+	return e.executeNodes(context.WithValue(ctx, outputModeKey{}, mode), tag.Children, execCtx, depth+1)
+}
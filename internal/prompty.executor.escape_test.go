@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinEscapers(t *testing.T) {
+	escapers := builtinEscapers()
+	value := `Tom's "<b>" *bold* & line` + "\n" + `\ok`
+
+	tests := []struct {
+		mode     string
+		expected string
+	}{
+		{OutputModeText, value},
+		{OutputModeHTML, `Tom&#39;s &#34;&lt;b&gt;&#34; *bold* &amp; line` + "\n" + `\ok`},
+		{OutputModeJSON, `Tom's \"<b>\" *bold* & line\n\\ok`},
+		{OutputModeMarkdown, `Tom's "\<b\>" \*bold\* & line` + "\n" + `\\ok`},
+		{OutputModeShell, `'Tom'\''s "<b>" *bold* & line` + "\n" + `\ok'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			assert.Equal(t, tt.expected, escapers[tt.mode](value))
+		})
+	}
+}
+
+func TestEscapeResolver_Validate(t *testing.T) {
+	resolver := NewEscapeResolver()
+	assert.NoError(t, resolver.Validate(Attributes{AttrMode: OutputModeJSON}))
+	assert.ErrorContains(t, resolver.Validate(Attributes{}), ErrMsgEscapeMissingMode)
+}
+
+func TestExecutor_OutputMode(t *testing.T) {
+	registry := NewRegistry(nil)
+	RegisterBuiltins(registry)
+	executor := NewExecutor(registry, DefaultExecutorConfig(), nil)
+	data := map[string]any{"v": `<"x">`}
+
+	execute := func(source string) (string, error) {
+		return executor.Execute(context.Background(), parseForImports(t, source), newMockContextAccessorWithChild(data))
+	}
+
+	out, err := execute(`<{~prompty.var name="v" /~}>`)
+	require.NoError(t, err)
+	assert.Equal(t, `<<"x">>`, out, "no escaping without a mode")
+
+	out, err = execute(`{~prompty.escape mode="html"~}<{~prompty.var name="v" /~}>{~/prompty.escape~}`)
+	require.NoError(t, err)
+	assert.Equal(t, `<&lt;&#34;x&#34;&gt;>`, out, "text in the region is not escaped")
+
+	out, err = execute(`{~prompty.var name="v" escape="json" /~}`)
+	require.NoError(t, err)
+	assert.Equal(t, `<\"x\">`, out)
+
+	executor.SetOutputMode(OutputModeJSON)
+	out, err = execute(`{~prompty.var name="v" /~}|{~prompty.var name="v" escape="text" /~}|{~prompty.escape mode="html"~}{~prompty.var name="v" /~}{~/prompty.escape~}`)
+	require.NoError(t, err)
+	assert.Equal(t, `<\"x\">|<"x">|&lt;&#34;x&#34;&gt;`, out)
+
+	executor.RegisterEscaper("upper", func(s string) string { return "[" + s + "]" })
+	out, err = execute(`{~prompty.var name="v" escape="upper" /~}`)
+	require.NoError(t, err)
+	assert.Equal(t, `[<"x">]`, out)
+
+	_, err = execute(`{~prompty.var name="v" escape="yaml" /~}`)
+	assert.ErrorContains(t, err, ErrMsgEscapeUnknownMode)
+
+	_, err = execute(`{~prompty.escape mode="yaml"~}{~prompty.var name="v" /~}{~/prompty.escape~}`)
+	assert.ErrorContains(t, err, ErrMsgEscapeUnknownMode)
+}
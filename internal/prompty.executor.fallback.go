@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Variables the fallback template of onerror="include:name" receives
+const (
+	FallbackVarTag   = "tag"   // Name of the failed tag
+	FallbackVarError = "error" // Message of the tag's error
+)
+
+// Error fallback error messages
+const (
+	ErrMsgErrorFallbackFailed = "error fallback template failed"
+)
+
+// ErrorFallbackTemplate returns the fallback template named by an
+// onerror="include:name" attribute value.
+func ErrorFallbackTemplate(onError string) (string, bool) {
+	name, ok := strings.CutPrefix(onError, ErrorStrategyIncludePrefix)
+	return name, ok && name != ""
+}
+
+// TagErrorEvent describes a tag failure and the error strategy applied to it.
+type TagErrorEvent struct {
+	TagName  string
+	Pos      Position
+	Strategy ErrorStrategy
+	Fallback string // Fallback template of ErrorStrategyInclude
+	Err      error
+}
+
+// TagErrorHook is notified of every tag failure, including those its error
+// strategy recovers from.
+type TagErrorHook interface {
+	OnTagError(ctx context.Context, event TagErrorEvent)
+}
+
+// SetTagErrorHook sets the hook notified of tag failures. A nil hook removes
+// it. Set the hook before executing templates.
+func (e *Executor) SetTagErrorHook(hook TagErrorHook) {
+	e.tagErrorHook = hook
+}
+
+// executeFallback renders the fallback template of a failed tag in its
+// place, included like prompty.include with the tag name and error message
+// as its data. If the fallback fails too, both errors are returned.
+func (e *Executor) executeFallback(ctx context.Context, tag *TagNode, execCtx ContextAccessor, name string, err error) (string, error) {
+	attrs := Attributes{
+		AttrTemplate:     name,
+		FallbackVarTag:   tag.Name,
+		FallbackVarError: err.Error(),
+	}
+	result, fallbackErr := NewIncludeResolver().Resolve(ctx, execCtx, attrs)
+	if fallbackErr != nil {
+		return "", NewExecutorErrorWithCause(ErrMsgErrorFallbackFailed, tag.Name, tag.Pos(), errors.Join(err, fallbackErr)).
+			WithMetadata(MetaKeyTemplateName, name)
+	}
+	return result, nil
+}
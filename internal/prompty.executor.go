@@ -3,7 +3,11 @@ package internal
 import (
 	"context"
 	"fmt"
+	"maps"
+	"reflect"
 	"strings"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -26,6 +30,17 @@ type Executor struct {
 	config   ExecutorConfig
 	logger   *zap.Logger
 	funcs    *FuncRegistry // Function registry for expression evaluation
+
+	resolveHook  ResolveHook  // Optional hook around resolver invocations
+	tagErrorHook TagErrorHook // Optional hook notified of tag failures
+	tagCache     TagCache     // Optional cache for tags with the cache attribute
+
+	clock  func() time.Time // Clock of now() and execution entropy
+	seed   uint64           // Random seed of every execution, if seeded
+	seeded bool
+
+	escapers   map[string]Escaper // Escapers of output modes
+	outputMode string             // Output mode outside prompty.escape regions
 }
 
 // NewExecutor creates a new executor with the given registry and configuration.
@@ -35,16 +50,48 @@ func NewExecutor(registry *Registry, config ExecutorConfig, logger *zap.Logger)
 	}
 	logger.Debug(LogMsgExecutorCreated)
 
-	// Create function registry with built-in functions
-	funcs := NewFuncRegistry()
-	RegisterBuiltinFuncs(funcs)
-
 	return &Executor{
 		registry: registry,
 		config:   config,
 		logger:   logger,
-		funcs:    funcs,
+		funcs:    builtinFuncs().clone(), // Function registry with built-in functions
+		clock:    time.Now,
+		escapers: builtinEscapers(),
+	}
+}
+
+// Clone returns a copy of e that resolves tags with registry. Functions and
+// escapers registered with the copy, and settings changed on it, do not
+// affect e.
+func (e *Executor) Clone(registry *Registry) *Executor {
+	clone := *e
+	clone.registry = registry
+	clone.funcs = e.funcs.clone()
+	clone.escapers = maps.Clone(e.escapers)
+	return &clone
+}
+
+// Snapshot returns a copy of e that resolves tags with registry, usually a
+// snapshot of e's, and whose functions are a read-only snapshot of e's
+// current ones.
+func (e *Executor) Snapshot(registry *Registry) *Executor {
+	snapshot := e.Clone(registry)
+	snapshot.funcs = e.funcs.Snapshot()
+	return snapshot
+}
+
+// SetConfig replaces the executor configuration. Set it before executing
+// templates.
+func (e *Executor) SetConfig(config ExecutorConfig) {
+	e.config = config
+}
+
+// SetLogger replaces the executor's logger; nil disables logging.
+func (e *Executor) SetLogger(logger *zap.Logger) {
+	if logger == nil {
+		logger = zap.NewNop()
 	}
+	e.logger = logger
 }
 
 // RegisterFunc registers a custom function for use in expressions.
@@ -76,6 +123,11 @@ func (e *Executor) FuncCount() int {
 func (e *Executor) Execute(ctx context.Context, root *RootNode, execCtx ContextAccessor) (string, error) {
 	e.logger.Debug(LogMsgExecutorStart)
 
+	// Resolvers that evaluate expressions use the executor's functions
+	ctx = context.WithValue(ctx, funcsKey{}, e.funcs)
+	ctx = e.withEntropy(ctx)
+	ctx = e.withOutputMode(ctx)
+
 	result, err := e.executeNodes(ctx, root.Children, execCtx, 0)
 	if err != nil {
 		return "", err
@@ -85,6 +137,25 @@ func (e *Executor) Execute(ctx context.Context, root *RootNode, execCtx ContextA
 	return result, nil
 }
 
+// funcsKey is the context key for the executing Executor's FuncRegistry.
+type funcsKey struct{}
+
+// builtinFuncs is the registry used when no executor is running.
+var builtinFuncs = sync.OnceValue(func() *FuncRegistry {
+	funcs := NewFuncRegistry()
+	RegisterBuiltinFuncs(funcs)
+	return funcs
+})
+
+// funcsFrom returns the function registry of the executor running ctx, or
+// the built-in functions outside an execution.
+func funcsFrom(ctx context.Context) *FuncRegistry {
+	if funcs, ok := ctx.Value(funcsKey{}).(*FuncRegistry); ok {
+		return funcs
+	}
+	return builtinFuncs()
+}
+
 // executeNodes processes a slice of nodes and concatenates their output.
 func (e *Executor) executeNodes(ctx context.Context, nodes []Node, execCtx ContextAccessor, depth int) (string, error) {
 	// Check depth limit
@@ -105,8 +176,22 @@ func (e *Executor) executeNodes(ctx context.Context, nodes []Node, execCtx Conte
 	return sb.String(), nil
 }
 
-// executeNode processes a single node and returns its output.
+// executeNode processes a single node and returns its output, recording a
+// span when the context carries an ExecTrace.
 func (e *Executor) executeNode(ctx context.Context, node Node, execCtx ContextAccessor, depth int) (string, error) {
+	trace := execTraceFrom(ctx)
+	if trace == nil {
+		return e.dispatchNode(ctx, node, execCtx, depth)
+	}
+
+	span := trace.begin(node)
+	output, err := e.dispatchNode(ctx, node, execCtx, depth)
+	trace.end(span, err)
+	return output, err
+}
+
+// dispatchNode executes a node according to its type.
+func (e *Executor) dispatchNode(ctx context.Context, node Node, execCtx ContextAccessor, depth int) (string, error) {
 	switch n := node.(type) {
 	case *TextNode:
 		return n.Content, nil
@@ -201,10 +286,22 @@ func (e *Executor) executeFor(ctx context.Context, forNode *ForNode, execCtx Con
 		items = items[:limit]
 	}
 
+	output, err := e.executeIterations(ctx, TagNameFor, forNode.Pos(), items, forNode.ItemVar, forNode.IndexVar, forNode.Children, execCtx, depth)
+	if err != nil {
+		return "", err
+	}
+
+	e.logger.Debug(LogMsgForEnd, zap.Int(LogFieldIteration, len(items)))
+	return output, nil
+}
+
+// executeIterations executes the children of a loop tag once per item, with
+// the item (and its index, if indexVar is set) in a child scope.
+func (e *Executor) executeIterations(ctx context.Context, tagName string, pos Position, items []any, itemVar, indexVar string, children []Node, execCtx ContextAccessor, depth int) (string, error) {
 	// Check if context supports child creation
 	childCreator, canCreateChild := execCtx.(ChildContextCreator)
 	if !canCreateChild {
-		return "", NewExecutorError(ErrMsgForContextNoChild, TagNameFor, forNode.Pos())
+		return "", NewExecutorError(ErrMsgForContextNoChild, tagName, pos)
 	}
 
 	// Iterate and execute children for each item
@@ -215,27 +312,24 @@ func (e *Executor) executeFor(ctx context.Context, forNode *ForNode, execCtx Con
 
 		// Build child context data with loop variables
 		childData := make(map[string]any)
-		childData[forNode.ItemVar] = item
-		if forNode.IndexVar != "" {
-			childData[forNode.IndexVar] = i
+		childData[itemVar] = item
+		if indexVar != "" {
+			childData[indexVar] = i
 		}
 
 		// Create child context
-		childCtxInterface := childCreator.Child(childData)
-		childCtx, ok := childCtxInterface.(ContextAccessor)
+		childCtx, ok := childCreator.Child(childData).(ContextAccessor)
 		if !ok {
-			return "", NewExecutorError(ErrMsgForContextNoChild, TagNameFor, forNode.Pos())
+			return "", NewExecutorError(ErrMsgForContextNoChild, tagName, pos)
 		}
 
 		// Execute children with child context
-		result, err := e.executeNodes(ctx, forNode.Children, childCtx, depth+1)
+		result, err := e.executeNodes(ctx, children, childCtx, depth+1)
 		if err != nil {
 			return "", err
 		}
 		sb.WriteString(result)
 	}
-
-	e.logger.Debug(LogMsgForEnd, zap.Int(LogFieldIteration, len(items)))
 	return sb.String(), nil
 }
 
@@ -254,7 +348,7 @@ func (e *Executor) executeSwitch(ctx context.Context, switchNode *SwitchNode, ex
 	switchValueStr := toSwitchString(switchValue)
 
 	// Try each case in order
-	for _, caseNode := range switchNode.Cases {
+	for i, caseNode := range switchNode.Cases {
 		e.logger.Debug(LogMsgSwitchCase,
 			zap.String(LogFieldCaseValue, caseNode.Value),
 			zap.String(LogFieldCaseEval, caseNode.Eval))
@@ -277,7 +371,7 @@ func (e *Executor) executeSwitch(ctx context.Context, switchNode *SwitchNode, ex
 			e.logger.Debug(LogMsgCaseMatch,
 				zap.String(LogFieldCaseValue, caseNode.Value),
 				zap.String(LogFieldCaseEval, caseNode.Eval))
-			return e.executeNodes(ctx, caseNode.Children, execCtx, depth+1)
+			return e.executeSwitchFrom(ctx, switchNode, i, execCtx, depth)
 		}
 	}
 
@@ -293,6 +387,36 @@ func (e *Executor) executeSwitch(ctx context.Context, switchNode *SwitchNode, ex
 	return "", nil
 }
 
+// executeSwitchFrom renders the case at index start and, while cases are marked
+// fallthrough, each following case body (ending with the default case, if any).
+func (e *Executor) executeSwitchFrom(ctx context.Context, switchNode *SwitchNode, start int, execCtx ContextAccessor, depth int) (string, error) {
+	var sb strings.Builder
+	for i := start; i < len(switchNode.Cases); i++ {
+		caseNode := switchNode.Cases[i]
+		result, err := e.executeNodes(ctx, caseNode.Children, execCtx, depth+1)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(result)
+		if !caseNode.Fallthrough {
+			return sb.String(), nil
+		}
+		e.logger.Debug(LogMsgCaseFallthrough,
+			zap.String(LogFieldCaseValue, caseNode.Value),
+			zap.String(LogFieldCaseEval, caseNode.Eval))
+	}
+
+	// The last case fell through - continue into the default case
+	if switchNode.Default != nil {
+		result, err := e.executeNodes(ctx, switchNode.Default.Children, execCtx, depth+1)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(result)
+	}
+	return sb.String(), nil
+}
+
 // toSwitchString converts a value to its string representation for switch comparison.
 func toSwitchString(val any) string {
 	if val == nil {
@@ -379,8 +503,49 @@ func toIterableSlice(val any) ([]any, error) {
 		}
 		return result, nil
 	default:
-		return nil, NewTypeNotIterableError(fmt.Sprintf("%T", val))
+		return reflectIterableSlice(val)
+	}
+}
+
+// reflectIterableSlice converts any other slice, array, or string-keyed map
+// (e.g. []User or map[string]Plan), or a pointer to one, into a slice of
+// items for iteration. Maps yield key/value pairs like map[string]any.
+func reflectIterableSlice(val any) ([]any, error) {
+	rv := reflect.ValueOf(val)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		result := make([]any, rv.Len())
+		for i := range result {
+			result[i] = rv.Index(i).Interface()
+		}
+		return result, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		keys := make([]string, 0, rv.Len())
+		values := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			keys = append(keys, k)
+			values[k] = iter.Value().Interface()
+		}
+		sortStrings(keys)
+		result := make([]any, len(keys))
+		for i, k := range keys {
+			result[i] = map[string]any{
+				ForMapKeyField:   k,
+				ForMapValueField: values[k],
+			}
+		}
+		return result, nil
 	}
+	return nil, NewTypeNotIterableError(fmt.Sprintf("%T", val))
 }
 
 // sortStrings sorts a slice of strings in place (simple insertion sort for small slices).
@@ -404,18 +569,46 @@ func (e *Executor) executeTag(ctx context.Context, tag *TagNode, execCtx Context
 	// Look up resolver
 	resolver, ok := e.registry.Get(tag.Name)
 	if !ok {
-		return e.handleTagError(tag, execCtx, NewExecutorError(ErrMsgUnknownTag, tag.Name, tag.Pos()))
+		err := NewExecutorError(ErrMsgUnknownTag, tag.Name, tag.Pos())
+		setTraceError(ctx, err)
+		return e.handleTagError(ctx, tag, execCtx, err)
+	}
+
+	// Block tags get their own scope, so variables the resolver sets are
+	// visible to its children but not after the tag
+	isBlock := !tag.SelfClose && len(tag.Children) > 0
+	scopeCtx := execCtx
+	if isBlock {
+		if creator, ok := execCtx.(ChildContextCreator); ok {
+			if child, ok := creator.Child(nil).(ContextAccessor); ok {
+				scopeCtx = child
+			}
+		}
 	}
 
 	// Execute resolver
-	result, err := resolver.Resolve(ctx, execCtx, tag.Attributes)
+	result, err := e.resolveCached(ctx, resolver, tag, scopeCtx)
 	if err != nil {
-		return e.handleTagError(tag, execCtx, NewExecutorErrorWithCause(ErrMsgResolverFailed, tag.Name, tag.Pos(), err))
+		err = NewExecutorErrorWithCause(ErrMsgResolverFailed, tag.Name, tag.Pos(), err)
+		setTraceError(ctx, err)
+		return e.handleTagError(ctx, tag, execCtx, err)
 	}
 
 	// For block tags with children, process children
-	if !tag.SelfClose && len(tag.Children) > 0 {
-		childResult, err := e.executeNodes(ctx, tag.Children, execCtx, depth+1)
+	if isBlock {
+		switch tag.Name {
+		case TagNameShuffle:
+			return e.executeShuffle(ctx, tag, scopeCtx, depth)
+		case TagNameEscape:
+			return e.executeEscape(ctx, tag, scopeCtx, depth)
+		}
+		// Message bodies collect their image and file parts
+		childCtx := ctx
+		var parts *ContentParts
+		if tag.Name == TagNameMessage {
+			childCtx, parts = WithContentParts(ctx)
+		}
+		childResult, err := e.executeNodes(childCtx, tag.Children, scopeCtx, depth+1)
 		if err != nil {
 			return "", err
 		}
@@ -423,19 +616,27 @@ func (e *Executor) executeTag(ctx context.Context, tag *TagNode, execCtx Context
 		// For message tags, sanitize content and add the end marker after children
 		if tag.Name == TagNameMessage {
 			// Sanitize content to prevent marker injection attacks
-			// Strip null bytes which are used as marker delimiters
-			childResult = strings.ReplaceAll(childResult, CharNullByte, "")
-			return result + childResult + MessageEndMarker, nil
+			// Strip null bytes which are used as marker delimiters, keeping
+			// only the markers of the parts collected above
+			return result + parts.Encode(childResult) + MessageEndMarker, nil
 		}
 		return result + childResult, nil
 	}
 
+	// Interpolated values are escaped for the surrounding output format
+	result, err = e.escapeValue(ctx, tag, result)
+	if err != nil {
+		err = NewExecutorErrorWithCause(ErrMsgResolverFailed, tag.Name, tag.Pos(), err)
+		setTraceError(ctx, err)
+		return e.handleTagError(ctx, tag, execCtx, err)
+	}
+
 	e.logger.Debug(LogMsgResolverComplete, zap.String(LogFieldTag, tag.Name))
 	return result, nil
 }
 
 // handleTagError applies the appropriate error strategy for a tag execution failure.
-func (e *Executor) handleTagError(tag *TagNode, execCtx ContextAccessor, err error) (string, error) {
+func (e *Executor) handleTagError(ctx context.Context, tag *TagNode, execCtx ContextAccessor, err error) (string, error) {
 	// Determine the error strategy to use
 	strategy := e.getErrorStrategy(tag, execCtx)
 
@@ -444,11 +645,45 @@ func (e *Executor) handleTagError(tag *TagNode, execCtx ContextAccessor, err err
 		zap.String(LogFieldStrategy, ErrorStrategy(strategy).String()),
 		zap.String(LogFieldErrorMsg, err.Error()))
 
+	fallback, hasFallback := ErrorFallbackTemplate(tag.Attributes.GetDefault(AttrOnError, ""))
+	if e.tagErrorHook != nil {
+		e.tagErrorHook.OnTagError(ctx, TagErrorEvent{
+			TagName:  tag.Name,
+			Pos:      tag.Pos(),
+			Strategy: ErrorStrategy(strategy),
+			Fallback: fallback,
+			Err:      err,
+		})
+	}
+
 	switch ErrorStrategy(strategy) {
 	case ErrorStrategyThrow:
 		// Default behavior - propagate the error
 		return "", err
 
+	case ErrorStrategyCollect:
+		// Record the error and continue as with the default strategy
+		if collector := ErrorCollectorFrom(ctx); collector != nil {
+			collector.add(tag, err)
+		} else {
+			e.logger.Warn(LogMsgErrorNotCollected,
+				zap.String(LogFieldTag, tag.Name),
+				zap.Error(err))
+		}
+		if defaultVal, hasDefault := tag.Attributes.Get(AttrDefault); hasDefault {
+			return defaultVal, nil
+		}
+		return "", nil
+
+	case ErrorStrategyInclude:
+		// Render the fallback template in place of the tag
+		if hasFallback {
+			return e.executeFallback(ctx, tag, execCtx, fallback, err)
+		}
+		// Without a template name, fall back to the default strategy
+		e.logger.Warn(LogMsgErrorNoFallback, zap.String(LogFieldTag, tag.Name))
+		fallthrough
+
 	case ErrorStrategyDefault:
 		// Use the default attribute value if available
 		if defaultVal, hasDefault := tag.Attributes.Get(AttrDefault); hasDefault {
@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"context"
+)
+
+// ResolveHook is notified around every resolver invocation, so callers can
+// record tag usage or post-process a tag's output without wrapping resolvers.
+type ResolveHook interface {
+	// BeforeResolve runs before the resolver. An error fails the tag, which
+	// is then handled by the tag's error strategy.
+	BeforeResolve(ctx context.Context, tagName string, attrs Attributes) error
+
+	// AfterResolve runs after the resolver with its result and error, and
+	// returns the result to use.
+	AfterResolve(ctx context.Context, tagName string, attrs Attributes, result string, err error) string
+}
+
+// SetResolveHook sets the hook notified around resolver invocations. A nil
+// hook removes it. Set the hook before executing templates.
+func (e *Executor) SetResolveHook(hook ResolveHook) {
+	e.resolveHook = hook
+}
+
+// resolve invokes the resolver of tag, notifying the resolve hook.
+func (e *Executor) resolve(ctx context.Context, resolver InternalResolver, tag *TagNode, execCtx ContextAccessor) (string, error) {
+	if e.resolveHook == nil {
+		return resolver.Resolve(ctx, execCtx, tag.Attributes)
+	}
+	if err := e.resolveHook.BeforeResolve(ctx, tag.Name, tag.Attributes); err != nil {
+		return "", err
+	}
+	result, err := resolver.Resolve(ctx, execCtx, tag.Attributes)
+	return e.resolveHook.AfterResolve(ctx, tag.Name, tag.Attributes, result, err), err
+}
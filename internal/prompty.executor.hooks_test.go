@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingResolveHook records resolver invocations and wraps their output.
+type recordingResolveHook struct {
+	calls  []string
+	reject string
+}
+
+func (h *recordingResolveHook) BeforeResolve(ctx context.Context, tagName string, attrs Attributes) error {
+	name, _ := attrs.Get(AttrName)
+	h.calls = append(h.calls, tagName+":"+name)
+	if name == h.reject {
+		return errors.New("rejected")
+	}
+	return nil
+}
+
+func (h *recordingResolveHook) AfterResolve(ctx context.Context, tagName string, attrs Attributes, result string, err error) string {
+	return "<" + result + ">"
+}
+
+func TestExecutor_ResolveHook(t *testing.T) {
+	registry := NewRegistry(nil)
+	RegisterBuiltins(registry)
+	executor := NewExecutor(registry, DefaultExecutorConfig(), nil)
+	hook := &recordingResolveHook{reject: "secret"}
+	executor.SetResolveHook(hook)
+
+	root := parseForImports(t, `{~prompty.var name="a" /~}-{~prompty.var name="secret" onerror="remove" /~}`)
+	out, err := executor.Execute(context.Background(), root, newMockContextAccessor(map[string]any{"a": "x", "secret": "s"}))
+	require.NoError(t, err)
+	assert.Equal(t, "<x>-", out)
+	assert.Equal(t, []string{"prompty.var:a", "prompty.var:secret"}, hook.calls)
+
+	executor.SetResolveHook(nil)
+	out, err = executor.Execute(context.Background(), root, newMockContextAccessor(map[string]any{"a": "x"}))
+	require.NoError(t, err)
+	assert.Equal(t, "x-", out)
+}
@@ -0,0 +1,278 @@
+package internal
+
+import (
+	"strings"
+)
+
+// BlockImportResolver expands {~prompty.use~} tags with the blocks of
+// templates imported by {~prompty.import~}. Like inheritance, imports are
+// resolved on the AST before execution, so used blocks render in the
+// caller's context.
+type BlockImportResolver struct {
+	sources     TemplateSourceResolver
+	maxDepth    int
+	lexerConfig LexerConfig             // Delimiters used to parse imported templates
+	libraries   map[string]blockLibrary // Imported templates by name, parsed once per resolver
+	importChain []string                // Track imported templates to detect circular imports
+}
+
+// blockLibrary holds the blocks defined by an imported template.
+type blockLibrary map[string]*BlockNode
+
+// NewBlockImportResolver creates a resolver that loads imported templates
+// from sources.
+func NewBlockImportResolver(sources TemplateSourceResolver, maxDepth int) *BlockImportResolver {
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxInheritanceDepth
+	}
+	return &BlockImportResolver{
+		sources:     sources,
+		maxDepth:    maxDepth,
+		lexerConfig: DefaultLexerConfig(),
+		libraries:   make(map[string]blockLibrary),
+	}
+}
+
+// WithLexerConfig sets the delimiters used to parse imported templates and
+// returns the resolver for chaining.
+func (r *BlockImportResolver) WithLexerConfig(config LexerConfig) *BlockImportResolver {
+	r.lexerConfig = config
+	return r
+}
+
+// HasBlockImports reports whether root has top-level import tags.
+func HasBlockImports(root *RootNode) bool {
+	if root == nil {
+		return false
+	}
+	for _, node := range root.Children {
+		if tag, ok := node.(*TagNode); ok && tag.Name == TagNameImport {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns a copy of root with its top-level import tags removed and
+// every use tag replaced by the imported block. The original AST is not
+// modified, since it may be shared (see ASTCache).
+func (r *BlockImportResolver) Resolve(root *RootNode) (*RootNode, error) {
+	imports := make(map[string]blockLibrary)
+	children := make([]Node, 0, len(root.Children))
+
+	for _, node := range root.Children {
+		tag, ok := node.(*TagNode)
+		if !ok || tag.Name != TagNameImport {
+			children = append(children, node)
+			continue
+		}
+
+		name, alias, err := importAttrs(tag.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := imports[alias]; exists {
+			return nil, NewBuiltinError(ErrMsgImportDuplicateAlias, TagNameImport).
+				WithMetadata(MetaKeyAlias, alias)
+		}
+		library, err := r.load(name)
+		if err != nil {
+			return nil, err
+		}
+		imports[alias] = library
+	}
+
+	expanded, err := r.expandNodes(children, imports)
+	if err != nil {
+		return nil, err
+	}
+	return &RootNode{Children: expanded}, nil
+}
+
+// load parses an imported template, resolving its own imports, and returns
+// its blocks.
+func (r *BlockImportResolver) load(name string) (blockLibrary, error) {
+	if library, ok := r.libraries[name]; ok {
+		return library, nil
+	}
+
+	for _, imported := range r.importChain {
+		if imported == name {
+			return nil, NewBuiltinError(ErrMsgCircularImport, TagNameImport).
+				WithSentinel(ErrCycleDetected).
+				WithMetadata(MetaKeyTemplateName, name)
+		}
+	}
+	if len(r.importChain) >= r.maxDepth {
+		return nil, NewBuiltinError(ErrMsgImportDepthExceeded, TagNameImport)
+	}
+	r.importChain = append(r.importChain, name)
+	defer func() {
+		r.importChain = r.importChain[:len(r.importChain)-1]
+	}()
+
+	if r.sources == nil {
+		return nil, NewBuiltinError(ErrMsgEngineNotAvailable, TagNameImport)
+	}
+	source, exists := r.sources.GetTemplateSource(name)
+	if !exists {
+		return nil, NewBuiltinError(ErrMsgTemplateNotFound, TagNameImport).
+			WithSentinel(ErrTemplateNotFound).
+			WithMetadata(MetaKeyTemplateName, name)
+	}
+
+	lexer := NewLexerWithConfig(source, r.lexerConfig, nil)
+	tokens, err := lexer.Tokenize()
+	if err != nil {
+		return nil, err
+	}
+	root, err := NewParserWithConfig(tokens, source, r.lexerConfig, nil).Parse()
+	if err != nil {
+		return nil, err
+	}
+	if HasBlockImports(root) {
+		if root, err = r.Resolve(root); err != nil {
+			return nil, err
+		}
+	}
+
+	library := blockLibrary(CollectBlocks(root))
+	r.libraries[name] = library
+	return library, nil
+}
+
+// expandNodes replaces use tags with the imported blocks. Nodes on the way to
+// a replacement are copied rather than modified.
+func (r *BlockImportResolver) expandNodes(nodes []Node, imports map[string]blockLibrary) ([]Node, error) {
+	result := make([]Node, 0, len(nodes))
+
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *TagNode:
+			if n.Name == TagNameUse {
+				block, err := useBlock(n, imports)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, block)
+				continue
+			}
+			if n.Children == nil {
+				result = append(result, n)
+				continue
+			}
+			children, err := r.expandNodes(n.Children, imports)
+			if err != nil {
+				return nil, err
+			}
+			cp := *n
+			cp.Children = children
+			result = append(result, &cp)
+
+		case *BlockNode:
+			children, err := r.expandNodes(n.Children, imports)
+			if err != nil {
+				return nil, err
+			}
+			cp := *n
+			cp.Children = children
+			result = append(result, &cp)
+
+		case *ConditionalNode:
+			cp := *n
+			cp.Branches = make([]ConditionalBranch, len(n.Branches))
+			for i, branch := range n.Branches {
+				children, err := r.expandNodes(branch.Children, imports)
+				if err != nil {
+					return nil, err
+				}
+				branch.Children = children
+				cp.Branches[i] = branch
+			}
+			result = append(result, &cp)
+
+		case *ForNode:
+			children, err := r.expandNodes(n.Children, imports)
+			if err != nil {
+				return nil, err
+			}
+			cp := *n
+			cp.Children = children
+			result = append(result, &cp)
+
+		case *SwitchNode:
+			cp := *n
+			cp.Cases = make([]SwitchCase, len(n.Cases))
+			for i, c := range n.Cases {
+				children, err := r.expandNodes(c.Children, imports)
+				if err != nil {
+					return nil, err
+				}
+				c.Children = children
+				cp.Cases[i] = c
+			}
+			result = append(result, &cp)
+
+		default:
+			result = append(result, node)
+		}
+	}
+
+	return result, nil
+}
+
+// useBlock returns the imported block a use tag refers to, positioned at the
+// tag and named by its qualified name.
+func useBlock(tag *TagNode, imports map[string]blockLibrary) (*BlockNode, error) {
+	alias, name, err := useAttrs(tag.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	library, ok := imports[alias]
+	if !ok {
+		return nil, NewBuiltinError(ErrMsgUseUnknownAlias, TagNameUse).
+			WithMetadata(MetaKeyAlias, alias)
+	}
+	block, ok := library[name]
+	if !ok {
+		return nil, NewBuiltinError(ErrMsgUseBlockNotFound, TagNameUse).
+			WithMetadata(MetaKeyBlock, alias+BlockPathSeparator+name)
+	}
+	return &BlockNode{
+		pos:       tag.Pos(),
+		Name:      alias + BlockPathSeparator + name,
+		Children:  block.Children,
+		RawSource: block.RawSource,
+	}, nil
+}
+
+// importAttrs returns the template name and alias of an import tag.
+func importAttrs(attrs Attributes) (name, alias string, err error) {
+	name, ok := attrs.Get(AttrTemplate)
+	if !ok {
+		return "", "", NewBuiltinError(ErrMsgImportMissingTemplate, TagNameImport)
+	}
+	alias, ok = attrs.Get(AttrAs)
+	if !ok {
+		return "", "", NewBuiltinError(ErrMsgImportMissingAlias, TagNameImport)
+	}
+	if alias == "" || strings.Contains(alias, BlockPathSeparator) {
+		return "", "", NewBuiltinError(ErrMsgImportInvalidAlias, TagNameImport).
+			WithMetadata(MetaKeyAlias, alias)
+	}
+	return name, alias, nil
+}
+
+// useAttrs splits the block attribute of a use tag into alias and block name.
+func useAttrs(attrs Attributes) (alias, name string, err error) {
+	qualified, ok := attrs.Get(AttrBlock)
+	if !ok {
+		return "", "", NewBuiltinError(ErrMsgUseMissingBlock, TagNameUse)
+	}
+	alias, name, ok = strings.Cut(qualified, BlockPathSeparator)
+	if !ok || alias == "" || name == "" {
+		return "", "", NewBuiltinError(ErrMsgUseInvalidBlock, TagNameUse).
+			WithMetadata(MetaKeyBlock, qualified)
+	}
+	return alias, name, nil
+}
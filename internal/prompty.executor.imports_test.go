@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseForImports parses source with the default delimiters.
+func parseForImports(t *testing.T, source string) *RootNode {
+	t.Helper()
+	tokens, err := NewLexer(source, nil).Tokenize()
+	require.NoError(t, err)
+	root, err := NewParser(tokens, nil).Parse()
+	require.NoError(t, err)
+	return root
+}
+
+// executeImports resolves the imports of source and executes the result.
+func executeImports(t *testing.T, sources map[string]string, source string, data map[string]any) (string, error) {
+	t.Helper()
+	root := parseForImports(t, source)
+	resolved, err := NewBlockImportResolver(newMockTemplateSourceResolver(sources), 0).Resolve(root)
+	if err != nil {
+		return "", err
+	}
+	registry := NewRegistry(nil)
+	RegisterBuiltins(registry)
+	executor := NewExecutor(registry, DefaultExecutorConfig(), nil)
+	return executor.Execute(context.Background(), resolved, newMockContextAccessorWithChild(data))
+}
+
+func TestHasBlockImports(t *testing.T) {
+	assert.False(t, HasBlockImports(nil))
+	assert.False(t, HasBlockImports(parseForImports(t, `plain {~prompty.use block="a.b" /~}`)))
+	assert.True(t, HasBlockImports(parseForImports(t, `{~prompty.import template="lib" as="l" /~}x`)))
+}
+
+func TestBlockImportResolver_Resolve(t *testing.T) {
+	sources := map[string]string{
+		"legal": `{~prompty.block name="disclaimer"~}Not advice for {~prompty.var name="who" /~}.{~/prompty.block~}` +
+			`{~prompty.block name="signoff"~}Bye{~/prompty.block~}`,
+		"style": `{~prompty.block name="tone"~}Be kind.{~/prompty.block~}`,
+	}
+
+	t.Run("uses blocks of several libraries in the caller's context", func(t *testing.T) {
+		source := `{~prompty.import template="legal" as="lib" /~}{~prompty.import template="style" as="st" /~}` +
+			`{~prompty.use block="st.tone" /~} {~prompty.use block="lib.disclaimer" /~} {~prompty.use block="lib.signoff" /~}`
+		out, err := executeImports(t, sources, source, map[string]any{"who": "Ada"})
+		require.NoError(t, err)
+		assert.Equal(t, "Be kind. Not advice for Ada. Bye", out)
+	})
+
+	t.Run("uses inside control flow", func(t *testing.T) {
+		source := `{~prompty.import template="style" as="st" /~}` +
+			`{~prompty.if eval="show"~}{~prompty.for item="x" in="items"~}{~prompty.use block="st.tone" /~}{~/prompty.for~}{~/prompty.if~}`
+		out, err := executeImports(t, sources, source, map[string]any{"show": true, "items": []any{1, 2}})
+		require.NoError(t, err)
+		assert.Equal(t, "Be kind.Be kind.", out)
+	})
+
+	t.Run("does not modify the original AST", func(t *testing.T) {
+		root := parseForImports(t, `{~prompty.import template="style" as="st" /~}{~prompty.if eval="x"~}{~prompty.use block="st.tone" /~}{~/prompty.if~}`)
+		_, err := NewBlockImportResolver(newMockTemplateSourceResolver(sources), 0).Resolve(root)
+		require.NoError(t, err)
+
+		cond := root.Children[1].(*ConditionalNode)
+		tag, ok := cond.Branches[0].Children[0].(*TagNode)
+		require.True(t, ok)
+		assert.Equal(t, TagNameUse, tag.Name)
+	})
+
+	t.Run("libraries import other libraries", func(t *testing.T) {
+		nested := map[string]string{
+			"base":    `{~prompty.block name="hi"~}Hi{~/prompty.block~}`,
+			"wrapper": `{~prompty.import template="base" as="b" /~}{~prompty.block name="greet"~}{~prompty.use block="b.hi" /~}!{~/prompty.block~}`,
+		}
+		out, err := executeImports(t, nested, `{~prompty.import template="wrapper" as="w" /~}{~prompty.use block="w.greet" /~}`, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "Hi!", out)
+	})
+}
+
+func TestBlockImportResolver_Errors(t *testing.T) {
+	sources := map[string]string{
+		"legal":  `{~prompty.block name="disclaimer"~}x{~/prompty.block~}`,
+		"loop-a": `{~prompty.import template="loop-b" as="b" /~}`,
+		"loop-b": `{~prompty.import template="loop-a" as="a" /~}`,
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{name: "missing template", source: `{~prompty.import as="l" /~}`, want: ErrMsgImportMissingTemplate},
+		{name: "missing alias", source: `{~prompty.import template="legal" /~}`, want: ErrMsgImportMissingAlias},
+		{name: "dotted alias", source: `{~prompty.import template="legal" as="a.b" /~}`, want: ErrMsgImportInvalidAlias},
+		{name: "duplicate alias", source: `{~prompty.import template="legal" as="l" /~}{~prompty.import template="legal" as="l" /~}`, want: ErrMsgImportDuplicateAlias},
+		{name: "unknown template", source: `{~prompty.import template="nope" as="l" /~}`, want: ErrMsgTemplateNotFound},
+		{name: "circular import", source: `{~prompty.import template="loop-a" as="l" /~}`, want: ErrMsgCircularImport},
+		{name: "unknown alias", source: `{~prompty.import template="legal" as="l" /~}{~prompty.use block="x.disclaimer" /~}`, want: ErrMsgUseUnknownAlias},
+		{name: "unknown block", source: `{~prompty.import template="legal" as="l" /~}{~prompty.use block="l.nope" /~}`, want: ErrMsgUseBlockNotFound},
+		{name: "unqualified block", source: `{~prompty.import template="legal" as="l" /~}{~prompty.use block="disclaimer" /~}`, want: ErrMsgUseInvalidBlock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := executeImports(t, sources, tt.source, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestImportUseResolvers(t *testing.T) {
+	importResolver := NewImportResolver()
+	useResolver := NewUseResolver()
+
+	assert.Equal(t, TagNameImport, importResolver.TagName())
+	assert.Equal(t, TagNameUse, useResolver.TagName())
+
+	assert.NoError(t, importResolver.Validate(Attributes{AttrTemplate: "legal", AttrAs: "l"}))
+	assert.Error(t, importResolver.Validate(Attributes{AttrTemplate: "legal"}))
+	assert.NoError(t, useResolver.Validate(Attributes{AttrBlock: "l.disclaimer"}))
+	assert.Error(t, useResolver.Validate(Attributes{AttrBlock: "disclaimer"}))
+
+	// Reaching the resolvers means the tags were not expanded before execution
+	_, err := importResolver.Resolve(context.Background(), nil, Attributes{AttrTemplate: "legal", AttrAs: "l"})
+	assert.ErrorContains(t, err, ErrMsgImportNotTopLevel)
+	_, err = useResolver.Resolve(context.Background(), nil, Attributes{AttrBlock: "l.disclaimer"})
+	assert.ErrorContains(t, err, ErrMsgUseUnknownAlias)
+}
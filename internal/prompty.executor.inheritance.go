@@ -10,7 +10,8 @@ type InheritanceResolver struct {
 	engine           TemplateExecutor
 	maxDepth         int
 	templateResolver TemplateSourceResolver
-	inheritanceChain []string // Track templates to detect circular inheritance
+	lexerConfig      LexerConfig // Delimiters used to parse parent templates
+	inheritanceChain []string    // Track templates to detect circular inheritance
 }
 
 // TemplateSourceResolver provides access to raw template sources
@@ -27,10 +28,18 @@ func NewInheritanceResolver(engine TemplateExecutor, templateResolver TemplateSo
 		engine:           engine,
 		maxDepth:         maxDepth,
 		templateResolver: templateResolver,
+		lexerConfig:      DefaultLexerConfig(),
 		inheritanceChain: make([]string, 0),
 	}
 }
 
+// WithLexerConfig sets the delimiters used to parse parent templates and
+// returns the resolver for chaining.
+func (r *InheritanceResolver) WithLexerConfig(config LexerConfig) *InheritanceResolver {
+	r.lexerConfig = config
+	return r
+}
+
 // ResolveInheritance resolves template inheritance by merging child blocks into parent.
 // Returns the final AST with all inheritance resolved.
 func (r *InheritanceResolver) ResolveInheritance(
@@ -47,7 +56,8 @@ func (r *InheritanceResolver) ResolveInheritance(
 	parentName := childInfo.ParentTemplate
 	for _, ancestor := range r.inheritanceChain {
 		if ancestor == parentName {
-			return nil, NewBuiltinError(ErrMsgCircularInheritance, TagNameExtends)
+			return nil, NewBuiltinError(ErrMsgCircularInheritance, TagNameExtends).
+				WithSentinel(ErrCycleDetected)
 		}
 	}
 	r.inheritanceChain = append(r.inheritanceChain, parentName)
@@ -87,18 +97,28 @@ func (r *InheritanceResolver) ResolveInheritance(
 // parseTemplateWithInheritance parses a template and extracts inheritance info
 func (r *InheritanceResolver) parseTemplateWithInheritance(source string) (*RootNode, *InheritanceInfo, error) {
 	// Create a lexer and parser for the parent template
-	lexer := NewLexer(source, nil)
+	lexer := NewLexerWithConfig(source, r.lexerConfig, nil)
 	tokens, err := lexer.Tokenize()
 	if err != nil {
 		return nil, nil, err
 	}
 
-	parser := NewParserWithSource(tokens, source, nil)
+	parser := NewParserWithConfig(tokens, source, r.lexerConfig, nil)
 	root, err := parser.Parse()
 	if err != nil {
 		return nil, nil, err
 	}
 
+	// Expand blocks the parent uses from its own imports
+	if HasBlockImports(root) {
+		root, err = NewBlockImportResolver(r.templateResolver, r.maxDepth).
+			WithLexerConfig(r.lexerConfig).
+			Resolve(root)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// Extract inheritance info
 	info, err := ExtractInheritanceInfo(root)
 	if err != nil {
@@ -183,7 +203,9 @@ func (r *InheritanceResolver) resolveParentCalls(childBlock, parentBlock *BlockN
 	}
 }
 
-// resolveParentCallsInNodes replaces prompty.parent tags with parent content
+// resolveParentCallsInNodes replaces prompty.parent tags with parent content.
+// Nodes on the way to a replacement are copied rather than modified, since
+// the child AST may be shared by other templates (see ASTCache).
 func (r *InheritanceResolver) resolveParentCallsInNodes(nodes []Node, parentContent []Node) []Node {
 	result := make([]Node, 0, len(nodes))
 
@@ -193,34 +215,43 @@ func (r *InheritanceResolver) resolveParentCallsInNodes(nodes []Node, parentCont
 			if n.Name == TagNameParent {
 				// Replace with parent content
 				result = append(result, parentContent...)
-			} else {
+			} else if n.Children != nil {
 				// Recursively process children
-				if n.Children != nil {
-					n.Children = r.resolveParentCallsInNodes(n.Children, parentContent)
-				}
+				cp := *n
+				cp.Children = r.resolveParentCallsInNodes(n.Children, parentContent)
+				result = append(result, &cp)
+			} else {
 				result = append(result, n)
 			}
 
 		case *BlockNode:
 			// Recursively process nested blocks
-			n.Children = r.resolveParentCallsInNodes(n.Children, parentContent)
-			result = append(result, n)
+			cp := *n
+			cp.Children = r.resolveParentCallsInNodes(n.Children, parentContent)
+			result = append(result, &cp)
 
 		case *ConditionalNode:
-			for i := range n.Branches {
-				n.Branches[i].Children = r.resolveParentCallsInNodes(n.Branches[i].Children, parentContent)
+			cp := *n
+			cp.Branches = make([]ConditionalBranch, len(n.Branches))
+			for i, branch := range n.Branches {
+				branch.Children = r.resolveParentCallsInNodes(branch.Children, parentContent)
+				cp.Branches[i] = branch
 			}
-			result = append(result, n)
+			result = append(result, &cp)
 
 		case *ForNode:
-			n.Children = r.resolveParentCallsInNodes(n.Children, parentContent)
-			result = append(result, n)
+			cp := *n
+			cp.Children = r.resolveParentCallsInNodes(n.Children, parentContent)
+			result = append(result, &cp)
 
 		case *SwitchNode:
-			for i := range n.Cases {
-				n.Cases[i].Children = r.resolveParentCallsInNodes(n.Cases[i].Children, parentContent)
+			cp := *n
+			cp.Cases = make([]SwitchCase, len(n.Cases))
+			for i, c := range n.Cases {
+				c.Children = r.resolveParentCallsInNodes(c.Children, parentContent)
+				cp.Cases[i] = c
 			}
-			result = append(result, n)
+			result = append(result, &cp)
 
 		default:
 			result = append(result, node)
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"context"
+	"time"
+)
+
+// ExecTrace records one span per executed node while attached to the
+// execution context with WithExecTrace. Spans of templates executed through
+// the same context (e.g. includes) nest below the tag that executed them.
+// An ExecTrace must not be shared by concurrent executions.
+type ExecTrace struct {
+	start time.Time
+	root  TraceSpan
+	stack []*TraceSpan
+}
+
+// TraceSpan is the execution record of a single node.
+type TraceSpan struct {
+	Node     Node
+	Start    time.Duration // Offset from the start of the trace
+	Duration time.Duration
+	Err      error  // Error returned by the node or handled by its error strategy
+	Cache    string // Cache status reported via SetTraceCacheStatus
+	Children []*TraceSpan
+}
+
+// execTraceKey is the context key for the active ExecTrace
+type execTraceKey struct{}
+
+// NewExecTrace creates an empty trace starting now.
+func NewExecTrace() *ExecTrace {
+	t := &ExecTrace{start: time.Now()}
+	t.stack = []*TraceSpan{&t.root}
+	return t
+}
+
+// WithExecTrace returns a context that records executed nodes into trace.
+func WithExecTrace(ctx context.Context, trace *ExecTrace) context.Context {
+	return context.WithValue(ctx, execTraceKey{}, trace)
+}
+
+// execTraceFrom returns the trace attached to ctx, or nil.
+func execTraceFrom(ctx context.Context) *ExecTrace {
+	trace, _ := ctx.Value(execTraceKey{}).(*ExecTrace)
+	return trace
+}
+
+// SetTraceCacheStatus records a cache status (e.g. "hit") on the node being
+// executed, if ctx carries a trace.
+func SetTraceCacheStatus(ctx context.Context, status string) {
+	if trace := execTraceFrom(ctx); trace != nil {
+		trace.current().Cache = status
+	}
+}
+
+// setTraceError records err on the node being executed, if ctx carries a
+// trace, so errors absorbed by an error strategy remain visible.
+func setTraceError(ctx context.Context, err error) {
+	if trace := execTraceFrom(ctx); trace != nil {
+		trace.current().Err = err
+	}
+}
+
+// Spans returns the top-level spans in execution order.
+func (t *ExecTrace) Spans() []*TraceSpan {
+	return t.root.Children
+}
+
+// begin opens a span for node as a child of the current span.
+func (t *ExecTrace) begin(node Node) *TraceSpan {
+	span := &TraceSpan{Node: node, Start: time.Since(t.start)}
+	parent := t.current()
+	parent.Children = append(parent.Children, span)
+	t.stack = append(t.stack, span)
+	return span
+}
+
+// end closes the current span. An error already recorded on the span (from
+// an error strategy) is kept when the node itself succeeded.
+func (t *ExecTrace) end(span *TraceSpan, err error) {
+	span.Duration = time.Since(t.start) - span.Start
+	if err != nil {
+		span.Err = err
+	}
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// current returns the innermost open span.
+func (t *ExecTrace) current() *TraceSpan {
+	return t.stack[len(t.stack)-1]
+}
@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecTrace_RecordsNestedSpans(t *testing.T) {
+	registry := NewRegistry(nil)
+	wrapper := newMockResolver("wrapper")
+	wrapper.resolveFunc = func(ctx context.Context, execCtx interface{}, attrs Attributes) (string, error) {
+		SetTraceCacheStatus(ctx, "hit")
+		return "[", nil
+	}
+	require.NoError(t, registry.Register(wrapper))
+	executor := NewExecutor(registry, DefaultExecutorConfig(), nil)
+
+	text := NewTextNode("a", Position{Line: 1, Column: 1})
+	inner := NewTextNode("b", Position{Line: 1, Column: 12})
+	block := NewBlockTag("wrapper", Attributes{}, []Node{inner}, Position{Line: 1, Column: 2})
+	root := &RootNode{Children: []Node{text, block}}
+
+	trace := NewExecTrace()
+	out, err := executor.Execute(WithExecTrace(context.Background(), trace), root, newMockContextAccessor(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "a[b", out)
+
+	spans := trace.Spans()
+	require.Len(t, spans, 2)
+	assert.Same(t, text, spans[0].Node)
+	assert.Empty(t, spans[0].Children)
+	assert.Empty(t, spans[0].Cache)
+
+	assert.Same(t, block, spans[1].Node)
+	assert.Equal(t, "hit", spans[1].Cache)
+	assert.GreaterOrEqual(t, spans[1].Start, spans[0].Start+spans[0].Duration)
+	require.Len(t, spans[1].Children, 1)
+	assert.Same(t, inner, spans[1].Children[0].Node)
+	assert.GreaterOrEqual(t, spans[1].Duration, spans[1].Children[0].Duration)
+}
+
+func TestExecTrace_RecordsErrors(t *testing.T) {
+	registry := NewRegistry(nil)
+	failing := newMockResolver("failing")
+	failing.resolveFunc = func(ctx context.Context, execCtx interface{}, attrs Attributes) (string, error) {
+		return "", errors.New("boom")
+	}
+	require.NoError(t, registry.Register(failing))
+	executor := NewExecutor(registry, DefaultExecutorConfig(), nil)
+
+	t.Run("returned error", func(t *testing.T) {
+		root := &RootNode{Children: []Node{
+			NewSelfClosingTag("failing", Attributes{}, Position{Line: 1, Column: 1}),
+		}}
+
+		trace := NewExecTrace()
+		_, err := executor.Execute(WithExecTrace(context.Background(), trace), root, newMockContextAccessor(nil))
+		require.Error(t, err)
+
+		require.Len(t, trace.Spans(), 1)
+		require.Error(t, trace.Spans()[0].Err)
+		assert.Contains(t, trace.Spans()[0].Err.Error(), "boom")
+	})
+
+	t.Run("error handled by strategy", func(t *testing.T) {
+		root := &RootNode{Children: []Node{
+			NewSelfClosingTag("failing", Attributes{AttrOnError: ErrorStrategyNameRemove}, Position{Line: 1, Column: 1}),
+		}}
+
+		trace := NewExecTrace()
+		out, err := executor.Execute(WithExecTrace(context.Background(), trace), root, newMockContextAccessor(nil))
+		require.NoError(t, err)
+		assert.Empty(t, out)
+
+		require.Len(t, trace.Spans(), 1)
+		require.Error(t, trace.Spans()[0].Err)
+		assert.Contains(t, trace.Spans()[0].Err.Error(), "boom")
+	})
+}
+
+func TestExecTrace_WithoutTrace(t *testing.T) {
+	// Recording helpers are no-ops when the context carries no trace
+	SetTraceCacheStatus(context.Background(), "hit")
+	setTraceError(context.Background(), errors.New("ignored"))
+	assert.Nil(t, execTraceFrom(context.Background()))
+}
@@ -1345,6 +1345,61 @@ func TestExecutor_ExecuteSwitch(t *testing.T) {
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), ErrMsgCondExprFailed)
 	})
+
+	t.Run("fallthrough continues into following cases", func(t *testing.T) {
+		registry := NewRegistry(nil)
+		RegisterBuiltins(registry)
+		executor := NewExecutor(registry, DefaultExecutorConfig(), nil)
+
+		ctx := newMockContextAccessor(map[string]any{
+			"level": "2",
+		})
+
+		level3 := NewSwitchCase("3", "", []Node{
+			NewTextNode("C", Position{Line: 1, Column: 1}),
+		}, false, Position{Line: 1, Column: 1})
+		level3.Fallthrough = true
+		level2 := NewSwitchCase("2", "", []Node{
+			NewTextNode("B", Position{Line: 2, Column: 1}),
+		}, false, Position{Line: 2, Column: 1})
+		level2.Fallthrough = true
+		level1 := NewSwitchCase("1", "", []Node{
+			NewTextNode("A", Position{Line: 3, Column: 1}),
+		}, false, Position{Line: 3, Column: 1})
+		level0 := NewSwitchCase("0", "", []Node{
+			NewTextNode("Z", Position{Line: 4, Column: 1}),
+		}, false, Position{Line: 4, Column: 1})
+
+		switchNode := NewSwitchNode("level", []SwitchCase{level3, level2, level1, level0}, nil, Position{Line: 1, Column: 1})
+
+		result, err := executor.executeSwitch(context.Background(), switchNode, ctx, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "BA", result)
+	})
+
+	t.Run("fallthrough from last case reaches default", func(t *testing.T) {
+		registry := NewRegistry(nil)
+		RegisterBuiltins(registry)
+		executor := NewExecutor(registry, DefaultExecutorConfig(), nil)
+
+		ctx := newMockContextAccessor(map[string]any{
+			"status": "active",
+		})
+
+		active := NewSwitchCase("active", "", []Node{
+			NewTextNode("Active;", Position{Line: 1, Column: 1}),
+		}, false, Position{Line: 1, Column: 1})
+		active.Fallthrough = true
+		defaultCase := NewSwitchCase("", "", []Node{
+			NewTextNode("Always", Position{Line: 2, Column: 1}),
+		}, true, Position{Line: 2, Column: 1})
+
+		switchNode := NewSwitchNode("status", []SwitchCase{active}, &defaultCase, Position{Line: 1, Column: 1})
+
+		result, err := executor.executeSwitch(context.Background(), switchNode, ctx, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "Active;Always", result)
+	})
 }
 
 // TestToIterableSlice tests the toIterableSlice helper function
@@ -1446,6 +1501,32 @@ func TestToIterableSlice(t *testing.T) {
 		_, err := toIterableSlice(input)
 		require.Error(t, err)
 	})
+
+	t.Run("slice of structs converts via reflection", func(t *testing.T) {
+		type item struct{ Name string }
+		input := []item{{Name: "a"}, {Name: "b"}}
+		result, err := toIterableSlice(&input)
+		require.NoError(t, err)
+		assert.Equal(t, []any{item{Name: "a"}, item{Name: "b"}}, result)
+
+		array, err := toIterableSlice([2]uint{3, 4})
+		require.NoError(t, err)
+		assert.Equal(t, []any{uint(3), uint(4)}, array)
+	})
+
+	t.Run("typed string-keyed map converts with sorted keys", func(t *testing.T) {
+		type plan struct{ Seats int }
+		result, err := toIterableSlice(map[string]plan{"pro": {Seats: 5}, "free": {Seats: 1}})
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, map[string]any{ForMapKeyField: "free", ForMapValueField: plan{Seats: 1}}, result[0])
+		assert.Equal(t, "pro", result[1].(map[string]any)[ForMapKeyField])
+	})
+
+	t.Run("map with non-string keys is not iterable", func(t *testing.T) {
+		_, err := toIterableSlice(map[int]string{1: "one"})
+		require.Error(t, err)
+	})
 }
 
 // TestToSwitchString tests the toSwitchString helper function
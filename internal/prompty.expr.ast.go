@@ -180,3 +180,23 @@ func NewBinary(left ExprNode, op ExprTokenType, right ExprNode) *BinaryNode {
 func NewCall(name string, args []ExprNode) *CallNode {
 	return &CallNode{Name: name, Args: args}
 }
+
+// WalkExpr calls visit for node and each of its descendants in depth-first,
+// left-to-right order. A nil node is ignored.
+func WalkExpr(node ExprNode, visit func(ExprNode)) {
+	if node == nil {
+		return
+	}
+	visit(node)
+	switch n := node.(type) {
+	case *UnaryNode:
+		WalkExpr(n.Right, visit)
+	case *BinaryNode:
+		WalkExpr(n.Left, visit)
+		WalkExpr(n.Right, visit)
+	case *CallNode:
+		for _, arg := range n.Args {
+			WalkExpr(arg, visit)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestExprNodeType_String(t *testing.T) {
@@ -209,3 +210,24 @@ func TestNewLiteralHelpers(t *testing.T) {
 		assert.Equal(t, LiteralKindNil, node.Kind)
 	})
 }
+
+func TestWalkExpr(t *testing.T) {
+	node, err := ParseExpression(`len(items) > 0 && !user.admin`)
+	require.NoError(t, err)
+
+	var visited []string
+	WalkExpr(node, func(n ExprNode) {
+		switch v := n.(type) {
+		case *IdentifierNode:
+			visited = append(visited, v.Name)
+		case *CallNode:
+			visited = append(visited, v.Name+"()")
+		}
+	})
+
+	assert.Equal(t, []string{"len()", "items", "user.admin"}, visited)
+
+	t.Run("nil node", func(t *testing.T) {
+		WalkExpr(nil, func(ExprNode) { t.Fatal("visit called for nil node") })
+	})
+}
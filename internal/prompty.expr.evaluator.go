@@ -78,10 +78,10 @@ func (e *ExprEvaluator) checkContext() error {
 	case <-e.evalCtx.Done():
 		err := e.evalCtx.Err()
 		if errors.Is(err, context.Canceled) {
-			return NewExprEvalError(ErrMsgExprCancelled, "")
+			return NewExprEvalError(ErrMsgExprCancelled, "").WithCause(err)
 		}
 		if errors.Is(err, context.DeadlineExceeded) {
-			return NewExprEvalError(ErrMsgExprTimeout, "")
+			return NewExprEvalError(ErrMsgExprTimeout, "").WithCause(err)
 		}
 		return NewExprEvalError(ErrMsgExprContextDone, err.Error())
 	default:
@@ -191,6 +191,8 @@ func (e *ExprEvaluator) evaluateBinary(node *BinaryNode) (any, error) {
 			return nil, err
 		}
 		return !result, nil
+	case ExprTokenTypeAdd:
+		return add(left, right), nil
 	default:
 		return nil, NewExprEvalError(ErrMsgExprUnknownOperator, string(node.Op))
 	}
@@ -291,6 +293,17 @@ func compareGreater(a, b any) (bool, error) {
 	return false, NewTypeComparisonError(fmt.Sprintf("%T", a), fmt.Sprintf("%T", b))
 }
 
+// add returns the sum of two numbers, or otherwise the concatenation of both
+// values as strings (nil concatenates as the empty string)
+func add(a, b any) any {
+	aNum, aIsNum := toNumber(a)
+	bNum, bIsNum := toNumber(b)
+	if aIsNum && bIsNum {
+		return aNum + bNum
+	}
+	return anyToString(a) + anyToString(b)
+}
+
 // toNumber attempts to convert a value to float64
 func toNumber(v any) (float64, bool) {
 	switch val := v.(type) {
@@ -312,6 +325,7 @@ type ExprEvalError struct {
 	Message  string
 	Detail   string
 	Metadata map[string]string
+	Cause    error // Context error of cancelled or timed out evaluations
 }
 
 // NewExprEvalError creates a new expression evaluation error
@@ -332,6 +346,22 @@ func (e *ExprEvalError) WithMetadata(key, value string) *ExprEvalError {
 	return e
 }
 
+// WithCause sets the underlying error and returns the error for chaining.
+func (e *ExprEvalError) WithCause(cause error) *ExprEvalError {
+	e.Cause = cause
+	return e
+}
+
+// Unwrap returns the underlying error.
+func (e *ExprEvalError) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether the evaluation timed out for ErrExecutionTimeout.
+func (e *ExprEvalError) Is(target error) bool {
+	return target == ErrExecutionTimeout && errors.Is(e.Cause, context.DeadlineExceeded)
+}
+
 // Error implements the error interface
 func (e *ExprEvalError) Error() string {
 	var result string
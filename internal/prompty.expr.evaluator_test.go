@@ -164,6 +164,38 @@ func TestExprEvaluator_Evaluate_Comparison(t *testing.T) {
 	}
 }
 
+func TestExprEvaluator_Evaluate_Addition(t *testing.T) {
+	funcs := NewFuncRegistry()
+	RegisterBuiltinFuncs(funcs)
+	ctx := newMockContextAccessor(map[string]any{
+		"x":    10,
+		"lang": "de",
+	})
+
+	tests := []struct {
+		name     string
+		input    string
+		expected any
+	}{
+		{"numbers", "x + 2.5", 12.5},
+		{"strings", `"intro-" + lang`, "intro-de"},
+		{"chained", `"a" + "b" + "c"`, "abc"},
+		{"string and number", `"v" + x`, "v10"},
+		{"missing variable", `"intro-" + missing`, "intro-"},
+		{"function result", `upper(lang) + "!"`, "DE!"},
+		{"before comparison", `"intro-" + lang == "intro-de"`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := EvaluateExpression(tt.input, funcs, ctx)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
 func TestExprEvaluator_Evaluate_LogicalAnd(t *testing.T) {
 	funcs := NewFuncRegistry()
 	RegisterBuiltinFuncs(funcs)
@@ -422,6 +454,8 @@ func TestEvaluateExpressionWithContext_Cancellation(t *testing.T) {
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), ErrMsgExprCancelled)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NotErrorIs(t, err, ErrExecutionTimeout)
 	})
 
 	t.Run("timed out context returns error", func(t *testing.T) {
@@ -433,6 +467,8 @@ func TestEvaluateExpressionWithContext_Cancellation(t *testing.T) {
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), ErrMsgExprTimeout)
+		assert.ErrorIs(t, err, ErrExecutionTimeout)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
 	})
 
 	t.Run("valid context works normally", func(t *testing.T) {
@@ -92,14 +92,14 @@ func (p *ExprParser) parseEquality() (ExprNode, error) {
 
 // parseComparison parses comparison expressions (<, >, <=, >=)
 func (p *ExprParser) parseComparison() (ExprNode, error) {
-	left, err := p.parseUnary()
+	left, err := p.parseAdditive()
 	if err != nil {
 		return nil, err
 	}
 
 	for p.matchAny(ExprTokenTypeLt, ExprTokenTypeGt, ExprTokenTypeLte, ExprTokenTypeGte) {
 		op := p.previous().Type
-		right, err := p.parseUnary()
+		right, err := p.parseAdditive()
 		if err != nil {
 			return nil, err
 		}
@@ -109,6 +109,24 @@ func (p *ExprParser) parseComparison() (ExprNode, error) {
 	return left, nil
 }
 
+// parseAdditive parses addition and string concatenation (+)
+func (p *ExprParser) parseAdditive() (ExprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(ExprTokenTypeAdd) {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = NewBinary(left, ExprTokenTypeAdd, right)
+	}
+
+	return left, nil
+}
+
 // parseUnary parses unary expressions (!)
 func (p *ExprParser) parseUnary() (ExprNode, error) {
 	if p.match(ExprTokenTypeNot) {
@@ -265,8 +265,26 @@ func TestExprParser_Parse_ComplexExpression(t *testing.T) {
 	assert.Equal(t, ExprTokenTypeOr, right.Op)
 }
 
+func TestExprParser_Parse_AdditionPrecedence(t *testing.T) {
+	// + binds tighter than comparison and is left-associative
+	node, err := ParseExpression(`"a" + b + c == "abc"`)
+	require.NoError(t, err)
+
+	eq, ok := node.(*BinaryNode)
+	require.True(t, ok)
+	assert.Equal(t, ExprTokenTypeEq, eq.Op)
+
+	sum, ok := eq.Left.(*BinaryNode)
+	require.True(t, ok)
+	assert.Equal(t, ExprTokenTypeAdd, sum.Op)
+
+	inner, ok := sum.Left.(*BinaryNode)
+	require.True(t, ok)
+	assert.Equal(t, ExprTokenTypeAdd, inner.Op)
+}
+
 func TestExprParser_Parse_Error_UnexpectedToken(t *testing.T) {
-	_, err := ParseExpression("1 +")
+	_, err := ParseExpression("1 *")
 
 	require.Error(t, err)
 	// The error could be about unexpected character or unexpected token
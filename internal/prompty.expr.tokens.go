@@ -30,6 +30,7 @@ const (
 	ExprTokenTypeGt  ExprTokenType = "GT"
 	ExprTokenTypeLte ExprTokenType = "LTE"
 	ExprTokenTypeGte ExprTokenType = "GTE"
+	ExprTokenTypeAdd ExprTokenType = "ADD"
 
 	ExprTokenTypeEOF ExprTokenType = "EOF"
 )
@@ -45,6 +46,7 @@ const (
 	ExprOpGt  = ">"
 	ExprOpLte = "<="
 	ExprOpGte = ">="
+	ExprOpAdd = "+"
 )
 
 // Expression keyword constants
@@ -168,6 +170,8 @@ func (t *ExprTokenizer) nextToken() (ExprToken, error) {
 		return ExprToken{Type: ExprTokenTypeLt, Value: ExprOpLt, Pos: startPos}, nil
 	case '>':
 		return ExprToken{Type: ExprTokenTypeGt, Value: ExprOpGt, Pos: startPos}, nil
+	case '+':
+		return ExprToken{Type: ExprTokenTypeAdd, Value: ExprOpAdd, Pos: startPos}, nil
 	}
 
 	return ExprToken{}, NewExprTokenError(ErrMsgExprUnexpectedChar, startPos, string(ch))
@@ -130,6 +130,7 @@ func TestExprTokenizer_Tokenize_Operators(t *testing.T) {
 		{">", ExprTokenTypeGt},
 		{"<=", ExprTokenTypeLte},
 		{">=", ExprTokenTypeGte},
+		{"+", ExprTokenTypeAdd},
 	}
 
 	for _, tt := range tests {
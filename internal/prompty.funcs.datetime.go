@@ -64,17 +64,22 @@ const (
 	MinutesPerHour = 60
 )
 
-// registerDateTimeFuncs registers date/time manipulation functions
-func registerDateTimeFuncs(r *FuncRegistry) {
-	// now() time.Time - returns current timestamp
-	r.MustRegister(&Func{
+// newNowFunc returns the now() function reading the given clock
+func newNowFunc(clock func() time.Time) *Func {
+	return &Func{
 		Name:    FuncNameNow,
 		MinArgs: 0,
 		MaxArgs: 0,
 		Fn: func(args []any) (any, error) {
-			return time.Now(), nil
+			return clock(), nil
 		},
-	})
+	}
+}
+
+// registerDateTimeFuncs registers date/time manipulation functions
+func registerDateTimeFuncs(r *FuncRegistry) {
+	// now() time.Time - returns current timestamp
+	r.MustRegister(newNowFunc(time.Now))
 
 	// formatDate(t time.Time, layout string) string
 	r.MustRegister(&Func{
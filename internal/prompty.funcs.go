@@ -2,7 +2,9 @@ package internal
 
 import (
 	"fmt"
+	"maps"
 	"sync"
+	"sync/atomic"
 )
 
 // Func represents a callable function in expressions
@@ -13,17 +15,21 @@ type Func struct {
 	Fn      func(args []any) (any, error)
 }
 
-// FuncRegistry manages registered functions
+// FuncRegistry manages registered functions. Like Registry it is
+// copy-on-write, so functions can be registered while expressions are
+// being evaluated.
 type FuncRegistry struct {
-	funcs map[string]*Func
-	mu    sync.RWMutex
+	funcs  atomic.Pointer[map[string]*Func]
+	mu     sync.Mutex // Serializes writers
+	frozen bool       // Set on snapshots, which reject registrations
 }
 
 // NewFuncRegistry creates a new function registry
 func NewFuncRegistry() *FuncRegistry {
-	return &FuncRegistry{
-		funcs: make(map[string]*Func),
-	}
+	funcs := make(map[string]*Func)
+	r := &FuncRegistry{}
+	r.funcs.Store(&funcs)
+	return r
 }
 
 // Register adds a function to the registry
@@ -34,18 +40,57 @@ func (r *FuncRegistry) Register(f *Func) error {
 	if f.Name == "" {
 		return NewFuncRegistryError(ErrMsgFuncEmptyName, "")
 	}
+	if r.frozen {
+		return NewFuncRegistryError(ErrMsgFuncRegistryFrozen, f.Name)
+	}
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.funcs[f.Name]; exists {
+	if _, exists := r.load()[f.Name]; exists {
 		return NewFuncRegistryError(ErrMsgFuncAlreadyExists, f.Name)
 	}
 
-	r.funcs[f.Name] = f
+	r.store(f)
 	return nil
 }
 
+// replace adds or overrides a function, for built-ins bound to executor state
+func (r *FuncRegistry) replace(f *Func) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store(f)
+}
+
+// store publishes a copy of the functions with f added. Callers hold mu.
+func (r *FuncRegistry) store(f *Func) {
+	funcs := maps.Clone(r.load())
+	funcs[f.Name] = f
+	r.funcs.Store(&funcs)
+}
+
+// load returns the current functions, which must not be modified
+func (r *FuncRegistry) load() map[string]*Func {
+	return *r.funcs.Load()
+}
+
+// clone returns a writable registry with the same functions. Both share
+// the current functions until either registers one.
+func (r *FuncRegistry) clone() *FuncRegistry {
+	clone := &FuncRegistry{}
+	clone.funcs.Store(r.funcs.Load())
+	return clone
+}
+
+// Snapshot returns a read-only registry with the functions currently
+// registered. Later registrations do not affect the snapshot, and
+// registering with the snapshot fails.
+func (r *FuncRegistry) Snapshot() *FuncRegistry {
+	snapshot := &FuncRegistry{frozen: true}
+	snapshot.funcs.Store(r.funcs.Load())
+	return snapshot
+}
+
 // MustRegister adds a function and panics on error
 func (r *FuncRegistry) MustRegister(f *Func) {
 	if err := r.Register(f); err != nil {
@@ -55,27 +100,19 @@ func (r *FuncRegistry) MustRegister(f *Func) {
 
 // Get retrieves a function by name
 func (r *FuncRegistry) Get(name string) (*Func, bool) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	f, ok := r.funcs[name]
+	f, ok := r.load()[name]
 	return f, ok
 }
 
 // Has checks if a function is registered
 func (r *FuncRegistry) Has(name string) bool {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	_, ok := r.funcs[name]
+	_, ok := r.load()[name]
 	return ok
 }
 
 // Call invokes a function by name with the given arguments
 func (r *FuncRegistry) Call(name string, args []any) (any, error) {
-	r.mu.RLock()
-	f, ok := r.funcs[name]
-	r.mu.RUnlock()
+	f, ok := r.load()[name]
 
 	if !ok {
 		return nil, NewFuncError(ErrMsgFuncNotFound, name)
@@ -101,11 +138,9 @@ func (r *FuncRegistry) Call(name string, args []any) (any, error) {
 
 // List returns all registered function names
 func (r *FuncRegistry) List() []string {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	names := make([]string, 0, len(r.funcs))
-	for name := range r.funcs {
+	funcs := r.load()
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
 		names = append(names, name)
 	}
 	return names
@@ -113,10 +148,7 @@ func (r *FuncRegistry) List() []string {
 
 // Count returns the number of registered functions
 func (r *FuncRegistry) Count() int {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	return len(r.funcs)
+	return len(r.load())
 }
 
 // FuncRegistryError represents a function registry error
@@ -212,6 +244,7 @@ const (
 	ErrMsgFuncNilFunc           = "function cannot be nil"
 	ErrMsgFuncEmptyName         = "function name cannot be empty"
 	ErrMsgFuncAlreadyExists     = "function already registered"
+	ErrMsgFuncRegistryFrozen    = "function registry snapshot is read-only"
 	ErrMsgFuncNotFound          = "function not found"
 	ErrMsgFuncTooFewArgs        = "too few arguments"
 	ErrMsgFuncTooManyArgs       = "too many arguments"
@@ -42,6 +42,24 @@ func TestFuncRegistry_Register_Duplicate(t *testing.T) {
 	assert.Contains(t, err.Error(), ErrMsgFuncAlreadyExists)
 }
 
+func TestFuncRegistry_Snapshot(t *testing.T) {
+	r := NewFuncRegistry()
+	identity := func(args []any) (any, error) { return args[0], nil }
+	r.MustRegister(&Func{Name: "early", MinArgs: 1, MaxArgs: 1, Fn: identity})
+
+	snapshot := r.Snapshot()
+	r.MustRegister(&Func{Name: "late", MinArgs: 1, MaxArgs: 1, Fn: identity})
+
+	assert.True(t, snapshot.Has("early"))
+	assert.False(t, snapshot.Has("late"))
+	assert.Equal(t, 1, snapshot.Count())
+	assert.Equal(t, 2, r.Count())
+
+	err := snapshot.Register(&Func{Name: "other", Fn: identity})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgFuncRegistryFrozen)
+}
+
 func TestFuncRegistry_MustRegister(t *testing.T) {
 	r := NewFuncRegistry()
 
@@ -36,7 +36,13 @@ func (c LexerConfig) escapeOpen() string {
 	return "\\" + c.OpenDelim
 }
 
-// Lexer tokenizes template source into a token stream
+// Lexer tokenizes template source into a token stream.
+//
+// The lexer works on byte offsets into the source and never copies it: the
+// values of text, tag name and attribute name tokens, and of attribute values
+// without escape sequences, are substrings of the source. Text runs are
+// located with strings.Index instead of byte-by-byte matching, so templates
+// dominated by large literal blocks tokenize in time close to a memory scan.
 type Lexer struct {
 	source string
 	config LexerConfig
@@ -44,6 +50,11 @@ type Lexer struct {
 	line   int // Current line (1-indexed)
 	column int // Current column (1-indexed)
 	logger *zap.Logger
+
+	// Delimiter patterns derived from config, computed once per lexer
+	blockClosePattern string
+	selfClosePattern  string
+	escapePattern     string
 }
 
 // NewLexer creates a new lexer with default configuration
@@ -58,42 +69,52 @@ func NewLexerWithConfig(source string, config LexerConfig, logger *zap.Logger) *
 	}
 	logger.Debug(LogMsgLexerCreated, zap.Int(LogFieldSource, len(source)))
 	return &Lexer{
-		source: source,
-		config: config,
-		pos:    0,
-		line:   1,
-		column: 1,
-		logger: logger,
+		source:            source,
+		config:            config,
+		pos:               0,
+		line:              1,
+		column:            1,
+		logger:            logger,
+		blockClosePattern: config.blockClose(),
+		selfClosePattern:  config.selfClose(),
+		escapePattern:     config.escapeOpen(),
 	}
 }
 
+// NewLexerAt creates a lexer that starts tokenizing source at pos, for
+// re-lexing a region of a larger source whose earlier part is unchanged
+func NewLexerAt(source string, pos Position, config LexerConfig, logger *zap.Logger) *Lexer {
+	l := NewLexerWithConfig(source, config, logger)
+	l.pos, l.line, l.column = pos.Offset, pos.Line, pos.Column
+	return l
+}
+
 // Tokenize processes the source and returns a token stream
 func (l *Lexer) Tokenize() ([]Token, error) {
 	l.logger.Debug(LogMsgTokenizerStart)
-	var tokens []Token
+	tokens := make([]Token, 0, l.estimateTokens())
 
 	for !l.isAtEnd() {
 		// Check for escape sequence first
 		if l.isEscapedOpenDelim() {
 			// Handle escape: consume \{~ and emit {~ as text
 			pos := l.currentPosition()
-			l.advanceN(len(l.config.escapeOpen())) // Skip escaped open delim
+			l.advanceN(len(l.escapePattern)) // Skip escaped open delim
 			tokens = append(tokens, NewTextToken(l.config.OpenDelim, pos))
 			continue
 		}
 
 		// Check for block close delimiter (e.g., {~/)
-		blockClosePattern := l.config.blockClose()
-		if l.matchStr(blockClosePattern) {
+		if l.matchStr(l.blockClosePattern) {
 			pos := l.currentPosition()
-			l.advanceN(len(blockClosePattern))
+			l.advanceN(len(l.blockClosePattern))
 			tokens = append(tokens, NewBlockCloseToken(pos))
 			// Now scan tag name
-			tagTokens, err := l.scanTagContent(true)
+			var err error
+			tokens, err = l.appendTagContent(tokens, true)
 			if err != nil {
 				return nil, err
 			}
-			tokens = append(tokens, tagTokens...)
 			continue
 		}
 
@@ -103,11 +124,20 @@ func (l *Lexer) Tokenize() ([]Token, error) {
 			l.advanceN(len(l.config.OpenDelim))
 			tokens = append(tokens, NewOpenTagToken(pos))
 			// Scan tag content (name, attributes, close)
-			tagTokens, err := l.scanTagContent(false)
+			tagStart := len(tokens)
+			var err error
+			tokens, err = l.appendTagContent(tokens, false)
 			if err != nil {
 				return nil, err
 			}
-			tokens = append(tokens, tagTokens...)
+
+			// Raw and comment block bodies are captured verbatim so that
+			// delimiter sequences inside them are never tokenized
+			if verbatimTag, ok := verbatimBlockName(tokens[tagStart:]); ok {
+				if textToken := l.scanVerbatim(verbatimTag); textToken.Value != "" {
+					tokens = append(tokens, textToken)
+				}
+			}
 			continue
 		}
 
@@ -127,38 +157,87 @@ func (l *Lexer) Tokenize() ([]Token, error) {
 	return tokens, nil
 }
 
-// scanText scans text content until the next delimiter or escape sequence
+// estimateTokens predicts the token count from the number of open
+// delimiters, so the token slice is allocated once for typical templates.
+func (l *Lexer) estimateTokens() int {
+	if len(l.config.OpenDelim) == 0 {
+		return 1
+	}
+	return strings.Count(l.source, l.config.OpenDelim)*estimatedTokensPerTag + 1
+}
+
+// scanText scans text content until the next delimiter or escape sequence.
+// The token value is a substring of the source.
 func (l *Lexer) scanText() (Token, error) {
 	startPos := l.currentPosition()
-	var sb strings.Builder
-
-	blockClosePattern := l.config.blockClose()
-	for !l.isAtEnd() {
-		// Stop at escape sequence
-		if l.isEscapedOpenDelim() {
-			break
+	start := l.pos
+
+	// Every stop (open delimiter, block close, escape) contains the open
+	// delimiter, and an escape begins one byte before it
+	end := len(l.source)
+	if i := strings.Index(l.source[start:], l.config.OpenDelim); i >= 0 && len(l.config.OpenDelim) > 0 {
+		end = start + i
+		if end > start && strings.HasPrefix(l.source[end-1:], l.escapePattern) {
+			end--
 		}
-		// Stop at block close
-		if l.matchStr(blockClosePattern) {
+	}
+
+	l.advanceN(end - start)
+	return NewTextToken(l.source[start:end], startPos), nil
+}
+
+// verbatimBlockName returns the tag name if the scanned tag opens a raw or
+// comment block (as opposed to a self-closing tag).
+func verbatimBlockName(tagTokens []Token) (string, bool) {
+	if len(tagTokens) == 0 || tagTokens[len(tagTokens)-1].Type != TokenTypeCloseTag {
+		return "", false
+	}
+	name := tagTokens[0].Value
+	if name == TagNameRaw || name == TagNameComment {
+		return name, true
+	}
+	return "", false
+}
+
+// scanVerbatim consumes source text unmodified until the closing tag for
+// tagName (e.g. {~/prompty.raw~}) or end of input, and returns it as a text token.
+// The closing tag itself is left for the main tokenizer loop.
+func (l *Lexer) scanVerbatim(tagName string) Token {
+	startPos := l.currentPosition()
+	start := l.pos
+	end := len(l.source)
+	for i := start; i < len(l.source); {
+		j := strings.Index(l.source[i:], l.blockClosePattern)
+		if j < 0 {
 			break
 		}
-		// Stop at open delimiter
-		if l.matchStr(l.config.OpenDelim) {
+		if l.matchVerbatimCloseAt(i+j, tagName) {
+			end = i + j
 			break
 		}
-
-		ch := l.advance()
-		sb.WriteByte(ch)
+		i += j + 1
 	}
-
-	return NewTextToken(sb.String(), startPos), nil
+	l.advanceN(end - start)
+	return NewTextToken(l.source[start:end], startPos)
 }
 
-// scanTagContent scans the content inside a tag (name, attributes, closing)
-// isBlockClose indicates if this is a closing tag ({~/...)
-func (l *Lexer) scanTagContent(isBlockClose bool) ([]Token, error) {
-	var tokens []Token
+// matchVerbatimCloseAt returns true if the source at offset starts with the
+// closing tag for tagName, allowing the same whitespace scanTagContent accepts.
+func (l *Lexer) matchVerbatimCloseAt(offset int, tagName string) bool {
+	if !strings.HasPrefix(l.source[offset:], l.blockClosePattern) {
+		return false
+	}
+	rest := strings.TrimLeft(l.source[offset+len(l.blockClosePattern):], StrWhitespaceChars)
+	if !strings.HasPrefix(rest, tagName) {
+		return false
+	}
+	rest = strings.TrimLeft(rest[len(tagName):], StrWhitespaceChars)
+	return strings.HasPrefix(rest, l.config.CloseDelim)
+}
 
+// appendTagContent scans the content inside a tag (name, attributes, closing)
+// and appends its tokens. isBlockClose indicates if this is a closing tag ({~/...)
+func (l *Lexer) appendTagContent(tokens []Token, isBlockClose bool) ([]Token, error) {
 	l.skipWhitespace()
 
 	// Scan tag name
@@ -177,37 +256,32 @@ func (l *Lexer) scanTagContent(isBlockClose bool) ([]Token, error) {
 		}
 		pos := l.currentPosition()
 		l.advanceN(len(l.config.CloseDelim))
-		tokens = append(tokens, NewCloseTagToken(pos))
-		return tokens, nil
+		return append(tokens, NewCloseTagToken(pos)), nil
 	}
 
 	// Scan attributes
-	selfClosePattern := l.config.selfClose()
 	for !l.isAtEnd() {
 		l.skipWhitespace()
 
 		// Check for self-close (e.g., /~})
-		if l.matchStr(selfClosePattern) {
+		if l.matchStr(l.selfClosePattern) {
 			pos := l.currentPosition()
-			l.advanceN(len(selfClosePattern))
-			tokens = append(tokens, NewSelfCloseToken(pos))
-			return tokens, nil
+			l.advanceN(len(l.selfClosePattern))
+			return append(tokens, NewSelfCloseToken(pos)), nil
 		}
 
 		// Check for close delimiter ~}
 		if l.matchStr(l.config.CloseDelim) {
 			pos := l.currentPosition()
 			l.advanceN(len(l.config.CloseDelim))
-			tokens = append(tokens, NewCloseTagToken(pos))
-			return tokens, nil
+			return append(tokens, NewCloseTagToken(pos)), nil
 		}
 
 		// Scan attribute
-		attrTokens, err := l.scanAttribute()
+		tokens, err = l.appendAttribute(tokens)
 		if err != nil {
 			return nil, err
 		}
-		tokens = append(tokens, attrTokens...)
 	}
 
 	return nil, l.newUnterminatedTagError()
@@ -216,32 +290,29 @@ func (l *Lexer) scanTagContent(isBlockClose bool) ([]Token, error) {
 // scanTagName scans an identifier for a tag name
 func (l *Lexer) scanTagName() (Token, error) {
 	startPos := l.currentPosition()
-	var sb strings.Builder
+	start := l.pos
 
 	// First character must be letter or underscore
-	if !l.isAtEnd() && (isLetter(l.peek()) || l.peek() == '_') {
-		sb.WriteByte(l.advance())
-	} else {
+	if l.isAtEnd() || !(isLetter(l.peek()) || l.peek() == '_') {
 		return Token{}, l.newInvalidTagNameError()
 	}
 
 	// Subsequent characters can be letter, digit, underscore, hyphen, or dot
-	for !l.isAtEnd() {
-		ch := l.peek()
-		if isLetter(ch) || isDigit(ch) || ch == '_' || ch == '-' || ch == '.' {
-			sb.WriteByte(l.advance())
-		} else {
+	end := start + 1
+	for end < len(l.source) {
+		ch := l.source[end]
+		if !(isLetter(ch) || isDigit(ch) || ch == '_' || ch == '-' || ch == '.') {
 			break
 		}
+		end++
 	}
 
-	return NewTagNameToken(sb.String(), startPos), nil
+	l.advanceN(end - start)
+	return NewTagNameToken(l.source[start:end], startPos), nil
 }
 
-// scanAttribute scans an attribute name=value pair
-func (l *Lexer) scanAttribute() ([]Token, error) {
-	var tokens []Token
-
+// appendAttribute scans an attribute name=value pair and appends its tokens
+func (l *Lexer) appendAttribute(tokens []Token) ([]Token, error) {
 	// Scan attribute name
 	nameToken, err := l.scanAttrName()
 	if err != nil {
@@ -265,37 +336,35 @@ func (l *Lexer) scanAttribute() ([]Token, error) {
 	if err != nil {
 		return nil, err
 	}
-	tokens = append(tokens, valueToken)
-
-	return tokens, nil
+	return append(tokens, valueToken), nil
 }
 
 // scanAttrName scans an attribute name identifier
 func (l *Lexer) scanAttrName() (Token, error) {
 	startPos := l.currentPosition()
-	var sb strings.Builder
+	start := l.pos
 
 	// First character must be letter or underscore
-	if !l.isAtEnd() && (isLetter(l.peek()) || l.peek() == '_') {
-		sb.WriteByte(l.advance())
-	} else {
+	if l.isAtEnd() || !(isLetter(l.peek()) || l.peek() == '_') {
 		return Token{}, l.newUnexpectedCharError()
 	}
 
 	// Subsequent characters can be letter, digit, underscore, or hyphen
-	for !l.isAtEnd() {
-		ch := l.peek()
-		if isLetter(ch) || isDigit(ch) || ch == '_' || ch == '-' {
-			sb.WriteByte(l.advance())
-		} else {
+	end := start + 1
+	for end < len(l.source) {
+		ch := l.source[end]
+		if !(isLetter(ch) || isDigit(ch) || ch == '_' || ch == '-') {
 			break
 		}
+		end++
 	}
 
-	return NewAttrNameToken(sb.String(), startPos), nil
+	l.advanceN(end - start)
+	return NewAttrNameToken(l.source[start:end], startPos), nil
 }
 
-// scanAttrValue scans a quoted attribute value
+// scanAttrValue scans a quoted attribute value. Values without escape
+// sequences are returned as substrings of the source.
 func (l *Lexer) scanAttrValue() (Token, error) {
 	startPos := l.currentPosition()
 
@@ -310,29 +379,37 @@ func (l *Lexer) scanAttrValue() (Token, error) {
 	}
 	l.advance() // consume opening quote
 
-	var sb strings.Builder
-	for !l.isAtEnd() {
-		ch := l.peek()
+	start := l.pos
+	var sb *strings.Builder // Only allocated once an escape sequence is seen
+	for i := start; i < len(l.source); i++ {
+		ch := l.source[i]
 
 		// Check for closing quote
 		if ch == quote {
-			l.advance() // consume closing quote
-			return NewAttrValueToken(sb.String(), startPos), nil
+			value := l.source[start:i]
+			if sb != nil {
+				sb.WriteString(value)
+				value = sb.String()
+			}
+			l.advanceN(i + 1 - l.pos) // consume value and closing quote
+			return NewAttrValueToken(value, startPos), nil
 		}
 
 		// Handle escape sequences within strings
-		if ch == CharBackslash && l.pos+1 < len(l.source) {
-			nextCh := l.source[l.pos+1]
+		if ch == CharBackslash && i+1 < len(l.source) {
+			nextCh := l.source[i+1]
 			if nextCh == quote || nextCh == CharBackslash {
-				l.advance() // skip backslash
-				sb.WriteByte(l.advance())
-				continue
+				if sb == nil {
+					sb = &strings.Builder{}
+				}
+				sb.WriteString(l.source[start:i])
+				start = i + 1 // keep the escaped character, skip the backslash
+				i++
 			}
 		}
-
-		sb.WriteByte(l.advance())
 	}
 
+	l.advanceN(len(l.source) - l.pos)
 	return Token{}, l.newUnterminatedStrError()
 }
 
@@ -376,11 +453,24 @@ func (l *Lexer) advance() byte {
 	return ch
 }
 
-// advanceN advances by n characters
+// advanceN advances by n characters, updating line and column from the
+// newlines in the skipped span
 func (l *Lexer) advanceN(n int) {
-	for i := 0; i < n && !l.isAtEnd(); i++ {
-		l.advance()
+	end := l.pos + n
+	if end > len(l.source) {
+		end = len(l.source)
+	}
+	if end <= l.pos {
+		return
+	}
+	span := l.source[l.pos:end]
+	if lines := strings.Count(span, StrNewline); lines > 0 {
+		l.line += lines
+		l.column = len(span) - strings.LastIndexByte(span, CharNewline)
+	} else {
+		l.column += len(span)
 	}
+	l.pos = end
 }
 
 // matchStr returns true if the remaining source starts with s
@@ -390,7 +480,7 @@ func (l *Lexer) matchStr(s string) bool {
 
 // isEscapedOpenDelim returns true if we're at an escaped open delimiter
 func (l *Lexer) isEscapedOpenDelim() bool {
-	return l.matchStr(l.config.escapeOpen())
+	return l.matchStr(l.escapePattern)
 }
 
 // skipWhitespace skips whitespace characters
@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"strings"
+)
+
+// HighlightSpan is a classified span of template source
+type HighlightSpan struct {
+	Kind  HighlightKind
+	Start Position // Position of the first byte
+	End   Position // Position just past the last byte
+}
+
+// Highlight splits the source into contiguous classified spans for syntax
+// highlighting. Unlike Tokenize it never fails: malformed tag content is
+// reported as HighlightKindInvalid and scanning resumes after it, so partially
+// typed templates still highlight. The spans cover the whole source in order.
+func (l *Lexer) Highlight() []HighlightSpan {
+	var spans []HighlightSpan
+
+	if fm, err := ExtractYAMLFrontmatter(l.source); err == nil && fm.HasFrontmatter {
+		spans = l.spanN(spans, HighlightKindFrontmatter, len(l.source)-len(fm.TemplateBody))
+	}
+
+	blockClosePattern := l.config.blockClose()
+	for !l.isAtEnd() {
+		switch {
+		case l.isEscapedOpenDelim():
+			spans = l.spanN(spans, HighlightKindEscape, len(l.config.escapeOpen()))
+		case l.matchStr(blockClosePattern):
+			spans = l.highlightTag(spans, blockClosePattern, true)
+		case l.matchStr(l.config.OpenDelim):
+			spans = l.highlightTag(spans, l.config.OpenDelim, false)
+		default:
+			spans = l.span(spans, HighlightKindText, func() { _, _ = l.scanText() })
+		}
+	}
+
+	return spans
+}
+
+// highlightTag classifies one tag starting at the open pattern. A tag left
+// unterminated by a following open delimiter ends there.
+func (l *Lexer) highlightTag(spans []HighlightSpan, open string, isBlockClose bool) []HighlightSpan {
+	spans = l.spanN(spans, HighlightKindTagOpen, len(open))
+	spans = l.span(spans, HighlightKindWhitespace, l.skipWhitespace)
+
+	tagName := ""
+	if !l.isAtEnd() && (isLetter(l.peek()) || l.peek() == '_') {
+		start := l.pos
+		spans = l.span(spans, HighlightKindTagName, func() { _, _ = l.scanTagName() })
+		tagName = l.source[start:l.pos]
+	}
+
+	selfClosePattern := l.config.selfClose()
+	attrName := ""
+	for !l.isAtEnd() {
+		spans = l.span(spans, HighlightKindWhitespace, l.skipWhitespace)
+
+		switch ch := l.peek(); {
+		case l.isAtEnd(), l.matchStr(l.config.OpenDelim):
+			return spans
+		case l.matchStr(selfClosePattern):
+			return l.spanN(spans, HighlightKindTagClose, len(selfClosePattern))
+		case l.matchStr(l.config.CloseDelim):
+			spans = l.spanN(spans, HighlightKindTagClose, len(l.config.CloseDelim))
+			// Raw and comment bodies are verbatim, as in Tokenize
+			if !isBlockClose && (tagName == TagNameRaw || tagName == TagNameComment) {
+				kind := HighlightKindText
+				if tagName == TagNameComment {
+					kind = HighlightKindComment
+				}
+				spans = l.span(spans, kind, func() { l.scanVerbatim(tagName) })
+			}
+			return spans
+		case isLetter(ch) || ch == '_':
+			start := l.pos
+			spans = l.span(spans, HighlightKindAttribute, func() { _, _ = l.scanAttrName() })
+			attrName = l.source[start:l.pos]
+		case ch == CharEquals:
+			spans = l.spanN(spans, HighlightKindEquals, 1)
+		case ch == CharDoubleQuote || ch == CharSingleQuote:
+			kind := HighlightKindString
+			if attrName == AttrEval {
+				kind = HighlightKindExpression
+			}
+			spans = l.span(spans, kind, l.skipQuoted)
+		default:
+			spans = l.span(spans, HighlightKindInvalid, l.skipInvalid)
+		}
+	}
+
+	return spans
+}
+
+// skipQuoted consumes a quoted attribute value including its quotes. A value
+// not closed before the tag's close delimiter ends there, so an unfinished
+// value does not swallow the rest of the template.
+func (l *Lexer) skipQuoted() {
+	quote := l.peek()
+	limit := len(l.source)
+	if i := strings.Index(l.source[l.pos:], l.config.CloseDelim); i >= 0 {
+		limit = l.pos + i
+		if strings.HasSuffix(l.source[:limit], string(CharSlash)) {
+			limit--
+		}
+	}
+
+	end := limit
+	for i := l.pos + 1; i < limit; i++ {
+		if l.source[i] == CharBackslash {
+			i++
+			continue
+		}
+		if l.source[i] == quote {
+			end = i + 1
+			break
+		}
+	}
+	l.advanceN(end - l.pos)
+}
+
+// skipInvalid consumes unexpected tag content up to the next whitespace or
+// delimiter, always consuming at least one byte.
+func (l *Lexer) skipInvalid() {
+	l.advance()
+	for !l.isAtEnd() && !strings.ContainsRune(StrWhitespaceChars, rune(l.peek())) &&
+		!l.matchStr(l.config.CloseDelim) && !l.matchStr(l.config.selfClose()) && !l.matchStr(l.config.OpenDelim) {
+		l.advance()
+	}
+}
+
+// span runs scan and records the consumed source as a span of kind.
+func (l *Lexer) span(spans []HighlightSpan, kind HighlightKind, scan func()) []HighlightSpan {
+	start := l.currentPosition()
+	scan()
+	if l.pos == start.Offset {
+		return spans
+	}
+	return append(spans, HighlightSpan{Kind: kind, Start: start, End: l.currentPosition()})
+}
+
+// spanN records the next n bytes as a span of kind.
+func (l *Lexer) spanN(spans []HighlightSpan, kind HighlightKind, n int) []HighlightSpan {
+	return l.span(spans, kind, func() { l.advanceN(n) })
+}
@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLexer_Highlight_EveryPrefixCovered(t *testing.T) {
+	// Every prefix simulates a template being typed; spans must stay contiguous
+	source := "---\nname: x\n---\n{~prompty.if eval=\"a && b\"~}\\{~ {~prompty.var name='u\\'v' default=\"d\" /~}" +
+		"{~prompty.comment~}c{~/prompty.comment~}{~ ?? ~}{~/prompty.if~}"
+
+	for end := 0; end <= len(source); end++ {
+		prefix := source[:end]
+		spans := NewLexer(prefix, nil).Highlight()
+
+		offset := 0
+		for _, span := range spans {
+			assert.Equal(t, offset, span.Start.Offset, "prefix %q", prefix)
+			assert.Greater(t, span.End.Offset, span.Start.Offset, "prefix %q", prefix)
+			offset = span.End.Offset
+		}
+		assert.Equal(t, len(prefix), offset, "prefix %q", prefix)
+	}
+}
+
+func TestLexer_Highlight_MatchesTokenize(t *testing.T) {
+	source := `Hi {~prompty.for item="x" in="xs"~}{~prompty.var name="x" /~}{~/prompty.for~}`
+
+	tokens, err := NewLexer(source, nil).Tokenize()
+	assert.NoError(t, err)
+
+	var tagNames, highlightedNames []string
+	for _, tok := range tokens {
+		if tok.Type == TokenTypeTagName {
+			tagNames = append(tagNames, tok.Value)
+		}
+	}
+	for _, span := range NewLexer(source, nil).Highlight() {
+		if span.Kind == HighlightKindTagName {
+			highlightedNames = append(highlightedNames, source[span.Start.Offset:span.End.Offset])
+		}
+	}
+	assert.Equal(t, tagNames, highlightedNames)
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"testing"
+	"unsafe"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -315,6 +316,37 @@ func TestLexer_Tokenize_PositionTracking(t *testing.T) {
 	assert.True(t, lastTextLine >= 2, "Last text should be on line 2 or 3, got %d", lastTextLine)
 }
 
+func TestLexer_Tokenize_ZeroCopy(t *testing.T) {
+	input := "first\nsecond {~tag a=\"plain\" b=\"say \\\"hi\\\"\" /~}\nthird \\{~ end"
+	tokens, err := NewLexer(input, zap.NewNop()).Tokenize()
+	require.NoError(t, err)
+
+	// Values sliced from the source share its memory
+	inSource := func(value string) bool {
+		start := uintptr(unsafe.Pointer(unsafe.StringData(input)))
+		p := uintptr(unsafe.Pointer(unsafe.StringData(value)))
+		return p >= start && p < start+uintptr(len(input))
+	}
+	assert.Equal(t, "first\nsecond ", tokens[0].Value)
+	assert.True(t, inSource(tokens[0].Value))
+	assert.Equal(t, "tag", tokens[2].Value)
+	assert.True(t, inSource(tokens[2].Value))
+	assert.Equal(t, "plain", tokens[5].Value)
+	assert.True(t, inSource(tokens[5].Value))
+
+	// Escaped values are unescaped into a copy
+	assert.Equal(t, `say "hi"`, tokens[8].Value)
+	assert.False(t, inSource(tokens[8].Value))
+
+	// Positions after multi-line spans
+	assert.Equal(t, Position{Offset: 13, Line: 2, Column: 8}, tokens[1].Position)
+	assert.Equal(t, TokenTypeText, tokens[10].Type)
+	assert.Equal(t, "\nthird ", tokens[10].Value)
+	assert.Equal(t, Position{Offset: 54, Line: 3, Column: 7}, tokens[11].Position)
+	assert.Equal(t, " end", tokens[12].Value)
+	assert.Equal(t, Position{Offset: 61, Line: 3, Column: 14}, tokens[13].Position)
+}
+
 func TestLexer_Tokenize_ConsecutiveTags(t *testing.T) {
 	input := `{~a /~}{~b /~}{~c /~}`
 	lexer := NewLexer(input, zap.NewNop())
@@ -331,9 +363,69 @@ func TestLexer_Tokenize_ConsecutiveTags(t *testing.T) {
 	assert.Equal(t, []string{"a", "b", "c"}, tagNames)
 }
 
+func TestLexer_Tokenize_VerbatimBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantText string
+	}{
+		{
+			name:     "raw block keeps malformed delimiters",
+			input:    `{~prompty.raw~}{~ not a tag {~/other~} ~}{~/prompty.raw~}`,
+			wantText: `{~ not a tag {~/other~} ~}`,
+		},
+		{
+			name:     "raw block keeps attribute formatting",
+			input:    `{~prompty.raw~}{~x  a='1'   /~}{~/prompty.raw~}`,
+			wantText: `{~x  a='1'   /~}`,
+		},
+		{
+			name:     "raw block keeps escape sequences",
+			input:    `{~prompty.raw~}\{~{~/prompty.raw~}`,
+			wantText: `\{~`,
+		},
+		{
+			name:     "comment block with unterminated tag",
+			input:    `{~prompty.comment~}{~prompty.var name="x"{~/prompty.comment~}`,
+			wantText: `{~prompty.var name="x"`,
+		},
+		{
+			name:     "closing tag with whitespace",
+			input:    "{~prompty.raw~}{~a~}{~/ prompty.raw ~}",
+			wantText: `{~a~}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := NewLexer(tt.input, zap.NewNop())
+			tokens, err := lexer.Tokenize()
+			require.NoError(t, err)
+
+			require.Len(t, tokens, 8)
+			assert.Equal(t, TokenTypeText, tokens[3].Type)
+			assert.Equal(t, tt.wantText, tokens[3].Value)
+			assert.Equal(t, TokenTypeBlockClose, tokens[4].Type)
+		})
+	}
+}
+
+func TestLexer_Tokenize_SelfClosingRawNotVerbatim(t *testing.T) {
+	lexer := NewLexer(`{~prompty.raw /~}{~prompty.var name="x" /~}`, zap.NewNop())
+	tokens, err := lexer.Tokenize()
+	require.NoError(t, err)
+
+	tagNames := []string{}
+	for _, tok := range tokens {
+		if tok.Type == TokenTypeTagName {
+			tagNames = append(tagNames, tok.Value)
+		}
+	}
+	assert.Equal(t, []string{"prompty.raw", "prompty.var"}, tagNames)
+}
+
 func TestLexer_CustomDelimiters(t *testing.T) {
-	// Note: Custom delimiters currently only affect open/close delims
-	// The self-close pattern /~} is still hardcoded
+	// Self-close and block-close patterns are derived from the configured delimiters
 	// This test verifies basic custom delimiter detection
 	config := LexerConfig{
 		OpenDelim:  "<%",
@@ -0,0 +1,55 @@
+package internal
+
+// nodeArena allocates the text and tag nodes of one parse from slabs sized
+// by the token stream, replacing an allocation per node with one per slab.
+// Node pointers stay valid because a slab is never grown: once it is full,
+// further nodes are allocated individually.
+type nodeArena struct {
+	texts []TextNode
+	tags  []TagNode
+}
+
+// newNodeArena sizes the slabs for the given tokens: one text node per text
+// token and at most one tag node per open tag.
+func newNodeArena(tokens []Token) *nodeArena {
+	var texts, tags int
+	for i := range tokens {
+		switch tokens[i].Type {
+		case TokenTypeText:
+			texts++
+		case TokenTypeOpenTag:
+			tags++
+		}
+	}
+	return &nodeArena{
+		texts: make([]TextNode, 0, texts),
+		tags:  make([]TagNode, 0, tags),
+	}
+}
+
+// newText returns a text node from the arena
+func (a *nodeArena) newText(content string, pos Position) *TextNode {
+	if len(a.texts) == cap(a.texts) {
+		return NewTextNode(content, pos)
+	}
+	a.texts = append(a.texts, TextNode{pos: pos, Content: content})
+	return &a.texts[len(a.texts)-1]
+}
+
+// newTag returns a tag node from the arena
+func (a *nodeArena) newTag(name string, attrs Attributes, children []Node, selfClose bool, pos Position) *TagNode {
+	if len(a.tags) == cap(a.tags) {
+		if selfClose {
+			return NewSelfClosingTag(name, attrs, pos)
+		}
+		return NewBlockTag(name, attrs, children, pos)
+	}
+	a.tags = append(a.tags, TagNode{
+		pos:        pos,
+		Name:       name,
+		Attributes: attrs,
+		Children:   children,
+		SelfClose:  selfClose,
+	})
+	return &a.tags[len(a.tags)-1]
+}
@@ -336,11 +336,12 @@ type SwitchNode struct {
 
 // SwitchCase represents a single case in a switch
 type SwitchCase struct {
-	Value     string   // For value comparison (mutually exclusive with Eval)
-	Eval      string   // For expression evaluation (mutually exclusive with Value)
-	Children  []Node   // Content to render if matched
-	IsDefault bool     // True for the default case
-	Pos       Position // Position of this case
+	Value       string   // For value comparison (mutually exclusive with Eval)
+	Eval        string   // For expression evaluation (mutually exclusive with Value)
+	Children    []Node   // Content to render if matched
+	IsDefault   bool     // True for the default case
+	Fallthrough bool     // Continue into the next case body after this one renders
+	Pos         Position // Position of this case
 }
 
 // Type returns NodeTypeSwitch
@@ -384,3 +385,170 @@ func NewSwitchCase(value, eval string, children []Node, isDefault bool, pos Posi
 		Pos:       pos,
 	}
 }
+
+// CountNodes returns the number of nodes in nodes and their descendants
+func CountNodes(nodes []Node) int {
+	count := len(nodes)
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *TagNode:
+			count += CountNodes(n.Children)
+		case *BlockNode:
+			count += CountNodes(n.Children)
+		case *ForNode:
+			count += CountNodes(n.Children)
+		case *ConditionalNode:
+			for _, branch := range n.Branches {
+				count += CountNodes(branch.Children)
+			}
+		case *SwitchNode:
+			for _, sc := range n.Cases {
+				count += CountNodes(sc.Children)
+			}
+			if n.Default != nil {
+				count += CountNodes(n.Default.Children)
+			}
+		}
+	}
+	return count
+}
+
+// FindBlock returns the first block named name in nodes or their
+// descendants, in document order
+func FindBlock(nodes []Node, name string) (*BlockNode, bool) {
+	for _, node := range nodes {
+		if block, ok := node.(*BlockNode); ok && block.Name == name {
+			return block, true
+		}
+		for _, children := range nodeChildren(node) {
+			if block, ok := FindBlock(children, name); ok {
+				return block, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// FilterNodes returns nodes without the nodes, at any depth, for which drop
+// returns true. Nodes containing dropped descendants are copied; all other
+// nodes are shared with the input.
+func FilterNodes(nodes []Node, drop func(Node) bool) []Node {
+	var filtered []Node
+	for i, node := range nodes {
+		kept := node
+		if drop(node) {
+			kept = nil
+		} else {
+			kept = filterNode(node, drop)
+		}
+		if kept != node && filtered == nil {
+			filtered = make([]Node, i, len(nodes))
+			copy(filtered, nodes[:i])
+		}
+		if filtered != nil && kept != nil {
+			filtered = append(filtered, kept)
+		}
+	}
+	if filtered == nil {
+		return nodes
+	}
+	return filtered
+}
+
+// filterNode returns node with the descendants for which drop returns true
+// removed, copying it only if any are
+func filterNode(node Node, drop func(Node) bool) Node {
+	switch n := node.(type) {
+	case *TagNode:
+		if children := FilterNodes(n.Children, drop); !sameNodes(children, n.Children) {
+			c := *n
+			c.Children = children
+			return &c
+		}
+	case *BlockNode:
+		if children := FilterNodes(n.Children, drop); !sameNodes(children, n.Children) {
+			c := *n
+			c.Children = children
+			return &c
+		}
+	case *ForNode:
+		if children := FilterNodes(n.Children, drop); !sameNodes(children, n.Children) {
+			c := *n
+			c.Children = children
+			return &c
+		}
+	case *ConditionalNode:
+		var branches []ConditionalBranch
+		for i, branch := range n.Branches {
+			children := FilterNodes(branch.Children, drop)
+			if sameNodes(children, branch.Children) && branches == nil {
+				continue
+			}
+			if branches == nil {
+				branches = append(make([]ConditionalBranch, 0, len(n.Branches)), n.Branches[:i]...)
+			}
+			branch.Children = children
+			branches = append(branches, branch)
+		}
+		if branches != nil {
+			c := *n
+			c.Branches = branches
+			return &c
+		}
+	case *SwitchNode:
+		changed := false
+		cases := make([]SwitchCase, len(n.Cases))
+		for i, sc := range n.Cases {
+			cases[i] = sc
+			cases[i].Children = FilterNodes(sc.Children, drop)
+			changed = changed || !sameNodes(cases[i].Children, sc.Children)
+		}
+		var def *SwitchCase
+		if n.Default != nil {
+			d := *n.Default
+			d.Children = FilterNodes(n.Default.Children, drop)
+			changed = changed || !sameNodes(d.Children, n.Default.Children)
+			def = &d
+		}
+		if changed {
+			c := *n
+			c.Cases = cases
+			c.Default = def
+			return &c
+		}
+	}
+	return node
+}
+
+// nodeChildren returns the child lists of a container node
+func nodeChildren(node Node) [][]Node {
+	switch n := node.(type) {
+	case *TagNode:
+		return [][]Node{n.Children}
+	case *BlockNode:
+		return [][]Node{n.Children}
+	case *ForNode:
+		return [][]Node{n.Children}
+	case *ConditionalNode:
+		children := make([][]Node, len(n.Branches))
+		for i, branch := range n.Branches {
+			children[i] = branch.Children
+		}
+		return children
+	case *SwitchNode:
+		children := make([][]Node, 0, len(n.Cases)+1)
+		for _, sc := range n.Cases {
+			children = append(children, sc.Children)
+		}
+		if n.Default != nil {
+			children = append(children, n.Default.Children)
+		}
+		return children
+	}
+	return nil
+}
+
+// sameNodes reports whether a and b are the same slice
+func sameNodes(a, b []Node) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
@@ -0,0 +1,417 @@
+package internal
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// CompiledTemplate is the content of a precompiled template artifact: the
+// parsed body AST plus what is needed to rebuild a Template around it.
+//
+// The binary layout is the magic, a uvarint format version, the delimiter,
+// source, body and frontmatter strings, then the AST. Strings are a uvarint
+// length followed by the bytes; nodes are a kind byte followed by their
+// position and fields, children as a uvarint count followed by the nodes.
+type CompiledTemplate struct {
+	OpenDelim   string
+	CloseDelim  string
+	Source      string
+	Body        string
+	Frontmatter string // YAML as written, before environment resolution
+	AST         *RootNode
+}
+
+// MarshalCompiledTemplate encodes a compiled template.
+func MarshalCompiledTemplate(ct *CompiledTemplate) ([]byte, error) {
+	size := len(CompiledMagic) + len(ct.Source) + len(ct.Body) + len(ct.Frontmatter)
+	e := &binaryEncoder{buf: make([]byte, 0, size*2)}
+
+	e.buf = append(e.buf, CompiledMagic...)
+	e.uint(CompiledFormatVersion)
+	e.string(ct.OpenDelim)
+	e.string(ct.CloseDelim)
+	e.string(ct.Source)
+	e.string(ct.Body)
+	e.string(ct.Frontmatter)
+
+	var children []Node
+	if ct.AST != nil {
+		children = ct.AST.Children
+	}
+	if err := e.nodes(children); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+// CompiledDecodeError reports compiled template data that cannot be
+// decoded: the code classifying the failure, the byte offset at which it
+// was detected and the format version, if it was read.
+type CompiledDecodeError struct {
+	Code    string
+	Message string
+	Offset  int
+	Version uint64
+}
+
+// Error implements the error interface.
+func (e *CompiledDecodeError) Error() string {
+	return e.Message
+}
+
+// UnmarshalCompiledTemplate decodes data written by MarshalCompiledTemplate.
+// Malformed input returns a *CompiledDecodeError; it never panics.
+func UnmarshalCompiledTemplate(data []byte) (*CompiledTemplate, error) {
+	if len(data) < len(CompiledMagic) || string(data[:len(CompiledMagic)]) != CompiledMagic {
+		return nil, &CompiledDecodeError{Code: ErrCodeCompiledBadMagic, Message: ErrMsgCompiledBadMagic}
+	}
+	d := &binaryDecoder{data: data[len(CompiledMagic):], size: len(data)}
+
+	version := d.uint()
+	if d.err != nil {
+		return nil, d.err
+	}
+	d.version = version
+	if version != CompiledFormatVersion {
+		d.fail(ErrCodeCompiledVersion, ErrMsgCompiledVersion)
+		return nil, d.err
+	}
+
+	ct := &CompiledTemplate{
+		OpenDelim:   d.string(),
+		CloseDelim:  d.string(),
+		Source:      d.string(),
+		Body:        d.string(),
+		Frontmatter: d.string(),
+	}
+	ct.AST = &RootNode{Children: d.nodes(0)}
+
+	if d.err == nil && len(d.data) > 0 {
+		d.fail(ErrCodeCompiledTrailing, ErrMsgCompiledTrailingData)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return ct, nil
+}
+
+// binaryEncoder appends the compiled encoding of AST values to buf.
+type binaryEncoder struct {
+	buf []byte
+}
+
+func (e *binaryEncoder) uint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+func (e *binaryEncoder) int(v int) {
+	e.buf = binary.AppendVarint(e.buf, int64(v))
+}
+
+func (e *binaryEncoder) bool(v bool) {
+	if v {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *binaryEncoder) string(s string) {
+	e.uint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *binaryEncoder) pos(p Position) {
+	e.int(p.Offset)
+	e.int(p.Line)
+	e.int(p.Column)
+}
+
+func (e *binaryEncoder) nodes(nodes []Node) error {
+	e.uint(uint64(len(nodes)))
+	for _, node := range nodes {
+		if err := e.node(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *binaryEncoder) node(node Node) error {
+	switch n := node.(type) {
+	case *TextNode:
+		e.buf = append(e.buf, compiledNodeText)
+		e.pos(n.pos)
+		e.string(n.Content)
+
+	case *TagNode:
+		var flags byte
+		if n.SelfClose {
+			flags |= compiledFlagSelfClose
+		}
+		if n.Children != nil {
+			flags |= compiledFlagChildren
+		}
+		if n.Attributes != nil {
+			flags |= compiledFlagAttributes
+		}
+		e.buf = append(e.buf, compiledNodeTag, flags)
+		e.pos(n.pos)
+		e.string(n.Name)
+		e.string(n.RawContent)
+		e.string(n.RawSource)
+		if n.Attributes != nil {
+			// Keys are sorted, so identical templates encode identically
+			keys := n.Attributes.Keys()
+			e.uint(uint64(len(keys)))
+			for _, k := range keys {
+				e.string(k)
+				e.string(n.Attributes[k])
+			}
+		}
+		if n.Children != nil {
+			return e.nodes(n.Children)
+		}
+
+	case *ConditionalNode:
+		e.buf = append(e.buf, compiledNodeConditional)
+		e.pos(n.pos)
+		e.uint(uint64(len(n.Branches)))
+		for _, branch := range n.Branches {
+			e.pos(branch.Pos)
+			e.string(branch.Condition)
+			e.bool(branch.IsElse)
+			if err := e.nodes(branch.Children); err != nil {
+				return err
+			}
+		}
+
+	case *ForNode:
+		e.buf = append(e.buf, compiledNodeFor)
+		e.pos(n.pos)
+		e.string(n.ItemVar)
+		e.string(n.IndexVar)
+		e.string(n.Source)
+		e.int(n.Limit)
+		return e.nodes(n.Children)
+
+	case *SwitchNode:
+		e.buf = append(e.buf, compiledNodeSwitch)
+		e.pos(n.pos)
+		e.string(n.Expression)
+		e.uint(uint64(len(n.Cases)))
+		for i := range n.Cases {
+			if err := e.switchCase(&n.Cases[i]); err != nil {
+				return err
+			}
+		}
+		e.bool(n.Default != nil)
+		if n.Default != nil {
+			return e.switchCase(n.Default)
+		}
+
+	case *BlockNode:
+		e.buf = append(e.buf, compiledNodeBlock)
+		e.pos(n.pos)
+		e.string(n.Name)
+		e.string(n.RawSource)
+		return e.nodes(n.Children)
+
+	default:
+		return fmt.Errorf("%s: %T", ErrMsgCompiledUnknownNode, node)
+	}
+	return nil
+}
+
+func (e *binaryEncoder) switchCase(c *SwitchCase) error {
+	e.pos(c.Pos)
+	e.string(c.Value)
+	e.string(c.Eval)
+	e.bool(c.IsDefault)
+	e.bool(c.Fallthrough)
+	return e.nodes(c.Children)
+}
+
+// binaryDecoder reads the compiled encoding. The first error sticks: later
+// reads return zero values, so callers check err once at the end.
+type binaryDecoder struct {
+	data    []byte
+	size    int    // Length of the whole input, to report offsets
+	version uint64 // Format version, once read
+	err     *CompiledDecodeError
+}
+
+// offset returns the position of the next unread byte in the input.
+func (d *binaryDecoder) offset() int {
+	return d.size - len(d.data)
+}
+
+func (d *binaryDecoder) fail(code, msg string) {
+	d.failAt(d.offset(), code, msg)
+}
+
+func (d *binaryDecoder) failAt(offset int, code, msg string) {
+	if d.err == nil {
+		d.err = &CompiledDecodeError{Code: code, Message: msg, Offset: offset, Version: d.version}
+	}
+	d.data = nil
+}
+
+func (d *binaryDecoder) byte() byte {
+	if len(d.data) == 0 {
+		d.fail(ErrCodeCompiledCorrupt, ErrMsgCompiledTruncated)
+		return 0
+	}
+	b := d.data[0]
+	d.data = d.data[1:]
+	return b
+}
+
+func (d *binaryDecoder) uint() uint64 {
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.fail(ErrCodeCompiledCorrupt, ErrMsgCompiledTruncated)
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *binaryDecoder) int() int {
+	v, n := binary.Varint(d.data)
+	if n <= 0 || v < math.MinInt32 || v > math.MaxInt32 {
+		d.fail(ErrCodeCompiledCorrupt, ErrMsgCompiledTruncated)
+		return 0
+	}
+	d.data = d.data[n:]
+	return int(v)
+}
+
+func (d *binaryDecoder) bool() bool {
+	return d.byte() != 0
+}
+
+// count reads a length that cannot exceed the remaining data, since every
+// element takes at least one byte.
+func (d *binaryDecoder) count() int {
+	n := d.uint()
+	if n > uint64(len(d.data)) {
+		d.fail(ErrCodeCompiledCorrupt, ErrMsgCompiledTruncated)
+		return 0
+	}
+	return int(n)
+}
+
+func (d *binaryDecoder) string() string {
+	n := d.count()
+	if d.err != nil {
+		return ""
+	}
+	s := string(d.data[:n])
+	d.data = d.data[n:]
+	return s
+}
+
+func (d *binaryDecoder) pos() Position {
+	return Position{Offset: d.int(), Line: d.int(), Column: d.int()}
+}
+
+func (d *binaryDecoder) nodes(depth int) []Node {
+	if depth > MaxCompiledNodeDepth {
+		d.fail(ErrCodeCompiledCorrupt, ErrMsgCompiledTooDeep)
+		return nil
+	}
+	n := d.count()
+	nodes := make([]Node, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		if node := d.node(depth); node != nil {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+func (d *binaryDecoder) node(depth int) Node {
+	start := d.offset()
+	switch kind := d.byte(); kind {
+	case compiledNodeText:
+		pos := d.pos()
+		return NewTextNode(d.string(), pos)
+
+	case compiledNodeTag:
+		flags := d.byte()
+		n := &TagNode{
+			pos:        d.pos(),
+			Name:       d.string(),
+			RawContent: d.string(),
+			RawSource:  d.string(),
+			SelfClose:  flags&compiledFlagSelfClose != 0,
+		}
+		if flags&compiledFlagAttributes != 0 {
+			count := d.count()
+			n.Attributes = make(Attributes, count)
+			for i := 0; i < count && d.err == nil; i++ {
+				k := d.string()
+				n.Attributes[k] = d.string()
+			}
+		}
+		if flags&compiledFlagChildren != 0 {
+			n.Children = d.nodes(depth + 1)
+		}
+		return n
+
+	case compiledNodeConditional:
+		pos := d.pos()
+		count := d.count()
+		branches := make([]ConditionalBranch, 0, count)
+		for i := 0; i < count && d.err == nil; i++ {
+			branchPos := d.pos()
+			condition := d.string()
+			isElse := d.bool()
+			branches = append(branches, NewConditionalBranch(condition, d.nodes(depth+1), isElse, branchPos))
+		}
+		return NewConditionalNode(branches, pos)
+
+	case compiledNodeFor:
+		pos := d.pos()
+		itemVar, indexVar, source := d.string(), d.string(), d.string()
+		limit := d.int()
+		return NewForNode(itemVar, indexVar, source, limit, d.nodes(depth+1), pos)
+
+	case compiledNodeSwitch:
+		pos := d.pos()
+		expr := d.string()
+		count := d.count()
+		cases := make([]SwitchCase, 0, count)
+		for i := 0; i < count && d.err == nil; i++ {
+			cases = append(cases, d.switchCase(depth))
+		}
+		var defaultCase *SwitchCase
+		if d.bool() {
+			c := d.switchCase(depth)
+			defaultCase = &c
+		}
+		return NewSwitchNode(expr, cases, defaultCase, pos)
+
+	case compiledNodeBlock:
+		pos := d.pos()
+		n := NewBlockNode(d.string(), nil, pos)
+		n.RawSource = d.string()
+		n.Children = d.nodes(depth + 1)
+		return n
+
+	default:
+		if d.err == nil {
+			d.failAt(start, ErrCodeCompiledCorrupt, ErrMsgCompiledNodeKind)
+		}
+		return nil
+	}
+}
+
+func (d *binaryDecoder) switchCase(depth int) SwitchCase {
+	pos := d.pos()
+	c := SwitchCase{Value: d.string(), Eval: d.string(), IsDefault: d.bool(), Fallthrough: d.bool(), Pos: pos}
+	c.Children = d.nodes(depth + 1)
+	return c
+}
@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// binaryTestSource exercises every node kind the parser produces.
+const binaryTestSource = `Hello {~prompty.var name="user" default="guest" /~}!
+{~prompty.raw~}{~not.a.tag~}{~/prompty.raw~}
+{~prompty.if eval="admin"~}A{~prompty.elseif eval="len(items) > 0"~}I{~prompty.else~}G{~/prompty.if~}
+{~prompty.for item="x" index="i" in="items" limit="5"~}{~prompty.var name="x" /~}{~/prompty.for~}
+{~prompty.switch eval="mode"~}{~prompty.case value="a" fallthrough="true"~}a{~/prompty.case~}{~prompty.case eval="mode == 'b'"~}b{~/prompty.case~}{~prompty.default~}d{~/prompty.default~}{~/prompty.switch~}
+{~prompty.block name="footer"~}bye {~prompty.parent /~}{~/prompty.block~}
+{~custom.tag a="1" b="2"~}inner{~/custom.tag~}`
+
+func parseForBinaryTest(t *testing.T, source string) *RootNode {
+	t.Helper()
+	tokens, err := NewLexer(source, nil).Tokenize()
+	require.NoError(t, err)
+	root, err := NewParserWithSource(tokens, source, nil).Parse()
+	require.NoError(t, err)
+	return root
+}
+
+func TestCompiledTemplate_RoundTrip(t *testing.T) {
+	root := parseForBinaryTest(t, binaryTestSource)
+	ct := &CompiledTemplate{
+		OpenDelim:   "{~",
+		CloseDelim:  "~}",
+		Source:      "---\nname: x\n---\n" + binaryTestSource,
+		Body:        binaryTestSource,
+		Frontmatter: "name: x\n",
+		AST:         root,
+	}
+
+	data, err := MarshalCompiledTemplate(ct)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), CompiledMagic))
+
+	decoded, err := UnmarshalCompiledTemplate(data)
+	require.NoError(t, err)
+	assert.Equal(t, ct.OpenDelim, decoded.OpenDelim)
+	assert.Equal(t, ct.CloseDelim, decoded.CloseDelim)
+	assert.Equal(t, ct.Source, decoded.Source)
+	assert.Equal(t, ct.Body, decoded.Body)
+	assert.Equal(t, ct.Frontmatter, decoded.Frontmatter)
+	assert.Equal(t, root.String(), decoded.AST.String())
+
+	// Encoding is deterministic, so a re-encode matches byte for byte
+	again, err := MarshalCompiledTemplate(decoded)
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+}
+
+func TestCompiledTemplate_NodeFields(t *testing.T) {
+	root := parseForBinaryTest(t, binaryTestSource)
+	data, err := MarshalCompiledTemplate(&CompiledTemplate{AST: root})
+	require.NoError(t, err)
+	decoded, err := UnmarshalCompiledTemplate(data)
+	require.NoError(t, err)
+	require.Len(t, decoded.AST.Children, len(root.Children))
+
+	kinds := make(map[NodeType]bool)
+	for i, node := range root.Children {
+		kinds[node.Type()] = true
+		got := decoded.AST.Children[i]
+		assert.Equal(t, node.Type(), got.Type())
+		assert.Equal(t, node.Pos(), got.Pos())
+
+		switch n := node.(type) {
+		case *TagNode:
+			g := got.(*TagNode)
+			assert.Equal(t, n.Name, g.Name)
+			assert.Equal(t, n.Attributes, g.Attributes)
+			assert.Equal(t, n.SelfClose, g.SelfClose)
+			assert.Equal(t, n.RawContent, g.RawContent)
+			assert.Equal(t, n.RawSource, g.RawSource)
+			assert.Equal(t, n.Children == nil, g.Children == nil)
+		case *ForNode:
+			g := got.(*ForNode)
+			assert.Equal(t, n.ItemVar, g.ItemVar)
+			assert.Equal(t, n.IndexVar, g.IndexVar)
+			assert.Equal(t, n.Source, g.Source)
+			assert.Equal(t, 5, g.Limit)
+		case *SwitchNode:
+			g := got.(*SwitchNode)
+			require.Len(t, g.Cases, 2)
+			assert.True(t, g.Cases[0].Fallthrough)
+			assert.Equal(t, n.Cases[1].Eval, g.Cases[1].Eval)
+			require.NotNil(t, g.Default)
+			assert.True(t, g.Default.IsDefault)
+		case *ConditionalNode:
+			g := got.(*ConditionalNode)
+			require.Len(t, g.Branches, 3)
+			assert.Equal(t, n.Branches[1].Condition, g.Branches[1].Condition)
+			assert.True(t, g.Branches[2].IsElse)
+		case *BlockNode:
+			g := got.(*BlockNode)
+			assert.Equal(t, n.Name, g.Name)
+			assert.Equal(t, n.RawSource, g.RawSource)
+			assert.Len(t, g.Children, len(n.Children))
+		}
+	}
+	for _, kind := range []NodeType{NodeTypeText, NodeTypeTag, NodeTypeRaw, NodeTypeConditional, NodeTypeFor, NodeTypeSwitch, NodeTypeBlock} {
+		assert.True(t, kinds[kind], "source has no %s node", kind)
+	}
+}
+
+func TestCompiledTemplate_NilAST(t *testing.T) {
+	data, err := MarshalCompiledTemplate(&CompiledTemplate{})
+	require.NoError(t, err)
+	decoded, err := UnmarshalCompiledTemplate(data)
+	require.NoError(t, err)
+	assert.Empty(t, decoded.AST.Children)
+}
+
+func TestCompiledTemplate_Malformed(t *testing.T) {
+	valid, err := MarshalCompiledTemplate(&CompiledTemplate{Body: "x", AST: parseForBinaryTest(t, binaryTestSource)})
+	require.NoError(t, err)
+
+	header := func(version uint64) []byte {
+		return binary.AppendUvarint([]byte(CompiledMagic), version)
+	}
+	emptyStrings := []byte{0, 0, 0, 0, 0}
+
+	headerLen := len(header(CompiledFormatVersion))
+
+	tests := []struct {
+		name   string
+		data   []byte
+		msg    string
+		code   string
+		offset int
+	}{
+		{name: "empty", data: nil, msg: ErrMsgCompiledBadMagic, code: ErrCodeCompiledBadMagic},
+		{name: "bad magic", data: []byte("NOPE!\x01"), msg: ErrMsgCompiledBadMagic, code: ErrCodeCompiledBadMagic},
+		{name: "future version", data: header(CompiledFormatVersion + 1), msg: ErrMsgCompiledVersion, code: ErrCodeCompiledVersion, offset: headerLen},
+		{name: "missing fields", data: header(CompiledFormatVersion), msg: ErrMsgCompiledTruncated, code: ErrCodeCompiledCorrupt, offset: headerLen},
+		{name: "string length beyond data", data: append(header(CompiledFormatVersion), 0x7f), msg: ErrMsgCompiledTruncated, code: ErrCodeCompiledCorrupt, offset: headerLen + 1},
+		{name: "unknown node kind", data: append(append(header(CompiledFormatVersion), emptyStrings...), 1, 0xee), msg: ErrMsgCompiledNodeKind, code: ErrCodeCompiledCorrupt, offset: headerLen + len(emptyStrings) + 1},
+		{name: "trailing data", data: append(append([]byte{}, valid...), 0), msg: ErrMsgCompiledTrailingData, code: ErrCodeCompiledTrailing, offset: len(valid)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UnmarshalCompiledTemplate(tt.data)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.msg)
+
+			var decodeErr *CompiledDecodeError
+			require.ErrorAs(t, err, &decodeErr)
+			assert.Equal(t, tt.code, decodeErr.Code)
+			assert.Equal(t, tt.offset, decodeErr.Offset)
+		})
+	}
+
+	t.Run("every truncation fails cleanly", func(t *testing.T) {
+		for n := 0; n < len(valid); n++ {
+			_, err := UnmarshalCompiledTemplate(valid[:n])
+			require.Error(t, err, "prefix length %d", n)
+		}
+	})
+}
+
+func TestCompiledTemplate_NestingLimit(t *testing.T) {
+	depth := MaxCompiledNodeDepth + 1
+	source := strings.Repeat(`{~prompty.for item="x" in="xs"~}`, depth) + strings.Repeat(`{~/prompty.for~}`, depth)
+	data, err := MarshalCompiledTemplate(&CompiledTemplate{AST: parseForBinaryTest(t, source)})
+	require.NoError(t, err)
+
+	_, err = UnmarshalCompiledTemplate(data)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgCompiledTooDeep)
+}
+
+type unknownBinaryNode struct{ TextNode }
+
+func TestCompiledTemplate_UnknownNode(t *testing.T) {
+	_, err := MarshalCompiledTemplate(&CompiledTemplate{AST: &RootNode{Children: []Node{&unknownBinaryNode{}}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgCompiledUnknownNode)
+}
@@ -13,7 +13,9 @@ type Parser struct {
 	source     string // Original source for raw text extraction
 	pos        int
 	logger     *zap.Logger
-	inRawBlock bool // Track if we're inside a raw block
+	config     LexerConfig // Delimiters the token stream was produced with
+	inRawBlock bool        // Track if we're inside a raw block
+	nodes      *nodeArena  // Allocates text and tag nodes
 }
 
 // NewParser creates a new parser for the given token stream
@@ -23,6 +25,12 @@ func NewParser(tokens []Token, logger *zap.Logger) *Parser {
 
 // NewParserWithSource creates a new parser with source for raw text capture
 func NewParserWithSource(tokens []Token, source string, logger *zap.Logger) *Parser {
+	return NewParserWithConfig(tokens, source, DefaultLexerConfig(), logger)
+}
+
+// NewParserWithConfig creates a new parser for a token stream produced with
+// custom delimiters, so raw source spans and reconstructed raw text use them.
+func NewParserWithConfig(tokens []Token, source string, config LexerConfig, logger *zap.Logger) *Parser {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
@@ -32,7 +40,9 @@ func NewParserWithSource(tokens []Token, source string, logger *zap.Logger) *Par
 		source:     source,
 		pos:        0,
 		logger:     logger,
+		config:     config,
 		inRawBlock: false,
+		nodes:      newNodeArena(tokens),
 	}
 }
 
@@ -55,6 +65,11 @@ func (p *Parser) Parse() (*RootNode, error) {
 	if err != nil {
 		return nil, err
 	}
+	if !p.isAtEnd() {
+		// A closing tag without a matching open tag
+		_, err := p.parseNode()
+		return nil, err
+	}
 
 	root := &RootNode{Children: nodes}
 	p.logger.Debug(LogMsgParserEnd, zap.Int(LogFieldNodes, len(nodes)))
@@ -88,8 +103,13 @@ func (p *Parser) parseNode() (Node, error) {
 	case TokenTypeOpenTag:
 		return p.parseTag()
 	case TokenTypeBlockClose:
-		// Block close is handled by parseBlockTag
-		return nil, nil
+		// Expected block closes are consumed by the enclosing block's parser,
+		// so one reaching here closes a tag that is not open
+		closeName := ""
+		if p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].Type == TokenTypeTagName {
+			closeName = p.tokens[p.pos+1].Value
+		}
+		return nil, p.newMismatchedTagError("", closeName)
 	case TokenTypeEOF:
 		return nil, nil
 	default:
@@ -100,7 +120,7 @@ func (p *Parser) parseNode() (Node, error) {
 // parseText parses a text node
 func (p *Parser) parseText() (*TextNode, error) {
 	tok := p.advance()
-	return NewTextNode(tok.Value, tok.Position), nil
+	return p.nodes.newText(tok.Value, tok.Position), nil
 }
 
 // parseTag parses a tag (self-closing or block)
@@ -129,9 +149,13 @@ func (p *Parser) parseTag() (Node, error) {
 	switch endTok.Type {
 	case TokenTypeSelfClose:
 		p.advance() // consume SELF_CLOSE
-		tag := NewSelfClosingTag(tagName, attrs, pos)
+		// A self-closing comment is an empty note and produces no node
+		if tagName == TagNameComment {
+			return nil, nil
+		}
+		tag := p.nodes.newTag(tagName, attrs, nil, true, pos)
 		// Capture raw source for keepRaw error strategy
-		endOffset := endTok.Position.Offset + LenSelfClose
+		endOffset := endTok.Position.Offset + len(p.config.selfClose())
 		tag.RawSource = p.extractRawSource(pos.Offset, endOffset)
 		return tag, nil
 
@@ -172,6 +196,11 @@ func (p *Parser) parseBlockTag(tagName string, attrs Attributes, pos Position, o
 		return p.parseSwitch(attrs, pos)
 	}
 
+	// Case tags are only meaningful as direct children of a switch
+	if isSwitchCaseTag(tagName) {
+		return nil, p.newSwitchError(ErrMsgSwitchCaseOutsideSwitch, pos)
+	}
+
 	// Special handling for block (template inheritance)
 	if tagName == TagNameBlock {
 		return p.parseBlock(attrs, pos)
@@ -211,9 +240,9 @@ func (p *Parser) parseBlockTag(tagName string, attrs Attributes, pos Position, o
 	}
 	p.advance()
 
-	tag := NewBlockTag(tagName, attrs, children, pos)
+	tag := p.nodes.newTag(tagName, attrs, children, false, pos)
 	// Capture raw source for keepRaw error strategy (full block from open to close)
-	endOffset := closeTok.Position.Offset + LenCloseDelim
+	endOffset := closeTok.Position.Offset + len(p.config.CloseDelim)
 	tag.RawSource = p.extractRawSource(pos.Offset, endOffset)
 	return tag, nil
 }
@@ -493,7 +522,7 @@ func (p *Parser) parseSwitch(attrs Attributes, pos Position) (*SwitchNode, error
 			continue
 		}
 
-		// Expect an open tag for case or casedefault
+		// Expect an open tag for case or casedefault/default
 		if tok.Type != TokenTypeOpenTag {
 			return nil, p.newSwitchError(ErrMsgSwitchInvalidCaseTag, tok.Position)
 		}
@@ -523,7 +552,7 @@ func (p *Parser) parseSwitch(attrs Attributes, pos Position) (*SwitchNode, error
 	return nil, p.newSwitchError(ErrMsgSwitchNotClosed, pos)
 }
 
-// parseSwitchCase parses a single case or casedefault within a switch block
+// parseSwitchCase parses a single case or casedefault/default within a switch block
 // Returns: case node, isDefault flag, error
 func (p *Parser) parseSwitchCase() (SwitchCase, bool, error) {
 	openTok := p.advance() // consume OPEN_TAG
@@ -537,12 +566,12 @@ func (p *Parser) parseSwitchCase() (SwitchCase, bool, error) {
 	tagName := nameTok.Value
 	p.advance() // consume TAG_NAME
 
-	// Validate it's a case or casedefault tag
-	if tagName != TagNameCase && tagName != TagNameCaseDefault {
+	// Validate it's a case or casedefault/default tag
+	if !isSwitchCaseTag(tagName) {
 		return SwitchCase{}, false, p.newSwitchError(ErrMsgSwitchInvalidCaseTag, casePos)
 	}
 
-	isDefault := tagName == TagNameCaseDefault
+	isDefault := tagName != TagNameCase
 
 	// Parse attributes
 	attrs, err := p.parseAttributes()
@@ -559,6 +588,25 @@ func (p *Parser) parseSwitchCase() (SwitchCase, bool, error) {
 		if value == "" && eval == "" {
 			return SwitchCase{}, false, p.newSwitchError(ErrMsgSwitchMissingValue, casePos)
 		}
+		if value != "" && eval != "" {
+			return SwitchCase{}, false, p.newSwitchError(ErrMsgSwitchValueAndEval, casePos)
+		}
+	}
+
+	// Parse optional fallthrough control
+	fallthroughCase := false
+	if fallthroughStr, hasFallthrough := attrs.Get(AttrFallthrough); hasFallthrough {
+		switch fallthroughStr {
+		case AttrValueTrue:
+			fallthroughCase = true
+		case AttrValueFalse:
+			fallthroughCase = false
+		default:
+			return SwitchCase{}, false, p.newSwitchError(ErrMsgSwitchInvalidFallthrough, casePos)
+		}
+		if isDefault {
+			return SwitchCase{}, false, p.newSwitchError(ErrMsgSwitchDefaultFallthrough, casePos)
+		}
 	}
 
 	// Consume the closing tag of the case opener
@@ -574,7 +622,14 @@ func (p *Parser) parseSwitchCase() (SwitchCase, bool, error) {
 		return SwitchCase{}, false, err
 	}
 
-	return NewSwitchCase(value, eval, children, isDefault, casePos), isDefault, nil
+	caseNode := NewSwitchCase(value, eval, children, isDefault, casePos)
+	caseNode.Fallthrough = fallthroughCase
+	return caseNode, isDefault, nil
+}
+
+// isSwitchCaseTag reports whether tagName opens a case branch inside a switch
+func isSwitchCaseTag(tagName string) bool {
+	return tagName == TagNameCase || tagName == TagNameCaseDefault || tagName == TagNameDefault
 }
 
 // parseSwitchCaseBody parses the body of a case until its closing tag
@@ -584,7 +639,7 @@ func (p *Parser) parseSwitchCaseBody(closingTag string) ([]Node, error) {
 	for !p.isAtEnd() {
 		tok := p.current()
 
-		// Check for closing {~/prompty.case~} or {~/prompty.casedefault~}
+		// Check for closing {~/prompty.case~}, {~/prompty.casedefault~} or {~/prompty.default~}
 		if tok.Type == TokenTypeBlockClose {
 			if p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].Type == TokenTypeTagName {
 				nextName := p.tokens[p.pos+1].Value
@@ -666,7 +721,7 @@ func (p *Parser) parseRawBlock(pos Position, openTok Token) (*TagNode, error) {
 			p.advance()
 		case TokenTypeOpenTag:
 			// Reconstruct the tag as literal text
-			rawContent += StrOpenDelim
+			rawContent += p.config.OpenDelim
 			p.advance()
 			// Get tag content
 			tagContent, err := p.collectTagAsText()
@@ -675,7 +730,7 @@ func (p *Parser) parseRawBlock(pos Position, openTok Token) (*TagNode, error) {
 			}
 			rawContent += tagContent
 		case TokenTypeBlockClose:
-			rawContent += StrBlockClose
+			rawContent += p.config.blockClose()
 			p.advance()
 			// Get tag content
 			tagContent, err := p.collectTagCloseAsText()
@@ -708,7 +763,7 @@ func (p *Parser) parseRawBlock(pos Position, openTok Token) (*TagNode, error) {
 
 	tag := NewRawBlockTag(rawContent, pos)
 	// Capture raw source for keepRaw error strategy
-	endOffset := closeTok.Position.Offset + LenCloseDelim
+	endOffset := closeTok.Position.Offset + len(p.config.CloseDelim)
 	tag.RawSource = p.extractRawSource(pos.Offset, endOffset)
 	return tag, nil
 }
@@ -778,11 +833,11 @@ func (p *Parser) collectTagAsText() (string, error) {
 			result += "\"" + tok.Value + "\""
 			p.advance()
 		case TokenTypeSelfClose:
-			result += " " + StrSelfClose
+			result += " " + p.config.selfClose()
 			p.advance()
 			return result, nil
 		case TokenTypeCloseTag:
-			result += StrCloseDelim
+			result += p.config.CloseDelim
 			p.advance()
 			return result, nil
 		default:
@@ -804,7 +859,7 @@ func (p *Parser) collectTagCloseAsText() (string, error) {
 
 	// Close delimiter
 	if p.current().Type == TokenTypeCloseTag {
-		result += StrCloseDelim
+		result += p.config.CloseDelim
 		p.advance()
 	}
 
@@ -813,7 +868,7 @@ func (p *Parser) collectTagCloseAsText() (string, error) {
 
 // parseAttributes parses tag attributes until we hit a closing token
 func (p *Parser) parseAttributes() (Attributes, error) {
-	attrs := make(Attributes)
+	attrs := make(Attributes, p.countAttributes())
 
 	for !p.isAtEnd() {
 		tok := p.current()
@@ -849,6 +904,22 @@ func (p *Parser) parseAttributes() (Attributes, error) {
 	return attrs, nil
 }
 
+// countAttributes returns the number of attributes ahead of the current
+// token in this tag, so the attribute map is allocated at its final size.
+func (p *Parser) countAttributes() int {
+	count := 0
+	for i := p.pos; i < len(p.tokens); i++ {
+		switch p.tokens[i].Type {
+		case TokenTypeAttrName:
+			count++
+		case TokenTypeAttrValue, TokenTypeEquals:
+		default:
+			return count
+		}
+	}
+	return count
+}
+
 // Helper methods
 
 // current returns the current token
@@ -0,0 +1,222 @@
+package internal
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// BodyEdit describes a text edit of a template body in byte offsets: the
+// bytes [Start, OldEnd) of the old body were replaced by [Start, NewEnd) of
+// the new body.
+type BodyEdit struct {
+	Start  int
+	OldEnd int
+	NewEnd int
+}
+
+// ReparseIncremental parses newBody by re-parsing only the top-level nodes of
+// old around the edit. Nodes before the affected region are reused as is;
+// nodes after it are reused with their positions shifted. It reports false
+// when the region cannot be re-parsed on its own (for example because the
+// edit unbalanced a block tag or introduced a syntax error), in which case
+// the caller must parse newBody in full to get the correct AST or error.
+func ReparseIncremental(old *RootNode, oldBody, newBody string, edit BodyEdit, config LexerConfig, logger *zap.Logger) (*RootNode, bool) {
+	children := old.Children
+	if len(children) == 0 {
+		return nil, false
+	}
+
+	// Top-level node i spans [start(i), start(i+1)); dropped comments and
+	// escape sequences fall into the span of the node before them
+	start := func(i int) int {
+		switch {
+		case i <= 0:
+			return 0
+		case i >= len(children):
+			return len(oldBody)
+		}
+		return children[i].Pos().Offset
+	}
+	containing := func(offset int) int {
+		for i := len(children) - 1; i > 0; i-- {
+			if start(i) <= offset {
+				return i
+			}
+		}
+		return 0
+	}
+
+	// Widen the region by one node on each side, so edits at a node boundary
+	// that join text with a delimiter are re-lexed together
+	first := containing(edit.Start) - 1
+	if first < 0 {
+		first = 0
+	}
+	last := containing(edit.OldEnd) + 1
+	if last >= len(children) {
+		last = len(children) - 1
+	}
+	regionOldEnd := start(last + 1)
+	regionNewEnd := regionOldEnd + edit.NewEnd - edit.OldEnd
+
+	startPos := Position{Offset: 0, Line: 1, Column: 1}
+	if first > 0 {
+		startPos = children[first].Pos()
+	}
+
+	// Re-parse the region as a standalone node sequence
+	lexer := NewLexerAt(newBody[:regionNewEnd], startPos, config, logger)
+	tokens, err := lexer.Tokenize()
+	if err != nil {
+		return nil, false
+	}
+	parser := NewParserWithConfig(tokens, newBody[:regionNewEnd], config, logger)
+	region, err := parser.Parse()
+	if err != nil {
+		return nil, false
+	}
+
+	// Nodes after the region move by the change in length, and those on the
+	// line the edit ended on also by the change in column
+	oldEnd := advancePosition(startPos, oldBody[startPos.Offset:edit.OldEnd])
+	newEnd := advancePosition(startPos, newBody[startPos.Offset:edit.NewEnd])
+	shift := positionShift{from: oldEnd, to: newEnd}
+
+	nodes := make([]Node, 0, first+len(region.Children)+len(children)-last-1)
+	nodes = append(nodes, children[:first]...)
+	nodes = append(nodes, region.Children...)
+	for _, child := range children[last+1:] {
+		shifted, ok := shift.node(child)
+		if !ok {
+			return nil, false
+		}
+		nodes = append(nodes, shifted)
+	}
+	return &RootNode{Children: nodes}, true
+}
+
+// advancePosition returns the position after text, which starts at pos
+func advancePosition(pos Position, text string) Position {
+	pos.Offset += len(text)
+	if lines := strings.Count(text, StrNewline); lines > 0 {
+		pos.Line += lines
+		pos.Column = len(text) - strings.LastIndexByte(text, CharNewline)
+	} else {
+		pos.Column += len(text)
+	}
+	return pos
+}
+
+// positionShift moves positions after an edit that ended at from in the old
+// source and ends at to in the new one
+type positionShift struct {
+	from Position
+	to   Position
+}
+
+// apply returns pos moved by the shift
+func (s positionShift) apply(pos Position) Position {
+	if pos.Line == s.from.Line {
+		pos.Column += s.to.Column - s.from.Column
+	}
+	pos.Line += s.to.Line - s.from.Line
+	pos.Offset += s.to.Offset - s.from.Offset
+	return pos
+}
+
+// isZero reports whether the shift leaves positions unchanged
+func (s positionShift) isZero() bool {
+	return s.from == s.to
+}
+
+// node returns n with its positions shifted. Unshifted nodes are returned
+// as is; shifted ones are copies sharing their attributes and strings with
+// n. It reports false for node types it cannot copy.
+func (s positionShift) node(n Node) (Node, bool) {
+	if s.isZero() {
+		return n, true
+	}
+	switch n := n.(type) {
+	case *TextNode:
+		c := *n
+		c.pos = s.apply(n.pos)
+		return &c, true
+	case *TagNode:
+		c := *n
+		c.pos = s.apply(n.pos)
+		children, ok := s.nodes(n.Children)
+		c.Children = children
+		return &c, ok
+	case *BlockNode:
+		c := *n
+		c.pos = s.apply(n.pos)
+		children, ok := s.nodes(n.Children)
+		c.Children = children
+		return &c, ok
+	case *ForNode:
+		c := *n
+		c.pos = s.apply(n.pos)
+		children, ok := s.nodes(n.Children)
+		c.Children = children
+		return &c, ok
+	case *ConditionalNode:
+		c := *n
+		c.pos = s.apply(n.pos)
+		c.Branches = make([]ConditionalBranch, len(n.Branches))
+		for i, branch := range n.Branches {
+			children, ok := s.nodes(branch.Children)
+			if !ok {
+				return nil, false
+			}
+			branch.Pos = s.apply(branch.Pos)
+			branch.Children = children
+			c.Branches[i] = branch
+		}
+		return &c, true
+	case *SwitchNode:
+		c := *n
+		c.pos = s.apply(n.pos)
+		c.Cases = make([]SwitchCase, len(n.Cases))
+		for i, sc := range n.Cases {
+			shifted, ok := s.switchCase(sc)
+			if !ok {
+				return nil, false
+			}
+			c.Cases[i] = shifted
+		}
+		if n.Default != nil {
+			shifted, ok := s.switchCase(*n.Default)
+			if !ok {
+				return nil, false
+			}
+			c.Default = &shifted
+		}
+		return &c, true
+	}
+	return nil, false
+}
+
+// nodes shifts a node list, keeping nil lists nil
+func (s positionShift) nodes(nodes []Node) ([]Node, bool) {
+	if nodes == nil {
+		return nil, true
+	}
+	shifted := make([]Node, len(nodes))
+	for i, n := range nodes {
+		c, ok := s.node(n)
+		if !ok {
+			return nil, false
+		}
+		shifted[i] = c
+	}
+	return shifted, true
+}
+
+// switchCase shifts a switch case and its children
+func (s positionShift) switchCase(sc SwitchCase) (SwitchCase, bool) {
+	children, ok := s.nodes(sc.Children)
+	sc.Pos = s.apply(sc.Pos)
+	sc.Children = children
+	return sc, ok
+}
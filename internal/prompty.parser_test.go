@@ -350,6 +350,26 @@ func TestParser_Errors(t *testing.T) {
 			input:       `{~outer~}Content`,
 			errContains: ErrMsgMismatchedTag,
 		},
+		{
+			name:        "closing tag without open tag",
+			input:       `Content{~/inner~}more`,
+			errContains: ErrMsgMismatchedTag,
+		},
+		{
+			name:        "foreign closing tag in for body",
+			input:       `{~prompty.for item="x" in="y"~}{~/prompty.if~}{~/prompty.for~}`,
+			errContains: ErrMsgMismatchedTag,
+		},
+		{
+			name:        "foreign closing tag in conditional",
+			input:       `{~prompty.if eval="x"~}{~/prompty.for~}{~/prompty.if~}`,
+			errContains: ErrMsgMismatchedTag,
+		},
+		{
+			name:        "foreign closing tag in block",
+			input:       `{~prompty.block name="x"~}{~/prompty.for~}{~/prompty.block~}`,
+			errContains: ErrMsgMismatchedTag,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1139,6 +1159,64 @@ func TestParser_ParseSwitch(t *testing.T) {
 			wantErr: true,
 			errMsg:  ErrMsgSwitchCaseNotClosed,
 		},
+		{
+			name:    "default alias for casedefault",
+			input:   `{~prompty.switch eval="x"~}{~prompty.case value="1"~}One{~/prompty.case~}{~prompty.default~}Other{~/prompty.default~}{~/prompty.switch~}`,
+			wantErr: false,
+			checkAST: func(t *testing.T, ast *RootNode) {
+				switchNode := ast.Children[0].(*SwitchNode)
+				assert.Len(t, switchNode.Cases, 1)
+				require.NotNil(t, switchNode.Default)
+				assert.True(t, switchNode.Default.IsDefault)
+			},
+		},
+		{
+			name:    "default alias and casedefault are duplicates",
+			input:   `{~prompty.switch eval="x"~}{~prompty.default~}A{~/prompty.default~}{~prompty.casedefault~}B{~/prompty.casedefault~}{~/prompty.switch~}`,
+			wantErr: true,
+			errMsg:  ErrMsgSwitchDuplicateDefault,
+		},
+		{
+			name:    "case with fallthrough",
+			input:   `{~prompty.switch eval="x"~}{~prompty.case value="1" fallthrough="true"~}One{~/prompty.case~}{~prompty.case value="2" fallthrough="false"~}Two{~/prompty.case~}{~/prompty.switch~}`,
+			wantErr: false,
+			checkAST: func(t *testing.T, ast *RootNode) {
+				switchNode := ast.Children[0].(*SwitchNode)
+				require.Len(t, switchNode.Cases, 2)
+				assert.True(t, switchNode.Cases[0].Fallthrough)
+				assert.False(t, switchNode.Cases[1].Fallthrough)
+			},
+		},
+		{
+			name:    "invalid fallthrough value",
+			input:   `{~prompty.switch eval="x"~}{~prompty.case value="1" fallthrough="yes"~}One{~/prompty.case~}{~/prompty.switch~}`,
+			wantErr: true,
+			errMsg:  ErrMsgSwitchInvalidFallthrough,
+		},
+		{
+			name:    "fallthrough on default case",
+			input:   `{~prompty.switch eval="x"~}{~prompty.default fallthrough="true"~}Other{~/prompty.default~}{~/prompty.switch~}`,
+			wantErr: true,
+			errMsg:  ErrMsgSwitchDefaultFallthrough,
+		},
+		{
+			name:    "case with both value and eval",
+			input:   `{~prompty.switch eval="x"~}{~prompty.case value="1" eval="x > 0"~}One{~/prompty.case~}{~/prompty.switch~}`,
+			wantErr: true,
+			errMsg:  ErrMsgSwitchValueAndEval,
+		},
+		{
+			name:    "case outside switch",
+			input:   `{~prompty.case value="1"~}One{~/prompty.case~}`,
+			wantErr: true,
+			errMsg:  ErrMsgSwitchCaseOutsideSwitch,
+		},
+		{
+			name:    "default outside switch",
+			input:   `{~prompty.default~}Other{~/prompty.default~}`,
+			wantErr: true,
+			errMsg:  ErrMsgSwitchCaseOutsideSwitch,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1258,3 +1336,30 @@ func TestParser_ParseCommentBlock(t *testing.T) {
 		})
 	}
 }
+
+func TestParser_NodeArena(t *testing.T) {
+	tokens, err := NewLexer(`a {~x k="1" /~} b {~y~}c{~/y~}{~prompty.if eval="v"~}d{~/prompty.if~}`, nil).Tokenize()
+	require.NoError(t, err)
+	ast, err := NewParser(tokens, nil).Parse()
+	require.NoError(t, err)
+	require.Len(t, ast.Children, 5)
+
+	x := ast.Children[1].(*TagNode)
+	y := ast.Children[3].(*TagNode)
+	assert.Equal(t, "x", x.Name)
+	assert.True(t, x.SelfClose)
+	assert.Equal(t, Attributes{"k": "1"}, x.Attributes)
+	assert.Equal(t, "y", y.Name)
+	assert.False(t, y.SelfClose)
+	require.Len(t, y.Children, 1)
+	assert.Equal(t, "c", y.Children[0].(*TextNode).Content)
+	assert.Equal(t, " b ", ast.Children[2].(*TextNode).Content)
+
+	// A full arena falls back to individual allocations
+	arena := newNodeArena(nil)
+	text := arena.newText("t", Position{})
+	tag := arena.newTag("n", nil, nil, true, Position{})
+	assert.Equal(t, "t", text.Content)
+	assert.True(t, tag.SelfClose)
+	assert.NotSame(t, text, arena.newText("t", Position{}))
+}
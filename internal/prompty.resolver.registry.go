@@ -3,8 +3,10 @@ package internal
 import (
 	"context"
 	"fmt"
+	"maps"
 	"sort"
 	"sync"
+	"sync/atomic"
 
 	"go.uber.org/zap"
 )
@@ -18,23 +20,35 @@ type InternalResolver interface {
 }
 
 // Registry manages resolver registration with first-come-wins semantics.
-// It is thread-safe for concurrent read/write access.
+// It is copy-on-write: lookups read an immutable state without locking, and
+// each registration publishes a new state, so registering resolvers never
+// races with executions that are resolving tags.
 type Registry struct {
+	state  atomic.Pointer[registryState]
+	mu     sync.Mutex // Serializes writers
+	logger *zap.Logger
+	frozen bool // Set on snapshots, which reject registrations
+}
+
+// registryState is an immutable set of registered resolvers.
+type registryState struct {
 	resolvers map[string]InternalResolver
-	mu        sync.RWMutex
-	logger    *zap.Logger
+	wrap      ResolverWrapper             // Optional decorator applied to every resolver
+	wrapped   map[string]InternalResolver // Decorated resolvers by tag name
 }
 
+// ResolverWrapper decorates a resolver, e.g. with a middleware chain.
+type ResolverWrapper func(InternalResolver) InternalResolver
+
 // NewRegistry creates a new resolver registry.
 func NewRegistry(logger *zap.Logger) *Registry {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
 	logger.Debug(LogMsgRegistryCreated)
-	return &Registry{
-		resolvers: make(map[string]InternalResolver),
-		logger:    logger,
-	}
+	r := &Registry{logger: logger}
+	r.state.Store(&registryState{resolvers: make(map[string]InternalResolver)})
+	return r
 }
 
 // Register adds a resolver to the registry.
@@ -49,11 +63,15 @@ func (r *Registry) Register(resolver InternalResolver) error {
 	if tagName == "" {
 		return NewRegistryError(ErrMsgEmptyTagName, "")
 	}
+	if r.frozen {
+		return NewRegistryError(ErrMsgRegistryFrozen, tagName)
+	}
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if existing, exists := r.resolvers[tagName]; exists {
+	old := r.state.Load()
+	if existing, exists := old.resolvers[tagName]; exists {
 		// First-come-wins: log collision but don't panic
 		r.logger.Warn(LogMsgResolverCollision,
 			zap.String(LogFieldTagName, tagName),
@@ -62,11 +80,47 @@ func (r *Registry) Register(resolver InternalResolver) error {
 		return NewRegistryError(ErrMsgResolverAlreadyExists, tagName)
 	}
 
-	r.resolvers[tagName] = resolver
+	state := &registryState{
+		resolvers: maps.Clone(old.resolvers),
+		wrap:      old.wrap,
+		wrapped:   maps.Clone(old.wrapped),
+	}
+	state.resolvers[tagName] = resolver
+	if state.wrap != nil {
+		state.wrapped[tagName] = state.wrap(resolver)
+	}
+	r.state.Store(state)
 	r.logger.Debug(LogMsgResolverRegistered, zap.String(LogFieldTagName, tagName))
 	return nil
 }
 
+// Unregister removes the resolver for tagName. It returns false if no
+// resolver is registered for it or the registry is a snapshot.
+func (r *Registry) Unregister(tagName string) bool {
+	if r.frozen {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.state.Load()
+	if _, exists := old.resolvers[tagName]; !exists {
+		return false
+	}
+
+	state := &registryState{
+		resolvers: maps.Clone(old.resolvers),
+		wrap:      old.wrap,
+		wrapped:   maps.Clone(old.wrapped),
+	}
+	delete(state.resolvers, tagName)
+	delete(state.wrapped, tagName)
+	r.state.Store(state)
+	r.logger.Debug(LogMsgResolverUnregistered, zap.String(LogFieldTagName, tagName))
+	return true
+}
+
 // MustRegister adds a resolver and panics if registration fails.
 // Use this for built-in resolvers that must always be available.
 func (r *Registry) MustRegister(resolver InternalResolver) {
@@ -75,32 +129,79 @@ func (r *Registry) MustRegister(resolver InternalResolver) {
 	}
 }
 
-// Get retrieves a resolver by tag name.
-// Returns the resolver and true if found, or nil and false if not.
+// Get retrieves a resolver by tag name, decorated by the registry's wrapper
+// if one is set. Returns the resolver and true if found, or nil and false if not.
 func (r *Registry) Get(tagName string) (InternalResolver, bool) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	state := r.state.Load()
+	if state.wrap != nil {
+		resolver, exists := state.wrapped[tagName]
+		return resolver, exists
+	}
+	resolver, exists := state.resolvers[tagName]
+	return resolver, exists
+}
 
-	resolver, exists := r.resolvers[tagName]
+// GetUnwrapped retrieves a resolver by tag name as it was registered,
+// without the registry's wrapper.
+func (r *Registry) GetUnwrapped(tagName string) (InternalResolver, bool) {
+	resolver, exists := r.state.Load().resolvers[tagName]
 	return resolver, exists
 }
 
+// SetWrapper sets the decorator applied to every registered resolver,
+// including resolvers registered later. Each resolver is decorated once, so
+// state held by the decorator persists across invocations until the wrapper
+// is replaced. A nil wrapper removes the decoration. Snapshots keep the
+// wrapper they were taken with.
+func (r *Registry) SetWrapper(wrap ResolverWrapper) {
+	if r.frozen {
+		r.logger.Warn(LogMsgRegistryFrozen)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.state.Load()
+	state := &registryState{resolvers: old.resolvers, wrap: wrap}
+	if wrap != nil {
+		state.wrapped = make(map[string]InternalResolver, len(old.resolvers))
+		for tagName, resolver := range old.resolvers {
+			state.wrapped[tagName] = wrap(resolver)
+		}
+	}
+	r.state.Store(state)
+}
+
+// Snapshot returns a read-only registry with the resolvers currently
+// registered. Later registrations do not affect the snapshot, and
+// registering with the snapshot fails.
+func (r *Registry) Snapshot() *Registry {
+	snapshot := &Registry{logger: r.logger, frozen: true}
+	snapshot.state.Store(r.state.Load())
+	return snapshot
+}
+
+// Clone returns a writable registry with the resolvers and wrapper of r.
+// Both share the current resolvers until either registers one or sets a
+// wrapper.
+func (r *Registry) Clone() *Registry {
+	clone := &Registry{logger: r.logger}
+	clone.state.Store(r.state.Load())
+	return clone
+}
+
 // Has checks if a resolver is registered for the given tag name.
 func (r *Registry) Has(tagName string) bool {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	_, exists := r.resolvers[tagName]
+	_, exists := r.state.Load().resolvers[tagName]
 	return exists
 }
 
 // List returns all registered tag names in sorted order.
 func (r *Registry) List() []string {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	names := make([]string, 0, len(r.resolvers))
-	for name := range r.resolvers {
+	resolvers := r.state.Load().resolvers
+	names := make([]string, 0, len(resolvers))
+	for name := range resolvers {
 		names = append(names, name)
 	}
 	sort.Strings(names)
@@ -109,10 +210,7 @@ func (r *Registry) List() []string {
 
 // Count returns the number of registered resolvers.
 func (r *Registry) Count() int {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	return len(r.resolvers)
+	return len(r.state.Load().resolvers)
 }
 
 // RegistryError represents a registry operation error
@@ -143,6 +241,7 @@ const (
 	ErrMsgEmptyTagName          = "resolver tag name cannot be empty"
 	ErrMsgResolverAlreadyExists = "resolver already registered for tag"
 	ErrMsgResolverUnknown       = "no resolver registered for tag"
+	ErrMsgRegistryFrozen        = "registry snapshot is read-only"
 )
 
 // Additional log field constants for registry
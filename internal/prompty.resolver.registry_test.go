@@ -164,6 +164,75 @@ func TestRegistry_Get(t *testing.T) {
 	})
 }
 
+func TestRegistry_SetWrapper(t *testing.T) {
+	reg := NewRegistry(nil)
+	reg.MustRegister(newMockResolver("early.tag"))
+
+	wraps := 0
+	reg.SetWrapper(func(next InternalResolver) InternalResolver {
+		wraps++
+		mock := newMockResolver(next.TagName())
+		mock.resolveFunc = func(ctx context.Context, execCtx interface{}, attrs Attributes) (string, error) {
+			result, err := next.Resolve(ctx, execCtx, attrs)
+			return "[" + result + "]", err
+		}
+		return mock
+	})
+	reg.MustRegister(newMockResolver("late.tag"))
+
+	for _, name := range []string{"early.tag", "late.tag"} {
+		resolver, ok := reg.Get(name)
+		require.True(t, ok)
+		result, err := resolver.Resolve(context.Background(), nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "[resolved:"+name+"]", result)
+	}
+
+	// Resolvers are wrapped once, not per lookup
+	reg.Get("early.tag")
+	assert.Equal(t, 2, wraps)
+
+	reg.SetWrapper(nil)
+	resolver, _ := reg.Get("early.tag")
+	result, _ := resolver.Resolve(context.Background(), nil, nil)
+	assert.Equal(t, "resolved:early.tag", result)
+}
+
+func TestRegistry_Snapshot(t *testing.T) {
+	reg := NewRegistry(nil)
+	reg.MustRegister(newMockResolver("early.tag"))
+	reg.SetWrapper(func(next InternalResolver) InternalResolver {
+		mock := newMockResolver(next.TagName())
+		mock.resolveFunc = func(ctx context.Context, execCtx interface{}, attrs Attributes) (string, error) {
+			result, err := next.Resolve(ctx, execCtx, attrs)
+			return "[" + result + "]", err
+		}
+		return mock
+	})
+
+	snapshot := reg.Snapshot()
+	reg.MustRegister(newMockResolver("late.tag"))
+	reg.SetWrapper(nil)
+
+	// The snapshot keeps the resolvers and wrapper it was taken with
+	assert.Equal(t, []string{"early.tag"}, snapshot.List())
+	assert.False(t, snapshot.Has("late.tag"))
+	resolver, ok := snapshot.Get("early.tag")
+	require.True(t, ok)
+	result, err := resolver.Resolve(context.Background(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[resolved:early.tag]", result)
+	assert.Equal(t, 2, reg.Count())
+
+	err = snapshot.Register(newMockResolver("other.tag"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgRegistryFrozen)
+	snapshot.SetWrapper(nil)
+	resolver, _ = snapshot.Get("early.tag")
+	result, _ = resolver.Resolve(context.Background(), nil, nil)
+	assert.Equal(t, "[resolved:early.tag]", result)
+}
+
 func TestRegistry_Has(t *testing.T) {
 	reg := NewRegistry(nil)
 	resolver := newMockResolver("test.tag")
@@ -173,6 +242,23 @@ func TestRegistry_Has(t *testing.T) {
 	assert.False(t, reg.Has("nonexistent"))
 }
 
+func TestRegistry_Unregister(t *testing.T) {
+	reg := NewRegistry(nil)
+	reg.MustRegister(newMockResolver("test.tag"))
+	snapshot := reg.Snapshot()
+
+	assert.True(t, reg.Unregister("test.tag"))
+	assert.False(t, reg.Has("test.tag"))
+	assert.False(t, reg.Unregister("test.tag"))
+
+	// Snapshots are read-only and keep their resolvers
+	assert.False(t, snapshot.Unregister("test.tag"))
+	assert.True(t, snapshot.Has("test.tag"))
+
+	// The tag name can be registered again
+	require.NoError(t, reg.Register(newMockResolver("test.tag")))
+}
+
 func TestRegistry_List(t *testing.T) {
 	t.Run("empty registry", func(t *testing.T) {
 		reg := NewRegistry(nil)
@@ -0,0 +1,30 @@
+package playground
+
+import "time"
+
+// Routes served by Handler, relative to its mount point
+const (
+	PathIndex = "/"
+	PathRun   = "/api/run"
+)
+
+// Handler defaults
+const (
+	DefaultTitle       = "prompty playground"
+	DefaultTimeout     = 5 * time.Second
+	DefaultMaxBodySize = 1 << 20 // 1 MiB
+)
+
+// HTTP header values
+const (
+	HeaderContentType   = "Content-Type"
+	ContentTypeJSON     = "application/json"
+	ContentTypeHTML     = "text/html; charset=utf-8"
+	HeaderCacheControl  = "Cache-Control"
+	CacheControlNoStore = "no-store"
+)
+
+// Error messages
+const (
+	ErrMsgInvalidBody = "invalid request body: expected {\"template\": string, \"data\": object}"
+)
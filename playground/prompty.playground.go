@@ -0,0 +1,196 @@
+// Package playground provides an embeddable web UI for iterating on prompty
+// templates: edit a template, supply JSON data, and see the rendered output
+// together with DryRun analysis and Explain timing.
+//
+// The handler is intended for internal tooling. Templates are executed with
+// the configured engine, so any resolver it has registered is reachable by
+// whoever can reach the handler. The default engine leaves out prompty.env,
+// so requests cannot read the server's environment. Authentication is left
+// to middleware.
+//
+//	mux.Handle("/playground/", http.StripPrefix("/playground", playground.Handler(
+//		playground.WithEngine(engine),
+//	)))
+package playground
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+//go:embed prompty.playground.html
+var pageSource string
+
+// pageTemplate renders the single-page UI
+var pageTemplate = template.Must(template.New("playground").Parse(pageSource))
+
+// RunRequest is the body of a POST to PathRun.
+type RunRequest struct {
+	Template string         `json:"template"`
+	Data     map[string]any `json:"data,omitempty"`
+}
+
+// RunResponse is the result of a POST to PathRun. Template errors are
+// reported in Error with status 200; only malformed requests fail.
+type RunResponse struct {
+	Output           string    `json:"output"`
+	Error            string    `json:"error,omitempty"`
+	DryRun           string    `json:"dry_run,omitempty"`
+	Explain          string    `json:"explain,omitempty"`
+	MissingVariables []string  `json:"missing_variables,omitempty"`
+	UnusedVariables  []string  `json:"unused_variables,omitempty"`
+	Timing           RunTiming `json:"timing"`
+}
+
+// RunTiming reports durations of one run in Go duration notation (e.g. "1.2ms").
+type RunTiming struct {
+	Parse     string `json:"parse"`
+	Execution string `json:"execution,omitempty"`
+	Total     string `json:"total"`
+}
+
+// errorBody is the JSON body of a failed request
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// config holds Handler settings
+type config struct {
+	engine      *prompty.Engine
+	title       string
+	timeout     time.Duration
+	maxBodySize int64
+}
+
+// Option configures Handler.
+type Option func(*config)
+
+// WithEngine sets the engine used to parse and execute templates, so its
+// registered templates, resolvers and functions are available. Build it
+// with prompty.WithoutBuiltins(prompty.TagNameEnv) unless its users may read
+// the server's environment variables.
+// Default: prompty.MustNew(prompty.WithoutBuiltins(prompty.TagNameEnv))
+func WithEngine(engine *prompty.Engine) Option {
+	return func(c *config) {
+		c.engine = engine
+	}
+}
+
+// WithTitle sets the page title.
+// Default: DefaultTitle
+func WithTitle(title string) Option {
+	return func(c *config) {
+		c.title = title
+	}
+}
+
+// WithTimeout limits the execution time of a single run.
+// Default: DefaultTimeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithMaxBodySize limits the size of a run request body in bytes.
+// Default: DefaultMaxBodySize
+func WithMaxBodySize(size int64) Option {
+	return func(c *config) {
+		c.maxBodySize = size
+	}
+}
+
+// Handler returns an http.Handler serving the playground UI at PathIndex
+// and its JSON API at PathRun. The page uses relative URLs, so it can be
+// mounted below any prefix with http.StripPrefix.
+func Handler(opts ...Option) http.Handler {
+	cfg := &config{
+		title:       DefaultTitle,
+		timeout:     DefaultTimeout,
+		maxBodySize: DefaultMaxBodySize,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.engine == nil {
+		cfg.engine = prompty.MustNew(prompty.WithoutBuiltins(prompty.TagNameEnv))
+	}
+
+	var page bytes.Buffer
+	_ = pageTemplate.Execute(&page, struct{ Title string }{cfg.title})
+
+	h := &handler{config: cfg, page: page.Bytes()}
+	mux := http.NewServeMux()
+	mux.HandleFunc(http.MethodGet+" "+PathIndex+"{$}", h.index)
+	mux.HandleFunc(http.MethodPost+" "+PathRun, h.run)
+	return mux
+}
+
+// handler serves the playground.
+type handler struct {
+	*config
+	page []byte
+}
+
+func (h *handler) index(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set(HeaderContentType, ContentTypeHTML)
+	w.Header().Set(HeaderCacheControl, CacheControlNoStore)
+	_, _ = w.Write(h.page)
+}
+
+func (h *handler) run(w http.ResponseWriter, r *http.Request) {
+	var req RunRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, h.maxBodySize)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorBody{Error: ErrMsgInvalidBody})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	writeJSON(w, http.StatusOK, h.execute(ctx, &req))
+}
+
+// execute parses the template and collects its DryRun and Explain results.
+func (h *handler) execute(ctx context.Context, req *RunRequest) *RunResponse {
+	start := time.Now()
+	resp := &RunResponse{}
+
+	tmpl, err := h.engine.Parse(req.Template)
+	resp.Timing.Parse = time.Since(start).String()
+	if err != nil {
+		resp.Error = err.Error()
+		resp.Timing.Total = time.Since(start).String()
+		return resp
+	}
+
+	dryRun := tmpl.DryRun(ctx, req.Data)
+	resp.DryRun = dryRun.String()
+	resp.MissingVariables = dryRun.MissingVariables
+	resp.UnusedVariables = dryRun.UnusedVariables
+
+	explain := tmpl.Explain(ctx, req.Data)
+	resp.Explain = explain.String()
+	resp.Output = explain.Output
+	if explain.Error != nil {
+		resp.Error = explain.Error.Error()
+	}
+	resp.Timing.Execution = explain.Timing.Execution.String()
+	resp.Timing.Total = time.Since(start).String()
+	return resp
+}
+
+// writeJSON writes value as a JSON response with the given status.
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set(HeaderContentType, ContentTypeJSON)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,174 @@
+package playground
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/itsatony/go-prompty/v2"
+)
+
+func postRun(t *testing.T, h http.Handler, body string) (*httptest.ResponseRecorder, *RunResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, PathRun, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var resp RunResponse
+	if rec.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	}
+	return rec, &resp
+}
+
+func TestHandler_Index(t *testing.T) {
+	h := Handler(WithTitle("Prompt <Lab>"))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, PathIndex, nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ContentTypeHTML, rec.Header().Get(HeaderContentType))
+	assert.Contains(t, rec.Body.String(), "<title>Prompt &lt;Lab&gt;</title>")
+	assert.Contains(t, rec.Body.String(), `fetch("api/run"`)
+}
+
+func TestHandler_UnknownPath(t *testing.T) {
+	h := Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, PathRun, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestHandler_Run(t *testing.T) {
+	h := Handler()
+
+	rec, resp := postRun(t, h, `{"template": "Hello {~prompty.var name=\"user.name\" /~}!", "data": {"user": {"name": "Alice"}, "extra": 1}}`)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ContentTypeJSON, rec.Header().Get(HeaderContentType))
+	assert.Empty(t, resp.Error)
+	assert.Equal(t, "Hello Alice!", resp.Output)
+	assert.Contains(t, resp.DryRun, "user.name")
+	assert.Contains(t, resp.Explain, "Hello Alice!")
+	assert.Equal(t, []string{"extra"}, resp.UnusedVariables)
+	assert.NotEmpty(t, resp.Timing.Parse)
+	assert.NotEmpty(t, resp.Timing.Execution)
+	assert.NotEmpty(t, resp.Timing.Total)
+}
+
+func TestHandler_RunMissingVariable(t *testing.T) {
+	h := Handler()
+
+	rec, resp := postRun(t, h, `{"template": "{~prompty.var name=\"missing\" /~}"}`)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEmpty(t, resp.Error)
+	assert.Equal(t, []string{"missing"}, resp.MissingVariables)
+}
+
+func TestHandler_RunParseError(t *testing.T) {
+	h := Handler()
+
+	rec, resp := postRun(t, h, `{"template": "{~prompty.if eval=\"x\"~}unclosed"}`)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEmpty(t, resp.Error)
+	assert.Empty(t, resp.Output)
+	assert.Empty(t, resp.DryRun)
+	assert.NotEmpty(t, resp.Timing.Parse)
+	assert.Empty(t, resp.Timing.Execution)
+}
+
+func TestHandler_RunInvalidBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "not json", body: "template"},
+		{name: "data not an object", body: `{"template": "x", "data": [1, 2]}`},
+	}
+
+	h := Handler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, _ := postRun(t, h, tt.body)
+
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+			var body errorBody
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, ErrMsgInvalidBody, body.Error)
+		})
+	}
+}
+
+func TestHandler_MaxBodySize(t *testing.T) {
+	h := Handler(WithMaxBodySize(16))
+
+	rec, _ := postRun(t, h, `{"template": "far more than sixteen bytes"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandler_DefaultEngineHidesEnvironment(t *testing.T) {
+	t.Setenv("PLAYGROUND_SECRET", "s3cr3t")
+	h := Handler()
+
+	for _, template := range []string{
+		`{~prompty.env name=\"PLAYGROUND_SECRET\" /~}`,
+		`---\nname: \"{~prompty.env name='PLAYGROUND_SECRET' /~}\"\n---\nHi`,
+	} {
+		rec, resp := postRun(t, h, `{"template": "`+template+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEmpty(t, resp.Error)
+		assert.NotContains(t, rec.Body.String(), "s3cr3t")
+	}
+}
+
+func TestHandler_WithEngine(t *testing.T) {
+	engine := prompty.MustNew()
+	require.NoError(t, engine.RegisterTemplate("greeting", "Hi {~prompty.var name=\"name\" /~}"))
+	require.NoError(t, engine.RegisterFunc(&prompty.Func{
+		Name:    "shout",
+		MinArgs: 1,
+		MaxArgs: 1,
+		Fn: func(args []any) (any, error) {
+			return strings.ToUpper(args[0].(string)), nil
+		},
+	}))
+	h := Handler(WithEngine(engine))
+
+	_, resp := postRun(t, h, `{"template": "{~prompty.include template=\"greeting\" with=\"user\" /~}{~prompty.if eval=\"shout(user.name) == 'BOB'\"~}!{~/prompty.if~}", "data": {"user": {"name": "Bob"}}}`)
+
+	assert.Empty(t, resp.Error)
+	assert.Equal(t, "Hi Bob!", resp.Output)
+}
+
+func TestHandler_StripPrefix(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/playground/", http.StripPrefix("/playground", Handler()))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost,
+		server.URL+"/playground"+PathRun, strings.NewReader(`{"template": "ok"}`))
+	require.NoError(t, err)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var resp RunResponse
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+	assert.Equal(t, "ok", resp.Output)
+}
@@ -0,0 +1,195 @@
+package prompty
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// File auditor defaults
+const (
+	// DefaultAuditMaxFiles is the number of rotated audit logs kept.
+	DefaultAuditMaxFiles = 5
+)
+
+// FileAuditorConfig configures rotation and retention of a FileAuditor.
+type FileAuditorConfig struct {
+	// MaxBytes rotates the log before it grows beyond the size (0 = never).
+	MaxBytes int64
+
+	// MaxFiles is the number of rotated logs kept (default DefaultAuditMaxFiles).
+	MaxFiles int
+
+	// MaxAge removes rotated logs last written before the duration, and
+	// leaves older events out of queries (0 = keep).
+	MaxAge time.Duration
+}
+
+// FileAuditor writes audit events as JSON Lines to a file, rotating it to
+// <path>.1, <path>.2, ... by size or on Rotate. It is a QueryableAuditor
+// reading the current and rotated logs.
+type FileAuditor struct {
+	mu     sync.Mutex
+	path   string
+	config FileAuditorConfig
+}
+
+// NewFileAuditor creates an auditor appending to the file at path. The
+// directory will be created if it doesn't exist.
+func NewFileAuditor(path string, config FileAuditorConfig) (*FileAuditor, error) {
+	if path == "" {
+		return nil, &StorageError{Message: ErrMsgInvalidStorageRoot}
+	}
+	if config.MaxFiles <= 0 {
+		config.MaxFiles = DefaultAuditMaxFiles
+	}
+	if err := os.MkdirAll(filepath.Dir(path), FilesystemDirPermissions); err != nil {
+		return nil, &StorageError{Message: ErrMsgCreateStorageDir, Name: path, Cause: err}
+	}
+	return &FileAuditor{path: path, config: config}, nil
+}
+
+// Log appends the event to the log, rotating it first when it would grow
+// beyond MaxBytes.
+func (a *FileAuditor) Log(ctx context.Context, event *AccessAuditEvent) error {
+	line, err := json.Marshal(newAuditRecord(event))
+	if err != nil {
+		return &StorageError{Message: ErrMsgWriteAuditLog, Name: a.path, Cause: err}
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.config.MaxBytes > 0 {
+		if info, err := os.Stat(a.path); err == nil && info.Size() > 0 && info.Size()+int64(len(line)) > a.config.MaxBytes {
+			if err := a.rotate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, FilesystemFilePermissions)
+	if err != nil {
+		return &StorageError{Message: ErrMsgWriteAuditLog, Name: a.path, Cause: err}
+	}
+	if _, err := f.Write(line); err != nil {
+		_ = f.Close()
+		return &StorageError{Message: ErrMsgWriteAuditLog, Name: a.path, Cause: err}
+	}
+	if err := f.Close(); err != nil {
+		return &StorageError{Message: ErrMsgWriteAuditLog, Name: a.path, Cause: err}
+	}
+	return nil
+}
+
+// Rotate moves the current log to <path>.1, e.g. for daily rotation, and
+// removes rotated logs beyond MaxFiles or older than MaxAge.
+func (a *FileAuditor) Rotate() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.rotate()
+}
+
+// Query reads the rotated and current logs, oldest first, and returns the
+// events matching the query.
+func (a *FileAuditor) Query(ctx context.Context, query *AuditQuery) ([]*AccessAuditEvent, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	cutoff := time.Time{}
+	if a.config.MaxAge > 0 {
+		cutoff = timeNow().Add(-a.config.MaxAge)
+	}
+
+	var events []*AccessAuditEvent
+	for i := a.config.MaxFiles; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		fileEvents, err := readAuditLog(a.logPath(i))
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range fileEvents {
+			if cutoff.IsZero() || !event.Timestamp.Before(cutoff) {
+				events = append(events, event)
+			}
+		}
+	}
+	return query.apply(events), nil
+}
+
+// rotate shifts the logs by one. Caller must hold the lock.
+func (a *FileAuditor) rotate() error {
+	if err := os.Remove(a.logPath(a.config.MaxFiles)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return &StorageError{Message: ErrMsgWriteAuditLog, Name: a.path, Cause: err}
+	}
+	for i := a.config.MaxFiles - 1; i >= 0; i-- {
+		err := os.Rename(a.logPath(i), a.logPath(i+1))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return &StorageError{Message: ErrMsgWriteAuditLog, Name: a.path, Cause: err}
+		}
+	}
+
+	if a.config.MaxAge <= 0 {
+		return nil
+	}
+	cutoff := timeNow().Add(-a.config.MaxAge)
+	for i := 1; i <= a.config.MaxFiles; i++ {
+		info, err := os.Stat(a.logPath(i))
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(a.logPath(i)); err != nil {
+			return &StorageError{Message: ErrMsgWriteAuditLog, Name: a.path, Cause: err}
+		}
+	}
+	return nil
+}
+
+// logPath returns the path of the current log (0) or a rotated log.
+func (a *FileAuditor) logPath(index int) string {
+	if index == 0 {
+		return a.path
+	}
+	return a.path + "." + strconv.Itoa(index)
+}
+
+// readAuditLog reads the events of a log file; a missing file has none.
+func readAuditLog(path string) ([]*AccessAuditEvent, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &StorageError{Message: ErrMsgReadAuditLog, Name: path, Cause: err}
+	}
+
+	var events []*AccessAuditEvent
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record auditRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, &StorageError{Message: ErrMsgReadAuditLog, Name: path, Cause: err}
+		}
+		events = append(events, record.event())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, &StorageError{Message: ErrMsgReadAuditLog, Name: path, Cause: err}
+	}
+	return events, nil
+}
@@ -25,6 +25,10 @@ type AccessAuditEvent struct {
 	// Subject identifies who requested access.
 	Subject *AccessSubject
 
+	// OnBehalfOf is the end user the subject acted for in a delegated
+	// request (nil otherwise).
+	OnBehalfOf *AccessSubject
+
 	// Operation is the type of access requested.
 	Operation Operation
 
@@ -37,6 +41,9 @@ type AccessAuditEvent struct {
 	// TemplateVersion is the version of the template (if known).
 	TemplateVersion int
 
+	// ContentHash is the content hash of the template version (if known).
+	ContentHash string
+
 	// Decision is the access control decision.
 	Decision *AccessDecision
 
@@ -52,7 +59,7 @@ type AccessAuditEvent struct {
 
 // NewAccessAuditEvent creates a new audit event.
 func NewAccessAuditEvent(op Operation, templateName string, subject *AccessSubject, decision *AccessDecision) *AccessAuditEvent {
-	return &AccessAuditEvent{
+	event := &AccessAuditEvent{
 		Timestamp:    timeNow(),
 		Operation:    op,
 		TemplateName: templateName,
@@ -60,6 +67,10 @@ func NewAccessAuditEvent(op Operation, templateName string, subject *AccessSubje
 		Decision:     decision,
 		Metadata:     make(map[string]any),
 	}
+	if subject.IsDelegated() {
+		event.OnBehalfOf = subject.OnBehalfOf
+	}
+	return event
 }
 
 // WithRequestID sets the request ID.
@@ -74,6 +85,7 @@ func (e *AccessAuditEvent) WithTemplate(tmpl *StoredTemplate) *AccessAuditEvent
 		e.TemplateID = tmpl.ID
 		e.TemplateName = tmpl.Name
 		e.TemplateVersion = tmpl.Version
+		e.ContentHash = storedContentHash(tmpl)
 	}
 	return e
 }
@@ -168,9 +180,10 @@ func (a *NoOpAuditor) Log(ctx context.Context, event *AccessAuditEvent) error {
 // MemoryAuditor stores audit events in memory.
 // Useful for testing and debugging.
 type MemoryAuditor struct {
-	mu     sync.RWMutex
-	events []*AccessAuditEvent
-	limit  int
+	mu        sync.RWMutex
+	events    []*AccessAuditEvent
+	limit     int
+	retention AuditRetention
 }
 
 // NewMemoryAuditor creates an in-memory auditor.
@@ -182,6 +195,18 @@ func NewMemoryAuditor(limit int) *MemoryAuditor {
 	}
 }
 
+// WithRetention sets a retention policy, applied as events are logged.
+// MaxEvents replaces the limit of NewMemoryAuditor.
+func (a *MemoryAuditor) WithRetention(retention AuditRetention) *MemoryAuditor {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.retention = retention
+	if retention.MaxEvents > 0 {
+		a.limit = retention.MaxEvents
+	}
+	return a
+}
+
 // Log stores an event in memory.
 func (a *MemoryAuditor) Log(ctx context.Context, event *AccessAuditEvent) error {
 	a.mu.Lock()
@@ -194,9 +219,30 @@ func (a *MemoryAuditor) Log(ctx context.Context, event *AccessAuditEvent) error
 		a.events = a.events[len(a.events)-a.limit:]
 	}
 
+	// Drop expired events
+	if cutoff := a.retention.cutoff(timeNow()); !cutoff.IsZero() {
+		kept := a.events[:0]
+		for _, e := range a.events {
+			if !e.Timestamp.Before(cutoff) {
+				kept = append(kept, e)
+			}
+		}
+		a.events = kept
+	}
+
 	return nil
 }
 
+// Query returns the stored events matching the query, oldest first.
+func (a *MemoryAuditor) Query(ctx context.Context, query *AuditQuery) ([]*AccessAuditEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return query.apply(a.events), nil
+}
+
 // Events returns all stored events.
 func (a *MemoryAuditor) Events() []*AccessAuditEvent {
 	a.mu.RLock()
@@ -308,6 +354,16 @@ func (a *MultiAuditor) Log(ctx context.Context, event *AccessAuditEvent) error {
 	return lastErr
 }
 
+// Query queries the first auditor that is a QueryableAuditor.
+func (a *MultiAuditor) Query(ctx context.Context, query *AuditQuery) ([]*AccessAuditEvent, error) {
+	for _, auditor := range a.auditors {
+		if queryable, ok := auditor.(QueryableAuditor); ok {
+			return queryable.Query(ctx, query)
+		}
+	}
+	return nil, &AccessError{Message: ErrMsgAuditNotQueryable}
+}
+
 // AddAuditor adds an auditor to the multi-auditor.
 func (a *MultiAuditor) AddAuditor(auditor AccessAuditor) {
 	a.auditors = append(a.auditors, auditor)
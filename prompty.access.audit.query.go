@@ -0,0 +1,312 @@
+package prompty
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+// AuditDecision filters audit events by their access decision.
+type AuditDecision string
+
+// Audit decision filters.
+const (
+	AuditDecisionAllowed AuditDecision = "allowed"
+	AuditDecisionDenied  AuditDecision = "denied"
+)
+
+// AuditExportFormat is a format of ExportAuditEvents.
+type AuditExportFormat string
+
+// Audit export formats.
+const (
+	// AuditExportJSON writes a JSON array of audit records.
+	AuditExportJSON AuditExportFormat = "json"
+	// AuditExportCSV writes a header row and one row per event.
+	AuditExportCSV AuditExportFormat = "csv"
+)
+
+// Audit log error messages.
+const (
+	ErrMsgAuditExportFormat = "unsupported audit export format"
+	ErrMsgAuditNotQueryable = "no queryable auditor configured"
+	ErrMsgWriteAuditLog     = "failed to write audit log"
+	ErrMsgReadAuditLog      = "failed to read audit log"
+)
+
+// auditCSVHeader lists the columns of CSV exports.
+var auditCSVHeader = []string{
+	"timestamp", "request_id", "subject_id", "subject_type", "tenant_id", "on_behalf_of",
+	"operation", "template_name", "template_id", "template_version", "content_hash",
+	"decision", "reason", "duration_ms", "error",
+}
+
+// QueryableAuditor is an auditor whose events can be queried, e.g. to
+// answer "who executed template X last month" in a compliance review.
+type QueryableAuditor interface {
+	AccessAuditor
+
+	// Query returns the events matching the query, oldest first.
+	Query(ctx context.Context, query *AuditQuery) ([]*AccessAuditEvent, error)
+}
+
+// AuditQuery filters audit events. Empty fields match all events.
+type AuditQuery struct {
+	// SubjectID matches the subject, or the end user it acted for.
+	SubjectID string
+
+	// TenantID matches the subject's tenant.
+	TenantID string
+
+	// Operations matches any of the operations.
+	Operations []Operation
+
+	// TemplateName matches the template.
+	TemplateName string
+
+	// Since matches events at or after the time.
+	Since time.Time
+
+	// Until matches events before the time.
+	Until time.Time
+
+	// Decision matches allowed or denied events.
+	Decision AuditDecision
+
+	// Offset skips the first matching events.
+	Offset int
+
+	// Limit caps the number of events returned (0 = no limit).
+	Limit int
+}
+
+// Matches reports whether the event matches the query filters.
+func (q *AuditQuery) Matches(event *AccessAuditEvent) bool {
+	if q == nil {
+		return true
+	}
+	if q.SubjectID != "" && !auditSubjectMatches(event, q.SubjectID) {
+		return false
+	}
+	if q.TenantID != "" && (event.Subject == nil || event.Subject.TenantID != q.TenantID) {
+		return false
+	}
+	if len(q.Operations) > 0 && !containsOperation(q.Operations, event.Operation) {
+		return false
+	}
+	if q.TemplateName != "" && event.TemplateName != q.TemplateName {
+		return false
+	}
+	if !q.Since.IsZero() && event.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !event.Timestamp.Before(q.Until) {
+		return false
+	}
+	switch q.Decision {
+	case AuditDecisionAllowed:
+		return event.Decision != nil && event.Decision.Allowed
+	case AuditDecisionDenied:
+		return event.Decision == nil || !event.Decision.Allowed
+	}
+	return true
+}
+
+// apply filters events and applies the offset and limit of the query.
+func (q *AuditQuery) apply(events []*AccessAuditEvent) []*AccessAuditEvent {
+	result := make([]*AccessAuditEvent, 0)
+	skipped := 0
+	for _, event := range events {
+		if !q.Matches(event) {
+			continue
+		}
+		if q != nil && skipped < q.Offset {
+			skipped++
+			continue
+		}
+		result = append(result, event)
+		if q != nil && q.Limit > 0 && len(result) == q.Limit {
+			break
+		}
+	}
+	return result
+}
+
+// auditSubjectMatches reports whether the event's subject, or the end user
+// it acted for, has the ID.
+func auditSubjectMatches(event *AccessAuditEvent, id string) bool {
+	if event.Subject != nil && event.Subject.ID == id {
+		return true
+	}
+	return event.OnBehalfOf != nil && event.OnBehalfOf.ID == id
+}
+
+// containsOperation reports whether ops contains op.
+func containsOperation(ops []Operation, op Operation) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditRetention limits how long audit events are kept.
+type AuditRetention struct {
+	// MaxAge drops events older than the duration (0 = keep).
+	MaxAge time.Duration
+
+	// MaxEvents keeps only the most recent events (0 = no limit).
+	MaxEvents int
+}
+
+// cutoff returns the oldest timestamp kept at now (zero when unlimited).
+func (r AuditRetention) cutoff(now time.Time) time.Time {
+	if r.MaxAge <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-r.MaxAge)
+}
+
+// auditRecord is the serialized form of an audit event.
+type auditRecord struct {
+	Timestamp       time.Time      `json:"timestamp"`
+	RequestID       string         `json:"request_id,omitempty"`
+	SubjectID       string         `json:"subject_id,omitempty"`
+	SubjectType     string         `json:"subject_type,omitempty"`
+	TenantID        string         `json:"tenant_id,omitempty"`
+	OnBehalfOf      string         `json:"on_behalf_of,omitempty"`
+	Operation       Operation      `json:"operation"`
+	TemplateName    string         `json:"template_name,omitempty"`
+	TemplateID      TemplateID     `json:"template_id,omitempty"`
+	TemplateVersion int            `json:"template_version,omitempty"`
+	ContentHash     string         `json:"content_hash,omitempty"`
+	Allowed         bool           `json:"allowed"`
+	Reason          string         `json:"reason,omitempty"`
+	Duration        time.Duration  `json:"duration,omitempty"`
+	Error           string         `json:"error,omitempty"`
+	Metadata        map[string]any `json:"metadata,omitempty"`
+}
+
+// newAuditRecord converts an event to its serialized form.
+func newAuditRecord(event *AccessAuditEvent) *auditRecord {
+	record := &auditRecord{
+		Timestamp:       event.Timestamp,
+		RequestID:       event.RequestID,
+		Operation:       event.Operation,
+		TemplateName:    event.TemplateName,
+		TemplateID:      event.TemplateID,
+		TemplateVersion: event.TemplateVersion,
+		ContentHash:     event.ContentHash,
+		Duration:        event.Duration,
+	}
+	if len(event.Metadata) > 0 {
+		record.Metadata = event.Metadata
+	}
+	if event.Subject != nil {
+		record.SubjectID = event.Subject.ID
+		record.SubjectType = event.Subject.Type
+		record.TenantID = event.Subject.TenantID
+	}
+	if event.OnBehalfOf != nil {
+		record.OnBehalfOf = event.OnBehalfOf.ID
+	}
+	if event.Decision != nil {
+		record.Allowed = event.Decision.Allowed
+		record.Reason = event.Decision.Reason
+	}
+	if event.Error != nil {
+		record.Error = event.Error.Error()
+	}
+	return record
+}
+
+// event converts a record back to an audit event. Subjects only carry
+// their ID, type and tenant.
+func (r *auditRecord) event() *AccessAuditEvent {
+	event := &AccessAuditEvent{
+		Timestamp:       r.Timestamp,
+		RequestID:       r.RequestID,
+		Operation:       r.Operation,
+		TemplateName:    r.TemplateName,
+		TemplateID:      r.TemplateID,
+		TemplateVersion: r.TemplateVersion,
+		ContentHash:     r.ContentHash,
+		Decision:        &AccessDecision{Allowed: r.Allowed, Reason: r.Reason},
+		Duration:        r.Duration,
+		Metadata:        r.Metadata,
+	}
+	if event.Metadata == nil {
+		event.Metadata = make(map[string]any)
+	}
+	if r.SubjectID != "" || r.TenantID != "" {
+		event.Subject = &AccessSubject{ID: r.SubjectID, Type: r.SubjectType, TenantID: r.TenantID}
+	}
+	if r.OnBehalfOf != "" {
+		event.OnBehalfOf = &AccessSubject{ID: r.OnBehalfOf}
+		if event.Subject != nil {
+			event.Subject.OnBehalfOf = event.OnBehalfOf
+		}
+	}
+	if r.Error != "" {
+		event.Error = errors.New(r.Error)
+	}
+	return event
+}
+
+// ExportAuditEvents writes events to w in the given format, e.g. the
+// result of a QueryableAuditor query for a compliance review.
+func ExportAuditEvents(w io.Writer, events []*AccessAuditEvent, format AuditExportFormat) error {
+	switch format {
+	case AuditExportJSON:
+		records := make([]*auditRecord, len(events))
+		for i, event := range events {
+			records[i] = newAuditRecord(event)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case AuditExportCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(auditCSVHeader); err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := cw.Write(newAuditRecord(event).csvRow()); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return &AccessError{Message: ErrMsgAuditExportFormat + ": " + string(format)}
+}
+
+// csvRow returns the record's columns in auditCSVHeader order.
+func (r *auditRecord) csvRow() []string {
+	decision := AuditDecisionDenied
+	if r.Allowed {
+		decision = AuditDecisionAllowed
+	}
+	return []string{
+		r.Timestamp.UTC().Format(time.RFC3339Nano),
+		r.RequestID,
+		r.SubjectID,
+		r.SubjectType,
+		r.TenantID,
+		r.OnBehalfOf,
+		string(r.Operation),
+		r.TemplateName,
+		string(r.TemplateID),
+		strconv.Itoa(r.TemplateVersion),
+		r.ContentHash,
+		string(decision),
+		r.Reason,
+		strconv.FormatFloat(float64(r.Duration)/float64(time.Millisecond), 'f', -1, 64),
+		r.Error,
+	}
+}
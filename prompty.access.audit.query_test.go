@@ -0,0 +1,161 @@
+package prompty
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAuditEvents returns events of a month: alice executes "greeting" on
+// days 1 and 20, bob is denied deleting it on day 10, and a service reads
+// "faq" for alice on day 25.
+func testAuditEvents() []*AccessAuditEvent {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	alice := NewAccessSubject("alice").WithTenant("acme")
+	bob := NewAccessSubject("bob").WithTenant("acme")
+
+	e1 := NewAccessAuditEvent(OpExecute, "greeting", alice, Allow("ok"))
+	e1.Timestamp = base
+	e2 := NewAccessAuditEvent(OpDelete, "greeting", bob, Deny("missing role")).WithError(errors.New("denied"))
+	e2.Timestamp = base.AddDate(0, 0, 9)
+	e3 := NewAccessAuditEvent(OpExecute, "greeting", alice, Allow("ok")).WithDuration(1500 * time.Microsecond)
+	e3.Timestamp = base.AddDate(0, 0, 19)
+	e3.TemplateVersion = 2
+	e4 := NewAccessAuditEvent(OpRead, "faq", NewDelegatedSubject("svc_chat", alice), Allow("ok"))
+	e4.Timestamp = base.AddDate(0, 0, 24)
+	return []*AccessAuditEvent{e1, e2, e3, e4}
+}
+
+func TestAuditQuery(t *testing.T) {
+	ctx := context.Background()
+	auditor := NewMemoryAuditor(0)
+	for _, event := range testAuditEvents() {
+		require.NoError(t, auditor.Log(ctx, event))
+	}
+
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		query *AuditQuery
+		want  []int // indexes into testAuditEvents
+	}{
+		{"all", nil, []int{0, 1, 2, 3}},
+		{"who executed greeting", &AuditQuery{TemplateName: "greeting", Operations: []Operation{OpExecute}}, []int{0, 2}},
+		{"subject includes end user", &AuditQuery{SubjectID: "alice"}, []int{0, 2, 3}},
+		{"time range", &AuditQuery{Since: march.AddDate(0, 0, 9), Until: march.AddDate(0, 0, 19)}, []int{1}},
+		{"denied", &AuditQuery{Decision: AuditDecisionDenied}, []int{1}},
+		{"tenant and paging", &AuditQuery{TenantID: "acme", Offset: 1, Limit: 2}, []int{1, 2}},
+	}
+	events := auditor.Events()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := auditor.Query(ctx, tt.query)
+			require.NoError(t, err)
+			want := make([]*AccessAuditEvent, len(tt.want))
+			for i, idx := range tt.want {
+				want[i] = events[idx]
+			}
+			assert.Equal(t, want, got)
+		})
+	}
+
+	// MultiAuditor queries its first queryable auditor
+	multi := NewMultiAuditor(&NoOpAuditor{}, auditor)
+	got, err := multi.Query(ctx, &AuditQuery{Decision: AuditDecisionDenied})
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+	_, err = NewMultiAuditor(&NoOpAuditor{}).Query(ctx, nil)
+	assert.Error(t, err)
+}
+
+func TestMemoryAuditor_Retention(t *testing.T) {
+	ctx := context.Background()
+	events := testAuditEvents()
+	restore := timeNow
+	timeNow = func() time.Time { return events[3].Timestamp }
+	defer func() { timeNow = restore }()
+
+	auditor := NewMemoryAuditor(0).WithRetention(AuditRetention{MaxAge: 10 * 24 * time.Hour})
+	for _, event := range events {
+		require.NoError(t, auditor.Log(ctx, event))
+	}
+	got, err := auditor.Query(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, events[2:], got, "events older than ten days are dropped")
+
+	auditor = NewMemoryAuditor(0).WithRetention(AuditRetention{MaxEvents: 1})
+	for _, event := range events {
+		require.NoError(t, auditor.Log(ctx, event))
+	}
+	assert.Equal(t, 1, auditor.Count())
+}
+
+func TestExportAuditEvents(t *testing.T) {
+	events := testAuditEvents()
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportAuditEvents(&buf, events, AuditExportCSV))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 5)
+	assert.Equal(t, auditCSVHeader, rows[0])
+	assert.Equal(t, []string{
+		"2026-03-10T12:00:00Z", "", "bob", SubjectTypeUser, "acme", "",
+		"delete", "greeting", "", "0", "", "denied", "missing role", "0", "denied",
+	}, rows[2])
+	assert.Equal(t, "1.5", rows[3][13])
+	assert.Equal(t, []string{"svc_chat", SubjectTypeService, "acme", "alice"}, rows[4][2:6])
+
+	buf.Reset()
+	require.NoError(t, ExportAuditEvents(&buf, events, AuditExportJSON))
+	var records []map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &records))
+	require.Len(t, records, 4)
+	assert.Equal(t, "alice", records[3]["on_behalf_of"])
+	assert.Equal(t, false, records[1]["allowed"])
+
+	assert.Error(t, ExportAuditEvents(&buf, events, "xml"))
+}
+
+func TestFileAuditor(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit", "access.jsonl")
+	auditor, err := NewFileAuditor(path, FileAuditorConfig{MaxBytes: 400, MaxFiles: 2})
+	require.NoError(t, err)
+
+	events := testAuditEvents()
+	for _, event := range events {
+		require.NoError(t, auditor.Log(ctx, event))
+	}
+	assert.FileExists(t, path+".1", "log rotated by size")
+
+	got, err := auditor.Query(ctx, &AuditQuery{SubjectID: "alice"})
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	assert.True(t, got[0].Timestamp.Equal(events[0].Timestamp), "oldest first across rotated logs")
+	assert.Equal(t, 2, got[1].TemplateVersion)
+	assert.Equal(t, "svc_chat", got[2].Subject.ID)
+	assert.Equal(t, "alice", got[2].OnBehalfOf.ID)
+
+	denied, err := auditor.Query(ctx, &AuditQuery{Decision: AuditDecisionDenied})
+	require.NoError(t, err)
+	require.Len(t, denied, 1)
+	assert.EqualError(t, denied[0].Error, "denied")
+
+	// Rotating beyond MaxFiles drops the oldest logs
+	for i := 0; i < 3; i++ {
+		require.NoError(t, auditor.Rotate())
+	}
+	assert.NoFileExists(t, path+".3")
+	got, err = auditor.Query(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
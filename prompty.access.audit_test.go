@@ -271,6 +271,27 @@ func TestAuditingChecker(t *testing.T) {
 	})
 }
 
+func TestAuditingChecker_Delegation(t *testing.T) {
+	ctx := context.Background()
+	auditor := NewMemoryAuditor(10)
+	se := MustNewSecureStorageEngine(SecureStorageEngineConfig{
+		StorageEngineConfig: StorageEngineConfig{Storage: NewMemoryStorage()},
+		Auditor:             auditor,
+	})
+	defer se.Close()
+	require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "greeting", Source: "Hello"}))
+
+	user := NewAccessSubject("usr_123").WithTenant("org_1")
+	_, err := se.ExecuteSecure(ctx, "greeting", nil, NewDelegatedSubject("svc_chat", user))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return auditor.Count() == 1 }, time.Second, time.Millisecond)
+	event := auditor.LastEvent()
+	assert.Equal(t, "svc_chat", event.Subject.ID)
+	require.NotNil(t, event.OnBehalfOf)
+	assert.Equal(t, "usr_123", event.OnBehalfOf.ID)
+}
+
 func TestAuditHook(t *testing.T) {
 	ctx := context.Background()
 
@@ -2,6 +2,7 @@ package prompty
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
@@ -93,6 +94,11 @@ type AccessSubject struct {
 
 	// Extra contains any additional data needed by custom access checkers.
 	Extra map[string]any
+
+	// OnBehalfOf is the end user a service subject acts for, for delegated
+	// execution. Checkers see both identities; DelegationChecker requires
+	// both to be allowed. Audit and storage events record both.
+	OnBehalfOf *AccessSubject
 }
 
 // AccessDecision is the result of an access check.
@@ -111,6 +117,10 @@ type AccessDecision struct {
 	// ExpiresAt indicates when this decision expires (for caching).
 	// Nil means the decision doesn't expire.
 	ExpiresAt *time.Time
+
+	// Redact masks fields of templates returned by an allowed read
+	// (GetSecure, ListSecure). Nil returns templates unmodified.
+	Redact *FieldMask
 }
 
 // NewAccessRequest creates a new access request with the given parameters.
@@ -164,6 +174,22 @@ func NewAccessSubject(id string) *AccessSubject {
 	}
 }
 
+// NewServiceSubject creates a subject for a backend service.
+func NewServiceSubject(id string) *AccessSubject {
+	return NewAccessSubject(id).WithType(SubjectTypeService)
+}
+
+// NewDelegatedSubject creates a service subject acting on behalf of user.
+// The subject takes the user's tenant, so tenant isolation applies to the
+// user's tenant.
+func NewDelegatedSubject(serviceID string, user *AccessSubject) *AccessSubject {
+	s := NewServiceSubject(serviceID).WithOnBehalfOf(user)
+	if user != nil {
+		s.TenantID = user.TenantID
+	}
+	return s
+}
+
 // Subject type constants.
 const (
 	SubjectTypeUser      = "user"
@@ -220,6 +246,26 @@ func (s *AccessSubject) WithExtra(key string, value any) *AccessSubject {
 	return s
 }
 
+// WithOnBehalfOf sets the end user the subject acts for.
+func (s *AccessSubject) WithOnBehalfOf(user *AccessSubject) *AccessSubject {
+	s.OnBehalfOf = user
+	return s
+}
+
+// IsDelegated checks if the subject acts on behalf of another subject.
+func (s *AccessSubject) IsDelegated() bool {
+	return s != nil && s.OnBehalfOf != nil
+}
+
+// EndUser returns the subject the request is ultimately made for: the
+// subject acted for in delegated requests, and the subject itself otherwise.
+func (s *AccessSubject) EndUser() *AccessSubject {
+	if s.IsDelegated() {
+		return s.OnBehalfOf
+	}
+	return s
+}
+
 // HasRole checks if the subject has the specified role.
 func (s *AccessSubject) HasRole(role string) bool {
 	for _, r := range s.Roles {
@@ -316,9 +362,15 @@ const (
 	ErrMsgNilSubject        = "subject is nil"
 	ErrMsgNilChecker        = "access checker is nil"
 	ErrMsgNoCheckersInChain = "no checkers in chain"
+	ErrMsgBatchDecisions    = "access checker returned a decision count that does not match the requests"
 )
 
-// AccessError represents an access control error.
+// ErrAccessDenied is matched by errors.Is for every operation an access
+// checker denies. Use errors.As with *AccessError for the details.
+var ErrAccessDenied = errors.New(ErrMsgAccessDenied)
+
+// AccessError represents an access control error. Denials match
+// ErrAccessDenied with errors.Is.
 type AccessError struct {
 	Message   string
 	Operation Operation
@@ -347,6 +399,11 @@ func (e *AccessError) Unwrap() error {
 	return e.Cause
 }
 
+// Is reports whether the error is an access denial for ErrAccessDenied.
+func (e *AccessError) Is(target error) bool {
+	return target == ErrAccessDenied && e.Message == ErrMsgAccessDenied
+}
+
 // NewAccessDeniedError creates an access denied error.
 func NewAccessDeniedError(op Operation, template string, subject *AccessSubject) *AccessError {
 	return &AccessError{
@@ -2,7 +2,9 @@ package prompty
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -81,7 +83,9 @@ func MustChainedChecker(checkers ...AccessChecker) *ChainedChecker {
 
 // Check evaluates all checkers in order.
 // Returns the first denial or an allow if all pass.
+// Field masks of the allowing checkers are combined.
 func (c *ChainedChecker) Check(ctx context.Context, req *AccessRequest) (*AccessDecision, error) {
+	var mask *FieldMask
 	for _, checker := range c.checkers {
 		decision, err := checker.Check(ctx, req)
 		if err != nil {
@@ -90,8 +94,9 @@ func (c *ChainedChecker) Check(ctx context.Context, req *AccessRequest) (*Access
 		if !decision.Allowed {
 			return decision, nil
 		}
+		mask = mask.Merge(decision.Redact)
 	}
-	return Allow("all checkers passed"), nil
+	return AllowRedacted("all checkers passed", mask), nil
 }
 
 // BatchCheck evaluates all requests through the chain.
@@ -114,6 +119,11 @@ func (c *ChainedChecker) AddChecker(checker AccessChecker) {
 
 // CachedChecker wraps a checker with caching for performance.
 // Caches both allow and deny decisions with configurable TTL.
+//
+// Decisions about a template can be invalidated when it changes:
+// CachedChecker is an EventSink dropping the decisions of templates that
+// are saved or deleted. A SecureStorageEngine configured with a
+// CachedChecker subscribes it to its own events.
 type CachedChecker struct {
 	checker AccessChecker
 	config  CachedCheckerConfig
@@ -133,7 +143,8 @@ type CachedCheckerConfig struct {
 	MaxEntries int
 
 	// KeyFunc generates cache keys from requests.
-	// Default uses subject ID + operation + template name.
+	// Default uses subject ID (and the ID of the subject it acts for) +
+	// operation + template name (and version of a loaded resource).
 	KeyFunc func(*AccessRequest) string
 }
 
@@ -147,9 +158,10 @@ func DefaultCachedCheckerConfig() CachedCheckerConfig {
 }
 
 type cachedDecision struct {
-	decision  *AccessDecision
-	cachedAt  time.Time
-	expiresAt time.Time
+	decision     *AccessDecision
+	templateName string
+	cachedAt     time.Time
+	expiresAt    time.Time
 }
 
 // NewCachedChecker wraps a checker with caching.
@@ -175,30 +187,85 @@ func defaultCacheKey(req *AccessRequest) string {
 	subjectID := ""
 	if req.Subject != nil {
 		subjectID = req.Subject.ID
+		if req.Subject.IsDelegated() {
+			subjectID += ">" + req.Subject.OnBehalfOf.ID
+		}
+	}
+	key := subjectID + ":" + string(req.Operation) + ":" + req.TemplateName
+	if req.Resource != nil {
+		key += "@" + strconv.Itoa(req.Resource.Version)
 	}
-	return subjectID + ":" + string(req.Operation) + ":" + req.TemplateName
+	return key
 }
 
 // Check evaluates the request, using cache when available.
 func (c *CachedChecker) Check(ctx context.Context, req *AccessRequest) (*AccessDecision, error) {
 	key := c.config.KeyFunc(req)
+	if decision, ok := c.lookup(key); ok {
+		return decision, nil
+	}
+
+	// Cache miss - call underlying checker
+	decision, err := c.checker.Check(ctx, req)
+	if err != nil {
+		return decision, err
+	}
+	c.store(key, req, decision)
+	return decision, nil
+}
 
-	// Check cache
+// BatchCheck evaluates multiple requests, passing the requests without a
+// cached decision to the underlying checker's BatchCheck in one call.
+func (c *CachedChecker) BatchCheck(ctx context.Context, reqs []*AccessRequest) ([]*AccessDecision, error) {
+	decisions := make([]*AccessDecision, len(reqs))
+	keys := make([]string, len(reqs))
+	var misses []*AccessRequest
+	var missIndexes []int
+	for i, req := range reqs {
+		keys[i] = c.config.KeyFunc(req)
+		if decision, ok := c.lookup(keys[i]); ok {
+			decisions[i] = decision
+			continue
+		}
+		misses = append(misses, req)
+		missIndexes = append(missIndexes, i)
+	}
+	if len(misses) == 0 {
+		return decisions, nil
+	}
+
+	checked, err := c.checker.BatchCheck(ctx, misses)
+	if err != nil {
+		return nil, err
+	}
+	if len(checked) != len(misses) {
+		return nil, NewAccessCheckError("", "", errors.New(ErrMsgBatchDecisions))
+	}
+	for j, i := range missIndexes {
+		decisions[i] = checked[j]
+		c.store(keys[i], reqs[i], checked[j])
+	}
+	return decisions, nil
+}
+
+// lookup returns the unexpired cached decision of key.
+func (c *CachedChecker) lookup(key string) (*AccessDecision, bool) {
 	c.mu.RLock()
 	cached, ok := c.cache[key]
 	c.mu.RUnlock()
 
 	if ok && time.Now().Before(cached.expiresAt) {
-		return cached.decision, nil
+		return cached.decision, true
 	}
+	return nil, false
+}
 
-	// Cache miss - call underlying checker
-	decision, err := c.checker.Check(ctx, req)
-	if err != nil {
-		return decision, err
+// store caches the decision of a request.
+func (c *CachedChecker) store(key string, req *AccessRequest, decision *AccessDecision) {
+	if decision == nil {
+		return
 	}
 
-	// Cache the result
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -216,25 +283,11 @@ func (c *CachedChecker) Check(ctx context.Context, req *AccessRequest) (*AccessD
 	}
 
 	c.cache[key] = &cachedDecision{
-		decision:  decision,
-		cachedAt:  now,
-		expiresAt: expiresAt,
+		decision:     decision,
+		templateName: req.TemplateName,
+		cachedAt:     now,
+		expiresAt:    expiresAt,
 	}
-
-	return decision, nil
-}
-
-// BatchCheck evaluates multiple requests.
-func (c *CachedChecker) BatchCheck(ctx context.Context, reqs []*AccessRequest) ([]*AccessDecision, error) {
-	decisions := make([]*AccessDecision, len(reqs))
-	for i, req := range reqs {
-		decision, err := c.Check(ctx, req)
-		if err != nil {
-			return nil, err
-		}
-		decisions[i] = decision
-	}
-	return decisions, nil
 }
 
 // Invalidate removes a specific cache entry.
@@ -245,6 +298,28 @@ func (c *CachedChecker) Invalidate(req *AccessRequest) {
 	c.mu.Unlock()
 }
 
+// InvalidateTemplate removes the cached decisions about a template.
+func (c *CachedChecker) InvalidateTemplate(templateName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.cache {
+		if entry.templateName == templateName {
+			delete(c.cache, key)
+		}
+	}
+}
+
+// Publish implements EventSink: saving or deleting a template invalidates
+// the cached decisions about it, as they may depend on its content
+// (tenant, metadata) or existence.
+func (c *CachedChecker) Publish(ctx context.Context, event *StorageEvent) error {
+	switch event.Type {
+	case EventTemplateSaved, EventVersionCreated, EventTemplateDeleted, EventVersionDeleted:
+		c.InvalidateTemplate(event.TemplateName)
+	}
+	return nil
+}
+
 // InvalidateAll clears the entire cache.
 func (c *CachedChecker) InvalidateAll() {
 	c.mu.Lock()
@@ -533,3 +608,50 @@ func (c *RoleChecker) BatchCheck(ctx context.Context, reqs []*AccessRequest) ([]
 	}
 	return decisions, nil
 }
+
+// DelegationChecker evaluates delegated requests for both identities: the
+// service subject and the end user it acts for (AccessSubject.OnBehalfOf)
+// must both be allowed, so a service cannot give a user access the user
+// lacks. Requests without delegation are passed through.
+type DelegationChecker struct {
+	checker AccessChecker
+}
+
+// NewDelegationChecker wraps a checker to require both identities of
+// delegated requests to be allowed.
+func NewDelegationChecker(checker AccessChecker) *DelegationChecker {
+	return &DelegationChecker{checker: checker}
+}
+
+// Check evaluates the request for the subject and, if delegated, for the
+// end user. Field masks of both decisions are combined.
+func (c *DelegationChecker) Check(ctx context.Context, req *AccessRequest) (*AccessDecision, error) {
+	decision, err := c.checker.Check(ctx, req)
+	if err != nil || !decision.Allowed || !req.Subject.IsDelegated() {
+		return decision, err
+	}
+
+	userReq := *req
+	userReq.Subject = req.Subject.OnBehalfOf
+	userDecision, err := c.checker.Check(ctx, &userReq)
+	if err != nil {
+		return Deny(ErrMsgAccessCheckFailed + ": " + err.Error()), err
+	}
+	if !userDecision.Allowed {
+		return Deny("end user denied: " + userDecision.Reason), nil
+	}
+	return AllowRedacted("subject and end user allowed", decision.Redact.Merge(userDecision.Redact)), nil
+}
+
+// BatchCheck evaluates all requests.
+func (c *DelegationChecker) BatchCheck(ctx context.Context, reqs []*AccessRequest) ([]*AccessDecision, error) {
+	decisions := make([]*AccessDecision, len(reqs))
+	for i, req := range reqs {
+		decision, err := c.Check(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		decisions[i] = decision
+	}
+	return decisions, nil
+}
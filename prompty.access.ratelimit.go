@@ -0,0 +1,400 @@
+package prompty
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitScope selects whose operations share a rate limit.
+type RateLimitScope string
+
+// Rate limit scopes.
+const (
+	// RateLimitScopeSubject limits each subject separately (the default).
+	RateLimitScopeSubject RateLimitScope = "subject"
+
+	// RateLimitScopeTenant limits each tenant separately.
+	RateLimitScopeTenant RateLimitScope = "tenant"
+
+	// RateLimitScopeGlobal shares one limit across all callers.
+	RateLimitScopeGlobal RateLimitScope = "global"
+)
+
+// Rate limit key format.
+const (
+	// RateLimitKeyPrefix prefixes all rate limit keys, so shared stores
+	// (e.g. Redis) can namespace them.
+	RateLimitKeyPrefix = "prompty:ratelimit:"
+
+	// rateLimitKeySep separates the parts of a rate limit key.
+	rateLimitKeySep = ":"
+
+	// rateLimitAllOps is the operation part of keys of rules without operations.
+	rateLimitAllOps = "*"
+
+	// rateLimitOpSep joins the operations of a rule in its key.
+	rateLimitOpSep = ","
+
+	// TokenBucketSweepInterval is how often a TokenBucketLimiter looks for
+	// idle buckets to evict.
+	TokenBucketSweepInterval = time.Minute
+)
+
+// Rate limit error messages.
+const (
+	ErrMsgRateLimited          = "rate limit exceeded"
+	ErrMsgRateLimitCheckFailed = "rate limit check failed"
+	ErrMsgInvalidRateLimit     = "invalid rate limit: limit and period must be positive"
+)
+
+// ErrRateLimited is matched by errors.Is for every operation rejected by a
+// rate limit. Use errors.As with *RateLimitError for the details.
+var ErrRateLimited = errors.New(ErrMsgRateLimited)
+
+// RateLimit is a token bucket: Limit operations per Per, with bursts of up
+// to Burst operations.
+type RateLimit struct {
+	// Limit is the number of operations allowed per period.
+	Limit int
+
+	// Per is the period of Limit.
+	Per time.Duration
+
+	// Burst is the bucket capacity. If <= 0, Limit is used.
+	Burst int
+}
+
+// valid reports whether the limit has a positive rate.
+func (l RateLimit) valid() bool {
+	return l.Limit > 0 && l.Per > 0
+}
+
+// capacity returns the bucket capacity.
+func (l RateLimit) capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Limit
+}
+
+// RateLimitRule applies a rate limit to operations of a SecureStorageEngine.
+// Every matching rule must allow an operation for it to proceed.
+type RateLimitRule struct {
+	// Operations the rule limits. Empty limits all rate-limited operations
+	// (execute, create and update), sharing one bucket.
+	Operations []Operation
+
+	// Scope selects whose operations share a bucket.
+	// If empty, RateLimitScopeSubject is used.
+	Scope RateLimitScope
+
+	// Limit is the rate limit of each bucket.
+	Limit RateLimit
+
+	// Overrides replaces Limit for specific tenants (tenant scope) or
+	// subjects (subject scope), keyed by their ID.
+	Overrides map[string]RateLimit
+}
+
+// matches reports whether the rule limits op.
+func (r RateLimitRule) matches(op Operation) bool {
+	if len(r.Operations) == 0 {
+		return true
+	}
+	for _, o := range r.Operations {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// scope returns the effective scope of the rule.
+func (r RateLimitRule) scope() RateLimitScope {
+	if r.Scope == "" {
+		return RateLimitScopeSubject
+	}
+	return r.Scope
+}
+
+// bucket returns the bucket key and limit of the rule for subject.
+func (r RateLimitRule) bucket(subject *AccessSubject) (string, RateLimit) {
+	scope := r.scope()
+	id := ""
+	if subject != nil {
+		switch scope {
+		case RateLimitScopeSubject:
+			id = subject.ID
+		case RateLimitScopeTenant:
+			id = subject.TenantID
+		}
+	}
+
+	limit := r.Limit
+	if override, ok := r.Overrides[id]; ok && scope != RateLimitScopeGlobal {
+		limit = override
+	}
+
+	ops := rateLimitAllOps
+	if len(r.Operations) > 0 {
+		names := make([]string, len(r.Operations))
+		for i, op := range r.Operations {
+			names[i] = string(op)
+		}
+		sort.Strings(names)
+		ops = strings.Join(names, rateLimitOpSep)
+	}
+	return RateLimitKeyPrefix + string(scope) + rateLimitKeySep + id + rateLimitKeySep + ops, limit
+}
+
+// validate checks the limit and overrides of the rule.
+func (r RateLimitRule) validate() error {
+	if !r.Limit.valid() {
+		return &RateLimitError{Message: ErrMsgInvalidRateLimit}
+	}
+	for _, override := range r.Overrides {
+		if !override.valid() {
+			return &RateLimitError{Message: ErrMsgInvalidRateLimit}
+		}
+	}
+	return nil
+}
+
+// RateLimiter is the interface for rate limit stores.
+// The built-in TokenBucketLimiter keeps buckets in memory; implement this
+// interface over a shared store (e.g. Redis) to limit across instances.
+type RateLimiter interface {
+	// Allow takes one operation from the bucket identified by key, which
+	// has the given limit.
+	Allow(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error)
+
+	// Cancel returns an operation taken by Allow to the bucket of key. It is
+	// called when another rule rejects the operation, so rejected calls do
+	// not use up the budgets of the rules that allowed them.
+	Cancel(ctx context.Context, key string, limit RateLimit) error
+}
+
+// RateLimitResult is the outcome of a RateLimiter.Allow call.
+type RateLimitResult struct {
+	// Allowed reports whether the operation may proceed.
+	Allowed bool
+
+	// Remaining is the number of operations left in the bucket.
+	Remaining int
+
+	// RetryAfter is how long until the next operation is allowed
+	// (zero when allowed).
+	RetryAfter time.Duration
+}
+
+// RateLimitError reports an operation rejected by, or a failure of, a rate
+// limit. Rejections match ErrRateLimited with errors.Is.
+type RateLimitError struct {
+	Message    string
+	Operation  Operation
+	Subject    *AccessSubject
+	Template   string
+	Key        string
+	RetryAfter time.Duration
+	Cause      error
+}
+
+// Error implements the error interface.
+func (e *RateLimitError) Error() string {
+	msg := e.Message
+	if e.Template != "" {
+		msg += " (template: " + e.Template + ")"
+	}
+	if e.Operation != "" {
+		msg += " (operation: " + string(e.Operation) + ")"
+	}
+	if e.RetryAfter > 0 {
+		msg += " (retry after: " + e.RetryAfter.String() + ")"
+	}
+	if e.Cause != nil {
+		msg += ": " + e.Cause.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *RateLimitError) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether the error is a rate limit rejection for ErrRateLimited.
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited && e.Message == ErrMsgRateLimited
+}
+
+// NewRateLimitedError creates an error for an operation rejected by a rate limit.
+func NewRateLimitedError(op Operation, template string, subject *AccessSubject, key string, retryAfter time.Duration) *RateLimitError {
+	return &RateLimitError{
+		Message:    ErrMsgRateLimited,
+		Operation:  op,
+		Subject:    subject,
+		Template:   template,
+		Key:        key,
+		RetryAfter: retryAfter,
+	}
+}
+
+// NewRateLimitCheckError creates an error for a failed rate limiter.
+func NewRateLimitCheckError(op Operation, template string, cause error) *RateLimitError {
+	return &RateLimitError{
+		Message:   ErrMsgRateLimitCheckFailed,
+		Operation: op,
+		Template:  template,
+		Cause:     cause,
+	}
+}
+
+// TokenBucketLimiter is an in-memory RateLimiter using token buckets.
+// It is safe for concurrent use. It keeps one bucket per key; every
+// TokenBucketSweepInterval, buckets that have refilled to capacity and have
+// been idle for at least their limit's period are evicted, which is
+// indistinguishable from keeping them.
+type TokenBucketLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// tokenBucket is the state of one bucket.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	limit  RateLimit // Limit of the last Allow, for eviction
+}
+
+// NewTokenBucketLimiter creates an in-memory token bucket limiter.
+func NewTokenBucketLimiter() *TokenBucketLimiter {
+	return &TokenBucketLimiter{buckets: make(map[string]*tokenBucket)}
+}
+
+// Allow takes one token from the bucket of key, refilled at the rate of limit.
+func (l *TokenBucketLimiter) Allow(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error) {
+	if !limit.valid() {
+		return nil, &RateLimitError{Message: ErrMsgInvalidRateLimit, Key: key}
+	}
+	capacity := float64(limit.capacity())
+	rate := float64(limit.Limit) / float64(limit.Per) // tokens per nanosecond
+	now := timeNow()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: capacity, last: now}
+		l.buckets[key] = bucket
+	}
+	bucket.limit = limit
+	if elapsed := now.Sub(bucket.last); elapsed > 0 {
+		bucket.tokens = math.Min(capacity, bucket.tokens+float64(elapsed)*rate)
+		bucket.last = now
+	}
+
+	if bucket.tokens < 1 {
+		return &RateLimitResult{
+			RetryAfter: time.Duration(math.Ceil((1 - bucket.tokens) / rate)),
+		}, nil
+	}
+	bucket.tokens--
+	return &RateLimitResult{Allowed: true, Remaining: int(bucket.tokens)}, nil
+}
+
+// Cancel returns one token to the bucket of key, up to its capacity.
+func (l *TokenBucketLimiter) Cancel(ctx context.Context, key string, limit RateLimit) error {
+	if !limit.valid() {
+		return &RateLimitError{Message: ErrMsgInvalidRateLimit, Key: key}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if bucket, ok := l.buckets[key]; ok {
+		bucket.tokens = math.Min(float64(limit.capacity()), bucket.tokens+1)
+	}
+	return nil
+}
+
+// sweep evicts full, idle buckets at most once per TokenBucketSweepInterval.
+// A missing bucket is created full, so eviction does not change any result.
+// The caller must hold l.mu.
+func (l *TokenBucketLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < TokenBucketSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		idle := now.Sub(bucket.last)
+		if idle < bucket.limit.Per {
+			continue
+		}
+		rate := float64(bucket.limit.Limit) / float64(bucket.limit.Per)
+		if bucket.tokens+float64(idle)*rate >= float64(bucket.limit.capacity()) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Len returns the number of buckets currently kept.
+func (l *TokenBucketLimiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+// Reset removes all buckets.
+func (l *TokenBucketLimiter) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buckets = make(map[string]*tokenBucket)
+}
+
+// rateLimitTaken is a token taken from a bucket during checkRateLimit.
+type rateLimitTaken struct {
+	key   string
+	limit RateLimit
+}
+
+// checkRateLimit takes op from the bucket of every matching rate limit rule.
+// If a rule rejects op or the limiter fails, the tokens already taken from
+// the other buckets are returned.
+func (se *SecureStorageEngine) checkRateLimit(ctx context.Context, op Operation, templateName string, subject *AccessSubject) error {
+	var taken []rateLimitTaken
+	refund := func() {
+		for _, t := range taken {
+			_ = se.limiter.Cancel(ctx, t.key, t.limit)
+		}
+	}
+
+	for _, rule := range se.rateLimits {
+		if !rule.matches(op) {
+			continue
+		}
+		key, limit := rule.bucket(subject)
+		result, err := se.limiter.Allow(ctx, key, limit)
+		if err != nil {
+			refund()
+			return NewRateLimitCheckError(op, templateName, err)
+		}
+		if !result.Allowed {
+			refund()
+			return NewRateLimitedError(op, templateName, subject, key, result.RetryAfter)
+		}
+		taken = append(taken, rateLimitTaken{key: key, limit: limit})
+	}
+	return nil
+}
+
+// RateLimiter returns the configured rate limiter, or nil.
+func (se *SecureStorageEngine) RateLimiter() RateLimiter {
+	return se.limiter
+}
@@ -0,0 +1,246 @@
+package prompty
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucketLimiter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	limiter := NewTokenBucketLimiter()
+	limit := RateLimit{Limit: 2, Per: time.Second}
+
+	t.Run("allows up to the capacity", func(t *testing.T) {
+		result, err := limiter.Allow(ctx, "k", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 1, result.Remaining)
+
+		result, err = limiter.Allow(ctx, "k", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+
+		result, err = limiter.Allow(ctx, "k", limit)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+	})
+
+	t.Run("refills over time", func(t *testing.T) {
+		now = now.Add(500 * time.Millisecond)
+		result, err := limiter.Allow(ctx, "k", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("keys are independent", func(t *testing.T) {
+		result, err := limiter.Allow(ctx, "other", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("burst sets the capacity", func(t *testing.T) {
+		burst := RateLimit{Limit: 1, Per: time.Minute, Burst: 3}
+		for i := 0; i < 3; i++ {
+			result, err := limiter.Allow(ctx, "burst", burst)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+		}
+		result, err := limiter.Allow(ctx, "burst", burst)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+	})
+
+	t.Run("cancel returns a token", func(t *testing.T) {
+		single := RateLimit{Limit: 1, Per: time.Hour}
+		result, err := limiter.Allow(ctx, "cancel", single)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+
+		require.NoError(t, limiter.Cancel(ctx, "cancel", single))
+		require.NoError(t, limiter.Cancel(ctx, "cancel", single))
+		result, err = limiter.Allow(ctx, "cancel", single)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		result, err = limiter.Allow(ctx, "cancel", single)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed, "cancel never exceeds the capacity")
+	})
+
+	t.Run("rejects invalid limits", func(t *testing.T) {
+		_, err := limiter.Allow(ctx, "k", RateLimit{})
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrRateLimited))
+	})
+}
+
+func TestTokenBucketLimiter_EvictsIdleBuckets(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	limiter := NewTokenBucketLimiter()
+	short := RateLimit{Limit: 1, Per: time.Second}
+	long := RateLimit{Limit: 1, Per: time.Hour}
+	for _, key := range []string{"a", "b", "c"} {
+		_, err := limiter.Allow(ctx, key, short)
+		require.NoError(t, err)
+	}
+	_, err := limiter.Allow(ctx, "slow", long)
+	require.NoError(t, err)
+	require.Equal(t, 4, limiter.Len())
+
+	// Short buckets have refilled and been idle for their period; the
+	// slow bucket is still refilling
+	now = now.Add(TokenBucketSweepInterval)
+	_, err = limiter.Allow(ctx, "d", short)
+	require.NoError(t, err)
+	assert.Equal(t, 2, limiter.Len())
+
+	// The slow bucket keeps its state until it is full again
+	result, err := limiter.Allow(ctx, "slow", long)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+
+	now = now.Add(2 * time.Hour)
+	_, err = limiter.Allow(ctx, "e", short)
+	require.NoError(t, err)
+	assert.Equal(t, 1, limiter.Len())
+}
+
+func TestSecureStorageEngine_RateLimits(t *testing.T) {
+	ctx := context.Background()
+
+	newEngine := func(t *testing.T, rules ...RateLimitRule) *SecureStorageEngine {
+		se, err := NewSecureStorageEngine(SecureStorageEngineConfig{
+			StorageEngineConfig: StorageEngineConfig{Storage: NewMemoryStorage()},
+			RateLimits:          rules,
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = se.Close() })
+		require.NoError(t, se.StorageEngine.Save(ctx, &StoredTemplate{Name: "test", Source: "content"}))
+		return se
+	}
+
+	t.Run("limits executions per subject", func(t *testing.T) {
+		se := newEngine(t, RateLimitRule{
+			Operations: []Operation{OpExecute},
+			Limit:      RateLimit{Limit: 1, Per: time.Hour},
+		})
+		alice := NewAccessSubject("alice")
+
+		_, err := se.ExecuteSecure(ctx, "test", nil, alice)
+		require.NoError(t, err)
+
+		_, err = se.ExecuteVersionSecure(ctx, "test", 1, nil, alice)
+		require.ErrorIs(t, err, ErrRateLimited)
+
+		var rlErr *RateLimitError
+		require.True(t, errors.As(err, &rlErr))
+		assert.Equal(t, OpExecute, rlErr.Operation)
+		assert.Equal(t, "test", rlErr.Template)
+		assert.Positive(t, rlErr.RetryAfter)
+
+		// Other subjects and operations are not limited
+		_, err = se.ExecuteSecure(ctx, "test", nil, NewAccessSubject("bob"))
+		require.NoError(t, err)
+		require.NoError(t, se.SaveSecure(ctx, &StoredTemplate{Name: "test", Source: "v2"}, alice))
+	})
+
+	t.Run("limits saves per tenant with overrides", func(t *testing.T) {
+		se := newEngine(t, RateLimitRule{
+			Operations: []Operation{OpCreate, OpUpdate},
+			Scope:      RateLimitScopeTenant,
+			Limit:      RateLimit{Limit: 1, Per: time.Hour},
+			Overrides:  map[string]RateLimit{"premium": {Limit: 3, Per: time.Hour}},
+		})
+
+		require.NoError(t, se.SaveSecure(ctx, &StoredTemplate{Name: "a", Source: "x"}, NewAccessSubject("u1").WithTenant("free")))
+		err := se.SaveSecure(ctx, &StoredTemplate{Name: "b", Source: "x"}, NewAccessSubject("u2").WithTenant("free"))
+		require.ErrorIs(t, err, ErrRateLimited)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, se.SaveSecure(ctx, &StoredTemplate{Name: "p", Source: "x"}, NewAccessSubject("u3").WithTenant("premium")))
+		}
+		err = se.SaveSecure(ctx, &StoredTemplate{Name: "p", Source: "x"}, NewAccessSubject("u3").WithTenant("premium"))
+		require.ErrorIs(t, err, ErrRateLimited)
+	})
+
+	t.Run("global limit spans subjects", func(t *testing.T) {
+		se := newEngine(t, RateLimitRule{Scope: RateLimitScopeGlobal, Limit: RateLimit{Limit: 1, Per: time.Hour}})
+
+		_, err := se.ExecuteSecure(ctx, "test", nil, NewAccessSubject("alice"))
+		require.NoError(t, err)
+		_, err = se.ExecuteSecure(ctx, "test", nil, NewAccessSubject("bob"))
+		require.ErrorIs(t, err, ErrRateLimited)
+	})
+
+	t.Run("rejections do not use other rules' tokens", func(t *testing.T) {
+		se := newEngine(t,
+			RateLimitRule{Scope: RateLimitScopeGlobal, Limit: RateLimit{Limit: 2, Per: time.Hour}},
+			RateLimitRule{Limit: RateLimit{Limit: 1, Per: time.Hour}},
+		)
+		alice := NewAccessSubject("alice")
+
+		_, err := se.ExecuteSecure(ctx, "test", nil, alice)
+		require.NoError(t, err)
+		for i := 0; i < 5; i++ {
+			_, err = se.ExecuteSecure(ctx, "test", nil, alice)
+			require.ErrorIs(t, err, ErrRateLimited)
+		}
+
+		// Alice's rejected calls left the global budget for bob
+		_, err = se.ExecuteSecure(ctx, "test", nil, NewAccessSubject("bob"))
+		require.NoError(t, err)
+	})
+
+	t.Run("limiter errors fail closed", func(t *testing.T) {
+		se, err := NewSecureStorageEngine(SecureStorageEngineConfig{
+			StorageEngineConfig: StorageEngineConfig{Storage: NewMemoryStorage()},
+			RateLimits:          []RateLimitRule{{Limit: RateLimit{Limit: 1, Per: time.Second}}},
+			RateLimiter:         failingLimiter{},
+		})
+		require.NoError(t, err)
+		defer se.Close()
+
+		_, err = se.ExecuteSecure(ctx, "test", nil, NewAccessSubject("alice"))
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrRateLimited))
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("rejects invalid rules", func(t *testing.T) {
+		_, err := NewSecureStorageEngine(SecureStorageEngineConfig{
+			StorageEngineConfig: StorageEngineConfig{Storage: NewMemoryStorage()},
+			RateLimits:          []RateLimitRule{{Limit: RateLimit{Limit: 0, Per: time.Second}}},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMsgInvalidRateLimit)
+	})
+
+	t.Run("no rules means no limiter", func(t *testing.T) {
+		se := newEngine(t)
+		assert.Nil(t, se.RateLimiter())
+	})
+}
+
+// failingLimiter is a RateLimiter whose store is unavailable.
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error) {
+	return nil, assert.AnError
+}
+
+func (failingLimiter) Cancel(ctx context.Context, key string, limit RateLimit) error {
+	return assert.AnError
+}
@@ -0,0 +1,108 @@
+package prompty
+
+import "context"
+
+// FieldMask lists the fields of a stored template that an access decision
+// hides from the subject. It lets a checker allow reading a template while
+// keeping parts of it private, e.g. "can execute but not view source":
+// execution is unaffected by a mask, which only applies to templates
+// returned by GetSecure and ListSecure.
+type FieldMask struct {
+	// Source removes the template source and its parsed prompt
+	// configuration, leaving the template's metadata.
+	Source bool
+
+	// Metadata lists metadata keys to remove.
+	Metadata []string
+}
+
+// AllowRedacted creates an AccessDecision that grants read access with the
+// masked fields removed from the returned templates.
+func AllowRedacted(reason string, mask *FieldMask) *AccessDecision {
+	return &AccessDecision{
+		Allowed: true,
+		Reason:  reason,
+		Redact:  mask,
+	}
+}
+
+// IsEmpty reports whether the mask hides nothing.
+func (m *FieldMask) IsEmpty() bool {
+	return m == nil || (!m.Source && len(m.Metadata) == 0)
+}
+
+// Merge returns a mask hiding the fields of both masks.
+func (m *FieldMask) Merge(other *FieldMask) *FieldMask {
+	if other.IsEmpty() {
+		return m
+	}
+	if m.IsEmpty() {
+		return other
+	}
+	merged := &FieldMask{
+		Source:   m.Source || other.Source,
+		Metadata: make([]string, 0, len(m.Metadata)+len(other.Metadata)),
+	}
+	merged.Metadata = append(merged.Metadata, m.Metadata...)
+	merged.Metadata = append(merged.Metadata, other.Metadata...)
+	return merged
+}
+
+// Apply returns a copy of tmpl with the masked fields removed. The content
+// hash is kept, so a redacted template still identifies its version.
+func (m *FieldMask) Apply(tmpl *StoredTemplate) *StoredTemplate {
+	if tmpl == nil || m.IsEmpty() {
+		return tmpl
+	}
+	redacted := copyStoredTemplate(tmpl)
+	if m.Source {
+		redacted.Source = ""
+		redacted.PromptConfig = nil
+	}
+	for _, key := range m.Metadata {
+		delete(redacted.Metadata, key)
+	}
+	return redacted
+}
+
+// RedactionChecker masks fields of read templates for subjects without an
+// exempt role. It allows every request and is meant to be chained after
+// the checkers that grant access:
+//
+//	checker := MustChainedChecker(
+//		NewRoleChecker().WithDefaultRoles("user"),
+//		NewRedactionChecker(&FieldMask{Source: true}, "prompt-author"),
+//	)
+type RedactionChecker struct {
+	// Mask is applied to reads of subjects without an exempt role.
+	Mask *FieldMask
+
+	// ExemptRoles see templates unredacted.
+	ExemptRoles []string
+}
+
+// NewRedactionChecker creates a checker masking fields for all subjects
+// without one of the exempt roles.
+func NewRedactionChecker(mask *FieldMask, exemptRoles ...string) *RedactionChecker {
+	return &RedactionChecker{Mask: mask, ExemptRoles: exemptRoles}
+}
+
+// Check allows the request, masking reads of non-exempt subjects.
+func (c *RedactionChecker) Check(ctx context.Context, req *AccessRequest) (*AccessDecision, error) {
+	if req.Operation != OpRead {
+		return Allow("redaction applies to reads only"), nil
+	}
+	if req.Subject != nil && req.Subject.HasAnyRole(c.ExemptRoles...) {
+		return Allow("exempt from redaction"), nil
+	}
+	return AllowRedacted("fields redacted", c.Mask), nil
+}
+
+// BatchCheck evaluates all requests.
+func (c *RedactionChecker) BatchCheck(ctx context.Context, reqs []*AccessRequest) ([]*AccessDecision, error) {
+	decisions := make([]*AccessDecision, len(reqs))
+	for i, req := range reqs {
+		decisions[i], _ = c.Check(ctx, req)
+	}
+	return decisions, nil
+}
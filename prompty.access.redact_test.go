@@ -0,0 +1,80 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldMask_Apply(t *testing.T) {
+	tmpl := &StoredTemplate{
+		Name:         "support",
+		Source:       "secret prompt",
+		ContentHash:  "sha256:abc",
+		Metadata:     map[string]string{"owner": "team-a", "cost_center": "42"},
+		PromptConfig: &Prompt{Name: "support"},
+	}
+
+	var nilMask *FieldMask
+	assert.Same(t, tmpl, nilMask.Apply(tmpl))
+
+	redacted := (&FieldMask{Source: true, Metadata: []string{"cost_center"}}).Apply(tmpl)
+	assert.Empty(t, redacted.Source)
+	assert.Nil(t, redacted.PromptConfig)
+	assert.Equal(t, "sha256:abc", redacted.ContentHash)
+	assert.Equal(t, map[string]string{"owner": "team-a"}, redacted.Metadata)
+	assert.Equal(t, "secret prompt", tmpl.Source, "original is not modified")
+	assert.Len(t, tmpl.Metadata, 2)
+
+	merged := (&FieldMask{Metadata: []string{"a"}}).Merge(&FieldMask{Source: true, Metadata: []string{"b"}})
+	assert.Equal(t, &FieldMask{Source: true, Metadata: []string{"a", "b"}}, merged)
+}
+
+func TestSecureStorageEngine_ReadRedaction(t *testing.T) {
+	ctx := context.Background()
+	se := MustNewSecureStorageEngine(SecureStorageEngineConfig{
+		StorageEngineConfig: StorageEngineConfig{Storage: NewMemoryStorage()},
+		AccessChecker: MustChainedChecker(
+			NewRoleChecker().WithDefaultRoles("user", "author"),
+			NewRedactionChecker(&FieldMask{Source: true}, "author"),
+			NewRedactionChecker(&FieldMask{Metadata: []string{"internal"}}, "admin"),
+		),
+	})
+	defer se.Close()
+	require.NoError(t, se.Save(ctx, &StoredTemplate{
+		Name:     "greeting",
+		Source:   `Hello {~prompty.var name="who" /~}`,
+		Metadata: map[string]string{"internal": "x", "owner": "team-a"},
+	}))
+
+	user := NewAccessSubject("usr_1").WithRoles("user")
+	author := NewAccessSubject("usr_2").WithRoles("user", "author")
+
+	// Can execute but not view source
+	out, err := se.ExecuteSecure(ctx, "greeting", map[string]any{"who": "Ada"}, user)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello Ada", out)
+
+	tmpl, err := se.GetSecure(ctx, "greeting", user)
+	require.NoError(t, err)
+	assert.Empty(t, tmpl.Source)
+	assert.Equal(t, map[string]string{"owner": "team-a"}, tmpl.Metadata)
+
+	list, err := se.ListSecure(ctx, nil, user)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Empty(t, list[0].Source)
+
+	tmpl, err = se.GetSecure(ctx, "greeting", author)
+	require.NoError(t, err)
+	assert.NotEmpty(t, tmpl.Source)
+	assert.Equal(t, map[string]string{"owner": "team-a"}, tmpl.Metadata)
+
+	// The stored template is untouched
+	stored, err := se.Get(ctx, "greeting")
+	require.NoError(t, err)
+	assert.NotEmpty(t, stored.Source)
+	assert.Len(t, stored.Metadata, 2)
+}
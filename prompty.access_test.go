@@ -2,6 +2,7 @@ package prompty
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -340,6 +341,72 @@ func TestCachedChecker(t *testing.T) {
 		checker.InvalidateAll()
 		assert.Equal(t, 0, checker.Stats().Entries)
 	})
+
+	t.Run("BatchCheck checks only misses", func(t *testing.T) {
+		callCount := 0
+		inner := &countingChecker{allow: true, onCheck: func() { callCount++ }}
+		checker := NewCachedChecker(inner, DefaultCachedCheckerConfig())
+		subject := NewAccessSubject("usr_123")
+
+		_, _ = checker.Check(ctx, NewAccessRequest(OpRead, "a", subject))
+		decisions, err := checker.BatchCheck(ctx, []*AccessRequest{
+			NewAccessRequest(OpRead, "a", subject),
+			NewAccessRequest(OpRead, "b", subject),
+			NewAccessRequest(OpRead, "c", subject),
+		})
+		require.NoError(t, err)
+		require.Len(t, decisions, 3)
+		assert.Equal(t, 3, callCount)
+		assert.Equal(t, 3, checker.Stats().Entries)
+	})
+
+	t.Run("BatchCheck rejects a wrong decision count", func(t *testing.T) {
+		checker := NewCachedChecker(shortBatchChecker{}, DefaultCachedCheckerConfig())
+		subject := NewAccessSubject("usr_123")
+
+		decisions, err := checker.BatchCheck(ctx, []*AccessRequest{
+			NewAccessRequest(OpRead, "a", subject),
+			NewAccessRequest(OpRead, "b", subject),
+		})
+		require.Error(t, err)
+		assert.Nil(t, decisions)
+		assert.Contains(t, err.Error(), ErrMsgBatchDecisions)
+		assert.False(t, errors.Is(err, ErrAccessDenied))
+		assert.Equal(t, 0, checker.Stats().Entries)
+	})
+
+	t.Run("keys include resource version", func(t *testing.T) {
+		subject := NewAccessSubject("usr_123")
+		v1 := NewAccessRequest(OpExecute, "test", subject).WithResource(&StoredTemplate{Name: "test", Version: 1})
+		v2 := NewAccessRequest(OpExecute, "test", subject).WithResource(&StoredTemplate{Name: "test", Version: 2})
+		assert.NotEqual(t, defaultCacheKey(v1), defaultCacheKey(v2))
+	})
+
+	t.Run("template changes invalidate decisions", func(t *testing.T) {
+		callCount := 0
+		inner := &countingChecker{allow: true, onCheck: func() { callCount++ }}
+		checker := NewCachedChecker(inner, DefaultCachedCheckerConfig())
+		se := MustNewSecureStorageEngine(SecureStorageEngineConfig{
+			StorageEngineConfig: StorageEngineConfig{Storage: NewMemoryStorage()},
+			AccessChecker:       checker,
+		})
+		defer se.Close()
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "a", Source: "A"}))
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "b", Source: "B"}))
+
+		subject := NewAccessSubject("usr_123")
+		for _, name := range []string{"a", "b", "a", "b"} {
+			_, err := se.GetSecure(ctx, name, subject)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, callCount)
+
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "a", Source: "A2"}))
+		assert.Equal(t, 1, checker.Stats().Entries)
+		_, _ = se.GetSecure(ctx, "a", subject)
+		_, _ = se.GetSecure(ctx, "b", subject)
+		assert.Equal(t, 3, callCount)
+	})
 }
 
 func TestOperationChecker(t *testing.T) {
@@ -455,6 +522,47 @@ func TestRoleChecker(t *testing.T) {
 	})
 }
 
+func TestAccessSubject_Delegation(t *testing.T) {
+	user := NewAccessSubject("usr_123").WithTenant("org_1").WithRoles("viewer")
+	svc := NewDelegatedSubject("svc_chat", user)
+
+	assert.Equal(t, SubjectTypeService, svc.Type)
+	assert.Equal(t, "org_1", svc.TenantID)
+	assert.True(t, svc.IsDelegated())
+	assert.Same(t, user, svc.EndUser())
+	assert.False(t, user.IsDelegated())
+	assert.Same(t, user, user.EndUser())
+
+	// Cached decisions are keyed by both identities
+	assert.NotEqual(t,
+		defaultCacheKey(NewAccessRequest(OpExecute, "t", svc)),
+		defaultCacheKey(NewAccessRequest(OpExecute, "t", NewDelegatedSubject("svc_chat", NewAccessSubject("usr_456")))))
+}
+
+func TestDelegationChecker(t *testing.T) {
+	ctx := context.Background()
+	checker := NewDelegationChecker(NewRoleChecker().
+		WithOperationRoles(OpExecute, "executor", "agent").
+		WithOperationRoles(OpDelete, "admin"))
+
+	agent := NewServiceSubject("svc_chat").WithRoles("agent", "admin")
+	user := NewAccessSubject("usr_123").WithRoles("executor")
+
+	decision, err := checker.Check(ctx, NewAccessRequest(OpExecute, "t", agent.WithOnBehalfOf(user)))
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+
+	// The service may delete, but not for a user who may not
+	decision, err = checker.Check(ctx, NewAccessRequest(OpDelete, "t", agent))
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Contains(t, decision.Reason, "end user denied")
+
+	decision, err = checker.Check(ctx, NewAccessRequest(OpDelete, "t", NewServiceSubject("svc_chat").WithRoles("admin")))
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+}
+
 func TestAccessError(t *testing.T) {
 	t.Run("error message formatting", func(t *testing.T) {
 		err := &AccessError{
@@ -495,6 +603,17 @@ func (c *countingChecker) Check(ctx context.Context, req *AccessRequest) (*Acces
 	return Deny("counting checker"), nil
 }
 
+// shortBatchChecker returns one decision fewer than requested from BatchCheck.
+type shortBatchChecker struct{}
+
+func (shortBatchChecker) Check(ctx context.Context, req *AccessRequest) (*AccessDecision, error) {
+	return Allow("short"), nil
+}
+
+func (shortBatchChecker) BatchCheck(ctx context.Context, reqs []*AccessRequest) ([]*AccessDecision, error) {
+	return []*AccessDecision{Allow("short")}, nil
+}
+
 func (c *countingChecker) BatchCheck(ctx context.Context, reqs []*AccessRequest) ([]*AccessDecision, error) {
 	decisions := make([]*AccessDecision, len(reqs))
 	for i := range reqs {
@@ -0,0 +1,255 @@
+package prompty
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Constraint enforcement formats and separators
+const (
+	constraintViolationFormat = "%s: %s (%s)"
+	constraintURLSchemeSep    = "://"
+	constraintURLNetPrefix    = "//"
+	constraintDomainWildcard  = "*."
+	constraintDomainSep       = "."
+	constraintDomainListSep   = ", "
+)
+
+// ConstraintViolation describes a check that failed against an agent's
+// constraints.
+type ConstraintViolation struct {
+	// Kind is the constraint that was violated.
+	Kind ConstraintKind
+	// Message describes the violation (one of the ConstraintMsg constants).
+	Message string
+	// Value is the offending value: turn number, token count, host, tool
+	// name or matched output text.
+	Value string
+	// Rule is the violated limit or rule: the maximum, the matching domain
+	// or the output rule's constraint text.
+	Rule string
+}
+
+// Error implements the error interface.
+func (v *ConstraintViolation) Error() string {
+	return fmt.Sprintf(constraintViolationFormat, v.Kind, v.Message, v.Value)
+}
+
+// OutputRule implements a behavioral or safety constraint as a regular
+// expression checked by ConstraintEnforcer.FilterOutput.
+type OutputRule struct {
+	// Kind is ConstraintKindBehavioral or ConstraintKindSafety.
+	Kind ConstraintKind
+	// Rule is the constraint text the pattern implements,
+	// e.g. "Never share PII".
+	Rule string
+	// Pattern matches output violating the rule.
+	Pattern *regexp.Regexp
+	// Action is what to do with matching output (report by default).
+	Action OutputRuleAction
+	// Replacement replaces matches of redacting rules
+	// (DefaultOutputRedaction if empty).
+	Replacement string
+}
+
+// FilteredOutput is the result of ConstraintEnforcer.FilterOutput.
+type FilteredOutput struct {
+	// Text is the output after redaction ("" when blocked).
+	Text string
+	// Blocked reports whether a blocking rule matched.
+	Blocked bool
+	// Violations lists the matching rules in rule order.
+	Violations []ConstraintViolation
+}
+
+// ConstraintEnforcer checks the actions of an agent loop against the
+// constraints of a compiled agent: turn and token limits, domain allow and
+// block lists, declared tools, and output rules for behavioral and safety
+// constraints. Each check returns nil when allowed. An enforcer is safe for
+// concurrent use.
+type ConstraintEnforcer struct {
+	constraints *OperationalConstraints
+	tools       *ToolsConfig
+	rules       []OutputRule
+}
+
+// NewConstraintEnforcer creates an enforcer for the constraints and tools
+// of a compiled agent. Behavioral and safety constraints are free text, so
+// they are enforced only through the given output rules.
+func NewConstraintEnforcer(compiled *CompiledPrompt, rules ...OutputRule) *ConstraintEnforcer {
+	e := &ConstraintEnforcer{rules: rules}
+	if compiled != nil {
+		e.constraints = compiled.Constraints
+		e.tools = compiled.Tools
+	}
+	return e
+}
+
+// AllowTurn checks a 1-based turn number against max_turns.
+func (e *ConstraintEnforcer) AllowTurn(n int) *ConstraintViolation {
+	if e.constraints == nil || e.constraints.MaxTurns == nil || n <= *e.constraints.MaxTurns {
+		return nil
+	}
+	return &ConstraintViolation{
+		Kind:    ConstraintKindMaxTurns,
+		Message: ConstraintMsgMaxTurns,
+		Value:   strconv.Itoa(n),
+		Rule:    strconv.Itoa(*e.constraints.MaxTurns),
+	}
+}
+
+// AllowTokens checks the token count of one turn against max_tokens_per_turn.
+func (e *ConstraintEnforcer) AllowTokens(tokens int) *ConstraintViolation {
+	if e.constraints == nil || e.constraints.MaxTokensPerTurn == nil || tokens <= *e.constraints.MaxTokensPerTurn {
+		return nil
+	}
+	return &ConstraintViolation{
+		Kind:    ConstraintKindMaxTokensPerTurn,
+		Message: ConstraintMsgMaxTokensPerTurn,
+		Value:   strconv.Itoa(tokens),
+		Rule:    strconv.Itoa(*e.constraints.MaxTokensPerTurn),
+	}
+}
+
+// AllowDomain checks the host of a URL (or a bare host) against the blocked
+// and allowed domains. A domain also covers its subdomains, and a leading
+// "*." is ignored. Blocked domains win over allowed ones; an empty allow
+// list allows every domain that is not blocked.
+func (e *ConstraintEnforcer) AllowDomain(rawURL string) *ConstraintViolation {
+	if e.constraints == nil || (len(e.constraints.AllowedDomains) == 0 && len(e.constraints.BlockedDomains) == 0) {
+		return nil
+	}
+
+	host := constraintHost(rawURL)
+	if host == "" {
+		kind := ConstraintKindAllowedDomains
+		if len(e.constraints.AllowedDomains) == 0 {
+			kind = ConstraintKindBlockedDomains
+		}
+		return &ConstraintViolation{Kind: kind, Message: ConstraintMsgDomainInvalid, Value: rawURL}
+	}
+
+	for _, domain := range e.constraints.BlockedDomains {
+		if domainMatches(host, domain) {
+			return &ConstraintViolation{
+				Kind:    ConstraintKindBlockedDomains,
+				Message: ConstraintMsgDomainBlocked,
+				Value:   host,
+				Rule:    domain,
+			}
+		}
+	}
+	if len(e.constraints.AllowedDomains) == 0 {
+		return nil
+	}
+	for _, domain := range e.constraints.AllowedDomains {
+		if domainMatches(host, domain) {
+			return nil
+		}
+	}
+	return &ConstraintViolation{
+		Kind:    ConstraintKindAllowedDomains,
+		Message: ConstraintMsgDomainNotAllowed,
+		Value:   host,
+		Rule:    strings.Join(e.constraints.AllowedDomains, constraintDomainListSep),
+	}
+}
+
+// AllowTool checks that a tool call names a declared function or a tool of
+// an MCP server. MCP servers that do not list their tools allow any name.
+// No tool is allowed when tool_choice is "none".
+func (e *ConstraintEnforcer) AllowTool(name string) *ConstraintViolation {
+	if e.tools != nil && e.tools.ToolChoice == ToolChoiceNone {
+		return &ConstraintViolation{
+			Kind:    ConstraintKindTools,
+			Message: ConstraintMsgToolsDisabled,
+			Value:   name,
+			Rule:    ToolChoiceNone,
+		}
+	}
+	if e.tools != nil {
+		for _, fn := range e.tools.Functions {
+			if fn != nil && fn.Name == name {
+				return nil
+			}
+		}
+		for _, server := range e.tools.MCPServers {
+			if server == nil {
+				continue
+			}
+			if len(server.Tools) == 0 {
+				return nil
+			}
+			for _, tool := range server.Tools {
+				if tool == name {
+					return nil
+				}
+			}
+		}
+	}
+	return &ConstraintViolation{
+		Kind:    ConstraintKindTools,
+		Message: ConstraintMsgToolNotDeclared,
+		Value:   name,
+	}
+}
+
+// FilterOutput applies the output rules to model output. Every matching rule
+// is reported; redacting rules replace their matches and a blocking rule
+// withholds the output.
+func (e *ConstraintEnforcer) FilterOutput(text string) FilteredOutput {
+	result := FilteredOutput{Text: text}
+	for _, rule := range e.rules {
+		if rule.Pattern == nil {
+			continue
+		}
+		loc := rule.Pattern.FindStringIndex(result.Text)
+		if loc == nil {
+			continue
+		}
+		result.Violations = append(result.Violations, ConstraintViolation{
+			Kind:    rule.Kind,
+			Message: ConstraintMsgOutputRule,
+			Value:   result.Text[loc[0]:loc[1]],
+			Rule:    rule.Rule,
+		})
+		switch rule.Action {
+		case OutputRuleActionRedact:
+			replacement := rule.Replacement
+			if replacement == "" {
+				replacement = DefaultOutputRedaction
+			}
+			result.Text = rule.Pattern.ReplaceAllLiteralString(result.Text, replacement)
+		case OutputRuleActionBlock:
+			result.Blocked = true
+		}
+	}
+	if result.Blocked {
+		result.Text = ""
+	}
+	return result
+}
+
+// constraintHost returns the lower-cased host of a URL or bare host.
+func constraintHost(rawURL string) string {
+	if !strings.Contains(rawURL, constraintURLSchemeSep) {
+		rawURL = constraintURLNetPrefix + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(u.Hostname()), constraintDomainSep)
+}
+
+// domainMatches reports whether host is domain or one of its subdomains.
+func domainMatches(host, domain string) bool {
+	domain = strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(domain), constraintDomainWildcard), constraintDomainSep)
+	if domain == "" {
+		return false
+	}
+	return host == domain || strings.HasSuffix(host, constraintDomainSep+domain)
+}
@@ -0,0 +1,148 @@
+package prompty
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEnforcer(rules ...OutputRule) *ConstraintEnforcer {
+	maxTurns, maxTokens := 3, 100
+	return NewConstraintEnforcer(&CompiledPrompt{
+		Constraints: &OperationalConstraints{
+			MaxTurns:         &maxTurns,
+			MaxTokensPerTurn: &maxTokens,
+			AllowedDomains:   []string{"example.com", "*.docs.io"},
+			BlockedDomains:   []string{"private.example.com"},
+		},
+		Tools: &ToolsConfig{
+			Functions:  []*FunctionDef{{Name: "search"}},
+			MCPServers: []*MCPServer{{Name: "fs", Tools: []string{"read_file"}}},
+		},
+	}, rules...)
+}
+
+func TestConstraintEnforcer_Limits(t *testing.T) {
+	e := newTestEnforcer()
+
+	assert.Nil(t, e.AllowTurn(3))
+	v := e.AllowTurn(4)
+	require.NotNil(t, v)
+	assert.Equal(t, ConstraintKindMaxTurns, v.Kind)
+	assert.Equal(t, "4", v.Value)
+	assert.Equal(t, "3", v.Rule)
+	assert.Contains(t, v.Error(), ConstraintMsgMaxTurns)
+
+	assert.Nil(t, e.AllowTokens(100))
+	v = e.AllowTokens(101)
+	require.NotNil(t, v)
+	assert.Equal(t, ConstraintKindMaxTokensPerTurn, v.Kind)
+
+	unconstrained := NewConstraintEnforcer(&CompiledPrompt{})
+	assert.Nil(t, unconstrained.AllowTurn(1000))
+	assert.Nil(t, unconstrained.AllowTokens(1000))
+	assert.Nil(t, unconstrained.AllowDomain("https://anything.net"))
+}
+
+func TestConstraintEnforcer_AllowDomain(t *testing.T) {
+	e := newTestEnforcer()
+
+	for _, allowed := range []string{"https://example.com/path", "api.example.com", "https://a.docs.io:8443", "HTTPS://EXAMPLE.COM."} {
+		assert.Nil(t, e.AllowDomain(allowed), allowed)
+	}
+
+	v := e.AllowDomain("https://private.example.com/x")
+	require.NotNil(t, v)
+	assert.Equal(t, ConstraintKindBlockedDomains, v.Kind)
+	assert.Equal(t, "private.example.com", v.Rule)
+
+	v = e.AllowDomain("https://notexample.com")
+	require.NotNil(t, v)
+	assert.Equal(t, ConstraintKindAllowedDomains, v.Kind)
+	assert.Equal(t, "notexample.com", v.Value)
+
+	v = e.AllowDomain("https://")
+	require.NotNil(t, v)
+	assert.Equal(t, ConstraintMsgDomainInvalid, v.Message)
+
+	blockOnly := NewConstraintEnforcer(&CompiledPrompt{Constraints: &OperationalConstraints{BlockedDomains: []string{"evil.org"}}})
+	assert.Nil(t, blockOnly.AllowDomain("good.org"))
+	assert.NotNil(t, blockOnly.AllowDomain("www.evil.org"))
+}
+
+func TestConstraintEnforcer_AllowTool(t *testing.T) {
+	e := newTestEnforcer()
+	assert.Nil(t, e.AllowTool("search"))
+	assert.Nil(t, e.AllowTool("read_file"))
+
+	v := e.AllowTool("delete_file")
+	require.NotNil(t, v)
+	assert.Equal(t, ConstraintKindTools, v.Kind)
+	assert.Equal(t, ConstraintMsgToolNotDeclared, v.Message)
+
+	open := NewConstraintEnforcer(&CompiledPrompt{Tools: &ToolsConfig{MCPServers: []*MCPServer{{Name: "all"}}}})
+	assert.Nil(t, open.AllowTool("anything"))
+
+	disabled := NewConstraintEnforcer(&CompiledPrompt{Tools: &ToolsConfig{Functions: []*FunctionDef{{Name: "search"}}, ToolChoice: ToolChoiceNone}})
+	v = disabled.AllowTool("search")
+	require.NotNil(t, v)
+	assert.Equal(t, ConstraintMsgToolsDisabled, v.Message)
+
+	assert.NotNil(t, NewConstraintEnforcer(nil).AllowTool("search"))
+}
+
+func TestConstraintEnforcer_FilterOutput(t *testing.T) {
+	e := newTestEnforcer(
+		OutputRule{Kind: ConstraintKindSafety, Rule: "Never share PII", Pattern: regexp.MustCompile(`\d{3}-\d{2}-\d{4}`), Action: OutputRuleActionRedact},
+		OutputRule{Kind: ConstraintKindBehavioral, Rule: "Use formal language", Pattern: regexp.MustCompile(`(?i)\bgonna\b`)},
+		OutputRule{Kind: ConstraintKindSafety, Rule: "Refuse harmful requests", Pattern: regexp.MustCompile(`(?i)how to build a bomb`), Action: OutputRuleActionBlock},
+	)
+
+	result := e.FilterOutput("I'm gonna say your SSN is 123-45-6789.")
+	assert.False(t, result.Blocked)
+	assert.Equal(t, "I'm gonna say your SSN is [REDACTED].", result.Text)
+	require.Len(t, result.Violations, 2)
+	assert.Equal(t, ConstraintKindSafety, result.Violations[0].Kind)
+	assert.Equal(t, "123-45-6789", result.Violations[0].Value)
+	assert.Equal(t, "Never share PII", result.Violations[0].Rule)
+	assert.Equal(t, ConstraintKindBehavioral, result.Violations[1].Kind)
+
+	result = e.FilterOutput("Here is how to build a bomb")
+	assert.True(t, result.Blocked)
+	assert.Empty(t, result.Text)
+	require.Len(t, result.Violations, 1)
+
+	result = e.FilterOutput("Certainly.")
+	assert.Equal(t, "Certainly.", result.Text)
+	assert.Empty(t, result.Violations)
+}
+
+func TestConstraintEnforcer_FromCompiledAgent(t *testing.T) {
+	doc := `---
+name: constrained
+description: Constrained agent
+type: agent
+tools:
+  functions:
+    - name: lookup
+      description: Look up a record
+constraints:
+  operational:
+    max_turns: 2
+    allowed_domains: [example.com]
+---
+Agent`
+	agent, err := Parse([]byte(doc))
+	require.NoError(t, err)
+	compiled, err := agent.CompileAgent(context.Background(), nil, nil)
+	require.NoError(t, err)
+
+	e := NewConstraintEnforcer(compiled)
+	assert.Nil(t, e.AllowTurn(2))
+	assert.NotNil(t, e.AllowTurn(3))
+	assert.Nil(t, e.AllowTool("lookup"))
+	assert.NotNil(t, e.AllowDomain("other.org"))
+}
@@ -10,6 +10,7 @@ const (
 	ErrMsgAgentExecNilPrompt   = "prompt cannot be nil"
 	ErrMsgAgentExecReadFile    = "failed to read agent file"
 	ErrMsgAgentExecParseFailed = "failed to parse agent source"
+	ErrMsgAgentExecNoStore     = "agent executor has no conversation store"
 )
 
 // AgentExecutor is a high-level convenience wrapper that combines parsing,
@@ -33,6 +34,10 @@ type AgentExecutor struct {
 	engine              *Engine
 	skillsCatalogFormat CatalogFormat
 	toolsCatalogFormat  CatalogFormat
+	conversations       ConversationStore
+	conversationTokens  int
+	models              *ModelRegistry
+	environment         string
 }
 
 // AgentExecutorOption is a functional option for configuring AgentExecutor.
@@ -66,6 +71,30 @@ func WithAgentToolsCatalogFormat(f CatalogFormat) AgentExecutorOption {
 	}
 }
 
+// WithAgentConversationStore persists multi-turn conversations. Inputs with
+// a "conversation_id" compile with that conversation's transcript.
+func WithAgentConversationStore(store ConversationStore) AgentExecutorOption {
+	return func(ae *AgentExecutor) {
+		ae.conversations = store
+	}
+}
+
+// WithAgentConversationMaxTokens sets the token budget of stored transcripts.
+func WithAgentConversationMaxTokens(maxTokens int) AgentExecutorOption {
+	return func(ae *AgentExecutor) {
+		ae.conversationTokens = maxTokens
+	}
+}
+
+// WithAgentModelRegistry resolves model aliases with registry, applying the
+// overrides of environment ("" for none).
+func WithAgentModelRegistry(registry *ModelRegistry, environment string) AgentExecutorOption {
+	return func(ae *AgentExecutor) {
+		ae.models = registry
+		ae.environment = environment
+	}
+}
+
 // NewAgentExecutor creates a new AgentExecutor with the given options.
 func NewAgentExecutor(options ...AgentExecutorOption) *AgentExecutor {
 	ae := &AgentExecutor{}
@@ -106,7 +135,7 @@ func (ae *AgentExecutor) ExecutePrompt(ctx context.Context, prompt *Prompt, inpu
 		return nil, err
 	}
 
-	return prompt.CompileAgent(ctx, input, ae.compileOptions())
+	return prompt.CompileAgent(ctx, input, ae.compileOptions(input))
 }
 
 // ActivateSkill parses agent source, validates it, and activates a specific skill.
@@ -121,7 +150,7 @@ func (ae *AgentExecutor) ActivateSkill(ctx context.Context, source string, skill
 		return nil, err
 	}
 
-	compiled, err := prompt.ActivateSkill(ctx, skillSlug, input, ae.compileOptions())
+	compiled, err := prompt.ActivateSkill(ctx, skillSlug, input, ae.compileOptions(input))
 	if err != nil {
 		return nil, err
 	}
@@ -132,16 +161,34 @@ func (ae *AgentExecutor) ActivateSkill(ctx context.Context, source string, skill
 	} else if runtimeExec != nil {
 		compiled.Execution = runtimeExec.Clone()
 	}
+	if runtimeExec != nil && ae.models != nil {
+		compiled.Execution = ae.models.Resolve(compiled.Execution, ae.environment)
+	}
 
 	return compiled, nil
 }
 
-// compileOptions builds CompileOptions from the executor's configuration.
-func (ae *AgentExecutor) compileOptions() *CompileOptions {
-	return &CompileOptions{
-		Resolver:            ae.resolver,
-		Engine:              ae.engine,
-		SkillsCatalogFormat: ae.skillsCatalogFormat,
-		ToolsCatalogFormat:  ae.toolsCatalogFormat,
+// RecordReply appends the model's reply to a conversation.
+func (ae *AgentExecutor) RecordReply(ctx context.Context, conversationID string, content string) error {
+	if ae.conversations == nil {
+		return &StorageError{Message: ErrMsgAgentExecNoStore}
+	}
+	return ae.conversations.Append(ctx, conversationID, Message{Role: RoleAssistant, Content: content})
+}
+
+// compileOptions builds CompileOptions from the executor's configuration
+// and the conversation_id of input.
+func (ae *AgentExecutor) compileOptions(input map[string]any) *CompileOptions {
+	opts := &CompileOptions{
+		Resolver:              ae.resolver,
+		Engine:                ae.engine,
+		SkillsCatalogFormat:   ae.skillsCatalogFormat,
+		ToolsCatalogFormat:    ae.toolsCatalogFormat,
+		ConversationStore:     ae.conversations,
+		ConversationMaxTokens: ae.conversationTokens,
+		ModelRegistry:         ae.models,
+		Environment:           ae.environment,
 	}
+	opts.ConversationID, _ = input[InputKeyConversationID].(string)
+	return opts
 }
@@ -0,0 +1,263 @@
+package prompty
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2/ast"
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// AST returns the syntax tree of the template body for analysis or
+// rewriting with the ast package. Each call returns a new tree, so changes
+// do not affect the template. Comments are not part of the tree.
+func (t *Template) AST() *ast.Root {
+	return &ast.Root{Children: publicNodes(t.ast.Children)}
+}
+
+// ParseAST parses a template body into a syntax tree with the default
+// delimiters. Frontmatter is not part of the tree; use Parse for documents.
+func ParseAST(source string) (*ast.Root, error) {
+	return parseAST(source, internal.DefaultLexerConfig())
+}
+
+// ParseAST is like the package-level ParseAST but uses the engine's delimiters.
+func (e *Engine) ParseAST(source string) (*ast.Root, error) {
+	return parseAST(source, e.config.lexerConfig())
+}
+
+// PrintAST renders a syntax tree as template source with the default
+// delimiters, escaping text and quoting attributes as Format does. Parsing
+// the result yields an equivalent tree.
+func PrintAST(root *ast.Root) string {
+	return printAST(root, DefaultOpenDelim, DefaultCloseDelim)
+}
+
+// PrintAST is like the package-level PrintAST but uses the engine's delimiters.
+func (e *Engine) PrintAST(root *ast.Root) string {
+	return printAST(root, e.config.openDelim, e.config.closeDelim)
+}
+
+// parseAST tokenizes and parses source into a public syntax tree.
+func parseAST(source string, config internal.LexerConfig) (*ast.Root, error) {
+	tokens, err := internal.NewLexerWithConfig(source, config, nil).Tokenize()
+	if err != nil {
+		return nil, NewParseError(ErrMsgParseFailed, Position{}, err)
+	}
+	root, err := internal.NewParserWithConfig(tokens, source, config, nil).Parse()
+	if err != nil {
+		return nil, NewParseError(ErrMsgParseFailed, Position{}, err)
+	}
+	return &ast.Root{Children: publicNodes(root.Children)}, nil
+}
+
+// publicNodes converts internal nodes to public syntax tree nodes. Adjacent
+// text, which the lexer splits at escaped delimiters, is merged.
+func publicNodes(nodes []internal.Node) []ast.Node {
+	if len(nodes) == 0 {
+		return nil
+	}
+	result := make([]ast.Node, 0, len(nodes))
+	for _, node := range nodes {
+		n := publicNode(node)
+		if n == nil {
+			continue
+		}
+		if text, ok := n.(*ast.Text); ok && len(result) > 0 {
+			if prev, ok := result[len(result)-1].(*ast.Text); ok {
+				prev.Content += text.Content
+				continue
+			}
+		}
+		result = append(result, n)
+	}
+	return result
+}
+
+// publicNode converts an internal node to a public syntax tree node.
+func publicNode(node internal.Node) ast.Node {
+	pos := astPosition(node.Pos())
+	switch n := node.(type) {
+	case *internal.TextNode:
+		return &ast.Text{Position: pos, Content: n.Content}
+	case *internal.TagNode:
+		if n.IsRaw() {
+			return &ast.Raw{Position: pos, Content: n.RawContent}
+		}
+		return &ast.Tag{
+			Position:   pos,
+			Name:       n.Name,
+			Attributes: n.Attributes.Map(),
+			SelfClose:  n.SelfClose,
+			Children:   publicNodes(n.Children),
+		}
+	case *internal.BlockNode:
+		return &ast.Block{Position: pos, Name: n.Name, Children: publicNodes(n.Children)}
+	case *internal.ForNode:
+		return &ast.For{
+			Position: pos,
+			Item:     n.ItemVar,
+			Index:    n.IndexVar,
+			In:       n.Source,
+			Limit:    n.Limit,
+			Children: publicNodes(n.Children),
+		}
+	case *internal.ConditionalNode:
+		cond := &ast.Conditional{Position: pos}
+		for _, branch := range n.Branches {
+			cond.Branches = append(cond.Branches, &ast.Branch{
+				Position:  astPosition(branch.Pos),
+				Condition: branch.Condition,
+				Else:      branch.IsElse,
+				Children:  publicNodes(branch.Children),
+			})
+		}
+		return cond
+	case *internal.SwitchNode:
+		sw := &ast.Switch{Position: pos, Expression: n.Expression}
+		for i := range n.Cases {
+			sw.Cases = append(sw.Cases, publicCase(&n.Cases[i]))
+		}
+		if n.Default != nil {
+			sw.Default = publicCase(n.Default)
+		}
+		return sw
+	}
+	return nil
+}
+
+// publicCase converts a switch case.
+func publicCase(c *internal.SwitchCase) *ast.Case {
+	return &ast.Case{
+		Position:    astPosition(c.Pos),
+		Value:       c.Value,
+		Eval:        c.Eval,
+		Fallthrough: c.Fallthrough,
+		Children:    publicNodes(c.Children),
+	}
+}
+
+// astPosition converts an internal source position.
+func astPosition(pos internal.Position) ast.Position {
+	return ast.Position{Offset: pos.Offset, Line: pos.Line, Column: pos.Column}
+}
+
+// astPrinter renders syntax trees with a pair of delimiters.
+type astPrinter struct {
+	sb         strings.Builder
+	openDelim  string
+	closeDelim string
+}
+
+// printAST renders a syntax tree as template source.
+func printAST(root *ast.Root, openDelim, closeDelim string) string {
+	if root == nil {
+		return ""
+	}
+	p := &astPrinter{openDelim: openDelim, closeDelim: closeDelim}
+	p.nodes(root.Children)
+	return p.sb.String()
+}
+
+// nodes renders a list of nodes.
+func (p *astPrinter) nodes(nodes []ast.Node) {
+	for _, node := range nodes {
+		p.node(node)
+	}
+}
+
+// node renders one node.
+func (p *astPrinter) node(node ast.Node) {
+	switch n := node.(type) {
+	case *ast.Text:
+		p.sb.WriteString(strings.ReplaceAll(n.Content, p.openDelim, string(internal.CharBackslash)+p.openDelim))
+	case *ast.Raw:
+		p.open(TagNameRaw, nil, false)
+		p.sb.WriteString(n.Content)
+		p.close(TagNameRaw)
+	case *ast.Tag:
+		p.open(n.Name, n.Attributes, n.SelfClose)
+		if !n.SelfClose {
+			p.nodes(n.Children)
+			p.close(n.Name)
+		}
+	case *ast.Block:
+		p.open(TagNameBlock, map[string]string{AttrName: n.Name}, false)
+		p.nodes(n.Children)
+		p.close(TagNameBlock)
+	case *ast.For:
+		attrs := map[string]string{AttrItem: n.Item, AttrIn: n.In}
+		if n.Index != "" {
+			attrs[AttrIndex] = n.Index
+		}
+		if n.Limit > 0 {
+			attrs[AttrLimit] = strconv.Itoa(n.Limit)
+		}
+		p.open(TagNameFor, attrs, false)
+		p.nodes(n.Children)
+		p.close(TagNameFor)
+	case *ast.Conditional:
+		for i, branch := range n.Branches {
+			switch {
+			case i == 0:
+				p.open(TagNameIf, map[string]string{AttrEval: branch.Condition}, false)
+			case branch.Else:
+				p.open(TagNameElse, nil, false)
+			default:
+				p.open(TagNameElseIf, map[string]string{AttrEval: branch.Condition}, false)
+			}
+			p.nodes(branch.Children)
+		}
+		p.close(TagNameIf)
+	case *ast.Switch:
+		p.open(TagNameSwitch, map[string]string{AttrEval: n.Expression}, false)
+		for _, c := range n.Cases {
+			attrs := map[string]string{}
+			if c.Eval != "" {
+				attrs[AttrEval] = c.Eval
+			} else {
+				attrs[AttrValue] = c.Value
+			}
+			if c.Fallthrough {
+				attrs[AttrFallthrough] = AttrValueTrue
+			}
+			p.open(TagNameCase, attrs, false)
+			p.nodes(c.Children)
+			p.close(TagNameCase)
+		}
+		if n.Default != nil {
+			p.open(TagNameCaseDefault, nil, false)
+			p.nodes(n.Default.Children)
+			p.close(TagNameCaseDefault)
+		}
+		p.close(TagNameSwitch)
+	}
+}
+
+// open writes an opening or self-closing tag with its attributes in
+// canonical order.
+func (p *astPrinter) open(name string, attrs map[string]string, selfClose bool) {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sortAttrKeys(keys)
+
+	p.sb.WriteString(p.openDelim)
+	p.sb.WriteString(name)
+	for _, key := range keys {
+		p.sb.WriteString(" ")
+		p.sb.WriteString(key)
+		p.sb.WriteString("=")
+		p.sb.WriteString(quoteAttrValue(attrs[key]))
+	}
+	if selfClose {
+		p.sb.WriteString(" " + DelimiterSlash)
+	}
+	p.sb.WriteString(p.closeDelim)
+}
+
+// close writes the closing tag of a block.
+func (p *astPrinter) close(name string) {
+	p.sb.WriteString(p.openDelim + DelimiterSlash + name + p.closeDelim)
+}
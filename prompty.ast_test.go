@@ -0,0 +1,123 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/itsatony/go-prompty/v2/ast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testASTSource = `Hi \{~ {~prompty.var name="user" default='say "hi"' /~}
+{~prompty.comment~}dropped{~/prompty.comment~}{~prompty.if eval="a > 1"~}big{~prompty.elseif eval="a == 1"~}one{~prompty.else~}small{~/prompty.if~}
+{~prompty.for item="x" index="i" in="xs" limit="2"~}{~prompty.var name="x" /~}{~/prompty.for~}
+{~prompty.switch eval="s"~}{~prompty.case value="a" fallthrough="true"~}A{~/prompty.case~}{~prompty.case eval="s == 'b'"~}B{~/prompty.case~}{~prompty.casedefault~}D{~/prompty.casedefault~}{~/prompty.switch~}
+{~prompty.block name="footer"~}{~prompty.raw~}{~not parsed~}{~/prompty.raw~}{~/prompty.block~}
+{~prompty.message role="system"~}Be nice.{~/prompty.message~}`
+
+func TestTemplate_AST(t *testing.T) {
+	tmpl, err := MustNew().Parse(testASTSource)
+	require.NoError(t, err)
+
+	root := tmpl.AST()
+	text := root.Children[0].(*ast.Text)
+	assert.Equal(t, "Hi {~ ", text.Content)
+	assert.Equal(t, 1, text.Pos().Line)
+
+	tag := root.Children[1].(*ast.Tag)
+	assert.Equal(t, TagNameVar, tag.Name)
+	assert.Equal(t, map[string]string{"name": "user", "default": `say "hi"`}, tag.Attributes)
+	assert.True(t, tag.SelfClose)
+
+	var cond *ast.Conditional
+	var loop *ast.For
+	var sw *ast.Switch
+	var block *ast.Block
+	ast.Inspect(root, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Conditional:
+			cond = n
+		case *ast.For:
+			loop = n
+		case *ast.Switch:
+			sw = n
+		case *ast.Block:
+			block = n
+		}
+		return true
+	})
+	require.NotNil(t, cond)
+	require.Len(t, cond.Branches, 3)
+	assert.Equal(t, "a == 1", cond.Branches[1].Condition)
+	assert.True(t, cond.Branches[2].Else)
+	assert.Equal(t, 2, cond.Pos().Line)
+	assert.Equal(t, &ast.For{Position: loop.Position, Item: "x", Index: "i", In: "xs", Limit: 2,
+		Children: []ast.Node{loop.Children[0]}}, loop)
+	assert.True(t, sw.Cases[0].Fallthrough)
+	assert.Equal(t, "s == 'b'", sw.Cases[1].Eval)
+	require.NotNil(t, sw.Default)
+	assert.Equal(t, "footer", block.Name)
+	assert.Equal(t, "{~not parsed~}", block.Children[0].(*ast.Raw).Content)
+
+	// The tree is a copy
+	tag.Name = "changed"
+	assert.Equal(t, TagNameVar, tmpl.AST().Children[1].(*ast.Tag).Name)
+}
+
+func TestPrintAST_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	root, err := ParseAST(testASTSource)
+	require.NoError(t, err)
+
+	printed := PrintAST(root)
+	reparsed, err := ParseAST(printed)
+	require.NoError(t, err)
+	assert.Equal(t, printed, PrintAST(reparsed))
+
+	data := map[string]any{"a": 1, "xs": []any{"p", "q", "r"}, "s": "a"}
+	want, err := MustNew().Execute(ctx, testASTSource, data)
+	require.NoError(t, err)
+	got, err := MustNew().Execute(ctx, printed, data)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	// Engine delimiters
+	engine := MustNew(WithDelimiters("<<", ">>"))
+	root, err = engine.ParseAST(`a \<< <<prompty.var name="x" />>`)
+	require.NoError(t, err)
+	assert.Equal(t, `a \<< <<prompty.var name="x" />>`, engine.PrintAST(root))
+	assert.Equal(t, `a << {~prompty.var name="x" /~}`, PrintAST(root))
+
+	_, err = ParseAST(`{~prompty.if eval="x"~}unclosed`)
+	assert.Error(t, err)
+}
+
+func TestTransform_Rewrite(t *testing.T) {
+	ctx := context.Background()
+	root, err := ParseAST(`{~legacy.user /~}: {~prompty.message role="system"~}Answer.{~/prompty.message~}`)
+	require.NoError(t, err)
+
+	// Migrate a deprecated tag and inject a disclaimer into system messages
+	root = ast.Transform(root, func(n ast.Node) ast.Node {
+		tag, ok := n.(*ast.Tag)
+		if !ok {
+			return n
+		}
+		switch {
+		case tag.Name == "legacy.user":
+			return &ast.Tag{Name: TagNameVar, Attributes: map[string]string{AttrName: "user"}, SelfClose: true}
+		case tag.Name == TagNameMessage && tag.Attributes[AttrRole] == RoleSystem:
+			tag.Children = append(tag.Children, &ast.Text{Content: " Not legal advice."})
+		}
+		return n
+	})
+
+	source := PrintAST(root)
+	assert.Equal(t, `{~prompty.var name="user" /~}: {~prompty.message role="system"~}Answer. Not legal advice.{~/prompty.message~}`, source)
+	tmpl, err := MustNew().Parse(source)
+	require.NoError(t, err)
+	messages, err := tmpl.ExecuteAndExtractMessages(ctx, map[string]any{"user": "Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, "Answer. Not legal advice.", messages[0].Content)
+}
@@ -99,6 +99,21 @@ Best regards,
 	}
 }
 
+func BenchmarkReparse_Large(b *testing.B) {
+	engine := MustNew()
+	source := strings.Repeat(`Record {~prompty.var name="id" /~}: some context text.`+"\n", 20000)
+	tmpl, err := engine.Parse(source)
+	if err != nil {
+		b.Fatal(err)
+	}
+	edit := Edit{Offset: len(source) / 2, Text: "x"}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = tmpl.Reparse(edit)
+	}
+}
+
 // =============================================================================
 // EXECUTION BENCHMARKS
 // =============================================================================
@@ -685,6 +700,19 @@ func BenchmarkExecuteRegistered(b *testing.B) {
 	}
 }
 
+func BenchmarkEngine_Clone(b *testing.B) {
+	engine := MustNew()
+	for i := 0; i < 100; i++ {
+		engine.MustRegisterTemplate(fmt.Sprintf("template%d", i), `Hello {~prompty.var name="user" /~}!`)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = engine.Clone()
+	}
+}
+
 // =============================================================================
 // CONTEXT OPERATIONS BENCHMARKS
 // =============================================================================
@@ -0,0 +1,153 @@
+package prompty
+
+import (
+	"github.com/itsatony/go-prompty/v2/ast"
+)
+
+// TemplateBuilder assembles a template from text and tags in Go code, e.g.
+// from dynamic business rules, without concatenating template syntax by
+// hand. Text is escaped and attribute values are quoted, so values holding
+// delimiters or quotes cannot break the template. Nested content such as
+// the branches of If is given as another builder (nil for none):
+//
+//	tmpl, err := prompty.Builder().
+//	    System(prompty.Builder().Text("You support ").Var("company").Text(" customers.")).
+//	    If("user.tier == 'gold'",
+//	        prompty.Builder().Text("Offer priority handling."),
+//	        prompty.Builder().Text("Point to the help center.")).
+//	    For("item", "orders", prompty.Builder().Var("item.id").Text("\n")).
+//	    Build()
+//
+// Methods append to the builder and return it for chaining. The result is
+// built as a syntax tree of the ast package (AST) and printed as source.
+type TemplateBuilder struct {
+	nodes []ast.Node
+}
+
+// Builder creates an empty template builder.
+func Builder() *TemplateBuilder {
+	return &TemplateBuilder{}
+}
+
+// Text appends literal text. Delimiters in the text are escaped.
+func (b *TemplateBuilder) Text(text string) *TemplateBuilder {
+	if text != "" {
+		b.nodes = append(b.nodes, &ast.Text{Content: text})
+	}
+	return b
+}
+
+// Var appends a prompty.var tag for the data path name.
+func (b *TemplateBuilder) Var(name string) *TemplateBuilder {
+	return b.Tag(TagNameVar, map[string]string{AttrName: name}, nil)
+}
+
+// VarDefault appends a prompty.var tag with a default for missing values.
+func (b *TemplateBuilder) VarDefault(name, defaultValue string) *TemplateBuilder {
+	return b.Tag(TagNameVar, map[string]string{AttrName: name, AttrDefault: defaultValue}, nil)
+}
+
+// If appends a prompty.if tag rendering then when the expression cond is
+// true, and otherwise (if not nil) when it is false.
+func (b *TemplateBuilder) If(cond string, then, otherwise *TemplateBuilder) *TemplateBuilder {
+	node := &ast.Conditional{Branches: []*ast.Branch{{Condition: cond, Children: then.content()}}}
+	if otherwise != nil {
+		node.Branches = append(node.Branches, &ast.Branch{Else: true, Children: otherwise.content()})
+	}
+	b.nodes = append(b.nodes, node)
+	return b
+}
+
+// For appends a prompty.for tag rendering body once per element of the
+// collection at data path in, with the element available as item.
+func (b *TemplateBuilder) For(item, in string, body *TemplateBuilder) *TemplateBuilder {
+	b.nodes = append(b.nodes, &ast.For{Item: item, In: in, Children: body.content()})
+	return b
+}
+
+// Include appends a prompty.include tag for a registered template.
+func (b *TemplateBuilder) Include(template string) *TemplateBuilder {
+	return b.Tag(TagNameInclude, map[string]string{AttrTemplate: template}, nil)
+}
+
+// Block appends an overridable prompty.block with default content.
+func (b *TemplateBuilder) Block(name string, body *TemplateBuilder) *TemplateBuilder {
+	b.nodes = append(b.nodes, &ast.Block{Name: name, Children: body.content()})
+	return b
+}
+
+// Message appends a prompty.message tag with the given role.
+func (b *TemplateBuilder) Message(role string, body *TemplateBuilder) *TemplateBuilder {
+	return b.Tag(TagNameMessage, map[string]string{AttrRole: role}, body.orEmpty())
+}
+
+// System appends a system message.
+func (b *TemplateBuilder) System(body *TemplateBuilder) *TemplateBuilder {
+	return b.Message(RoleSystem, body)
+}
+
+// User appends a user message.
+func (b *TemplateBuilder) User(body *TemplateBuilder) *TemplateBuilder {
+	return b.Message(RoleUser, body)
+}
+
+// Assistant appends an assistant message.
+func (b *TemplateBuilder) Assistant(body *TemplateBuilder) *TemplateBuilder {
+	return b.Message(RoleAssistant, body)
+}
+
+// Tag appends any tag, e.g. of a custom resolver. A nil body appends a
+// self-closing tag, otherwise a block tag around the body.
+func (b *TemplateBuilder) Tag(name string, attrs map[string]string, body *TemplateBuilder) *TemplateBuilder {
+	node := &ast.Tag{Name: name, Attributes: make(map[string]string, len(attrs)), SelfClose: body == nil}
+	for key, value := range attrs {
+		node.Attributes[key] = value
+	}
+	node.Children = body.content()
+	b.nodes = append(b.nodes, node)
+	return b
+}
+
+// Append appends the content of another builder.
+func (b *TemplateBuilder) Append(other *TemplateBuilder) *TemplateBuilder {
+	b.nodes = append(b.nodes, other.content()...)
+	return b
+}
+
+// AST returns the built syntax tree (see the ast package).
+func (b *TemplateBuilder) AST() *ast.Root {
+	return &ast.Root{Children: b.content()}
+}
+
+// Source returns the template source with the default delimiters.
+func (b *TemplateBuilder) Source() string {
+	return PrintAST(b.AST())
+}
+
+// Build parses the built template with a default engine.
+func (b *TemplateBuilder) Build() (*Template, error) {
+	return b.BuildWith(MustNew())
+}
+
+// BuildWith parses the built template with the engine, using its
+// delimiters, resolvers and functions.
+func (b *TemplateBuilder) BuildWith(engine *Engine) (*Template, error) {
+	return engine.Parse(engine.PrintAST(b.AST()))
+}
+
+// content returns a copy of the builder's nodes (nil for a nil builder).
+func (b *TemplateBuilder) content() []ast.Node {
+	if b == nil {
+		return nil
+	}
+	return append([]ast.Node(nil), b.nodes...)
+}
+
+// orEmpty returns b, or an empty builder when b is nil, for tags that
+// always have a body.
+func (b *TemplateBuilder) orEmpty() *TemplateBuilder {
+	if b == nil {
+		return Builder()
+	}
+	return b
+}
@@ -0,0 +1,82 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateBuilder(t *testing.T) {
+	ctx := context.Background()
+
+	b := Builder().
+		Text("Hello ").VarDefault("user.name", "there").Text("!\n").
+		If("tier == 'gold'",
+			Builder().Text("Priority support."),
+			Builder().Text("Standard support.")).
+		Text("\n").
+		For("item", "orders", Builder().Text("- ").Var("item").Text("\n"))
+
+	assert.Equal(t, `Hello {~prompty.var name="user.name" default="there" /~}!
+{~prompty.if eval="tier == 'gold'"~}Priority support.{~prompty.else~}Standard support.{~/prompty.if~}
+{~prompty.for item="item" in="orders"~}- {~prompty.var name="item" /~}
+{~/prompty.for~}`, b.Source())
+
+	tmpl, err := b.Build()
+	require.NoError(t, err)
+	out, err := tmpl.Execute(ctx, map[string]any{"tier": "gold", "orders": []any{"A-1", "B-2"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello there!\nPriority support.\n- A-1\n- B-2\n", out)
+
+	out, err = tmpl.Execute(ctx, map[string]any{"user": map[string]any{"name": "Ada"}, "tier": "basic", "orders": []any{}})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello Ada!\nStandard support.\n", out)
+}
+
+func TestTemplateBuilder_Escaping(t *testing.T) {
+	ctx := context.Background()
+
+	// Text and attribute values holding delimiters and quotes stay literal
+	tmpl, err := Builder().
+		Text(`Literal {~prompty.var name="x" /~} and \{~`).
+		VarDefault("missing", `say "hi" it's ~}`).
+		Build()
+	require.NoError(t, err)
+	out, err := tmpl.Execute(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `Literal {~prompty.var name="x" /~} and \{~say "hi" it's ~}`, out)
+}
+
+func TestTemplateBuilder_Messages(t *testing.T) {
+	ctx := context.Background()
+
+	tmpl, err := Builder().
+		System(Builder().Text("You support ").Var("company").Text(" customers.")).
+		User(Builder().Var("question")).
+		Build()
+	require.NoError(t, err)
+
+	messages, err := tmpl.ExecuteAndExtractMessages(ctx, map[string]any{"company": "Acme", "question": "Where is my order?"})
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, RoleSystem, messages[0].Role)
+	assert.Equal(t, "You support Acme customers.", messages[0].Content)
+	assert.Equal(t, "Where is my order?", messages[1].Content)
+}
+
+func TestTemplateBuilder_BuildWith(t *testing.T) {
+	engine := MustNew(WithDelimiters("<<", ">>"))
+	tmpl, err := Builder().Text("Literal << and ").Var("x").BuildWith(engine)
+	require.NoError(t, err)
+	assert.Equal(t, `Literal \<< and <<prompty.var name="x" />>`, tmpl.Source())
+
+	out, err := tmpl.Execute(context.Background(), map[string]any{"x": 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Literal << and 1", out)
+
+	// Block and generic tags
+	src := Builder().Block("intro", nil).Tag("custom.tag", map[string]string{"a": "1"}, nil).Source()
+	assert.Equal(t, `{~prompty.block name="intro"~}{~/prompty.block~}{~custom.tag a="1" /~}`, src)
+}
@@ -0,0 +1,534 @@
+package prompty
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+	"gopkg.in/yaml.v3"
+)
+
+// Bundle layout
+const (
+	// BundleManifestFilename is the manifest at the root of a bundle archive.
+	BundleManifestFilename = "manifest.yaml"
+	// BundleFormatVersion is the manifest format written by BuildBundle.
+	BundleFormatVersion = "1"
+	// BundleMetadataKey is the StoredTemplate.Metadata key recording the
+	// bundle ("name@version") a template was installed from.
+	BundleMetadataKey = "bundle"
+
+	bundleDirResources = "resources/"
+	bundleDirSuffix    = "s/"
+	bundleRefSeparator = "@"
+)
+
+// BundleManifest describes the contents of a bundle: a zip archive with a
+// manifest.yaml, one document per prompt, skill or agent, and resources.
+type BundleManifest struct {
+	// FormatVersion is the manifest format (BundleFormatVersion).
+	FormatVersion string `yaml:"format_version" json:"format_version"`
+	// Name, Version and Description identify the bundle.
+	Name        string `yaml:"name,omitempty" json:"name,omitempty"`
+	Version     string `yaml:"version,omitempty" json:"version,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	// Documents lists the bundled documents, sorted by name.
+	Documents []BundleDocument `yaml:"documents" json:"documents"`
+	// Resources lists the bundled resource files, sorted, relative to the
+	// resources/ directory of the archive.
+	Resources []string `yaml:"resources,omitempty" json:"resources,omitempty"`
+}
+
+// BundleDocument is a prompt, skill or agent in a bundle.
+type BundleDocument struct {
+	// Name is the document name, used as the stored template name.
+	Name string `yaml:"name" json:"name"`
+	// Type is the document type.
+	Type DocumentType `yaml:"type" json:"type"`
+	// Version is the document's semantic version from Metadata["version"].
+	Version string `yaml:"version,omitempty" json:"version,omitempty"`
+	// Path is the document file in the archive, e.g. "skills/summarizer.md".
+	Path string `yaml:"path" json:"path"`
+	// ContentHash is the content hash of the document (see Prompt.Hash),
+	// verified when the bundle is read.
+	ContentHash string `yaml:"content_hash,omitempty" json:"content_hash,omitempty"`
+	// Dependencies lists the documents this one references.
+	Dependencies []BundleDependency `yaml:"dependencies,omitempty" json:"dependencies,omitempty"`
+}
+
+// BundleDependency is a reference from one document to another: a skill
+// reference, or an include, ref or extends tag in the body.
+type BundleDependency struct {
+	// Name is the referenced document name.
+	Name string `yaml:"name" json:"name"`
+	// Version is the version constraint ("" for any version).
+	Version string `yaml:"version,omitempty" json:"version,omitempty"`
+}
+
+// BundleOptions configures BuildBundle.
+type BundleOptions struct {
+	// Name, Version and Description identify the bundle in its manifest.
+	Name        string
+	Version     string
+	Description string
+	// Resources maps resource paths to their content.
+	Resources map[string][]byte
+	// External lists document names that may be referenced without being
+	// bundled; InstallBundle requires them to be installed already.
+	External []string
+}
+
+// BundleInstallOptions configures InstallBundle.
+type BundleInstallOptions struct {
+	// Overwrite installs documents that conflict with the installed version
+	// (a newer version, or the same version with different content).
+	Overwrite bool
+	// CreatedBy and TenantID are set on the stored templates.
+	CreatedBy string
+	TenantID  string
+}
+
+// BundleInstallResult reports what InstallBundle did.
+type BundleInstallResult struct {
+	// Manifest is the manifest of the installed bundle.
+	Manifest *BundleManifest
+	// Installed lists the documents saved as new template versions.
+	Installed []string
+	// Unchanged lists the documents already installed with the same content.
+	Unchanged []string
+	// Resources maps the bundled resource paths to their content.
+	Resources map[string][]byte
+}
+
+// bundleFile is a file written to a bundle archive.
+type bundleFile struct {
+	name    string
+	content []byte
+}
+
+// bundleEntry is a document read from a bundle archive.
+type bundleEntry struct {
+	doc    *BundleDocument
+	source []byte
+	prompt *Prompt
+}
+
+// BuildBundle packages prompts, skills and agents with their resources into
+// a bundle archive. The dependencies of each document are recorded in the
+// manifest and must be satisfied within the bundle, by a document of that
+// name whose version matches the constraint, unless they are listed in
+// BundleOptions.External.
+func BuildBundle(prompts []*Prompt, opts *BundleOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &BundleOptions{}
+	}
+
+	manifest := &BundleManifest{
+		FormatVersion: BundleFormatVersion,
+		Name:          opts.Name,
+		Version:       opts.Version,
+		Description:   opts.Description,
+		Documents:     make([]BundleDocument, 0, len(prompts)),
+	}
+	sources := make(map[string][]byte, len(prompts))
+	for _, p := range prompts {
+		if p == nil || p.Name == "" {
+			return nil, NewBundleError(ErrMsgBundleUnnamed, "", nil)
+		}
+		if _, exists := sources[p.Name]; exists {
+			return nil, NewBundleError(ErrMsgBundleDuplicate, p.Name, nil)
+		}
+		deps, err := promptDependencies(p)
+		if err != nil {
+			return nil, NewBundleError(ErrMsgBundleDocumentInvalid, p.Name, err)
+		}
+		source, err := p.ExportFull()
+		if err != nil {
+			return nil, NewBundleError(ErrMsgBundleDocumentInvalid, p.Name, err)
+		}
+		sources[p.Name] = source
+		docType := p.EffectiveType()
+		manifest.Documents = append(manifest.Documents, BundleDocument{
+			Name:         p.Name,
+			Type:         docType,
+			Version:      PromptVersion(p),
+			Path:         string(docType) + bundleDirSuffix + p.Name + FileExtensionMarkdown,
+			ContentHash:  p.Hash(),
+			Dependencies: deps,
+		})
+	}
+	sort.Slice(manifest.Documents, func(i, j int) bool {
+		return manifest.Documents[i].Name < manifest.Documents[j].Name
+	})
+
+	external := make(map[string]bool, len(opts.External))
+	for _, name := range opts.External {
+		external[name] = true
+	}
+	for i := range manifest.Documents {
+		doc := &manifest.Documents[i]
+		for _, dep := range doc.Dependencies {
+			if external[dep.Name] {
+				continue
+			}
+			ok, err := manifest.satisfies(dep)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, NewBundleDependencyError(doc.Name, dep)
+			}
+		}
+	}
+
+	for name := range opts.Resources {
+		if !validBundleResourcePath(name) {
+			return nil, NewBundleError(ErrMsgBundleResourcePath, name, nil)
+		}
+		manifest.Resources = append(manifest.Resources, name)
+	}
+	sort.Strings(manifest.Resources)
+
+	manifestBytes, err := yaml.Marshal(manifest)
+	if err != nil {
+		return nil, NewBundleError(ErrMsgExportFailed, opts.Name, err)
+	}
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	files := []bundleFile{{BundleManifestFilename, manifestBytes}}
+	for _, doc := range manifest.Documents {
+		files = append(files, bundleFile{doc.Path, sources[doc.Name]})
+	}
+	for _, name := range manifest.Resources {
+		files = append(files, bundleFile{bundleDirResources + name, opts.Resources[name]})
+	}
+	for _, file := range files {
+		f, err := w.Create(file.name)
+		if err != nil {
+			return nil, NewCompilationError(ErrMsgExportZipFailed, err)
+		}
+		if _, err := f.Write(file.content); err != nil {
+			return nil, NewCompilationError(ErrMsgExportZipFailed, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, NewCompilationError(ErrMsgExportZipFailed, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ReadBundleManifest returns the manifest of a bundle archive without
+// installing it.
+func ReadBundleManifest(data []byte) (*BundleManifest, error) {
+	manifest, _, _, err := readBundle(data)
+	return manifest, err
+}
+
+// InstallBundle saves the documents of a bundle archive to storage, each as
+// a new version of the template with its name. Nothing is saved unless the
+// whole bundle can be installed: every dependency must be bundled or
+// already installed in a satisfying version, and no document may conflict
+// with its installed version, i.e. be older, or have the same version but
+// different content (see BundleInstallOptions.Overwrite). Documents already
+// installed with the same content are left unchanged.
+func InstallBundle(ctx context.Context, storage TemplateStorage, data []byte, opts *BundleInstallOptions) (*BundleInstallResult, error) {
+	if opts == nil {
+		opts = &BundleInstallOptions{}
+	}
+	manifest, entries, resources, err := readBundle(data)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := NewStorageDocumentResolver(storage)
+	for _, entry := range entries {
+		for _, dep := range entry.doc.Dependencies {
+			ok, err := manifest.satisfies(dep)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				continue
+			}
+			constraint := dep.Version
+			if constraint == "" {
+				constraint = RefVersionLatest
+			}
+			if _, _, err := resolveSkillVersion(ctx, resolver, dep.Name, constraint); err != nil {
+				return nil, NewBundleDependencyError(entry.doc.Name, dep)
+			}
+		}
+	}
+
+	result := &BundleInstallResult{Manifest: manifest, Resources: resources}
+	pending := make([]*bundleEntry, 0, len(entries))
+	for _, entry := range entries {
+		unchanged, err := bundleInstallCheck(ctx, storage, entry, opts.Overwrite)
+		if err != nil {
+			return nil, err
+		}
+		if unchanged {
+			result.Unchanged = append(result.Unchanged, entry.doc.Name)
+			continue
+		}
+		pending = append(pending, entry)
+	}
+
+	bundleRef := manifest.Name
+	if manifest.Version != "" {
+		bundleRef += bundleRefSeparator + manifest.Version
+	}
+	for _, entry := range pending {
+		tmpl := &StoredTemplate{
+			Name:         entry.doc.Name,
+			Source:       string(entry.source),
+			PromptConfig: entry.prompt,
+			CreatedBy:    opts.CreatedBy,
+			TenantID:     opts.TenantID,
+		}
+		if bundleRef != "" {
+			tmpl.Metadata = map[string]string{BundleMetadataKey: bundleRef}
+		}
+		if err := storage.Save(ctx, tmpl); err != nil {
+			return result, err
+		}
+		result.Installed = append(result.Installed, entry.doc.Name)
+	}
+
+	return result, nil
+}
+
+// bundleInstallCheck reports whether a document is installed with the same
+// content, or returns a conflict error.
+func bundleInstallCheck(ctx context.Context, storage TemplateStorage, entry *bundleEntry, overwrite bool) (bool, error) {
+	exists, err := storage.Exists(ctx, entry.doc.Name)
+	if err != nil || !exists {
+		return false, err
+	}
+	installed, err := storage.Get(ctx, entry.doc.Name)
+	if err != nil {
+		return false, err
+	}
+	if installed.Source == string(entry.source) {
+		return true, nil
+	}
+	if overwrite {
+		return false, nil
+	}
+
+	installedPrompt := installed.PromptConfig
+	if installedPrompt == nil {
+		installedPrompt, _ = Parse([]byte(installed.Source))
+	}
+	installedVersion := PromptVersion(installedPrompt)
+	if installedVersion == "" || entry.doc.Version == "" {
+		return false, nil
+	}
+	current, err := ParseSemVersion(installedVersion)
+	if err != nil {
+		return false, nil
+	}
+	bundled, err := ParseSemVersion(entry.doc.Version)
+	if err != nil {
+		return false, nil
+	}
+	if bundled.Compare(current) <= 0 {
+		return false, NewBundleError(ErrMsgBundleConflict, entry.doc.Name, nil)
+	}
+	return false, nil
+}
+
+// readBundle reads and checks a bundle archive: the manifest format, and
+// that every listed file is present and every document parses.
+func readBundle(data []byte) (*BundleManifest, []*bundleEntry, map[string][]byte, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, nil, NewBundleError(ErrMsgBundleInvalid, "", err)
+	}
+	files := make(map[string]*zip.File, len(reader.File))
+	for _, f := range reader.File {
+		files[f.Name] = f
+	}
+
+	manifestBytes, err := readBundleFile(files, BundleManifestFilename)
+	if err != nil {
+		return nil, nil, nil, NewBundleError(ErrMsgBundleNoManifest, BundleManifestFilename, err)
+	}
+	manifest := &BundleManifest{}
+	if err := yaml.Unmarshal(manifestBytes, manifest); err != nil {
+		return nil, nil, nil, NewBundleError(ErrMsgBundleInvalid, BundleManifestFilename, err)
+	}
+	if manifest.FormatVersion != BundleFormatVersion {
+		return nil, nil, nil, NewBundleError(ErrMsgBundleFormatVersion, manifest.FormatVersion, nil)
+	}
+
+	entries := make([]*bundleEntry, 0, len(manifest.Documents))
+	seen := make(map[string]bool, len(manifest.Documents))
+	for i := range manifest.Documents {
+		doc := &manifest.Documents[i]
+		if doc.Name == "" {
+			return nil, nil, nil, NewBundleError(ErrMsgBundleUnnamed, doc.Path, nil)
+		}
+		if seen[doc.Name] {
+			return nil, nil, nil, NewBundleError(ErrMsgBundleDuplicate, doc.Name, nil)
+		}
+		seen[doc.Name] = true
+		source, err := readBundleFile(files, doc.Path)
+		if err != nil {
+			return nil, nil, nil, NewBundleError(ErrMsgBundleMissingFile, doc.Path, err)
+		}
+		prompt, err := Parse(source)
+		if err != nil {
+			return nil, nil, nil, NewBundleError(ErrMsgBundleDocumentInvalid, doc.Name, err)
+		}
+		if prompt.Name != doc.Name {
+			return nil, nil, nil, NewBundleError(ErrMsgBundleDocumentInvalid, doc.Name, nil)
+		}
+		if doc.ContentHash != "" && prompt.Hash() != doc.ContentHash {
+			return nil, nil, nil, NewBundleError(ErrMsgBundleHashMismatch, doc.Name, nil)
+		}
+		entries = append(entries, &bundleEntry{doc: doc, source: source, prompt: prompt})
+	}
+
+	resources := make(map[string][]byte, len(manifest.Resources))
+	for _, name := range manifest.Resources {
+		if !validBundleResourcePath(name) {
+			return nil, nil, nil, NewBundleError(ErrMsgBundleResourcePath, name, nil)
+		}
+		content, err := readBundleFile(files, bundleDirResources+name)
+		if err != nil {
+			return nil, nil, nil, NewBundleError(ErrMsgBundleMissingFile, name, err)
+		}
+		resources[name] = content
+	}
+
+	return manifest, entries, resources, nil
+}
+
+// readBundleFile reads one file of a bundle archive.
+func readBundleFile(files map[string]*zip.File, name string) ([]byte, error) {
+	f, ok := files[name]
+	if !ok {
+		return nil, NewStorageTemplateNotFoundError(name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// satisfies reports whether a bundled document satisfies a dependency.
+// Documents without a version satisfy any constraint, as in skill
+// resolution.
+func (m *BundleManifest) satisfies(dep BundleDependency) (bool, error) {
+	for _, doc := range m.Documents {
+		if doc.Name != dep.Name {
+			continue
+		}
+		if dep.Version == "" || doc.Version == "" {
+			return true, nil
+		}
+		c, err := ParseVersionConstraint(dep.Version)
+		if err != nil {
+			return false, err
+		}
+		v, err := ParseSemVersion(doc.Version)
+		if err != nil {
+			return false, nil
+		}
+		return c.Check(v), nil
+	}
+	return false, nil
+}
+
+// validBundleResourcePath reports whether a resource path stays inside the
+// resources directory.
+func validBundleResourcePath(name string) bool {
+	return name != "" && !path.IsAbs(name) && path.Clean(name) == name &&
+		name != ".." && !strings.HasPrefix(name, "../")
+}
+
+// promptDependencies returns the documents a prompt references: its skills
+// and the templates named by include, ref and extends tags in its body.
+// Each name is listed once, with the first constraint given for it.
+func promptDependencies(p *Prompt) ([]BundleDependency, error) {
+	var deps []BundleDependency
+	seen := make(map[string]bool)
+	add := func(name, version string) {
+		if name == "" || name == p.Name || seen[name] {
+			return
+		}
+		if version == RefVersionLatest {
+			version = ""
+		}
+		seen[name] = true
+		deps = append(deps, BundleDependency{Name: name, Version: version})
+	}
+
+	for i := range p.Skills {
+		if !p.Skills[i].IsInline() {
+			add(p.Skills[i].GetSlug(), p.Skills[i].GetVersion())
+		}
+	}
+
+	tokens, err := internal.NewLexer(p.Body, nil).Tokenize()
+	if err != nil {
+		return nil, err
+	}
+	root, err := internal.NewParserWithSource(tokens, p.Body, nil).Parse()
+	if err != nil {
+		return nil, err
+	}
+	walkTemplateReferences(root.Children, add)
+
+	return deps, nil
+}
+
+// walkTemplateReferences calls add for every statically named template
+// reference in the nodes.
+func walkTemplateReferences(nodes []internal.Node, add func(name, version string)) {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *internal.TagNode:
+			switch n.Name {
+			case TagNameInclude, TagNameExtends:
+				name, _ := n.Attributes.Get(AttrTemplate)
+				add(name, "")
+			case TagNameRef:
+				ref, _ := n.Attributes.Get(AttrSlug)
+				slug, version := splitSkillRef(ref)
+				if v, ok := n.Attributes.Get(AttrVersion); ok && v != "" {
+					version = v
+				}
+				add(slug, version)
+			}
+			if !n.IsRaw() {
+				walkTemplateReferences(n.Children, add)
+			}
+		case *internal.BlockNode:
+			walkTemplateReferences(n.Children, add)
+		case *internal.ConditionalNode:
+			for _, branch := range n.Branches {
+				walkTemplateReferences(branch.Children, add)
+			}
+		case *internal.ForNode:
+			walkTemplateReferences(n.Children, add)
+		case *internal.SwitchNode:
+			for _, c := range n.Cases {
+				walkTemplateReferences(c.Children, add)
+			}
+			if n.Default != nil {
+				walkTemplateReferences(n.Default.Children, add)
+			}
+		}
+	}
+}
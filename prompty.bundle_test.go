@@ -0,0 +1,186 @@
+package prompty
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBundlePrompts() []*Prompt {
+	return []*Prompt{
+		{
+			Name:        "support-agent",
+			Description: "Answers support questions",
+			Type:        DocumentTypeAgent,
+			Metadata:    map[string]any{PromptMetadataVersion: "1.0.0"},
+			Skills:      []SkillRef{{Slug: "summarizer@^2"}, {Inline: &InlineSkill{Slug: "inline", Body: "x"}}},
+			Body:        `{~prompty.include template="signature" /~}{~prompty.if eval="x"~}{~prompty.ref slug="style-guide" /~}{~/prompty.if~}`,
+		},
+		{
+			Name:        "summarizer",
+			Description: "Summarizes text",
+			Metadata:    map[string]any{PromptMetadataVersion: "2.3.0"},
+			Body:        "Summarize {~prompty.var name=\"text\" /~}",
+		},
+		{Name: "signature", Description: "Signature", Type: DocumentTypePrompt, Body: "-- Support"},
+	}
+}
+
+func TestBuildBundle(t *testing.T) {
+	prompts := testBundlePrompts()
+	data, err := BuildBundle(prompts, &BundleOptions{
+		Name:      "support",
+		Version:   "1.0.0",
+		Resources: map[string][]byte{"docs/faq.md": []byte("FAQ")},
+		External:  []string{"style-guide"},
+	})
+	require.NoError(t, err)
+
+	manifest, err := ReadBundleManifest(data)
+	require.NoError(t, err)
+	assert.Equal(t, BundleFormatVersion, manifest.FormatVersion)
+	assert.Equal(t, []string{"docs/faq.md"}, manifest.Resources)
+	require.Len(t, manifest.Documents, 3)
+	agent := manifest.Documents[2]
+	assert.Equal(t, BundleDocument{
+		Name:        "support-agent",
+		Type:        DocumentTypeAgent,
+		Version:     "1.0.0",
+		Path:        "agents/support-agent.md",
+		ContentHash: prompts[0].Hash(),
+		Dependencies: []BundleDependency{
+			{Name: "summarizer", Version: "^2"},
+			{Name: "signature"},
+			{Name: "style-guide"},
+		},
+	}, agent)
+	assert.Equal(t, "skills/summarizer.md", manifest.Documents[1].Path)
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	names := make([]string, 0, len(reader.File))
+	for _, f := range reader.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{BundleManifestFilename, "prompts/signature.md", "skills/summarizer.md", "agents/support-agent.md", "resources/docs/faq.md"}, names)
+}
+
+func TestBuildBundle_Errors(t *testing.T) {
+	prompts := testBundlePrompts()
+	_, err := BuildBundle(prompts, nil)
+	require.Error(t, err, "style-guide is neither bundled nor external")
+	assert.Contains(t, err.Error(), ErrMsgBundleUnsatisfied)
+
+	prompts[0].Skills[0].Slug = "summarizer@^3"
+	_, err = BuildBundle(prompts, &BundleOptions{External: []string{"style-guide"}})
+	require.Error(t, err, "bundled version does not satisfy the constraint")
+	assert.Contains(t, err.Error(), ErrMsgBundleUnsatisfied)
+
+	_, err = BuildBundle([]*Prompt{prompts[2], prompts[2]}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgBundleDuplicate)
+
+	_, err = BuildBundle([]*Prompt{prompts[2]}, &BundleOptions{Resources: map[string][]byte{"../escape": nil}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgBundleResourcePath)
+}
+
+func TestInstallBundle(t *testing.T) {
+	ctx := context.Background()
+	data, err := BuildBundle(testBundlePrompts(), &BundleOptions{Name: "support", Version: "1.0.0", External: []string{"style-guide"}})
+	require.NoError(t, err)
+
+	storage := NewMemoryStorage()
+	_, err = InstallBundle(ctx, storage, data, nil)
+	require.Error(t, err, "external dependency not installed")
+	assert.Contains(t, err.Error(), ErrMsgBundleUnsatisfied)
+	exists, err := storage.Exists(ctx, "summarizer")
+	require.NoError(t, err)
+	assert.False(t, exists, "nothing is saved when the bundle cannot be installed")
+
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "style-guide", Source: "Be concise."}))
+	result, err := InstallBundle(ctx, storage, data, &BundleInstallOptions{CreatedBy: "ci"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"signature", "summarizer", "support-agent"}, result.Installed)
+
+	stored, err := storage.Get(ctx, "summarizer")
+	require.NoError(t, err)
+	assert.Equal(t, "support@1.0.0", stored.Metadata[BundleMetadataKey])
+	assert.Equal(t, "ci", stored.CreatedBy)
+	require.NotNil(t, stored.PromptConfig)
+	assert.Equal(t, "2.3.0", PromptVersion(stored.PromptConfig))
+
+	result, err = InstallBundle(ctx, storage, data, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.Installed)
+	assert.Equal(t, []string{"signature", "summarizer", "support-agent"}, result.Unchanged)
+}
+
+func TestInstallBundle_Conflict(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	installed := &Prompt{Name: "summarizer", Description: "Newer", Metadata: map[string]any{PromptMetadataVersion: "3.0.0"}, Body: "v3"}
+	source, err := installed.ExportFull()
+	require.NoError(t, err)
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "summarizer", Source: string(source)}))
+
+	data, err := BuildBundle(testBundlePrompts()[1:], nil)
+	require.NoError(t, err)
+
+	_, err = InstallBundle(ctx, storage, data, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgBundleConflict)
+
+	result, err := InstallBundle(ctx, storage, data, &BundleInstallOptions{Overwrite: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"signature", "summarizer"}, result.Installed)
+	versions, err := storage.ListVersions(ctx, "summarizer")
+	require.NoError(t, err)
+	assert.Len(t, versions, 2)
+}
+
+func TestReadBundleManifest_Invalid(t *testing.T) {
+	_, err := ReadBundleManifest([]byte("not a zip"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgBundleInvalid)
+
+	archive, err := ExportSkillDirectory(&Prompt{Name: "x", Description: "x"}, nil)
+	require.NoError(t, err)
+	_, err = ReadBundleManifest(archive)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgBundleNoManifest)
+}
+
+func TestReadBundleManifest_HashMismatch(t *testing.T) {
+	data, err := BuildBundle(testBundlePrompts()[2:], nil)
+	require.NoError(t, err)
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, f := range reader.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		if f.Name == "prompts/signature.md" {
+			content = bytes.Replace(content, []byte("-- Support"), []byte("-- Sales"), 1)
+		}
+		fw, err := w.Create(f.Name)
+		require.NoError(t, err)
+		_, err = fw.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	_, err = ReadBundleManifest(buf.Bytes())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgBundleHashMismatch)
+}
@@ -0,0 +1,231 @@
+package prompty
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// ASTCache caches parsed template bodies keyed by a hash of the source and
+// the delimiters, so identical sources are tokenized and parsed once per
+// process even across Engine and StorageEngine instances.
+// Attach it to an engine with WithASTCache.
+//
+// Only the template body is cached: frontmatter is re-parsed on every
+// Parse because it may reference environment variables. Cached ASTs are
+// shared between templates and never modified after parsing.
+type ASTCache struct {
+	mu      sync.Mutex
+	entries *boundedCache[astCacheEntry]
+	config  ASTCacheConfig
+	stats   ASTCacheStats
+}
+
+// astCacheEntry holds a cached AST with metadata.
+type astCacheEntry struct {
+	ast       *internal.RootNode
+	expiresAt time.Time
+}
+
+// ASTCacheConfig configures the AST cache.
+type ASTCacheConfig struct {
+	// MaxEntries is the maximum number of cached ASTs; an entry chosen by
+	// Policy is evicted beyond it. Default: 1000.
+	MaxEntries int
+
+	// MaxBytes bounds the estimated memory held by the cached ASTs,
+	// including the template bodies they reference. ASTs larger than
+	// MaxBytes are not cached. Default: 0 (no byte limit).
+	MaxBytes int64
+
+	// Policy selects the AST evicted when a limit is reached.
+	// Default: CachePolicyLRU.
+	Policy CachePolicy
+
+	// TTL is how long an AST stays cached after parsing. Sources are
+	// content-addressed, so entries never go stale; the TTL only releases
+	// ASTs that are no longer used. Default: 1 hour.
+	TTL time.Duration
+}
+
+// ASTCacheStats tracks AST cache performance metrics.
+type ASTCacheStats struct {
+	Hits        int64
+	Misses      int64
+	Evictions   int64 // Entries removed to stay within MaxEntries or MaxBytes
+	Expirations int64
+	Purges      int64 // Entries removed by Purge or Clear
+	EntryCount  int
+	Bytes       int64 // Estimated memory held by the cached ASTs
+}
+
+// DefaultASTCacheConfig returns sensible defaults for AST caching.
+func DefaultASTCacheConfig() ASTCacheConfig {
+	return ASTCacheConfig{
+		MaxEntries: DefaultASTCacheMaxEntries,
+		Policy:     CachePolicyLRU,
+		TTL:        DefaultASTCacheTTL,
+	}
+}
+
+// NewASTCache creates a new AST cache. Zero config values use the defaults.
+func NewASTCache(config ASTCacheConfig) *ASTCache {
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = DefaultASTCacheMaxEntries
+	}
+	if config.MaxBytes < 0 {
+		config.MaxBytes = 0
+	}
+	if config.Policy == "" {
+		config.Policy = CachePolicyLRU
+	}
+	if config.TTL <= 0 {
+		config.TTL = DefaultASTCacheTTL
+	}
+
+	c := &ASTCache{
+		entries: newBoundedCache[astCacheEntry](config.MaxEntries, config.MaxBytes, config.Policy),
+		config:  config,
+	}
+	c.entries.onEvict = func(string, astCacheEntry) {
+		c.stats.Evictions++
+	}
+	return c
+}
+
+var (
+	sharedASTCache     *ASTCache
+	sharedASTCacheOnce sync.Once
+)
+
+// SharedASTCache returns the process-wide AST cache with default
+// configuration, for engines that should share parse results:
+//
+//	engine := prompty.MustNew(prompty.WithASTCache(prompty.SharedASTCache()))
+func SharedASTCache() *ASTCache {
+	sharedASTCacheOnce.Do(func() {
+		sharedASTCache = NewASTCache(DefaultASTCacheConfig())
+	})
+	return sharedASTCache
+}
+
+// get returns the cached AST for key if present and not expired.
+func (c *ASTCache) get(key string) (*internal.RootNode, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries.get(key)
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+
+	if time.Now().After(entry.value.expiresAt) {
+		c.entries.remove(key)
+		c.stats.Expirations++
+		c.stats.Misses++
+		return nil, false
+	}
+
+	c.stats.Hits++
+	return entry.value.ast, true
+}
+
+// set stores the AST of body, evicting entries to stay within the limits.
+func (c *ASTCache) set(key, body string, ast *internal.RootNode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(c.config.TTL)
+	if entry, ok := c.entries.get(key); ok {
+		// A concurrent parse of the same source; keep the first AST
+		entry.value.expiresAt = expiresAt
+		return
+	}
+
+	c.entries.add(key, astCacheEntry{ast: ast, expiresAt: expiresAt}, astSize(body, ast))
+}
+
+// astSize estimates the memory held by the AST of body. AST text and
+// attribute values share memory with the body, which stays alive as long
+// as the AST is cached.
+func astSize(body string, ast *internal.RootNode) int64 {
+	return int64(len(body)) + int64(internal.CountNodes(ast.Children))*astNodeBytes + cacheEntryOverhead
+}
+
+// Len returns the number of cached ASTs.
+func (c *ASTCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.len()
+}
+
+// Clear removes all entries from the cache. Statistics are kept.
+func (c *ASTCache) Clear() {
+	c.Purge()
+}
+
+// Purge removes all entries from the cache, releasing their memory, and
+// returns how many were removed. Statistics other than the occupancy are
+// kept.
+func (c *ASTCache) Purge() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := c.entries.purge()
+	c.stats.Purges += int64(removed)
+	return removed
+}
+
+// Cleanup removes expired entries and returns how many were removed.
+// Expired entries are also dropped lazily on lookup.
+func (c *ASTCache) Cleanup() int {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := c.entries.removeFunc(func(entry *boundedEntry[astCacheEntry]) bool {
+		return now.After(entry.value.expiresAt)
+	})
+	c.stats.Expirations += int64(removed)
+	return removed
+}
+
+// Stats returns current cache statistics.
+func (c *ASTCache) Stats() ASTCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.EntryCount = c.entries.len()
+	stats.Bytes = c.entries.bytes
+	return stats
+}
+
+// HitRate returns the cache hit rate (0.0 to 1.0).
+func (c *ASTCache) HitRate() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	total := c.stats.Hits + c.stats.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(c.stats.Hits) / float64(total)
+}
+
+// astCacheKey hashes a template body together with the delimiters it is
+// tokenized with, since the same text parses differently under others.
+func astCacheKey(config internal.LexerConfig, body string) string {
+	h := sha256.New()
+	h.Write([]byte(config.OpenDelim))
+	h.Write([]byte{0})
+	h.Write([]byte(config.CloseDelim))
+	h.Write([]byte{0})
+	h.Write([]byte(body))
+	return hex.EncodeToString(h.Sum(nil))
+}
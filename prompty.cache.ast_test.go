@@ -0,0 +1,249 @@
+package prompty
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+func TestNewASTCache_Defaults(t *testing.T) {
+	cache := NewASTCache(ASTCacheConfig{})
+
+	assert.Equal(t, DefaultASTCacheMaxEntries, cache.config.MaxEntries)
+	assert.Equal(t, DefaultASTCacheTTL, cache.config.TTL)
+	assert.Equal(t, DefaultASTCacheConfig(), cache.config)
+}
+
+func TestSharedASTCache(t *testing.T) {
+	assert.Same(t, SharedASTCache(), SharedASTCache())
+}
+
+func TestASTCache_SharedAcrossEngines(t *testing.T) {
+	cache := NewASTCache(DefaultASTCacheConfig())
+	source := `Hello, {~prompty.var name="user" /~}!`
+
+	e1 := MustNew(WithASTCache(cache))
+	e2 := MustNew(WithASTCache(cache))
+
+	t1, err := e1.Parse(source)
+	require.NoError(t, err)
+	t2, err := e2.Parse(source)
+	require.NoError(t, err)
+
+	assert.Same(t, t1.ast, t2.ast)
+	stats := cache.Stats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, 1, stats.EntryCount)
+	assert.InDelta(t, 0.5, cache.HitRate(), 1e-9)
+
+	// Ad hoc Execute reuses the cached AST too
+	out, err := e2.Execute(context.Background(), source, map[string]any{"user": "Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello, Ada!", out)
+	assert.Equal(t, int64(2), cache.Stats().Hits)
+}
+
+func TestASTCache_KeyIncludesDelimiters(t *testing.T) {
+	cache := NewASTCache(DefaultASTCacheConfig())
+	source := `[[~prompty.var name="x" /~]] {~prompty.var name="x" /~}`
+	data := map[string]any{"x": "1"}
+
+	out, err := MustNew(WithASTCache(cache)).Execute(context.Background(), source, data)
+	require.NoError(t, err)
+	assert.Equal(t, `[[~prompty.var name="x" /~]] 1`, out)
+
+	out, err = MustNew(WithASTCache(cache), WithDelimiters("[[~", "~]]")).Execute(context.Background(), source, data)
+	require.NoError(t, err)
+	assert.Equal(t, `1 {~prompty.var name="x" /~}`, out)
+
+	assert.Equal(t, 2, cache.Len())
+	assert.Equal(t, int64(0), cache.Stats().Hits)
+}
+
+func TestASTCache_FrontmatterNotCached(t *testing.T) {
+	t.Setenv("AST_CACHE_TEST_MODEL", "model-a")
+	cache := NewASTCache(DefaultASTCacheConfig())
+	engine := MustNew(WithASTCache(cache))
+	source := "---\nname: cached\ndescription: AST cache test\nexecution:\n  model: '{~prompty.env name=\"AST_CACHE_TEST_MODEL\" /~}'\n---\nBody"
+
+	tmpl, err := engine.Parse(source)
+	require.NoError(t, err)
+	assert.Equal(t, "model-a", tmpl.Prompt().Execution.Model)
+
+	t.Setenv("AST_CACHE_TEST_MODEL", "model-b")
+	tmpl, err = engine.Parse(source)
+	require.NoError(t, err)
+	assert.Equal(t, "model-b", tmpl.Prompt().Execution.Model)
+	// Hits for the body and for the frontmatter's env tag template, which
+	// is only parsed once but resolved on every Parse
+	assert.Equal(t, int64(2), cache.Stats().Hits)
+}
+
+func TestASTCache_ParseErrorsNotCached(t *testing.T) {
+	cache := NewASTCache(DefaultASTCacheConfig())
+	engine := MustNew(WithASTCache(cache))
+
+	_, err := engine.Parse(`{~prompty.if eval="x"~}unclosed`)
+	require.Error(t, err)
+	_, err = engine.Parse(`{~prompty.if eval="x"~}unclosed`)
+	require.Error(t, err)
+
+	assert.Equal(t, 0, cache.Len())
+	assert.Equal(t, int64(2), cache.Stats().Misses)
+}
+
+func TestASTCache_LRUEviction(t *testing.T) {
+	cache := NewASTCache(ASTCacheConfig{MaxEntries: 2})
+	ast := &internal.RootNode{}
+
+	cache.set("a", "", ast)
+	cache.set("b", "", ast)
+	_, ok := cache.get("a") // a becomes most recently used
+	require.True(t, ok)
+	cache.set("c", "", ast)
+
+	_, ok = cache.get("b")
+	assert.False(t, ok)
+	_, ok = cache.get("a")
+	assert.True(t, ok)
+	_, ok = cache.get("c")
+	assert.True(t, ok)
+
+	stats := cache.Stats()
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.Equal(t, 2, stats.EntryCount)
+}
+
+func TestASTCache_LFUEviction(t *testing.T) {
+	cache := NewASTCache(ASTCacheConfig{MaxEntries: 2, Policy: CachePolicyLFU})
+	ast := &internal.RootNode{}
+
+	cache.set("a", "", ast)
+	cache.set("b", "", ast)
+	for i := 0; i < 3; i++ {
+		_, ok := cache.get("a")
+		require.True(t, ok)
+	}
+	_, ok := cache.get("b")
+	require.True(t, ok)
+
+	// b is the most recently but least frequently used entry
+	cache.set("c", "", ast)
+	_, ok = cache.get("b")
+	assert.False(t, ok)
+	_, ok = cache.get("a")
+	assert.True(t, ok)
+}
+
+func TestASTCache_MaxBytes(t *testing.T) {
+	body := strings.Repeat("x", 1000)
+	ast := &internal.RootNode{Children: []internal.Node{internal.NewTextNode(body, internal.Position{})}}
+	size := astSize(body, ast)
+
+	cache := NewASTCache(ASTCacheConfig{MaxBytes: 2*size + size/2})
+	cache.set("a", body, ast)
+	cache.set("b", body, ast)
+	stats := cache.Stats()
+	assert.Equal(t, 2*size, stats.Bytes)
+	assert.Equal(t, int64(0), stats.Evictions)
+
+	cache.set("c", body, ast)
+	stats = cache.Stats()
+	assert.Equal(t, 2, stats.EntryCount)
+	assert.Equal(t, 2*size, stats.Bytes)
+	assert.Equal(t, int64(1), stats.Evictions)
+	_, ok := cache.get("a")
+	assert.False(t, ok)
+
+	// An AST larger than the limit is not cached
+	huge := strings.Repeat("y", int(3*size))
+	cache.set("d", huge, &internal.RootNode{})
+	_, ok = cache.get("d")
+	assert.False(t, ok)
+	assert.Equal(t, 2, cache.Len())
+
+	assert.Equal(t, 2, cache.Purge())
+	stats = cache.Stats()
+	assert.Equal(t, int64(2), stats.Purges)
+	assert.Equal(t, int64(0), stats.Bytes)
+	assert.Equal(t, 0, stats.EntryCount)
+}
+
+func TestASTCache_TTL(t *testing.T) {
+	cache := NewASTCache(ASTCacheConfig{TTL: 20 * time.Millisecond})
+	ast := &internal.RootNode{}
+
+	cache.set("a", "", ast)
+	cache.set("b", "", ast)
+	time.Sleep(30 * time.Millisecond)
+	cache.set("c", "", ast)
+
+	_, ok := cache.get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, cache.Cleanup())
+	assert.Equal(t, 1, cache.Len())
+	assert.Equal(t, int64(2), cache.Stats().Expirations)
+
+	cache.Clear()
+	assert.Equal(t, 0, cache.Len())
+	assert.Equal(t, 0, cache.Stats().EntryCount)
+}
+
+func TestASTCache_SharedChildWithInheritance(t *testing.T) {
+	// A child with a nested parent call is shared by engines whose base
+	// templates differ; resolving inheritance must not bake one engine's
+	// parent content into the shared AST.
+	cache := NewASTCache(DefaultASTCacheConfig())
+	child := `{~prompty.extends template="base" /~}{~prompty.block name="b"~}{~prompty.if eval="true"~}[{~prompty.parent /~}]{~/prompty.if~}{~/prompty.block~}`
+
+	e1 := MustNew(WithASTCache(cache))
+	e1.MustRegisterTemplate("base", `{~prompty.block name="b"~}one{~/prompty.block~}`)
+	e2 := MustNew(WithASTCache(cache))
+	e2.MustRegisterTemplate("base", `{~prompty.block name="b"~}two{~/prompty.block~}`)
+
+	out, err := e1.Execute(context.Background(), child, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[one]", out)
+
+	out, err = e2.Execute(context.Background(), child, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[two]", out)
+}
+
+func TestASTCache_Concurrent(t *testing.T) {
+	cache := NewASTCache(ASTCacheConfig{MaxEntries: 4})
+	sources := []string{
+		`a {~prompty.var name="x" /~}`,
+		`b {~prompty.var name="x" /~}`,
+		`c {~prompty.var name="x" /~}`,
+		`d {~prompty.var name="x" /~}`,
+		`e {~prompty.var name="x" /~}`,
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			engine := MustNew(WithASTCache(cache))
+			for j := 0; j < 50; j++ {
+				src := sources[(i+j)%len(sources)]
+				_, err := engine.Execute(context.Background(), src, map[string]any{"x": j})
+				assert.NoError(t, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, cache.Len(), 4)
+	stats := cache.Stats()
+	assert.Equal(t, int64(8*50), stats.Hits+stats.Misses)
+}
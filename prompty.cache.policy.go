@@ -0,0 +1,182 @@
+package prompty
+
+import (
+	"container/list"
+)
+
+// CachePolicy selects the entry a cache evicts when it reaches its entry
+// or byte limit.
+type CachePolicy string
+
+const (
+	// CachePolicyLRU evicts the least recently used entry. It suits caches
+	// whose hot set changes over time.
+	CachePolicyLRU CachePolicy = "lru"
+	// CachePolicyLFU evicts the least frequently used entry, and the least
+	// recently used of equally used ones. It keeps popular templates cached
+	// through bursts of one-off lookups.
+	CachePolicyLFU CachePolicy = "lfu"
+)
+
+// Size estimates of cached values, in bytes
+const (
+	astNodeBytes       = 128 // One AST node with its attributes
+	cacheEntryOverhead = 256 // Bookkeeping of one cache entry
+)
+
+// boundedCache holds the entries of an in-memory cache in recency order
+// and evicts them according to a CachePolicy to stay within an entry count
+// and an estimated byte size. It is not safe for concurrent use.
+type boundedCache[V any] struct {
+	entries    map[string]*list.Element
+	order      *list.List // front = most recently used
+	policy     CachePolicy
+	maxEntries int   // 0 for no entry limit
+	maxBytes   int64 // 0 for no byte limit
+	bytes      int64
+	onEvict    func(key string, value V) // Optional, called for evicted entries
+}
+
+// boundedEntry is an entry of a boundedCache.
+type boundedEntry[V any] struct {
+	key   string
+	value V
+	size  int64
+	hits  int64
+}
+
+// newBoundedCache creates a cache holding at most maxEntries entries and
+// maxBytes bytes, where 0 means no limit.
+func newBoundedCache[V any](maxEntries int, maxBytes int64, policy CachePolicy) *boundedCache[V] {
+	return &boundedCache[V]{
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		policy:     policy,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+	}
+}
+
+// get returns the entry for key, recording a use of it.
+func (c *boundedCache[V]) get(key string) (*boundedEntry[V], bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	entry := elem.Value.(*boundedEntry[V])
+	entry.hits++
+	return entry, true
+}
+
+// peek returns the entry for key without recording a use.
+func (c *boundedCache[V]) peek(key string) (*boundedEntry[V], bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return elem.Value.(*boundedEntry[V]), true
+}
+
+// add stores value under key, replacing any entry for it, and evicts
+// entries until the cache is within its limits again. It reports false
+// and stores nothing when the value alone exceeds the byte limit.
+func (c *boundedCache[V]) add(key string, value V, size int64) bool {
+	c.remove(key)
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return false
+	}
+
+	for c.order.Len() > 0 && c.full(size) {
+		c.evict(c.victim())
+	}
+
+	c.entries[key] = c.order.PushFront(&boundedEntry[V]{key: key, value: value, size: size})
+	c.bytes += size
+	return true
+}
+
+// remove removes the entry for key, reporting whether there was one.
+func (c *boundedCache[V]) remove(key string) bool {
+	elem, ok := c.entries[key]
+	if ok {
+		c.removeElement(elem)
+	}
+	return ok
+}
+
+// removeFunc removes the entries for which fn returns true and returns how
+// many were removed.
+func (c *boundedCache[V]) removeFunc(fn func(entry *boundedEntry[V]) bool) int {
+	removed := 0
+	for elem := c.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		if fn(elem.Value.(*boundedEntry[V])) {
+			c.removeElement(elem)
+			removed++
+		}
+		elem = prev
+	}
+	return removed
+}
+
+// purge removes every entry and returns how many were removed.
+func (c *boundedCache[V]) purge() int {
+	removed := c.order.Len()
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+	c.bytes = 0
+	return removed
+}
+
+// each calls fn for every entry, most recently used first.
+func (c *boundedCache[V]) each(fn func(entry *boundedEntry[V])) {
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		fn(elem.Value.(*boundedEntry[V]))
+	}
+}
+
+// len returns the number of entries.
+func (c *boundedCache[V]) len() int {
+	return c.order.Len()
+}
+
+// full reports whether an entry of size does not fit next to the entries.
+func (c *boundedCache[V]) full(size int64) bool {
+	if c.maxEntries > 0 && c.order.Len() >= c.maxEntries {
+		return true
+	}
+	return c.maxBytes > 0 && c.bytes+size > c.maxBytes
+}
+
+// victim returns the element to evict next under the cache's policy.
+func (c *boundedCache[V]) victim() *list.Element {
+	victim := c.order.Back()
+	if c.policy != CachePolicyLFU {
+		return victim
+	}
+	hits := victim.Value.(*boundedEntry[V]).hits
+	for elem := victim.Prev(); elem != nil; elem = elem.Prev() {
+		if entry := elem.Value.(*boundedEntry[V]); entry.hits < hits {
+			victim, hits = elem, entry.hits
+		}
+	}
+	return victim
+}
+
+// evict removes elem and notifies onEvict.
+func (c *boundedCache[V]) evict(elem *list.Element) {
+	entry := elem.Value.(*boundedEntry[V])
+	c.removeElement(elem)
+	if c.onEvict != nil {
+		c.onEvict(entry.key, entry.value)
+	}
+}
+
+// removeElement removes elem from the cache.
+func (c *boundedCache[V]) removeElement(elem *list.Element) {
+	entry := elem.Value.(*boundedEntry[V])
+	c.order.Remove(elem)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+}
@@ -0,0 +1,147 @@
+package prompty
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// TagCache stores the results of tags carrying the cache attribute:
+//
+//	{~prompty.var name="profile" cache="5m" cache_key="user.id" /~}
+//
+// Keys are opaque hashes of the tag name, its attributes and the values of
+// the data paths listed in cache_key. Every Engine gets its own
+// MemoryTagCache; implement TagCache to back tag caching with a shared
+// store and attach it with WithTagCache.
+type TagCache interface {
+	// Get returns the cached result for key, if present and not expired.
+	Get(ctx context.Context, key string) (string, bool)
+
+	// Set stores a result for key for the given time to live.
+	Set(ctx context.Context, key, value string, ttl time.Duration)
+}
+
+// MemoryTagCache is an in-memory TagCache that evicts the least recently
+// used entry beyond its capacity. It is safe for concurrent use.
+type MemoryTagCache struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	lru        *list.List // front = most recently used
+	maxEntries int
+	stats      TagCacheStats
+}
+
+// tagCacheEntry holds a cached tag result with its expiry.
+type tagCacheEntry struct {
+	key       string
+	value     string
+	expiresAt time.Time
+}
+
+// TagCacheStats tracks tag cache performance metrics.
+type TagCacheStats struct {
+	Hits        int64
+	Misses      int64
+	Evictions   int64
+	Expirations int64
+	EntryCount  int
+}
+
+// NewMemoryTagCache creates an in-memory tag cache holding up to maxEntries
+// results. A non-positive maxEntries uses DefaultTagCacheMaxEntries.
+func NewMemoryTagCache(maxEntries int) *MemoryTagCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultTagCacheMaxEntries
+	}
+	return &MemoryTagCache{
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		maxEntries: maxEntries,
+	}
+}
+
+// Get returns the cached result for key if present and not expired.
+func (c *MemoryTagCache) Get(ctx context.Context, key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return "", false
+	}
+
+	entry := elem.Value.(*tagCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.removeElement(elem)
+		c.stats.Expirations++
+		c.stats.Misses++
+		return "", false
+	}
+
+	c.lru.MoveToFront(elem)
+	c.stats.Hits++
+	return entry.value, true
+}
+
+// Set stores a result, evicting the least recently used entry at capacity.
+func (c *MemoryTagCache) Set(ctx context.Context, key, value string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*tagCacheEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	for len(c.entries) >= c.maxEntries {
+		c.removeElement(c.lru.Back())
+		c.stats.Evictions++
+	}
+
+	c.entries[key] = c.lru.PushFront(&tagCacheEntry{key: key, value: value, expiresAt: expiresAt})
+	c.stats.EntryCount = len(c.entries)
+}
+
+// removeElement removes an entry. Caller must hold c.mu.
+func (c *MemoryTagCache) removeElement(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*tagCacheEntry).key)
+	c.stats.EntryCount = len(c.entries)
+}
+
+// Len returns the number of cached results.
+func (c *MemoryTagCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Clear removes all entries from the cache. Statistics are kept.
+func (c *MemoryTagCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.stats.EntryCount = 0
+}
+
+// Stats returns current cache statistics.
+func (c *MemoryTagCache) Stats() TagCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// TagCache returns the cache backing the cache attribute, or nil if tag
+// caching is disabled.
+func (e *Engine) TagCache() TagCache {
+	return e.config.tagCache
+}
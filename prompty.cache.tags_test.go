@@ -0,0 +1,163 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingEngine returns an engine with a Fetch resolver counting its invocations.
+func countingEngine(t *testing.T, opts ...Option) (*Engine, *int) {
+	t.Helper()
+	engine := MustNew(opts...)
+	invocations := 0
+	engine.MustRegister(NewResolverFunc("Fetch", func(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+		invocations++
+		return attrs.GetDefault("q", "") + ":" + execCtx.GetString("user.id"), nil
+	}, nil))
+	return engine, &invocations
+}
+
+func TestTagCache_Execute(t *testing.T) {
+	engine, invocations := countingEngine(t)
+	ctx := context.Background()
+	ada := map[string]any{"user": map[string]any{"id": "ada"}}
+	bob := map[string]any{"user": map[string]any{"id": "bob"}}
+
+	source := `{~Fetch q="weather" cache="5m" cache_key="user.id" /~}`
+	for i := 0; i < 3; i++ {
+		result, err := engine.Execute(ctx, source, ada)
+		require.NoError(t, err)
+		assert.Equal(t, "weather:ada", result)
+	}
+	assert.Equal(t, 1, *invocations)
+
+	// Data values listed in cache_key are part of the key
+	result, err := engine.Execute(ctx, source, bob)
+	require.NoError(t, err)
+	assert.Equal(t, "weather:bob", result)
+	assert.Equal(t, 2, *invocations)
+
+	// So are the other attributes
+	result, err = engine.Execute(ctx, `{~Fetch q="news" cache="5m" cache_key="user.id" /~}`, ada)
+	require.NoError(t, err)
+	assert.Equal(t, "news:ada", result)
+	assert.Equal(t, 3, *invocations)
+
+	// Tags without the cache attribute always resolve
+	_, err = engine.Execute(ctx, `{~Fetch q="weather" /~}{~Fetch q="weather" /~}`, ada)
+	require.NoError(t, err)
+	assert.Equal(t, 5, *invocations)
+
+	assert.Equal(t, 3, engine.TagCache().(*MemoryTagCache).Len())
+}
+
+func TestTagCache_Include(t *testing.T) {
+	engine := MustNew()
+	engine.MustRegisterTemplate("banner", `{~prompty.var name="title" /~}/{~prompty.var name="cache" default="-" /~}`)
+
+	source := `{~prompty.include template="banner" title="Hi" cache="1m" /~}`
+	result, err := engine.Execute(context.Background(), source, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Hi/-", result, "cache attributes are not passed to the included template")
+
+	// The included template is not rendered again while cached
+	engine.UnregisterTemplate("banner")
+	engine.MustRegisterTemplate("banner", `changed`)
+	result, err = engine.Execute(context.Background(), source, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Hi/-", result)
+}
+
+func TestTagCache_InvalidTTL(t *testing.T) {
+	engine, invocations := countingEngine(t)
+	source := `{~Fetch q="x" cache="soon" /~}`
+
+	_, err := engine.Execute(context.Background(), source, nil)
+	assert.ErrorContains(t, err, "invalid 'cache' attribute")
+	assert.Zero(t, *invocations)
+
+	result, err := engine.Validate(source)
+	require.NoError(t, err)
+	assert.True(t, result.HasErrors())
+	assert.Equal(t, ErrMsgInvalidTagCacheTTL, result.Errors()[0].Message)
+}
+
+func TestTagCache_MessageCacheAttribute(t *testing.T) {
+	engine := MustNew()
+	result, err := engine.Execute(context.Background(),
+		`{~prompty.message role="system" cache="true"~}Rules{~/prompty.message~}`, nil)
+	require.NoError(t, err)
+
+	messages := ExtractMessagesFromOutput(result)
+	require.Len(t, messages, 1)
+	assert.True(t, messages[0].Cache)
+}
+
+// recordingTagCache is a TagCache recording the TTLs it is given.
+type recordingTagCache struct {
+	*MemoryTagCache
+	ttls []time.Duration
+}
+
+func (c *recordingTagCache) Set(ctx context.Context, key, value string, ttl time.Duration) {
+	c.ttls = append(c.ttls, ttl)
+	c.MemoryTagCache.Set(ctx, key, value, ttl)
+}
+
+func TestTagCache_Options(t *testing.T) {
+	t.Run("custom cache", func(t *testing.T) {
+		cache := &recordingTagCache{MemoryTagCache: NewMemoryTagCache(0)}
+		engine, invocations := countingEngine(t, WithTagCache(cache))
+
+		for i := 0; i < 2; i++ {
+			_, err := engine.Execute(context.Background(), `{~Fetch q="x" cache="90s" /~}`, nil)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 1, *invocations)
+		assert.Equal(t, []time.Duration{90 * time.Second}, cache.ttls)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		engine, invocations := countingEngine(t, WithTagCache(nil))
+		assert.Nil(t, engine.TagCache())
+
+		for i := 0; i < 2; i++ {
+			_, err := engine.Execute(context.Background(), `{~Fetch q="x" cache="5m" /~}`, nil)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, *invocations)
+	})
+}
+
+func TestMemoryTagCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryTagCache(2)
+
+	cache.Set(ctx, "a", "1", time.Minute)
+	cache.Set(ctx, "b", "2", time.Minute)
+	_, ok := cache.Get(ctx, "a")
+	require.True(t, ok)
+
+	// "b" is the least recently used entry
+	cache.Set(ctx, "c", "3", time.Minute)
+	_, ok = cache.Get(ctx, "b")
+	assert.False(t, ok)
+
+	cache.Set(ctx, "a", "expired", -time.Second)
+	_, ok = cache.Get(ctx, "a")
+	assert.False(t, ok)
+
+	stats := cache.Stats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(2), stats.Misses)
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.Equal(t, int64(1), stats.Expirations)
+	assert.Equal(t, 1, stats.EntryCount)
+
+	cache.Clear()
+	assert.Zero(t, cache.Len())
+}
@@ -62,9 +62,12 @@ func GenerateSkillsCatalog(ctx context.Context, skills []SkillRef, resolver Docu
 		if ref.IsInline() {
 			entry.description = ref.Inline.Description
 		} else if resolver != nil {
-			resolved, err := resolver.ResolveSkill(ctx, ref.Slug)
+			resolved, version, err := ResolveSkillRef(ctx, resolver, ref)
 			if err == nil && resolved != nil {
 				entry.description = resolved.Description
+				if version != "" {
+					entry.version = version
+				}
 			}
 			// If resolution fails, use empty description (non-fatal)
 		}
@@ -0,0 +1,305 @@
+package prompty
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Input coercion reasons, reported as the reason of input validation errors
+const (
+	coerceReasonFormat = "cannot convert %q to %s" // value, type
+	coerceReasonType   = "cannot convert %T to %s" // value, type
+	coerceReasonEnum   = "value not in enum"
+	coerceReasonInt    = "integer expected"
+)
+
+// Boolean spellings accepted by input coercion, besides those of strconv.ParseBool
+var coerceBoolWords = map[string]bool{
+	"yes": true, "on": true, "y": true,
+	"no": false, "off": false, "n": false,
+}
+
+// Coercer converts a raw input value, e.g. a string from an HTTP form or
+// query, to the value of an input type. It returns an error if the value
+// cannot be converted.
+type Coercer func(value any) (any, error)
+
+// CoerceOption configures Prompt.CoerceInputs.
+type CoerceOption func(*coerceConfig)
+
+// coerceConfig holds the configuration of one CoerceInputs call.
+type coerceConfig struct {
+	trimSpace bool
+	coercers  map[string]Coercer
+}
+
+// WithTrimSpace trims leading and trailing white space from string inputs.
+// Numbers and booleans are parsed without surrounding white space either way.
+func WithTrimSpace() CoerceOption {
+	return func(c *coerceConfig) {
+		c.trimSpace = true
+	}
+}
+
+// WithCoercer converts inputs of a type with c instead of the built-in
+// conversion. The type may be a built-in one ("number") or a custom type
+// name used in the inputs schema (e.g. "date").
+func WithCoercer(inputType string, c Coercer) CoerceOption {
+	return func(cfg *coerceConfig) {
+		if cfg.coercers == nil {
+			cfg.coercers = make(map[string]Coercer)
+		}
+		cfg.coercers[inputType] = c
+	}
+}
+
+// CoerceInputs normalizes data according to the inputs schema before
+// execution, so callers receiving strings from HTTP or JSON need no
+// conversion glue of their own:
+//   - strings are converted to the input type: "number" (float64),
+//     "integer" (int), "boolean" (true/false, 1/0, yes/no, on/off), and
+//     "array" or "object" (JSON)
+//   - missing or nil inputs with a default get the default
+//   - values are checked against the input's enum
+//   - missing required inputs are errors
+//   - array items and object properties are coerced by their nested
+//     definitions (items, properties)
+//
+// Data without an input definition is kept as is. The result is a new
+// map; data is not modified. Errors are input validation errors naming the
+// input.
+//
+//	data, err := tmpl.Prompt().CoerceInputs(map[string]any{"limit": r.FormValue("limit")})
+func (p *Prompt) CoerceInputs(data map[string]any, opts ...CoerceOption) (map[string]any, error) {
+	cfg := &coerceConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	result := make(map[string]any, len(data))
+	for key, value := range data {
+		result[key] = value
+	}
+	if !p.HasInputs() {
+		return result, nil
+	}
+
+	if err := coerceProperties("", result, p.Inputs, cfg); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// coerceProperties coerces the values of data, in place, according to
+// their input definitions. path prefixes the names in errors; it is empty
+// for top-level inputs.
+func coerceProperties(path string, data map[string]any, defs map[string]*InputDef, cfg *coerceConfig) error {
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names) // Report the first error deterministically
+
+	for _, name := range names {
+		def := defs[name]
+		if def == nil {
+			continue
+		}
+		namePath := name
+		if path != "" {
+			namePath = fmt.Sprintf(inputPathProperty, path, name)
+		}
+		value, exists := data[name]
+		if !exists || value == nil {
+			switch {
+			case def.Default != nil:
+				value = deepCopyValue(def.Default)
+			case def.Required:
+				return NewRequiredInputMissingError(namePath)
+			default:
+				continue
+			}
+		}
+
+		coerced, err := coerceValue(namePath, value, def, cfg)
+		if err != nil {
+			return err
+		}
+		data[name] = coerced
+	}
+	return nil
+}
+
+// coerceValue converts a value to its input definition and checks its enum,
+// recursing into the items of arrays and the properties of objects. Nested
+// containers are copied rather than modified.
+func coerceValue(path string, value any, def *InputDef, cfg *coerceConfig) (any, error) {
+	coerced, err := coerceInput(value, def, cfg)
+	if err != nil {
+		return nil, NewInputValidationError(path, err.Error())
+	}
+	if !inEnum(coerced, def.Enum) {
+		return nil, NewInputValidationError(path, coerceReasonEnum)
+	}
+
+	switch v := coerced.(type) {
+	case []any:
+		if def.Items == nil {
+			break
+		}
+		items := make([]any, len(v))
+		for i, item := range v {
+			if item == nil {
+				continue
+			}
+			if items[i], err = coerceValue(fmt.Sprintf(inputPathIndex, path, i), item, def.Items, cfg); err != nil {
+				return nil, err
+			}
+		}
+		coerced = items
+	case map[string]any:
+		if len(def.Properties) == 0 {
+			break
+		}
+		obj := make(map[string]any, len(v))
+		for key, field := range v {
+			obj[key] = field
+		}
+		if err := coerceProperties(path, obj, def.Properties, cfg); err != nil {
+			return nil, err
+		}
+		coerced = obj
+	}
+	return coerced, nil
+}
+
+// coerceInput converts a value to the type of an input definition.
+func coerceInput(value any, def *InputDef, cfg *coerceConfig) (any, error) {
+	if c, ok := cfg.coercers[def.Type]; ok {
+		return c(value)
+	}
+
+	s, isString := value.(string)
+	if isString && cfg.trimSpace {
+		s = strings.TrimSpace(s)
+		value = s
+	}
+
+	switch def.Type {
+	case SchemaTypeString:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case bool, int, int64, float64, float32:
+			return fmt.Sprint(v), nil
+		}
+	case SchemaTypeNumber:
+		if isString {
+			f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return nil, fmt.Errorf(coerceReasonFormat, s, def.Type)
+			}
+			return f, nil
+		}
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case float32:
+			return float64(v), nil
+		case int:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		}
+	case SchemaTypeInteger:
+		if isString {
+			i, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				return nil, fmt.Errorf(coerceReasonFormat, s, def.Type)
+			}
+			return i, nil
+		}
+		switch v := value.(type) {
+		case int:
+			return v, nil
+		case int64:
+			return int(v), nil
+		case float64:
+			if v != math.Trunc(v) {
+				return nil, errors.New(coerceReasonInt)
+			}
+			return int(v), nil
+		}
+	case SchemaTypeBoolean:
+		if isString {
+			word := strings.ToLower(strings.TrimSpace(s))
+			if b, ok := coerceBoolWords[word]; ok {
+				return b, nil
+			}
+			b, err := strconv.ParseBool(word)
+			if err != nil {
+				return nil, fmt.Errorf(coerceReasonFormat, s, def.Type)
+			}
+			return b, nil
+		}
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case SchemaTypeArray, SchemaTypeObject:
+		if isString {
+			var decoded any
+			if err := json.Unmarshal([]byte(s), &decoded); err != nil {
+				return nil, fmt.Errorf(coerceReasonFormat, s, def.Type)
+			}
+			value = decoded
+		}
+		if err := validatePromptInputType("", value, def.Type); err != nil {
+			return nil, fmt.Errorf(coerceReasonType, value, def.Type)
+		}
+		return value, nil
+	default:
+		// Unknown types without a coercer are kept as is
+		return value, nil
+	}
+	return nil, fmt.Errorf(coerceReasonType, value, def.Type)
+}
+
+// inEnum reports whether value is one of the allowed values; an empty enum
+// allows any value. Numbers compare by value, so 1 matches 1.0.
+func inEnum(value any, enum []any) bool {
+	if len(enum) == 0 {
+		return true
+	}
+	for _, allowed := range enum {
+		if a, ok := toFloat(allowed); ok {
+			if v, ok := toFloat(value); ok && a == v {
+				return true
+			}
+			continue
+		}
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// toFloat converts a numeric value to float64.
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
@@ -0,0 +1,113 @@
+package prompty
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func coercePrompt() *Prompt {
+	return &Prompt{
+		Name:        "search",
+		Description: "Searches documents",
+		Inputs: map[string]*InputDef{
+			"query":   {Type: SchemaTypeString, Required: true},
+			"limit":   {Type: SchemaTypeInteger, Default: 10},
+			"score":   {Type: SchemaTypeNumber},
+			"exact":   {Type: SchemaTypeBoolean, Default: false},
+			"tags":    {Type: SchemaTypeArray},
+			"filters": {Type: SchemaTypeObject},
+			"sort":    {Type: SchemaTypeString, Enum: []any{"relevance", "date"}, Default: "relevance"},
+		},
+	}
+}
+
+func TestPrompt_CoerceInputs(t *testing.T) {
+	p := coercePrompt()
+	data := map[string]any{
+		"query":   "  go templates ",
+		"limit":   " 25",
+		"score":   "0.5",
+		"exact":   "on",
+		"tags":    `["go","llm"]`,
+		"filters": `{"lang":"en"}`,
+		"extra":   "kept",
+	}
+
+	result, err := p.CoerceInputs(data)
+	require.NoError(t, err)
+	assert.Equal(t, "  go templates ", result["query"])
+	assert.Equal(t, 25, result["limit"])
+	assert.Equal(t, 0.5, result["score"])
+	assert.Equal(t, true, result["exact"])
+	assert.Equal(t, []any{"go", "llm"}, result["tags"])
+	assert.Equal(t, map[string]any{"lang": "en"}, result["filters"])
+	assert.Equal(t, "relevance", result["sort"], "default materialized")
+	assert.Equal(t, "kept", result["extra"])
+	assert.Equal(t, " 25", data["limit"], "data is not modified")
+	require.NoError(t, p.ValidateInputs(result))
+
+	result, err = p.CoerceInputs(data, WithTrimSpace())
+	require.NoError(t, err)
+	assert.Equal(t, "go templates", result["query"])
+
+	// JSON numbers become integers when integral
+	result, err = p.CoerceInputs(map[string]any{"query": "q", "limit": float64(3)})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result["limit"])
+}
+
+func TestPrompt_CoerceInputs_Errors(t *testing.T) {
+	p := coercePrompt()
+
+	tests := []struct {
+		name string
+		data map[string]any
+		msg  string
+	}{
+		{"missing required", map[string]any{}, ErrMsgRequiredInputMissing},
+		{"bad integer", map[string]any{"query": "q", "limit": "ten"}, ErrMsgInputValidationFailed},
+		{"fractional integer", map[string]any{"query": "q", "limit": 2.5}, ErrMsgInputValidationFailed},
+		{"bad boolean", map[string]any{"query": "q", "exact": "maybe"}, ErrMsgInputValidationFailed},
+		{"bad array", map[string]any{"query": "q", "tags": `{"a":1}`}, ErrMsgInputValidationFailed},
+		{"not in enum", map[string]any{"query": "q", "sort": "price"}, ErrMsgInputValidationFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.CoerceInputs(tt.data)
+			assert.ErrorContains(t, err, tt.msg)
+		})
+	}
+
+	err := p.ValidateInputs(map[string]any{"query": "q", "sort": "price"})
+	assert.ErrorContains(t, err, ErrMsgInputValidationFailed)
+}
+
+func TestPrompt_CoerceInputs_CustomCoercer(t *testing.T) {
+	p := &Prompt{Inputs: map[string]*InputDef{
+		"since": {Type: "date"},
+		"query": {Type: SchemaTypeString},
+	}}
+	date := WithCoercer("date", func(value any) (any, error) {
+		return time.Parse(time.DateOnly, value.(string))
+	})
+	upper := WithCoercer(SchemaTypeString, func(value any) (any, error) {
+		return strings.ToUpper(value.(string)), nil
+	})
+
+	result, err := p.CoerceInputs(map[string]any{"since": "2024-03-01", "query": "go"}, date, upper)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), result["since"])
+	assert.Equal(t, "GO", result["query"])
+
+	_, err = p.CoerceInputs(map[string]any{"since": "March"}, date)
+	assert.ErrorContains(t, err, ErrMsgInputValidationFailed)
+
+	// Without a coercer, unknown types are kept
+	result, err = p.CoerceInputs(map[string]any{"since": "March"})
+	require.NoError(t, err)
+	assert.Equal(t, "March", result["since"])
+}
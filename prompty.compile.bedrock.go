@@ -0,0 +1,122 @@
+package prompty
+
+import "strings"
+
+// ToBedrockMessages converts compiled messages to AWS Bedrock format,
+// following the model family of the execution config's model ID (see
+// ExecutionConfig.ToBedrock):
+//
+//   - Anthropic models: the Anthropic layout of ToAnthropicMessages.
+//   - Amazon Titan text models: {"inputText": "..."} with the system prompt
+//     followed by "User: " and "Bot:" turns.
+//   - Other models: the Converse API {"system": [...], "messages": [...]}
+//     with text, toolUse and toolResult content blocks.
+func (cp *CompiledPrompt) ToBedrockMessages() map[string]any {
+	if cp == nil || len(cp.Messages) == 0 {
+		return nil
+	}
+
+	switch family, name, _ := bedrockModelParts(cp.Execution.GetModel()); {
+	case family == BedrockFamilyAnthropic:
+		return cp.ToAnthropicMessages()
+	case family == BedrockFamilyAmazon && strings.HasPrefix(name, BedrockTitanPrefix):
+		return map[string]any{BedrockKeyInputText: cp.bedrockTitanInput()}
+	default:
+		return cp.bedrockConverseMessages()
+	}
+}
+
+// bedrockTitanInput renders the messages as a Titan text prompt.
+func (cp *CompiledPrompt) bedrockTitanInput() string {
+	var sb strings.Builder
+	for _, msg := range cp.Messages {
+		switch {
+		case msg.Role == RoleSystem:
+			sb.WriteString(msg.Content)
+		case msg.Role == RoleAssistant && len(msg.ToolCalls) == 0:
+			sb.WriteString(BedrockTitanBotPrefix + " " + msg.Content)
+		case msg.Role == RoleAssistant:
+			continue
+		default:
+			sb.WriteString(BedrockTitanUserPrefix + msg.Content)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(BedrockTitanBotPrefix)
+	return sb.String()
+}
+
+// bedrockConverseMessages converts the messages to the Converse API layout.
+func (cp *CompiledPrompt) bedrockConverseMessages() map[string]any {
+	var system []map[string]any
+	messages := make([]map[string]any, 0, len(cp.Messages))
+
+	for _, msg := range cp.Messages {
+		if msg.Role == RoleSystem {
+			system = append(system, map[string]any{PartKeyText: msg.Content})
+			continue
+		}
+
+		role := msg.Role
+		content := make([]map[string]any, 0, len(msg.ToolCalls)+1)
+		switch {
+		case len(msg.ToolResults) > 0:
+			role = RoleUser
+			for _, r := range msg.ToolResults {
+				result := map[string]any{
+					BedrockKeyToolUseID: r.CallID,
+					PayloadKeyContent:   []map[string]any{{PartKeyText: r.Content}},
+				}
+				if r.IsError {
+					result[BedrockKeyStatus] = BedrockStatusError
+				}
+				content = append(content, map[string]any{BedrockKeyToolResult: result})
+			}
+		default:
+			if msg.Content != "" || len(msg.ToolCalls) == 0 {
+				content = append(content, map[string]any{PartKeyText: msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				content = append(content, map[string]any{BedrockKeyToolUse: map[string]any{
+					BedrockKeyToolUseID: call.ID,
+					PartKeyName:         call.Name,
+					PartKeyInput:        toolArguments(call),
+				}})
+			}
+		}
+		messages = append(messages, map[string]any{AttrRole: role, PayloadKeyContent: content})
+	}
+
+	result := make(map[string]any, 2)
+	if len(system) > 0 {
+		result[RoleSystem] = system
+	}
+	result[PayloadKeyMessages] = messages
+	return result
+}
+
+// bedrockToolConfig converts function definitions to the Converse API
+// toolConfig. Tool choice "required" maps to "any"; "none" and specific
+// function names are not representable and leave the choice to the model.
+func bedrockToolConfig(tools *ToolsConfig) map[string]any {
+	specs := make([]map[string]any, 0, len(tools.Functions))
+	for _, fn := range tools.Functions {
+		spec := map[string]any{PartKeyName: fn.Name}
+		if fn.Description != "" {
+			spec[SchemaKeyDescription] = fn.Description
+		}
+		if fn.Parameters != nil {
+			spec[BedrockKeyInputSchema] = map[string]any{BedrockKeyJSON: copySchema(fn.Parameters)}
+		}
+		specs = append(specs, map[string]any{BedrockKeyToolSpec: spec})
+	}
+
+	config := map[string]any{PayloadKeyTools: specs}
+	switch tools.ToolChoice {
+	case ToolChoiceAuto:
+		config[BedrockKeyToolChoice] = map[string]any{BedrockToolChoiceAuto: map[string]any{}}
+	case ToolChoiceRequired:
+		config[BedrockKeyToolChoice] = map[string]any{BedrockToolChoiceAny: map[string]any{}}
+	}
+	return config
+}
@@ -0,0 +1,98 @@
+package prompty
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBedrockPrompt(model string) *CompiledPrompt {
+	return &CompiledPrompt{
+		Messages: []CompiledMessage{
+			{Role: RoleSystem, Content: "Be brief."},
+			{Role: RoleUser, Content: "Hi."},
+			{Role: RoleAssistant, Content: "Hello."},
+			{Role: RoleUser, Content: "Weather?"},
+		},
+		Execution: &ExecutionConfig{Model: model},
+		Tools: &ToolsConfig{
+			Functions: []*FunctionDef{{
+				Name:        "weather",
+				Description: "Current weather",
+				Parameters:  map[string]any{"type": "object"},
+			}},
+			ToolChoice: ToolChoiceRequired,
+		},
+	}
+}
+
+func TestCompiledPrompt_ToBedrockMessages(t *testing.T) {
+	t.Run("anthropic", func(t *testing.T) {
+		compiled := newBedrockPrompt("anthropic.claude-3-haiku-20240307-v1:0")
+		assert.Equal(t, compiled.ToAnthropicMessages(), compiled.ToBedrockMessages())
+	})
+
+	t.Run("titan", func(t *testing.T) {
+		compiled := newBedrockPrompt("amazon.titan-text-express-v1")
+		assert.Equal(t, map[string]any{
+			BedrockKeyInputText: "Be brief.\nUser: Hi.\nBot: Hello.\nUser: Weather?\nBot:",
+		}, compiled.ToBedrockMessages())
+	})
+
+	t.Run("converse", func(t *testing.T) {
+		compiled, err := newBedrockPrompt("meta.llama3-1-70b-instruct-v1:0").WithToolResults([]ToolResult{
+			{CallID: "t1", Name: "weather", Content: "unavailable", IsError: true},
+		})
+		require.NoError(t, err)
+
+		result := compiled.ToBedrockMessages()
+		assert.Equal(t, []map[string]any{{PartKeyText: "Be brief."}}, result[RoleSystem])
+		messages := result[PayloadKeyMessages].([]map[string]any)
+		require.Len(t, messages, 5)
+		assert.Equal(t, map[string]any{AttrRole: RoleUser, PayloadKeyContent: []map[string]any{{PartKeyText: "Hi."}}}, messages[0])
+		assert.Equal(t, []map[string]any{{BedrockKeyToolUse: map[string]any{
+			BedrockKeyToolUseID: "t1", PartKeyName: "weather", PartKeyInput: map[string]any{},
+		}}}, messages[3][PayloadKeyContent])
+		assert.Equal(t, RoleUser, messages[4][AttrRole])
+		assert.Equal(t, []map[string]any{{BedrockKeyToolResult: map[string]any{
+			BedrockKeyToolUseID: "t1",
+			PayloadKeyContent:   []map[string]any{{PartKeyText: "unavailable"}},
+			BedrockKeyStatus:    BedrockStatusError,
+		}}}, messages[4][PayloadKeyContent])
+	})
+}
+
+func TestCompiledPrompt_ToProviderPayload_Bedrock(t *testing.T) {
+	payload, err := newBedrockPrompt("anthropic.claude-3-haiku-20240307-v1:0").ToProviderPayload("")
+	require.NoError(t, err)
+	assert.Equal(t, BedrockAnthropicVersion, payload[BedrockKeyAnthropicVersion])
+	assert.Equal(t, "Be brief.", payload[RoleSystem])
+	assert.Len(t, payload[PayloadKeyTools], 1)
+	assert.Equal(t, map[string]any{PayloadKeyToolChoiceType: ToolChoiceAnthropicAny}, payload[PayloadKeyToolChoice])
+
+	payload, err = newBedrockPrompt("mistral.mistral-large-2407-v1:0").ToProviderPayload(ProviderBedrock)
+	require.NoError(t, err)
+	assert.Equal(t, "mistral.mistral-large-2407-v1:0", payload[BedrockKeyModelID])
+	assert.Equal(t, map[string]any{
+		PayloadKeyTools: []map[string]any{{BedrockKeyToolSpec: map[string]any{
+			PartKeyName:           "weather",
+			SchemaKeyDescription:  "Current weather",
+			BedrockKeyInputSchema: map[string]any{BedrockKeyJSON: map[string]any{"type": "object"}},
+		}}},
+		BedrockKeyToolChoice: map[string]any{BedrockToolChoiceAny: map[string]any{}},
+	}, payload[BedrockKeyToolConfig])
+
+	payload, err = newBedrockPrompt("amazon.titan-text-express-v1").ToProviderPayload(ProviderBedrock)
+	require.NoError(t, err)
+	assert.NotContains(t, payload, PayloadKeyTools)
+	assert.NotContains(t, payload, BedrockKeyToolConfig)
+
+	compiled := newBedrockPrompt("gpt-4o")
+	compiled.Execution.ProviderOptions = map[string]any{ProviderOptionAzureDeployment: "prod"}
+	payload, err = compiled.ToProviderPayload(ProviderAzure)
+	require.NoError(t, err)
+	assert.NotContains(t, payload, ParamKeyModel)
+	assert.NotContains(t, payload, ProviderOptionAzureDeployment)
+	assert.Len(t, payload[PayloadKeyMessages], 4)
+}
@@ -0,0 +1,339 @@
+package prompty
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// CompileCache caches the results of CompileAgent, so hot agents skip
+// catalog generation, skill resolution and template execution when they
+// are compiled again with the same data. Attach it with WithCompileCache
+// or CompileOptions.Cache.
+//
+// Results are keyed by the content hash of the agent (after environment
+// overlays and variant selection), the compile options that shape the
+// output and the data fields selected by CompileCacheConfig.DataFields.
+// Each entry records the documents it was compiled from: the agent and its
+// skills, whose resolved content hashes are in CompiledPrompt.Lock. Saving
+// or deleting one of them drops the entries built from it, either through
+// Invalidate or by subscribing the cache to a storage engine's events:
+//
+//	cache := prompty.NewCompileCache(prompty.CompileCacheConfig{DataFields: []string{"locale"}})
+//	se.Subscribe(cache, prompty.EventTemplateSaved, prompty.EventVersionCreated,
+//	    prompty.EventTemplateDeleted, prompty.EventVersionDeleted)
+//
+// A cache should serve compilations with one Engine, DocumentResolver and
+// ModelRegistry, as these are not part of the key. Compilations with a
+// ConversationStore are never cached. Each caller receives its own copy of
+// a cached prompt. It is safe for concurrent use.
+type CompileCache struct {
+	mu         sync.Mutex
+	entries    *boundedCache[compileCacheEntry]
+	config     CompileCacheConfig
+	stats      CompileCacheStats
+	generation uint64 // Incremented by invalidations, so in-flight compilations are not cached
+}
+
+// compileCacheEntry holds a compiled prompt with the documents it depends on.
+type compileCacheEntry struct {
+	prompt    *CompiledPrompt
+	documents []string
+	expiresAt time.Time
+}
+
+// CompileCacheConfig configures a CompileCache.
+type CompileCacheConfig struct {
+	// MaxEntries is the maximum number of cached prompts; an entry chosen
+	// by Policy is evicted beyond it. Default: 1000.
+	MaxEntries int
+
+	// MaxBytes bounds the estimated memory held by the cached prompts.
+	// Default: 0 (no byte limit).
+	MaxBytes int64
+
+	// Policy selects the prompt evicted when a limit is reached.
+	// Default: CachePolicyLRU.
+	Policy CachePolicy
+
+	// TTL is how long a compiled prompt stays cached. It bounds how stale
+	// results of templates using time or other external state can get.
+	// Default: 10 minutes.
+	TTL time.Duration
+
+	// DataFields lists the input fields the compiled output depends on.
+	// Only these are part of the cache key, so inputs differing in other
+	// fields (such as a request ID) share an entry. When empty, the whole
+	// input is part of the key.
+	DataFields []string
+}
+
+// CompileCacheStats tracks CompileCache performance metrics.
+type CompileCacheStats struct {
+	Hits          int64
+	Misses        int64 // Including compilations whose input cannot be hashed
+	Evictions     int64 // Entries removed to stay within MaxEntries or MaxBytes
+	Expirations   int64
+	Invalidations int64 // Entries removed by Invalidate, storage events or Purge
+	EntryCount    int
+	Bytes         int64 // Estimated memory held by the cached prompts
+}
+
+// NewCompileCache creates a compile cache. Zero config values use the
+// defaults.
+func NewCompileCache(config CompileCacheConfig) *CompileCache {
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = DefaultCompileCacheMaxEntries
+	}
+	if config.MaxBytes < 0 {
+		config.MaxBytes = 0
+	}
+	if config.Policy == "" {
+		config.Policy = CachePolicyLRU
+	}
+	if config.TTL <= 0 {
+		config.TTL = DefaultCompileCacheTTL
+	}
+
+	c := &CompileCache{
+		entries: newBoundedCache[compileCacheEntry](config.MaxEntries, config.MaxBytes, config.Policy),
+		config:  config,
+	}
+	c.entries.onEvict = func(string, compileCacheEntry) {
+		c.stats.Evictions++
+	}
+	return c
+}
+
+// compile returns the cached compilation of p for input, compiling and
+// caching it on a miss.
+func (c *CompileCache) compile(ctx context.Context, p *Prompt, input map[string]any, opts *CompileOptions) (*CompiledPrompt, error) {
+	key, ok := c.key(p, input, opts)
+	if !ok {
+		c.mu.Lock()
+		c.stats.Misses++
+		c.mu.Unlock()
+		return compileAgent(ctx, p, input, opts)
+	}
+
+	c.mu.Lock()
+	if cached, ok := c.lookup(key); ok {
+		c.mu.Unlock()
+		return cached.clone(), nil
+	}
+	c.stats.Misses++
+	generation := c.generation
+	c.mu.Unlock()
+
+	compiled, err := compileAgent(ctx, p, input, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Prompts degraded by resolution failures are not cached, as the
+	// failures may be transient
+	if c.generation == generation && len(compiled.Warnings) == 0 {
+		c.entries.add(key, compileCacheEntry{
+			prompt:    compiled.clone(),
+			documents: compileDocuments(p, compiled),
+			expiresAt: time.Now().Add(c.config.TTL),
+		}, compiledPromptSize(compiled))
+	}
+	return compiled, nil
+}
+
+// lookup returns the unexpired cached prompt of key. The caller must hold mu.
+func (c *CompileCache) lookup(key string) (*CompiledPrompt, bool) {
+	entry, ok := c.entries.get(key)
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.value.expiresAt) {
+		c.entries.remove(key)
+		c.stats.Expirations++
+		return nil, false
+	}
+	c.stats.Hits++
+	return entry.value.prompt, true
+}
+
+// compileCacheKey holds the inputs of a compilation that shape its output.
+type compileCacheKey struct {
+	PromptHash          string        `json:"prompt_hash"`
+	DataHash            string        `json:"data_hash"`
+	Environment         string        `json:"environment,omitempty"`
+	Variant             string        `json:"variant,omitempty"`
+	SkillsCatalogFormat CatalogFormat `json:"skills_catalog_format,omitempty"`
+	ToolsCatalogFormat  CatalogFormat `json:"tools_catalog_format,omitempty"`
+	Lock                *CompileLock  `json:"lock,omitempty"`
+	OnResolveError      string        `json:"on_resolve_error,omitempty"`
+	ResolvePlaceholder  string        `json:"resolve_placeholder,omitempty"`
+}
+
+// key returns the cache key of compiling p with input and opts. It reports
+// false when the selected input cannot be hashed.
+func (c *CompileCache) key(p *Prompt, input map[string]any, opts *CompileOptions) (string, bool) {
+	selected := input
+	if len(c.config.DataFields) > 0 {
+		selected = make(map[string]any, len(c.config.DataFields))
+		for _, field := range c.config.DataFields {
+			if v, ok := input[field]; ok {
+				selected[field] = v
+			}
+		}
+	}
+	dataHash, err := DataHash(selected)
+	if err != nil {
+		return "", false
+	}
+
+	encoded, err := json.Marshal(compileCacheKey{
+		PromptHash:          p.Hash(),
+		DataHash:            dataHash,
+		Environment:         opts.environment(),
+		Variant:             opts.Variant,
+		SkillsCatalogFormat: opts.SkillsCatalogFormat,
+		ToolsCatalogFormat:  opts.ToolsCatalogFormat,
+		Lock:                opts.Lock,
+		OnResolveError:      string(opts.OnResolveError),
+		ResolvePlaceholder:  opts.ResolvePlaceholder,
+	})
+	if err != nil {
+		return "", false
+	}
+	return hashContent(string(encoded)), true
+}
+
+// compileDocuments returns the names of the documents a compilation of p
+// depends on: the agent itself and its referenced skills.
+func compileDocuments(p *Prompt, compiled *CompiledPrompt) []string {
+	seen := make(map[string]bool)
+	var documents []string
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			documents = append(documents, name)
+		}
+	}
+	add(p.Name)
+	for i := range p.Skills {
+		if !p.Skills[i].IsInline() {
+			add(p.Skills[i].GetSlug())
+		}
+	}
+	if compiled.Lock != nil {
+		for _, skill := range compiled.Lock.Skills {
+			add(skill.Slug)
+		}
+	}
+	return documents
+}
+
+// compiledPromptSize estimates the memory held by a compiled prompt.
+func compiledPromptSize(cp *CompiledPrompt) int64 {
+	size := int64(cacheEntryOverhead)
+	for _, msg := range cp.Messages {
+		size += int64(len(msg.Content)) + cacheEntryOverhead
+		for _, part := range msg.Parts {
+			size += int64(len(part.Text)) + int64(len(part.URL))
+		}
+	}
+	return size
+}
+
+// clone returns a copy of the compiled prompt that shares no mutable state
+// with it.
+func (cp *CompiledPrompt) clone() *CompiledPrompt {
+	c := *cp
+	c.Messages = make([]CompiledMessage, len(cp.Messages))
+	for i, msg := range cp.Messages {
+		msg.Parts = append([]ContentPart(nil), msg.Parts...)
+		msg.ToolCalls = append([]ToolCall(nil), msg.ToolCalls...)
+		msg.ToolResults = append([]ToolResult(nil), msg.ToolResults...)
+		c.Messages[i] = msg
+	}
+	if cp.Execution != nil {
+		c.Execution = cp.Execution.Clone()
+	}
+	if cp.Tools != nil {
+		c.Tools = cp.Tools.Clone()
+	}
+	if cp.Constraints != nil {
+		c.Constraints = cp.Constraints.Clone()
+	}
+	c.Skills = nil
+	for i := range cp.Skills {
+		c.Skills = append(c.Skills, *cp.Skills[i].Clone())
+	}
+	c.Warnings = append([]CompileWarning(nil), cp.Warnings...)
+	if cp.Lock != nil {
+		lock := *cp.Lock
+		if cp.Lock.Skills != nil {
+			lock.Skills = append(make([]LockedSkill, 0, len(cp.Lock.Skills)), cp.Lock.Skills...)
+		}
+		c.Lock = &lock
+	}
+	return &c
+}
+
+// Invalidate removes the compiled prompts built from the named agent or
+// skill and returns how many were removed. Compilations in progress are
+// not cached.
+func (c *CompileCache) Invalidate(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	removed := c.entries.removeFunc(func(entry *boundedEntry[compileCacheEntry]) bool {
+		for _, document := range entry.value.documents {
+			if document == name {
+				return true
+			}
+		}
+		return false
+	})
+	c.stats.Invalidations += int64(removed)
+	return removed
+}
+
+// Publish implements EventSink: saving or deleting a template invalidates
+// the compiled prompts built from it.
+func (c *CompileCache) Publish(ctx context.Context, event *StorageEvent) error {
+	switch event.Type {
+	case EventTemplateSaved, EventVersionCreated, EventTemplateDeleted, EventVersionDeleted:
+		c.Invalidate(event.TemplateName)
+	}
+	return nil
+}
+
+// Purge removes all compiled prompts and returns how many were removed.
+func (c *CompileCache) Purge() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	removed := c.entries.purge()
+	c.stats.Invalidations += int64(removed)
+	return removed
+}
+
+// Len returns the number of cached prompts.
+func (c *CompileCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.len()
+}
+
+// Stats returns current cache statistics.
+func (c *CompileCache) Stats() CompileCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.EntryCount = c.entries.len()
+	stats.Bytes = c.entries.bytes
+	return stats
+}
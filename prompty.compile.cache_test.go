@@ -0,0 +1,153 @@
+package prompty
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingDocumentResolver counts the skill resolutions of a resolver
+type countingDocumentResolver struct {
+	*MapDocumentResolver
+	skills atomic.Int32
+}
+
+func (r *countingDocumentResolver) ResolveSkill(ctx context.Context, ref string) (*Prompt, error) {
+	r.skills.Add(1)
+	return r.MapDocumentResolver.ResolveSkill(ctx, ref)
+}
+
+func compileCacheAgent() *Prompt {
+	return &Prompt{
+		Name:        "support-agent",
+		Description: "Support agent",
+		Type:        DocumentTypeAgent,
+		Skills:      []SkillRef{{Slug: "search"}},
+		Body:        `Answer in {~prompty.var name="locale" default="en" /~}.{~prompty.skills_catalog /~}`,
+	}
+}
+
+func compileCacheResolver(description string) *countingDocumentResolver {
+	resolver := &countingDocumentResolver{MapDocumentResolver: NewMapDocumentResolver()}
+	resolver.AddSkill("search", &Prompt{Name: "search", Description: description, Type: DocumentTypeSkill, Body: "Search."})
+	return resolver
+}
+
+func TestCompileCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCompileCache(CompileCacheConfig{DataFields: []string{"locale"}})
+	resolver := compileCacheResolver("Searches the web")
+	opts := NewCompileOptions(WithResolver(resolver), WithCompileCache(cache))
+	agent := compileCacheAgent()
+
+	first, err := agent.CompileAgent(ctx, map[string]any{"locale": "de", "request_id": "a"}, opts)
+	require.NoError(t, err)
+	assert.Contains(t, first.Messages[0].Content, "Answer in de.")
+	assert.Contains(t, first.Messages[0].Content, "Searches the web")
+	resolved := resolver.skills.Load()
+	assert.Positive(t, resolved)
+
+	// Fields outside DataFields share the entry; no skill is resolved again
+	second, err := agent.CompileAgent(ctx, map[string]any{"locale": "de", "request_id": "b"}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, resolved, resolver.skills.Load())
+
+	// Callers get their own copies
+	second.Messages[0].Content = "changed"
+	third, err := agent.CompileAgent(ctx, map[string]any{"locale": "de"}, opts)
+	require.NoError(t, err)
+	assert.Contains(t, third.Messages[0].Content, "Answer in de.")
+
+	// Selected fields, options and agent content are part of the key
+	other, err := agent.CompileAgent(ctx, map[string]any{"locale": "fr"}, opts)
+	require.NoError(t, err)
+	assert.Contains(t, other.Messages[0].Content, "Answer in fr.")
+
+	compact := NewCompileOptions(WithResolver(resolver), WithCompileCache(cache), WithSkillsCatalogFormat(CatalogFormatCompact))
+	_, err = agent.CompileAgent(ctx, map[string]any{"locale": "de"}, compact)
+	require.NoError(t, err)
+
+	edited := compileCacheAgent()
+	edited.Body = "Edited."
+	compiled, err := edited.CompileAgent(ctx, map[string]any{"locale": "de"}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Edited.", compiled.Messages[0].Content)
+
+	stats := cache.Stats()
+	assert.Equal(t, int64(2), stats.Hits)
+	assert.Equal(t, int64(4), stats.Misses)
+	assert.Equal(t, 4, stats.EntryCount)
+	assert.Positive(t, stats.Bytes)
+}
+
+func TestCompileCache_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCompileCache(CompileCacheConfig{})
+	resolver := compileCacheResolver("Old description")
+	opts := &CompileOptions{Resolver: resolver, Cache: cache}
+	agent := compileCacheAgent()
+
+	_, err := agent.CompileAgent(ctx, nil, opts)
+	require.NoError(t, err)
+	plain := &Prompt{Name: "plain-agent", Description: "Plain", Type: DocumentTypeAgent, Body: "Plain."}
+	_, err = plain.CompileAgent(ctx, nil, opts)
+	require.NoError(t, err)
+	require.Equal(t, 2, cache.Len())
+
+	// Changing a skill drops only the prompts built from it
+	resolver.AddSkill("search", &Prompt{Name: "search", Description: "New description", Type: DocumentTypeSkill, Body: "Search."})
+	assert.Equal(t, 1, cache.Invalidate("search"))
+	assert.Equal(t, 0, cache.Invalidate("search"))
+
+	compiled, err := agent.CompileAgent(ctx, nil, opts)
+	require.NoError(t, err)
+	assert.Contains(t, compiled.Messages[0].Content, "New description")
+
+	assert.Equal(t, 1, cache.Invalidate("plain-agent"))
+	assert.Equal(t, 1, cache.Purge())
+	assert.Equal(t, int64(3), cache.Stats().Invalidations)
+}
+
+func TestCompileCache_StorageEvents(t *testing.T) {
+	ctx := context.Background()
+	se, err := NewStorageEngine(StorageEngineConfig{Storage: NewMemoryStorage()})
+	require.NoError(t, err)
+	defer se.Close()
+
+	cache := NewCompileCache(CompileCacheConfig{})
+	se.Subscribe(cache, EventTemplateSaved, EventVersionCreated, EventTemplateDeleted, EventVersionDeleted)
+	opts := &CompileOptions{Resolver: compileCacheResolver("Searches"), Cache: cache}
+
+	_, err = compileCacheAgent().CompileAgent(ctx, nil, opts)
+	require.NoError(t, err)
+	require.Equal(t, 1, cache.Len())
+
+	require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "unrelated", Source: "x"}))
+	assert.Equal(t, 1, cache.Len())
+	require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "search", Source: "Search better."}))
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestCompileCache_Bypass(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCompileCache(CompileCacheConfig{})
+	agent := compileCacheAgent()
+	agent.Skills = nil
+
+	// Inputs that cannot be hashed are compiled without caching
+	_, err := agent.CompileAgent(ctx, map[string]any{"fn": func() {}}, &CompileOptions{Cache: cache})
+	require.NoError(t, err)
+	assert.Equal(t, 0, cache.Len())
+	assert.Equal(t, int64(1), cache.Stats().Misses)
+
+	// So are conversations
+	store := NewMemoryConversationStore()
+	opts := &CompileOptions{Cache: cache, ConversationStore: store, ConversationID: "c1"}
+	_, err = agent.CompileAgent(ctx, map[string]any{"message": "hi"}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 0, cache.Len())
+}
@@ -4,17 +4,23 @@ import (
 	"context"
 	"fmt"
 	"strings"
+
+	"github.com/itsatony/go-prompty/v2/internal"
 )
 
 // Compilation error messages
 const (
-	ErrMsgCompileNotAgent       = "cannot compile non-agent document as agent"
-	ErrMsgCompileBodyFailed     = "failed to compile body template"
-	ErrMsgCompileMessageFailed  = "failed to compile message template"
-	ErrMsgCompileSkillFailed    = "failed to compile skill for activation"
-	ErrMsgCompileNoEngine       = "engine required for compilation"
-	ErrMsgActivateSkillNotFound = "skill not found in agent for activation"
-	ErrMsgAgentDryRunNilPrompt  = "prompt is nil"
+	ErrMsgCompileNotAgent         = "cannot compile non-agent document as agent"
+	ErrMsgCompileBodyFailed       = "failed to compile body template"
+	ErrMsgCompileMessageFailed    = "failed to compile message template"
+	ErrMsgCompileSkillFailed      = "failed to compile skill for activation"
+	ErrMsgCompileSkillSelection   = "skill selection failed"
+	ErrMsgCompileConversation     = "failed to load conversation"
+	ErrMsgCompileNoEngine         = "engine required for compilation"
+	ErrMsgCompileResolveErrorMode = "invalid resolve error mode"
+	ErrMsgCompileSkillUnresolved  = "failed to resolve skill"
+	ErrMsgActivateSkillNotFound   = "skill not found in agent for activation"
+	ErrMsgAgentDryRunNilPrompt    = "prompt is nil"
 )
 
 // AgentDryRunCategory categorizes the type of issue found during an agent dry run.
@@ -120,6 +126,50 @@ type CompileOptions struct {
 	// When set, user-registered resolvers, functions, and templates are available during compilation.
 	// When nil, a new engine with default options is created for each compilation.
 	Engine *Engine
+	// SkillSelector chooses the subset of the agent's skills used for this compilation
+	// (catalogs and CompiledPrompt.Skills). When nil, all declared skills are used.
+	// See NewBudgetSkillSelector for a token-budget selector.
+	SkillSelector SkillSelector
+	// Lock pins skills to the versions recorded by an earlier compilation
+	// (CompiledPrompt.Lock). Skills missing from the lock use their constraint.
+	Lock *CompileLock
+	// ConversationStore persists the transcript of ConversationID. When both
+	// are set, the transcript is available to templates as "conversation"
+	// and follows the system message in default messages, and input.message
+	// is appended to it.
+	ConversationStore ConversationStore
+	// ConversationID identifies the conversation in ConversationStore.
+	ConversationID string
+	// ConversationMaxTokens trims the stored transcript to this token budget
+	// before loading it (0 keeps the whole transcript).
+	ConversationMaxTokens int
+	// ModelRegistry resolves a model alias in the execution config of the
+	// compiled prompt (see ModelRegistry.Resolve).
+	ModelRegistry *ModelRegistry
+	// Environment selects the environment overlays of the prompt
+	// (Prompt.Environments, then EnvironmentOverlays) and the ModelRegistry
+	// overrides to apply. When empty, the environment of Engine
+	// (WithEnvironment) is used.
+	Environment string
+	// EnvironmentOverlays are applied over the prompt's own environments
+	// section, e.g. from a separate overlay document (ParseEnvironmentOverlays).
+	EnvironmentOverlays EnvironmentOverlays
+	// Variant selects a variant of the prompt (Prompt.SelectVariant),
+	// applied after the environment overlays, e.g. the result of
+	// Prompt.AssignVariant for an A/B experiment.
+	Variant string
+	// OnResolveError selects how skills the Resolver cannot resolve are
+	// handled: ResolveErrorFail, ResolveErrorSkip or ResolveErrorPlaceholder.
+	// Each recovered failure is recorded in CompiledPrompt.Warnings.
+	// Default: ResolveErrorDefault.
+	OnResolveError ResolveErrorMode
+	// ResolvePlaceholder stands in for the description and body of
+	// unresolvable skills under ResolveErrorPlaceholder.
+	// Default: DefaultResolvePlaceholder.
+	ResolvePlaceholder string
+	// Cache caches compiled prompts across compilations (see CompileCache).
+	// Compilations with a ConversationStore are not cached.
+	Cache *CompileCache
 }
 
 // CompiledPrompt is the result of agent compilation.
@@ -132,6 +182,17 @@ type CompiledPrompt struct {
 	Tools *ToolsConfig
 	// Constraints are the operational constraints for the agent.
 	Constraints *OperationalConstraints
+	// Skills are the agent's skills selected for this compilation.
+	Skills []SkillRef
+	// Lock records the exact skill versions used, sorted by slug.
+	Lock *CompileLock
+	// ConversationID is the conversation the prompt was compiled for, if any.
+	ConversationID string
+	// Variant is the prompt variant that was compiled, if any.
+	Variant string
+	// Warnings lists the problems the compilation recovered from, such as
+	// skills that could not be resolved (see CompileOptions.OnResolveError).
+	Warnings []CompileWarning
 }
 
 // CompiledMessage is a single message in the compiled output.
@@ -142,6 +203,14 @@ type CompiledMessage struct {
 	Content string
 	// Cache indicates whether this message should be cached.
 	Cache bool
+	// Parts lists the text, image and file parts in order when the message
+	// holds images or files; Content keeps the text alone.
+	Parts []ContentPart
+	// ToolCalls are the tool calls of an assistant message.
+	ToolCalls []ToolCall
+	// ToolResults are the results of a tool message (see
+	// CompiledPrompt.WithToolResults); Content restates them as text.
+	ToolResults []ToolResult
 }
 
 // CompileOption is a functional option for configuring CompileOptions.
@@ -161,6 +230,85 @@ func WithCompileEngine(e *Engine) CompileOption {
 	}
 }
 
+// WithSkillSelector sets the skill selector for compilation.
+func WithSkillSelector(s SkillSelector) CompileOption {
+	return func(o *CompileOptions) {
+		o.SkillSelector = s
+	}
+}
+
+// WithCompileLock pins skill versions to a lock from an earlier compilation.
+func WithCompileLock(l *CompileLock) CompileOption {
+	return func(o *CompileOptions) {
+		o.Lock = l
+	}
+}
+
+// WithConversation loads and persists the transcript of a conversation.
+func WithConversation(store ConversationStore, conversationID string) CompileOption {
+	return func(o *CompileOptions) {
+		o.ConversationStore = store
+		o.ConversationID = conversationID
+	}
+}
+
+// WithConversationMaxTokens sets the token budget of the stored transcript.
+func WithConversationMaxTokens(maxTokens int) CompileOption {
+	return func(o *CompileOptions) {
+		o.ConversationMaxTokens = maxTokens
+	}
+}
+
+// WithModelRegistry resolves model aliases with registry, applying the
+// overrides of environment ("" for none).
+func WithModelRegistry(registry *ModelRegistry, environment string) CompileOption {
+	return func(o *CompileOptions) {
+		o.ModelRegistry = registry
+		o.Environment = environment
+	}
+}
+
+// WithCompileEnvironment selects the environment overlays to apply,
+// optionally adding overlays to those of the prompt.
+func WithCompileEnvironment(environment string, overlays ...EnvironmentOverlays) CompileOption {
+	return func(o *CompileOptions) {
+		o.Environment = environment
+		for _, overlay := range overlays {
+			if o.EnvironmentOverlays == nil {
+				o.EnvironmentOverlays = make(EnvironmentOverlays, len(overlay))
+			}
+			for name, v := range overlay {
+				o.EnvironmentOverlays[name] = v
+			}
+		}
+	}
+}
+
+// WithCompileVariant selects the prompt variant to compile.
+func WithCompileVariant(variant string) CompileOption {
+	return func(o *CompileOptions) {
+		o.Variant = variant
+	}
+}
+
+// WithCompileCache caches compiled prompts in cache.
+func WithCompileCache(cache *CompileCache) CompileOption {
+	return func(o *CompileOptions) {
+		o.Cache = cache
+	}
+}
+
+// WithOnResolveError sets how skills that cannot be resolved are handled.
+// A placeholder replaces the default text of ResolveErrorPlaceholder.
+func WithOnResolveError(mode ResolveErrorMode, placeholder ...string) CompileOption {
+	return func(o *CompileOptions) {
+		o.OnResolveError = mode
+		if len(placeholder) > 0 {
+			o.ResolvePlaceholder = placeholder[0]
+		}
+	}
+}
+
 // WithSkillsCatalogFormat sets the skills catalog output format.
 func WithSkillsCatalogFormat(f CatalogFormat) CompileOption {
 	return func(o *CompileOptions) {
@@ -192,6 +340,35 @@ func NewCompileOptions(options ...CompileOption) *CompileOptions {
 	return opts
 }
 
+// resolveModel resolves the model alias of exec with the options' registry.
+func (o *CompileOptions) resolveModel(exec *ExecutionConfig) *ExecutionConfig {
+	if o == nil || o.ModelRegistry == nil {
+		return exec
+	}
+	return o.ModelRegistry.Resolve(exec, o.environment())
+}
+
+// environment returns the selected environment: the options' own, or else
+// the engine's.
+func (o *CompileOptions) environment() string {
+	if o == nil {
+		return ""
+	}
+	if o.Environment == "" && o.Engine != nil {
+		return o.Engine.config.environment
+	}
+	return o.Environment
+}
+
+// resolvePrompt applies the environment overlays and the variant selected
+// by the options.
+func (o *CompileOptions) resolvePrompt(p *Prompt) (*Prompt, error) {
+	if o == nil {
+		return p, nil
+	}
+	return p.ForEnvironment(o.environment(), o.EnvironmentOverlays).SelectVariant(o.Variant)
+}
+
 // compileEngine returns the engine from options, or creates a new default engine.
 func compileEngine(opts *CompileOptions) *Engine {
 	if opts != nil && opts.Engine != nil {
@@ -206,6 +383,10 @@ func (p *Prompt) Compile(ctx context.Context, input map[string]any, opts *Compil
 	if p == nil {
 		return "", NewCompilationError(ErrMsgCompilationFailed, nil)
 	}
+	p, err := opts.resolvePrompt(p)
+	if err != nil {
+		return "", err
+	}
 
 	// Build context data
 	data := buildCompileContext(p, input)
@@ -234,17 +415,57 @@ func (p *Prompt) CompileAgent(ctx context.Context, input map[string]any, opts *C
 	if opts == nil {
 		opts = &CompileOptions{}
 	}
+	p, err := opts.resolvePrompt(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// Conversations are appended to on every compilation, so they bypass the cache
+	if opts.Cache != nil && !opts.hasConversation() {
+		return opts.Cache.compile(ctx, p, input, opts)
+	}
+	return compileAgent(ctx, p, input, opts)
+}
 
+// compileAgent compiles an agent whose environment and variant are resolved.
+func compileAgent(ctx context.Context, p *Prompt, input map[string]any, opts *CompileOptions) (*CompiledPrompt, error) {
 	// Build context data
 	data := buildCompileContext(p, input)
 
+	// Load the conversation transcript and record the new user message
+	var transcript []Message
+	if opts.hasConversation() {
+		loaded, err := loadConversation(ctx, opts, input)
+		if err != nil {
+			return nil, err
+		}
+		transcript = loaded
+		data[ContextKeyConversation] = conversationData(transcript)
+	}
+
+	// Choose the skills for this compilation and pin locked versions
+	selected, err := selectSkills(ctx, p, input, opts)
+	if err != nil {
+		return nil, err
+	}
+	skills := opts.Lock.pinSkills(selected)
+
+	// Leave out or replace skills that cannot be resolved, per OnResolveError
+	selected, skills, warnings, err := checkSkills(ctx, selected, skills, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate catalogs and inject into context
-	skillsCatalog, err := GenerateSkillsCatalog(ctx, p.Skills, opts.Resolver, opts.SkillsCatalogFormat)
+	skillsCatalog, err := GenerateSkillsCatalog(ctx, skills, opts.Resolver, opts.SkillsCatalogFormat)
 	if err != nil {
 		// Non-fatal: empty catalog on error
 		skillsCatalog = ""
 	}
 	data[ContextKeySkills] = skillsCatalog
+	if selector := relevantSkillsSelector(skills, opts.Resolver, opts.SkillsCatalogFormat); selector != nil {
+		data[ContextKeySkillsSelector] = selector
+	}
 
 	toolsCatalog, err := GenerateToolsCatalog(p.Tools, opts.ToolsCatalogFormat)
 	if err != nil {
@@ -265,11 +486,16 @@ func (p *Prompt) CompileAgent(ctx context.Context, input map[string]any, opts *C
 		}
 	}
 
-	// Compile body
-	compiledBody, err := engine.Execute(ctx, p.Body, data)
+	// Compile body, collecting cache breakpoints of the system prompt
+	bodyCtx, bodyParts := internal.WithContentParts(ctx)
+	compiledBody, err := engine.Execute(bodyCtx, p.Body, data)
 	if err != nil {
 		return nil, NewCompileBodyError(err)
 	}
+	var systemParts []internal.ContentPartInfo
+	if bodyParts.Len() > 0 {
+		compiledBody, systemParts = internal.SplitContentParts(bodyParts.Encode(compiledBody))
+	}
 
 	// Process messages
 	var messages []CompiledMessage
@@ -280,16 +506,21 @@ func (p *Prompt) CompileAgent(ctx context.Context, input map[string]any, opts *C
 		}
 	} else {
 		// Default messages: system (compiled body) + user (input message if present)
-		messages = buildDefaultMessages(compiledBody, input)
+		messages = buildDefaultMessages(compiledBody, contentPartsFromInternal(systemParts), transcript, input)
 	}
 
 	// Build result
 	result := &CompiledPrompt{
 		Messages: messages,
+		Warnings: warnings,
 	}
+	if opts.hasConversation() {
+		result.ConversationID = opts.ConversationID
+	}
+	result.Variant = opts.Variant
 
 	if p.Execution != nil {
-		result.Execution = p.Execution.Clone()
+		result.Execution = opts.resolveModel(p.Execution.Clone())
 	}
 
 	if p.Tools != nil {
@@ -300,6 +531,12 @@ func (p *Prompt) CompileAgent(ctx context.Context, input map[string]any, opts *C
 		result.Constraints = p.Constraints.Operational.Clone()
 	}
 
+	for i := range skills {
+		result.Skills = append(result.Skills, *skills[i].Clone())
+	}
+	result.Lock = buildCompileLock(ctx, opts.Resolver, selected, skills)
+	result.Lock.PromptHash = p.Hash()
+
 	return result, nil
 }
 
@@ -311,6 +548,10 @@ func (p *Prompt) ActivateSkill(ctx context.Context, skillSlug string, input map[
 	if err != nil {
 		return nil, err
 	}
+	p, err = opts.resolvePrompt(p)
+	if err != nil {
+		return nil, err
+	}
 
 	// Find the skill ref by slug
 	var skillRef *SkillRef
@@ -323,6 +564,9 @@ func (p *Prompt) ActivateSkill(ctx context.Context, skillSlug string, input map[
 	if skillRef == nil {
 		return nil, NewSkillNotFoundError(skillSlug)
 	}
+	if opts != nil {
+		skillRef = &opts.Lock.pinSkills([]SkillRef{*skillRef})[0]
+	}
 
 	// Resolve skill body
 	var skillBody string
@@ -331,9 +575,17 @@ func (p *Prompt) ActivateSkill(ctx context.Context, skillSlug string, input map[
 	if skillRef.IsInline() {
 		skillBody = skillRef.Inline.Body
 	} else if opts != nil && opts.Resolver != nil {
-		resolved, err := opts.Resolver.ResolveSkill(ctx, skillRef.Slug)
+		resolved, _, err := ResolveSkillRef(ctx, opts.Resolver, skillRef)
 		if err != nil {
-			return nil, NewCompileSkillError(skillSlug, err)
+			if opts.OnResolveError != ResolveErrorSkip && opts.OnResolveError != ResolveErrorPlaceholder {
+				return nil, NewCompileSkillError(skillSlug, err)
+			}
+			degraded, warning, _ := degradeSkill(skillRef, err, opts.OnResolveError, opts)
+			compiled.addWarning(warning)
+			if degraded == nil {
+				return compiled, nil
+			}
+			resolved = &Prompt{Body: degraded.Inline.Body}
 		}
 		skillBody = resolved.Body
 		if resolved.Execution != nil {
@@ -376,6 +628,7 @@ func (p *Prompt) ActivateSkill(ctx context.Context, skillSlug string, input map[
 	} else if skillRef.Execution != nil {
 		compiled.Execution = skillRef.Execution.Clone()
 	}
+	compiled.Execution = opts.resolveModel(compiled.Execution)
 
 	return compiled, nil
 }
@@ -462,15 +715,18 @@ func compileMessages(ctx context.Context, engine *Engine, templates []MessageTem
 
 	for i := range templates {
 		mt := &templates[i]
-		content, err := engine.Execute(ctx, mt.Content, data)
+		partsCtx, parts := internal.WithContentParts(ctx)
+		output, err := engine.Execute(partsCtx, mt.Content, data)
 		if err != nil {
 			return nil, NewCompileMessageError(i, mt.Role, err)
 		}
+		content, contentParts := internal.SplitContentParts(parts.Encode(output))
 
 		messages = append(messages, CompiledMessage{
 			Role:    mt.Role,
-			Content: strings.TrimSpace(content),
+			Content: content,
 			Cache:   mt.Cache,
+			Parts:   contentPartsFromInternal(contentParts),
 		})
 	}
 
@@ -478,32 +734,40 @@ func compileMessages(ctx context.Context, engine *Engine, templates []MessageTem
 }
 
 // buildDefaultMessages creates default messages when no explicit messages are defined.
-func buildDefaultMessages(compiledBody string, input map[string]any) []CompiledMessage {
-	messages := make([]CompiledMessage, 0, 2)
+// systemParts holds the parts of the compiled body when it has cache breakpoints;
+// transcript holds the earlier messages of the conversation, if any.
+func buildDefaultMessages(compiledBody string, systemParts []ContentPart, transcript []Message, input map[string]any) []CompiledMessage {
+	messages := make([]CompiledMessage, 0, len(transcript)+2)
 
 	// System message from compiled body
 	if compiledBody != "" {
 		messages = append(messages, CompiledMessage{
 			Role:    RoleSystem,
 			Content: strings.TrimSpace(compiledBody),
+			Parts:   systemParts,
 		})
 	}
 
+	// Earlier turns of the conversation
+	messages = append(messages, conversationMessages(transcript)...)
+
 	// User message from input.message if present
-	if input != nil {
-		if msg, ok := input["message"]; ok {
-			if msgStr, ok := msg.(string); ok && msgStr != "" {
-				messages = append(messages, CompiledMessage{
-					Role:    RoleUser,
-					Content: msgStr,
-				})
-			}
-		}
+	if msg := inputMessage(input); msg != "" {
+		messages = append(messages, CompiledMessage{
+			Role:    RoleUser,
+			Content: msg,
+		})
 	}
 
 	return messages
 }
 
+// inputMessage returns the string input.message, or "".
+func inputMessage(input map[string]any) string {
+	msg, _ := input[InputKeyMessage].(string)
+	return msg
+}
+
 // injectSkillIntoSystemPrompt appends skill content to the system message.
 func injectSkillIntoSystemPrompt(compiled *CompiledPrompt, slug string, content string) {
 	marker := SkillInjectionMarkerStart + slug + SkillInjectionMarkerClose + "\n" +
@@ -514,6 +778,9 @@ func injectSkillIntoSystemPrompt(compiled *CompiledPrompt, slug string, content
 	for i := range compiled.Messages {
 		if compiled.Messages[i].Role == RoleSystem {
 			compiled.Messages[i].Content += "\n\n" + marker
+			if len(compiled.Messages[i].Parts) > 0 {
+				compiled.Messages[i].Parts = append(compiled.Messages[i].Parts, ContentPart{Type: ContentPartTypeText, Text: marker})
+			}
 			return
 		}
 	}
@@ -539,7 +806,8 @@ func injectSkillIntoUserContext(compiled *CompiledPrompt, slug string, content s
 }
 
 // ToOpenAIMessages converts compiled messages to OpenAI Chat Completions API format.
-// Returns a slice of message objects with "role" and "content" keys.
+// Returns a slice of message objects with "role" and "content" keys. The
+// content of a message with image or file parts is a content-part array.
 func (cp *CompiledPrompt) ToOpenAIMessages() []map[string]any {
 	if cp == nil || len(cp.Messages) == 0 {
 		return nil
@@ -547,10 +815,24 @@ func (cp *CompiledPrompt) ToOpenAIMessages() []map[string]any {
 
 	result := make([]map[string]any, 0, len(cp.Messages))
 	for _, msg := range cp.Messages {
-		result = append(result, map[string]any{
-			AttrRole:  msg.Role,
-			"content": msg.Content,
-		})
+		if len(msg.ToolResults) > 0 {
+			result = append(result, openAIToolMessages(msg.ToolResults)...)
+			continue
+		}
+		var content any = msg.Content
+		if len(msg.Parts) > 0 {
+			content = openAIContentParts(msg.Parts)
+		} else if msg.Content == "" && len(msg.ToolCalls) > 0 {
+			content = nil
+		}
+		m := map[string]any{
+			AttrRole:          msg.Role,
+			PayloadKeyContent: content,
+		}
+		if len(msg.ToolCalls) > 0 {
+			m[PayloadKeyToolCalls] = openAIToolCalls(msg.ToolCalls)
+		}
+		result = append(result, m)
 	}
 	return result
 }
@@ -558,28 +840,51 @@ func (cp *CompiledPrompt) ToOpenAIMessages() []map[string]any {
 // ToAnthropicMessages converts compiled messages to Anthropic Messages API format.
 // Returns a map with "system" (string) and "messages" (slice of role/content maps).
 // System messages are extracted and concatenated into the top-level "system" field,
-// as required by the Anthropic API.
+// as required by the Anthropic API. Image and file parts of non-system messages
+// become image and document content blocks. Cached messages and cache breakpoints
+// (prompty.cache_breakpoint) add cache_control to the block they end; the system
+// prompt then becomes a list of text blocks.
 func (cp *CompiledPrompt) ToAnthropicMessages() map[string]any {
 	if cp == nil || len(cp.Messages) == 0 {
 		return nil
 	}
 
 	var systemParts []string
+	var systemBlocks []map[string]any
+	systemCached := false
 	messages := make([]map[string]any, 0, len(cp.Messages))
 
 	for _, msg := range cp.Messages {
 		if msg.Role == RoleSystem {
 			systemParts = append(systemParts, msg.Content)
+			systemBlocks = append(systemBlocks, anthropicContentBlocks(msg)...)
+			systemCached = systemCached || msg.hasCacheBreakpoint()
 			continue
 		}
+		if len(msg.ToolResults) > 0 {
+			messages = append(messages, map[string]any{
+				AttrRole:          RoleUser,
+				PayloadKeyContent: anthropicToolResultBlocks(msg.ToolResults),
+			})
+			continue
+		}
+		var content any = msg.Content
+		if len(msg.ToolCalls) > 0 {
+			content = anthropicToolUseBlocks(msg)
+		} else if len(msg.Parts) > 0 || msg.Cache {
+			content = anthropicContentBlocks(msg)
+		}
 		messages = append(messages, map[string]any{
-			AttrRole:  msg.Role,
-			"content": msg.Content,
+			AttrRole:          msg.Role,
+			PayloadKeyContent: content,
 		})
 	}
 
 	result := make(map[string]any, 2)
-	if len(systemParts) > 0 {
+	if systemCached {
+		// Cache directives need the block form of the system prompt
+		result[RoleSystem] = systemBlocks
+	} else if len(systemParts) > 0 {
 		result[RoleSystem] = strings.Join(systemParts, "\n\n")
 	}
 	result["messages"] = messages
@@ -588,7 +893,8 @@ func (cp *CompiledPrompt) ToAnthropicMessages() map[string]any {
 
 // ToGeminiContents converts compiled messages to Gemini/Vertex AI API format.
 // System messages are returned separately in the "system_instruction" key.
-// Other messages use Gemini roles: "user" and "model" (instead of "assistant").
+// Other messages use Gemini roles: "user" and "model" (instead of "assistant"),
+// with image and file parts as inline_data or file_data parts.
 func (cp *CompiledPrompt) ToGeminiContents() map[string]any {
 	if cp == nil || len(cp.Messages) == 0 {
 		return nil
@@ -605,12 +911,22 @@ func (cp *CompiledPrompt) ToGeminiContents() map[string]any {
 
 		role := msg.Role
 		if role == RoleAssistant {
-			role = "model"
+			role = GeminiRoleModel
 		}
 
+		var parts any = []map[string]string{{PartKeyText: msg.Content}}
+		switch {
+		case len(msg.ToolResults) > 0:
+			role = RoleUser
+			parts = geminiFunctionResponseParts(msg.ToolResults)
+		case len(msg.ToolCalls) > 0:
+			parts = geminiFunctionCallParts(msg)
+		case len(msg.Parts) > 0:
+			parts = geminiContentParts(msg.Parts)
+		}
 		contents = append(contents, map[string]any{
-			AttrRole: role,
-			"parts":  []map[string]string{{"text": msg.Content}},
+			AttrRole:        role,
+			PayloadKeyParts: parts,
 		})
 	}
 
@@ -625,7 +941,9 @@ func (cp *CompiledPrompt) ToGeminiContents() map[string]any {
 }
 
 // ToProviderMessages converts compiled messages to the format required by the given provider.
-// Supported providers: "openai", "azure", "anthropic", "gemini", "google", "vertex".
+// Supported providers: "openai", "azure", "openrouter", "litellm",
+// "openai_responses", "anthropic", "bedrock", "gemini", "google", "vertex",
+// "ollama", "llamacpp".
 // Returns the provider-specific message structure, or an error for unsupported providers.
 func (cp *CompiledPrompt) ToProviderMessages(provider string) (any, error) {
 	if cp == nil {
@@ -633,7 +951,15 @@ func (cp *CompiledPrompt) ToProviderMessages(provider string) (any, error) {
 	}
 
 	switch provider {
-	case ProviderOpenAI, ProviderAzure:
+	case ProviderOpenAI, ProviderAzure, ProviderOpenRouter, ProviderLiteLLM:
+		return cp.ToOpenAIMessages(), nil
+	case ProviderOpenAIResponses:
+		return cp.ToOpenAIResponsesInput(), nil
+	case ProviderBedrock:
+		return cp.ToBedrockMessages(), nil
+	case ProviderOllama:
+		return cp.ToOllamaMessages(), nil
+	case ProviderLlamaCpp:
 		return cp.ToOpenAIMessages(), nil
 	case ProviderAnthropic:
 		return cp.ToAnthropicMessages(), nil
@@ -644,6 +970,162 @@ func (cp *CompiledPrompt) ToProviderMessages(provider string) (any, error) {
 	}
 }
 
+// ToProviderPayload builds a complete request body for the given provider by
+// combining the execution parameters, the provider-specific message layout and,
+// when tools are configured, the function definitions and tool choice.
+// If provider is empty, the execution config's effective provider is used.
+//
+// Supported providers: "openai", "azure", "mistral", "vllm", "cohere",
+// "llamacpp", "openrouter", "litellm" (OpenAI-style messages), "ollama", "openai_responses" (OpenAI Responses API),
+// "anthropic", "bedrock" (AWS Bedrock, shaped by the model family), and
+// "gemini", "google", "vertex". Azure payloads omit the model, which Azure
+// takes from the deployment in the URL (see ExecutionConfig.ToAzureOpenAI).
+// OpenAI and Azure payloads of prompts with cache breakpoints carry a
+// prompt_cache_key (see PromptCacheKey).
+func (cp *CompiledPrompt) ToProviderPayload(provider string) (map[string]any, error) {
+	if cp == nil {
+		return nil, nil
+	}
+	if provider == "" {
+		provider = cp.Execution.GetEffectiveProvider()
+	}
+
+	var payload map[string]any
+	switch provider {
+	case ProviderOpenAI, ProviderAzure, ProviderMistral, ProviderVLLM, ProviderCohere, ProviderLlamaCpp,
+		ProviderOpenRouter, ProviderLiteLLM:
+		params := map[string]func() map[string]any{
+			ProviderOpenAI:     cp.Execution.ToOpenAI,
+			ProviderOpenRouter: cp.Execution.ToOpenAI,
+			ProviderLiteLLM:    cp.Execution.ToOpenAI,
+			ProviderAzure:      cp.Execution.ToAzureOpenAI,
+			ProviderMistral:    cp.Execution.ToMistral,
+			ProviderVLLM:       cp.Execution.ToVLLM,
+			ProviderCohere:     cp.Execution.ToCohere,
+			ProviderLlamaCpp:   cp.Execution.ToLlamaCpp,
+		}
+		payload = ensurePayload(params[provider]())
+		payload[PayloadKeyMessages] = cp.ToOpenAIMessages()
+		if key := cp.PromptCacheKey(); key != "" && (provider == ProviderOpenAI || provider == ProviderAzure) {
+			payload[PayloadKeyPromptCacheKey] = key
+		}
+		if cp.Tools != nil && len(cp.Tools.Functions) > 0 {
+			tools := make([]map[string]any, 0, len(cp.Tools.Functions))
+			for _, fn := range cp.Tools.Functions {
+				tools = append(tools, fn.ToOpenAITool())
+			}
+			payload[PayloadKeyTools] = tools
+			if cp.Tools.ToolChoice != "" {
+				payload[PayloadKeyToolChoice] = cp.Tools.ToolChoice
+			}
+		}
+
+	case ProviderOpenAIResponses:
+		payload = ensurePayload(cp.Execution.ToOpenAIResponses())
+		for k, v := range cp.ToOpenAIResponsesInput() {
+			payload[k] = v
+		}
+		if key := cp.PromptCacheKey(); key != "" {
+			payload[PayloadKeyPromptCacheKey] = key
+		}
+		if cp.Tools != nil && len(cp.Tools.Functions) > 0 {
+			tools := make([]map[string]any, 0, len(cp.Tools.Functions))
+			for _, fn := range cp.Tools.Functions {
+				tools = append(tools, fn.ToOpenAIResponsesTool())
+			}
+			payload[PayloadKeyTools] = tools
+			if cp.Tools.ToolChoice != "" {
+				payload[PayloadKeyToolChoice] = cp.Tools.ToolChoice
+			}
+		}
+
+	case ProviderAnthropic:
+		payload = ensurePayload(cp.Execution.ToAnthropic())
+		for k, v := range cp.ToAnthropicMessages() {
+			payload[k] = v
+		}
+		if cp.Tools != nil && len(cp.Tools.Functions) > 0 {
+			addAnthropicTools(payload, cp.Tools)
+		}
+
+	case ProviderOllama:
+		payload = ensurePayload(cp.Execution.ToOllama())
+		payload[PayloadKeyMessages] = cp.ToOllamaMessages()
+		if cp.Tools != nil && len(cp.Tools.Functions) > 0 {
+			tools := make([]map[string]any, 0, len(cp.Tools.Functions))
+			for _, fn := range cp.Tools.Functions {
+				tools = append(tools, fn.ToOpenAITool())
+			}
+			payload[PayloadKeyTools] = tools
+		}
+
+	case ProviderBedrock:
+		payload = ensurePayload(cp.Execution.ToBedrock())
+		for k, v := range cp.ToBedrockMessages() {
+			payload[k] = v
+		}
+		if cp.Tools != nil && len(cp.Tools.Functions) > 0 {
+			switch family, name, _ := bedrockModelParts(cp.Execution.GetModel()); {
+			case family == BedrockFamilyAnthropic:
+				addAnthropicTools(payload, cp.Tools)
+			case family == BedrockFamilyAmazon && strings.HasPrefix(name, BedrockTitanPrefix):
+				// Titan text models have no tool use
+			default:
+				payload[BedrockKeyToolConfig] = bedrockToolConfig(cp.Tools)
+			}
+		}
+
+	case ProviderGoogle, ProviderGemini, ProviderVertex:
+		payload = ensurePayload(cp.Execution.ToGemini())
+		for k, v := range cp.ToGeminiContents() {
+			payload[k] = v
+		}
+		if cp.Tools != nil && len(cp.Tools.Functions) > 0 {
+			decls := make([]map[string]any, 0, len(cp.Tools.Functions))
+			for _, fn := range cp.Tools.Functions {
+				decl := map[string]any{AttrName: fn.Name}
+				if fn.Description != "" {
+					decl[SchemaKeyDescription] = fn.Description
+				}
+				if fn.Parameters != nil {
+					decl["parameters"] = copySchema(fn.Parameters)
+				}
+				decls = append(decls, decl)
+			}
+			payload[PayloadKeyTools] = []map[string]any{{PayloadKeyFunctionDeclarations: decls}}
+		}
+
+	default:
+		return nil, NewProviderMessageError(provider)
+	}
+
+	return payload, nil
+}
+
+// addAnthropicTools adds Anthropic tool definitions and tool choice to a
+// payload.
+func addAnthropicTools(payload map[string]any, config *ToolsConfig) {
+	tools := make([]map[string]any, 0, len(config.Functions))
+	for _, fn := range config.Functions {
+		tools = append(tools, fn.ToAnthropicTool())
+	}
+	payload[PayloadKeyTools] = tools
+	if choice := config.ToolChoice; choice != "" {
+		if choice == ToolChoiceRequired {
+			choice = ToolChoiceAnthropicAny
+		}
+		payload[PayloadKeyToolChoice] = map[string]any{PayloadKeyToolChoiceType: choice}
+	}
+}
+
+// ensurePayload returns m, or a new map if m is nil.
+func ensurePayload(m map[string]any) map[string]any {
+	if m == nil {
+		return make(map[string]any)
+	}
+	return m
+}
+
 // AgentDryRun validates all references and templates in an agent document without producing output.
 // It collects ALL issues rather than stopping at the first error, making it ideal for
 // pre-flight checks before compilation.
@@ -711,7 +1193,7 @@ func (p *Prompt) AgentDryRun(ctx context.Context, opts *CompileOptions) *AgentDr
 				continue
 			}
 
-			_, err := opts.Resolver.ResolveSkill(ctx, skill.Slug)
+			_, _, err := ResolveSkillRef(ctx, opts.Resolver, skill)
 			if err != nil {
 				result.Issues = append(result.Issues, AgentDryRunIssue{
 					Category: AgentDryRunCategorySkill,
@@ -0,0 +1,62 @@
+package prompty
+
+import "github.com/itsatony/go-prompty/v2/internal"
+
+// ToOllamaMessages converts compiled messages to Ollama /api/chat format.
+// Messages have "role" and "content"; base64 data URL images go in the
+// message's "images" list (Ollama takes no image URLs, so other images are
+// dropped), tool calls carry object arguments and tool results become one
+// "tool" message per result with the tool_name.
+func (cp *CompiledPrompt) ToOllamaMessages() []map[string]any {
+	if cp == nil || len(cp.Messages) == 0 {
+		return nil
+	}
+
+	result := make([]map[string]any, 0, len(cp.Messages))
+	for _, msg := range cp.Messages {
+		if len(msg.ToolResults) > 0 {
+			for _, r := range msg.ToolResults {
+				result = append(result, map[string]any{
+					AttrRole:          RoleTool,
+					PayloadKeyContent: r.Content,
+					OllamaKeyToolName: r.Name,
+				})
+			}
+			continue
+		}
+
+		m := map[string]any{
+			AttrRole:          msg.Role,
+			PayloadKeyContent: msg.Content,
+		}
+		if images := ollamaImages(msg.Parts); len(images) > 0 {
+			m[OllamaKeyImages] = images
+		}
+		if len(msg.ToolCalls) > 0 {
+			calls := make([]map[string]any, len(msg.ToolCalls))
+			for i, call := range msg.ToolCalls {
+				calls[i] = map[string]any{PayloadKeyFunction: map[string]any{
+					PartKeyName:         call.Name,
+					PayloadKeyArguments: toolArguments(call),
+				}}
+			}
+			m[PayloadKeyToolCalls] = calls
+		}
+		result = append(result, m)
+	}
+	return result
+}
+
+// ollamaImages returns the base64 data of the data URL image parts.
+func ollamaImages(parts []ContentPart) []string {
+	var images []string
+	for _, p := range parts {
+		if p.Type != ContentPartTypeImage {
+			continue
+		}
+		if _, data, ok := internal.ParseDataURL(p.URL); ok {
+			images = append(images, data)
+		}
+	}
+	return images
+}
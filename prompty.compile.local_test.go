@@ -0,0 +1,47 @@
+package prompty
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompiledPrompt_ToOllamaMessages(t *testing.T) {
+	compiled := &CompiledPrompt{
+		Messages: []CompiledMessage{
+			{Role: RoleSystem, Content: "Describe images."},
+			{Role: RoleUser, Content: "What is this?", Parts: []ContentPart{
+				{Type: ContentPartTypeText, Text: "What is this?"},
+				{Type: ContentPartTypeImage, URL: "data:image/png;base64,iVBORw0KGgo="},
+				{Type: ContentPartTypeImage, URL: "https://example.com/remote.png"},
+			}},
+		},
+		Execution: &ExecutionConfig{Model: "llava:13b"},
+		Tools:     &ToolsConfig{Functions: []*FunctionDef{{Name: "zoom"}}},
+	}
+	compiled, err := compiled.WithToolResults([]ToolResult{
+		{CallID: "c1", Name: "zoom", Arguments: map[string]any{"factor": 2}, Content: "a cat"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []map[string]any{
+		{AttrRole: RoleSystem, PayloadKeyContent: "Describe images."},
+		{AttrRole: RoleUser, PayloadKeyContent: "What is this?", OllamaKeyImages: []string{"iVBORw0KGgo="}},
+		{AttrRole: RoleAssistant, PayloadKeyContent: "", PayloadKeyToolCalls: []map[string]any{
+			{PayloadKeyFunction: map[string]any{PartKeyName: "zoom", PayloadKeyArguments: map[string]any{"factor": 2}}},
+		}},
+		{AttrRole: RoleTool, PayloadKeyContent: "a cat", OllamaKeyToolName: "zoom"},
+	}, compiled.ToOllamaMessages())
+
+	payload, err := compiled.ToProviderPayload("")
+	require.NoError(t, err)
+	assert.Equal(t, "llava:13b", payload[ParamKeyModel])
+	assert.Equal(t, false, payload[ParamKeyStream])
+	assert.Len(t, payload[PayloadKeyMessages], 4)
+	assert.Len(t, payload[PayloadKeyTools], 1)
+
+	payload, err = compiled.ToProviderPayload(ProviderLlamaCpp)
+	require.NoError(t, err)
+	assert.Len(t, payload[PayloadKeyMessages], 4)
+}
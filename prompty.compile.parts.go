@@ -0,0 +1,186 @@
+package prompty
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// contentPartsFromInternal converts extracted content parts to ContentParts.
+func contentPartsFromInternal(parts []internal.ContentPartInfo) []ContentPart {
+	if len(parts) == 0 {
+		return nil
+	}
+	result := make([]ContentPart, len(parts))
+	for i, p := range parts {
+		result[i] = ContentPart(p)
+	}
+	return result
+}
+
+// openAIContentParts converts parts to OpenAI Chat Completions content parts:
+// text, image_url and file.
+func openAIContentParts(parts []ContentPart) []map[string]any {
+	result := make([]map[string]any, 0, len(parts))
+	for _, p := range parts {
+		switch p.Type {
+		case ContentPartTypeImage:
+			image := map[string]any{PartKeyURL: p.URL}
+			if p.Detail != "" {
+				image[PartKeyDetail] = p.Detail
+			}
+			result = append(result, map[string]any{PartKeyType: PartTypeOpenAIImageURL, PartKeyImageURL: image})
+		case ContentPartTypeFile:
+			file := make(map[string]any, 3)
+			if p.FileID != "" {
+				file[PartKeyFileID] = p.FileID
+			}
+			if _, _, ok := internal.ParseDataURL(p.URL); ok {
+				file[PartKeyFileData] = p.URL
+			}
+			if p.Name != "" {
+				file[PartKeyFilename] = p.Name
+			}
+			result = append(result, map[string]any{PartKeyType: ContentPartTypeFile, PartKeyFile: file})
+		default:
+			result = append(result, map[string]any{PartKeyType: ContentPartTypeText, PartKeyText: p.Text})
+		}
+	}
+	return result
+}
+
+// anthropicContentBlocks converts a message to Anthropic content blocks:
+// text, image and document. A cached message marks its last block with
+// cache_control, a cache breakpoint the block before it.
+func anthropicContentBlocks(msg CompiledMessage) []map[string]any {
+	parts := msg.Parts
+	if len(parts) == 0 {
+		parts = []ContentPart{{Type: ContentPartTypeText, Text: msg.Content}}
+	}
+
+	result := make([]map[string]any, 0, len(parts))
+	for _, p := range parts {
+		var block map[string]any
+		switch p.Type {
+		case ContentPartTypeImage:
+			block = map[string]any{PartKeyType: PartTypeAnthropicImage, PartKeySource: anthropicSource(p)}
+		case ContentPartTypeFile:
+			block = map[string]any{PartKeyType: PartTypeAnthropicDoc, PartKeySource: anthropicSource(p)}
+			if p.Name != "" {
+				block[PartKeyTitle] = p.Name
+			}
+		default:
+			block = map[string]any{PartKeyType: ContentPartTypeText, PartKeyText: p.Text}
+		}
+		if p.Cache {
+			block[PartKeyCacheControl] = anthropicCacheControl()
+		}
+		result = append(result, block)
+	}
+	if msg.Cache {
+		result[len(result)-1][PartKeyCacheControl] = anthropicCacheControl()
+	}
+	return result
+}
+
+// anthropicCacheControl returns the cache_control of a cached block.
+func anthropicCacheControl() map[string]any {
+	return map[string]any{PartKeyType: CacheControlEphemeral}
+}
+
+// anthropicSource returns the source of an Anthropic image or document
+// block: base64 data, a files API ID or a URL.
+func anthropicSource(p ContentPart) map[string]any {
+	if mediaType, data, ok := internal.ParseDataURL(p.URL); ok {
+		return map[string]any{PartKeyType: PartSourceTypeBase64, PartKeyMediaType: mediaType, PartKeyData: data}
+	}
+	if p.FileID != "" {
+		return map[string]any{PartKeyType: PartSourceTypeFile, PartKeyFileID: p.FileID}
+	}
+	return map[string]any{PartKeyType: PartSourceTypeURL, PartKeyURL: p.URL}
+}
+
+// geminiContentParts converts parts to Gemini parts: text, inline_data for
+// data URLs and file_data for URLs and file IDs.
+func geminiContentParts(parts []ContentPart) []map[string]any {
+	result := make([]map[string]any, 0, len(parts))
+	for _, p := range parts {
+		if p.Type == ContentPartTypeText {
+			result = append(result, map[string]any{PartKeyText: p.Text})
+			continue
+		}
+		if mediaType, data, ok := internal.ParseDataURL(p.URL); ok {
+			result = append(result, map[string]any{
+				PartKeyInlineData: map[string]any{PartKeyMimeType: mediaType, PartKeyData: data},
+			})
+			continue
+		}
+		uri := p.URL
+		if uri == "" {
+			uri = p.FileID
+		}
+		fileData := map[string]any{PartKeyFileURI: uri}
+		if p.MediaType != "" {
+			fileData[PartKeyMimeType] = p.MediaType
+		}
+		result = append(result, map[string]any{PartKeyGeminiFileData: fileData})
+	}
+	return result
+}
+
+// hasCacheBreakpoint reports whether the message is cached or holds a cache
+// breakpoint.
+func (m CompiledMessage) hasCacheBreakpoint() bool {
+	if m.Cache {
+		return true
+	}
+	for _, p := range m.Parts {
+		if p.Cache {
+			return true
+		}
+	}
+	return false
+}
+
+// PromptCacheKey returns a key identifying the cached prefix of the prompt:
+// the messages and parts up to the last cache breakpoint (a cached message or
+// prompty.cache_breakpoint). Prompts sharing that prefix share the key, which
+// ToProviderPayload sends to OpenAI as prompt_cache_key to route requests to
+// the same cache. It returns "" when the prompt has no cache breakpoint.
+func (cp *CompiledPrompt) PromptCacheKey() string {
+	if cp == nil {
+		return ""
+	}
+
+	h := sha256.New()
+	key := ""
+	sum := func() string {
+		return hex.EncodeToString(h.Sum(nil)[:PromptCacheKeyBytes])
+	}
+	for _, msg := range cp.Messages {
+		writeCacheKeyField(h, msg.Role)
+		if len(msg.Parts) == 0 {
+			writeCacheKeyField(h, msg.Content)
+		}
+		for _, p := range msg.Parts {
+			writeCacheKeyField(h, p.Type, p.Text, p.URL, p.FileID, p.Name)
+			if p.Cache {
+				key = sum()
+			}
+		}
+		if msg.Cache {
+			key = sum()
+		}
+	}
+	return key
+}
+
+// writeCacheKeyField writes null-terminated fields to h.
+func writeCacheKeyField(h hash.Hash, fields ...string) {
+	for _, field := range fields {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+}
@@ -0,0 +1,160 @@
+package prompty
+
+import (
+	"context"
+)
+
+// ResolveErrorMode selects how compilation handles skills the
+// DocumentResolver cannot resolve.
+type ResolveErrorMode string
+
+const (
+	// ResolveErrorDefault keeps unresolvable skills in the catalog without
+	// a description and fails ActivateSkill for them, recording a
+	// CompileWarningSkillUnresolved warning for each.
+	ResolveErrorDefault ResolveErrorMode = ""
+	// ResolveErrorFail fails the compilation when a skill cannot be resolved.
+	ResolveErrorFail ResolveErrorMode = "fail"
+	// ResolveErrorSkip leaves unresolvable skills out of the catalog and
+	// CompiledPrompt.Skills, and ActivateSkill injects nothing for them,
+	// recording a CompileWarningSkillSkipped warning for each.
+	ResolveErrorSkip ResolveErrorMode = "skip"
+	// ResolveErrorPlaceholder replaces unresolvable skills with an inline
+	// skill whose description and body are CompileOptions.ResolvePlaceholder,
+	// recording a CompileWarningSkillPlaceholder warning for each.
+	ResolveErrorPlaceholder ResolveErrorMode = "placeholder"
+)
+
+// DefaultResolvePlaceholder stands in for unresolvable skills under
+// ResolveErrorPlaceholder when CompileOptions.ResolvePlaceholder is empty.
+const DefaultResolvePlaceholder = "(unavailable)"
+
+// Compile warning codes
+const (
+	CompileWarningSkillUnresolved  = "skill_unresolved"
+	CompileWarningSkillSkipped     = "skill_skipped"
+	CompileWarningSkillPlaceholder = "skill_placeholder"
+)
+
+// CompileWarning describes a problem a compilation recovered from, such as
+// a skill the DocumentResolver could not resolve.
+type CompileWarning struct {
+	// Code identifies the kind of problem (CompileWarningSkillUnresolved,
+	// CompileWarningSkillSkipped or CompileWarningSkillPlaceholder).
+	Code string `json:"code"`
+	// Slug is the affected skill.
+	Slug string `json:"slug"`
+	// Constraint is the version constraint of the skill reference.
+	Constraint string `json:"constraint,omitempty"`
+	// Message is the resolution error.
+	Message string `json:"message"`
+}
+
+// isValidResolveErrorMode reports whether mode is a known ResolveErrorMode.
+func isValidResolveErrorMode(mode ResolveErrorMode) bool {
+	switch mode {
+	case ResolveErrorDefault, ResolveErrorFail, ResolveErrorSkip, ResolveErrorPlaceholder:
+		return true
+	}
+	return false
+}
+
+// resolveErrorMode returns the options' mode, validated.
+func (o *CompileOptions) resolveErrorMode() (ResolveErrorMode, error) {
+	if o == nil {
+		return ResolveErrorDefault, nil
+	}
+	if !isValidResolveErrorMode(o.OnResolveError) {
+		return "", NewCompilationError(ErrMsgCompileResolveErrorMode+": "+string(o.OnResolveError), nil)
+	}
+	return o.OnResolveError, nil
+}
+
+// resolvePlaceholder returns the text standing in for unresolvable skills.
+func (o *CompileOptions) resolvePlaceholder() string {
+	if o == nil || o.ResolvePlaceholder == "" {
+		return DefaultResolvePlaceholder
+	}
+	return o.ResolvePlaceholder
+}
+
+// checkSkills resolves the pinned skills and applies the options'
+// ResolveErrorMode to those that fail, returning the selected and pinned
+// skills to compile with, still in step, and the warnings. Without a
+// resolver, skills are returned as is.
+func checkSkills(ctx context.Context, selected, pinned []SkillRef, opts *CompileOptions) ([]SkillRef, []SkillRef, []CompileWarning, error) {
+	mode, err := opts.resolveErrorMode()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if opts.Resolver == nil {
+		return selected, pinned, nil, nil
+	}
+
+	var warnings []CompileWarning
+	keptSelected := make([]SkillRef, 0, len(selected))
+	keptPinned := make([]SkillRef, 0, len(pinned))
+	for i := range pinned {
+		ref := &pinned[i]
+		if !ref.IsInline() {
+			if _, _, err := ResolveSkillRef(ctx, opts.Resolver, ref); err != nil {
+				degraded, warning, err := degradeSkill(ref, err, mode, opts)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				warnings = append(warnings, warning)
+				if degraded == nil {
+					continue
+				}
+				ref = degraded
+			}
+		}
+		keptSelected = append(keptSelected, selected[i])
+		keptPinned = append(keptPinned, *ref)
+	}
+	return keptSelected, keptPinned, warnings, nil
+}
+
+// degradeSkill applies mode to a skill that failed to resolve with err. It
+// returns the skill to use instead (nil to leave it out) and the warning,
+// or the compilation error under ResolveErrorFail.
+func degradeSkill(ref *SkillRef, err error, mode ResolveErrorMode, opts *CompileOptions) (*SkillRef, CompileWarning, error) {
+	slug := ref.GetSlug()
+	warning := CompileWarning{
+		Code:       CompileWarningSkillUnresolved,
+		Slug:       slug,
+		Constraint: ref.GetVersion(),
+		Message:    err.Error(),
+	}
+
+	switch mode {
+	case ResolveErrorFail:
+		return nil, warning, NewCompileSkillResolveError(slug, err)
+	case ResolveErrorSkip:
+		warning.Code = CompileWarningSkillSkipped
+		return nil, warning, nil
+	case ResolveErrorPlaceholder:
+		warning.Code = CompileWarningSkillPlaceholder
+		placeholder := opts.resolvePlaceholder()
+		return &SkillRef{
+			Injection: ref.Injection,
+			Inline: &InlineSkill{
+				Slug:        slug,
+				Description: placeholder,
+				Body:        placeholder,
+			},
+		}, warning, nil
+	}
+	return ref, warning, nil
+}
+
+// addWarning appends warning to the compiled prompt unless it already
+// holds the same warning.
+func (cp *CompiledPrompt) addWarning(warning CompileWarning) {
+	for _, w := range cp.Warnings {
+		if w == warning {
+			return
+		}
+	}
+	cp.Warnings = append(cp.Warnings, warning)
+}
@@ -0,0 +1,108 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func degradedAgent() (*Prompt, *MapDocumentResolver) {
+	agent := &Prompt{
+		Name:        "degraded-agent",
+		Description: "Agent with a missing skill",
+		Type:        DocumentTypeAgent,
+		Skills: []SkillRef{
+			{Slug: "search"},
+			{Slug: "missing@^2"},
+			{Inline: &InlineSkill{Slug: "greet", Description: "Greets", Body: "Say hi."}},
+		},
+		Body: `Agent.{~prompty.skills_catalog /~}`,
+	}
+	resolver := NewMapDocumentResolver()
+	resolver.AddSkill("search", &Prompt{Name: "search", Description: "Searches", Type: DocumentTypeSkill, Body: "Search."})
+	return agent, resolver
+}
+
+func TestPrompt_CompileAgent_OnResolveError(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("default keeps the skill without description", func(t *testing.T) {
+		agent, resolver := degradedAgent()
+		compiled, err := agent.CompileAgent(ctx, nil, &CompileOptions{Resolver: resolver})
+		require.NoError(t, err)
+		assert.Contains(t, compiled.Messages[0].Content, "- **missing**\n")
+		assert.Len(t, compiled.Skills, 3)
+		require.Len(t, compiled.Warnings, 1)
+		assert.Equal(t, CompileWarningSkillUnresolved, compiled.Warnings[0].Code)
+		assert.Equal(t, "missing", compiled.Warnings[0].Slug)
+		assert.Equal(t, "^2", compiled.Warnings[0].Constraint)
+		assert.NotEmpty(t, compiled.Warnings[0].Message)
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		agent, resolver := degradedAgent()
+		_, err := agent.CompileAgent(ctx, nil, NewCompileOptions(WithResolver(resolver), WithOnResolveError(ResolveErrorFail)))
+		assert.ErrorContains(t, err, ErrMsgCompileSkillUnresolved)
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		agent, resolver := degradedAgent()
+		compiled, err := agent.CompileAgent(ctx, nil, NewCompileOptions(WithResolver(resolver), WithOnResolveError(ResolveErrorSkip)))
+		require.NoError(t, err)
+		assert.NotContains(t, compiled.Messages[0].Content, "missing")
+		assert.Contains(t, compiled.Messages[0].Content, "**search**: Searches")
+		assert.Contains(t, compiled.Messages[0].Content, "**greet**: Greets")
+		require.Len(t, compiled.Skills, 2)
+		assert.Equal(t, "search", compiled.Skills[0].GetSlug())
+		assert.Equal(t, "greet", compiled.Skills[1].GetSlug())
+		require.Len(t, compiled.Warnings, 1)
+		assert.Equal(t, CompileWarningSkillSkipped, compiled.Warnings[0].Code)
+	})
+
+	t.Run("placeholder", func(t *testing.T) {
+		agent, resolver := degradedAgent()
+		compiled, err := agent.CompileAgent(ctx, nil, NewCompileOptions(WithResolver(resolver), WithOnResolveError(ResolveErrorPlaceholder, "temporarily unavailable")))
+		require.NoError(t, err)
+		assert.Contains(t, compiled.Messages[0].Content, "**missing**: temporarily unavailable")
+		require.Len(t, compiled.Skills, 3)
+		assert.Equal(t, "missing", compiled.Skills[1].GetSlug())
+		require.Len(t, compiled.Warnings, 1)
+		assert.Equal(t, CompileWarningSkillPlaceholder, compiled.Warnings[0].Code)
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		agent, resolver := degradedAgent()
+		_, err := agent.CompileAgent(ctx, nil, &CompileOptions{Resolver: resolver, OnResolveError: "ignore"})
+		assert.ErrorContains(t, err, ErrMsgCompileResolveErrorMode)
+	})
+}
+
+func TestPrompt_ActivateSkill_OnResolveError(t *testing.T) {
+	ctx := context.Background()
+	agent, resolver := degradedAgent()
+
+	_, err := agent.ActivateSkill(ctx, "missing", nil, &CompileOptions{Resolver: resolver})
+	assert.ErrorContains(t, err, ErrMsgCompileSkillFailed)
+
+	compiled, err := agent.ActivateSkill(ctx, "missing", nil, &CompileOptions{Resolver: resolver, OnResolveError: ResolveErrorSkip})
+	require.NoError(t, err)
+	assert.NotContains(t, compiled.Messages[0].Content, SkillInjectionMarkerStart)
+	assert.Len(t, compiled.Warnings, 1)
+
+	compiled, err = agent.ActivateSkill(ctx, "missing", nil, &CompileOptions{Resolver: resolver, OnResolveError: ResolveErrorPlaceholder})
+	require.NoError(t, err)
+	assert.Contains(t, compiled.Messages[0].Content, SkillInjectionMarkerStart+"missing")
+	assert.Contains(t, compiled.Messages[0].Content, DefaultResolvePlaceholder)
+	assert.Len(t, compiled.Warnings, 1)
+}
+
+func TestCompileCache_SkipsDegradedPrompts(t *testing.T) {
+	agent, resolver := degradedAgent()
+	cache := NewCompileCache(CompileCacheConfig{})
+	compiled, err := agent.CompileAgent(context.Background(), nil, &CompileOptions{Resolver: resolver, Cache: cache, OnResolveError: ResolveErrorSkip})
+	require.NoError(t, err)
+	assert.Len(t, compiled.Warnings, 1)
+	assert.Equal(t, 0, cache.Len())
+}
@@ -0,0 +1,111 @@
+package prompty
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// ToOpenAIResponsesInput converts compiled messages to the OpenAI Responses
+// API layout. Returns a map with "instructions" (the system messages joined)
+// and "input" (the input items). Tool calls become function_call items, tool
+// results function_call_output items, and image and file parts input_image
+// and input_file content.
+func (cp *CompiledPrompt) ToOpenAIResponsesInput() map[string]any {
+	if cp == nil || len(cp.Messages) == 0 {
+		return nil
+	}
+
+	var instructions []string
+	items := make([]map[string]any, 0, len(cp.Messages))
+
+	for _, msg := range cp.Messages {
+		switch {
+		case msg.Role == RoleSystem:
+			instructions = append(instructions, msg.Content)
+		case len(msg.ToolResults) > 0:
+			for _, r := range msg.ToolResults {
+				items = append(items, map[string]any{
+					PartKeyType:        ResponsesTypeFunctionOutput,
+					ResponsesKeyCallID: r.CallID,
+					ResponsesKeyOutput: r.Content,
+				})
+			}
+		case len(msg.ToolCalls) > 0:
+			if msg.Content != "" {
+				items = append(items, map[string]any{AttrRole: msg.Role, PayloadKeyContent: msg.Content})
+			}
+			items = append(items, responsesFunctionCalls(msg.ToolCalls)...)
+		case len(msg.Parts) > 0:
+			items = append(items, map[string]any{
+				AttrRole:          msg.Role,
+				PayloadKeyContent: responsesContentParts(msg.Role, msg.Parts),
+			})
+		default:
+			items = append(items, map[string]any{AttrRole: msg.Role, PayloadKeyContent: msg.Content})
+		}
+	}
+
+	result := make(map[string]any, 2)
+	if len(instructions) > 0 {
+		result[ResponsesKeyInstructions] = strings.Join(instructions, "\n\n")
+	}
+	result[ResponsesKeyInput] = items
+	return result
+}
+
+// responsesFunctionCalls converts tool calls to Responses API function_call
+// items, whose arguments are a JSON string.
+func responsesFunctionCalls(calls []ToolCall) []map[string]any {
+	items := make([]map[string]any, len(calls))
+	for i, call := range calls {
+		args, err := json.Marshal(toolArguments(call))
+		if err != nil {
+			args = []byte(toolArgumentsEmpty)
+		}
+		items[i] = map[string]any{
+			PartKeyType:         ResponsesTypeFunctionCall,
+			ResponsesKeyCallID:  call.ID,
+			PartKeyName:         call.Name,
+			PayloadKeyArguments: string(args),
+		}
+	}
+	return items
+}
+
+// responsesContentParts converts content parts to Responses API content.
+// Assistant text is output_text; everything else is input content.
+func responsesContentParts(role string, parts []ContentPart) []map[string]any {
+	textType := ResponsesTypeInputText
+	if role == RoleAssistant {
+		textType = ResponsesTypeOutputText
+	}
+
+	result := make([]map[string]any, 0, len(parts))
+	for _, p := range parts {
+		switch p.Type {
+		case ContentPartTypeImage:
+			image := map[string]any{PartKeyType: ResponsesTypeInputImage, ResponsesKeyImageURL: p.URL}
+			if p.Detail != "" {
+				image[PartKeyDetail] = p.Detail
+			}
+			result = append(result, image)
+		case ContentPartTypeFile:
+			file := map[string]any{PartKeyType: ResponsesTypeInputFile}
+			if p.FileID != "" {
+				file[PartKeyFileID] = p.FileID
+			}
+			if _, _, ok := internal.ParseDataURL(p.URL); ok {
+				file[PartKeyFileData] = p.URL
+			}
+			if p.Name != "" {
+				file[PartKeyFilename] = p.Name
+			}
+			result = append(result, file)
+		default:
+			result = append(result, map[string]any{PartKeyType: textType, PartKeyText: p.Text})
+		}
+	}
+	return result
+}
@@ -0,0 +1,47 @@
+package prompty
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompiledPrompt_ToOpenAIResponsesInput(t *testing.T) {
+	compiled := &CompiledPrompt{
+		Messages: []CompiledMessage{
+			{Role: RoleSystem, Content: "Be brief."},
+			{Role: RoleSystem, Content: "Be kind."},
+			{Role: RoleUser, Parts: []ContentPart{
+				{Type: ContentPartTypeText, Text: "What is this?"},
+				{Type: ContentPartTypeImage, URL: "https://example.com/cat.png", Detail: "low"},
+				{Type: ContentPartTypeFile, FileID: "file-1", Name: "notes.pdf"},
+			}},
+			{Role: RoleAssistant, Content: "A cat."},
+		},
+	}
+	compiled, err := compiled.WithToolResults([]ToolResult{
+		{CallID: "call_1", Name: "search", Arguments: map[string]any{"q": "cat"}, Content: "3 hits"},
+	})
+	require.NoError(t, err)
+
+	result := compiled.ToOpenAIResponsesInput()
+	assert.Equal(t, "Be brief.\n\nBe kind.", result[ResponsesKeyInstructions])
+	assert.Equal(t, []map[string]any{
+		{AttrRole: RoleUser, PayloadKeyContent: []map[string]any{
+			{PartKeyType: ResponsesTypeInputText, PartKeyText: "What is this?"},
+			{PartKeyType: ResponsesTypeInputImage, ResponsesKeyImageURL: "https://example.com/cat.png", PartKeyDetail: "low"},
+			{PartKeyType: ResponsesTypeInputFile, PartKeyFileID: "file-1", PartKeyFilename: "notes.pdf"},
+		}},
+		{AttrRole: RoleAssistant, PayloadKeyContent: "A cat."},
+		{PartKeyType: ResponsesTypeFunctionCall, ResponsesKeyCallID: "call_1", PartKeyName: "search", PayloadKeyArguments: `{"q":"cat"}`},
+		{PartKeyType: ResponsesTypeFunctionOutput, ResponsesKeyCallID: "call_1", ResponsesKeyOutput: "3 hits"},
+	}, result[ResponsesKeyInput])
+
+	var nilPrompt *CompiledPrompt
+	assert.Nil(t, nilPrompt.ToOpenAIResponsesInput())
+
+	messages, err := compiled.ToProviderMessages(ProviderOpenAIResponses)
+	require.NoError(t, err)
+	assert.Equal(t, result, messages)
+}
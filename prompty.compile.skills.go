@@ -0,0 +1,85 @@
+package prompty
+
+import (
+	"context"
+)
+
+// SkillSelector chooses the skills an agent exposes for one compilation.
+// CompileAgent passes the agent's declared skills and uses the returned
+// subset for the skills catalog, so agents declaring dozens of skills need
+// not list all of them in every system prompt.
+type SkillSelector interface {
+	// SelectSkills returns the skills to use for input, in the order they
+	// should appear. resolver is CompileOptions.Resolver and may be nil.
+	SelectSkills(ctx context.Context, input map[string]any, skills []SkillRef, resolver DocumentResolver) ([]SkillRef, error)
+}
+
+// SkillSelectorFunc adapts a function to the SkillSelector interface.
+type SkillSelectorFunc func(ctx context.Context, input map[string]any, skills []SkillRef, resolver DocumentResolver) ([]SkillRef, error)
+
+// SelectSkills calls the function.
+func (f SkillSelectorFunc) SelectSkills(ctx context.Context, input map[string]any, skills []SkillRef, resolver DocumentResolver) ([]SkillRef, error) {
+	return f(ctx, input, skills, resolver)
+}
+
+// BudgetSkillSelector selects skills in declaration order while the
+// estimated tokens of their bodies fit a ceiling. Skills that do not fit
+// are skipped, so later smaller skills may still be selected. Skills whose
+// body cannot be resolved count as zero tokens.
+type BudgetSkillSelector struct {
+	maxTokens int
+}
+
+// NewBudgetSkillSelector creates a selector admitting skill bodies up to
+// maxTokens estimated tokens in total.
+func NewBudgetSkillSelector(maxTokens int) *BudgetSkillSelector {
+	return &BudgetSkillSelector{maxTokens: maxTokens}
+}
+
+// MaxTokens returns the token ceiling.
+func (s *BudgetSkillSelector) MaxTokens() int {
+	return s.maxTokens
+}
+
+// SelectSkills returns the skills fitting the token ceiling.
+func (s *BudgetSkillSelector) SelectSkills(ctx context.Context, input map[string]any, skills []SkillRef, resolver DocumentResolver) ([]SkillRef, error) {
+	selected := make([]SkillRef, 0, len(skills))
+	used := 0
+	for i := range skills {
+		tokens := EstimateTokens(skillBody(ctx, &skills[i], resolver)).EstimatedGeneric
+		if used+tokens > s.maxTokens {
+			continue
+		}
+		used += tokens
+		selected = append(selected, skills[i])
+	}
+	return selected, nil
+}
+
+// skillBody returns the body of a skill reference: the inline body, or the
+// resolved skill's body. It returns "" when the skill cannot be resolved.
+func skillBody(ctx context.Context, ref *SkillRef, resolver DocumentResolver) string {
+	if ref.IsInline() {
+		return ref.Inline.Body
+	}
+	if resolver == nil {
+		return ""
+	}
+	resolved, _, err := ResolveSkillRef(ctx, resolver, ref)
+	if err != nil || resolved == nil {
+		return ""
+	}
+	return resolved.Body
+}
+
+// selectSkills applies the configured skill selector to the agent's skills.
+func selectSkills(ctx context.Context, p *Prompt, input map[string]any, opts *CompileOptions) ([]SkillRef, error) {
+	if opts.SkillSelector == nil || len(p.Skills) == 0 {
+		return p.Skills, nil
+	}
+	selected, err := opts.SkillSelector.SelectSkills(ctx, input, p.Skills, opts.Resolver)
+	if err != nil {
+		return nil, NewCompilationError(ErrMsgCompileSkillSelection, err)
+	}
+	return selected, nil
+}
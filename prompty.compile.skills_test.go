@@ -0,0 +1,100 @@
+package prompty
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func skillSlugs(skills []SkillRef) []string {
+	slugs := make([]string, len(skills))
+	for i := range skills {
+		slugs[i] = skills[i].GetSlug()
+	}
+	return slugs
+}
+
+func TestBudgetSkillSelector(t *testing.T) {
+	ctx := context.Background()
+	resolver := NewMapDocumentResolver()
+	resolver.AddSkill("large", &Prompt{Name: "large", Body: strings.Repeat("word ", 400)})
+	resolver.AddSkill("small", &Prompt{Name: "small", Body: "Short body"})
+
+	skills := []SkillRef{
+		{Slug: "small"},
+		{Slug: "large"},
+		{Inline: &InlineSkill{Slug: "inline", Body: "Inline body text"}},
+		{Slug: "missing"},
+	}
+
+	selector := NewBudgetSkillSelector(50)
+	assert.Equal(t, 50, selector.MaxTokens())
+
+	selected, err := selector.SelectSkills(ctx, nil, skills, resolver)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"small", "inline", "missing"}, skillSlugs(selected))
+
+	selected, err = NewBudgetSkillSelector(1000).SelectSkills(ctx, nil, skills, resolver)
+	require.NoError(t, err)
+	assert.Len(t, selected, 4)
+
+	selected, err = NewBudgetSkillSelector(0).SelectSkills(ctx, nil, skills, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"small", "large", "missing"}, skillSlugs(selected))
+}
+
+func TestCompileAgent_SkillSelector(t *testing.T) {
+	doc := `---
+name: selector-agent
+description: Agent with many skills
+type: agent
+skills:
+  - slug: billing
+  - slug: weather
+---
+{~prompty.skills_catalog /~}`
+
+	agent, err := Parse([]byte(doc))
+	require.NoError(t, err)
+
+	resolver := NewMapDocumentResolver()
+	resolver.AddSkill("billing", &Prompt{Name: "billing", Description: "Explain invoices", Type: DocumentTypeSkill})
+	resolver.AddSkill("weather", &Prompt{Name: "weather", Description: "Weather forecast", Type: DocumentTypeSkill})
+
+	t.Run("uses the selected skills", func(t *testing.T) {
+		var gotInput map[string]any
+		opts := NewCompileOptions(
+			WithResolver(resolver),
+			WithSkillSelector(SkillSelectorFunc(func(ctx context.Context, input map[string]any, skills []SkillRef, r DocumentResolver) ([]SkillRef, error) {
+				gotInput = input
+				return skills[1:], nil
+			})),
+		)
+		compiled, err := agent.CompileAgent(context.Background(), map[string]any{"topic": "rain"}, opts)
+		require.NoError(t, err)
+
+		assert.Equal(t, "rain", gotInput["topic"])
+		assert.Equal(t, []string{"weather"}, skillSlugs(compiled.Skills))
+		assert.Contains(t, compiled.Messages[0].Content, "weather")
+		assert.NotContains(t, compiled.Messages[0].Content, "billing")
+	})
+
+	t.Run("uses all skills without a selector", func(t *testing.T) {
+		compiled, err := agent.CompileAgent(context.Background(), nil, &CompileOptions{Resolver: resolver})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"billing", "weather"}, skillSlugs(compiled.Skills))
+	})
+
+	t.Run("fails on selector errors", func(t *testing.T) {
+		opts := &CompileOptions{SkillSelector: SkillSelectorFunc(func(ctx context.Context, input map[string]any, skills []SkillRef, r DocumentResolver) ([]SkillRef, error) {
+			return nil, errors.New("boom")
+		})}
+		_, err := agent.CompileAgent(context.Background(), nil, opts)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMsgCompileSkillSelection)
+	})
+}
@@ -0,0 +1,226 @@
+package prompty
+
+import (
+	"encoding/json"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// toolArgumentsEmpty is the OpenAI arguments of a call whose arguments
+// cannot be encoded.
+const toolArgumentsEmpty = "{}"
+
+// ToolCall is a function call requested by the model.
+type ToolCall struct {
+	// ID identifies the call (tool_call_id / tool_use_id).
+	ID string `yaml:"id" json:"id"`
+	// Name is the called function.
+	Name string `yaml:"name" json:"name"`
+	// Arguments are the call arguments.
+	Arguments map[string]any `yaml:"arguments,omitempty" json:"arguments,omitempty"`
+}
+
+// ToolResult is the output of a tool call, sent back to the model on the
+// next turn with CompiledPrompt.WithToolResults.
+type ToolResult struct {
+	// CallID is the ID of the tool call this result answers.
+	CallID string `yaml:"call_id" json:"call_id"`
+	// Name is the called function.
+	Name string `yaml:"name" json:"name"`
+	// Arguments are the call arguments, used to restate the model's tool
+	// call when the prompt does not end with it.
+	Arguments map[string]any `yaml:"arguments,omitempty" json:"arguments,omitempty"`
+	// Content is the tool output, as text or JSON.
+	Content string `yaml:"content" json:"content"`
+	// IsError reports that the tool failed and Content describes the error.
+	IsError bool `yaml:"is_error,omitempty" json:"is_error,omitempty"`
+}
+
+// WithToolResults returns a copy of the compiled prompt extended for the
+// next tool-calling turn: the assistant message with the tool calls (unless
+// the prompt already ends with one) followed by a tool message holding the
+// results. Provider serialization turns them into the provider's structures:
+// OpenAI tool_calls and tool role messages, Anthropic tool_use and
+// tool_result blocks, and Gemini function_call and function_response parts.
+func (cp *CompiledPrompt) WithToolResults(results []ToolResult) (*CompiledPrompt, error) {
+	if cp == nil {
+		return nil, NewCompilationError(ErrMsgCompilationFailed, nil)
+	}
+	for i, r := range results {
+		if r.CallID == "" {
+			return nil, NewToolResultError(ErrMsgToolResultNoCallID, i)
+		}
+		if r.Name == "" {
+			return nil, NewToolResultError(ErrMsgToolResultNoName, i)
+		}
+	}
+
+	next := *cp
+	next.Messages = make([]CompiledMessage, len(cp.Messages), len(cp.Messages)+2)
+	copy(next.Messages, cp.Messages)
+	if len(results) == 0 {
+		return &next, nil
+	}
+
+	if !endsWithToolCalls(next.Messages, results) {
+		calls := make([]ToolCall, len(results))
+		for i, r := range results {
+			calls[i] = ToolCall{ID: r.CallID, Name: r.Name, Arguments: r.Arguments}
+		}
+		next.Messages = append(next.Messages, CompiledMessage{Role: RoleAssistant, ToolCalls: calls})
+	}
+
+	stored := make([]ToolResult, len(results))
+	copy(stored, results)
+	next.Messages = append(next.Messages, CompiledMessage{
+		Role:        RoleTool,
+		Content:     internal.FormatToolResults(toolResultInfos(results), ToolResultsFormatPlain),
+		ToolResults: stored,
+	})
+	return &next, nil
+}
+
+// endsWithToolCalls reports whether the last message is an assistant
+// message calling every tool the results answer.
+func endsWithToolCalls(messages []CompiledMessage, results []ToolResult) bool {
+	if len(messages) == 0 {
+		return false
+	}
+	last := messages[len(messages)-1]
+	if last.Role != RoleAssistant || len(last.ToolCalls) == 0 {
+		return false
+	}
+	ids := make(map[string]bool, len(last.ToolCalls))
+	for _, call := range last.ToolCalls {
+		ids[call.ID] = true
+	}
+	for _, r := range results {
+		if !ids[r.CallID] {
+			return false
+		}
+	}
+	return true
+}
+
+// toolResultInfos converts tool results for internal.FormatToolResults.
+func toolResultInfos(results []ToolResult) []internal.ToolResultInfo {
+	infos := make([]internal.ToolResultInfo, len(results))
+	for i, r := range results {
+		infos[i] = internal.ToolResultInfo{CallID: r.CallID, Name: r.Name, Content: r.Content, IsError: r.IsError}
+	}
+	return infos
+}
+
+// toolArguments returns the arguments of a call, never nil.
+func toolArguments(call ToolCall) map[string]any {
+	if call.Arguments == nil {
+		return map[string]any{}
+	}
+	return call.Arguments
+}
+
+// openAIToolCalls converts tool calls to OpenAI tool_calls, whose
+// arguments are a JSON string.
+func openAIToolCalls(calls []ToolCall) []map[string]any {
+	result := make([]map[string]any, len(calls))
+	for i, call := range calls {
+		args, err := json.Marshal(toolArguments(call))
+		if err != nil {
+			args = []byte(toolArgumentsEmpty)
+		}
+		result[i] = map[string]any{
+			PartKeyID:   call.ID,
+			PartKeyType: ToolCallTypeFunction,
+			PayloadKeyFunction: map[string]any{
+				PartKeyName:         call.Name,
+				PayloadKeyArguments: string(args),
+			},
+		}
+	}
+	return result
+}
+
+// openAIToolMessages converts tool results to one tool role message each.
+func openAIToolMessages(results []ToolResult) []map[string]any {
+	messages := make([]map[string]any, len(results))
+	for i, r := range results {
+		messages[i] = map[string]any{
+			AttrRole:             RoleTool,
+			PayloadKeyToolCallID: r.CallID,
+			PayloadKeyContent:    r.Content,
+		}
+	}
+	return messages
+}
+
+// anthropicToolUseBlocks converts an assistant message with tool calls to
+// Anthropic content blocks: its text, then a tool_use block per call.
+func anthropicToolUseBlocks(msg CompiledMessage) []map[string]any {
+	blocks := make([]map[string]any, 0, len(msg.ToolCalls)+1)
+	if msg.Content != "" {
+		blocks = append(blocks, map[string]any{PartKeyType: ContentPartTypeText, PartKeyText: msg.Content})
+	}
+	for _, call := range msg.ToolCalls {
+		blocks = append(blocks, map[string]any{
+			PartKeyType:  PartTypeAnthropicToolUse,
+			PartKeyID:    call.ID,
+			PartKeyName:  call.Name,
+			PartKeyInput: toolArguments(call),
+		})
+	}
+	return blocks
+}
+
+// anthropicToolResultBlocks converts tool results to Anthropic tool_result
+// blocks, sent in a user message.
+func anthropicToolResultBlocks(results []ToolResult) []map[string]any {
+	blocks := make([]map[string]any, len(results))
+	for i, r := range results {
+		block := map[string]any{
+			PartKeyType:       PartTypeAnthropicToolResult,
+			PartKeyToolUseID:  r.CallID,
+			PayloadKeyContent: r.Content,
+		}
+		if r.IsError {
+			block[PartKeyIsError] = true
+		}
+		blocks[i] = block
+	}
+	return blocks
+}
+
+// geminiFunctionCallParts converts an assistant message with tool calls to
+// Gemini parts: its text, then a function_call part per call.
+func geminiFunctionCallParts(msg CompiledMessage) []map[string]any {
+	parts := make([]map[string]any, 0, len(msg.ToolCalls)+1)
+	if msg.Content != "" {
+		parts = append(parts, map[string]any{PartKeyText: msg.Content})
+	}
+	for _, call := range msg.ToolCalls {
+		parts = append(parts, map[string]any{
+			PartKeyGeminiFunctionCall: map[string]any{PartKeyName: call.Name, PartKeyArgs: toolArguments(call)},
+		})
+	}
+	return parts
+}
+
+// geminiFunctionResponseParts converts tool results to Gemini
+// function_response parts. Gemini responses are objects: JSON object
+// content is sent as is, other content under "content" (or "error").
+func geminiFunctionResponseParts(results []ToolResult) []map[string]any {
+	parts := make([]map[string]any, len(results))
+	for i, r := range results {
+		var response map[string]any
+		if err := json.Unmarshal([]byte(r.Content), &response); err != nil || response == nil {
+			key := GeminiResponseKeyContent
+			if r.IsError {
+				key = GeminiResponseKeyError
+			}
+			response = map[string]any{key: r.Content}
+		}
+		parts[i] = map[string]any{
+			PartKeyGeminiFunctionResponse: map[string]any{PartKeyName: r.Name, PartKeyResponse: response},
+		}
+	}
+	return parts
+}
@@ -0,0 +1,128 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newToolTurnPrompt() *CompiledPrompt {
+	return &CompiledPrompt{
+		Messages: []CompiledMessage{
+			{Role: RoleSystem, Content: "You are helpful."},
+			{Role: RoleUser, Content: "Search for go"},
+		},
+	}
+}
+
+func newToolTurnResults() []ToolResult {
+	return []ToolResult{
+		{CallID: "call_1", Name: "search", Arguments: map[string]any{"q": "go"}, Content: `{"hits":3}`},
+		{CallID: "call_2", Name: "fetch", Content: "timeout", IsError: true},
+	}
+}
+
+func TestCompiledPrompt_WithToolResults(t *testing.T) {
+	base := newToolTurnPrompt()
+	next, err := base.WithToolResults(newToolTurnResults())
+	require.NoError(t, err)
+
+	assert.Len(t, base.Messages, 2, "original prompt is unchanged")
+	require.Len(t, next.Messages, 4)
+	assert.Equal(t, RoleAssistant, next.Messages[2].Role)
+	assert.Equal(t, []ToolCall{
+		{ID: "call_1", Name: "search", Arguments: map[string]any{"q": "go"}},
+		{ID: "call_2", Name: "fetch"},
+	}, next.Messages[2].ToolCalls)
+	assert.Equal(t, RoleTool, next.Messages[3].Role)
+	assert.Equal(t, "Tool search returned:\n{\"hits\":3}\n\nTool fetch failed:\ntimeout", next.Messages[3].Content)
+
+	// An existing assistant tool call message is reused
+	withCalls := newToolTurnPrompt()
+	withCalls.Messages = append(withCalls.Messages, CompiledMessage{
+		Role:      RoleAssistant,
+		ToolCalls: []ToolCall{{ID: "call_1", Name: "search"}, {ID: "call_2", Name: "fetch"}},
+	})
+	next, err = withCalls.WithToolResults(newToolTurnResults())
+	require.NoError(t, err)
+	assert.Len(t, next.Messages, 4)
+
+	_, err = base.WithToolResults([]ToolResult{{Name: "search"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgToolResultNoCallID)
+
+	_, err = base.WithToolResults([]ToolResult{{CallID: "call_1"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgToolResultNoName)
+}
+
+func TestCompiledPrompt_ToolResultsSerialization(t *testing.T) {
+	next, err := newToolTurnPrompt().WithToolResults(newToolTurnResults())
+	require.NoError(t, err)
+
+	t.Run("openai", func(t *testing.T) {
+		msgs := next.ToOpenAIMessages()
+		require.Len(t, msgs, 5)
+		assert.Nil(t, msgs[2][PayloadKeyContent])
+		assert.Equal(t, []map[string]any{
+			{PartKeyID: "call_1", PartKeyType: ToolCallTypeFunction, PayloadKeyFunction: map[string]any{PartKeyName: "search", PayloadKeyArguments: `{"q":"go"}`}},
+			{PartKeyID: "call_2", PartKeyType: ToolCallTypeFunction, PayloadKeyFunction: map[string]any{PartKeyName: "fetch", PayloadKeyArguments: `{}`}},
+		}, msgs[2][PayloadKeyToolCalls])
+		assert.Equal(t, map[string]any{AttrRole: RoleTool, PayloadKeyToolCallID: "call_1", PayloadKeyContent: `{"hits":3}`}, msgs[3])
+		assert.Equal(t, "call_2", msgs[4][PayloadKeyToolCallID])
+	})
+
+	t.Run("anthropic", func(t *testing.T) {
+		payload := next.ToAnthropicMessages()
+		msgs := payload[PayloadKeyMessages].([]map[string]any)
+		require.Len(t, msgs, 3)
+		assert.Equal(t, []map[string]any{
+			{PartKeyType: PartTypeAnthropicToolUse, PartKeyID: "call_1", PartKeyName: "search", PartKeyInput: map[string]any{"q": "go"}},
+			{PartKeyType: PartTypeAnthropicToolUse, PartKeyID: "call_2", PartKeyName: "fetch", PartKeyInput: map[string]any{}},
+		}, msgs[1][PayloadKeyContent])
+		assert.Equal(t, RoleUser, msgs[2][AttrRole])
+		assert.Equal(t, []map[string]any{
+			{PartKeyType: PartTypeAnthropicToolResult, PartKeyToolUseID: "call_1", PayloadKeyContent: `{"hits":3}`},
+			{PartKeyType: PartTypeAnthropicToolResult, PartKeyToolUseID: "call_2", PayloadKeyContent: "timeout", PartKeyIsError: true},
+		}, msgs[2][PayloadKeyContent])
+	})
+
+	t.Run("gemini", func(t *testing.T) {
+		payload := next.ToGeminiContents()
+		contents := payload["contents"].([]map[string]any)
+		require.Len(t, contents, 3)
+		assert.Equal(t, GeminiRoleModel, contents[1][AttrRole])
+		assert.Equal(t, []map[string]any{
+			{PartKeyGeminiFunctionCall: map[string]any{PartKeyName: "search", PartKeyArgs: map[string]any{"q": "go"}}},
+			{PartKeyGeminiFunctionCall: map[string]any{PartKeyName: "fetch", PartKeyArgs: map[string]any{}}},
+		}, contents[1][PayloadKeyParts])
+		assert.Equal(t, RoleUser, contents[2][AttrRole])
+		assert.Equal(t, []map[string]any{
+			{PartKeyGeminiFunctionResponse: map[string]any{PartKeyName: "search", PartKeyResponse: map[string]any{"hits": float64(3)}}},
+			{PartKeyGeminiFunctionResponse: map[string]any{PartKeyName: "fetch", PartKeyResponse: map[string]any{GeminiResponseKeyError: "timeout"}}},
+		}, contents[2][PayloadKeyParts])
+	})
+}
+
+func TestCompileAgent_ToolResultsTag(t *testing.T) {
+	agent, err := Parse([]byte(`---
+name: tool-agent
+description: Agent without native tools
+type: agent
+messages:
+  - role: system
+    content: You are helpful.
+  - role: user
+    content: '{~prompty.tool_results format="xml" /~}'
+---
+`))
+	require.NoError(t, err)
+
+	compiled, err := agent.CompileAgent(context.Background(), map[string]any{
+		"tool_results": []ToolResult{{CallID: "call_1", Name: "search", Content: "3 hits"}},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `<tool_result name="search" id="call_1">3 hits</tool_result>`, compiled.Messages[1].Content)
+}
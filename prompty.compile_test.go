@@ -574,6 +574,98 @@ func TestCompiledPrompt_ToProviderMessages_Nil(t *testing.T) {
 	assert.Nil(t, result)
 }
 
+func TestCompiledPrompt_ToProviderPayload(t *testing.T) {
+	temp := 0.2
+	maxTokens := 256
+	compiled := &CompiledPrompt{
+		Messages: []CompiledMessage{
+			{Role: RoleSystem, Content: "System."},
+			{Role: RoleUser, Content: "Hi."},
+		},
+		Execution: &ExecutionConfig{Model: "claude-sonnet-4", Temperature: &temp, MaxTokens: &maxTokens},
+		Tools: &ToolsConfig{
+			Functions: []*FunctionDef{{
+				Name:        "lookup",
+				Description: "Look something up",
+				Parameters:  map[string]any{"type": "object", "properties": map[string]any{}},
+			}},
+			ToolChoice: ToolChoiceRequired,
+		},
+	}
+
+	t.Run("openai", func(t *testing.T) {
+		payload, err := compiled.ToProviderPayload(ProviderOpenAI)
+		require.NoError(t, err)
+		assert.Equal(t, "claude-sonnet-4", payload[ParamKeyModel])
+		assert.Equal(t, 0.2, payload[ParamKeyTemperature])
+		assert.Len(t, payload[PayloadKeyMessages], 2)
+		tools := payload[PayloadKeyTools].([]map[string]any)
+		require.Len(t, tools, 1)
+		assert.Equal(t, "function", tools[0][SchemaKeyType])
+		assert.Equal(t, ToolChoiceRequired, payload[PayloadKeyToolChoice])
+	})
+
+	t.Run("openai responses", func(t *testing.T) {
+		payload, err := compiled.ToProviderPayload(ProviderOpenAIResponses)
+		require.NoError(t, err)
+		assert.Equal(t, "System.", payload[ResponsesKeyInstructions])
+		assert.Len(t, payload[ResponsesKeyInput], 1)
+		assert.Equal(t, 256, payload[ResponsesKeyMaxOutputTokens])
+		tools := payload[PayloadKeyTools].([]map[string]any)
+		require.Len(t, tools, 1)
+		assert.Equal(t, "lookup", tools[0][AttrName])
+		assert.NotContains(t, tools[0], "function")
+		assert.Equal(t, ToolChoiceRequired, payload[PayloadKeyToolChoice])
+	})
+
+	t.Run("anthropic from effective provider", func(t *testing.T) {
+		payload, err := compiled.ToProviderPayload("")
+		require.NoError(t, err)
+		assert.Equal(t, "System.", payload[RoleSystem])
+		assert.Len(t, payload[PayloadKeyMessages], 1)
+		assert.Equal(t, 256, payload[ParamKeyMaxTokens])
+		tools := payload[PayloadKeyTools].([]map[string]any)
+		require.Len(t, tools, 1)
+		assert.Contains(t, tools[0], "input_schema")
+		assert.Equal(t, map[string]any{PayloadKeyToolChoiceType: ToolChoiceAnthropicAny}, payload[PayloadKeyToolChoice])
+	})
+
+	t.Run("gemini", func(t *testing.T) {
+		payload, err := compiled.ToProviderPayload(ProviderGemini)
+		require.NoError(t, err)
+		assert.Contains(t, payload, "contents")
+		assert.Contains(t, payload, "system_instruction")
+		assert.Contains(t, payload, ParamKeyGenerationConfig)
+		tools := payload[PayloadKeyTools].([]map[string]any)
+		require.Len(t, tools, 1)
+		assert.Len(t, tools[0][PayloadKeyFunctionDeclarations], 1)
+	})
+
+	t.Run("without execution or tools", func(t *testing.T) {
+		bare := &CompiledPrompt{Messages: compiled.Messages}
+		payload, err := bare.ToProviderPayload(ProviderMistral)
+		require.NoError(t, err)
+		assert.Len(t, payload, 1)
+		assert.Len(t, payload[PayloadKeyMessages], 2)
+	})
+
+	t.Run("unsupported provider", func(t *testing.T) {
+		_, err := compiled.ToProviderPayload("unknown-provider")
+		require.Error(t, err)
+
+		bare := &CompiledPrompt{Messages: compiled.Messages}
+		_, err = bare.ToProviderPayload("")
+		require.Error(t, err)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var cp *CompiledPrompt
+		payload, err := cp.ToProviderPayload(ProviderOpenAI)
+		require.NoError(t, err)
+		assert.Nil(t, payload)
+	})
+}
+
 // ==================== ENH-02: Functional Options for CompileOptions ====================
 
 func TestNewCompileOptions(t *testing.T) {
@@ -913,3 +1005,101 @@ func TestAgentDryRunResult_String(t *testing.T) {
 		assert.Contains(t, s, "body")
 	})
 }
+
+func TestCompiledPrompt_ContentParts(t *testing.T) {
+	compiled := &CompiledPrompt{
+		Messages: []CompiledMessage{{
+			Role:    RoleUser,
+			Content: "Summarize",
+			Parts: []ContentPart{
+				{Type: ContentPartTypeText, Text: "Summarize"},
+				{Type: ContentPartTypeImage, URL: "data:image/png;base64,AAAA", MediaType: "image/png"},
+				{Type: ContentPartTypeFile, Name: "report.pdf", URL: "https://example.com/report.pdf", MediaType: "application/pdf"},
+			},
+		}},
+	}
+
+	openAI := compiled.ToOpenAIMessages()
+	require.Len(t, openAI, 1)
+	assert.Equal(t, []map[string]any{
+		{PartKeyType: ContentPartTypeText, PartKeyText: "Summarize"},
+		{PartKeyType: PartTypeOpenAIImageURL, PartKeyImageURL: map[string]any{PartKeyURL: "data:image/png;base64,AAAA"}},
+		{PartKeyType: ContentPartTypeFile, PartKeyFile: map[string]any{PartKeyFilename: "report.pdf"}},
+	}, openAI[0][PayloadKeyContent])
+
+	anthropic := compiled.ToAnthropicMessages()[PayloadKeyMessages].([]map[string]any)
+	assert.Equal(t, []map[string]any{
+		{PartKeyType: ContentPartTypeText, PartKeyText: "Summarize"},
+		{PartKeyType: PartTypeAnthropicImage, PartKeySource: map[string]any{
+			PartKeyType: PartSourceTypeBase64, PartKeyMediaType: "image/png", PartKeyData: "AAAA",
+		}},
+		{PartKeyType: PartTypeAnthropicDoc, PartKeyTitle: "report.pdf", PartKeySource: map[string]any{
+			PartKeyType: PartSourceTypeURL, PartKeyURL: "https://example.com/report.pdf",
+		}},
+	}, anthropic[0][PayloadKeyContent])
+
+	gemini := compiled.ToGeminiContents()["contents"].([]map[string]any)
+	assert.Equal(t, []map[string]any{
+		{PartKeyText: "Summarize"},
+		{PartKeyInlineData: map[string]any{PartKeyMimeType: "image/png", PartKeyData: "AAAA"}},
+		{PartKeyGeminiFileData: map[string]any{PartKeyMimeType: "application/pdf", PartKeyFileURI: "https://example.com/report.pdf"}},
+	}, gemini[0][PayloadKeyParts])
+}
+
+func TestPrompt_CompileAgent_CacheBreakpoint(t *testing.T) {
+	p := &Prompt{
+		Name:        "test-agent",
+		Description: "A test agent",
+		Type:        DocumentTypeAgent,
+		Execution:   &ExecutionConfig{Provider: ProviderAnthropic, Model: "claude-sonnet-4-5"},
+		Body:        "Long reference material.{~prompty.cache_breakpoint /~}\nToday is Monday.",
+	}
+
+	compiled, err := p.CompileAgent(context.Background(), map[string]any{"message": "Hi"}, nil)
+	require.NoError(t, err)
+	require.Len(t, compiled.Messages, 2)
+	assert.Equal(t, "Long reference material.\nToday is Monday.", compiled.Messages[0].Content)
+	assert.Equal(t, []ContentPart{
+		{Type: ContentPartTypeText, Text: "Long reference material.", Cache: true},
+		{Type: ContentPartTypeText, Text: "Today is Monday."},
+	}, compiled.Messages[0].Parts)
+
+	anthropic := compiled.ToAnthropicMessages()
+	assert.Equal(t, []map[string]any{
+		{PartKeyType: ContentPartTypeText, PartKeyText: "Long reference material.", PartKeyCacheControl: map[string]any{PartKeyType: CacheControlEphemeral}},
+		{PartKeyType: ContentPartTypeText, PartKeyText: "Today is Monday."},
+	}, anthropic[RoleSystem])
+	assert.Equal(t, "Hi", anthropic[PayloadKeyMessages].([]map[string]any)[0][PayloadKeyContent])
+}
+
+func TestCompiledPrompt_CachedMessage(t *testing.T) {
+	compiled := &CompiledPrompt{
+		Messages: []CompiledMessage{
+			{Role: RoleSystem, Content: "Rules."},
+			{Role: RoleUser, Content: "Document.", Cache: true},
+			{Role: RoleUser, Content: "Question?"},
+		},
+	}
+
+	anthropic := compiled.ToAnthropicMessages()
+	assert.Equal(t, "Rules.", anthropic[RoleSystem])
+	msgs := anthropic[PayloadKeyMessages].([]map[string]any)
+	assert.Equal(t, []map[string]any{{
+		PartKeyType:         ContentPartTypeText,
+		PartKeyText:         "Document.",
+		PartKeyCacheControl: map[string]any{PartKeyType: CacheControlEphemeral},
+	}}, msgs[0][PayloadKeyContent])
+	assert.Equal(t, "Question?", msgs[1][PayloadKeyContent])
+
+	// The key covers the prefix through the last breakpoint only
+	key := compiled.PromptCacheKey()
+	assert.Len(t, key, PromptCacheKeyBytes*2)
+	other := &CompiledPrompt{Messages: append(compiled.Messages[:2:2], CompiledMessage{Role: RoleUser, Content: "Other?"})}
+	assert.Equal(t, key, other.PromptCacheKey())
+	assert.Empty(t, (&CompiledPrompt{Messages: compiled.Messages[2:]}).PromptCacheKey())
+
+	payload, err := compiled.ToProviderPayload(ProviderOpenAI)
+	require.NoError(t, err)
+	assert.Equal(t, key, payload[PayloadKeyPromptCacheKey])
+	assert.Equal(t, "Document.", payload[PayloadKeyMessages].([]map[string]any)[1][PayloadKeyContent])
+}
@@ -1,6 +1,9 @@
 package prompty
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Delimiter constants - the {~ ~} syntax chosen for minimal collision with prompt content
 const (
@@ -10,33 +13,89 @@ const (
 	DefaultBlockClose = "{~/"
 )
 
+// Delimiter validation constants for WithDelimiters
+const (
+	// DelimiterForbiddenChars may not appear in custom delimiters because the
+	// lexer uses them for whitespace skipping, attribute values, or escapes
+	DelimiterForbiddenChars = " \t\r\n\"'=\\"
+	// DelimiterSlash may not start a delimiter; it marks self-close and block-close
+	DelimiterSlash = "/"
+)
+
+// TokenKind classifies a Token returned by Tokenize for syntax highlighting
+type TokenKind string
+
+// Token kinds produced by Tokenize
+const (
+	TokenKindText        TokenKind = "text"        // Literal template text (including raw block bodies)
+	TokenKindFrontmatter TokenKind = "frontmatter" // YAML frontmatter including its --- delimiters
+	TokenKindEscape      TokenKind = "escape"      // Escaped open delimiter (\{~)
+	TokenKindTagOpen     TokenKind = "tag-open"    // Open or block-close delimiter ({~ or {~/)
+	TokenKindTagClose    TokenKind = "tag-close"   // Close or self-close delimiter (~} or /~})
+	TokenKindTagName     TokenKind = "tag-name"    // Tag name (e.g. prompty.var)
+	TokenKindAttribute   TokenKind = "attribute"   // Attribute name
+	TokenKindEquals      TokenKind = "equals"      // = between attribute name and value
+	TokenKindString      TokenKind = "string"      // Quoted attribute value
+	TokenKindExpression  TokenKind = "expression"  // Quoted eval attribute value
+	TokenKindComment     TokenKind = "comment"     // Comment block body
+	TokenKindWhitespace  TokenKind = "whitespace"  // Whitespace inside a tag
+	TokenKindInvalid     TokenKind = "invalid"     // Unexpected content inside a tag
+)
+
 // Built-in tag names - all use prompty. namespace prefix
 const (
-	TagNameVar         = "prompty.var"
-	TagNameRaw         = "prompty.raw"
-	TagNameInclude     = "prompty.include"     // Nested template inclusion
-	TagNameIf          = "prompty.if"          // Phase 2
-	TagNameElseIf      = "prompty.elseif"      // Phase 2
-	TagNameElse        = "prompty.else"        // Phase 2
-	TagNameFor         = "prompty.for"         // Phase 4
-	TagNameComment     = "prompty.comment"     // Phase 3
-	TagNameDefault     = "prompty.default"     // Phase 3
-	TagNameSwitch      = "prompty.switch"      // Phase 5
-	TagNameCase        = "prompty.case"        // Phase 5
-	TagNameCaseDefault = "prompty.casedefault" // Phase 5 - default case in switch
-	TagNameEnv         = "prompty.env"         // Environment variable resolver
-	TagNameConfig      = "prompty.config"      // Legacy inference configuration block (JSON)
-	TagNameExtends     = "prompty.extends"     // Template inheritance - extends parent
-	TagNameBlock       = "prompty.block"       // Template inheritance - overridable block
-	TagNameParent      = "prompty.parent"      // Template inheritance - call parent block content
-	TagNameMessage     = "prompty.message"     // Conversation message for chat API
-	TagNameRef         = "prompty.ref"         // v2.0: Prompt reference resolver
+	TagNameVar             = "prompty.var"
+	TagNameRaw             = "prompty.raw"
+	TagNameInclude         = "prompty.include"          // Nested template inclusion
+	TagNameIf              = "prompty.if"               // Phase 2
+	TagNameElseIf          = "prompty.elseif"           // Phase 2
+	TagNameElse            = "prompty.else"             // Phase 2
+	TagNameFor             = "prompty.for"              // Phase 4
+	TagNameComment         = "prompty.comment"          // Phase 3
+	TagNameDefault         = "prompty.default"          // Alias for prompty.casedefault inside a switch
+	TagNameSwitch          = "prompty.switch"           // Phase 5
+	TagNameCase            = "prompty.case"             // Phase 5
+	TagNameCaseDefault     = "prompty.casedefault"      // Phase 5 - default case in switch
+	TagNameEnv             = "prompty.env"              // Environment variable resolver
+	TagNameConfig          = "prompty.config"           // Legacy inference configuration block (JSON)
+	TagNameExtends         = "prompty.extends"          // Template inheritance - extends parent
+	TagNameBlock           = "prompty.block"            // Template inheritance - overridable block
+	TagNameParent          = "prompty.parent"           // Template inheritance - call parent block content
+	TagNameImport          = "prompty.import"           // Import the blocks of a template as a library
+	TagNameUse             = "prompty.use"              // Render a block of an imported library
+	TagNameChoice          = "prompty.choice"           // Random element of a collection
+	TagNameShuffle         = "prompty.shuffle"          // Body repeated over a random sample of a collection
+	TagNameHistory         = "prompty.history"          // Conversation history from data
+	TagNameImage           = "prompty.image"            // Image content part of a message
+	TagNameFile            = "prompty.file"             // File content part of a message
+	TagNameCacheBreakpoint = "prompty.cache_breakpoint" // Prompt-cache boundary inside a message
+	TagNameToolResults     = "prompty.tool_results"     // Tool call outputs from data
+	TagNameEscape          = "prompty.escape"           // Output mode of interpolated values in its body
+	TagNameMessage         = "prompty.message"          // Conversation message for chat API
+	TagNameRef             = "prompty.ref"              // v2.0: Prompt reference resolver
 )
 
 // YAML frontmatter constants
 const (
 	// YAMLFrontmatterDelimiter is the standard YAML frontmatter delimiter
 	YAMLFrontmatterDelimiter = "---"
+	// JSONFrontmatterDelimiter opens JSON frontmatter, closed by ---
+	JSONFrontmatterDelimiter = "---json"
+)
+
+// DocumentFormat is the on-disk format of a prompt document.
+type DocumentFormat string
+
+// Document formats (DetectDocumentFormat, SerializeOptions.Format)
+const (
+	// DocumentFormatYAML is YAML frontmatter between --- lines, then the body
+	DocumentFormatYAML DocumentFormat = "yaml"
+	// DocumentFormatJSONFrontmatter is JSON frontmatter between ---json and
+	// --- lines, then the body
+	DocumentFormatJSONFrontmatter DocumentFormat = "json_frontmatter"
+	// DocumentFormatJSON is a single JSON object of the frontmatter fields
+	// with the body under "body"
+	DocumentFormatJSON DocumentFormat = "json"
 )
 
 // Message role constants for prompty.message tag
@@ -60,32 +119,91 @@ const (
 
 // Attribute name constants
 const (
-	AttrName     = "name"
-	AttrDefault  = "default"
-	AttrEval     = "eval"
-	AttrOnError  = "onerror"
-	AttrFormat   = "format"
-	AttrEscape   = "escape"
-	AttrItem     = "item"
-	AttrIndex    = "index"
-	AttrIn       = "in"
-	AttrLimit    = "limit"
-	AttrValue    = "value"
-	AttrText     = "text"
-	AttrTemplate = "template" // Template name for include
-	AttrWith     = "with"     // Context path for include
-	AttrIsolate  = "isolate"  // Isolated context flag for include
-	AttrRequired = "required" // Required flag for env resolver
-	AttrSlug     = "slug"     // v2.0: Prompt slug for reference
-	AttrVersion  = "version"  // v2.0: Prompt version for reference
+	AttrName         = "name"
+	AttrDefault      = "default"
+	AttrEval         = "eval"
+	AttrOnError      = "onerror"
+	AttrFormat       = "format"
+	AttrEscape       = "escape" // Output mode of a single interpolated value
+	AttrMode         = "mode"   // Output mode of prompty.escape
+	AttrItem         = "item"
+	AttrIndex        = "index"
+	AttrIn           = "in"
+	AttrLimit        = "limit"
+	AttrValue        = "value"
+	AttrFallthrough  = "fallthrough" // Continue into the next switch case body after a match
+	AttrText         = "text"
+	AttrTemplate     = "template"      // Template name for include
+	AttrTemplateExpr = "template_expr" // Expression computing the template name for include
+	AttrWith         = "with"          // Context path for include
+	AttrIsolate      = "isolate"       // Isolated context flag for include
+	AttrSource       = "source"        // Template source for include: registered or storage
+	AttrRequired     = "required"      // Required flag for env resolver
+	AttrSlug         = "slug"          // v2.0: Prompt slug for reference
+	AttrVersion      = "version"       // v2.0: Prompt version for reference
+	AttrAs           = "as"            // Alias of an imported block library
+	AttrBlock        = "block"         // Imported block to render: alias.block
+	AttrCacheKey     = "cache_key"     // Data paths keying a tag's cached result
+	AttrFrom         = "from"          // Collection path for prompty.choice, URL path for content parts
+	AttrWindow       = "window"        // Token budget of prompty.history: "tokens:N"
+	AttrURL          = "url"           // URL of a content part (http(s) or data:)
+	AttrDetail       = "detail"        // Image detail level: low, high or auto
+	AttrID           = "id"            // Provider file ID of a content part
+	AttrMediaType    = "media_type"    // MIME type of a content part
+	AttrSelection    = "selection"     // Skills catalog selection: all or relevant
+	AttrQueryFrom    = "query_from"    // Data path of the relevance query of a skills catalog
+)
+
+// Content part types (ContentPart.Type)
+const (
+	ContentPartTypeText  = "text"
+	ContentPartTypeImage = "image"
+	ContentPartTypeFile  = "file"
 )
 
+// Image detail levels for prompty.image
+const (
+	ImageDetailLow  = "low"
+	ImageDetailHigh = "high"
+	ImageDetailAuto = "auto"
+)
+
+// prompty.history format attribute values
+const (
+	HistoryFormatMessages = "messages" // Structured messages, see ExtractMessagesFromOutput
+	HistoryFormatChatML   = "chatml"   // <|im_start|>role ... <|im_end|> text
+	HistoryFormatPlain    = "plain"    // "Role: content" lines
+)
+
+// prompty.tool_results format attribute values
+const (
+	ToolResultsFormatPlain = "plain" // "Tool name returned:" blocks
+	ToolResultsFormatXML   = "xml"   // <tool_result name="..." id="..."> elements
+	ToolResultsFormatJSON  = "json"  // JSON array of the results
+
+	// DefaultToolResultsIn is the data path of prompty.tool_results without in
+	DefaultToolResultsIn = "tool_results"
+)
+
+// Include source attribute values
+const (
+	AttrValueRegistered = "registered"
+	AttrValueStorage    = "storage"
+)
+
+// IncludeChainSeparator joins template names in include and inheritance cycle errors.
+const IncludeChainSeparator = " -> "
+
 // Boolean attribute values
 const (
 	AttrValueTrue  = "true"
 	AttrValueFalse = "false"
 )
 
+// AttrValueEphemeral is accepted as cache="ephemeral" on prompty.message, the
+// Anthropic spelling of cache="true"
+const AttrValueEphemeral = "ephemeral"
+
 // ErrorStrategy defines how to handle errors during execution
 type ErrorStrategy int
 
@@ -100,6 +218,13 @@ const (
 	ErrorStrategyKeepRaw
 	// ErrorStrategyLog logs the error and continues with empty string
 	ErrorStrategyLog
+	// ErrorStrategyCollect continues like ErrorStrategyDefault and returns
+	// the output with an *ExecutionErrors listing every failed tag
+	ErrorStrategyCollect
+	// ErrorStrategyInclude renders the fallback template named by
+	// onerror="include:name" in place of the tag. Without a template name,
+	// as an engine strategy, it behaves like ErrorStrategyDefault
+	ErrorStrategyInclude
 )
 
 // Error strategy string values for attribute parsing
@@ -109,6 +234,12 @@ const (
 	ErrorStrategyNameRemove  = "remove"
 	ErrorStrategyNameKeepRaw = "keepraw"
 	ErrorStrategyNameLog     = "log"
+	ErrorStrategyNameCollect = "collect"
+	ErrorStrategyNameInclude = "include"
+
+	// ErrorStrategyIncludePrefix prefixes the fallback template name in
+	// onerror="include:name"
+	ErrorStrategyIncludePrefix = ErrorStrategyNameInclude + ":"
 )
 
 // String returns the string representation of the error strategy
@@ -124,6 +255,10 @@ func (s ErrorStrategy) String() string {
 		return ErrorStrategyNameKeepRaw
 	case ErrorStrategyLog:
 		return ErrorStrategyNameLog
+	case ErrorStrategyCollect:
+		return ErrorStrategyNameCollect
+	case ErrorStrategyInclude:
+		return ErrorStrategyNameInclude
 	default:
 		return ErrorStrategyNameThrow
 	}
@@ -142,14 +277,20 @@ const (
 
 // Cache configuration defaults
 const (
-	DefaultCacheTTL              = 5 * time.Minute
-	DefaultCacheMaxEntries       = 1000
-	DefaultNegativeCacheTTL      = 30 * time.Second
-	DefaultAccessCacheTTL        = 5 * time.Minute
-	DefaultAccessCacheMaxEntries = 10000
-	DefaultResultCacheTTL        = 5 * time.Minute
-	DefaultResultCacheMaxEntries = 1000
-	DefaultResultCacheMaxSize    = 1 << 20 // 1MB
+	DefaultCacheTTL               = 5 * time.Minute
+	DefaultCacheMaxEntries        = 1000
+	DefaultNegativeCacheTTL       = 30 * time.Second
+	DefaultAccessCacheTTL         = 5 * time.Minute
+	DefaultAccessCacheMaxEntries  = 10000
+	DefaultResultCacheTTL         = 5 * time.Minute
+	DefaultResultCacheMaxEntries  = 1000
+	DefaultResultCacheMaxSize     = 1 << 20 // 1MB
+	DefaultASTCacheMaxEntries     = 1000
+	DefaultASTCacheTTL            = time.Hour
+	DefaultTagCacheMaxEntries     = 1000
+	DefaultCacheRefreshTimeout    = 10 * time.Second // Bound on background CachedStorage reloads
+	DefaultCompileCacheMaxEntries = 1000
+	DefaultCompileCacheTTL        = 10 * time.Minute
 )
 
 // Filesystem storage constants
@@ -167,6 +308,7 @@ const (
 	DocumentFilenameSkill  = "SKILL.md"
 	FileExtensionMarkdown  = ".md"
 	FileExtensionZip       = ".zip"
+	FileExtensionJSON      = ".json"
 )
 
 // Metadata keys for cuserr.WithMetadata
@@ -187,6 +329,11 @@ const (
 	MetaKeyMaxDepth     = "max_depth"
 	MetaKeyFuncName     = "func_name"
 	MetaKeyReason       = "reason"
+	MetaKeyOpenDelim    = "open_delim"
+	MetaKeyCloseDelim   = "close_delim"
+	MetaKeyPattern      = "pattern"
+	MetaKeyOutputMode   = "output_mode"
+	MetaKeyRule         = "rule"
 	MetaKeyFromType     = "from_type"
 	MetaKeyToType       = "to_type"
 	MetaKeyEnvVar       = "env_var"
@@ -194,12 +341,16 @@ const (
 	MetaKeyPromptSlug   = "prompt_slug"     // v2.0: Prompt slug for reference errors
 	MetaKeyPromptName   = "prompt_name"     // v2.0: Prompt name for validation errors
 	MetaKeyRefChain     = "reference_chain" // v2.0: Reference chain for circular detection
+	MetaKeyIncludePath  = "include_chain"   // Include chain for circular detection
+	MetaKeyExtendsPath  = "extends_chain"   // Inheritance chain for circular detection
 	MetaKeyLabel        = "label"           // Label name for label operations
 	MetaKeyFromStatus   = "from_status"     // Source status in transitions
 	MetaKeyToStatus     = "to_status"       // Target status in transitions
 	MetaKeyVersion      = "version"         // Version number
 	MetaKeyStatus       = "status"          // Deployment status value
 	MetaKeyProvider     = "provider"        // LLM provider name
+	MetaKeyModel        = "model"           // LLM model name
+	MetaKeyBatchIndex   = "batch_index"     // Index of a batch item
 )
 
 // Escape sequence constants
@@ -211,15 +362,19 @@ const (
 // Internal meta keys for nested template data passing
 // These are used internally and prefixed with underscore to avoid collision
 const (
-	MetaKeyParentDepth = "_parentDepth" // Used to pass depth between nested template executions
-	MetaKeyValueData   = "_value"       // Used to pass non-map values in with attribute
-	MetaKeyRawSource   = "_rawSource"   // Original tag source for keepRaw strategy
-	MetaKeyStrategy    = "strategy"     // Applied error strategy for logging
+	MetaKeyParentDepth  = "_parentDepth"  // Used to pass depth between nested template executions
+	MetaKeyIncludeChain = "_includeChain" // Used to pass the include chain for cycle detection
+	MetaKeyValueData    = "_value"        // Used to pass non-map values in with attribute
+	MetaKeyRawSource    = "_rawSource"    // Original tag source for keepRaw strategy
+	MetaKeyStrategy     = "strategy"      // Applied error strategy for logging
 )
 
 // ParseErrorStrategy parses a string into an ErrorStrategy.
 // Returns ErrorStrategyThrow for unknown values.
 func ParseErrorStrategy(s string) ErrorStrategy {
+	if strings.HasPrefix(s, ErrorStrategyIncludePrefix) {
+		return ErrorStrategyInclude
+	}
 	switch s {
 	case ErrorStrategyNameDefault:
 		return ErrorStrategyDefault
@@ -229,6 +384,10 @@ func ParseErrorStrategy(s string) ErrorStrategy {
 		return ErrorStrategyKeepRaw
 	case ErrorStrategyNameLog:
 		return ErrorStrategyLog
+	case ErrorStrategyNameCollect:
+		return ErrorStrategyCollect
+	case ErrorStrategyNameInclude:
+		return ErrorStrategyInclude
 	case ErrorStrategyNameThrow:
 		return ErrorStrategyThrow
 	default:
@@ -236,11 +395,16 @@ func ParseErrorStrategy(s string) ErrorStrategy {
 	}
 }
 
-// IsValidErrorStrategy checks if a string is a valid error strategy name.
+// IsValidErrorStrategy checks if a string is a valid error strategy name,
+// or include:name naming a fallback template.
 func IsValidErrorStrategy(s string) bool {
+	if name, ok := strings.CutPrefix(s, ErrorStrategyIncludePrefix); ok {
+		return name != ""
+	}
 	switch s {
 	case ErrorStrategyNameThrow, ErrorStrategyNameDefault,
-		ErrorStrategyNameRemove, ErrorStrategyNameKeepRaw, ErrorStrategyNameLog:
+		ErrorStrategyNameRemove, ErrorStrategyNameKeepRaw, ErrorStrategyNameLog,
+		ErrorStrategyNameCollect:
 		return true
 	default:
 		return false
@@ -293,6 +457,41 @@ const (
 	StorageDriverNameMemory     = "memory"
 	StorageDriverNameFilesystem = "filesystem"
 	StorageDriverNamePostgres   = "postgres"
+	StorageDriverNameHTTP       = "http"
+)
+
+// HTTP storage driver configuration
+const (
+	HTTPStorageDefaultTimeout = 30 * time.Second
+	HTTPStoragePathTemplates  = "/templates"
+	HTTPStoragePathVersions   = "/versions"
+	HTTPStoragePathIDs        = "/ids"
+	HTTPStorageContentType    = "application/json"
+	HTTPStorageMaxBodySize    = 10 << 20 // 10 MiB
+	HTTPStorageHeaderAccept   = "Accept"
+	HTTPStorageHeaderContent  = "Content-Type"
+	HTTPStorageHeaderETag     = "ETag"
+	HTTPStorageHeaderIfNone   = "If-None-Match"
+
+	// Query parameters for List
+	HTTPStorageParamTenantID      = "tenant_id"
+	HTTPStorageParamTag           = "tag"
+	HTTPStorageParamCreatedBy     = "created_by"
+	HTTPStorageParamNamePrefix    = "name_prefix"
+	HTTPStorageParamNameContains  = "name_contains"
+	HTTPStorageParamStatus        = "status"
+	HTTPStorageParamLimit         = "limit"
+	HTTPStorageParamOffset        = "offset"
+	HTTPStorageParamAllVersions   = "all_versions"
+	HTTPStorageParamExpiredAsOf   = "expired_as_of"
+	HTTPStorageParamSearch        = "search"
+	HTTPStorageParamMetadata      = "metadata"
+	HTTPStorageParamCreatedAfter  = "created_after"
+	HTTPStorageParamCreatedBefore = "created_before"
+	HTTPStorageParamUpdatedAfter  = "updated_after"
+	HTTPStorageParamUpdatedBefore = "updated_before"
+	HTTPStorageParamSort          = "sort"
+	HTTPStorageParamSortDesc      = "desc"
 )
 
 // PostgreSQL storage driver configuration defaults
@@ -318,6 +517,7 @@ const (
 	SchemaTypeBoolean = "boolean"
 	SchemaTypeArray   = "array"
 	SchemaTypeObject  = "object"
+	SchemaTypeInteger = "integer"
 )
 
 // Model parameter map keys (for ToMap conversion)
@@ -349,6 +549,27 @@ const (
 	ParamKeyBudgetTokens          = "budget_tokens"
 )
 
+// Provider-neutral reasoning keys, efforts and budgets (ReasoningConfig)
+const (
+	ParamKeyReasoning           = "reasoning"
+	ReasoningKeyEffort          = "effort"
+	ReasoningKeyBudgetTokens    = "budget_tokens"
+	ReasoningKeyIncludeThoughts = "include_thoughts"
+
+	ReasoningEffortLow    = "low"
+	ReasoningEffortMedium = "medium"
+	ReasoningEffortHigh   = "high"
+
+	// Thinking budgets of the efforts, for providers that take a budget
+	ReasoningBudgetLow    = 1024
+	ReasoningBudgetMedium = 8192
+	ReasoningBudgetHigh   = 24576
+
+	GeminiKeyThinkingConfig  = "thinkingConfig"
+	GeminiKeyThinkingBudget  = "thinkingBudget"
+	GeminiKeyIncludeThoughts = "includeThoughts"
+)
+
 // Gemini-specific parameter keys
 const (
 	ParamKeyGenerationConfig     = "generationConfig"
@@ -361,6 +582,113 @@ const (
 	GeminiResponseMimeJSON       = "application/json"
 )
 
+// Provider request payload keys (CompiledPrompt.ToProviderPayload)
+const (
+	PayloadKeyMessages             = "messages"
+	PayloadKeyTools                = "tools"
+	PayloadKeyToolChoice           = "tool_choice"
+	PayloadKeyFunctionDeclarations = "function_declarations"
+	PayloadKeyToolChoiceType       = "type"
+	PayloadKeyContent              = "content"
+	PayloadKeyParts                = "parts"
+	PayloadKeyPromptCacheKey       = "prompt_cache_key" // OpenAI prompt cache routing hint
+)
+
+// OpenAI Responses API payload keys and values (ToOpenAIResponses)
+const (
+	ResponsesKeyInstructions    = "instructions"
+	ResponsesKeyInput           = "input"
+	ResponsesKeyMaxOutputTokens = "max_output_tokens"
+	ResponsesKeyText            = "text"
+	ResponsesKeyFormat          = "format"
+	ResponsesKeyReasoning       = "reasoning"
+	ResponsesKeyEffort          = "effort"
+	ResponsesKeySummary         = "summary"
+	ResponsesSummaryAuto        = "auto"
+	ResponsesKeyCallID          = "call_id"
+	ResponsesKeyOutput          = "output"
+	ResponsesKeyImageURL        = "image_url"
+	ResponsesTypeMessage        = "message"
+	ResponsesTypeInputText      = "input_text"
+	ResponsesTypeOutputText     = "output_text"
+	ResponsesTypeInputImage     = "input_image"
+	ResponsesTypeInputFile      = "input_file"
+	ResponsesTypeFunctionCall   = "function_call"
+	ResponsesTypeFunctionOutput = "function_call_output"
+
+	// ProviderOptionReasoningEffort is the Chat Completions reasoning_effort
+	// provider option, nested under reasoning.effort for the Responses API.
+	ProviderOptionReasoningEffort = "reasoning_effort"
+
+	// DefaultResponsesReasoningEffort is the reasoning effort used when
+	// thinking is enabled without an explicit effort.
+	DefaultResponsesReasoningEffort = ReasoningEffortMedium
+)
+
+// PromptCacheKeyBytes is the number of hash bytes in CompiledPrompt.PromptCacheKey
+const PromptCacheKeyBytes = 16
+
+// Multimodal content part payload keys and values (ContentPart serialization)
+const (
+	PartKeyType            = "type"
+	PartKeyText            = "text"
+	PartKeyURL             = "url"
+	PartKeyDetail          = "detail"
+	PartKeyImageURL        = "image_url"
+	PartKeyFile            = "file"
+	PartKeyFileID          = "file_id"
+	PartKeyFileData        = "file_data"
+	PartKeyFilename        = "filename"
+	PartKeySource          = "source"
+	PartKeyMediaType       = "media_type"
+	PartKeyData            = "data"
+	PartKeyTitle           = "title"
+	PartKeyInlineData      = "inline_data"
+	PartKeyGeminiFileData  = "file_data"
+	PartKeyMimeType        = "mime_type"
+	PartKeyFileURI         = "file_uri"
+	PartKeyCacheControl    = "cache_control"
+	CacheControlEphemeral  = "ephemeral"
+	PartTypeOpenAIImageURL = "image_url"
+	PartTypeAnthropicImage = "image"
+	PartTypeAnthropicDoc   = "document"
+	PartSourceTypeURL      = "url"
+	PartSourceTypeBase64   = "base64"
+	PartSourceTypeFile     = "file"
+)
+
+// Tool call and tool result payload keys and values
+const (
+	PayloadKeyToolCalls           = "tool_calls"
+	PayloadKeyToolCallID          = "tool_call_id"
+	PayloadKeyFunction            = "function"
+	PayloadKeyArguments           = "arguments"
+	PartKeyID                     = "id"
+	PartKeyName                   = "name"
+	PartKeyInput                  = "input"
+	PartKeyToolUseID              = "tool_use_id"
+	PartKeyIsError                = "is_error"
+	PartKeyArgs                   = "args"
+	PartKeyResponse               = "response"
+	PartKeyGeminiFunctionCall     = "function_call"
+	PartKeyGeminiFunctionResponse = "function_response"
+	PartTypeAnthropicToolUse      = "tool_use"
+	PartTypeAnthropicToolResult   = "tool_result"
+	ToolCallTypeFunction          = "function"
+	GeminiRoleModel               = "model"
+	GeminiResponseKeyContent      = "content"
+	GeminiResponseKeyError        = "error"
+	ContextKeyToolResults         = "tool_results"
+)
+
+// Tool choice strategies
+const (
+	ToolChoiceAuto         = "auto"
+	ToolChoiceNone         = "none"
+	ToolChoiceRequired     = "required"
+	ToolChoiceAnthropicAny = "any" // Anthropic's equivalent of "required"
+)
+
 // Error format strings for type validation
 const (
 	ErrFmtTypeMismatch = "expected %s, got %s"
@@ -377,6 +705,171 @@ const (
 	ProviderAzure     = "azure"
 	ProviderMistral   = "mistral"
 	ProviderCohere    = "cohere"
+
+	// ProviderOpenAIResponses targets the OpenAI Responses API rather than
+	// Chat Completions (see ExecutionConfig.ToOpenAIResponses).
+	ProviderOpenAIResponses = "openai_responses"
+
+	// ProviderBedrock targets AWS Bedrock (see ExecutionConfig.ToBedrock).
+	ProviderBedrock = "bedrock"
+
+	// Local inference servers (see ExecutionConfig.ToOllama and ToLlamaCpp)
+	ProviderOllama   = "ollama"
+	ProviderLlamaCpp = "llamacpp"
+
+	// Gateways routing OpenAI-style requests (see GatewayConfig)
+	ProviderOpenRouter = "openrouter"
+	ProviderLiteLLM    = "litellm"
+)
+
+// Execution capabilities: the settings of the provider capability matrix
+// (CheckCompatibility, ProviderSupports). Nested settings are dotted.
+const (
+	CapabilityTemperature       = "temperature"
+	CapabilityMaxTokens         = "max_tokens"
+	CapabilityTopP              = "top_p"
+	CapabilityTopK              = "top_k"
+	CapabilityStopSequences     = "stop_sequences"
+	CapabilityMinP              = "min_p"
+	CapabilityRepetitionPenalty = "repetition_penalty"
+	CapabilitySeed              = "seed"
+	CapabilityLogprobs          = "logprobs"
+	CapabilityStopTokenIDs      = "stop_token_ids"
+	CapabilityLogitBias         = "logit_bias"
+	CapabilityThinking          = "thinking"
+	CapabilityReasoning         = "reasoning"
+	CapabilityResponseFormat    = "response_format"
+	CapabilityStrictSchema      = "response_format.strict"
+	CapabilityGuidedDecoding    = "guided_decoding"
+	CapabilityGuidedRegex       = "guided_decoding.regex"
+	CapabilityGuidedLark        = "guided_decoding.lark"
+	CapabilityImage             = "image"
+	CapabilityImageStyle        = "image.style"
+	CapabilityImageQuality      = "image.quality"
+	CapabilityImageAspectRatio  = "image.aspect_ratio"
+	CapabilityAudio             = "audio"
+	CapabilityEmbedding         = "embedding"
+	CapabilityStreaming         = "streaming"
+	CapabilityGateway           = "gateway"
+
+	capabilitySeparator           = "."
+	capabilityKeyBedrockAnthropic = ProviderBedrock + capabilitySeparator + BedrockFamilyAnthropic
+	capabilityKeyBedrockTitan     = ProviderBedrock + capabilitySeparator + BedrockTitanPrefix
+
+	ParamKeyProvider            = "provider"
+	ErrFmtCompatUnknownProvider = "unknown provider %q"
+	ErrFmtCompatDropped         = "%s is not supported by %s and will be dropped"
+	ErrFmtCompatUnsupported     = "%s is not supported by %s"
+)
+
+// Gateway routing payload keys (GatewayConfig)
+const (
+	ParamKeyGateway          = "gateway"
+	GatewayKeyModels         = "models"
+	GatewayKeyProvider       = "provider"
+	GatewayKeyOrder          = "order"
+	GatewayKeyIgnore         = "ignore"
+	GatewayKeyAllowFallbacks = "allow_fallbacks"
+	GatewayKeyMaxPrice       = "max_price"
+	GatewayKeyPrompt         = "prompt"
+	GatewayKeyCompletion     = "completion"
+	GatewayKeyFallbacks      = "fallbacks"
+	GatewayKeyNumRetries     = "num_retries"
+	GatewayKeyType           = "type"
+	GatewayKeyProviders      = "providers"
+)
+
+// Fallback policy keys and defaults (FallbackConfig)
+const (
+	ParamKeyFallback                 = "fallback"
+	FallbackKeyTargets               = "targets"
+	FallbackKeyProvider              = "provider"
+	FallbackKeyModel                 = "model"
+	FallbackKeyMaxRetries            = "max_retries"
+	FallbackKeyInitialBackoff        = "initial_backoff_seconds"
+	FallbackKeyMaxBackoff            = "max_backoff_seconds"
+	FallbackKeyBackoffMultiplier     = "backoff_multiplier"
+	FallbackKeyRetryOn               = "retry_on"
+	DefaultFallbackInitialBackoff    = 1.0  // seconds
+	DefaultFallbackMaxBackoff        = 30.0 // seconds
+	DefaultFallbackBackoffMultiplier = 2.0
+)
+
+// RetryCondition classifies a failed provider call for FallbackConfig.RetryOn
+type RetryCondition string
+
+// Retry conditions
+const (
+	RetryOnRateLimit   RetryCondition = "rate_limit"   // HTTP 429
+	RetryOnTimeout     RetryCondition = "timeout"      // HTTP 408 and 504, request timeouts
+	RetryOnServerError RetryCondition = "server_error" // Other HTTP 5xx
+	RetryOnOverloaded  RetryCondition = "overloaded"   // HTTP 503 and 529
+	RetryOnConnection  RetryCondition = "connection"   // Network failures
+)
+
+// StatusProviderOverloaded is the non-standard HTTP status Anthropic returns
+// when its API is overloaded
+const StatusProviderOverloaded = 529
+
+// Ollama and llama.cpp payload keys and values (ToOllama, ToLlamaCpp)
+const (
+	OllamaKeyOptions       = "options"
+	OllamaKeyNumPredict    = "num_predict"
+	OllamaKeyRepeatPenalty = "repeat_penalty"
+	OllamaKeyFormat        = "format"
+	OllamaFormatJSON       = "json"
+	OllamaKeyImages        = "images"
+	OllamaKeyToolName      = "tool_name"
+	LlamaCppKeyJSONSchema  = "json_schema"
+	LlamaCppKeyGrammar     = "grammar"
+
+	// GGUFModelSuffix marks llama.cpp model files ("model-q4_k_m.gguf")
+	GGUFModelSuffix = ".gguf"
+)
+
+// AWS Bedrock payload keys and values (ToBedrock)
+const (
+	BedrockKeyAnthropicVersion     = "anthropic_version"
+	BedrockAnthropicVersion        = "bedrock-2023-05-31"
+	BedrockKeyInputText            = "inputText"
+	BedrockKeyTextGenerationConfig = "textGenerationConfig"
+	BedrockKeyMaxTokenCount        = "maxTokenCount"
+	BedrockKeyModelID              = "modelId"
+	BedrockKeyInferenceConfig      = "inferenceConfig"
+	BedrockKeyMaxTokens            = "maxTokens"
+	BedrockKeyTopP                 = "topP"
+	BedrockKeyStopSequences        = "stopSequences"
+	BedrockKeyAdditionalFields     = "additionalModelRequestFields"
+	BedrockKeyToolConfig           = "toolConfig"
+	BedrockKeyToolChoice           = "toolChoice"
+	BedrockKeyToolSpec             = "toolSpec"
+	BedrockKeyInputSchema          = "inputSchema"
+	BedrockKeyJSON                 = "json"
+	BedrockKeyToolUse              = "toolUse"
+	BedrockKeyToolUseID            = "toolUseId"
+	BedrockKeyToolResult           = "toolResult"
+	BedrockKeyStatus               = "status"
+	BedrockStatusError             = "error"
+	BedrockToolChoiceAuto          = "auto"
+	BedrockToolChoiceAny           = "any"
+
+	// Bedrock model families (the vendor segment of a model ID such as
+	// "anthropic.claude-3-5-sonnet-20240620-v1:0")
+	BedrockFamilyAnthropic = "anthropic"
+	BedrockFamilyAmazon    = "amazon"
+	BedrockTitanPrefix     = "titan"
+
+	// Titan text prompt turn prefixes
+	BedrockTitanUserPrefix = "User: "
+	BedrockTitanBotPrefix  = "Bot:"
+)
+
+// Azure OpenAI provider options and parameters (ToAzureOpenAI)
+const (
+	ProviderOptionAzureDeployment = "deployment"
+	ProviderOptionAzureAPIVersion = "api_version"
+	DefaultAzureAPIVersion        = "2024-10-21"
+	ParamKeyMaxCompletionTokens   = "max_completion_tokens"
 )
 
 // Response format types for structured outputs
@@ -395,6 +888,15 @@ const (
 	GuidedBackendAuto             = "auto"
 )
 
+// Guided decoding grammar formats and their start rules
+const (
+	GrammarFormatGBNF = "gbnf" // llama.cpp GBNF, the default
+	GrammarFormatLark = "lark" // Lark EBNF
+
+	GrammarRootRuleGBNF = "root"
+	GrammarRootRuleLark = "start"
+)
+
 // JSON Schema property keys
 const (
 	SchemaKeyType                 = "type"
@@ -409,6 +911,29 @@ const (
 	SchemaKeyStrict               = "strict"
 	SchemaKeyFormat               = "format"
 	SchemaKeyJSONSchema           = "json_schema"
+	SchemaKeyMinimum              = "minimum"
+	SchemaKeyMaximum              = "maximum"
+	SchemaKeyMinLength            = "minLength"
+	SchemaKeyMaxLength            = "maxLength"
+	SchemaKeyMinItems             = "minItems"
+	SchemaKeyMaxItems             = "maxItems"
+	SchemaKeyPattern              = "pattern"
+	SchemaKeyDefault              = "default"
+	SchemaFormatDateTime          = "date-time"
+)
+
+// Struct tags read by SchemaFromType
+const (
+	StructTagValidate    = "validate"    // go-playground/validator rules: required, oneof, min, max
+	StructTagDescription = "description" // Property description
+	StructTagOmitEmpty   = "omitempty"
+
+	ValidateRuleRequired  = "required"
+	ValidateRuleOneOf     = "oneof"
+	ValidateRuleMin       = "min"
+	ValidateRuleMax       = "max"
+	ValidateRuleSeparator = ","
+	ValidateRuleAssign    = "="
 )
 
 // vLLM guided decoding parameter keys
@@ -453,14 +978,20 @@ const (
 	PromptFieldSample        = "sample"
 
 	// go-prompty extension fields
-	PromptFieldType        = "type"
-	PromptFieldExecution   = "execution"
-	PromptFieldExtensions  = "extensions"
-	PromptFieldSkills      = "skills"
-	PromptFieldTools       = "tools"
-	PromptFieldContext     = "context"
-	PromptFieldConstraints = "constraints"
-	PromptFieldMessages    = "messages"
+	PromptFieldType         = "type"
+	PromptFieldExecution    = "execution"
+	PromptFieldEnvironments = "environments"
+	PromptFieldVariants     = "variants"
+	PromptFieldTests        = "tests"
+	PromptFieldExtensions   = "extensions"
+	PromptFieldSkills       = "skills"
+	PromptFieldTools        = "tools"
+	PromptFieldContext      = "context"
+	PromptFieldConstraints  = "constraints"
+	PromptFieldMessages     = "messages"
+
+	// PromptFieldBody holds the body of JSON documents (DocumentFormatJSON)
+	PromptFieldBody = "body"
 )
 
 // v2.0 Reference resolution constants
@@ -539,6 +1070,17 @@ const (
 	ErrMsgPostgresAlreadyClosed         = "PostgreSQL storage is already closed"
 )
 
+// HTTP storage error messages
+const (
+	ErrMsgHTTPStorageEmptyURL       = "HTTP storage base URL is empty"
+	ErrMsgHTTPStorageInvalidURL     = "HTTP storage base URL is invalid"
+	ErrMsgHTTPStorageRequestFailed  = "HTTP storage request failed"
+	ErrMsgHTTPStorageUnexpectedCode = "HTTP storage returned unexpected status"
+	ErrMsgHTTPStorageDecodeFailed   = "failed to decode HTTP storage response"
+	ErrMsgHTTPStorageInvalidVersion = "invalid template version in request path"
+	ErrMsgHTTPStorageInvalidBody    = "invalid request body"
+)
+
 // Access control message formats
 const (
 	ErrFmtOperationAllowed    = "operation %s is allowed"
@@ -584,6 +1126,18 @@ const (
 	CatalogFormatFunctionCalling CatalogFormat = "function_calling"
 )
 
+// Skills catalog selection modes
+const (
+	// SkillsSelectionAll lists every skill of the agent (the default).
+	SkillsSelectionAll = "all"
+	// SkillsSelectionRelevant lists the skills most similar to a query,
+	// ranked by a SemanticDocumentResolver.
+	SkillsSelectionRelevant = "relevant"
+	// DefaultRelevantSkillsLimit is the number of relevant skills listed
+	// without a limit attribute.
+	DefaultRelevantSkillsLimit = 5
+)
+
 // v2.1 Catalog resolver tag names
 const (
 	TagNameSkillsCatalog = "prompty.skills_catalog"
@@ -598,50 +1152,162 @@ const (
 
 // v2.1 Error message constants
 const (
-	ErrMsgNotAnAgent              = "document is not an agent type"
-	ErrMsgSkillNotFound           = "skill not found"
-	ErrMsgSkillRefEmpty           = "skill reference slug is empty"
-	ErrMsgSkillRefAmbiguous       = "skill reference is ambiguous"
-	ErrMsgNoExecutionConfig       = "execution configuration is required"
-	ErrMsgNoProvider              = "provider is required in execution config"
-	ErrMsgNoModel                 = "model is required in execution config"
-	ErrMsgCompilationFailed       = "agent compilation failed"
-	ErrMsgInvalidDocumentType     = "invalid document type"
-	ErrMsgPromptNoSkillsAllowed   = "prompt type does not support skills"
-	ErrMsgPromptNoToolsAllowed    = "prompt type does not support tools"
-	ErrMsgPromptNoConstraints     = "prompt type does not support constraints"
-	ErrMsgAgentMessagesInvalid    = "agent messages must include system or user role"
-	ErrMsgSkillRefInvalidVersion  = "invalid skill reference version"
-	ErrMsgCatalogGenerationFailed = "catalog generation failed"
-	ErrMsgSkillNoSkillsAllowed    = "skill type does not support nested skills"
-	ErrMsgInvalidSkillInjection   = "invalid skill injection mode"
-	ErrMsgMCPServerNameEmpty      = "MCP server name is empty"
-	ErrMsgMCPServerURLEmpty       = "MCP server URL is empty"
-	ErrMsgMessageTemplateNoRole   = "message template requires a role"
-	ErrMsgMessageTemplateNoBody   = "message template requires content"
-	ErrMsgInlineSkillNoSlug       = "inline skill requires a slug"
-	ErrMsgInlineSkillNoBody       = "inline skill requires a body"
-	ErrMsgAgentNoBodyOrMessages   = "agent requires body or messages"
-	ErrMsgUnsupportedMsgProvider  = "unsupported provider for message serialization"
-	ErrMsgNoDocumentResolver      = "no document resolver configured"
+	ErrMsgNotAnAgent               = "document is not an agent type"
+	ErrMsgSkillNotFound            = "skill not found"
+	ErrMsgSkillVersionNotFound     = "no skill version satisfies constraint"
+	ErrMsgInvalidVersionConstraint = "invalid version constraint"
+	ErrMsgSkillRefEmpty            = "skill reference slug is empty"
+	ErrMsgSkillRefAmbiguous        = "skill reference is ambiguous"
+	ErrMsgNoExecutionConfig        = "execution configuration is required"
+	ErrMsgNoProvider               = "provider is required in execution config"
+	ErrMsgNoModel                  = "model is required in execution config"
+	ErrMsgCompilationFailed        = "agent compilation failed"
+	ErrMsgInvalidDocumentType      = "invalid document type"
+	ErrMsgPromptNoSkillsAllowed    = "prompt type does not support skills"
+	ErrMsgPromptNoToolsAllowed     = "prompt type does not support tools"
+	ErrMsgPromptNoConstraints      = "prompt type does not support constraints"
+	ErrMsgAgentMessagesInvalid     = "agent messages must include system or user role"
+	ErrMsgSkillRefInvalidVersion   = "invalid skill reference version"
+	ErrMsgCatalogGenerationFailed  = "catalog generation failed"
+	ErrMsgSkillNoSkillsAllowed     = "skill type does not support nested skills"
+	ErrMsgInvalidSkillInjection    = "invalid skill injection mode"
+	ErrMsgMCPServerNameEmpty       = "MCP server name is empty"
+	ErrMsgMCPServerURLEmpty        = "MCP server URL is empty"
+	ErrMsgMessageTemplateNoRole    = "message template requires a role"
+	ErrMsgMessageTemplateNoBody    = "message template requires content"
+	ErrMsgInlineSkillNoSlug        = "inline skill requires a slug"
+	ErrMsgInlineSkillNoBody        = "inline skill requires a body"
+	ErrMsgAgentNoBodyOrMessages    = "agent requires body or messages"
+	ErrMsgUnsupportedMsgProvider   = "unsupported provider for message serialization"
+	ErrMsgNoDocumentResolver       = "no document resolver configured"
+	ErrMsgToolResultNoCallID       = "tool result requires a call ID"
+	ErrMsgToolResultNoName         = "tool result requires a tool name"
 )
 
 // v2.1 Error code constants
 const (
-	ErrCodeAgent   = "PROMPTY_AGENT"
-	ErrCodeCompile = "PROMPTY_COMPILE"
-	ErrCodeCatalog = "PROMPTY_CATALOG"
+	ErrCodeAgent     = "PROMPTY_AGENT"
+	ErrCodeCompile   = "PROMPTY_COMPILE"
+	ErrCodeCatalog   = "PROMPTY_CATALOG"
+	ErrCodeCost      = "PROMPTY_COST"
+	ErrCodeEmbedding = "PROMPTY_EMBEDDING"
+	ErrCodeModel     = "PROMPTY_MODEL"
+	ErrCodeFallback  = "PROMPTY_FALLBACK"
+	ErrCodeVariant   = "PROMPTY_VARIANT"
+	ErrCodeSelfTest  = "PROMPTY_SELFTEST"
+	ErrCodeReplay    = "PROMPTY_REPLAY"
+)
+
+// Cost estimation error messages
+const (
+	ErrMsgCostUnknownModel   = "no pricing for model"
+	ErrMsgCostInvalidPricing = "invalid pricing table"
+	ErrMsgCostNoExecution    = "template has no execution config to price"
+)
+
+// Model registry error messages
+const (
+	ErrMsgModelAliasNoName        = "model alias requires a name"
+	ErrMsgModelAliasNoModel       = "model alias requires a model"
+	ErrMsgModelAliasInvalid       = "invalid model alias execution config"
+	ErrMsgModelRegistryInvalid    = "invalid model registry document"
+	ErrMsgModelAliasNoEnvironment = "model alias override requires an environment"
 )
 
+// Prompt variant error messages
+const (
+	ErrMsgVariantNotFound      = "prompt variant not found"
+	ErrMsgVariantInvalidWeight = "prompt variant weight must not be negative"
+	ErrMsgVariantEmptyPatch    = "prompt variant patch requires find text"
+	ErrMsgVariantPatchNoMatch  = "prompt variant patch text not found in body"
+	ErrMsgVariantBlockNotFound = "prompt variant overrides an unknown block"
+)
+
+// Prompt self-test error messages
+const (
+	ErrMsgSelfTestNoName        = "prompt test requires a name"
+	ErrMsgSelfTestDuplicateName = "duplicate prompt test name"
+	ErrMsgSelfTestBadPattern    = "prompt test has an invalid matches pattern"
+	ErrMsgSelfTestBadJSONPath   = "prompt test has an invalid JSON path"
+)
+
+// Fallback execution error messages
+const (
+	ErrMsgFallbackExhausted = "all fallback attempts failed"
+)
+
+// Embedding error messages
+const (
+	ErrMsgEmbeddingFailed        = "failed to compute embeddings"
+	ErrMsgEmbeddingCountMismatch = "embedder returned wrong number of embeddings"
+	ErrMsgEmbeddingNoIndexer     = "no embedding indexer configured"
+)
+
+// Tool definition import error messages
+const (
+	ErrMsgToolImportInvalid       = "invalid tool definition document"
+	ErrMsgToolImportVersion       = "unsupported OpenAPI version; OpenAPI 3.x is required"
+	ErrMsgToolImportUnresolvedRef = "unresolved reference in tool definition document"
+	ErrMsgToolImportDuplicateName = "duplicate tool name"
+	ErrMsgToolExportNoName        = "tool export requires named functions"
+	ErrMsgToolImportUnnamed       = "tool definition without a name"
+)
+
+// Bundle error messages
+const (
+	ErrMsgBundleInvalid         = "invalid bundle archive"
+	ErrMsgBundleNoManifest      = "bundle has no manifest"
+	ErrMsgBundleFormatVersion   = "unsupported bundle format version"
+	ErrMsgBundleUnnamed         = "bundle document without a name"
+	ErrMsgBundleDuplicate       = "duplicate document in bundle"
+	ErrMsgBundleMissingFile     = "bundle manifest lists a missing file"
+	ErrMsgBundleResourcePath    = "invalid bundle resource path"
+	ErrMsgBundleDocumentInvalid = "invalid bundle document"
+	ErrMsgBundleHashMismatch    = "bundle document does not match its content hash"
+	ErrMsgBundleUnsatisfied     = "unsatisfied bundle dependency"
+	ErrMsgBundleConflict        = "bundle document conflicts with the installed version"
+)
+
+// Execution recording error messages
+const (
+	ErrMsgRecordingInvalid       = "invalid execution recording"
+	ErrMsgRecordingFormatVersion = "unsupported execution recording format version"
+	ErrMsgRecordingWriteFailed   = "failed to write execution recording"
+	ErrMsgReplayNoRecordedResult = "no recorded result for resolver call"
+)
+
+// RecordingFormatVersion is the ExecutionRecording format written by
+// ExecutionRecorder.
+const RecordingFormatVersion = "1"
+
 // v2.1 Metadata keys for agent context
 const (
-	MetaKeyDocumentType  = "document_type"
-	MetaKeySkillSlug     = "skill_slug"
-	MetaKeyInjectionMode = "injection_mode"
-	MetaKeySkillVersion  = "skill_version"
-	MetaKeyMessageIndex  = "message_index"
-	MetaKeyMessageRole   = "message_role"
-	MetaKeyCompileStage  = "compile_stage"
+	MetaKeyDocumentType      = "document_type"
+	MetaKeySkillSlug         = "skill_slug"
+	MetaKeyInjectionMode     = "injection_mode"
+	MetaKeySkillVersion      = "skill_version"
+	MetaKeyVersionConstraint = "version_constraint"
+	MetaKeyMessageIndex      = "message_index"
+	MetaKeyToolResultIndex   = "tool_result_index"
+	MetaKeyModelAlias        = "model_alias"
+	MetaKeyEnvironment       = "environment"
+	MetaKeyVariant           = "variant"
+	MetaKeyTestName          = "test_name"
+	MetaKeyBlockName         = "block_name"
+	MetaKeyAttempts          = "attempts"
+	MetaKeyMessageRole       = "message_role"
+	MetaKeyCompileStage      = "compile_stage"
+	MetaKeyTimeout           = "timeout"
+	MetaKeyOperation         = "operation"
+	MetaKeySubject           = "subject"
+	MetaKeyRetryAfter        = "retry_after"
+	MetaKeyHookPoint         = "hook_point"
+)
+
+// Agent input keys read during compilation
+const (
+	InputKeyMessage        = "message"
+	InputKeyConversationID = "conversation_id"
 )
 
 // v2.1 Special template name for self-reference
@@ -658,6 +1324,13 @@ const (
 	ContextKeySkills      = "skills"
 	ContextKeyTools       = "tools"
 	ContextKeySelfBody    = "_selfBody"
+
+	// ContextKeyConversation holds the transcript of CompileOptions.ConversationID
+	ContextKeyConversation = "conversation"
+
+	// ContextKeySkillsSelector holds the relevant-skills selector of
+	// {~prompty.skills_catalog selection="relevant"~}
+	ContextKeySkillsSelector = "_skillsSelector"
 )
 
 // v2.1 Skill injection markers
@@ -848,3 +1521,115 @@ const (
 	AudioMaxDuration       = 600.0
 	EmbeddingMaxDimensions = 65536
 )
+
+// Lint rule IDs reported by Lint
+const (
+	LintRuleUnusedBlock        = "BLK001"  // Block definition that can never be rendered
+	LintRuleShadowedLoopVar    = "LOOP003" // Nested loop reuses an outer loop variable
+	LintRuleSuspiciousDefault  = "VAR003"  // Default value that looks like a placeholder
+	LintRuleNestedConditional  = "COND001" // Conditionals nested beyond the configured depth
+	LintRuleOversizedText      = "TEXT001" // Inline text larger than the configured limit
+	LintRuleDeprecatedTag      = "TAG002"  // Tag that is deprecated or legacy
+	LintRuleMissingDescription = "META001" // Frontmatter prompt or input without description
+)
+
+// Validation issue codes reported by Validate. Codes are stable across
+// releases and locales, so they can key translations and log queries.
+const (
+	IssueCodeParseFailed       = "VAL001"    // Template or frontmatter does not parse
+	IssueCodeUnknownTag        = "VAL002"    // Tag without a registered resolver
+	IssueCodeInvalidAttributes = "VAL003"    // Attributes rejected by the tag's resolver
+	IssueCodeInvalidOnError    = "VAL004"    // Unknown onerror strategy
+	IssueCodeInvalidCacheTTL   = "VAL005"    // Invalid cache attribute
+	IssueCodeMissingInclude    = "VAL006"    // Included template not registered
+	IssueCodeMissingImport     = "VAL007"    // Imported template not registered
+	IssueCodeMissingFallback   = "VAL008"    // onerror fallback template not registered
+	IssueCodeForMissingItem    = "VAL009"    // prompty.for without item
+	IssueCodeForMissingIn      = "VAL010"    // prompty.for without in
+	IssueCodeForInvalidLimit   = "VAL011"    // prompty.for with a negative limit
+	IssueCodeSwitchMissingEval = "VAL012"    // prompty.switch without eval
+	IssueCodeCaseMissingValue  = "VAL013"    // prompty.case without value or eval
+	IssueCodeUnknownAttribute  = "STRICT001" // Attribute the tag does not accept
+	IssueCodeUnusedDefault     = "STRICT002" // Default of a required input
+	IssueCodeUndeclaredInput   = "STRICT003" // Reference to an undeclared input
+)
+
+// Lint defaults
+const (
+	DefaultLintMaxConditionalDepth = 3
+	DefaultLintMaxTextLength       = 4096
+)
+
+// Lint finding messages
+const (
+	LintMsgUnusedBlock               = "block is nested inside other content of a child template and is never used as an override"
+	LintMsgShadowedLoopVar           = "loop variable shadows a variable of an enclosing loop"
+	LintMsgSuspiciousDefault         = "default value looks like a placeholder"
+	LintMsgDefaultContainsTag        = "default value contains a tag delimiter; defaults are not rendered"
+	LintMsgNestedConditional         = "conditional nesting exceeds maximum depth"
+	LintMsgOversizedText             = "inline text exceeds maximum length in bytes; consider moving it into an included template"
+	LintMsgDeprecatedTag             = "tag is deprecated"
+	LintMsgMissingPromptDescription  = "frontmatter has no description"
+	LintMsgMissingInputDescription   = "frontmatter input has no description"
+	LintMsgDeprecatedConfigTagReason = "use YAML frontmatter instead"
+)
+
+// FormatIndent is the indentation Format applies per block nesting level
+// to lines starting with a tag whose indentation is not rendered, and per
+// nesting level of frontmatter YAML.
+const FormatIndent = "  "
+
+// TraceNodeType identifies the kind of AST node in an ExplainJSON trace
+type TraceNodeType string
+
+// Trace node types reported by ExplainJSON
+const (
+	TraceNodeTypeText        TraceNodeType = "text"
+	TraceNodeTypeTag         TraceNodeType = "tag"
+	TraceNodeTypeConditional TraceNodeType = "conditional"
+	TraceNodeTypeFor         TraceNodeType = "for"
+	TraceNodeTypeSwitch      TraceNodeType = "switch"
+	TraceNodeTypeBlock       TraceNodeType = "block"
+)
+
+// TraceLabelMaxLength is the maximum length of a text node label in an ExplainJSON trace
+const TraceLabelMaxLength = 40
+
+// ConstraintKind identifies the agent constraint a ConstraintViolation breaks
+type ConstraintKind string
+
+// Constraint kinds reported by ConstraintEnforcer
+const (
+	ConstraintKindMaxTurns         ConstraintKind = "max_turns"
+	ConstraintKindMaxTokensPerTurn ConstraintKind = "max_tokens_per_turn"
+	ConstraintKindAllowedDomains   ConstraintKind = "allowed_domains"
+	ConstraintKindBlockedDomains   ConstraintKind = "blocked_domains"
+	ConstraintKindTools            ConstraintKind = "tools"
+	ConstraintKindBehavioral       ConstraintKind = "behavioral"
+	ConstraintKindSafety           ConstraintKind = "safety"
+)
+
+// OutputRuleAction is what ConstraintEnforcer.FilterOutput does with a match
+type OutputRuleAction string
+
+// Output rule actions
+const (
+	OutputRuleActionReport OutputRuleAction = ""       // Report the violation, keep the text
+	OutputRuleActionRedact OutputRuleAction = "redact" // Replace matches with the rule's replacement
+	OutputRuleActionBlock  OutputRuleAction = "block"  // Withhold the whole output
+)
+
+// DefaultOutputRedaction replaces matches of redacting output rules without a replacement
+const DefaultOutputRedaction = "[REDACTED]"
+
+// Constraint violation messages
+const (
+	ConstraintMsgMaxTurns         = "turn exceeds max_turns"
+	ConstraintMsgMaxTokensPerTurn = "turn exceeds max_tokens_per_turn"
+	ConstraintMsgDomainNotAllowed = "domain is not in allowed_domains"
+	ConstraintMsgDomainBlocked    = "domain is in blocked_domains"
+	ConstraintMsgDomainInvalid    = "url has no host"
+	ConstraintMsgToolNotDeclared  = "tool is not declared by the agent"
+	ConstraintMsgToolsDisabled    = "agent tool_choice is none"
+	ConstraintMsgOutputRule       = "output matches constraint rule"
+)
@@ -41,6 +41,9 @@ type Context struct {
 	promptResolver PromptBodyResolver // v2.0: Prompt resolver for reference resolution
 	refDepth       int                // v2.0: Current reference resolution depth
 	refChain       []string           // v2.0: Chain of referenced prompt slugs for circular detection
+	provider       *providerState     // Optional lazy data source, shared by contexts derived for one execution
+	fieldMatch     FieldMatch         // How path segments match struct fields
+	includeChain   []string           // Templates being included, for cycle detection
 }
 
 // NewContext creates a new execution context with the given data.
@@ -72,14 +75,31 @@ func (c *Context) Get(path string) (any, bool) {
 }
 
 // getPath resolves a dot-notation path without locking (internal use).
+// The context's own data is searched first, then its data provider, then
+// the parent context.
 func (c *Context) getPath(path string) (any, bool) {
 	if path == "" {
 		return nil, false
 	}
 
 	parts := strings.Split(path, PathSeparator)
-	var current any = c.data
+	if val, ok := walkPath(c.data, parts, c.fieldMatch); ok {
+		return val, true
+	}
+	if c.provider != nil {
+		if val, ok := c.provider.lookup(parts, c.fieldMatch); ok {
+			return val, true
+		}
+	}
+	if c.parent != nil {
+		return c.parent.getPath(path)
+	}
+	return nil, false
+}
 
+// walkPath descends through nested maps, structs, and pointers along parts,
+// matching struct fields according to match. Empty parts are skipped.
+func walkPath(current any, parts []string, match FieldMatch) (any, bool) {
 	for _, part := range parts {
 		if part == "" {
 			continue
@@ -89,28 +109,21 @@ func (c *Context) getPath(path string) (any, bool) {
 		case map[string]any:
 			val, ok := v[part]
 			if !ok {
-				// Try parent context if not found
-				if c.parent != nil {
-					return c.parent.getPath(path)
-				}
 				return nil, false
 			}
 			current = val
 		case map[string]string:
 			val, ok := v[part]
 			if !ok {
-				if c.parent != nil {
-					return c.parent.getPath(path)
-				}
 				return nil, false
 			}
 			current = val
 		default:
-			// Can't traverse further
-			if c.parent != nil {
-				return c.parent.getPath(path)
+			val, ok := lookupField(current, part, match)
+			if !ok {
+				return nil, false
 			}
-			return nil, false
+			current = val
 		}
 	}
 
@@ -183,6 +196,8 @@ func (c *Context) Child(data map[string]any) interface{} {
 		promptResolver: c.promptResolver,
 		refDepth:       c.refDepth,
 		refChain:       c.refChain,
+		fieldMatch:     c.fieldMatch,
+		includeChain:   c.includeChain,
 	}
 }
 
@@ -287,6 +302,9 @@ func (c *Context) WithEngine(engine TemplateExecutor) *Context {
 		promptResolver: c.promptResolver,
 		refDepth:       c.refDepth,
 		refChain:       c.refChain,
+		fieldMatch:     c.fieldMatch,
+		includeChain:   c.includeChain,
+		provider:       c.provider,
 	}
 	return newCtx
 }
@@ -312,6 +330,9 @@ func (c *Context) WithDepth(depth int) *Context {
 		promptResolver: c.promptResolver,
 		refDepth:       c.refDepth,
 		refChain:       c.refChain,
+		fieldMatch:     c.fieldMatch,
+		includeChain:   c.includeChain,
+		provider:       c.provider,
 	}
 	return newCtx
 }
@@ -334,6 +355,9 @@ func (c *Context) WithPromptResolver(resolver PromptBodyResolver) *Context {
 		promptResolver: resolver,
 		refDepth:       c.refDepth,
 		refChain:       c.refChain,
+		fieldMatch:     c.fieldMatch,
+		includeChain:   c.includeChain,
+		provider:       c.provider,
 	}
 	return newCtx
 }
@@ -355,6 +379,9 @@ func (c *Context) WithRefDepth(depth int) *Context {
 		promptResolver: c.promptResolver,
 		refDepth:       depth,
 		refChain:       c.refChain,
+		fieldMatch:     c.fieldMatch,
+		includeChain:   c.includeChain,
+		provider:       c.provider,
 	}
 	return newCtx
 }
@@ -384,10 +411,37 @@ func (c *Context) WithRefChain(chain []string) *Context {
 		promptResolver: c.promptResolver,
 		refDepth:       c.refDepth,
 		refChain:       chainCopy,
+		fieldMatch:     c.fieldMatch,
+		includeChain:   c.includeChain,
+		provider:       c.provider,
 	}
 	return newCtx
 }
 
+// withData returns a new context with data in place of the context's own
+// data. It is used to apply the data rewritten by execute hooks.
+func (c *Context) withData(data map[string]any) *Context {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if data == nil {
+		data = make(map[string]any)
+	}
+	return &Context{
+		data:           data,
+		parent:         c.parent,
+		errorStrat:     c.errorStrat,
+		engine:         c.engine,
+		depth:          c.depth,
+		promptResolver: c.promptResolver,
+		refDepth:       c.refDepth,
+		refChain:       c.refChain,
+		fieldMatch:     c.fieldMatch,
+		includeChain:   c.includeChain,
+		provider:       c.provider,
+	}
+}
+
 // PromptResolver returns the prompt body resolver for reference resolution.
 // Implements internal.PromptResolverAccessor interface.
 // Returns interface{} to avoid import cycles with internal package.
@@ -401,6 +455,12 @@ func (c *Context) RefDepth() int {
 	return c.refDepth
 }
 
+// IncludeChain returns the chain of templates being included, outermost
+// first. Implements internal.IncludeChainAccessor interface.
+func (c *Context) IncludeChain() []string {
+	return c.includeChain
+}
+
 // RefChain returns the current chain of referenced prompt slugs.
 // Implements internal.RefChainAccessor interface.
 func (c *Context) RefChain() []string {
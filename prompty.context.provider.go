@@ -0,0 +1,84 @@
+package prompty
+
+import (
+	"strings"
+	"sync"
+)
+
+// DataProvider supplies template data on demand instead of up front, for
+// values that are expensive to fetch or compute and only worth loading when
+// a template actually references them.
+//
+// For a template path such as "user.profile.name", Lookup is called with
+// "user" first; if it returns a map, the rest of the path is resolved
+// inside it. Otherwise the longer prefixes "user.profile" and finally the
+// full path are tried, so a provider can serve whole objects, computed
+// leaf values, or both. Each looked-up path, found or not, is memoized for
+// the lifetime of the Context, so Lookup runs at most once per path.
+//
+// Lookups within one Context are serialized, so Lookup need not be
+// safe for concurrent use by a single execution.
+type DataProvider interface {
+	Lookup(path string) (any, bool)
+}
+
+// DataProviderFunc adapts a function to the DataProvider interface.
+type DataProviderFunc func(path string) (any, bool)
+
+// Lookup calls f(path).
+func (f DataProviderFunc) Lookup(path string) (any, bool) {
+	return f(path)
+}
+
+// NewContextWithProvider creates an execution context whose data comes from
+// provider. Values set with Set take precedence over the provider.
+func NewContextWithProvider(provider DataProvider) *Context {
+	ctx := NewContext(nil)
+	if provider != nil {
+		ctx.provider = &providerState{provider: provider, memo: make(map[string]providerResult)}
+	}
+	return ctx
+}
+
+// providerState memoizes DataProvider lookups for one Context lineage.
+type providerState struct {
+	provider DataProvider
+	mu       sync.Mutex
+	memo     map[string]providerResult
+}
+
+// providerResult is a memoized Lookup result.
+type providerResult struct {
+	value any
+	found bool
+}
+
+// lookup resolves path parts through the provider, trying the shortest
+// prefix first and descending into its value for the remaining parts.
+func (s *providerState) lookup(parts []string, match FieldMatch) (any, bool) {
+	segments := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			segments = append(segments, part)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for n := 1; n <= len(segments); n++ {
+		prefix := strings.Join(segments[:n], PathSeparator)
+		result, ok := s.memo[prefix]
+		if !ok {
+			result.value, result.found = s.provider.Lookup(prefix)
+			s.memo[prefix] = result
+		}
+		if !result.found {
+			continue
+		}
+		if val, ok := walkPath(result.value, segments[n:], match); ok {
+			return val, true
+		}
+	}
+	return nil, false
+}
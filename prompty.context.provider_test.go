@@ -0,0 +1,124 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingProvider serves values from a map and records every Lookup.
+type recordingProvider struct {
+	values  map[string]any
+	lookups []string
+}
+
+func (p *recordingProvider) Lookup(path string) (any, bool) {
+	p.lookups = append(p.lookups, path)
+	v, ok := p.values[path]
+	return v, ok
+}
+
+func TestTemplate_ExecuteWithProvider_Lazy(t *testing.T) {
+	provider := &recordingProvider{values: map[string]any{
+		"user":   map[string]any{"name": "Ada", "email": "ada@example.com"},
+		"orders": []any{"expensive"},
+	}}
+
+	tmpl, err := MustNew().Parse(`{~prompty.var name="user.name" /~} <{~prompty.var name="user.email" /~}> {~prompty.var name="user.name" /~}`)
+	require.NoError(t, err)
+
+	out, err := tmpl.ExecuteWithProvider(context.Background(), provider)
+	require.NoError(t, err)
+	assert.Equal(t, "Ada <ada@example.com> Ada", out)
+	// Only the referenced root is fetched, once
+	assert.Equal(t, []string{"user"}, provider.lookups)
+}
+
+func TestTemplate_ExecuteWithProvider_ComputedPaths(t *testing.T) {
+	provider := &recordingProvider{values: map[string]any{
+		"stats.total": 42,
+		"stats":       map[string]any{"avg": 3},
+	}}
+
+	tmpl, err := MustNew().Parse(`{~prompty.var name="stats.avg" /~}/{~prompty.var name="stats.total" /~}`)
+	require.NoError(t, err)
+
+	out, err := tmpl.ExecuteWithProvider(context.Background(), provider)
+	require.NoError(t, err)
+	assert.Equal(t, "3/42", out)
+	assert.Equal(t, []string{"stats", "stats.total"}, provider.lookups)
+}
+
+func TestTemplate_ExecuteWithProvider_LoopsAndConditions(t *testing.T) {
+	provider := DataProviderFunc(func(path string) (any, bool) {
+		switch path {
+		case "items":
+			return []any{map[string]any{"n": "a"}, map[string]any{"n": "b"}}, true
+		case "verbose":
+			return true, true
+		}
+		return nil, false
+	})
+
+	tmpl, err := MustNew().Parse(`{~prompty.if eval="verbose && len(items) > 1"~}{~prompty.for item="it" in="items"~}[{~prompty.var name="it.n" /~}]{~/prompty.for~}{~/prompty.if~}`)
+	require.NoError(t, err)
+
+	out, err := tmpl.ExecuteWithProvider(context.Background(), provider)
+	require.NoError(t, err)
+	assert.Equal(t, "[a][b]", out)
+}
+
+func TestTemplate_ExecuteWithProvider_Missing(t *testing.T) {
+	provider := &recordingProvider{}
+
+	tmpl, err := MustNew().Parse(`{~prompty.var name="a.b.c" /~}`)
+	require.NoError(t, err)
+	_, err = tmpl.ExecuteWithProvider(context.Background(), provider)
+	require.Error(t, err)
+	assert.Equal(t, []string{"a", "a.b", "a.b.c"}, provider.lookups)
+
+	tmpl, err = MustNew(WithErrorStrategy(ErrorStrategyDefault)).Parse(`[{~prompty.var name="a.b.c" default="none" /~}]`)
+	require.NoError(t, err)
+	out, err := tmpl.ExecuteWithProvider(context.Background(), provider)
+	require.NoError(t, err)
+	assert.Equal(t, "[none]", out)
+}
+
+func TestTemplate_ExecuteWithProvider_MemoPerExecution(t *testing.T) {
+	provider := &recordingProvider{values: map[string]any{"x": "1"}}
+	tmpl, err := MustNew().Parse(`{~prompty.var name="x" /~}`)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err := tmpl.ExecuteWithProvider(context.Background(), provider)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"x", "x"}, provider.lookups)
+}
+
+func TestNewContextWithProvider(t *testing.T) {
+	provider := &recordingProvider{values: map[string]any{"name": "provided", "other": "o"}}
+	ctx := NewContextWithProvider(provider)
+	ctx.Set("name", "local")
+
+	assert.Equal(t, "local", ctx.GetString("name"))
+	assert.Equal(t, "o", ctx.GetString("other"))
+	assert.True(t, ctx.Has("other"))
+	assert.False(t, ctx.Has("missing"))
+
+	// Derived contexts share the memo
+	derived := ctx.WithDepth(1)
+	assert.Equal(t, "o", derived.GetString("other"))
+	assert.Equal(t, []string{"other", "missing"}, provider.lookups)
+
+	// Children resolve through their parent
+	child := ctx.Child(map[string]any{"item": 1}).(*Context)
+	assert.Equal(t, "o", child.GetString("other"))
+	assert.Equal(t, 1, child.GetIntDefault("item", 0))
+	assert.Len(t, provider.lookups, 2)
+
+	empty := NewContextWithProvider(nil)
+	assert.False(t, empty.Has("name"))
+}
@@ -0,0 +1,208 @@
+package prompty
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// FieldMatch controls how path segments match struct fields when template
+// data contains Go structs. Fields are matched by json tag name or Go field
+// name; fields tagged `json:"-"` and unexported fields are never visible.
+type FieldMatch int
+
+const (
+	// FieldMatchCaseInsensitive matches tag and field names ignoring case,
+	// so "user.profile.name" resolves Profile.Name. This is the default.
+	FieldMatchCaseInsensitive FieldMatch = iota
+	// FieldMatchExact requires the exact json tag or Go field name.
+	FieldMatchExact
+	// FieldMatchSnakeCase additionally matches the snake_case form of Go
+	// field names, so "first_name" resolves FirstName and "user_id" UserID.
+	FieldMatchSnakeCase
+)
+
+// Struct tag handling for field resolution
+const (
+	StructTagJSON      = "json"
+	StructTagIgnore    = "-"
+	StructTagSeparator = ","
+)
+
+// structLayout maps the names a path segment can match to field indexes.
+type structLayout struct {
+	exact  map[string][]int // json tag names and Go field names
+	folded map[string][]int // lower-cased exact names
+	snake  map[string][]int // snake_case Go field names
+}
+
+// structLayouts caches structLayout by reflect.Type.
+var structLayouts sync.Map
+
+// layoutOf returns the cached layout of struct type t.
+func layoutOf(t reflect.Type) *structLayout {
+	if cached, ok := structLayouts.Load(t); ok {
+		return cached.(*structLayout)
+	}
+
+	layout := &structLayout{
+		exact:  make(map[string][]int),
+		folded: make(map[string][]int),
+		snake:  make(map[string][]int),
+	}
+	// Tag names take precedence over Go field names; otherwise the first
+	// visible field wins a collision.
+	fields := reflect.VisibleFields(t)
+	var names []string
+	var indexes [][]int
+	for _, f := range fields {
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get(StructTagJSON)
+		if tag == StructTagIgnore {
+			continue
+		}
+		if name, _, _ := strings.Cut(tag, StructTagSeparator); name != "" {
+			names = append(names, name)
+			indexes = append(indexes, f.Index)
+		}
+	}
+	for _, f := range fields {
+		if f.IsExported() && f.Tag.Get(StructTagJSON) != StructTagIgnore {
+			names = append(names, f.Name)
+			indexes = append(indexes, f.Index)
+			addField(layout.snake, toSnakeCase(f.Name), f.Index)
+		}
+	}
+	for i, name := range names {
+		addField(layout.exact, name, indexes[i])
+		addField(layout.folded, strings.ToLower(name), indexes[i])
+	}
+
+	cached, _ := structLayouts.LoadOrStore(t, layout)
+	return cached.(*structLayout)
+}
+
+// addField records index under name unless the name is already taken.
+func addField(m map[string][]int, name string, index []int) {
+	if _, exists := m[name]; !exists {
+		m[name] = index
+	}
+}
+
+// field returns the index of the field matching segment under match.
+func (l *structLayout) field(segment string, match FieldMatch) ([]int, bool) {
+	if index, ok := l.exact[segment]; ok {
+		return index, true
+	}
+	if match == FieldMatchExact {
+		return nil, false
+	}
+	lower := strings.ToLower(segment)
+	if index, ok := l.folded[lower]; ok {
+		return index, true
+	}
+	if match == FieldMatchSnakeCase {
+		index, ok := l.snake[lower]
+		return index, ok
+	}
+	return nil, false
+}
+
+// lookupField resolves one path segment in a struct, pointer to struct, or
+// map with string keys of any element type.
+func lookupField(current any, segment string, match FieldMatch) (any, bool) {
+	rv := reflect.ValueOf(current)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, false
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		index, ok := layoutOf(rv.Type()).field(segment, match)
+		if !ok {
+			return nil, false
+		}
+		fv, err := rv.FieldByIndexErr(index)
+		if err != nil || !fv.CanInterface() {
+			// Promoted through a nil embedded pointer
+			return nil, false
+		}
+		return reflectedValue(fv), true
+
+	case reflect.Map:
+		keyType := rv.Type().Key()
+		if keyType.Kind() != reflect.String {
+			return nil, false
+		}
+		fv := rv.MapIndex(reflect.ValueOf(segment).Convert(keyType))
+		if !fv.IsValid() {
+			return nil, false
+		}
+		return reflectedValue(fv), true
+	}
+	return nil, false
+}
+
+// reflectedValue unwraps a reflected value for use in templates. Named
+// types of basic kinds become their builtin type (fmt.Stringer types their
+// String()), so they compare naturally in expressions; other values,
+// including structs, are returned as-is.
+func reflectedValue(v reflect.Value) any {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	val := v.Interface()
+	if v.Type().PkgPath() == "" {
+		return val
+	}
+
+	if s, ok := val.(fmt.Stringer); ok {
+		switch v.Kind() {
+		case reflect.Struct, reflect.Pointer, reflect.Map, reflect.Slice, reflect.Array:
+			return val
+		}
+		return s.String()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+	return val
+}
+
+// toSnakeCase converts a Go identifier to snake_case, keeping acronyms
+// together: FirstName -> first_name, UserID -> user_id, HTTPServer -> http_server.
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
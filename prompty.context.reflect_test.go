@@ -0,0 +1,205 @@
+package prompty
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reflectRole int
+
+const (
+	reflectRoleUser reflectRole = iota
+	reflectRoleAdmin
+)
+
+func (r reflectRole) String() string {
+	if r == reflectRoleAdmin {
+		return "admin"
+	}
+	return "user"
+}
+
+type reflectAudit struct {
+	CreatedBy string
+}
+
+type reflectProfile struct {
+	DisplayName string `json:"display_name"`
+	Bio         string
+}
+
+type reflectUser struct {
+	*reflectAudit
+	UserID   int
+	Profile  *reflectProfile
+	Role     reflectRole
+	Tags     []string
+	Password string `json:"-"`
+	Settings map[string]int
+	nickname string
+}
+
+func newReflectUser() reflectUser {
+	return reflectUser{
+		reflectAudit: &reflectAudit{CreatedBy: "system"},
+		UserID:       7,
+		Profile:      &reflectProfile{DisplayName: "Ada", Bio: "math"},
+		Role:         reflectRoleAdmin,
+		Tags:         []string{"a", "b"},
+		Password:     "secret",
+		Settings:     map[string]int{"theme": 2},
+		nickname:     "hidden",
+	}
+}
+
+func TestContext_Get_Struct(t *testing.T) {
+	ctx := NewContext(map[string]any{"user": newReflectUser()})
+
+	tests := []struct {
+		path  string
+		want  any
+		found bool
+	}{
+		{path: "user.Profile.DisplayName", want: "Ada", found: true},
+		{path: "user.profile.display_name", want: "Ada", found: true},
+		{path: "user.PROFILE.bio", want: "math", found: true},
+		{path: "user.userid", want: 7, found: true},
+		{path: "user.role", want: "admin", found: true},
+		{path: "user.CreatedBy", want: "system", found: true},
+		{path: "user.settings.theme", want: 2, found: true},
+		{path: "user.password", found: false},
+		{path: "user.nickname", found: false},
+		{path: "user.profile.missing", found: false},
+		{path: "user.settings.missing", found: false},
+		{path: "user.user_id", found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, found := ctx.Get(tt.path)
+			assert.Equal(t, tt.found, found)
+			if tt.found {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestContext_Get_StructNilPointers(t *testing.T) {
+	ctx := NewContext(map[string]any{"user": &reflectUser{}, "nobody": (*reflectUser)(nil)})
+
+	_, found := ctx.Get("user.profile.bio")
+	assert.False(t, found)
+	_, found = ctx.Get("user.CreatedBy")
+	assert.False(t, found, "promoted through nil embedded pointer")
+	_, found = ctx.Get("nobody.UserID")
+	assert.False(t, found)
+
+	val, found := ctx.Get("user.profile")
+	assert.True(t, found)
+	assert.Nil(t, val)
+}
+
+func TestFieldMatch_Policies(t *testing.T) {
+	data := map[string]any{"user": newReflectUser()}
+
+	tests := []struct {
+		match FieldMatch
+		path  string
+		found bool
+	}{
+		{match: FieldMatchExact, path: "user.Profile.display_name", found: true},
+		{match: FieldMatchExact, path: "user.Profile.DisplayName", found: true},
+		{match: FieldMatchExact, path: "user.profile.display_name", found: false},
+		{match: FieldMatchCaseInsensitive, path: "user.profile.DISPLAY_NAME", found: true},
+		{match: FieldMatchCaseInsensitive, path: "user.user_id", found: false},
+		{match: FieldMatchSnakeCase, path: "user.user_id", found: true},
+		{match: FieldMatchSnakeCase, path: "user.created_by", found: true},
+		{match: FieldMatchSnakeCase, path: "user.Profile.Bio", found: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, found := walkPath(data, strings.Split(tt.path, PathSeparator), tt.match)
+			assert.Equal(t, tt.found, found)
+		})
+	}
+}
+
+func TestTemplate_Execute_Structs(t *testing.T) {
+	source := `{~prompty.var name="user.profile.display_name" /~} ({~prompty.var name="user.Role" /~})
+{~prompty.if eval="user.role == 'admin' && user.userId > 5"~}privileged{~/prompty.if~}
+{~prompty.for item="t" in="user.tags"~}#{~prompty.var name="t" /~}{~/prompty.for~}
+{~prompty.for item="m" in="members"~}[{~prompty.var name="m.profile.bio" /~}]{~/prompty.for~}
+{~prompty.for item="p" in="plans"~}{~prompty.var name="p.key" /~}={~prompty.var name="p.value.seats" /~};{~/prompty.for~}`
+
+	type plan struct{ Seats int }
+	data := map[string]any{
+		"user":    newReflectUser(),
+		"members": []*reflectUser{{Profile: &reflectProfile{Bio: "x"}}, {Profile: &reflectProfile{Bio: "y"}}},
+		"plans":   map[string]plan{"pro": {Seats: 5}, "free": {Seats: 1}},
+	}
+
+	tmpl, err := MustNew().Parse(source)
+	require.NoError(t, err)
+	out, err := tmpl.Execute(context.Background(), data)
+	require.NoError(t, err)
+	assert.Equal(t, "Ada (admin)\nprivileged\n#a#b\n[x][y]\nfree=1;pro=5;", out)
+}
+
+func TestWithFieldMatch(t *testing.T) {
+	data := map[string]any{"user": newReflectUser()}
+
+	out, err := MustNew(WithFieldMatch(FieldMatchSnakeCase)).Execute(context.Background(), `{~prompty.var name="user.user_id" /~}`, data)
+	require.NoError(t, err)
+	assert.Equal(t, "7", out)
+
+	_, err = MustNew(WithFieldMatch(FieldMatchExact)).Execute(context.Background(), `{~prompty.var name="user.userid" /~}`, data)
+	require.Error(t, err)
+
+	// Includes inherit the engine's policy
+	engine := MustNew(WithFieldMatch(FieldMatchSnakeCase))
+	engine.MustRegisterTemplate("card", `{~prompty.var name="_value.created_by" /~}`)
+	out, err = engine.Execute(context.Background(), `{~prompty.include template="card" with="user" /~}`, data)
+	require.NoError(t, err)
+	assert.Equal(t, "system", out)
+
+	// Dry runs resolve struct fields like execution does
+	tmpl, err := MustNew(WithFieldMatch(FieldMatchSnakeCase)).Parse(`{~prompty.var name="user.profile.display_name" /~}{~prompty.var name="user.user_id" /~}`)
+	require.NoError(t, err)
+	result := tmpl.DryRun(context.Background(), data)
+	assert.Empty(t, result.MissingVariables)
+}
+
+func TestStructLayout_Cached(t *testing.T) {
+	typ := reflect.TypeOf(reflectProfile{})
+	structLayouts.Delete(typ)
+
+	first := layoutOf(typ)
+	assert.Same(t, first, layoutOf(typ))
+
+	index, ok := first.field("display_name", FieldMatchExact)
+	require.True(t, ok)
+	assert.Equal(t, []int{0}, index)
+	_, ok = first.field("DISPLAYNAME", FieldMatchCaseInsensitive)
+	assert.True(t, ok)
+}
+
+func TestToSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"Name":       "name",
+		"FirstName":  "first_name",
+		"UserID":     "user_id",
+		"HTTPServer": "http_server",
+		"APIKey2":    "api_key2",
+		"V2Config":   "v2_config",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, toSnakeCase(in), in)
+	}
+}
@@ -0,0 +1,41 @@
+package prompty
+
+// Scoping
+//
+// A Context is a chain of scopes: each Child, With or PushScope call returns a
+// new innermost scope whose parent is the receiver. The rules are:
+//
+//   - A path is resolved in the innermost scope first and falls through to the
+//     enclosing scopes when it does not resolve there. Shadowing is by path, so
+//     an inner "user" map without a "name" key still lets "user.name" resolve
+//     from an outer scope.
+//   - Set writes to the scope it is called on and never modifies an enclosing
+//     scope, so a value set in an inner scope is discarded with it.
+//   - Each iteration of prompty.for runs in its own scope holding the item and
+//     index variables, which shadow same-named outer values.
+//   - Each block tag (a resolver tag with children) runs in its own scope: the
+//     resolver receives it, and variables it sets are visible to the tag's
+//     children only.
+//   - prompty.include executes the included template in a fresh context built
+//     from its attributes and "with" value; the caller's scopes are not visible.
+
+// With returns a new scope that binds key to value, shadowing any key of the
+// same name in c. c itself is not modified.
+func (c *Context) With(key string, value any) *Context {
+	return c.Child(map[string]any{key: value}).(*Context)
+}
+
+// PushScope returns a new, empty scope enclosed by c. Values Set on it shadow
+// values in c until the scope is discarded or popped with PopScope.
+func (c *Context) PushScope() *Context {
+	return c.Child(nil).(*Context)
+}
+
+// PopScope returns the scope enclosing c, discarding c's own values. A root
+// context has no enclosing scope and returns itself.
+func (c *Context) PopScope() *Context {
+	if c.parent == nil {
+		return c
+	}
+	return c.parent
+}
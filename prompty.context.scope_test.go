@@ -0,0 +1,84 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// letResolver is a block tag that binds attribute name to attribute value for
+// its children.
+type letResolver struct{}
+
+func (letResolver) TagName() string { return "test.let" }
+
+func (letResolver) Resolve(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+	name, _ := attrs.Get(AttrName)
+	value, _ := attrs.Get("value")
+	execCtx.Set(name, value)
+	return "", nil
+}
+
+func (letResolver) Validate(attrs Attributes) error { return nil }
+
+func TestContext_With(t *testing.T) {
+	root := NewContext(map[string]any{"name": "outer", "keep": "k"})
+	inner := root.With("name", "inner")
+
+	assert.Equal(t, "inner", inner.GetString("name"))
+	assert.Equal(t, "k", inner.GetString("keep"))
+	assert.Equal(t, "outer", root.GetString("name"))
+	assert.Same(t, root, inner.Parent())
+}
+
+func TestContext_PushPopScope(t *testing.T) {
+	root := NewContext(map[string]any{"name": "outer"})
+
+	scope := root.PushScope()
+	scope.Set("name", "inner")
+	scope.Set("local", 1)
+	assert.Equal(t, "inner", scope.GetString("name"))
+	assert.True(t, scope.Has("local"))
+
+	popped := scope.PopScope()
+	assert.Same(t, root, popped)
+	assert.Equal(t, "outer", popped.GetString("name"))
+	assert.False(t, popped.Has("local"))
+
+	assert.Same(t, root, root.PopScope())
+}
+
+func TestContext_ScopeShadowingIsByPath(t *testing.T) {
+	root := NewContext(map[string]any{"user": map[string]any{"name": "Ada", "role": "admin"}})
+	scope := root.With("user", map[string]any{"name": "Bob"})
+
+	assert.Equal(t, "Bob", scope.GetString("user.name"))
+	assert.Equal(t, "admin", scope.GetString("user.role"))
+}
+
+func TestBlockTag_ResolverScope(t *testing.T) {
+	engine := MustNew()
+	engine.MustRegister(letResolver{})
+
+	source := `{~test.let name="greeting" value="hi"~}{~prompty.var name="greeting" /~} {~prompty.var name="who" /~}{~/test.let~}|{~prompty.var name="greeting" default="gone" /~}`
+	tmpl, err := engine.Parse(source)
+	require.NoError(t, err)
+
+	execCtx := NewContextWithStrategy(map[string]any{"who": "you"}, ErrorStrategyDefault)
+	out, err := tmpl.ExecuteWithContext(context.Background(), execCtx)
+	require.NoError(t, err)
+	assert.Equal(t, "hi you|gone", out)
+	assert.False(t, execCtx.Has("greeting"))
+}
+
+func TestBlockTag_NestedScopesShadow(t *testing.T) {
+	engine := MustNew()
+	engine.MustRegister(letResolver{})
+
+	source := `{~test.let name="x" value="1"~}{~prompty.var name="x" /~}{~test.let name="x" value="2"~}{~prompty.var name="x" /~}{~/test.let~}{~prompty.var name="x" /~}{~prompty.for item="x" in="items"~}{~prompty.var name="x" /~}{~/prompty.for~}{~/test.let~}`
+	out, err := engine.Execute(context.Background(), source, map[string]any{"items": []string{"a", "b"}})
+	require.NoError(t, err)
+	assert.Equal(t, "121ab", out)
+}
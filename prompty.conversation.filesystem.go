@@ -0,0 +1,193 @@
+package prompty
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Filesystem conversation file naming
+const (
+	FilesystemConversationSuffix = ".jsonl"
+	filesystemConversationTemp   = ".tmp"
+)
+
+// FilesystemConversationStore stores each conversation as a JSON Lines file
+// with one message per line:
+//
+//	<root>/
+//	  <conversation-id>.jsonl
+//
+// Appends only add lines; Trim rewrites the file atomically.
+type FilesystemConversationStore struct {
+	mu   sync.Mutex
+	root string
+}
+
+// NewFilesystemConversationStore creates a conversation store in root.
+// The root directory will be created if it doesn't exist.
+func NewFilesystemConversationStore(root string) (*FilesystemConversationStore, error) {
+	if root == "" {
+		return nil, &StorageError{Message: ErrMsgInvalidStorageRoot}
+	}
+	if err := os.MkdirAll(root, FilesystemDirPermissions); err != nil {
+		return nil, &StorageError{Message: ErrMsgCreateStorageDir, Name: root, Cause: err}
+	}
+	return &FilesystemConversationStore{root: root}, nil
+}
+
+// Append adds messages to the conversation file.
+func (s *FilesystemConversationStore) Append(ctx context.Context, conversationID string, messages ...Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, err := s.path(conversationID)
+	if err != nil {
+		return err
+	}
+	data, err := encodeTranscript(messages)
+	if err != nil {
+		return &StorageError{Message: ErrMsgWriteConversation, Name: conversationID, Cause: err}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, FilesystemFilePermissions)
+	if err != nil {
+		return &StorageError{Message: ErrMsgWriteConversation, Name: conversationID, Cause: err}
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return &StorageError{Message: ErrMsgWriteConversation, Name: conversationID, Cause: err}
+	}
+	if err := f.Close(); err != nil {
+		return &StorageError{Message: ErrMsgWriteConversation, Name: conversationID, Cause: err}
+	}
+	return nil
+}
+
+// Load reads the conversation file.
+func (s *FilesystemConversationStore) Load(ctx context.Context, conversationID string) ([]Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	path, err := s.path(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(conversationID, path)
+}
+
+// Trim drops the oldest messages beyond the token budget.
+func (s *FilesystemConversationStore) Trim(ctx context.Context, conversationID string, maxTokens int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	path, err := s.path(conversationID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transcript, err := s.load(conversationID, path)
+	if err != nil {
+		return 0, err
+	}
+	kept := trimTranscript(transcript, maxTokens)
+	dropped := len(transcript) - len(kept)
+	if dropped == 0 {
+		return 0, nil
+	}
+
+	data, err := encodeTranscript(kept)
+	if err != nil {
+		return 0, &StorageError{Message: ErrMsgWriteConversation, Name: conversationID, Cause: err}
+	}
+	tmp := path + filesystemConversationTemp
+	if err := os.WriteFile(tmp, data, FilesystemFilePermissions); err != nil {
+		return 0, &StorageError{Message: ErrMsgWriteConversation, Name: conversationID, Cause: err}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return 0, &StorageError{Message: ErrMsgWriteConversation, Name: conversationID, Cause: err}
+	}
+	return dropped, nil
+}
+
+// Delete removes the conversation file.
+func (s *FilesystemConversationStore) Delete(ctx context.Context, conversationID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, err := s.path(conversationID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return &StorageError{Message: ErrMsgWriteConversation, Name: conversationID, Cause: err}
+	}
+	return nil
+}
+
+// path returns the file of a conversation, rejecting unsafe IDs.
+func (s *FilesystemConversationStore) path(conversationID string) (string, error) {
+	if err := validateTemplateNameForFilesystem(conversationID); err != nil {
+		return "", &StorageError{Message: ErrMsgInvalidConversationID, Name: conversationID, Cause: err}
+	}
+	return filepath.Join(s.root, conversationID+FilesystemConversationSuffix), nil
+}
+
+// load reads a conversation file; the caller holds the lock.
+func (s *FilesystemConversationStore) load(conversationID, path string) ([]Message, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Message{}, nil
+		}
+		return nil, &StorageError{Message: ErrMsgReadConversation, Name: conversationID, Cause: err}
+	}
+
+	transcript := []Message{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var msg Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			return nil, &StorageError{Message: ErrMsgReadConversation, Name: conversationID, Cause: err}
+		}
+		transcript = append(transcript, msg)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, &StorageError{Message: ErrMsgReadConversation, Name: conversationID, Cause: err}
+	}
+	return transcript, nil
+}
+
+// encodeTranscript encodes messages as JSON Lines.
+func encodeTranscript(messages []Message) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range messages {
+		if err := enc.Encode(&messages[i]); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,174 @@
+package prompty
+
+import (
+	"context"
+	"sync"
+)
+
+// Conversation error message constants
+const (
+	ErrMsgInvalidConversationID = "invalid conversation ID"
+	ErrMsgReadConversation      = "failed to read conversation"
+	ErrMsgWriteConversation     = "failed to write conversation"
+)
+
+// Transcript entry keys exposed to templates
+const (
+	conversationKeyRole    = "role"
+	conversationKeyContent = "content"
+)
+
+// ConversationStore persists the transcripts of multi-turn agent runs,
+// keyed by conversation ID. CompileAgent loads the transcript named by
+// CompileOptions.ConversationID and appends the new user message, so a
+// caller only appends the model's replies.
+//
+// Implementations must be safe for concurrent use.
+type ConversationStore interface {
+	// Append adds messages to the end of a conversation, creating it if
+	// needed.
+	Append(ctx context.Context, conversationID string, messages ...Message) error
+
+	// Load returns the transcript of a conversation in order
+	// (empty for an unknown conversation).
+	Load(ctx context.Context, conversationID string) ([]Message, error)
+
+	// Trim drops the oldest messages until the estimated tokens of the
+	// remaining ones fit maxTokens, and returns the number dropped.
+	Trim(ctx context.Context, conversationID string, maxTokens int) (int, error)
+
+	// Delete removes a conversation. Deleting an unknown conversation is
+	// not an error.
+	Delete(ctx context.Context, conversationID string) error
+}
+
+// MemoryConversationStore keeps conversations in memory.
+type MemoryConversationStore struct {
+	mu            sync.RWMutex
+	conversations map[string][]Message
+}
+
+// NewMemoryConversationStore creates an empty in-memory conversation store.
+func NewMemoryConversationStore() *MemoryConversationStore {
+	return &MemoryConversationStore{conversations: make(map[string][]Message)}
+}
+
+// Append adds messages to a conversation.
+func (s *MemoryConversationStore) Append(ctx context.Context, conversationID string, messages ...Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if conversationID == "" {
+		return &StorageError{Message: ErrMsgInvalidConversationID}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conversations[conversationID] = append(s.conversations[conversationID], messages...)
+	return nil
+}
+
+// Load returns a copy of the transcript of a conversation.
+func (s *MemoryConversationStore) Load(ctx context.Context, conversationID string) ([]Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	transcript := s.conversations[conversationID]
+	result := make([]Message, len(transcript))
+	copy(result, transcript)
+	return result, nil
+}
+
+// Trim drops the oldest messages beyond the token budget.
+func (s *MemoryConversationStore) Trim(ctx context.Context, conversationID string, maxTokens int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	transcript := s.conversations[conversationID]
+	kept := trimTranscript(transcript, maxTokens)
+	dropped := len(transcript) - len(kept)
+	if dropped > 0 {
+		s.conversations[conversationID] = append([]Message(nil), kept...)
+	}
+	return dropped, nil
+}
+
+// Delete removes a conversation.
+func (s *MemoryConversationStore) Delete(ctx context.Context, conversationID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conversations, conversationID)
+	return nil
+}
+
+// trimTranscript returns the newest messages whose estimated tokens fit
+// maxTokens.
+func trimTranscript(transcript []Message, maxTokens int) []Message {
+	used := 0
+	for i := len(transcript) - 1; i >= 0; i-- {
+		used += EstimateTokens(transcript[i].Content).EstimatedGeneric
+		if used > maxTokens {
+			return transcript[i+1:]
+		}
+	}
+	return transcript
+}
+
+// hasConversation reports whether compilation uses a stored conversation.
+func (o *CompileOptions) hasConversation() bool {
+	return o.ConversationStore != nil && o.ConversationID != ""
+}
+
+// loadConversation trims and loads the conversation configured in opts and
+// appends the new user message from input.
+func loadConversation(ctx context.Context, opts *CompileOptions, input map[string]any) ([]Message, error) {
+	store, id := opts.ConversationStore, opts.ConversationID
+
+	if opts.ConversationMaxTokens > 0 {
+		if _, err := store.Trim(ctx, id, opts.ConversationMaxTokens); err != nil {
+			return nil, NewCompilationError(ErrMsgCompileConversation, err)
+		}
+	}
+	transcript, err := store.Load(ctx, id)
+	if err != nil {
+		return nil, NewCompilationError(ErrMsgCompileConversation, err)
+	}
+	if msg := inputMessage(input); msg != "" {
+		if err := store.Append(ctx, id, Message{Role: RoleUser, Content: msg}); err != nil {
+			return nil, NewCompilationError(ErrMsgCompileConversation, err)
+		}
+	}
+	return transcript, nil
+}
+
+// conversationData converts a transcript to template data for
+// {~prompty.history in="conversation"~}.
+func conversationData(transcript []Message) []any {
+	data := make([]any, len(transcript))
+	for i, msg := range transcript {
+		data[i] = map[string]any{
+			conversationKeyRole:    msg.Role,
+			conversationKeyContent: msg.Content,
+		}
+	}
+	return data
+}
+
+// conversationMessages converts a transcript to compiled messages.
+func conversationMessages(transcript []Message) []CompiledMessage {
+	messages := make([]CompiledMessage, len(transcript))
+	for i, msg := range transcript {
+		messages[i] = CompiledMessage{Role: msg.Role, Content: msg.Content, Cache: msg.Cache, Parts: msg.Parts}
+	}
+	return messages
+}
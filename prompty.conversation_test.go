@@ -0,0 +1,192 @@
+package prompty
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func conversationStores(t *testing.T) map[string]ConversationStore {
+	fs, err := NewFilesystemConversationStore(t.TempDir())
+	require.NoError(t, err)
+	return map[string]ConversationStore{
+		"memory":     NewMemoryConversationStore(),
+		"filesystem": fs,
+	}
+}
+
+func TestConversationStore(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range conversationStores(t) {
+		t.Run(name, func(t *testing.T) {
+			transcript, err := store.Load(ctx, "c1")
+			require.NoError(t, err)
+			assert.Empty(t, transcript)
+
+			require.NoError(t, store.Append(ctx, "c1",
+				Message{Role: RoleUser, Content: strings.Repeat("old question ", 20)},
+				Message{Role: RoleAssistant, Content: strings.Repeat("old answer ", 20)},
+			))
+			require.NoError(t, store.Append(ctx, "c1", Message{Role: RoleUser, Content: "new question"}))
+			require.NoError(t, store.Append(ctx, "c2", Message{Role: RoleUser, Content: "other"}))
+
+			transcript, err = store.Load(ctx, "c1")
+			require.NoError(t, err)
+			require.Len(t, transcript, 3)
+			assert.Equal(t, "new question", transcript[2].Content)
+
+			dropped, err := store.Trim(ctx, "c1", 1000)
+			require.NoError(t, err)
+			assert.Zero(t, dropped)
+
+			dropped, err = store.Trim(ctx, "c1", 10)
+			require.NoError(t, err)
+			assert.Equal(t, 2, dropped)
+			transcript, err = store.Load(ctx, "c1")
+			require.NoError(t, err)
+			assert.Equal(t, []Message{{Role: RoleUser, Content: "new question"}}, transcript)
+
+			require.NoError(t, store.Delete(ctx, "c1"))
+			require.NoError(t, store.Delete(ctx, "c1"))
+			transcript, err = store.Load(ctx, "c1")
+			require.NoError(t, err)
+			assert.Empty(t, transcript)
+
+			transcript, err = store.Load(ctx, "c2")
+			require.NoError(t, err)
+			assert.Len(t, transcript, 1)
+
+			var storageErr *StorageError
+			err = store.Append(ctx, "", Message{Role: RoleUser})
+			require.True(t, errors.As(err, &storageErr))
+			assert.Equal(t, ErrMsgInvalidConversationID, storageErr.Message)
+		})
+	}
+}
+
+func TestFilesystemConversationStore_Files(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	store, err := NewFilesystemConversationStore(root)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Append(ctx, "session-1", Message{Role: RoleUser, Content: "hi"}))
+	data, err := os.ReadFile(filepath.Join(root, "session-1"+FilesystemConversationSuffix))
+	require.NoError(t, err)
+	assert.Equal(t, `{"role":"user","content":"hi"}`+"\n", string(data))
+
+	_, err = store.Load(ctx, "../escape")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgInvalidConversationID)
+
+	_, err = NewFilesystemConversationStore("")
+	require.Error(t, err)
+}
+
+func TestCompileAgent_Conversation(t *testing.T) {
+	ctx := context.Background()
+	agent, err := Parse([]byte(`---
+name: chat
+description: Chat agent
+type: agent
+---
+You are helpful.`))
+	require.NoError(t, err)
+
+	store := NewMemoryConversationStore()
+	opts := NewCompileOptions(WithConversation(store, "c1"))
+
+	compiled, err := agent.CompileAgent(ctx, map[string]any{"message": "Hi"}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "c1", compiled.ConversationID)
+	require.Len(t, compiled.Messages, 2)
+	require.NoError(t, store.Append(ctx, "c1", Message{Role: RoleAssistant, Content: "Hello!"}))
+
+	compiled, err = agent.CompileAgent(ctx, map[string]any{"message": "How are you?"}, opts)
+	require.NoError(t, err)
+	roles := make([]string, len(compiled.Messages))
+	for i, m := range compiled.Messages {
+		roles[i] = m.Role
+	}
+	assert.Equal(t, []string{RoleSystem, RoleUser, RoleAssistant, RoleUser}, roles)
+	assert.Equal(t, "Hi", compiled.Messages[1].Content)
+	assert.Equal(t, "How are you?", compiled.Messages[3].Content)
+
+	transcript, err := store.Load(ctx, "c1")
+	require.NoError(t, err)
+	assert.Len(t, transcript, 3)
+
+	// Without a conversation nothing is loaded or stored
+	compiled, err = agent.CompileAgent(ctx, map[string]any{"message": "Solo"}, nil)
+	require.NoError(t, err)
+	assert.Len(t, compiled.Messages, 2)
+	assert.Empty(t, compiled.ConversationID)
+}
+
+func TestCompileAgent_ConversationHistoryTag(t *testing.T) {
+	ctx := context.Background()
+	agent, err := Parse([]byte(`---
+name: chat
+description: Chat agent
+type: agent
+messages:
+  - role: system
+    content: You are helpful.
+  - role: user
+    content: '{~prompty.history in="conversation" format="plain" /~}'
+---
+`))
+	require.NoError(t, err)
+
+	store := NewMemoryConversationStore()
+	require.NoError(t, store.Append(ctx, "c1",
+		Message{Role: RoleUser, Content: strings.Repeat("ancient ", 50)},
+		Message{Role: RoleUser, Content: "Hi"},
+		Message{Role: RoleAssistant, Content: "Hello!"},
+	))
+
+	compiled, err := agent.CompileAgent(ctx, nil, NewCompileOptions(WithConversation(store, "c1"), WithConversationMaxTokens(20)))
+	require.NoError(t, err)
+	require.Len(t, compiled.Messages, 2)
+	assert.Contains(t, compiled.Messages[1].Content, "Hello!")
+	assert.NotContains(t, compiled.Messages[1].Content, "ancient")
+
+	transcript, err := store.Load(ctx, "c1")
+	require.NoError(t, err)
+	assert.Len(t, transcript, 2)
+}
+
+func TestAgentExecutor_Conversation(t *testing.T) {
+	ctx := context.Background()
+	source := `---
+name: chat
+description: Chat agent
+type: agent
+execution:
+  provider: openai
+  model: gpt-4
+---
+You are helpful.`
+
+	store := NewMemoryConversationStore()
+	executor := NewAgentExecutor(WithAgentConversationStore(store))
+
+	_, err := executor.Execute(ctx, source, map[string]any{"conversation_id": "c1", "message": "Hi"})
+	require.NoError(t, err)
+	require.NoError(t, executor.RecordReply(ctx, "c1", "Hello!"))
+
+	compiled, err := executor.Execute(ctx, source, map[string]any{"conversation_id": "c1", "message": "Again"})
+	require.NoError(t, err)
+	assert.Len(t, compiled.Messages, 4)
+	assert.Equal(t, "c1", compiled.ConversationID)
+
+	err = NewAgentExecutor().RecordReply(ctx, "c1", "Hello!")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgAgentExecNoStore)
+}
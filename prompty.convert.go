@@ -0,0 +1,1372 @@
+package prompty
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Template dialects accepted by ConvertFrom
+const (
+	DialectJinja2     = "jinja2"
+	DialectHandlebars = "handlebars"
+	DialectMustache   = "mustache" // Converted with the Handlebars rules
+)
+
+// Warning messages for template dialect conversion
+const (
+	WarnMsgConvertStatement  = "statement %q is not converted and is kept as is"
+	WarnMsgConvertExpression = "expression %q is not converted and is kept as is"
+	WarnMsgConvertFilter     = "filter %q has no prompty equivalent and is dropped"
+	WarnMsgConvertProblem    = "expression %q: %s"
+	WarnMsgConvertHelper     = "helper %q is not converted and is kept as is"
+	WarnMsgConvertSection    = "section %q is converted to a condition; iteration over lists is not preserved"
+	WarnMsgConvertReference  = "reference %q has no prompty equivalent"
+	WarnMsgConvertIgnoreMiss = "include %q fails instead of being ignored when the template is missing"
+
+	convertProblemOperator = "operator %q is not supported"
+	convertProblemTest     = "test %q is not supported"
+	convertProblemFunction = "function %q is not supported"
+	convertProblemFilter   = "filter %q is not supported"
+	convertProblemLoop     = "loop variable %q is not supported"
+	convertProblemLiteral  = "list and dict literals are not supported"
+	convertProblemSyntax   = "unexpected %q"
+)
+
+// Template dialect syntax
+const (
+	jinjaOutputOpen     = "{{"
+	jinjaOutputClose    = "}}"
+	jinjaStatementOpen  = "{%"
+	jinjaStatementClose = "%}"
+	jinjaCommentOpen    = "{#"
+	jinjaCommentClose   = "#}"
+	jinjaTrimMarkers    = "-+"
+
+	hbOpen             = "{{"
+	hbClose            = "}}"
+	hbTripleOpen       = "{{{"
+	hbTripleClose      = "}}}"
+	hbLongCommentOpen  = "{{!--"
+	hbLongCommentClose = "--}}"
+	hbTrimMarker       = "~"
+	hbThis             = "this"
+	hbParentPrefix     = "../"
+	hbRootPrefix       = "@root."
+	hbIndex            = "@index"
+	hbElse             = "else"
+	hbDefaultItem      = "item"
+
+	convertLoopVar     = "loop"
+	convertEntryItem   = "entry"
+	convertIndexSuffix = "_index"
+	convertKeySuffix   = ".key"
+	convertValueSuffix = ".value"
+	convertWhitespace  = " \t\r\n"
+
+	convertLiteralTrue  = "true"
+	convertLiteralFalse = "false"
+	convertLiteralNil   = "nil"
+)
+
+// Block kinds tracked while converting
+const (
+	convertBlockIf      = "if"
+	convertBlockUnless  = "unless"
+	convertBlockFor     = "for"
+	convertBlockEach    = "each"
+	convertBlockWith    = "with"
+	convertBlockBlock   = "block"
+	convertBlockSection = "section"
+	convertBlockKept    = "kept"
+)
+
+var (
+	// jinjaFilterFuncs maps Jinja2 filters to prompty expression functions.
+	jinjaFilterFuncs = map[string]string{
+		"length":  "len",
+		"count":   "len",
+		"upper":   "upper",
+		"lower":   "lower",
+		"trim":    "trim",
+		"first":   "first",
+		"last":    "last",
+		"string":  "toString",
+		"int":     "toInt",
+		"float":   "toFloat",
+		"default": "default",
+		"d":       "default",
+		"join":    "join",
+		"replace": "replace",
+	}
+	// jinjaMethodFuncs maps Python string and dict methods to prompty
+	// expression functions.
+	jinjaMethodFuncs = map[string]string{
+		"upper":      "upper",
+		"lower":      "lower",
+		"strip":      "trim",
+		"startswith": "hasPrefix",
+		"endswith":   "hasSuffix",
+		"replace":    "replace",
+		"keys":       "keys",
+		"values":     "values",
+	}
+	// jinjaDefaultFilters are the filters converted to the default
+	// attribute of a variable.
+	jinjaDefaultFilters = map[string]bool{"default": true, "d": true}
+
+	// hbBlockParams matches the "as |item index|" suffix of a Handlebars block.
+	hbBlockParams = regexp.MustCompile(`^(.*?)\s+as\s+\|\s*([^|]*?)\s*\|$`)
+	// convertPathRef matches a plain dotted reference.
+	convertPathRef = regexp.MustCompile(`^[A-Za-z_@][A-Za-z0-9_]*(?:\.[A-Za-z0-9_]+)*$`)
+)
+
+// ConvertFrom converts a Jinja2 or Handlebars template into prompty
+// template syntax. Variables, conditionals, loops, includes, comments and
+// common filters are converted; constructs without a prompty equivalent are
+// kept as is and reported with their position in source. Structural
+// problems, such as an unclosed block, are returned as errors.
+func ConvertFrom(dialect, source string) (string, *ConversionReport, error) {
+	c := &templateConverter{source: source, report: &ConversionReport{}}
+	var err error
+	switch strings.ToLower(dialect) {
+	case DialectJinja2:
+		err = c.convertJinja2()
+	case DialectHandlebars, DialectMustache:
+		err = c.convertHandlebars()
+	default:
+		return "", nil, NewImportTypeError(ErrMsgConvertUnknownDialect, dialect)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return c.String(), c.report, nil
+}
+
+// convertForeignTemplate converts a template embedded in an imported
+// document, falling back to plain variable conversion when the template
+// cannot be converted structurally.
+func convertForeignTemplate(dialect, template, field string, report *ConversionReport) string {
+	converted, convertReport, err := ConvertFrom(dialect, template)
+	if err != nil {
+		return convertDoubleBraceVariables(template, field, report)
+	}
+	for _, w := range convertReport.Warnings {
+		report.addAt(field, w.Position, w.Message)
+	}
+	return converted
+}
+
+// templateConverter holds the state of a single ConvertFrom call.
+type templateConverter struct {
+	source   string
+	report   *ConversionReport
+	out      []string
+	lastText int // index of the last text segment in out, or -1
+	trimNext bool
+	blocks   []*convertBlock
+}
+
+// convertBlock is an open block of the source template.
+type convertBlock struct {
+	kind      string
+	name      string // source keyword or helper name
+	pos       Position
+	open      int    // index of the opening segment in out
+	closing   string // emitted when the block ends
+	item      string
+	in        string
+	index     string
+	filter    string            // Jinja2 loop condition
+	prefix    string            // Handlebars context path for bare references
+	params    map[string]bool   // Handlebars block parameters
+	aliases   map[string]string // names rewritten to prompty paths
+	elseBlock bool              // set once the block is in its else branch
+}
+
+// String returns the converted template.
+func (c *templateConverter) String() string {
+	return strings.Join(c.out, "")
+}
+
+// text emits literal template text.
+func (c *templateConverter) text(s string) {
+	if c.trimNext {
+		s = strings.TrimLeft(s, convertWhitespace)
+		c.trimNext = false
+	}
+	if s == "" {
+		return
+	}
+	c.out = append(c.out, s)
+	c.lastText = len(c.out) - 1
+}
+
+// emit emits converted markup.
+func (c *templateConverter) emit(s string) {
+	c.lastText = -1
+	if s != "" {
+		c.out = append(c.out, s)
+	}
+}
+
+// trimMarkers applies whitespace control markers around a construct and
+// returns its inner content without them. It must be called after the text
+// preceding the construct was emitted.
+func (c *templateConverter) trimMarkers(inner, markers string) string {
+	if inner != "" && strings.ContainsRune(markers, rune(inner[0])) {
+		if c.lastText >= 0 {
+			c.out[c.lastText] = strings.TrimRight(c.out[c.lastText], convertWhitespace)
+		}
+		inner = inner[1:]
+	}
+	if inner != "" && strings.ContainsRune(markers, rune(inner[len(inner)-1])) {
+		c.trimNext = true
+		inner = inner[:len(inner)-1]
+	}
+	return strings.TrimSpace(inner)
+}
+
+func (c *templateConverter) warn(pos Position, format string, args ...any) {
+	c.report.addAt(PromptFieldBody, pos, fmt.Sprintf(format, args...))
+}
+
+func (c *templateConverter) push(b *convertBlock) {
+	b.open = len(c.out)
+	c.blocks = append(c.blocks, b)
+}
+
+func (c *templateConverter) top() *convertBlock {
+	if len(c.blocks) == 0 {
+		return nil
+	}
+	return c.blocks[len(c.blocks)-1]
+}
+
+// pop closes the innermost block, which must be one of kinds.
+func (c *templateConverter) pop(construct string, pos Position, kinds ...string) (*convertBlock, error) {
+	b := c.top()
+	if b == nil {
+		return nil, NewConvertError(ErrMsgConvertUnexpectedEnd, construct, pos)
+	}
+	for _, kind := range kinds {
+		if b.kind == kind {
+			c.blocks = c.blocks[:len(c.blocks)-1]
+			return b, nil
+		}
+	}
+	return nil, NewConvertError(ErrMsgConvertMismatchedEnd, construct, pos)
+}
+
+// finish reports blocks left open at the end of the source.
+func (c *templateConverter) finish() error {
+	if b := c.top(); b != nil {
+		return NewConvertError(ErrMsgConvertUnclosedBlock, b.name, b.pos)
+	}
+	return nil
+}
+
+// loopIndex returns the index variable of a loop block, adding the index
+// attribute to its opening tag on first use.
+func (c *templateConverter) loopIndex(b *convertBlock) string {
+	if b.index == "" {
+		b.index = b.item + convertIndexSuffix
+		c.out[b.open] = c.forTag(b)
+	}
+	return b.index
+}
+
+func (c *templateConverter) forTag(b *convertBlock) string {
+	attrs := []string{AttrItem, b.item, AttrIn, b.in}
+	if b.index != "" {
+		attrs = append(attrs, AttrIndex, b.index)
+	}
+	tag := promptyTag(TagNameFor, false, attrs...)
+	if b.filter != "" {
+		tag += promptyTag(TagNameIf, false, AttrEval, b.filter)
+	}
+	return tag
+}
+
+// --- Jinja2 ---
+
+// convertJinja2 converts a Jinja2 template.
+func (c *templateConverter) convertJinja2() error {
+	c.lastText = -1
+	src := c.source
+	i := 0
+	for i < len(src) {
+		start := nextJinjaConstruct(src, i)
+		if start < 0 {
+			c.text(src[i:])
+			break
+		}
+		c.text(src[i:start])
+
+		opener := src[start : start+2]
+		closer := jinjaOutputClose
+		switch opener {
+		case jinjaStatementOpen:
+			closer = jinjaStatementClose
+		case jinjaCommentOpen:
+			closer = jinjaCommentClose
+		}
+		pos := positionAt(src, start)
+		end := strings.Index(src[start+2:], closer)
+		if end < 0 {
+			return NewConvertError(ErrMsgConvertUnterminated, opener, pos)
+		}
+		end += start + 2
+		construct := src[start : end+2]
+		i = end + 2
+
+		switch opener {
+		case jinjaCommentOpen:
+			c.comment(c.trimMarkers(src[start+2:end], jinjaTrimMarkers))
+		case jinjaOutputOpen:
+			c.jinjaOutput(c.trimMarkers(src[start+2:end], jinjaTrimMarkers), construct, pos)
+		default:
+			stmt := c.trimMarkers(src[start+2:end], jinjaTrimMarkers)
+			if stmt == "raw" {
+				next, err := c.jinjaRaw(i, pos)
+				if err != nil {
+					return err
+				}
+				i = next
+				continue
+			}
+			if err := c.jinjaStatement(stmt, construct, pos); err != nil {
+				return err
+			}
+		}
+	}
+	return c.finish()
+}
+
+// nextJinjaConstruct returns the offset of the next Jinja2 construct at or
+// after from, or -1.
+func nextJinjaConstruct(src string, from int) int {
+	for i := from; i+1 < len(src); i++ {
+		if src[i] != '{' {
+			continue
+		}
+		switch src[i+1] {
+		case '{', '%', '#':
+			return i
+		}
+	}
+	return -1
+}
+
+// jinjaRaw converts a raw block whose content starts at from and returns
+// the offset after its endraw statement.
+func (c *templateConverter) jinjaRaw(from int, pos Position) (int, error) {
+	for i := from; ; {
+		start := strings.Index(c.source[i:], jinjaStatementOpen)
+		if start < 0 {
+			return 0, NewConvertError(ErrMsgConvertUnclosedBlock, "raw", pos)
+		}
+		start += i
+		end := strings.Index(c.source[start:], jinjaStatementClose)
+		if end < 0 {
+			return 0, NewConvertError(ErrMsgConvertUnclosedBlock, "raw", pos)
+		}
+		end += start
+		inner := strings.Trim(c.source[start+2:end], jinjaTrimMarkers+convertWhitespace)
+		if inner == "endraw" {
+			c.emit(promptyTag(TagNameRaw, false) + c.source[from:start] + promptyCloseTag(TagNameRaw))
+			return end + 2, nil
+		}
+		i = end + 2
+	}
+}
+
+func (c *templateConverter) comment(content string) {
+	c.emit(promptyTag(TagNameComment, false) + content + promptyCloseTag(TagNameComment))
+}
+
+// jinjaOutput converts an output construct. Only variables, with an
+// optional default filter, can be converted.
+func (c *templateConverter) jinjaOutput(expr, construct string, pos Position) {
+	toks, ok := tokenizeJinja(expr)
+	if !ok || len(toks) == 0 {
+		c.keep(construct, pos, WarnMsgConvertExpression)
+		return
+	}
+	if len(toks) == 1 && toks[0].kind == jinjaTokenString {
+		c.text(toks[0].text)
+		return
+	}
+	if len(toks) == 3 && toks[0].text == "super" && toks[1].text == "(" && toks[2].text == ")" {
+		c.emit(promptyTag(TagNameParent, true))
+		return
+	}
+
+	p := &jinjaExprParser{c: c, toks: toks}
+	segs, ok := p.parsePathSegments()
+	if !ok {
+		c.keep(construct, pos, WarnMsgConvertExpression)
+		return
+	}
+	name, plain := c.jinjaPath(segs, p)
+	if !plain || len(p.problems) > 0 || !convertPathRef.MatchString(name) {
+		c.keep(construct, pos, WarnMsgConvertExpression)
+		return
+	}
+
+	attrs := []string{AttrName, name}
+	for p.isOp("|") {
+		p.pos++
+		filter := p.next()
+		if filter.kind != jinjaTokenIdent {
+			c.keep(construct, pos, WarnMsgConvertExpression)
+			return
+		}
+		var args []jinjaToken
+		if p.isOp("(") {
+			args = p.literalArgs()
+		}
+		if jinjaDefaultFilters[filter.text] && len(args) > 0 {
+			attrs = append(attrs, AttrDefault, args[0].text)
+			continue
+		}
+		c.warn(pos, WarnMsgConvertFilter, filter.text)
+	}
+	if p.pos < len(toks) {
+		c.keep(construct, pos, WarnMsgConvertExpression)
+		return
+	}
+	c.emit(promptyTag(TagNameVar, true, attrs...))
+}
+
+// keep emits a construct unchanged and reports it.
+func (c *templateConverter) keep(construct string, pos Position, format string) {
+	c.warn(pos, format, construct)
+	c.emit(construct)
+}
+
+// jinjaExpr converts a Jinja2 expression, reporting unsupported parts.
+func (c *templateConverter) jinjaExpr(expr string, pos Position) string {
+	toks, ok := tokenizeJinja(expr)
+	if !ok {
+		c.warn(pos, WarnMsgConvertProblem, expr, fmt.Sprintf(convertProblemSyntax, expr))
+		return expr
+	}
+	p := &jinjaExprParser{c: c, toks: toks}
+	converted := p.parseOr()
+	if p.pos < len(toks) {
+		p.problem(convertProblemSyntax, p.toks[p.pos].text)
+	}
+	for _, problem := range p.problems {
+		c.warn(pos, WarnMsgConvertProblem, expr, problem)
+	}
+	return converted
+}
+
+// jinjaStatement converts a statement construct.
+func (c *templateConverter) jinjaStatement(stmt, construct string, pos Position) error {
+	keyword, rest, _ := strings.Cut(stmt, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch keyword {
+	case "if":
+		c.push(&convertBlock{kind: convertBlockIf, name: construct, pos: pos, closing: promptyCloseTag(TagNameIf)})
+		c.emit(promptyTag(TagNameIf, false, AttrEval, c.jinjaExpr(rest, pos)))
+	case "elif":
+		if b := c.top(); b == nil || b.kind != convertBlockIf {
+			return NewConvertError(ErrMsgConvertUnexpectedEnd, construct, pos)
+		}
+		c.emit(promptyTag(TagNameElseIf, false, AttrEval, c.jinjaExpr(rest, pos)))
+	case "else":
+		return c.elseBranch(construct, pos)
+	case "endif":
+		return c.end(construct, pos, convertBlockIf)
+	case "for":
+		c.jinjaFor(rest, construct, pos)
+	case "endfor":
+		return c.end(construct, pos, convertBlockFor)
+	case "block":
+		name, _, _ := strings.Cut(rest, " ")
+		c.push(&convertBlock{kind: convertBlockBlock, name: construct, pos: pos, closing: promptyCloseTag(TagNameBlock)})
+		c.emit(promptyTag(TagNameBlock, false, AttrName, name))
+	case "endblock":
+		return c.end(construct, pos, convertBlockBlock)
+	case "extends":
+		toks, ok := tokenizeJinja(rest)
+		if !ok || len(toks) != 1 || toks[0].kind != jinjaTokenString {
+			c.keep(construct, pos, WarnMsgConvertStatement)
+			return nil
+		}
+		c.emit(promptyTag(TagNameExtends, true, AttrTemplate, toks[0].text))
+	case "include":
+		c.jinjaInclude(rest, construct, pos)
+	default:
+		c.keep(construct, pos, WarnMsgConvertStatement)
+	}
+	return nil
+}
+
+// end closes the innermost block, which must be of kind.
+func (c *templateConverter) end(construct string, pos Position, kinds ...string) error {
+	b, err := c.pop(construct, pos, kinds...)
+	if err != nil {
+		return err
+	}
+	if b.kind == convertBlockKept {
+		c.keep(construct, pos, WarnMsgConvertStatement)
+		return nil
+	}
+	c.emit(b.closing)
+	return nil
+}
+
+// elseBranch converts the else branch of the innermost block. The else
+// branch of a loop runs when the collection is empty.
+func (c *templateConverter) elseBranch(construct string, pos Position) error {
+	b := c.top()
+	if b == nil {
+		return NewConvertError(ErrMsgConvertUnexpectedEnd, construct, pos)
+	}
+	switch b.kind {
+	case convertBlockIf, convertBlockUnless, convertBlockSection:
+		c.emit(promptyTag(TagNameElse, false))
+	case convertBlockFor, convertBlockEach:
+		c.emit(b.closing + promptyTag(TagNameIf, false, AttrEval, "!"+b.in))
+		b.closing = promptyCloseTag(TagNameIf)
+	default:
+		c.keep(construct, pos, WarnMsgConvertStatement)
+	}
+	b.elseBlock = true
+	return nil
+}
+
+// jinjaFor converts a for statement of the form
+// "target in iterable [if condition]".
+func (c *templateConverter) jinjaFor(rest, construct string, pos Position) {
+	b := &convertBlock{kind: convertBlockFor, name: construct, pos: pos, closing: promptyCloseTag(TagNameFor)}
+
+	target, iterable, found := strings.Cut(rest, " in ")
+	iterable, condition, hasCondition := strings.Cut(iterable, " if ")
+	iterable = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(iterable), " recursive"))
+
+	names := strings.Split(strings.Trim(target, "() "), ",")
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+	}
+
+	toks, ok := tokenizeJinja(iterable)
+	var segs []string
+	if ok {
+		p := &jinjaExprParser{c: c, toks: toks}
+		segs, ok = p.parsePathSegments()
+		ok = ok && p.pos == len(toks)
+	}
+	if ok && len(segs) > 1 && (segs[len(segs)-1] == "items()") {
+		segs = segs[:len(segs)-1]
+	} else if ok && len(names) != 1 {
+		ok = false
+	}
+	var in string
+	if ok {
+		var plain bool
+		in, plain = c.jinjaPath(segs, nil)
+		ok = plain
+	}
+	if !found || !ok || len(names) > 2 {
+		c.push(&convertBlock{kind: convertBlockKept, name: construct, pos: pos})
+		c.keep(construct, pos, WarnMsgConvertStatement)
+		return
+	}
+
+	b.in = in
+	if len(names) == 2 {
+		b.item = convertEntryItem
+		b.aliases = map[string]string{
+			names[0]: convertEntryItem + convertKeySuffix,
+			names[1]: convertEntryItem + convertValueSuffix,
+		}
+	} else {
+		b.item = names[0]
+	}
+
+	c.push(b)
+	if hasCondition {
+		b.filter = c.jinjaExpr(condition, pos)
+		b.closing = promptyCloseTag(TagNameIf) + b.closing
+	}
+	c.emit(c.forTag(b))
+}
+
+// jinjaInclude converts an include statement. A literal template name
+// becomes the template attribute, any other expression template_expr.
+func (c *templateConverter) jinjaInclude(rest, construct string, pos Position) {
+	for _, suffix := range []string{" with context", " without context"} {
+		rest = strings.TrimSuffix(rest, suffix)
+	}
+	if strings.HasSuffix(rest, " ignore missing") {
+		rest = strings.TrimSuffix(rest, " ignore missing")
+		c.warn(pos, WarnMsgConvertIgnoreMiss, rest)
+	}
+	toks, ok := tokenizeJinja(rest)
+	if !ok || len(toks) == 0 {
+		c.keep(construct, pos, WarnMsgConvertStatement)
+		return
+	}
+	if len(toks) == 1 && toks[0].kind == jinjaTokenString {
+		c.emit(promptyTag(TagNameInclude, true, AttrTemplate, toks[0].text))
+		return
+	}
+	c.emit(promptyTag(TagNameInclude, true, AttrTemplateExpr, c.jinjaExpr(rest, pos)))
+}
+
+// jinjaPath resolves a dotted reference against loop variables and aliases.
+// plain reports whether the result is a variable path rather than an
+// expression. Unsupported loop variables are recorded on p when given.
+func (c *templateConverter) jinjaPath(segs []string, p *jinjaExprParser) (string, bool) {
+	for i := len(c.blocks) - 1; i >= 0; i-- {
+		b := c.blocks[i]
+		if alias, ok := b.aliases[segs[0]]; ok && !b.elseBlock {
+			return strings.Join(append([]string{alias}, segs[1:]...), "."), true
+		}
+	}
+	if segs[0] != convertLoopVar || len(segs) != 2 {
+		return strings.Join(segs, "."), true
+	}
+
+	var loop *convertBlock
+	for i := len(c.blocks) - 1; i >= 0 && loop == nil; i-- {
+		if c.blocks[i].kind == convertBlockFor && !c.blocks[i].elseBlock {
+			loop = c.blocks[i]
+		}
+	}
+	if loop == nil {
+		return strings.Join(segs, "."), true
+	}
+	switch segs[1] {
+	case "index0":
+		return c.loopIndex(loop), true
+	case "index":
+		return "(" + c.loopIndex(loop) + " + 1)", false
+	case "first":
+		return c.loopIndex(loop) + " == 0", false
+	}
+	if p != nil {
+		p.problem(convertProblemLoop, strings.Join(segs, "."))
+	}
+	return strings.Join(segs, "."), false
+}
+
+// --- Jinja2 expressions ---
+
+type jinjaTokenKind int
+
+const (
+	jinjaTokenIdent jinjaTokenKind = iota
+	jinjaTokenNumber
+	jinjaTokenString
+	jinjaTokenOp
+)
+
+type jinjaToken struct {
+	kind jinjaTokenKind
+	text string // decoded value for strings
+}
+
+// tokenizeJinja splits a Jinja2 expression into tokens.
+func tokenizeJinja(expr string) ([]jinjaToken, bool) {
+	var toks []jinjaToken
+	for i := 0; i < len(expr); {
+		ch := expr[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z':
+			start := i
+			for i < len(expr) && (expr[i] == '_' || isDigit(expr[i]) ||
+				expr[i] >= 'a' && expr[i] <= 'z' || expr[i] >= 'A' && expr[i] <= 'Z') {
+				i++
+			}
+			toks = append(toks, jinjaToken{kind: jinjaTokenIdent, text: expr[start:i]})
+		case isDigit(ch):
+			start := i
+			for i < len(expr) && (isDigit(expr[i]) || expr[i] == '.') {
+				i++
+			}
+			toks = append(toks, jinjaToken{kind: jinjaTokenNumber, text: expr[start:i]})
+		case ch == '\'' || ch == '"':
+			var sb strings.Builder
+			i++
+			for i < len(expr) && expr[i] != ch {
+				if expr[i] == '\\' && i+1 < len(expr) {
+					i++
+				}
+				sb.WriteByte(expr[i])
+				i++
+			}
+			if i >= len(expr) {
+				return nil, false
+			}
+			i++
+			toks = append(toks, jinjaToken{kind: jinjaTokenString, text: sb.String()})
+		default:
+			op := string(ch)
+			if i+1 < len(expr) {
+				switch two := expr[i : i+2]; two {
+				case "==", "!=", "<=", ">=", "//", "**":
+					op = two
+				}
+			}
+			if !strings.Contains("=!<>+-*/%~()[]{}.,|:", op[:1]) {
+				return nil, false
+			}
+			toks = append(toks, jinjaToken{kind: jinjaTokenOp, text: op})
+			i += len(op)
+		}
+	}
+	return toks, true
+}
+
+// jinjaExprParser converts Jinja2 expression tokens into a prompty
+// expression by recursive descent, collecting unsupported constructs as
+// problems instead of failing.
+type jinjaExprParser struct {
+	c        *templateConverter
+	toks     []jinjaToken
+	pos      int
+	problems []string
+}
+
+func (p *jinjaExprParser) problem(format string, args ...any) {
+	p.problems = append(p.problems, fmt.Sprintf(format, args...))
+}
+
+func (p *jinjaExprParser) next() jinjaToken {
+	if p.pos >= len(p.toks) {
+		return jinjaToken{kind: jinjaTokenOp}
+	}
+	tok := p.toks[p.pos]
+	p.pos++
+	return tok
+}
+
+func (p *jinjaExprParser) isOp(op string) bool {
+	return p.pos < len(p.toks) && p.toks[p.pos].kind == jinjaTokenOp && p.toks[p.pos].text == op
+}
+
+func (p *jinjaExprParser) isIdent(word string) bool {
+	return p.pos < len(p.toks) && p.toks[p.pos].kind == jinjaTokenIdent && p.toks[p.pos].text == word
+}
+
+func (p *jinjaExprParser) parseOr() string {
+	left := p.parseAnd()
+	for p.isIdent("or") {
+		p.pos++
+		left += " || " + p.parseAnd()
+	}
+	return left
+}
+
+func (p *jinjaExprParser) parseAnd() string {
+	left := p.parseNot()
+	for p.isIdent("and") {
+		p.pos++
+		left += " && " + p.parseNot()
+	}
+	return left
+}
+
+func (p *jinjaExprParser) parseNot() string {
+	if p.isIdent("not") {
+		p.pos++
+		return "!" + convertGroup(p.parseNot())
+	}
+	return p.parseCompare()
+}
+
+func (p *jinjaExprParser) parseCompare() string {
+	left := p.parseConcat()
+	for p.pos < len(p.toks) {
+		tok := p.toks[p.pos]
+		switch {
+		case tok.kind == jinjaTokenOp && (tok.text == "==" || tok.text == "!=" ||
+			tok.text == "<" || tok.text == ">" || tok.text == "<=" || tok.text == ">="):
+			p.pos++
+			left += " " + tok.text + " " + p.parseConcat()
+		case p.isIdent("in"):
+			p.pos++
+			left = "contains(" + p.parseConcat() + ", " + left + ")"
+		case p.isIdent("not") && p.pos+1 < len(p.toks) && p.toks[p.pos+1].text == "in":
+			p.pos += 2
+			left = "!contains(" + p.parseConcat() + ", " + left + ")"
+		case p.isIdent("is"):
+			p.pos++
+			left = p.parseTest(left)
+		default:
+			return left
+		}
+	}
+	return left
+}
+
+// parseTest converts the tests that compare against nil.
+func (p *jinjaExprParser) parseTest(operand string) string {
+	negate := p.isIdent("not")
+	if negate {
+		p.pos++
+	}
+	test := p.next().text
+	var isNil bool
+	switch test {
+	case "defined":
+		isNil = negate
+	case "undefined", "none":
+		isNil = !negate
+	default:
+		p.problem(convertProblemTest, test)
+		return operand
+	}
+	if isNil {
+		return operand + " == nil"
+	}
+	return operand + " != nil"
+}
+
+func (p *jinjaExprParser) parseConcat() string {
+	left := p.parseUnary()
+	for p.pos < len(p.toks) && p.toks[p.pos].kind == jinjaTokenOp {
+		op := p.toks[p.pos].text
+		switch op {
+		case "+", "~":
+		case "-", "*", "/", "//", "%", "**":
+			p.problem(convertProblemOperator, op)
+		default:
+			return left
+		}
+		p.pos++
+		if op == "~" {
+			op = "+"
+		}
+		left += " " + op + " " + p.parseUnary()
+	}
+	return left
+}
+
+func (p *jinjaExprParser) parseUnary() string {
+	if p.isOp("-") {
+		p.pos++
+		return "-" + p.parseUnary()
+	}
+	value := p.parsePrimary()
+	for p.isOp("|") {
+		p.pos++
+		value = p.applyFilter(p.next().text, value, p.callArgs())
+	}
+	return value
+}
+
+func (p *jinjaExprParser) parsePrimary() string {
+	tok := p.next()
+	switch tok.kind {
+	case jinjaTokenNumber:
+		return tok.text
+	case jinjaTokenString:
+		return convertStringLiteral(tok.text)
+	case jinjaTokenIdent:
+		switch tok.text {
+		case "True", "true":
+			return convertLiteralTrue
+		case "False", "false":
+			return convertLiteralFalse
+		case "None", "none":
+			return convertLiteralNil
+		}
+		p.pos--
+		return p.parseReference()
+	}
+
+	switch tok.text {
+	case "(":
+		inner := p.parseOr()
+		p.expect(")")
+		return "(" + inner + ")"
+	case "[", "{":
+		p.problem(convertProblemLiteral)
+		depth := 1
+		for p.pos < len(p.toks) && depth > 0 {
+			switch p.next().text {
+			case "[", "{":
+				depth++
+			case "]", "}":
+				depth--
+			}
+		}
+		return convertLiteralNil
+	}
+	p.problem(convertProblemSyntax, tok.text)
+	return tok.text
+}
+
+// parseReference converts a variable reference, method call or function
+// call.
+func (p *jinjaExprParser) parseReference() string {
+	segs, _ := p.parsePathSegments()
+	if last := segs[len(segs)-1]; last == "items()" {
+		p.problem(convertProblemFunction, last)
+	}
+	if !p.isOp("(") {
+		path, _ := p.c.jinjaPath(segs, p)
+		return path
+	}
+
+	args := p.callArgs()
+	if len(segs) == 1 {
+		p.problem(convertProblemFunction, segs[0])
+		return segs[0] + "(" + strings.Join(args, ", ") + ")"
+	}
+	method := segs[len(segs)-1]
+	target, _ := p.c.jinjaPath(segs[:len(segs)-1], p)
+	fn, ok := jinjaMethodFuncs[method]
+	if !ok {
+		p.problem(convertProblemFunction, method)
+		fn = method
+	}
+	return fn + "(" + strings.Join(append([]string{target}, args...), ", ") + ")"
+}
+
+// parsePathSegments reads a dotted or subscripted reference such as
+// user.address['city']. A trailing items() call is returned as the
+// segment "items()".
+func (p *jinjaExprParser) parsePathSegments() ([]string, bool) {
+	tok := p.next()
+	if tok.kind != jinjaTokenIdent {
+		return nil, false
+	}
+	segs := []string{tok.text}
+	for p.pos < len(p.toks) {
+		switch {
+		case p.isOp("."):
+			p.pos++
+			seg := p.next()
+			if seg.kind != jinjaTokenIdent && seg.kind != jinjaTokenNumber {
+				return segs, false
+			}
+			if seg.text == "items" && p.isOp("(") && p.pos+1 < len(p.toks) && p.toks[p.pos+1].text == ")" {
+				p.pos += 2
+				seg.text = "items()"
+			}
+			segs = append(segs, seg.text)
+		case p.isOp("[") && p.pos+2 < len(p.toks) && p.toks[p.pos+2].text == "]" &&
+			p.toks[p.pos+1].kind != jinjaTokenOp && p.toks[p.pos+1].kind != jinjaTokenIdent:
+			segs = append(segs, p.toks[p.pos+1].text)
+			p.pos += 3
+		default:
+			return segs, true
+		}
+	}
+	return segs, true
+}
+
+// callArgs reads an optional parenthesized argument list.
+func (p *jinjaExprParser) callArgs() []string {
+	if !p.isOp("(") {
+		return nil
+	}
+	p.pos++
+	var args []string
+	for p.pos < len(p.toks) && !p.isOp(")") {
+		if p.pos+1 < len(p.toks) && p.toks[p.pos].kind == jinjaTokenIdent && p.toks[p.pos+1].text == "=" {
+			p.pos += 2 // keyword arguments are passed positionally
+		}
+		args = append(args, p.parseOr())
+		if p.isOp(",") {
+			p.pos++
+		} else {
+			break
+		}
+	}
+	p.expect(")")
+	return args
+}
+
+// literalArgs reads a parenthesized list of literal arguments, as used by
+// output filters.
+func (p *jinjaExprParser) literalArgs() []jinjaToken {
+	p.pos++
+	var args []jinjaToken
+	for p.pos < len(p.toks) && !p.isOp(")") {
+		tok := p.next()
+		if tok.kind != jinjaTokenOp {
+			args = append(args, tok)
+		}
+	}
+	p.expect(")")
+	return args
+}
+
+func (p *jinjaExprParser) expect(op string) {
+	if !p.isOp(op) {
+		p.problem(convertProblemSyntax, p.next().text)
+		return
+	}
+	p.pos++
+}
+
+// applyFilter converts a filter applied to value into a function call.
+func (p *jinjaExprParser) applyFilter(name, value string, args []string) string {
+	fn, ok := jinjaFilterFuncs[name]
+	if !ok {
+		p.problem(convertProblemFilter, name)
+		return value
+	}
+	switch fn {
+	case "default", "join":
+		if len(args) == 0 {
+			args = []string{convertStringLiteral("")}
+		}
+		args = args[:1]
+	case "replace":
+		if len(args) > 2 {
+			args = args[:2]
+		}
+	default:
+		args = nil
+	}
+	return fn + "(" + strings.Join(append([]string{value}, args...), ", ") + ")"
+}
+
+// convertStringLiteral quotes s as a single-quoted expression string.
+func convertStringLiteral(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+// convertGroup parenthesizes a compound expression operand.
+func convertGroup(expr string) string {
+	if strings.ContainsAny(expr, " ") && !(strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")")) {
+		return "(" + expr + ")"
+	}
+	return expr
+}
+
+// --- Handlebars ---
+
+// convertHandlebars converts a Handlebars or Mustache template.
+func (c *templateConverter) convertHandlebars() error {
+	c.lastText = -1
+	src := c.source
+	i := 0
+	for i < len(src) {
+		start := strings.Index(src[i:], hbOpen)
+		if start < 0 {
+			c.text(src[i:])
+			break
+		}
+		start += i
+		c.text(src[i:start])
+
+		opener, closer := hbOpen, hbClose
+		switch {
+		case strings.HasPrefix(src[start:], hbLongCommentOpen):
+			opener, closer = hbLongCommentOpen, hbLongCommentClose
+		case strings.HasPrefix(src[start:], hbTripleOpen):
+			opener, closer = hbTripleOpen, hbTripleClose
+		}
+		pos := positionAt(src, start)
+		end := strings.Index(src[start+len(opener):], closer)
+		if end < 0 {
+			return NewConvertError(ErrMsgConvertUnterminated, opener, pos)
+		}
+		end += start + len(opener)
+		construct := src[start : end+len(closer)]
+		inner := c.trimMarkers(src[start+len(opener):end], hbTrimMarker)
+		i = end + len(closer)
+
+		if err := c.hbConstruct(opener, inner, construct, pos); err != nil {
+			return err
+		}
+	}
+	return c.finish()
+}
+
+// hbConstruct converts a single Handlebars construct.
+func (c *templateConverter) hbConstruct(opener, inner, construct string, pos Position) error {
+	if opener == hbLongCommentOpen {
+		c.comment(inner)
+		return nil
+	}
+	if opener == hbTripleOpen || inner == "" {
+		c.hbOutput(inner, construct, pos)
+		return nil
+	}
+
+	switch inner[0] {
+	case '!':
+		c.comment(strings.TrimSpace(inner[1:]))
+	case '&':
+		c.hbOutput(strings.TrimSpace(inner[1:]), construct, pos)
+	case '#':
+		c.hbBlock(strings.TrimSpace(inner[1:]), construct, pos)
+	case '^':
+		if strings.TrimSpace(inner[1:]) == "" {
+			return c.elseBranch(construct, pos)
+		}
+		name := strings.TrimSpace(inner[1:])
+		c.push(&convertBlock{kind: convertBlockSection, name: name, pos: pos, closing: promptyCloseTag(TagNameIf)})
+		c.emit(promptyTag(TagNameIf, false, AttrEval, "!"+c.hbPath(name, pos)))
+	case '/':
+		return c.hbEnd(strings.TrimSpace(inner[1:]), construct, pos)
+	case '>':
+		c.hbPartial(strings.TrimSpace(inner[1:]), construct, pos)
+	default:
+		if inner == hbElse {
+			return c.elseBranch(construct, pos)
+		}
+		if cond, ok := strings.CutPrefix(inner, hbElse+" if "); ok {
+			if b := c.top(); b == nil || (b.kind != convertBlockIf && b.kind != convertBlockUnless) {
+				return NewConvertError(ErrMsgConvertUnexpectedEnd, construct, pos)
+			}
+			c.emit(promptyTag(TagNameElseIf, false, AttrEval, c.hbCondition(cond, pos)))
+			return nil
+		}
+		c.hbOutput(inner, construct, pos)
+	}
+	return nil
+}
+
+// hbOutput converts a variable reference. Helper calls are kept.
+func (c *templateConverter) hbOutput(ref, construct string, pos Position) {
+	if ref == hbIndex {
+		if loop := c.hbLoop(); loop != nil {
+			c.emit(promptyTag(TagNameVar, true, AttrName, c.loopIndex(loop)))
+			return
+		}
+	}
+	if strings.ContainsAny(ref, " \t\n") || ref == "" {
+		name, _, _ := strings.Cut(ref, " ")
+		c.warn(pos, WarnMsgConvertHelper, name)
+		c.emit(construct)
+		return
+	}
+	c.emit(promptyTag(TagNameVar, true, AttrName, c.hbPath(ref, pos)))
+}
+
+// hbBlock converts the opening construct of a block helper or section.
+func (c *templateConverter) hbBlock(body, construct string, pos Position) {
+	var params []string
+	if m := hbBlockParams.FindStringSubmatch(body); m != nil {
+		body = m[1]
+		params = strings.Fields(m[2])
+	}
+	helper, args, _ := strings.Cut(body, " ")
+	args = strings.TrimSpace(args)
+	b := &convertBlock{name: helper, pos: pos}
+
+	var open string
+	switch {
+	case helper == convertBlockIf && args != "":
+		b.kind, b.closing = convertBlockIf, promptyCloseTag(TagNameIf)
+		open = promptyTag(TagNameIf, false, AttrEval, c.hbCondition(args, pos))
+	case helper == convertBlockUnless && args != "":
+		b.kind, b.closing = convertBlockUnless, promptyCloseTag(TagNameIf)
+		open = promptyTag(TagNameIf, false, AttrEval, "!"+convertGroup(c.hbCondition(args, pos)))
+	case helper == convertBlockEach && convertPathRef.MatchString(args):
+		b.kind, b.closing = convertBlockEach, promptyCloseTag(TagNameFor)
+		b.in = c.hbPath(args, pos)
+		b.item = c.hbItemName()
+		if len(params) > 0 {
+			b.item = params[0]
+			b.params = map[string]bool{}
+			for _, param := range params {
+				b.params[param] = true
+			}
+		}
+		if len(params) > 1 {
+			b.index = params[1]
+		}
+		b.prefix = b.item
+		open = c.forTag(b)
+	case helper == convertBlockWith && convertPathRef.MatchString(args):
+		b.kind = convertBlockWith
+		target := c.hbPath(args, pos)
+		if len(params) > 0 {
+			b.aliases = map[string]string{params[0]: target}
+		} else {
+			b.prefix = target
+		}
+	case args == "" && convertPathRef.MatchString(helper):
+		c.warn(pos, WarnMsgConvertSection, helper)
+		b.kind, b.closing = convertBlockSection, promptyCloseTag(TagNameIf)
+		open = promptyTag(TagNameIf, false, AttrEval, c.hbPath(helper, pos))
+	default:
+		b.kind = convertBlockKept
+		c.warn(pos, WarnMsgConvertHelper, helper)
+		open = construct
+	}
+	c.push(b)
+	c.emit(open)
+}
+
+// hbEnd converts the closing construct of a block.
+func (c *templateConverter) hbEnd(name, construct string, pos Position) error {
+	b := c.top()
+	if b == nil || b.name != name {
+		return NewConvertError(ErrMsgConvertMismatchedEnd, construct, pos)
+	}
+	c.blocks = c.blocks[:len(c.blocks)-1]
+	if b.kind == convertBlockKept {
+		c.emit(construct)
+		return nil
+	}
+	c.emit(b.closing)
+	return nil
+}
+
+// hbPartial converts a partial with an optional context argument.
+func (c *templateConverter) hbPartial(body, construct string, pos Position) {
+	fields := strings.Fields(body)
+	if len(fields) == 0 || len(fields) > 2 || strings.Contains(body, "=") {
+		c.keep(construct, pos, WarnMsgConvertStatement)
+		return
+	}
+	attrs := []string{AttrTemplate, strings.Trim(fields[0], `"'`)}
+	if len(fields) == 2 {
+		attrs = append(attrs, AttrWith, c.hbPath(fields[1], pos))
+	}
+	c.emit(promptyTag(TagNameInclude, true, attrs...))
+}
+
+// hbCondition converts the argument of an if or unless helper.
+func (c *templateConverter) hbCondition(args string, pos Position) string {
+	if !convertPathRef.MatchString(args) {
+		c.warn(pos, WarnMsgConvertHelper, args)
+		return args
+	}
+	if args == hbIndex {
+		if loop := c.hbLoop(); loop != nil {
+			return c.loopIndex(loop)
+		}
+	}
+	return c.hbPath(args, pos)
+}
+
+// hbLoop returns the innermost each block.
+func (c *templateConverter) hbLoop() *convertBlock {
+	for i := len(c.blocks) - 1; i >= 0; i-- {
+		if c.blocks[i].kind == convertBlockEach && !c.blocks[i].elseBlock {
+			return c.blocks[i]
+		}
+	}
+	return nil
+}
+
+// hbItemName returns an item variable name not used by an enclosing loop.
+func (c *templateConverter) hbItemName() string {
+	depth := 0
+	for _, b := range c.blocks {
+		if b.kind == convertBlockEach {
+			depth++
+		}
+	}
+	if depth == 0 {
+		return hbDefaultItem
+	}
+	return hbDefaultItem + strconv.Itoa(depth+1)
+}
+
+// hbPath resolves a Handlebars reference against the enclosing context
+// blocks: bare names are relative to the innermost each or with block,
+// "../" climbs one context and "this" is the context itself.
+func (c *templateConverter) hbPath(ref string, pos Position) string {
+	if root, ok := strings.CutPrefix(ref, hbRootPrefix); ok {
+		return root
+	}
+
+	var scopes []*convertBlock
+	for i := len(c.blocks) - 1; i >= 0; i-- {
+		b := c.blocks[i]
+		if (b.prefix != "" || b.params != nil || b.aliases != nil) && !b.elseBlock {
+			scopes = append(scopes, b)
+		}
+	}
+
+	level := 0
+	for strings.HasPrefix(ref, hbParentPrefix) {
+		ref = strings.TrimPrefix(ref, hbParentPrefix)
+		level++
+	}
+	for _, self := range []string{hbThis + ".", hbThis + "/", "./"} {
+		ref = strings.TrimPrefix(ref, self)
+	}
+	if ref == hbThis || ref == "." {
+		ref = ""
+	}
+	ref = strings.ReplaceAll(ref, "/", ".")
+	if strings.HasPrefix(ref, "@") {
+		c.warn(pos, WarnMsgConvertReference, ref)
+		return ref
+	}
+
+	if ref != "" {
+		head, tail, _ := strings.Cut(ref, ".")
+		for _, b := range scopes {
+			if alias, ok := b.aliases[head]; ok {
+				return scopedPath(alias, tail)
+			}
+			if b.params[head] {
+				return ref
+			}
+		}
+	}
+
+	for _, b := range scopes {
+		if b.prefix == "" {
+			continue
+		}
+		if level == 0 {
+			return scopedPath(b.prefix, ref)
+		}
+		level--
+	}
+	if ref == "" {
+		c.warn(pos, WarnMsgConvertReference, hbThis)
+		return hbThis
+	}
+	return ref
+}
+
+// scopedPath joins two dotted paths, either of which may be empty.
+func scopedPath(prefix, path string) string {
+	if prefix == "" {
+		return path
+	}
+	if path == "" {
+		return prefix
+	}
+	return prefix + "." + path
+}
+
+// --- prompty markup ---
+
+// promptyTag renders a prompty tag with attributes given as name/value
+// pairs. Attribute values are escaped for double quotes.
+func promptyTag(name string, selfClosing bool, attrs ...string) string {
+	var sb strings.Builder
+	sb.WriteString(DefaultOpenDelim)
+	sb.WriteString(name)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		value := strings.ReplaceAll(attrs[i+1], `\`, `\\`)
+		value = strings.ReplaceAll(value, `"`, `\"`)
+		sb.WriteString(" " + attrs[i] + `="` + value + `"`)
+	}
+	if selfClosing {
+		sb.WriteString(" /")
+	}
+	sb.WriteString(DefaultCloseDelim)
+	return sb.String()
+}
+
+// promptyCloseTag renders the closing tag of a prompty block.
+func promptyCloseTag(name string) string {
+	return DefaultOpenDelim + "/" + name + DefaultCloseDelim
+}
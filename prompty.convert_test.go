@@ -0,0 +1,174 @@
+package prompty
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertFrom_Jinja2(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{"variable", "Hi {{ user.name }}!", `Hi {~prompty.var name="user.name" /~}!`},
+		{"default filter", "{{ name | default('friend') }}", `{~prompty.var name="name" default="friend" /~}`},
+		{"subscript", "{{ user['name'] }}", `{~prompty.var name="user.name" /~}`},
+		{"string literal", "{{ '{{' }}", "{{"},
+		{
+			"conditionals",
+			`{% if items|length > 0 and not done %}a{% elif user is defined %}b{% else %}c{% endif %}`,
+			`{~prompty.if eval="len(items) > 0 && !done"~}a{~prompty.elseif eval="user != nil"~}b{~prompty.else~}c{~/prompty.if~}`,
+		},
+		{
+			"expression literals",
+			`{% if name == "Ann" or flag == True or x is none %}y{% endif %}`,
+			`{~prompty.if eval="name == 'Ann' || flag == true || x == nil"~}y{~/prompty.if~}`,
+		},
+		{
+			"membership",
+			`{% if 'admin' in roles %}y{% endif %}`,
+			`{~prompty.if eval="contains(roles, 'admin')"~}y{~/prompty.if~}`,
+		},
+		{
+			"loop with index and else",
+			`{% for x in items %}{{ loop.index0 }}={{ x }}{% else %}none{% endfor %}`,
+			`{~prompty.for item="x" in="items" index="x_index"~}{~prompty.var name="x_index" /~}={~prompty.var name="x" /~}{~/prompty.for~}{~prompty.if eval="!items"~}none{~/prompty.if~}`,
+		},
+		{
+			"loop filter",
+			`{% for x in items if x.ok %}{{ x.id }}{% endfor %}`,
+			`{~prompty.for item="x" in="items"~}{~prompty.if eval="x.ok"~}{~prompty.var name="x.id" /~}{~/prompty.if~}{~/prompty.for~}`,
+		},
+		{
+			"dict items",
+			`{% for k, v in env.items() %}{{ k }}={{ v }};{% endfor %}`,
+			`{~prompty.for item="entry" in="env"~}{~prompty.var name="entry.key" /~}={~prompty.var name="entry.value" /~};{~/prompty.for~}`,
+		},
+		{"include", `{% include "header" %}`, `{~prompty.include template="header" /~}`},
+		{"dynamic include", `{% include name ~ '-footer' %}`, `{~prompty.include template_expr="name + '-footer'" /~}`},
+		{
+			"inheritance",
+			`{% extends "base" %}{% block body %}{{ super() }} more{% endblock %}`,
+			`{~prompty.extends template="base" /~}{~prompty.block name="body"~}{~prompty.parent /~} more{~/prompty.block~}`,
+		},
+		{"comment", "{# note #}", `{~prompty.comment~}note{~/prompty.comment~}`},
+		{"raw", "{% raw %}{{ x }}{% endraw %}", `{~prompty.raw~}{{ x }}{~/prompty.raw~}`},
+		{"whitespace control", "a  \n{%- if x -%}\n  b  {%- endif %}", `a{~prompty.if eval="x"~}b{~/prompty.if~}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converted, report, err := ConvertFrom(DialectJinja2, tt.source)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, converted)
+			assert.True(t, report.Lossless(), "%v", report.Warnings)
+		})
+	}
+}
+
+func TestConvertFrom_Handlebars(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{"variables", "{{name}} {{{html}}} {{user/name}}", `{~prompty.var name="name" /~} {~prompty.var name="html" /~} {~prompty.var name="user.name" /~}`},
+		{
+			"conditionals",
+			"{{#if a}}1{{else if b}}2{{else}}3{{/if}}{{#unless ok}}no{{/unless}}",
+			`{~prompty.if eval="a"~}1{~prompty.elseif eval="b"~}2{~prompty.else~}3{~/prompty.if~}{~prompty.if eval="!ok"~}no{~/prompty.if~}`,
+		},
+		{
+			"each scopes",
+			"{{#each items}}{{@index}}:{{name}} {{this}} {{../title}}{{else}}empty{{/each}}",
+			`{~prompty.for item="item" in="items" index="item_index"~}{~prompty.var name="item_index" /~}:{~prompty.var name="item.name" /~} {~prompty.var name="item" /~} {~prompty.var name="title" /~}{~/prompty.for~}{~prompty.if eval="!items"~}empty{~/prompty.if~}`,
+		},
+		{
+			"nested each with block params",
+			"{{#each groups as |g|}}{{#each g.members}}{{name}}@{{g.id}}{{/each}}{{/each}}",
+			`{~prompty.for item="g" in="groups"~}{~prompty.for item="item2" in="g.members"~}{~prompty.var name="item2.name" /~}@{~prompty.var name="g.id" /~}{~/prompty.for~}{~/prompty.for~}`,
+		},
+		{"with", "{{#with user}}{{name}}{{/with}}", `{~prompty.var name="user.name" /~}`},
+		{"partial", "{{> footer user}}", `{~prompty.include template="footer" with="user" /~}`},
+		{"comments", "{{! one }}{{!-- two --}}", `{~prompty.comment~}one{~/prompty.comment~}{~prompty.comment~}two{~/prompty.comment~}`},
+		{"inverted section", "{{^items}}none{{/items}}", `{~prompty.if eval="!items"~}none{~/prompty.if~}`},
+		{"whitespace control", "a \n{{~#if x~}}\n b {{~/if}}", `a{~prompty.if eval="x"~}b{~/prompty.if~}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converted, report, err := ConvertFrom(DialectHandlebars, tt.source)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, converted)
+			assert.True(t, report.Lossless(), "%v", report.Warnings)
+		})
+	}
+}
+
+func TestConvertFrom_Warnings(t *testing.T) {
+	converted, report, err := ConvertFrom(DialectJinja2, "Hello\n  {{ name | title }} {% set x = 1 %}{{ a * b }}")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello\n  {~prompty.var name=\"name\" /~} {% set x = 1 %}{{ a * b }}", converted)
+	require.Len(t, report.Warnings, 3)
+	assert.Equal(t, PromptFieldBody, report.Warnings[0].Field)
+	assert.Equal(t, 2, report.Warnings[0].Position.Line)
+	assert.Equal(t, 3, report.Warnings[0].Position.Column)
+	assert.Contains(t, report.Warnings[0].Message, `"title"`)
+	assert.Contains(t, report.Warnings[1].Message, "{% set x = 1 %}")
+	assert.Contains(t, report.Warnings[2].Message, "{{ a * b }}")
+
+	converted, report, err = ConvertFrom(DialectHandlebars, "{{#each items}}{{@key}}{{/each}}{{loud name}}{{#list}}x{{/list}}")
+	require.NoError(t, err)
+	assert.Contains(t, converted, "{{loud name}}")
+	require.Len(t, report.Warnings, 3)
+	assert.Contains(t, report.Warnings[0].Message, "@key")
+	assert.Contains(t, report.Warnings[1].Message, `"loud"`)
+	assert.Contains(t, report.Warnings[2].Message, `"list"`)
+}
+
+func TestConvertFrom_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect string
+		source  string
+		wantErr string
+	}{
+		{"unknown dialect", "liquid", "x", ErrMsgConvertUnknownDialect},
+		{"unterminated", DialectJinja2, "a {{ b", ErrMsgConvertUnterminated},
+		{"unclosed", DialectJinja2, "{% if a %}x", ErrMsgConvertUnclosedBlock},
+		{"mismatched", DialectJinja2, "{% if a %}x{% endfor %}", ErrMsgConvertMismatchedEnd},
+		{"stray end", DialectJinja2, "{% endif %}", ErrMsgConvertUnexpectedEnd},
+		{"handlebars mismatched", DialectHandlebars, "{{#if a}}x{{/each}}", ErrMsgConvertMismatchedEnd},
+		{"handlebars unclosed", DialectHandlebars, "{{#each a}}x", ErrMsgConvertUnclosedBlock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ConvertFrom(tt.dialect, tt.source)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestConvertFrom_Executes(t *testing.T) {
+	data := map[string]any{
+		"title": "Team",
+		"items": []any{map[string]any{"name": "a"}, map[string]any{"name": "b"}},
+	}
+	sources := map[string]string{
+		DialectJinja2:     `{% for x in items %}{{ loop.index0 }}{{ x.name }}/{{ title }} {% endfor %}`,
+		DialectHandlebars: `{{#each items}}{{@index}}{{name}}/{{../title}} {{/each}}`,
+	}
+	for dialect, source := range sources {
+		t.Run(dialect, func(t *testing.T) {
+			converted, _, err := ConvertFrom(dialect, source)
+			require.NoError(t, err)
+			tmpl, err := MustNew().Parse(converted)
+			require.NoError(t, err)
+			out, err := tmpl.Execute(t.Context(), data)
+			require.NoError(t, err)
+			assert.Equal(t, "0a/Team 1b/Team ", out)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -34,6 +35,9 @@ type DryRunResult struct {
 	// Loops lists all loop blocks found
 	Loops []LoopReference
 
+	// Functions lists all function calls found in eval expressions
+	Functions []FunctionReference
+
 	// Errors contains any structural errors found
 	Errors []string
 
@@ -56,6 +60,17 @@ type VariableReference struct {
 	HasDefault  bool     // Whether a default was specified
 	InData      bool     // Whether the variable exists in provided data
 	Suggestions []string // Similar variable names if not found
+	Expression  string   // Eval expression containing the reference (empty for prompty.var)
+}
+
+// FunctionReference represents a function call in an eval expression.
+type FunctionReference struct {
+	Name        string   // Function name
+	Expression  string   // Eval expression containing the call
+	Line        int      // Source line number
+	Column      int      // Source column number
+	Registered  bool     // Whether the function is registered with the engine
+	Suggestions []string // Similar registered function names if not registered
 }
 
 // ResolverReference represents a resolver invocation in a template.
@@ -73,8 +88,10 @@ type IncludeReference struct {
 	Attributes   map[string]string // Additional attributes
 	Line         int               // Source line number
 	Column       int               // Source column number
-	Exists       bool              // Whether template is registered
+	Exists       bool              // Whether template is registered (always false for storage includes)
 	Isolated     bool              // Whether isolate="true"
+	Storage      bool              // Whether source="storage"; stored templates are not checked
+	Expression   string            // template_expr of a dynamic include; TemplateName is empty and not checked
 }
 
 // ConditionalReference represents a conditional block in a template.
@@ -173,6 +190,7 @@ func (t *Template) DryRun(ctx context.Context, data map[string]any) *DryRunResul
 		Includes:         make([]IncludeReference, 0),
 		Conditionals:     make([]ConditionalReference, 0),
 		Loops:            make([]LoopReference, 0),
+		Functions:        make([]FunctionReference, 0),
 		Errors:           make([]string, 0),
 		Warnings:         make([]string, 0),
 		MissingVariables: make([]string, 0),
@@ -185,8 +203,17 @@ func (t *Template) DryRun(ctx context.Context, data map[string]any) *DryRunResul
 	// Collect available keys for suggestions
 	availableKeys := collectAllKeys(data, "")
 
-	// Walk the AST and collect references
-	t.walkASTForDryRun(t.ast, data, result, usedKeys, availableKeys)
+	// Walk the AST, with imported blocks expanded, and collect references
+	ast := t.ast
+	if t.engine != nil && internal.HasBlockImports(ast) {
+		resolved, err := t.resolveBlockImports(ctx)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		} else {
+			ast = resolved
+		}
+	}
+	t.walkASTForDryRun(ast, data, result, usedKeys, availableKeys, nil)
 
 	// Find missing variables
 	missingSet := make(map[string]bool)
@@ -212,7 +239,7 @@ func (t *Template) DryRun(ctx context.Context, data map[string]any) *DryRunResul
 	sort.Strings(result.UnusedVariables)
 
 	// Generate placeholder output
-	result.Output = t.generatePlaceholderOutput(t.ast, data)
+	result.Output = t.generatePlaceholderOutput(ast, data)
 
 	// Set valid based on errors
 	if len(result.Errors) > 0 {
@@ -223,29 +250,34 @@ func (t *Template) DryRun(ctx context.Context, data map[string]any) *DryRunResul
 }
 
 // walkASTForDryRun recursively walks the AST to collect dry-run information.
-func (t *Template) walkASTForDryRun(node interface{}, data map[string]any, result *DryRunResult, usedKeys map[string]bool, availableKeys []string) {
+func (t *Template) walkASTForDryRun(node interface{}, data map[string]any, result *DryRunResult, usedKeys map[string]bool, availableKeys []string, scope loopScope) {
 	switch n := node.(type) {
 	case *internal.RootNode:
 		for _, child := range n.Children {
-			t.walkASTForDryRun(child, data, result, usedKeys, availableKeys)
+			t.walkASTForDryRun(child, data, result, usedKeys, availableKeys, scope)
 		}
 
 	case *internal.TagNode:
-		t.processTagNodeForDryRun(n, data, result, usedKeys, availableKeys)
+		t.processTagNodeForDryRun(n, data, result, usedKeys, availableKeys, scope)
 
 	case *internal.ConditionalNode:
-		t.processConditionalNodeForDryRun(n, data, result, usedKeys, availableKeys)
+		t.processConditionalNodeForDryRun(n, data, result, usedKeys, availableKeys, scope)
 
 	case *internal.ForNode:
-		t.processForNodeForDryRun(n, data, result, usedKeys, availableKeys)
+		t.processForNodeForDryRun(n, data, result, usedKeys, availableKeys, scope)
 
 	case *internal.SwitchNode:
-		t.processSwitchNodeForDryRun(n, data, result, usedKeys, availableKeys)
+		t.processSwitchNodeForDryRun(n, data, result, usedKeys, availableKeys, scope)
+
+	case *internal.BlockNode:
+		for _, child := range n.Children {
+			t.walkASTForDryRun(child, data, result, usedKeys, availableKeys, scope)
+		}
 	}
 }
 
 // processTagNodeForDryRun processes a tag node for dry-run.
-func (t *Template) processTagNodeForDryRun(n *internal.TagNode, data map[string]any, result *DryRunResult, usedKeys map[string]bool, availableKeys []string) {
+func (t *Template) processTagNodeForDryRun(n *internal.TagNode, data map[string]any, result *DryRunResult, usedKeys map[string]bool, availableKeys []string, scope loopScope) {
 	attrs := n.Attributes.Map()
 	pos := n.Pos()
 	line := pos.Line
@@ -257,35 +289,26 @@ func (t *Template) processTagNodeForDryRun(n *internal.TagNode, data map[string]
 		defaultVal := n.Attributes.GetDefault(AttrDefault, "")
 		hasDefault := n.Attributes.Has(AttrDefault)
 
-		// Check if variable exists in data
-		inData := hasPath(data, varName)
-		if inData {
-			markKeyUsed(usedKeys, varName)
-		}
-
-		// Find suggestions if not found
-		var suggestions []string
-		if !inData && !hasDefault {
-			suggestions = findSimilarStrings(varName, availableKeys, 3)
+		ref := VariableReference{
+			Name:       varName,
+			Default:    defaultVal,
+			Line:       line,
+			Column:     col,
+			HasDefault: hasDefault,
 		}
-
-		result.Variables = append(result.Variables, VariableReference{
-			Name:        varName,
-			Default:     defaultVal,
-			Line:        line,
-			Column:      col,
-			HasDefault:  hasDefault,
-			InData:      inData,
-			Suggestions: suggestions,
-		})
+		t.lookupVariableForDryRun(&ref, data, usedKeys, availableKeys, scope)
+		result.Variables = append(result.Variables, ref)
 
 	case TagNameInclude:
 		tmplName, _ := n.Attributes.Get(AttrTemplate)
+		tmplExpr, dynamic := n.Attributes.Get(AttrTemplateExpr)
 		isolated := n.Attributes.GetDefault(AttrIsolate, "") == AttrValueTrue
+		storage := n.Attributes.GetDefault(AttrSource, "") == AttrValueStorage
 
-		// Check if template exists
+		// Check if template exists; dynamic names are only known at execution
+		checked := !storage && !dynamic
 		exists := false
-		if t.engine != nil {
+		if t.engine != nil && checked {
 			exists = t.engine.HasTemplate(tmplName)
 		}
 
@@ -296,14 +319,80 @@ func (t *Template) processTagNodeForDryRun(n *internal.TagNode, data map[string]
 			Column:       col,
 			Exists:       exists,
 			Isolated:     isolated,
+			Storage:      storage,
+			Expression:   tmplExpr,
 		})
 
-		if !exists {
+		if !exists && checked {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("line %d: included template '%s' not found", line, tmplName))
 		}
+		if dynamic {
+			t.processExpressionForDryRun(tmplExpr, pos, data, result, usedKeys, availableKeys, scope)
+		}
 
-	case TagNameRaw, TagNameComment:
-		// No action needed for raw/comment
+		// Mapped 'with' expressions are evaluated in this template's data
+		if with, ok := n.Attributes.Get(AttrWith); ok && !isolated {
+			bindings, _, err := internal.ParseIncludeBindings(with)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("line %d: %v", line, err))
+			}
+			for _, b := range bindings {
+				t.processExpressionForDryRun(b.Expr, pos, data, result, usedKeys, availableKeys, scope)
+			}
+		}
+
+	case TagNameChoice:
+		from, _ := n.Attributes.Get(AttrFrom)
+		ref := VariableReference{
+			Name:       from,
+			Default:    n.Attributes.GetDefault(AttrDefault, ""),
+			Line:       line,
+			Column:     col,
+			HasDefault: n.Attributes.Has(AttrDefault),
+		}
+		t.lookupVariableForDryRun(&ref, data, usedKeys, availableKeys, scope)
+		result.Variables = append(result.Variables, ref)
+
+	case TagNameHistory:
+		// A missing history renders nothing, like a variable with a default
+		in, _ := n.Attributes.Get(AttrIn)
+		ref := VariableReference{Name: in, Line: line, Column: col, HasDefault: true}
+		t.lookupVariableForDryRun(&ref, data, usedKeys, availableKeys, scope)
+		result.Variables = append(result.Variables, ref)
+
+	case TagNameToolResults:
+		// Missing tool results render nothing, like a variable with a default
+		in := n.Attributes.GetDefault(AttrIn, DefaultToolResultsIn)
+		ref := VariableReference{Name: in, Line: line, Column: col, HasDefault: true}
+		t.lookupVariableForDryRun(&ref, data, usedKeys, availableKeys, scope)
+		result.Variables = append(result.Variables, ref)
+
+	case TagNameImage, TagNameFile:
+		// Content parts only reference data through a from path
+		if from, ok := n.Attributes.Get(AttrFrom); ok {
+			ref := VariableReference{Name: from, Line: line, Column: col}
+			t.lookupVariableForDryRun(&ref, data, usedKeys, availableKeys, scope)
+			result.Variables = append(result.Variables, ref)
+		}
+
+	case TagNameShuffle:
+		// A shuffle is a loop over a random sample of its collection
+		limit, _ := strconv.Atoi(n.Attributes.GetDefault(AttrLimit, ""))
+		loop := internal.NewForNode(
+			n.Attributes.GetDefault(AttrItem, internal.DefaultShuffleItemVar),
+			n.Attributes.GetDefault(AttrIndex, ""),
+			n.Attributes.GetDefault(AttrIn, ""),
+			limit, n.Children, pos)
+		t.processForNodeForDryRun(loop, data, result, usedKeys, availableKeys, scope)
+
+	case TagNameRaw, TagNameComment, TagNameImport, TagNameUse, TagNameCacheBreakpoint:
+		// No action needed for raw/comment/cache breakpoints; block imports are expanded before the walk
+
+	case TagNameMessage, TagNameEscape:
+		// Messages and escape regions render their body
+		for _, child := range n.Children {
+			t.walkASTForDryRun(child, data, result, usedKeys, availableKeys, scope)
+		}
 
 	default:
 		// Custom resolver
@@ -314,11 +403,14 @@ func (t *Template) processTagNodeForDryRun(n *internal.TagNode, data map[string]
 			Column:     col,
 			Registered: true, // Assume registered since it parsed
 		})
+		for _, child := range n.Children {
+			t.walkASTForDryRun(child, data, result, usedKeys, availableKeys, scope)
+		}
 	}
 }
 
 // processConditionalNodeForDryRun processes a conditional node for dry-run.
-func (t *Template) processConditionalNodeForDryRun(n *internal.ConditionalNode, data map[string]any, result *DryRunResult, usedKeys map[string]bool, availableKeys []string) {
+func (t *Template) processConditionalNodeForDryRun(n *internal.ConditionalNode, data map[string]any, result *DryRunResult, usedKeys map[string]bool, availableKeys []string, scope loopScope) {
 	pos := n.Pos()
 
 	// Count branches
@@ -346,16 +438,19 @@ func (t *Template) processConditionalNodeForDryRun(n *internal.ConditionalNode,
 
 	// Walk all branches
 	for _, branch := range n.Branches {
+		if !branch.IsElse {
+			t.processExpressionForDryRun(branch.Condition, branch.Pos, data, result, usedKeys, availableKeys, scope)
+		}
 		for _, child := range branch.Children {
-			t.walkASTForDryRun(child, data, result, usedKeys, availableKeys)
+			t.walkASTForDryRun(child, data, result, usedKeys, availableKeys, scope)
 		}
 	}
 }
 
 // processForNodeForDryRun processes a for node for dry-run.
-func (t *Template) processForNodeForDryRun(n *internal.ForNode, data map[string]any, result *DryRunResult, usedKeys map[string]bool, availableKeys []string) {
+func (t *Template) processForNodeForDryRun(n *internal.ForNode, data map[string]any, result *DryRunResult, usedKeys map[string]bool, availableKeys []string, scope loopScope) {
 	pos := n.Pos()
-	inData := hasPath(data, n.Source)
+	inData := scope.covers(n.Source) || hasPath(data, n.Source, t.config.fieldMatch)
 	if inData {
 		markKeyUsed(usedKeys, n.Source)
 	}
@@ -374,27 +469,111 @@ func (t *Template) processForNodeForDryRun(n *internal.ForNode, data map[string]
 		result.Warnings = append(result.Warnings, fmt.Sprintf("line %d: loop source '%s' not found in data", pos.Line, n.Source))
 	}
 
-	// Walk body (with loop variables added conceptually)
+	// Walk body with the loop variables in scope
+	bodyScope := scope.with(n.ItemVar, n.IndexVar)
 	for _, child := range n.Children {
-		t.walkASTForDryRun(child, data, result, usedKeys, availableKeys)
+		t.walkASTForDryRun(child, data, result, usedKeys, availableKeys, bodyScope)
 	}
 }
 
 // processSwitchNodeForDryRun processes a switch node for dry-run.
-func (t *Template) processSwitchNodeForDryRun(n *internal.SwitchNode, data map[string]any, result *DryRunResult, usedKeys map[string]bool, availableKeys []string) {
+func (t *Template) processSwitchNodeForDryRun(n *internal.SwitchNode, data map[string]any, result *DryRunResult, usedKeys map[string]bool, availableKeys []string, scope loopScope) {
+	t.processExpressionForDryRun(n.Expression, n.Pos(), data, result, usedKeys, availableKeys, scope)
+
 	// Walk all cases
 	for _, c := range n.Cases {
+		if c.Eval != "" {
+			t.processExpressionForDryRun(c.Eval, c.Pos, data, result, usedKeys, availableKeys, scope)
+		}
 		for _, child := range c.Children {
-			t.walkASTForDryRun(child, data, result, usedKeys, availableKeys)
+			t.walkASTForDryRun(child, data, result, usedKeys, availableKeys, scope)
 		}
 	}
 	if n.Default != nil {
 		for _, child := range n.Default.Children {
-			t.walkASTForDryRun(child, data, result, usedKeys, availableKeys)
+			t.walkASTForDryRun(child, data, result, usedKeys, availableKeys, scope)
+		}
+	}
+}
+
+// processExpressionForDryRun reports the variables and function calls of an
+// eval expression. Unparseable expressions and unregistered functions are
+// errors, since they fail at execution time.
+func (t *Template) processExpressionForDryRun(expr string, pos internal.Position, data map[string]any, result *DryRunResult, usedKeys map[string]bool, availableKeys []string, scope loopScope) {
+	node, err := internal.ParseExpression(expr)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("line %d: invalid expression '%s': %v", pos.Line, expr, err))
+		return
+	}
+
+	internal.WalkExpr(node, func(n internal.ExprNode) {
+		switch v := n.(type) {
+		case *internal.IdentifierNode:
+			ref := VariableReference{
+				Name:       v.Name,
+				Line:       pos.Line,
+				Column:     pos.Column,
+				Expression: expr,
+			}
+			t.lookupVariableForDryRun(&ref, data, usedKeys, availableKeys, scope)
+			result.Variables = append(result.Variables, ref)
+
+		case *internal.CallNode:
+			ref := FunctionReference{
+				Name:       v.Name,
+				Expression: expr,
+				Line:       pos.Line,
+				Column:     pos.Column,
+				Registered: t.executor.HasFunc(v.Name),
+			}
+			if !ref.Registered {
+				ref.Suggestions = findSimilarStrings(v.Name, t.executor.ListFuncs(), 3)
+				result.Errors = append(result.Errors, fmt.Sprintf("line %d: unknown function '%s' in expression '%s'", pos.Line, v.Name, expr))
+			}
+			result.Functions = append(result.Functions, ref)
 		}
+	})
+}
+
+// lookupVariableForDryRun sets InData and Suggestions of a variable reference.
+// Paths rooted at a loop variable are bound at execution time and count as found.
+func (t *Template) lookupVariableForDryRun(ref *VariableReference, data map[string]any, usedKeys map[string]bool, availableKeys []string, scope loopScope) {
+	if scope.covers(ref.Name) {
+		ref.InData = true
+		return
+	}
+
+	ref.InData = hasPath(data, ref.Name, t.config.fieldMatch)
+	if ref.InData {
+		markKeyUsed(usedKeys, ref.Name)
+	} else if !ref.HasDefault {
+		ref.Suggestions = findSimilarStrings(ref.Name, availableKeys, 3)
 	}
 }
 
+// loopScope is the set of loop item and index variables in scope during a dry run.
+type loopScope map[string]bool
+
+// with returns a copy of the scope extended with the given variable names.
+func (s loopScope) with(names ...string) loopScope {
+	scope := make(loopScope, len(s)+len(names))
+	for name := range s {
+		scope[name] = true
+	}
+	for _, name := range names {
+		if name != "" {
+			scope[name] = true
+		}
+	}
+	return scope
+}
+
+// covers reports whether the root segment of path is a loop variable.
+func (s loopScope) covers(path string) bool {
+	root, _, _ := strings.Cut(path, ".")
+	return s[root]
+}
+
 // generatePlaceholderOutput generates output with placeholders for dynamic content.
 func (t *Template) generatePlaceholderOutput(node interface{}, data map[string]any) string {
 	var sb strings.Builder
@@ -413,6 +592,11 @@ func (t *Template) generatePlaceholders(node interface{}, data map[string]any, s
 	case *internal.TextNode:
 		sb.WriteString(n.Content)
 
+	case *internal.BlockNode:
+		for _, child := range n.Children {
+			t.generatePlaceholders(child, data, sb)
+		}
+
 	case *internal.TagNode:
 		switch n.Name {
 		case TagNameVar:
@@ -420,7 +604,7 @@ func (t *Template) generatePlaceholders(node interface{}, data map[string]any, s
 			defaultVal := n.Attributes.GetDefault(AttrDefault, "")
 
 			// Try to get actual value
-			if val, ok := getPath(data, varName); ok {
+			if val, ok := getPath(data, varName, t.config.fieldMatch); ok {
 				sb.WriteString(fmt.Sprintf("%v", val))
 			} else if defaultVal != "" {
 				sb.WriteString(defaultVal)
@@ -429,7 +613,10 @@ func (t *Template) generatePlaceholders(node interface{}, data map[string]any, s
 			}
 
 		case TagNameInclude:
-			tmplName, _ := n.Attributes.Get(AttrTemplate)
+			tmplName, ok := n.Attributes.Get(AttrTemplate)
+			if !ok {
+				tmplName = n.Attributes.GetDefault(AttrTemplateExpr, "")
+			}
 			sb.WriteString(fmt.Sprintf("{{include:%s}}", tmplName))
 
 		case TagNameRaw:
@@ -505,7 +692,7 @@ func (t *Template) Explain(ctx context.Context, data map[string]any) *ExplainRes
 	result.AST = t.formatAST(t.ast, 0)
 
 	// Execute with tracking
-	execCtx := NewContextWithStrategy(data, t.config.errorStrategy)
+	execCtx := t.newContext(data)
 	if t.engine != nil {
 		execCtx = execCtx.WithEngine(t.engine)
 	}
@@ -598,10 +785,14 @@ func (t *Template) formatAST(node interface{}, depth int) string {
 		pos := n.Pos()
 		sb.WriteString(fmt.Sprintf("%sSwitch: %s (line %d)\n", indent, n.Expression, pos.Line))
 		for _, c := range n.Cases {
+			fallthroughNote := ""
+			if c.Fallthrough {
+				fallthroughNote = " (fallthrough)"
+			}
 			if c.Value != "" {
-				sb.WriteString(fmt.Sprintf("%s  Case: %s\n", indent, c.Value))
+				sb.WriteString(fmt.Sprintf("%s  Case: %s%s\n", indent, c.Value, fallthroughNote))
 			} else {
-				sb.WriteString(fmt.Sprintf("%s  Case eval: %s\n", indent, c.Eval))
+				sb.WriteString(fmt.Sprintf("%s  Case eval: %s%s\n", indent, c.Eval, fallthroughNote))
 			}
 			for _, child := range c.Children {
 				sb.WriteString(t.formatAST(child, depth+2))
@@ -632,7 +823,7 @@ func (t *Template) collectVariableAccesses(node interface{}, data map[string]any
 			defaultVal := n.Attributes.GetDefault(AttrDefault, "")
 			pos := n.Pos()
 
-			val, found := getPath(data, varName)
+			val, found := getPath(data, varName, t.config.fieldMatch)
 			result.Variables = append(result.Variables, VariableAccess{
 				Path:    varName,
 				Value:   val,
@@ -672,44 +863,18 @@ func (t *Template) collectVariableAccesses(node interface{}, data map[string]any
 // Helper functions
 
 // hasPath checks if a path exists in data.
-func hasPath(data map[string]any, path string) bool {
-	_, ok := getPath(data, path)
+func hasPath(data map[string]any, path string, match FieldMatch) bool {
+	_, ok := getPath(data, path, match)
 	return ok
 }
 
-// getPath retrieves a value by dot-notation path.
-func getPath(data map[string]any, path string) (any, bool) {
+// getPath retrieves a value by dot-notation path, resolving struct fields
+// the same way execution does.
+func getPath(data map[string]any, path string, match FieldMatch) (any, bool) {
 	if path == "" || data == nil {
 		return nil, false
 	}
-
-	parts := strings.Split(path, ".")
-	var current any = data
-
-	for _, part := range parts {
-		if part == "" {
-			continue
-		}
-
-		switch v := current.(type) {
-		case map[string]any:
-			val, ok := v[part]
-			if !ok {
-				return nil, false
-			}
-			current = val
-		case map[string]string:
-			val, ok := v[part]
-			if !ok {
-				return nil, false
-			}
-			current = val
-		default:
-			return nil, false
-		}
-	}
-
-	return current, true
+	return walkPath(data, strings.Split(path, PathSeparator), match)
 }
 
 // collectAllKeys collects all keys from nested maps with dot notation.
@@ -843,6 +1008,9 @@ func (r *DryRunResult) String() string {
 					status = "MISSING"
 				}
 			}
+			if v.Expression != "" {
+				status += fmt.Sprintf(" (in %q)", v.Expression)
+			}
 			sb.WriteString(fmt.Sprintf("  - %s [line %d]: %s\n", v.Name, v.Line, status))
 			if len(v.Suggestions) > 0 {
 				sb.WriteString(fmt.Sprintf("    Did you mean: %s?\n", strings.Join(v.Suggestions, ", ")))
@@ -886,6 +1054,20 @@ func (r *DryRunResult) String() string {
 		}
 	}
 
+	if len(r.Functions) > 0 {
+		sb.WriteString(fmt.Sprintf("\nFunctions (%d):\n", len(r.Functions)))
+		for _, f := range r.Functions {
+			status := "registered"
+			if !f.Registered {
+				status = "UNKNOWN"
+			}
+			sb.WriteString(fmt.Sprintf("  - %s [line %d]: %s\n", f.Name, f.Line, status))
+			if len(f.Suggestions) > 0 {
+				sb.WriteString(fmt.Sprintf("    Did you mean: %s?\n", strings.Join(f.Suggestions, ", ")))
+			}
+		}
+	}
+
 	if len(r.MissingVariables) > 0 {
 		sb.WriteString(fmt.Sprintf("\nMissing Variables (%d):\n", len(r.MissingVariables)))
 		for _, v := range r.MissingVariables {
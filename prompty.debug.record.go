@@ -0,0 +1,226 @@
+package prompty
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"maps"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// ExecutionRecording captures one template execution with everything needed
+// to reproduce it: the source, the data, the output of every resolver call
+// and the final render. It marshals to JSON, so recordings can be written
+// to logs or files and replayed later with a ReplayEngine.
+type ExecutionRecording struct {
+	FormatVersion string `json:"format_version"`
+	// Template and Version identify the executed template, when known:
+	// registered templates have a name, stored templates also a version.
+	Template string `json:"template,omitempty"`
+	Version  int    `json:"version,omitempty"`
+	// Source is the full template source, including frontmatter.
+	Source string `json:"source"`
+	// Data is a snapshot of the execution data, taken after the
+	// before-execute hooks ran.
+	Data map[string]any `json:"data,omitempty"`
+	// Templates holds the sources of the templates the execution extended
+	// or imported blocks from, by name.
+	Templates map[string]string `json:"templates,omitempty"`
+	// Resolves lists the resolver calls in the order they completed,
+	// including those of included templates.
+	Resolves []ResolveRecord `json:"resolves,omitempty"`
+	// Output and Error are the result of the execution.
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+	// StartedAt is the time of the engine clock when the execution started.
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration_ns"`
+}
+
+// ResolveRecord is one resolver call of a recorded execution.
+type ResolveRecord struct {
+	Tag        string            `json:"tag"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Output     string            `json:"output"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// ExecutionRecorder records the first top-level execution run with a
+// context from WithExecutionRecorder, including the templates it includes.
+// Use one recorder per execution.
+type ExecutionRecorder struct {
+	mu        sync.Mutex
+	name      string
+	version   int
+	active    bool
+	recording *ExecutionRecording
+}
+
+// NewExecutionRecorder creates an ExecutionRecorder.
+func NewExecutionRecorder() *ExecutionRecorder {
+	return &ExecutionRecorder{}
+}
+
+// recorderKey is the context key for the active ExecutionRecorder
+type recorderKey struct{}
+
+// WithExecutionRecorder returns ctx carrying recorder. Templates executed
+// with it, directly or through a StorageEngine, are recorded:
+//
+//	recorder := prompty.NewExecutionRecorder()
+//	output, err := tmpl.Execute(prompty.WithExecutionRecorder(ctx, recorder), data)
+//	_ = prompty.SaveRecording("bad-render.json", recorder.Recording())
+func WithExecutionRecorder(ctx context.Context, recorder *ExecutionRecorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, recorder)
+}
+
+// recorderFrom returns the recorder of ctx, or nil.
+func recorderFrom(ctx context.Context) *ExecutionRecorder {
+	recorder, _ := ctx.Value(recorderKey{}).(*ExecutionRecorder)
+	return recorder
+}
+
+// Recording returns the recorded execution, or nil if none completed.
+func (r *ExecutionRecorder) Recording() *ExecutionRecording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.active {
+		return nil
+	}
+	return r.recording
+}
+
+// identify sets the name and version of the stored template about to be
+// executed, unless an execution is already being recorded.
+func (r *ExecutionRecorder) identify(name string, version int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.recording == nil {
+		r.name, r.version = name, version
+	}
+}
+
+// begin starts recording the execution of t, reporting false if the
+// recorder already recorded or is recording another execution.
+func (r *ExecutionRecorder) begin(t *Template, data map[string]any, startedAt time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.recording != nil {
+		return false
+	}
+	name := r.name
+	if name == "" {
+		name = t.name
+	}
+	r.active = true
+	r.recording = &ExecutionRecording{
+		FormatVersion: RecordingFormatVersion,
+		Template:      name,
+		Version:       r.version,
+		Source:        t.source,
+		Data:          data,
+		StartedAt:     startedAt,
+	}
+	return true
+}
+
+// finish completes the recording with the result of the execution.
+func (r *ExecutionRecorder) finish(output string, err error, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.active = false
+	r.recording.Output = output
+	r.recording.Duration = duration
+	if err != nil {
+		r.recording.Error = err.Error()
+	}
+}
+
+// addResolve records a resolver call of the execution being recorded.
+func (r *ExecutionRecorder) addResolve(tagName string, attrs internal.Attributes, output string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.active {
+		return
+	}
+	record := ResolveRecord{Tag: tagName, Attributes: maps.Clone(map[string]string(attrs)), Output: output}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	r.recording.Resolves = append(r.recording.Resolves, record)
+}
+
+// addTemplate records the source of a template the execution extended or
+// imported blocks from.
+func (r *ExecutionRecorder) addTemplate(name, source string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.active {
+		return
+	}
+	if r.recording.Templates == nil {
+		r.recording.Templates = make(map[string]string)
+	}
+	r.recording.Templates[name] = source
+}
+
+// executeRecorded executes the template, recording the execution if ctx
+// carries a recorder that has not recorded one yet.
+func (t *Template) executeRecorded(ctx context.Context, execCtx *Context) (string, error) {
+	recorder := recorderFrom(ctx)
+	clock := t.config.clock
+	if clock == nil {
+		clock = time.Now
+	}
+	startedAt := clock()
+	if recorder == nil || !recorder.begin(t, execCtx.Data(), startedAt) {
+		return t.execute(ctx, execCtx)
+	}
+
+	start := time.Now()
+	output, err := t.execute(ctx, execCtx)
+	recorder.finish(output, err, time.Since(start))
+	return output, err
+}
+
+// ReadRecording reads an ExecutionRecording written as JSON.
+func ReadRecording(r io.Reader) (*ExecutionRecording, error) {
+	var recording ExecutionRecording
+	if err := json.NewDecoder(r).Decode(&recording); err != nil {
+		return nil, NewRecordingError(ErrMsgRecordingInvalid, err)
+	}
+	if recording.FormatVersion != RecordingFormatVersion {
+		return nil, NewRecordingError(ErrMsgRecordingFormatVersion, nil)
+	}
+	return &recording, nil
+}
+
+// LoadRecording reads an ExecutionRecording from a JSON file.
+func LoadRecording(path string) (*ExecutionRecording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, NewRecordingError(ErrMsgRecordingInvalid, err)
+	}
+	defer f.Close()
+	return ReadRecording(f)
+}
+
+// SaveRecording writes an ExecutionRecording to a JSON file. The data must
+// be JSON-serializable.
+func SaveRecording(path string, recording *ExecutionRecording) error {
+	if recording == nil {
+		return NewRecordingError(ErrMsgRecordingInvalid, nil)
+	}
+	data, err := json.MarshalIndent(recording, "", "  ")
+	if err != nil {
+		return NewRecordingError(ErrMsgRecordingWriteFailed, err)
+	}
+	if err := os.WriteFile(path, data, FilesystemFilePermissions); err != nil {
+		return NewRecordingError(ErrMsgRecordingWriteFailed, err)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package prompty
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReplayEngine re-executes ExecutionRecordings with their recorded inputs
+// to reproduce a render exactly. Every resolver call is answered with the
+// recorded output, or error, of the matching call, so custom resolvers,
+// environment variables, includes and random choices behave as they did
+// when the execution was recorded, and now() reports the recording's start
+// time. Only the template's own logic (conditions, loops, expressions) runs
+// again.
+//
+// Tags made live with Live run their current resolver instead, e.g. to
+// check whether a fixed resolver repairs the render.
+type ReplayEngine struct {
+	opts      []Option
+	resolvers []Resolver
+	live      map[string]bool
+}
+
+// ReplayResult is the outcome of replaying an ExecutionRecording.
+type ReplayResult struct {
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+	// Matched reports whether the output and error equal the recorded ones.
+	Matched bool `json:"matched"`
+	// Unmatched lists the resolver calls without a recorded result, which
+	// ran their current resolver, e.g. because the template's logic took a
+	// different path than when it was recorded.
+	Unmatched []ResolveRecord `json:"unmatched,omitempty"`
+	// Unused is the number of recorded resolver calls that were not
+	// replayed, e.g. those of included templates whose include was
+	// replayed from the recording.
+	Unused int `json:"unused"`
+}
+
+// NewReplayEngine creates a ReplayEngine whose replays run on engines
+// created with opts. Pass the options of the recording engine that affect
+// parsing and rendering, such as delimiters and the error strategy.
+func NewReplayEngine(opts ...Option) (*ReplayEngine, error) {
+	if _, err := New(opts...); err != nil {
+		return nil, err
+	}
+	return &ReplayEngine{opts: opts, live: make(map[string]bool)}, nil
+}
+
+// Register adds a custom resolver to the engines of future replays. It
+// only runs for live tags and for calls the recording has no result for.
+func (r *ReplayEngine) Register(resolver Resolver) {
+	r.resolvers = append(r.resolvers, resolver)
+}
+
+// Live makes the given tags run their resolver instead of replaying the
+// recorded results.
+func (r *ReplayEngine) Live(tags ...string) {
+	for _, tag := range tags {
+		r.live[tag] = true
+	}
+}
+
+// Replay re-executes the recording. The error is non-nil if the recording
+// cannot be replayed; a failed execution is reported in the result.
+func (r *ReplayEngine) Replay(ctx context.Context, recording *ExecutionRecording) (*ReplayResult, error) {
+	if recording == nil {
+		return nil, NewRecordingError(ErrMsgRecordingInvalid, nil)
+	}
+	if recording.FormatVersion != RecordingFormatVersion {
+		return nil, NewRecordingError(ErrMsgRecordingFormatVersion, nil)
+	}
+
+	startedAt := recording.StartedAt
+	opts := append(append([]Option(nil), r.opts...), WithClock(func() time.Time { return startedAt }))
+	engine, err := New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, resolver := range r.resolvers {
+		if err := engine.Register(resolver); err != nil {
+			return nil, err
+		}
+	}
+	for _, call := range recording.Resolves {
+		if !engine.HasResolver(call.Tag) {
+			engine.MustRegister(&replayStubResolver{tag: call.Tag})
+		}
+	}
+	if err := engine.RegisterTemplates(recording.Templates); err != nil {
+		return nil, NewRecordingError(ErrMsgRecordingInvalid, err)
+	}
+
+	state := newReplayState(recording.Resolves)
+	if err := engine.UseResolverMiddleware(func(next Resolver) Resolver {
+		return &replayResolver{next: next, state: state, live: r.live[next.TagName()]}
+	}); err != nil {
+		return nil, err
+	}
+
+	tmpl, err := engine.Parse(recording.Source)
+	if err != nil {
+		return nil, NewRecordingError(ErrMsgRecordingInvalid, err)
+	}
+	output, err := tmpl.Execute(ctx, recording.Data)
+
+	result := &ReplayResult{Output: output, Unmatched: state.unmatched, Unused: state.unused()}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.Matched = result.Output == recording.Output && result.Error == recording.Error
+	return result, nil
+}
+
+// replayState hands out the recorded results of one replay. Calls are
+// matched by tag and attributes, in the order they were recorded.
+type replayState struct {
+	mu        sync.Mutex
+	pending   map[string][]ResolveRecord
+	unmatched []ResolveRecord
+}
+
+func newReplayState(resolves []ResolveRecord) *replayState {
+	pending := make(map[string][]ResolveRecord)
+	for _, call := range resolves {
+		key := replayKey(call.Tag, call.Attributes)
+		pending[key] = append(pending[key], call)
+	}
+	return &replayState{pending: pending}
+}
+
+// next returns the next recorded result of the call.
+func (s *replayState) next(tag string, attrs map[string]string) (ResolveRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := replayKey(tag, attrs)
+	calls := s.pending[key]
+	if len(calls) == 0 {
+		return ResolveRecord{}, false
+	}
+	s.pending[key] = calls[1:]
+	return calls[0], true
+}
+
+// miss records a call that ran its resolver instead of a recorded result.
+func (s *replayState) miss(record ResolveRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unmatched = append(s.unmatched, record)
+}
+
+// unused counts the recorded results that were not handed out.
+func (s *replayState) unused() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, calls := range s.pending {
+		n += len(calls)
+	}
+	return n
+}
+
+// replayKey identifies a resolver call by its tag and sorted attributes.
+func replayKey(tag string, attrs map[string]string) string {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(tag)
+	for _, k := range keys {
+		b.WriteByte(0)
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(attrs[k])
+	}
+	return b.String()
+}
+
+// replayResolver answers resolver calls from the recording, falling back to
+// the wrapped resolver for live tags and calls without a recorded result.
+type replayResolver struct {
+	next  Resolver
+	state *replayState
+	live  bool
+}
+
+func (r *replayResolver) TagName() string {
+	return r.next.TagName()
+}
+
+func (r *replayResolver) Resolve(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+	if r.live {
+		return r.next.Resolve(ctx, execCtx, attrs)
+	}
+	if call, ok := r.state.next(r.next.TagName(), attrs.Map()); ok {
+		if call.Error != "" {
+			return call.Output, errors.New(call.Error)
+		}
+		return call.Output, nil
+	}
+
+	output, err := r.next.Resolve(ctx, execCtx, attrs)
+	record := ResolveRecord{Tag: r.next.TagName(), Attributes: attrs.Map(), Output: output}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	r.state.miss(record)
+	return output, err
+}
+
+func (r *replayResolver) Validate(attrs Attributes) error {
+	return r.next.Validate(attrs)
+}
+
+// replayStubResolver stands in for a custom resolver the replay engine does
+// not have; only recorded calls of its tag can be replayed.
+type replayStubResolver struct {
+	tag string
+}
+
+func (r *replayStubResolver) TagName() string {
+	return r.tag
+}
+
+func (r *replayStubResolver) Resolve(context.Context, *Context, Attributes) (string, error) {
+	return "", NewReplayMissingResultError(r.tag)
+}
+
+func (r *replayStubResolver) Validate(Attributes) error {
+	return nil
+}
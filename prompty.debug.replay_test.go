@@ -0,0 +1,162 @@
+package prompty
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingEngine returns an engine whose Stock resolver reports a
+// different level on every call and whose Lookup resolver fails.
+func recordingEngine(t *testing.T) *Engine {
+	t.Helper()
+	engine := MustNew()
+	calls := 0
+	engine.MustRegister(NewResolverFunc("Stock", func(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+		calls++
+		return strconv.Itoa(calls * 10), nil
+	}, nil))
+	engine.MustRegister(NewResolverFunc("Lookup", func(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+		return "", errors.New("lookup service down")
+	}, nil))
+	engine.MustRegisterTemplate("footer", `-- {~Stock item="footer" /~}`)
+	return engine
+}
+
+const recordingSource = `{~prompty.if eval="vip"~}VIP {~/prompty.if~}{~prompty.var name="user" /~}: {~prompty.for item="item" in="items"~}{~Stock item="x" /~},{~/prompty.for~} {~Lookup onerror="default" default="n/a" /~} {~prompty.include template="footer" /~}`
+
+func TestExecutionRecorder(t *testing.T) {
+	engine := recordingEngine(t)
+	tmpl, err := engine.Parse(recordingSource)
+	require.NoError(t, err)
+
+	recorder := NewExecutionRecorder()
+	ctx := WithExecutionRecorder(context.Background(), recorder)
+	output, err := tmpl.Execute(ctx, map[string]any{"user": "ada", "vip": true, "items": []any{"a", "b"}})
+	require.NoError(t, err)
+	assert.Equal(t, "VIP ada: 10,20, n/a -- 30", output)
+
+	recording := recorder.Recording()
+	require.NotNil(t, recording)
+	assert.Equal(t, RecordingFormatVersion, recording.FormatVersion)
+	assert.Equal(t, recordingSource, recording.Source)
+	assert.Equal(t, output, recording.Output)
+	assert.Equal(t, "ada", recording.Data["user"])
+	assert.False(t, recording.StartedAt.IsZero())
+
+	var stocks []string
+	for _, call := range recording.Resolves {
+		if call.Tag == "Stock" {
+			stocks = append(stocks, call.Attributes["item"]+"="+call.Output)
+		}
+		if call.Tag == "Lookup" {
+			assert.Equal(t, "lookup service down", call.Error)
+		}
+	}
+	assert.Equal(t, []string{"x=10", "x=20", "footer=30"}, stocks)
+
+	// Only the first execution is recorded
+	_, err = tmpl.Execute(ctx, map[string]any{"user": "bob", "items": []any{}})
+	require.NoError(t, err)
+	assert.Equal(t, "ada", recorder.Recording().Data["user"])
+}
+
+func TestReplayEngine(t *testing.T) {
+	engine := recordingEngine(t)
+	tmpl, err := engine.Parse(recordingSource)
+	require.NoError(t, err)
+	recorder := NewExecutionRecorder()
+	_, err = tmpl.Execute(WithExecutionRecorder(context.Background(), recorder), map[string]any{"user": "ada", "vip": false, "items": []any{"a"}})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "recording.json")
+	require.NoError(t, SaveRecording(path, recorder.Recording()))
+	recording, err := LoadRecording(path)
+	require.NoError(t, err)
+
+	// The replay engine has neither the custom resolvers nor the footer
+	replay, err := NewReplayEngine()
+	require.NoError(t, err)
+	result, err := replay.Replay(context.Background(), recording)
+	require.NoError(t, err)
+	assert.True(t, result.Matched)
+	assert.Equal(t, "ada: 10, n/a -- 20", result.Output)
+	assert.Empty(t, result.Unmatched)
+	assert.Equal(t, 1, result.Unused) // The Stock call within the replayed include
+
+	// Live resolvers replace the recorded results
+	replay.Register(NewResolverFunc("Lookup", func(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+		return "found", nil
+	}, nil))
+	replay.Live("Lookup")
+	result, err = replay.Replay(context.Background(), recording)
+	require.NoError(t, err)
+	assert.False(t, result.Matched)
+	assert.Equal(t, "ada: 10, found -- 20", result.Output)
+
+	// Changed logic reaches calls the recording has no result for
+	recording.Data["items"] = []any{"a", "b"}
+	result, err = replay.Replay(context.Background(), recording)
+	require.NoError(t, err)
+	require.Len(t, result.Unmatched, 1)
+	assert.Equal(t, "Stock", result.Unmatched[0].Tag)
+	assert.Contains(t, result.Error, ErrMsgReplayNoRecordedResult)
+}
+
+func TestReplayEngine_TemplatesAndClock(t *testing.T) {
+	engine := MustNew()
+	engine.MustRegisterTemplate("base", `[{~prompty.block name="body"~}base{~/prompty.block~}]`)
+	engine.MustRegisterTemplate("page", `{~prompty.extends template="base" /~}{~prompty.block name="body"~}{~prompty.var name="year" default="?" /~} {~prompty.if eval="formatDate(now(), '2006') != ''"~}dated{~/prompty.if~}{~/prompty.block~}`)
+
+	recorder := NewExecutionRecorder()
+	output, err := engine.ExecuteTemplate(WithExecutionRecorder(context.Background(), recorder), "page", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[? dated]", output)
+
+	recording := recorder.Recording()
+	assert.Equal(t, "page", recording.Template)
+	assert.Contains(t, recording.Templates, "base")
+
+	replay, err := NewReplayEngine()
+	require.NoError(t, err)
+	result, err := replay.Replay(context.Background(), recording)
+	require.NoError(t, err)
+	assert.True(t, result.Matched, result.Output)
+}
+
+func TestExecutionRecorder_StorageEngine(t *testing.T) {
+	ctx := context.Background()
+	se, err := NewStorageEngine(StorageEngineConfig{Storage: NewMemoryStorage()})
+	require.NoError(t, err)
+	defer se.Close()
+	require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "greeting", Source: `Hi {~prompty.var name="name" /~}`}))
+	require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "greeting", Source: `Hello {~prompty.var name="name" /~}`}))
+
+	recorder := NewExecutionRecorder()
+	_, err = se.ExecuteVersion(WithExecutionRecorder(ctx, recorder), "greeting", 1, map[string]any{"name": "Ada"})
+	require.NoError(t, err)
+
+	recording := recorder.Recording()
+	assert.Equal(t, "greeting", recording.Template)
+	assert.Equal(t, 1, recording.Version)
+	assert.Equal(t, "Hi Ada", recording.Output)
+}
+
+func TestReadRecording_Errors(t *testing.T) {
+	_, err := ReadRecording(strings.NewReader(`{"format_version": "99"}`))
+	assert.ErrorContains(t, err, ErrMsgRecordingFormatVersion)
+	_, err = ReadRecording(strings.NewReader(`not json`))
+	assert.ErrorContains(t, err, ErrMsgRecordingInvalid)
+
+	replay, err := NewReplayEngine()
+	require.NoError(t, err)
+	_, err = replay.Replay(context.Background(), nil)
+	assert.ErrorContains(t, err, ErrMsgRecordingInvalid)
+	assert.Error(t, SaveRecording(filepath.Join(t.TempDir(), "r.json"), nil))
+}
@@ -0,0 +1,132 @@
+package prompty
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// ExecutionTrace is the machine-readable execution trace produced by
+// ExplainJSON. Node start offsets are relative to the start of execution,
+// so the nodes can be laid out directly in flamegraph or waterfall viewers.
+type ExecutionTrace struct {
+	Output   string        `json:"output"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+	Nodes    []*TraceNode  `json:"nodes"`
+}
+
+// TraceNode is the execution record of a single AST node. Loop bodies
+// contribute one set of children per iteration; templates executed by a
+// tag (e.g. prompty.include) appear as that tag's children.
+type TraceNode struct {
+	Type     TraceNodeType `json:"type"`
+	Tag      string        `json:"tag,omitempty"`   // Tag name for tag nodes
+	Label    string        `json:"label,omitempty"` // Short description (variable path, condition, loop source, ...)
+	Line     int           `json:"line"`
+	Column   int           `json:"column"`
+	Offset   int           `json:"offset"`
+	Start    time.Duration `json:"start_ns"`        // Offset from the start of execution
+	Duration time.Duration `json:"duration_ns"`     // Wall time including children
+	Cache    string        `json:"cache,omitempty"` // Cache status, if the node was served by a cache
+	Error    string        `json:"error,omitempty"` // Resolver error, also when handled by an error strategy
+	Children []*TraceNode  `json:"children,omitempty"`
+}
+
+// ExplainTrace executes the template and records a trace node with timing
+// for every executed AST node.
+func (t *Template) ExplainTrace(ctx context.Context, data map[string]any) *ExecutionTrace {
+	execCtx := t.newContext(data)
+	if t.engine != nil {
+		execCtx = execCtx.WithEngine(t.engine)
+	}
+
+	trace := internal.NewExecTrace()
+	start := time.Now()
+	output, err := t.executor.Execute(internal.WithExecTrace(ctx, trace), t.ast, execCtx)
+
+	result := &ExecutionTrace{
+		Output:   output,
+		Duration: time.Since(start),
+		Nodes:    toTraceNodes(trace.Spans()),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// ExplainJSON executes the template and returns its ExecutionTrace as JSON.
+func (t *Template) ExplainJSON(ctx context.Context, data map[string]any) ([]byte, error) {
+	return json.Marshal(t.ExplainTrace(ctx, data))
+}
+
+// toTraceNodes converts executor spans to trace nodes.
+func toTraceNodes(spans []*internal.TraceSpan) []*TraceNode {
+	nodes := make([]*TraceNode, 0, len(spans))
+	for _, span := range spans {
+		pos := span.Node.Pos()
+		node := &TraceNode{
+			Line:     pos.Line,
+			Column:   pos.Column,
+			Offset:   pos.Offset,
+			Start:    span.Start,
+			Duration: span.Duration,
+			Cache:    span.Cache,
+		}
+		if span.Err != nil {
+			node.Error = span.Err.Error()
+		}
+		if len(span.Children) > 0 {
+			node.Children = toTraceNodes(span.Children)
+		}
+		describeTraceNode(node, span.Node)
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// describeTraceNode sets the type, tag and label of a trace node.
+func describeTraceNode(node *TraceNode, n internal.Node) {
+	switch n := n.(type) {
+	case *internal.TextNode:
+		node.Type = TraceNodeTypeText
+		label := n.Content
+		if len(label) > TraceLabelMaxLength {
+			label = label[:TraceLabelMaxLength] + "..."
+		}
+		node.Label = strings.ReplaceAll(label, "\n", "\\n")
+
+	case *internal.TagNode:
+		node.Type = TraceNodeTypeTag
+		node.Tag = n.Name
+		for _, attr := range []string{AttrName, AttrTemplate, AttrTemplateExpr, AttrSlug, AttrRole} {
+			if v, ok := n.Attributes.Get(attr); ok {
+				node.Label = v
+				break
+			}
+		}
+
+	case *internal.ConditionalNode:
+		node.Type = TraceNodeTypeConditional
+		if len(n.Branches) > 0 {
+			node.Label = n.Branches[0].Condition
+		}
+
+	case *internal.ForNode:
+		node.Type = TraceNodeTypeFor
+		node.Label = fmt.Sprintf("%s in %s", n.ItemVar, n.Source)
+
+	case *internal.SwitchNode:
+		node.Type = TraceNodeTypeSwitch
+		node.Label = n.Expression
+
+	case *internal.BlockNode:
+		node.Type = TraceNodeTypeBlock
+		node.Label = n.Name
+	}
+}
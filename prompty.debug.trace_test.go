@@ -0,0 +1,146 @@
+package prompty
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate_ExplainTrace(t *testing.T) {
+	engine := MustNew()
+	tmpl, err := engine.Parse("Hi {~prompty.var name=\"user\" /~}\n" +
+		`{~prompty.for item="x" in="items"~}{~prompty.var name="x" /~}{~/prompty.for~}` +
+		`{~prompty.if eval="len(items) > 1"~}many{~/prompty.if~}`)
+	require.NoError(t, err)
+
+	trace := tmpl.ExplainTrace(context.Background(), map[string]any{
+		"user":  "Ann",
+		"items": []string{"a", "b"},
+	})
+
+	assert.Empty(t, trace.Error)
+	assert.Equal(t, "Hi Ann\nabmany", trace.Output)
+	assert.Positive(t, trace.Duration)
+	require.Len(t, trace.Nodes, 5)
+
+	text := trace.Nodes[0]
+	assert.Equal(t, TraceNodeTypeText, text.Type)
+	assert.Equal(t, "Hi ", text.Label)
+	assert.Equal(t, 1, text.Line)
+
+	variable := trace.Nodes[1]
+	assert.Equal(t, TraceNodeTypeTag, variable.Type)
+	assert.Equal(t, TagNameVar, variable.Tag)
+	assert.Equal(t, "user", variable.Label)
+	assert.Equal(t, 1, variable.Line)
+	assert.Equal(t, 4, variable.Column)
+	assert.Equal(t, 3, variable.Offset)
+
+	loop := trace.Nodes[3]
+	assert.Equal(t, TraceNodeTypeFor, loop.Type)
+	assert.Equal(t, "x in items", loop.Label)
+	assert.Equal(t, 2, loop.Line)
+	require.Len(t, loop.Children, 2, "one child per iteration")
+	for _, child := range loop.Children {
+		assert.Equal(t, "x", child.Label)
+		assert.GreaterOrEqual(t, child.Start, loop.Start)
+		assert.LessOrEqual(t, child.Start+child.Duration, loop.Start+loop.Duration)
+	}
+
+	cond := trace.Nodes[4]
+	assert.Equal(t, TraceNodeTypeConditional, cond.Type)
+	assert.Equal(t, "len(items) > 1", cond.Label)
+	require.Len(t, cond.Children, 1)
+	assert.Equal(t, "many", cond.Children[0].Label)
+}
+
+func TestTemplate_ExplainTrace_Include(t *testing.T) {
+	engine := MustNew()
+	require.NoError(t, engine.RegisterTemplate("greeting", `Hello {~prompty.var name="name" /~}`))
+	tmpl, err := engine.Parse(`{~prompty.include template="greeting" with="user" /~}`)
+	require.NoError(t, err)
+
+	trace := tmpl.ExplainTrace(context.Background(), map[string]any{
+		"user": map[string]any{"name": "Ann"},
+	})
+
+	assert.Equal(t, "Hello Ann", trace.Output)
+	require.Len(t, trace.Nodes, 1)
+	include := trace.Nodes[0]
+	assert.Equal(t, TagNameInclude, include.Tag)
+	assert.Equal(t, "greeting", include.Label)
+	require.Len(t, include.Children, 2)
+	assert.Equal(t, "name", include.Children[1].Label)
+}
+
+func TestTemplate_ExplainTrace_Errors(t *testing.T) {
+	engine := MustNew()
+
+	t.Run("handled by error strategy", func(t *testing.T) {
+		tmpl, err := engine.Parse(`a{~prompty.var name="missing" onerror="remove" /~}b`)
+		require.NoError(t, err)
+
+		trace := tmpl.ExplainTrace(context.Background(), nil)
+
+		assert.Empty(t, trace.Error)
+		assert.Equal(t, "ab", trace.Output)
+		require.Len(t, trace.Nodes, 3)
+		assert.Contains(t, trace.Nodes[1].Error, "missing")
+	})
+
+	t.Run("execution failure", func(t *testing.T) {
+		tmpl, err := engine.Parse(`{~prompty.var name="missing" /~}`)
+		require.NoError(t, err)
+
+		trace := tmpl.ExplainTrace(context.Background(), nil)
+
+		assert.NotEmpty(t, trace.Error)
+		require.Len(t, trace.Nodes, 1)
+		assert.NotEmpty(t, trace.Nodes[0].Error)
+	})
+}
+
+func TestTemplate_ExplainJSON(t *testing.T) {
+	engine := MustNew()
+	tmpl, err := engine.Parse(`{~prompty.switch eval="status"~}{~prompty.case value="ok"~}fine{~/prompty.case~}{~/prompty.switch~}`)
+	require.NoError(t, err)
+
+	raw, err := tmpl.ExplainJSON(context.Background(), map[string]any{"status": "ok"})
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.Equal(t, "fine", decoded["output"])
+	assert.Contains(t, decoded, "duration_ns")
+	assert.NotContains(t, decoded, "error")
+
+	nodes := decoded["nodes"].([]any)
+	require.Len(t, nodes, 1)
+	node := nodes[0].(map[string]any)
+	assert.Equal(t, string(TraceNodeTypeSwitch), node["type"])
+	assert.Equal(t, "status", node["label"])
+	for _, key := range []string{"line", "column", "offset", "start_ns", "duration_ns", "children"} {
+		assert.Contains(t, node, key)
+	}
+
+	var trace ExecutionTrace
+	require.NoError(t, json.Unmarshal(raw, &trace))
+	assert.Equal(t, "fine", trace.Nodes[0].Children[0].Label)
+}
+
+func TestTemplate_ExplainTrace_TextLabelTruncated(t *testing.T) {
+	engine := MustNew()
+	long := "line one\n" + strings.Repeat("x", TraceLabelMaxLength)
+	tmpl, err := engine.Parse(long)
+	require.NoError(t, err)
+
+	trace := tmpl.ExplainTrace(context.Background(), nil)
+
+	require.Len(t, trace.Nodes, 1)
+	assert.Contains(t, trace.Nodes[0].Label, `line one\n`)
+	assert.Contains(t, trace.Nodes[0].Label, "...")
+}
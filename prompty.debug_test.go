@@ -151,6 +151,47 @@ func TestTemplate_DryRun_IncludeIsolated(t *testing.T) {
 	assert.True(t, result.Includes[0].Isolated)
 }
 
+func TestTemplate_DryRun_IncludeFromStorage(t *testing.T) {
+	tmpl, err := MustNew().Parse(`{~prompty.include template="greeting" source="storage" version="2" /~}`)
+	require.NoError(t, err)
+
+	result := tmpl.DryRun(context.Background(), nil)
+
+	require.Len(t, result.Includes, 1)
+	assert.True(t, result.Includes[0].Storage)
+	assert.Equal(t, "2", result.Includes[0].Attributes[AttrVersion])
+	assert.Empty(t, result.Warnings)
+}
+
+func TestTemplate_DryRun_IncludeTemplateExpr(t *testing.T) {
+	tmpl, err := MustNew().Parse(`{~prompty.include template_expr="'intro-' + lang" /~}`)
+	require.NoError(t, err)
+
+	result := tmpl.DryRun(context.Background(), nil)
+
+	require.Len(t, result.Includes, 1)
+	assert.Equal(t, `'intro-' + lang`, result.Includes[0].Expression)
+	assert.Empty(t, result.Includes[0].TemplateName)
+	assert.Empty(t, result.Warnings)
+	assert.Contains(t, result.MissingVariables, "lang")
+}
+
+func TestTemplate_DryRun_IncludeWithMapping(t *testing.T) {
+	engine := MustNew()
+	engine.MustRegisterTemplate("card", "x")
+
+	tmpl, err := engine.Parse(`{~prompty.include template="card" with="name=customer.name, n=len(cart)" /~}`)
+	require.NoError(t, err)
+
+	result := tmpl.DryRun(context.Background(), map[string]any{"customer": map[string]any{"name": "Ada"}})
+
+	assert.True(t, result.Valid)
+	assert.Contains(t, result.MissingVariables, "cart")
+	assert.NotContains(t, result.MissingVariables, "customer.name")
+	require.Len(t, result.Functions, 1)
+	assert.Equal(t, "len", result.Functions[0].Name)
+}
+
 func TestTemplate_DryRun_Conditional(t *testing.T) {
 	engine := MustNew()
 	tmpl, err := engine.Parse(`
@@ -476,7 +517,7 @@ func TestGetPath(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			val, found := getPath(tt.data, tt.path)
+			val, found := getPath(tt.data, tt.path, FieldMatchCaseInsensitive)
 			assert.Equal(t, tt.found, found)
 			if tt.found {
 				assert.Equal(t, tt.expected, val)
@@ -492,10 +533,10 @@ func TestHasPath(t *testing.T) {
 		},
 	}
 
-	assert.True(t, hasPath(data, "user"))
-	assert.True(t, hasPath(data, "user.name"))
-	assert.False(t, hasPath(data, "user.email"))
-	assert.False(t, hasPath(data, "missing"))
+	assert.True(t, hasPath(data, "user", FieldMatchCaseInsensitive))
+	assert.True(t, hasPath(data, "user.name", FieldMatchCaseInsensitive))
+	assert.False(t, hasPath(data, "user.email", FieldMatchCaseInsensitive))
+	assert.False(t, hasPath(data, "missing", FieldMatchCaseInsensitive))
 }
 
 func TestCollectAllKeys(t *testing.T) {
@@ -681,6 +722,97 @@ func TestTemplate_DryRun_Switch(t *testing.T) {
 	assert.Contains(t, result.Output, "{{switch:")
 }
 
+func TestTemplate_DryRun_ExpressionVariables(t *testing.T) {
+	engine := MustNew()
+	tmpl, err := engine.Parse("{~prompty.if eval=\"user.isAdmin && len(items) > 0\"~}A{~/prompty.if~}\n" +
+		`{~prompty.switch eval="stauts"~}{~prompty.case eval="count > 1"~}B{~/prompty.case~}{~/prompty.switch~}`)
+	require.NoError(t, err)
+
+	result := tmpl.DryRun(context.Background(), map[string]any{
+		"user":   map[string]any{"isAdmin": true},
+		"items":  []string{"a"},
+		"status": "active",
+	})
+
+	names := make([]string, 0, len(result.Variables))
+	for _, v := range result.Variables {
+		names = append(names, v.Name)
+	}
+	assert.Equal(t, []string{"user.isAdmin", "items", "stauts", "count"}, names)
+
+	assert.Equal(t, "user.isAdmin && len(items) > 0", result.Variables[0].Expression)
+	assert.Equal(t, 1, result.Variables[0].Line)
+	assert.True(t, result.Variables[0].InData)
+
+	typo := result.Variables[2]
+	assert.Equal(t, 2, typo.Line)
+	assert.False(t, typo.InData)
+	assert.Contains(t, typo.Suggestions, "status")
+
+	assert.Equal(t, []string{"count", "stauts"}, result.MissingVariables)
+	assert.Equal(t, []string{"status"}, result.UnusedVariables)
+	assert.True(t, result.Valid)
+}
+
+func TestTemplate_DryRun_ExpressionFunctions(t *testing.T) {
+	engine := MustNew()
+	require.NoError(t, engine.RegisterFunc(&Func{
+		Name:    "isVIP",
+		MinArgs: 1,
+		MaxArgs: 1,
+		Fn:      func(args []any) (any, error) { return true, nil },
+	}))
+	tmpl, err := engine.Parse(`{~prompty.if eval="isVIP(user) || lenn(items) > 0"~}x{~/prompty.if~}`)
+	require.NoError(t, err)
+
+	result := tmpl.DryRun(context.Background(), map[string]any{"user": "u", "items": []string{}})
+
+	require.Len(t, result.Functions, 2)
+	assert.Equal(t, "isVIP", result.Functions[0].Name)
+	assert.True(t, result.Functions[0].Registered)
+	assert.Empty(t, result.Functions[0].Suggestions)
+
+	unknown := result.Functions[1]
+	assert.Equal(t, "lenn", unknown.Name)
+	assert.False(t, unknown.Registered)
+	assert.Equal(t, 1, unknown.Line)
+	assert.Contains(t, unknown.Suggestions, "len")
+
+	assert.False(t, result.Valid)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0], "unknown function 'lenn'")
+	assert.Contains(t, result.String(), "Did you mean: ")
+}
+
+func TestTemplate_DryRun_ExpressionLoopScope(t *testing.T) {
+	engine := MustNew()
+	tmpl, err := engine.Parse(`{~prompty.for item="u" index="i" in="users"~}` +
+		`{~prompty.for item="tag" in="u.tags"~}{~prompty.var name="tag" /~}{~/prompty.for~}` +
+		`{~prompty.if eval="u.active && i > 0 && minAge > 1"~}{~prompty.var name="u.name" /~}{~/prompty.if~}` +
+		`{~/prompty.for~}`)
+	require.NoError(t, err)
+
+	result := tmpl.DryRun(context.Background(), map[string]any{"users": []any{}})
+
+	assert.Equal(t, []string{"minAge"}, result.MissingVariables)
+	assert.Empty(t, result.Warnings)
+	for _, v := range result.Variables {
+		assert.Equal(t, v.Name != "minAge", v.InData, v.Name)
+	}
+}
+
+func TestTemplate_DryRun_InvalidExpression(t *testing.T) {
+	engine := MustNew()
+	tmpl, err := engine.Parse(`{~prompty.if eval="a &&"~}x{~/prompty.if~}`)
+	require.NoError(t, err)
+
+	result := tmpl.DryRun(context.Background(), nil)
+
+	assert.False(t, result.Valid)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0], "invalid expression 'a &&'")
+}
+
 func TestTemplate_Explain_Switch(t *testing.T) {
 	engine := MustNew()
 	tmpl, err := engine.Parse(`{~prompty.switch eval="status"~}{~prompty.case value="active"~}Active{~/prompty.case~}{~prompty.case value="pending"~}Pending{~/prompty.case~}{~prompty.casedefault~}Unknown{~/prompty.casedefault~}{~/prompty.switch~}`)
@@ -748,7 +880,7 @@ func TestGetPath_MapStringString(t *testing.T) {
 		},
 	}
 
-	val, found := getPath(data, "headers.Content-Type")
+	val, found := getPath(data, "headers.Content-Type", FieldMatchCaseInsensitive)
 	assert.True(t, found)
 	assert.Equal(t, "application/json", val)
 }
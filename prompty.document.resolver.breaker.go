@@ -0,0 +1,403 @@
+package prompty
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/itsatony/go-cuserr"
+)
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState string
+
+// Circuit breaker states.
+const (
+	// CircuitClosed lets calls through and counts their failures.
+	CircuitClosed CircuitState = "closed"
+
+	// CircuitOpen rejects calls until CircuitBreakerConfig.OpenDuration has
+	// passed since the circuit opened.
+	CircuitOpen CircuitState = "open"
+
+	// CircuitHalfOpen lets a limited number of probe calls through; the
+	// circuit closes when they succeed and opens again when one fails.
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// Circuit breaker defaults.
+const (
+	DefaultResolveTimeout          = 5 * time.Second
+	DefaultCircuitFailureThreshold = 5
+	DefaultCircuitOpenDuration     = 30 * time.Second
+	DefaultCircuitHalfOpenProbes   = 1
+)
+
+// Circuit breaker error messages.
+const (
+	ErrMsgCircuitOpen    = "resolver circuit open"
+	ErrMsgResolveTimeout = "resolution timed out"
+)
+
+// ErrCircuitOpen is matched by errors.Is for every call a CircuitBreaker
+// rejects because its circuit is open.
+var ErrCircuitOpen = errors.New(ErrMsgCircuitOpen)
+
+// CircuitBreakerConfig configures a CircuitBreaker.
+type CircuitBreakerConfig struct {
+	// Timeout is the deadline of each call. Calls still running at the
+	// deadline are abandoned: the caller gets an error matching
+	// context.DeadlineExceeded and ErrExecutionTimeout while the call
+	// finishes in the background.
+	// Default: DefaultResolveTimeout. Negative disables the deadline.
+	Timeout time.Duration
+
+	// FailureThreshold is the number of consecutive failures that opens
+	// the circuit. Default: DefaultCircuitFailureThreshold.
+	FailureThreshold int
+
+	// OpenDuration is how long the circuit rejects calls before letting
+	// probes through. Default: DefaultCircuitOpenDuration.
+	OpenDuration time.Duration
+
+	// HalfOpenProbes is the number of probe calls let through while the
+	// circuit is half-open; all must succeed to close it.
+	// Default: DefaultCircuitHalfOpenProbes.
+	HalfOpenProbes int
+
+	// IsFailure reports whether an error counts as a failure. By default
+	// every error does except not-found errors and the caller's own
+	// context cancellation, which say nothing about the backend's health.
+	IsFailure func(err error) bool
+
+	// OnStateChange is called after each state change, e.g. to log or
+	// export it (optional). It must not call the breaker.
+	OnStateChange func(from, to CircuitState)
+}
+
+// CircuitBreakerStats reports the calls of a CircuitBreaker.
+type CircuitBreakerStats struct {
+	State               CircuitState
+	ConsecutiveFailures int
+	Calls               int64 // Calls let through
+	Successes           int64
+	Failures            int64 // Calls counted as failures, including timeouts
+	Timeouts            int64
+	Rejections          int64 // Calls rejected while the circuit was open
+	Trips               int64 // Times the circuit opened
+}
+
+// CircuitBreaker bounds the duration of calls to a backend and stops
+// calling it after repeated failures, so a slow or failing backend fails
+// fast instead of stalling every caller. It is safe for concurrent use.
+type CircuitBreaker struct {
+	mu       sync.Mutex
+	config   CircuitBreakerConfig
+	state    CircuitState
+	openedAt time.Time
+	probes   int // Probes in flight while half-open
+	passed   int // Probes succeeded while half-open
+	stats    CircuitBreakerStats
+}
+
+// NewCircuitBreaker creates a closed circuit breaker. Zero config values
+// use the defaults.
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	if config.Timeout == 0 {
+		config.Timeout = DefaultResolveTimeout
+	}
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = DefaultCircuitFailureThreshold
+	}
+	if config.OpenDuration <= 0 {
+		config.OpenDuration = DefaultCircuitOpenDuration
+	}
+	if config.HalfOpenProbes <= 0 {
+		config.HalfOpenProbes = DefaultCircuitHalfOpenProbes
+	}
+	return &CircuitBreaker{config: config, state: CircuitClosed}
+}
+
+// Do calls fn with a context bounded by the configured timeout, unless the
+// circuit is open, in which case it returns an error matching
+// ErrCircuitOpen without calling fn. Failures of fn count towards opening
+// the circuit.
+func (b *CircuitBreaker) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	probe, err := b.admit()
+	if err != nil {
+		return err
+	}
+
+	err = b.call(ctx, fn)
+	timedOut := err != nil && ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded)
+	failed := timedOut || (err != nil && ctx.Err() == nil && b.isFailure(err))
+	b.record(probe, failed, timedOut)
+	return err
+}
+
+// call runs fn under the deadline, abandoning it when the deadline passes.
+func (b *CircuitBreaker) call(ctx context.Context, fn func(ctx context.Context) error) error {
+	if b.config.Timeout < 0 {
+		return fn(ctx)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, b.config.Timeout)
+	done := make(chan error, 1)
+	go func() {
+		defer cancel()
+		done <- fn(callCtx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-callCtx.Done():
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return withSentinel(cuserr.WrapStdError(callCtx.Err(), ErrCodeRef, ErrMsgResolveTimeout), ErrExecutionTimeout).
+			WithMetadata(MetaKeyTimeout, b.config.Timeout.String())
+	}
+}
+
+// admit reports whether a call may proceed and whether it is a probe.
+func (b *CircuitBreaker) admit() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && timeNow().Sub(b.openedAt) >= b.config.OpenDuration {
+		b.setState(CircuitHalfOpen)
+	}
+
+	switch b.state {
+	case CircuitOpen:
+		b.stats.Rejections++
+		return false, cuserr.WrapStdError(ErrCircuitOpen, ErrCodeRef, ErrMsgCircuitOpen)
+	case CircuitHalfOpen:
+		if b.probes+b.passed >= b.config.HalfOpenProbes {
+			b.stats.Rejections++
+			return false, cuserr.WrapStdError(ErrCircuitOpen, ErrCodeRef, ErrMsgCircuitOpen)
+		}
+		b.probes++
+		b.stats.Calls++
+		return true, nil
+	}
+	b.stats.Calls++
+	return false, nil
+}
+
+// record updates the circuit with the outcome of a call.
+func (b *CircuitBreaker) record(probe, failed, timedOut bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probes--
+	}
+	if timedOut {
+		b.stats.Timeouts++
+	}
+
+	if failed {
+		b.stats.Failures++
+		b.stats.ConsecutiveFailures++
+		switch {
+		case probe && b.state == CircuitHalfOpen:
+			b.trip()
+		case b.state == CircuitClosed && b.stats.ConsecutiveFailures >= b.config.FailureThreshold:
+			b.trip()
+		}
+		return
+	}
+
+	b.stats.Successes++
+	b.stats.ConsecutiveFailures = 0
+	if probe && b.state == CircuitHalfOpen {
+		b.passed++
+		if b.passed >= b.config.HalfOpenProbes {
+			b.setState(CircuitClosed)
+		}
+	}
+}
+
+// isFailure reports whether err, returned while the caller's context was
+// still live, counts as a failure.
+func (b *CircuitBreaker) isFailure(err error) bool {
+	if b.config.IsFailure != nil {
+		return b.config.IsFailure(err)
+	}
+	return !isNotFoundError(err)
+}
+
+// trip opens the circuit. The caller must hold mu.
+func (b *CircuitBreaker) trip() {
+	b.openedAt = timeNow()
+	b.stats.Trips++
+	b.setState(CircuitOpen)
+}
+
+// setState moves the circuit to state. The caller must hold mu.
+func (b *CircuitBreaker) setState(state CircuitState) {
+	from := b.state
+	b.state = state
+	b.passed = 0
+	if state == CircuitClosed {
+		b.stats.ConsecutiveFailures = 0
+	}
+	if from != state && b.config.OnStateChange != nil {
+		b.config.OnStateChange(from, state)
+	}
+}
+
+// State returns the current state of the circuit.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && timeNow().Sub(b.openedAt) >= b.config.OpenDuration {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// Stats returns the call statistics and current state.
+func (b *CircuitBreaker) Stats() CircuitBreakerStats {
+	stats := func() CircuitBreakerStats {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return b.stats
+	}()
+	stats.State = b.State()
+	return stats
+}
+
+// Reset closes the circuit. Statistics other than the consecutive
+// failures are kept.
+func (b *CircuitBreaker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.setState(CircuitClosed)
+}
+
+// ResilientDocumentResolver wraps a DocumentResolver (such as a
+// StorageDocumentResolver over a remote store) with per-resolution
+// deadlines and a circuit breaker, so a slow or failing backend fails
+// compilations fast instead of stalling them. Combined with
+// CompileOptions.OnResolveError, agents keep compiling without the
+// affected skills while the circuit is open.
+//
+// Version lookups of a VersionedDocumentResolver go through the breaker
+// too. To add semantic search, wrap the resilient resolver in an
+// EmbeddingDocumentResolver.
+type ResilientDocumentResolver struct {
+	resolver DocumentResolver
+	breaker  *CircuitBreaker
+}
+
+// NewResilientDocumentResolver wraps resolver with a circuit breaker.
+func NewResilientDocumentResolver(resolver DocumentResolver, config CircuitBreakerConfig) *ResilientDocumentResolver {
+	return &ResilientDocumentResolver{resolver: resolver, breaker: NewCircuitBreaker(config)}
+}
+
+// Breaker returns the circuit breaker, e.g. to read its Stats.
+func (r *ResilientDocumentResolver) Breaker() *CircuitBreaker {
+	return r.breaker
+}
+
+// ResolvePrompt resolves a prompt through the breaker.
+func (r *ResilientDocumentResolver) ResolvePrompt(ctx context.Context, slug string) (*Prompt, error) {
+	return r.resolve(ctx, func(ctx context.Context) (*Prompt, error) {
+		return r.resolver.ResolvePrompt(ctx, slug)
+	})
+}
+
+// ResolveSkill resolves a skill through the breaker.
+func (r *ResilientDocumentResolver) ResolveSkill(ctx context.Context, ref string) (*Prompt, error) {
+	return r.resolve(ctx, func(ctx context.Context) (*Prompt, error) {
+		return r.resolver.ResolveSkill(ctx, ref)
+	})
+}
+
+// ResolveAgent resolves an agent through the breaker.
+func (r *ResilientDocumentResolver) ResolveAgent(ctx context.Context, slug string) (*Prompt, error) {
+	return r.resolve(ctx, func(ctx context.Context) (*Prompt, error) {
+		return r.resolver.ResolveAgent(ctx, slug)
+	})
+}
+
+// ListSkillVersions lists the versions known to the wrapped resolver
+// through the breaker.
+func (r *ResilientDocumentResolver) ListSkillVersions(ctx context.Context, slug string) ([]string, error) {
+	versioned, ok := r.resolver.(VersionedDocumentResolver)
+	if !ok {
+		return nil, nil
+	}
+	var versions []string
+	err := r.breaker.Do(ctx, func(ctx context.Context) error {
+		listed, err := versioned.ListSkillVersions(ctx, slug)
+		versions = listed
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// ResolveSkillVersion resolves one version with the wrapped resolver
+// through the breaker.
+func (r *ResilientDocumentResolver) ResolveSkillVersion(ctx context.Context, slug, version string) (*Prompt, error) {
+	versioned, ok := r.resolver.(VersionedDocumentResolver)
+	if !ok {
+		return nil, NewSkillVersionNotFoundError(slug, version)
+	}
+	return r.resolve(ctx, func(ctx context.Context) (*Prompt, error) {
+		return versioned.ResolveSkillVersion(ctx, slug, version)
+	})
+}
+
+// resolve runs a resolution through the breaker.
+func (r *ResilientDocumentResolver) resolve(ctx context.Context, fn func(ctx context.Context) (*Prompt, error)) (*Prompt, error) {
+	var resolved *Prompt
+	err := r.breaker.Do(ctx, func(ctx context.Context) error {
+		p, err := fn(ctx)
+		resolved = p
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+// ResilientPromptBodyResolver wraps the PromptBodyResolver of
+// {~prompty.ref~} tags with per-resolution deadlines and a circuit
+// breaker, like ResilientDocumentResolver.
+type ResilientPromptBodyResolver struct {
+	resolver PromptBodyResolver
+	breaker  *CircuitBreaker
+}
+
+// NewResilientPromptBodyResolver wraps resolver with a circuit breaker.
+func NewResilientPromptBodyResolver(resolver PromptBodyResolver, config CircuitBreakerConfig) *ResilientPromptBodyResolver {
+	return &ResilientPromptBodyResolver{resolver: resolver, breaker: NewCircuitBreaker(config)}
+}
+
+// Breaker returns the circuit breaker, e.g. to read its Stats.
+func (r *ResilientPromptBodyResolver) Breaker() *CircuitBreaker {
+	return r.breaker
+}
+
+// ResolvePromptBody resolves a prompt body through the breaker.
+func (r *ResilientPromptBodyResolver) ResolvePromptBody(ctx context.Context, slug string, version string) (string, error) {
+	var body string
+	err := r.breaker.Do(ctx, func(ctx context.Context) error {
+		b, err := r.resolver.ResolvePromptBody(ctx, slug, version)
+		body = b
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return body, nil
+}
@@ -0,0 +1,227 @@
+package prompty
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyDocumentResolver resolves skills with a replaceable function
+type flakyDocumentResolver struct {
+	NoopDocumentResolver
+	mu    sync.Mutex
+	skill func(ctx context.Context, ref string) (*Prompt, error)
+}
+
+func (r *flakyDocumentResolver) set(fn func(ctx context.Context, ref string) (*Prompt, error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.skill = fn
+}
+
+func (r *flakyDocumentResolver) ResolveSkill(ctx context.Context, ref string) (*Prompt, error) {
+	r.mu.Lock()
+	fn := r.skill
+	r.mu.Unlock()
+	return fn(ctx, ref)
+}
+
+func TestCircuitBreaker_States(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	var transitions []string
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 2,
+		OpenDuration:     time.Minute,
+		OnStateChange: func(from, to CircuitState) {
+			transitions = append(transitions, string(from)+">"+string(to))
+		},
+	})
+	ctx := context.Background()
+	failure := errors.New("backend down")
+	fail := func(context.Context) error { return failure }
+	succeed := func(context.Context) error { return nil }
+	notFound := func(context.Context) error { return NewSkillNotFoundError("x") }
+
+	// Not-found errors and successes do not count as failures
+	assert.ErrorIs(t, breaker.Do(ctx, fail), failure)
+	assert.Error(t, breaker.Do(ctx, notFound))
+	assert.ErrorIs(t, breaker.Do(ctx, fail), failure)
+	assert.Equal(t, CircuitClosed, breaker.State())
+
+	// Consecutive failures open the circuit
+	assert.ErrorIs(t, breaker.Do(ctx, fail), failure)
+	assert.Equal(t, CircuitOpen, breaker.State())
+	called := false
+	err := breaker.Do(ctx, func(context.Context) error { called = true; return nil })
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.False(t, called)
+
+	// A failed probe opens it again, a successful one closes it
+	now = now.Add(time.Minute)
+	assert.Equal(t, CircuitHalfOpen, breaker.State())
+	assert.ErrorIs(t, breaker.Do(ctx, fail), failure)
+	assert.Equal(t, CircuitOpen, breaker.State())
+	now = now.Add(time.Minute)
+	assert.NoError(t, breaker.Do(ctx, succeed))
+	assert.Equal(t, CircuitClosed, breaker.State())
+
+	stats := breaker.Stats()
+	assert.Equal(t, CircuitClosed, stats.State)
+	assert.Equal(t, int64(6), stats.Calls)
+	assert.Equal(t, int64(2), stats.Successes)
+	assert.Equal(t, int64(4), stats.Failures)
+	assert.Equal(t, int64(1), stats.Rejections)
+	assert.Equal(t, int64(2), stats.Trips)
+	assert.Equal(t, 0, stats.ConsecutiveFailures)
+	assert.Equal(t, []string{"closed>open", "open>half_open", "half_open>open", "open>half_open", "half_open>closed"}, transitions)
+}
+
+func TestCircuitBreaker_HalfOpenProbes(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, HalfOpenProbes: 2})
+	ctx := context.Background()
+	_ = breaker.Do(ctx, func(context.Context) error { return errors.New("down") })
+	now = now.Add(DefaultCircuitOpenDuration)
+
+	// Probes beyond the limit are rejected while others are in flight
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = breaker.Do(ctx, func(context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	assert.NoError(t, breaker.Do(ctx, func(context.Context) error { return nil }))
+	assert.ErrorIs(t, breaker.Do(ctx, func(context.Context) error { return nil }), ErrCircuitOpen)
+	close(release)
+	require.Eventually(t, func() bool { return breaker.State() == CircuitClosed }, time.Second, time.Millisecond)
+
+	breaker.Reset()
+	assert.Equal(t, CircuitClosed, breaker.State())
+}
+
+func TestCircuitBreaker_Timeout(t *testing.T) {
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{Timeout: 20 * time.Millisecond, FailureThreshold: 1})
+	release := make(chan struct{})
+	defer close(release)
+
+	start := time.Now()
+	err := breaker.Do(context.Background(), func(context.Context) error {
+		<-release // Ignores its context
+		return nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, ErrMsgResolveTimeout)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int64(1), breaker.Stats().Timeouts)
+	assert.Equal(t, CircuitOpen, breaker.State())
+
+	// The caller's own cancellation is not a failure
+	breaker.Reset()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = breaker.Do(ctx, func(ctx context.Context) error { return ctx.Err() })
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, CircuitClosed, breaker.State())
+	assert.Equal(t, 0, breaker.Stats().ConsecutiveFailures)
+}
+
+func TestResilientDocumentResolver_Compile(t *testing.T) {
+	ctx := context.Background()
+	backend := &flakyDocumentResolver{}
+	backend.set(func(ctx context.Context, ref string) (*Prompt, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	resolver := NewResilientDocumentResolver(backend, CircuitBreakerConfig{Timeout: 10 * time.Millisecond, FailureThreshold: 1})
+
+	agent := &Prompt{
+		Name:        "agent",
+		Description: "Agent",
+		Type:        DocumentTypeAgent,
+		Skills:      []SkillRef{{Slug: "search"}, {Slug: "summarize"}},
+		Body:        `Agent.{~prompty.skills_catalog /~}`,
+	}
+	opts := &CompileOptions{Resolver: resolver, OnResolveError: ResolveErrorSkip}
+
+	// The first skill times out and opens the circuit; the second fails fast
+	start := time.Now()
+	compiled, err := agent.CompileAgent(ctx, nil, opts)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Empty(t, compiled.Skills)
+	require.Len(t, compiled.Warnings, 2)
+	assert.Contains(t, compiled.Warnings[0].Message, ErrMsgResolveTimeout)
+	assert.Contains(t, compiled.Warnings[1].Message, ErrMsgCircuitOpen)
+	assert.Equal(t, int64(1), resolver.Breaker().Stats().Rejections)
+
+	// Once the backend recovers, the circuit closes again
+	backend.set(func(ctx context.Context, ref string) (*Prompt, error) {
+		return &Prompt{Name: ref, Description: "Skill " + ref, Type: DocumentTypeSkill, Body: ref}, nil
+	})
+	resolver.Breaker().Reset()
+	compiled, err = agent.CompileAgent(ctx, nil, opts)
+	require.NoError(t, err)
+	assert.Len(t, compiled.Skills, 2)
+	assert.Empty(t, compiled.Warnings)
+}
+
+func TestResilientDocumentResolver_Versions(t *testing.T) {
+	backend := NewMapDocumentResolver()
+	backend.AddSkillVersion("summarizer", "1.0.0", &Prompt{Name: "summarizer", Body: "v1"})
+	backend.AddSkillVersion("summarizer", "2.1.0", &Prompt{Name: "summarizer", Body: "v2"})
+	resolver := NewResilientDocumentResolver(backend, CircuitBreakerConfig{})
+
+	resolved, version, err := ResolveSkillRef(context.Background(), resolver, &SkillRef{Slug: "summarizer@^1"})
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", version)
+	assert.Equal(t, "v1", resolved.Body)
+	assert.Equal(t, int64(2), resolver.Breaker().Stats().Calls)
+
+	_, err = resolver.ResolvePrompt(context.Background(), "missing")
+	assert.Error(t, err)
+	assert.Equal(t, CircuitClosed, resolver.Breaker().State())
+}
+
+func TestStorageDocumentResolver_StorageErrors(t *testing.T) {
+	storage := NewMemoryStorage()
+	resolver := NewStorageDocumentResolver(storage)
+
+	_, err := resolver.ResolveSkill(context.Background(), "missing")
+	assert.ErrorContains(t, err, ErrMsgRefNotFound)
+
+	require.NoError(t, storage.Close())
+	_, err = resolver.ResolveSkill(context.Background(), "missing")
+	assert.ErrorContains(t, err, ErrMsgStorageClosed)
+}
+
+func TestResilientPromptBodyResolver(t *testing.T) {
+	backend := &mockPromptResolver{prompts: map[string]string{"rules": "Be kind."}}
+	resolver := NewResilientPromptBodyResolver(backend, CircuitBreakerConfig{FailureThreshold: 1})
+	tmpl, err := MustNew().Parse(`{~prompty.ref slug="rules" /~}`)
+	require.NoError(t, err)
+
+	output, err := tmpl.ExecuteWithContext(context.Background(), NewContext(nil).WithPromptResolver(resolver))
+	require.NoError(t, err)
+	assert.Equal(t, "Be kind.", output)
+
+	// Missing prompts do not open the circuit
+	_, err = resolver.ResolvePromptBody(context.Background(), "missing", "")
+	assert.Error(t, err)
+	assert.Equal(t, CircuitClosed, resolver.Breaker().State())
+	assert.Equal(t, int64(2), resolver.Breaker().Stats().Successes)
+}
@@ -2,7 +2,6 @@ package prompty
 
 import (
 	"context"
-	"strings"
 	"sync"
 )
 
@@ -39,18 +38,20 @@ func (r *NoopDocumentResolver) ResolveAgent(_ context.Context, slug string) (*Pr
 // MapDocumentResolver is an in-memory DocumentResolver backed by maps.
 // Useful for testing and simple use cases. Safe for concurrent access.
 type MapDocumentResolver struct {
-	mu      sync.RWMutex
-	prompts map[string]*Prompt
-	skills  map[string]*Prompt
-	agents  map[string]*Prompt
+	mu            sync.RWMutex
+	prompts       map[string]*Prompt
+	skills        map[string]*Prompt
+	skillVersions map[string]map[string]*Prompt
+	agents        map[string]*Prompt
 }
 
 // NewMapDocumentResolver creates a new MapDocumentResolver.
 func NewMapDocumentResolver() *MapDocumentResolver {
 	return &MapDocumentResolver{
-		prompts: make(map[string]*Prompt),
-		skills:  make(map[string]*Prompt),
-		agents:  make(map[string]*Prompt),
+		prompts:       make(map[string]*Prompt),
+		skills:        make(map[string]*Prompt),
+		skillVersions: make(map[string]map[string]*Prompt),
+		agents:        make(map[string]*Prompt),
 	}
 }
 
@@ -61,11 +62,15 @@ func (r *MapDocumentResolver) AddPrompt(slug string, p *Prompt) {
 	r.prompts[slug] = p
 }
 
-// AddSkill registers a skill by slug.
+// AddSkill registers a skill by slug. A skill with a metadata version is
+// also registered as that version.
 func (r *MapDocumentResolver) AddSkill(slug string, p *Prompt) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.skills[slug] = p
+	if version := PromptVersion(p); version != "" {
+		r.addSkillVersionLocked(slug, version, p)
+	}
 }
 
 // AddAgent registers an agent by slug.
@@ -89,17 +94,18 @@ func (r *MapDocumentResolver) ResolvePrompt(_ context.Context, slug string) (*Pr
 	return nil, NewRefNotFoundError(slug, RefVersionLatest)
 }
 
-// ResolveSkill looks up a skill by slug reference.
-func (r *MapDocumentResolver) ResolveSkill(_ context.Context, ref string) (*Prompt, error) {
-	// Parse slug@version if present
-	slug := ref
-	if atIdx := len(ref) - 1; atIdx > 0 {
-		for i := len(ref) - 1; i > 0; i-- {
-			if ref[i] == '@' {
-				slug = ref[:i]
-				break
-			}
-		}
+// ResolveSkill looks up a skill by slug reference. For skills with
+// registered versions, "slug@constraint" resolves the highest version
+// satisfying the constraint and a bare slug the highest release.
+func (r *MapDocumentResolver) ResolveSkill(ctx context.Context, ref string) (*Prompt, error) {
+	slug, constraint := splitSkillRef(ref)
+
+	r.mu.RLock()
+	hasVersions := len(r.skillVersions[slug]) > 0
+	r.mu.RUnlock()
+	if hasVersions {
+		resolved, _, err := resolveSkillVersion(ctx, r, slug, constraint)
+		return resolved, err
 	}
 
 	r.mu.RLock()
@@ -140,14 +146,19 @@ func (r *StorageDocumentResolver) ResolvePrompt(ctx context.Context, slug string
 }
 
 // ResolveSkill looks up a skill by slug reference from storage.
-// Supports slug@version format; the version portion is ignored for now
-// (storage resolves the latest version by name).
+// Supports slug@constraint format, matched against the metadata version of
+// each stored version or else its version number (so "slug@v2" selects
+// stored version 2).
 func (r *StorageDocumentResolver) ResolveSkill(ctx context.Context, ref string) (*Prompt, error) {
-	slug := ref
-	if idx := strings.LastIndex(ref, "@"); idx > 0 {
-		slug = ref[:idx]
+	slug, constraint := splitSkillRef(ref)
+	if constraint == RefVersionLatest {
+		return r.resolveByName(ctx, slug)
 	}
-	return r.resolveByName(ctx, slug)
+	resolved, _, err := resolveSkillVersion(ctx, r, slug, constraint)
+	if err != nil {
+		return nil, err
+	}
+	return resolved, nil
 }
 
 // ResolveAgent looks up an agent by slug from storage.
@@ -155,18 +166,28 @@ func (r *StorageDocumentResolver) ResolveAgent(ctx context.Context, slug string)
 	return r.resolveByName(ctx, slug)
 }
 
-// resolveByName fetches a stored template by name and returns its PromptConfig.
+// resolveByName fetches a stored template by name and returns its
+// PromptConfig. Storage errors other than a missing template, such as
+// timeouts, are returned as is so callers can tell them apart.
 func (r *StorageDocumentResolver) resolveByName(ctx context.Context, name string) (*Prompt, error) {
 	tmpl, err := r.storage.Get(ctx, name)
 	if err != nil {
-		return nil, NewRefNotFoundError(name, RefVersionLatest)
+		if isNotFoundError(err) {
+			return nil, NewRefNotFoundError(name, RefVersionLatest)
+		}
+		return nil, err
 	}
+	return storedTemplatePrompt(tmpl), nil
+}
+
+// storedTemplatePrompt returns the PromptConfig of a stored template, or a
+// minimal prompt with the source as body when it has none.
+func storedTemplatePrompt(tmpl *StoredTemplate) *Prompt {
 	if tmpl.PromptConfig != nil {
-		return tmpl.PromptConfig.Clone(), nil
+		return tmpl.PromptConfig.Clone()
 	}
-	// Template exists but has no PromptConfig — return minimal prompt with body from source
 	return &Prompt{
 		Name: tmpl.Name,
 		Body: tmpl.Source,
-	}, nil
+	}
 }
@@ -0,0 +1,146 @@
+package prompty
+
+import (
+	"context"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// DocumentMatch is a result of SemanticDocumentResolver.SearchSimilar.
+type DocumentMatch struct {
+	// Slug identifies the matching document.
+	Slug string
+
+	// Score is the cosine similarity to the query, from -1 to 1.
+	Score float64
+
+	// Prompt is the resolved document (nil if it could not be resolved,
+	// e.g. for inline skills).
+	Prompt *Prompt
+}
+
+// SemanticDocumentResolver is a DocumentResolver that can rank documents by
+// semantic similarity to a query. CompileAgent uses it for
+// {~prompty.skills_catalog selection="relevant"~}.
+type SemanticDocumentResolver interface {
+	DocumentResolver
+	// SearchSimilar returns the k documents most similar to query, best
+	// first. If k <= 0, all indexed documents are returned.
+	SearchSimilar(ctx context.Context, query string, k int) ([]DocumentMatch, error)
+}
+
+// skillIndexer is implemented by semantic resolvers that index the skills
+// of an agent on demand before selecting among them.
+type skillIndexer interface {
+	IndexSkills(ctx context.Context, skills []SkillRef) error
+}
+
+// EmbeddingDocumentResolver adds semantic search to any DocumentResolver
+// using an EmbeddingIndexer keyed by slug.
+type EmbeddingDocumentResolver struct {
+	DocumentResolver
+	indexer *EmbeddingIndexer
+}
+
+// NewEmbeddingDocumentResolver wraps resolver with semantic search over
+// the documents indexed in indexer.
+func NewEmbeddingDocumentResolver(resolver DocumentResolver, indexer *EmbeddingIndexer) *EmbeddingDocumentResolver {
+	return &EmbeddingDocumentResolver{DocumentResolver: resolver, indexer: indexer}
+}
+
+// Indexer returns the embedding indexer.
+func (r *EmbeddingDocumentResolver) Indexer() *EmbeddingIndexer {
+	return r.indexer
+}
+
+// IndexDocument indexes a prompt, skill or agent under slug.
+func (r *EmbeddingDocumentResolver) IndexDocument(ctx context.Context, slug string, p *Prompt) error {
+	return r.indexer.Index(ctx, slug, documentEmbeddingText(slug, p.Description, p.Body))
+}
+
+// IndexSkills indexes skill references by slug: inline skills from their
+// definition, others by resolving them. Unresolvable skills are skipped.
+func (r *EmbeddingDocumentResolver) IndexSkills(ctx context.Context, skills []SkillRef) error {
+	docs := make(map[string]string, len(skills))
+	for i := range skills {
+		ref := &skills[i]
+		slug := ref.GetSlug()
+		if ref.IsInline() {
+			docs[slug] = documentEmbeddingText(slug, ref.Inline.Description, ref.Inline.Body)
+			continue
+		}
+		resolved, _, err := ResolveSkillRef(ctx, r, ref)
+		if err != nil || resolved == nil {
+			continue
+		}
+		docs[slug] = documentEmbeddingText(slug, resolved.Description, resolved.Body)
+	}
+	return r.indexer.IndexAll(ctx, docs)
+}
+
+// SearchSimilar returns the k indexed documents most similar to query,
+// resolved as skills.
+func (r *EmbeddingDocumentResolver) SearchSimilar(ctx context.Context, query string, k int) ([]DocumentMatch, error) {
+	matches, err := r.indexer.Search(ctx, query, k)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]DocumentMatch, len(matches))
+	for i, match := range matches {
+		results[i] = DocumentMatch{Slug: match.Key, Score: match.Score}
+		if resolved, err := r.ResolveSkill(ctx, match.Key); err == nil {
+			results[i].Prompt = resolved
+		}
+	}
+	return results, nil
+}
+
+// documentEmbeddingText returns the text embedded for a document: its slug
+// and description, or its body when there is no description.
+func documentEmbeddingText(slug, description, body string) string {
+	if description != "" {
+		return slug + "\n" + description
+	}
+	return slug + "\n" + body
+}
+
+// relevantSkillsSelector returns the selector of
+// {~prompty.skills_catalog selection="relevant"~} for an agent, or nil when
+// the resolver has no semantic search.
+func relevantSkillsSelector(skills []SkillRef, resolver DocumentResolver, format CatalogFormat) internal.SkillsSelector {
+	semantic, ok := resolver.(SemanticDocumentResolver)
+	if !ok || len(skills) == 0 {
+		return nil
+	}
+
+	return func(ctx context.Context, query string, limit int) (string, error) {
+		if limit <= 0 {
+			limit = DefaultRelevantSkillsLimit
+		}
+		if indexer, ok := semantic.(skillIndexer); ok {
+			if err := indexer.IndexSkills(ctx, skills); err != nil {
+				return "", err
+			}
+		}
+
+		bySlug := make(map[string]SkillRef, len(skills))
+		for _, ref := range skills {
+			bySlug[ref.GetSlug()] = ref
+		}
+
+		matches, err := semantic.SearchSimilar(ctx, query, 0)
+		if err != nil {
+			return "", err
+		}
+		selected := make([]SkillRef, 0, limit)
+		for _, match := range matches {
+			if ref, ok := bySlug[match.Slug]; ok {
+				selected = append(selected, ref)
+				if len(selected) == limit {
+					break
+				}
+			}
+		}
+		return GenerateSkillsCatalog(ctx, selected, resolver, format)
+	}
+}
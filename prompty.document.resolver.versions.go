@@ -0,0 +1,297 @@
+package prompty
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// VersionedDocumentResolver is a DocumentResolver that knows the versions
+// of each skill, so skill references can use semantic version constraints
+// such as "summarizer@^2.1" or "web-search@>=1.0 <2".
+type VersionedDocumentResolver interface {
+	DocumentResolver
+	// ListSkillVersions returns the available versions of a skill
+	// (empty if the resolver has no version information for it).
+	ListSkillVersions(ctx context.Context, slug string) ([]string, error)
+	// ResolveSkillVersion resolves one exact version of a skill.
+	ResolveSkillVersion(ctx context.Context, slug, version string) (*Prompt, error)
+}
+
+// PromptVersion returns the semantic version of a document from
+// Metadata["version"], or "" if it has none.
+func PromptVersion(p *Prompt) string {
+	if p == nil || p.Metadata == nil {
+		return ""
+	}
+	switch v := p.Metadata[PromptMetadataVersion].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// splitSkillRef splits "slug@version" into slug and version
+// (RefVersionLatest when absent).
+func splitSkillRef(ref string) (string, string) {
+	if idx := strings.LastIndex(ref, "@"); idx > 0 {
+		return ref[:idx], ref[idx+1:]
+	}
+	return ref, RefVersionLatest
+}
+
+// ResolveSkillRef resolves a skill reference, honoring its version
+// constraint, and returns the skill with its resolved version ("" when
+// unknown). Inline skills resolve to their definition.
+//
+// With a VersionedDocumentResolver the highest version satisfying the
+// constraint is chosen ("latest" chooses the highest release). Other
+// resolvers resolve the slug and the constraint is checked against the
+// resolved document's metadata version, if any.
+func ResolveSkillRef(ctx context.Context, resolver DocumentResolver, ref *SkillRef) (*Prompt, string, error) {
+	if ref.IsInline() {
+		return &Prompt{
+			Name:        ref.Inline.Slug,
+			Description: ref.Inline.Description,
+			Type:        DocumentTypeSkill,
+			Body:        ref.Inline.Body,
+		}, "", nil
+	}
+	slug := ref.GetSlug()
+	if resolver == nil {
+		return nil, "", NewSkillNotFoundError(slug)
+	}
+	return resolveSkillVersion(ctx, resolver, slug, ref.GetVersion())
+}
+
+// resolveSkillVersion resolves slug at the highest version satisfying
+// constraint.
+func resolveSkillVersion(ctx context.Context, resolver DocumentResolver, slug, constraint string) (*Prompt, string, error) {
+	rangeExpr := constraint
+	if constraint == RefVersionLatest || constraint == "" {
+		rangeExpr = VersionWildcardStar
+	}
+	c, err := ParseVersionConstraint(rangeExpr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if versioned, ok := resolver.(VersionedDocumentResolver); ok {
+		versions, err := versioned.ListSkillVersions(ctx, slug)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(versions) > 0 {
+			version, ok := c.MaxSatisfying(versions)
+			if !ok {
+				return nil, "", NewSkillVersionNotFoundError(slug, constraint)
+			}
+			resolved, err := versioned.ResolveSkillVersion(ctx, slug, version)
+			if err != nil {
+				return nil, "", err
+			}
+			return resolved, version, nil
+		}
+	}
+
+	resolved, err := resolver.ResolveSkill(ctx, slug)
+	if err != nil {
+		return nil, "", err
+	}
+	version := PromptVersion(resolved)
+	if version != "" {
+		if v, err := ParseSemVersion(version); err == nil && !c.Check(v) {
+			return nil, "", NewSkillVersionNotFoundError(slug, constraint)
+		}
+	}
+	return resolved, version, nil
+}
+
+// CompileLock records the exact skill versions used by a compilation.
+// Pass it back in CompileOptions.Lock to compile with the same versions.
+type CompileLock struct {
+	// PromptHash is the content hash of the compiled prompt (see Prompt.Hash).
+	PromptHash string `json:"prompt_hash,omitempty" yaml:"prompt_hash,omitempty"`
+	// Skills lists the locked skills, sorted by slug.
+	Skills []LockedSkill `json:"skills" yaml:"skills"`
+}
+
+// LockedSkill is the resolved version of one skill reference.
+type LockedSkill struct {
+	// Slug is the skill slug.
+	Slug string `json:"slug" yaml:"slug"`
+	// Constraint is the version constraint of the reference ("latest" if none).
+	Constraint string `json:"constraint" yaml:"constraint"`
+	// Version is the resolved version.
+	Version string `json:"version" yaml:"version"`
+	// ContentHash is the content hash of the resolved skill.
+	ContentHash string `json:"content_hash,omitempty" yaml:"content_hash,omitempty"`
+}
+
+// Version returns the locked version of a skill.
+func (l *CompileLock) Version(slug string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+	for _, s := range l.Skills {
+		if s.Slug == slug {
+			return s.Version, true
+		}
+	}
+	return "", false
+}
+
+// pinSkills returns the skill references with their versions pinned to the
+// lock. References missing from the lock keep their constraint.
+func (l *CompileLock) pinSkills(skills []SkillRef) []SkillRef {
+	if l == nil || len(l.Skills) == 0 {
+		return skills
+	}
+	pinned := make([]SkillRef, len(skills))
+	for i := range skills {
+		pinned[i] = *skills[i].Clone()
+		if version, ok := l.Version(skills[i].GetSlug()); ok && !skills[i].IsInline() {
+			pinned[i].Slug = skills[i].GetSlug()
+			pinned[i].Version = VersionOpEQ + version
+		}
+	}
+	return pinned
+}
+
+// buildCompileLock resolves the versions of the pinned skill references;
+// skills holds the same references before pinning, for their constraints.
+// Skills without a known version (inline or unversioned) are omitted.
+func buildCompileLock(ctx context.Context, resolver DocumentResolver, skills, pinned []SkillRef) *CompileLock {
+	lock := &CompileLock{Skills: []LockedSkill{}}
+	for i := range pinned {
+		if pinned[i].IsInline() {
+			continue
+		}
+		resolved, version, err := ResolveSkillRef(ctx, resolver, &pinned[i])
+		if err != nil || version == "" {
+			continue
+		}
+		lock.Skills = append(lock.Skills, LockedSkill{
+			Slug:        skills[i].GetSlug(),
+			Constraint:  skills[i].GetVersion(),
+			Version:     version,
+			ContentHash: resolved.Hash(),
+		})
+	}
+	sort.Slice(lock.Skills, func(i, j int) bool {
+		return lock.Skills[i].Slug < lock.Skills[j].Slug
+	})
+	return lock
+}
+
+// ListSkillVersions returns the versions registered with AddSkillVersion
+// or AddSkill (from the skill's metadata version).
+func (r *MapDocumentResolver) ListSkillVersions(_ context.Context, slug string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := make([]string, 0, len(r.skillVersions[slug]))
+	for version := range r.skillVersions[slug] {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
+
+// ResolveSkillVersion looks up one version of a skill.
+func (r *MapDocumentResolver) ResolveSkillVersion(_ context.Context, slug, version string) (*Prompt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if p, ok := r.skillVersions[slug][version]; ok {
+		return p.Clone(), nil
+	}
+	return nil, NewSkillVersionNotFoundError(slug, version)
+}
+
+// AddSkillVersion registers one version of a skill. ResolveSkill without
+// a version returns the highest registered release.
+func (r *MapDocumentResolver) AddSkillVersion(slug, version string, p *Prompt) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addSkillVersionLocked(slug, version, p)
+}
+
+// addSkillVersionLocked registers a skill version; the caller holds the lock.
+func (r *MapDocumentResolver) addSkillVersionLocked(slug, version string, p *Prompt) {
+	versions, ok := r.skillVersions[slug]
+	if !ok {
+		versions = make(map[string]*Prompt)
+		r.skillVersions[slug] = versions
+	}
+	versions[version] = p
+}
+
+// ListSkillVersions returns the versions of a stored skill: each stored
+// version's metadata version, or its storage version number.
+func (r *StorageDocumentResolver) ListSkillVersions(ctx context.Context, slug string) ([]string, error) {
+	byVersion, err := r.skillVersions(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]string, 0, len(byVersion))
+	for version := range byVersion {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
+
+// ResolveSkillVersion resolves one version of a stored skill.
+func (r *StorageDocumentResolver) ResolveSkillVersion(ctx context.Context, slug, version string) (*Prompt, error) {
+	byVersion, err := r.skillVersions(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, ok := byVersion[version]
+	if !ok {
+		return nil, NewSkillVersionNotFoundError(slug, version)
+	}
+	return storedTemplatePrompt(tmpl), nil
+}
+
+// skillVersions maps the version strings of a stored skill to its versions.
+// A missing template has no versions.
+func (r *StorageDocumentResolver) skillVersions(ctx context.Context, slug string) (map[string]*StoredTemplate, error) {
+	numbers, err := r.storage.ListVersions(ctx, slug)
+	if err != nil || len(numbers) == 0 {
+		return nil, nil
+	}
+	byVersion := make(map[string]*StoredTemplate, len(numbers))
+	for _, n := range numbers {
+		tmpl, err := r.storage.GetVersion(ctx, slug, n)
+		if err != nil {
+			return nil, err
+		}
+		version := PromptVersion(tmpl.PromptConfig)
+		if version == "" {
+			version = strconv.Itoa(tmpl.Version)
+		}
+		byVersion[version] = tmpl
+	}
+	return byVersion, nil
+}
+
+// ListSkillVersions returns the versions known to the wrapped resolver.
+func (r *EmbeddingDocumentResolver) ListSkillVersions(ctx context.Context, slug string) ([]string, error) {
+	if versioned, ok := r.DocumentResolver.(VersionedDocumentResolver); ok {
+		return versioned.ListSkillVersions(ctx, slug)
+	}
+	return nil, nil
+}
+
+// ResolveSkillVersion resolves one version with the wrapped resolver.
+func (r *EmbeddingDocumentResolver) ResolveSkillVersion(ctx context.Context, slug, version string) (*Prompt, error) {
+	if versioned, ok := r.DocumentResolver.(VersionedDocumentResolver); ok {
+		return versioned.ResolveSkillVersion(ctx, slug, version)
+	}
+	return nil, NewSkillVersionNotFoundError(slug, version)
+}
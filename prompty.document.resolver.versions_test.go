@@ -0,0 +1,170 @@
+package prompty
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newVersionedSkillResolver() *MapDocumentResolver {
+	resolver := NewMapDocumentResolver()
+	for _, version := range []string{"1.0.0", "2.1.0", "2.4.1", "3.0.0-beta"} {
+		resolver.AddSkillVersion("summarizer", version, &Prompt{
+			Name:        "summarizer",
+			Description: "Summarizer " + version,
+			Body:        "Summarize v" + version,
+			Type:        DocumentTypeSkill,
+		})
+	}
+	return resolver
+}
+
+func TestMapDocumentResolver_SkillVersions(t *testing.T) {
+	ctx := context.Background()
+	resolver := newVersionedSkillResolver()
+
+	versions, err := resolver.ListSkillVersions(ctx, "summarizer")
+	require.NoError(t, err)
+	assert.Len(t, versions, 4)
+
+	tests := []struct {
+		ref         string
+		description string
+	}{
+		{"summarizer", "Summarizer 2.4.1"},
+		{"summarizer@^2.1", "Summarizer 2.4.1"},
+		{"summarizer@>=1.0 <2", "Summarizer 1.0.0"},
+		{"summarizer@v1", "Summarizer 1.0.0"},
+		{"summarizer@3.0.0-beta", "Summarizer 3.0.0-beta"},
+	}
+	for _, tt := range tests {
+		resolved, err := resolver.ResolveSkill(ctx, tt.ref)
+		require.NoError(t, err, tt.ref)
+		assert.Equal(t, tt.description, resolved.Description, tt.ref)
+	}
+
+	_, err = resolver.ResolveSkill(ctx, "summarizer@^4")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgSkillVersionNotFound)
+
+	// Metadata versions register with AddSkill
+	resolver.AddSkill("translator", &Prompt{Name: "translator", Metadata: map[string]any{"version": "1.2.0"}})
+	versions, err = resolver.ListSkillVersions(ctx, "translator")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.2.0"}, versions)
+}
+
+func TestResolveSkillRef(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("uses the explicit version field", func(t *testing.T) {
+		resolved, version, err := ResolveSkillRef(ctx, newVersionedSkillResolver(), &SkillRef{Slug: "summarizer", Version: "~2.1"})
+		require.NoError(t, err)
+		assert.Equal(t, "2.1.0", version)
+		assert.Equal(t, "Summarize v2.1.0", resolved.Body)
+	})
+
+	t.Run("checks metadata versions of unversioned resolvers", func(t *testing.T) {
+		resolver := &NoopDocumentResolver{}
+		_, _, err := ResolveSkillRef(ctx, resolver, &SkillRef{Slug: "missing@^1"})
+		require.Error(t, err)
+
+		storage := NewMemoryStorage()
+		require.NoError(t, storage.Save(ctx, &StoredTemplate{
+			Name:         "translator",
+			Source:       "Translate",
+			PromptConfig: &Prompt{Name: "translator", Description: "Translate", Metadata: map[string]any{"version": "1.4.0"}},
+		}))
+		embedding := NewEmbeddingDocumentResolver(NewStorageDocumentResolver(storage), NewEmbeddingIndexer(newWordEmbedder("translate")))
+
+		_, version, err := ResolveSkillRef(ctx, embedding, &SkillRef{Slug: "translator@^1.2"})
+		require.NoError(t, err)
+		assert.Equal(t, "1.4.0", version)
+
+		_, _, err = ResolveSkillRef(ctx, embedding, &SkillRef{Slug: "translator@^2"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMsgSkillVersionNotFound)
+	})
+
+	t.Run("resolves inline skills", func(t *testing.T) {
+		resolved, version, err := ResolveSkillRef(ctx, nil, &SkillRef{Inline: &InlineSkill{Slug: "inline", Body: "Body"}})
+		require.NoError(t, err)
+		assert.Equal(t, "Body", resolved.Body)
+		assert.Empty(t, version)
+	})
+}
+
+func TestStorageDocumentResolver_SkillVersions(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "writer", Source: "first"}))
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "writer", Source: "second"}))
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "writer", Source: "third"}))
+
+	resolver := NewStorageDocumentResolver(storage)
+	versions, err := resolver.ListSkillVersions(ctx, "writer")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, versions)
+
+	resolved, err := resolver.ResolveSkill(ctx, "writer@v2")
+	require.NoError(t, err)
+	assert.Equal(t, "second", resolved.Body)
+
+	resolved, err = resolver.ResolveSkill(ctx, "writer")
+	require.NoError(t, err)
+	assert.Equal(t, "third", resolved.Body)
+
+	versions, err = resolver.ListSkillVersions(ctx, "missing")
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+}
+
+func TestCompileAgent_Lock(t *testing.T) {
+	doc := `---
+name: lock-agent
+description: Agent with versioned skills
+type: agent
+skills:
+  - slug: summarizer@^2.1
+  - inline:
+      slug: helper
+      body: Help
+---
+{~prompty.skills_catalog /~}`
+
+	agent, err := Parse([]byte(doc))
+	require.NoError(t, err)
+	ctx := context.Background()
+	resolver := newVersionedSkillResolver()
+
+	compiled, err := agent.CompileAgent(ctx, nil, &CompileOptions{Resolver: resolver})
+	require.NoError(t, err)
+	require.NotNil(t, compiled.Lock)
+	skill, err := resolver.ResolveSkillVersion(ctx, "summarizer", "2.4.1")
+	require.NoError(t, err)
+	assert.Equal(t, []LockedSkill{{Slug: "summarizer", Constraint: "^2.1", Version: "2.4.1", ContentHash: skill.Hash()}}, compiled.Lock.Skills)
+	assert.Equal(t, agent.Hash(), compiled.Lock.PromptHash)
+	assert.Contains(t, compiled.Messages[0].Content, "Summarizer 2.4.1")
+
+	data, err := json.Marshal(compiled.Lock)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"prompt_hash":"`+agent.Hash()+`","skills":[{"slug":"summarizer","constraint":"^2.1","version":"2.4.1","content_hash":"`+skill.Hash()+`"}]}`, string(data))
+
+	// A newer matching version appears; the lock keeps the old one
+	resolver.AddSkillVersion("summarizer", "2.5.0", &Prompt{Name: "summarizer", Description: "Summarizer 2.5.0", Body: "Summarize v2.5.0"})
+	locked, err := agent.CompileAgent(ctx, nil, NewCompileOptions(WithResolver(resolver), WithCompileLock(compiled.Lock)))
+	require.NoError(t, err)
+	assert.Equal(t, compiled.Lock, locked.Lock)
+	assert.Contains(t, locked.Messages[0].Content, "Summarizer 2.4.1")
+
+	activated, err := agent.ActivateSkill(ctx, "summarizer", nil, NewCompileOptions(WithResolver(resolver), WithCompileLock(compiled.Lock)))
+	require.NoError(t, err)
+	assert.Contains(t, activated.Messages[0].Content, "Summarize v2.4.1")
+
+	unlocked, err := agent.CompileAgent(ctx, nil, &CompileOptions{Resolver: resolver})
+	require.NoError(t, err)
+	assert.Equal(t, "2.5.0", unlocked.Lock.Skills[0].Version)
+}
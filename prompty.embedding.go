@@ -0,0 +1,199 @@
+package prompty
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Embedder computes embedding vectors for texts.
+// Implement this to plug in an embedding API or a local model.
+type Embedder interface {
+	// Embed returns one vector per text, in order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// FuncEmbedder wraps a function as an embedder.
+type FuncEmbedder struct {
+	fn func(context.Context, []string) ([][]float32, error)
+}
+
+// NewFuncEmbedder creates an embedder from a function.
+func NewFuncEmbedder(fn func(context.Context, []string) ([][]float32, error)) *FuncEmbedder {
+	return &FuncEmbedder{fn: fn}
+}
+
+// Embed calls the wrapped function.
+func (e *FuncEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.fn(ctx, texts)
+}
+
+// EmbeddingMatch is a search result of an EmbeddingIndexer.
+type EmbeddingMatch struct {
+	// Key identifies the indexed document.
+	Key string
+
+	// Score is the cosine similarity to the query, from -1 to 1.
+	Score float64
+}
+
+// embeddingEntry is an indexed document.
+type embeddingEntry struct {
+	text   string
+	vector []float32
+	norm   float64
+}
+
+// EmbeddingIndexer keeps embedding vectors of documents, keyed by a caller
+// chosen key, and ranks them by cosine similarity to a query. Re-indexing a
+// key with unchanged text does not call the embedder again. It is safe for
+// concurrent use.
+type EmbeddingIndexer struct {
+	embedder Embedder
+	mu       sync.RWMutex
+	entries  map[string]*embeddingEntry
+}
+
+// NewEmbeddingIndexer creates an empty index using the given embedder.
+func NewEmbeddingIndexer(embedder Embedder) *EmbeddingIndexer {
+	return &EmbeddingIndexer{
+		embedder: embedder,
+		entries:  make(map[string]*embeddingEntry),
+	}
+}
+
+// Index embeds text and stores it under key, replacing an earlier entry.
+func (x *EmbeddingIndexer) Index(ctx context.Context, key, text string) error {
+	return x.IndexAll(ctx, map[string]string{key: text})
+}
+
+// IndexAll embeds several documents, keyed by key, in one embedder call.
+// Documents whose text is unchanged are skipped.
+func (x *EmbeddingIndexer) IndexAll(ctx context.Context, docs map[string]string) error {
+	x.mu.RLock()
+	keys := make([]string, 0, len(docs))
+	for key, text := range docs {
+		if entry, ok := x.entries[key]; !ok || entry.text != text {
+			keys = append(keys, key)
+		}
+	}
+	x.mu.RUnlock()
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+
+	texts := make([]string, len(keys))
+	for i, key := range keys {
+		texts[i] = docs[key]
+	}
+	vectors, err := x.embed(ctx, texts)
+	if err != nil {
+		return err
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for i, key := range keys {
+		x.entries[key] = &embeddingEntry{text: texts[i], vector: vectors[i], norm: vectorNorm(vectors[i])}
+	}
+	return nil
+}
+
+// Remove removes the document stored under key.
+func (x *EmbeddingIndexer) Remove(key string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	delete(x.entries, key)
+}
+
+// Has reports whether a document is stored under key.
+func (x *EmbeddingIndexer) Has(key string) bool {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	_, ok := x.entries[key]
+	return ok
+}
+
+// Len returns the number of indexed documents.
+func (x *EmbeddingIndexer) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.entries)
+}
+
+// Search returns the k documents most similar to query, best first.
+// If k <= 0, all documents are returned.
+func (x *EmbeddingIndexer) Search(ctx context.Context, query string, k int) ([]EmbeddingMatch, error) {
+	return x.SearchFunc(ctx, query, k, nil)
+}
+
+// SearchFunc is like Search but only considers keys for which keep returns
+// true. A nil keep considers all keys.
+func (x *EmbeddingIndexer) SearchFunc(ctx context.Context, query string, k int, keep func(key string) bool) ([]EmbeddingMatch, error) {
+	vectors, err := x.embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	queryVector := vectors[0]
+	queryNorm := vectorNorm(queryVector)
+
+	x.mu.RLock()
+	matches := make([]EmbeddingMatch, 0, len(x.entries))
+	for key, entry := range x.entries {
+		if keep != nil && !keep(key) {
+			continue
+		}
+		matches = append(matches, EmbeddingMatch{
+			Key:   key,
+			Score: cosineSimilarity(queryVector, queryNorm, entry.vector, entry.norm),
+		})
+	}
+	x.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Key < matches[j].Key
+	})
+	if k > 0 && len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+// embed calls the embedder and checks the number of returned vectors.
+func (x *EmbeddingIndexer) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors, err := x.embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, NewEmbeddingError(ErrMsgEmbeddingFailed, err)
+	}
+	if len(vectors) != len(texts) {
+		return nil, NewEmbeddingError(ErrMsgEmbeddingCountMismatch, nil)
+	}
+	return vectors, nil
+}
+
+// vectorNorm returns the Euclidean norm of v.
+func vectorNorm(v []float32) float64 {
+	var sum float64
+	for _, f := range v {
+		sum += float64(f) * float64(f)
+	}
+	return math.Sqrt(sum)
+}
+
+// cosineSimilarity returns the cosine similarity of a and b given their
+// norms. Vectors of different dimensions or zero norm score 0.
+func cosineSimilarity(a []float32, normA float64, b []float32, normB float64) float64 {
+	if len(a) != len(b) || normA == 0 || normB == 0 {
+		return 0
+	}
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot / (normA * normB)
+}
@@ -0,0 +1,217 @@
+package prompty
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wordEmbedder is a test embedder counting occurrences of a fixed
+// vocabulary, so texts sharing words are similar.
+type wordEmbedder struct {
+	vocabulary []string
+	calls      int
+	texts      int
+}
+
+func newWordEmbedder(vocabulary ...string) *wordEmbedder {
+	return &wordEmbedder{vocabulary: vocabulary}
+}
+
+func (e *wordEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.calls++
+	e.texts += len(texts)
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, len(e.vocabulary))
+		for _, token := range searchTokens(text) {
+			for j, word := range e.vocabulary {
+				if strings.HasPrefix(token, word) {
+					vector[j]++
+				}
+			}
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+func TestEmbeddingIndexer(t *testing.T) {
+	ctx := context.Background()
+	embedder := newWordEmbedder("invoice", "refund", "weather", "forecast")
+	indexer := NewEmbeddingIndexer(embedder)
+
+	require.NoError(t, indexer.IndexAll(ctx, map[string]string{
+		"billing": "Answer invoice and refund questions",
+		"refunds": "Process a refund",
+		"weather": "Weather forecast for a city",
+	}))
+	assert.Equal(t, 3, indexer.Len())
+	assert.Equal(t, 1, embedder.calls)
+
+	t.Run("ranks by similarity", func(t *testing.T) {
+		matches, err := indexer.Search(ctx, "where is my refund", 2)
+		require.NoError(t, err)
+		require.Len(t, matches, 2)
+		assert.Equal(t, "refunds", matches[0].Key)
+		assert.InDelta(t, 1.0, matches[0].Score, 1e-9)
+		assert.Equal(t, "billing", matches[1].Key)
+
+		matches, err = indexer.Search(ctx, "forecast", 0)
+		require.NoError(t, err)
+		require.Len(t, matches, 3)
+		assert.Equal(t, "weather", matches[0].Key)
+		assert.Zero(t, matches[2].Score)
+	})
+
+	t.Run("filters keys", func(t *testing.T) {
+		matches, err := indexer.SearchFunc(ctx, "refund", 0, func(key string) bool { return key != "refunds" })
+		require.NoError(t, err)
+		assert.Equal(t, "billing", matches[0].Key)
+		assert.Len(t, matches, 2)
+	})
+
+	t.Run("skips unchanged texts", func(t *testing.T) {
+		embedder.texts = 0
+		require.NoError(t, indexer.Index(ctx, "refunds", "Process a refund"))
+		assert.Zero(t, embedder.texts)
+		require.NoError(t, indexer.Index(ctx, "refunds", "Process an invoice"))
+		assert.Equal(t, 1, embedder.texts)
+	})
+
+	t.Run("removes documents", func(t *testing.T) {
+		indexer.Remove("weather")
+		assert.False(t, indexer.Has("weather"))
+		assert.Equal(t, 2, indexer.Len())
+	})
+}
+
+func TestEmbeddingIndexer_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	failing := NewEmbeddingIndexer(NewFuncEmbedder(func(ctx context.Context, texts []string) ([][]float32, error) {
+		return nil, errors.New("quota exceeded")
+	}))
+	err := failing.Index(ctx, "a", "text")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgEmbeddingFailed)
+
+	short := NewEmbeddingIndexer(NewFuncEmbedder(func(ctx context.Context, texts []string) ([][]float32, error) {
+		return [][]float32{}, nil
+	}))
+	_, err = short.Search(ctx, "query", 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgEmbeddingCountMismatch)
+}
+
+func TestStorageEngine_SearchSimilar(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("requires an indexer", func(t *testing.T) {
+		se := MustNewStorageEngine(StorageEngineConfig{Storage: NewMemoryStorage()})
+		defer se.Close()
+		_, err := se.SearchSimilar(ctx, "refund", 1)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMsgEmbeddingNoIndexer)
+	})
+
+	t.Run("tracks saves and deletes", func(t *testing.T) {
+		indexer := NewEmbeddingIndexer(newWordEmbedder("invoice", "refund", "weather"))
+		se := MustNewStorageEngine(StorageEngineConfig{Storage: NewMemoryStorage(), EmbeddingIndexer: indexer})
+		defer se.Close()
+
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "billing", Source: "Explain the invoice"}))
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "weather", Source: "Weather report"}))
+
+		results, err := se.SearchSimilar(ctx, "invoice total", 1)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "billing", results[0].Template.Name)
+
+		// A new version replaces the indexed text
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "billing", Source: "Issue a refund"}))
+		results, err = se.SearchSimilar(ctx, "refund", 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, results[0].Template.Version)
+
+		// Deleting the latest version re-indexes the previous one
+		require.NoError(t, se.DeleteVersion(ctx, "billing", 2))
+		results, err = se.SearchSimilar(ctx, "invoice", 1)
+		require.NoError(t, err)
+		assert.InDelta(t, 1.0, results[0].Score, 1e-9)
+
+		require.NoError(t, se.Delete(ctx, "billing"))
+		assert.False(t, indexer.Has("billing"))
+		assert.Equal(t, 1, indexer.Len())
+	})
+
+	t.Run("reindexes existing storage", func(t *testing.T) {
+		storage := NewMemoryStorage()
+		require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "billing", Source: "Explain the invoice"}))
+
+		indexer := NewEmbeddingIndexer(newWordEmbedder("invoice"))
+		se := MustNewStorageEngine(StorageEngineConfig{Storage: storage, EmbeddingIndexer: indexer})
+		defer se.Close()
+
+		require.NoError(t, se.ReindexEmbeddings(ctx))
+		results, err := se.SearchSimilar(ctx, "invoice", 0)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+
+		// Entries deleted behind the engine's back are dropped
+		require.NoError(t, storage.Delete(ctx, "billing"))
+		results, err = se.SearchSimilar(ctx, "invoice", 0)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+		assert.Zero(t, indexer.Len())
+	})
+}
+
+func TestCompileAgent_RelevantSkillsCatalog(t *testing.T) {
+	doc := `---
+name: support-agent
+description: Customer support agent
+type: agent
+skills:
+  - slug: billing
+  - slug: weather
+  - inline:
+      slug: refunds
+      description: Issue a refund for an order
+      body: Refund steps
+---
+{~prompty.skills_catalog selection="relevant" limit="1" /~}`
+
+	agent, err := Parse([]byte(doc))
+	require.NoError(t, err)
+
+	base := NewMapDocumentResolver()
+	base.AddSkill("billing", &Prompt{Name: "billing", Description: "Explain an invoice", Type: DocumentTypeSkill})
+	base.AddSkill("weather", &Prompt{Name: "weather", Description: "Weather forecast", Type: DocumentTypeSkill})
+	resolver := NewEmbeddingDocumentResolver(base, NewEmbeddingIndexer(newWordEmbedder("invoice", "weather", "refund")))
+
+	compile := func(input map[string]any) string {
+		compiled, err := agent.CompileAgent(context.Background(), input, &CompileOptions{Resolver: resolver})
+		require.NoError(t, err)
+		return compiled.Messages[0].Content
+	}
+
+	content := compile(map[string]any{"query": "I need a refund"})
+	assert.Contains(t, content, "refunds")
+	assert.NotContains(t, content, "billing")
+	assert.NotContains(t, content, "weather")
+
+	content = compile(map[string]any{"query": "what is the weather"})
+	assert.Contains(t, content, "weather")
+	assert.NotContains(t, content, "refunds")
+
+	// Without a query the full catalog is rendered
+	content = compile(nil)
+	assert.Contains(t, content, "billing")
+	assert.Contains(t, content, "weather")
+	assert.Contains(t, content, "refunds")
+}
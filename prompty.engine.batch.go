@@ -0,0 +1,140 @@
+package prompty
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// BatchOptions configures ExecuteBatch.
+type BatchOptions struct {
+	// Concurrency is the number of items rendered at once.
+	// Default: runtime.GOMAXPROCS(0).
+	Concurrency int
+
+	// FailFast stops the batch at the first failed item. Items not yet
+	// rendered then fail with ErrMsgBatchItemSkipped, and ExecuteBatch
+	// returns the first item error. Without FailFast, every item is
+	// rendered and failures are only reported per item.
+	FailFast bool
+}
+
+// BatchResult is the outcome of rendering one item of a batch.
+type BatchResult struct {
+	// Output is the rendered template; empty when Err is set, except for
+	// the best-effort output under ErrorStrategyCollect.
+	Output string
+
+	// Err is the item's execution error.
+	Err error
+}
+
+// ExecuteBatch parses source once and renders it with each data item on a
+// pool of workers, so callers rendering a template for many records do not
+// need their own goroutine pool. Results are in the order of items. The
+// error is non-nil when source does not parse, when ctx is done before
+// every item was started (results then hold the items rendered so far, and
+// ctx's error for the others) or, with FailFast, for the first failed item.
+func (e *Engine) ExecuteBatch(ctx context.Context, source string, items []map[string]any, opts BatchOptions) ([]BatchResult, error) {
+	tmpl, err := e.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.ExecuteBatch(ctx, items, opts)
+}
+
+// ExecuteBatch renders the template with each data item on a pool of
+// workers, as Engine.ExecuteBatch does.
+func (t *Template) ExecuteBatch(ctx context.Context, items []map[string]any, opts BatchOptions) ([]BatchResult, error) {
+	return runBatch(ctx, len(items), opts, func(ctx context.Context, i int) (string, error) {
+		return t.Execute(ctx, items[i])
+	})
+}
+
+// runBatch calls render for the indexes [0, n) on opts.Concurrency workers
+// and collects the results.
+func runBatch(ctx context.Context, n int, opts BatchOptions, render func(ctx context.Context, i int) (string, error)) ([]BatchResult, error) {
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, n)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]BatchResult, n)
+	unstarted := make([]bool, n) // Items never rendered because ctx was done
+	var (
+		firstErr error
+		errOnce  sync.Once
+	)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// The feed may hand out an item after cancellation, since
+				// select picks randomly among ready cases
+				if ctx.Err() != nil {
+					unstarted[i] = true
+					continue
+				}
+				output, err := render(ctx, i)
+				results[i] = BatchResult{Output: output, Err: err}
+				if err != nil && opts.FailFast {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	next := 0
+feed:
+	for ; next < n; next++ {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case jobs <- next:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	for i := next; i < n; i++ {
+		unstarted[i] = true
+	}
+
+	if firstErr != nil {
+		for i := range unstarted {
+			if unstarted[i] {
+				results[i].Err = NewBatchItemSkippedError(i)
+			}
+		}
+		return results, firstErr
+	}
+	// A context cancelled after the last item was started is not a batch
+	// error; the items report their own errors
+	err := ctx.Err()
+	if err == nil {
+		return results, nil
+	}
+	skipped := false
+	for i := range unstarted {
+		if unstarted[i] {
+			results[i].Err = err
+			skipped = true
+		}
+	}
+	if !skipped {
+		return results, nil
+	}
+	return results, err
+}
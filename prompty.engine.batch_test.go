@@ -0,0 +1,110 @@
+package prompty
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_ExecuteBatch(t *testing.T) {
+	ctx := context.Background()
+	engine := MustNew()
+
+	items := make([]map[string]any, 100)
+	for i := range items {
+		items[i] = map[string]any{"name": fmt.Sprintf("user%d", i)}
+	}
+	results, err := engine.ExecuteBatch(ctx, `Dear {~prompty.var name="name" /~}`, items, BatchOptions{Concurrency: 4})
+	require.NoError(t, err)
+	require.Len(t, results, len(items))
+	for i, result := range results {
+		require.NoError(t, result.Err)
+		assert.Equal(t, fmt.Sprintf("Dear user%d", i), result.Output)
+	}
+
+	results, err = engine.ExecuteBatch(ctx, `x`, nil, BatchOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	_, err = engine.ExecuteBatch(ctx, `{~prompty.if~}`, items, BatchOptions{})
+	assert.Error(t, err)
+}
+
+func TestEngine_ExecuteBatch_Errors(t *testing.T) {
+	ctx := context.Background()
+	tmpl, err := MustNew().Parse(`Dear {~prompty.var name="name" /~}`)
+	require.NoError(t, err)
+	items := []map[string]any{{"name": "a"}, {}, {"name": "c"}, {"name": "d"}}
+
+	t.Run("reports item errors", func(t *testing.T) {
+		results, err := tmpl.ExecuteBatch(ctx, items, BatchOptions{Concurrency: 2})
+		require.NoError(t, err)
+		assert.Equal(t, "Dear a", results[0].Output)
+		assert.Error(t, results[1].Err)
+		assert.Empty(t, results[1].Output)
+		assert.Equal(t, "Dear c", results[2].Output)
+		assert.Equal(t, "Dear d", results[3].Output)
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		results, err := tmpl.ExecuteBatch(ctx, items, BatchOptions{Concurrency: 1, FailFast: true})
+		require.Error(t, err)
+		assert.Equal(t, results[1].Err, err)
+		assert.Equal(t, "Dear a", results[0].Output)
+		for _, result := range results[2:] {
+			assert.ErrorContains(t, result.Err, ErrMsgBatchItemSkipped)
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		results, err := tmpl.ExecuteBatch(cancelled, items, BatchOptions{})
+		assert.ErrorIs(t, err, context.Canceled)
+		require.Len(t, results, len(items))
+		for _, result := range results {
+			assert.Error(t, result.Err)
+		}
+	})
+	t.Run("fail fast never renders after cancellation", func(t *testing.T) {
+		render := func(ctx context.Context, i int) (string, error) {
+			if i == 0 {
+				return "", assert.AnError
+			}
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			return "ok", nil
+		}
+		// Items rendered before the failure succeed; every other item is
+		// skipped rather than rendered with the cancelled context
+		for run := 0; run < 100; run++ {
+			results, err := runBatch(ctx, 16, BatchOptions{Concurrency: 4, FailFast: true}, render)
+			require.ErrorIs(t, err, assert.AnError)
+			for _, result := range results[1:] {
+				if result.Err != nil {
+					require.ErrorContains(t, result.Err, ErrMsgBatchItemSkipped)
+				}
+			}
+		}
+	})
+
+	t.Run("context cancelled after the last item started", func(t *testing.T) {
+		parent, cancel := context.WithCancel(ctx)
+		defer cancel()
+		results, err := runBatch(parent, 3, BatchOptions{Concurrency: 1}, func(ctx context.Context, i int) (string, error) {
+			if i == 2 {
+				cancel()
+			}
+			return "ok", nil
+		})
+		require.NoError(t, err)
+		for _, result := range results {
+			assert.NoError(t, result.Err)
+			assert.Equal(t, "ok", result.Output)
+		}
+	})
+}
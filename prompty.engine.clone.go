@@ -0,0 +1,101 @@
+package prompty
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+	"go.uber.org/zap"
+)
+
+// Clone returns an independent copy of the engine. The copy starts with the
+// engine's resolvers, functions, templates, middleware, hooks and options;
+// registrations on either engine afterwards do not affect the other. Clone
+// is cheap: the registries are shared copy-on-write, so nothing is copied
+// until one of the engines registers something, and parse and tag caches
+// are shared.
+//
+// Cloning a snapshot returns a writable engine.
+func (e *Engine) Clone() *Engine {
+	return e.clone(e.cloneConfig())
+}
+
+// WithOverrides returns a clone of the engine (see Clone) with opts applied
+// on top of the engine's options, e.g. a different error strategy or output
+// mode for one request:
+//
+//	reqEngine, err := engine.WithOverrides(prompty.WithErrorStrategy(prompty.ErrorStrategyDefault))
+//	reqEngine.MustRegisterFunc(tenantFunc)
+//
+// Registered templates run with the overridden options when executed
+// through the clone. Options that only take effect while parsing, such as
+// WithDelimiters, apply to templates parsed by the clone. WithOverrides
+// returns the errors New returns for the same options.
+func (e *Engine) WithOverrides(opts ...Option) (*Engine, error) {
+	config := e.cloneConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+	if err := config.validateDelimiters(); err != nil {
+		return nil, err
+	}
+	if err := config.validateIncludeAllowlist(); err != nil {
+		return nil, err
+	}
+
+	clone := e.clone(config)
+	clone.executor.SetConfig(internal.ExecutorConfig{MaxDepth: config.maxDepth})
+	clone.executor.SetLogger(clone.logger)
+	if err := configureExecutor(clone.executor, config); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+// cloneConfig returns a copy of the engine's options that options can
+// modify without affecting the engine.
+func (e *Engine) cloneConfig() *engineConfig {
+	config := *e.config
+	config.hooks = e.config.hooks.Clone()
+	config.includeAllow = slices.Clip(e.config.includeAllow)
+	config.escapers = maps.Clone(e.config.escapers)
+	return &config
+}
+
+// clone returns a writable copy of the engine using config.
+func (e *Engine) clone(config *engineConfig) *Engine {
+	logger := config.logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	// Take the middleware and the resolvers it wraps together
+	e.mwMu.Lock()
+	middleware := slices.Clone(e.middleware)
+	registry := e.registry.Clone()
+	e.mwMu.Unlock()
+
+	clone := &Engine{
+		registry:   registry,
+		config:     config,
+		executor:   e.executor.Clone(registry),
+		logger:     logger,
+		middleware: middleware,
+	}
+	clone.executor.SetResolveHook(&resolveHookAdapter{hooks: config.hooks})
+	clone.templates.Store(e.publishedTemplates())
+	return clone
+}
+
+// boundTo returns t, or a copy of it executing with e when t was registered
+// with another engine that e was cloned or snapshotted from.
+func (t *Template) boundTo(e *Engine) *Template {
+	if engine, ok := t.engine.(*Engine); !ok || engine == e {
+		return t
+	}
+	bound := *t
+	bound.executor = e.executor
+	bound.config = e.config
+	bound.engine = e
+	return &bound
+}
@@ -0,0 +1,103 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_Clone(t *testing.T) {
+	ctx := context.Background()
+	engine := MustNew()
+	engine.MustRegister(staticResolver("Greeting", "hello"))
+	engine.MustRegisterTemplate("page", `{~Greeting /~} {~Weather onerror="default" default="?" /~}`)
+
+	clone := engine.Clone()
+	assert.False(t, clone.IsSnapshot())
+	assert.True(t, clone.HasResolver("Greeting"))
+	assert.Equal(t, engine.FuncCount(), clone.FuncCount())
+
+	// Registrations on either engine stay with it
+	clone.MustRegister(staticResolver("Weather", "sunny"))
+	clone.MustRegisterFunc(&Func{Name: "tenant", Fn: func(args []any) (any, error) { return "acme", nil }})
+	clone.MustRegisterTemplate("footer", `bye`)
+	engine.MustRegisterTemplate("header", `hi`)
+
+	assert.False(t, engine.HasResolver("Weather"))
+	assert.False(t, engine.HasFunc("tenant"))
+	assert.False(t, engine.HasTemplate("footer"))
+	assert.False(t, clone.HasTemplate("header"))
+
+	// Templates registered before cloning resolve with the clone's resolvers
+	output, err := clone.ExecuteTemplate(ctx, "page", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "hello sunny", output)
+	output, err = engine.ExecuteTemplate(ctx, "page", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "hello ?", output)
+
+	// Hooks and middleware added to the clone do not reach the engine
+	calls := 0
+	clone.RegisterHook(HookBeforeExecute, func(ctx context.Context, point HookPoint, data *HookData) error {
+		calls++
+		return nil
+	})
+	require.NoError(t, clone.UseResolverMiddleware(func(next Resolver) Resolver {
+		return NewResolverFunc(next.TagName(), func(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+			result, err := next.Resolve(ctx, execCtx, attrs)
+			return "<" + result + ">", err
+		}, next.Validate)
+	}))
+	output, err = engine.ExecuteTemplate(ctx, "page", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "hello ?", output)
+	assert.Equal(t, 0, calls)
+	output, err = clone.ExecuteTemplate(ctx, "page", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "<hello> <sunny>", output)
+	assert.Equal(t, 1, calls)
+
+	// A clone of a snapshot is writable
+	writable := engine.Snapshot().Clone()
+	require.NoError(t, writable.Register(staticResolver("Weather", "rain")))
+}
+
+func TestEngine_WithOverrides(t *testing.T) {
+	ctx := context.Background()
+	engine := MustNew()
+	engine.MustRegisterTemplate("greeting", `Hi {~prompty.var name="name" /~}`)
+	engine.MustRegisterTemplate("page", `{~prompty.include template="greeting" with="name=name" /~}!`)
+
+	_, err := engine.ExecuteTemplate(ctx, "greeting", nil)
+	require.Error(t, err)
+
+	lenient, err := engine.WithOverrides(WithErrorStrategy(ErrorStrategyRemove), WithOutputMode(OutputHTML))
+	require.NoError(t, err)
+	output, err := lenient.ExecuteTemplate(ctx, "greeting", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Hi ", output)
+	output, err = lenient.ExecuteTemplate(ctx, "page", map[string]any{"name": "<b>"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hi &lt;b&gt;!", output)
+
+	// The engine keeps its options
+	output, err = engine.ExecuteTemplate(ctx, "page", map[string]any{"name": "<b>"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hi <b>!", output)
+
+	// Parsing options apply to templates parsed by the override
+	brackets, err := engine.WithOverrides(WithDelimiters("[[", "]]"))
+	require.NoError(t, err)
+	output, err = brackets.Execute(ctx, `[[prompty.include template="greeting" with="name=name" /]] {~x~}`, map[string]any{"name": "Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hi Ada {~x~}", output)
+
+	_, err = engine.WithOverrides(WithDelimiters("@@", "@@"))
+	assert.ErrorContains(t, err, ErrMsgDelimitersIdentical)
+	_, err = engine.WithOverrides(WithOutputMode("yaml"))
+	assert.Error(t, err)
+	_, err = engine.WithOverrides(WithDynamicIncludeAllowlist("["))
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package prompty
+
+import (
+	"context"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// ErrorEvent describes a failed tag reported to an ErrorCallback.
+type ErrorEvent struct {
+	// TagName is the name of the failed tag.
+	TagName string
+	// Position is the location of the tag in its template.
+	Position Position
+	// Strategy is the error strategy applied to the failure.
+	Strategy ErrorStrategy
+	// FallbackTemplate is the template rendered in place of the tag under
+	// ErrorStrategyInclude.
+	FallbackTemplate string
+	// Err is the failure.
+	Err error
+}
+
+// ErrorCallback is called for every failed tag, including included
+// templates' tags and failures that the error strategy recovers from, so
+// monitoring can see renders that degraded silently. It runs synchronously
+// on the executing goroutine and may be called concurrently by concurrent
+// executions.
+type ErrorCallback func(ctx context.Context, event ErrorEvent)
+
+// WithErrorCallback sets a callback called for every failed tag with the
+// error strategy applied to it.
+//
+//	engine := prompty.MustNew(
+//		prompty.WithErrorStrategy(prompty.ErrorStrategyDefault),
+//		prompty.WithErrorCallback(func(ctx context.Context, event prompty.ErrorEvent) {
+//			metrics.Inc("template_tag_errors", event.TagName, event.Strategy.String())
+//		}),
+//	)
+func WithErrorCallback(fn ErrorCallback) Option {
+	return func(c *engineConfig) {
+		c.errorCallback = fn
+	}
+}
+
+// errorCallbackAdapter reports the tag failures of an executor (see
+// internal.TagErrorHook) to an ErrorCallback.
+type errorCallbackAdapter struct {
+	callback ErrorCallback
+}
+
+func (a *errorCallbackAdapter) OnTagError(ctx context.Context, event internal.TagErrorEvent) {
+	a.callback(ctx, ErrorEvent{
+		TagName:          event.TagName,
+		Position:         publicPosition(event.Pos),
+		Strategy:         ErrorStrategy(event.Strategy),
+		FallbackTemplate: event.Fallback,
+		Err:              event.Err,
+	})
+}
+
+// tagErrorHook returns the executor hook reporting to the configured error
+// callback, or nil without one.
+func (c *engineConfig) tagErrorHook() internal.TagErrorHook {
+	if c.errorCallback == nil {
+		return nil
+	}
+	return &errorCallbackAdapter{callback: c.errorCallback}
+}
@@ -0,0 +1,122 @@
+package prompty
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// errorEventRecorder is an ErrorCallback recording its events.
+type errorEventRecorder struct {
+	mu     sync.Mutex
+	events []ErrorEvent
+}
+
+func (r *errorEventRecorder) record(_ context.Context, event ErrorEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func TestErrorStrategyInclude(t *testing.T) {
+	ctx := context.Background()
+	engine := MustNew()
+	require.NoError(t, engine.RegisterTemplate("unavailable",
+		`[{~prompty.var name="tag" /~} unavailable]`))
+
+	t.Run("renders the fallback template", func(t *testing.T) {
+		output, err := engine.Execute(ctx,
+			`Profile: {~prompty.var name="user.bio" onerror="include:unavailable" /~}`, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "Profile: [prompty.var unavailable]", output)
+	})
+
+	t.Run("fallback receives the error", func(t *testing.T) {
+		require.NoError(t, engine.RegisterTemplate("reason", `{~prompty.var name="error" /~}`))
+		output, err := engine.Execute(ctx, `{~prompty.var name="x" onerror="include:reason" /~}`, nil)
+		require.NoError(t, err)
+		assert.Contains(t, output, "variable not found")
+	})
+
+	t.Run("missing fallback returns both errors", func(t *testing.T) {
+		_, err := engine.Execute(ctx, `{~prompty.var name="x" onerror="include:missing" /~}`, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error fallback template failed")
+		assert.ErrorIs(t, err, ErrTemplateNotFound)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		assert.True(t, IsValidErrorStrategy("include:unavailable"))
+		assert.False(t, IsValidErrorStrategy(ErrorStrategyIncludePrefix))
+		assert.False(t, IsValidErrorStrategy(ErrorStrategyNameInclude))
+		assert.Equal(t, ErrorStrategyInclude, ParseErrorStrategy("include:unavailable"))
+
+		result, err := engine.Validate(`{~prompty.var name="a" onerror="include:unavailable" /~}`)
+		require.NoError(t, err)
+		assert.Empty(t, result.Issues())
+
+		result, err = engine.Validate(`{~prompty.var name="a" onerror="include:missing" /~}`)
+		require.NoError(t, err)
+		require.Len(t, result.Warnings(), 1)
+		assert.Equal(t, ErrMsgMissingFallback, result.Warnings()[0].Message)
+	})
+}
+
+func TestWithErrorCallback(t *testing.T) {
+	ctx := context.Background()
+	recorder := &errorEventRecorder{}
+	engine := MustNew(
+		WithErrorStrategy(ErrorStrategyRemove),
+		WithErrorCallback(recorder.record),
+	)
+	require.NoError(t, engine.RegisterTemplate("footer", `{~prompty.var name="company" /~}`))
+	require.NoError(t, engine.RegisterTemplate("unavailable", `n/a`))
+
+	output, err := engine.Execute(ctx,
+		"{~prompty.var name=\"name\" /~}\n"+
+			`{~prompty.var name="plan" onerror="include:unavailable" /~} {~prompty.include template="footer" /~}`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "\nn/a ", output)
+
+	require.Len(t, recorder.events, 3)
+
+	first := recorder.events[0]
+	assert.Equal(t, TagNameVar, first.TagName)
+	assert.Equal(t, 1, first.Position.Line)
+	assert.Equal(t, 1, first.Position.Column)
+	assert.Equal(t, ErrorStrategyRemove, first.Strategy)
+	assert.Empty(t, first.FallbackTemplate)
+	assert.ErrorIs(t, first.Err, ErrNotFound)
+
+	second := recorder.events[1]
+	assert.Equal(t, 2, second.Position.Line)
+	assert.Equal(t, ErrorStrategyInclude, second.Strategy)
+	assert.Equal(t, "unavailable", second.FallbackTemplate)
+
+	// The included template's tag is reported too
+	assert.Equal(t, TagNameVar, recorder.events[2].TagName)
+	assert.Equal(t, ErrorStrategyRemove, recorder.events[2].Strategy)
+
+	t.Run("reported when throwing", func(t *testing.T) {
+		recorder := &errorEventRecorder{}
+		engine := MustNew(WithErrorCallback(recorder.record))
+		_, err := engine.Execute(ctx, `{~prompty.var name="x" /~}`, nil)
+		require.Error(t, err)
+		require.Len(t, recorder.events, 1)
+		assert.Equal(t, ErrorStrategyThrow, recorder.events[0].Strategy)
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		recorder := &errorEventRecorder{}
+		reqEngine, err := MustNew(WithErrorStrategy(ErrorStrategyRemove)).WithOverrides(WithErrorCallback(recorder.record))
+		require.NoError(t, err)
+		_, err = reqEngine.Execute(ctx, `{~prompty.var name="x" /~}`, nil)
+		require.NoError(t, err)
+		require.Len(t, recorder.events, 1)
+		assert.Equal(t, ErrorStrategyRemove, recorder.events[0].Strategy)
+	})
+}
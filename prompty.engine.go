@@ -2,9 +2,13 @@ package prompty
 
 import (
 	"context"
+	"maps"
+	"path"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/itsatony/go-prompty/v2/internal"
 	"go.uber.org/zap"
@@ -12,13 +16,24 @@ import (
 
 // Engine is the main entry point for the prompty templating system.
 // It manages parsing, execution, resolver registration, and template storage.
+//
+// Registering resolvers, functions and templates is safe while templates are
+// executing: the registries are copy-on-write, so an execution sees each
+// registration either completely or not at all. Use Snapshot for a view
+// that does not change at all.
 type Engine struct {
 	registry  *internal.Registry
-	templates map[string]*Template // Named templates for inclusion
-	tmplMu    sync.RWMutex         // Protects templates map
+	templates atomic.Pointer[map[string]*Template] // Named templates for inclusion, copy-on-write
+	tmplMu    sync.Mutex                           // Serializes template registration
+	tmplDraft map[string]*Template                 // Registrations not yet published; guarded by tmplMu
+	tmplDirty atomic.Bool                          // tmplDraft must be published before reading
 	config    *engineConfig
 	executor  *internal.Executor
 	logger    *zap.Logger
+	snapshot  bool // Read-only view created by Snapshot
+
+	middleware []ResolverMiddleware // Resolver middleware, outermost first
+	mwMu       sync.Mutex           // Protects middleware
 }
 
 // New creates a new prompty Engine with the given options.
@@ -27,6 +42,12 @@ func New(opts ...Option) (*Engine, error) {
 	for _, opt := range opts {
 		opt(config)
 	}
+	if err := config.validateDelimiters(); err != nil {
+		return nil, err
+	}
+	if err := config.validateIncludeAllowlist(); err != nil {
+		return nil, err
+	}
 
 	logger := config.logger
 	if logger == nil {
@@ -35,19 +56,45 @@ func New(opts ...Option) (*Engine, error) {
 
 	registry := internal.NewRegistry(logger)
 	internal.RegisterBuiltins(registry)
+	for _, tagName := range config.withoutTags {
+		registry.Unregister(tagName)
+	}
 
 	executorConfig := internal.ExecutorConfig{
 		MaxDepth: config.maxDepth,
 	}
 	executor := internal.NewExecutor(registry, executorConfig, logger)
+	if err := configureExecutor(executor, config); err != nil {
+		return nil, err
+	}
+
+	engine := &Engine{
+		registry: registry,
+		config:   config,
+		executor: executor,
+		logger:   logger,
+	}
+	engine.storeTemplates(make(map[string]*Template))
+	return engine, nil
+}
 
-	return &Engine{
-		registry:  registry,
-		templates: make(map[string]*Template),
-		config:    config,
-		executor:  executor,
-		logger:    logger,
-	}, nil
+// configureExecutor applies the options that affect execution to executor.
+func configureExecutor(executor *internal.Executor, config *engineConfig) error {
+	executor.SetResolveHook(&resolveHookAdapter{hooks: config.hooks})
+	executor.SetTagErrorHook(config.tagErrorHook())
+	executor.SetTagCache(config.tagCache)
+	executor.SetClock(config.clock)
+	if config.seed != nil {
+		executor.SetSeed(uint64(*config.seed))
+	}
+	for mode, escaper := range config.escapers {
+		executor.RegisterEscaper(string(mode), escaper)
+	}
+	if config.outputMode != "" && !executor.HasEscaper(string(config.outputMode)) {
+		return NewOutputModeError(config.outputMode)
+	}
+	executor.SetOutputMode(string(config.outputMode))
+	return nil
 }
 
 // MustNew creates a new Engine and panics if there's an error.
@@ -65,12 +112,31 @@ func MustNew(opts ...Option) *Engine {
 // If the source contains YAML frontmatter (delimited by --- on separate lines),
 // it is extracted and parsed as a v2.1 Prompt configuration. The frontmatter must appear
 // at the start of the source (after optional whitespace/BOM).
+//
+// Parse hooks registered with RegisterHook run around parsing.
 func (e *Engine) Parse(source string) (*Template, error) {
-	// Create lexer config
-	lexerConfig := internal.LexerConfig{
-		OpenDelim:  e.config.openDelim,
-		CloseDelim: e.config.closeDelim,
+	return e.parseWithHooks("", source)
+}
+
+// parse parses a template source without running hooks, applying the
+// strict mode checks if enabled.
+func (e *Engine) parse(source string) (*Template, error) {
+	tmpl, err := e.parseSource(source)
+	if err != nil {
+		return nil, err
+	}
+	if e.config.strict {
+		if issues := e.strictIssues(tmpl); len(issues) > 0 {
+			return nil, NewStrictModeError(issues)
+		}
 	}
+	return tmpl, nil
+}
+
+// parseSource parses a template source into a Template.
+func (e *Engine) parseSource(source string) (*Template, error) {
+	// Create lexer config
+	lexerConfig := e.config.lexerConfig()
 
 	// Extract config block if present
 	configResult, err := internal.ExtractConfigBlock(source, lexerConfig)
@@ -88,31 +154,64 @@ func (e *Engine) Parse(source string) (*Template, error) {
 
 	// Parse frontmatter if present
 	var prompt *Prompt
-
-	if configResult.HasFrontmatter && configResult.FrontmatterYAML != "" {
-		// Resolve environment variables in YAML before parsing
-		resolvedYAML, err := e.resolveConfigEnvVars(configResult.FrontmatterYAML)
+	if configResult.HasFrontmatter {
+		prompt, err = e.parseFrontmatter(configResult.FrontmatterYAML)
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		// Parse as Prompt
-		prompt, err = ParseYAMLPrompt(resolvedYAML)
-		if err != nil {
+	// Use template body (without config block) for parsing
+	templateBody := configResult.TemplateBody
+
+	ast, err := e.parseBody(templateBody, lexerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTemplateWithConfig(source, templateBody, ast, e.executor, e.config, e, prompt), nil
+}
+
+// parseFrontmatter resolves environment variables in frontmatter YAML and
+// parses it as a Prompt. Empty YAML yields a nil Prompt.
+func (e *Engine) parseFrontmatter(frontmatterYAML string) (*Prompt, error) {
+	if frontmatterYAML == "" {
+		return nil, nil
+	}
+
+	// Resolve environment variables in YAML before parsing
+	resolvedYAML, err := e.resolveConfigEnvVars(frontmatterYAML)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse as Prompt
+	prompt, err := ParseYAMLPrompt(resolvedYAML)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate if we have a prompt with required fields
+	if prompt != nil {
+		if err := prompt.ValidateOptional(); err != nil {
 			return nil, err
 		}
+	}
+	return prompt.ForEnvironment(e.config.environment), nil
+}
 
-		// Validate if we have a prompt with required fields
-		if prompt != nil {
-			if err := prompt.ValidateOptional(); err != nil {
-				return nil, err
-			}
+// parseBody tokenizes and parses a template body, using the AST cache when
+// one is configured. Parse errors are not cached.
+func (e *Engine) parseBody(templateBody string, lexerConfig internal.LexerConfig) (*internal.RootNode, error) {
+	cache := e.config.astCache
+	var key string
+	if cache != nil {
+		key = astCacheKey(lexerConfig, templateBody)
+		if ast, ok := cache.get(key); ok {
+			return ast, nil
 		}
 	}
 
-	// Use template body (without config block) for parsing
-	templateBody := configResult.TemplateBody
-
 	// Create lexer with configured delimiters
 	lexer := internal.NewLexerWithConfig(templateBody, lexerConfig, e.logger)
 
@@ -123,13 +222,16 @@ func (e *Engine) Parse(source string) (*Template, error) {
 	}
 
 	// Parse with source for raw text extraction (keepRaw strategy)
-	parser := internal.NewParserWithSource(tokens, templateBody, e.logger)
+	parser := internal.NewParserWithConfig(tokens, templateBody, lexerConfig, e.logger)
 	ast, err := parser.Parse()
 	if err != nil {
 		return nil, NewParseError(ErrMsgParseFailed, Position{}, err)
 	}
 
-	return newTemplateWithConfig(source, templateBody, ast, e.executor, e.config, e, prompt), nil
+	if cache != nil {
+		cache.set(key, templateBody, ast)
+	}
+	return ast, nil
 }
 
 // resolveConfigEnvVars resolves {~prompty.env~} tags in the YAML frontmatter.
@@ -176,6 +278,9 @@ func (e *Engine) Execute(ctx context.Context, source string, data map[string]any
 // Register adds a custom resolver to the engine.
 // Returns an error if a resolver for the same tag name is already registered.
 func (e *Engine) Register(r Resolver) error {
+	if e.snapshot {
+		return NewSnapshotReadOnlyError()
+	}
 	adapter := &resolverAdapter{resolver: r}
 	return e.registry.Register(adapter)
 }
@@ -211,33 +316,84 @@ func (e *Engine) ResolverCount() int {
 // RegisterTemplate registers a named template for later inclusion via prompty.include.
 // Template names cannot be empty or use the reserved "prompty." namespace prefix.
 // Returns an error if a template with the same name already exists.
+//
+// The map of registered templates is copied on write, but consecutive
+// registrations share one copy until the templates are next read, so
+// registering n templates in a loop, e.g. at startup, costs O(n) rather
+// than O(n²).
 func (e *Engine) RegisterTemplate(name string, source string) error {
-	// Validate template name
-	if name == "" {
-		return NewEmptyTemplateNameError()
-	}
-	if strings.HasPrefix(name, ReservedNamespacePrefix) {
-		return NewReservedTemplateNameError(name)
+	tmpl, err := e.prepareTemplate(name, source)
+	if err != nil {
+		return err
 	}
 
 	// Check for existing template
 	e.tmplMu.Lock()
 	defer e.tmplMu.Unlock()
 
-	if _, exists := e.templates[name]; exists {
+	if _, exists := e.currentTemplates()[name]; exists {
 		return NewTemplateExistsError(name)
 	}
+	e.draftTemplates()[name] = tmpl
+	return nil
+}
 
-	// Parse the template
-	tmpl, err := e.Parse(source)
-	if err != nil {
-		return err
+// RegisterTemplates registers named templates, given as name to source,
+// like RegisterTemplate. The registration is atomic: if any template is
+// invalid, fails to parse or is already registered, none is registered and
+// the error of the first such name in sorted order is returned.
+func (e *Engine) RegisterTemplates(sources map[string]string) error {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	e.templates[name] = tmpl
+	parsed := make(map[string]*Template, len(names))
+	for _, name := range names {
+		tmpl, err := e.prepareTemplate(name, sources[name])
+		if err != nil {
+			return err
+		}
+		parsed[name] = tmpl
+	}
+
+	e.tmplMu.Lock()
+	defer e.tmplMu.Unlock()
+
+	current := e.currentTemplates()
+	for _, name := range names {
+		if _, exists := current[name]; exists {
+			return NewTemplateExistsError(name)
+		}
+	}
+	maps.Copy(e.draftTemplates(), parsed)
 	return nil
 }
 
+// prepareTemplate validates a template name and parses the template for
+// registration.
+func (e *Engine) prepareTemplate(name string, source string) (*Template, error) {
+	// Validate template name
+	if name == "" {
+		return nil, NewEmptyTemplateNameError()
+	}
+	if strings.HasPrefix(name, ReservedNamespacePrefix) {
+		return nil, NewReservedTemplateNameError(name)
+	}
+	if e.snapshot {
+		return nil, NewSnapshotReadOnlyError()
+	}
+
+	// Parse the template before locking, since parse hooks may use the engine
+	tmpl, err := e.parseWithHooks(name, source)
+	if err != nil {
+		return nil, err
+	}
+	tmpl.name = name
+	return tmpl, nil
+}
+
 // MustRegisterTemplate registers a template and panics on error.
 func (e *Engine) MustRegisterTemplate(name string, source string) {
 	if err := e.RegisterTemplate(name, source); err != nil {
@@ -247,35 +403,83 @@ func (e *Engine) MustRegisterTemplate(name string, source string) {
 
 // UnregisterTemplate removes a registered template by name.
 // Returns true if the template existed and was removed, false otherwise.
+// On a snapshot it removes nothing and always returns false, even for
+// templates the snapshot holds; use IsSnapshot to tell the cases apart.
 func (e *Engine) UnregisterTemplate(name string) bool {
+	if e.snapshot {
+		return false
+	}
+
 	e.tmplMu.Lock()
 	defer e.tmplMu.Unlock()
 
-	if _, exists := e.templates[name]; exists {
-		delete(e.templates, name)
-		return true
+	if _, exists := e.currentTemplates()[name]; !exists {
+		return false
 	}
-	return false
+	delete(e.draftTemplates(), name)
+	return true
+}
+
+// loadTemplates returns the registered templates, which must not be modified
+func (e *Engine) loadTemplates() map[string]*Template {
+	return *e.publishedTemplates()
+}
+
+// publishedTemplates publishes pending registrations and returns the
+// registered templates, shared by readers
+func (e *Engine) publishedTemplates() *map[string]*Template {
+	if e.tmplDirty.Load() {
+		e.tmplMu.Lock()
+		if e.tmplDraft != nil {
+			e.storeTemplates(e.tmplDraft)
+			e.tmplDraft = nil
+		}
+		e.tmplDirty.Store(false)
+		e.tmplMu.Unlock()
+	}
+	return e.templates.Load()
+}
+
+// currentTemplates returns the registered templates including pending
+// registrations, which must not be modified. The caller must hold tmplMu.
+func (e *Engine) currentTemplates() map[string]*Template {
+	if e.tmplDraft != nil {
+		return e.tmplDraft
+	}
+	return *e.templates.Load()
+}
+
+// draftTemplates returns the writable map of pending registrations, copying
+// the published templates once after each publication, and marks it for
+// publication. The caller must hold tmplMu.
+func (e *Engine) draftTemplates() map[string]*Template {
+	if e.tmplDraft == nil {
+		e.tmplDraft = maps.Clone(*e.templates.Load())
+	}
+	e.tmplDirty.Store(true)
+	return e.tmplDraft
+}
+
+// storeTemplates publishes templates, which the engine then owns
+func (e *Engine) storeTemplates(templates map[string]*Template) {
+	e.templates.Store(&templates)
 }
 
 // GetTemplate retrieves a registered template by name.
 // Returns the template and true if found, or nil and false if not.
 func (e *Engine) GetTemplate(name string) (*Template, bool) {
-	e.tmplMu.RLock()
-	defer e.tmplMu.RUnlock()
-
-	tmpl, ok := e.templates[name]
-	return tmpl, ok
+	tmpl, ok := e.loadTemplates()[name]
+	if !ok {
+		return nil, false
+	}
+	return tmpl.boundTo(e), true
 }
 
 // GetTemplateSource retrieves the source string of a registered template by name.
 // This implements TemplateSourceResolver for template inheritance support.
 // Returns the source and true if found, or empty string and false if not.
 func (e *Engine) GetTemplateSource(name string) (string, bool) {
-	e.tmplMu.RLock()
-	defer e.tmplMu.RUnlock()
-
-	tmpl, ok := e.templates[name]
+	tmpl, ok := e.loadTemplates()[name]
 	if !ok {
 		return "", false
 	}
@@ -284,20 +488,29 @@ func (e *Engine) GetTemplateSource(name string) (string, bool) {
 
 // HasTemplate checks if a template is registered with the given name.
 func (e *Engine) HasTemplate(name string) bool {
-	e.tmplMu.RLock()
-	defer e.tmplMu.RUnlock()
-
-	_, ok := e.templates[name]
+	_, ok := e.loadTemplates()[name]
 	return ok
 }
 
+// AllowsDynamicInclude reports whether a template_expr include may select
+// the named template under the WithDynamicIncludeAllowlist patterns.
+func (e *Engine) AllowsDynamicInclude(name string) bool {
+	if len(e.config.includeAllow) == 0 {
+		return true
+	}
+	for _, pattern := range e.config.includeAllow {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // ListTemplates returns all registered template names in sorted order.
 func (e *Engine) ListTemplates() []string {
-	e.tmplMu.RLock()
-	defer e.tmplMu.RUnlock()
-
-	names := make([]string, 0, len(e.templates))
-	for name := range e.templates {
+	templates := e.loadTemplates()
+	names := make([]string, 0, len(templates))
+	for name := range templates {
 		names = append(names, name)
 	}
 	sort.Strings(names)
@@ -306,44 +519,55 @@ func (e *Engine) ListTemplates() []string {
 
 // TemplateCount returns the number of registered templates.
 func (e *Engine) TemplateCount() int {
-	e.tmplMu.RLock()
-	defer e.tmplMu.RUnlock()
-
-	return len(e.templates)
+	return len(e.loadTemplates())
 }
 
 // ExecuteTemplate executes a registered template by name with the given data.
 // This implements the TemplateExecutor interface for nested template support.
-// It handles depth tracking for nested template inclusion.
+// It handles depth tracking and cycle detection for nested template inclusion.
 // Note: This method creates a copy of the data map to avoid mutating caller's data.
 func (e *Engine) ExecuteTemplate(ctx context.Context, name string, data map[string]any) (string, error) {
 	tmpl, ok := e.GetTemplate(name)
 	if !ok {
 		return "", NewTemplateNotFoundError(name)
 	}
+	return e.executeIncluded(ctx, tmpl, name, data, e)
+}
 
-	// Extract parent depth if provided and create clean data copy
+// executeIncluded executes tmpl as the template identified by key, with
+// executor serving the includes nested in it. It fails if key is already
+// being included further up the chain.
+func (e *Engine) executeIncluded(ctx context.Context, tmpl *Template, key string, data map[string]any, executor TemplateExecutor) (string, error) {
+	// Extract parent depth and include chain if provided and create clean data copy
 	parentDepth := 0
+	var chain []string
 	var cleanData map[string]any
 	if data != nil {
-		// Extract depth before copying
+		// Extract meta keys before copying
 		if pd, ok := data[MetaKeyParentDepth]; ok {
 			if depth, ok := pd.(int); ok {
 				parentDepth = depth
 			}
 		}
-		// Create a copy without the meta key to avoid mutating caller's data
+		chain, _ = data[MetaKeyIncludeChain].([]string)
+		// Create a copy without the meta keys to avoid mutating caller's data
 		cleanData = make(map[string]any, len(data))
 		for k, v := range data {
-			if k != MetaKeyParentDepth {
+			if k != MetaKeyParentDepth && k != MetaKeyIncludeChain {
 				cleanData[k] = v
 			}
 		}
 	}
 
+	if slices.Contains(chain, key) {
+		return "", NewTemplateIncludeCycleError(key, chain)
+	}
+
 	// Create context with incremented depth
 	execCtx := NewContextWithStrategy(cleanData, e.config.errorStrategy)
-	execCtx = execCtx.WithEngine(e).WithDepth(parentDepth + 1)
+	execCtx.fieldMatch = e.config.fieldMatch
+	execCtx.includeChain = append(slices.Clip(chain), key)
+	execCtx = execCtx.WithEngine(executor).WithDepth(parentDepth + 1)
 
 	return tmpl.ExecuteWithContext(ctx, execCtx)
 }
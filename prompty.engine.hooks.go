@@ -0,0 +1,140 @@
+package prompty
+
+import (
+	"context"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// RegisterHook registers a hook for the specified point.
+//
+// A plain Engine runs these hook points:
+//   - HookBeforeParse / HookAfterParse around Parse and RegisterTemplate.
+//     Before-parse hooks may rewrite HookData.Source.
+//   - HookBeforeExecute / HookAfterExecute once per top-level execution;
+//     templates included by it do not run them again. Before-execute hooks
+//     may add, change or replace HookData.ExecutionData, a copy of the
+//     execution's data. After-execute hooks may rewrite HookData.Result.
+//   - HookBeforeResolve / HookAfterResolve around every resolver invocation,
+//     including those of included templates. A before-resolve error fails
+//     the tag, which is then handled by its error strategy. After-resolve
+//     hooks may rewrite HookData.Result.
+func (e *Engine) RegisterHook(point HookPoint, hook Hook) {
+	e.config.hooks.Register(point, hook)
+}
+
+// RegisterHooks registers a hook for multiple points.
+func (e *Engine) RegisterHooks(hook Hook, points ...HookPoint) {
+	e.config.hooks.RegisterMultiple(hook, points...)
+}
+
+// ClearHooks removes all hooks for a specific point.
+func (e *Engine) ClearHooks(point HookPoint) {
+	e.config.hooks.Clear(point)
+}
+
+// ClearAllHooks removes all hooks.
+func (e *Engine) ClearAllHooks() {
+	e.config.hooks.ClearAll()
+}
+
+// Hooks returns the hook registry for direct access.
+func (e *Engine) Hooks() *HookRegistry {
+	return e.config.hooks
+}
+
+// parseWithHooks parses source as the template name (empty for unregistered
+// templates), running the parse hooks.
+func (e *Engine) parseWithHooks(name, source string) (*Template, error) {
+	hooks := e.config.hooks
+	if !hooks.HasHooks(HookBeforeParse) && !hooks.HasHooks(HookAfterParse) {
+		return e.parse(source)
+	}
+
+	ctx := context.Background()
+	hookData := NewHookData("", name, nil)
+	hookData.Source = source
+	if err := hooks.Run(ctx, HookBeforeParse, hookData); err != nil {
+		return nil, NewHookError(HookBeforeParse, err)
+	}
+
+	tmpl, err := e.parse(hookData.Source)
+
+	hookData.ParsedTemplate = tmpl
+	hookData.WithError(err)
+	_ = hooks.Run(ctx, HookAfterParse, hookData)
+
+	return tmpl, err
+}
+
+// hookScopeKey is the context key marking an execution whose execute hooks
+// already run. Its value is the name of the executing template.
+type hookScopeKey struct{}
+
+// hasExecutionHooks reports whether any execute or resolve hooks are registered.
+func (r *HookRegistry) hasExecutionHooks() bool {
+	return r.HasHooks(HookBeforeExecute) || r.HasHooks(HookAfterExecute) ||
+		r.HasHooks(HookBeforeResolve) || r.HasHooks(HookAfterResolve)
+}
+
+// executeWithHooks executes the template, running the execute hooks around
+// it and marking ctx so nested executions do not run them again.
+func (t *Template) executeWithHooks(ctx context.Context, execCtx *Context) (string, error) {
+	hooks := t.config.hooks
+	ctx = context.WithValue(ctx, hookScopeKey{}, t.name)
+
+	hookData := NewHookData(OpExecute, t.name, nil).WithExecutionData(execCtx.Data())
+	hookData.ParsedTemplate = t
+	if hooks.HasHooks(HookBeforeExecute) {
+		if err := hooks.Run(ctx, HookBeforeExecute, hookData); err != nil {
+			return "", NewHookError(HookBeforeExecute, err)
+		}
+		execCtx = execCtx.withData(hookData.ExecutionData)
+	}
+
+	result, err := t.executeRecorded(ctx, execCtx)
+
+	hookData.WithResult(result).WithError(err)
+	_ = hooks.Run(ctx, HookAfterExecute, hookData)
+
+	return hookData.Result, err
+}
+
+// resolveHookAdapter runs the resolve hooks of an engine around resolver
+// invocations (see internal.ResolveHook) and records the invocations of
+// executions run with an ExecutionRecorder.
+type resolveHookAdapter struct {
+	hooks *HookRegistry
+}
+
+func (a *resolveHookAdapter) BeforeResolve(ctx context.Context, tagName string, attrs internal.Attributes) error {
+	if !a.hooks.HasHooks(HookBeforeResolve) {
+		return nil
+	}
+	if err := a.hooks.Run(ctx, HookBeforeResolve, newResolveHookData(ctx, tagName, attrs)); err != nil {
+		return NewHookError(HookBeforeResolve, err)
+	}
+	return nil
+}
+
+func (a *resolveHookAdapter) AfterResolve(ctx context.Context, tagName string, attrs internal.Attributes, result string, err error) string {
+	if a.hooks.HasHooks(HookAfterResolve) {
+		hookData := newResolveHookData(ctx, tagName, attrs).WithResult(result).WithError(err)
+		_ = a.hooks.Run(ctx, HookAfterResolve, hookData)
+		result = hookData.Result
+	}
+	if recorder := recorderFrom(ctx); recorder != nil {
+		recorder.addResolve(tagName, attrs, result, err)
+	}
+	return result
+}
+
+// newResolveHookData creates the hook data for a resolver invocation within
+// the execution running ctx.
+func newResolveHookData(ctx context.Context, tagName string, attrs internal.Attributes) *HookData {
+	name, _ := ctx.Value(hookScopeKey{}).(string)
+	hookData := NewHookData(OpExecute, name, nil)
+	hookData.TagName = tagName
+	hookData.Attributes = &internalAttributesAdapter{attrs: attrs}
+	return hookData
+}
@@ -0,0 +1,151 @@
+package prompty
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineHooks_Execute(t *testing.T) {
+	engine := MustNew()
+	engine.MustRegisterTemplate("greeting", `Hello {~prompty.var name="user" /~} from {~prompty.var name="app" /~}`)
+
+	var names []string
+	engine.RegisterHook(HookBeforeExecute, func(ctx context.Context, point HookPoint, data *HookData) error {
+		// Inject a global variable
+		data.ExecutionData["app"] = "prompty"
+		names = append(names, data.TemplateName)
+		return nil
+	})
+	engine.RegisterHook(HookAfterExecute, func(ctx context.Context, point HookPoint, data *HookData) error {
+		require.NoError(t, data.Error)
+		require.NotNil(t, data.ParsedTemplate)
+		data.Result = strings.ToUpper(data.Result)
+		return nil
+	})
+
+	input := map[string]any{"user": "Ada"}
+	result, err := engine.ExecuteTemplate(context.Background(), "greeting", input)
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO ADA FROM PROMPTY", result)
+	assert.NotContains(t, input, "app", "hooks must not modify the caller's data")
+
+	// Included templates run as part of the top-level execution
+	result, err = engine.Execute(context.Background(), `{~prompty.include template="greeting" with="user=user,app=app" /~}!`, input)
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO ADA FROM PROMPTY!", result)
+	assert.Equal(t, []string{"greeting", ""}, names)
+}
+
+func TestEngineHooks_ReplaceExecutionData(t *testing.T) {
+	engine := MustNew()
+	engine.RegisterHook(HookBeforeExecute, func(ctx context.Context, point HookPoint, data *HookData) error {
+		data.ExecutionData = map[string]any{"user": "Replaced"}
+		return nil
+	})
+
+	result, err := engine.Execute(context.Background(), `{~prompty.var name="user" /~}`, map[string]any{"user": "Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, "Replaced", result)
+}
+
+func TestEngineHooks_BeforeExecuteAborts(t *testing.T) {
+	engine := MustNew()
+	denied := errors.New("denied")
+	engine.RegisterHook(HookBeforeExecute, func(ctx context.Context, point HookPoint, data *HookData) error {
+		return denied
+	})
+
+	_, err := engine.Execute(context.Background(), `text`, nil)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, denied)
+
+	var hookErr *HookError
+	require.ErrorAs(t, err, &hookErr)
+	assert.Equal(t, HookBeforeExecute, hookErr.Point)
+
+	engine.ClearHooks(HookBeforeExecute)
+	result, err := engine.Execute(context.Background(), `text`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "text", result)
+}
+
+func TestEngineHooks_Parse(t *testing.T) {
+	engine := MustNew()
+	var parsed []string
+	engine.RegisterHook(HookBeforeParse, func(ctx context.Context, point HookPoint, data *HookData) error {
+		data.Source = strings.ReplaceAll(data.Source, "{{user}}", `{~prompty.var name="user" /~}`)
+		return nil
+	})
+	engine.RegisterHook(HookAfterParse, func(ctx context.Context, point HookPoint, data *HookData) error {
+		if data.Error == nil {
+			parsed = append(parsed, data.TemplateName)
+		}
+		return nil
+	})
+
+	require.NoError(t, engine.RegisterTemplate("hello", `Hi {{user}}`))
+	result, err := engine.ExecuteTemplate(context.Background(), "hello", map[string]any{"user": "Bo"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hi Bo", result)
+
+	_, err = engine.Parse(`{~prompty.var name="x"`)
+	require.Error(t, err)
+	assert.Equal(t, []string{"hello"}, parsed)
+
+	engine.RegisterHook(HookBeforeParse, func(ctx context.Context, point HookPoint, data *HookData) error {
+		return errors.New("no parsing")
+	})
+	_, err = engine.Parse(`text`)
+	assert.ErrorContains(t, err, ErrMsgHookFailed)
+}
+
+func TestEngineHooks_Resolve(t *testing.T) {
+	engine := MustNew()
+	engine.MustRegisterTemplate("footer", `{~prompty.var name="sig" default="bye" /~}`)
+
+	var tags []string
+	engine.RegisterHook(HookBeforeResolve, func(ctx context.Context, point HookPoint, data *HookData) error {
+		name, _ := data.Attributes.Get(AttrName)
+		tags = append(tags, data.TagName+":"+name)
+		if name == "secret" {
+			return errors.New("forbidden variable")
+		}
+		return nil
+	})
+	engine.RegisterHook(HookAfterResolve, func(ctx context.Context, point HookPoint, data *HookData) error {
+		if data.TagName == TagNameVar {
+			data.Result = "[" + data.Result + "]"
+		}
+		return nil
+	})
+
+	result, err := engine.Execute(context.Background(), `{~prompty.var name="user" /~} {~prompty.include template="footer" /~}`, map[string]any{"user": "Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, "[Ada] [bye]", result)
+	assert.Equal(t, []string{"prompty.var:user", "prompty.include:", "prompty.var:sig"}, tags)
+
+	// A failing before-resolve hook is handled by the tag's error strategy
+	result, err = engine.Execute(context.Background(), `a{~prompty.var name="secret" onerror="remove" /~}b`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ab", result)
+
+	_, err = engine.Execute(context.Background(), `{~prompty.var name="secret" /~}`, nil)
+	assert.ErrorContains(t, err, "forbidden variable")
+}
+
+func TestEngineHooks_RegisterAndClear(t *testing.T) {
+	engine := MustNew()
+	noop := func(ctx context.Context, point HookPoint, data *HookData) error { return nil }
+
+	engine.RegisterHooks(noop, HookBeforeExecute, HookAfterExecute, HookBeforeResolve)
+	assert.Equal(t, 1, engine.Hooks().Count(HookBeforeExecute))
+	assert.True(t, engine.Hooks().HasHooks(HookBeforeResolve))
+
+	engine.ClearAllHooks()
+	assert.False(t, engine.Hooks().HasHooks(HookBeforeExecute))
+}
@@ -0,0 +1,45 @@
+package prompty
+
+// Snapshot returns a read-only view of the engine with the resolvers,
+// functions, templates and resolver middleware registered so far. Resolvers,
+// functions and templates registered with the engine later are not visible
+// through the snapshot, so a worker pool can share one snapshot and get the
+// same behavior for every job while the engine keeps being configured.
+//
+// Registering resolvers, functions, templates or resolver middleware with a
+// snapshot fails with ErrMsgSnapshotReadOnly; UnregisterTemplate removes
+// nothing and returns false. Templates parsed by or retrieved from the
+// snapshot execute with the snapshot's registries. Hooks, caches and
+// options are shared with the engine. The snapshot of a snapshot is the
+// snapshot itself.
+//
+// Taking a snapshot copies no templates; the engine's next registration
+// copies the template map instead.
+func (e *Engine) Snapshot() *Engine {
+	if e.snapshot {
+		return e
+	}
+
+	// Take the middleware and the resolvers it wraps together
+	e.mwMu.Lock()
+	middleware := append([]ResolverMiddleware(nil), e.middleware...)
+	registry := e.registry.Snapshot()
+	e.mwMu.Unlock()
+
+	snapshot := &Engine{
+		registry:   registry,
+		config:     e.config,
+		executor:   e.executor.Snapshot(registry),
+		logger:     e.logger,
+		snapshot:   true,
+		middleware: middleware,
+	}
+	snapshot.templates.Store(e.publishedTemplates())
+	return snapshot
+}
+
+// IsSnapshot reports whether the engine is a read-only view created by
+// Snapshot.
+func (e *Engine) IsSnapshot() bool {
+	return e.snapshot
+}
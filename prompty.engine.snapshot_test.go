@@ -0,0 +1,166 @@
+package prompty
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func staticResolver(tagName, output string) Resolver {
+	return NewResolverFunc(tagName, func(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+		return output, nil
+	}, nil)
+}
+
+func TestEngine_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	engine := MustNew()
+	engine.MustRegister(staticResolver("Greeting", "hello"))
+	engine.MustRegisterFunc(&Func{Name: "twice", MinArgs: 1, MaxArgs: 1, Fn: func(args []any) (any, error) {
+		return fmt.Sprint(args[0], args[0]), nil
+	}})
+	engine.MustRegisterTemplate("header", `{~Greeting /~}`)
+	engine.MustRegisterTemplate("page", `{~prompty.include template="header" /~}|{~Weather onerror="default" default="?" /~}`)
+
+	snapshot := engine.Snapshot()
+	assert.True(t, snapshot.IsSnapshot())
+	assert.False(t, engine.IsSnapshot())
+	assert.Same(t, snapshot, snapshot.Snapshot())
+
+	// Registrations after the snapshot are not visible through it
+	engine.MustRegister(staticResolver("Weather", "sunny"))
+	engine.MustRegisterFunc(&Func{Name: "thrice", MinArgs: 1, MaxArgs: 1, Fn: func(args []any) (any, error) {
+		return fmt.Sprint(args[0], args[0], args[0]), nil
+	}})
+	engine.MustRegisterTemplate("footer", `bye`)
+	assert.True(t, engine.UnregisterTemplate("header"))
+
+	assert.False(t, snapshot.HasResolver("Weather"))
+	assert.False(t, snapshot.HasFunc("thrice"))
+	assert.True(t, snapshot.HasFunc("twice"))
+	assert.Equal(t, []string{"header", "page"}, snapshot.ListTemplates())
+
+	output, err := snapshot.ExecuteTemplate(ctx, "page", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "hello|?", output)
+
+	output, err = snapshot.Execute(ctx, `{~prompty.if eval="twice('a') == 'aa'"~}ok{~/prompty.if~}`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", output)
+	_, err = snapshot.Execute(ctx, `{~prompty.if eval="thrice('a') == 'aaa'"~}ok{~/prompty.if~}`, nil)
+	assert.Error(t, err)
+
+	// The engine sees its own registrations
+	engine.MustRegisterTemplate("header", `{~Weather /~}`)
+	output, err = engine.ExecuteTemplate(ctx, "page", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "sunny|sunny", output)
+}
+
+func TestEngine_Snapshot_ReadOnly(t *testing.T) {
+	engine := MustNew()
+	engine.MustRegisterTemplate("greeting", `hi`)
+	snapshot := engine.Snapshot()
+
+	err := snapshot.Register(staticResolver("Weather", "sunny"))
+	assert.ErrorContains(t, err, ErrMsgSnapshotReadOnly)
+	err = snapshot.RegisterFunc(&Func{Name: "noop", Fn: func(args []any) (any, error) { return nil, nil }})
+	assert.ErrorContains(t, err, ErrMsgSnapshotReadOnly)
+	err = snapshot.RegisterTemplate("other", `x`)
+	assert.ErrorContains(t, err, ErrMsgSnapshotReadOnly)
+	err = snapshot.RegisterTemplates(map[string]string{"other": `x`})
+	assert.ErrorContains(t, err, ErrMsgSnapshotReadOnly)
+	assert.False(t, snapshot.UnregisterTemplate("greeting"))
+	assert.True(t, snapshot.HasTemplate("greeting"))
+
+	// Middleware added to the engine or the snapshot does not change it
+	upper := func(next Resolver) Resolver {
+		return NewResolverFunc(next.TagName(), func(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+			return "wrapped", nil
+		}, nil)
+	}
+	require.NoError(t, engine.UseResolverMiddleware(upper))
+	err = snapshot.UseResolverMiddleware(upper)
+	assert.ErrorContains(t, err, ErrMsgSnapshotReadOnly)
+	output, err := snapshot.Execute(context.Background(), `{~prompty.var name="x" /~}`, map[string]any{"x": "plain"})
+	require.NoError(t, err)
+	assert.Equal(t, "plain", output)
+}
+
+func TestEngine_ConcurrentRegistration(t *testing.T) {
+	ctx := context.Background()
+	engine := MustNew()
+	engine.MustRegister(staticResolver("Base", "base"))
+	engine.MustRegisterTemplate("base", `{~Base /~}`)
+	tmpl, err := engine.Parse(`{~prompty.include template="base" /~}:{~prompty.var name="n" /~}`)
+	require.NoError(t, err)
+
+	const workers = 8
+	const registrations = 50
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				output, err := tmpl.Execute(ctx, map[string]any{"n": 1})
+				if err != nil || output != "base:1" {
+					errs <- fmt.Errorf("unexpected result %q: %v", output, err)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < registrations; i++ {
+		name := fmt.Sprintf("Tag%d", i)
+		require.NoError(t, engine.Register(staticResolver(name, name)))
+		require.NoError(t, engine.RegisterFunc(&Func{Name: fmt.Sprintf("fn%d", i), Fn: func(args []any) (any, error) { return nil, nil }}))
+		require.NoError(t, engine.RegisterTemplate(fmt.Sprintf("tmpl%d", i), `{~`+name+` /~}`))
+		_ = engine.Snapshot()
+	}
+	close(stop)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	output, err := engine.ExecuteTemplate(ctx, fmt.Sprintf("tmpl%d", registrations-1), nil)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("Tag%d", registrations-1), output)
+}
+
+func TestEngine_RegisterTemplate_SharesCopyUntilRead(t *testing.T) {
+	engine := MustNew()
+	engine.MustRegisterTemplate("a", `a`)
+	draft := engine.tmplDraft
+	engine.MustRegisterTemplate("b", `b`)
+	assert.True(t, engine.UnregisterTemplate("a"))
+
+	// Consecutive writes reuse one copy of the template map
+	require.NotNil(t, draft)
+	assert.Equal(t, reflect.ValueOf(draft).Pointer(), reflect.ValueOf(engine.tmplDraft).Pointer())
+	assert.Empty(t, *engine.templates.Load())
+
+	// Reading publishes the copy; the next write copies again
+	snapshot := engine.Snapshot()
+	assert.Equal(t, []string{"b"}, engine.ListTemplates())
+	assert.Nil(t, engine.tmplDraft)
+	engine.MustRegisterTemplate("c", `c`)
+	assert.Equal(t, []string{"b"}, snapshot.ListTemplates())
+	assert.Equal(t, []string{"b", "c"}, engine.ListTemplates())
+}
@@ -12,6 +12,10 @@ type SecureStorageEngine struct {
 	checker AccessChecker
 	hooks   *HookRegistry
 	auditor AccessAuditor
+
+	// Rate limiting (nil limiter when no rules are configured)
+	limiter    RateLimiter
+	rateLimits []RateLimitRule
 }
 
 // SecureStorageEngineConfig configures the SecureStorageEngine.
@@ -21,11 +25,20 @@ type SecureStorageEngineConfig struct {
 
 	// AccessChecker performs access control checks.
 	// If nil, AllowAllChecker is used (no access control).
+	// A CachedChecker is subscribed to the engine's template change events.
 	AccessChecker AccessChecker
 
 	// Auditor logs access decisions.
 	// If nil, no audit logging is performed.
 	Auditor AccessAuditor
+
+	// RateLimits are enforced on ExecuteSecure, ExecuteVersionSecure and
+	// SaveSecure. Every matching rule must allow an operation.
+	RateLimits []RateLimitRule
+
+	// RateLimiter stores the rate limit buckets.
+	// If nil and RateLimits are set, a TokenBucketLimiter is used.
+	RateLimiter RateLimiter
 }
 
 // NewSecureStorageEngine creates a new SecureStorageEngine.
@@ -40,16 +53,35 @@ func NewSecureStorageEngine(config SecureStorageEngineConfig) (*SecureStorageEng
 		checker = &AllowAllChecker{}
 	}
 
+	// Drop cached decisions about templates that change
+	if cached, ok := checker.(*CachedChecker); ok {
+		se.Subscribe(cached, EventTemplateSaved, EventVersionCreated, EventTemplateDeleted, EventVersionDeleted)
+	}
+
 	// Wrap checker with auditing if auditor is provided
 	if config.Auditor != nil {
 		checker = NewAuditingChecker(checker, config.Auditor)
 	}
 
+	limiter := config.RateLimiter
+	if len(config.RateLimits) > 0 {
+		for _, rule := range config.RateLimits {
+			if err := rule.validate(); err != nil {
+				return nil, err
+			}
+		}
+		if limiter == nil {
+			limiter = NewTokenBucketLimiter()
+		}
+	}
+
 	return &SecureStorageEngine{
 		StorageEngine: se,
 		checker:       checker,
 		hooks:         NewHookRegistry(),
 		auditor:       config.Auditor,
+		limiter:       limiter,
+		rateLimits:    config.RateLimits,
 	}, nil
 }
 
@@ -94,6 +126,10 @@ func (se *SecureStorageEngine) AccessChecker() AccessChecker {
 
 // ExecuteSecure executes a stored template with access control.
 func (se *SecureStorageEngine) ExecuteSecure(ctx context.Context, templateName string, data map[string]any, subject *AccessSubject) (string, error) {
+	if err := se.checkRateLimit(ctx, OpExecute, templateName, subject); err != nil {
+		return "", err
+	}
+
 	// Load template first to enable resource-level access control
 	tmpl, err := se.StorageEngine.Get(ctx, templateName)
 	if err != nil {
@@ -119,7 +155,7 @@ func (se *SecureStorageEngine) ExecuteSecure(ctx context.Context, templateName s
 	}
 
 	// Execute
-	result, execErr := se.StorageEngine.Execute(ctx, templateName, data)
+	result, execErr := se.executeLatest(ctx, templateName, data, subject)
 
 	// Run after hooks
 	hookData.WithResult(result).WithError(execErr)
@@ -130,6 +166,10 @@ func (se *SecureStorageEngine) ExecuteSecure(ctx context.Context, templateName s
 
 // ExecuteVersionSecure executes a specific version with access control.
 func (se *SecureStorageEngine) ExecuteVersionSecure(ctx context.Context, templateName string, version int, data map[string]any, subject *AccessSubject) (string, error) {
+	if err := se.checkRateLimit(ctx, OpExecute, templateName, subject); err != nil {
+		return "", err
+	}
+
 	// Load template first to enable resource-level access control
 	tmpl, err := se.StorageEngine.GetVersion(ctx, templateName, version)
 	if err != nil {
@@ -155,7 +195,7 @@ func (se *SecureStorageEngine) ExecuteVersionSecure(ctx context.Context, templat
 	}
 
 	// Execute
-	result, execErr := se.StorageEngine.ExecuteVersion(ctx, templateName, version, data)
+	result, execErr := se.executeVersion(ctx, templateName, version, data, subject)
 
 	// Run after hooks
 	hookData.WithResult(result).WithError(execErr)
@@ -164,12 +204,14 @@ func (se *SecureStorageEngine) ExecuteVersionSecure(ctx context.Context, templat
 	return result, execErr
 }
 
-// GetSecure retrieves a template with access control.
+// GetSecure retrieves a template with access control. Fields masked by the
+// access decision (AccessDecision.Redact) are removed from the result.
 func (se *SecureStorageEngine) GetSecure(ctx context.Context, templateName string, subject *AccessSubject) (*StoredTemplate, error) {
 	// Check access
 	req := NewAccessRequest(OpRead, templateName, subject)
 
-	if err := se.checkAccess(ctx, req); err != nil {
+	decision, err := se.authorize(ctx, req)
+	if err != nil {
 		return nil, err
 	}
 
@@ -187,7 +229,7 @@ func (se *SecureStorageEngine) GetSecure(ctx context.Context, templateName strin
 	hookData.WithTemplate(tmpl).WithError(loadErr)
 	_ = se.hooks.Run(ctx, HookAfterLoad, hookData)
 
-	return tmpl, loadErr
+	return decision.Redact.Apply(tmpl), loadErr
 }
 
 // SaveSecure stores a template with access control.
@@ -199,6 +241,10 @@ func (se *SecureStorageEngine) SaveSecure(ctx context.Context, tmpl *StoredTempl
 		op = OpUpdate
 	}
 
+	if err := se.checkRateLimit(ctx, op, tmpl.Name, subject); err != nil {
+		return err
+	}
+
 	// Check access
 	req := NewAccessRequest(op, tmpl.Name, subject).
 		WithResource(tmpl)
@@ -319,7 +365,8 @@ func (se *SecureStorageEngine) ValidateSecure(ctx context.Context, templateName
 	return result, validateErr
 }
 
-// ListSecure returns templates the subject can access.
+// ListSecure returns templates the subject can access, with the fields
+// masked by each read decision removed.
 func (se *SecureStorageEngine) ListSecure(ctx context.Context, query *TemplateQuery, subject *AccessSubject) ([]*StoredTemplate, error) {
 	// Check list access
 	req := NewAccessRequest(OpList, "", subject)
@@ -345,7 +392,7 @@ func (se *SecureStorageEngine) ListSecure(ctx context.Context, query *TemplateQu
 			continue // Skip on error
 		}
 		if decision.Allowed {
-			accessible = append(accessible, tmpl)
+			accessible = append(accessible, decision.Redact.Apply(tmpl))
 		}
 	}
 
@@ -354,16 +401,33 @@ func (se *SecureStorageEngine) ListSecure(ctx context.Context, query *TemplateQu
 
 // checkAccess performs an access check and returns an error if denied.
 func (se *SecureStorageEngine) checkAccess(ctx context.Context, req *AccessRequest) error {
+	_, err := se.authorize(ctx, req)
+	return err
+}
+
+// authorize performs an access check and returns the allowing decision,
+// or an error if denied.
+func (se *SecureStorageEngine) authorize(ctx context.Context, req *AccessRequest) (*AccessDecision, error) {
 	decision, err := se.checker.Check(ctx, req)
 	if err != nil {
-		return NewAccessCheckError(req.Operation, req.TemplateName, err)
+		return nil, NewAccessCheckError(req.Operation, req.TemplateName, err)
 	}
 
 	if !decision.Allowed {
-		return NewAccessDeniedError(req.Operation, req.TemplateName, req.Subject)
+		event := newStorageEvent(EventAccessDenied, req.TemplateName)
+		event.Operation = req.Operation
+		event.Subject = req.Subject
+		if req.Subject != nil {
+			event.TenantID = req.Subject.TenantID
+		}
+		if req.Resource != nil {
+			event.TemplateVersion = req.Resource.Version
+		}
+		_ = se.events.Publish(ctx, event)
+		return nil, NewAccessDeniedError(req.Operation, req.TemplateName, req.Subject)
 	}
 
-	return nil
+	return decision, nil
 }
 
 // CheckAccess explicitly checks access for a request.
@@ -0,0 +1,56 @@
+package prompty
+
+import "context"
+
+// ConditionalResult is the outcome of StorageEngine.ExecuteIfNoneMatch.
+type ConditionalResult struct {
+	// Output is the rendered template; empty when NotModified.
+	Output string
+
+	// ETag is the quoted ResultKey of the executed template version and
+	// the data. Clients cache Output under it and send it back to skip
+	// rendering when neither has changed.
+	ETag string
+
+	// Version is the template version the ETag refers to.
+	Version int
+
+	// NotModified reports that the ETag passed in matched, so the
+	// template was not rendered.
+	NotModified bool
+}
+
+// GetIfNoneMatch retrieves the latest version of a stored template unless
+// its TemplateETag equals etag (see the GetIfNoneMatch function).
+func (se *StorageEngine) GetIfNoneMatch(ctx context.Context, templateName, etag string) (*StoredTemplate, bool, error) {
+	return GetIfNoneMatch(ctx, se.storage, templateName, etag)
+}
+
+// ExecuteIfNoneMatch executes a stored template like Execute unless etag
+// (an ETag from an earlier ConditionalResult or an If-None-Match header)
+// matches the result key of the template's current content and data. The
+// template is then not rendered and the result reports NotModified, so an
+// HTTP frontend can answer 304 Not Modified and clients can reuse the
+// output they cached. The key does not cover included templates, resolvers
+// or clock and random functions (see ResultKey).
+func (se *StorageEngine) ExecuteIfNoneMatch(ctx context.Context, templateName string, data map[string]any, etag string) (*ConditionalResult, error) {
+	tmpl, stored, err := se.loadAndParse(ctx, templateName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ResultKey(stored, data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ConditionalResult{ETag: quoteETag(key), Version: stored.Version}
+	if ETagMatches(etag, result.ETag) {
+		result.NotModified = true
+		return result, nil
+	}
+	result.Output, err = se.executeStored(ctx, tmpl, stored, templateName, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageEngine_ExecuteIfNoneMatch(t *testing.T) {
+	ctx := context.Background()
+	se, err := NewStorageEngine(StorageEngineConfig{Storage: NewMemoryStorage()})
+	require.NoError(t, err)
+	defer se.Close()
+	require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "greet", Source: `Hi {~prompty.var name="user" /~}`}))
+
+	var executions int
+	se.Subscribe(NewFuncEventSink(func(ctx context.Context, event *StorageEvent) error {
+		executions++
+		return nil
+	}), EventTemplateExecuted)
+
+	data := map[string]any{"user": "Ana"}
+	result, err := se.ExecuteIfNoneMatch(ctx, "greet", data, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Hi Ana", result.Output)
+	assert.False(t, result.NotModified)
+	assert.Equal(t, 1, result.Version)
+	assert.NotEmpty(t, result.ETag)
+
+	// Same content and data: not rendered again
+	again, err := se.ExecuteIfNoneMatch(ctx, "greet", map[string]any{"user": "Ana"}, result.ETag)
+	require.NoError(t, err)
+	assert.True(t, again.NotModified)
+	assert.Empty(t, again.Output)
+	assert.Equal(t, result.ETag, again.ETag)
+	assert.Equal(t, 1, executions)
+
+	// Different data or content renders
+	other, err := se.ExecuteIfNoneMatch(ctx, "greet", map[string]any{"user": "Bo"}, result.ETag)
+	require.NoError(t, err)
+	assert.False(t, other.NotModified)
+	assert.Equal(t, "Hi Bo", other.Output)
+
+	require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "greet", Source: `Hello {~prompty.var name="user" /~}`}))
+	updated, err := se.ExecuteIfNoneMatch(ctx, "greet", data, result.ETag)
+	require.NoError(t, err)
+	assert.False(t, updated.NotModified)
+	assert.Equal(t, "Hello Ana", updated.Output)
+	assert.Equal(t, 2, updated.Version)
+
+	_, err = se.ExecuteIfNoneMatch(ctx, "greet", map[string]any{"ch": make(chan int)}, "")
+	assert.ErrorContains(t, err, ErrMsgDataNotHashable)
+
+	tmpl, modified, err := se.GetIfNoneMatch(ctx, "greet", "")
+	require.NoError(t, err)
+	require.True(t, modified)
+	_, modified, err = se.GetIfNoneMatch(ctx, "greet", TemplateETag(tmpl))
+	require.NoError(t, err)
+	assert.False(t, modified)
+}
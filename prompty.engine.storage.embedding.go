@@ -0,0 +1,80 @@
+package prompty
+
+import (
+	"context"
+)
+
+// SimilarTemplate is a result of StorageEngine.SearchSimilar.
+type SimilarTemplate struct {
+	// Template is the latest version of the matching template.
+	Template *StoredTemplate
+
+	// Score is the cosine similarity to the query, from -1 to 1.
+	Score float64
+}
+
+// EmbeddingIndexer returns the configured embedding indexer, or nil.
+func (se *StorageEngine) EmbeddingIndexer() *EmbeddingIndexer {
+	return se.embeddings
+}
+
+// SearchSimilar returns the k templates whose source and prompt description
+// are semantically closest to query, best first. If k <= 0, all indexed
+// templates are returned. Requires StorageEngineConfig.EmbeddingIndexer.
+func (se *StorageEngine) SearchSimilar(ctx context.Context, query string, k int) ([]*SimilarTemplate, error) {
+	if se.embeddings == nil {
+		return nil, NewEmbeddingNoIndexerError()
+	}
+
+	matches, err := se.embeddings.Search(ctx, query, k)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*SimilarTemplate, 0, len(matches))
+	for _, match := range matches {
+		tmpl, err := se.storage.Get(ctx, match.Key)
+		if err != nil {
+			// Deleted outside the engine; drop the stale entry
+			se.embeddings.Remove(match.Key)
+			continue
+		}
+		results = append(results, &SimilarTemplate{Template: tmpl, Score: match.Score})
+	}
+	return results, nil
+}
+
+// ReindexEmbeddings indexes the latest version of every stored template,
+// e.g. after configuring an indexer for an existing storage or after
+// changes made to the storage outside this engine. Unchanged templates are
+// not embedded again.
+func (se *StorageEngine) ReindexEmbeddings(ctx context.Context) error {
+	if se.embeddings == nil {
+		return NewEmbeddingNoIndexerError()
+	}
+
+	templates, err := se.storage.List(ctx, &TemplateQuery{})
+	if err != nil {
+		return err
+	}
+	docs := make(map[string]string, len(templates))
+	for _, tmpl := range templates {
+		docs[tmpl.Name] = templateSearchText(tmpl)
+	}
+	return se.embeddings.IndexAll(ctx, docs)
+}
+
+// updateEmbedding re-indexes the latest version of a template after it
+// changed, or removes it when no version is left. Embedding errors do not
+// fail the storage operation; ReindexEmbeddings repairs the index.
+func (se *StorageEngine) updateEmbedding(ctx context.Context, templateName string) {
+	if se.embeddings == nil {
+		return
+	}
+	tmpl, err := se.storage.Get(ctx, templateName)
+	if err != nil {
+		se.embeddings.Remove(templateName)
+		return
+	}
+	_ = se.embeddings.Index(ctx, templateName, templateSearchText(tmpl))
+}
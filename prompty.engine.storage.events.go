@@ -0,0 +1,532 @@
+package prompty
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// StorageEventType identifies a storage lifecycle event.
+type StorageEventType string
+
+// Storage event types.
+const (
+	// EventTemplateSaved is published when a save creates a new template.
+	EventTemplateSaved StorageEventType = "template.saved"
+
+	// EventVersionCreated is published when a save adds a version to an
+	// existing template.
+	EventVersionCreated StorageEventType = "version.created"
+
+	// EventTemplateDeleted is published when all versions of a template are deleted.
+	EventTemplateDeleted StorageEventType = "template.deleted"
+
+	// EventVersionDeleted is published when one version of a template is deleted.
+	EventVersionDeleted StorageEventType = "version.deleted"
+
+	// EventTemplateExecuted is published after each execution of a stored template.
+	EventTemplateExecuted StorageEventType = "template.executed"
+
+	// EventAccessDenied is published when a SecureStorageEngine denies access.
+	EventAccessDenied StorageEventType = "access.denied"
+)
+
+// Webhook and NATS delivery constants.
+const (
+	// WebhookHeaderEvent carries the event type of a webhook request.
+	WebhookHeaderEvent = "X-Prompty-Event"
+
+	// WebhookHeaderSignature carries the HMAC-SHA256 of the timestamp and
+	// the request body, as WebhookSignaturePrefix + hex digest.
+	WebhookHeaderSignature = "X-Prompty-Signature"
+
+	// WebhookHeaderTimestamp carries the signing time of a webhook request
+	// in Unix seconds. It is covered by the signature.
+	WebhookHeaderTimestamp = "X-Prompty-Timestamp"
+
+	// WebhookSignaturePrefix prefixes the hex digest in WebhookHeaderSignature.
+	WebhookSignaturePrefix = "sha256="
+
+	// WebhookDefaultTimeout is the request timeout of webhooks without a client.
+	WebhookDefaultTimeout = 10 * time.Second
+
+	// WebhookDefaultWorkers is the number of concurrent deliveries of a
+	// webhook sink without WebhookConfig.Workers.
+	WebhookDefaultWorkers = 4
+
+	// WebhookDefaultQueueSize is the number of events a webhook sink
+	// without WebhookConfig.QueueSize buffers before dropping events.
+	WebhookDefaultQueueSize = 256
+
+	// WebhookDefaultTolerance is the maximum age of a webhook timestamp
+	// accepted by VerifyWebhookSignature.
+	WebhookDefaultTolerance = 5 * time.Minute
+
+	// NATSDefaultSubjectPrefix prefixes the event type to form NATS subjects.
+	NATSDefaultSubjectPrefix = "prompty.events."
+)
+
+// Event delivery error messages.
+const (
+	ErrMsgWebhookStatus    = "webhook returned non-success status"
+	ErrMsgWebhookQueueFull = "webhook queue full, event dropped"
+	ErrMsgWebhookClosed    = "webhook sink closed"
+)
+
+// StorageEvent describes a storage lifecycle event.
+type StorageEvent struct {
+	// Type is the kind of event.
+	Type StorageEventType `json:"type"`
+
+	// Timestamp is when the event occurred.
+	Timestamp time.Time `json:"timestamp"`
+
+	// TemplateName is the name of the affected template.
+	TemplateName string `json:"template_name"`
+
+	// TemplateVersion is the affected version (0 when all versions are affected).
+	TemplateVersion int `json:"template_version,omitempty"`
+
+	// ContentHash is the content hash of the saved or executed version.
+	ContentHash string `json:"content_hash,omitempty"`
+
+	// TenantID is the tenant of the template or subject, if known.
+	TenantID string `json:"tenant_id,omitempty"`
+
+	// Subject is who caused the event (nil for unsecured operations).
+	Subject *AccessSubject `json:"subject,omitempty"`
+
+	// Operation is the denied operation of EventAccessDenied.
+	Operation Operation `json:"operation,omitempty"`
+
+	// Duration is the execution time of EventTemplateExecuted.
+	Duration time.Duration `json:"duration,omitempty"`
+
+	// Error is the execution error of EventTemplateExecuted, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// newStorageEvent creates an event of the given type for a template.
+func newStorageEvent(eventType StorageEventType, templateName string) *StorageEvent {
+	return &StorageEvent{
+		Type:         eventType,
+		Timestamp:    timeNow(),
+		TemplateName: templateName,
+	}
+}
+
+// EventSink receives storage events from an EventBus.
+// Implement this to integrate caches, search indexes or notifications.
+type EventSink interface {
+	// Publish delivers an event. It is called synchronously by the bus;
+	// implementations should be fast or deliver asynchronously.
+	Publish(ctx context.Context, event *StorageEvent) error
+}
+
+// eventSubscription is a sink registered for some event types.
+type eventSubscription struct {
+	id    uint64
+	sink  EventSink
+	types map[StorageEventType]bool // nil for all types
+}
+
+// EventBus publishes storage events to subscribed sinks.
+// It is safe for concurrent use.
+type EventBus struct {
+	mu     sync.RWMutex
+	subs   []eventSubscription
+	nextID uint64
+}
+
+// NewEventBus creates an event bus without subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers sink for the given event types, or for all events
+// if none are given. It returns a function that removes the subscription.
+func (b *EventBus) Subscribe(sink EventSink, types ...StorageEventType) func() {
+	sub := eventSubscription{sink: sink}
+	if len(types) > 0 {
+		sub.types = make(map[StorageEventType]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	b.nextID++
+	sub.id = b.nextID
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, s := range b.subs {
+			if s.id == sub.id {
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish delivers event to every subscribed sink.
+// Continues even if individual sinks fail, returning the last error.
+func (b *EventBus) Publish(ctx context.Context, event *StorageEvent) error {
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+
+	var lastErr error
+	for _, sub := range subs {
+		if sub.types != nil && !sub.types[event.Type] {
+			continue
+		}
+		if err := sub.sink.Publish(ctx, event); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// SubscriberCount returns the number of subscriptions.
+func (b *EventBus) SubscriberCount() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+// Events returns the event bus of the storage engine.
+func (se *StorageEngine) Events() *EventBus {
+	return se.events
+}
+
+// Subscribe registers sink for storage events of the given types, or for
+// all events if none are given. It returns a function that removes the
+// subscription.
+func (se *StorageEngine) Subscribe(sink EventSink, types ...StorageEventType) func() {
+	return se.events.Subscribe(sink, types...)
+}
+
+// publishSaved publishes the save event of a stored template.
+func (se *StorageEngine) publishSaved(ctx context.Context, tmpl *StoredTemplate) {
+	eventType := EventTemplateSaved
+	if tmpl.Version > 1 {
+		eventType = EventVersionCreated
+	}
+	event := newStorageEvent(eventType, tmpl.Name)
+	event.TemplateVersion = tmpl.Version
+	event.ContentHash = storedContentHash(tmpl)
+	event.TenantID = tmpl.TenantID
+	_ = se.events.Publish(ctx, event)
+}
+
+// publishDeleted publishes the deletion of a template (version 0) or of
+// one of its versions.
+func (se *StorageEngine) publishDeleted(ctx context.Context, templateName string, version int) {
+	eventType := EventTemplateDeleted
+	if version > 0 {
+		eventType = EventVersionDeleted
+	}
+	event := newStorageEvent(eventType, templateName)
+	event.TemplateVersion = version
+	_ = se.events.Publish(ctx, event)
+}
+
+// ChannelEventSink sends events to a channel.
+type ChannelEventSink struct {
+	ch chan<- *StorageEvent
+}
+
+// NewChannelEventSink creates a sink that sends events to a channel.
+// The channel should be buffered to prevent dropping events.
+func NewChannelEventSink(ch chan<- *StorageEvent) *ChannelEventSink {
+	return &ChannelEventSink{ch: ch}
+}
+
+// Publish sends the event to the channel.
+// Returns immediately if the channel is full (non-blocking).
+func (s *ChannelEventSink) Publish(ctx context.Context, event *StorageEvent) error {
+	select {
+	case s.ch <- event:
+	default:
+		// Channel full, drop event
+	}
+	return nil
+}
+
+// FuncEventSink wraps a function as an event sink.
+type FuncEventSink struct {
+	fn func(context.Context, *StorageEvent) error
+}
+
+// NewFuncEventSink creates an event sink from a function.
+func NewFuncEventSink(fn func(context.Context, *StorageEvent) error) *FuncEventSink {
+	return &FuncEventSink{fn: fn}
+}
+
+// Publish calls the wrapped function.
+func (s *FuncEventSink) Publish(ctx context.Context, event *StorageEvent) error {
+	return s.fn(ctx, event)
+}
+
+// WebhookConfig configures a WebhookEventSink.
+type WebhookConfig struct {
+	// URL receives the events as JSON POST requests (required).
+	URL string
+
+	// Secret signs the X-Prompty-Timestamp header and the request body with
+	// HMAC-SHA256 in the X-Prompty-Signature header. If empty, requests are
+	// unsigned.
+	Secret string
+
+	// Client sends the requests.
+	// If nil, a client with WebhookDefaultTimeout is used.
+	Client *http.Client
+
+	// Headers are added to every request.
+	Headers map[string]string
+
+	// OnError is called with delivery errors and dropped events (optional).
+	OnError func(event *StorageEvent, err error)
+
+	// Workers is the number of concurrent deliveries.
+	// If zero, WebhookDefaultWorkers is used.
+	Workers int
+
+	// QueueSize is the number of events buffered for delivery. Events
+	// published while the queue is full are dropped.
+	// If zero, WebhookDefaultQueueSize is used.
+	QueueSize int
+}
+
+// webhookDelivery is a queued webhook request.
+type webhookDelivery struct {
+	event *StorageEvent
+	body  []byte
+}
+
+// WebhookEventSink posts events to an HTTP endpoint. A fixed pool of
+// workers delivers queued events in the background, so slow endpoints
+// neither delay storage operations nor accumulate goroutines.
+type WebhookEventSink struct {
+	config  WebhookConfig
+	client  *http.Client
+	workers int
+	queue   chan webhookDelivery
+	running sync.WaitGroup // Workers
+
+	mu      sync.Mutex
+	idle    *sync.Cond // Signaled when pending drops to zero
+	started bool
+	closed  bool
+	pending int // Queued or in-flight deliveries
+}
+
+// NewWebhookEventSink creates a sink that posts events to a webhook.
+// The workers start with the first event; call Close to stop them.
+func NewWebhookEventSink(config WebhookConfig) *WebhookEventSink {
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: WebhookDefaultTimeout}
+	}
+	workers := config.Workers
+	if workers <= 0 {
+		workers = WebhookDefaultWorkers
+	}
+	queueSize := config.QueueSize
+	if queueSize <= 0 {
+		queueSize = WebhookDefaultQueueSize
+	}
+	s := &WebhookEventSink{
+		config:  config,
+		client:  client,
+		workers: workers,
+		queue:   make(chan webhookDelivery, queueSize),
+	}
+	s.idle = sync.NewCond(&s.mu)
+	return s
+}
+
+// Publish queues the event for delivery. It returns an error, which is
+// also passed to OnError, if the queue is full or the sink is closed.
+func (s *WebhookEventSink) Publish(ctx context.Context, event *StorageEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return s.dropped(event, ErrMsgWebhookClosed)
+	}
+	if !s.started {
+		s.started = true
+		s.startWorkers()
+	}
+	select {
+	case s.queue <- webhookDelivery{event: event, body: body}:
+		s.pending++
+		s.mu.Unlock()
+		return nil
+	default:
+		s.mu.Unlock()
+		return s.dropped(event, ErrMsgWebhookQueueFull)
+	}
+}
+
+// Wait blocks until all queued deliveries have finished. Events published
+// while Wait blocks are waited for too.
+func (s *WebhookEventSink) Wait() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.pending > 0 {
+		s.idle.Wait()
+	}
+}
+
+// Close delivers the queued events and stops the workers. Events published
+// after Close are dropped.
+func (s *WebhookEventSink) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	s.running.Wait()
+	return nil
+}
+
+// startWorkers starts the delivery workers. The caller must hold s.mu, so
+// the workers are counted before Close waits for them.
+func (s *WebhookEventSink) startWorkers() {
+	s.running.Add(s.workers)
+	for i := 0; i < s.workers; i++ {
+		go func() {
+			defer s.running.Done()
+			for d := range s.queue {
+				if err := s.deliver(d.event, d.body); err != nil && s.config.OnError != nil {
+					s.config.OnError(d.event, err)
+				}
+				s.done()
+			}
+		}()
+	}
+}
+
+// done records a finished delivery and wakes Wait when none are left.
+func (s *WebhookEventSink) done() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending--
+	if s.pending == 0 {
+		s.idle.Broadcast()
+	}
+}
+
+// dropped reports an event that was not queued.
+func (s *WebhookEventSink) dropped(event *StorageEvent, message string) error {
+	err := &StorageError{Message: message, Name: string(event.Type)}
+	if s.config.OnError != nil {
+		s.config.OnError(event, err)
+	}
+	return err
+}
+
+// deliver posts one event body.
+func (s *WebhookEventSink) deliver(event *StorageEvent, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(HTTPStorageHeaderContent, HTTPStorageContentType)
+	req.Header.Set(WebhookHeaderEvent, string(event.Type))
+	for k, v := range s.config.Headers {
+		req.Header.Set(k, v)
+	}
+	if s.config.Secret != "" {
+		timestamp := strconv.FormatInt(timeNow().Unix(), 10)
+		req.Header.Set(WebhookHeaderTimestamp, timestamp)
+		req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(s.config.Secret, timestamp, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return &StorageError{Message: ErrMsgWebhookStatus + ": " + strconv.Itoa(resp.StatusCode), Name: string(event.Type)}
+	}
+	return nil
+}
+
+// SignWebhookPayload returns the X-Prompty-Signature value of a webhook
+// request: the HMAC of the X-Prompty-Timestamp value, a dot and the body.
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return WebhookSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature reports whether signature is the valid
+// X-Prompty-Signature of timestamp and body, comparing in constant time,
+// and whether timestamp is within WebhookDefaultTolerance of now. Checking
+// the timestamp stops captured requests from being replayed later.
+// Receivers use it to authenticate webhook requests.
+func VerifyWebhookSignature(secret, timestamp string, body []byte, signature string) bool {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := timeNow().Sub(time.Unix(seconds, 0))
+	if age > WebhookDefaultTolerance || age < -WebhookDefaultTolerance {
+		return false
+	}
+	return hmac.Equal([]byte(SignWebhookPayload(secret, timestamp, body)), []byte(signature))
+}
+
+// NATSPublisher publishes a message to a NATS subject. *nats.Conn from
+// github.com/nats-io/nats.go implements it, so the NATS sink needs no
+// dependency on the client library.
+type NATSPublisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// NATSEventSink publishes events as JSON to NATS subjects named
+// prefix + event type, e.g. "prompty.events.template.saved".
+type NATSEventSink struct {
+	conn   NATSPublisher
+	prefix string
+}
+
+// NewNATSEventSink creates a sink publishing to NATS. If prefix is empty,
+// NATSDefaultSubjectPrefix is used.
+func NewNATSEventSink(conn NATSPublisher, prefix string) *NATSEventSink {
+	if prefix == "" {
+		prefix = NATSDefaultSubjectPrefix
+	}
+	return &NATSEventSink{conn: conn, prefix: prefix}
+}
+
+// Publish publishes the event to its subject.
+func (s *NATSEventSink) Publish(ctx context.Context, event *StorageEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.conn.Publish(s.prefix+string(event.Type), data)
+}
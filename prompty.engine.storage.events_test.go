@@ -0,0 +1,271 @@
+package prompty
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageEngine_Events(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("publishes lifecycle events", func(t *testing.T) {
+		se := MustNewStorageEngine(StorageEngineConfig{Storage: NewMemoryStorage()})
+		defer se.Close()
+
+		var events []*StorageEvent
+		se.Subscribe(NewFuncEventSink(func(ctx context.Context, event *StorageEvent) error {
+			events = append(events, event)
+			return nil
+		}))
+
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "greet", Source: "v1", TenantID: "acme"}))
+		require.NoError(t, se.SaveWithoutValidation(ctx, &StoredTemplate{Name: "greet", Source: "v2"}))
+		_, err := se.Execute(ctx, "greet", nil)
+		require.NoError(t, err)
+		require.NoError(t, se.DeleteVersion(ctx, "greet", 1))
+		require.NoError(t, se.Delete(ctx, "greet"))
+
+		types := make([]StorageEventType, len(events))
+		for i, e := range events {
+			types[i] = e.Type
+		}
+		assert.Equal(t, []StorageEventType{
+			EventTemplateSaved, EventVersionCreated, EventTemplateExecuted, EventVersionDeleted, EventTemplateDeleted,
+		}, types)
+
+		assert.Equal(t, "acme", events[0].TenantID)
+		assert.Equal(t, 1, events[0].TemplateVersion)
+		assert.Equal(t, 2, events[2].TemplateVersion)
+		assert.Empty(t, events[2].Error)
+		assert.Equal(t, 0, events[4].TemplateVersion)
+	})
+
+	t.Run("filters by type and unsubscribes", func(t *testing.T) {
+		se := MustNewStorageEngine(StorageEngineConfig{Storage: NewMemoryStorage()})
+		defer se.Close()
+
+		ch := make(chan *StorageEvent, 10)
+		unsubscribe := se.Subscribe(NewChannelEventSink(ch), EventTemplateDeleted)
+		assert.Equal(t, 1, se.Events().SubscriberCount())
+
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "a", Source: "x"}))
+		require.NoError(t, se.Delete(ctx, "a"))
+		require.Len(t, ch, 1)
+		assert.Equal(t, EventTemplateDeleted, (<-ch).Type)
+
+		unsubscribe()
+		assert.Equal(t, 0, se.Events().SubscriberCount())
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "b", Source: "x"}))
+		require.NoError(t, se.Delete(ctx, "b"))
+		assert.Empty(t, ch)
+	})
+
+	t.Run("publishes access denials", func(t *testing.T) {
+		bus := NewEventBus()
+		ch := make(chan *StorageEvent, 10)
+		bus.Subscribe(NewChannelEventSink(ch), EventAccessDenied)
+
+		se := MustNewSecureStorageEngine(SecureStorageEngineConfig{
+			StorageEngineConfig: StorageEngineConfig{Storage: NewMemoryStorage(), EventBus: bus},
+			AccessChecker:       NewDenyAllChecker("maintenance"),
+		})
+		defer se.Close()
+		require.NoError(t, se.StorageEngine.Save(ctx, &StoredTemplate{Name: "test", Source: "content"}))
+
+		_, err := se.ExecuteSecure(ctx, "test", nil, NewAccessSubject("usr_1").WithTenant("acme"))
+		require.Error(t, err)
+
+		require.Len(t, ch, 1)
+		event := <-ch
+		assert.Equal(t, OpExecute, event.Operation)
+		assert.Equal(t, "test", event.TemplateName)
+		assert.Equal(t, "acme", event.TenantID)
+		assert.Equal(t, "usr_1", event.Subject.ID)
+	})
+}
+
+func TestWebhookEventSink(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		bodies    [][]byte
+		headers   []http.Header
+		responses = http.StatusOK
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, body)
+		headers = append(headers, r.Header.Clone())
+		status := responses
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	t.Run("posts signed events", func(t *testing.T) {
+		sink := NewWebhookEventSink(WebhookConfig{
+			URL:     server.URL,
+			Secret:  "s3cret",
+			Headers: map[string]string{"X-Custom": "yes"},
+		})
+		require.NoError(t, sink.Publish(context.Background(), &StorageEvent{Type: EventTemplateSaved, TemplateName: "greet", TemplateVersion: 1}))
+		sink.Wait()
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.Len(t, bodies, 1)
+
+		var event StorageEvent
+		require.NoError(t, json.Unmarshal(bodies[0], &event))
+		assert.Equal(t, EventTemplateSaved, event.Type)
+		assert.Equal(t, "greet", event.TemplateName)
+
+		assert.Equal(t, string(EventTemplateSaved), headers[0].Get(WebhookHeaderEvent))
+		assert.Equal(t, "yes", headers[0].Get("X-Custom"))
+		timestamp := headers[0].Get(WebhookHeaderTimestamp)
+		signature := headers[0].Get(WebhookHeaderSignature)
+		assert.True(t, VerifyWebhookSignature("s3cret", timestamp, bodies[0], signature))
+		assert.False(t, VerifyWebhookSignature("other", timestamp, bodies[0], signature))
+		assert.False(t, VerifyWebhookSignature("s3cret", timestamp+"0", bodies[0], signature))
+	})
+
+	t.Run("reports failed deliveries", func(t *testing.T) {
+		mu.Lock()
+		responses = http.StatusInternalServerError
+		mu.Unlock()
+
+		var deliveryErr error
+		sink := NewWebhookEventSink(WebhookConfig{
+			URL:     server.URL,
+			OnError: func(event *StorageEvent, err error) { deliveryErr = err },
+		})
+		require.NoError(t, sink.Publish(context.Background(), &StorageEvent{Type: EventTemplateDeleted}))
+		sink.Wait()
+
+		require.Error(t, deliveryErr)
+		assert.Contains(t, deliveryErr.Error(), ErrMsgWebhookStatus)
+	})
+}
+
+func TestWebhookEventSink_BoundedQueue(t *testing.T) {
+	arrived := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+	}))
+	defer server.Close()
+
+	var dropped []error
+	sink := NewWebhookEventSink(WebhookConfig{
+		URL:       server.URL,
+		Workers:   1,
+		QueueSize: 1,
+		OnError:   func(event *StorageEvent, err error) { dropped = append(dropped, err) },
+	})
+	ctx := context.Background()
+	event := &StorageEvent{Type: EventTemplateSaved}
+
+	// The only worker is busy with the first event and the second fills the queue
+	require.NoError(t, sink.Publish(ctx, event))
+	<-arrived
+	require.NoError(t, sink.Publish(ctx, event))
+	err := sink.Publish(ctx, event)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgWebhookQueueFull)
+	require.Len(t, dropped, 1)
+
+	close(release)
+	<-arrived
+	require.NoError(t, sink.Close())
+
+	err = sink.Publish(ctx, event)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgWebhookClosed)
+}
+
+func TestWebhookEventSink_WaitWhilePublishing(t *testing.T) {
+	var delivered atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered.Add(1)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookEventSink(WebhookConfig{URL: server.URL, QueueSize: 200})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				assert.NoError(t, sink.Publish(context.Background(), &StorageEvent{Type: EventTemplateSaved}))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			sink.Wait()
+		}()
+	}
+	wg.Wait()
+	sink.Wait()
+	assert.Equal(t, int64(100), delivered.Load())
+	require.NoError(t, sink.Close())
+}
+
+func TestVerifyWebhookSignature_Timestamp(t *testing.T) {
+	now := time.Date(2025, 12, 10, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	body := []byte(`{"type":"template.saved"}`)
+	sign := func(at time.Time) (string, string) {
+		timestamp := strconv.FormatInt(at.Unix(), 10)
+		return timestamp, SignWebhookPayload("s3cret", timestamp, body)
+	}
+
+	timestamp, signature := sign(now.Add(-time.Minute))
+	assert.True(t, VerifyWebhookSignature("s3cret", timestamp, body, signature))
+
+	timestamp, signature = sign(now.Add(-WebhookDefaultTolerance - time.Second))
+	assert.False(t, VerifyWebhookSignature("s3cret", timestamp, body, signature))
+
+	timestamp, signature = sign(now.Add(WebhookDefaultTolerance + time.Second))
+	assert.False(t, VerifyWebhookSignature("s3cret", timestamp, body, signature))
+
+	assert.False(t, VerifyWebhookSignature("s3cret", "not a number", body, signature))
+}
+
+// recordingNATS is a NATSPublisher that records published messages.
+type recordingNATS struct {
+	subjects []string
+	data     [][]byte
+}
+
+func (n *recordingNATS) Publish(subject string, data []byte) error {
+	n.subjects = append(n.subjects, subject)
+	n.data = append(n.data, data)
+	return nil
+}
+
+func TestNATSEventSink(t *testing.T) {
+	conn := &recordingNATS{}
+
+	require.NoError(t, NewNATSEventSink(conn, "").Publish(context.Background(), &StorageEvent{Type: EventVersionCreated, TemplateName: "greet"}))
+	require.NoError(t, NewNATSEventSink(conn, "acme.").Publish(context.Background(), &StorageEvent{Type: EventTemplateExecuted}))
+
+	assert.Equal(t, []string{NATSDefaultSubjectPrefix + "version.created", "acme.template.executed"}, conn.subjects)
+	var event StorageEvent
+	require.NoError(t, json.Unmarshal(conn.data[0], &event))
+	assert.Equal(t, "greet", event.TemplateName)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"strings"
 	"sync"
+
+	"github.com/itsatony/go-prompty/v2/internal"
 )
 
 // StorageEngine combines template storage with the execution engine.
@@ -14,9 +16,23 @@ type StorageEngine struct {
 	storage TemplateStorage
 
 	// Parsed template cache
-	mu           sync.RWMutex
-	parsedCache  map[string]*parsedCacheEntry
+	mu           sync.Mutex
+	parsedCache  *boundedCache[*parsedCacheEntry]
+	parsedStats  ParsedCacheStats
 	cacheEnabled bool
+
+	// usage records each execution (nil disables usage accounting)
+	usage UsageRecorder
+
+	// stats counts executions per template and version
+	stats UsageStatsStore
+
+	// events publishes storage lifecycle events
+	events *EventBus
+
+	// embeddings indexes the latest version of each template for
+	// SearchSimilar (nil disables semantic search)
+	embeddings *EmbeddingIndexer
 }
 
 // parsedCacheEntry caches a parsed template with its version.
@@ -38,6 +54,39 @@ type StorageEngineConfig struct {
 	// By default (false), templates are cached and only re-parsed when their version changes.
 	// Set to true to disable caching and always re-parse templates.
 	DisableParsedTemplateCache bool
+
+	// ParsedCacheMaxEntries bounds the number of cached parsed templates.
+	// Default: 0 (no limit).
+	ParsedCacheMaxEntries int
+
+	// ParsedCacheMaxBytes bounds the estimated memory held by the cached
+	// parsed templates, i.e. their sources and ASTs. Templates larger than
+	// the limit are parsed on every execution. Default: 0 (no limit).
+	ParsedCacheMaxBytes int64
+
+	// ParsedCachePolicy selects the parsed template evicted when a limit is
+	// reached. Default: CachePolicyLRU.
+	ParsedCachePolicy CachePolicy
+
+	// UsageRecorder is invoked after each execution with its template,
+	// tenant, size, token estimate and duration.
+	// If nil, no usage is recorded.
+	UsageRecorder UsageRecorder
+
+	// UsageStatsStore keeps execution counts and last-executed times for
+	// UsageStats and FindStale.
+	// If nil, a MemoryUsageStatsStore is used.
+	UsageStatsStore UsageStatsStore
+
+	// EventBus receives the storage lifecycle events of the engine.
+	// If nil, the engine creates its own; share one bus across engines to
+	// subscribe once.
+	EventBus *EventBus
+
+	// EmbeddingIndexer keeps embeddings of the latest version of each
+	// template up to date on save and delete, enabling SearchSimilar.
+	// If nil, semantic search is disabled.
+	EmbeddingIndexer *EmbeddingIndexer
 }
 
 // NewStorageEngine creates a new StorageEngine with the given configuration.
@@ -58,12 +107,40 @@ func NewStorageEngine(config StorageEngineConfig) (*StorageEngine, error) {
 	// Caching is enabled by default (disabled only if explicitly set)
 	cacheEnabled := !config.DisableParsedTemplateCache
 
-	return &StorageEngine{
+	events := config.EventBus
+	if events == nil {
+		events = NewEventBus()
+	}
+
+	stats := config.UsageStatsStore
+	if stats == nil {
+		stats = NewMemoryUsageStatsStore()
+	}
+
+	policy := config.ParsedCachePolicy
+	if policy == "" {
+		policy = CachePolicyLRU
+	}
+
+	se := &StorageEngine{
 		engine:       engine,
 		storage:      config.Storage,
-		parsedCache:  make(map[string]*parsedCacheEntry),
+		parsedCache:  newBoundedCache[*parsedCacheEntry](max(config.ParsedCacheMaxEntries, 0), max(config.ParsedCacheMaxBytes, 0), policy),
 		cacheEnabled: cacheEnabled,
-	}, nil
+		usage:        config.UsageRecorder,
+		stats:        stats,
+		events:       events,
+		embeddings:   config.EmbeddingIndexer,
+	}
+	se.parsedStats = ParsedCacheStats{
+		Enabled:    cacheEnabled,
+		MaxEntries: se.parsedCache.maxEntries,
+		MaxBytes:   se.parsedCache.maxBytes,
+	}
+	se.parsedCache.onEvict = func(string, *parsedCacheEntry) {
+		se.parsedStats.Evictions++
+	}
+	return se, nil
 }
 
 // MustNewStorageEngine creates a new StorageEngine, panicking on error.
@@ -78,18 +155,30 @@ func MustNewStorageEngine(config StorageEngineConfig) *StorageEngine {
 // Execute executes a stored template by name with the given data.
 // This is the primary method for executing templates from storage.
 func (se *StorageEngine) Execute(ctx context.Context, templateName string, data map[string]any) (string, error) {
+	return se.executeLatest(ctx, templateName, data, nil)
+}
+
+// ExecuteVersion executes a specific version of a stored template.
+func (se *StorageEngine) ExecuteVersion(ctx context.Context, templateName string, version int, data map[string]any) (string, error) {
+	return se.executeVersion(ctx, templateName, version, data, nil)
+}
+
+// executeLatest executes the latest version of a stored template on behalf
+// of subject (nil when unknown).
+func (se *StorageEngine) executeLatest(ctx context.Context, templateName string, data map[string]any, subject *AccessSubject) (string, error) {
 	// Load and parse template
-	tmpl, err := se.loadAndParse(ctx, templateName)
+	tmpl, stored, err := se.loadAndParse(ctx, templateName)
 	if err != nil {
 		return "", err
 	}
 
 	// Execute the template
-	return tmpl.Execute(ctx, data)
+	return se.executeStored(ctx, tmpl, stored, templateName, data, subject)
 }
 
-// ExecuteVersion executes a specific version of a stored template.
-func (se *StorageEngine) ExecuteVersion(ctx context.Context, templateName string, version int, data map[string]any) (string, error) {
+// executeVersion executes a specific version of a stored template on behalf
+// of subject (nil when unknown).
+func (se *StorageEngine) executeVersion(ctx context.Context, templateName string, version int, data map[string]any, subject *AccessSubject) (string, error) {
 	// Load specific version (bypasses cache)
 	stored, err := se.storage.GetVersion(ctx, templateName, version)
 	if err != nil {
@@ -103,16 +192,36 @@ func (se *StorageEngine) ExecuteVersion(ctx context.Context, templateName string
 	}
 
 	// Execute the template
-	return tmpl.Execute(ctx, data)
+	return se.executeStored(ctx, tmpl, stored, templateName, data, subject)
+}
+
+// ExecuteBatch loads and parses the latest version of a stored template
+// once and renders it with each data item on a pool of workers, as
+// Engine.ExecuteBatch does. Every item counts as one execution for events
+// and usage accounting.
+func (se *StorageEngine) ExecuteBatch(ctx context.Context, templateName string, items []map[string]any, opts BatchOptions) ([]BatchResult, error) {
+	tmpl, stored, err := se.loadAndParse(ctx, templateName)
+	if err != nil {
+		return nil, err
+	}
+	return runBatch(ctx, len(items), opts, func(ctx context.Context, i int) (string, error) {
+		return se.executeStored(ctx, tmpl, stored, templateName, items[i], nil)
+	})
 }
 
 // ExecuteWithContext executes a stored template with a pre-built context.
 func (se *StorageEngine) ExecuteWithContext(ctx context.Context, templateName string, execCtx *Context) (string, error) {
-	tmpl, err := se.loadAndParse(ctx, templateName)
+	tmpl, stored, err := se.loadAndParse(ctx, templateName)
 	if err != nil {
 		return "", err
 	}
 
+	if execCtx.Engine() == nil {
+		execCtx = execCtx.WithEngine(se)
+		if len(execCtx.includeChain) == 0 {
+			execCtx.includeChain = []string{storedTemplateKey(templateName, stored.Version)}
+		}
+	}
 	return tmpl.ExecuteWithContext(ctx, execCtx)
 }
 
@@ -181,6 +290,8 @@ func (se *StorageEngine) Save(ctx context.Context, tmpl *StoredTemplate) error {
 	// Invalidate parsed cache
 	se.invalidateParsedCache(tmpl.Name)
 
+	se.publishSaved(ctx, tmpl)
+	se.updateEmbedding(ctx, tmpl.Name)
 	return nil
 }
 
@@ -192,6 +303,8 @@ func (se *StorageEngine) SaveWithoutValidation(ctx context.Context, tmpl *Stored
 	}
 
 	se.invalidateParsedCache(tmpl.Name)
+	se.publishSaved(ctx, tmpl)
+	se.updateEmbedding(ctx, tmpl.Name)
 	return nil
 }
 
@@ -202,6 +315,8 @@ func (se *StorageEngine) Delete(ctx context.Context, templateName string) error
 	}
 
 	se.invalidateParsedCache(templateName)
+	se.publishDeleted(ctx, templateName, 0)
+	se.updateEmbedding(ctx, templateName)
 	return nil
 }
 
@@ -212,6 +327,8 @@ func (se *StorageEngine) DeleteVersion(ctx context.Context, templateName string,
 	}
 
 	se.invalidateParsedCache(templateName)
+	se.publishDeleted(ctx, templateName, version)
+	se.updateEmbedding(ctx, templateName)
 	return nil
 }
 
@@ -254,7 +371,7 @@ func (se *StorageEngine) Storage() TemplateStorage {
 // Close closes the storage engine and underlying storage.
 func (se *StorageEngine) Close() error {
 	se.mu.Lock()
-	se.parsedCache = nil
+	se.parsedCache.purge()
 	se.mu.Unlock()
 
 	return se.storage.Close()
@@ -262,71 +379,100 @@ func (se *StorageEngine) Close() error {
 
 // ClearParsedCache clears the parsed template cache.
 func (se *StorageEngine) ClearParsedCache() {
+	se.PurgeParsedCache()
+}
+
+// PurgeParsedCache removes all parsed templates from the cache, releasing
+// their memory, and returns how many were removed.
+func (se *StorageEngine) PurgeParsedCache() int {
 	se.mu.Lock()
-	se.parsedCache = make(map[string]*parsedCacheEntry)
-	se.mu.Unlock()
+	defer se.mu.Unlock()
+
+	removed := se.parsedCache.purge()
+	se.parsedStats.Purges += int64(removed)
+	return removed
 }
 
 // ParsedCacheStats returns statistics about the parsed template cache.
 func (se *StorageEngine) ParsedCacheStats() ParsedCacheStats {
-	se.mu.RLock()
-	defer se.mu.RUnlock()
+	se.mu.Lock()
+	defer se.mu.Unlock()
 
-	return ParsedCacheStats{
-		Entries: len(se.parsedCache),
-		Enabled: se.cacheEnabled,
-	}
+	stats := se.parsedStats
+	stats.Entries = se.parsedCache.len()
+	stats.Bytes = se.parsedCache.bytes
+	return stats
 }
 
 // ParsedCacheStats contains parsed cache statistics.
 type ParsedCacheStats struct {
-	Entries int
-	Enabled bool
+	Entries    int
+	Enabled    bool
+	Hits       int64
+	Misses     int64 // Lookups that parsed the template, including version changes
+	Evictions  int64 // Entries removed to stay within the limits
+	Purges     int64 // Entries removed by PurgeParsedCache or ClearParsedCache
+	Bytes      int64 // Estimated memory held by the cached templates
+	MaxEntries int   // Entry limit; 0 for none
+	MaxBytes   int64 // Byte limit; 0 for none
 }
 
 // loadAndParse loads a template from storage and parses it.
 // Uses caching to avoid re-parsing unchanged templates.
-func (se *StorageEngine) loadAndParse(ctx context.Context, name string) (*Template, error) {
-	// Load from storage
+// It returns the parsed template with the stored template it came from.
+func (se *StorageEngine) loadAndParse(ctx context.Context, name string) (*Template, *StoredTemplate, error) {
+	// Load from storage, falling back to the newest version whose
+	// activation schedule includes now
 	stored, err := se.storage.Get(ctx, name)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if stored, err = se.activeVersion(ctx, stored); err != nil {
+		return nil, nil, err
 	}
 
 	// Check parsed cache
 	if se.cacheEnabled {
-		se.mu.RLock()
-		entry, ok := se.parsedCache[name]
-		se.mu.RUnlock()
-
-		if ok && entry.version == stored.Version {
-			return entry.template, nil
+		se.mu.Lock()
+		entry, ok := se.parsedCache.get(name)
+		if ok && entry.value.version == stored.Version {
+			se.parsedStats.Hits++
+			se.mu.Unlock()
+			return entry.value.template, stored, nil
 		}
+		se.parsedStats.Misses++
+		se.mu.Unlock()
 	}
 
 	// Parse the template
 	tmpl, err := se.engine.Parse(stored.Source)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Cache the parsed template
 	if se.cacheEnabled {
 		se.mu.Lock()
-		se.parsedCache[name] = &parsedCacheEntry{
+		se.parsedCache.add(name, &parsedCacheEntry{
 			template: tmpl,
 			version:  stored.Version,
-		}
+		}, templateSize(tmpl))
 		se.mu.Unlock()
 	}
 
-	return tmpl, nil
+	return tmpl, stored, nil
+}
+
+// templateSize estimates the memory held by a parsed template: its source,
+// which the AST shares text with, and its AST nodes.
+func templateSize(tmpl *Template) int64 {
+	return int64(len(tmpl.source)) + int64(internal.CountNodes(tmpl.ast.Children))*astNodeBytes + cacheEntryOverhead
 }
 
 // invalidateParsedCache removes a template from the parsed cache.
 func (se *StorageEngine) invalidateParsedCache(name string) {
 	se.mu.Lock()
-	delete(se.parsedCache, name)
+	se.parsedCache.remove(name)
 	se.mu.Unlock()
 }
 
@@ -463,7 +609,7 @@ func (se *StorageEngine) ExecuteLabeled(ctx context.Context, templateName, label
 		return "", err
 	}
 
-	return tmpl.Execute(ctx, data)
+	return se.executeStored(ctx, tmpl, stored, templateName, data, nil)
 }
 
 // ListLabels returns all labels for a template.
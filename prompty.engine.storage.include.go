@@ -0,0 +1,89 @@
+package prompty
+
+import (
+	"context"
+	"fmt"
+)
+
+// storedTemplateKeyFormat identifies a stored template version in the
+// include chain, distinct from a registered template of the same name.
+const storedTemplateKeyFormat = "%s@v%d"
+
+// storedTemplateKey returns the include chain key of a stored template version.
+func storedTemplateKey(name string, version int) string {
+	return fmt.Sprintf(storedTemplateKeyFormat, name, version)
+}
+
+// newContext creates the execution context for a stored template. The
+// StorageEngine serves its includes, so they may use source="storage", and
+// the template starts the include chain.
+func (se *StorageEngine) newContext(tmpl *Template, name string, version int, data map[string]any) *Context {
+	execCtx := tmpl.newContext(data)
+	execCtx.includeChain = []string{storedTemplateKey(name, version)}
+	return execCtx.WithEngine(se)
+}
+
+// ExecuteTemplate executes a template registered on the underlying engine,
+// keeping the StorageEngine available to its includes.
+// This implements the TemplateExecutor interface for nested template support.
+func (se *StorageEngine) ExecuteTemplate(ctx context.Context, name string, data map[string]any) (string, error) {
+	tmpl, ok := se.engine.GetTemplate(name)
+	if !ok {
+		return "", NewTemplateNotFoundError(name)
+	}
+	return se.engine.executeIncluded(ctx, tmpl, name, data, se)
+}
+
+// ExecuteStoredTemplate executes a stored template for
+// {~prompty.include source="storage" ~}. Version 0 selects the latest
+// version. Depth and cycle protection span registered and stored templates.
+// During a tenant execution (ExecuteOptions.TenantID) the tenant's
+// override of the template is preferred.
+func (se *StorageEngine) ExecuteStoredTemplate(ctx context.Context, name string, version int, data map[string]any) (string, error) {
+	name, err := se.ResolveTenantName(ctx, tenantFromContext(ctx), name)
+	if err != nil {
+		return "", err
+	}
+
+	var tmpl *Template
+	if version == 0 {
+		loaded, stored, err := se.loadAndParse(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		tmpl, version = loaded, stored.Version
+	} else {
+		stored, err := se.storage.GetVersion(ctx, name, version)
+		if err != nil {
+			return "", err
+		}
+		if tmpl, err = se.engine.Parse(stored.Source); err != nil {
+			return "", err
+		}
+	}
+	return se.engine.executeIncluded(ctx, tmpl, storedTemplateKey(name, version), data, se)
+}
+
+// HasTemplate checks if a template is registered on the underlying engine.
+// This implements the TemplateExecutor interface.
+func (se *StorageEngine) HasTemplate(name string) bool {
+	return se.engine.HasTemplate(name)
+}
+
+// MaxDepth returns the underlying engine's maximum nesting depth.
+// This implements the TemplateExecutor interface.
+func (se *StorageEngine) MaxDepth() int {
+	return se.engine.MaxDepth()
+}
+
+// GetTemplateSource returns the source of a template registered on the
+// underlying engine. This implements the TemplateExecutor interface.
+func (se *StorageEngine) GetTemplateSource(name string) (string, bool) {
+	return se.engine.GetTemplateSource(name)
+}
+
+// AllowsDynamicInclude applies the underlying engine's dynamic include
+// allowlist.
+func (se *StorageEngine) AllowsDynamicInclude(name string) bool {
+	return se.engine.AllowsDynamicInclude(name)
+}
@@ -0,0 +1,150 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newIncludeStorageEngine(t *testing.T, templates ...*StoredTemplate) *StorageEngine {
+	t.Helper()
+	se := MustNewStorageEngine(StorageEngineConfig{Storage: NewMemoryStorage()})
+	t.Cleanup(func() { _ = se.Close() })
+	for _, tmpl := range templates {
+		require.NoError(t, se.Save(context.Background(), tmpl))
+	}
+	return se
+}
+
+func TestStorageEngine_IncludeFromStorage(t *testing.T) {
+	ctx := context.Background()
+	se := newIncludeStorageEngine(t,
+		&StoredTemplate{Name: "greeting", Source: `Hello v1, {~prompty.var name="who" /~}`},
+		&StoredTemplate{Name: "greeting", Source: `Hi v2, {~prompty.var name="who" /~}`},
+		&StoredTemplate{Name: "page", Source: `[{~prompty.include template="greeting" source="storage" who="Ada" /~}] [{~prompty.include template="greeting" source="storage" version="1" who="Bo" /~}]`},
+	)
+
+	out, err := se.Execute(ctx, "page", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[Hi v2, Ada] [Hello v1, Bo]", out)
+
+	// The page's own version is selectable as well
+	out, err = se.ExecuteVersion(ctx, "page", 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[Hi v2, Ada] [Hello v1, Bo]", out)
+}
+
+func TestStorageEngine_IncludeMixedSources(t *testing.T) {
+	ctx := context.Background()
+	se := newIncludeStorageEngine(t,
+		&StoredTemplate{Name: "signature", Source: `-- {~prompty.var name="team" /~}`},
+		&StoredTemplate{Name: "mail", Source: `Body {~prompty.include template="footer" /~}`},
+	)
+	// A registered template that includes a stored one
+	se.Engine().MustRegisterTemplate("footer", `{~prompty.include template="signature" source="storage" team="Support" /~}`)
+
+	out, err := se.Execute(ctx, "mail", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Body -- Support", out)
+}
+
+func TestStorageEngine_IncludeTemplateExpr(t *testing.T) {
+	se := MustNewStorageEngine(StorageEngineConfig{
+		Storage: NewMemoryStorage(),
+		Engine:  MustNew(WithDynamicIncludeAllowlist("faq-*")),
+	})
+	defer se.Close()
+	ctx := context.Background()
+	require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "faq-acme", Source: `ACME FAQ`}))
+	require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "billing", Source: `internal`}))
+	require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "page", Source: `{~prompty.include template_expr="'faq-' + tenant" source="storage" /~}`}))
+	require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "leak", Source: `{~prompty.include template_expr="tenant" source="storage" /~}`}))
+
+	out, err := se.Execute(ctx, "page", map[string]any{"tenant": "acme"})
+	require.NoError(t, err)
+	assert.Equal(t, "ACME FAQ", out)
+
+	_, err = se.Execute(ctx, "leak", map[string]any{"tenant": "billing"})
+	require.Error(t, err)
+}
+
+func TestStorageEngine_IncludeVersionIsReservedOnlyForStorage(t *testing.T) {
+	se := newIncludeStorageEngine(t,
+		&StoredTemplate{Name: "page", Source: `{~prompty.include template="badge" version="beta" /~}`},
+	)
+	se.Engine().MustRegisterTemplate("badge", `<{~prompty.var name="version" /~}>`)
+
+	out, err := se.Execute(context.Background(), "page", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "<beta>", out)
+}
+
+func TestStorageEngine_IncludeCycle(t *testing.T) {
+	ctx := context.Background()
+	se := newIncludeStorageEngine(t,
+		&StoredTemplate{Name: "a", Source: `a{~prompty.include template="b" source="storage" /~}`},
+		&StoredTemplate{Name: "b", Source: `b{~prompty.include template="bridge" /~}`},
+	)
+	se.Engine().MustRegisterTemplate("bridge", `{~prompty.include template="a" source="storage" /~}`)
+
+	_, err := se.Execute(ctx, "a", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgTemplateIncludeCycle)
+
+	// Different versions of one template are distinct
+	require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "doc", Source: `v1`}))
+	require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "doc", Source: `v2+{~prompty.include template="doc" source="storage" version="1" /~}`}))
+	out, err := se.Execute(ctx, "doc", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "v2+v1", out)
+}
+
+func TestStorageEngine_IncludeDepthSpansSources(t *testing.T) {
+	se := MustNewStorageEngine(StorageEngineConfig{Storage: NewMemoryStorage(), Engine: MustNew(WithMaxDepth(2))})
+	defer se.Close()
+	ctx := context.Background()
+	require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "s1", Source: `{~prompty.include template="r1" /~}`}))
+	require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "s2", Source: `{~prompty.include template="r2" /~}`}))
+	se.Engine().MustRegisterTemplate("r1", `{~prompty.include template="s2" source="storage" /~}`)
+	se.Engine().MustRegisterTemplate("r2", `deep`)
+
+	_, err := se.Execute(ctx, "s1", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "depth")
+}
+
+func TestInclude_StorageErrors(t *testing.T) {
+	ctx := context.Background()
+
+	// A plain Engine has no storage
+	_, err := MustNew().Execute(ctx, `{~prompty.include template="x" source="storage" /~}`, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "storage not available")
+
+	se := newIncludeStorageEngine(t)
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{name: "missing stored template", source: `{~prompty.include template="nope" source="storage" /~}`, want: "not found"},
+		{name: "missing version", source: `{~prompty.include template="page" source="storage" version="9" /~}`, want: "not found"},
+		{name: "bad version", source: `{~prompty.include template="page" source="storage" version="latest" /~}`, want: "version"},
+		{name: "bad source", source: `{~prompty.include template="page" source="disk" /~}`, want: "source"},
+	}
+	require.NoError(t, se.SaveWithoutValidation(ctx, &StoredTemplate{Name: "page", Source: "p"}))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, se.SaveWithoutValidation(ctx, &StoredTemplate{Name: "host", Source: tt.source}))
+			_, err := se.Execute(ctx, "host", nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+
+	// Save validates the include attributes
+	err = se.Save(ctx, &StoredTemplate{Name: "invalid", Source: `{~prompty.include template="page" source="storage" version="0" /~}`})
+	require.Error(t, err)
+}
@@ -0,0 +1,178 @@
+package prompty
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// UsageStatsStore persists execution counts and last-executed times per
+// template and version for StorageEngine.UsageStats and FindStale.
+// Implement it over a database to keep statistics across restarts.
+type UsageStatsStore interface {
+	// RecordExecution counts one execution of a template version at the
+	// given time. It is called synchronously after each execution;
+	// implementations should be fast or buffer.
+	RecordExecution(ctx context.Context, templateName string, version int, at time.Time) error
+
+	// Stats returns the statistics of a template, or nil if it was never
+	// executed.
+	Stats(ctx context.Context, templateName string) (*TemplateUsageStats, error)
+}
+
+// TemplateUsageStats is the execution history of a template.
+type TemplateUsageStats struct {
+	// TemplateName is the template.
+	TemplateName string
+
+	// Executions is the number of executions of all versions.
+	Executions int64
+
+	// LastExecuted is the time of the latest execution (zero if never).
+	LastExecuted time.Time
+
+	// Versions holds the statistics per version, ordered by version.
+	Versions []VersionUsageStats
+}
+
+// VersionUsageStats is the execution history of a template version.
+type VersionUsageStats struct {
+	// Version is the template version.
+	Version int
+
+	// Executions is the number of executions of the version.
+	Executions int64
+
+	// LastExecuted is the time of the latest execution of the version.
+	LastExecuted time.Time
+}
+
+// StaleTemplate is a template in a FindStale report.
+type StaleTemplate struct {
+	// TemplateName is the template.
+	TemplateName string
+
+	// Version is the latest version.
+	Version int
+
+	// UpdatedAt is when the latest version was saved.
+	UpdatedAt time.Time
+
+	// Executions is the number of executions of all versions.
+	Executions int64
+
+	// LastExecuted is the time of the latest execution (zero if never).
+	LastExecuted time.Time
+}
+
+// MemoryUsageStatsStore keeps usage statistics in memory. It is the
+// default UsageStatsStore of a StorageEngine and is safe for concurrent use.
+type MemoryUsageStatsStore struct {
+	mu    sync.RWMutex
+	stats map[string]*TemplateUsageStats
+}
+
+// NewMemoryUsageStatsStore creates an empty in-memory statistics store.
+func NewMemoryUsageStatsStore() *MemoryUsageStatsStore {
+	return &MemoryUsageStatsStore{stats: make(map[string]*TemplateUsageStats)}
+}
+
+// RecordExecution counts one execution of a template version.
+func (s *MemoryUsageStatsStore) RecordExecution(ctx context.Context, templateName string, version int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, ok := s.stats[templateName]
+	if !ok {
+		stats = &TemplateUsageStats{TemplateName: templateName}
+		s.stats[templateName] = stats
+	}
+	stats.Executions++
+	if at.After(stats.LastExecuted) {
+		stats.LastExecuted = at
+	}
+
+	i := sort.Search(len(stats.Versions), func(i int) bool { return stats.Versions[i].Version >= version })
+	if i == len(stats.Versions) || stats.Versions[i].Version != version {
+		stats.Versions = append(stats.Versions, VersionUsageStats{})
+		copy(stats.Versions[i+1:], stats.Versions[i:])
+		stats.Versions[i] = VersionUsageStats{Version: version}
+	}
+	stats.Versions[i].Executions++
+	if at.After(stats.Versions[i].LastExecuted) {
+		stats.Versions[i].LastExecuted = at
+	}
+	return nil
+}
+
+// Stats returns a copy of the statistics of a template, or nil.
+func (s *MemoryUsageStatsStore) Stats(ctx context.Context, templateName string) (*TemplateUsageStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats, ok := s.stats[templateName]
+	if !ok {
+		return nil, nil
+	}
+	clone := *stats
+	clone.Versions = append([]VersionUsageStats(nil), stats.Versions...)
+	return &clone, nil
+}
+
+// UsageStats returns the execution counts and last-executed times of a
+// template and its versions. A template that was never executed has zero
+// executions.
+func (se *StorageEngine) UsageStats(ctx context.Context, templateName string) (*TemplateUsageStats, error) {
+	stats, err := se.stats.Stats(ctx, templateName)
+	if err != nil {
+		return nil, err
+	}
+	if stats == nil {
+		stats = &TemplateUsageStats{TemplateName: templateName}
+	}
+	return stats, nil
+}
+
+// FindStale reports the stored templates not executed within olderThan:
+// those last executed before the cutoff, and those never executed whose
+// latest version was saved before it. Templates never executed come first,
+// then by last execution and name. Statistics only cover executions seen
+// by the engine's UsageStatsStore, so use a persistent store, or a
+// reporting period shorter than the engine's uptime.
+func (se *StorageEngine) FindStale(ctx context.Context, olderThan time.Duration) ([]*StaleTemplate, error) {
+	templates, err := se.storage.List(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := timeNow().Add(-olderThan)
+	stale := make([]*StaleTemplate, 0)
+	for _, tmpl := range templates {
+		stats, err := se.UsageStats(ctx, tmpl.Name)
+		if err != nil {
+			return nil, err
+		}
+		if stats.Executions > 0 && !stats.LastExecuted.Before(cutoff) {
+			continue
+		}
+		if stats.Executions == 0 && !tmpl.UpdatedAt.Before(cutoff) {
+			continue
+		}
+		stale = append(stale, &StaleTemplate{
+			TemplateName: tmpl.Name,
+			Version:      tmpl.Version,
+			UpdatedAt:    tmpl.UpdatedAt,
+			Executions:   stats.Executions,
+			LastExecuted: stats.LastExecuted,
+		})
+	}
+
+	sort.Slice(stale, func(i, j int) bool {
+		if !stale[i].LastExecuted.Equal(stale[j].LastExecuted) {
+			return stale[i].LastExecuted.Before(stale[j].LastExecuted)
+		}
+		return stale[i].TemplateName < stale[j].TemplateName
+	})
+	return stale, nil
+}
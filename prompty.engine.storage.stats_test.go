@@ -0,0 +1,66 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageEngine_UsageStats(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Round(0)
+	restore := timeNow
+	defer func() { timeNow = restore }()
+	at := func(days int) { timeNow = func() time.Time { return now.AddDate(0, 0, days) } }
+
+	se := MustNewStorageEngine(StorageEngineConfig{Storage: NewMemoryStorage()})
+	defer se.Close()
+	for _, tmpl := range []*StoredTemplate{
+		{Name: "used", Source: "v1"},
+		{Name: "used", Source: "v2"},
+		{Name: "old", Source: "old"},
+		{Name: "unused", Source: "unused"},
+	} {
+		require.NoError(t, se.Save(ctx, tmpl))
+	}
+
+	at(0)
+	_, err := se.Execute(ctx, "old", nil)
+	require.NoError(t, err)
+	at(15)
+	_, err = se.Execute(ctx, "used", nil)
+	require.NoError(t, err)
+	_, err = se.Execute(ctx, "used", nil)
+	require.NoError(t, err)
+	_, err = se.ExecuteVersion(ctx, "used", 1, nil)
+	require.NoError(t, err)
+
+	stats, err := se.UsageStats(ctx, "used")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stats.Executions)
+	assert.Equal(t, now.AddDate(0, 0, 15), stats.LastExecuted)
+	require.Len(t, stats.Versions, 2)
+	assert.Equal(t, VersionUsageStats{Version: 1, Executions: 1, LastExecuted: now.AddDate(0, 0, 15)}, stats.Versions[0])
+	assert.Equal(t, int64(2), stats.Versions[1].Executions)
+
+	stats, err = se.UsageStats(ctx, "unused")
+	require.NoError(t, err)
+	assert.Equal(t, &TemplateUsageStats{TemplateName: "unused"}, stats)
+
+	at(20)
+	stale, err := se.FindStale(ctx, 10*24*time.Hour)
+	require.NoError(t, err)
+	require.Len(t, stale, 2)
+	assert.Equal(t, "unused", stale[0].TemplateName, "never executed first")
+	assert.Zero(t, stale[0].Executions)
+	assert.Equal(t, "old", stale[1].TemplateName)
+	assert.Equal(t, now, stale[1].LastExecuted)
+
+	// Templates saved within the period are not stale yet
+	stale, err = se.FindStale(ctx, 30*24*time.Hour)
+	require.NoError(t, err)
+	assert.Empty(t, stale)
+}
@@ -0,0 +1,192 @@
+package prompty
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// Tenant template namespaces
+const (
+	// TenantNamespacePrefix starts the name of a tenant's override of a
+	// template: "tenant:acme/greeting" overrides "greeting" for tenant acme.
+	TenantNamespacePrefix = "tenant:"
+	// TenantNamespaceSeparator separates the tenant from the template name.
+	TenantNamespaceSeparator = "/"
+)
+
+// ExecuteOptions configures StorageEngine.ExecuteWithOptions.
+type ExecuteOptions struct {
+	// TenantID executes the tenant's override of the template
+	// (TenantTemplateName) when it has one, and the global template
+	// otherwise. Stored templates included with source="storage" are
+	// resolved the same way.
+	TenantID string
+
+	// Version executes a specific version of the resolved template
+	// (0 = latest).
+	Version int
+}
+
+// tenantNamespaceKey is the context key of the tenant whose overrides
+// apply to stored includes.
+type tenantNamespaceKey struct{}
+
+// TenantTemplateName returns the name of a tenant's override of a
+// template, e.g. "tenant:acme/greeting". An empty tenant returns name.
+func TenantTemplateName(tenantID, name string) string {
+	if tenantID == "" {
+		return name
+	}
+	return TenantNamespacePrefix + tenantID + TenantNamespaceSeparator + name
+}
+
+// SplitTenantTemplateName splits a tenant override name into its tenant and
+// the name of the template it overrides. Global names return an empty
+// tenant and the name unchanged.
+func SplitTenantTemplateName(name string) (tenantID, baseName string) {
+	rest, ok := strings.CutPrefix(name, TenantNamespacePrefix)
+	if !ok {
+		return "", name
+	}
+	tenantID, baseName, ok = strings.Cut(rest, TenantNamespaceSeparator)
+	if !ok || tenantID == "" {
+		return "", name
+	}
+	return tenantID, baseName
+}
+
+// ExecuteWithOptions executes a stored template with the given options.
+// With a TenantID, the tenant's override of the template is executed if
+// it exists, and the global template otherwise.
+func (se *StorageEngine) ExecuteWithOptions(ctx context.Context, templateName string, data map[string]any, opts *ExecuteOptions) (string, error) {
+	if opts == nil {
+		opts = &ExecuteOptions{}
+	}
+	if opts.TenantID != "" {
+		ctx = context.WithValue(ctx, tenantNamespaceKey{}, opts.TenantID)
+	}
+
+	name, err := se.ResolveTenantName(ctx, opts.TenantID, templateName)
+	if err != nil {
+		return "", err
+	}
+	if opts.Version > 0 {
+		return se.executeVersion(ctx, name, opts.Version, data, nil)
+	}
+	return se.executeLatest(ctx, name, data, nil)
+}
+
+// ResolveTenantName returns the name of the template a tenant sees: its
+// override of name if one is stored, and name otherwise.
+func (se *StorageEngine) ResolveTenantName(ctx context.Context, tenantID, name string) (string, error) {
+	if tenantID == "" {
+		return name, nil
+	}
+	override := TenantTemplateName(tenantID, name)
+	exists, err := se.storage.Exists(ctx, override)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return override, nil
+	}
+	return name, nil
+}
+
+// GetForTenant retrieves the latest version of the template a tenant sees:
+// its override of name, or else the global template.
+func (se *StorageEngine) GetForTenant(ctx context.Context, tenantID, name string) (*StoredTemplate, error) {
+	resolved, err := se.ResolveTenantName(ctx, tenantID, name)
+	if err != nil {
+		return nil, err
+	}
+	return se.storage.Get(ctx, resolved)
+}
+
+// SaveForTenant saves tmpl as the tenant's override of the global template
+// tmpl.Name. The name is replaced with the namespaced name and TenantID is
+// set to the tenant.
+func (se *StorageEngine) SaveForTenant(ctx context.Context, tenantID string, tmpl *StoredTemplate) error {
+	if tenantID == "" || tmpl.Name == "" {
+		return &StorageError{Message: ErrMsgInvalidTemplateName, Name: tmpl.Name}
+	}
+	tmpl.Name = TenantTemplateName(tenantID, tmpl.Name)
+	tmpl.TenantID = tenantID
+	return se.Save(ctx, tmpl)
+}
+
+// ListForTenant returns the effective set of templates of a tenant: its
+// overrides, and the global templates it does not override. Other tenants'
+// overrides are left out. Overrides keep their namespaced names; name
+// filters of the query apply to the global name (SplitTenantTemplateName),
+// and results are ordered by it unless the query sets SortBy.
+func (se *StorageEngine) ListForTenant(ctx context.Context, tenantID string, query *TemplateQuery) ([]*StoredTemplate, error) {
+	q := TemplateQuery{}
+	if query != nil {
+		q = *query
+	}
+	limit, offset := q.Limit, q.Offset
+	namePrefix, nameContains := q.NamePrefix, q.NameContains
+	q.Limit, q.Offset, q.NamePrefix, q.NameContains, q.TenantID = 0, 0, "", "", ""
+
+	templates, err := se.storage.List(ctx, &q)
+	if err != nil {
+		return nil, err
+	}
+
+	// Overrides shadow their global template even when the query filters
+	// the override itself out
+	overridden := make(map[string]bool)
+	if tenantID != "" {
+		overrides, err := se.storage.List(ctx, &TemplateQuery{NamePrefix: TenantTemplateName(tenantID, "")})
+		if err != nil {
+			return nil, err
+		}
+		for _, tmpl := range overrides {
+			_, base := SplitTenantTemplateName(tmpl.Name)
+			overridden[base] = true
+		}
+	}
+
+	results := make([]*StoredTemplate, 0, len(templates))
+	for _, tmpl := range templates {
+		owner, base := SplitTenantTemplateName(tmpl.Name)
+		switch {
+		case owner != "" && owner != tenantID:
+			continue
+		case owner == "" && overridden[base]:
+			continue
+		case namePrefix != "" && !strings.HasPrefix(base, namePrefix):
+			continue
+		case nameContains != "" && !strings.Contains(base, nameContains):
+			continue
+		}
+		results = append(results, tmpl)
+	}
+
+	if q.SortBy == "" {
+		sort.SliceStable(results, func(i, j int) bool {
+			_, a := SplitTenantTemplateName(results[i].Name)
+			_, b := SplitTenantTemplateName(results[j].Name)
+			return a < b
+		})
+	}
+	if offset > 0 {
+		if offset >= len(results) {
+			return []*StoredTemplate{}, nil
+		}
+		results = results[offset:]
+	}
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// tenantFromContext returns the tenant whose overrides apply to stored
+// includes of the current execution.
+func tenantFromContext(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantNamespaceKey{}).(string)
+	return tenantID
+}
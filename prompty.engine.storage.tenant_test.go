@@ -0,0 +1,104 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantTemplateName(t *testing.T) {
+	assert.Equal(t, "tenant:acme/greeting", TenantTemplateName("acme", "greeting"))
+	assert.Equal(t, "greeting", TenantTemplateName("", "greeting"))
+
+	tenant, base := SplitTenantTemplateName("tenant:acme/mail/footer")
+	assert.Equal(t, "acme", tenant)
+	assert.Equal(t, "mail/footer", base)
+
+	for _, name := range []string{"greeting", "tenant:", "tenant:/greeting", "tenant:acme"} {
+		tenant, base = SplitTenantTemplateName(name)
+		assert.Empty(t, tenant, name)
+		assert.Equal(t, name, base)
+	}
+}
+
+func TestStorageEngine_ExecuteWithOptions_TenantOverride(t *testing.T) {
+	ctx := context.Background()
+	se := newIncludeStorageEngine(t,
+		&StoredTemplate{Name: "greeting", Source: `Hello {~prompty.var name="who" /~}`},
+		&StoredTemplate{Name: "footer", Source: `-- Global`},
+		&StoredTemplate{Name: "page", Source: `{~prompty.include template="greeting" source="storage" who="Ada" /~} {~prompty.include template="footer" source="storage" /~}`},
+	)
+	require.NoError(t, se.SaveForTenant(ctx, "acme", &StoredTemplate{Name: "greeting", Source: `Welcome to ACME, {~prompty.var name="who" /~}`}))
+	require.NoError(t, se.SaveForTenant(ctx, "acme", &StoredTemplate{Name: "greeting", Source: `Greetings from ACME, {~prompty.var name="who" /~}`}))
+
+	out, err := se.ExecuteWithOptions(ctx, "greeting", map[string]any{"who": "Bo"}, &ExecuteOptions{TenantID: "acme"})
+	require.NoError(t, err)
+	assert.Equal(t, "Greetings from ACME, Bo", out)
+
+	out, err = se.ExecuteWithOptions(ctx, "greeting", map[string]any{"who": "Bo"}, &ExecuteOptions{TenantID: "acme", Version: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome to ACME, Bo", out)
+
+	// Other tenants and tenant-less executions see the global template
+	out, err = se.ExecuteWithOptions(ctx, "greeting", map[string]any{"who": "Bo"}, &ExecuteOptions{TenantID: "globex"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello Bo", out)
+	out, err = se.ExecuteWithOptions(ctx, "greeting", map[string]any{"who": "Bo"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello Bo", out)
+
+	// Stored includes resolve overrides of the executing tenant
+	out, err = se.ExecuteWithOptions(ctx, "page", nil, &ExecuteOptions{TenantID: "acme"})
+	require.NoError(t, err)
+	assert.Equal(t, "Greetings from ACME, Ada -- Global", out)
+	out, err = se.Execute(ctx, "page", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello Ada -- Global", out)
+
+	stored, err := se.GetForTenant(ctx, "acme", "greeting")
+	require.NoError(t, err)
+	assert.Equal(t, "tenant:acme/greeting", stored.Name)
+	assert.Equal(t, "acme", stored.TenantID)
+	assert.Equal(t, 2, stored.Version)
+}
+
+func TestStorageEngine_ListForTenant(t *testing.T) {
+	ctx := context.Background()
+	se := newIncludeStorageEngine(t,
+		&StoredTemplate{Name: "greeting", Source: `Hello`},
+		&StoredTemplate{Name: "farewell", Source: `Bye`},
+		&StoredTemplate{Name: "support-intro", Source: `Support`},
+	)
+	require.NoError(t, se.SaveForTenant(ctx, "acme", &StoredTemplate{Name: "greeting", Source: `ACME hello`}))
+	require.NoError(t, se.SaveForTenant(ctx, "acme", &StoredTemplate{Name: "support-faq", Source: `ACME FAQ`}))
+	require.NoError(t, se.SaveForTenant(ctx, "globex", &StoredTemplate{Name: "farewell", Source: `Globex bye`}))
+
+	names := func(templates []*StoredTemplate) []string {
+		out := make([]string, len(templates))
+		for i, tmpl := range templates {
+			out[i] = tmpl.Name
+		}
+		return out
+	}
+
+	list, err := se.ListForTenant(ctx, "acme", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"farewell", "tenant:acme/greeting", "tenant:acme/support-faq", "support-intro"}, names(list))
+
+	list, err = se.ListForTenant(ctx, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"farewell", "greeting", "support-intro"}, names(list))
+
+	list, err = se.ListForTenant(ctx, "acme", &TemplateQuery{NamePrefix: "support-", Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"support-intro"}, names(list))
+
+	list, err = se.ListForTenant(ctx, "globex", &TemplateQuery{NameContains: "well"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tenant:globex/farewell"}, names(list))
+
+	err = se.SaveForTenant(ctx, "", &StoredTemplate{Name: "greeting", Source: `x`})
+	require.Error(t, err)
+}
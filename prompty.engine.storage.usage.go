@@ -0,0 +1,303 @@
+package prompty
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// UsageRecorder is the interface for usage accounting implementations.
+// A StorageEngine configured with a UsageRecorder invokes it after each
+// execution, so per-tenant billing needs no wrapper around every call.
+type UsageRecorder interface {
+	// Record records one template execution.
+	// It is called synchronously; implementations should be fast or buffer.
+	Record(ctx context.Context, event *UsageEvent) error
+}
+
+// UsageEvent describes one execution of a stored template.
+type UsageEvent struct {
+	// Timestamp is when the execution started.
+	Timestamp time.Time
+
+	// TemplateName is the name of the executed template.
+	TemplateName string
+
+	// TemplateVersion is the executed version.
+	TemplateVersion int
+
+	// TenantID is the tenant billed for the execution: the subject's tenant,
+	// or the template's tenant when there is no subject.
+	TenantID string
+
+	// Subject is who executed the template (nil for unsecured executions).
+	Subject *AccessSubject
+
+	// OutputBytes is the size of the rendered output in bytes.
+	OutputBytes int
+
+	// EstimatedTokens is the generic token estimate of the rendered output.
+	EstimatedTokens int
+
+	// Duration is how long the execution took.
+	Duration time.Duration
+
+	// Error is the execution error, if any.
+	Error error
+}
+
+// executeStored executes a loaded stored template, publishes
+// EventTemplateExecuted and records its usage.
+func (se *StorageEngine) executeStored(ctx context.Context, tmpl *Template, stored *StoredTemplate, templateName string, data map[string]any, subject *AccessSubject) (string, error) {
+	if recorder := recorderFrom(ctx); recorder != nil {
+		recorder.identify(templateName, stored.Version)
+	}
+	start := timeNow()
+	result, err := tmpl.ExecuteWithContext(ctx, se.newContext(tmpl, templateName, stored.Version, data))
+	duration := timeNow().Sub(start)
+
+	tenantID := stored.TenantID
+	if tenant := tenantFromContext(ctx); tenant != "" {
+		tenantID = tenant
+	}
+	if subject != nil && subject.TenantID != "" {
+		tenantID = subject.TenantID
+	}
+
+	event := newStorageEvent(EventTemplateExecuted, templateName)
+	event.TemplateVersion = stored.Version
+	event.ContentHash = storedContentHash(stored)
+	event.TenantID = tenantID
+	event.Subject = subject
+	event.Duration = duration
+	if err != nil {
+		event.Error = err.Error()
+	}
+	_ = se.events.Publish(ctx, event)
+	_ = se.stats.RecordExecution(ctx, templateName, stored.Version, start)
+
+	if se.usage == nil {
+		return result, err
+	}
+	_ = se.usage.Record(ctx, &UsageEvent{
+		Timestamp:       start,
+		TemplateName:    templateName,
+		TemplateVersion: stored.Version,
+		TenantID:        tenantID,
+		Subject:         subject,
+		OutputBytes:     len(result),
+		EstimatedTokens: EstimateTokens(result).EstimatedGeneric,
+		Duration:        duration,
+		Error:           err,
+	})
+	return result, err
+}
+
+// UsageRecorder returns the configured usage recorder, or nil.
+func (se *StorageEngine) UsageRecorder() UsageRecorder {
+	return se.usage
+}
+
+// FuncUsageRecorder wraps a function as a usage recorder.
+type FuncUsageRecorder struct {
+	fn func(context.Context, *UsageEvent) error
+}
+
+// NewFuncUsageRecorder creates a usage recorder from a function.
+func NewFuncUsageRecorder(fn func(context.Context, *UsageEvent) error) *FuncUsageRecorder {
+	return &FuncUsageRecorder{fn: fn}
+}
+
+// Record calls the wrapped function.
+func (r *FuncUsageRecorder) Record(ctx context.Context, event *UsageEvent) error {
+	return r.fn(ctx, event)
+}
+
+// Usage aggregation defaults
+const (
+	// DefaultUsageBucket is the time bucket of NewUsageAggregator(0).
+	DefaultUsageBucket = time.Hour
+)
+
+// UsageSummary is the aggregated usage of one tenant and template within
+// one time bucket, or the total over a query.
+type UsageSummary struct {
+	// BucketStart is the start of the time bucket (zero for totals).
+	BucketStart time.Time
+
+	// TenantID is the tenant (empty for totals across tenants).
+	TenantID string
+
+	// TemplateName is the template (empty for totals across templates).
+	TemplateName string
+
+	// Executions is the number of executions.
+	Executions int
+
+	// Errors is the number of failed executions.
+	Errors int
+
+	// OutputBytes is the total rendered size in bytes.
+	OutputBytes int64
+
+	// EstimatedTokens is the total estimated output tokens.
+	EstimatedTokens int64
+
+	// Duration is the total execution time.
+	Duration time.Duration
+}
+
+// add adds one execution to the summary.
+func (s *UsageSummary) add(event *UsageEvent) {
+	s.Executions++
+	if event.Error != nil {
+		s.Errors++
+	}
+	s.OutputBytes += int64(event.OutputBytes)
+	s.EstimatedTokens += int64(event.EstimatedTokens)
+	s.Duration += event.Duration
+}
+
+// merge adds the usage of other to the summary.
+func (s *UsageSummary) merge(other *UsageSummary) {
+	s.Executions += other.Executions
+	s.Errors += other.Errors
+	s.OutputBytes += other.OutputBytes
+	s.EstimatedTokens += other.EstimatedTokens
+	s.Duration += other.Duration
+}
+
+// UsageQuery filters aggregated usage. Zero fields match everything.
+type UsageQuery struct {
+	// TenantID selects one tenant.
+	TenantID string
+
+	// TemplateName selects one template.
+	TemplateName string
+
+	// Since selects buckets starting at or after this time.
+	Since time.Time
+
+	// Until selects buckets starting before this time.
+	Until time.Time
+}
+
+// matches reports whether a bucket matches the query.
+func (q UsageQuery) matches(key usageKey) bool {
+	if q.TenantID != "" && key.tenantID != q.TenantID {
+		return false
+	}
+	if q.TemplateName != "" && key.templateName != q.TemplateName {
+		return false
+	}
+	if !q.Since.IsZero() && key.bucketStart.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !key.bucketStart.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// usageKey identifies one aggregation bucket.
+type usageKey struct {
+	bucketStart  time.Time
+	tenantID     string
+	templateName string
+}
+
+// UsageAggregator is a UsageRecorder that aggregates executions in memory
+// by tenant, template and time bucket. It is safe for concurrent use.
+type UsageAggregator struct {
+	mu      sync.RWMutex
+	bucket  time.Duration
+	buckets map[usageKey]*UsageSummary
+}
+
+// NewUsageAggregator creates an aggregator with the given time bucket
+// width. If bucket <= 0, DefaultUsageBucket is used.
+func NewUsageAggregator(bucket time.Duration) *UsageAggregator {
+	if bucket <= 0 {
+		bucket = DefaultUsageBucket
+	}
+	return &UsageAggregator{
+		bucket:  bucket,
+		buckets: make(map[usageKey]*UsageSummary),
+	}
+}
+
+// Record adds an execution to its bucket.
+func (a *UsageAggregator) Record(ctx context.Context, event *UsageEvent) error {
+	key := usageKey{
+		bucketStart:  event.Timestamp.UTC().Truncate(a.bucket),
+		tenantID:     event.TenantID,
+		templateName: event.TemplateName,
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	summary, ok := a.buckets[key]
+	if !ok {
+		summary = &UsageSummary{
+			BucketStart:  key.bucketStart,
+			TenantID:     key.tenantID,
+			TemplateName: key.templateName,
+		}
+		a.buckets[key] = summary
+	}
+	summary.add(event)
+	return nil
+}
+
+// Query returns the buckets matching the query, ordered by bucket start,
+// tenant and template.
+func (a *UsageAggregator) Query(query UsageQuery) []UsageSummary {
+	a.mu.RLock()
+	result := make([]UsageSummary, 0, len(a.buckets))
+	for key, summary := range a.buckets {
+		if query.matches(key) {
+			result = append(result, *summary)
+		}
+	}
+	a.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].BucketStart.Equal(result[j].BucketStart) {
+			return result[i].BucketStart.Before(result[j].BucketStart)
+		}
+		if result[i].TenantID != result[j].TenantID {
+			return result[i].TenantID < result[j].TenantID
+		}
+		return result[i].TemplateName < result[j].TemplateName
+	})
+	return result
+}
+
+// Total returns the usage matching the query summed over all buckets.
+// TenantID and TemplateName of the total are those of the query.
+func (a *UsageAggregator) Total(query UsageQuery) UsageSummary {
+	total := UsageSummary{TenantID: query.TenantID, TemplateName: query.TemplateName}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for key, summary := range a.buckets {
+		if query.matches(key) {
+			total.merge(summary)
+		}
+	}
+	return total
+}
+
+// Bucket returns the time bucket width.
+func (a *UsageAggregator) Bucket() time.Duration {
+	return a.bucket
+}
+
+// Reset removes all aggregated usage.
+func (a *UsageAggregator) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.buckets = make(map[usageKey]*UsageSummary)
+}
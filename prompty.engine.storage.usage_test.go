@@ -0,0 +1,140 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageEngine_UsageRecorder(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("records executions", func(t *testing.T) {
+		var events []*UsageEvent
+		se := MustNewStorageEngine(StorageEngineConfig{
+			Storage: NewMemoryStorage(),
+			UsageRecorder: NewFuncUsageRecorder(func(ctx context.Context, event *UsageEvent) error {
+				events = append(events, event)
+				return nil
+			}),
+		})
+		defer se.Close()
+
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "greet", Source: "v1", TenantID: "tenant_a"}))
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "greet", Source: "Hello {~prompty.var name=\"name\" /~}!", TenantID: "tenant_a"}))
+
+		result, err := se.Execute(ctx, "greet", map[string]any{"name": "World"})
+		require.NoError(t, err)
+		_, err = se.ExecuteVersion(ctx, "greet", 1, nil)
+		require.NoError(t, err)
+
+		require.Len(t, events, 2)
+		assert.Equal(t, "greet", events[0].TemplateName)
+		assert.Equal(t, 2, events[0].TemplateVersion)
+		assert.Equal(t, "tenant_a", events[0].TenantID)
+		assert.Nil(t, events[0].Subject)
+		assert.Equal(t, len(result), events[0].OutputBytes)
+		assert.Equal(t, EstimateTokens(result).EstimatedGeneric, events[0].EstimatedTokens)
+		assert.False(t, events[0].Timestamp.IsZero())
+		assert.NoError(t, events[0].Error)
+		assert.Equal(t, 1, events[1].TemplateVersion)
+	})
+
+	t.Run("records execution errors", func(t *testing.T) {
+		aggregator := NewUsageAggregator(0)
+		se := MustNewStorageEngine(StorageEngineConfig{Storage: NewMemoryStorage(), UsageRecorder: aggregator})
+		defer se.Close()
+
+		require.NoError(t, se.SaveWithoutValidation(ctx, &StoredTemplate{Name: "broken", Source: "{~prompty.var name=\"missing\" /~}"}))
+		_, err := se.Execute(ctx, "broken", nil)
+		require.Error(t, err)
+
+		// Templates that fail to load are not executions
+		_, err = se.Execute(ctx, "unknown", nil)
+		require.Error(t, err)
+
+		total := aggregator.Total(UsageQuery{})
+		assert.Equal(t, 1, total.Executions)
+		assert.Equal(t, 1, total.Errors)
+	})
+
+	t.Run("secure executions bill the subject's tenant", func(t *testing.T) {
+		aggregator := NewUsageAggregator(time.Hour)
+		se := MustNewSecureStorageEngine(SecureStorageEngineConfig{
+			StorageEngineConfig: StorageEngineConfig{Storage: NewMemoryStorage(), UsageRecorder: aggregator},
+		})
+		defer se.Close()
+
+		require.NoError(t, se.StorageEngine.Save(ctx, &StoredTemplate{Name: "shared", Source: "content", TenantID: "owner"}))
+
+		subject := NewAccessSubject("usr_1").WithTenant("tenant_b")
+		_, err := se.ExecuteSecure(ctx, "shared", nil, subject)
+		require.NoError(t, err)
+		_, err = se.ExecuteVersionSecure(ctx, "shared", 1, nil, subject)
+		require.NoError(t, err)
+		_, err = se.Execute(ctx, "shared", nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, aggregator.Total(UsageQuery{TenantID: "tenant_b"}).Executions)
+		assert.Equal(t, 1, aggregator.Total(UsageQuery{TenantID: "owner"}).Executions)
+	})
+}
+
+func TestUsageAggregator(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	aggregator := NewUsageAggregator(time.Hour)
+	assert.Equal(t, time.Hour, aggregator.Bucket())
+
+	events := []*UsageEvent{
+		{Timestamp: base.Add(5 * time.Minute), TenantID: "a", TemplateName: "t1", OutputBytes: 10, EstimatedTokens: 3, Duration: time.Millisecond},
+		{Timestamp: base.Add(50 * time.Minute), TenantID: "a", TemplateName: "t1", OutputBytes: 20, EstimatedTokens: 5, Duration: time.Millisecond},
+		{Timestamp: base.Add(70 * time.Minute), TenantID: "a", TemplateName: "t2", OutputBytes: 30, EstimatedTokens: 7, Error: assert.AnError},
+		{Timestamp: base.Add(10 * time.Minute), TenantID: "b", TemplateName: "t1", OutputBytes: 40, EstimatedTokens: 9},
+	}
+	for _, event := range events {
+		require.NoError(t, aggregator.Record(ctx, event))
+	}
+
+	t.Run("buckets by tenant, template and time", func(t *testing.T) {
+		buckets := aggregator.Query(UsageQuery{})
+		require.Len(t, buckets, 3)
+
+		assert.Equal(t, base, buckets[0].BucketStart)
+		assert.Equal(t, "a", buckets[0].TenantID)
+		assert.Equal(t, "t1", buckets[0].TemplateName)
+		assert.Equal(t, 2, buckets[0].Executions)
+		assert.Equal(t, int64(30), buckets[0].OutputBytes)
+		assert.Equal(t, int64(8), buckets[0].EstimatedTokens)
+		assert.Equal(t, 2*time.Millisecond, buckets[0].Duration)
+
+		assert.Equal(t, "b", buckets[1].TenantID)
+		assert.Equal(t, base.Add(time.Hour), buckets[2].BucketStart)
+		assert.Equal(t, 1, buckets[2].Errors)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		assert.Len(t, aggregator.Query(UsageQuery{TenantID: "a"}), 2)
+		assert.Len(t, aggregator.Query(UsageQuery{TemplateName: "t1"}), 2)
+		assert.Len(t, aggregator.Query(UsageQuery{Since: base.Add(time.Hour)}), 1)
+		assert.Len(t, aggregator.Query(UsageQuery{Until: base.Add(time.Hour)}), 2)
+	})
+
+	t.Run("totals", func(t *testing.T) {
+		total := aggregator.Total(UsageQuery{TenantID: "a"})
+		assert.Equal(t, "a", total.TenantID)
+		assert.Equal(t, 3, total.Executions)
+		assert.Equal(t, 1, total.Errors)
+		assert.Equal(t, int64(60), total.OutputBytes)
+		assert.Equal(t, int64(15), total.EstimatedTokens)
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		aggregator.Reset()
+		assert.Empty(t, aggregator.Query(UsageQuery{}))
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -429,6 +430,75 @@ func TestStorageEngine_ParsedCache(t *testing.T) {
 	})
 }
 
+func TestStorageEngine_ParsedCacheLimits(t *testing.T) {
+	ctx := context.Background()
+	se, err := NewStorageEngine(StorageEngineConfig{
+		Storage:               NewMemoryStorage(),
+		ParsedCacheMaxEntries: 2,
+		ParsedCachePolicy:     CachePolicyLFU,
+	})
+	require.NoError(t, err)
+	defer se.Close()
+
+	for _, name := range []string{"hot", "warm", "cold"} {
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: name, Source: `Hi {~prompty.var name="x" default="?" /~}`}))
+	}
+	for i := 0; i < 3; i++ {
+		_, err = se.Execute(ctx, "hot", nil)
+		require.NoError(t, err)
+	}
+	_, err = se.Execute(ctx, "warm", nil)
+	require.NoError(t, err)
+	_, err = se.Execute(ctx, "cold", nil) // Evicts warm, the least frequently used
+	require.NoError(t, err)
+	_, err = se.Execute(ctx, "hot", nil)
+	require.NoError(t, err)
+
+	stats := se.ParsedCacheStats()
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, 2, stats.MaxEntries)
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.Equal(t, int64(3), stats.Hits)
+	assert.Equal(t, int64(3), stats.Misses)
+	assert.Positive(t, stats.Bytes)
+
+	assert.Equal(t, 2, se.PurgeParsedCache())
+	stats = se.ParsedCacheStats()
+	assert.Equal(t, int64(2), stats.Purges)
+	assert.Equal(t, int64(0), stats.Bytes)
+
+	// A byte limit smaller than any template disables caching it
+	small, err := NewStorageEngine(StorageEngineConfig{Storage: NewMemoryStorage(), ParsedCacheMaxBytes: 1})
+	require.NoError(t, err)
+	defer small.Close()
+	require.NoError(t, small.Save(ctx, &StoredTemplate{Name: "t", Source: "content"}))
+	_, err = small.Execute(ctx, "t", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, small.ParsedCacheStats().Entries)
+}
+
+func TestStorageEngine_ExecuteBatch(t *testing.T) {
+	ctx := context.Background()
+	recorder := NewUsageAggregator(time.Hour)
+	se, err := NewStorageEngine(StorageEngineConfig{Storage: NewMemoryStorage(), UsageRecorder: recorder})
+	require.NoError(t, err)
+	defer se.Close()
+	require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "mail", Source: `Hi {~prompty.var name="name" /~}`}))
+
+	items := []map[string]any{{"name": "Ana"}, {"name": "Bo"}, {}}
+	results, err := se.ExecuteBatch(ctx, "mail", items, BatchOptions{Concurrency: 2})
+	require.NoError(t, err)
+	assert.Equal(t, "Hi Ana", results[0].Output)
+	assert.Equal(t, "Hi Bo", results[1].Output)
+	assert.Error(t, results[2].Err)
+
+	assert.Equal(t, 3, recorder.Total(UsageQuery{TemplateName: "mail"}).Executions)
+	assert.Equal(t, int64(1), se.ParsedCacheStats().Misses)
+
+	_, err = se.ExecuteBatch(ctx, "missing", items, BatchOptions{})
+	assert.ErrorContains(t, err, ErrMsgTemplateNotFound)
+}
+
 func TestStorageEngine_CacheDisabled(t *testing.T) {
 	storage := NewMemoryStorage()
 	se, err := NewStorageEngine(StorageEngineConfig{
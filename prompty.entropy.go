@@ -0,0 +1,51 @@
+package prompty
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// DeterministicTime is the instant now() and Now report in deterministic
+// mode (see WithDeterministic).
+var DeterministicTime = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// WithDeterministic makes rendering reproducible for golden tests: now()
+// and Now report DeterministicTime, and every execution draws its random
+// choices from a source seeded with seed. Executions render identically as
+// long as the data and the seed match. Combine with WithClock for a
+// different fixed time.
+// Default: disabled (wall clock, randomly seeded source per execution)
+func WithDeterministic(seed int64) Option {
+	return func(c *engineConfig) {
+		c.clock = func() time.Time { return DeterministicTime }
+		c.seed = &seed
+	}
+}
+
+// WithClock sets the clock read by the now() function and by resolvers
+// through Now, e.g. a fake clock in tests.
+// Default: time.Now
+func WithClock(clock func() time.Time) Option {
+	return func(c *engineConfig) {
+		c.clock = clock
+	}
+}
+
+// Now returns the current time of the execution running ctx, honoring
+// WithClock and WithDeterministic. Custom resolvers should use it instead
+// of time.Now. Outside an execution it returns time.Now().
+func Now(ctx context.Context) time.Time {
+	return internal.EntropyFrom(ctx).Now()
+}
+
+// Rand returns the random source of the execution running ctx, seeded by
+// WithDeterministic if set. Custom resolvers should draw random values from
+// it so deterministic mode covers them. The source is shared by the whole
+// execution and is not safe for concurrent use. Outside an execution it
+// returns a new randomly seeded source.
+func Rand(ctx context.Context) *rand.Rand {
+	return internal.EntropyFrom(ctx).Rand
+}
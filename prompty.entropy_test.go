@@ -0,0 +1,95 @@
+package prompty
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomEngine returns an engine with a Roll resolver drawing from Rand.
+func randomEngine(opts ...Option) *Engine {
+	engine := MustNew(opts...)
+	engine.MustRegister(NewResolverFunc("Roll", func(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+		return strconv.Itoa(Rand(ctx).IntN(1_000_000)), nil
+	}, nil))
+	engine.MustRegisterTemplate("rolls", `{~Roll /~},{~Roll /~}`)
+	return engine
+}
+
+func TestWithDeterministic(t *testing.T) {
+	ctx := context.Background()
+	source := `{~prompty.include template="day" with="today=formatDate(now(), '2006-01-02')" /~} {~prompty.include template="rolls" /~} {~Roll /~}`
+
+	render := func(seed int64) string {
+		engine := randomEngine(WithDeterministic(seed))
+		engine.MustRegisterTemplate("day", `{~prompty.var name="today" /~}`)
+		result, err := engine.Execute(ctx, source, nil)
+		require.NoError(t, err)
+		return result
+	}
+
+	first := render(42)
+	assert.Equal(t, first, render(42))
+	assert.NotEqual(t, first, render(7))
+	assert.Regexp(t, `^2024-01-01 \d+,\d+ \d+$`, first)
+
+	// Every execution starts from the seed
+	engine := randomEngine(WithDeterministic(42))
+	a, err := engine.ExecuteTemplate(ctx, "rolls", nil)
+	require.NoError(t, err)
+	b, err := engine.ExecuteTemplate(ctx, "rolls", nil)
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+}
+
+func TestWithClock(t *testing.T) {
+	fixed := time.Date(2030, time.June, 15, 12, 0, 0, 0, time.UTC)
+	engine := MustNew(WithDeterministic(1), WithClock(func() time.Time { return fixed }))
+	engine.MustRegister(NewResolverFunc("Year", func(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+		return strconv.Itoa(Now(ctx).Year()), nil
+	}, nil))
+
+	result, err := engine.Execute(context.Background(),
+		`{~Year /~}{~prompty.if eval="month(now()) == 6"~} June{~/prompty.if~}`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "2030 June", result)
+}
+
+func TestNowAndRand_OutsideExecution(t *testing.T) {
+	assert.WithinDuration(t, time.Now(), Now(context.Background()), time.Minute)
+	assert.NotNil(t, Rand(context.Background()))
+}
+
+func TestRandomTags_Deterministic(t *testing.T) {
+	source := `{~prompty.choice from="greetings" /~}!
+{~prompty.shuffle in="examples" item="ex" limit="2"~}- {~prompty.var name="ex.q" /~}
+{~/prompty.shuffle~}`
+	data := map[string]any{
+		"greetings": []string{"Hi", "Hello", "Hey", "Howdy"},
+		"examples": []map[string]any{
+			{"q": "one"}, {"q": "two"}, {"q": "three"}, {"q": "four"},
+		},
+	}
+
+	render := func() string {
+		result, err := MustNew(WithDeterministic(3)).Execute(context.Background(), source, data)
+		require.NoError(t, err)
+		return result
+	}
+	result := render()
+	assert.Equal(t, result, render())
+	assert.Regexp(t, `^(Hi|Hello|Hey|Howdy)!\n- \w+\n- \w+\n$`, result)
+
+	tmpl, err := MustNew().Parse(source)
+	require.NoError(t, err)
+	dryRun := tmpl.DryRun(context.Background(), data)
+	require.Len(t, dryRun.Loops, 1)
+	assert.Equal(t, "examples", dryRun.Loops[0].Source)
+	assert.Equal(t, 2, dryRun.Loops[0].Limit)
+	assert.Empty(t, dryRun.Warnings)
+	assert.Empty(t, dryRun.UnusedVariables)
+}
@@ -0,0 +1,125 @@
+package prompty
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// EnvironmentOverlay overrides parts of a prompt in one environment
+// (e.g. dev, staging, prod), so the prompt is written once:
+//
+//	execution:
+//	  provider: openai
+//	  model: gpt-4o-mini
+//	environments:
+//	  prod:
+//	    execution:
+//	      model: gpt-4o
+//	      temperature: 0.2
+//	    context:
+//	      support_email: help@example.com
+type EnvironmentOverlay struct {
+	// Execution is merged over the prompt's execution config
+	// (ExecutionConfig.Merge).
+	Execution *ExecutionConfig `yaml:"execution,omitempty" json:"execution,omitempty"`
+
+	// Context overrides context variables of the prompt by key.
+	Context map[string]any `yaml:"context,omitempty" json:"context,omitempty"`
+}
+
+// EnvironmentOverlays maps environment names to overlays.
+type EnvironmentOverlays map[string]*EnvironmentOverlay
+
+// environmentOverlayDocument is the YAML form of a separate overlay
+// document, matching the environments section of frontmatter.
+type environmentOverlayDocument struct {
+	Environments EnvironmentOverlays `yaml:"environments"`
+}
+
+// ParseEnvironmentOverlays parses a separate overlay document holding an
+// environments section as written in frontmatter, e.g. to keep production
+// settings next to deployment configuration instead of in the prompt. Pass
+// the result as CompileOptions.EnvironmentOverlays.
+func ParseEnvironmentOverlays(data []byte) (EnvironmentOverlays, error) {
+	var doc environmentOverlayDocument
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, NewFrontmatterParseError(err)
+	}
+	if err := doc.Environments.Validate(); err != nil {
+		return nil, err
+	}
+	return doc.Environments, nil
+}
+
+// Validate validates the execution configs of the overlays.
+func (o EnvironmentOverlays) Validate() error {
+	for _, overlay := range o {
+		if overlay == nil || overlay.Execution == nil {
+			continue
+		}
+		if err := overlay.Execution.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ForEnvironment returns a copy of the prompt with the overlays of an
+// environment applied: first the prompt's own environments entry, then
+// the entries of extra overlays in order, so a separate overlay document
+// wins over the frontmatter. The copy has no environments section. An
+// empty environment, or one without overlays, returns the prompt unchanged.
+func (p *Prompt) ForEnvironment(environment string, extra ...EnvironmentOverlays) *Prompt {
+	if p == nil || environment == "" {
+		return p
+	}
+	overlays := []*EnvironmentOverlay{p.Environments[environment]}
+	for _, o := range extra {
+		overlays = append(overlays, o[environment])
+	}
+
+	var resolved *Prompt
+	for _, overlay := range overlays {
+		if overlay == nil {
+			continue
+		}
+		if resolved == nil {
+			resolved = p.Clone()
+			resolved.Environments = nil
+		}
+		if overlay.Execution != nil {
+			resolved.Execution = resolved.Execution.Merge(overlay.Execution)
+		}
+		if len(overlay.Context) > 0 {
+			if resolved.Context == nil {
+				resolved.Context = make(map[string]any, len(overlay.Context))
+			}
+			for k, v := range overlay.Context {
+				resolved.Context[k] = v
+			}
+		}
+	}
+	if resolved == nil {
+		return p
+	}
+	return resolved
+}
+
+// clone returns a deep copy of the overlays.
+func (o EnvironmentOverlays) clone() EnvironmentOverlays {
+	if o == nil {
+		return nil
+	}
+	clone := make(EnvironmentOverlays, len(o))
+	for name, overlay := range o {
+		if overlay == nil {
+			clone[name] = nil
+			continue
+		}
+		c := &EnvironmentOverlay{Execution: overlay.Execution.Clone()}
+		if overlay.Context != nil {
+			c.Context = deepCopyMap(overlay.Context)
+		}
+		clone[name] = c
+	}
+	return clone
+}
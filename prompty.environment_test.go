@@ -0,0 +1,91 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testEnvironmentAgent = `---
+name: support
+description: Support agent
+type: agent
+execution:
+  provider: openai
+  model: gpt-4o-mini
+  temperature: 0.7
+context:
+  tier: free
+  team: support
+environments:
+  prod:
+    execution:
+      model: gpt-4o
+      temperature: 0.2
+    context:
+      tier: enterprise
+---
+Model tier {~prompty.var name="tier" /~} for {~prompty.var name="team" /~}`
+
+func TestPrompt_ForEnvironment(t *testing.T) {
+	p, err := Parse([]byte(testEnvironmentAgent))
+	require.NoError(t, err)
+	require.Contains(t, p.Environments, "prod")
+	assert.NotContains(t, p.Extensions, PromptFieldEnvironments)
+
+	prod := p.ForEnvironment("prod")
+	assert.Equal(t, "gpt-4o", prod.Execution.Model)
+	assert.Equal(t, "openai", prod.Execution.Provider)
+	assert.Equal(t, 0.2, *prod.Execution.Temperature)
+	assert.Equal(t, map[string]any{"tier": "enterprise", "team": "support"}, prod.Context)
+	assert.Nil(t, prod.Environments)
+	assert.Equal(t, "gpt-4o-mini", p.Execution.Model, "original is not modified")
+
+	assert.Same(t, p, p.ForEnvironment("dev"), "environments without overlay keep the prompt")
+	assert.Same(t, p, p.ForEnvironment(""))
+
+	// A separate overlay document wins over the frontmatter
+	overlays, err := ParseEnvironmentOverlays([]byte("environments:\n  prod:\n    execution:\n      model: gpt-4.1\n  dev:\n    context:\n      tier: test\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4.1", p.ForEnvironment("prod", overlays).Execution.Model)
+	assert.Equal(t, "test", p.ForEnvironment("dev", overlays).Context["tier"])
+
+	_, err = ParseEnvironmentOverlays([]byte("environments:\n  prod:\n    execution:\n      temperature: 5\n"))
+	assert.Error(t, err)
+
+	// Overlays survive serialization
+	data, err := p.Serialize(nil)
+	require.NoError(t, err)
+	reparsed, err := Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o", reparsed.ForEnvironment("prod").Execution.Model)
+}
+
+func TestCompileAgent_Environment(t *testing.T) {
+	ctx := context.Background()
+	p, err := Parse([]byte(testEnvironmentAgent))
+	require.NoError(t, err)
+
+	compiled, err := p.CompileAgent(ctx, nil, NewCompileOptions(WithCompileEnvironment("prod")))
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o", compiled.Execution.Model)
+	assert.Equal(t, "Model tier enterprise for support", compiled.Messages[0].Content)
+
+	compiled, err = p.CompileAgent(ctx, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o-mini", compiled.Execution.Model)
+	assert.Equal(t, "Model tier free for support", compiled.Messages[0].Content)
+
+	// The engine's environment applies at parse time
+	engine := MustNew(WithEnvironment("prod"))
+	tmpl, err := engine.Parse(testEnvironmentAgent)
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o", tmpl.Prompt().Execution.Model)
+
+	// and to compilations using the engine without their own environment
+	compiled, err = p.CompileAgent(ctx, nil, NewCompileOptions(WithCompileEngine(engine)))
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o", compiled.Execution.Model)
+}
@@ -1,10 +1,14 @@
 package prompty
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/itsatony/go-cuserr"
+
+	"github.com/itsatony/go-prompty/v2/internal"
 )
 
 // Error message constants - ALL error messages must be constants (NO MAGIC STRINGS)
@@ -21,6 +25,30 @@ const (
 	ErrMsgInvalidTagName  = "invalid tag name"
 	ErrMsgEmptyTagName    = "tag name cannot be empty"
 	ErrMsgNestedRawBlock  = "nested raw blocks are not allowed"
+	ErrMsgEditOutOfRange  = "edit range outside the template source"
+	ErrMsgReparseNoEngine = "template has no engine to re-parse with"
+
+	// Delimiter configuration errors
+	ErrMsgDelimitersIdentical  = "open and close delimiters must differ"
+	ErrMsgDelimiterInvalidChar = "delimiters cannot contain whitespace, quotes, '=' or '\\' and cannot start with '/'"
+
+	// Dynamic include configuration errors
+	ErrMsgDynamicIncludePattern = "invalid dynamic include allowlist pattern"
+
+	// Output mode configuration errors
+	ErrMsgUnknownOutputMode = "unknown output mode"
+
+	// Compiled template errors
+	ErrMsgCompiledTemplateEncode    = "failed to encode compiled template"
+	ErrMsgCompiledTemplateDecode    = "failed to decode compiled template"
+	ErrMsgCompiledDelimiterMismatch = "compiled template uses different delimiters than the engine"
+
+	// Template dialect conversion errors
+	ErrMsgConvertUnknownDialect = "unknown template dialect"
+	ErrMsgConvertUnterminated   = "unterminated template construct"
+	ErrMsgConvertUnexpectedEnd  = "block construct outside of a matching block"
+	ErrMsgConvertMismatchedEnd  = "block closed by a mismatched construct"
+	ErrMsgConvertUnclosedBlock  = "block is never closed"
 
 	// Execution errors
 	ErrMsgUnknownTag       = "unknown tag"
@@ -38,6 +66,15 @@ const (
 	// Registry errors
 	ErrMsgResolverExists = "resolver already registered"
 
+	// Engine snapshot errors
+	ErrMsgSnapshotReadOnly = "engine snapshot is read-only"
+
+	// Result key errors
+	ErrMsgDataNotHashable = "template data cannot be hashed"
+
+	// Batch execution errors
+	ErrMsgBatchItemSkipped = "batch item skipped after an earlier failure"
+
 	// Type conversion errors
 	ErrMsgTypeConversion = "type conversion failed"
 
@@ -45,12 +82,18 @@ const (
 	ErrMsgTemplateNotFound      = "template not found"
 	ErrMsgTemplateAlreadyExists = "template already registered"
 	ErrMsgTemplateDepthExceeded = "template inclusion depth exceeded"
+	ErrMsgTemplateIncludeCycle  = "circular template include detected"
 	ErrMsgInvalidTemplateName   = "invalid template name"
 	ErrMsgEmptyTemplateName     = "template name cannot be empty"
 	ErrMsgMissingTemplateAttr   = "missing required 'template' attribute"
 	ErrMsgEngineNotAvailable    = "engine not available for nested template resolution"
+	ErrMsgBlockNotFound         = "template block not found"
 	ErrMsgReservedTemplateName  = "template name uses reserved prompty.* namespace"
 
+	// Inheritance inspection errors
+	ErrMsgInheritanceCycle         = "circular template inheritance detected"
+	ErrMsgInheritanceDepthExceeded = "template inheritance depth exceeded"
+
 	// Error strategy messages (Phase 3)
 	ErrMsgInvalidErrorStrategy = "invalid error strategy"
 	ErrMsgErrorHandledByStrat  = "error handled by strategy"
@@ -59,7 +102,15 @@ const (
 	ErrMsgValidationFailed     = "template validation failed"
 	ErrMsgUnknownTagInTemplate = "unknown tag in template"
 	ErrMsgInvalidOnErrorAttr   = "invalid onerror attribute value"
+	ErrMsgInvalidTagCacheTTL   = "invalid cache attribute value, expected a positive duration"
 	ErrMsgMissingIncludeTarget = "included template not found"
+	ErrMsgMissingImportTarget  = "imported template not found"
+	ErrMsgMissingFallback      = "onerror fallback template not found"
+
+	// Strict mode messages
+	ErrMsgStrictUnknownAttribute = "unknown attribute"
+	ErrMsgStrictUnusedDefault    = "default is never used, the input is required"
+	ErrMsgStrictUndeclaredInput  = "reference to undeclared input"
 
 	// For loop messages (Phase 4)
 	ErrMsgForMissingItem    = "missing required 'item' attribute"
@@ -71,13 +122,17 @@ const (
 	ErrMsgForNotClosed      = "for block not closed"
 
 	// Switch/case messages (Phase 5)
-	ErrMsgSwitchMissingEval      = "missing required 'eval' attribute for switch"
-	ErrMsgSwitchMissingValue     = "case requires 'value' or 'eval' attribute"
-	ErrMsgSwitchNotClosed        = "switch block not closed"
-	ErrMsgSwitchCaseNotClosed    = "case block not closed"
-	ErrMsgSwitchDefaultNotLast   = "default case must be last in switch"
-	ErrMsgSwitchDuplicateDefault = "only one default case allowed in switch"
-	ErrMsgSwitchInvalidCaseTag   = "unexpected tag inside switch block"
+	ErrMsgSwitchMissingEval        = "missing required 'eval' attribute for switch"
+	ErrMsgSwitchMissingValue       = "case requires 'value' or 'eval' attribute"
+	ErrMsgSwitchNotClosed          = "switch block not closed"
+	ErrMsgSwitchCaseNotClosed      = "case block not closed"
+	ErrMsgSwitchDefaultNotLast     = "default case must be last in switch"
+	ErrMsgSwitchDuplicateDefault   = "only one default case allowed in switch"
+	ErrMsgSwitchInvalidCaseTag     = "unexpected tag inside switch block"
+	ErrMsgSwitchValueAndEval       = "case cannot have both 'value' and 'eval' attributes"
+	ErrMsgSwitchInvalidFallthrough = "invalid 'fallthrough' attribute value (expected \"true\" or \"false\")"
+	ErrMsgSwitchDefaultFallthrough = "default case cannot use 'fallthrough'"
+	ErrMsgSwitchCaseOutsideSwitch  = "case tag used outside of a switch block"
 
 	// Custom function messages (Phase 5)
 	ErrMsgFuncNilFunc       = "function cannot be nil"
@@ -106,6 +161,8 @@ const (
 	ErrMsgFrontmatterInvalid       = "invalid YAML frontmatter format"
 	ErrMsgFrontmatterUnclosed      = "YAML frontmatter not properly closed"
 	ErrMsgLegacyJSONConfigDetected = "legacy JSON config block detected - please migrate to YAML frontmatter with --- delimiters"
+	ErrMsgFrontmatterJSONParse     = "failed to parse JSON frontmatter"
+	ErrMsgDocumentBodyNotString    = "JSON document body must be a string"
 
 	// Message tag messages
 	ErrMsgMessageMissingRole      = "missing required 'role' attribute"
@@ -140,14 +197,34 @@ const (
 	ErrMsgSchemaAdditionalProperties = "strict mode requires additionalProperties: false"
 	ErrMsgSchemaPropertyOrdering     = "propertyOrdering requires Gemini 2.5+ provider"
 	ErrMsgEnumEmptyValues            = "enum constraint requires at least one value"
+	ErrMsgSchemaUnsupportedGoType    = "Go type has no JSON schema representation"
+	ErrMsgSchemaRecursiveGoType      = "recursive Go types are not supported"
+	ErrMsgResponseInvalidJSON        = "response is not valid JSON"
+	ErrMsgResponseMissingField       = "response is missing a required field"
+	ErrMsgResponseWrongType          = "response value has the wrong type"
+	ErrMsgResponseUnknownField       = "response has an unknown field"
+	ErrMsgResponseNotInEnum          = "response value is not one of the allowed values"
+	ErrMsgResponseOutOfRange         = "response value is out of range"
 	ErrMsgGuidedDecodingConflict     = "only one guided decoding constraint allowed"
+	ErrMsgGrammarUnknownFormat       = "unknown grammar format"
+	ErrMsgGrammarEmpty               = "grammar has no rules"
+	ErrMsgGrammarUnterminated        = "grammar has an unterminated literal"
+	ErrMsgGrammarUnexpectedChar      = "grammar has an unexpected character"
+	ErrMsgGrammarUnbalanced          = "grammar has unbalanced brackets"
+	ErrMsgGrammarOutsideRule         = "grammar has text outside of a rule"
+	ErrMsgGrammarEmptyRule           = "grammar rule has no body"
+	ErrMsgGrammarUndefinedRule       = "grammar references an undefined rule"
+	ErrMsgGrammarMissingRoot         = "grammar has no start rule"
+	ErrMsgGrammarNoChoices           = "grammar requires at least one choice"
 
 	// Core execution parameter validation messages
-	ErrMsgTemperatureOutOfRange = "temperature must be between 0.0 and 2.0"
-	ErrMsgTopPOutOfRange        = "top_p must be between 0.0 and 1.0"
-	ErrMsgMaxTokensInvalid      = "max_tokens must be positive"
-	ErrMsgTopKInvalid           = "top_k must be non-negative"
-	ErrMsgThinkingBudgetInvalid = "thinking.budget_tokens must be positive"
+	ErrMsgTemperatureOutOfRange  = "temperature must be between 0.0 and 2.0"
+	ErrMsgTopPOutOfRange         = "top_p must be between 0.0 and 1.0"
+	ErrMsgMaxTokensInvalid       = "max_tokens must be positive"
+	ErrMsgTopKInvalid            = "top_k must be non-negative"
+	ErrMsgThinkingBudgetInvalid  = "thinking.budget_tokens must be positive"
+	ErrMsgReasoningInvalidEffort = "reasoning effort must be low, medium or high"
+	ErrMsgReasoningBudgetInvalid = "reasoning.budget_tokens must be positive"
 
 	// Inference parameter validation messages (v2.3)
 	ErrMsgMinPOutOfRange              = "min_p must be between 0.0 and 1.0"
@@ -179,6 +256,16 @@ const (
 	ErrMsgAsyncPollIntervalInvalid      = "async poll interval must be positive"
 	ErrMsgAsyncPollTimeoutInvalid       = "async poll timeout must be positive"
 	ErrMsgAsyncPollTimeoutTooSmall      = "async poll timeout must be greater than or equal to poll interval"
+	ErrMsgGatewayInvalidType            = "gateway type must be openrouter or litellm"
+	ErrMsgGatewayPriceNegative          = "gateway max price must not be negative"
+	ErrMsgGatewayRetriesNegative        = "gateway num_retries must not be negative"
+	ErrMsgGatewayEmptyFallback          = "gateway fallback model must not be empty"
+	ErrMsgGatewayUnsupportedField       = "gateway setting is not supported by the gateway type"
+	ErrMsgFallbackRetriesNegative       = "fallback max_retries must not be negative"
+	ErrMsgFallbackBackoffNegative       = "fallback backoff must not be negative"
+	ErrMsgFallbackMultiplierTooSmall    = "fallback backoff_multiplier must be at least 1"
+	ErrMsgFallbackEmptyTarget           = "fallback target requires a provider or model"
+	ErrMsgFallbackInvalidCondition      = "invalid fallback retry_on condition"
 
 	// v2.0 Prompt validation messages
 	ErrMsgPromptNameRequired        = "prompt name is required"
@@ -211,6 +298,21 @@ const (
 	ErrCodePrompt     = "PROMPTY_PROMPT"     // v2.0: Prompt validation errors
 	ErrCodeRef        = "PROMPTY_REF"        // v2.0: Reference resolution errors
 	ErrCodeVersioning = "PROMPTY_VERSIONING" // Versioning operation errors
+	ErrCodeNotFound   = "PROMPTY_NOT_FOUND"
+	ErrCodeStorage    = "PROMPTY_STORAGE"
+	ErrCodeAccess     = "PROMPTY_ACCESS"
+	ErrCodeRateLimit  = "PROMPTY_RATE_LIMIT"
+	ErrCodeHook       = "PROMPTY_HOOK"
+	ErrCodeTimeout    = "PROMPTY_TIMEOUT"
+	ErrCodeInternal   = "PROMPTY_INTERNAL"
+)
+
+// Error codes of compiled template artifacts that cannot be decoded
+const (
+	ErrCodeCompiledBadMagic = internal.ErrCodeCompiledBadMagic // Not a compiled template
+	ErrCodeCompiledVersion  = internal.ErrCodeCompiledVersion  // Unsupported format version
+	ErrCodeCompiledCorrupt  = internal.ErrCodeCompiledCorrupt  // Truncated or malformed data
+	ErrCodeCompiledTrailing = internal.ErrCodeCompiledTrailing // Data after the template
 )
 
 // Position represents a location in the source template
@@ -230,15 +332,77 @@ func NewParseError(msg string, pos Position, cause error) error {
 	var err *cuserr.CustomError
 	if cause != nil {
 		err = cuserr.WrapStdError(cause, ErrCodeParse, msg)
+		err.Category = cuserr.ErrorCategoryValidation
 	} else {
 		err = cuserr.NewValidationError(ErrCodeParse, msg)
 	}
-	return err.
+	return withSentinel(err, ErrValidation).
 		WithMetadata(MetaKeyLine, strconv.Itoa(pos.Line)).
 		WithMetadata(MetaKeyColumn, strconv.Itoa(pos.Column)).
 		WithMetadata(MetaKeyOffset, strconv.Itoa(pos.Offset))
 }
 
+// NewDelimiterError creates an error for an invalid WithDelimiters configuration
+func NewDelimiterError(msg, open, close string) error {
+	return cuserr.NewValidationError(ErrCodeValidation, msg).
+		WithMetadata(MetaKeyOpenDelim, open).
+		WithMetadata(MetaKeyCloseDelim, close)
+}
+
+// NewStrictModeError creates an error for the strict mode violations of a
+// template, listing all of them; the position is that of the first
+func NewStrictModeError(issues []ValidationIssue) error {
+	details := make([]string, len(issues))
+	for i, issue := range issues {
+		details[i] = fmt.Sprintf(strictIssueFormat, issue.Message, issue.Position.Line, issue.Position.Column)
+	}
+	err := cuserr.NewValidationError(ErrCodeValidation, ErrMsgValidationFailed+": "+strings.Join(details, lintHintSep))
+	if len(issues) > 0 {
+		err = err.
+			WithMetadata(MetaKeyLine, strconv.Itoa(issues[0].Position.Line)).
+			WithMetadata(MetaKeyColumn, strconv.Itoa(issues[0].Position.Column)).
+			WithMetadata(MetaKeyTag, issues[0].TagName)
+	}
+	return err
+}
+
+// NewOutputModeError creates an error for an output mode without an escaper
+func NewOutputModeError(mode OutputMode) error {
+	return cuserr.NewValidationError(ErrCodeValidation, ErrMsgUnknownOutputMode).
+		WithMetadata(MetaKeyOutputMode, string(mode))
+}
+
+// NewDynamicIncludePatternError creates an error for a malformed dynamic
+// include allowlist pattern
+func NewDynamicIncludePatternError(pattern string) error {
+	return cuserr.NewValidationError(ErrCodeValidation, ErrMsgDynamicIncludePattern).
+		WithMetadata(MetaKeyPattern, pattern)
+}
+
+// NewCompiledTemplateError creates an error for encoding or decoding a
+// compiled template
+func NewCompiledTemplateError(msg string, cause error) error {
+	return cuserr.WrapStdError(cause, ErrCodeTemplate, msg)
+}
+
+// NewCompiledTemplateDecodeError creates an error for compiled template data
+// that cannot be decoded. Its code (ErrCodeCompiledBadMagic, ...) classifies
+// the failure; the metadata holds the byte offset and the format version.
+func NewCompiledTemplateDecodeError(cause error) error {
+	var decodeErr *internal.CompiledDecodeError
+	if !errors.As(cause, &decodeErr) {
+		return NewCompiledTemplateError(ErrMsgCompiledTemplateDecode, cause)
+	}
+	err := cuserr.WrapStdError(cause, decodeErr.Code, ErrMsgCompiledTemplateDecode)
+	err.Category = cuserr.ErrorCategoryValidation
+	err = withSentinel(err, ErrValidation).
+		WithMetadata(MetaKeyOffset, strconv.Itoa(decodeErr.Offset))
+	if decodeErr.Version > 0 {
+		err = err.WithMetadata(MetaKeyVersion, strconv.FormatUint(decodeErr.Version, 10))
+	}
+	return err
+}
+
 // NewUnterminatedTagError creates an error for unterminated tags
 func NewUnterminatedTagError(pos Position) error {
 	return cuserr.NewValidationError(ErrCodeParse, ErrMsgUnterminatedTag).
@@ -306,6 +470,25 @@ func NewResolverExistsError(tagName string) error {
 		WithMetadata(MetaKeyTag, tagName)
 }
 
+// NewSnapshotReadOnlyError creates an error for registering with an engine
+// snapshot
+func NewSnapshotReadOnlyError() error {
+	return cuserr.NewValidationError(ErrCodeRegistry, ErrMsgSnapshotReadOnly)
+}
+
+// NewDataHashError creates an error for template data that cannot be
+// encoded for hashing, such as data holding functions or channels
+func NewDataHashError(cause error) error {
+	return cuserr.WrapStdError(cause, ErrCodeValidation, ErrMsgDataNotHashable)
+}
+
+// NewBatchItemSkippedError creates an error for a batch item that was not
+// rendered because an earlier item failed with FailFast set
+func NewBatchItemSkippedError(index int) error {
+	return cuserr.NewValidationError(ErrCodeExec, ErrMsgBatchItemSkipped).
+		WithMetadata(MetaKeyBatchIndex, strconv.Itoa(index))
+}
+
 // NewMissingAttributeError creates a missing required attribute error
 func NewMissingAttributeError(attrName string, tagName string) error {
 	return cuserr.NewValidationError(ErrCodeValidation, ErrMsgMissingAttribute).
@@ -337,10 +520,17 @@ func NewTypeConversionError(fromType, toType string, value any) error {
 
 // NewTemplateNotFoundError creates an error for missing templates
 func NewTemplateNotFoundError(name string) error {
-	return cuserr.NewNotFoundError(MetaKeyTemplateName, ErrMsgTemplateNotFound).
+	return withSentinel(cuserr.NewNotFoundError(MetaKeyTemplateName, ErrMsgTemplateNotFound), ErrTemplateNotFound).
 		WithMetadata(MetaKeyTemplateName, name)
 }
 
+// NewBlockNotFoundError creates an error for rendering a block the template
+// does not define
+func NewBlockNotFoundError(name string) error {
+	return cuserr.NewNotFoundError(ErrCodeTemplate, ErrMsgBlockNotFound).
+		WithMetadata(MetaKeyBlockName, name)
+}
+
 // NewTemplateExistsError creates an error for duplicate template registration
 func NewTemplateExistsError(name string) error {
 	return cuserr.NewValidationError(ErrCodeTemplate, ErrMsgTemplateAlreadyExists).
@@ -354,6 +544,30 @@ func NewTemplateDepthError(depth, max int) error {
 		WithMetadata(MetaKeyMaxDepth, strconv.Itoa(max))
 }
 
+// NewTemplateIncludeCycleError creates an error when a template includes
+// itself, directly or through other templates.
+func NewTemplateIncludeCycleError(name string, chain []string) error {
+	return withSentinel(cuserr.NewValidationError(ErrCodeTemplate, ErrMsgTemplateIncludeCycle), ErrCycleDetected).
+		WithMetadata(MetaKeyTemplateName, name).
+		WithMetadata(MetaKeyIncludePath, strings.Join(append(chain, name), IncludeChainSeparator))
+}
+
+// NewInheritanceCycleError creates an error when a template extends itself,
+// directly or through its parents.
+func NewInheritanceCycleError(name string, chain []string) error {
+	return withSentinel(cuserr.NewValidationError(ErrCodeTemplate, ErrMsgInheritanceCycle), ErrCycleDetected).
+		WithMetadata(MetaKeyTemplateName, name).
+		WithMetadata(MetaKeyExtendsPath, strings.Join(append(chain, name), IncludeChainSeparator))
+}
+
+// NewInheritanceDepthError creates an error when an inheritance chain is
+// longer than the engine's maximum depth.
+func NewInheritanceDepthError(depth, max int) error {
+	return cuserr.NewValidationError(ErrCodeTemplate, ErrMsgInheritanceDepthExceeded).
+		WithMetadata(MetaKeyCurrentDepth, strconv.Itoa(depth)).
+		WithMetadata(MetaKeyMaxDepth, strconv.Itoa(max))
+}
+
 // NewReservedTemplateNameError creates an error for reserved namespace usage
 func NewReservedTemplateNameError(name string) error {
 	return cuserr.NewValidationError(ErrCodeTemplate, ErrMsgReservedTemplateName).
@@ -443,6 +657,12 @@ func NewFrontmatterParseError(cause error) error {
 	return cuserr.WrapStdError(cause, ErrCodeConfig, ErrMsgFrontmatterParse)
 }
 
+// NewJSONFrontmatterParseError creates an error for JSON frontmatter and
+// JSON document parsing failures.
+func NewJSONFrontmatterParseError(cause error) error {
+	return cuserr.WrapStdError(cause, ErrCodeConfig, ErrMsgFrontmatterJSONParse)
+}
+
 // NewMessageTagError creates an error for message tag validation failures
 func NewMessageTagError(msg string, tagPos Position) error {
 	return cuserr.NewValidationError(ErrCodeValidation, msg).
@@ -491,6 +711,35 @@ func NewSchemaValidationError(msg, path string) error {
 		WithMetadata(MetaKeyPath, path)
 }
 
+// NewGrammarError creates an error for a guided decoding grammar, naming
+// the rule and line of the problem when known.
+func NewGrammarError(msg, rule string, line int) error {
+	err := cuserr.NewValidationError(ErrCodeSchema, msg)
+	if rule != "" {
+		err = err.WithMetadata(MetaKeyRule, rule)
+	}
+	if line > 0 {
+		err = err.WithMetadata(MetaKeyLine, strconv.Itoa(line))
+	}
+	return err
+}
+
+// NewImportTypeError creates an error for a foreign document type that
+// cannot be imported, naming the type.
+func NewImportTypeError(msg, docType string) error {
+	return cuserr.NewValidationError(ErrCodeConfig, msg).WithMetadata(MetaKeyTag, docType)
+}
+
+// NewConvertError creates an error for a template construct that prevents
+// converting a foreign template dialect.
+func NewConvertError(msg, construct string, pos Position) error {
+	return cuserr.NewValidationError(ErrCodeParse, msg).
+		WithMetadata(MetaKeyTag, construct).
+		WithMetadata(MetaKeyLine, strconv.Itoa(pos.Line)).
+		WithMetadata(MetaKeyColumn, strconv.Itoa(pos.Column)).
+		WithMetadata(MetaKeyOffset, strconv.Itoa(pos.Offset))
+}
+
 // NewSchemaProviderError creates an error for provider-specific schema issues.
 func NewSchemaProviderError(msg, provider string) error {
 	return cuserr.NewValidationError(ErrCodeSchema, msg).
@@ -548,7 +797,7 @@ func NewRefCircularError(slug string, chain []string) error {
 		}
 		chainStr += s
 	}
-	return cuserr.NewValidationError(ErrCodeRef, ErrMsgRefCircular).
+	return withSentinel(cuserr.NewValidationError(ErrCodeRef, ErrMsgRefCircular), ErrCycleDetected).
 		WithMetadata(MetaKeyPromptSlug, slug).
 		WithMetadata(MetaKeyRefChain, chainStr)
 }
@@ -610,6 +859,19 @@ func NewSkillNotFoundError(slug string) error {
 		WithMetadata(MetaKeySkillSlug, slug)
 }
 
+// NewSkillVersionNotFoundError creates an error for a skill without a version satisfying a constraint.
+func NewSkillVersionNotFoundError(slug, constraint string) error {
+	return cuserr.NewNotFoundError(ErrCodeAgent, ErrMsgSkillVersionNotFound).
+		WithMetadata(MetaKeySkillSlug, slug).
+		WithMetadata(MetaKeyVersionConstraint, constraint)
+}
+
+// NewVersionConstraintError creates an error for an unparsable version or version constraint.
+func NewVersionConstraintError(constraint string) error {
+	return cuserr.NewValidationError(ErrCodeAgent, ErrMsgInvalidVersionConstraint).
+		WithMetadata(MetaKeyVersionConstraint, constraint)
+}
+
 // NewCompileMessageError creates an error for message compilation failures with index context.
 func NewCompileMessageError(messageIndex int, role string, cause error) error {
 	return cuserr.WrapStdError(cause, ErrCodeCompile, ErrMsgCompileMessageFailed).
@@ -625,18 +887,155 @@ func NewCompileSkillError(skillSlug string, cause error) error {
 		WithMetadata(MetaKeyCompileStage, "skill_activation")
 }
 
+// NewCompileSkillResolveError creates an error for a skill that could not be
+// resolved while compiling an agent with ResolveErrorFail.
+func NewCompileSkillResolveError(skillSlug string, cause error) error {
+	return cuserr.WrapStdError(cause, ErrCodeCompile, ErrMsgCompileSkillUnresolved).
+		WithMetadata(MetaKeySkillSlug, skillSlug).
+		WithMetadata(MetaKeyCompileStage, "skills")
+}
+
 // NewCompileBodyError creates an error for body compilation failures with stage context.
 func NewCompileBodyError(cause error) error {
 	return cuserr.WrapStdError(cause, ErrCodeCompile, ErrMsgCompileBodyFailed).
 		WithMetadata(MetaKeyCompileStage, "body")
 }
 
+// NewToolResultError creates an error for an invalid tool result passed to
+// CompiledPrompt.WithToolResults.
+func NewToolResultError(msg string, index int) error {
+	return cuserr.NewValidationError(ErrCodeCompile, msg).
+		WithMetadata(MetaKeyToolResultIndex, strconv.Itoa(index))
+}
+
+// NewToolImportError creates an error for a tool definition document that
+// cannot be converted; location points at the offending part of it.
+func NewToolImportError(msg, location string) error {
+	return cuserr.NewValidationError(ErrCodeSchema, msg).
+		WithMetadata(MetaKeyPath, location)
+}
+
+// NewBundleError creates an error for a bundle that cannot be built or
+// installed; document names the offending bundle entry.
+func NewBundleError(msg, document string, cause error) error {
+	if cause != nil {
+		return cuserr.WrapStdError(cause, ErrCodeConfig, msg).
+			WithMetadata(MetaKeyTemplateName, document)
+	}
+	return cuserr.NewValidationError(ErrCodeConfig, msg).
+		WithMetadata(MetaKeyTemplateName, document)
+}
+
+// NewBundleDependencyError creates an error for a bundle document whose
+// dependency is neither bundled nor installed in a satisfying version.
+func NewBundleDependencyError(document string, dep BundleDependency) error {
+	return cuserr.NewValidationError(ErrCodeConfig, ErrMsgBundleUnsatisfied).
+		WithMetadata(MetaKeyTemplateName, document).
+		WithMetadata(MetaKeyPromptSlug, dep.Name).
+		WithMetadata(MetaKeyVersion, dep.Version)
+}
+
+// NewRecordingError creates an error for an execution recording that cannot
+// be read, written or replayed.
+func NewRecordingError(msg string, cause error) error {
+	if cause != nil {
+		return cuserr.WrapStdError(cause, ErrCodeReplay, msg)
+	}
+	return cuserr.NewValidationError(ErrCodeReplay, msg)
+}
+
+// NewReplayMissingResultError creates an error for a resolver call during
+// replay that the recording has no result for.
+func NewReplayMissingResultError(tagName string) error {
+	return cuserr.NewNotFoundError(ErrCodeReplay, ErrMsgReplayNoRecordedResult).
+		WithMetadata(MetaKeyTag, tagName)
+}
+
 // NewProviderMessageError creates an error for unsupported provider in message serialization.
 func NewProviderMessageError(provider string) error {
 	return cuserr.NewValidationError(ErrCodeCompile, ErrMsgUnsupportedMsgProvider).
 		WithMetadata(MetaKeyProvider, provider)
 }
 
+// NewCostUnknownModelError creates an error for a model missing from the pricing table.
+func NewCostUnknownModelError(provider, model string) error {
+	return cuserr.NewNotFoundError(ErrCodeCost, ErrMsgCostUnknownModel).
+		WithMetadata(MetaKeyProvider, provider).
+		WithMetadata(MetaKeyModel, model)
+}
+
+// NewCostPricingError creates an error for an invalid pricing table entry or document.
+func NewCostPricingError(model string, cause error) error {
+	if cause != nil {
+		return cuserr.WrapStdError(cause, ErrCodeCost, ErrMsgCostInvalidPricing)
+	}
+	return cuserr.NewValidationError(ErrCodeCost, ErrMsgCostInvalidPricing).
+		WithMetadata(MetaKeyModel, model)
+}
+
+// NewCostNoExecutionError creates an error for pricing a template without execution config.
+func NewCostNoExecutionError() error {
+	return cuserr.NewValidationError(ErrCodeCost, ErrMsgCostNoExecution)
+}
+
+// NewModelAliasError creates an error for an invalid model alias or model
+// registry document.
+func NewModelAliasError(msg, alias string, cause error) error {
+	if cause != nil {
+		return cuserr.WrapStdError(cause, ErrCodeModel, msg).
+			WithMetadata(MetaKeyModelAlias, alias)
+	}
+	return cuserr.NewValidationError(ErrCodeModel, msg).
+		WithMetadata(MetaKeyModelAlias, alias)
+}
+
+// NewVariantError creates an error for an invalid or unknown prompt variant.
+func NewVariantError(msg, variant string) error {
+	return cuserr.NewValidationError(ErrCodeVariant, msg).
+		WithMetadata(MetaKeyVariant, variant)
+}
+
+// NewVariantNotFoundError creates an error for selecting an undefined prompt variant.
+func NewVariantNotFoundError(variant string) error {
+	return cuserr.NewNotFoundError(ErrCodeVariant, ErrMsgVariantNotFound).
+		WithMetadata(MetaKeyVariant, variant)
+}
+
+// NewVariantBlockNotFoundError creates an error for a variant overriding a
+// block the prompt body does not define.
+func NewVariantBlockNotFoundError(variant, block string) error {
+	return cuserr.NewValidationError(ErrCodeVariant, ErrMsgVariantBlockNotFound).
+		WithMetadata(MetaKeyVariant, variant).
+		WithMetadata(MetaKeyBlockName, block)
+}
+
+// NewSelfTestError creates an error for an invalid test case in the tests
+// section of a prompt.
+func NewSelfTestError(msg, test string) error {
+	return cuserr.NewValidationError(ErrCodeSelfTest, msg).
+		WithMetadata(MetaKeyTestName, test)
+}
+
+// NewFallbackExhaustedError creates an error for a request whose attempts
+// under its fallback policy all failed; cause is the last failure.
+func NewFallbackExhaustedError(attempts int, cause error) error {
+	return cuserr.WrapStdError(cause, ErrCodeFallback, ErrMsgFallbackExhausted).
+		WithMetadata(MetaKeyAttempts, strconv.Itoa(attempts))
+}
+
+// NewEmbeddingError creates an error for a failed embedding computation.
+func NewEmbeddingError(msg string, cause error) error {
+	if cause != nil {
+		return cuserr.WrapStdError(cause, ErrCodeEmbedding, msg)
+	}
+	return cuserr.NewValidationError(ErrCodeEmbedding, msg)
+}
+
+// NewEmbeddingNoIndexerError creates an error for semantic search without an EmbeddingIndexer.
+func NewEmbeddingNoIndexerError() error {
+	return cuserr.NewValidationError(ErrCodeEmbedding, ErrMsgEmbeddingNoIndexer)
+}
+
 // NewInvalidDocumentTypeError creates an error for invalid document type.
 func NewInvalidDocumentTypeError(docType string) error {
 	return cuserr.NewValidationError(ErrCodeAgent, ErrMsgInvalidDocumentType).
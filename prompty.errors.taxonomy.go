@@ -0,0 +1,216 @@
+package prompty
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/itsatony/go-cuserr"
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// Sentinel errors matched by errors.Is, whichever subsystem returned the
+// error and however deeply it is wrapped. Use errors.As with the concrete
+// error types (*AccessError, *RateLimitError, *StorageError, *HookError)
+// for their fields, or ErrorDetailsOf for a serializable summary.
+// ErrAccessDenied, ErrRateLimited and ErrCircuitOpen complete the set.
+var (
+	// ErrNotFound matches every error about something that does not exist:
+	// templates, versions, labels, variables, skills, referenced prompts.
+	ErrNotFound = internal.ErrNotFound
+	// ErrTemplateNotFound matches missing templates, in storage and in
+	// includes and imports. It refines ErrNotFound.
+	ErrTemplateNotFound = internal.ErrTemplateNotFound
+	// ErrValidation matches every error about invalid input: templates,
+	// attributes, options, prompts.
+	ErrValidation = internal.ErrValidation
+	// ErrCycleDetected matches circular includes, imports, inheritance and
+	// prompt references. It refines ErrValidation.
+	ErrCycleDetected = internal.ErrCycleDetected
+	// ErrExecutionTimeout matches executions and resolutions stopped by
+	// their deadline.
+	ErrExecutionTimeout = internal.ErrExecutionTimeout
+)
+
+// ErrorKind classifies an error by the sentinel it matches.
+type ErrorKind string
+
+// Error kinds, from the most to the least specific.
+const (
+	ErrorKindAccessDenied     ErrorKind = "access_denied"
+	ErrorKindRateLimited      ErrorKind = "rate_limited"
+	ErrorKindCircuitOpen      ErrorKind = "circuit_open"
+	ErrorKindExecutionTimeout ErrorKind = "execution_timeout"
+	ErrorKindTemplateNotFound ErrorKind = "template_not_found"
+	ErrorKindCycleDetected    ErrorKind = "cycle_detected"
+	ErrorKindNotFound         ErrorKind = "not_found"
+	ErrorKindValidation       ErrorKind = "validation"
+	ErrorKindInternal         ErrorKind = "internal"
+)
+
+// errorKinds maps each kind but ErrorKindInternal to its sentinel, its
+// category and its fallback code, in matching order.
+var errorKinds = []struct {
+	kind     ErrorKind
+	sentinel error
+	category cuserr.ErrorCategory
+	code     string
+}{
+	{ErrorKindAccessDenied, ErrAccessDenied, cuserr.ErrorCategoryForbidden, ErrCodeAccess},
+	{ErrorKindRateLimited, ErrRateLimited, cuserr.ErrorCategoryRateLimit, ErrCodeRateLimit},
+	{ErrorKindCircuitOpen, ErrCircuitOpen, cuserr.ErrorCategoryExternal, ErrCodeRef},
+	{ErrorKindExecutionTimeout, ErrExecutionTimeout, cuserr.ErrorCategoryTimeout, ErrCodeTimeout},
+	{ErrorKindExecutionTimeout, context.DeadlineExceeded, cuserr.ErrorCategoryTimeout, ErrCodeTimeout},
+	{ErrorKindTemplateNotFound, ErrTemplateNotFound, cuserr.ErrorCategoryNotFound, ErrCodeNotFound},
+	{ErrorKindCycleDetected, ErrCycleDetected, cuserr.ErrorCategoryValidation, ErrCodeValidation},
+	{ErrorKindNotFound, ErrNotFound, cuserr.ErrorCategoryNotFound, ErrCodeNotFound},
+	{ErrorKindValidation, ErrValidation, cuserr.ErrorCategoryValidation, ErrCodeValidation},
+}
+
+// errorCodePrefix starts the codes of this package's errors.
+const errorCodePrefix = "PROMPTY_"
+
+// Metadata keys under which cuserr constructors store the error code, in
+// lookup order.
+var cuserrCodeKeys = []string{"context", "field", "resource"}
+
+// Metadata keys of cuserr errors that hold the error code or duplicate
+// other fields, left out of ErrorDetails.Metadata.
+var cuserrBookkeepingKeys = map[string]bool{
+	"context":        true,
+	"field":          true,
+	"resource":       true,
+	"resource_id":    true,
+	"error_type":     true,
+	"migrated_from":  true,
+	"original_error": true,
+}
+
+// ErrorDetails is the machine-readable form of an error, for API layers
+// that serialize errors or map them to status codes.
+type ErrorDetails struct {
+	// Code is the code of the subsystem that failed, such as ErrCodeParse
+	// or ErrCodeStorage.
+	Code string `json:"code"`
+	// Kind classifies the error by the sentinel it matches.
+	Kind ErrorKind `json:"kind"`
+	// Category is the cuserr category of the error, such as "not_found".
+	Category string `json:"category"`
+	// Message is the error text.
+	Message string `json:"message"`
+	// Metadata holds the context the errors in the chain carry, such as
+	// the template name or the line of a parse error.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// HTTPStatus returns the HTTP status code matching the error's category.
+func (d *ErrorDetails) HTTPStatus() int {
+	return cuserr.CategoryToHTTPStatus(cuserr.ErrorCategory(d.Category))
+}
+
+// ErrorDetailsOf returns the machine-readable details of err, or nil for a
+// nil error. The code comes from the outermost error of this package in
+// the chain, the kind from the sentinel err matches and the metadata from
+// all errors in the chain, outer ones taking precedence.
+func ErrorDetailsOf(err error) *ErrorDetails {
+	if err == nil {
+		return nil
+	}
+
+	details := &ErrorDetails{
+		Kind:     ErrorKindInternal,
+		Category: string(cuserr.ErrorCategoryInternal),
+		Message:  err.Error(),
+	}
+	var fallbackCode string
+	for _, k := range errorKinds {
+		if errors.Is(err, k.sentinel) {
+			details.Kind = k.kind
+			details.Category = string(k.category)
+			fallbackCode = k.code
+			break
+		}
+	}
+
+	metadata := make(map[string]string)
+	setDefault := func(key, value string) {
+		if _, ok := metadata[key]; !ok && value != "" {
+			metadata[key] = value
+		}
+	}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		var code string
+		switch t := e.(type) {
+		case *cuserr.CustomError:
+			all := t.GetAllMetadata()
+			for _, key := range cuserrCodeKeys {
+				if value := all[key]; strings.HasPrefix(value, errorCodePrefix) {
+					code = value
+					break
+				}
+			}
+			for key, value := range all {
+				if !cuserrBookkeepingKeys[key] {
+					setDefault(key, value)
+				}
+			}
+			if details.Kind == ErrorKindInternal && t.Category != "" {
+				details.Category = string(t.Category)
+			}
+		case *AccessError:
+			code = ErrCodeAccess
+			setDefault(MetaKeyTemplateName, t.Template)
+			setDefault(MetaKeyOperation, string(t.Operation))
+			if t.Subject != nil {
+				setDefault(MetaKeySubject, t.Subject.ID)
+			}
+		case *RateLimitError:
+			code = ErrCodeRateLimit
+			setDefault(MetaKeyTemplateName, t.Template)
+			setDefault(MetaKeyOperation, string(t.Operation))
+			if t.RetryAfter > 0 {
+				setDefault(MetaKeyRetryAfter, t.RetryAfter.String())
+			}
+		case *StorageError:
+			code = ErrCodeStorage
+			setDefault(MetaKeyTemplateName, t.Name)
+			if t.Version > 0 {
+				setDefault(MetaKeyVersion, intToStr(t.Version))
+			}
+		case *HookError:
+			code = ErrCodeHook
+			setDefault(MetaKeyHookPoint, string(t.Point))
+		case *internal.BuiltinError:
+			code = ErrCodeExec
+			setDefault(MetaKeyTag, t.TagName)
+			for key, value := range t.Metadata {
+				setDefault(key, value)
+			}
+		case *internal.ExprEvalError:
+			code = ErrCodeExec
+			for key, value := range t.Metadata {
+				setDefault(key, value)
+			}
+		}
+		if details.Code == "" {
+			details.Code = code
+		}
+	}
+
+	if details.Code == "" {
+		details.Code = fallbackCode
+	}
+	if details.Code == "" {
+		details.Code = ErrCodeInternal
+	}
+	if len(metadata) > 0 {
+		details.Metadata = metadata
+	}
+	return details
+}
+
+// withSentinel sets the sentinel err matches with errors.Is.
+func withSentinel(err *cuserr.CustomError, sentinel error) *cuserr.CustomError {
+	err.Sentinel = sentinel
+	return err
+}
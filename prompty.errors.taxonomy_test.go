@@ -0,0 +1,169 @@
+package prompty
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSentinels_Storage(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "greeting", Source: "Hello"}))
+
+	_, err := storage.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NotErrorIs(t, err, ErrValidation)
+
+	_, err = storage.GetVersion(ctx, "greeting", 42)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NotErrorIs(t, err, ErrTemplateNotFound)
+
+	details := ErrorDetailsOf(err)
+	assert.Equal(t, ErrCodeStorage, details.Code)
+	assert.Equal(t, ErrorKindNotFound, details.Kind)
+	assert.Equal(t, "greeting", details.Metadata[MetaKeyTemplateName])
+	assert.Equal(t, "42", details.Metadata[MetaKeyVersion])
+	assert.Equal(t, http.StatusNotFound, details.HTTPStatus())
+
+	assert.NotErrorIs(t, NewStorageClosedError(), ErrNotFound)
+}
+
+func TestSentinels_Execution(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("missing include", func(t *testing.T) {
+		_, err := MustNew().Execute(ctx, `{~prompty.include template="missing" /~}`, nil)
+		assert.ErrorIs(t, err, ErrTemplateNotFound)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("include cycle", func(t *testing.T) {
+		engine := MustNew()
+		require.NoError(t, engine.RegisterTemplate("a", `{~prompty.include template="b" /~}`))
+		require.NoError(t, engine.RegisterTemplate("b", `{~prompty.include template="a" /~}`))
+
+		_, err := engine.ExecuteTemplate(ctx, "a", nil)
+		assert.ErrorIs(t, err, ErrCycleDetected)
+		assert.ErrorIs(t, err, ErrValidation)
+		assert.Equal(t, ErrorKindCycleDetected, ErrorDetailsOf(err).Kind)
+	})
+
+	t.Run("missing reference", func(t *testing.T) {
+		tmpl, err := MustNew().Parse(`{~prompty.ref slug="missing" /~}`)
+		require.NoError(t, err)
+
+		_, err = tmpl.ExecuteWithContext(ctx, NewContext(nil).WithPromptResolver(&mockPromptResolver{}))
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NotErrorIs(t, err, ErrTemplateNotFound)
+	})
+
+	t.Run("expression timeout", func(t *testing.T) {
+		expired, cancel := context.WithTimeout(ctx, time.Nanosecond)
+		defer cancel()
+		<-expired.Done()
+
+		_, err := MustNew().Execute(expired, `{~prompty.if eval="n > 1"~}big{~/prompty.if~}`, map[string]any{"n": 2})
+		assert.ErrorIs(t, err, ErrExecutionTimeout)
+		assert.Equal(t, ErrorKindExecutionTimeout, ErrorDetailsOf(err).Kind)
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		_, err := MustNew().Parse(`{~prompty.var name="x"`)
+		assert.ErrorIs(t, err, ErrValidation)
+
+		details := ErrorDetailsOf(err)
+		assert.Equal(t, ErrCodeParse, details.Code)
+		assert.Equal(t, ErrorKindValidation, details.Kind)
+		assert.Equal(t, http.StatusBadRequest, details.HTTPStatus())
+	})
+
+	t.Run("cycle constructors", func(t *testing.T) {
+		assert.ErrorIs(t, NewRefCircularError("a", []string{"a", "b"}), ErrCycleDetected)
+		assert.ErrorIs(t, NewInheritanceCycleError("a", []string{"b"}), ErrCycleDetected)
+		assert.ErrorIs(t, NewTemplateIncludeCycleError("a", []string{"b"}), ErrValidation)
+	})
+}
+
+func TestSentinels_AccessAndRateLimits(t *testing.T) {
+	subject := &AccessSubject{ID: "user-1"}
+
+	denied := fmt.Errorf("serving: %w", NewAccessDeniedError(OpExecute, "greeting", subject))
+	assert.ErrorIs(t, denied, ErrAccessDenied)
+	assert.NotErrorIs(t, NewAccessCheckError(OpExecute, "greeting", errors.New("backend down")), ErrAccessDenied)
+
+	details := ErrorDetailsOf(denied)
+	assert.Equal(t, ErrCodeAccess, details.Code)
+	assert.Equal(t, ErrorKindAccessDenied, details.Kind)
+	assert.Equal(t, map[string]string{
+		MetaKeyTemplateName: "greeting",
+		MetaKeyOperation:    string(OpExecute),
+		MetaKeySubject:      "user-1",
+	}, details.Metadata)
+	assert.Equal(t, http.StatusForbidden, details.HTTPStatus())
+
+	limited := NewRateLimitedError(OpExecute, "greeting", subject, "user-1", 2*time.Second)
+	details = ErrorDetailsOf(limited)
+	assert.Equal(t, ErrorKindRateLimited, details.Kind)
+	assert.Equal(t, "2s", details.Metadata[MetaKeyRetryAfter])
+	assert.Equal(t, http.StatusTooManyRequests, details.HTTPStatus())
+}
+
+func TestSentinels_Resolvers(t *testing.T) {
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{Timeout: time.Millisecond, FailureThreshold: 1})
+
+	err := breaker.Do(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.ErrorIs(t, err, ErrExecutionTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	err = breaker.Do(context.Background(), func(context.Context) error { return nil })
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, ErrorKindCircuitOpen, ErrorDetailsOf(err).Kind)
+}
+
+func TestErrorDetailsOf(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		assert.Nil(t, ErrorDetailsOf(nil))
+	})
+
+	t.Run("foreign error", func(t *testing.T) {
+		details := ErrorDetailsOf(errors.New("boom"))
+		assert.Equal(t, ErrCodeInternal, details.Code)
+		assert.Equal(t, ErrorKindInternal, details.Kind)
+		assert.Equal(t, "boom", details.Message)
+		assert.Nil(t, details.Metadata)
+		assert.Equal(t, http.StatusInternalServerError, details.HTTPStatus())
+	})
+
+	t.Run("outermost code wins", func(t *testing.T) {
+		err := NewCompileSkillResolveError("search", NewStorageVersionNotFoundError("search", 3))
+		details := ErrorDetailsOf(err)
+		assert.Equal(t, ErrCodeCompile, details.Code)
+		assert.Equal(t, ErrorKindNotFound, details.Kind)
+		assert.Equal(t, "search", details.Metadata[MetaKeySkillSlug])
+		assert.Equal(t, "3", details.Metadata[MetaKeyVersion])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		encoded, err := json.Marshal(ErrorDetailsOf(NewTemplateNotFoundError("greeting")))
+		require.NoError(t, err)
+
+		var decoded map[string]any
+		require.NoError(t, json.Unmarshal(encoded, &decoded))
+		assert.Equal(t, ErrCodeNotFound, decoded["code"])
+		assert.Equal(t, string(ErrorKindTemplateNotFound), decoded["kind"])
+		assert.Equal(t, "not_found", decoded["category"])
+		assert.Equal(t, map[string]any{MetaKeyTemplateName: "greeting"}, decoded["metadata"])
+	})
+}
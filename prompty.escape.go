@@ -0,0 +1,47 @@
+package prompty
+
+import (
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// OutputMode selects how interpolated values (prompty.var, prompty.env and
+// prompty.choice output) are escaped for the format of the surrounding
+// template, so that data cannot break a JSON document, inject markup or
+// split a shell word. Literal template text is never escaped.
+type OutputMode string
+
+// Built-in output modes.
+const (
+	OutputText     OutputMode = internal.OutputModeText     // No escaping
+	OutputHTML     OutputMode = internal.OutputModeHTML     // HTML entities for <, >, &, ' and "
+	OutputJSON     OutputMode = internal.OutputModeJSON     // Contents of a JSON string literal, without the quotes
+	OutputMarkdown OutputMode = internal.OutputModeMarkdown // Backslash before Markdown formatting characters
+	OutputShell    OutputMode = internal.OutputModeShell    // Single-quoted POSIX shell word
+)
+
+// WithOutputMode escapes every interpolated value in mode, e.g. for
+// templates that render a JSON payload:
+//
+//	engine := prompty.MustNew(prompty.WithOutputMode(prompty.OutputJSON))
+//	// {"question": "{~prompty.var name="question" /~}"}
+//
+// A prompty.escape block changes the mode for its body, and the escape
+// attribute of a single tag overrides both (escape="text" disables it).
+// Included templates inherit the mode in effect at the include.
+// Default: OutputText (no escaping)
+func WithOutputMode(mode OutputMode) Option {
+	return func(c *engineConfig) {
+		c.outputMode = mode
+	}
+}
+
+// WithEscaper adds an output mode, or replaces a built-in one, for use with
+// WithOutputMode, prompty.escape and the escape attribute.
+func WithEscaper(mode OutputMode, escaper func(string) string) Option {
+	return func(c *engineConfig) {
+		if c.escapers == nil {
+			c.escapers = make(map[OutputMode]func(string) string)
+		}
+		c.escapers[mode] = escaper
+	}
+}
@@ -0,0 +1,65 @@
+package prompty
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithOutputMode_JSON(t *testing.T) {
+	engine := MustNew(WithOutputMode(OutputJSON))
+	require.NoError(t, engine.RegisterTemplate("context", `"context": "{~prompty.var name="context" /~}"`))
+
+	data := map[string]any{
+		"question": "Is \"prompty\" safe?\nYes.",
+		"context":  `C:\docs`,
+	}
+	out, err := engine.Execute(context.Background(),
+		`{"question": "{~prompty.var name="question" /~}", {~prompty.include template="context" with="context=context" /~}}`, data)
+	require.NoError(t, err)
+
+	var payload map[string]string
+	require.NoError(t, json.Unmarshal([]byte(out), &payload), out)
+	assert.Equal(t, data["question"], payload["question"])
+	assert.Equal(t, data["context"], payload["context"], "included templates inherit the mode")
+}
+
+func TestOutputMode_Region(t *testing.T) {
+	engine := MustNew()
+	data := map[string]any{"file": "it's here.txt", "title": "<b>Hi</b>"}
+
+	out, err := engine.Execute(context.Background(),
+		`cat {~prompty.escape mode="shell"~}{~prompty.var name="file" /~}{~/prompty.escape~}`, data)
+	require.NoError(t, err)
+	assert.Equal(t, `cat 'it'\''s here.txt'`, out)
+
+	out, err = engine.Execute(context.Background(),
+		`<h1>{~prompty.var name="title" escape="html" /~}</h1>{~prompty.var name="title" /~}`, data)
+	require.NoError(t, err)
+	assert.Equal(t, `<h1>&lt;b&gt;Hi&lt;/b&gt;</h1><b>Hi</b>`, out)
+}
+
+func TestWithEscaper(t *testing.T) {
+	engine, err := New(WithEscaper("csv", func(s string) string {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}), WithOutputMode("csv"))
+	require.NoError(t, err)
+
+	out, err := engine.Execute(context.Background(), `{~prompty.var name="a" /~},{~prompty.var name="b" /~}`,
+		map[string]any{"a": `say "hi"`, "b": "x,y"})
+	require.NoError(t, err)
+	assert.Equal(t, `"say ""hi""","x,y"`, out)
+}
+
+func TestWithOutputMode_Unknown(t *testing.T) {
+	_, err := New(WithOutputMode("yaml"))
+	assert.ErrorContains(t, err, ErrMsgUnknownOutputMode)
+
+	_, err = MustNew().Execute(context.Background(),
+		`{~prompty.escape mode="yaml"~}{~prompty.var name="a" /~}{~/prompty.escape~}`, map[string]any{"a": "x"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,65 @@
+package prompty
+
+import "strings"
+
+// openAIReasoningPrefixes are the OpenAI reasoning model families, which
+// take max_completion_tokens instead of max_tokens.
+var openAIReasoningPrefixes = []string{"o1", "o3", "o4"}
+
+// isOpenAIReasoningModel checks if a model or deployment name is an OpenAI
+// reasoning model.
+func isOpenAIReasoningModel(name string) bool {
+	for _, prefix := range openAIReasoningPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// AzureDeployment returns the Azure OpenAI deployment name: the
+// "deployment" provider option, or the model when none is set.
+func (e *ExecutionConfig) AzureDeployment() string {
+	if e == nil {
+		return ""
+	}
+	if deployment, ok := e.ProviderOptions[ProviderOptionAzureDeployment].(string); ok && deployment != "" {
+		return deployment
+	}
+	return e.Model
+}
+
+// AzureAPIVersion returns the Azure OpenAI api-version query parameter: the
+// "api_version" provider option, or DefaultAzureAPIVersion.
+func (e *ExecutionConfig) AzureAPIVersion() string {
+	if e != nil {
+		if version, ok := e.ProviderOptions[ProviderOptionAzureAPIVersion].(string); ok && version != "" {
+			return version
+		}
+	}
+	return DefaultAzureAPIVersion
+}
+
+// ToAzureOpenAI converts the execution config to Azure OpenAI Chat
+// Completions format. Azure addresses the model through the deployment in
+// the request URL, so the body carries no model, and the deployment and
+// api_version provider options (see AzureDeployment and AzureAPIVersion)
+// are not sent as parameters. Reasoning model deployments get
+// max_completion_tokens instead of max_tokens.
+func (e *ExecutionConfig) ToAzureOpenAI() map[string]any {
+	if e == nil {
+		return nil
+	}
+
+	result := e.ToOpenAI()
+	delete(result, ParamKeyModel)
+	delete(result, ProviderOptionAzureDeployment)
+	delete(result, ProviderOptionAzureAPIVersion)
+
+	if maxTokens, ok := result[ParamKeyMaxTokens]; ok &&
+		(isOpenAIReasoningModel(e.Model) || isOpenAIReasoningModel(e.AzureDeployment())) {
+		delete(result, ParamKeyMaxTokens)
+		result[ParamKeyMaxCompletionTokens] = maxTokens
+	}
+	return result
+}
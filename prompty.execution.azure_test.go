@@ -0,0 +1,42 @@
+package prompty
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutionConfig_ToAzureOpenAI(t *testing.T) {
+	temp := 0.3
+	maxTokens := 800
+
+	config := &ExecutionConfig{
+		Model:       "gpt-4o",
+		Temperature: &temp,
+		MaxTokens:   &maxTokens,
+		ProviderOptions: map[string]any{
+			ProviderOptionAzureDeployment: "prod-gpt4o",
+			ProviderOptionAzureAPIVersion: "2024-08-01-preview",
+			"user":                        "u1",
+		},
+	}
+	assert.Equal(t, map[string]any{
+		ParamKeyTemperature: 0.3,
+		ParamKeyMaxTokens:   800,
+		"user":              "u1",
+	}, config.ToAzureOpenAI())
+	assert.Equal(t, "prod-gpt4o", config.AzureDeployment())
+	assert.Equal(t, "2024-08-01-preview", config.AzureAPIVersion())
+
+	reasoning := &ExecutionConfig{Model: "o3-mini", MaxTokens: &maxTokens}
+	result := reasoning.ToAzureOpenAI()
+	assert.Equal(t, 800, result[ParamKeyMaxCompletionTokens])
+	assert.NotContains(t, result, ParamKeyMaxTokens)
+	assert.Equal(t, "o3-mini", reasoning.AzureDeployment())
+	assert.Equal(t, DefaultAzureAPIVersion, reasoning.AzureAPIVersion())
+
+	var nilConfig *ExecutionConfig
+	assert.Nil(t, nilConfig.ToAzureOpenAI())
+	assert.Empty(t, nilConfig.AzureDeployment())
+	assert.Equal(t, DefaultAzureAPIVersion, nilConfig.AzureAPIVersion())
+}
@@ -0,0 +1,137 @@
+package prompty
+
+import "strings"
+
+// bedrockRegionPrefixes are the cross-region inference profile prefixes of
+// Bedrock model IDs (e.g. "us.anthropic.claude-3-5-sonnet-20240620-v1:0").
+var bedrockRegionPrefixes = map[string]bool{
+	"us": true, "eu": true, "apac": true, "us-gov": true, "global": true,
+}
+
+// bedrockFamilies are the model vendors available on Bedrock.
+var bedrockFamilies = map[string]bool{
+	BedrockFamilyAnthropic: true, BedrockFamilyAmazon: true, "meta": true, "mistral": true,
+	"cohere": true, "ai21": true, "deepseek": true, "writer": true,
+}
+
+// bedrockModelParts splits a Bedrock model ID into its vendor family and
+// model name, dropping a cross-region prefix. ok is false for IDs that are
+// not Bedrock model IDs.
+func bedrockModelParts(modelID string) (family, name string, ok bool) {
+	segments := strings.SplitN(modelID, ".", 3)
+	if len(segments) == 3 && bedrockRegionPrefixes[segments[0]] {
+		segments = []string{segments[1], segments[2]}
+	} else if len(segments) == 3 {
+		segments = []string{segments[0], segments[1] + "." + segments[2]}
+	}
+	if len(segments) != 2 || !bedrockFamilies[segments[0]] || segments[1] == "" {
+		return "", "", false
+	}
+	return segments[0], segments[1], true
+}
+
+// isBedrockModel checks if the model name is a Bedrock model ID
+// ("<vendor>.<model>", optionally with a cross-region prefix).
+// Used by GetEffectiveProvider() for automatic provider detection.
+func isBedrockModel(name string) bool {
+	_, _, ok := bedrockModelParts(name)
+	return ok
+}
+
+// ToBedrock converts the execution config to AWS Bedrock format. The shape
+// follows the model family of the Bedrock model ID:
+//
+//   - Anthropic models ("anthropic.claude-..."): the InvokeModel Messages
+//     body with anthropic_version and without model.
+//   - Amazon Titan text models ("amazon.titan-text-..."): the InvokeModel
+//     body's textGenerationConfig.
+//   - Other models: the Converse API request with modelId, inferenceConfig
+//     (maxTokens, temperature, topP, stopSequences) and model-specific
+//     parameters such as top_k under additionalModelRequestFields.
+//
+// The prompt itself is added by CompiledPrompt.ToProviderPayload("bedrock").
+func (e *ExecutionConfig) ToBedrock() map[string]any {
+	if e == nil {
+		return nil
+	}
+
+	switch family, name, _ := bedrockModelParts(e.Model); {
+	case family == BedrockFamilyAnthropic:
+		return e.bedrockAnthropic()
+	case family == BedrockFamilyAmazon && strings.HasPrefix(name, BedrockTitanPrefix):
+		return e.bedrockTitan()
+	default:
+		return e.bedrockConverse()
+	}
+}
+
+// bedrockAnthropic builds the Anthropic-on-Bedrock InvokeModel body: the
+// Anthropic parameters without model (part of the endpoint) and stream
+// (a separate operation).
+func (e *ExecutionConfig) bedrockAnthropic() map[string]any {
+	result := e.ToAnthropic()
+	delete(result, ParamKeyModel)
+	delete(result, ParamKeyStream)
+	result[BedrockKeyAnthropicVersion] = BedrockAnthropicVersion
+	return result
+}
+
+// bedrockTitan builds the Titan text InvokeModel parameters.
+func (e *ExecutionConfig) bedrockTitan() map[string]any {
+	config := make(map[string]any)
+	if e.MaxTokens != nil {
+		config[BedrockKeyMaxTokenCount] = *e.MaxTokens
+	}
+	if e.Temperature != nil {
+		config[ParamKeyTemperature] = *e.Temperature
+	}
+	if e.TopP != nil {
+		config[BedrockKeyTopP] = *e.TopP
+	}
+	if len(e.StopSequences) > 0 {
+		config[BedrockKeyStopSequences] = e.StopSequences
+	}
+
+	result := map[string]any{BedrockKeyTextGenerationConfig: config}
+	for k, v := range e.ProviderOptions {
+		result[k] = v
+	}
+	return result
+}
+
+// bedrockConverse builds the Converse API request parameters.
+func (e *ExecutionConfig) bedrockConverse() map[string]any {
+	result := make(map[string]any)
+	if e.Model != "" {
+		result[BedrockKeyModelID] = e.Model
+	}
+
+	inference := make(map[string]any)
+	if e.MaxTokens != nil {
+		inference[BedrockKeyMaxTokens] = *e.MaxTokens
+	}
+	if e.Temperature != nil {
+		inference[ParamKeyTemperature] = *e.Temperature
+	}
+	if e.TopP != nil {
+		inference[BedrockKeyTopP] = *e.TopP
+	}
+	if len(e.StopSequences) > 0 {
+		inference[BedrockKeyStopSequences] = e.StopSequences
+	}
+	if len(inference) > 0 {
+		result[BedrockKeyInferenceConfig] = inference
+	}
+
+	additional := make(map[string]any)
+	if e.TopK != nil {
+		additional[ParamKeyTopK] = *e.TopK
+	}
+	for k, v := range e.ProviderOptions {
+		additional[k] = v
+	}
+	if len(additional) > 0 {
+		result[BedrockKeyAdditionalFields] = additional
+	}
+	return result
+}
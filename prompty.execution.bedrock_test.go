@@ -0,0 +1,91 @@
+package prompty
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsBedrockModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  bool
+	}{
+		{"anthropic.claude-3-5-sonnet-20240620-v1:0", true},
+		{"us.anthropic.claude-3-7-sonnet-20250219-v1:0", true},
+		{"amazon.titan-text-express-v1", true},
+		{"meta.llama3-1-70b-instruct-v1:0", true},
+		{"claude-3-opus", false},
+		{"gpt-4.1", false},
+		{"anthropic.", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			assert.Equal(t, tt.want, isBedrockModel(tt.model))
+		})
+	}
+
+	config := &ExecutionConfig{Model: "anthropic.claude-3-haiku-20240307-v1:0", Thinking: &ThinkingConfig{Enabled: true}}
+	assert.Equal(t, ProviderBedrock, config.GetEffectiveProvider())
+}
+
+func TestExecutionConfig_ToBedrock(t *testing.T) {
+	temp := 0.5
+	maxTokens := 512
+	topP := 0.9
+	topK := 40
+
+	t.Run("anthropic", func(t *testing.T) {
+		config := &ExecutionConfig{
+			Model:       "anthropic.claude-3-5-sonnet-20240620-v1:0",
+			Temperature: &temp,
+			MaxTokens:   &maxTokens,
+			TopK:        &topK,
+			Streaming:   &StreamingConfig{Enabled: true},
+		}
+		assert.Equal(t, map[string]any{
+			BedrockKeyAnthropicVersion: BedrockAnthropicVersion,
+			ParamKeyTemperature:        0.5,
+			ParamKeyMaxTokens:          512,
+			ParamKeyTopK:               40,
+		}, config.ToBedrock())
+	})
+
+	t.Run("titan", func(t *testing.T) {
+		config := &ExecutionConfig{
+			Model:         "amazon.titan-text-express-v1",
+			MaxTokens:     &maxTokens,
+			TopP:          &topP,
+			StopSequences: []string{"User:"},
+		}
+		assert.Equal(t, map[string]any{
+			BedrockKeyTextGenerationConfig: map[string]any{
+				BedrockKeyMaxTokenCount: 512,
+				BedrockKeyTopP:          0.9,
+				BedrockKeyStopSequences: []string{"User:"},
+			},
+		}, config.ToBedrock())
+	})
+
+	t.Run("converse", func(t *testing.T) {
+		config := &ExecutionConfig{
+			Model:           "meta.llama3-1-70b-instruct-v1:0",
+			Temperature:     &temp,
+			MaxTokens:       &maxTokens,
+			TopK:            &topK,
+			ProviderOptions: map[string]any{"max_gen_len": 256},
+		}
+		assert.Equal(t, map[string]any{
+			BedrockKeyModelID: "meta.llama3-1-70b-instruct-v1:0",
+			BedrockKeyInferenceConfig: map[string]any{
+				BedrockKeyMaxTokens: 512,
+				ParamKeyTemperature: 0.5,
+			},
+			BedrockKeyAdditionalFields: map[string]any{ParamKeyTopK: 40, "max_gen_len": 256},
+		}, config.ToBedrock())
+	})
+
+	var nilConfig *ExecutionConfig
+	assert.Nil(t, nilConfig.ToBedrock())
+}
@@ -0,0 +1,221 @@
+package prompty
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Incompatibility is an execution config setting that a provider's
+// serializer does not send. Errors mark settings whose loss changes what
+// the model produces (structured output, guided decoding, media
+// generation); warnings mark dropped tuning parameters.
+type Incompatibility struct {
+	// Field is the execution config setting, e.g. "top_k" or "image.style"
+	Field string
+	// Provider is the provider checked
+	Provider string
+	// Severity is SeverityError or SeverityWarning
+	Severity ValidationSeverity
+	// Message describes the incompatibility
+	Message string
+}
+
+// String returns the incompatibility as "severity: message".
+func (i Incompatibility) String() string {
+	return i.Severity.String() + ": " + i.Message
+}
+
+// capabilitiesCommon are the settings every provider serializes.
+var capabilitiesCommon = []string{
+	CapabilityTemperature, CapabilityMaxTokens, CapabilityTopP, CapabilityStopSequences, CapabilityStreaming,
+}
+
+// capabilitiesOpenAI are the settings of ToOpenAI, shared by OpenAI-compatible
+// providers.
+var capabilitiesOpenAI = append([]string{
+	CapabilitySeed, CapabilityLogprobs, CapabilityLogitBias, CapabilityReasoning, CapabilityResponseFormat, CapabilityStrictSchema,
+	CapabilityImage, CapabilityImageStyle, CapabilityImageQuality, CapabilityAudio, CapabilityEmbedding,
+}, capabilitiesCommon...)
+
+// providerCapabilities is the capability matrix: the execution settings each
+// provider's serializer sends. Keep it in sync with the To*() serializers.
+var providerCapabilities = map[string][]string{
+	ProviderOpenAI:     capabilitiesOpenAI,
+	ProviderAzure:      capabilitiesOpenAI,
+	ProviderOpenRouter: append([]string{CapabilityGateway}, capabilitiesOpenAI...),
+	ProviderLiteLLM:    append([]string{CapabilityGateway}, capabilitiesOpenAI...),
+	ProviderOpenAIResponses: {
+		CapabilityTemperature, CapabilityMaxTokens, CapabilityTopP, CapabilityLogprobs, CapabilityThinking,
+		CapabilityReasoning, CapabilityResponseFormat, CapabilityStrictSchema, CapabilityStreaming,
+	},
+	ProviderAnthropic: append([]string{
+		CapabilityTopK, CapabilitySeed, CapabilityThinking, CapabilityReasoning, CapabilityResponseFormat,
+		CapabilityStrictSchema,
+	}, capabilitiesCommon...),
+	capabilityKeyBedrockAnthropic: {
+		CapabilityTemperature, CapabilityMaxTokens, CapabilityTopP, CapabilityTopK, CapabilityStopSequences,
+		CapabilitySeed, CapabilityThinking, CapabilityReasoning, CapabilityResponseFormat, CapabilityStrictSchema,
+	},
+	capabilityKeyBedrockTitan: {
+		CapabilityTemperature, CapabilityMaxTokens, CapabilityTopP, CapabilityStopSequences,
+	},
+	ProviderBedrock: {
+		CapabilityTemperature, CapabilityMaxTokens, CapabilityTopP, CapabilityTopK, CapabilityStopSequences,
+	},
+	ProviderGemini: append([]string{
+		CapabilityTopK, CapabilityReasoning, CapabilityResponseFormat, CapabilityImage, CapabilityImageAspectRatio,
+		CapabilityEmbedding,
+	}, capabilitiesCommon...),
+	ProviderVLLM: append([]string{
+		CapabilityTopK, CapabilityMinP, CapabilityRepetitionPenalty, CapabilitySeed, CapabilityLogprobs,
+		CapabilityStopTokenIDs, CapabilityLogitBias, CapabilityGuidedDecoding, CapabilityGuidedRegex,
+		CapabilityGuidedLark, CapabilityEmbedding,
+	}, capabilitiesCommon...),
+	ProviderMistral: append([]string{
+		CapabilitySeed, CapabilityResponseFormat, CapabilityStrictSchema, CapabilityEmbedding,
+	}, capabilitiesCommon...),
+	ProviderCohere: append([]string{
+		CapabilityTopK, CapabilitySeed, CapabilityEmbedding,
+	}, capabilitiesCommon...),
+	ProviderOllama: append([]string{
+		CapabilityTopK, CapabilityMinP, CapabilityRepetitionPenalty, CapabilitySeed, CapabilityResponseFormat,
+	}, capabilitiesCommon...),
+	ProviderLlamaCpp: append([]string{
+		CapabilityTopK, CapabilityMinP, CapabilityRepetitionPenalty, CapabilitySeed, CapabilityLogitBias,
+		CapabilityResponseFormat, CapabilityStrictSchema, CapabilityGuidedDecoding,
+	}, capabilitiesCommon...),
+}
+
+// capabilityErrors are the settings whose loss changes the output.
+var capabilityErrors = map[string]bool{
+	CapabilityResponseFormat: true,
+	CapabilityGuidedDecoding: true,
+	CapabilityGuidedRegex:    true,
+	CapabilityGuidedLark:     true,
+	CapabilityImage:          true,
+	CapabilityAudio:          true,
+	CapabilityEmbedding:      true,
+}
+
+// capabilityKey returns the capability matrix key of a provider, resolving
+// aliases and Bedrock model families.
+func capabilityKey(provider, model string) string {
+	switch provider {
+	case ProviderGoogle, ProviderVertex:
+		return ProviderGemini
+	case ProviderBedrock:
+		family, name, _ := bedrockModelParts(model)
+		switch {
+		case family == BedrockFamilyAnthropic:
+			return capabilityKeyBedrockAnthropic
+		case family == BedrockFamilyAmazon && strings.HasPrefix(name, BedrockTitanPrefix):
+			return capabilityKeyBedrockTitan
+		}
+	}
+	return provider
+}
+
+// ProviderSupports reports whether the provider's serializer sends an
+// execution setting (a Capability* name). Bedrock support is that of
+// non-Anthropic, non-Titan models; use CheckCompatibility to account for
+// the model.
+func ProviderSupports(provider, capability string) bool {
+	for _, c := range providerCapabilities[capabilityKey(provider, "")] {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckCompatibility reports the settings of config that the provider's
+// serializer would silently drop, in config field order. An empty provider
+// uses the config's effective provider; an unknown provider yields a single
+// error. Returns nil when everything configured is supported.
+func CheckCompatibility(config *ExecutionConfig, provider string) []Incompatibility {
+	if config == nil {
+		return nil
+	}
+	if provider == "" {
+		provider = config.GetEffectiveProvider()
+	}
+
+	capabilities, ok := providerCapabilities[capabilityKey(provider, config.Model)]
+	if !ok {
+		return []Incompatibility{{
+			Field:    ParamKeyProvider,
+			Provider: provider,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf(ErrFmtCompatUnknownProvider, provider),
+		}}
+	}
+	supported := make(map[string]bool, len(capabilities))
+	for _, c := range capabilities {
+		supported[c] = true
+	}
+
+	var result []Incompatibility
+	for _, field := range config.configuredCapabilities() {
+		if supported[field] {
+			continue
+		}
+		// A setting within an unsupported section is covered by the section
+		if parent, _, nested := strings.Cut(field, capabilitySeparator); nested && !supported[parent] {
+			continue
+		}
+		severity, format := SeverityWarning, ErrFmtCompatDropped
+		if capabilityErrors[field] {
+			severity, format = SeverityError, ErrFmtCompatUnsupported
+		}
+		result = append(result, Incompatibility{
+			Field:    field,
+			Provider: provider,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, field, provider),
+		})
+	}
+	return result
+}
+
+// configuredCapabilities lists the capabilities the config uses.
+func (e *ExecutionConfig) configuredCapabilities() []string {
+	set := []struct {
+		capability string
+		used       bool
+	}{
+		{CapabilityTemperature, e.Temperature != nil},
+		{CapabilityMaxTokens, e.MaxTokens != nil},
+		{CapabilityTopP, e.TopP != nil},
+		{CapabilityTopK, e.TopK != nil},
+		{CapabilityStopSequences, len(e.StopSequences) > 0},
+		{CapabilityMinP, e.MinP != nil},
+		{CapabilityRepetitionPenalty, e.RepetitionPenalty != nil},
+		{CapabilitySeed, e.Seed != nil},
+		{CapabilityLogprobs, e.Logprobs != nil},
+		{CapabilityStopTokenIDs, len(e.StopTokenIDs) > 0},
+		{CapabilityLogitBias, len(e.LogitBias) > 0},
+		{CapabilityThinking, e.HasThinking()},
+		{CapabilityReasoning, e.Reasoning != nil},
+		{CapabilityResponseFormat, e.ResponseFormat != nil},
+		{CapabilityStrictSchema, e.ResponseFormat != nil && e.ResponseFormat.JSONSchema != nil && e.ResponseFormat.JSONSchema.Strict},
+		{CapabilityGuidedDecoding, e.GuidedDecoding != nil},
+		{CapabilityGuidedRegex, e.GuidedDecoding != nil && e.GuidedDecoding.Regex != ""},
+		{CapabilityGuidedLark, e.GuidedDecoding.isLark()},
+		{CapabilityImage, e.Image != nil},
+		{CapabilityImageStyle, e.Image != nil && e.Image.Style != ""},
+		{CapabilityImageQuality, e.Image != nil && e.Image.Quality != ""},
+		{CapabilityImageAspectRatio, e.Image != nil && e.Image.AspectRatio != ""},
+		{CapabilityAudio, e.Audio != nil},
+		{CapabilityEmbedding, e.Embedding != nil},
+		{CapabilityStreaming, e.Streaming != nil && e.Streaming.Enabled},
+		{CapabilityGateway, e.Gateway != nil},
+	}
+
+	var result []string
+	for _, s := range set {
+		if s.used {
+			result = append(result, s.capability)
+		}
+	}
+	return result
+}
@@ -0,0 +1,148 @@
+package prompty
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	topK := 40
+	seed := 7
+	temp := 0.5
+
+	t.Run("supported", func(t *testing.T) {
+		config := &ExecutionConfig{Model: "claude-sonnet-4", TopK: &topK, Temperature: &temp}
+		assert.Nil(t, CheckCompatibility(config, ""))
+	})
+
+	t.Run("dropped parameters", func(t *testing.T) {
+		config := &ExecutionConfig{
+			Model:    "gpt-4o",
+			TopK:     &topK,
+			Seed:     &seed,
+			Thinking: &ThinkingConfig{Enabled: true},
+		}
+		result := CheckCompatibility(config, ProviderOpenAI)
+		require.Len(t, result, 2)
+		assert.Equal(t, Incompatibility{
+			Field:    CapabilityTopK,
+			Provider: ProviderOpenAI,
+			Severity: SeverityWarning,
+			Message:  "top_k is not supported by openai and will be dropped",
+		}, result[0])
+		assert.Equal(t, CapabilityThinking, result[1].Field)
+		assert.Equal(t, "warning: thinking is not supported by openai and will be dropped", result[1].String())
+	})
+
+	t.Run("structured output is an error", func(t *testing.T) {
+		config := &ExecutionConfig{ResponseFormat: &ResponseFormat{Type: ResponseFormatJSONObject}}
+		result := CheckCompatibility(config, ProviderCohere)
+		require.Len(t, result, 1)
+		assert.Equal(t, SeverityError, result[0].Severity)
+		assert.Equal(t, CapabilityResponseFormat, result[0].Field)
+	})
+
+	t.Run("nested settings", func(t *testing.T) {
+		config := &ExecutionConfig{
+			Image: &ImageConfig{Style: ImageStyleVivid, AspectRatio: "16:9"},
+			ResponseFormat: &ResponseFormat{
+				Type:       ResponseFormatJSONSchema,
+				JSONSchema: &JSONSchemaSpec{Name: "out", Strict: true, Schema: map[string]any{"type": "object"}},
+			},
+		}
+		fields := func(result []Incompatibility) []string {
+			var names []string
+			for _, r := range result {
+				names = append(names, r.Field)
+			}
+			return names
+		}
+		assert.Equal(t, []string{CapabilityStrictSchema, CapabilityImageStyle}, fields(CheckCompatibility(config, ProviderGemini)))
+		assert.Equal(t, []string{CapabilityImageAspectRatio}, fields(CheckCompatibility(config, ProviderOpenAI)))
+		assert.Equal(t, []string{CapabilityResponseFormat, CapabilityImage}, fields(CheckCompatibility(config, ProviderVLLM)))
+	})
+
+	t.Run("bedrock model families", func(t *testing.T) {
+		config := &ExecutionConfig{Model: "anthropic.claude-3-haiku-20240307-v1:0", Seed: &seed}
+		assert.Nil(t, CheckCompatibility(config, ""))
+		config.Model = "amazon.titan-text-express-v1"
+		assert.Len(t, CheckCompatibility(config, ""), 1)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		result := CheckCompatibility(&ExecutionConfig{}, "acme")
+		require.Len(t, result, 1)
+		assert.Equal(t, ParamKeyProvider, result[0].Field)
+		assert.Equal(t, SeverityError, result[0].Severity)
+	})
+
+	assert.Nil(t, CheckCompatibility(nil, ProviderOpenAI))
+}
+
+func TestProviderSupports(t *testing.T) {
+	assert.True(t, ProviderSupports(ProviderAnthropic, CapabilityTopK))
+	assert.False(t, ProviderSupports(ProviderOpenAI, CapabilityTopK))
+	assert.True(t, ProviderSupports(ProviderVertex, CapabilityImageAspectRatio))
+	assert.True(t, ProviderSupports(ProviderOpenRouter, CapabilityGateway))
+	assert.False(t, ProviderSupports("acme", CapabilityTemperature))
+}
+
+// TestCapabilityMatrix_MatchesSerializers checks that every capability the
+// matrix lists for a provider changes that provider's serialized output.
+func TestCapabilityMatrix_MatchesSerializers(t *testing.T) {
+	temp, maxTokens, topP, topK, minP, rep := 0.5, 100, 0.9, 40, 0.1, 1.1
+	seed, logprobs := 7, 3
+	full := &ExecutionConfig{
+		Temperature: &temp, MaxTokens: &maxTokens, TopP: &topP, TopK: &topK, StopSequences: []string{"x"},
+		MinP: &minP, RepetitionPenalty: &rep, Seed: &seed, Logprobs: &logprobs, StopTokenIDs: []int{1},
+		LogitBias: map[string]float64{"1": 1}, Thinking: &ThinkingConfig{Enabled: true},
+		ResponseFormat: &ResponseFormat{Type: ResponseFormatJSONObject},
+		GuidedDecoding: &GuidedDecoding{Regex: "a+"},
+		Streaming:      &StreamingConfig{Enabled: true},
+		Gateway:        &GatewayConfig{Type: ProviderOpenRouter, Providers: []string{"a"}},
+	}
+	serializers := map[string]func(*ExecutionConfig) map[string]any{
+		ProviderOpenAI:          (*ExecutionConfig).ToOpenAI,
+		ProviderOpenAIResponses: (*ExecutionConfig).ToOpenAIResponses,
+		ProviderAnthropic:       (*ExecutionConfig).ToAnthropic,
+		ProviderGemini:          (*ExecutionConfig).ToGemini,
+		ProviderVLLM:            (*ExecutionConfig).ToVLLM,
+		ProviderMistral:         (*ExecutionConfig).ToMistral,
+		ProviderCohere:          (*ExecutionConfig).ToCohere,
+		ProviderOllama:          (*ExecutionConfig).ToOllama,
+		ProviderLlamaCpp:        (*ExecutionConfig).ToLlamaCpp,
+	}
+	isolated := map[string]func(*ExecutionConfig){
+		CapabilityTemperature:       func(c *ExecutionConfig) { c.Temperature = full.Temperature },
+		CapabilityMaxTokens:         func(c *ExecutionConfig) { c.MaxTokens = full.MaxTokens },
+		CapabilityTopP:              func(c *ExecutionConfig) { c.TopP = full.TopP },
+		CapabilityTopK:              func(c *ExecutionConfig) { c.TopK = full.TopK },
+		CapabilityStopSequences:     func(c *ExecutionConfig) { c.StopSequences = full.StopSequences },
+		CapabilityMinP:              func(c *ExecutionConfig) { c.MinP = full.MinP },
+		CapabilityRepetitionPenalty: func(c *ExecutionConfig) { c.RepetitionPenalty = full.RepetitionPenalty },
+		CapabilitySeed:              func(c *ExecutionConfig) { c.Seed = full.Seed },
+		CapabilityLogprobs:          func(c *ExecutionConfig) { c.Logprobs = full.Logprobs },
+		CapabilityStopTokenIDs:      func(c *ExecutionConfig) { c.StopTokenIDs = full.StopTokenIDs },
+		CapabilityLogitBias:         func(c *ExecutionConfig) { c.LogitBias = full.LogitBias },
+		CapabilityThinking:          func(c *ExecutionConfig) { c.Thinking = full.Thinking },
+		CapabilityResponseFormat:    func(c *ExecutionConfig) { c.ResponseFormat = full.ResponseFormat },
+		CapabilityGuidedDecoding:    func(c *ExecutionConfig) { c.GuidedDecoding = &GuidedDecoding{Grammar: "root ::= x"} },
+		CapabilityStreaming:         func(c *ExecutionConfig) { c.Streaming = full.Streaming },
+		CapabilityGateway:           func(c *ExecutionConfig) { c.Gateway = full.Gateway },
+	}
+
+	for provider, serialize := range serializers {
+		baseline := serialize(&ExecutionConfig{})
+		for capability, set := range isolated {
+			if provider == ProviderOpenAI && capability == CapabilityGateway {
+				continue // ToOpenAI also serializes the gateways
+			}
+			config := &ExecutionConfig{}
+			set(config)
+			changed := !assert.ObjectsAreEqual(baseline, serialize(config))
+			assert.Equal(t, ProviderSupports(provider, capability), changed, "%s %s", provider, capability)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package prompty
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// FallbackConfig is the retry and fallback policy of a request. The primary
+// provider and model are tried first, then each of Targets in order; every
+// one is retried up to MaxRetries times with exponential backoff when a call
+// fails with one of the RetryOn conditions. Use ExecuteWithFallback to run a
+// provider call under the policy.
+//
+// FallbackConfig is safe for concurrent reads. Use Clone() to create an
+// independent copy if mutation is needed.
+type FallbackConfig struct {
+	// Targets are the alternatives tried in order after the primary model
+	Targets []FallbackTarget `yaml:"targets,omitempty" json:"targets,omitempty"`
+	// MaxRetries is the number of retries of each target (default 0)
+	MaxRetries *int `yaml:"max_retries,omitempty" json:"max_retries,omitempty"`
+	// InitialBackoffSeconds is the wait before the first retry (default 1)
+	InitialBackoffSeconds *float64 `yaml:"initial_backoff_seconds,omitempty" json:"initial_backoff_seconds,omitempty"`
+	// MaxBackoffSeconds caps the wait between retries (default 30)
+	MaxBackoffSeconds *float64 `yaml:"max_backoff_seconds,omitempty" json:"max_backoff_seconds,omitempty"`
+	// BackoffMultiplier grows the wait after each retry (default 2)
+	BackoffMultiplier *float64 `yaml:"backoff_multiplier,omitempty" json:"backoff_multiplier,omitempty"`
+	// RetryOn lists the conditions that are retried and fall back; empty
+	// means all of them
+	RetryOn []RetryCondition `yaml:"retry_on,omitempty" json:"retry_on,omitempty"`
+}
+
+// FallbackTarget is an alternative provider and model. An empty provider
+// keeps the primary provider.
+type FallbackTarget struct {
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+	Model    string `yaml:"model,omitempty" json:"model,omitempty"`
+}
+
+// validRetryConditions are the accepted RetryOn values.
+var validRetryConditions = map[RetryCondition]bool{
+	RetryOnRateLimit:   true,
+	RetryOnTimeout:     true,
+	RetryOnServerError: true,
+	RetryOnOverloaded:  true,
+	RetryOnConnection:  true,
+}
+
+// Validate checks the fallback config for consistency.
+func (f *FallbackConfig) Validate() error {
+	if f == nil {
+		return nil
+	}
+
+	if f.MaxRetries != nil && *f.MaxRetries < 0 {
+		return NewPromptValidationError(ErrMsgFallbackRetriesNegative, "")
+	}
+	if (f.InitialBackoffSeconds != nil && *f.InitialBackoffSeconds < 0) || (f.MaxBackoffSeconds != nil && *f.MaxBackoffSeconds < 0) {
+		return NewPromptValidationError(ErrMsgFallbackBackoffNegative, "")
+	}
+	if f.BackoffMultiplier != nil && *f.BackoffMultiplier < 1 {
+		return NewPromptValidationError(ErrMsgFallbackMultiplierTooSmall, "")
+	}
+	for _, target := range f.Targets {
+		if target.Provider == "" && target.Model == "" {
+			return NewPromptValidationError(ErrMsgFallbackEmptyTarget, "")
+		}
+	}
+	for _, condition := range f.RetryOn {
+		if !validRetryConditions[condition] {
+			return NewPromptValidationError(ErrMsgFallbackInvalidCondition, "")
+		}
+	}
+	return nil
+}
+
+// Clone creates a deep copy of the fallback config.
+func (f *FallbackConfig) Clone() *FallbackConfig {
+	if f == nil {
+		return nil
+	}
+	clone := &FallbackConfig{
+		MaxRetries:            coalesceIntPtr(f.MaxRetries, nil),
+		InitialBackoffSeconds: coalesceFloat64Ptr(f.InitialBackoffSeconds, nil),
+		MaxBackoffSeconds:     coalesceFloat64Ptr(f.MaxBackoffSeconds, nil),
+		BackoffMultiplier:     coalesceFloat64Ptr(f.BackoffMultiplier, nil),
+	}
+	if f.Targets != nil {
+		clone.Targets = append([]FallbackTarget(nil), f.Targets...)
+	}
+	if f.RetryOn != nil {
+		clone.RetryOn = append([]RetryCondition(nil), f.RetryOn...)
+	}
+	return clone
+}
+
+// ToMap converts the fallback config to a parameter map.
+func (f *FallbackConfig) ToMap() map[string]any {
+	if f == nil {
+		return nil
+	}
+	result := make(map[string]any)
+	if len(f.Targets) > 0 {
+		targets := make([]map[string]any, 0, len(f.Targets))
+		for _, t := range f.Targets {
+			target := make(map[string]any, 2)
+			if t.Provider != "" {
+				target[FallbackKeyProvider] = t.Provider
+			}
+			if t.Model != "" {
+				target[FallbackKeyModel] = t.Model
+			}
+			targets = append(targets, target)
+		}
+		result[FallbackKeyTargets] = targets
+	}
+	if f.MaxRetries != nil {
+		result[FallbackKeyMaxRetries] = *f.MaxRetries
+	}
+	if f.InitialBackoffSeconds != nil {
+		result[FallbackKeyInitialBackoff] = *f.InitialBackoffSeconds
+	}
+	if f.MaxBackoffSeconds != nil {
+		result[FallbackKeyMaxBackoff] = *f.MaxBackoffSeconds
+	}
+	if f.BackoffMultiplier != nil {
+		result[FallbackKeyBackoffMultiplier] = *f.BackoffMultiplier
+	}
+	if len(f.RetryOn) > 0 {
+		conditions := make([]string, len(f.RetryOn))
+		for i, c := range f.RetryOn {
+			conditions[i] = string(c)
+		}
+		result[FallbackKeyRetryOn] = conditions
+	}
+	return result
+}
+
+// Retries returns the number of retries of each target.
+func (f *FallbackConfig) Retries() int {
+	if f == nil || f.MaxRetries == nil {
+		return 0
+	}
+	return *f.MaxRetries
+}
+
+// Backoff returns the wait before retry number retry (0 for the first):
+// InitialBackoffSeconds grown by BackoffMultiplier per retry, capped at
+// MaxBackoffSeconds.
+func (f *FallbackConfig) Backoff(retry int) time.Duration {
+	initial, maxBackoff, multiplier := DefaultFallbackInitialBackoff, DefaultFallbackMaxBackoff, DefaultFallbackBackoffMultiplier
+	if f != nil {
+		initial = derefFloat64(f.InitialBackoffSeconds, initial)
+		maxBackoff = derefFloat64(f.MaxBackoffSeconds, maxBackoff)
+		multiplier = derefFloat64(f.BackoffMultiplier, multiplier)
+	}
+	seconds := math.Min(initial*math.Pow(multiplier, float64(retry)), maxBackoff)
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// ShouldRetry reports whether a failure with condition is retried and
+// falls back. All conditions are when RetryOn is empty.
+func (f *FallbackConfig) ShouldRetry(condition RetryCondition) bool {
+	if condition == "" {
+		return false
+	}
+	if f == nil || len(f.RetryOn) == 0 {
+		return true
+	}
+	for _, c := range f.RetryOn {
+		if c == condition {
+			return true
+		}
+	}
+	return false
+}
+
+// FallbackChain returns the execution configs to try in order: the config
+// itself, then one per fallback target with the target's provider and
+// model. The configs carry no fallback policy of their own.
+func (e *ExecutionConfig) FallbackChain() []*ExecutionConfig {
+	if e == nil {
+		return nil
+	}
+	primary := e.Clone()
+	primary.Fallback = nil
+	chain := []*ExecutionConfig{primary}
+	if e.Fallback == nil {
+		return chain
+	}
+	for _, target := range e.Fallback.Targets {
+		alternative := primary.Clone()
+		if target.Provider != "" {
+			alternative.Provider = target.Provider
+		}
+		if target.Model != "" {
+			alternative.Model = target.Model
+		}
+		chain = append(chain, alternative)
+	}
+	return chain
+}
+
+// ProviderCallError is a failed provider call, classified by the retry
+// condition it meets ("" for failures that are not retried).
+type ProviderCallError struct {
+	Condition  RetryCondition
+	StatusCode int
+	Err        error
+}
+
+// Error returns the message of the underlying error, or the condition and
+// status code when there is none.
+func (e *ProviderCallError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return string(e.Condition) + " (status " + strconv.Itoa(e.StatusCode) + ")"
+}
+
+// Unwrap returns the underlying error.
+func (e *ProviderCallError) Unwrap() error {
+	return e.Err
+}
+
+// NewProviderStatusError creates a ProviderCallError for an HTTP status,
+// classified with RetryConditionForStatus.
+func NewProviderStatusError(statusCode int, err error) error {
+	return &ProviderCallError{Condition: RetryConditionForStatus(statusCode), StatusCode: statusCode, Err: err}
+}
+
+// RetryConditionForStatus classifies an HTTP status code: 429 is a rate
+// limit, 408 and 504 are timeouts, 503 and 529 mean overloaded and other
+// 5xx codes are server errors. Other codes are not retried ("").
+func RetryConditionForStatus(statusCode int) RetryCondition {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return RetryOnRateLimit
+	case statusCode == http.StatusRequestTimeout || statusCode == http.StatusGatewayTimeout:
+		return RetryOnTimeout
+	case statusCode == http.StatusServiceUnavailable || statusCode == StatusProviderOverloaded:
+		return RetryOnOverloaded
+	case statusCode >= http.StatusInternalServerError:
+		return RetryOnServerError
+	default:
+		return ""
+	}
+}
+
+// ExecuteWithFallback runs call under the fallback policy of config: with
+// each config of FallbackChain in turn, retrying each up to MaxRetries
+// times. Only errors that are a *ProviderCallError with a condition the
+// policy retries lead to a retry or the next target; other errors are
+// returned at once. When every attempt fails, the error wraps the last
+// failure. Backoff waits end early when ctx is done. A nil config makes a
+// single call.
+func ExecuteWithFallback(ctx context.Context, config *ExecutionConfig, call func(ctx context.Context, config *ExecutionConfig) error) error {
+	var policy *FallbackConfig
+	if config != nil {
+		policy = config.Fallback
+	}
+
+	chain := config.FallbackChain()
+	if len(chain) == 0 {
+		chain = []*ExecutionConfig{nil}
+	}
+
+	var lastErr error
+	attempts := 0
+	for _, candidate := range chain {
+		for retry := 0; retry <= policy.Retries(); retry++ {
+			if retry > 0 {
+				if err := sleepContext(ctx, policy.Backoff(retry-1)); err != nil {
+					return err
+				}
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			attempts++
+			lastErr = call(ctx, candidate)
+			if lastErr == nil {
+				return nil
+			}
+			var callErr *ProviderCallError
+			if !errors.As(lastErr, &callErr) || !policy.ShouldRetry(callErr.Condition) {
+				return lastErr
+			}
+		}
+	}
+	if lastErr == nil {
+		return nil
+	}
+	return NewFallbackExhaustedError(attempts, lastErr)
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// derefFloat64 returns *p, or def when p is nil.
+func derefFloat64(p *float64, def float64) float64 {
+	if p == nil {
+		return def
+	}
+	return *p
+}
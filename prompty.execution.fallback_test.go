@@ -0,0 +1,246 @@
+package prompty
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestFallbackConfig_Validate(t *testing.T) {
+	negativeInt := -1
+	negative := -1.0
+	half := 0.5
+
+	tests := []struct {
+		name    string
+		config  *FallbackConfig
+		wantErr string
+	}{
+		{name: "nil", config: nil},
+		{name: "valid", config: &FallbackConfig{Targets: []FallbackTarget{{Provider: ProviderAnthropic, Model: "claude-sonnet-4-5"}}, RetryOn: []RetryCondition{RetryOnRateLimit}}},
+		{name: "negative retries", config: &FallbackConfig{MaxRetries: &negativeInt}, wantErr: ErrMsgFallbackRetriesNegative},
+		{name: "negative backoff", config: &FallbackConfig{InitialBackoffSeconds: &negative}, wantErr: ErrMsgFallbackBackoffNegative},
+		{name: "negative max backoff", config: &FallbackConfig{MaxBackoffSeconds: &negative}, wantErr: ErrMsgFallbackBackoffNegative},
+		{name: "shrinking backoff", config: &FallbackConfig{BackoffMultiplier: &half}, wantErr: ErrMsgFallbackMultiplierTooSmall},
+		{name: "empty target", config: &FallbackConfig{Targets: []FallbackTarget{{}}}, wantErr: ErrMsgFallbackEmptyTarget},
+		{name: "unknown condition", config: &FallbackConfig{RetryOn: []RetryCondition{"bad_luck"}}, wantErr: ErrMsgFallbackInvalidCondition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	config := &ExecutionConfig{Fallback: &FallbackConfig{MaxRetries: &negativeInt}}
+	assert.Error(t, config.Validate())
+}
+
+func TestFallbackConfig_YAMLCloneMerge(t *testing.T) {
+	var config ExecutionConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`
+provider: openai
+model: gpt-4o
+fallback:
+  targets:
+    - {provider: anthropic, model: claude-sonnet-4-5}
+    - {model: gpt-4o-mini}
+  max_retries: 2
+  initial_backoff_seconds: 0.5
+  retry_on: [rate_limit, timeout]
+`), &config))
+	require.NotNil(t, config.Fallback)
+	require.NoError(t, config.Validate())
+	assert.Len(t, config.Fallback.Targets, 2)
+	assert.Equal(t, 2, config.Fallback.Retries())
+
+	clone := config.Clone()
+	clone.Fallback.Targets[0].Model = "changed"
+	clone.Fallback.RetryOn[0] = RetryOnServerError
+	assert.Equal(t, "claude-sonnet-4-5", config.Fallback.Targets[0].Model)
+	assert.Equal(t, RetryOnRateLimit, config.Fallback.RetryOn[0])
+
+	m := config.ToMap()[ParamKeyFallback].(map[string]any)
+	assert.Equal(t, 2, m[FallbackKeyMaxRetries])
+	assert.Equal(t, []string{"rate_limit", "timeout"}, m[FallbackKeyRetryOn])
+	assert.Equal(t, []map[string]any{
+		{FallbackKeyProvider: ProviderAnthropic, FallbackKeyModel: "claude-sonnet-4-5"},
+		{FallbackKeyModel: "gpt-4o-mini"},
+	}, m[FallbackKeyTargets])
+
+	retries := 5
+	merged := config.Merge(&ExecutionConfig{Fallback: &FallbackConfig{MaxRetries: &retries}})
+	assert.Equal(t, 5, merged.Fallback.Retries())
+	assert.Empty(t, merged.Fallback.Targets, "fallback sections replace, not merge")
+	assert.Equal(t, 2, config.Merge(&ExecutionConfig{}).Fallback.Retries())
+
+	assert.NotContains(t, config.ToOpenAI(), ParamKeyFallback, "policy is not sent to providers")
+}
+
+func TestFallbackConfig_Backoff(t *testing.T) {
+	var nilConfig *FallbackConfig
+	assert.Equal(t, time.Second, nilConfig.Backoff(0))
+	assert.Equal(t, 4*time.Second, nilConfig.Backoff(2))
+	assert.Equal(t, 30*time.Second, nilConfig.Backoff(10), "capped at the default maximum")
+
+	initial, maxBackoff, multiplier := 0.1, 0.25, 3.0
+	config := &FallbackConfig{InitialBackoffSeconds: &initial, MaxBackoffSeconds: &maxBackoff, BackoffMultiplier: &multiplier}
+	assert.Equal(t, 100*time.Millisecond, config.Backoff(0))
+	assert.Equal(t, 250*time.Millisecond, config.Backoff(1))
+}
+
+func TestFallbackConfig_ShouldRetry(t *testing.T) {
+	var all *FallbackConfig
+	assert.True(t, all.ShouldRetry(RetryOnConnection))
+	assert.False(t, all.ShouldRetry(""))
+
+	limited := &FallbackConfig{RetryOn: []RetryCondition{RetryOnRateLimit}}
+	assert.True(t, limited.ShouldRetry(RetryOnRateLimit))
+	assert.False(t, limited.ShouldRetry(RetryOnServerError))
+}
+
+func TestRetryConditionForStatus(t *testing.T) {
+	tests := map[int]RetryCondition{
+		http.StatusTooManyRequests:     RetryOnRateLimit,
+		http.StatusRequestTimeout:      RetryOnTimeout,
+		http.StatusGatewayTimeout:      RetryOnTimeout,
+		http.StatusServiceUnavailable:  RetryOnOverloaded,
+		StatusProviderOverloaded:       RetryOnOverloaded,
+		http.StatusInternalServerError: RetryOnServerError,
+		http.StatusBadGateway:          RetryOnServerError,
+		http.StatusBadRequest:          "",
+		http.StatusUnauthorized:        "",
+	}
+	for status, want := range tests {
+		assert.Equal(t, want, RetryConditionForStatus(status), "status %d", status)
+	}
+}
+
+func TestExecutionConfig_FallbackChain(t *testing.T) {
+	temperature := 0.3
+	config := &ExecutionConfig{
+		Provider:    ProviderOpenAI,
+		Model:       "gpt-4o",
+		Temperature: &temperature,
+		Fallback: &FallbackConfig{Targets: []FallbackTarget{
+			{Provider: ProviderAnthropic, Model: "claude-sonnet-4-5"},
+			{Model: "gpt-4o-mini"},
+		}},
+	}
+
+	chain := config.FallbackChain()
+	require.Len(t, chain, 3)
+	assert.Equal(t, "gpt-4o", chain[0].Model)
+	assert.Equal(t, ProviderAnthropic, chain[1].Provider)
+	assert.Equal(t, "claude-sonnet-4-5", chain[1].Model)
+	assert.Equal(t, ProviderOpenAI, chain[2].Provider)
+	assert.Equal(t, "gpt-4o-mini", chain[2].Model)
+	for _, c := range chain {
+		assert.Nil(t, c.Fallback)
+		assert.Equal(t, 0.3, *c.Temperature)
+	}
+
+	assert.Len(t, (&ExecutionConfig{Model: "gpt-4o"}).FallbackChain(), 1)
+	var nilConfig *ExecutionConfig
+	assert.Nil(t, nilConfig.FallbackChain())
+}
+
+func TestExecuteWithFallback(t *testing.T) {
+	retries := 1
+	noWait := 0.0
+	newConfig := func(retryOn ...RetryCondition) *ExecutionConfig {
+		return &ExecutionConfig{
+			Provider: ProviderOpenAI,
+			Model:    "gpt-4o",
+			Fallback: &FallbackConfig{
+				Targets:               []FallbackTarget{{Provider: ProviderAnthropic, Model: "claude-sonnet-4-5"}},
+				MaxRetries:            &retries,
+				InitialBackoffSeconds: &noWait,
+				RetryOn:               retryOn,
+			},
+		}
+	}
+	rateLimited := NewProviderStatusError(http.StatusTooManyRequests, errors.New("slow down"))
+
+	t.Run("falls back after retries", func(t *testing.T) {
+		var models []string
+		err := ExecuteWithFallback(context.Background(), newConfig(), func(_ context.Context, c *ExecutionConfig) error {
+			models = append(models, c.Model)
+			if c.Provider == ProviderOpenAI {
+				return rateLimited
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"gpt-4o", "gpt-4o", "claude-sonnet-4-5"}, models)
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		calls := 0
+		err := ExecuteWithFallback(context.Background(), newConfig(), func(context.Context, *ExecutionConfig) error {
+			calls++
+			return rateLimited
+		})
+		require.Error(t, err)
+		assert.Equal(t, 4, calls)
+		assert.Contains(t, err.Error(), ErrMsgFallbackExhausted)
+		var callErr *ProviderCallError
+		require.ErrorAs(t, err, &callErr)
+		assert.Equal(t, http.StatusTooManyRequests, callErr.StatusCode)
+	})
+
+	t.Run("unclassified error stops", func(t *testing.T) {
+		calls := 0
+		boom := errors.New("bad request")
+		err := ExecuteWithFallback(context.Background(), newConfig(), func(context.Context, *ExecutionConfig) error {
+			calls++
+			return boom
+		})
+		assert.Same(t, boom, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("condition not in retry_on stops", func(t *testing.T) {
+		calls := 0
+		err := ExecuteWithFallback(context.Background(), newConfig(RetryOnTimeout), func(context.Context, *ExecutionConfig) error {
+			calls++
+			return rateLimited
+		})
+		assert.Equal(t, rateLimited, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("canceled context stops backoff", func(t *testing.T) {
+		wait := 10.0
+		config := newConfig()
+		config.Fallback.InitialBackoffSeconds = &wait
+		ctx, cancel := context.WithCancel(context.Background())
+		err := ExecuteWithFallback(ctx, config, func(context.Context, *ExecutionConfig) error {
+			cancel()
+			return rateLimited
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("no policy", func(t *testing.T) {
+		calls := 0
+		err := ExecuteWithFallback(context.Background(), nil, func(_ context.Context, c *ExecutionConfig) error {
+			calls++
+			assert.Nil(t, c)
+			return rateLimited
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -0,0 +1,176 @@
+package prompty
+
+// GatewayConfig routes requests through an OpenAI-compatible gateway such as
+// OpenRouter or a LiteLLM proxy. ToOpenAI adds the gateway's routing fields
+// to the request body:
+//
+//   - OpenRouter: "models" (the model followed by FallbackModels) and a
+//     "provider" object with order, ignore, allow_fallbacks and max_price.
+//   - LiteLLM: "fallbacks" and "num_retries".
+//
+// GatewayConfig is safe for concurrent reads. Use Clone() to create an
+// independent copy if mutation is needed.
+type GatewayConfig struct {
+	// Type is the gateway: "openrouter" or "litellm"
+	Type string `yaml:"type" json:"type"`
+	// Providers lists the preferred upstream providers in order (OpenRouter)
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+	// IgnoreProviders lists upstream providers never to use (OpenRouter)
+	IgnoreProviders []string `yaml:"ignore_providers,omitempty" json:"ignore_providers,omitempty"`
+	// AllowFallbacks permits upstream providers beyond Providers (OpenRouter)
+	AllowFallbacks *bool `yaml:"allow_fallbacks,omitempty" json:"allow_fallbacks,omitempty"`
+	// FallbackModels are tried in order when the model fails
+	FallbackModels []string `yaml:"fallback_models,omitempty" json:"fallback_models,omitempty"`
+	// MaxPromptPrice caps the prompt price in USD per million tokens (OpenRouter)
+	MaxPromptPrice *float64 `yaml:"max_prompt_price,omitempty" json:"max_prompt_price,omitempty"`
+	// MaxCompletionPrice caps the completion price in USD per million tokens (OpenRouter)
+	MaxCompletionPrice *float64 `yaml:"max_completion_price,omitempty" json:"max_completion_price,omitempty"`
+	// NumRetries is the number of retries before falling back (LiteLLM)
+	NumRetries *int `yaml:"num_retries,omitempty" json:"num_retries,omitempty"`
+}
+
+// Validate checks the gateway config: a known type, non-negative limits,
+// and only settings the gateway supports.
+func (g *GatewayConfig) Validate() error {
+	if g == nil {
+		return nil
+	}
+
+	switch g.Type {
+	case ProviderOpenRouter:
+		if g.NumRetries != nil {
+			return NewPromptValidationError(ErrMsgGatewayUnsupportedField, "")
+		}
+	case ProviderLiteLLM:
+		if len(g.Providers) > 0 || len(g.IgnoreProviders) > 0 || g.AllowFallbacks != nil ||
+			g.MaxPromptPrice != nil || g.MaxCompletionPrice != nil {
+			return NewPromptValidationError(ErrMsgGatewayUnsupportedField, "")
+		}
+	default:
+		return NewPromptValidationError(ErrMsgGatewayInvalidType, "")
+	}
+
+	if (g.MaxPromptPrice != nil && *g.MaxPromptPrice < 0) || (g.MaxCompletionPrice != nil && *g.MaxCompletionPrice < 0) {
+		return NewPromptValidationError(ErrMsgGatewayPriceNegative, "")
+	}
+	if g.NumRetries != nil && *g.NumRetries < 0 {
+		return NewPromptValidationError(ErrMsgGatewayRetriesNegative, "")
+	}
+	for _, model := range g.FallbackModels {
+		if model == "" {
+			return NewPromptValidationError(ErrMsgGatewayEmptyFallback, "")
+		}
+	}
+	return nil
+}
+
+// Clone creates a deep copy of the gateway config.
+func (g *GatewayConfig) Clone() *GatewayConfig {
+	if g == nil {
+		return nil
+	}
+	clone := &GatewayConfig{
+		Type:               g.Type,
+		AllowFallbacks:     coalesceBoolPtr(g.AllowFallbacks, nil),
+		MaxPromptPrice:     coalesceFloat64Ptr(g.MaxPromptPrice, nil),
+		MaxCompletionPrice: coalesceFloat64Ptr(g.MaxCompletionPrice, nil),
+		NumRetries:         coalesceIntPtr(g.NumRetries, nil),
+	}
+	if g.Providers != nil {
+		clone.Providers = append([]string(nil), g.Providers...)
+	}
+	if g.IgnoreProviders != nil {
+		clone.IgnoreProviders = append([]string(nil), g.IgnoreProviders...)
+	}
+	if g.FallbackModels != nil {
+		clone.FallbackModels = append([]string(nil), g.FallbackModels...)
+	}
+	return clone
+}
+
+// ToMap converts the gateway config to a parameter map.
+func (g *GatewayConfig) ToMap() map[string]any {
+	if g == nil {
+		return nil
+	}
+	result := map[string]any{GatewayKeyType: g.Type}
+	if len(g.Providers) > 0 {
+		result[GatewayKeyProviders] = g.Providers
+	}
+	if len(g.IgnoreProviders) > 0 {
+		result[GatewayKeyIgnore] = g.IgnoreProviders
+	}
+	if g.AllowFallbacks != nil {
+		result[GatewayKeyAllowFallbacks] = *g.AllowFallbacks
+	}
+	if len(g.FallbackModels) > 0 {
+		result[GatewayKeyFallbacks] = g.FallbackModels
+	}
+	if g.MaxPromptPrice != nil || g.MaxCompletionPrice != nil {
+		result[GatewayKeyMaxPrice] = g.maxPrice()
+	}
+	if g.NumRetries != nil {
+		result[GatewayKeyNumRetries] = *g.NumRetries
+	}
+	return result
+}
+
+// addRoutingParams adds the gateway's routing fields for model to an
+// OpenAI-style request body.
+func (g *GatewayConfig) addRoutingParams(result map[string]any, model string) {
+	switch g.Type {
+	case ProviderOpenRouter:
+		if len(g.FallbackModels) > 0 {
+			models := make([]string, 0, len(g.FallbackModels)+1)
+			if model != "" {
+				models = append(models, model)
+			}
+			result[GatewayKeyModels] = append(models, g.FallbackModels...)
+		}
+		provider := make(map[string]any)
+		if len(g.Providers) > 0 {
+			provider[GatewayKeyOrder] = g.Providers
+		}
+		if len(g.IgnoreProviders) > 0 {
+			provider[GatewayKeyIgnore] = g.IgnoreProviders
+		}
+		if g.AllowFallbacks != nil {
+			provider[GatewayKeyAllowFallbacks] = *g.AllowFallbacks
+		}
+		if g.MaxPromptPrice != nil || g.MaxCompletionPrice != nil {
+			provider[GatewayKeyMaxPrice] = g.maxPrice()
+		}
+		if len(provider) > 0 {
+			result[GatewayKeyProvider] = provider
+		}
+
+	case ProviderLiteLLM:
+		if len(g.FallbackModels) > 0 {
+			result[GatewayKeyFallbacks] = g.FallbackModels
+		}
+		if g.NumRetries != nil {
+			result[GatewayKeyNumRetries] = *g.NumRetries
+		}
+	}
+}
+
+// maxPrice returns the configured price caps.
+func (g *GatewayConfig) maxPrice() map[string]any {
+	price := make(map[string]any, 2)
+	if g.MaxPromptPrice != nil {
+		price[GatewayKeyPrompt] = *g.MaxPromptPrice
+	}
+	if g.MaxCompletionPrice != nil {
+		price[GatewayKeyCompletion] = *g.MaxCompletionPrice
+	}
+	return price
+}
+
+// coalesceBoolPtr returns a copy of a if non-nil, otherwise b.
+func coalesceBoolPtr(a, b *bool) *bool {
+	if a != nil {
+		v := *a
+		return &v
+	}
+	return b
+}
@@ -0,0 +1,113 @@
+package prompty
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestGatewayConfig_Validate(t *testing.T) {
+	negative := -1.0
+	retries := 2
+	badRetries := -1
+	allow := false
+
+	tests := []struct {
+		name    string
+		config  *GatewayConfig
+		wantErr string
+	}{
+		{name: "nil", config: nil},
+		{name: "openrouter", config: &GatewayConfig{Type: ProviderOpenRouter, Providers: []string{"anthropic"}, AllowFallbacks: &allow}},
+		{name: "litellm", config: &GatewayConfig{Type: ProviderLiteLLM, FallbackModels: []string{"gpt-4o-mini"}, NumRetries: &retries}},
+		{name: "missing type", config: &GatewayConfig{}, wantErr: ErrMsgGatewayInvalidType},
+		{name: "unknown type", config: &GatewayConfig{Type: "kong"}, wantErr: ErrMsgGatewayInvalidType},
+		{name: "negative price", config: &GatewayConfig{Type: ProviderOpenRouter, MaxPromptPrice: &negative}, wantErr: ErrMsgGatewayPriceNegative},
+		{name: "negative retries", config: &GatewayConfig{Type: ProviderLiteLLM, NumRetries: &badRetries}, wantErr: ErrMsgGatewayRetriesNegative},
+		{name: "empty fallback", config: &GatewayConfig{Type: ProviderLiteLLM, FallbackModels: []string{""}}, wantErr: ErrMsgGatewayEmptyFallback},
+		{name: "retries on openrouter", config: &GatewayConfig{Type: ProviderOpenRouter, NumRetries: &retries}, wantErr: ErrMsgGatewayUnsupportedField},
+		{name: "providers on litellm", config: &GatewayConfig{Type: ProviderLiteLLM, Providers: []string{"openai"}}, wantErr: ErrMsgGatewayUnsupportedField},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	config := &ExecutionConfig{Gateway: &GatewayConfig{Type: "kong"}}
+	assert.Error(t, config.Validate())
+}
+
+func TestExecutionConfig_ToOpenAI_Gateway(t *testing.T) {
+	var config ExecutionConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`
+model: anthropic/claude-3.5-sonnet
+gateway:
+  type: openrouter
+  providers: [anthropic, amazon-bedrock]
+  allow_fallbacks: false
+  fallback_models: [openai/gpt-4o]
+  max_prompt_price: 3
+  max_completion_price: 15
+`), &config))
+	require.NoError(t, config.Validate())
+	assert.Equal(t, ProviderOpenRouter, config.GetEffectiveProvider())
+
+	result := config.ToOpenAI()
+	assert.Equal(t, "anthropic/claude-3.5-sonnet", result[ParamKeyModel])
+	assert.Equal(t, []string{"anthropic/claude-3.5-sonnet", "openai/gpt-4o"}, result[GatewayKeyModels])
+	assert.Equal(t, map[string]any{
+		GatewayKeyOrder:          []string{"anthropic", "amazon-bedrock"},
+		GatewayKeyAllowFallbacks: false,
+		GatewayKeyMaxPrice:       map[string]any{GatewayKeyPrompt: 3.0, GatewayKeyCompletion: 15.0},
+	}, result[GatewayKeyProvider])
+
+	retries := 3
+	litellm := &ExecutionConfig{
+		Model:   "gpt-4o",
+		Gateway: &GatewayConfig{Type: ProviderLiteLLM, FallbackModels: []string{"claude-3-haiku"}, NumRetries: &retries},
+	}
+	result = litellm.ToOpenAI()
+	assert.Equal(t, []string{"claude-3-haiku"}, result[GatewayKeyFallbacks])
+	assert.Equal(t, 3, result[GatewayKeyNumRetries])
+	assert.NotContains(t, result, GatewayKeyProvider)
+
+	payload, err := (&CompiledPrompt{
+		Messages:  []CompiledMessage{{Role: RoleUser, Content: "Hi"}},
+		Execution: litellm,
+	}).ToProviderPayload("")
+	require.NoError(t, err)
+	assert.Equal(t, 3, payload[GatewayKeyNumRetries])
+	assert.Len(t, payload[PayloadKeyMessages], 1)
+}
+
+func TestGatewayConfig_CloneMerge(t *testing.T) {
+	price := 2.0
+	base := &ExecutionConfig{Model: "gpt-4o", Gateway: &GatewayConfig{
+		Type: ProviderOpenRouter, Providers: []string{"openai"}, MaxPromptPrice: &price,
+	}}
+
+	clone := base.Clone()
+	clone.Gateway.Providers[0] = "azure"
+	*clone.Gateway.MaxPromptPrice = 9
+	assert.Equal(t, "openai", base.Gateway.Providers[0])
+	assert.Equal(t, 2.0, *base.Gateway.MaxPromptPrice)
+
+	merged := base.Merge(&ExecutionConfig{Gateway: &GatewayConfig{Type: ProviderLiteLLM}})
+	assert.Equal(t, ProviderLiteLLM, merged.Gateway.Type)
+	assert.Equal(t, ProviderOpenRouter, base.Merge(&ExecutionConfig{}).Gateway.Type)
+
+	assert.Equal(t, map[string]any{
+		GatewayKeyType:      ProviderOpenRouter,
+		GatewayKeyProviders: []string{"openai"},
+		GatewayKeyMaxPrice:  map[string]any{GatewayKeyPrompt: 2.0},
+	}, base.ToMap()[ParamKeyGateway])
+}
@@ -32,6 +32,9 @@ type ExecutionConfig struct {
 	// Extended thinking configuration (Anthropic)
 	Thinking *ThinkingConfig `yaml:"thinking,omitempty" json:"thinking,omitempty"`
 
+	// Provider-neutral reasoning effort and budget
+	Reasoning *ReasoningConfig `yaml:"reasoning,omitempty" json:"reasoning,omitempty"`
+
 	// Structured output configuration
 	ResponseFormat *ResponseFormat `yaml:"response_format,omitempty" json:"response_format,omitempty"`
 	GuidedDecoding *GuidedDecoding `yaml:"guided_decoding,omitempty" json:"guided_decoding,omitempty"`
@@ -48,6 +51,12 @@ type ExecutionConfig struct {
 	Streaming *StreamingConfig `yaml:"streaming,omitempty" json:"streaming,omitempty"`
 	Async     *AsyncConfig     `yaml:"async,omitempty" json:"async,omitempty"`
 
+	// Gateway routing (OpenRouter, LiteLLM), added to OpenAI-style requests
+	Gateway *GatewayConfig `yaml:"gateway,omitempty" json:"gateway,omitempty"`
+
+	// Retry and fallback policy for callers executing the request
+	Fallback *FallbackConfig `yaml:"fallback,omitempty" json:"fallback,omitempty"`
+
 	// Provider-specific options (passthrough)
 	ProviderOptions map[string]any `yaml:"provider_options,omitempty" json:"provider_options,omitempty"`
 }
@@ -129,6 +138,11 @@ func (e *ExecutionConfig) Validate() error {
 			return NewPromptValidationError(ErrMsgThinkingBudgetInvalid, "")
 		}
 	}
+	if e.Reasoning != nil {
+		if err := e.Reasoning.Validate(); err != nil {
+			return err
+		}
+	}
 
 	// Validate modality if set
 	if e.Modality != "" && !isValidModality(e.Modality) {
@@ -161,6 +175,21 @@ func (e *ExecutionConfig) Validate() error {
 			return err
 		}
 	}
+	if e.Gateway != nil {
+		if err := e.Gateway.Validate(); err != nil {
+			return err
+		}
+	}
+	if e.Fallback != nil {
+		if err := e.Fallback.Validate(); err != nil {
+			return err
+		}
+	}
+	if e.GuidedDecoding != nil {
+		if err := e.GuidedDecoding.Validate(); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -233,6 +262,9 @@ func (e *ExecutionConfig) Clone() *ExecutionConfig {
 			clone.Thinking.BudgetTokens = &bt
 		}
 	}
+	if e.Reasoning != nil {
+		clone.Reasoning = e.Reasoning.Clone()
+	}
 
 	if e.ResponseFormat != nil {
 		clone.ResponseFormat = cloneResponseFormat(e.ResponseFormat)
@@ -258,6 +290,12 @@ func (e *ExecutionConfig) Clone() *ExecutionConfig {
 	if e.Async != nil {
 		clone.Async = e.Async.Clone()
 	}
+	if e.Gateway != nil {
+		clone.Gateway = e.Gateway.Clone()
+	}
+	if e.Fallback != nil {
+		clone.Fallback = e.Fallback.Clone()
+	}
 
 	if e.ProviderOptions != nil {
 		clone.ProviderOptions = make(map[string]any, len(e.ProviderOptions))
@@ -316,6 +354,7 @@ func cloneGuidedDecoding(gd *GuidedDecoding) *GuidedDecoding {
 		Backend:           gd.Backend,
 		Regex:             gd.Regex,
 		Grammar:           gd.Grammar,
+		GrammarFormat:     gd.GrammarFormat,
 		WhitespacePattern: gd.WhitespacePattern,
 	}
 	if gd.JSON != nil {
@@ -591,6 +630,16 @@ func (e *ExecutionConfig) GetEffectiveProvider() string {
 		return e.Provider
 	}
 
+	// A gateway routes the request
+	if e.Gateway != nil && e.Gateway.Type != "" {
+		return e.Gateway.Type
+	}
+
+	// Bedrock model IDs name their vendor ("anthropic.claude-...")
+	if isBedrockModel(e.Model) {
+		return ProviderBedrock
+	}
+
 	// Infer from configuration shape
 	if e.GuidedDecoding != nil {
 		return ProviderVLLM
@@ -619,6 +668,12 @@ func (e *ExecutionConfig) GetEffectiveProvider() string {
 		if isCohereModel(e.Model) {
 			return ProviderCohere
 		}
+		if isLlamaCppModel(e.Model) {
+			return ProviderLlamaCpp
+		}
+		if isOllamaModel(e.Model) {
+			return ProviderOllama
+		}
 	}
 
 	return ""
@@ -663,6 +718,9 @@ func (e *ExecutionConfig) ToMap() map[string]any {
 	if len(e.LogitBias) > 0 {
 		result[ParamKeyLogitBias] = e.LogitBias
 	}
+	if e.Reasoning != nil {
+		result[ParamKeyReasoning] = e.Reasoning.ToMap()
+	}
 
 	// v2.5 media fields
 	if e.Modality != "" {
@@ -683,11 +741,18 @@ func (e *ExecutionConfig) ToMap() map[string]any {
 	if e.Async != nil {
 		result[ParamKeyAsync] = e.Async.ToMap()
 	}
+	if e.Gateway != nil {
+		result[ParamKeyGateway] = e.Gateway.ToMap()
+	}
+	if e.Fallback != nil {
+		result[ParamKeyFallback] = e.Fallback.ToMap()
+	}
 
 	return result
 }
 
 // ToOpenAI converts the execution config to OpenAI API format.
+// With a Gateway configured, the gateway's routing fields are added.
 func (e *ExecutionConfig) ToOpenAI() map[string]any {
 	if e == nil {
 		return nil
@@ -721,6 +786,9 @@ func (e *ExecutionConfig) ToOpenAI() map[string]any {
 	if len(e.LogitBias) > 0 {
 		result[ParamKeyLogitBias] = e.LogitBias
 	}
+	if e.Reasoning != nil {
+		result[ProviderOptionReasoningEffort] = e.Reasoning.EffectiveEffort()
+	}
 
 	if e.ResponseFormat != nil {
 		result[ParamKeyResponseFormat] = e.ResponseFormat.ToOpenAI()
@@ -733,6 +801,9 @@ func (e *ExecutionConfig) ToOpenAI() map[string]any {
 	if e.Streaming != nil && e.Streaming.Enabled {
 		result[ParamKeyStream] = true
 	}
+	if e.Gateway != nil {
+		e.Gateway.addRoutingParams(result, e.Model)
+	}
 
 	// Merge provider options
 	for k, v := range e.ProviderOptions {
@@ -795,6 +866,69 @@ func (e *ExecutionConfig) openAIEmbeddingParams(result map[string]any) {
 	}
 }
 
+// ToOpenAIResponses converts the execution config to OpenAI Responses API
+// format. Unlike Chat Completions, the Responses API takes max_output_tokens,
+// nests structured output under text.format and reasoning effort under
+// reasoning.effort, and does not accept stop sequences, seeds or logit bias.
+// The reasoning effort is the reasoning_effort provider option, else that of
+// the Reasoning section, else the default effort when thinking is enabled.
+func (e *ExecutionConfig) ToOpenAIResponses() map[string]any {
+	if e == nil {
+		return nil
+	}
+
+	result := make(map[string]any)
+
+	if e.Model != "" {
+		result[ParamKeyModel] = e.Model
+	}
+	if e.Temperature != nil {
+		result[ParamKeyTemperature] = *e.Temperature
+	}
+	if e.MaxTokens != nil {
+		result[ResponsesKeyMaxOutputTokens] = *e.MaxTokens
+	}
+	if e.TopP != nil {
+		result[ParamKeyTopP] = *e.TopP
+	}
+	if e.Logprobs != nil {
+		result[ParamKeyTopLogprobs] = *e.Logprobs
+	}
+
+	if e.ResponseFormat != nil {
+		result[ResponsesKeyText] = map[string]any{ResponsesKeyFormat: e.ResponseFormat.ToOpenAIResponses()}
+	}
+
+	effort, _ := e.ProviderOptions[ProviderOptionReasoningEffort].(string)
+	if effort == "" {
+		effort = e.Reasoning.EffectiveEffort()
+	}
+	if effort == "" && e.Thinking != nil && e.Thinking.Enabled {
+		effort = DefaultResponsesReasoningEffort
+	}
+	if effort != "" {
+		reasoning := map[string]any{ResponsesKeyEffort: effort}
+		if e.Reasoning.includesThoughts() {
+			reasoning[ResponsesKeySummary] = ResponsesSummaryAuto
+		}
+		result[ResponsesKeyReasoning] = reasoning
+	}
+
+	if e.Streaming != nil && e.Streaming.Enabled {
+		result[ParamKeyStream] = true
+	}
+
+	// Merge provider options
+	for k, v := range e.ProviderOptions {
+		if k == ProviderOptionReasoningEffort {
+			continue
+		}
+		result[k] = v
+	}
+
+	return result
+}
+
 // ToAnthropic converts the execution config to Anthropic API format.
 func (e *ExecutionConfig) ToAnthropic() map[string]any {
 	if e == nil {
@@ -825,7 +959,7 @@ func (e *ExecutionConfig) ToAnthropic() map[string]any {
 		result[ParamKeySeed] = *e.Seed
 	}
 
-	// Handle extended thinking
+	// Handle extended thinking; explicit thinking wins over reasoning
 	if e.Thinking != nil && e.Thinking.Enabled {
 		thinking := map[string]any{
 			ParamKeyThinkingType: ParamKeyThinkingTypeEnabled,
@@ -834,6 +968,11 @@ func (e *ExecutionConfig) ToAnthropic() map[string]any {
 			thinking[ParamKeyBudgetTokens] = *e.Thinking.BudgetTokens
 		}
 		result[ParamKeyAnthropicThinking] = thinking
+	} else if e.Reasoning != nil {
+		result[ParamKeyAnthropicThinking] = map[string]any{
+			ParamKeyThinkingType: ParamKeyThinkingTypeEnabled,
+			ParamKeyBudgetTokens: e.Reasoning.EffectiveBudgetTokens(),
+		}
 	}
 
 	// Handle response format for Anthropic
@@ -891,6 +1030,9 @@ func (e *ExecutionConfig) ToGemini() map[string]any {
 		genConfig[ParamKeyGeminiResponseMime] = GeminiResponseMimeJSON
 		genConfig[ParamKeyGeminiResponseSchema] = e.ResponseFormat.ToGemini()
 	}
+	if e.Reasoning != nil {
+		genConfig[GeminiKeyThinkingConfig] = e.Reasoning.geminiThinkingConfig()
+	}
 
 	// v2.5 Gemini image params in generationConfig
 	if e.Image != nil {
@@ -1139,18 +1281,31 @@ func (e *ExecutionConfig) ProviderFormat(provider string) (map[string]any, error
 	}
 
 	switch provider {
-	case ProviderOpenAI, ProviderAzure:
+	case ProviderOpenAI, ProviderAzure, ProviderOpenRouter, ProviderLiteLLM:
 		if e.ResponseFormat != nil {
 			return e.ResponseFormat.ToOpenAI(), nil
 		}
 		return nil, nil
 
+	case ProviderOpenAIResponses:
+		if e.ResponseFormat != nil {
+			return e.ResponseFormat.ToOpenAIResponses(), nil
+		}
+		return nil, nil
+
 	case ProviderAnthropic:
 		if e.ResponseFormat != nil {
 			return e.ResponseFormat.ToAnthropic(), nil
 		}
 		return nil, nil
 
+	case ProviderBedrock:
+		// Only Anthropic models on Bedrock take an output format
+		if family, _, _ := bedrockModelParts(e.Model); family == BedrockFamilyAnthropic && e.ResponseFormat != nil {
+			return e.ResponseFormat.ToAnthropic(), nil
+		}
+		return nil, nil
+
 	case ProviderGoogle, ProviderGemini, ProviderVertex:
 		if e.ResponseFormat != nil {
 			return e.ResponseFormat.ToGemini(), nil
@@ -1163,6 +1318,18 @@ func (e *ExecutionConfig) ProviderFormat(provider string) (map[string]any, error
 		}
 		return nil, nil
 
+	case ProviderOllama:
+		if format, ok := e.ToOllama()[OllamaKeyFormat]; ok {
+			return map[string]any{OllamaKeyFormat: format}, nil
+		}
+		return nil, nil
+
+	case ProviderLlamaCpp:
+		if e.ResponseFormat != nil {
+			return e.ResponseFormat.ToOpenAI(), nil
+		}
+		return nil, nil
+
 	case ProviderMistral:
 		// Mistral uses OpenAI-compatible response_format
 		if e.ResponseFormat != nil {
@@ -1250,6 +1417,9 @@ func (e *ExecutionConfig) Merge(other *ExecutionConfig) *ExecutionConfig {
 			result.Thinking.BudgetTokens = &bt
 		}
 	}
+	if other.Reasoning != nil {
+		result.Reasoning = other.Reasoning.Clone()
+	}
 
 	if other.ResponseFormat != nil {
 		result.ResponseFormat = cloneResponseFormat(other.ResponseFormat)
@@ -1277,6 +1447,12 @@ func (e *ExecutionConfig) Merge(other *ExecutionConfig) *ExecutionConfig {
 	if other.Async != nil {
 		result.Async = other.Async.Clone()
 	}
+	if other.Gateway != nil {
+		result.Gateway = other.Gateway.Clone()
+	}
+	if other.Fallback != nil {
+		result.Fallback = other.Fallback.Clone()
+	}
 
 	// Merge provider options (other wins on conflict)
 	if len(other.ProviderOptions) > 0 {
@@ -0,0 +1,177 @@
+package prompty
+
+import "strings"
+
+// ollamaModelOptions are the Ollama model options that ProviderOptions may
+// set; they are sent in the "options" map rather than at the top level.
+var ollamaModelOptions = map[string]bool{
+	"mirostat": true, "mirostat_eta": true, "mirostat_tau": true,
+	"num_ctx": true, "num_keep": true, "num_batch": true, "num_gpu": true, "num_thread": true,
+	"repeat_last_n": true, "tfs_z": true, "typical_p": true,
+	"presence_penalty": true, "frequency_penalty": true, "penalize_newline": true,
+	"use_mmap": true, "use_mlock": true, "low_vram": true, "numa": true,
+}
+
+// localModelPrefixes are the model families commonly run on local servers
+// under their Ollama names ("llama3.1:8b", "qwen2.5-coder").
+var localModelPrefixes = []string{
+	"llama", "codellama", "tinyllama", "qwen", "phi", "gemma", "deepseek-r1", "deepseek-coder",
+	"mixtral", "starcoder", "granite", "smollm", "nomic-embed", "mxbai-embed", "llava",
+}
+
+// isOllamaModel checks if the model name is an Ollama model name: a local
+// model family, or any name with a single ":" tag ("mistral:latest").
+// Used by GetEffectiveProvider() for automatic provider detection.
+func isOllamaModel(name string) bool {
+	if _, tag, found := strings.Cut(name, ":"); found && tag != "" &&
+		strings.Count(name, ":") == 1 && !strings.Contains(name, "/") {
+		return true
+	}
+	for _, prefix := range localModelPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLlamaCppModel checks if the model name is a GGUF model file.
+// Used by GetEffectiveProvider() for automatic provider detection.
+func isLlamaCppModel(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), GGUFModelSuffix)
+}
+
+// ToOllama converts the execution config to Ollama /api/chat format.
+// Sampling parameters go in the "options" map (max_tokens as num_predict,
+// repetition_penalty as repeat_penalty), as do Ollama model options such as
+// mirostat or num_ctx set in ProviderOptions; other provider options
+// (keep_alive, think) stay at the top level. Structured output becomes
+// "format": the JSON schema, or "json" for json_object. Ollama streams by
+// default, so stream is always set.
+func (e *ExecutionConfig) ToOllama() map[string]any {
+	if e == nil {
+		return nil
+	}
+
+	result := make(map[string]any)
+	options := make(map[string]any)
+
+	if e.Model != "" {
+		result[ParamKeyModel] = e.Model
+	}
+	if e.Temperature != nil {
+		options[ParamKeyTemperature] = *e.Temperature
+	}
+	if e.MaxTokens != nil {
+		options[OllamaKeyNumPredict] = *e.MaxTokens
+	}
+	if e.TopP != nil {
+		options[ParamKeyTopP] = *e.TopP
+	}
+	if e.TopK != nil {
+		options[ParamKeyTopK] = *e.TopK
+	}
+	if e.MinP != nil {
+		options[ParamKeyMinP] = *e.MinP
+	}
+	if e.RepetitionPenalty != nil {
+		options[OllamaKeyRepeatPenalty] = *e.RepetitionPenalty
+	}
+	if e.Seed != nil {
+		options[ParamKeySeed] = *e.Seed
+	}
+	if len(e.StopSequences) > 0 {
+		options[ParamKeyStop] = e.StopSequences
+	}
+
+	if e.ResponseFormat != nil {
+		switch {
+		case e.ResponseFormat.JSONSchema != nil && e.ResponseFormat.JSONSchema.Schema != nil:
+			result[OllamaKeyFormat] = copySchema(e.ResponseFormat.JSONSchema.Schema)
+		case e.ResponseFormat.Type == ResponseFormatJSONObject:
+			result[OllamaKeyFormat] = OllamaFormatJSON
+		}
+	}
+
+	result[ParamKeyStream] = e.Streaming != nil && e.Streaming.Enabled
+
+	for k, v := range e.ProviderOptions {
+		if ollamaModelOptions[k] {
+			options[k] = v
+			continue
+		}
+		result[k] = v
+	}
+	if len(options) > 0 {
+		result[OllamaKeyOptions] = options
+	}
+
+	return result
+}
+
+// ToLlamaCpp converts the execution config to llama.cpp server format
+// (/v1/chat/completions with llama.cpp sampling extensions). Parameters are
+// top-level: top_k, min_p, repeat_penalty, seed and any llama.cpp options
+// such as mirostat from ProviderOptions. Guided decoding JSON schemas and
+// grammars map to json_schema and grammar.
+func (e *ExecutionConfig) ToLlamaCpp() map[string]any {
+	if e == nil {
+		return nil
+	}
+
+	result := make(map[string]any)
+
+	if e.Model != "" {
+		result[ParamKeyModel] = e.Model
+	}
+	if e.Temperature != nil {
+		result[ParamKeyTemperature] = *e.Temperature
+	}
+	if e.MaxTokens != nil {
+		result[ParamKeyMaxTokens] = *e.MaxTokens
+	}
+	if e.TopP != nil {
+		result[ParamKeyTopP] = *e.TopP
+	}
+	if e.TopK != nil {
+		result[ParamKeyTopK] = *e.TopK
+	}
+	if e.MinP != nil {
+		result[ParamKeyMinP] = *e.MinP
+	}
+	if e.RepetitionPenalty != nil {
+		result[OllamaKeyRepeatPenalty] = *e.RepetitionPenalty
+	}
+	if e.Seed != nil {
+		result[ParamKeySeed] = *e.Seed
+	}
+	if len(e.StopSequences) > 0 {
+		result[ParamKeyStop] = e.StopSequences
+	}
+	if len(e.LogitBias) > 0 {
+		result[ParamKeyLogitBias] = e.LogitBias
+	}
+
+	if e.ResponseFormat != nil {
+		result[ParamKeyResponseFormat] = e.ResponseFormat.ToOpenAI()
+	}
+	if e.GuidedDecoding != nil {
+		if e.GuidedDecoding.JSON != nil {
+			result[LlamaCppKeyJSONSchema] = copySchema(e.GuidedDecoding.JSON)
+		}
+		if grammar := e.GuidedDecoding.llamaCppGrammar(); grammar != "" {
+			result[LlamaCppKeyGrammar] = grammar
+		}
+	}
+
+	if e.Streaming != nil && e.Streaming.Enabled {
+		result[ParamKeyStream] = true
+	}
+
+	// Merge provider options
+	for k, v := range e.ProviderOptions {
+		result[k] = v
+	}
+
+	return result
+}
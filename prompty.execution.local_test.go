@@ -0,0 +1,97 @@
+package prompty
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutionConfig_GetEffectiveProvider_Local(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"llama3.1:8b", ProviderOllama},
+		{"qwen2.5-coder", ProviderOllama},
+		{"mistral:latest", ProviderOllama},
+		{"mistral-large-latest", ProviderMistral},
+		{"ft:gpt-3.5-turbo:acme::abc123", ""},
+		{"models/Meta-Llama-3-8B-Instruct-Q4_K_M.gguf", ProviderLlamaCpp},
+		{"gpt-4o", ProviderOpenAI},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			config := &ExecutionConfig{Model: tt.model}
+			assert.Equal(t, tt.want, config.GetEffectiveProvider())
+		})
+	}
+}
+
+func TestExecutionConfig_ToOllama(t *testing.T) {
+	temp := 0.8
+	maxTokens := 128
+	topK := 40
+	repPen := 1.1
+
+	config := &ExecutionConfig{
+		Model:             "llama3.1:8b",
+		Temperature:       &temp,
+		MaxTokens:         &maxTokens,
+		TopK:              &topK,
+		RepetitionPenalty: &repPen,
+		StopSequences:     []string{"</s>"},
+		ResponseFormat: &ResponseFormat{
+			Type:       ResponseFormatJSONSchema,
+			JSONSchema: &JSONSchemaSpec{Name: "answer", Schema: map[string]any{"type": "object"}},
+		},
+		ProviderOptions: map[string]any{"mirostat": 2, "num_ctx": 8192, "keep_alive": "5m"},
+	}
+	assert.Equal(t, map[string]any{
+		ParamKeyModel:   "llama3.1:8b",
+		OllamaKeyFormat: map[string]any{"type": "object"},
+		ParamKeyStream:  false,
+		"keep_alive":    "5m",
+		OllamaKeyOptions: map[string]any{
+			ParamKeyTemperature:    0.8,
+			OllamaKeyNumPredict:    128,
+			ParamKeyTopK:           40,
+			OllamaKeyRepeatPenalty: 1.1,
+			ParamKeyStop:           []string{"</s>"},
+			"mirostat":             2,
+			"num_ctx":              8192,
+		},
+	}, config.ToOllama())
+
+	jsonMode := &ExecutionConfig{ResponseFormat: &ResponseFormat{Type: ResponseFormatJSONObject}, Streaming: &StreamingConfig{Enabled: true}}
+	assert.Equal(t, map[string]any{OllamaKeyFormat: OllamaFormatJSON, ParamKeyStream: true}, jsonMode.ToOllama())
+
+	format, err := jsonMode.ProviderFormat(ProviderOllama)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{OllamaKeyFormat: OllamaFormatJSON}, format)
+
+	var nilConfig *ExecutionConfig
+	assert.Nil(t, nilConfig.ToOllama())
+}
+
+func TestExecutionConfig_ToLlamaCpp(t *testing.T) {
+	minP := 0.05
+	repPen := 1.15
+
+	config := &ExecutionConfig{
+		Model:             "model.gguf",
+		MinP:              &minP,
+		RepetitionPenalty: &repPen,
+		GuidedDecoding:    &GuidedDecoding{Grammar: `root ::= "yes" | "no"`},
+		ProviderOptions:   map[string]any{"mirostat": 1},
+	}
+	assert.Equal(t, map[string]any{
+		ParamKeyModel:          "model.gguf",
+		ParamKeyMinP:           0.05,
+		OllamaKeyRepeatPenalty: 1.15,
+		LlamaCppKeyGrammar:     `root ::= "yes" | "no"`,
+		"mirostat":             1,
+	}, config.ToLlamaCpp())
+
+	var nilConfig *ExecutionConfig
+	assert.Nil(t, nilConfig.ToLlamaCpp())
+}
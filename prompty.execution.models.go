@@ -0,0 +1,230 @@
+package prompty
+
+import (
+	"io"
+	"sort"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ModelAlias is what a model alias such as "fast" or "smart" stands for: a
+// provider, a model and default execution parameters. Prompts name the
+// alias in execution.model and ModelRegistry.Resolve swaps in the target.
+type ModelAlias struct {
+	// Provider of the model; empty keeps the config's provider
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+	// Model is the provider's model name
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
+	// Execution holds default parameters; the prompt's own settings win
+	Execution *ExecutionConfig `yaml:"execution,omitempty" json:"execution,omitempty"`
+}
+
+// ModelRegistryDocument is the YAML document read by ModelRegistry.LoadYAML.
+type ModelRegistryDocument struct {
+	// Aliases maps alias names to their targets
+	Aliases map[string]ModelAlias `yaml:"aliases" json:"aliases"`
+	// Environments maps environment names to alias overrides
+	Environments map[string]map[string]ModelAlias `yaml:"environments,omitempty" json:"environments,omitempty"`
+}
+
+// ModelRegistry resolves model aliases in execution configs, so templates
+// reference stable names instead of hard-coded model names. Overrides
+// registered for an environment (e.g. "staging") replace the provider and
+// model of an alias there and add to its default parameters. It is safe
+// for concurrent use.
+//
+// Example:
+//
+//	registry := prompty.NewModelRegistry()
+//	_ = registry.Register("fast", prompty.ModelAlias{Provider: "openai", Model: "gpt-4o-mini"})
+//	_ = registry.RegisterOverride("dev", "fast", prompty.ModelAlias{Provider: "ollama", Model: "llama3.1:8b"})
+//	compiled, err := agent.CompileAgent(ctx, input, prompty.NewCompileOptions(
+//	    prompty.WithModelRegistry(registry, "dev"),
+//	))
+type ModelRegistry struct {
+	mu           sync.RWMutex
+	aliases      map[string]ModelAlias
+	environments map[string]map[string]ModelAlias
+}
+
+// NewModelRegistry creates an empty model registry.
+func NewModelRegistry() *ModelRegistry {
+	return &ModelRegistry{
+		aliases:      make(map[string]ModelAlias),
+		environments: make(map[string]map[string]ModelAlias),
+	}
+}
+
+// Register adds or replaces an alias. The alias needs a model, and its
+// execution defaults must be valid.
+func (r *ModelRegistry) Register(name string, alias ModelAlias) error {
+	if err := validateModelAlias(name, alias, true); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.aliases[name] = alias.clone()
+	return nil
+}
+
+// RegisterOverride adds or replaces the override of an alias in an
+// environment. Its provider and model replace those of the alias when set,
+// and its execution defaults take precedence over the alias's.
+func (r *ModelRegistry) RegisterOverride(environment, name string, alias ModelAlias) error {
+	if environment == "" {
+		return NewModelAliasError(ErrMsgModelAliasNoEnvironment, name, nil)
+	}
+	if err := validateModelAlias(name, alias, false); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.environments[environment] == nil {
+		r.environments[environment] = make(map[string]ModelAlias)
+	}
+	r.environments[environment][name] = alias.clone()
+	return nil
+}
+
+// LoadYAML adds or replaces aliases and overrides from a
+// ModelRegistryDocument. Nothing is registered if any entry is invalid.
+//
+//	aliases:
+//	  fast: {provider: openai, model: gpt-4o-mini, execution: {temperature: 0.2}}
+//	  smart: {provider: anthropic, model: claude-sonnet-4-5}
+//	environments:
+//	  dev:
+//	    smart: {provider: ollama, model: "qwen2.5:14b"}
+func (r *ModelRegistry) LoadYAML(reader io.Reader) error {
+	var doc ModelRegistryDocument
+	if err := yaml.NewDecoder(reader).Decode(&doc); err != nil && err != io.EOF {
+		return NewModelAliasError(ErrMsgModelRegistryInvalid, "", err)
+	}
+	for name, alias := range doc.Aliases {
+		if err := validateModelAlias(name, alias, true); err != nil {
+			return err
+		}
+	}
+	for environment, overrides := range doc.Environments {
+		for name, alias := range overrides {
+			if environment == "" {
+				return NewModelAliasError(ErrMsgModelAliasNoEnvironment, name, nil)
+			}
+			if err := validateModelAlias(name, alias, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, alias := range doc.Aliases {
+		r.aliases[name] = alias.clone()
+	}
+	for environment, overrides := range doc.Environments {
+		if r.environments[environment] == nil {
+			r.environments[environment] = make(map[string]ModelAlias, len(overrides))
+		}
+		for name, alias := range overrides {
+			r.environments[environment][name] = alias.clone()
+		}
+	}
+	return nil
+}
+
+// Aliases returns the registered alias names, sorted.
+func (r *ModelRegistry) Aliases() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.aliases))
+	for name := range r.aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the target of an alias in an environment: the alias with
+// the environment's override applied. An override without a base alias
+// stands alone. Reports false when name is not an alias there.
+func (r *ModelRegistry) Lookup(name, environment string) (ModelAlias, bool) {
+	if r == nil {
+		return ModelAlias{}, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	alias, ok := r.aliases[name]
+	override, overridden := r.environments[environment][name]
+	switch {
+	case overridden && ok:
+		alias = alias.merge(override)
+	case overridden:
+		alias = override.clone()
+	case ok:
+		alias = alias.clone()
+	}
+	if alias.Model == "" {
+		return ModelAlias{}, false
+	}
+	return alias, true
+}
+
+// Resolve returns config with its model alias resolved for an environment:
+// the alias's provider and model replace the config's, and its execution
+// defaults apply where the config sets nothing. Configs whose model is not
+// an alias are returned unchanged.
+func (r *ModelRegistry) Resolve(config *ExecutionConfig, environment string) *ExecutionConfig {
+	if config == nil {
+		return nil
+	}
+	alias, ok := r.Lookup(config.Model, environment)
+	if !ok {
+		return config
+	}
+
+	overrides := config.Clone()
+	overrides.Model = alias.Model
+	if alias.Provider != "" {
+		overrides.Provider = alias.Provider
+	}
+	return alias.Execution.Merge(overrides)
+}
+
+// validateModelAlias checks an alias or, when requireModel is false, an
+// environment override.
+func validateModelAlias(name string, alias ModelAlias, requireModel bool) error {
+	if name == "" {
+		return NewModelAliasError(ErrMsgModelAliasNoName, name, nil)
+	}
+	if requireModel && alias.Model == "" {
+		return NewModelAliasError(ErrMsgModelAliasNoModel, name, nil)
+	}
+	if err := alias.Execution.Validate(); err != nil {
+		return NewModelAliasError(ErrMsgModelAliasInvalid, name, err)
+	}
+	return nil
+}
+
+// clone creates a deep copy of the alias.
+func (a ModelAlias) clone() ModelAlias {
+	a.Execution = a.Execution.Clone()
+	return a
+}
+
+// merge applies an environment override to the alias.
+func (a ModelAlias) merge(override ModelAlias) ModelAlias {
+	result := ModelAlias{
+		Provider:  a.Provider,
+		Model:     a.Model,
+		Execution: a.Execution.Merge(override.Execution),
+	}
+	if override.Model != "" {
+		result.Model = override.Model
+	}
+	if override.Provider != "" {
+		result.Provider = override.Provider
+	}
+	return result
+}
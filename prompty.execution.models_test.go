@@ -0,0 +1,200 @@
+package prompty
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestModelRegistry(t *testing.T) *ModelRegistry {
+	t.Helper()
+	temperature := 0.2
+	maxTokens := 512
+	registry := NewModelRegistry()
+	require.NoError(t, registry.Register("fast", ModelAlias{
+		Provider:  ProviderOpenAI,
+		Model:     "gpt-4o-mini",
+		Execution: &ExecutionConfig{Temperature: &temperature, MaxTokens: &maxTokens},
+	}))
+	require.NoError(t, registry.Register("smart", ModelAlias{Provider: ProviderAnthropic, Model: "claude-sonnet-4-5"}))
+	require.NoError(t, registry.RegisterOverride("dev", "fast", ModelAlias{Provider: ProviderOllama, Model: "llama3.1:8b"}))
+	require.NoError(t, registry.RegisterOverride("dev", "local", ModelAlias{Provider: ProviderLlamaCpp, Model: "qwen.gguf"}))
+	return registry
+}
+
+func TestModelRegistry_Register(t *testing.T) {
+	registry := NewModelRegistry()
+	tooHot := 3.0
+
+	tests := []struct {
+		name    string
+		alias   string
+		target  ModelAlias
+		wantErr string
+	}{
+		{name: "valid", alias: "fast", target: ModelAlias{Provider: ProviderOpenAI, Model: "gpt-4o-mini"}},
+		{name: "no name", target: ModelAlias{Model: "gpt-4o"}, wantErr: ErrMsgModelAliasNoName},
+		{name: "no model", alias: "fast", target: ModelAlias{Provider: ProviderOpenAI}, wantErr: ErrMsgModelAliasNoModel},
+		{name: "invalid execution", alias: "hot", target: ModelAlias{Model: "gpt-4o", Execution: &ExecutionConfig{Temperature: &tooHot}}, wantErr: ErrMsgModelAliasInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := registry.Register(tt.alias, tt.target)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	err := registry.RegisterOverride("", "fast", ModelAlias{Model: "gpt-4o"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgModelAliasNoEnvironment)
+	assert.NoError(t, registry.RegisterOverride("dev", "fast", ModelAlias{}), "overrides need no model")
+	assert.Equal(t, []string{"fast"}, registry.Aliases())
+}
+
+func TestModelRegistry_Lookup(t *testing.T) {
+	registry := newTestModelRegistry(t)
+
+	alias, ok := registry.Lookup("fast", "")
+	require.True(t, ok)
+	assert.Equal(t, ProviderOpenAI, alias.Provider)
+	assert.Equal(t, "gpt-4o-mini", alias.Model)
+
+	alias, ok = registry.Lookup("fast", "dev")
+	require.True(t, ok)
+	assert.Equal(t, ProviderOllama, alias.Provider)
+	assert.Equal(t, "llama3.1:8b", alias.Model)
+	require.NotNil(t, alias.Execution, "override keeps the alias defaults")
+	assert.Equal(t, 512, *alias.Execution.MaxTokens)
+
+	alias, ok = registry.Lookup("smart", "dev")
+	require.True(t, ok)
+	assert.Equal(t, "claude-sonnet-4-5", alias.Model, "no override in dev")
+
+	_, ok = registry.Lookup("local", "")
+	assert.False(t, ok, "override-only alias outside its environment")
+	alias, ok = registry.Lookup("local", "dev")
+	require.True(t, ok)
+	assert.Equal(t, "qwen.gguf", alias.Model)
+
+	_, ok = registry.Lookup("gpt-4o", "")
+	assert.False(t, ok)
+
+	var nilRegistry *ModelRegistry
+	_, ok = nilRegistry.Lookup("fast", "")
+	assert.False(t, ok)
+}
+
+func TestModelRegistry_Lookup_PartialOverride(t *testing.T) {
+	registry := newTestModelRegistry(t)
+	temperature := 0.9
+	require.NoError(t, registry.RegisterOverride("staging", "fast", ModelAlias{
+		Execution: &ExecutionConfig{Temperature: &temperature},
+	}))
+
+	alias, ok := registry.Lookup("fast", "staging")
+	require.True(t, ok)
+	assert.Equal(t, ProviderOpenAI, alias.Provider)
+	assert.Equal(t, "gpt-4o-mini", alias.Model)
+	assert.Equal(t, 0.9, *alias.Execution.Temperature)
+	assert.Equal(t, 512, *alias.Execution.MaxTokens)
+}
+
+func TestModelRegistry_Resolve(t *testing.T) {
+	registry := newTestModelRegistry(t)
+	temperature := 0.7
+
+	config := &ExecutionConfig{Model: "fast", Temperature: &temperature}
+	resolved := registry.Resolve(config, "")
+	require.NotNil(t, resolved)
+	assert.Equal(t, ProviderOpenAI, resolved.Provider)
+	assert.Equal(t, "gpt-4o-mini", resolved.Model)
+	assert.Equal(t, 0.7, *resolved.Temperature, "config settings win over alias defaults")
+	assert.Equal(t, 512, *resolved.MaxTokens)
+	assert.Equal(t, "fast", config.Model, "config is not modified")
+
+	resolved = registry.Resolve(&ExecutionConfig{Provider: ProviderOpenAI, Model: "fast"}, "dev")
+	assert.Equal(t, ProviderOllama, resolved.Provider, "alias provider replaces the config's")
+	assert.Equal(t, "llama3.1:8b", resolved.Model)
+
+	plain := &ExecutionConfig{Provider: ProviderOpenAI, Model: "gpt-4o"}
+	assert.Same(t, plain, registry.Resolve(plain, ""))
+	assert.Nil(t, registry.Resolve(nil, ""))
+}
+
+func TestModelRegistry_LoadYAML(t *testing.T) {
+	registry := NewModelRegistry()
+	err := registry.LoadYAML(strings.NewReader(`
+aliases:
+  fast: {provider: openai, model: gpt-4o-mini, execution: {temperature: 0.2}}
+  smart: {provider: anthropic, model: claude-sonnet-4-5}
+environments:
+  dev:
+    smart: {provider: ollama, model: "qwen2.5:14b"}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fast", "smart"}, registry.Aliases())
+
+	alias, ok := registry.Lookup("fast", "")
+	require.True(t, ok)
+	assert.Equal(t, 0.2, *alias.Execution.Temperature)
+	alias, ok = registry.Lookup("smart", "dev")
+	require.True(t, ok)
+	assert.Equal(t, "qwen2.5:14b", alias.Model)
+
+	t.Run("invalid entry registers nothing", func(t *testing.T) {
+		registry := NewModelRegistry()
+		err := registry.LoadYAML(strings.NewReader("aliases:\n  fast: {model: gpt-4o-mini}\n  broken: {provider: openai}\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMsgModelAliasNoModel)
+		assert.Empty(t, registry.Aliases())
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		err := NewModelRegistry().LoadYAML(strings.NewReader("aliases: [fast"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMsgModelRegistryInvalid)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.NoError(t, NewModelRegistry().LoadYAML(strings.NewReader("")))
+	})
+}
+
+func TestCompileAgent_ModelRegistry(t *testing.T) {
+	prompt, err := Parse([]byte(`---
+name: alias-agent
+description: Agent using a model alias
+type: agent
+execution:
+  provider: openai
+  model: fast
+  temperature: 0.5
+---
+You are helpful.`))
+	require.NoError(t, err)
+	registry := newTestModelRegistry(t)
+
+	compiled, err := prompt.CompileAgent(context.Background(), nil, NewCompileOptions(WithModelRegistry(registry, "dev")))
+	require.NoError(t, err)
+	assert.Equal(t, ProviderOllama, compiled.Execution.Provider)
+	assert.Equal(t, "llama3.1:8b", compiled.Execution.Model)
+	assert.Equal(t, 0.5, *compiled.Execution.Temperature)
+	assert.Equal(t, "fast", prompt.Execution.Model, "prompt is not modified")
+
+	executor := NewAgentExecutor(WithAgentModelRegistry(registry, ""))
+	compiled, err = executor.ExecutePrompt(context.Background(), prompt, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o-mini", compiled.Execution.Model)
+
+	compiled, err = prompt.CompileAgent(context.Background(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "fast", compiled.Execution.Model, "no registry leaves the alias")
+}
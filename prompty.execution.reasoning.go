@@ -0,0 +1,126 @@
+package prompty
+
+// ReasoningConfig is the provider-neutral reasoning setting of a request,
+// mapped by the serializers to each provider's own parameters:
+//
+//   - OpenAI Chat Completions: reasoning_effort
+//   - OpenAI Responses: reasoning.effort, and reasoning.summary "auto" when
+//     IncludeThoughts is set
+//   - Anthropic (and Anthropic on Bedrock): thinking with budget_tokens,
+//     unless Thinking is enabled, which takes precedence
+//   - Gemini: generationConfig.thinkingConfig with thinkingBudget and
+//     includeThoughts
+//
+// Providers taking an effort get Effort, or the effort nearest to
+// BudgetTokens; providers taking a budget get BudgetTokens, or the budget of
+// Effort. Without either the effort is medium.
+//
+// ReasoningConfig is safe for concurrent reads. Use Clone() to create an
+// independent copy if mutation is needed.
+type ReasoningConfig struct {
+	// Effort is the reasoning effort: "low", "medium" or "high"
+	Effort string `yaml:"effort,omitempty" json:"effort,omitempty"`
+	// BudgetTokens caps the tokens spent reasoning
+	BudgetTokens *int `yaml:"budget_tokens,omitempty" json:"budget_tokens,omitempty"`
+	// IncludeThoughts returns the reasoning (or a summary) with the reply
+	IncludeThoughts *bool `yaml:"include_thoughts,omitempty" json:"include_thoughts,omitempty"`
+}
+
+// Validate checks the reasoning config: a known effort and a positive
+// budget.
+func (r *ReasoningConfig) Validate() error {
+	if r == nil {
+		return nil
+	}
+	switch r.Effort {
+	case "", ReasoningEffortLow, ReasoningEffortMedium, ReasoningEffortHigh:
+	default:
+		return NewPromptValidationError(ErrMsgReasoningInvalidEffort, "")
+	}
+	if r.BudgetTokens != nil && *r.BudgetTokens <= 0 {
+		return NewPromptValidationError(ErrMsgReasoningBudgetInvalid, "")
+	}
+	return nil
+}
+
+// Clone creates a deep copy of the reasoning config.
+func (r *ReasoningConfig) Clone() *ReasoningConfig {
+	if r == nil {
+		return nil
+	}
+	return &ReasoningConfig{
+		Effort:          r.Effort,
+		BudgetTokens:    coalesceIntPtr(r.BudgetTokens, nil),
+		IncludeThoughts: coalesceBoolPtr(r.IncludeThoughts, nil),
+	}
+}
+
+// ToMap converts the reasoning config to a parameter map.
+func (r *ReasoningConfig) ToMap() map[string]any {
+	if r == nil {
+		return nil
+	}
+	result := make(map[string]any)
+	if r.Effort != "" {
+		result[ReasoningKeyEffort] = r.Effort
+	}
+	if r.BudgetTokens != nil {
+		result[ReasoningKeyBudgetTokens] = *r.BudgetTokens
+	}
+	if r.IncludeThoughts != nil {
+		result[ReasoningKeyIncludeThoughts] = *r.IncludeThoughts
+	}
+	return result
+}
+
+// EffectiveEffort returns Effort, or the effort whose budget BudgetTokens
+// does not exceed, or medium.
+func (r *ReasoningConfig) EffectiveEffort() string {
+	switch {
+	case r == nil:
+		return ""
+	case r.Effort != "":
+		return r.Effort
+	case r.BudgetTokens == nil:
+		return ReasoningEffortMedium
+	case *r.BudgetTokens <= ReasoningBudgetLow:
+		return ReasoningEffortLow
+	case *r.BudgetTokens <= ReasoningBudgetMedium:
+		return ReasoningEffortMedium
+	default:
+		return ReasoningEffortHigh
+	}
+}
+
+// EffectiveBudgetTokens returns BudgetTokens, or the budget of the
+// effective effort.
+func (r *ReasoningConfig) EffectiveBudgetTokens() int {
+	if r == nil {
+		return 0
+	}
+	if r.BudgetTokens != nil {
+		return *r.BudgetTokens
+	}
+	switch r.EffectiveEffort() {
+	case ReasoningEffortLow:
+		return ReasoningBudgetLow
+	case ReasoningEffortHigh:
+		return ReasoningBudgetHigh
+	default:
+		return ReasoningBudgetMedium
+	}
+}
+
+// includesThoughts reports whether the reasoning is returned with the reply.
+func (r *ReasoningConfig) includesThoughts() bool {
+	return r != nil && r.IncludeThoughts != nil && *r.IncludeThoughts
+}
+
+// geminiThinkingConfig returns the Gemini thinkingConfig object.
+func (r *ReasoningConfig) geminiThinkingConfig() map[string]any {
+	result := map[string]any{GeminiKeyThinkingBudget: r.EffectiveBudgetTokens()}
+	if r.IncludeThoughts != nil {
+		result[GeminiKeyIncludeThoughts] = *r.IncludeThoughts
+	}
+	return result
+}
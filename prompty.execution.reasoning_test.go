@@ -0,0 +1,147 @@
+package prompty
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestReasoningConfig_Validate(t *testing.T) {
+	zero := 0
+	budget := 2048
+
+	tests := []struct {
+		name    string
+		config  *ReasoningConfig
+		wantErr string
+	}{
+		{name: "nil", config: nil},
+		{name: "effort", config: &ReasoningConfig{Effort: ReasoningEffortHigh}},
+		{name: "budget", config: &ReasoningConfig{BudgetTokens: &budget}},
+		{name: "unknown effort", config: &ReasoningConfig{Effort: "extreme"}, wantErr: ErrMsgReasoningInvalidEffort},
+		{name: "zero budget", config: &ReasoningConfig{BudgetTokens: &zero}, wantErr: ErrMsgReasoningBudgetInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	config := &ExecutionConfig{Reasoning: &ReasoningConfig{Effort: "max"}}
+	assert.Error(t, config.Validate())
+}
+
+func TestReasoningConfig_Effective(t *testing.T) {
+	budget := func(n int) *int { return &n }
+
+	tests := []struct {
+		name       string
+		config     *ReasoningConfig
+		wantEffort string
+		wantBudget int
+	}{
+		{"nil", nil, "", 0},
+		{"empty", &ReasoningConfig{}, ReasoningEffortMedium, ReasoningBudgetMedium},
+		{"low effort", &ReasoningConfig{Effort: ReasoningEffortLow}, ReasoningEffortLow, ReasoningBudgetLow},
+		{"high effort", &ReasoningConfig{Effort: ReasoningEffortHigh}, ReasoningEffortHigh, ReasoningBudgetHigh},
+		{"small budget", &ReasoningConfig{BudgetTokens: budget(512)}, ReasoningEffortLow, 512},
+		{"medium budget", &ReasoningConfig{BudgetTokens: budget(4000)}, ReasoningEffortMedium, 4000},
+		{"large budget", &ReasoningConfig{BudgetTokens: budget(30000)}, ReasoningEffortHigh, 30000},
+		{"both", &ReasoningConfig{Effort: ReasoningEffortLow, BudgetTokens: budget(30000)}, ReasoningEffortLow, 30000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantEffort, tt.config.EffectiveEffort())
+			assert.Equal(t, tt.wantBudget, tt.config.EffectiveBudgetTokens())
+		})
+	}
+}
+
+func TestReasoningConfig_YAMLCloneMerge(t *testing.T) {
+	var config ExecutionConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`
+model: gpt-5
+reasoning:
+  effort: high
+  budget_tokens: 16000
+  include_thoughts: true
+`), &config))
+	require.NotNil(t, config.Reasoning)
+	require.NoError(t, config.Validate())
+	assert.Equal(t, map[string]any{
+		ReasoningKeyEffort:          ReasoningEffortHigh,
+		ReasoningKeyBudgetTokens:    16000,
+		ReasoningKeyIncludeThoughts: true,
+	}, config.ToMap()[ParamKeyReasoning])
+
+	clone := config.Clone()
+	*clone.Reasoning.BudgetTokens = 1
+	clone.Reasoning.Effort = ReasoningEffortLow
+	assert.Equal(t, 16000, *config.Reasoning.BudgetTokens)
+	assert.Equal(t, ReasoningEffortHigh, config.Reasoning.Effort)
+
+	merged := config.Merge(&ExecutionConfig{Reasoning: &ReasoningConfig{Effort: ReasoningEffortLow}})
+	assert.Equal(t, ReasoningEffortLow, merged.Reasoning.Effort)
+	assert.Nil(t, merged.Reasoning.BudgetTokens, "reasoning sections replace, not merge")
+	assert.Equal(t, ReasoningEffortHigh, config.Merge(&ExecutionConfig{}).Reasoning.Effort)
+}
+
+func TestReasoningConfig_Serializers(t *testing.T) {
+	include := true
+	budget := 4000
+	config := &ExecutionConfig{Reasoning: &ReasoningConfig{BudgetTokens: &budget, IncludeThoughts: &include}}
+
+	assert.Equal(t, ReasoningEffortMedium, config.ToOpenAI()[ProviderOptionReasoningEffort])
+	assert.Equal(t, ReasoningEffortMedium, config.ToAzureOpenAI()[ProviderOptionReasoningEffort])
+	assert.Equal(t, map[string]any{
+		ResponsesKeyEffort:  ReasoningEffortMedium,
+		ResponsesKeySummary: ResponsesSummaryAuto,
+	}, config.ToOpenAIResponses()[ResponsesKeyReasoning])
+	assert.Equal(t, map[string]any{
+		ParamKeyThinkingType: ParamKeyThinkingTypeEnabled,
+		ParamKeyBudgetTokens: 4000,
+	}, config.ToAnthropic()[ParamKeyAnthropicThinking])
+	assert.Equal(t, map[string]any{
+		GeminiKeyThinkingBudget:  4000,
+		GeminiKeyIncludeThoughts: true,
+	}, config.ToGemini()[ParamKeyGenerationConfig].(map[string]any)[GeminiKeyThinkingConfig])
+	assert.NotContains(t, config.ToVLLM(), ParamKeyReasoning)
+
+	t.Run("thinking wins for anthropic", func(t *testing.T) {
+		thinkingBudget := 2000
+		config := &ExecutionConfig{
+			Thinking:  &ThinkingConfig{Enabled: true, BudgetTokens: &thinkingBudget},
+			Reasoning: &ReasoningConfig{Effort: ReasoningEffortHigh},
+		}
+		assert.Equal(t, 2000, config.ToAnthropic()[ParamKeyAnthropicThinking].(map[string]any)[ParamKeyBudgetTokens])
+		assert.Equal(t, map[string]any{ResponsesKeyEffort: ReasoningEffortHigh}, config.ToOpenAIResponses()[ResponsesKeyReasoning])
+	})
+
+	t.Run("provider option wins", func(t *testing.T) {
+		config := &ExecutionConfig{
+			Reasoning:       &ReasoningConfig{Effort: ReasoningEffortHigh},
+			ProviderOptions: map[string]any{ProviderOptionReasoningEffort: ReasoningEffortLow},
+		}
+		assert.Equal(t, ReasoningEffortLow, config.ToOpenAI()[ProviderOptionReasoningEffort])
+		assert.Equal(t, map[string]any{ResponsesKeyEffort: ReasoningEffortLow}, config.ToOpenAIResponses()[ResponsesKeyReasoning])
+	})
+
+	t.Run("compatibility", func(t *testing.T) {
+		config := &ExecutionConfig{Reasoning: &ReasoningConfig{Effort: ReasoningEffortLow}}
+		for _, provider := range []string{ProviderOpenAI, ProviderOpenAIResponses, ProviderAnthropic, ProviderGemini} {
+			assert.Nil(t, CheckCompatibility(config, provider), provider)
+		}
+		result := CheckCompatibility(config, ProviderMistral)
+		require.Len(t, result, 1)
+		assert.Equal(t, CapabilityReasoning, result[0].Field)
+		assert.Equal(t, SeverityWarning, result[0].Severity)
+	})
+}
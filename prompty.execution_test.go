@@ -350,6 +350,52 @@ func TestExecutionConfig_ToOpenAI(t *testing.T) {
 	assert.Equal(t, "option", result["custom"])
 }
 
+func TestExecutionConfig_ToOpenAIResponses(t *testing.T) {
+	temp := 0.7
+	maxTokens := 1000
+
+	config := &ExecutionConfig{
+		Model:         "gpt-5",
+		Temperature:   &temp,
+		MaxTokens:     &maxTokens,
+		StopSequences: []string{"END"},
+		ResponseFormat: &ResponseFormat{
+			Type: ResponseFormatJSONSchema,
+			JSONSchema: &JSONSchemaSpec{
+				Name:   "answer",
+				Strict: true,
+				Schema: map[string]any{"type": "object"},
+			},
+		},
+		ProviderOptions: map[string]any{
+			ProviderOptionReasoningEffort: "high",
+			"store":                       false,
+		},
+	}
+
+	result := config.ToOpenAIResponses()
+
+	assert.Equal(t, "gpt-5", result[ParamKeyModel])
+	assert.Equal(t, 1000, result[ResponsesKeyMaxOutputTokens])
+	assert.NotContains(t, result, ParamKeyMaxTokens)
+	assert.NotContains(t, result, ParamKeyStop)
+	assert.NotContains(t, result, ProviderOptionReasoningEffort)
+	assert.Equal(t, map[string]any{ResponsesKeyEffort: "high"}, result[ResponsesKeyReasoning])
+	assert.Equal(t, false, result["store"])
+	assert.Equal(t, map[string]any{ResponsesKeyFormat: map[string]any{
+		SchemaKeyType:   ResponseFormatJSONSchema,
+		AttrName:        "answer",
+		SchemaKeyStrict: true,
+		SchemaKeySchema: map[string]any{"type": "object", "additionalProperties": false},
+	}}, result[ResponsesKeyText])
+
+	thinking := &ExecutionConfig{Thinking: &ThinkingConfig{Enabled: true}}
+	assert.Equal(t, map[string]any{ResponsesKeyEffort: DefaultResponsesReasoningEffort}, thinking.ToOpenAIResponses()[ResponsesKeyReasoning])
+
+	var nilConfig *ExecutionConfig
+	assert.Nil(t, nilConfig.ToOpenAIResponses())
+}
+
 func TestExecutionConfig_ToAnthropic(t *testing.T) {
 	temp := 0.7
 	maxTokens := 1000
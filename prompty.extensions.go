@@ -137,6 +137,15 @@ func (p *Prompt) GetPromptyFields() map[string]any {
 	if p.Execution != nil {
 		m[PromptFieldExecution] = p.Execution
 	}
+	if len(p.Environments) > 0 {
+		m[PromptFieldEnvironments] = p.Environments
+	}
+	if len(p.Variants) > 0 {
+		m[PromptFieldVariants] = p.Variants
+	}
+	if len(p.Tests) > 0 {
+		m[PromptFieldTests] = p.Tests
+	}
 	if len(p.Skills) > 0 {
 		m[PromptFieldSkills] = p.Skills
 	}
@@ -0,0 +1,344 @@
+package prompty
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+	"gopkg.in/yaml.v3"
+)
+
+// formatAttrOrder lists attributes that identify a tag; they are emitted
+// first in this order, followed by all other attributes alphabetically.
+var formatAttrOrder = []string{
+	AttrName, AttrTemplate, AttrSlug, AttrVersion, AttrRole,
+	AttrItem, AttrIndex, AttrIn, AttrEval, AttrValue,
+}
+
+// formatFrontmatterOrder lists top-level frontmatter keys in canonical order.
+// Unknown (extension) keys follow alphabetically; nested mapping keys are
+// always sorted alphabetically.
+var formatFrontmatterOrder = []string{
+	PromptFieldName, PromptFieldDescription, PromptFieldLicense, PromptFieldCompatibility,
+	PromptFieldAllowedTools, PromptFieldMetadata, PromptFieldType, PromptFieldExecution,
+	PromptFieldEnvironments, PromptFieldInputs, PromptFieldOutputs, PromptFieldSample,
+	PromptFieldSkills, PromptFieldTools, PromptFieldContext, PromptFieldConstraints,
+	PromptFieldMessages, PromptFieldVariants, PromptFieldTests,
+}
+
+// Format parses a template and re-emits it in canonical form:
+//   - YAML frontmatter keys in a stable order (see formatFrontmatterOrder)
+//   - tags written as {~name attr="value" /~} with identifying attributes first
+//     and the rest sorted, double-quoted unless the value contains a double quote
+//   - tags that start a line indented by FormatIndent per block nesting level,
+//     where the indentation never reaches the output: the case tags and
+//     closing tag of a switch, and the top-level tags of a child template
+//
+// Text that is rendered, including raw and comment bodies and the whitespace
+// around other block tags, is preserved exactly, so the formatted template
+// renders the same output. Format is idempotent and returns an error if the
+// template does not parse.
+func Format(source string) (string, error) {
+	fm, err := internal.ExtractYAMLFrontmatter(source)
+	if err != nil {
+		pos := Position{}
+		if configErr, ok := err.(*internal.ConfigError); ok {
+			pos = Position{
+				Offset: configErr.Position.Offset,
+				Line:   configErr.Position.Line,
+				Column: configErr.Position.Column,
+			}
+		}
+		return "", NewConfigBlockError(ErrMsgConfigBlockExtract, pos, err)
+	}
+
+	var sb strings.Builder
+	if fm.HasFrontmatter {
+		frontmatter, err := formatFrontmatter(fm.FrontmatterYAML)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(YAMLFrontmatterDelimiter)
+		sb.WriteString("\n")
+		sb.WriteString(frontmatter)
+		sb.WriteString(YAMLFrontmatterDelimiter)
+		sb.WriteString("\n")
+	}
+
+	body, err := formatBody(fm.TemplateBody)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString(body)
+	return sb.String(), nil
+}
+
+// formatFrontmatter re-encodes frontmatter YAML with canonical key order.
+func formatFrontmatter(frontmatterYAML string) (string, error) {
+	if strings.TrimSpace(frontmatterYAML) == "" {
+		return "", nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(frontmatterYAML), &doc); err != nil {
+		return "", NewFrontmatterParseError(err)
+	}
+	if len(doc.Content) > 0 {
+		sortYAMLMapping(doc.Content[0], formatFrontmatterOrder)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(len(FormatIndent))
+	if err := enc.Encode(&doc); err != nil {
+		return "", NewFrontmatterParseError(err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", NewFrontmatterParseError(err)
+	}
+	return buf.String(), nil
+}
+
+// sortYAMLMapping reorders mapping keys in place: keys listed in priority
+// first, the rest alphabetically. Nested mappings are sorted alphabetically.
+func sortYAMLMapping(node *yaml.Node, priority []string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		type pair struct{ key, value *yaml.Node }
+		pairs := make([]pair, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			pairs = append(pairs, pair{node.Content[i], node.Content[i+1]})
+		}
+		sort.SliceStable(pairs, func(i, j int) bool {
+			return orderedKeyLess(pairs[i].key.Value, pairs[j].key.Value, priority)
+		})
+		node.Content = node.Content[:0]
+		for _, p := range pairs {
+			sortYAMLMapping(p.value, nil)
+			node.Content = append(node.Content, p.key, p.value)
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			sortYAMLMapping(child, nil)
+		}
+	}
+}
+
+// orderedKeyLess orders keys by their index in priority, then alphabetically.
+func orderedKeyLess(a, b string, priority []string) bool {
+	ai, bi := indexOf(priority, a), indexOf(priority, b)
+	switch {
+	case ai >= 0 && bi >= 0:
+		return ai < bi
+	case ai >= 0:
+		return true
+	case bi >= 0:
+		return false
+	default:
+		return a < b
+	}
+}
+
+// indexOf returns the index of s in list, or -1.
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// formatSegment is a piece of formatted body output.
+type formatSegment struct {
+	text     string
+	isTag    bool
+	verbatim bool // Raw/comment body; never rewritten
+	depth    int  // Block nesting depth of a tag
+	indent   bool // The text before the tag is discarded, so its line may be re-indented
+}
+
+// formatBody re-emits a template body from its token stream. Text between
+// tags is copied unchanged, except for the indentation of tags whose
+// preceding text the executor discards (see normalizeIndentation).
+func formatBody(body string) (string, error) {
+	tokens, err := internal.NewLexer(body, nil).Tokenize()
+	if err != nil {
+		return "", NewParseError(ErrMsgParseFailed, Position{}, err)
+	}
+	ast, err := internal.NewParserWithSource(tokens, body, nil).Parse()
+	if err != nil {
+		return "", NewParseError(ErrMsgParseFailed, Position{}, err)
+	}
+	inheritance, _ := internal.ExtractInheritanceInfo(ast)
+	isChild := inheritance != nil
+
+	// open holds the names of the enclosing block tags. Text directly inside
+	// a switch, or at the top level of a child template, is never rendered.
+	var open []string
+	discarded := func() bool {
+		if len(open) == 0 {
+			return isChild
+		}
+		return open[len(open)-1] == TagNameSwitch
+	}
+
+	segments := make([]formatSegment, 0, len(tokens))
+	verbatimNext := false
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch tok.Type {
+		case internal.TokenTypeText:
+			if verbatimNext {
+				segments = append(segments, formatSegment{text: tok.Value, verbatim: true})
+			} else {
+				// Regular text never contains the open delimiter; any occurrence
+				// came from an escape sequence and must be escaped again.
+				text := strings.ReplaceAll(tok.Value, DefaultOpenDelim, internal.StrEscapeOpen)
+				segments = append(segments, formatSegment{text: text})
+			}
+			verbatimNext = false
+
+		case internal.TokenTypeBlockClose:
+			name := tokens[i+1].Value
+			i += 2 // TAG_NAME, CLOSE_TAG
+			seg := formatSegment{
+				text:   DefaultOpenDelim + DelimiterSlash + name + DefaultCloseDelim,
+				isTag:  true,
+				indent: discarded(),
+			}
+			open = open[:max(len(open)-1, 0)]
+			seg.depth = len(open)
+			segments = append(segments, seg)
+			verbatimNext = false
+
+		case internal.TokenTypeOpenTag:
+			text, name, selfClose, last := formatTag(tokens, i+1)
+			i = last
+			seg := formatSegment{text: text, isTag: true, depth: len(open), indent: discarded()}
+			switch {
+			case selfClose:
+			case name == TagNameElse || name == TagNameElseIf:
+				seg.depth--
+			default:
+				open = append(open, name)
+			}
+			segments = append(segments, seg)
+			verbatimNext = !selfClose && (name == TagNameRaw || name == TagNameComment)
+		}
+	}
+
+	normalizeIndentation(segments)
+
+	var sb strings.Builder
+	for _, seg := range segments {
+		sb.WriteString(seg.text)
+	}
+	return sb.String(), nil
+}
+
+// normalizeIndentation rewrites the leading whitespace of lines that start
+// with a tag whose preceding text is discarded by the executor to
+// FormatIndent per nesting level. Indentation elsewhere is rendered and left
+// alone.
+func normalizeIndentation(segments []formatSegment) {
+	for i, seg := range segments {
+		if !seg.indent {
+			continue
+		}
+
+		// The tag must be preceded by optional whitespace and a newline (or start of input)
+		if i == 0 {
+			continue // already at column 1; nothing to indent against
+		}
+		prev := &segments[i-1]
+		if prev.isTag || prev.verbatim {
+			continue
+		}
+		lineStart := strings.LastIndexByte(prev.text, '\n') + 1
+		if lineStart == 0 && i-1 != 0 {
+			continue
+		}
+		if strings.TrimSpace(prev.text[lineStart:]) != "" {
+			continue
+		}
+		prev.text = prev.text[:lineStart] + strings.Repeat(FormatIndent, max(seg.depth, 0))
+	}
+}
+
+// formatTag renders the tag whose name token is at tokens[start] in canonical
+// form. It returns the text, the tag name, whether the tag is self-closing and
+// the index of the tag's last token.
+func formatTag(tokens []internal.Token, start int) (string, string, bool, int) {
+	name := tokens[start].Value
+	attrs := make(map[string]string)
+	keys := make([]string, 0)
+
+	i := start + 1
+	for ; i < len(tokens); i++ {
+		tok := tokens[i]
+		if tok.Type == internal.TokenTypeCloseTag || tok.Type == internal.TokenTypeSelfClose {
+			break
+		}
+		if tok.Type == internal.TokenTypeAttrName && i+2 < len(tokens) {
+			if _, seen := attrs[tok.Value]; !seen {
+				keys = append(keys, tok.Value)
+			}
+			attrs[tok.Value] = tokens[i+2].Value // NAME, EQUALS, VALUE
+			i += 2
+		}
+	}
+	selfClose := i < len(tokens) && tokens[i].Type == internal.TokenTypeSelfClose
+
+	sortAttrKeys(keys)
+
+	var sb strings.Builder
+	sb.WriteString(DefaultOpenDelim)
+	sb.WriteString(name)
+	for _, key := range keys {
+		sb.WriteString(" ")
+		sb.WriteString(key)
+		sb.WriteString("=")
+		sb.WriteString(quoteAttrValue(attrs[key]))
+	}
+	if selfClose {
+		sb.WriteString(" ")
+		sb.WriteString(DelimiterSlash)
+	}
+	sb.WriteString(DefaultCloseDelim)
+	return sb.String(), name, selfClose, i
+}
+
+// sortAttrKeys orders attribute names as Format emits them: identifying
+// attributes first (formatAttrOrder), then the rest alphabetically.
+func sortAttrKeys(keys []string) {
+	sort.SliceStable(keys, func(a, b int) bool {
+		return orderedKeyLess(keys[a], keys[b], formatAttrOrder)
+	})
+}
+
+// quoteAttrValue quotes an attribute value with double quotes, or single
+// quotes when the value contains double but no single quotes. Backslashes are
+// escaped only where the lexer would otherwise read them as an escape.
+func quoteAttrValue(value string) string {
+	quote := byte('"')
+	if strings.Contains(value, `"`) && !strings.Contains(value, "'") {
+		quote = '\''
+	}
+
+	var sb strings.Builder
+	sb.WriteByte(quote)
+	for i := 0; i < len(value); i++ {
+		ch := value[i]
+		switch {
+		case ch == quote:
+			sb.WriteByte('\\')
+		case ch == '\\' && (i+1 == len(value) || value[i+1] == quote || value[i+1] == '\\'):
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(ch)
+	}
+	sb.WriteByte(quote)
+	return sb.String()
+}
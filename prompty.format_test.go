@@ -0,0 +1,269 @@
+package prompty
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "plain text unchanged",
+			source:   "Hello, world!\n  indented text\n",
+			expected: "Hello, world!\n  indented text\n",
+		},
+		{
+			name:     "attribute ordering and quoting",
+			source:   `{~prompty.var   default='Guest' name='user'/~}`,
+			expected: `{~prompty.var name="user" default="Guest" /~}`,
+		},
+		{
+			name:     "identifying attributes first",
+			source:   `{~prompty.for limit="5" in="items" index="i" item="x"~}{~/prompty.for~}`,
+			expected: `{~prompty.for item="x" index="i" in="items" limit="5"~}{~/prompty.for~}`,
+		},
+		{
+			name:     "value with double quotes uses single quotes",
+			source:   `{~prompty.if eval="name == \"x\""~}y{~/prompty.if~}`,
+			expected: `{~prompty.if eval='name == "x"'~}y{~/prompty.if~}`,
+		},
+		{
+			name:     "value with both quote kinds is escaped",
+			source:   `{~prompty.var name="a" default='it\'s "x"' /~}`,
+			expected: `{~prompty.var name="a" default="it's \"x\"" /~}`,
+		},
+		{
+			name:     "block close whitespace normalized",
+			source:   `{~prompty.if eval="x" ~}y{~/ prompty.if ~}`,
+			expected: `{~prompty.if eval="x"~}y{~/prompty.if~}`,
+		},
+		{
+			name:     "escaped delimiter preserved",
+			source:   `Use \{~prompty.var name="x" /~} literally`,
+			expected: `Use \{~prompty.var name="x" /~} literally`,
+		},
+		{
+			name: "whitespace around block tags preserved",
+			source: "{~prompty.if eval=\"a\"~}\n" +
+				"{~prompty.for item=\"x\" in=\"xs\"~}\n" +
+				"    text stays as is\n" +
+				"        {~/prompty.for~}\n" +
+				"\t{~prompty.else~}\n" +
+				"other\n" +
+				"{~/prompty.if~}\n",
+			expected: "{~prompty.if eval=\"a\"~}\n" +
+				"{~prompty.for item=\"x\" in=\"xs\"~}\n" +
+				"    text stays as is\n" +
+				"        {~/prompty.for~}\n" +
+				"\t{~prompty.else~}\n" +
+				"other\n" +
+				"{~/prompty.if~}\n",
+		},
+		{
+			name: "switch case tags indented",
+			source: "{~prompty.if eval=\"a\"~}\n" +
+				"  {~prompty.switch eval=\"v\"~}\n" +
+				"{~prompty.case value=\"x\"~}\n" +
+				" X\n" +
+				"{~/prompty.case~}\n" +
+				"\t\t{~prompty.casedefault~}D{~/prompty.casedefault~}\n" +
+				"      {~/prompty.switch~}\n" +
+				"{~/prompty.if~}\n",
+			expected: "{~prompty.if eval=\"a\"~}\n" +
+				"  {~prompty.switch eval=\"v\"~}\n" +
+				"    {~prompty.case value=\"x\"~}\n" +
+				" X\n" +
+				"{~/prompty.case~}\n" +
+				"    {~prompty.casedefault~}D{~/prompty.casedefault~}\n" +
+				"  {~/prompty.switch~}\n" +
+				"{~/prompty.if~}\n",
+		},
+		{
+			name: "child template top-level tags unindented",
+			source: "  {~prompty.extends template=\"base\" /~}\n" +
+				"    {~prompty.block name=\"body\"~}\n" +
+				"  kept\n" +
+				"  {~/prompty.block~}\n",
+			expected: "{~prompty.extends template=\"base\" /~}\n" +
+				"{~prompty.block name=\"body\"~}\n" +
+				"  kept\n" +
+				"  {~/prompty.block~}\n",
+		},
+		{
+			name:     "inline block tags unchanged",
+			source:   "{~prompty.if eval=\"a\"~}\nA {~prompty.if eval=\"b\"~}B{~/prompty.if~}\n{~/prompty.if~}",
+			expected: "{~prompty.if eval=\"a\"~}\nA {~prompty.if eval=\"b\"~}B{~/prompty.if~}\n{~/prompty.if~}",
+		},
+		{
+			name:     "raw body preserved verbatim",
+			source:   "{~prompty.if eval=\"a\"~}\n{~prompty.raw~}\n{~ keep   this='x' ~}\n{~/prompty.raw~}\n{~/prompty.if~}",
+			expected: "{~prompty.if eval=\"a\"~}\n{~prompty.raw~}\n{~ keep   this='x' ~}\n{~/prompty.raw~}\n{~/prompty.if~}",
+		},
+		{
+			name:     "comment body preserved verbatim",
+			source:   "{~prompty.comment~} {~broken {~/prompty.comment~}",
+			expected: "{~prompty.comment~} {~broken {~/prompty.comment~}",
+		},
+		{
+			name:     "frontmatter keys reordered",
+			source:   "---\ndescription: Greets users\ninputs:\n  zeta:\n    type: string\n  alpha:\n    type: string\nname: greeter\ncustom_key: 1\n---\nHello\n",
+			expected: "---\nname: greeter\ndescription: Greets users\ninputs:\n  alpha:\n    type: string\n  zeta:\n    type: string\ncustom_key: 1\n---\nHello\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatted, err := Format(tt.source)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, formatted)
+
+			again, err := Format(formatted)
+			require.NoError(t, err)
+			assert.Equal(t, formatted, again, "Format must be idempotent")
+		})
+	}
+}
+
+func TestFormat_PreservesRenderedOutput(t *testing.T) {
+	source := `Hi {~prompty.var default='friend' name="user"/~}! {~prompty.if eval='count > 1'~}{~prompty.for in="items" item="x"~}[{~prompty.var name="x" /~}]{~/prompty.for~}{~/prompty.if~} \{~literal`
+	data := map[string]any{"count": 2, "items": []any{"a", "b"}}
+
+	formatted, err := Format(source)
+	require.NoError(t, err)
+
+	engine := MustNew()
+	want, err := engine.Execute(context.Background(), source, data)
+	require.NoError(t, err)
+	got, err := engine.Execute(context.Background(), formatted, data)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestFormat_PreservesRenderedOutputOfIndentedTags(t *testing.T) {
+	engine := MustNew()
+	engine.MustRegisterTemplate("base", `[{~prompty.block name="body"~}default{~/prompty.block~}]`)
+	sources := []string{
+		"{~prompty.switch eval=\"v\"~}\n\t{~prompty.case value=\"x\"~}\n X\n{~/prompty.case~}\n {~prompty.casedefault~}D{~/prompty.casedefault~}\n   {~/prompty.switch~}|",
+		"  {~prompty.extends template=\"base\" /~}\n   {~prompty.block name=\"body\"~}\n  kept\n  {~/prompty.block~}\n",
+	}
+	for _, source := range sources {
+		formatted, err := Format(source)
+		require.NoError(t, err)
+		require.NotEqual(t, source, formatted)
+
+		want, err := engine.Execute(context.Background(), source, map[string]any{"v": "x"})
+		require.NoError(t, err)
+		got, err := engine.Execute(context.Background(), formatted, map[string]any{"v": "x"})
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+}
+
+func TestFormat_PreservesRenderedOutputProperty(t *testing.T) {
+	data := map[string]any{
+		"user":  "Alice",
+		"flag":  true,
+		"off":   false,
+		"items": []any{"a", "b", "c"},
+	}
+	engine := MustNew()
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for i := 0; i < 500; i++ {
+		source := genFormatTemplate(rng, 0)
+
+		formatted, err := Format(source)
+		require.NoError(t, err, "source: %q", source)
+
+		want, err := engine.Execute(context.Background(), source, data)
+		require.NoError(t, err, "source: %q", source)
+		got, err := engine.Execute(context.Background(), formatted, data)
+		require.NoError(t, err, "formatted: %q", formatted)
+		require.Equal(t, want, got, "source: %q\nformatted: %q", source, formatted)
+
+		again, err := Format(formatted)
+		require.NoError(t, err)
+		require.Equal(t, formatted, again, "Format must be idempotent")
+	}
+}
+
+// genFormatTemplate generates a random template body mixing whitespace-heavy
+// text with nested block tags and inconsistently written attributes.
+func genFormatTemplate(rng *rand.Rand, depth int) string {
+	texts := []string{"world", "\n", "\t", "  ", "\n\t ", " a b ", "\\{~lit", "x\n  "}
+	quote := func(v string) string {
+		if rng.IntN(2) == 0 {
+			return "'" + v + "'"
+		}
+		return `"` + v + `"`
+	}
+	space := func() string { return []string{"", " ", "  "}[rng.IntN(3)] }
+
+	var sb strings.Builder
+	for n := rng.IntN(5) + 1; n > 0; n-- {
+		choice := rng.IntN(8)
+		if depth >= 3 && choice >= 3 {
+			choice = 0
+		}
+		switch choice {
+		case 0, 1:
+			sb.WriteString(texts[rng.IntN(len(texts))])
+		case 2:
+			if rng.IntN(2) == 0 {
+				sb.WriteString(`{~prompty.var default=` + quote("guest") + ` name=` + quote("user") + space() + `/~}`)
+			} else {
+				sb.WriteString(`{~prompty.var name=` + quote("missing") + ` default=` + quote("none") + ` /~}`)
+			}
+		case 3:
+			cond := []string{"flag", "off"}[rng.IntN(2)]
+			sb.WriteString(`{~prompty.if eval=` + quote(cond) + space() + `~}`)
+			sb.WriteString(genFormatTemplate(rng, depth+1))
+			if rng.IntN(2) == 0 {
+				sb.WriteString(`{~prompty.else~}`)
+				sb.WriteString(genFormatTemplate(rng, depth+1))
+			}
+			sb.WriteString(`{~/` + space() + `prompty.if` + space() + `~}`)
+		case 4:
+			sb.WriteString(`{~prompty.for in=` + quote("items") + ` item=` + quote("x") + `~}`)
+			sb.WriteString(genFormatTemplate(rng, depth+1))
+			sb.WriteString(`{~prompty.var name="x" /~}{~/prompty.for~}`)
+		case 5:
+			sb.WriteString("{~prompty.raw~}\t{~ keep  this ~}\n{~/prompty.raw~}")
+		case 6:
+			if rng.IntN(2) == 0 {
+				sb.WriteString("{~prompty.comment~} note {~/prompty.comment~}")
+				break
+			}
+			ws := func() string { return texts[1+rng.IntN(4)] }
+			sb.WriteString(`{~prompty.switch eval=` + quote("user") + `~}` + ws())
+			sb.WriteString(`{~prompty.case value="Alice"~}` + genFormatTemplate(rng, depth+1) + `{~/prompty.case~}` + ws())
+			sb.WriteString(`{~prompty.casedefault~}` + genFormatTemplate(rng, depth+1) + `{~/prompty.casedefault~}` + ws())
+			sb.WriteString(`{~/prompty.switch~}`)
+		default:
+			sb.WriteString(texts[rng.IntN(len(texts))] + texts[rng.IntN(len(texts))])
+		}
+	}
+	return sb.String()
+}
+
+func TestFormat_Errors(t *testing.T) {
+	t.Run("unclosed block", func(t *testing.T) {
+		_, err := Format(`{~prompty.if eval="x"~}unclosed`)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMsgParseFailed)
+	})
+
+	t.Run("invalid frontmatter", func(t *testing.T) {
+		_, err := Format("---\nname: [unclosed\n---\nbody")
+		require.Error(t, err)
+	})
+}
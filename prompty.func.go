@@ -45,6 +45,9 @@ func (e *Engine) RegisterFunc(f *Func) error {
 	if f.Name == "" {
 		return NewFuncRegistrationError(ErrMsgFuncEmptyName, "")
 	}
+	if e.snapshot {
+		return NewSnapshotReadOnlyError()
+	}
 
 	// Convert to internal Func
 	internalFunc := &internal.Func{
@@ -0,0 +1,71 @@
+package prompty
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// Content hash format
+const (
+	// ContentHashPrefix prefixes every content hash with its algorithm.
+	ContentHashPrefix = "sha256:"
+
+	contentHashSeparator = "\n---\n"
+	contentHashTrimSet   = " \t\r\n"
+)
+
+// Hash returns the canonical content hash of the prompt: a SHA-256 over its
+// frontmatter as canonical JSON (sorted keys, with the effective document
+// type) and its body with normalized line endings and without trailing
+// whitespace. Formatting differences such as YAML key order, quoting or
+// JSON frontmatter do not change the hash, so it identifies exactly which
+// prompt content was used, across storage, exports and compilations.
+func (p *Prompt) Hash() string {
+	if p == nil {
+		return ""
+	}
+	frontmatter := p.Clone()
+	frontmatter.Type = p.EffectiveType()
+	canonical, err := json.Marshal(frontmatter)
+	if err != nil {
+		// Only values JSON cannot encode end up here; fall back to the
+		// body alone rather than fail.
+		canonical = nil
+	}
+	return hashContent(string(canonical) + contentHashSeparator + normalizeContent(p.Body))
+}
+
+// ContentHash returns the canonical content hash of a template source, as
+// stored in StoredTemplate.ContentHash. Prompt documents hash like
+// Prompt.Hash; sources that do not parse as documents hash their text with
+// normalized line endings.
+func ContentHash(source string) string {
+	if prompt, err := Parse([]byte(source)); err == nil {
+		return prompt.Hash()
+	}
+	return hashContent(normalizeContent(source))
+}
+
+// storedContentHash returns the content hash of a stored template,
+// computing it for storage backends that do not set ContentHash.
+func storedContentHash(tmpl *StoredTemplate) string {
+	if tmpl.ContentHash != "" {
+		return tmpl.ContentHash
+	}
+	return ContentHash(tmpl.Source)
+}
+
+// normalizeContent converts line endings to "\n" and removes trailing
+// whitespace.
+func normalizeContent(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.TrimRight(s, contentHashTrimSet)
+}
+
+// hashContent returns the prefixed SHA-256 hex digest of s.
+func hashContent(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return ContentHashPrefix + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,66 @@
+package prompty
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrompt_Hash(t *testing.T) {
+	yamlDoc := "---\nname: greeter\ndescription: Greets users\nmetadata:\n  version: 1.0.0\n  team: support\n---\nHello {~prompty.var name=\"user\" /~}!\n"
+	reordered := "---\r\ndescription: 'Greets users'\r\nmetadata:\r\n  team: support\r\n  version: 1.0.0\r\nname: greeter\r\n---\r\nHello {~prompty.var name=\"user\" /~}!\r\n\r\n"
+	jsonDoc := `{"name": "greeter", "description": "Greets users", "type": "skill", "metadata": {"team": "support", "version": "1.0.0"}, "body": "Hello {~prompty.var name=\"user\" /~}!"}`
+
+	p, err := Parse([]byte(yamlDoc))
+	require.NoError(t, err)
+	hash := p.Hash()
+	assert.True(t, strings.HasPrefix(hash, ContentHashPrefix))
+	assert.Len(t, hash, len(ContentHashPrefix)+64)
+
+	for name, doc := range map[string]string{"reordered": reordered, "json": jsonDoc} {
+		other, err := Parse([]byte(doc))
+		require.NoError(t, err, name)
+		assert.Equal(t, hash, other.Hash(), name)
+		assert.Equal(t, hash, ContentHash(doc), name)
+	}
+
+	exported, err := p.ExportFull()
+	require.NoError(t, err)
+	assert.Equal(t, hash, ContentHash(string(exported)), "stable across export")
+
+	changed := p.Clone()
+	changed.Body = "Hi {~prompty.var name=\"user\" /~}!"
+	assert.NotEqual(t, hash, changed.Hash())
+	changed = p.Clone()
+	changed.Metadata[PromptMetadataVersion] = "1.0.1"
+	assert.NotEqual(t, hash, changed.Hash())
+
+	assert.Empty(t, (*Prompt)(nil).Hash())
+	assert.Equal(t, ContentHash("plain text\n"), ContentHash("plain text"))
+}
+
+func TestStoredTemplate_ContentHash(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	source := "---\nname: greeter\ndescription: Greets users\n---\nHello!"
+	tmpl := &StoredTemplate{Name: "greeter", Source: source}
+	require.NoError(t, storage.Save(ctx, tmpl))
+	assert.Equal(t, ContentHash(source), tmpl.ContentHash)
+
+	stored, err := storage.Get(ctx, "greeter")
+	require.NoError(t, err)
+	assert.Equal(t, tmpl.ContentHash, stored.ContentHash)
+
+	event := NewAccessAuditEvent(OpExecute, "", nil, nil).WithTemplate(stored)
+	assert.Equal(t, stored.ContentHash, event.ContentHash)
+
+	engine, err := NewStorageEngine(StorageEngineConfig{Storage: storage})
+	require.NoError(t, err)
+	history, err := engine.GetVersionHistory(ctx, "greeter")
+	require.NoError(t, err)
+	assert.Equal(t, stored.ContentHash, history.Versions[0].ContentHash)
+	assert.Contains(t, history.String(), "Hash: "+stored.ContentHash)
+}
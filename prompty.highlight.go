@@ -0,0 +1,49 @@
+package prompty
+
+import (
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// Token is a classified span of template source returned by Tokenize.
+type Token struct {
+	Kind  TokenKind
+	Value string   // Source text of the span
+	Start Position // Position of the first byte
+	End   Position // Position just past the last byte
+}
+
+// Tokenize splits template source into tokens for syntax highlighting in
+// editors and web UIs, using the default delimiters.
+//
+// Tokens are contiguous and cover the whole source, so concatenating their
+// values reproduces it exactly. Tokenize never fails: malformed or partially
+// typed tags yield TokenKindInvalid tokens and tokenizing continues.
+//
+// Example:
+//
+//	for _, tok := range prompty.Tokenize(source) {
+//	    fmt.Printf("%s %q at %s\n", tok.Kind, tok.Value, tok.Start)
+//	}
+func Tokenize(source string) []Token {
+	return tokenize(source, internal.DefaultLexerConfig())
+}
+
+// Tokenize is like the package-level Tokenize but uses the engine's delimiters.
+func (e *Engine) Tokenize(source string) []Token {
+	return tokenize(source, e.config.lexerConfig())
+}
+
+// tokenize converts the lexer's highlight spans into public tokens.
+func tokenize(source string, config internal.LexerConfig) []Token {
+	spans := internal.NewLexerWithConfig(source, config, nil).Highlight()
+	tokens := make([]Token, 0, len(spans))
+	for _, span := range spans {
+		tokens = append(tokens, Token{
+			Kind:  TokenKind(span.Kind),
+			Value: source[span.Start.Offset:span.End.Offset],
+			Start: Position{Offset: span.Start.Offset, Line: span.Start.Line, Column: span.Start.Column},
+			End:   Position{Offset: span.End.Offset, Line: span.End.Line, Column: span.End.Column},
+		})
+	}
+	return tokens
+}
@@ -0,0 +1,236 @@
+package prompty
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenSummary is a compact kind/value pair for assertions
+type tokenSummary struct {
+	Kind  TokenKind
+	Value string
+}
+
+func summarize(tokens []Token) []tokenSummary {
+	out := make([]tokenSummary, 0, len(tokens))
+	for _, tok := range tokens {
+		out = append(out, tokenSummary{tok.Kind, tok.Value})
+	}
+	return out
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected []tokenSummary
+	}{
+		{
+			name:   "text and self-closing tag",
+			source: `Hi {~prompty.var name="user" /~}!`,
+			expected: []tokenSummary{
+				{TokenKindText, "Hi "},
+				{TokenKindTagOpen, "{~"},
+				{TokenKindTagName, "prompty.var"},
+				{TokenKindWhitespace, " "},
+				{TokenKindAttribute, "name"},
+				{TokenKindEquals, "="},
+				{TokenKindString, `"user"`},
+				{TokenKindWhitespace, " "},
+				{TokenKindTagClose, "/~}"},
+				{TokenKindText, "!"},
+			},
+		},
+		{
+			name:   "eval is an expression",
+			source: `{~prompty.if eval='len(x) > 0'~}y{~/prompty.if~}`,
+			expected: []tokenSummary{
+				{TokenKindTagOpen, "{~"},
+				{TokenKindTagName, "prompty.if"},
+				{TokenKindWhitespace, " "},
+				{TokenKindAttribute, "eval"},
+				{TokenKindEquals, "="},
+				{TokenKindExpression, "'len(x) > 0'"},
+				{TokenKindTagClose, "~}"},
+				{TokenKindText, "y"},
+				{TokenKindTagOpen, "{~/"},
+				{TokenKindTagName, "prompty.if"},
+				{TokenKindTagClose, "~}"},
+			},
+		},
+		{
+			name:   "comment body",
+			source: `{~prompty.comment~}note {~x~}{~/prompty.comment~}`,
+			expected: []tokenSummary{
+				{TokenKindTagOpen, "{~"},
+				{TokenKindTagName, "prompty.comment"},
+				{TokenKindTagClose, "~}"},
+				{TokenKindComment, "note {~x~}"},
+				{TokenKindTagOpen, "{~/"},
+				{TokenKindTagName, "prompty.comment"},
+				{TokenKindTagClose, "~}"},
+			},
+		},
+		{
+			name:   "raw body is text",
+			source: `{~prompty.raw~}{~a~}{~/prompty.raw~}`,
+			expected: []tokenSummary{
+				{TokenKindTagOpen, "{~"},
+				{TokenKindTagName, "prompty.raw"},
+				{TokenKindTagClose, "~}"},
+				{TokenKindText, "{~a~}"},
+				{TokenKindTagOpen, "{~/"},
+				{TokenKindTagName, "prompty.raw"},
+				{TokenKindTagClose, "~}"},
+			},
+		},
+		{
+			name:   "escape",
+			source: `a \{~ b`,
+			expected: []tokenSummary{
+				{TokenKindText, "a "},
+				{TokenKindEscape, `\{~`},
+				{TokenKindText, " b"},
+			},
+		},
+		{
+			name:   "escaped quote inside value",
+			source: `{~t a="x\"y"~}`,
+			expected: []tokenSummary{
+				{TokenKindTagOpen, "{~"},
+				{TokenKindTagName, "t"},
+				{TokenKindWhitespace, " "},
+				{TokenKindAttribute, "a"},
+				{TokenKindEquals, "="},
+				{TokenKindString, `"x\"y"`},
+				{TokenKindTagClose, "~}"},
+			},
+		},
+		{
+			name:   "frontmatter",
+			source: "---\nname: x\n---\nBody",
+			expected: []tokenSummary{
+				{TokenKindFrontmatter, "---\nname: x\n---\n"},
+				{TokenKindText, "Body"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, summarize(Tokenize(tt.source)))
+		})
+	}
+}
+
+func TestTokenize_Malformed(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected []tokenSummary
+	}{
+		{
+			name:   "unterminated value stops at close delimiter",
+			source: `{~prompty.var name="us /~} after`,
+			expected: []tokenSummary{
+				{TokenKindTagOpen, "{~"},
+				{TokenKindTagName, "prompty.var"},
+				{TokenKindWhitespace, " "},
+				{TokenKindAttribute, "name"},
+				{TokenKindEquals, "="},
+				{TokenKindString, `"us `},
+				{TokenKindTagClose, "/~}"},
+				{TokenKindText, " after"},
+			},
+		},
+		{
+			name:   "unterminated tag ends at next tag",
+			source: `{~prompty.v {~prompty.else~}`,
+			expected: []tokenSummary{
+				{TokenKindTagOpen, "{~"},
+				{TokenKindTagName, "prompty.v"},
+				{TokenKindWhitespace, " "},
+				{TokenKindTagOpen, "{~"},
+				{TokenKindTagName, "prompty.else"},
+				{TokenKindTagClose, "~}"},
+			},
+		},
+		{
+			name:   "invalid content",
+			source: `{~ 9x a=1 ~}`,
+			expected: []tokenSummary{
+				{TokenKindTagOpen, "{~"},
+				{TokenKindWhitespace, " "},
+				{TokenKindInvalid, "9x"},
+				{TokenKindWhitespace, " "},
+				{TokenKindAttribute, "a"},
+				{TokenKindEquals, "="},
+				{TokenKindInvalid, "1"},
+				{TokenKindWhitespace, " "},
+				{TokenKindTagClose, "~}"},
+			},
+		},
+		{
+			name:     "open delimiter at end",
+			source:   `text {~`,
+			expected: []tokenSummary{{TokenKindText, "text "}, {TokenKindTagOpen, "{~"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, summarize(Tokenize(tt.source)))
+		})
+	}
+}
+
+func TestTokenize_PositionsAndCoverage(t *testing.T) {
+	source := "Line one\n{~prompty.for item=\"x\" in=\"items\"~}\n  - {~prompty.var name=\"x\" /~}\n{~/prompty.for~}"
+	tokens := Tokenize(source)
+
+	var sb strings.Builder
+	offset := 0
+	for _, tok := range tokens {
+		assert.Equal(t, offset, tok.Start.Offset, "tokens must be contiguous")
+		assert.Equal(t, source[tok.Start.Offset:tok.End.Offset], tok.Value)
+		offset = tok.End.Offset
+		sb.WriteString(tok.Value)
+	}
+	assert.Equal(t, source, sb.String())
+
+	var varName Token
+	for _, tok := range tokens {
+		if tok.Kind == TokenKindTagName && tok.Value == TagNameVar {
+			varName = tok
+		}
+	}
+	assert.Equal(t, 3, varName.Start.Line)
+	assert.Equal(t, 7, varName.Start.Column)
+	assert.Equal(t, 18, varName.End.Column)
+}
+
+func TestEngine_Tokenize_CustomDelimiters(t *testing.T) {
+	engine, err := New(WithDelimiters("<<", ">>"))
+	require.NoError(t, err)
+
+	tokens := summarize(engine.Tokenize(`a <<prompty.var name="x" />> {~b~}`))
+	assert.Equal(t, []tokenSummary{
+		{TokenKindText, "a "},
+		{TokenKindTagOpen, "<<"},
+		{TokenKindTagName, "prompty.var"},
+		{TokenKindWhitespace, " "},
+		{TokenKindAttribute, "name"},
+		{TokenKindEquals, "="},
+		{TokenKindString, `"x"`},
+		{TokenKindWhitespace, " "},
+		{TokenKindTagClose, "/>>"},
+		{TokenKindText, " {~b~}"},
+	}, tokens)
+}
+
+func TestTokenize_Empty(t *testing.T) {
+	assert.Empty(t, Tokenize(""))
+}
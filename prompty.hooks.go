@@ -2,6 +2,7 @@ package prompty
 
 import (
 	"context"
+	"slices"
 	"sync"
 )
 
@@ -39,6 +40,18 @@ const (
 
 	// HookAfterValidate is called after template validation.
 	HookAfterValidate HookPoint = "after_validate"
+
+	// HookBeforeParse is called before an Engine parses a template source.
+	HookBeforeParse HookPoint = "before_parse"
+
+	// HookAfterParse is called after an Engine parsed a template (success or failure).
+	HookAfterParse HookPoint = "after_parse"
+
+	// HookBeforeResolve is called before a resolver renders a tag.
+	HookBeforeResolve HookPoint = "before_resolve"
+
+	// HookAfterResolve is called after a resolver rendered a tag (success or failure).
+	HookAfterResolve HookPoint = "after_resolve"
 )
 
 // Hook is a function called at specific points during template operations.
@@ -72,6 +85,20 @@ type HookData struct {
 	// ValidationResult contains validation results (for after_validate).
 	ValidationResult *ValidationResult
 
+	// Source is the template source (for parse operations). Before-parse
+	// hooks may rewrite it.
+	Source string
+
+	// ParsedTemplate is the parsed template (for after_parse and execute
+	// operations on an Engine).
+	ParsedTemplate *Template
+
+	// TagName is the name of the tag being resolved (for resolve operations).
+	TagName string
+
+	// Attributes are the attributes of the tag being resolved (for resolve operations).
+	Attributes Attributes
+
 	// Metadata allows hooks to pass data to each other.
 	Metadata map[string]any
 }
@@ -177,6 +204,19 @@ func (r *HookRegistry) ClearAll() {
 	r.hooks = make(map[HookPoint][]Hook)
 }
 
+// Clone returns a registry with the same hooks. Hooks registered with or
+// cleared from either registry later do not affect the other.
+func (r *HookRegistry) Clone() *HookRegistry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	clone := NewHookRegistry()
+	for point, hooks := range r.hooks {
+		clone.hooks[point] = slices.Clone(hooks)
+	}
+	return clone
+}
+
 // Run executes all hooks for the specified point.
 // For "before" hooks, the first error stops execution and returns the error.
 // For "after" hooks, all hooks are executed and errors are collected.
@@ -241,7 +281,8 @@ func (r *HookRegistry) HasHooks(point HookPoint) bool {
 // isBeforeHook checks if a hook point is a "before" hook.
 func isBeforeHook(point HookPoint) bool {
 	switch point {
-	case HookBeforeLoad, HookBeforeExecute, HookBeforeSave, HookBeforeDelete, HookBeforeValidate:
+	case HookBeforeLoad, HookBeforeExecute, HookBeforeSave, HookBeforeDelete, HookBeforeValidate,
+		HookBeforeParse, HookBeforeResolve:
 		return true
 	default:
 		return false
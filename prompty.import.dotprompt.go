@@ -0,0 +1,701 @@
+package prompty
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Microsoft Prompty (.prompty) format constants
+const (
+	FileExtensionPrompty = ".prompty"
+
+	dotpromptKeyModel         = "model"
+	dotpromptKeyConfiguration = "configuration"
+	dotpromptKeyResponse      = "response"
+	dotpromptKeyType          = "type"
+	dotpromptKeyName          = "name"
+	dotpromptKeyDescription   = "description"
+	dotpromptKeyAuthors       = "authors"
+	dotpromptKeyTags          = "tags"
+	dotpromptKeyVersion       = "version"
+	dotpromptKeySample        = "sample"
+	dotpromptKeyTemplate      = "template"
+	dotpromptKeyFormat        = "format"
+	dotpromptKeyDefault       = "default"
+	dotpromptKeyRequired      = "required"
+
+	dotpromptKeyAzureDeployment = "azure_deployment"
+	dotpromptKeyAPIVersion      = "api_version"
+
+	dotpromptAPIChat        = "chat"
+	dotpromptResponseFirst  = "first"
+	dotpromptTypeOpenAI     = "openai"
+	dotpromptTypeAzure      = "azure_openai"
+	dotpromptTypeAzureShort = "azure"
+	dotpromptFormatJinja2   = "jinja2"
+	dotpromptFormatMustache = "mustache"
+	dotpromptRoleDeveloper  = "developer"
+	dotpromptRoleFunction   = "function"
+	dotpromptFieldBody      = "body"
+)
+
+// Error and warning messages for .prompty conversion
+const (
+	ErrMsgDotpromptMissingFrontmatter = ".prompty file missing frontmatter"
+	ErrMsgDotpromptNilPrompt          = "cannot export a nil prompt to .prompty"
+
+	WarnMsgDotpromptConnection       = "connection setting %q is not part of an execution config and was dropped"
+	WarnMsgDotpromptResponseAll      = "response %q is not supported; only the first choice is returned"
+	WarnMsgDotpromptTemplateFormat   = "template format %q is not converted; the body is kept as is"
+	WarnMsgDotpromptSampleFile       = "sample file references are not loaded"
+	WarnMsgDotpromptRoleMapped       = "role %q is converted to %q"
+	WarnMsgDotpromptRoleAttributes   = "role attributes %s are dropped"
+	WarnMsgDotpromptTemplateSyntax   = "template construct %q is not converted"
+	WarnMsgDotpromptProvider         = "provider %q has no Prompty connection type; it is exported as type %q"
+	WarnMsgDotpromptUnsupportedField = "%s are not supported by .prompty and were dropped"
+	WarnMsgDotpromptPromptyTag       = "prompty tag %q has no Prompty equivalent and is kept as is"
+)
+
+var (
+	// dotpromptRoleLine matches a Prompty role marker line such as
+	// "system:" or "user[name=\"bob\"]:".
+	dotpromptRoleLine = regexp.MustCompile(`(?im)^[ \t]*#?[ \t]*(system|user|assistant|developer|function|tool)[ \t]*(\[[^\]\n]*\])?[ \t]*:[ \t]*\r?$`)
+	// dotpromptVariable matches a plain Jinja2/Mustache variable.
+	dotpromptVariable = regexp.MustCompile(`\{\{-?\s*([A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)*)\s*-?\}\}`)
+	// dotpromptConstruct matches any other Jinja2 expression, statement or comment.
+	dotpromptConstruct = regexp.MustCompile(`(?s)\{\{.*?\}\}|\{%.*?%\}|\{#.*?#\}`)
+
+	// promptyVarTag matches a prompty.var tag with a name and optional default.
+	promptyVarTag = regexp.MustCompile(regexp.QuoteMeta(DefaultOpenDelim) + `\s*` + regexp.QuoteMeta(TagNameVar) +
+		`\s+` + AttrName + `="([^"]+)"(?:\s+` + AttrDefault + `="([^"]*)")?\s*/` + regexp.QuoteMeta(DefaultCloseDelim))
+	// promptyMessageOpenTag matches an opening prompty.message tag.
+	promptyMessageOpenTag = regexp.MustCompile(`[ \t]*` + regexp.QuoteMeta(DefaultOpenDelim) + `\s*` + regexp.QuoteMeta(TagNameMessage) +
+		`\s+` + AttrRole + `="([^"]+)"[^~]*` + regexp.QuoteMeta(DefaultCloseDelim) + `\r?\n?`)
+	// promptyMessageCloseTag matches a closing prompty.message tag.
+	promptyMessageCloseTag = regexp.MustCompile(`[ \t]*` + regexp.QuoteMeta(DefaultOpenDelim) + `\s*/` + regexp.QuoteMeta(TagNameMessage) +
+		`\s*` + regexp.QuoteMeta(DefaultCloseDelim) + `\r?\n?`)
+	// promptyAnyTag matches any remaining prompty tag.
+	promptyAnyTag = regexp.MustCompile(`(?s)` + regexp.QuoteMeta(DefaultOpenDelim) + `.*?` + regexp.QuoteMeta(DefaultCloseDelim))
+)
+
+// dotpromptFile is the Microsoft Prompty frontmatter schema.
+type dotpromptFile struct {
+	Name        string          `yaml:"name,omitempty"`
+	Description string          `yaml:"description,omitempty"`
+	Authors     []string        `yaml:"authors,omitempty"`
+	Tags        []string        `yaml:"tags,omitempty"`
+	Version     string          `yaml:"version,omitempty"`
+	Model       *dotpromptModel `yaml:"model,omitempty"`
+	Sample      any             `yaml:"sample,omitempty"`
+	Inputs      map[string]any  `yaml:"inputs,omitempty"`
+	Outputs     map[string]any  `yaml:"outputs,omitempty"`
+	Template    any             `yaml:"template,omitempty"`
+	Extensions  map[string]any  `yaml:",inline"`
+}
+
+// dotpromptModel is the model section of a .prompty file.
+type dotpromptModel struct {
+	API           string         `yaml:"api,omitempty"`
+	Configuration map[string]any `yaml:"configuration,omitempty"`
+	Parameters    map[string]any `yaml:"parameters,omitempty"`
+	Response      string         `yaml:"response,omitempty"`
+}
+
+// ImportDotprompt converts a Microsoft Prompty (.prompty) file into a
+// prompt. The model configuration becomes the execution config, inputs and
+// outputs their definitions, role marker lines ("system:", "user:") become
+// prompty.message tags and plain {{variable}} references prompty.var tags.
+// Everything that could not be carried over exactly is listed in the
+// result's Report.
+func ImportDotprompt(data []byte) (*ImportResult, error) {
+	fm, body, ok := splitDotpromptDocument(string(data))
+	if !ok {
+		return nil, NewFrontmatterError(ErrMsgDotpromptMissingFrontmatter, Position{Line: 1, Column: 1}, nil)
+	}
+	if len(fm) > DefaultMaxFrontmatterSize {
+		return nil, NewFrontmatterError(ErrMsgFrontmatterTooLarge, Position{Line: 1, Column: 1}, nil)
+	}
+
+	var file dotpromptFile
+	if err := yaml.Unmarshal([]byte(fm), &file); err != nil {
+		return nil, NewFrontmatterParseError(err)
+	}
+
+	report := &ConversionReport{}
+	prompt := &Prompt{
+		Type:       DocumentTypePrompt,
+		Extensions: file.Extensions,
+	}
+	prompt.Name, prompt.Description = importIdentity(file.Name, file.Description, report)
+	prompt.Metadata = dotpromptMetadata(&file)
+
+	if file.Model != nil {
+		prompt.Execution = importDotpromptModel(file.Model, report)
+	}
+	prompt.Inputs, prompt.Sample = importDotpromptInputs(file.Inputs)
+	prompt.Outputs = importDotpromptOutputs(file.Outputs)
+
+	switch sample := file.Sample.(type) {
+	case nil:
+	case map[string]any:
+		if prompt.Sample == nil {
+			prompt.Sample = make(map[string]any, len(sample))
+		}
+		for k, v := range sample {
+			prompt.Sample[k] = v
+		}
+	default:
+		report.add(dotpromptKeySample, WarnMsgDotpromptSampleFile)
+	}
+
+	prompt.Body = importDotpromptBody(body, dotpromptTemplateFormat(file.Template), report)
+
+	if err := prompt.Validate(); err != nil {
+		return nil, err
+	}
+	return &ImportResult{
+		Prompt:    prompt,
+		Resources: make(map[string][]byte),
+		Report:    report,
+	}, nil
+}
+
+// ExportDotprompt converts a prompt into a Microsoft Prompty (.prompty)
+// file. The execution config is written as model.parameters in OpenAI
+// format; agent fields, extensions, settings OpenAI does not take and
+// prompty tags other than prompty.var and prompty.message are listed in the
+// returned report.
+func ExportDotprompt(prompt *Prompt) ([]byte, *ConversionReport, error) {
+	if prompt == nil {
+		return nil, nil, NewCompilationError(ErrMsgDotpromptNilPrompt, nil)
+	}
+
+	report := &ConversionReport{}
+	file := dotpromptFile{
+		Name:        prompt.Name,
+		Description: prompt.Description,
+		Inputs:      exportDotpromptInputs(prompt.Inputs),
+		Outputs:     exportDotpromptOutputs(prompt.Outputs),
+		Template:    dotpromptFormatJinja2,
+	}
+	if len(prompt.Sample) > 0 {
+		file.Sample = prompt.Sample
+	}
+	file.Authors = stringSlice(prompt.Metadata[dotpromptKeyAuthors])
+	file.Tags = stringSlice(prompt.Metadata[dotpromptKeyTags])
+	if version, ok := prompt.Metadata[dotpromptKeyVersion].(string); ok {
+		file.Version = version
+	}
+	if prompt.Execution != nil {
+		file.Model = exportDotpromptModel(prompt.Execution, report)
+	}
+
+	var dropped []string
+	if len(prompt.Skills) > 0 {
+		dropped = append(dropped, PromptFieldSkills)
+	}
+	if prompt.Tools != nil {
+		dropped = append(dropped, PromptFieldTools)
+	}
+	if len(prompt.Context) > 0 {
+		dropped = append(dropped, PromptFieldContext)
+	}
+	if prompt.Constraints != nil {
+		dropped = append(dropped, PromptFieldConstraints)
+	}
+	if len(prompt.Messages) > 0 {
+		dropped = append(dropped, PromptFieldMessages)
+	}
+	for _, field := range dropped {
+		report.add(field, fmt.Sprintf(WarnMsgDotpromptUnsupportedField, field))
+	}
+	for _, key := range sortedKeys(prompt.Extensions) {
+		report.add(key, fmt.Sprintf(WarnMsgDotpromptUnsupportedField, key))
+	}
+
+	fm, err := yaml.Marshal(file)
+	if err != nil {
+		return nil, nil, NewCompilationError(ErrMsgSerializeYAML, err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(YAMLFrontmatterDelimiter)
+	sb.WriteString("\n")
+	sb.Write(fm)
+	sb.WriteString(YAMLFrontmatterDelimiter)
+	sb.WriteString("\n")
+	sb.WriteString(exportDotpromptBody(prompt.Body, report))
+	return []byte(sb.String()), report, nil
+}
+
+// splitDotpromptDocument splits a .prompty file into frontmatter and body.
+func splitDotpromptDocument(content string) (string, string, bool) {
+	content = strings.TrimLeft(content, "\xef\xbb\xbf \t\r\n")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(content, YAMLFrontmatterDelimiter+"\n") {
+		return "", "", false
+	}
+	rest := content[len(YAMLFrontmatterDelimiter)+1:]
+	fm, body, found := strings.Cut(rest, "\n"+YAMLFrontmatterDelimiter)
+	if !found {
+		if !strings.HasPrefix(rest, YAMLFrontmatterDelimiter) {
+			return "", "", false
+		}
+		fm, body = "", rest[len(YAMLFrontmatterDelimiter):]
+	}
+	body = strings.TrimPrefix(body, "\n")
+	return fm, body, true
+}
+
+// dotpromptMetadata collects the Prompty authors, tags and version.
+func dotpromptMetadata(file *dotpromptFile) map[string]any {
+	metadata := make(map[string]any)
+	if len(file.Authors) > 0 {
+		metadata[dotpromptKeyAuthors] = file.Authors
+	}
+	if len(file.Tags) > 0 {
+		metadata[dotpromptKeyTags] = file.Tags
+	}
+	if file.Version != "" {
+		metadata[dotpromptKeyVersion] = file.Version
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
+
+// dotpromptTemplateFormat returns the template format of the template
+// field, which is either a string or an object with a format.
+func dotpromptTemplateFormat(template any) string {
+	switch t := template.(type) {
+	case string:
+		return t
+	case map[string]any:
+		if format, ok := t[dotpromptKeyFormat].(string); ok {
+			return format
+		}
+	}
+	return dotpromptFormatJinja2
+}
+
+// importDotpromptModel converts the model section into an execution config.
+// Known parameters get their typed fields; the others become provider
+// options, which the OpenAI serializers send unchanged.
+func importDotpromptModel(model *dotpromptModel, report *ConversionReport) *ExecutionConfig {
+	config := &ExecutionConfig{}
+	path := dotpromptKeyModel + "." + dotpromptKeyConfiguration + "."
+
+	for _, key := range sortedKeys(model.Configuration) {
+		value := model.Configuration[key]
+		switch key {
+		case dotpromptKeyType:
+			config.Provider, _ = value.(string)
+			switch config.Provider {
+			case dotpromptTypeAzure, dotpromptTypeAzureShort:
+				config.Provider = ProviderAzure
+			}
+		case dotpromptKeyName:
+			config.Model, _ = value.(string)
+		case dotpromptKeyAzureDeployment:
+			if deployment, _ := value.(string); deployment != "" {
+				config.Model = deployment
+			}
+		case dotpromptKeyAPIVersion:
+			config.setProviderOption(ProviderOptionAzureAPIVersion, value)
+		default:
+			report.add(path+key, fmt.Sprintf(WarnMsgDotpromptConnection, key))
+		}
+	}
+
+	for _, key := range sortedKeys(model.Parameters) {
+		value := model.Parameters[key]
+		if !importDotpromptParameter(config, key, value) {
+			config.setProviderOption(key, value)
+		}
+	}
+
+	if model.Response != "" && model.Response != dotpromptResponseFirst {
+		report.add(dotpromptKeyModel+"."+dotpromptKeyResponse, fmt.Sprintf(WarnMsgDotpromptResponseAll, model.Response))
+	}
+	return config
+}
+
+// importDotpromptParameter sets the typed field of a known parameter and
+// reports whether it did.
+func importDotpromptParameter(config *ExecutionConfig, key string, value any) bool {
+	switch key {
+	case ParamKeyTemperature:
+		f, ok := dotpromptFloat(value)
+		if ok {
+			config.Temperature = &f
+		}
+		return ok
+	case ParamKeyTopP:
+		f, ok := dotpromptFloat(value)
+		if ok {
+			config.TopP = &f
+		}
+		return ok
+	case ParamKeyMaxTokens:
+		n, ok := dotpromptInt(value)
+		if ok {
+			config.MaxTokens = &n
+		}
+		return ok
+	case ParamKeySeed:
+		n, ok := dotpromptInt(value)
+		if ok {
+			config.Seed = &n
+		}
+		return ok
+	case ParamKeyStop:
+		switch stop := value.(type) {
+		case string:
+			config.StopSequences = []string{stop}
+		default:
+			config.StopSequences = stringSlice(stop)
+		}
+		return config.StopSequences != nil
+	case ParamKeyResponseFormat:
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return false
+		}
+		var format ResponseFormat
+		if err := yaml.Unmarshal(data, &format); err != nil || format.Type == "" {
+			return false
+		}
+		config.ResponseFormat = &format
+		return true
+	}
+	return false
+}
+
+// setProviderOption sets a provider option, creating the map if needed.
+func (e *ExecutionConfig) setProviderOption(key string, value any) {
+	if e.ProviderOptions == nil {
+		e.ProviderOptions = make(map[string]any)
+	}
+	e.ProviderOptions[key] = value
+}
+
+// exportDotpromptModel converts an execution config into a model section.
+// The parameters are the OpenAI (or Azure OpenAI) request parameters, so
+// settings those serializers drop are reported.
+func exportDotpromptModel(config *ExecutionConfig, report *ConversionReport) *dotpromptModel {
+	model := &dotpromptModel{
+		API:           dotpromptAPIChat,
+		Configuration: make(map[string]any),
+	}
+
+	var params map[string]any
+	checked := ProviderOpenAI
+	switch config.Provider {
+	case ProviderAzure:
+		checked = ProviderAzure
+		model.Configuration[dotpromptKeyType] = dotpromptTypeAzure
+		model.Configuration[dotpromptKeyAzureDeployment] = config.AzureDeployment()
+		if version, ok := config.ProviderOptions[ProviderOptionAzureAPIVersion].(string); ok {
+			model.Configuration[dotpromptKeyAPIVersion] = version
+		}
+		params = config.ToAzureOpenAI()
+	case "", ProviderOpenAI:
+		model.Configuration[dotpromptKeyType] = dotpromptTypeOpenAI
+		params = config.ToOpenAI()
+	default:
+		model.Configuration[dotpromptKeyType] = config.Provider
+		report.add(dotpromptKeyModel+"."+dotpromptKeyConfiguration+"."+dotpromptKeyType,
+			fmt.Sprintf(WarnMsgDotpromptProvider, config.Provider, config.Provider))
+		params = config.ToOpenAI()
+	}
+	if config.Model != "" && config.Provider != ProviderAzure {
+		model.Configuration[dotpromptKeyName] = config.Model
+	}
+	delete(params, ParamKeyModel)
+	if len(params) > 0 {
+		model.Parameters = params
+	}
+
+	for _, issue := range CheckCompatibility(config.withoutProvider(), checked) {
+		report.add(PromptFieldExecution+"."+issue.Field, issue.Message)
+	}
+	return model
+}
+
+// withoutProvider returns a shallow copy of the config with the provider
+// cleared, so compatibility is checked against the format's provider.
+func (e *ExecutionConfig) withoutProvider() *ExecutionConfig {
+	c := *e
+	c.Provider = ""
+	return &c
+}
+
+// importDotpromptInputs converts Prompty inputs, which are either input
+// definitions or a bare sample value, into input definitions and sample
+// data.
+func importDotpromptInputs(inputs map[string]any) (map[string]*InputDef, map[string]any) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	defs := make(map[string]*InputDef, len(inputs))
+	var sample map[string]any
+	for name, value := range inputs {
+		def := &InputDef{}
+		spec, isSpec := value.(map[string]any)
+		if _, typed := spec[dotpromptKeyType]; isSpec && typed {
+			def.Type, _ = spec[dotpromptKeyType].(string)
+			def.Description, _ = spec[dotpromptKeyDescription].(string)
+			def.Required, _ = spec[dotpromptKeyRequired].(bool)
+			def.Default = spec[dotpromptKeyDefault]
+			value = spec[dotpromptKeySample]
+		} else {
+			def.Type = dotpromptValueType(value)
+		}
+		if value != nil {
+			if sample == nil {
+				sample = make(map[string]any)
+			}
+			sample[name] = value
+		}
+		defs[name] = def
+	}
+	return defs, sample
+}
+
+// importDotpromptOutputs converts Prompty outputs into output definitions.
+func importDotpromptOutputs(outputs map[string]any) map[string]*OutputDef {
+	if len(outputs) == 0 {
+		return nil
+	}
+	defs := make(map[string]*OutputDef, len(outputs))
+	for name, value := range outputs {
+		def := &OutputDef{Type: SchemaTypeString}
+		if spec, ok := value.(map[string]any); ok {
+			if t, ok := spec[dotpromptKeyType].(string); ok {
+				def.Type = t
+			}
+			def.Description, _ = spec[dotpromptKeyDescription].(string)
+		}
+		defs[name] = def
+	}
+	return defs
+}
+
+// exportDotpromptInputs converts input definitions into Prompty inputs.
+func exportDotpromptInputs(inputs map[string]*InputDef) map[string]any {
+	if len(inputs) == 0 {
+		return nil
+	}
+	result := make(map[string]any, len(inputs))
+	for name, def := range inputs {
+		if def == nil {
+			continue
+		}
+		spec := map[string]any{dotpromptKeyType: def.Type}
+		if def.Description != "" {
+			spec[dotpromptKeyDescription] = def.Description
+		}
+		if def.Required {
+			spec[dotpromptKeyRequired] = true
+		}
+		if def.Default != nil {
+			spec[dotpromptKeyDefault] = def.Default
+		}
+		result[name] = spec
+	}
+	return result
+}
+
+// exportDotpromptOutputs converts output definitions into Prompty outputs.
+func exportDotpromptOutputs(outputs map[string]*OutputDef) map[string]any {
+	if len(outputs) == 0 {
+		return nil
+	}
+	result := make(map[string]any, len(outputs))
+	for name, def := range outputs {
+		if def == nil {
+			continue
+		}
+		spec := map[string]any{dotpromptKeyType: def.Type}
+		if def.Description != "" {
+			spec[dotpromptKeyDescription] = def.Description
+		}
+		result[name] = spec
+	}
+	return result
+}
+
+// dotpromptValueType infers an input type from a sample value.
+func dotpromptValueType(value any) string {
+	switch value.(type) {
+	case bool:
+		return SchemaTypeBoolean
+	case int, int64, float64:
+		return SchemaTypeNumber
+	case []any:
+		return SchemaTypeArray
+	case map[string]any:
+		return SchemaTypeObject
+	default:
+		return SchemaTypeString
+	}
+}
+
+// importDotpromptBody converts the role markers and template syntax of a
+// Prompty body. Constructs without a prompty equivalent are kept and
+// reported.
+func importDotpromptBody(body string, format string, report *ConversionReport) string {
+	if format != dotpromptFormatJinja2 && format != dotpromptFormatMustache {
+		report.add(dotpromptKeyTemplate, fmt.Sprintf(WarnMsgDotpromptTemplateFormat, format))
+		return body
+	}
+
+	converted := convertForeignTemplate(format, body, dotpromptFieldBody, report)
+
+	markers := dotpromptRoleLine.FindAllStringSubmatchIndex(converted, -1)
+	if len(markers) == 0 {
+		return converted
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.TrimRight(converted[:markers[0][0]], " \t\r\n"))
+	for i, m := range markers {
+		role := strings.ToLower(converted[m[2]:m[3]])
+		pos := positionAt(converted, m[0])
+		switch role {
+		case dotpromptRoleDeveloper:
+			report.addAt(dotpromptFieldBody, pos, fmt.Sprintf(WarnMsgDotpromptRoleMapped, role, RoleSystem))
+			role = RoleSystem
+		case dotpromptRoleFunction:
+			report.addAt(dotpromptFieldBody, pos, fmt.Sprintf(WarnMsgDotpromptRoleMapped, role, RoleTool))
+			role = RoleTool
+		}
+		if m[4] >= 0 {
+			report.addAt(dotpromptFieldBody, pos, fmt.Sprintf(WarnMsgDotpromptRoleAttributes, converted[m[4]:m[5]]))
+		}
+
+		end := len(converted)
+		if i+1 < len(markers) {
+			end = markers[i+1][0]
+		}
+		content := strings.Trim(converted[m[1]:end], "\r\n")
+		content = strings.TrimRight(content, " \t\r\n")
+
+		if sb.Len() > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(promptyMessageBlock(role, content))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// convertDoubleBraceVariables converts plain {{variable}} references into
+// prompty.var tags. Other Jinja2 or Mustache constructs are kept and
+// reported.
+func convertDoubleBraceVariables(body, field string, report *ConversionReport) string {
+	for _, loc := range dotpromptConstruct.FindAllStringIndex(body, -1) {
+		construct := body[loc[0]:loc[1]]
+		if dotpromptVariable.FindString(construct) != construct {
+			report.addAt(field, positionAt(body, loc[0]), fmt.Sprintf(WarnMsgDotpromptTemplateSyntax, construct))
+		}
+	}
+	return dotpromptVariable.ReplaceAllString(body, promptyVarTagFor("$1"))
+}
+
+// promptyMessageBlock returns a prompty.message block of a role.
+func promptyMessageBlock(role, content string) string {
+	return DefaultOpenDelim + TagNameMessage + " " + AttrRole + `="` + role + `"` + DefaultCloseDelim + "\n" +
+		content + "\n" + DefaultOpenDelim + "/" + TagNameMessage + DefaultCloseDelim
+}
+
+// promptyVarTagFor returns the prompty.var tag of a variable.
+func promptyVarTagFor(name string) string {
+	return DefaultOpenDelim + TagNameVar + " " + AttrName + `="` + name + `" /` + DefaultCloseDelim
+}
+
+// exportDotpromptBody converts prompty.message tags into role marker lines
+// and prompty.var tags into Jinja2 variables. Other prompty tags are kept
+// and reported.
+func exportDotpromptBody(body string, report *ConversionReport) string {
+	converted := promptyMessageOpenTag.ReplaceAllString(body, "$1:\n")
+	converted = promptyMessageCloseTag.ReplaceAllString(converted, "")
+	converted = promptyVarTag.ReplaceAllStringFunc(converted, func(tag string) string {
+		m := promptyVarTag.FindStringSubmatch(tag)
+		if m[2] != "" {
+			return "{{ " + m[1] + " | default(" + fmt.Sprintf("%q", m[2]) + ") }}"
+		}
+		return "{{" + m[1] + "}}"
+	})
+	for _, loc := range promptyAnyTag.FindAllStringIndex(converted, -1) {
+		report.addAt(dotpromptFieldBody, positionAt(converted, loc[0]),
+			fmt.Sprintf(WarnMsgDotpromptPromptyTag, converted[loc[0]:loc[1]]))
+	}
+	return converted
+}
+
+// dotpromptFloat converts a decoded YAML number into a float64.
+func dotpromptFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// dotpromptInt converts a decoded YAML integer into an int.
+func dotpromptInt(value any) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), true
+		}
+	}
+	return 0, false
+}
+
+// positionAt returns the line and column of a byte offset in text.
+func positionAt(text string, offset int) Position {
+	line := 1 + strings.Count(text[:offset], "\n")
+	column := offset - strings.LastIndex(text[:offset], "\n")
+	return Position{Offset: offset, Line: line, Column: column}
+}
+
+// sortedKeys returns the keys of a map in sorted order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stringSlice converts a string list value, as decoded from YAML or set
+// in Go, into a []string. Other values give nil.
+func stringSlice(value any) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []any:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil
+			}
+			result = append(result, s)
+		}
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package prompty
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDotprompt = `---
+name: ExamplePrompt
+description: A prompt that uses context to ground an incoming question
+authors:
+  - Seth Juarez
+model:
+  api: chat
+  configuration:
+    type: azure_openai
+    azure_endpoint: ${env:AZURE_OPENAI_ENDPOINT}
+    azure_deployment: gpt-4o-mini
+    api_version: 2024-07-01-preview
+  parameters:
+    max_tokens: 3000
+    temperature: 0.2
+    stop: "###"
+    frequency_penalty: 0.5
+    response_format:
+      type: json_object
+sample:
+  firstName: Seth
+inputs:
+  firstName:
+    type: string
+    description: The customer's first name
+  question: What kind of clothing do you suggest?
+template: jinja2
+---
+
+system:
+You are an AI assistant who helps people find information.
+{% if context %}Use this context: {{ context | trim }}{% endif %}
+
+developer[name="ops"]:
+Answer briefly.
+
+user:
+{{firstName}} asks: {{ question }}
+`
+
+func TestImportDotprompt(t *testing.T) {
+	result, err := ImportDotprompt([]byte(testDotprompt))
+	require.NoError(t, err)
+	p := result.Prompt
+
+	assert.Equal(t, "example-prompt", p.Name)
+	assert.Equal(t, DocumentTypePrompt, p.Type)
+	assert.Equal(t, []string{"Seth Juarez"}, p.Metadata["authors"])
+
+	require.NotNil(t, p.Execution)
+	assert.Equal(t, ProviderAzure, p.Execution.Provider)
+	assert.Equal(t, "gpt-4o-mini", p.Execution.Model)
+	assert.Equal(t, 3000, *p.Execution.MaxTokens)
+	assert.Equal(t, 0.2, *p.Execution.Temperature)
+	assert.Equal(t, []string{"###"}, p.Execution.StopSequences)
+	assert.Equal(t, ResponseFormatJSONObject, p.Execution.ResponseFormat.Type)
+	assert.Equal(t, 0.5, p.Execution.ProviderOptions[ParamKeyFrequencyPenalty])
+	assert.Equal(t, "2024-07-01-preview", p.Execution.AzureAPIVersion())
+
+	assert.Equal(t, &InputDef{Type: SchemaTypeString, Description: "The customer's first name"}, p.Inputs["firstName"])
+	assert.Equal(t, SchemaTypeString, p.Inputs["question"].Type)
+	assert.Equal(t, map[string]any{"firstName": "Seth", "question": "What kind of clothing do you suggest?"}, p.Sample)
+
+	assert.Equal(t, `{~prompty.message role="system"~}
+You are an AI assistant who helps people find information.
+{~prompty.if eval="context"~}Use this context: {~prompty.var name="context" /~}{~/prompty.if~}
+{~/prompty.message~}
+
+{~prompty.message role="system"~}
+Answer briefly.
+{~/prompty.message~}
+
+{~prompty.message role="user"~}
+{~prompty.var name="firstName" /~} asks: {~prompty.var name="question" /~}
+{~/prompty.message~}
+`, p.Body)
+
+	var fields []string
+	for _, w := range result.Report.Warnings {
+		fields = append(fields, w.Field)
+	}
+	assert.Equal(t, []string{
+		"name", "model.configuration.azure_endpoint",
+		"body", "body", "body",
+	}, fields)
+	assert.False(t, result.Report.Lossless())
+	assert.Equal(t, 4, result.Report.Warnings[2].Position.Line, "positions are relative to the body")
+	assert.Contains(t, result.Report.Warnings[2].String(), "line 4")
+	assert.Contains(t, result.Report.Warnings[2].Message, `"trim"`)
+
+	data, err := p.ExportFull()
+	require.NoError(t, err)
+	_, err = Parse(data)
+	assert.NoError(t, err, "imported prompts are valid documents")
+}
+
+func TestImportDotprompt_Errors(t *testing.T) {
+	_, err := ImportDotprompt([]byte("system:\nhello"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgDotpromptMissingFrontmatter)
+
+	_, err = ImportDotprompt([]byte("---\nname: [broken\n---\n"))
+	assert.Error(t, err)
+
+	result, err := Import([]byte("---\nname: basic\n---\nHello {{name}}"), "basic.prompty")
+	require.NoError(t, err)
+	assert.Equal(t, "basic", result.Prompt.Description, "the name stands in for a missing description")
+	assert.Equal(t, `Hello {~prompty.var name="name" /~}`, result.Prompt.Body)
+	require.Len(t, result.Report.Warnings, 1)
+	assert.Equal(t, "description", result.Report.Warnings[0].Field)
+}
+
+func TestExportDotprompt(t *testing.T) {
+	temp := 0.7
+	topK := 40
+	p := &Prompt{
+		Name:        "support-agent",
+		Description: "Answers support questions",
+		Metadata:    map[string]any{"authors": []any{"Jane"}},
+		Execution: &ExecutionConfig{
+			Provider:    ProviderOpenAI,
+			Model:       "gpt-4o",
+			Temperature: &temp,
+			TopK:        &topK,
+		},
+		Inputs:     map[string]*InputDef{"query": {Type: SchemaTypeString, Required: true}},
+		Sample:     map[string]any{"query": "Where is my order?"},
+		Extensions: map[string]any{"x-team": "support"},
+		Body: `{~prompty.message role="system"~}
+You are helpful.
+{~/prompty.message~}
+
+{~prompty.message role="user"~}
+{~prompty.var name="query" default="hi" /~} from {~prompty.var name="user.name" /~}
+{~prompty.env name="REGION" /~}
+{~/prompty.message~}
+`,
+	}
+
+	data, report, err := ExportDotprompt(p)
+	require.NoError(t, err)
+	doc := string(data)
+	assert.True(t, strings.HasPrefix(doc, "---\n"))
+	assert.Contains(t, doc, "type: openai")
+	assert.Contains(t, doc, "name: gpt-4o")
+	assert.Contains(t, doc, "temperature: 0.7")
+	assert.Contains(t, doc, "template: jinja2")
+	assert.Contains(t, doc, "system:\nYou are helpful.\n\nuser:\n{{ query | default(\"hi\") }} from {{user.name}}\n")
+
+	var fields []string
+	for _, w := range report.Warnings {
+		fields = append(fields, w.Field)
+	}
+	assert.Equal(t, []string{"execution.top_k", "x-team", "body"}, fields)
+
+	result, err := ImportDotprompt(data)
+	require.NoError(t, err)
+	assert.Equal(t, p.Name, result.Prompt.Name)
+	assert.Equal(t, "gpt-4o", result.Prompt.Execution.Model)
+	assert.Equal(t, 0.7, *result.Prompt.Execution.Temperature)
+	assert.True(t, result.Prompt.Inputs["query"].Required)
+	assert.Equal(t, []string{"Jane"}, result.Prompt.Metadata["authors"])
+
+	_, _, err = ExportDotprompt(nil)
+	assert.Error(t, err)
+}
+
+func TestExportDotprompt_Azure(t *testing.T) {
+	p := &Prompt{
+		Name:        "azure-prompt",
+		Description: "Runs on Azure",
+		Execution: &ExecutionConfig{
+			Provider:        ProviderAzure,
+			Model:           "gpt-4o",
+			ProviderOptions: map[string]any{ProviderOptionAzureDeployment: "prod-4o", ProviderOptionAzureAPIVersion: "2024-10-21"},
+		},
+		Body: "Hi",
+	}
+
+	data, report, err := ExportDotprompt(p)
+	require.NoError(t, err)
+	assert.True(t, report.Lossless())
+	doc := string(data)
+	assert.Contains(t, doc, "type: azure_openai")
+	assert.Contains(t, doc, "azure_deployment: prod-4o")
+	assert.Contains(t, doc, "api_version: \"2024-10-21\"")
+	assert.NotContains(t, doc, "deployment: prod-4o\n    parameters")
+
+	result, err := ImportDotprompt(data)
+	require.NoError(t, err)
+	assert.Equal(t, "prod-4o", result.Prompt.Execution.AzureDeployment())
+	assert.Equal(t, "2024-10-21", result.Prompt.Execution.AzureAPIVersion())
+}
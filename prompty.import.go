@@ -3,6 +3,7 @@ package prompty
 import (
 	"archive/zip"
 	"bytes"
+	"fmt"
 	"io"
 	"path/filepath"
 	"strings"
@@ -17,16 +18,72 @@ const (
 	ErrMsgImportReadFailed  = "failed to read import file"
 )
 
+// Conversion warning messages shared by the foreign format importers
+const (
+	WarnMsgImportNameSlugified = "name %q is not a slug and was converted to %q"
+	WarnMsgImportNoDescription = "description is missing; the name is used instead"
+
+	importFallbackName = "imported-prompt"
+)
+
 // ImportResult holds the result of importing a document.
 type ImportResult struct {
 	// Prompt is the parsed prompt/skill/agent configuration
 	Prompt *Prompt
 	// Resources maps resource filenames to their content (e.g., from zip)
 	Resources map[string][]byte
+	// Report lists what a conversion from another format could not carry
+	// over exactly; nil for native documents
+	Report *ConversionReport
+}
+
+// ConversionWarning is a construct that a conversion from or to another
+// prompt format dropped or changed.
+type ConversionWarning struct {
+	// Field is the frontmatter field, e.g. "model.configuration.api_key",
+	// or "body" for template constructs
+	Field string
+	// Position locates body constructs relative to the start of the body;
+	// zero for frontmatter fields
+	Position Position
+	// Message describes what was lost or changed
+	Message string
+}
+
+// String returns the warning as "field: message", with the position for
+// body constructs.
+func (w ConversionWarning) String() string {
+	if w.Position.Line > 0 {
+		return w.Field + " (" + w.Position.String() + "): " + w.Message
+	}
+	return w.Field + ": " + w.Message
+}
+
+// ConversionReport lists the warnings of a format conversion.
+type ConversionReport struct {
+	Warnings []ConversionWarning
+}
+
+// Lossless reports whether the conversion carried everything over.
+func (r *ConversionReport) Lossless() bool {
+	return r == nil || len(r.Warnings) == 0
+}
+
+// add records a warning about a frontmatter field.
+func (r *ConversionReport) add(field, message string) {
+	r.Warnings = append(r.Warnings, ConversionWarning{Field: field, Message: message})
+}
+
+// addAt records a warning about a construct at a position.
+func (r *ConversionReport) addAt(field string, pos Position, message string) {
+	r.Warnings = append(r.Warnings, ConversionWarning{Field: field, Position: pos, Message: message})
 }
 
 // Import parses a document from raw data and filename.
-// Supported formats: .md (SKILL.md/AGENT.md), .zip (directory archive).
+// Supported formats: .md (SKILL.md/AGENT.md) with YAML or JSON frontmatter,
+// .json (JSON document, or a LangChain export, see ImportLangChain), .zip
+// (directory archive), .prompty (Microsoft Prompty, see ImportDotprompt).
+// Other files are detected from their content.
 func Import(data []byte, filename string) (*ImportResult, error) {
 	if len(data) == 0 {
 		return nil, NewFrontmatterError(ErrMsgImportFailed, Position{Line: 1, Column: 1}, nil)
@@ -34,19 +91,26 @@ func Import(data []byte, filename string) (*ImportResult, error) {
 
 	ext := strings.ToLower(filepath.Ext(filename))
 	switch ext {
-	case FileExtensionMarkdown:
-		return importMarkdown(data)
 	case FileExtensionZip:
 		return ImportDirectory(data)
+	case FileExtensionPrompty:
+		return ImportDotprompt(data)
 	default:
-		// Try as markdown
-		return importMarkdown(data)
+		format := DetectDocumentFormat(data, filename)
+		if format == DocumentFormatJSON && isLangChainDocument(data) {
+			var name string
+			if filename != "" {
+				name = strings.TrimSuffix(filepath.Base(filename), ext)
+			}
+			return ImportLangChain(data, name)
+		}
+		return importDocument(data, format)
 	}
 }
 
-// importMarkdown imports from a markdown document with YAML frontmatter.
-func importMarkdown(data []byte) (*ImportResult, error) {
-	prompt, err := Parse(data)
+// importDocument imports from a single prompt document.
+func importDocument(data []byte, format DocumentFormat) (*ImportResult, error) {
+	prompt, err := parseFormat(data, format)
 	if err != nil {
 		return nil, err
 	}
@@ -124,3 +188,53 @@ func ImportDirectory(data []byte) (*ImportResult, error) {
 		Resources: resources,
 	}, nil
 }
+
+// importIdentity returns the prompt name and description of an imported
+// document: the name as a slug, and the original name when there is no
+// description. Changes are reported.
+func importIdentity(name, description string, report *ConversionReport) (string, string) {
+	slug := importSlug(name)
+	if slug != name {
+		report.add(PromptFieldName, fmt.Sprintf(WarnMsgImportNameSlugified, name, slug))
+	}
+	if description == "" {
+		description = name
+		if description == "" {
+			description = slug
+		}
+		report.add(PromptFieldDescription, WarnMsgImportNoDescription)
+	}
+	return slug, description
+}
+
+// importSlug converts a foreign prompt name such as "Basic Prompt" or
+// "ExamplePrompt" into a prompt slug.
+func importSlug(name string) string {
+	var sb strings.Builder
+	var prev rune
+	for _, r := range name {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			if (prev >= 'a' && prev <= 'z') || (prev >= '0' && prev <= '9') {
+				sb.WriteByte('-')
+			}
+			sb.WriteRune(r - 'A' + 'a')
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			sb.WriteRune(r)
+		default:
+			if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "-") {
+				sb.WriteByte('-')
+			}
+		}
+		prev = r
+	}
+	slug := strings.TrimLeft(sb.String(), "0123456789-")
+	if len(slug) > PromptNameMaxLength {
+		slug = slug[:PromptNameMaxLength]
+	}
+	slug = strings.TrimRight(slug, "-")
+	if slug == "" {
+		return importFallbackName
+	}
+	return slug
+}
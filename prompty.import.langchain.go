@@ -0,0 +1,490 @@
+package prompty
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// LangChain serialization constants
+const (
+	langChainKeyLC        = "lc"
+	langChainKeyType      = "type"
+	langChainKeyID        = "id"
+	langChainKeyKwargs    = "kwargs"
+	langChainKeyLegacy    = "_type"
+	langChainKeyManifest  = "manifest"
+	langChainKeyCommit    = "commit_hash"
+	langChainTypeSecret   = "secret"
+	langChainLegacyPrompt = "prompt"
+
+	langChainKeyTemplate         = "template"
+	langChainKeyTemplateFormat   = "template_format"
+	langChainKeyInputVariables   = "input_variables"
+	langChainKeyOptionalVars     = "optional_variables"
+	langChainKeyPartialVariables = "partial_variables"
+	langChainKeyMessages         = "messages"
+	langChainKeyPrompt           = "prompt"
+	langChainKeyContent          = "content"
+	langChainKeyRole             = "role"
+	langChainKeyVariableName     = "variable_name"
+	langChainKeyOptional         = "optional"
+	langChainKeySchema           = "schema_"
+	langChainKeyMetadata         = "metadata"
+	langChainKeyHubRepo          = "lc_hub_repo"
+	langChainKeyFirst            = "first"
+	langChainKeyMiddle           = "middle"
+	langChainKeyLast             = "last"
+	langChainKeyModel            = "model"
+	langChainKeyModelName        = "model_name"
+	langChainKeyDeploymentName   = "deployment_name"
+	langChainKeyAzureDeployment  = "azure_deployment"
+	langChainKeyAPIVersion       = "openai_api_version"
+	langChainKeyMaxTokensSample  = "max_tokens_to_sample"
+	langChainKeyMaxOutputTokens  = "max_output_tokens"
+	langChainKeyStopSequences    = "stop_sequences"
+	langChainKeyTitle            = "title"
+	langChainFieldSteps          = "steps"
+	langChainSchemaName          = "output"
+
+	langChainFormatFString  = "f-string"
+	langChainFormatMustache = "mustache"
+	langChainFormatJinja2   = "jinja2"
+
+	langChainRoleHuman = "human"
+	langChainRoleAI    = "ai"
+
+	// MetadataKeyLangSmithCommit is the prompt metadata key holding the
+	// LangSmith commit hash of an imported hub prompt.
+	MetadataKeyLangSmithCommit = "langsmith_commit"
+)
+
+// LangChain class names
+const (
+	langChainClassPromptTemplate     = "PromptTemplate"
+	langChainClassChatPromptTemplate = "ChatPromptTemplate"
+	langChainClassStructuredPrompt   = "StructuredPrompt"
+	langChainClassRunnableSequence   = "RunnableSequence"
+	langChainClassSystemTemplate     = "SystemMessagePromptTemplate"
+	langChainClassHumanTemplate      = "HumanMessagePromptTemplate"
+	langChainClassAITemplate         = "AIMessagePromptTemplate"
+	langChainClassChatTemplate       = "ChatMessagePromptTemplate"
+	langChainClassSystemMessage      = "SystemMessage"
+	langChainClassHumanMessage       = "HumanMessage"
+	langChainClassAIMessage          = "AIMessage"
+	langChainClassPlaceholder        = "MessagesPlaceholder"
+)
+
+// langChainModelProviders maps LangChain chat model classes to providers.
+var langChainModelProviders = map[string]string{
+	"ChatOpenAI":             ProviderOpenAI,
+	"AzureChatOpenAI":        ProviderAzure,
+	"ChatAnthropic":          ProviderAnthropic,
+	"ChatGoogleGenerativeAI": ProviderGemini,
+	"ChatVertexAI":           ProviderGemini,
+	"ChatMistralAI":          ProviderMistral,
+	"ChatCohere":             ProviderCohere,
+	"ChatOllama":             ProviderOllama,
+}
+
+// Error and warning messages for LangChain conversion
+const (
+	ErrMsgLangChainInvalidJSON  = "invalid LangChain prompt JSON"
+	ErrMsgLangChainUnsupported  = "unsupported LangChain prompt type"
+	ErrMsgLangChainNoPrompt     = "LangChain document contains no prompt template"
+	WarnMsgLangChainStep        = "runnable step %q is not converted"
+	WarnMsgLangChainMessage     = "message type %q is not converted"
+	WarnMsgLangChainRole        = "role %q is converted to %q"
+	WarnMsgLangChainContent     = "non-text message content is dropped"
+	WarnMsgLangChainPlaceholder = "placeholder %q is not converted"
+	WarnMsgLangChainSecret      = "secret %q is not imported"
+	WarnMsgLangChainModelArg    = "model argument %q is not converted"
+	WarnMsgLangChainFormat      = "template format %q is not converted; the template is kept as is"
+)
+
+// ImportLangChain converts a LangChain prompt export into a prompt. It
+// accepts the JSON of a serialized PromptTemplate, ChatPromptTemplate or
+// StructuredPrompt (langchain_core.load.dumpd), a prompt | model
+// RunnableSequence, whose chat model becomes the execution config, the
+// legacy PromptTemplate.save format, and LangSmith prompt hub commits
+// (the "manifest" of a pull).
+//
+// Chat messages become prompty.message tags, MessagesPlaceholder entries
+// prompty.history tags and {variable} placeholders prompty.var tags. The
+// input variables become required inputs, partial variables their
+// defaults. The prompt is named name, or after its hub repository; what
+// could not be converted is listed in the result's Report.
+func ImportLangChain(data []byte, name string) (*ImportResult, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, NewFrontmatterError(ErrMsgLangChainInvalidJSON, Position{Line: 1, Column: 1}, err)
+	}
+
+	c := &langChainConverter{report: &ConversionReport{}, inputs: make(map[string]*InputDef)}
+	var commit string
+	if manifest, ok := doc[langChainKeyManifest].(map[string]any); ok {
+		commit, _ = doc[langChainKeyCommit].(string)
+		doc = manifest
+	}
+
+	prompt := &Prompt{Type: DocumentTypePrompt}
+	if err := c.convertRoot(doc, prompt); err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = c.hubRepo
+	}
+	prompt.Name, prompt.Description = importIdentity(name, "", c.report)
+	if commit != "" {
+		prompt.Metadata = map[string]any{MetadataKeyLangSmithCommit: commit}
+	}
+	if len(c.inputs) > 0 {
+		prompt.Inputs = c.inputs
+	}
+
+	if err := prompt.Validate(); err != nil {
+		return nil, err
+	}
+	return &ImportResult{
+		Prompt:    prompt,
+		Resources: make(map[string][]byte),
+		Report:    c.report,
+	}, nil
+}
+
+// isLangChainDocument reports whether a JSON document is a LangChain
+// export rather than a prompty JSON document.
+func isLangChainDocument(data []byte) bool {
+	var doc map[string]any
+	if json.Unmarshal(data, &doc) != nil {
+		return false
+	}
+	if _, ok := doc[langChainKeyManifest].(map[string]any); ok {
+		return true
+	}
+	if _, ok := doc[langChainKeyLegacy]; ok {
+		return true
+	}
+	_, lc := doc[langChainKeyLC]
+	_, id := doc[langChainKeyID]
+	return lc && id
+}
+
+// langChainConverter holds the state of a LangChain conversion.
+type langChainConverter struct {
+	report  *ConversionReport
+	inputs  map[string]*InputDef
+	hubRepo string
+}
+
+// convertRoot converts the top-level object of a LangChain export.
+func (c *langChainConverter) convertRoot(doc map[string]any, prompt *Prompt) error {
+	if legacy, ok := doc[langChainKeyLegacy].(string); ok {
+		if legacy != langChainLegacyPrompt {
+			return NewImportTypeError(ErrMsgLangChainUnsupported, legacy)
+		}
+		c.addVariables(doc)
+		prompt.Body = c.convertTemplate(doc, langChainKeyTemplate)
+		return nil
+	}
+
+	class, kwargs := langChainNode(doc)
+	if meta, ok := kwargs[langChainKeyMetadata].(map[string]any); ok {
+		c.hubRepo, _ = meta[langChainKeyHubRepo].(string)
+	}
+
+	switch class {
+	case langChainClassPromptTemplate:
+		c.addVariables(kwargs)
+		prompt.Body = c.convertTemplate(kwargs, langChainKeyTemplate)
+	case langChainClassChatPromptTemplate, langChainClassStructuredPrompt:
+		c.addVariables(kwargs)
+		prompt.Body = c.convertMessages(kwargs)
+		if schema, ok := kwargs[langChainKeySchema].(map[string]any); ok {
+			prompt.Execution = &ExecutionConfig{ResponseFormat: langChainResponseFormat(schema)}
+		}
+	case langChainClassRunnableSequence:
+		return c.convertSequence(kwargs, prompt)
+	default:
+		return NewImportTypeError(ErrMsgLangChainUnsupported, class)
+	}
+	return nil
+}
+
+// convertSequence converts a RunnableSequence: the first step is the prompt
+// and the first chat model after it the execution config. Other steps, such
+// as output parsers, are reported.
+func (c *langChainConverter) convertSequence(kwargs map[string]any, prompt *Prompt) error {
+	first, ok := kwargs[langChainKeyFirst].(map[string]any)
+	if !ok {
+		return NewImportTypeError(ErrMsgLangChainNoPrompt, langChainClassRunnableSequence)
+	}
+	if err := c.convertRoot(first, prompt); err != nil {
+		return err
+	}
+
+	steps, _ := kwargs[langChainKeyMiddle].([]any)
+	steps = append(steps, kwargs[langChainKeyLast])
+	for _, step := range steps {
+		class, stepKwargs := langChainNode(step)
+		provider, isModel := langChainModelProviders[class]
+		if !isModel || (prompt.Execution != nil && prompt.Execution.Provider != "") {
+			c.report.add(langChainFieldSteps, fmt.Sprintf(WarnMsgLangChainStep, class))
+			continue
+		}
+		if prompt.Execution == nil {
+			prompt.Execution = &ExecutionConfig{}
+		}
+		prompt.Execution.Provider = provider
+		c.convertModel(stepKwargs, prompt.Execution)
+	}
+	return nil
+}
+
+// convertModel converts the arguments of a chat model.
+func (c *langChainConverter) convertModel(kwargs map[string]any, config *ExecutionConfig) {
+	for _, key := range sortedKeys(kwargs) {
+		value := kwargs[key]
+		if node, ok := value.(map[string]any); ok && node[langChainKeyType] == langChainTypeSecret {
+			c.report.add(langChainKeyModel+"."+key, fmt.Sprintf(WarnMsgLangChainSecret, key))
+			continue
+		}
+		switch key {
+		case langChainKeyModel, langChainKeyModelName:
+			config.Model, _ = value.(string)
+		case langChainKeyDeploymentName, langChainKeyAzureDeployment:
+			config.setProviderOption(ProviderOptionAzureDeployment, value)
+		case langChainKeyAPIVersion:
+			config.setProviderOption(ProviderOptionAzureAPIVersion, value)
+		case langChainKeyMaxTokensSample, langChainKeyMaxOutputTokens:
+			if !importDotpromptParameter(config, ParamKeyMaxTokens, value) {
+				c.report.add(langChainKeyModel+"."+key, fmt.Sprintf(WarnMsgLangChainModelArg, key))
+			}
+		case langChainKeyStopSequences:
+			importDotpromptParameter(config, ParamKeyStop, value)
+		case ParamKeyTopK:
+			if n, ok := dotpromptInt(value); ok {
+				config.TopK = &n
+			}
+		default:
+			if !importDotpromptParameter(config, key, value) {
+				c.report.add(langChainKeyModel+"."+key, fmt.Sprintf(WarnMsgLangChainModelArg, key))
+			}
+		}
+	}
+}
+
+// convertMessages converts the messages of a chat prompt into
+// prompty.message blocks.
+func (c *langChainConverter) convertMessages(kwargs map[string]any) string {
+	messages, _ := kwargs[langChainKeyMessages].([]any)
+	blocks := make([]string, 0, len(messages))
+	for i, message := range messages {
+		field := fmt.Sprintf("%s[%d]", langChainKeyMessages, i)
+		class, msgKwargs := langChainNode(message)
+
+		role := ""
+		content := ""
+		switch class {
+		case langChainClassSystemTemplate, langChainClassSystemMessage:
+			role = RoleSystem
+		case langChainClassHumanTemplate, langChainClassHumanMessage:
+			role = RoleUser
+		case langChainClassAITemplate, langChainClassAIMessage:
+			role = RoleAssistant
+		case langChainClassChatTemplate:
+			role = c.convertRole(field, msgKwargs)
+		case langChainClassPlaceholder:
+			blocks = append(blocks, c.convertPlaceholder(field, msgKwargs))
+			continue
+		default:
+			c.report.add(field, fmt.Sprintf(WarnMsgLangChainMessage, class))
+			continue
+		}
+
+		switch class {
+		case langChainClassSystemMessage, langChainClassHumanMessage, langChainClassAIMessage:
+			text, ok := msgKwargs[langChainKeyContent].(string)
+			if !ok {
+				c.report.add(field, WarnMsgLangChainContent)
+			}
+			content = text
+		default:
+			content = c.convertMessagePrompt(field, msgKwargs[langChainKeyPrompt])
+		}
+		blocks = append(blocks, promptyMessageBlock(role, content))
+	}
+	if len(blocks) == 0 {
+		return ""
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+// convertMessagePrompt converts the prompt of a message template, which is
+// a PromptTemplate or, for multimodal messages, a list of templates.
+func (c *langChainConverter) convertMessagePrompt(field string, prompt any) string {
+	parts, isList := prompt.([]any)
+	if !isList {
+		parts = []any{prompt}
+	}
+	var texts []string
+	for _, part := range parts {
+		class, kwargs := langChainNode(part)
+		if class != langChainClassPromptTemplate {
+			c.report.add(field, WarnMsgLangChainContent)
+			continue
+		}
+		c.addVariables(kwargs)
+		texts = append(texts, c.convertTemplate(kwargs, field))
+	}
+	return strings.Join(texts, "\n")
+}
+
+// convertRole returns the role of a ChatMessagePromptTemplate.
+func (c *langChainConverter) convertRole(field string, kwargs map[string]any) string {
+	role, _ := kwargs[langChainKeyRole].(string)
+	switch role {
+	case RoleSystem, RoleUser, RoleAssistant, RoleTool:
+		return role
+	case langChainRoleHuman:
+		return RoleUser
+	case langChainRoleAI:
+		return RoleAssistant
+	}
+	c.report.add(field, fmt.Sprintf(WarnMsgLangChainRole, role, RoleUser))
+	return RoleUser
+}
+
+// convertPlaceholder converts a MessagesPlaceholder into a prompty.history
+// tag over its variable.
+func (c *langChainConverter) convertPlaceholder(field string, kwargs map[string]any) string {
+	variable, _ := kwargs[langChainKeyVariableName].(string)
+	if variable == "" {
+		c.report.add(field, fmt.Sprintf(WarnMsgLangChainPlaceholder, variable))
+		return ""
+	}
+	optional, _ := kwargs[langChainKeyOptional].(bool)
+	c.inputs[variable] = &InputDef{Type: SchemaTypeArray, Required: !optional}
+	return DefaultOpenDelim + TagNameHistory + " " + AttrIn + `="` + variable + `" /` + DefaultCloseDelim
+}
+
+// addVariables records the input, optional and partial variables of a
+// template as inputs.
+func (c *langChainConverter) addVariables(kwargs map[string]any) {
+	for _, name := range stringSlice(kwargs[langChainKeyInputVariables]) {
+		if _, exists := c.inputs[name]; !exists {
+			c.inputs[name] = &InputDef{Type: SchemaTypeString, Required: true}
+		}
+	}
+	for _, name := range stringSlice(kwargs[langChainKeyOptionalVars]) {
+		if _, exists := c.inputs[name]; !exists {
+			c.inputs[name] = &InputDef{Type: SchemaTypeString}
+		}
+	}
+	partials, _ := kwargs[langChainKeyPartialVariables].(map[string]any)
+	for name, value := range partials {
+		c.inputs[name] = &InputDef{Type: SchemaTypeString, Default: value}
+	}
+}
+
+// convertTemplate converts a template in its template format, reporting
+// constructs under field.
+func (c *langChainConverter) convertTemplate(kwargs map[string]any, field string) string {
+	template, _ := kwargs[langChainKeyTemplate].(string)
+	format, _ := kwargs[langChainKeyTemplateFormat].(string)
+	switch format {
+	case "", langChainFormatFString:
+		return convertFStringTemplate(template, field, c.report)
+	case langChainFormatMustache, langChainFormatJinja2:
+		return convertForeignTemplate(format, template, field, c.report)
+	default:
+		c.report.add(field, fmt.Sprintf(WarnMsgLangChainFormat, format))
+		return template
+	}
+}
+
+// convertFStringTemplate converts a Python f-string template: {name} and
+// {name.attr} become prompty.var tags and {{ and }} literal braces.
+// Placeholders with an index, conversion or format spec are kept and
+// reported.
+func convertFStringTemplate(template, field string, report *ConversionReport) string {
+	var sb strings.Builder
+	for i := 0; i < len(template); i++ {
+		ch := template[i]
+		switch {
+		case ch == '{' && strings.HasPrefix(template[i:], "{{"):
+			sb.WriteByte('{')
+			i++
+		case ch == '}' && strings.HasPrefix(template[i:], "}}"):
+			sb.WriteByte('}')
+			i++
+		case ch == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				sb.WriteString(template[i:])
+				return sb.String()
+			}
+			placeholder := template[i : i+end+1]
+			name := strings.TrimSpace(placeholder[1 : len(placeholder)-1])
+			if isFStringField(name) {
+				sb.WriteString(promptyVarTagFor(name))
+			} else {
+				report.addAt(field, positionAt(template, i), fmt.Sprintf(WarnMsgDotpromptTemplateSyntax, placeholder))
+				sb.WriteString(placeholder)
+			}
+			i += end
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return sb.String()
+}
+
+// isFStringField reports whether an f-string placeholder is a plain
+// (dotted) variable reference.
+func isFStringField(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, part := range strings.Split(name, ".") {
+		if part == "" || isDigit(part[0]) {
+			return false
+		}
+		for i := 0; i < len(part); i++ {
+			c := part[i]
+			if c != '_' && !isDigit(c) && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// langChainResponseFormat converts the schema of a StructuredPrompt into a
+// JSON schema response format.
+func langChainResponseFormat(schema map[string]any) *ResponseFormat {
+	name, _ := schema[langChainKeyTitle].(string)
+	if name == "" {
+		name = langChainSchemaName
+	}
+	return &ResponseFormat{
+		Type:       ResponseFormatJSONSchema,
+		JSONSchema: &JSONSchemaSpec{Name: name, Schema: schema},
+	}
+}
+
+// langChainNode returns the class name and kwargs of a serialized LangChain
+// object, or empty values for anything else.
+func langChainNode(value any) (string, map[string]any) {
+	node, ok := value.(map[string]any)
+	if !ok {
+		return "", nil
+	}
+	id := stringSlice(node[langChainKeyID])
+	if len(id) == 0 {
+		return "", nil
+	}
+	kwargs, _ := node[langChainKeyKwargs].(map[string]any)
+	return id[len(id)-1], kwargs
+}
@@ -0,0 +1,167 @@
+package prompty
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testLangChainChat = `{
+  "lc": 1, "type": "constructor",
+  "id": ["langchain", "prompts", "chat", "ChatPromptTemplate"],
+  "kwargs": {
+    "input_variables": ["question", "history"],
+    "partial_variables": {"tone": "friendly"},
+    "metadata": {"lc_hub_owner": "-", "lc_hub_repo": "rag-prompt"},
+    "messages": [
+      {"lc": 1, "type": "constructor",
+       "id": ["langchain", "prompts", "chat", "SystemMessagePromptTemplate"],
+       "kwargs": {"prompt": {"lc": 1, "type": "constructor",
+         "id": ["langchain", "prompts", "prompt", "PromptTemplate"],
+         "kwargs": {"input_variables": ["tone"], "template": "Be {tone}. Reply as JSON: {{\"answer\": ...}}", "template_format": "f-string"}}}},
+      {"lc": 1, "type": "constructor",
+       "id": ["langchain", "prompts", "chat", "MessagesPlaceholder"],
+       "kwargs": {"variable_name": "history", "optional": true}},
+      {"lc": 1, "type": "constructor",
+       "id": ["langchain", "prompts", "chat", "HumanMessagePromptTemplate"],
+       "kwargs": {"prompt": {"lc": 1, "type": "constructor",
+         "id": ["langchain", "prompts", "prompt", "PromptTemplate"],
+         "kwargs": {"input_variables": ["question", "user"], "template": "{user.name} asks: {question}\nBudget: {budget:.2f}"}}}},
+      {"lc": 1, "type": "constructor",
+       "id": ["langchain", "prompts", "chat", "ChatMessagePromptTemplate"],
+       "kwargs": {"role": "critic", "prompt": {"lc": 1, "type": "constructor",
+         "id": ["langchain", "prompts", "prompt", "PromptTemplate"],
+         "kwargs": {"template": "Check {{ question }}", "template_format": "mustache"}}}}
+    ]
+  }
+}`
+
+func TestImportLangChain_ChatPromptTemplate(t *testing.T) {
+	result, err := ImportLangChain([]byte(testLangChainChat), "")
+	require.NoError(t, err)
+	p := result.Prompt
+
+	assert.Equal(t, "rag-prompt", p.Name, "named after the hub repository")
+	assert.Equal(t, DocumentTypePrompt, p.Type)
+	assert.Equal(t, `{~prompty.message role="system"~}
+Be {~prompty.var name="tone" /~}. Reply as JSON: {"answer": ...}
+{~/prompty.message~}
+
+{~prompty.history in="history" /~}
+
+{~prompty.message role="user"~}
+{~prompty.var name="user.name" /~} asks: {~prompty.var name="question" /~}
+Budget: {budget:.2f}
+{~/prompty.message~}
+
+{~prompty.message role="user"~}
+Check {~prompty.var name="question" /~}
+{~/prompty.message~}
+`, p.Body)
+
+	assert.Equal(t, &InputDef{Type: SchemaTypeString, Required: true}, p.Inputs["question"])
+	assert.Equal(t, &InputDef{Type: SchemaTypeString, Default: "friendly"}, p.Inputs["tone"])
+	assert.Equal(t, &InputDef{Type: SchemaTypeArray}, p.Inputs["history"], "optional placeholder")
+
+	require.Len(t, result.Report.Warnings, 3)
+	assert.Equal(t, "messages[2]", result.Report.Warnings[0].Field)
+	assert.Equal(t, 2, result.Report.Warnings[0].Position.Line)
+	assert.Contains(t, result.Report.Warnings[0].Message, "{budget:.2f}")
+	assert.Equal(t, "messages[3]", result.Report.Warnings[1].Field)
+	assert.Equal(t, PromptFieldDescription, result.Report.Warnings[2].Field)
+
+	engine := MustNew()
+	tmpl, err := engine.Parse(p.Body)
+	require.NoError(t, err)
+	messages, err := tmpl.ExecuteAndExtractMessages(t.Context(), map[string]any{
+		"question": "Why?", "user": map[string]any{"name": "Ann"},
+		"history": []map[string]any{{"role": "assistant", "content": "Earlier"}},
+		"tone":    "brief",
+	})
+	require.NoError(t, err)
+	require.Len(t, messages, 4)
+	assert.Equal(t, "Earlier", messages[1].Content)
+	assert.Contains(t, messages[2].Content, "Ann asks: Why?")
+}
+
+func TestImportLangChain_Formats(t *testing.T) {
+	t.Run("legacy prompt", func(t *testing.T) {
+		doc := `{"_type": "prompt", "input_variables": ["topic"], "template": "Tell me about {topic}", "template_format": "f-string"}`
+		result, err := ImportLangChain([]byte(doc), "Topic Prompt")
+		require.NoError(t, err)
+		assert.Equal(t, "topic-prompt", result.Prompt.Name)
+		assert.Equal(t, `Tell me about {~prompty.var name="topic" /~}`, result.Prompt.Body)
+
+		_, err = ImportLangChain([]byte(`{"_type": "few_shot"}`), "x")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMsgLangChainUnsupported)
+	})
+
+	t.Run("langsmith commit with model", func(t *testing.T) {
+		doc := `{
+  "commit_hash": "a1b2c3",
+  "manifest": {
+    "lc": 1, "type": "constructor", "id": ["langchain", "schema", "runnable", "RunnableSequence"],
+    "kwargs": {
+      "first": {"lc": 1, "type": "constructor", "id": ["langchain_core", "prompts", "structured", "StructuredPrompt"],
+        "kwargs": {"input_variables": ["text"], "schema_": {"title": "sentiment", "type": "object", "properties": {"label": {"type": "string"}}},
+          "messages": [{"lc": 1, "type": "constructor", "id": ["langchain", "schema", "messages", "SystemMessage"], "kwargs": {"content": "Classify."}},
+            {"lc": 1, "type": "constructor", "id": ["langchain", "prompts", "chat", "HumanMessagePromptTemplate"],
+             "kwargs": {"prompt": {"lc": 1, "type": "constructor", "id": ["langchain", "prompts", "prompt", "PromptTemplate"], "kwargs": {"template": "{text}"}}}}]}},
+      "last": {"lc": 1, "type": "constructor", "id": ["langchain", "chat_models", "anthropic", "ChatAnthropic"],
+        "kwargs": {"model": "claude-sonnet-4-5", "temperature": 0, "max_tokens_to_sample": 1024, "top_k": 5, "max_retries": 3,
+          "anthropic_api_key": {"lc": 1, "type": "secret", "id": ["ANTHROPIC_API_KEY"]}}}
+    }
+  }
+}`
+		result, err := ImportLangChain([]byte(doc), "sentiment")
+		require.NoError(t, err)
+		p := result.Prompt
+		assert.Equal(t, "a1b2c3", p.Metadata[MetadataKeyLangSmithCommit])
+		require.NotNil(t, p.Execution)
+		assert.Equal(t, ProviderAnthropic, p.Execution.Provider)
+		assert.Equal(t, "claude-sonnet-4-5", p.Execution.Model)
+		assert.Equal(t, 0.0, *p.Execution.Temperature)
+		assert.Equal(t, 1024, *p.Execution.MaxTokens)
+		assert.Equal(t, 5, *p.Execution.TopK)
+		assert.Equal(t, ResponseFormatJSONSchema, p.Execution.ResponseFormat.Type)
+		assert.Equal(t, "sentiment", p.Execution.ResponseFormat.JSONSchema.Name)
+		assert.Contains(t, p.Body, "{~prompty.message role=\"system\"~}\nClassify.\n")
+
+		var fields []string
+		for _, w := range result.Report.Warnings {
+			fields = append(fields, w.Field)
+		}
+		assert.Equal(t, []string{"model.anthropic_api_key", "model.max_retries", PromptFieldDescription}, fields)
+	})
+
+	t.Run("import detects langchain json", func(t *testing.T) {
+		result, err := Import([]byte(testLangChainChat), "support.json")
+		require.NoError(t, err)
+		assert.Equal(t, "support", result.Prompt.Name)
+		assert.NotNil(t, result.Report)
+
+		result, err = Import([]byte(`{"name": "native", "description": "Native JSON", "body": "Hi"}`), "native.json")
+		require.NoError(t, err)
+		assert.Nil(t, result.Report)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ImportLangChain([]byte("not json"), "x")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMsgLangChainInvalidJSON)
+
+		_, err = ImportLangChain([]byte(`{"lc": 1, "id": ["langchain", "prompts", "FewShotPromptTemplate"]}`), "x")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMsgLangChainUnsupported)
+	})
+}
+
+func TestConvertFStringTemplate(t *testing.T) {
+	report := &ConversionReport{}
+	got := convertFStringTemplate("{a} {{literal}} {b_2.c} {0} {x!r} {open", "template", report)
+	assert.Equal(t, `{~prompty.var name="a" /~} {literal} {~prompty.var name="b_2.c" /~} {0} {x!r} {open`, got)
+	require.Len(t, report.Warnings, 2)
+	assert.Equal(t, 25, report.Warnings[0].Position.Column)
+}
@@ -131,3 +131,37 @@ Agent body here.`
 	assert.Equal(t, DocumentTypeAgent, result.Prompt.Type)
 	assert.Equal(t, "zip-agent", result.Prompt.Name)
 }
+
+func TestImport_JSON(t *testing.T) {
+	doc := `{"name": "json-import", "description": "Imported from JSON", "body": "Hello!"}`
+
+	result, err := Import([]byte(doc), "prompt.json")
+	require.NoError(t, err)
+	assert.Equal(t, "json-import", result.Prompt.Name)
+	assert.Equal(t, "Hello!", result.Prompt.Body)
+
+	result, err = Import([]byte("---json\n{\"name\": \"fm-import\", \"description\": \"JSON frontmatter\"}\n---\nBody."), "SKILL.md")
+	require.NoError(t, err)
+	assert.Equal(t, "fm-import", result.Prompt.Name)
+	assert.Equal(t, "Body.", result.Prompt.Body)
+
+	_, err = Import([]byte("---\nname: yaml\n---\n"), "prompt.json")
+	assert.Error(t, err, "the .json extension requires a JSON document")
+}
+
+func TestImportSlug(t *testing.T) {
+	tests := map[string]string{
+		"ExamplePrompt":       "example-prompt",
+		"Basic Prompt":        "basic-prompt",
+		"basic":               "basic",
+		"42 answers":          "answers",
+		"":                    importFallbackName,
+		"GPT4 chat (v2)!":     "gpt4-chat-v2",
+		"already-a-slug":      "already-a-slug",
+		"snake_case_name":     "snake-case-name",
+		"  --leading dashes ": "leading-dashes",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, importSlug(name), name)
+	}
+}
@@ -0,0 +1,79 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBlockLibraryEngine(t *testing.T) *Engine {
+	t.Helper()
+	engine := MustNew()
+	engine.MustRegisterTemplate("shared-blocks", `{~prompty.block name="disclaimer"~}This is not legal advice, {~prompty.var name="user" /~}.{~/prompty.block~}
+{~prompty.block name="tone"~}Be concise.{~/prompty.block~}`)
+	engine.MustRegisterTemplate("persona", `{~prompty.block name="role"~}You are a {~prompty.var name="role" default="helpful" /~} assistant.{~/prompty.block~}`)
+	return engine
+}
+
+func TestImports_E2E_UseBlocks(t *testing.T) {
+	engine := newBlockLibraryEngine(t)
+
+	source := `{~prompty.import template="shared-blocks" as="lib" /~}{~prompty.import template="persona" as="p" /~}
+{~prompty.use block="p.role" /~} {~prompty.use block="lib.tone" /~}
+{~prompty.if eval="legal"~}{~prompty.use block="lib.disclaimer" /~}{~/prompty.if~}`
+
+	result, err := engine.Execute(context.Background(), source, map[string]any{"user": "Ada", "role": "legal", "legal": true})
+	require.NoError(t, err)
+	assert.Equal(t, "\nYou are a legal assistant. Be concise.\nThis is not legal advice, Ada.", result)
+}
+
+func TestImports_E2E_WithInheritance(t *testing.T) {
+	engine := newBlockLibraryEngine(t)
+	engine.MustRegisterTemplate("base", `{~prompty.import template="persona" as="p" /~}System: {~prompty.block name="system"~}{~prompty.use block="p.role" /~}{~/prompty.block~}
+Footer: {~prompty.block name="footer"~}-{~/prompty.block~}`)
+
+	child := `{~prompty.extends template="base" /~}
+{~prompty.import template="shared-blocks" as="lib" /~}
+{~prompty.block name="footer"~}{~prompty.use block="lib.disclaimer" /~}{~/prompty.block~}`
+
+	result, err := engine.Execute(context.Background(), child, map[string]any{"user": "Bo"})
+	require.NoError(t, err)
+	assert.Equal(t, "System: You are a helpful assistant.\nFooter: This is not legal advice, Bo.", result)
+}
+
+func TestImports_E2E_Errors(t *testing.T) {
+	engine := newBlockLibraryEngine(t)
+	ctx := context.Background()
+
+	_, err := engine.Execute(ctx, `{~prompty.import template="missing" as="lib" /~}`, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+
+	_, err = engine.Execute(ctx, `{~prompty.import template="shared-blocks" as="lib" /~}{~prompty.use block="lib.nope" /~}`, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "block not found")
+
+	_, err = engine.Execute(ctx, `{~prompty.use block="lib.tone" /~}`, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not imported")
+
+	result, err := engine.Validate(`{~prompty.import template="missing" as="lib" /~}{~prompty.use block="tone" /~}`)
+	require.NoError(t, err)
+	assert.True(t, result.HasErrors())
+	assert.True(t, result.HasWarnings())
+}
+
+func TestImports_E2E_DryRun(t *testing.T) {
+	engine := newBlockLibraryEngine(t)
+	tmpl, err := engine.Parse(`{~prompty.import template="shared-blocks" as="lib" /~}{~prompty.use block="lib.disclaimer" /~}`)
+	require.NoError(t, err)
+
+	result := tmpl.DryRun(context.Background(), nil)
+
+	assert.True(t, result.Valid)
+	assert.Contains(t, result.MissingVariables, "user")
+	assert.Empty(t, result.Resolvers)
+	assert.Equal(t, "This is not legal advice, {{user}}.", result.Output)
+}
@@ -0,0 +1,281 @@
+package prompty
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+// Input constraint reasons, reported as the reason of input validation errors
+const (
+	inputReasonMinimum   = "value %v is less than minimum %v"
+	inputReasonMaximum   = "value %v is greater than maximum %v"
+	inputReasonMinLength = "length %d is less than min_length %d"
+	inputReasonMaxLength = "length %d is greater than max_length %d"
+	inputReasonMinItems  = "%d items are less than min_items %d"
+	inputReasonMaxItems  = "%d items are more than max_items %d"
+	inputReasonPattern   = "value does not match pattern %q"
+	inputReasonBadRegex  = "invalid pattern %q"
+)
+
+// Nested input paths: object property and array element
+const (
+	inputPathProperty = "%s.%s"
+	inputPathIndex    = "%s[%d]"
+)
+
+// inputPatterns caches compiled InputDef patterns by source.
+var inputPatterns sync.Map
+
+// Clone returns a deep copy of the input definition, including nested
+// properties and items.
+func (d *InputDef) Clone() *InputDef {
+	if d == nil {
+		return nil
+	}
+	clone := *d
+	if d.Default != nil {
+		clone.Default = deepCopyValue(d.Default)
+	}
+	if d.Enum != nil {
+		clone.Enum = append([]any(nil), d.Enum...)
+	}
+	if d.Properties != nil {
+		clone.Properties = make(map[string]*InputDef, len(d.Properties))
+		for name, prop := range d.Properties {
+			clone.Properties[name] = prop.Clone()
+		}
+	}
+	clone.Items = d.Items.Clone()
+	clone.Minimum = clonePtr(d.Minimum)
+	clone.Maximum = clonePtr(d.Maximum)
+	clone.MinLength = clonePtr(d.MinLength)
+	clone.MaxLength = clonePtr(d.MaxLength)
+	clone.MinItems = clonePtr(d.MinItems)
+	clone.MaxItems = clonePtr(d.MaxItems)
+	return &clone
+}
+
+// clonePtr returns a pointer to a copy of *p, or nil.
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// JSONSchema returns the input definition as a JSON Schema, e.g. to describe
+// a prompt's inputs to a tool-calling LLM or a form generator. Constraints
+// map to their JSON Schema keywords (minimum, maxLength, minItems, pattern,
+// ...); nested properties and items are converted recursively.
+func (d *InputDef) JSONSchema() map[string]any {
+	if d == nil {
+		return nil
+	}
+	schema := make(map[string]any)
+	if d.Type != "" {
+		schema[SchemaKeyType] = d.Type
+	}
+	if d.Description != "" {
+		schema[SchemaKeyDescription] = d.Description
+	}
+	if d.Default != nil {
+		schema[SchemaKeyDefault] = deepCopyValue(d.Default)
+	}
+	if len(d.Enum) > 0 {
+		schema[SchemaKeyEnum] = append([]any(nil), d.Enum...)
+	}
+	if d.Minimum != nil {
+		schema[SchemaKeyMinimum] = *d.Minimum
+	}
+	if d.Maximum != nil {
+		schema[SchemaKeyMaximum] = *d.Maximum
+	}
+	if d.MinLength != nil {
+		schema[SchemaKeyMinLength] = *d.MinLength
+	}
+	if d.MaxLength != nil {
+		schema[SchemaKeyMaxLength] = *d.MaxLength
+	}
+	if d.MinItems != nil {
+		schema[SchemaKeyMinItems] = *d.MinItems
+	}
+	if d.MaxItems != nil {
+		schema[SchemaKeyMaxItems] = *d.MaxItems
+	}
+	if d.Pattern != "" {
+		schema[SchemaKeyPattern] = d.Pattern
+	}
+	if len(d.Properties) > 0 {
+		properties, required := inputsJSONSchema(d.Properties)
+		schema[SchemaKeyProperties] = properties
+		if len(required) > 0 {
+			schema[SchemaKeyRequired] = required
+		}
+	}
+	if d.Items != nil {
+		schema[SchemaKeyItems] = d.Items.JSONSchema()
+	}
+	return schema
+}
+
+// InputsJSONSchema returns the prompt's inputs as a JSON Schema object with
+// one property per input and the required inputs listed, or nil if the
+// prompt declares no inputs.
+func (p *Prompt) InputsJSONSchema() map[string]any {
+	if !p.HasInputs() {
+		return nil
+	}
+	properties, required := inputsJSONSchema(p.Inputs)
+	schema := map[string]any{
+		SchemaKeyType:       SchemaTypeObject,
+		SchemaKeyProperties: properties,
+	}
+	if len(required) > 0 {
+		schema[SchemaKeyRequired] = required
+	}
+	return schema
+}
+
+// inputsJSONSchema converts input definitions to JSON Schema properties and
+// the sorted names of the required ones.
+func inputsJSONSchema(inputs map[string]*InputDef) (map[string]any, []any) {
+	properties := make(map[string]any, len(inputs))
+	var names []string
+	for name, def := range inputs {
+		if def == nil {
+			continue
+		}
+		properties[name] = def.JSONSchema()
+		if def.Required {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var required []any
+	for _, name := range names {
+		required = append(required, name)
+	}
+	return properties, required
+}
+
+// validateInputValue checks a value against its input definition: type,
+// enum and constraints, recursing into object properties and array items.
+// path names the value in errors.
+func validateInputValue(path string, val any, def *InputDef) error {
+	if val == nil || def == nil {
+		return nil // nil is allowed for optional inputs
+	}
+	if err := validatePromptInputType(path, val, def.Type); err != nil {
+		return err
+	}
+	if !inEnum(val, def.Enum) {
+		return NewInputValidationError(path, coerceReasonEnum)
+	}
+
+	switch def.Type {
+	case SchemaTypeNumber, SchemaTypeInteger:
+		n, _ := toFloat(val)
+		if def.Minimum != nil && n < *def.Minimum {
+			return NewInputValidationError(path, fmt.Sprintf(inputReasonMinimum, val, *def.Minimum))
+		}
+		if def.Maximum != nil && n > *def.Maximum {
+			return NewInputValidationError(path, fmt.Sprintf(inputReasonMaximum, val, *def.Maximum))
+		}
+	case SchemaTypeString:
+		return validateInputString(path, val.(string), def)
+	case SchemaTypeArray:
+		return validateInputArray(path, reflect.ValueOf(val), def)
+	case SchemaTypeObject:
+		return validateInputObject(path, reflect.ValueOf(val), def)
+	}
+	return nil
+}
+
+// validateInputString checks the length and pattern constraints of a string.
+func validateInputString(path, s string, def *InputDef) error {
+	length := utf8.RuneCountInString(s)
+	if def.MinLength != nil && length < *def.MinLength {
+		return NewInputValidationError(path, fmt.Sprintf(inputReasonMinLength, length, *def.MinLength))
+	}
+	if def.MaxLength != nil && length > *def.MaxLength {
+		return NewInputValidationError(path, fmt.Sprintf(inputReasonMaxLength, length, *def.MaxLength))
+	}
+	if def.Pattern != "" {
+		re, err := inputPattern(def.Pattern)
+		if err != nil {
+			return NewInputValidationError(path, fmt.Sprintf(inputReasonBadRegex, def.Pattern))
+		}
+		if !re.MatchString(s) {
+			return NewInputValidationError(path, fmt.Sprintf(inputReasonPattern, def.Pattern))
+		}
+	}
+	return nil
+}
+
+// validateInputArray checks the item count of a slice and each item against
+// the items definition.
+func validateInputArray(path string, list reflect.Value, def *InputDef) error {
+	count := list.Len()
+	if def.MinItems != nil && count < *def.MinItems {
+		return NewInputValidationError(path, fmt.Sprintf(inputReasonMinItems, count, *def.MinItems))
+	}
+	if def.MaxItems != nil && count > *def.MaxItems {
+		return NewInputValidationError(path, fmt.Sprintf(inputReasonMaxItems, count, *def.MaxItems))
+	}
+	if def.Items == nil {
+		return nil
+	}
+	for i := 0; i < count; i++ {
+		if err := validateInputValue(fmt.Sprintf(inputPathIndex, path, i), list.Index(i).Interface(), def.Items); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateInputObject checks the declared properties of a map. Properties
+// without a definition are allowed.
+func validateInputObject(path string, obj reflect.Value, def *InputDef) error {
+	names := make([]string, 0, len(def.Properties))
+	for name := range def.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names) // Report the first error deterministically
+
+	for _, name := range names {
+		prop := def.Properties[name]
+		if prop == nil {
+			continue
+		}
+		propPath := fmt.Sprintf(inputPathProperty, path, name)
+		field := obj.MapIndex(reflect.ValueOf(name))
+		if !field.IsValid() {
+			if prop.Required {
+				return NewRequiredInputMissingError(propPath)
+			}
+			continue
+		}
+		if err := validateInputValue(propPath, field.Interface(), prop); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inputPattern compiles a pattern once and caches it.
+func inputPattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := inputPatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	inputPatterns.Store(pattern, re)
+	return re, nil
+}
@@ -0,0 +1,197 @@
+package prompty
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/itsatony/go-cuserr"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nestedInputsYAML = `name: chat
+description: Answers a conversation
+inputs:
+  user:
+    type: object
+    required: true
+    properties:
+      name:
+        type: string
+        required: true
+        min_length: 1
+        max_length: 20
+      email:
+        type: string
+        pattern: "^[^@]+@[^@]+$"
+      age:
+        type: integer
+        minimum: 0
+        maximum: 150
+  messages:
+    type: array
+    min_items: 1
+    max_items: 3
+    items:
+      type: object
+      properties:
+        role:
+          type: string
+          required: true
+          enum: [system, user, assistant]
+        content:
+          type: string
+`
+
+func nestedInputsPrompt(t *testing.T) *Prompt {
+	t.Helper()
+	p, err := ParseYAMLPrompt(nestedInputsYAML)
+	require.NoError(t, err)
+	return p
+}
+
+func validNestedInputs() map[string]any {
+	return map[string]any{
+		"user": map[string]any{"name": "Ada", "email": "ada@example.com", "age": 36},
+		"messages": []any{
+			map[string]any{"role": "user", "content": "Hi"},
+			map[string]any{"role": "assistant", "content": "Hello"},
+		},
+	}
+}
+
+func TestPrompt_ValidateInputs_Nested(t *testing.T) {
+	p := nestedInputsPrompt(t)
+	require.NoError(t, p.ValidateInputs(validNestedInputs()))
+
+	tests := []struct {
+		name   string
+		modify func(data map[string]any)
+		msg    string
+		path   string
+		reason string
+	}{
+		{"missing property", func(d map[string]any) { delete(d["user"].(map[string]any), "name") }, ErrMsgRequiredInputMissing, "user.name", ""},
+		{"property type", func(d map[string]any) { d["user"].(map[string]any)["age"] = "old" }, ErrMsgInputValidationFailed, "user.age", ""},
+		{"fractional integer", func(d map[string]any) { d["user"].(map[string]any)["age"] = 36.5 }, ErrMsgInputValidationFailed, "user.age", ""},
+		{"minimum", func(d map[string]any) { d["user"].(map[string]any)["age"] = -1 }, ErrMsgInputValidationFailed, "user.age", "minimum"},
+		{"maximum", func(d map[string]any) { d["user"].(map[string]any)["age"] = 200.0 }, ErrMsgInputValidationFailed, "user.age", "maximum"},
+		{"min length", func(d map[string]any) { d["user"].(map[string]any)["name"] = "" }, ErrMsgInputValidationFailed, "user.name", "min_length"},
+		{"max length", func(d map[string]any) { d["user"].(map[string]any)["name"] = "Augusta Ada King, Countess" }, ErrMsgInputValidationFailed, "user.name", "max_length"},
+		{"pattern", func(d map[string]any) { d["user"].(map[string]any)["email"] = "ada" }, ErrMsgInputValidationFailed, "user.email", "pattern"},
+		{"min items", func(d map[string]any) { d["messages"] = []any{} }, ErrMsgInputValidationFailed, "messages", "min_items"},
+		{"max items", func(d map[string]any) { d["messages"] = []any{nil, nil, nil, nil} }, ErrMsgInputValidationFailed, "messages", "max_items"},
+		{"item type", func(d map[string]any) { d["messages"] = []any{"hi"} }, ErrMsgInputValidationFailed, "messages[0]", ""},
+		{"item enum", func(d map[string]any) {
+			d["messages"].([]any)[1] = map[string]any{"role": "bot"}
+		}, ErrMsgInputValidationFailed, "messages[1].role", coerceReasonEnum},
+		{"item property missing", func(d map[string]any) {
+			d["messages"].([]any)[0] = map[string]any{"content": "Hi"}
+		}, ErrMsgRequiredInputMissing, "messages[0].role", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := validNestedInputs()
+			tt.modify(data)
+			assertInputError(t, p.ValidateInputs(data), tt.msg, tt.path, tt.reason)
+		})
+	}
+
+	// Typed Go maps and slices are validated too
+	err := p.ValidateInputs(map[string]any{
+		"user":     map[string]string{"name": "Ada", "email": "ada"},
+		"messages": []any{map[string]any{"role": "user"}},
+	})
+	assertInputError(t, err, ErrMsgInputValidationFailed, "user.email", "pattern")
+}
+
+// assertInputError asserts an input error's message, input path and, if
+// given, part of its reason.
+func assertInputError(t *testing.T, err error, msg, path, reason string) {
+	t.Helper()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), msg)
+	var customErr *cuserr.CustomError
+	require.True(t, errors.As(err, &customErr))
+	name, _ := customErr.GetMetadata(MetaKeyInputName)
+	assert.Equal(t, path, name)
+	if reason != "" {
+		got, _ := customErr.GetMetadata(MetaKeyReason)
+		assert.Contains(t, got, reason)
+	}
+}
+
+func TestInputDef_JSONSchema(t *testing.T) {
+	schema := nestedInputsPrompt(t).InputsJSONSchema()
+
+	assert.Equal(t, map[string]any{
+		SchemaKeyType:     SchemaTypeObject,
+		SchemaKeyRequired: []any{"user"},
+		SchemaKeyProperties: map[string]any{
+			"user": map[string]any{
+				SchemaKeyType:     SchemaTypeObject,
+				SchemaKeyRequired: []any{"name"},
+				SchemaKeyProperties: map[string]any{
+					"name":  map[string]any{SchemaKeyType: SchemaTypeString, SchemaKeyMinLength: 1, SchemaKeyMaxLength: 20},
+					"email": map[string]any{SchemaKeyType: SchemaTypeString, SchemaKeyPattern: "^[^@]+@[^@]+$"},
+					"age":   map[string]any{SchemaKeyType: SchemaTypeInteger, SchemaKeyMinimum: 0.0, SchemaKeyMaximum: 150.0},
+				},
+			},
+			"messages": map[string]any{
+				SchemaKeyType:     SchemaTypeArray,
+				SchemaKeyMinItems: 1,
+				SchemaKeyMaxItems: 3,
+				SchemaKeyItems: map[string]any{
+					SchemaKeyType:     SchemaTypeObject,
+					SchemaKeyRequired: []any{"role"},
+					SchemaKeyProperties: map[string]any{
+						"role":    map[string]any{SchemaKeyType: SchemaTypeString, SchemaKeyEnum: []any{"system", "user", "assistant"}},
+						"content": map[string]any{SchemaKeyType: SchemaTypeString},
+					},
+				},
+			},
+		},
+	}, schema)
+
+	result := ValidateJSONSchema(schema)
+	assert.True(t, result.Valid, result.Errors)
+
+	assert.Nil(t, (&Prompt{}).InputsJSONSchema())
+}
+
+func TestInputDef_Clone_Nested(t *testing.T) {
+	p := nestedInputsPrompt(t)
+	clone := p.Clone()
+
+	clone.Inputs["user"].Properties["name"].Required = false
+	*clone.Inputs["user"].Properties["age"].Minimum = 18
+	clone.Inputs["messages"].Items.Properties["role"].Enum[0] = "tool"
+
+	assert.True(t, p.Inputs["user"].Properties["name"].Required)
+	assert.Equal(t, 0.0, *p.Inputs["user"].Properties["age"].Minimum)
+	assert.Equal(t, "system", p.Inputs["messages"].Items.Properties["role"].Enum[0])
+}
+
+func TestPrompt_CoerceInputs_Nested(t *testing.T) {
+	p := &Prompt{Inputs: map[string]*InputDef{
+		"filters": {Type: SchemaTypeObject, Properties: map[string]*InputDef{
+			"limit":  {Type: SchemaTypeInteger, Default: 10},
+			"strict": {Type: SchemaTypeBoolean},
+		}},
+		"scores": {Type: SchemaTypeArray, Items: &InputDef{Type: SchemaTypeNumber}},
+	}}
+	data := map[string]any{
+		"filters": `{"strict":"yes"}`,
+		"scores":  []any{"0.5", 2},
+	}
+
+	result, err := p.CoerceInputs(data)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"limit": 10, "strict": true}, result["filters"])
+	assert.Equal(t, []any{0.5, 2.0}, result["scores"])
+	assert.Equal(t, []any{"0.5", 2}, data["scores"], "data is not modified")
+
+	_, err = p.CoerceInputs(map[string]any{"scores": []any{"1", "high"}})
+	assertInputError(t, err, ErrMsgInputValidationFailed, "scores[1]", "")
+}
@@ -0,0 +1,370 @@
+package prompty
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// Lint message formats
+const (
+	lintDetailFormat = "%s: %q"       // message: "name"
+	lintLimitFormat  = "%s (%d > %d)" // message (actual > limit)
+	lintHintSep      = "; "
+)
+
+// defaultLintSeverities maps each lint rule to its default severity.
+var defaultLintSeverities = map[string]ValidationSeverity{
+	LintRuleUnusedBlock:        SeverityWarning,
+	LintRuleShadowedLoopVar:    SeverityWarning,
+	LintRuleSuspiciousDefault:  SeverityWarning,
+	LintRuleNestedConditional:  SeverityInfo,
+	LintRuleOversizedText:      SeverityInfo,
+	LintRuleDeprecatedTag:      SeverityWarning,
+	LintRuleMissingDescription: SeverityWarning,
+}
+
+// LintConfig configures which lint rules run and how they are tuned.
+// The zero value enables every rule with default thresholds.
+type LintConfig struct {
+	// DisabledRules lists rule IDs (e.g. "TEXT001") that are skipped.
+	DisabledRules []string
+	// Severities overrides the default severity of individual rules.
+	Severities map[string]ValidationSeverity
+	// MaxConditionalDepth is the deepest allowed nesting of prompty.if blocks.
+	// Default: DefaultLintMaxConditionalDepth
+	MaxConditionalDepth int
+	// MaxTextLength is the largest allowed inline text run in bytes.
+	// Default: DefaultLintMaxTextLength
+	MaxTextLength int
+	// SuspiciousDefaults lists default values reported by VAR003, compared
+	// case-insensitively. Nil uses DefaultLintSuspiciousDefaults().
+	SuspiciousDefaults []string
+	// DeprecatedTags maps deprecated tag names to a replacement hint.
+	// Nil uses DefaultLintDeprecatedTags(); custom resolvers can be added here.
+	DeprecatedTags map[string]string
+}
+
+// DefaultLintConfig returns a LintConfig with every rule enabled and default thresholds.
+func DefaultLintConfig() LintConfig {
+	return LintConfig{
+		MaxConditionalDepth: DefaultLintMaxConditionalDepth,
+		MaxTextLength:       DefaultLintMaxTextLength,
+		SuspiciousDefaults:  DefaultLintSuspiciousDefaults(),
+		DeprecatedTags:      DefaultLintDeprecatedTags(),
+	}
+}
+
+// DefaultLintSuspiciousDefaults returns the default values that usually
+// indicate a forgotten placeholder.
+func DefaultLintSuspiciousDefaults() []string {
+	return []string{"null", "nil", "none", "undefined", "<no value>", "todo", "tbd", "fixme", "xxx", "changeme"}
+}
+
+// DefaultLintDeprecatedTags returns the built-in deprecated tags and their replacement hints.
+func DefaultLintDeprecatedTags() map[string]string {
+	return map[string]string{
+		TagNameConfig: LintMsgDeprecatedConfigTagReason,
+	}
+}
+
+// LintRules returns all lint rule IDs in sorted order.
+func LintRules() []string {
+	rules := make([]string, 0, len(defaultLintSeverities))
+	for rule := range defaultLintSeverities {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	return rules
+}
+
+// LintFinding represents a single lint finding.
+type LintFinding struct {
+	RuleID   string
+	Severity ValidationSeverity
+	Message  string
+	Position Position
+	TagName  string
+}
+
+// LintResult contains the findings of a lint run.
+type LintResult struct {
+	findings []LintFinding
+}
+
+// Findings returns all findings in source order.
+func (r *LintResult) Findings() []LintFinding {
+	return r.findings
+}
+
+// HasFindings returns true if any rule reported a finding.
+func (r *LintResult) HasFindings() bool {
+	return len(r.findings) > 0
+}
+
+// BySeverity returns only findings with the given severity.
+func (r *LintResult) BySeverity(severity ValidationSeverity) []LintFinding {
+	var findings []LintFinding
+	for _, f := range r.findings {
+		if f.Severity == severity {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// ByRule returns only findings reported by the given rule ID.
+func (r *LintResult) ByRule(ruleID string) []LintFinding {
+	var findings []LintFinding
+	for _, f := range r.findings {
+		if f.RuleID == ruleID {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// Lint checks a template for issues that are syntactically valid but likely
+// mistakes: unused block definitions, shadowed loop variables, suspicious
+// default values, deeply nested conditionals, oversized inline text,
+// deprecated tags and missing frontmatter descriptions.
+// Structural problems are the job of Engine.Validate; a template that does
+// not parse is returned as an error.
+func Lint(source string, config LintConfig) (*LintResult, error) {
+	l := newLinter(config)
+
+	fm, err := internal.ExtractYAMLFrontmatter(source)
+	if err != nil {
+		pos := Position{}
+		if configErr, ok := err.(*internal.ConfigError); ok {
+			pos = Position{
+				Offset: configErr.Position.Offset,
+				Line:   configErr.Position.Line,
+				Column: configErr.Position.Column,
+			}
+		}
+		return nil, NewConfigBlockError(ErrMsgConfigBlockExtract, pos, err)
+	}
+
+	if fm.HasFrontmatter {
+		prompt, err := ParseYAMLPrompt(fm.FrontmatterYAML)
+		if err != nil {
+			return nil, err
+		}
+		l.lintPrompt(prompt)
+	}
+
+	body := fm.TemplateBody
+	l.bodyOffset = len(source) - len(body)
+	l.lineOffset = strings.Count(source[:l.bodyOffset], "\n")
+
+	tokens, err := internal.NewLexer(body, nil).Tokenize()
+	if err != nil {
+		return nil, NewParseError(ErrMsgParseFailed, Position{}, err)
+	}
+	ast, err := internal.NewParserWithSource(tokens, body, nil).Parse()
+	if err != nil {
+		return nil, NewParseError(ErrMsgParseFailed, Position{}, err)
+	}
+
+	inheritance, _ := internal.ExtractInheritanceInfo(ast)
+	l.isChild = inheritance != nil
+	l.walk(ast.Children, 0)
+
+	sort.SliceStable(l.result.findings, func(i, j int) bool {
+		return l.result.findings[i].Position.Offset < l.result.findings[j].Position.Offset
+	})
+	return l.result, nil
+}
+
+// linter holds the state of a single Lint run.
+type linter struct {
+	config     LintConfig
+	disabled   map[string]bool
+	suspicious map[string]bool
+	result     *LintResult
+	isChild    bool     // Template uses prompty.extends
+	loopVars   []string // Item/index variables of enclosing loops
+	condDepth  int      // Current prompty.if nesting
+	bodyOffset int      // Byte offset of the template body in the source
+	lineOffset int      // Lines consumed by frontmatter before the body
+}
+
+func newLinter(config LintConfig) *linter {
+	if config.MaxConditionalDepth <= 0 {
+		config.MaxConditionalDepth = DefaultLintMaxConditionalDepth
+	}
+	if config.MaxTextLength <= 0 {
+		config.MaxTextLength = DefaultLintMaxTextLength
+	}
+	if config.SuspiciousDefaults == nil {
+		config.SuspiciousDefaults = DefaultLintSuspiciousDefaults()
+	}
+	if config.DeprecatedTags == nil {
+		config.DeprecatedTags = DefaultLintDeprecatedTags()
+	}
+
+	l := &linter{
+		config:     config,
+		disabled:   make(map[string]bool, len(config.DisabledRules)),
+		suspicious: make(map[string]bool, len(config.SuspiciousDefaults)),
+		result:     &LintResult{findings: make([]LintFinding, 0)},
+	}
+	for _, rule := range config.DisabledRules {
+		l.disabled[strings.ToUpper(strings.TrimSpace(rule))] = true
+	}
+	for _, value := range config.SuspiciousDefaults {
+		l.suspicious[strings.ToLower(value)] = true
+	}
+	return l
+}
+
+// report records a finding unless its rule is disabled.
+func (l *linter) report(ruleID, message string, pos Position, tagName string) {
+	if l.disabled[ruleID] {
+		return
+	}
+	severity, ok := l.config.Severities[ruleID]
+	if !ok {
+		severity = defaultLintSeverities[ruleID]
+	}
+	l.result.findings = append(l.result.findings, LintFinding{
+		RuleID:   ruleID,
+		Severity: severity,
+		Message:  message,
+		Position: pos,
+		TagName:  tagName,
+	})
+}
+
+// position converts a body position into a position in the full source.
+func (l *linter) position(pos internal.Position) Position {
+	return Position{
+		Offset: pos.Offset + l.bodyOffset,
+		Line:   pos.Line + l.lineOffset,
+		Column: pos.Column,
+	}
+}
+
+// lintPrompt checks the frontmatter for missing descriptions. Empty
+// frontmatter parses to a nil prompt, which lacks a description too.
+func (l *linter) lintPrompt(prompt *Prompt) {
+	start := Position{Line: 1, Column: 1}
+	if prompt == nil {
+		l.report(LintRuleMissingDescription, LintMsgMissingPromptDescription, start, "")
+		return
+	}
+	if prompt.Description == "" {
+		l.report(LintRuleMissingDescription, LintMsgMissingPromptDescription, start, "")
+	}
+
+	names := make([]string, 0, len(prompt.Inputs))
+	for name := range prompt.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if input := prompt.Inputs[name]; input == nil || input.Description == "" {
+			l.report(LintRuleMissingDescription, fmt.Sprintf(lintDetailFormat, LintMsgMissingInputDescription, name), start, "")
+		}
+	}
+}
+
+// walk lints a slice of nodes at the given inheritance depth
+// (0 = template root, where child-template blocks take effect).
+func (l *linter) walk(nodes []internal.Node, depth int) {
+	for _, node := range nodes {
+		l.lintNode(node, depth)
+	}
+}
+
+// lintNode lints a single node and its children.
+func (l *linter) lintNode(node internal.Node, depth int) {
+	switch n := node.(type) {
+	case *internal.TextNode:
+		if len(n.Content) > l.config.MaxTextLength {
+			l.report(LintRuleOversizedText, fmt.Sprintf(lintLimitFormat, LintMsgOversizedText, len(n.Content), l.config.MaxTextLength), l.position(n.Pos()), "")
+		}
+
+	case *internal.TagNode:
+		l.lintTag(n)
+		if !n.IsRaw() {
+			l.walk(n.Children, depth+1)
+		}
+
+	case *internal.BlockNode:
+		if l.isChild && depth > 0 {
+			l.report(LintRuleUnusedBlock, fmt.Sprintf(lintDetailFormat, LintMsgUnusedBlock, n.Name), l.position(n.Pos()), TagNameBlock)
+		}
+		l.walk(n.Children, depth+1)
+
+	case *internal.ConditionalNode:
+		l.condDepth++
+		if l.condDepth == l.config.MaxConditionalDepth+1 {
+			l.report(LintRuleNestedConditional, fmt.Sprintf(lintLimitFormat, LintMsgNestedConditional, l.condDepth, l.config.MaxConditionalDepth), l.position(n.Pos()), TagNameIf)
+		}
+		for _, branch := range n.Branches {
+			l.walk(branch.Children, depth+1)
+		}
+		l.condDepth--
+
+	case *internal.ForNode:
+		l.lintLoop(n, depth)
+
+	case *internal.SwitchNode:
+		for _, c := range n.Cases {
+			l.walk(c.Children, depth+1)
+		}
+		if n.Default != nil {
+			l.walk(n.Default.Children, depth+1)
+		}
+	}
+}
+
+// lintTag checks a tag for deprecated names and suspicious defaults.
+func (l *linter) lintTag(tag *internal.TagNode) {
+	if hint, deprecated := l.config.DeprecatedTags[tag.Name]; deprecated {
+		msg := fmt.Sprintf(lintDetailFormat, LintMsgDeprecatedTag, tag.Name)
+		if hint != "" {
+			msg += lintHintSep + hint
+		}
+		l.report(LintRuleDeprecatedTag, msg, l.position(tag.Pos()), tag.Name)
+	}
+
+	value, ok := tag.Attributes.Get(AttrDefault)
+	if !ok {
+		return
+	}
+	switch {
+	case strings.Contains(value, DefaultOpenDelim):
+		l.report(LintRuleSuspiciousDefault, fmt.Sprintf(lintDetailFormat, LintMsgDefaultContainsTag, value), l.position(tag.Pos()), tag.Name)
+	case value != "" && strings.TrimSpace(value) == "",
+		l.suspicious[strings.ToLower(strings.TrimSpace(value))]:
+		l.report(LintRuleSuspiciousDefault, fmt.Sprintf(lintDetailFormat, LintMsgSuspiciousDefault, value), l.position(tag.Pos()), tag.Name)
+	}
+}
+
+// lintLoop checks a loop for variables shadowing an enclosing loop and lints its body.
+func (l *linter) lintLoop(loop *internal.ForNode, depth int) {
+	for _, name := range []string{loop.ItemVar, loop.IndexVar} {
+		if name == "" {
+			continue
+		}
+		for _, outer := range l.loopVars {
+			if name == outer {
+				l.report(LintRuleShadowedLoopVar, fmt.Sprintf(lintDetailFormat, LintMsgShadowedLoopVar, name), l.position(loop.Pos()), TagNameFor)
+				break
+			}
+		}
+	}
+
+	saved := l.loopVars
+	l.loopVars = append(l.loopVars[:len(l.loopVars):len(l.loopVars)], loop.ItemVar)
+	if loop.IndexVar != "" {
+		l.loopVars = append(l.loopVars, loop.IndexVar)
+	}
+	l.walk(loop.Children, depth+1)
+	l.loopVars = saved
+}
@@ -0,0 +1,248 @@
+package prompty
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint_CleanTemplate(t *testing.T) {
+	source := `Hello {~prompty.var name="user" default="Guest" /~}!
+{~prompty.for item="x" in="items"~}{~prompty.var name="x" /~}{~/prompty.for~}`
+
+	result, err := Lint(source, LintConfig{})
+	require.NoError(t, err)
+	assert.False(t, result.HasFindings())
+	assert.Empty(t, result.Findings())
+}
+
+func TestLint_Rules(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		rule    string
+		count   int
+		message string
+	}{
+		{
+			name:    "unused block nested in child template conditional",
+			source:  `{~prompty.extends template="base" /~}{~prompty.if eval="x"~}{~prompty.block name="header"~}H{~/prompty.block~}{~/prompty.if~}`,
+			rule:    LintRuleUnusedBlock,
+			count:   1,
+			message: `"header"`,
+		},
+		{
+			name:   "top-level block in child template is used",
+			source: `{~prompty.extends template="base" /~}{~prompty.block name="header"~}H{~/prompty.block~}`,
+			rule:   LintRuleUnusedBlock,
+			count:  0,
+		},
+		{
+			name:   "nested block without extends is rendered",
+			source: `{~prompty.if eval="x"~}{~prompty.block name="header"~}H{~/prompty.block~}{~/prompty.if~}`,
+			rule:   LintRuleUnusedBlock,
+			count:  0,
+		},
+		{
+			name:    "shadowed item variable",
+			source:  `{~prompty.for item="x" in="a"~}{~prompty.for item="x" in="x.b"~}{~/prompty.for~}{~/prompty.for~}`,
+			rule:    LintRuleShadowedLoopVar,
+			count:   1,
+			message: `"x"`,
+		},
+		{
+			name:    "shadowed index variable",
+			source:  `{~prompty.for item="x" index="i" in="a"~}{~prompty.for item="y" index="i" in="b"~}{~/prompty.for~}{~/prompty.for~}`,
+			rule:    LintRuleShadowedLoopVar,
+			count:   1,
+			message: `"i"`,
+		},
+		{
+			name:   "sibling loops reuse variable",
+			source: `{~prompty.for item="x" in="a"~}{~/prompty.for~}{~prompty.for item="x" in="b"~}{~/prompty.for~}`,
+			rule:   LintRuleShadowedLoopVar,
+			count:  0,
+		},
+		{
+			name:   "placeholder defaults",
+			source: `{~prompty.var name="a" default="TODO" /~}{~prompty.var name="b" default="null" /~}{~prompty.var name="c" default="  " /~}`,
+			rule:   LintRuleSuspiciousDefault,
+			count:  3,
+		},
+		{
+			name:    "default containing tag delimiter",
+			source:  `{~prompty.var name="a" default="{~prompty.var name='b' /~}" /~}`,
+			rule:    LintRuleSuspiciousDefault,
+			count:   1,
+			message: LintMsgDefaultContainsTag,
+		},
+		{
+			name:   "empty default is intentional",
+			source: `{~prompty.var name="a" default="" /~}`,
+			rule:   LintRuleSuspiciousDefault,
+			count:  0,
+		},
+		{
+			name:   "conditionals nested too deep",
+			source: `{~prompty.if eval="a"~}{~prompty.if eval="b"~}{~prompty.if eval="c"~}{~prompty.if eval="d"~}{~prompty.if eval="e"~}x{~/prompty.if~}{~/prompty.if~}{~/prompty.if~}{~/prompty.if~}{~/prompty.if~}`,
+			rule:   LintRuleNestedConditional,
+			count:  1,
+		},
+		{
+			name:   "conditionals within depth",
+			source: `{~prompty.if eval="a"~}{~prompty.if eval="b"~}{~prompty.if eval="c"~}x{~/prompty.if~}{~/prompty.if~}{~/prompty.if~}`,
+			rule:   LintRuleNestedConditional,
+			count:  0,
+		},
+		{
+			name:   "oversized text",
+			source: strings.Repeat("a", DefaultLintMaxTextLength+1),
+			rule:   LintRuleOversizedText,
+			count:  1,
+		},
+		{
+			name:    "deprecated tag",
+			source:  `Body {~prompty.config~}{}{~/prompty.config~}`,
+			rule:    LintRuleDeprecatedTag,
+			count:   1,
+			message: LintMsgDeprecatedConfigTagReason,
+		},
+		{
+			name:    "missing descriptions in frontmatter",
+			source:  "---\nname: test\ninputs:\n  user:\n    type: string\n  topic:\n    type: string\n    description: The topic\n---\nHello",
+			rule:    LintRuleMissingDescription,
+			count:   2,
+			message: "",
+		},
+		{
+			name:    "empty frontmatter",
+			source:  "---\n---\nhello",
+			rule:    LintRuleMissingDescription,
+			count:   1,
+			message: LintMsgMissingPromptDescription,
+		},
+		{
+			name:    "blank frontmatter without body",
+			source:  "---\n\n---",
+			rule:    LintRuleMissingDescription,
+			count:   1,
+			message: LintMsgMissingPromptDescription,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Lint(tt.source, LintConfig{})
+			require.NoError(t, err)
+
+			findings := result.ByRule(tt.rule)
+			require.Len(t, findings, tt.count)
+			if tt.message != "" {
+				assert.Contains(t, findings[0].Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestLint_DefaultSeverities(t *testing.T) {
+	source := `{~prompty.var name="a" default="TODO" /~}` + strings.Repeat("a", DefaultLintMaxTextLength+1)
+
+	result, err := Lint(source, LintConfig{})
+	require.NoError(t, err)
+
+	require.Len(t, result.ByRule(LintRuleSuspiciousDefault), 1)
+	assert.Equal(t, SeverityWarning, result.ByRule(LintRuleSuspiciousDefault)[0].Severity)
+	require.Len(t, result.ByRule(LintRuleOversizedText), 1)
+	assert.Equal(t, SeverityInfo, result.ByRule(LintRuleOversizedText)[0].Severity)
+	assert.Len(t, result.BySeverity(SeverityWarning), 1)
+	assert.Len(t, result.BySeverity(SeverityInfo), 1)
+}
+
+func TestLint_Config(t *testing.T) {
+	source := `{~prompty.var name="a" default="TODO" /~}{~prompty.var name="b" default="pending" /~}{~prompty.legacy /~}`
+
+	t.Run("disabled rules", func(t *testing.T) {
+		result, err := Lint(source, LintConfig{DisabledRules: []string{"var003"}})
+		require.NoError(t, err)
+		assert.Empty(t, result.ByRule(LintRuleSuspiciousDefault))
+	})
+
+	t.Run("severity override", func(t *testing.T) {
+		result, err := Lint(source, LintConfig{
+			Severities: map[string]ValidationSeverity{LintRuleSuspiciousDefault: SeverityError},
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, result.ByRule(LintRuleSuspiciousDefault))
+		assert.Equal(t, SeverityError, result.ByRule(LintRuleSuspiciousDefault)[0].Severity)
+	})
+
+	t.Run("custom suspicious defaults and deprecated tags", func(t *testing.T) {
+		result, err := Lint(source, LintConfig{
+			SuspiciousDefaults: []string{"Pending"},
+			DeprecatedTags:     map[string]string{"prompty.legacy": "use prompty.modern"},
+		})
+		require.NoError(t, err)
+
+		defaults := result.ByRule(LintRuleSuspiciousDefault)
+		require.Len(t, defaults, 1)
+		assert.Contains(t, defaults[0].Message, "pending")
+
+		deprecated := result.ByRule(LintRuleDeprecatedTag)
+		require.Len(t, deprecated, 1)
+		assert.Equal(t, "prompty.legacy", deprecated[0].TagName)
+		assert.Contains(t, deprecated[0].Message, "use prompty.modern")
+	})
+
+	t.Run("thresholds", func(t *testing.T) {
+		result, err := Lint(`{~prompty.if eval="a"~}{~prompty.if eval="b"~}0123456789{~/prompty.if~}{~/prompty.if~}`, LintConfig{
+			MaxConditionalDepth: 1,
+			MaxTextLength:       5,
+		})
+		require.NoError(t, err)
+		assert.Len(t, result.ByRule(LintRuleNestedConditional), 1)
+		assert.Len(t, result.ByRule(LintRuleOversizedText), 1)
+	})
+}
+
+func TestLint_PositionsIncludeFrontmatter(t *testing.T) {
+	source := "---\nname: test\ndescription: A test\n---\nline one\n{~prompty.var name=\"a\" default=\"TODO\" /~}"
+
+	result, err := Lint(source, DefaultLintConfig())
+	require.NoError(t, err)
+
+	findings := result.Findings()
+	require.Len(t, findings, 1)
+	assert.Equal(t, LintRuleSuspiciousDefault, findings[0].RuleID)
+	assert.Equal(t, 6, findings[0].Position.Line)
+	assert.Equal(t, 1, findings[0].Position.Column)
+	assert.Equal(t, strings.Index(source, "{~prompty.var"), findings[0].Position.Offset)
+}
+
+func TestLint_FindingsSortedBySource(t *testing.T) {
+	source := `{~prompty.var name="a" default="nil" /~}{~prompty.for item="x" in="a"~}{~prompty.for item="x" in="b"~}{~/prompty.for~}{~/prompty.for~}{~prompty.var name="b" default="nil" /~}`
+
+	result, err := Lint(source, LintConfig{})
+	require.NoError(t, err)
+
+	findings := result.Findings()
+	require.Len(t, findings, 3)
+	assert.Equal(t, LintRuleSuspiciousDefault, findings[0].RuleID)
+	assert.Equal(t, LintRuleShadowedLoopVar, findings[1].RuleID)
+	assert.Equal(t, LintRuleSuspiciousDefault, findings[2].RuleID)
+}
+
+func TestLint_ParseError(t *testing.T) {
+	_, err := Lint(`{~prompty.if eval="x"~}unclosed`, LintConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgParseFailed)
+}
+
+func TestLintRules(t *testing.T) {
+	rules := LintRules()
+	assert.Len(t, rules, 7)
+	assert.Contains(t, rules, LintRuleUnusedBlock)
+	assert.Contains(t, rules, LintRuleMissingDescription)
+	assert.IsIncreasing(t, rules)
+}
@@ -0,0 +1,168 @@
+package prompty
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Message placeholder syntax: {key} is replaced by the value of key
+const (
+	messageParamOpen  = "{"
+	messageParamClose = "}"
+	localeSeparator   = "-"
+	localeAltSep      = "_"
+)
+
+// MessageCatalog holds translated message templates by locale, keyed by
+// validation issue codes (IssueCode constants) and error kinds (ErrorKind
+// values), so UIs can show validation issues and errors in the author's
+// language while logs keep the canonical codes and English messages.
+//
+// Templates may reference the values of a message as {key}: the issue's
+// Params and its tag as {tag}, or the error's metadata.
+//
+//	catalog := prompty.NewMessageCatalog()
+//	catalog.Register("de", map[string]string{
+//		prompty.IssueCodeMissingInclude:            `Eingebundene Vorlage "{template_name}" nicht gefunden`,
+//		string(prompty.ErrorKindTemplateNotFound): `Vorlage "{template_name}" nicht gefunden`,
+//	})
+//	engine := prompty.MustNew(prompty.WithMessageCatalog(catalog), prompty.WithLocale("de-AT"))
+//
+// A MessageCatalog is safe for concurrent use.
+type MessageCatalog struct {
+	mu      sync.RWMutex
+	locales map[string]map[string]string
+}
+
+// NewMessageCatalog creates an empty message catalog.
+func NewMessageCatalog() *MessageCatalog {
+	return &MessageCatalog{locales: make(map[string]map[string]string)}
+}
+
+// Register adds the message templates of a locale, replacing templates
+// already registered for the same codes. Locales are BCP 47 tags such as
+// "de" or "pt-BR", compared case-insensitively.
+func (c *MessageCatalog) Register(locale string, messages map[string]string) {
+	locale = normalizeLocale(locale)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	existing := c.locales[locale]
+	if existing == nil {
+		existing = make(map[string]string, len(messages))
+		c.locales[locale] = existing
+	}
+	for code, template := range messages {
+		existing[code] = template
+	}
+}
+
+// Locales returns the registered locales in sorted order.
+func (c *MessageCatalog) Locales() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	locales := make([]string, 0, len(c.locales))
+	for locale := range c.locales {
+		locales = append(locales, locale)
+	}
+	slices.Sort(locales)
+	return locales
+}
+
+// Message returns the message for code in locale with params filled in.
+// A regional locale such as "de-AT" falls back to its language "de". It
+// returns false if neither has a template for code.
+func (c *MessageCatalog) Message(locale, code string, params map[string]string) (string, bool) {
+	locale = normalizeLocale(locale)
+	c.mu.RLock()
+	template, ok := c.locales[locale][code]
+	if !ok {
+		if language, _, regional := strings.Cut(locale, localeSeparator); regional {
+			template, ok = c.locales[language][code]
+		}
+	}
+	c.mu.RUnlock()
+	if !ok {
+		return "", false
+	}
+	return fillMessageParams(template, params), true
+}
+
+// fillMessageParams replaces the {key} placeholders of template with params.
+func fillMessageParams(template string, params map[string]string) string {
+	if len(params) == 0 || !strings.Contains(template, messageParamOpen) {
+		return template
+	}
+	pairs := make([]string, 0, 2*len(params))
+	for key, value := range params {
+		pairs = append(pairs, messageParamOpen+key+messageParamClose, value)
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
+
+// normalizeLocale lowercases a locale and uses "-" as its separator.
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(locale, localeAltSep, localeSeparator))
+}
+
+// WithMessageCatalog sets the catalog of translated messages used by
+// LocalizeIssue and LocalizeError.
+func WithMessageCatalog(catalog *MessageCatalog) Option {
+	return func(c *engineConfig) {
+		c.messageCatalog = catalog
+	}
+}
+
+// WithLocale sets the locale of the messages returned by LocalizeIssue and
+// LocalizeError. Combine it with WithOverrides for a per-request locale:
+//
+//	reqEngine, err := engine.WithOverrides(prompty.WithLocale(req.Header.Get("Accept-Language")))
+func WithLocale(locale string) Option {
+	return func(c *engineConfig) {
+		c.locale = locale
+	}
+}
+
+// Locale returns the locale set with WithLocale, or "" for none.
+func (e *Engine) Locale() string {
+	return e.config.locale
+}
+
+// LocalizeIssue returns the message of a validation issue in the engine's
+// locale, or its canonical message if the catalog has no translation.
+func (e *Engine) LocalizeIssue(issue ValidationIssue) string {
+	params := issue.Params
+	if issue.TagName != "" {
+		params = make(map[string]string, len(issue.Params)+1)
+		for key, value := range issue.Params {
+			params[key] = value
+		}
+		params[MetaKeyTag] = issue.TagName
+	}
+	if message, ok := e.localize(issue.Code, params); ok {
+		return message
+	}
+	return issue.Message
+}
+
+// LocalizeError returns the message of err in the engine's locale, looked
+// up by its ErrorKind (see ErrorDetailsOf), or its canonical message if the
+// catalog has no translation. It returns "" for a nil error.
+func (e *Engine) LocalizeError(err error) string {
+	details := ErrorDetailsOf(err)
+	if details == nil {
+		return ""
+	}
+	if message, ok := e.localize(string(details.Kind), details.Metadata); ok {
+		return message
+	}
+	return err.Error()
+}
+
+// localize returns the catalog message for code in the engine's locale.
+func (e *Engine) localize(code string, params map[string]string) (string, bool) {
+	if e.config.messageCatalog == nil || e.config.locale == "" || code == "" {
+		return "", false
+	}
+	return e.config.messageCatalog.Message(e.config.locale, code, params)
+}
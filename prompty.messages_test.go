@@ -0,0 +1,147 @@
+package prompty
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGermanCatalog() *MessageCatalog {
+	catalog := NewMessageCatalog()
+	catalog.Register("de", map[string]string{
+		IssueCodeMissingInclude:           `Eingebundene Vorlage "{template_name}" in {tag} nicht gefunden`,
+		IssueCodeForMissingItem:           `prompty.for benötigt das Attribut "item"`,
+		string(ErrorKindTemplateNotFound): `Vorlage "{template_name}" nicht gefunden`,
+	})
+	catalog.Register("de-CH", map[string]string{
+		IssueCodeForMissingItem: `prompty.for brucht s Attribut "item"`,
+	})
+	return catalog
+}
+
+func TestMessageCatalog(t *testing.T) {
+	catalog := newGermanCatalog()
+	assert.Equal(t, []string{"de", "de-ch"}, catalog.Locales())
+
+	message, ok := catalog.Message("de", IssueCodeMissingInclude, map[string]string{MetaKeyTemplateName: "footer", MetaKeyTag: TagNameInclude})
+	require.True(t, ok)
+	assert.Equal(t, `Eingebundene Vorlage "footer" in prompty.include nicht gefunden`, message)
+
+	t.Run("regional fallback", func(t *testing.T) {
+		message, ok := catalog.Message("de_AT", IssueCodeForMissingItem, nil)
+		require.True(t, ok)
+		assert.Equal(t, `prompty.for benötigt das Attribut "item"`, message)
+
+		message, ok = catalog.Message("DE-ch", IssueCodeForMissingItem, nil)
+		require.True(t, ok)
+		assert.Equal(t, `prompty.for brucht s Attribut "item"`, message)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, ok := catalog.Message("fr", IssueCodeForMissingItem, nil)
+		assert.False(t, ok)
+		_, ok = catalog.Message("de-CH", IssueCodeUnknownTag, nil)
+		assert.False(t, ok)
+	})
+
+	t.Run("register merges", func(t *testing.T) {
+		catalog.Register("de", map[string]string{IssueCodeUnknownTag: "Unbekannter Tag {tag}"})
+		message, ok := catalog.Message("de", IssueCodeUnknownTag, map[string]string{MetaKeyTag: "x"})
+		require.True(t, ok)
+		assert.Equal(t, "Unbekannter Tag x", message)
+		_, ok = catalog.Message("de", IssueCodeForMissingItem, nil)
+		assert.True(t, ok)
+	})
+
+	t.Run("concurrent use", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				catalog.Register("fr", map[string]string{IssueCodeUnknownTag: "Balise inconnue"})
+			}()
+			go func() {
+				defer wg.Done()
+				catalog.Message("de", IssueCodeUnknownTag, nil)
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestEngine_LocalizeIssue(t *testing.T) {
+	engine := MustNew(WithMessageCatalog(newGermanCatalog()), WithLocale("de-AT"))
+	assert.Equal(t, "de-AT", engine.Locale())
+
+	result, err := engine.Validate(`{~prompty.include template="footer" /~}{~prompty.unknown /~}`)
+	require.NoError(t, err)
+	require.Len(t, result.Issues(), 2)
+
+	missing := result.Issues()[0]
+	assert.Equal(t, IssueCodeMissingInclude, missing.Code)
+	assert.Equal(t, ErrMsgMissingIncludeTarget, missing.Message)
+	assert.Equal(t, "footer", missing.Params[MetaKeyTemplateName])
+	assert.Equal(t, `Eingebundene Vorlage "footer" in prompty.include nicht gefunden`, engine.LocalizeIssue(missing))
+
+	// Untranslated issues keep their canonical message
+	unknown := result.Issues()[1]
+	assert.Equal(t, IssueCodeUnknownTag, unknown.Code)
+	assert.Equal(t, ErrMsgUnknownTagInTemplate, engine.LocalizeIssue(unknown))
+
+	t.Run("without locale", func(t *testing.T) {
+		engine := MustNew(WithMessageCatalog(newGermanCatalog()))
+		assert.Equal(t, ErrMsgMissingIncludeTarget, engine.LocalizeIssue(missing))
+	})
+
+	t.Run("per request locale", func(t *testing.T) {
+		engine := MustNew(WithMessageCatalog(newGermanCatalog()))
+		reqEngine, err := engine.WithOverrides(WithLocale("de"))
+		require.NoError(t, err)
+		assert.Equal(t, `Eingebundene Vorlage "footer" in prompty.include nicht gefunden`, reqEngine.LocalizeIssue(missing))
+		assert.Empty(t, engine.Locale())
+	})
+}
+
+func TestValidationIssueCodes(t *testing.T) {
+	engine := MustNew(WithStrict())
+
+	tests := []struct {
+		name   string
+		source string
+		code   string
+	}{
+		{"parse", `{~prompty.var name="x"`, IssueCodeParseFailed},
+		{"unknown tag", `{~prompty.unknown /~}`, IssueCodeUnknownTag},
+		{"invalid attributes", `{~prompty.var /~}`, IssueCodeInvalidAttributes},
+		{"invalid onerror", `{~prompty.var name="x" onerror="explode" /~}`, IssueCodeInvalidOnError},
+		{"invalid cache", `{~prompty.var name="x" cache="soon" /~}`, IssueCodeInvalidCacheTTL},
+		{"missing import", `{~prompty.import template="lib" as="lib" /~}`, IssueCodeMissingImport},
+		{"missing fallback", `{~prompty.var name="x" onerror="include:gone" /~}`, IssueCodeMissingFallback},
+		{"unknown attribute", `{~prompty.var name="x" colour="red" /~}`, IssueCodeUnknownAttribute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := engine.Validate(tt.source)
+			require.NoError(t, err)
+			require.NotEmpty(t, result.Issues())
+			assert.Equal(t, tt.code, result.Issues()[0].Code, result.Issues()[0].Message)
+		})
+	}
+}
+
+func TestEngine_LocalizeError(t *testing.T) {
+	engine := MustNew(WithMessageCatalog(newGermanCatalog()), WithLocale("de"))
+
+	_, err := engine.Execute(context.Background(), `{~prompty.include template="footer" /~}`, nil)
+	require.Error(t, err)
+	assert.Equal(t, `Vorlage "footer" nicht gefunden`, engine.LocalizeError(err))
+
+	// Untranslated kinds keep the canonical message
+	parseErr := NewParseError(ErrMsgParseFailed, Position{Line: 1, Column: 1}, nil)
+	assert.Equal(t, parseErr.Error(), engine.LocalizeError(parseErr))
+	assert.Empty(t, engine.LocalizeError(nil))
+}
@@ -1,6 +1,11 @@
 package prompty
 
 import (
+	"path"
+	"strings"
+	"time"
+
+	"github.com/itsatony/go-prompty/v2/internal"
 	"go.uber.org/zap"
 )
 
@@ -9,11 +14,27 @@ type Option func(*engineConfig)
 
 // engineConfig holds the internal configuration for an Engine.
 type engineConfig struct {
-	openDelim     string
-	closeDelim    string
-	errorStrategy ErrorStrategy
-	maxDepth      int
-	logger        *zap.Logger
+	openDelim      string
+	closeDelim     string
+	errorStrategy  ErrorStrategy
+	errorCallback  ErrorCallback // Called for every failed tag; nil for none
+	maxDepth       int
+	logger         *zap.Logger
+	astCache       *ASTCache
+	fieldMatch     FieldMatch
+	includeAllow   []string
+	hooks          *HookRegistry // Engine hooks, shared with the engine's templates
+	tagCache       TagCache      // Backs the cache attribute; nil disables tag caching
+	clock          func() time.Time
+	seed           *int64 // Random seed of every execution; nil for random seeds
+	costEstimator  *CostEstimator
+	environment    string // Environment overlay applied to parsed frontmatter
+	outputMode     OutputMode
+	escapers       map[OutputMode]func(string) string // Custom escapers by output mode
+	strict         bool                               // Strict mode checks at Parse and Validate
+	locale         string                             // Locale of localized messages
+	messageCatalog *MessageCatalog                    // Translated messages; nil for canonical messages only
+	withoutTags    []string                           // Built-in tags not registered
 }
 
 // defaultEngineConfig returns the default engine configuration.
@@ -24,10 +45,56 @@ func defaultEngineConfig() *engineConfig {
 		errorStrategy: ErrorStrategyThrow,
 		maxDepth:      DefaultMaxDepth,
 		logger:        nil,
+		hooks:         NewHookRegistry(),
+		tagCache:      NewMemoryTagCache(DefaultTagCacheMaxEntries),
+	}
+}
+
+// lexerConfig returns the lexer configuration for the configured delimiters.
+func (c *engineConfig) lexerConfig() internal.LexerConfig {
+	return internal.LexerConfig{
+		OpenDelim:  c.openDelim,
+		CloseDelim: c.closeDelim,
 	}
 }
 
+// validateDelimiters checks that the configured delimiters can be tokenized
+// unambiguously. Self-close and block-close markers are derived from them
+// ("/" + close and open + "/"), and the escape sequence is a backslash + open.
+func (c *engineConfig) validateDelimiters() error {
+	if c.openDelim == c.closeDelim {
+		return NewDelimiterError(ErrMsgDelimitersIdentical, c.openDelim, c.closeDelim)
+	}
+	for _, delim := range []string{c.openDelim, c.closeDelim} {
+		if strings.ContainsAny(delim, DelimiterForbiddenChars) {
+			return NewDelimiterError(ErrMsgDelimiterInvalidChar, c.openDelim, c.closeDelim)
+		}
+	}
+	if strings.HasPrefix(c.openDelim, DelimiterSlash) || strings.HasPrefix(c.closeDelim, DelimiterSlash) {
+		return NewDelimiterError(ErrMsgDelimiterInvalidChar, c.openDelim, c.closeDelim)
+	}
+	return nil
+}
+
+// validateIncludeAllowlist checks that the dynamic include allowlist
+// patterns are well-formed.
+func (c *engineConfig) validateIncludeAllowlist() error {
+	for _, pattern := range c.includeAllow {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return NewDynamicIncludePatternError(pattern)
+		}
+	}
+	return nil
+}
+
 // WithDelimiters sets custom delimiters for template tags.
+// Use this when prompt content legitimately contains the default markers,
+// e.g. WithDelimiters("[[~", "~]]") makes tags look like [[~prompty.var name="x" /~]]
+// with block closes written as [[~/prompty.if~]] and escapes as \[[~.
+// Delimiters are handled by the lexer, so error positions stay accurate.
+// New returns an error if the pair is ambiguous (identical, containing
+// whitespace, quotes, '=' or a backslash, or starting with '/').
+// Empty values keep the corresponding default.
 // Default: "{~" and "~}"
 func WithDelimiters(open, close string) Option {
 	return func(c *engineConfig) {
@@ -64,3 +131,75 @@ func WithLogger(logger *zap.Logger) Option {
 		c.logger = logger
 	}
 }
+
+// WithASTCache shares parse results through the given cache: Parse (and
+// therefore Execute, RegisterTemplate and StorageEngine loads) reuses the
+// AST of any previously parsed identical template body. Pass
+// SharedASTCache() to share across all engines in the process.
+// Default: nil (every Parse tokenizes and parses)
+func WithASTCache(cache *ASTCache) Option {
+	return func(c *engineConfig) {
+		c.astCache = cache
+	}
+}
+
+// WithFieldMatch sets how template paths match the fields of Go structs
+// passed as data, e.g. FieldMatchSnakeCase to resolve "first_name" against
+// FirstName. Contexts created with NewContext use the default.
+// Default: FieldMatchCaseInsensitive
+func WithFieldMatch(match FieldMatch) Option {
+	return func(c *engineConfig) {
+		c.fieldMatch = match
+	}
+}
+
+// WithDynamicIncludeAllowlist restricts the templates that
+// {~prompty.include template_expr="..." /~} may select to names matching one
+// of the given path.Match patterns, e.g. "intro-*". Templates named by a
+// literal template attribute are not affected. New returns an error for a
+// malformed pattern.
+// Default: nil (any template may be selected)
+func WithDynamicIncludeAllowlist(patterns ...string) Option {
+	return func(c *engineConfig) {
+		c.includeAllow = append(c.includeAllow, patterns...)
+	}
+}
+
+// WithoutBuiltins leaves the built-in resolvers of the given tags, e.g.
+// TagNameEnv, out of the engine, so templates using them fail as unknown
+// tags. Use it when templates come from untrusted users. The tags can then
+// be registered with a custom resolver.
+// Default: nil (all built-in tags are available)
+func WithoutBuiltins(tagNames ...string) Option {
+	return func(c *engineConfig) {
+		c.withoutTags = append(c.withoutTags, tagNames...)
+	}
+}
+
+// WithTagCache sets the cache storing the results of tags with the cache
+// attribute, e.g. a store shared by several engines or processes. Pass nil
+// to disable tag caching; cache attributes are then ignored.
+// Default: a MemoryTagCache per engine
+func WithTagCache(cache TagCache) Option {
+	return func(c *engineConfig) {
+		c.tagCache = cache
+	}
+}
+
+// WithEnvironment selects the environment overlay (Prompt.Environments)
+// applied to the frontmatter of templates parsed by the engine, and to
+// compilations using the engine that select no environment themselves.
+// Default: "" (no overlay)
+func WithEnvironment(environment string) Option {
+	return func(c *engineConfig) {
+		c.environment = environment
+	}
+}
+
+// WithCostEstimator sets the prices used by Template.EstimateCost.
+// Default: DefaultCostEstimator
+func WithCostEstimator(estimator *CostEstimator) Option {
+	return func(c *engineConfig) {
+		c.costEstimator = estimator
+	}
+}
@@ -1,7 +1,9 @@
 package prompty
 
 import (
+	"encoding/json"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -11,10 +13,42 @@ import (
 // The document must start with --- and have a closing --- delimiter.
 // The body (everything after the closing ---) is set on the Prompt.Body field.
 // If the document has no frontmatter, the entire content is treated as body.
+//
+// JSON is accepted as well: frontmatter opened by ---json instead of ---,
+// and pure JSON documents (a JSON object of the frontmatter fields with the
+// body under "body"). The format is detected from the content.
 func Parse(data []byte) (*Prompt, error) {
+	return parseFormat(data, DetectDocumentFormat(data, ""))
+}
+
+// DetectDocumentFormat detects the format of a prompt document: a .json
+// filename or content that is a JSON object is DocumentFormatJSON, content
+// opening with ---json is DocumentFormatJSONFrontmatter, anything else is
+// DocumentFormatYAML. The filename may be empty.
+func DetectDocumentFormat(data []byte, filename string) DocumentFormat {
+	if strings.EqualFold(filepath.Ext(filename), FileExtensionJSON) {
+		return DocumentFormatJSON
+	}
+	content := strings.TrimLeft(string(data), "\xef\xbb\xbf \t\r\n")
+	switch {
+	case strings.HasPrefix(content, "{") && json.Valid([]byte(content)):
+		return DocumentFormatJSON
+	case strings.HasPrefix(content, JSONFrontmatterDelimiter):
+		return DocumentFormatJSONFrontmatter
+	default:
+		return DocumentFormatYAML
+	}
+}
+
+// parseFormat parses a document in the given format. Frontmatter documents
+// of either flavor are told apart by their opening delimiter.
+func parseFormat(data []byte, format DocumentFormat) (*Prompt, error) {
 	if len(data) == 0 {
 		return nil, NewFrontmatterError(ErrMsgFrontmatterInvalid, Position{Line: 1, Column: 1}, nil)
 	}
+	if format == DocumentFormatJSON {
+		return parseJSONDocument(data)
+	}
 
 	content := string(data)
 
@@ -31,7 +65,12 @@ func Parse(data []byte) (*Prompt, error) {
 	}
 
 	// Skip opening delimiter and newline
-	afterOpening := content[len(YAMLFrontmatterDelimiter):]
+	opening := YAMLFrontmatterDelimiter
+	isJSON := strings.HasPrefix(content, JSONFrontmatterDelimiter)
+	if isJSON {
+		opening = JSONFrontmatterDelimiter
+	}
+	afterOpening := content[len(opening):]
 	if len(afterOpening) > 0 && afterOpening[0] == '\n' {
 		afterOpening = afterOpening[1:]
 	} else if len(afterOpening) > 1 && afterOpening[0] == '\r' && afterOpening[1] == '\n' {
@@ -64,15 +103,76 @@ func Parse(data []byte) (*Prompt, error) {
 		}
 	}
 
-	// Parse YAML frontmatter into Prompt
+	// Parse frontmatter into Prompt
 	var prompt Prompt
-	if err := yaml.Unmarshal([]byte(fmYAML), &prompt); err != nil {
+	if isJSON {
+		fields, err := decodeJSONObject([]byte(fmYAML))
+		if err != nil {
+			return nil, err
+		}
+		if err := decodeFrontmatterFields(fields, &prompt); err != nil {
+			return nil, err
+		}
+	} else if err := yaml.Unmarshal([]byte(fmYAML), &prompt); err != nil {
 		return nil, NewFrontmatterParseError(err)
 	}
 
 	// Set the body
 	prompt.Body = body
 
+	return finishParsedPrompt(&prompt)
+}
+
+// parseJSONDocument parses a pure JSON document: the frontmatter fields as
+// a JSON object, with the body under "body".
+func parseJSONDocument(data []byte) (*Prompt, error) {
+	fields, err := decodeJSONObject(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var body string
+	if raw, ok := fields[PromptFieldBody]; ok {
+		if body, ok = raw.(string); !ok && raw != nil {
+			return nil, NewFrontmatterError(ErrMsgDocumentBodyNotString, Position{Line: 1, Column: 1}, nil)
+		}
+		delete(fields, PromptFieldBody)
+	}
+
+	var prompt Prompt
+	if err := decodeFrontmatterFields(fields, &prompt); err != nil {
+		return nil, err
+	}
+	prompt.Body = body
+
+	return finishParsedPrompt(&prompt)
+}
+
+// decodeJSONObject decodes a JSON object.
+func decodeJSONObject(data []byte) (map[string]any, error) {
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, NewJSONFrontmatterParseError(err)
+	}
+	return fields, nil
+}
+
+// decodeFrontmatterFields decodes frontmatter fields into prompt through
+// YAML, so JSON frontmatter uses the YAML field names and unknown fields
+// become extensions exactly as in YAML frontmatter.
+func decodeFrontmatterFields(fields map[string]any, prompt *Prompt) error {
+	yamlData, err := yaml.Marshal(fields)
+	if err != nil {
+		return NewJSONFrontmatterParseError(err)
+	}
+	if err := yaml.Unmarshal(yamlData, prompt); err != nil {
+		return NewJSONFrontmatterParseError(err)
+	}
+	return nil
+}
+
+// finishParsedPrompt defaults the type and validates a parsed prompt.
+func finishParsedPrompt(prompt *Prompt) (*Prompt, error) {
 	// Set default type if not specified
 	if prompt.Type == "" {
 		prompt.Type = DocumentTypeSkill
@@ -83,16 +183,17 @@ func Parse(data []byte) (*Prompt, error) {
 		return nil, err
 	}
 
-	return &prompt, nil
+	return prompt, nil
 }
 
-// ParseFile reads a file and parses it as a v2.1 document.
+// ParseFile reads a file and parses it as a v2.1 document. A .json file is
+// parsed as a JSON document.
 func ParseFile(path string) (*Prompt, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, NewFrontmatterError(ErrMsgFrontmatterExtract, Position{Line: 1, Column: 1}, err)
 	}
-	return Parse(data)
+	return parseFormat(data, DetectDocumentFormat(data, path))
 }
 
 // MustParse parses a v2.1 document and panics on error.
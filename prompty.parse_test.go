@@ -193,3 +193,67 @@ Context: {~prompty.var name="context.company" /~}`
 	assert.Equal(t, "Acme Corp", p.Context["company"])
 	assert.Equal(t, "Engineering", p.Context["department"])
 }
+
+func TestParse_JSONFrontmatter(t *testing.T) {
+	doc := "---json\n" + `{
+  "name": "json-skill",
+  "description": "Skill with JSON frontmatter",
+  "execution": {"provider": "openai", "model": "gpt-4o", "max_tokens": 256, "temperature": 0.2},
+  "inputs": {"query": {"type": "string", "required": true}},
+  "x-team": "search \/ ranking"
+}` + "\n---\nAnswer {~prompty.var name=\"query\" /~}"
+
+	prompt, err := Parse([]byte(doc))
+	require.NoError(t, err)
+	assert.Equal(t, "json-skill", prompt.Name)
+	assert.Equal(t, DocumentTypeSkill, prompt.Type)
+	assert.Equal(t, "gpt-4o", prompt.Execution.Model)
+	assert.Equal(t, 256, *prompt.Execution.MaxTokens)
+	assert.True(t, prompt.Inputs["query"].Required)
+	assert.Equal(t, "search / ranking", prompt.Extensions["x-team"], "unknown fields are extensions as in YAML")
+	assert.Equal(t, `Answer {~prompty.var name="query" /~}`, prompt.Body)
+
+	_, err = Parse([]byte("---json\nname: not-json\n---\nbody"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgFrontmatterJSONParse)
+}
+
+func TestParse_JSONDocument(t *testing.T) {
+	doc := `{"name": "json-doc", "description": "Pure JSON document", "type": "prompt", "body": "Hello {~prompty.var name=\"user\" /~}"}`
+
+	prompt, err := Parse([]byte(doc))
+	require.NoError(t, err)
+	assert.Equal(t, "json-doc", prompt.Name)
+	assert.Equal(t, DocumentTypePrompt, prompt.Type)
+	assert.Equal(t, `Hello {~prompty.var name="user" /~}`, prompt.Body)
+	assert.NotContains(t, prompt.Extensions, PromptFieldBody)
+
+	_, err = Parse([]byte(`{"name": "json-doc", "description": "d", "body": 42}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgDocumentBodyNotString)
+
+	prompt, err = Parse([]byte(`{~prompty.var name="x" /~}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{~prompty.var name="x" /~}`, prompt.Body, "template bodies are not JSON")
+}
+
+func TestDetectDocumentFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		filename string
+		want     DocumentFormat
+	}{
+		{"yaml", "---\nname: a\n---\n", "", DocumentFormatYAML},
+		{"json frontmatter", "---json\n{}\n---\n", "SKILL.md", DocumentFormatJSONFrontmatter},
+		{"json content", "\n  {\"name\": \"a\"}", "", DocumentFormatJSON},
+		{"json extension", "not json", "prompt.JSON", DocumentFormatJSON},
+		{"template body", `{~prompty.var name="a" /~}`, "", DocumentFormatYAML},
+		{"empty", "", "", DocumentFormatYAML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DetectDocumentFormat([]byte(tt.data), tt.filename))
+		})
+	}
+}
@@ -2,6 +2,7 @@ package prompty
 
 import (
 	"encoding/json"
+	"math"
 	"regexp"
 
 	"gopkg.in/yaml.v3"
@@ -30,6 +31,17 @@ type Prompt struct {
 	// v2.0 Namespaced configuration
 	Execution *ExecutionConfig `yaml:"execution,omitempty" json:"execution,omitempty"`
 
+	// Environments holds per-environment overrides, applied by
+	// ForEnvironment (see CompileOptions.Environment and WithEnvironment)
+	Environments EnvironmentOverlays `yaml:"environments,omitempty" json:"environments,omitempty"`
+
+	// Variants holds named variations of the prompt, applied by
+	// SelectVariant (see CompileOptions.Variant and AssignVariant)
+	Variants PromptVariants `yaml:"variants,omitempty" json:"variants,omitempty"`
+
+	// Tests holds output contract tests of the prompt, run by RunSelfTests
+	Tests PromptTests `yaml:"tests,omitempty" json:"tests,omitempty"`
+
 	// Extensions captures non-standard YAML frontmatter fields.
 	// Any top-level YAML key that doesn't match a known Prompt field
 	// is automatically captured here during parsing.
@@ -137,7 +149,13 @@ func (p *Prompt) Validate() error {
 		}
 	}
 
-	return nil
+	if err := p.Environments.Validate(); err != nil {
+		return err
+	}
+	if err := p.Variants.Validate(); err != nil {
+		return err
+	}
+	return p.Tests.Validate()
 }
 
 // ValidateOptional performs validation only if the prompt has enough
@@ -264,7 +282,10 @@ func (p *Prompt) HasMetadata() bool {
 }
 
 // ValidateInputs validates the provided data against the input definitions.
-// Returns an error if any required input is missing or has wrong type.
+// Returns an error if any required input is missing, has wrong type or
+// violates a constraint of its definition. Object properties and array items
+// are validated recursively; errors name the nested path, e.g.
+// "messages[2].role".
 func (p *Prompt) ValidateInputs(data map[string]any) error {
 	if p == nil || p.Inputs == nil {
 		return nil
@@ -283,8 +304,8 @@ func (p *Prompt) ValidateInputs(data map[string]any) error {
 			continue
 		}
 
-		// Type validation
-		if err := validatePromptInputType(name, val, def.Type); err != nil {
+		// Type and constraint validation, recursing into nested schemas
+		if err := validateInputValue(name, val, def); err != nil {
 			return err
 		}
 	}
@@ -307,6 +328,13 @@ func validatePromptInputType(name string, val any, expectedType string) error {
 		case int, int64, float64, float32:
 			valid = true
 		}
+	case SchemaTypeInteger:
+		switch v := val.(type) {
+		case int, int64:
+			valid = true
+		case float64:
+			valid = v == math.Trunc(v)
+		}
 	case SchemaTypeBoolean:
 		_, valid = val.(bool)
 	case SchemaTypeArray:
@@ -396,6 +424,9 @@ func (p *Prompt) Clone() *Prompt {
 	if p.Execution != nil {
 		clone.Execution = p.Execution.Clone()
 	}
+	clone.Environments = p.Environments.clone()
+	clone.Variants = p.Variants.clone()
+	clone.Tests = p.Tests.clone()
 
 	// Clone extensions
 	if p.Extensions != nil {
@@ -406,8 +437,7 @@ func (p *Prompt) Clone() *Prompt {
 	if p.Inputs != nil {
 		clone.Inputs = make(map[string]*InputDef, len(p.Inputs))
 		for k, v := range p.Inputs {
-			inputClone := *v
-			clone.Inputs[k] = &inputClone
+			clone.Inputs[k] = v.Clone()
 		}
 	}
 
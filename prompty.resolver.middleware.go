@@ -0,0 +1,76 @@
+package prompty
+
+import (
+	"context"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// ResolverMiddleware decorates a resolver with a cross-cutting concern such
+// as caching, rate limiting, timing or argument logging. The returned
+// resolver usually delegates to next.
+type ResolverMiddleware func(next Resolver) Resolver
+
+// UseResolverMiddleware wraps every resolver of the engine, built-in and
+// custom, including resolvers registered later, with the given middleware.
+// Middleware added first is outermost. Each resolver is wrapped once per
+// call, so state a middleware keeps for the resolver it wraps persists
+// across invocations; adding middleware re-wraps all resolvers. Add
+// middleware while setting up the engine, before executing templates.
+// Snapshots keep the middleware they were taken with; on a snapshot this
+// fails with ErrMsgSnapshotReadOnly.
+func (e *Engine) UseResolverMiddleware(middleware ...ResolverMiddleware) error {
+	if e.snapshot {
+		return NewSnapshotReadOnlyError()
+	}
+
+	e.mwMu.Lock()
+	defer e.mwMu.Unlock()
+
+	e.middleware = append(e.middleware, middleware...)
+	chain := append([]ResolverMiddleware(nil), e.middleware...)
+	e.registry.SetWrapper(func(resolver internal.InternalResolver) internal.InternalResolver {
+		return applyResolverMiddleware(resolver, chain)
+	})
+	return nil
+}
+
+// applyResolverMiddleware wraps an internal resolver with chain, outermost first.
+func applyResolverMiddleware(resolver internal.InternalResolver, chain []ResolverMiddleware) internal.InternalResolver {
+	var public Resolver
+	if adapter, ok := resolver.(*resolverAdapter); ok {
+		public = adapter.resolver
+	} else {
+		public = &builtinResolverAdapter{resolver: resolver}
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		public = chain[i](public)
+	}
+	return &resolverAdapter{resolver: public}
+}
+
+// builtinResolverAdapter exposes an internal resolver as a public Resolver,
+// so middleware can wrap built-in tags.
+type builtinResolverAdapter struct {
+	resolver internal.InternalResolver
+}
+
+func (a *builtinResolverAdapter) TagName() string {
+	return a.resolver.TagName()
+}
+
+func (a *builtinResolverAdapter) Resolve(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+	return a.resolver.Resolve(ctx, execCtx, toInternalAttributes(attrs))
+}
+
+func (a *builtinResolverAdapter) Validate(attrs Attributes) error {
+	return a.resolver.Validate(toInternalAttributes(attrs))
+}
+
+// toInternalAttributes unwraps attributes passed through middleware.
+func toInternalAttributes(attrs Attributes) internal.Attributes {
+	if adapter, ok := attrs.(*internalAttributesAdapter); ok {
+		return adapter.attrs
+	}
+	return internal.Attributes(attrs.Map())
+}
@@ -0,0 +1,104 @@
+package prompty
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tagMiddleware returns middleware recording invocations in calls, tagged with label.
+func tagMiddleware(label string, calls *[]string) ResolverMiddleware {
+	return func(next Resolver) Resolver {
+		return NewResolverFunc(next.TagName(), func(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+			*calls = append(*calls, label+":"+next.TagName())
+			return next.Resolve(ctx, execCtx, attrs)
+		}, next.Validate)
+	}
+}
+
+func TestEngine_UseResolverMiddleware(t *testing.T) {
+	engine := MustNew()
+	require.NoError(t, engine.Register(NewResolverFunc("Shout", func(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+		return strings.ToUpper(attrs.GetDefault("text", "")), nil
+	}, nil)))
+
+	var calls []string
+	require.NoError(t, engine.UseResolverMiddleware(tagMiddleware("outer", &calls), tagMiddleware("inner", &calls)))
+
+	// Resolvers registered after the middleware are wrapped as well
+	require.NoError(t, engine.Register(NewResolverFunc("Late", func(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+		return "late", nil
+	}, nil)))
+
+	result, err := engine.Execute(context.Background(),
+		`{~prompty.var name="user" /~} {~Shout text="hi" /~} {~Late /~}`, map[string]any{"user": "Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, "Ada HI late", result)
+	assert.Equal(t, []string{
+		"outer:prompty.var", "inner:prompty.var",
+		"outer:Shout", "inner:Shout",
+		"outer:Late", "inner:Late",
+	}, calls)
+}
+
+func TestEngine_UseResolverMiddleware_Caching(t *testing.T) {
+	engine := MustNew()
+	invocations := 0
+	engine.MustRegister(NewResolverFunc("Expensive", func(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+		invocations++
+		return "value-" + attrs.GetDefault("key", ""), nil
+	}, nil))
+
+	// Each wrapped resolver keeps its own cache across executions
+	require.NoError(t, engine.UseResolverMiddleware(func(next Resolver) Resolver {
+		cache := make(map[string]string)
+		return NewResolverFunc(next.TagName(), func(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+			key := attrs.GetDefault("key", "")
+			if cached, ok := cache[key]; ok {
+				return cached, nil
+			}
+			result, err := next.Resolve(ctx, execCtx, attrs)
+			if err == nil {
+				cache[key] = result
+			}
+			return result, err
+		}, next.Validate)
+	}))
+
+	for i := 0; i < 3; i++ {
+		result, err := engine.Execute(context.Background(), `{~Expensive key="a" /~}`, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "value-a", result)
+	}
+	assert.Equal(t, 1, invocations)
+}
+
+func TestEngine_UseResolverMiddleware_ErrorsAndValidation(t *testing.T) {
+	engine := MustNew()
+	blocked := errors.New("rate limited")
+	require.NoError(t, engine.UseResolverMiddleware(func(next Resolver) Resolver {
+		return NewResolverFunc(next.TagName(), func(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+			if next.TagName() == TagNameEnv {
+				return "", blocked
+			}
+			return next.Resolve(ctx, execCtx, attrs)
+		}, next.Validate)
+	}))
+
+	_, err := engine.Execute(context.Background(), `{~prompty.env name="HOME" /~}`, nil)
+	assert.ErrorContains(t, err, "rate limited")
+
+	// Error strategies still apply to the wrapped resolver
+	result, err := engine.Execute(context.Background(), `a{~prompty.env name="HOME" onerror="remove" /~}b`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ab", result)
+
+	// Validation reaches built-in resolvers through the middleware
+	validation, err := engine.Validate(`{~prompty.var /~}`)
+	require.NoError(t, err)
+	assert.True(t, validation.HasErrors())
+}
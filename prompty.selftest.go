@@ -0,0 +1,338 @@
+package prompty
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Self-test failure messages, reported in SelfTestResult.Failures
+const (
+	selfTestFailRender      = "rendering failed: %v"
+	selfTestFailNoError     = "rendering succeeded, an error was expected"
+	selfTestFailContains    = "output does not contain %q"
+	selfTestFailNotContains = "output contains %q"
+	selfTestFailMatches     = "output does not match %q"
+	selfTestFailNotJSON     = "output is not valid JSON: %v"
+	selfTestFailJSONMissing = "JSON path %s not found"
+	selfTestFailJSONValue   = "JSON path %s is %s, expected %s"
+)
+
+// JSON path syntax of PromptTestExpect.JSON
+const (
+	jsonPathRoot      = "$"
+	jsonPathSeparator = "."
+	jsonPathIndexOpen = "["
+	jsonPathIndexEnd  = "]"
+)
+
+// PromptTest is an output contract test declared in the tests section of a
+// prompt's frontmatter, so that quality gates travel with the prompt:
+//
+//	tests:
+//	  - name: greets by name
+//	    inputs: {user: Ada}
+//	    expect:
+//	      contains: ["Hello Ada"]
+//	      not_contains: ["prompty."]
+//	  - name: json reply
+//	    variant: structured
+//	    inputs: {user: Ada}
+//	    expect:
+//	      matches: ['^\{']
+//	      json:
+//	        $.greeting.name: Ada
+//
+// Run them with Prompt.RunSelfTests or "prompty test".
+type PromptTest struct {
+	// Name identifies the test in reports; required and unique.
+	Name string `yaml:"name" json:"name"`
+
+	// Description documents the test.
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+
+	// Inputs are the data the prompt is rendered with.
+	Inputs map[string]any `yaml:"inputs,omitempty" json:"inputs,omitempty"`
+
+	// Variant renders the named variant of the prompt (Prompt.SelectVariant).
+	Variant string `yaml:"variant,omitempty" json:"variant,omitempty"`
+
+	// Expect holds the assertions on the rendered output.
+	Expect PromptTestExpect `yaml:"expect" json:"expect"`
+}
+
+// PromptTestExpect holds the assertions of a PromptTest. All must hold for
+// the test to pass.
+type PromptTestExpect struct {
+	// Contains lists substrings the output must contain.
+	Contains []string `yaml:"contains,omitempty" json:"contains,omitempty"`
+
+	// NotContains lists substrings the output must not contain.
+	NotContains []string `yaml:"not_contains,omitempty" json:"not_contains,omitempty"`
+
+	// Matches lists regular expressions the output must match.
+	Matches []string `yaml:"matches,omitempty" json:"matches,omitempty"`
+
+	// JSON maps JSON paths, like $.user.name or $.items[0], to the values
+	// they must have when the output is parsed as JSON.
+	JSON map[string]any `yaml:"json,omitempty" json:"json,omitempty"`
+
+	// Error expects rendering to fail, e.g. for a missing required input.
+	Error bool `yaml:"error,omitempty" json:"error,omitempty"`
+}
+
+// PromptTests is the list of tests of a prompt.
+type PromptTests []*PromptTest
+
+// Validate checks that tests are named uniquely and that their patterns and
+// JSON paths are valid.
+func (t PromptTests) Validate() error {
+	seen := make(map[string]bool, len(t))
+	for _, test := range t {
+		if test == nil {
+			continue
+		}
+		if test.Name == "" {
+			return NewSelfTestError(ErrMsgSelfTestNoName, "")
+		}
+		if seen[test.Name] {
+			return NewSelfTestError(ErrMsgSelfTestDuplicateName, test.Name)
+		}
+		seen[test.Name] = true
+
+		for _, pattern := range test.Expect.Matches {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return NewSelfTestError(ErrMsgSelfTestBadPattern, test.Name)
+			}
+		}
+		for path := range test.Expect.JSON {
+			if _, err := parseJSONPath(path); err != nil {
+				return NewSelfTestError(ErrMsgSelfTestBadJSONPath, test.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// clone returns a deep copy of the tests.
+func (t PromptTests) clone() PromptTests {
+	if t == nil {
+		return nil
+	}
+	clone := make(PromptTests, len(t))
+	for i, test := range t {
+		if test == nil {
+			continue
+		}
+		c := *test
+		if test.Inputs != nil {
+			c.Inputs = deepCopyMap(test.Inputs)
+		}
+		c.Expect.Contains = append([]string(nil), test.Expect.Contains...)
+		c.Expect.NotContains = append([]string(nil), test.Expect.NotContains...)
+		c.Expect.Matches = append([]string(nil), test.Expect.Matches...)
+		if test.Expect.JSON != nil {
+			c.Expect.JSON = deepCopyMap(test.Expect.JSON)
+		}
+		clone[i] = &c
+	}
+	return clone
+}
+
+// SelfTestReport is the machine-readable result of Prompt.RunSelfTests.
+type SelfTestReport struct {
+	Prompt  string           `json:"prompt"`
+	Passed  int              `json:"passed"`
+	Failed  int              `json:"failed"`
+	Results []SelfTestResult `json:"results"`
+}
+
+// OK reports whether all tests passed.
+func (r *SelfTestReport) OK() bool {
+	return r != nil && r.Failed == 0
+}
+
+// SelfTestResult is the outcome of one PromptTest.
+type SelfTestResult struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Failures []string      `json:"failures,omitempty"`
+	Output   string        `json:"output,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// RunSelfTests renders the prompt for each test in its tests section and
+// checks the output against the test's assertions. The prompt is compiled
+// with engine (Prompt.Compile), so its registered resolvers, functions and
+// templates are available; a nil engine uses a default one.
+//
+// Failed assertions are reported in the results, not as an error; the error
+// is non-nil only for invalid test definitions.
+//
+//	prompt, err := prompty.Parse(source)
+//	report, err := prompt.RunSelfTests(ctx, engine)
+//	if err == nil && !report.OK() { ... }
+func (p *Prompt) RunSelfTests(ctx context.Context, engine *Engine) (*SelfTestReport, error) {
+	report := &SelfTestReport{Prompt: p.GetName(), Results: []SelfTestResult{}}
+	if p == nil {
+		return report, nil
+	}
+	if err := p.Tests.Validate(); err != nil {
+		return nil, err
+	}
+
+	for _, test := range p.Tests {
+		if test == nil {
+			continue
+		}
+		start := time.Now()
+		output, err := p.Compile(ctx, test.Inputs, &CompileOptions{Engine: engine, Variant: test.Variant})
+		result := SelfTestResult{
+			Name:     test.Name,
+			Output:   output,
+			Failures: test.Expect.check(output, err),
+			Duration: time.Since(start),
+		}
+		result.Passed = len(result.Failures) == 0
+		if result.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+// check returns the failed assertions for a rendered output or render error.
+func (e *PromptTestExpect) check(output string, renderErr error) []string {
+	if e.Error {
+		if renderErr == nil {
+			return []string{selfTestFailNoError}
+		}
+		return nil
+	}
+	if renderErr != nil {
+		return []string{fmt.Sprintf(selfTestFailRender, renderErr)}
+	}
+
+	var failures []string
+	for _, s := range e.Contains {
+		if !strings.Contains(output, s) {
+			failures = append(failures, fmt.Sprintf(selfTestFailContains, s))
+		}
+	}
+	for _, s := range e.NotContains {
+		if strings.Contains(output, s) {
+			failures = append(failures, fmt.Sprintf(selfTestFailNotContains, s))
+		}
+	}
+	for _, pattern := range e.Matches {
+		if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(output) {
+			failures = append(failures, fmt.Sprintf(selfTestFailMatches, pattern))
+		}
+	}
+	return append(failures, e.checkJSON(output)...)
+}
+
+// checkJSON returns the failed JSON path assertions, in path order.
+func (e *PromptTestExpect) checkJSON(output string) []string {
+	if len(e.JSON) == 0 {
+		return nil
+	}
+	var doc any
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		return []string{fmt.Sprintf(selfTestFailNotJSON, err)}
+	}
+
+	paths := make([]string, 0, len(e.JSON))
+	for path := range e.JSON {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var failures []string
+	for _, path := range paths {
+		segments, err := parseJSONPath(path)
+		if err != nil {
+			continue // Rejected by Validate
+		}
+		actual, ok := lookupJSONPath(doc, segments)
+		if !ok {
+			failures = append(failures, fmt.Sprintf(selfTestFailJSONMissing, path))
+			continue
+		}
+		// Normalize the expected value to decoded JSON types (float64, []any, ...)
+		var expected any
+		raw, err := json.Marshal(e.JSON[path])
+		if err == nil {
+			err = json.Unmarshal(raw, &expected)
+		}
+		if err != nil || !reflect.DeepEqual(actual, expected) {
+			got, _ := json.Marshal(actual)
+			failures = append(failures, fmt.Sprintf(selfTestFailJSONValue, path, got, raw))
+		}
+	}
+	return failures
+}
+
+// parseJSONPath splits a JSON path like $.items[0].name into object keys
+// (string) and array indexes (int). The leading $ is optional.
+func parseJSONPath(path string) ([]any, error) {
+	rest := strings.TrimPrefix(path, jsonPathRoot)
+	var segments []any
+	for _, part := range strings.Split(rest, jsonPathSeparator) {
+		key, indexes, _ := strings.Cut(part, jsonPathIndexOpen)
+		if key != "" {
+			segments = append(segments, key)
+		}
+		if indexes == "" {
+			if key == "" && part != "" {
+				return nil, errors.New(ErrMsgSelfTestBadJSONPath)
+			}
+			continue
+		}
+		for _, index := range strings.Split(indexes, jsonPathIndexOpen) {
+			n, err := strconv.Atoi(strings.TrimSuffix(index, jsonPathIndexEnd))
+			if err != nil || n < 0 || !strings.HasSuffix(index, jsonPathIndexEnd) {
+				return nil, errors.New(ErrMsgSelfTestBadJSONPath)
+			}
+			segments = append(segments, n)
+		}
+	}
+	if len(segments) == 0 && rest != "" {
+		return nil, errors.New(ErrMsgSelfTestBadJSONPath)
+	}
+	return segments, nil
+}
+
+// lookupJSONPath resolves parsed path segments in a decoded JSON document.
+func lookupJSONPath(doc any, segments []any) (any, bool) {
+	current := doc
+	for _, segment := range segments {
+		switch s := segment.(type) {
+		case string:
+			obj, ok := current.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if current, ok = obj[s]; !ok {
+				return nil, false
+			}
+		case int:
+			list, ok := current.([]any)
+			if !ok || s >= len(list) {
+				return nil, false
+			}
+			current = list[s]
+		}
+	}
+	return current, true
+}
@@ -0,0 +1,136 @@
+package prompty
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const selfTestSource = `---
+name: greeter
+description: Greets a user
+type: prompt
+inputs:
+  user:
+    type: string
+    required: true
+variants:
+  structured:
+    blocks:
+      reply: '{"greeting": {"name": "Ada", "tags": ["hi"]}}'
+tests:
+  - name: greets by name
+    inputs: {user: Ada}
+    expect:
+      contains: ["Hello Ada"]
+      not_contains: ["prompty."]
+      matches: ['^Hello \w+!$']
+  - name: json reply
+    variant: structured
+    inputs: {user: Ada}
+    expect:
+      json:
+        $.greeting.name: Ada
+        $.greeting.tags[0]: hi
+  - name: wrong expectations
+    inputs: {user: Bob}
+    expect:
+      contains: ["Hello Ada"]
+      matches: ['^Bye']
+      json:
+        $.name: Bob
+  - name: missing user fails
+    expect:
+      error: true
+---
+{~prompty.block name="reply"~}Hello {~prompty.var name="user" /~}!{~/prompty.block~}`
+
+func TestPrompt_RunSelfTests(t *testing.T) {
+	p, err := Parse([]byte(selfTestSource))
+	require.NoError(t, err)
+
+	report, err := p.RunSelfTests(context.Background(), MustNew())
+	require.NoError(t, err)
+	assert.Equal(t, "greeter", report.Prompt)
+	assert.Equal(t, 3, report.Passed)
+	assert.Equal(t, 1, report.Failed)
+	assert.False(t, report.OK())
+	require.Len(t, report.Results, 4)
+
+	assert.True(t, report.Results[0].Passed, report.Results[0].Failures)
+	assert.Equal(t, "Hello Ada!", report.Results[0].Output)
+	assert.True(t, report.Results[1].Passed, report.Results[1].Failures)
+	assert.True(t, report.Results[3].Passed, report.Results[3].Failures)
+
+	failed := report.Results[2]
+	assert.False(t, failed.Passed)
+	require.Len(t, failed.Failures, 3)
+	assert.Contains(t, failed.Failures[0], `"Hello Ada"`)
+	assert.Contains(t, failed.Failures[1], `^Bye`)
+	assert.True(t, strings.HasPrefix(failed.Failures[2], "output is not valid JSON"))
+}
+
+func TestPromptTestExpect_JSON(t *testing.T) {
+	output := `{"user": {"name": "Ada", "age": 36, "roles": ["admin", "dev"]}, "ok": true}`
+	expect := &PromptTestExpect{JSON: map[string]any{
+		"$.user.name":     "Ada",
+		"$.user.age":      36,
+		"user.roles[1]":   "dev",
+		"$.user.roles":    []any{"admin", "dev"},
+		"$.ok":            true,
+		"$.user.missing":  "x",
+		"$.user.roles[5]": "x",
+		"$.user.name.x":   "x",
+	}}
+
+	failures := expect.check(output, nil)
+	assert.Equal(t, []string{
+		"JSON path $.user.missing not found",
+		"JSON path $.user.name.x not found",
+		"JSON path $.user.roles[5] not found",
+	}, failures)
+
+	expect = &PromptTestExpect{JSON: map[string]any{"$.user.age": 40}}
+	assert.Equal(t, []string{"JSON path $.user.age is 36, expected 40"}, expect.check(output, nil))
+}
+
+func TestPromptTests_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		tests PromptTests
+		msg   string
+	}{
+		{"no name", PromptTests{{}}, ErrMsgSelfTestNoName},
+		{"duplicate", PromptTests{{Name: "a"}, {Name: "a"}}, ErrMsgSelfTestDuplicateName},
+		{"bad pattern", PromptTests{{Name: "a", Expect: PromptTestExpect{Matches: []string{"("}}}}, ErrMsgSelfTestBadPattern},
+		{"bad json path", PromptTests{{Name: "a", Expect: PromptTestExpect{JSON: map[string]any{"$.a[x]": 1}}}}, ErrMsgSelfTestBadJSONPath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Prompt{Name: "p", Description: "d", Tests: tt.tests}
+			assert.ErrorContains(t, p.Validate(), tt.msg)
+			_, err := p.RunSelfTests(context.Background(), nil)
+			assert.ErrorContains(t, err, tt.msg)
+		})
+	}
+}
+
+func TestPromptTests_RoundTrip(t *testing.T) {
+	p, err := Parse([]byte(selfTestSource))
+	require.NoError(t, err)
+
+	clone := p.Clone()
+	clone.Tests[0].Expect.Contains[0] = "changed"
+	clone.Tests[0].Inputs["user"] = "Eve"
+	assert.Equal(t, "Hello Ada", p.Tests[0].Expect.Contains[0])
+	assert.Equal(t, "Ada", p.Tests[0].Inputs["user"])
+
+	data, err := p.Serialize(DefaultSerializeOptions())
+	require.NoError(t, err)
+	parsed, err := Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, p.Tests, parsed.Tests)
+}
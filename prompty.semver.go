@@ -0,0 +1,280 @@
+package prompty
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version constraint syntax
+const (
+	VersionPrefix          = "v"
+	VersionSeparator       = "."
+	VersionPrereleaseSep   = "-"
+	VersionBuildSep        = "+"
+	VersionWildcardX       = "x"
+	VersionWildcardStar    = "*"
+	VersionConstraintOr    = "||"
+	VersionConstraintCaret = "^"
+	VersionConstraintTilde = "~"
+	VersionOpGTE           = ">="
+	VersionOpLTE           = "<="
+	VersionOpGT            = ">"
+	VersionOpLT            = "<"
+	VersionOpEQ            = "="
+
+	// PromptMetadataVersion is the Prompt.Metadata key holding a document's
+	// semantic version, as in the Agent Skills standard.
+	PromptMetadataVersion = "version"
+)
+
+// SemVersion is a semantic version: MAJOR.MINOR.PATCH with an optional
+// prerelease. Build metadata is ignored.
+type SemVersion struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+// ParseSemVersion parses a version such as "1.2.3", "v2.1" or
+// "1.0.0-beta.1". Missing minor and patch numbers are zero.
+func ParseSemVersion(s string) (SemVersion, error) {
+	v, parts, err := parsePartialVersion(s)
+	if err != nil {
+		return SemVersion{}, err
+	}
+	if parts < 0 {
+		return SemVersion{}, NewVersionConstraintError(s)
+	}
+	return v, nil
+}
+
+// parsePartialVersion parses a possibly partial version, returning the
+// number of numeric parts given (1-3), or -1 for a wildcard major.
+// Wildcards ("x", "*") end the numeric parts.
+func parsePartialVersion(s string) (SemVersion, int, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), VersionPrefix)
+	if s == "" {
+		return SemVersion{}, 0, NewVersionConstraintError(s)
+	}
+	if idx := strings.Index(s, VersionBuildSep); idx >= 0 {
+		s = s[:idx]
+	}
+
+	var v SemVersion
+	if idx := strings.Index(s, VersionPrereleaseSep); idx >= 0 {
+		v.Prerelease = s[idx+1:]
+		s = s[:idx]
+	}
+
+	fields := strings.Split(s, VersionSeparator)
+	if len(fields) > 3 {
+		return SemVersion{}, 0, NewVersionConstraintError(s)
+	}
+	numbers := [3]*int{&v.Major, &v.Minor, &v.Patch}
+	for i, field := range fields {
+		if field == VersionWildcardX || field == VersionWildcardStar {
+			if i == 0 {
+				return SemVersion{}, -1, nil
+			}
+			return v, i, nil
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return SemVersion{}, 0, NewVersionConstraintError(s)
+		}
+		*numbers[i] = n
+	}
+	return v, len(fields), nil
+}
+
+// Compare returns -1, 0 or 1 as v is lower than, equal to or higher than
+// other. A prerelease is lower than its release.
+func (v SemVersion) Compare(other SemVersion) int {
+	for _, d := range [3]int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	case v.Prerelease < other.Prerelease:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// String returns the version as MAJOR.MINOR.PATCH[-PRERELEASE].
+func (v SemVersion) String() string {
+	s := strconv.Itoa(v.Major) + VersionSeparator + strconv.Itoa(v.Minor) + VersionSeparator + strconv.Itoa(v.Patch)
+	if v.Prerelease != "" {
+		s += VersionPrereleaseSep + v.Prerelease
+	}
+	return s
+}
+
+// versionBound is one comparison of a constraint.
+type versionBound struct {
+	op      string
+	version SemVersion
+}
+
+// matches reports whether v satisfies the bound.
+func (b versionBound) matches(v SemVersion) bool {
+	c := v.Compare(b.version)
+	switch b.op {
+	case VersionOpGTE:
+		return c >= 0
+	case VersionOpLTE:
+		return c <= 0
+	case VersionOpGT:
+		return c > 0
+	case VersionOpLT:
+		return c < 0
+	default:
+		return c == 0
+	}
+}
+
+// VersionConstraint is a semantic version range such as "^2.1",
+// "~1.4", ">=1.0 <2", "1.x" or "1.2.3 || ^2". Space-separated comparisons
+// must all hold; "||" separates alternatives. A partial version without an
+// operator ("2", "v2") matches that major (or minor) line.
+type VersionConstraint struct {
+	raw  string
+	sets [][]versionBound
+}
+
+// ParseVersionConstraint parses a version constraint.
+func ParseVersionConstraint(s string) (*VersionConstraint, error) {
+	c := &VersionConstraint{raw: s}
+	for _, alternative := range strings.Split(s, VersionConstraintOr) {
+		fields := strings.Fields(alternative)
+		if len(fields) == 0 {
+			return nil, NewVersionConstraintError(s)
+		}
+		var set []versionBound
+		for _, field := range fields {
+			bounds, err := parseVersionComparison(field)
+			if err != nil {
+				return nil, NewVersionConstraintError(s)
+			}
+			set = append(set, bounds...)
+		}
+		c.sets = append(c.sets, set)
+	}
+	return c, nil
+}
+
+// parseVersionComparison parses one comparison into lower/upper bounds.
+func parseVersionComparison(field string) ([]versionBound, error) {
+	for _, op := range []string{VersionOpGTE, VersionOpLTE, VersionOpGT, VersionOpLT, VersionOpEQ} {
+		if rest, ok := strings.CutPrefix(field, op); ok {
+			v, err := ParseSemVersion(rest)
+			if err != nil {
+				return nil, err
+			}
+			return []versionBound{{op: op, version: v}}, nil
+		}
+	}
+
+	caret := strings.HasPrefix(field, VersionConstraintCaret)
+	tilde := strings.HasPrefix(field, VersionConstraintTilde)
+	field = strings.TrimLeft(field, VersionConstraintCaret+VersionConstraintTilde)
+
+	v, parts, err := parsePartialVersion(field)
+	if err != nil {
+		return nil, err
+	}
+	if parts < 0 {
+		return nil, nil // "*" matches everything
+	}
+
+	lower := versionBound{op: VersionOpGTE, version: v}
+	var upper SemVersion
+	switch {
+	case caret:
+		// ^1.2.3 := <2.0.0, ^0.2.3 := <0.3.0, ^0.0.3 := <0.0.4
+		switch {
+		case v.Major > 0 || parts == 1:
+			upper = SemVersion{Major: v.Major + 1}
+		case v.Minor > 0 || parts == 2:
+			upper = SemVersion{Minor: v.Minor + 1}
+		default:
+			upper = SemVersion{Patch: v.Patch + 1}
+		}
+	case tilde && parts > 1, parts == 2:
+		upper = SemVersion{Major: v.Major, Minor: v.Minor + 1}
+	case parts == 1:
+		upper = SemVersion{Major: v.Major + 1}
+	default:
+		return []versionBound{{op: VersionOpEQ, version: v}}, nil
+	}
+	// Exclude prereleases of the upper bound
+	upper.Prerelease = "0"
+	return []versionBound{lower, {op: VersionOpLT, version: upper}}, nil
+}
+
+// Check reports whether v satisfies the constraint. Prereleases only
+// satisfy constraints naming a prerelease of the same version line.
+func (c *VersionConstraint) Check(v SemVersion) bool {
+	for _, set := range c.sets {
+		if c.checkSet(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSet reports whether v satisfies all bounds of one alternative.
+func (c *VersionConstraint) checkSet(set []versionBound, v SemVersion) bool {
+	allowPrerelease := v.Prerelease == ""
+	for _, bound := range set {
+		if !bound.matches(v) {
+			return false
+		}
+		if bound.version.Prerelease != "" && bound.version.Prerelease != "0" &&
+			bound.version.Major == v.Major && bound.version.Minor == v.Minor && bound.version.Patch == v.Patch {
+			allowPrerelease = true
+		}
+	}
+	return allowPrerelease
+}
+
+// String returns the constraint as written.
+func (c *VersionConstraint) String() string {
+	return c.raw
+}
+
+// MaxSatisfying returns the highest of versions satisfying the constraint.
+// Versions that do not parse are ignored. It reports false if none match.
+func (c *VersionConstraint) MaxSatisfying(versions []string) (string, bool) {
+	type parsed struct {
+		raw string
+		v   SemVersion
+	}
+	candidates := make([]parsed, 0, len(versions))
+	for _, raw := range versions {
+		v, err := ParseSemVersion(raw)
+		if err == nil && c.Check(v) {
+			candidates = append(candidates, parsed{raw: raw, v: v})
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].v.Compare(candidates[j].v) > 0
+	})
+	return candidates[0].raw, true
+}
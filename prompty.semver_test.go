@@ -0,0 +1,96 @@
+package prompty
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSemVersion(t *testing.T) {
+	v, err := ParseSemVersion("v2.1")
+	require.NoError(t, err)
+	assert.Equal(t, SemVersion{Major: 2, Minor: 1}, v)
+	assert.Equal(t, "2.1.0", v.String())
+
+	v, err = ParseSemVersion("1.0.0-beta.1+build.5")
+	require.NoError(t, err)
+	assert.Equal(t, SemVersion{Major: 1, Prerelease: "beta.1"}, v)
+
+	for _, invalid := range []string{"", "x", "1.a", "1.2.3.4", "-1"} {
+		_, err := ParseSemVersion(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestSemVersion_Compare(t *testing.T) {
+	parse := func(s string) SemVersion {
+		v, err := ParseSemVersion(s)
+		require.NoError(t, err)
+		return v
+	}
+	assert.Equal(t, -1, parse("1.2.3").Compare(parse("1.10.0")))
+	assert.Equal(t, 1, parse("2.0.0").Compare(parse("1.99.99")))
+	assert.Equal(t, 0, parse("v1.2").Compare(parse("1.2.0")))
+	assert.Equal(t, -1, parse("1.0.0-rc.1").Compare(parse("1.0.0")))
+	assert.Equal(t, -1, parse("1.0.0-alpha").Compare(parse("1.0.0-beta")))
+}
+
+func TestVersionConstraint_Check(t *testing.T) {
+	tests := []struct {
+		constraint string
+		matches    []string
+		rejects    []string
+	}{
+		{"^2.1", []string{"2.1.0", "2.9.3"}, []string{"2.0.9", "3.0.0", "3.0.0-beta"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0", "0.2.2"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"~1.4", []string{"1.4.0", "1.4.7"}, []string{"1.5.0", "1.3.9"}},
+		{">=1.0 <2", []string{"1.0.0", "1.9.9"}, []string{"0.9.0", "2.0.0"}},
+		{"1.x", []string{"1.0.0", "1.7.2"}, []string{"2.0.0"}},
+		{"v2", []string{"2.0.0", "2.3.1"}, []string{"1.0.0", "3.0.0"}},
+		{"1.2.3", []string{"1.2.3"}, []string{"1.2.4"}},
+		{"=1.2", []string{"1.2.0"}, []string{"1.2.1"}},
+		{"*", []string{"0.0.1", "9.9.9"}, []string{"1.0.0-rc.1"}},
+		{"^1 || ^3", []string{"1.5.0", "3.0.0"}, []string{"2.0.0"}},
+		{">=1.0.0-rc.1", []string{"1.0.0-rc.2", "1.0.0"}, []string{"1.1.0-beta"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			c, err := ParseVersionConstraint(tt.constraint)
+			require.NoError(t, err)
+			assert.Equal(t, tt.constraint, c.String())
+			for _, s := range tt.matches {
+				v, err := ParseSemVersion(s)
+				require.NoError(t, err)
+				assert.True(t, c.Check(v), "%s should match %s", tt.constraint, s)
+			}
+			for _, s := range tt.rejects {
+				v, err := ParseSemVersion(s)
+				require.NoError(t, err)
+				assert.False(t, c.Check(v), "%s should reject %s", tt.constraint, s)
+			}
+		})
+	}
+}
+
+func TestVersionConstraint_Invalid(t *testing.T) {
+	for _, invalid := range []string{"", "^", ">=a", "1.0 ||", "~x.y.z.w"} {
+		_, err := ParseVersionConstraint(invalid)
+		require.Error(t, err, invalid)
+		assert.Contains(t, err.Error(), ErrMsgInvalidVersionConstraint)
+	}
+}
+
+func TestVersionConstraint_MaxSatisfying(t *testing.T) {
+	c, err := ParseVersionConstraint("^2.1")
+	require.NoError(t, err)
+
+	version, ok := c.MaxSatisfying([]string{"1.9.0", "2.1.0", "2.4.1", "2.10.0", "3.0.0", "not-a-version"})
+	require.True(t, ok)
+	assert.Equal(t, "2.10.0", version)
+
+	_, ok = c.MaxSatisfying([]string{"1.0.0", "3.0.0"})
+	assert.False(t, ok)
+}
@@ -1,6 +1,7 @@
 package prompty
 
 import (
+	"encoding/json"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -10,6 +11,7 @@ import (
 const (
 	ErrMsgSerializeFailed = "serialization failed"
 	ErrMsgSerializeYAML   = "YAML marshaling failed"
+	ErrMsgSerializeJSON   = "JSON marshaling failed"
 )
 
 // SerializeOptions configures prompt serialization.
@@ -22,6 +24,8 @@ type SerializeOptions struct {
 	IncludeAgentFields bool
 	// IncludeContext includes the context map in output
 	IncludeContext bool
+	// Format is the document format; empty means DocumentFormatYAML
+	Format DocumentFormat
 }
 
 // DefaultSerializeOptions returns the default serialization options (all included).
@@ -45,7 +49,8 @@ func AgentSkillsExportOptions() *SerializeOptions {
 	}
 }
 
-// Serialize outputs the Prompt as a YAML frontmatter + body document.
+// Serialize outputs the Prompt as a YAML frontmatter + body document, or in
+// the JSON format of opts.Format. Parse reads every format back.
 // If opts is nil, DefaultSerializeOptions is used.
 func (p *Prompt) Serialize(opts *SerializeOptions) ([]byte, error) {
 	if p == nil {
@@ -59,6 +64,13 @@ func (p *Prompt) Serialize(opts *SerializeOptions) ([]byte, error) {
 	// Build a serializable struct based on options
 	exportData := p.buildSerializeMap(opts)
 
+	switch opts.Format {
+	case DocumentFormatJSONFrontmatter:
+		return p.serializeJSONFrontmatter(exportData)
+	case DocumentFormatJSON:
+		return serializeJSONDocument(exportData, p.Body)
+	}
+
 	yamlBytes, err := yaml.Marshal(exportData)
 	if err != nil {
 		return nil, NewCompilationError(ErrMsgSerializeYAML, err)
@@ -77,6 +89,38 @@ func (p *Prompt) Serialize(opts *SerializeOptions) ([]byte, error) {
 	return []byte(sb.String()), nil
 }
 
+// serializeJSONFrontmatter writes the fields as JSON frontmatter between
+// ---json and --- lines, followed by the body.
+func (p *Prompt) serializeJSONFrontmatter(fields map[string]any) ([]byte, error) {
+	jsonBytes, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return nil, NewCompilationError(ErrMsgSerializeJSON, err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(JSONFrontmatterDelimiter)
+	sb.WriteString("\n")
+	sb.Write(jsonBytes)
+	sb.WriteString("\n")
+	sb.WriteString(YAMLFrontmatterDelimiter)
+	sb.WriteString("\n")
+	sb.WriteString(p.Body)
+
+	return []byte(sb.String()), nil
+}
+
+// serializeJSONDocument writes the fields and body as one JSON object.
+func serializeJSONDocument(fields map[string]any, body string) ([]byte, error) {
+	if body != "" {
+		fields[PromptFieldBody] = body
+	}
+	jsonBytes, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return nil, NewCompilationError(ErrMsgSerializeJSON, err)
+	}
+	return append(jsonBytes, '\n'), nil
+}
+
 // ExportAgentSkill serializes the prompt with only Agent Skills compatible fields.
 func (p *Prompt) ExportAgentSkill() ([]byte, error) {
 	return p.Serialize(AgentSkillsExportOptions())
@@ -87,6 +131,14 @@ func (p *Prompt) ExportFull() ([]byte, error) {
 	return p.Serialize(DefaultSerializeOptions())
 }
 
+// ExportJSON serializes the prompt with all fields included as a JSON
+// document (DocumentFormatJSON).
+func (p *Prompt) ExportJSON() ([]byte, error) {
+	opts := DefaultSerializeOptions()
+	opts.Format = DocumentFormatJSON
+	return p.Serialize(opts)
+}
+
 // knownPromptFields is the set of all known Prompt struct YAML field names.
 // Extensions with these keys are skipped during serialization to prevent overwriting.
 var knownPromptFields = map[string]bool{
@@ -101,6 +153,9 @@ var knownPromptFields = map[string]bool{
 	PromptFieldSample:        true,
 	PromptFieldType:          true,
 	PromptFieldExecution:     true,
+	PromptFieldEnvironments:  true,
+	PromptFieldVariants:      true,
+	PromptFieldTests:         true,
 	PromptFieldExtensions:    true,
 	PromptFieldSkills:        true,
 	PromptFieldTools:         true,
@@ -139,6 +194,9 @@ func (p *Prompt) buildSerializeMap(opts *SerializeOptions) map[string]any {
 	if opts.IncludeExecution && p.Execution != nil {
 		m[PromptFieldExecution] = p.Execution
 	}
+	if opts.IncludeExecution && len(p.Environments) > 0 {
+		m[PromptFieldEnvironments] = p.Environments
+	}
 
 	// Extensions (non-standard YAML keys, written as top-level keys)
 	// Skip keys that match known Prompt fields to prevent overwriting.
@@ -168,6 +226,16 @@ func (p *Prompt) buildSerializeMap(opts *SerializeOptions) map[string]any {
 		m[PromptFieldMetadata] = p.Metadata
 	}
 
+	// Variants (always included)
+	if len(p.Variants) > 0 {
+		m[PromptFieldVariants] = p.Variants
+	}
+
+	// Tests (always included)
+	if len(p.Tests) > 0 {
+		m[PromptFieldTests] = p.Tests
+	}
+
 	// Agent-specific fields
 	if opts.IncludeAgentFields {
 		if len(p.Skills) > 0 {
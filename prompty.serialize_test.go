@@ -1,6 +1,8 @@
 package prompty
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -154,3 +156,40 @@ func TestPrompt_Serialize_RoundTrip(t *testing.T) {
 	assert.Equal(t, original.EffectiveType(), parsed.EffectiveType())
 	assert.Equal(t, original.Body, parsed.Body)
 }
+
+func TestPrompt_Serialize_JSONFormats(t *testing.T) {
+	temp := 0.3
+	p := &Prompt{
+		Name:        "json-export",
+		Description: "Exported as JSON",
+		Type:        DocumentTypeSkill,
+		Execution:   &ExecutionConfig{Provider: ProviderAnthropic, Model: "claude-sonnet-4-5", Temperature: &temp},
+		Extensions:  map[string]any{"x-owner": "search"},
+		Body:        "Summarize {~prompty.var name=\"text\" /~}",
+	}
+
+	frontmatter, err := p.Serialize(&SerializeOptions{IncludeExecution: true, IncludeExtensions: true, Format: DocumentFormatJSONFrontmatter})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(frontmatter), JSONFrontmatterDelimiter+"\n{"))
+	assert.True(t, strings.HasSuffix(string(frontmatter), "}\n---\n"+p.Body))
+
+	document, err := p.ExportJSON()
+	require.NoError(t, err)
+	assert.True(t, json.Valid(document))
+	assert.Equal(t, DocumentFormatJSON, DetectDocumentFormat(document, ""))
+
+	yamlDoc, err := p.ExportFull()
+	require.NoError(t, err)
+
+	for name, data := range map[string][]byte{"json frontmatter": frontmatter, "json": document, "yaml": yamlDoc} {
+		t.Run(name, func(t *testing.T) {
+			parsed, err := Parse(data)
+			require.NoError(t, err)
+			assert.Equal(t, p.Name, parsed.Name)
+			assert.Equal(t, p.Body, parsed.Body)
+			assert.Equal(t, "claude-sonnet-4-5", parsed.Execution.Model)
+			assert.Equal(t, 0.3, *parsed.Execution.Temperature)
+			assert.Equal(t, "search", parsed.Extensions["x-owner"])
+		})
+	}
+}
@@ -2,20 +2,38 @@ package prompty
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
+
+	"github.com/itsatony/go-cuserr"
 )
 
 // CachedStorage wraps any TemplateStorage with in-memory caching.
-// It caches Get operations with configurable TTL and size limits.
+// It caches Get operations with configurable TTL, entry count and byte
+// size limits.
+//
+// Concurrent misses for the same name share one load from the underlying
+// storage, so an expiring popular template causes a single reload instead
+// of one per caller. With StaleWhileRevalidate and RefreshAhead, popular
+// templates are reloaded in the background and callers do not wait for
+// the storage at all. When the underlying storage implements
+// ConditionalStorage, reloads of cached templates are conditional fetches.
 type CachedStorage struct {
 	storage TemplateStorage
 	config  CacheConfig
 
-	mu     sync.RWMutex
-	cache  map[string]*cacheEntry
-	byID   map[TemplateID]*cacheEntry
-	closed bool
+	mu      sync.Mutex
+	cache   *boundedCache[*cacheEntry]
+	byID    map[TemplateID]*cacheEntry
+	flights map[string]*cacheFlight
+	stats   CacheStats
+	closed  bool
+
+	// Background refreshes run with ctx, cancelled by Close
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 // CacheConfig configures the caching behavior.
@@ -25,14 +43,40 @@ type CacheConfig struct {
 	TTL time.Duration
 
 	// MaxEntries is the maximum number of cached templates.
-	// When exceeded, oldest entries are evicted.
+	// When exceeded, an entry chosen by Policy is evicted.
 	// Default: 1000.
 	MaxEntries int
 
+	// MaxBytes bounds the estimated memory held by the cached templates
+	// (sources, metadata and tags). Templates larger than MaxBytes are not
+	// cached. Default: 0 (no byte limit).
+	MaxBytes int64
+
+	// Policy selects the entry evicted when a limit is reached.
+	// Default: CachePolicyLRU.
+	Policy CachePolicy
+
 	// NegativeCacheTTL is how long to cache "not found" results.
+	// Only not-found errors are cached; other storage errors and context
+	// cancellations are returned without caching.
 	// Set to 0 to disable negative caching.
 	// Default: 30 seconds.
 	NegativeCacheTTL time.Duration
+
+	// StaleWhileRevalidate is how long after TTL an expired template is
+	// still served while it is reloaded in the background. If the reload
+	// fails, the stale template keeps being served until the window ends.
+	// Default: 0 (expired templates are reloaded before returning).
+	StaleWhileRevalidate time.Duration
+
+	// RefreshAhead reloads a template in the background when it is read
+	// within this duration before its TTL expires, so templates read
+	// regularly never expire. Default: 0 (disabled).
+	RefreshAhead time.Duration
+
+	// RefreshTimeout bounds each background reload.
+	// Default: 10 seconds.
+	RefreshTimeout time.Duration
 }
 
 // DefaultCacheConfig returns the default caching configuration.
@@ -40,17 +84,27 @@ func DefaultCacheConfig() CacheConfig {
 	return CacheConfig{
 		TTL:              DefaultCacheTTL,
 		MaxEntries:       DefaultCacheMaxEntries,
+		Policy:           CachePolicyLRU,
 		NegativeCacheTTL: DefaultNegativeCacheTTL,
 	}
 }
 
 // cacheEntry represents a cached template.
 type cacheEntry struct {
-	template   *StoredTemplate
-	notFound   bool
-	cachedAt   time.Time
-	accessedAt time.Time
-	key        string
+	template *StoredTemplate
+	notFound bool
+	cachedAt time.Time
+	key      string
+}
+
+// cacheFlight is a load of one name from the underlying storage, shared by
+// the callers that miss the name while it runs.
+type cacheFlight struct {
+	done        chan struct{} // Closed when the load has finished
+	template    *StoredTemplate
+	err         error
+	background  bool // Refresh of a cached entry
+	invalidated bool // Name was invalidated during the load; do not cache
 }
 
 // NewCachedStorage wraps a storage with caching.
@@ -61,13 +115,31 @@ func NewCachedStorage(storage TemplateStorage, config CacheConfig) *CachedStorag
 	if config.MaxEntries == 0 {
 		config.MaxEntries = DefaultCacheMaxEntries
 	}
+	if config.MaxBytes < 0 {
+		config.MaxBytes = 0
+	}
+	if config.Policy == "" {
+		config.Policy = CachePolicyLRU
+	}
+	if config.RefreshTimeout <= 0 {
+		config.RefreshTimeout = DefaultCacheRefreshTimeout
+	}
 
-	return &CachedStorage{
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &CachedStorage{
 		storage: storage,
 		config:  config,
-		cache:   make(map[string]*cacheEntry),
+		cache:   newBoundedCache[*cacheEntry](config.MaxEntries, config.MaxBytes, config.Policy),
 		byID:    make(map[TemplateID]*cacheEntry),
+		flights: make(map[string]*cacheFlight),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	s.cache.onEvict = func(_ string, entry *cacheEntry) {
+		s.forgetID(entry)
+		s.stats.Evictions++
 	}
+	return s
 }
 
 // Get retrieves a template, using cache when available.
@@ -76,46 +148,64 @@ func (s *CachedStorage) Get(ctx context.Context, name string) (*StoredTemplate,
 		return nil, err
 	}
 
-	s.mu.RLock()
-	if s.closed {
-		s.mu.RUnlock()
-		return nil, NewStorageClosedError()
-	}
-
-	// Check cache
-	entry, ok := s.cache[name]
-	if ok && s.isValid(entry) {
-		entry.accessedAt = time.Now()
-		s.mu.RUnlock()
+	for {
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return nil, NewStorageClosedError()
+		}
 
-		if entry.notFound {
-			return nil, NewStorageTemplateNotFoundError(name)
+		// Check cache
+		entry, ok := s.lookup(name)
+		if ok {
+			s.mu.Unlock()
+			if entry.notFound {
+				return nil, NewStorageTemplateNotFoundError(name)
+			}
+			return copyStoredTemplate(entry.template), nil
 		}
-		return copyStoredTemplate(entry.template), nil
-	}
-	s.mu.RUnlock()
 
-	// Cache miss - fetch from storage
-	tmpl, err := s.storage.Get(ctx, name)
+		// Cache miss - join the load in flight or start one
+		flight, leader := s.flights[name], false
+		if flight == nil {
+			flight, leader = s.startFlight(name, false), true
+		} else {
+			s.stats.Coalesced++
+		}
+		s.mu.Unlock()
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+		if leader {
+			s.load(ctx, name, flight)
+		}
+		select {
+		case <-flight.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
 
-	if s.closed {
-		return nil, NewStorageClosedError()
+		// A load cancelled by the caller that started it is retried by
+		// the callers still waiting
+		if !leader && isContextError(flight.err) {
+			continue
+		}
+		if flight.err != nil {
+			return nil, flight.err
+		}
+		return copyStoredTemplate(flight.template), nil
 	}
+}
 
+// GetIfNoneMatch retrieves a template, using cache when available, unless
+// its TemplateETag equals etag.
+func (s *CachedStorage) GetIfNoneMatch(ctx context.Context, name, etag string) (*StoredTemplate, bool, error) {
+	tmpl, err := s.Get(ctx, name)
 	if err != nil {
-		// Cache negative result if configured
-		if s.config.NegativeCacheTTL > 0 {
-			s.addEntry(name, nil, true)
-		}
-		return nil, err
+		return nil, false, err
 	}
-
-	// Cache positive result
-	s.addEntry(name, tmpl, false)
-	return copyStoredTemplate(tmpl), nil
+	if ETagMatches(etag, TemplateETag(tmpl)) {
+		return nil, false, nil
+	}
+	return tmpl, true, nil
 }
 
 // GetByID retrieves a template by ID, using cache when available.
@@ -124,20 +214,23 @@ func (s *CachedStorage) GetByID(ctx context.Context, id TemplateID) (*StoredTemp
 		return nil, err
 	}
 
-	s.mu.RLock()
+	s.mu.Lock()
 	if s.closed {
-		s.mu.RUnlock()
+		s.mu.Unlock()
 		return nil, NewStorageClosedError()
 	}
 
 	// Check cache
-	entry, ok := s.byID[id]
-	if ok && s.isValid(entry) {
-		entry.accessedAt = time.Now()
-		s.mu.RUnlock()
+	var entry *cacheEntry
+	var ok bool
+	if byID, found := s.byID[id]; found {
+		entry, ok = s.lookup(byID.key)
+		ok = ok && entry == byID
+	}
+	s.mu.Unlock()
+	if ok {
 		return copyStoredTemplate(entry.template), nil
 	}
-	s.mu.RUnlock()
 
 	// Cache miss - fetch from storage
 	return s.storage.GetByID(ctx, id)
@@ -200,18 +293,17 @@ func (s *CachedStorage) List(ctx context.Context, query *TemplateQuery) ([]*Stor
 // Exists checks if a template exists (may use cache).
 func (s *CachedStorage) Exists(ctx context.Context, name string) (bool, error) {
 	// Check cache first
-	s.mu.RLock()
+	s.mu.Lock()
 	if s.closed {
-		s.mu.RUnlock()
+		s.mu.Unlock()
 		return false, NewStorageClosedError()
 	}
 
-	entry, ok := s.cache[name]
-	if ok && s.isValid(entry) {
-		s.mu.RUnlock()
+	entry, ok := s.lookup(name)
+	s.mu.Unlock()
+	if ok {
 		return !entry.notFound, nil
 	}
-	s.mu.RUnlock()
 
 	return s.storage.Exists(ctx, name)
 }
@@ -221,14 +313,18 @@ func (s *CachedStorage) ListVersions(ctx context.Context, name string) ([]int, e
 	return s.storage.ListVersions(ctx, name)
 }
 
-// Close closes the cache and underlying storage.
+// Close closes the cache and underlying storage. It cancels background
+// refreshes and waits for them to finish.
 func (s *CachedStorage) Close() error {
 	s.mu.Lock()
 	s.closed = true
-	s.cache = nil
+	s.cache.purge()
 	s.byID = nil
+	s.invalidateFlights()
 	s.mu.Unlock()
 
+	s.cancel()
+	s.wg.Wait()
 	return s.storage.Close()
 }
 
@@ -241,33 +337,42 @@ func (s *CachedStorage) Invalidate(name string) {
 
 // InvalidateAll clears the entire cache.
 func (s *CachedStorage) InvalidateAll() {
+	s.Purge()
+}
+
+// Purge removes all entries from the cache, releasing their memory, and
+// returns how many were removed.
+func (s *CachedStorage) Purge() int {
 	s.mu.Lock()
-	s.cache = make(map[string]*cacheEntry)
+	defer s.mu.Unlock()
+
+	removed := s.cache.purge()
 	s.byID = make(map[TemplateID]*cacheEntry)
-	s.mu.Unlock()
+	s.invalidateFlights()
+	s.stats.Purges += int64(removed)
+	return removed
 }
 
 // Stats returns cache statistics.
 func (s *CachedStorage) Stats() CacheStats {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	var validCount, negativeCount int
-	for _, entry := range s.cache {
-		if s.isValid(entry) {
-			if entry.notFound {
-				negativeCount++
+	stats := s.stats
+	stats.Entries = s.cache.len()
+	stats.Bytes = s.cache.bytes
+	stats.MaxEntries = s.config.MaxEntries
+	stats.MaxBytes = s.config.MaxBytes
+	s.cache.each(func(entry *boundedEntry[*cacheEntry]) {
+		if s.isValid(entry.value) {
+			if entry.value.notFound {
+				stats.NegativeEntries++
 			} else {
-				validCount++
+				stats.ValidEntries++
 			}
 		}
-	}
-
-	return CacheStats{
-		Entries:         len(s.cache),
-		ValidEntries:    validCount,
-		NegativeEntries: negativeCount,
-	}
+	})
+	return stats
 }
 
 // CacheStats contains cache statistics.
@@ -275,6 +380,45 @@ type CacheStats struct {
 	Entries         int
 	ValidEntries    int
 	NegativeEntries int
+	Hits            int64 // Lookups served from the cache, including StaleHits
+	Misses          int64 // Lookups of missing or expired entries
+	StaleHits       int64 // Lookups served an expired entry while it is reloaded
+	Coalesced       int64 // Misses that waited for another caller's load
+	Refreshes       int64 // Background reloads started
+	RefreshErrors   int64 // Background reloads that failed
+	Revalidations   int64 // Reloads the storage answered with not modified
+	Evictions       int64 // Entries removed to stay within MaxEntries or MaxBytes
+	Purges          int64 // Entries removed by Purge or InvalidateAll
+	Bytes           int64 // Estimated memory held by the cached entries
+	MaxEntries      int   // Entry limit
+	MaxBytes        int64 // Byte limit; 0 for none
+}
+
+// lookup returns the entry for name if it may be served, recording a hit
+// or miss. Stale entries and entries nearing expiry are served and
+// reloaded in the background. Caller must hold the lock.
+func (s *CachedStorage) lookup(name string) (*cacheEntry, bool) {
+	entry, ok := s.cache.get(name)
+	if !ok {
+		s.stats.Misses++
+		return nil, false
+	}
+
+	age := time.Since(entry.value.cachedAt)
+	switch {
+	case s.isValid(entry.value):
+		if !entry.value.notFound && s.config.RefreshAhead > 0 && age >= s.config.TTL-s.config.RefreshAhead {
+			s.refresh(name)
+		}
+	case !entry.value.notFound && age < s.config.TTL+s.config.StaleWhileRevalidate:
+		s.stats.StaleHits++
+		s.refresh(name)
+	default:
+		s.stats.Misses++
+		return nil, false
+	}
+	s.stats.Hits++
+	return entry.value, true
 }
 
 // isValid checks if a cache entry is still valid.
@@ -286,54 +430,164 @@ func (s *CachedStorage) isValid(entry *cacheEntry) bool {
 	return time.Since(entry.cachedAt) < ttl
 }
 
+// startFlight registers a load of name.
+// Caller must hold the lock.
+func (s *CachedStorage) startFlight(name string, background bool) *cacheFlight {
+	flight := &cacheFlight{done: make(chan struct{}), background: background}
+	s.flights[name] = flight
+	return flight
+}
+
+// refresh reloads name in the background unless a load is already running.
+// Caller must hold the lock.
+func (s *CachedStorage) refresh(name string) {
+	if _, ok := s.flights[name]; ok {
+		return
+	}
+	flight := s.startFlight(name, true)
+	s.stats.Refreshes++
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ctx, cancel := context.WithTimeout(s.ctx, s.config.RefreshTimeout)
+		defer cancel()
+		s.load(ctx, name, flight)
+	}()
+}
+
+// load fetches name from the underlying storage, caches the result and
+// completes the flight. Templates that do not exist are cached as negative
+// entries; other errors are not cached, and a failed background reload
+// keeps the entry it was refreshing.
+func (s *CachedStorage) load(ctx context.Context, name string, flight *cacheFlight) {
+	tmpl, err := s.fetch(ctx, name)
+
+	s.mu.Lock()
+	if s.flights[name] == flight {
+		delete(s.flights, name)
+	}
+	switch {
+	case s.closed || flight.invalidated:
+	case err == nil:
+		s.addEntry(name, tmpl, false)
+	case isNotFoundError(err):
+		if s.config.NegativeCacheTTL > 0 {
+			s.addEntry(name, nil, true)
+		} else if flight.background {
+			s.invalidateName(name)
+		}
+	case flight.background:
+		s.stats.RefreshErrors++
+	}
+	s.mu.Unlock()
+
+	flight.template, flight.err = tmpl, err
+	close(flight.done)
+}
+
+// fetch reads name from the underlying storage. A cached template is
+// revalidated with a conditional fetch when the storage supports it, and
+// reused if it has not changed.
+func (s *CachedStorage) fetch(ctx context.Context, name string) (*StoredTemplate, error) {
+	cs, ok := s.storage.(ConditionalStorage)
+	if !ok {
+		return s.storage.Get(ctx, name)
+	}
+
+	s.mu.Lock()
+	var cached *StoredTemplate
+	if entry, found := s.cache.peek(name); found {
+		cached = entry.value.template
+	}
+	s.mu.Unlock()
+
+	tmpl, modified, err := cs.GetIfNoneMatch(ctx, name, TemplateETag(cached))
+	if err != nil || modified {
+		return tmpl, err
+	}
+	s.mu.Lock()
+	s.stats.Revalidations++
+	s.mu.Unlock()
+	return cached, nil
+}
+
+// invalidateFlights keeps the loads in flight from caching their results.
+// Caller must hold the lock.
+func (s *CachedStorage) invalidateFlights() {
+	for name, flight := range s.flights {
+		flight.invalidated = true
+		delete(s.flights, name)
+	}
+}
+
+// isNotFoundError reports whether err means the template does not exist.
+func isNotFoundError(err error) bool {
+	return cuserr.IsErrorCategory(err, cuserr.ErrorCategoryNotFound)
+}
+
+// isContextError reports whether err comes from a cancelled or expired
+// context.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
 // addEntry adds an entry to the cache, evicting if necessary.
 // Caller must hold write lock.
 func (s *CachedStorage) addEntry(name string, tmpl *StoredTemplate, notFound bool) {
-	// Evict if at capacity
-	if len(s.cache) >= s.config.MaxEntries {
-		s.evictOldest()
-	}
-
-	now := time.Now()
 	entry := &cacheEntry{
-		template:   tmpl,
-		notFound:   notFound,
-		cachedAt:   now,
-		accessedAt: now,
-		key:        name,
+		template: tmpl,
+		notFound: notFound,
+		cachedAt: time.Now(),
+		key:      name,
 	}
 
-	s.cache[name] = entry
+	s.invalidateName(name)
+	if !s.cache.add(name, entry, storedTemplateSize(name, tmpl)) {
+		return
+	}
 	if tmpl != nil {
 		s.byID[tmpl.ID] = entry
 	}
 }
 
-// invalidateName removes a name from the cache.
+// storedTemplateSize estimates the memory held by a cached template, or by
+// a negative entry for name when tmpl is nil.
+func storedTemplateSize(name string, tmpl *StoredTemplate) int64 {
+	size := int64(len(name)) + cacheEntryOverhead
+	if tmpl == nil {
+		return size
+	}
+	size += int64(len(tmpl.Source) + len(tmpl.ContentHash) + len(tmpl.CreatedBy) + len(tmpl.TenantID) + len(tmpl.ID))
+	for key, value := range tmpl.Metadata {
+		size += int64(len(key) + len(value))
+	}
+	for _, tag := range tmpl.Tags {
+		size += int64(len(tag))
+	}
+	return size
+}
+
+// invalidateName removes a name from the cache, and keeps a load of it in
+// flight from caching what may be an outdated template.
 // Caller must hold write lock.
 func (s *CachedStorage) invalidateName(name string) {
-	entry, ok := s.cache[name]
+	if flight, ok := s.flights[name]; ok {
+		flight.invalidated = true
+		delete(s.flights, name)
+	}
+	entry, ok := s.cache.peek(name)
 	if !ok {
 		return
 	}
-
-	if entry.template != nil {
-		delete(s.byID, entry.template.ID)
-	}
-	delete(s.cache, name)
+	s.forgetID(entry.value)
+	s.cache.remove(name)
 }
 
-// evictOldest removes the oldest accessed entry.
+// forgetID removes the ID index of an entry leaving the cache.
 // Caller must hold write lock.
-func (s *CachedStorage) evictOldest() {
-	var oldest *cacheEntry
-	for _, entry := range s.cache {
-		if oldest == nil || entry.accessedAt.Before(oldest.accessedAt) {
-			oldest = entry
-		}
-	}
-
-	if oldest != nil {
-		s.invalidateName(oldest.key)
+func (s *CachedStorage) forgetID(entry *cacheEntry) {
+	if entry.template != nil && s.byID[entry.template.ID] == entry {
+		delete(s.byID, entry.template.ID)
 	}
 }
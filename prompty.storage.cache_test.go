@@ -2,6 +2,10 @@ package prompty
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -120,6 +124,67 @@ func TestCachedStorage_MaxEntries(t *testing.T) {
 	assert.LessOrEqual(t, stats.Entries, 3)
 }
 
+func TestCachedStorage_MaxBytes(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "tmpl" + intToStr(i), Source: strings.Repeat("x", 1000)}))
+	}
+	one, err := storage.Get(ctx, "tmpl0")
+	require.NoError(t, err)
+	size := storedTemplateSize(one.Name, one)
+
+	cached := NewCachedStorage(storage, CacheConfig{TTL: time.Hour, MaxBytes: 2*size + size/2})
+	defer cached.Close()
+
+	for i := 0; i < 3; i++ {
+		_, err := cached.Get(ctx, "tmpl"+intToStr(i))
+		require.NoError(t, err)
+	}
+	stats := cached.Stats()
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, 2*size, stats.Bytes)
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.Equal(t, int64(3), stats.Misses)
+
+	// tmpl0 was evicted; tmpl2 is still cached
+	_, err = cached.Get(ctx, "tmpl2")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), cached.Stats().Hits)
+	_, err = cached.Get(ctx, "tmpl0")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), cached.Stats().Misses)
+
+	assert.Equal(t, 2, cached.Purge())
+	stats = cached.Stats()
+	assert.Equal(t, 0, stats.Entries)
+	assert.Equal(t, int64(0), stats.Bytes)
+	assert.Equal(t, int64(2), stats.Purges)
+}
+
+func TestCachedStorage_LFUPolicy(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	for _, name := range []string{"hot", "warm", "cold"} {
+		require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: name, Source: name}))
+	}
+	cached := NewCachedStorage(storage, CacheConfig{TTL: time.Hour, MaxEntries: 2, Policy: CachePolicyLFU})
+	defer cached.Close()
+
+	for i := 0; i < 3; i++ {
+		_, _ = cached.Get(ctx, "hot")
+	}
+	_, _ = cached.Get(ctx, "warm")
+	_, _ = cached.Get(ctx, "cold")
+
+	// warm, the least frequently used, was evicted
+	hits := cached.Stats().Hits
+	_, _ = cached.Get(ctx, "hot")
+	assert.Equal(t, hits+1, cached.Stats().Hits)
+	_, _ = cached.Get(ctx, "warm")
+	assert.Equal(t, hits+1, cached.Stats().Hits)
+}
+
 func TestCachedStorage_Invalidate(t *testing.T) {
 	storage := NewMemoryStorage()
 	cached := NewCachedStorage(storage, CacheConfig{
@@ -346,3 +411,204 @@ func TestCachedStorage_PassThrough(t *testing.T) {
 		assert.Equal(t, []int{2, 1}, versions)
 	})
 }
+
+// slowStorage counts Get calls, optionally blocks them until release is
+// closed, and fails them while failing is set
+type slowStorage struct {
+	TemplateStorage
+	gets    atomic.Int32
+	release chan struct{}
+	failing atomic.Bool
+}
+
+func (s *slowStorage) Get(ctx context.Context, name string) (*StoredTemplate, error) {
+	s.gets.Add(1)
+	if s.release != nil {
+		select {
+		case <-s.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if s.failing.Load() {
+		return nil, errors.New("connection refused")
+	}
+	return s.TemplateStorage.Get(ctx, name)
+}
+
+func TestCachedStorage_CoalescesMisses(t *testing.T) {
+	ctx := context.Background()
+	storage := &slowStorage{TemplateStorage: NewMemoryStorage(), release: make(chan struct{})}
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "popular", Source: "hi"}))
+	cached := NewCachedStorage(storage, CacheConfig{TTL: time.Hour})
+	defer cached.Close()
+
+	const callers = 10
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tmpl, err := cached.Get(ctx, "popular")
+			assert.NoError(t, err)
+			assert.Equal(t, "hi", tmpl.Source)
+		}()
+	}
+	require.Eventually(t, func() bool { return cached.Stats().Coalesced == callers-1 }, time.Second, time.Millisecond)
+	close(storage.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), storage.gets.Load())
+	assert.Equal(t, int64(callers), cached.Stats().Misses)
+}
+
+func TestCachedStorage_CoalescedLoadCancelled(t *testing.T) {
+	storage := &slowStorage{TemplateStorage: NewMemoryStorage(), release: make(chan struct{})}
+	require.NoError(t, storage.Save(context.Background(), &StoredTemplate{Name: "popular", Source: "hi"}))
+	cached := NewCachedStorage(storage, CacheConfig{TTL: time.Hour})
+	defer cached.Close()
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := cached.Get(leaderCtx, "popular")
+		leaderErr <- err
+	}()
+	require.Eventually(t, func() bool { return storage.gets.Load() == 1 }, time.Second, time.Millisecond)
+
+	waiter := make(chan *StoredTemplate, 1)
+	go func() {
+		tmpl, err := cached.Get(context.Background(), "popular")
+		assert.NoError(t, err)
+		waiter <- tmpl
+	}()
+	require.Eventually(t, func() bool { return cached.Stats().Coalesced == 1 }, time.Second, time.Millisecond)
+
+	// The waiter retries the load instead of failing with the leader
+	cancel()
+	assert.ErrorIs(t, <-leaderErr, context.Canceled)
+	require.Eventually(t, func() bool { return storage.gets.Load() == 2 }, time.Second, time.Millisecond)
+	close(storage.release)
+	assert.Equal(t, "hi", (<-waiter).Source)
+
+	// The cancelled load was not cached as not found
+	assert.Equal(t, 0, cached.Stats().NegativeEntries)
+}
+
+func TestCachedStorage_NegativeCacheOnlyNotFound(t *testing.T) {
+	ctx := context.Background()
+	storage := &slowStorage{TemplateStorage: NewMemoryStorage()}
+	cached := NewCachedStorage(storage, CacheConfig{TTL: time.Hour, NegativeCacheTTL: time.Hour})
+	defer cached.Close()
+
+	storage.failing.Store(true)
+	_, err := cached.Get(ctx, "missing")
+	assert.ErrorContains(t, err, "connection refused")
+	assert.Equal(t, 0, cached.Stats().NegativeEntries)
+
+	storage.failing.Store(false)
+	_, err = cached.Get(ctx, "missing")
+	assert.ErrorContains(t, err, ErrMsgTemplateNotFound)
+	_, err = cached.Get(ctx, "missing")
+	assert.ErrorContains(t, err, ErrMsgTemplateNotFound)
+	assert.Equal(t, 1, cached.Stats().NegativeEntries)
+	assert.Equal(t, int32(2), storage.gets.Load())
+}
+
+func TestCachedStorage_StaleWhileRevalidate(t *testing.T) {
+	ctx := context.Background()
+	storage := &slowStorage{TemplateStorage: NewMemoryStorage()}
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "tmpl", Source: "v1"}))
+	cached := NewCachedStorage(storage, CacheConfig{TTL: 20 * time.Millisecond, StaleWhileRevalidate: time.Hour})
+	defer cached.Close()
+
+	_, err := cached.Get(ctx, "tmpl")
+	require.NoError(t, err)
+	time.Sleep(30 * time.Millisecond)
+
+	// A failed reload keeps serving the stale template
+	storage.failing.Store(true)
+	tmpl, err := cached.Get(ctx, "tmpl")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", tmpl.Source)
+	require.Eventually(t, func() bool { return cached.Stats().RefreshErrors == 1 }, time.Second, time.Millisecond)
+
+	storage.failing.Store(false)
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "tmpl", Source: "v2"}))
+	tmpl, err = cached.Get(ctx, "tmpl")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", tmpl.Source)
+	require.Eventually(t, func() bool {
+		tmpl, err := cached.Get(ctx, "tmpl")
+		return err == nil && tmpl.Source == "v2"
+	}, time.Second, time.Millisecond)
+
+	stats := cached.Stats()
+	assert.GreaterOrEqual(t, stats.StaleHits, int64(2))
+	assert.GreaterOrEqual(t, stats.Refreshes, int64(2))
+	assert.Equal(t, int64(1), stats.Misses)
+}
+
+func TestCachedStorage_RefreshAhead(t *testing.T) {
+	ctx := context.Background()
+	storage := &slowStorage{TemplateStorage: NewMemoryStorage()}
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "tmpl", Source: "v1"}))
+	cached := NewCachedStorage(storage, CacheConfig{TTL: time.Hour, RefreshAhead: time.Hour - time.Millisecond})
+	defer cached.Close()
+
+	_, err := cached.Get(ctx, "tmpl")
+	require.NoError(t, err)
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "tmpl", Source: "v2"}))
+	time.Sleep(2 * time.Millisecond)
+
+	// Reads near expiry are served from the cache and reload it
+	tmpl, err := cached.Get(ctx, "tmpl")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", tmpl.Source)
+	require.Eventually(t, func() bool {
+		tmpl, err := cached.Get(ctx, "tmpl")
+		return err == nil && tmpl.Source == "v2"
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int64(1), cached.Stats().Misses)
+	assert.Equal(t, int64(0), cached.Stats().StaleHits)
+}
+
+func TestCachedStorage_InvalidateDuringLoad(t *testing.T) {
+	ctx := context.Background()
+	storage := &slowStorage{TemplateStorage: NewMemoryStorage(), release: make(chan struct{})}
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "tmpl", Source: "v1"}))
+	cached := NewCachedStorage(storage, CacheConfig{TTL: time.Hour})
+	defer cached.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := cached.Get(ctx, "tmpl")
+		assert.NoError(t, err)
+	}()
+	require.Eventually(t, func() bool { return storage.gets.Load() == 1 }, time.Second, time.Millisecond)
+	cached.Invalidate("tmpl")
+	close(storage.release)
+	<-done
+
+	// The load that raced the invalidation was not cached
+	assert.Equal(t, 0, cached.Stats().Entries)
+}
+
+func TestCachedStorage_CloseCancelsRefresh(t *testing.T) {
+	ctx := context.Background()
+	storage := &slowStorage{TemplateStorage: NewMemoryStorage()}
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "tmpl", Source: "v1"}))
+	cached := NewCachedStorage(storage, CacheConfig{TTL: 10 * time.Millisecond, StaleWhileRevalidate: time.Hour})
+
+	_, err := cached.Get(ctx, "tmpl")
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	storage.release = make(chan struct{}) // Block the refresh
+	_, err = cached.Get(ctx, "tmpl")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return storage.gets.Load() == 2 }, time.Second, time.Millisecond)
+
+	require.NoError(t, cached.Close())
+	assert.Equal(t, int64(1), cached.Stats().Refreshes)
+}
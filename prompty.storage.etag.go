@@ -0,0 +1,103 @@
+package prompty
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// ETag syntax (RFC 9110)
+const (
+	etagQuote    = `"`
+	etagWeak     = "W/"
+	etagWildcard = "*"
+	etagListSep  = ","
+)
+
+// TemplateETag returns the entity tag of a stored template: a quoted hash
+// over its ID, version, content, status and update time. It changes
+// whenever the stored representation of the template does, so clients
+// holding a template can fetch it conditionally with GetIfNoneMatch or an
+// HTTP If-None-Match header.
+func TemplateETag(tmpl *StoredTemplate) string {
+	if tmpl == nil {
+		return ""
+	}
+	fields := []string{
+		string(tmpl.ID),
+		strconv.Itoa(tmpl.Version),
+		storedContentHash(tmpl),
+		string(tmpl.Status),
+		strconv.FormatInt(tmpl.UpdatedAt.UnixNano(), 10),
+	}
+	return quoteETag(hashContent(strings.Join(fields, contentHashSeparator)))
+}
+
+// GetIfNoneMatch retrieves the latest version of a template from storage
+// unless its TemplateETag equals etag. Storages implementing
+// ConditionalStorage skip the transfer; others are read with Get and the
+// tags compared, which still lets callers skip decoding and parsing. An
+// empty etag always fetches the template.
+func GetIfNoneMatch(ctx context.Context, storage TemplateStorage, name, etag string) (*StoredTemplate, bool, error) {
+	if cs, ok := storage.(ConditionalStorage); ok {
+		return cs.GetIfNoneMatch(ctx, name, etag)
+	}
+	tmpl, err := storage.Get(ctx, name)
+	if err != nil {
+		return nil, false, err
+	}
+	if etag != "" && ETagMatches(etag, TemplateETag(tmpl)) {
+		return nil, false, nil
+	}
+	return tmpl, true, nil
+}
+
+// DataHash returns a prefixed SHA-256 hash of template data, computed over
+// its JSON encoding (map keys sorted), so equal data hashes equally
+// regardless of map order. Data JSON cannot encode, such as functions or
+// channels, returns an error with ErrMsgDataNotHashable.
+func DataHash(data map[string]any) (string, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", NewDataHashError(err)
+	}
+	return hashContent(string(encoded)), nil
+}
+
+// ResultKey returns a hash identifying the render of a stored template's
+// content with data. Clients can cache rendered output under it: it stays
+// the same as long as the template content and the data do. It does not
+// cover included templates, resolvers or functions such as now(), so
+// templates depending on those should not be cached by it.
+func ResultKey(tmpl *StoredTemplate, data map[string]any) (string, error) {
+	dataHash, err := DataHash(data)
+	if err != nil {
+		return "", err
+	}
+	return hashContent(storedContentHash(tmpl) + contentHashSeparator + dataHash), nil
+}
+
+// ETagMatches reports whether an If-None-Match header value matches etag.
+// The header may list several tags, use weak tags or be "*".
+func ETagMatches(header, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, etagWeak)
+	for _, candidate := range strings.Split(header, etagListSep) {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "" {
+			continue
+		}
+		if candidate == etagWildcard || strings.TrimPrefix(candidate, etagWeak) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteETag returns value as a strong entity tag.
+func quoteETag(value string) string {
+	return etagQuote + value + etagQuote
+}
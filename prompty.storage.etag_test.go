@@ -0,0 +1,96 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateETag(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "greeting", Source: "Hello"}))
+	v1, err := storage.Get(ctx, "greeting")
+	require.NoError(t, err)
+
+	etag := TemplateETag(v1)
+	assert.Regexp(t, `^"sha256:[0-9a-f]{64}"$`, etag)
+	assert.Equal(t, etag, TemplateETag(copyStoredTemplate(v1)))
+	assert.Empty(t, TemplateETag(nil))
+
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "greeting", Source: "Hello"}))
+	v2, err := storage.Get(ctx, "greeting")
+	require.NoError(t, err)
+	assert.NotEqual(t, etag, TemplateETag(v2))
+
+	archived := copyStoredTemplate(v1)
+	archived.Status = DeploymentStatusArchived
+	assert.NotEqual(t, etag, TemplateETag(archived))
+}
+
+func TestETagMatches(t *testing.T) {
+	etag := `"abc"`
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"xyz", "abc"`, true},
+		{`*`, true},
+		{`"xyz"`, false},
+		{``, false},
+		{`abc`, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ETagMatches(tt.header, etag), tt.header)
+	}
+	assert.False(t, ETagMatches(`*`, ""))
+}
+
+func TestDataHashAndResultKey(t *testing.T) {
+	a, err := DataHash(map[string]any{"user": map[string]any{"name": "Ada", "id": 1}, "lang": "en"})
+	require.NoError(t, err)
+	b, err := DataHash(map[string]any{"lang": "en", "user": map[string]any{"id": 1, "name": "Ada"}})
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+	assert.Contains(t, a, ContentHashPrefix)
+
+	_, err = DataHash(map[string]any{"fn": func() {}})
+	assert.ErrorContains(t, err, ErrMsgDataNotHashable)
+
+	tmpl := &StoredTemplate{Name: "greeting", Source: "Hello", Version: 1}
+	key, err := ResultKey(tmpl, map[string]any{"lang": "en"})
+	require.NoError(t, err)
+
+	// The key depends on the content and data, not the version
+	other := *tmpl
+	other.Version = 2
+	same, err := ResultKey(&other, map[string]any{"lang": "en"})
+	require.NoError(t, err)
+	assert.Equal(t, key, same)
+	changed, err := ResultKey(tmpl, map[string]any{"lang": "de"})
+	require.NoError(t, err)
+	assert.NotEqual(t, key, changed)
+}
+
+func TestGetIfNoneMatch(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "greeting", Source: "Hello"}))
+
+	tmpl, modified, err := GetIfNoneMatch(ctx, storage, "greeting", "")
+	require.NoError(t, err)
+	require.True(t, modified)
+	assert.Equal(t, "Hello", tmpl.Source)
+
+	tmpl, modified, err = GetIfNoneMatch(ctx, storage, "greeting", TemplateETag(tmpl))
+	require.NoError(t, err)
+	assert.False(t, modified)
+	assert.Nil(t, tmpl)
+
+	_, _, err = GetIfNoneMatch(ctx, storage, "missing", `"x"`)
+	assert.ErrorContains(t, err, ErrMsgTemplateNotFound)
+}
@@ -219,6 +219,7 @@ func (s *FilesystemStorage) Save(ctx context.Context, tmpl *StoredTemplate) erro
 		ID:           generateTemplateID(),
 		Name:         tmpl.Name,
 		Source:       tmpl.Source,
+		ContentHash:  ContentHash(tmpl.Source),
 		Version:      nextVersion,
 		Status:       status,
 		Metadata:     copyStringMap(tmpl.Metadata),
@@ -228,6 +229,8 @@ func (s *FilesystemStorage) Save(ctx context.Context, tmpl *StoredTemplate) erro
 		CreatedBy:    tmpl.CreatedBy,
 		TenantID:     tmpl.TenantID,
 		Tags:         copyStringSlice(tmpl.Tags),
+		ActiveFrom:   copyTimePtr(tmpl.ActiveFrom),
+		ActiveUntil:  copyTimePtr(tmpl.ActiveUntil),
 	}
 
 	// Write to file
@@ -243,6 +246,7 @@ func (s *FilesystemStorage) Save(ctx context.Context, tmpl *StoredTemplate) erro
 
 	// Update input template with generated values
 	tmpl.ID = stored.ID
+	tmpl.ContentHash = stored.ContentHash
 	tmpl.Version = stored.Version
 	tmpl.Status = stored.Status
 	tmpl.CreatedAt = stored.CreatedAt
@@ -410,7 +414,7 @@ func (s *FilesystemStorage) List(ctx context.Context, query *TemplateQuery) ([]*
 				if err != nil {
 					continue
 				}
-				if matchesTemplateQuery(tmpl, query) {
+				if matchesSearch(tmpl, query.Search) && matchesTemplateQuery(tmpl, query) {
 					results = append(results, tmpl)
 				}
 			}
@@ -420,19 +424,13 @@ func (s *FilesystemStorage) List(ctx context.Context, query *TemplateQuery) ([]*
 			if err != nil {
 				continue
 			}
-			if matchesTemplateQuery(tmpl, query) {
+			if matchesSearch(tmpl, query.Search) && matchesTemplateQuery(tmpl, query) {
 				results = append(results, tmpl)
 			}
 		}
 	}
 
-	// Sort by name, then version descending
-	sort.Slice(results, func(i, j int) bool {
-		if results[i].Name != results[j].Name {
-			return results[i].Name < results[j].Name
-		}
-		return results[i].Version > results[j].Version
-	})
+	sortTemplates(results, query)
 
 	// Apply offset and limit
 	if query.Offset > 0 {
@@ -542,6 +540,10 @@ func (s *FilesystemStorage) loadTemplate(name string, version int) (*StoredTempl
 	if err := json.Unmarshal(data, &tmpl); err != nil {
 		return nil, &StorageError{Message: ErrMsgUnmarshalTemplate, Name: filename, Cause: err}
 	}
+	if tmpl.ContentHash == "" {
+		// Saved before content hashes were recorded
+		tmpl.ContentHash = ContentHash(tmpl.Source)
+	}
 
 	return &tmpl, nil
 }
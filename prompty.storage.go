@@ -22,6 +22,10 @@ type StoredTemplate struct {
 	// Source is the raw template source code.
 	Source string `json:"source"`
 
+	// ContentHash is the canonical content hash of Source (see ContentHash),
+	// set by the storage backend on Save.
+	ContentHash string `json:"content_hash,omitempty"`
+
 	// Version is the version number (1, 2, 3, ...).
 	// Higher versions are newer.
 	Version int `json:"version"`
@@ -52,6 +56,14 @@ type StoredTemplate struct {
 
 	// Tags for categorization and querying.
 	Tags []string `json:"tags,omitempty"`
+
+	// ActiveFrom is when this version becomes executable (optional).
+	// StorageEngine.Execute skips versions scheduled for later.
+	ActiveFrom *time.Time `json:"active_from,omitempty"`
+
+	// ActiveUntil is when this version expires (optional).
+	// StorageEngine.Execute skips expired versions.
+	ActiveUntil *time.Time `json:"active_until,omitempty"`
 }
 
 // TemplateQuery defines filters for listing templates.
@@ -86,6 +98,33 @@ type TemplateQuery struct {
 
 	// IncludeAllVersions includes all versions, not just latest.
 	IncludeAllVersions bool
+
+	// ExpiredAsOf filters to templates whose ActiveUntil is at or before
+	// this time, e.g. time.Now() for expired templates (zero = no filter).
+	ExpiredAsOf time.Time
+
+	// Search filters to templates whose source or prompt description
+	// contains every word of this text (case-insensitive).
+	Search string
+
+	// Metadata filters to templates having ALL these metadata values.
+	Metadata map[string]string
+
+	// CreatedAfter and CreatedBefore bound the creation time
+	// (inclusive, zero = unbounded).
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+
+	// UpdatedAfter and UpdatedBefore bound the last update time
+	// (inclusive, zero = unbounded).
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+
+	// SortBy orders the results (default: name, then version descending).
+	SortBy TemplateSortField
+
+	// SortDescending reverses the order of SortBy.
+	SortDescending bool
 }
 
 // TemplateStorage is the interface for pluggable storage backends.
@@ -198,6 +237,18 @@ type StatusStorage interface {
 	ListByStatus(ctx context.Context, status DeploymentStatus, query *TemplateQuery) ([]*StoredTemplate, error)
 }
 
+// ConditionalStorage is implemented by storages that can skip transferring
+// a template the caller already holds, such as remote storages. Use the
+// GetIfNoneMatch function to fetch conditionally from any storage.
+// Implementations must be safe for concurrent use.
+type ConditionalStorage interface {
+	// GetIfNoneMatch retrieves the latest version of a template unless its
+	// TemplateETag equals etag, in which case it returns no template and
+	// modified is false.
+	// Returns ErrTemplateNotFound if the template doesn't exist.
+	GetIfNoneMatch(ctx context.Context, name, etag string) (tmpl *StoredTemplate, modified bool, err error)
+}
+
 // ExtendedTemplateStorage combines all storage interfaces.
 // Implementations that support labels and status should implement this interface.
 type ExtendedTemplateStorage interface {
@@ -267,6 +318,7 @@ const (
 	ErrMsgStorageClosed           = "storage is closed"
 	ErrMsgInvalidTemplateID       = "invalid template ID"
 	ErrMsgVersionNotFound         = "template version not found"
+	ErrMsgNoActiveVersion         = "no active template version"
 )
 
 // Storage metadata key constants
@@ -296,6 +348,15 @@ func NewStorageVersionNotFoundError(name string, version int) error {
 	}
 }
 
+// NewStorageNoActiveVersionError creates an error for a template without a
+// version inside its activation schedule.
+func NewStorageNoActiveVersionError(name string) error {
+	return &StorageError{
+		Message: ErrMsgNoActiveVersion,
+		Name:    name,
+	}
+}
+
 // NewStorageClosedError creates an error for operations on closed storage.
 func NewStorageClosedError() error {
 	return &StorageError{
@@ -303,7 +364,8 @@ func NewStorageClosedError() error {
 	}
 }
 
-// StorageError represents a storage-related error.
+// StorageError represents a storage-related error. Missing drivers,
+// versions and labels match ErrNotFound with errors.Is.
 type StorageError struct {
 	Message string
 	Name    string
@@ -327,6 +389,21 @@ func (e *StorageError) Unwrap() error {
 	return e.Cause
 }
 
+// Is reports whether the error is a missing driver, version or label for
+// ErrNotFound.
+func (e *StorageError) Is(target error) bool {
+	return target == ErrNotFound && e.notFound()
+}
+
+// notFound reports whether the error is about something that does not exist.
+func (e *StorageError) notFound() bool {
+	switch e.Message {
+	case ErrMsgStorageDriverNotFound, ErrMsgVersionNotFound, ErrMsgNoActiveVersion, ErrMsgLabelNotFound:
+		return true
+	}
+	return false
+}
+
 // intToStr converts an int to string without importing strconv.
 func intToStr(i int) string {
 	if i == 0 {
@@ -0,0 +1,572 @@
+package prompty
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itsatony/go-cuserr"
+)
+
+// HTTPStorageConfig configures the HTTP storage client.
+type HTTPStorageConfig struct {
+	// BaseURL is the root URL of a server exposing NewStorageHTTPHandler,
+	// e.g. "https://prompts.example.com/api".
+	BaseURL string
+
+	// Client is the HTTP client used for requests.
+	// Default: a client with HTTPStorageDefaultTimeout
+	Client *http.Client
+
+	// Header is added to every request (e.g. Authorization).
+	Header http.Header
+}
+
+// HTTPStorage implements TemplateStorage against a remote server that
+// exposes a storage backend via NewStorageHTTPHandler.
+//
+// Endpoints (relative to BaseURL):
+//
+//	GET    /templates                       List (query: name_prefix, tag, status, all_versions, ...)
+//	POST   /templates                       Save (returns the stored template)
+//	GET    /templates/{name}                Get latest version (honors If-None-Match)
+//	DELETE /templates/{name}                Delete all versions
+//	GET    /templates/{name}/versions       ListVersions
+//	GET    /templates/{name}/versions/{v}   GetVersion
+//	DELETE /templates/{name}/versions/{v}   DeleteVersion
+//	GET    /ids/{id}                        GetByID
+//
+// Template responses carry a TemplateETag in the ETag header, and
+// GetIfNoneMatch sends it back in If-None-Match so the server answers
+// 304 Not Modified instead of the template when it has not changed.
+type HTTPStorage struct {
+	baseURL string
+	client  *http.Client
+	header  http.Header
+	mu      sync.RWMutex
+	closed  bool
+}
+
+// HTTPStorageDriver is the driver for creating HTTPStorage instances.
+type HTTPStorageDriver struct{}
+
+func init() {
+	RegisterStorageDriver(StorageDriverNameHTTP, &HTTPStorageDriver{})
+}
+
+// Open creates a new HTTPStorage instance.
+// The connection string is the server base URL.
+func (d *HTTPStorageDriver) Open(connectionString string) (TemplateStorage, error) {
+	return NewHTTPStorage(HTTPStorageConfig{BaseURL: connectionString})
+}
+
+// NewHTTPStorage creates a new HTTP template storage client.
+func NewHTTPStorage(config HTTPStorageConfig) (*HTTPStorage, error) {
+	if config.BaseURL == "" {
+		return nil, &StorageError{Message: ErrMsgHTTPStorageEmptyURL}
+	}
+	parsed, err := url.Parse(config.BaseURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, &StorageError{Message: ErrMsgHTTPStorageInvalidURL, Name: config.BaseURL, Cause: err}
+	}
+
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: HTTPStorageDefaultTimeout}
+	}
+
+	return &HTTPStorage{
+		baseURL: strings.TrimRight(config.BaseURL, "/"),
+		client:  client,
+		header:  config.Header.Clone(),
+	}, nil
+}
+
+// Get retrieves the latest version of a template by name.
+func (s *HTTPStorage) Get(ctx context.Context, name string) (*StoredTemplate, error) {
+	var tmpl StoredTemplate
+	if err := s.do(ctx, http.MethodGet, templatePath(name), nil, nil, &tmpl); err != nil {
+		return nil, notFoundAs(err, NewStorageTemplateNotFoundError(name))
+	}
+	return &tmpl, nil
+}
+
+// GetIfNoneMatch retrieves the latest version of a template unless the
+// server reports that its ETag still equals etag.
+func (s *HTTPStorage) GetIfNoneMatch(ctx context.Context, name, etag string) (*StoredTemplate, bool, error) {
+	var header http.Header
+	if etag != "" {
+		header = http.Header{HTTPStorageHeaderIfNone: []string{etag}}
+	}
+	var tmpl StoredTemplate
+	status, err := s.request(ctx, http.MethodGet, templatePath(name), nil, header, nil, &tmpl)
+	if err != nil {
+		return nil, false, notFoundAs(err, NewStorageTemplateNotFoundError(name))
+	}
+	if status == http.StatusNotModified {
+		return nil, false, nil
+	}
+	return &tmpl, true, nil
+}
+
+// GetByID retrieves a specific template version by ID.
+func (s *HTTPStorage) GetByID(ctx context.Context, id TemplateID) (*StoredTemplate, error) {
+	var tmpl StoredTemplate
+	path := HTTPStoragePathIDs + "/" + url.PathEscape(string(id))
+	if err := s.do(ctx, http.MethodGet, path, nil, nil, &tmpl); err != nil {
+		return nil, notFoundAs(err, NewStorageTemplateNotFoundError(string(id)))
+	}
+	return &tmpl, nil
+}
+
+// GetVersion retrieves a specific version of a template.
+func (s *HTTPStorage) GetVersion(ctx context.Context, name string, version int) (*StoredTemplate, error) {
+	var tmpl StoredTemplate
+	if err := s.do(ctx, http.MethodGet, versionPath(name, version), nil, nil, &tmpl); err != nil {
+		return nil, notFoundAs(err, NewStorageVersionNotFoundError(name, version))
+	}
+	return &tmpl, nil
+}
+
+// Save stores a template on the server. ID, Version, CreatedAt and UpdatedAt
+// are updated from the server response.
+func (s *HTTPStorage) Save(ctx context.Context, tmpl *StoredTemplate) error {
+	if tmpl.Name == "" {
+		return &StorageError{Message: ErrMsgInvalidTemplateName}
+	}
+	var saved StoredTemplate
+	if err := s.do(ctx, http.MethodPost, HTTPStoragePathTemplates, nil, tmpl, &saved); err != nil {
+		return err
+	}
+	tmpl.ID = saved.ID
+	tmpl.ContentHash = saved.ContentHash
+	tmpl.Version = saved.Version
+	tmpl.Status = saved.Status
+	tmpl.CreatedAt = saved.CreatedAt
+	tmpl.UpdatedAt = saved.UpdatedAt
+	return nil
+}
+
+// Delete removes all versions of a template by name.
+func (s *HTTPStorage) Delete(ctx context.Context, name string) error {
+	if err := s.do(ctx, http.MethodDelete, templatePath(name), nil, nil, nil); err != nil {
+		return notFoundAs(err, NewStorageTemplateNotFoundError(name))
+	}
+	return nil
+}
+
+// DeleteVersion removes a specific version of a template.
+func (s *HTTPStorage) DeleteVersion(ctx context.Context, name string, version int) error {
+	if err := s.do(ctx, http.MethodDelete, versionPath(name, version), nil, nil, nil); err != nil {
+		return notFoundAs(err, NewStorageVersionNotFoundError(name, version))
+	}
+	return nil
+}
+
+// List returns templates matching the query.
+func (s *HTTPStorage) List(ctx context.Context, query *TemplateQuery) ([]*StoredTemplate, error) {
+	var templates []*StoredTemplate
+	if err := s.do(ctx, http.MethodGet, HTTPStoragePathTemplates, encodeTemplateQuery(query), nil, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// Exists checks if a template with the given name exists.
+func (s *HTTPStorage) Exists(ctx context.Context, name string) (bool, error) {
+	err := s.do(ctx, http.MethodGet, templatePath(name), nil, nil, nil)
+	if err == nil {
+		return true, nil
+	}
+	if isHTTPNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// ListVersions returns all version numbers for a template.
+func (s *HTTPStorage) ListVersions(ctx context.Context, name string) ([]int, error) {
+	var versions []int
+	if err := s.do(ctx, http.MethodGet, templatePath(name)+HTTPStoragePathVersions, nil, nil, &versions); err != nil {
+		return nil, err
+	}
+	if versions == nil {
+		versions = []int{}
+	}
+	return versions, nil
+}
+
+// Close marks the client as closed. The underlying HTTP client is not closed.
+func (s *HTTPStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+// httpStatusError is returned by HTTPStorage.do for non-2xx responses.
+type httpStatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *httpStatusError) Error() string {
+	if e.Message == "" {
+		return strconv.Itoa(e.StatusCode)
+	}
+	return strconv.Itoa(e.StatusCode) + " " + e.Message
+}
+
+// httpErrorBody is the JSON error body written by the storage handler.
+type httpErrorBody struct {
+	Error string `json:"error"`
+}
+
+// do performs a request and decodes a JSON response into out (if non-nil).
+func (s *HTTPStorage) do(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	_, err := s.request(ctx, method, path, query, nil, in, out)
+	return err
+}
+
+// request performs a request with additional headers and decodes a JSON
+// response into out (if non-nil). It returns the response status; 304 Not
+// Modified is not an error.
+func (s *HTTPStorage) request(ctx context.Context, method, path string, query url.Values, header http.Header, in, out any) (int, error) {
+	s.mu.RLock()
+	closed := s.closed
+	s.mu.RUnlock()
+	if closed {
+		return 0, NewStorageClosedError()
+	}
+
+	target := s.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return 0, &StorageError{Message: ErrMsgHTTPStorageRequestFailed, Cause: err}
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return 0, &StorageError{Message: ErrMsgHTTPStorageRequestFailed, Cause: err}
+	}
+	for _, h := range []http.Header{s.header, header} {
+		for key, values := range h {
+			for _, v := range values {
+				req.Header.Add(key, v)
+			}
+		}
+	}
+	req.Header.Set(HTTPStorageHeaderAccept, HTTPStorageContentType)
+	if in != nil {
+		req.Header.Set(HTTPStorageHeaderContent, HTTPStorageContentType)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, &StorageError{Message: ErrMsgHTTPStorageRequestFailed, Cause: err}
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, HTTPStorageMaxBodySize))
+	if err != nil {
+		return 0, &StorageError{Message: ErrMsgHTTPStorageRequestFailed, Cause: err}
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		return resp.StatusCode, nil
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		statusErr := &httpStatusError{StatusCode: resp.StatusCode}
+		var errBody httpErrorBody
+		if json.Unmarshal(data, &errBody) == nil {
+			statusErr.Message = errBody.Error
+		}
+		return resp.StatusCode, &StorageError{Message: ErrMsgHTTPStorageUnexpectedCode, Name: statusErr.Error(), Cause: statusErr}
+	}
+
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return resp.StatusCode, &StorageError{Message: ErrMsgHTTPStorageDecodeFailed, Cause: err}
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// isHTTPNotFound reports whether err is a 404 response from the server.
+func isHTTPNotFound(err error) bool {
+	var statusErr *httpStatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// notFoundAs maps a 404 response to the storage-specific not-found error.
+func notFoundAs(err, notFound error) error {
+	if isHTTPNotFound(err) {
+		return notFound
+	}
+	return err
+}
+
+// templatePath returns the escaped path for a template name.
+func templatePath(name string) string {
+	return HTTPStoragePathTemplates + "/" + url.PathEscape(name)
+}
+
+// versionPath returns the escaped path for a template version.
+func versionPath(name string, version int) string {
+	return templatePath(name) + HTTPStoragePathVersions + "/" + strconv.Itoa(version)
+}
+
+// encodeTemplateQuery encodes a TemplateQuery as URL query parameters.
+func encodeTemplateQuery(query *TemplateQuery) url.Values {
+	values := url.Values{}
+	if query == nil {
+		return values
+	}
+	if query.TenantID != "" {
+		values.Set(HTTPStorageParamTenantID, query.TenantID)
+	}
+	for _, tag := range query.Tags {
+		values.Add(HTTPStorageParamTag, tag)
+	}
+	if query.CreatedBy != "" {
+		values.Set(HTTPStorageParamCreatedBy, query.CreatedBy)
+	}
+	if query.NamePrefix != "" {
+		values.Set(HTTPStorageParamNamePrefix, query.NamePrefix)
+	}
+	if query.NameContains != "" {
+		values.Set(HTTPStorageParamNameContains, query.NameContains)
+	}
+	statuses := query.Statuses
+	if len(statuses) == 0 && query.Status != "" {
+		statuses = []DeploymentStatus{query.Status}
+	}
+	for _, status := range statuses {
+		values.Add(HTTPStorageParamStatus, string(status))
+	}
+	if query.Limit > 0 {
+		values.Set(HTTPStorageParamLimit, strconv.Itoa(query.Limit))
+	}
+	if query.Offset > 0 {
+		values.Set(HTTPStorageParamOffset, strconv.Itoa(query.Offset))
+	}
+	if query.IncludeAllVersions {
+		values.Set(HTTPStorageParamAllVersions, AttrValueTrue)
+	}
+	if !query.ExpiredAsOf.IsZero() {
+		values.Set(HTTPStorageParamExpiredAsOf, query.ExpiredAsOf.Format(time.RFC3339Nano))
+	}
+	if query.Search != "" {
+		values.Set(HTTPStorageParamSearch, query.Search)
+	}
+	for k, v := range query.Metadata {
+		values.Add(HTTPStorageParamMetadata, k+"="+v)
+	}
+	for param, t := range map[string]time.Time{
+		HTTPStorageParamCreatedAfter:  query.CreatedAfter,
+		HTTPStorageParamCreatedBefore: query.CreatedBefore,
+		HTTPStorageParamUpdatedAfter:  query.UpdatedAfter,
+		HTTPStorageParamUpdatedBefore: query.UpdatedBefore,
+	} {
+		if !t.IsZero() {
+			values.Set(param, t.Format(time.RFC3339Nano))
+		}
+	}
+	if query.SortBy != "" {
+		values.Set(HTTPStorageParamSort, string(query.SortBy))
+	}
+	if query.SortDescending {
+		values.Set(HTTPStorageParamSortDesc, AttrValueTrue)
+	}
+	return values
+}
+
+// decodeTemplateQuery decodes URL query parameters into a TemplateQuery.
+func decodeTemplateQuery(values url.Values) *TemplateQuery {
+	query := &TemplateQuery{
+		TenantID:           values.Get(HTTPStorageParamTenantID),
+		Tags:               values[HTTPStorageParamTag],
+		CreatedBy:          values.Get(HTTPStorageParamCreatedBy),
+		NamePrefix:         values.Get(HTTPStorageParamNamePrefix),
+		NameContains:       values.Get(HTTPStorageParamNameContains),
+		IncludeAllVersions: values.Get(HTTPStorageParamAllVersions) == AttrValueTrue,
+	}
+	for _, status := range values[HTTPStorageParamStatus] {
+		query.Statuses = append(query.Statuses, DeploymentStatus(status))
+	}
+	query.Limit, _ = strconv.Atoi(values.Get(HTTPStorageParamLimit))
+	query.Offset, _ = strconv.Atoi(values.Get(HTTPStorageParamOffset))
+	query.ExpiredAsOf, _ = time.Parse(time.RFC3339Nano, values.Get(HTTPStorageParamExpiredAsOf))
+	query.Search = values.Get(HTTPStorageParamSearch)
+	for _, pair := range values[HTTPStorageParamMetadata] {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		if query.Metadata == nil {
+			query.Metadata = make(map[string]string)
+		}
+		query.Metadata[k] = v
+	}
+	query.CreatedAfter, _ = time.Parse(time.RFC3339Nano, values.Get(HTTPStorageParamCreatedAfter))
+	query.CreatedBefore, _ = time.Parse(time.RFC3339Nano, values.Get(HTTPStorageParamCreatedBefore))
+	query.UpdatedAfter, _ = time.Parse(time.RFC3339Nano, values.Get(HTTPStorageParamUpdatedAfter))
+	query.UpdatedBefore, _ = time.Parse(time.RFC3339Nano, values.Get(HTTPStorageParamUpdatedBefore))
+	query.SortBy = TemplateSortField(values.Get(HTTPStorageParamSort))
+	query.SortDescending = values.Get(HTTPStorageParamSortDesc) == AttrValueTrue
+	return query
+}
+
+// NewStorageHTTPHandler exposes a TemplateStorage over HTTP using the
+// endpoints documented on HTTPStorage. Mount it with http.StripPrefix to
+// serve it below a path prefix. Authentication is left to middleware.
+func NewStorageHTTPHandler(storage TemplateStorage) http.Handler {
+	h := &storageHandler{storage: storage}
+	mux := http.NewServeMux()
+	mux.HandleFunc(http.MethodGet+" "+HTTPStoragePathTemplates, h.list)
+	mux.HandleFunc(http.MethodPost+" "+HTTPStoragePathTemplates, h.save)
+	mux.HandleFunc(http.MethodGet+" "+HTTPStoragePathTemplates+"/{name}", h.get)
+	mux.HandleFunc(http.MethodDelete+" "+HTTPStoragePathTemplates+"/{name}", h.delete)
+	mux.HandleFunc(http.MethodGet+" "+HTTPStoragePathTemplates+"/{name}"+HTTPStoragePathVersions, h.listVersions)
+	mux.HandleFunc(http.MethodGet+" "+HTTPStoragePathTemplates+"/{name}"+HTTPStoragePathVersions+"/{version}", h.getVersion)
+	mux.HandleFunc(http.MethodDelete+" "+HTTPStoragePathTemplates+"/{name}"+HTTPStoragePathVersions+"/{version}", h.deleteVersion)
+	mux.HandleFunc(http.MethodGet+" "+HTTPStoragePathIDs+"/{id}", h.getByID)
+	return mux
+}
+
+// storageHandler serves a TemplateStorage over HTTP.
+type storageHandler struct {
+	storage TemplateStorage
+}
+
+func (h *storageHandler) list(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.storage.List(r.Context(), decodeTemplateQuery(r.URL.Query()))
+	h.respond(w, http.StatusOK, templates, err)
+}
+
+func (h *storageHandler) save(w http.ResponseWriter, r *http.Request) {
+	var tmpl StoredTemplate
+	if err := json.NewDecoder(io.LimitReader(r.Body, HTTPStorageMaxBodySize)).Decode(&tmpl); err != nil {
+		writeJSON(w, http.StatusBadRequest, httpErrorBody{Error: ErrMsgHTTPStorageInvalidBody})
+		return
+	}
+	err := h.storage.Save(r.Context(), &tmpl)
+	h.respond(w, http.StatusCreated, &tmpl, err)
+}
+
+func (h *storageHandler) get(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := h.storage.Get(r.Context(), r.PathValue("name"))
+	h.respondTemplate(w, r, tmpl, err)
+}
+
+func (h *storageHandler) delete(w http.ResponseWriter, r *http.Request) {
+	err := h.storage.Delete(r.Context(), r.PathValue("name"))
+	h.respond(w, http.StatusNoContent, nil, err)
+}
+
+func (h *storageHandler) listVersions(w http.ResponseWriter, r *http.Request) {
+	versions, err := h.storage.ListVersions(r.Context(), r.PathValue("name"))
+	h.respond(w, http.StatusOK, versions, err)
+}
+
+func (h *storageHandler) getVersion(w http.ResponseWriter, r *http.Request) {
+	version, ok := pathVersion(w, r)
+	if !ok {
+		return
+	}
+	tmpl, err := h.storage.GetVersion(r.Context(), r.PathValue("name"), version)
+	h.respondTemplate(w, r, tmpl, err)
+}
+
+func (h *storageHandler) deleteVersion(w http.ResponseWriter, r *http.Request) {
+	version, ok := pathVersion(w, r)
+	if !ok {
+		return
+	}
+	err := h.storage.DeleteVersion(r.Context(), r.PathValue("name"), version)
+	h.respond(w, http.StatusNoContent, nil, err)
+}
+
+func (h *storageHandler) getByID(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := h.storage.GetByID(r.Context(), TemplateID(r.PathValue("id")))
+	h.respondTemplate(w, r, tmpl, err)
+}
+
+// respondTemplate writes a template with its ETag, or 304 Not Modified when
+// the request's If-None-Match matches it.
+func (h *storageHandler) respondTemplate(w http.ResponseWriter, r *http.Request, tmpl *StoredTemplate, err error) {
+	if err != nil {
+		h.respond(w, http.StatusOK, nil, err)
+		return
+	}
+	etag := TemplateETag(tmpl)
+	w.Header().Set(HTTPStorageHeaderETag, etag)
+	if ETagMatches(strings.Join(r.Header.Values(HTTPStorageHeaderIfNone), etagListSep), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, http.StatusOK, tmpl)
+}
+
+// respond writes either the error mapped to a status code or the value.
+func (h *storageHandler) respond(w http.ResponseWriter, status int, value any, err error) {
+	if err != nil {
+		writeJSON(w, storageErrorStatus(err), httpErrorBody{Error: err.Error()})
+		return
+	}
+	if status == http.StatusNoContent {
+		w.WriteHeader(status)
+		return
+	}
+	writeJSON(w, status, value)
+}
+
+// pathVersion parses the {version} path segment, writing 400 on failure.
+func pathVersion(w http.ResponseWriter, r *http.Request) (int, bool) {
+	version, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil || version < 1 {
+		writeJSON(w, http.StatusBadRequest, httpErrorBody{Error: ErrMsgHTTPStorageInvalidVersion})
+		return 0, false
+	}
+	return version, true
+}
+
+// storageErrorStatus maps storage errors to HTTP status codes.
+func storageErrorStatus(err error) int {
+	if cuserr.IsErrorCategory(err, cuserr.ErrorCategoryNotFound) {
+		return http.StatusNotFound
+	}
+	var storageErr *StorageError
+	if errors.As(err, &storageErr) {
+		switch storageErr.Message {
+		case ErrMsgVersionNotFound:
+			return http.StatusNotFound
+		case ErrMsgInvalidTemplateName:
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// writeJSON writes value as a JSON response with the given status.
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set(HTTPStorageHeaderContent, HTTPStorageContentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,268 @@
+package prompty
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/itsatony/go-cuserr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHTTPStorage(t *testing.T) (*HTTPStorage, *MemoryStorage) {
+	t.Helper()
+	backend := NewMemoryStorage()
+	server := httptest.NewServer(NewStorageHTTPHandler(backend))
+	t.Cleanup(server.Close)
+
+	storage, err := NewHTTPStorage(HTTPStorageConfig{BaseURL: server.URL})
+	require.NoError(t, err)
+	return storage, backend
+}
+
+func TestNewHTTPStorage(t *testing.T) {
+	t.Run("rejects empty URL", func(t *testing.T) {
+		_, err := NewHTTPStorage(HTTPStorageConfig{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMsgHTTPStorageEmptyURL)
+	})
+
+	t.Run("rejects URL without host", func(t *testing.T) {
+		_, err := NewHTTPStorage(HTTPStorageConfig{BaseURL: "not-a-url"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMsgHTTPStorageInvalidURL)
+	})
+
+	t.Run("opens via driver registry", func(t *testing.T) {
+		storage, err := OpenStorage(StorageDriverNameHTTP, "http://localhost:1/api/")
+		require.NoError(t, err)
+		require.NoError(t, storage.Close())
+	})
+}
+
+func TestHTTPStorage_RoundTrip(t *testing.T) {
+	storage, backend := newTestHTTPStorage(t)
+	ctx := context.Background()
+
+	tmpl := &StoredTemplate{Name: "team/greeting", Source: "Hello v1", Tags: []string{"public"}}
+	require.NoError(t, storage.Save(ctx, tmpl))
+	assert.Equal(t, 1, tmpl.Version)
+	assert.NotEmpty(t, tmpl.ID)
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "team/greeting", Source: "Hello v2"}))
+
+	// Stored in the backend
+	exists, err := backend.Exists(ctx, "team/greeting")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	latest, err := storage.Get(ctx, "team/greeting")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello v2", latest.Source)
+	assert.Equal(t, 2, latest.Version)
+
+	v1, err := storage.GetVersion(ctx, "team/greeting", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello v1", v1.Source)
+
+	byID, err := storage.GetByID(ctx, tmpl.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello v1", byID.Source)
+
+	versions, err := storage.ListVersions(ctx, "team/greeting")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int{1, 2}, versions)
+
+	list, err := storage.List(ctx, &TemplateQuery{NamePrefix: "team/", IncludeAllVersions: true})
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	list, err = storage.List(ctx, &TemplateQuery{Tags: []string{"public"}, IncludeAllVersions: true})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, 1, list[0].Version)
+
+	require.NoError(t, storage.DeleteVersion(ctx, "team/greeting", 1))
+	versions, err = storage.ListVersions(ctx, "team/greeting")
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, versions)
+
+	require.NoError(t, storage.Delete(ctx, "team/greeting"))
+	exists, err = storage.Exists(ctx, "team/greeting")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestHTTPStorage_NotFound(t *testing.T) {
+	storage, _ := newTestHTTPStorage(t)
+	ctx := context.Background()
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "present", Source: "x"}))
+
+	_, err := storage.Get(ctx, "missing")
+	require.Error(t, err)
+	assert.True(t, cuserr.IsErrorCategory(err, cuserr.ErrorCategoryNotFound))
+
+	_, err = storage.GetVersion(ctx, "present", 9)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgVersionNotFound)
+
+	err = storage.Delete(ctx, "missing")
+	require.Error(t, err)
+	assert.True(t, cuserr.IsErrorCategory(err, cuserr.ErrorCategoryNotFound))
+}
+
+func TestHTTPStorage_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("empty name rejected client side", func(t *testing.T) {
+		storage, _ := newTestHTTPStorage(t)
+		err := storage.Save(ctx, &StoredTemplate{Source: "x"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMsgInvalidTemplateName)
+	})
+
+	t.Run("server error surfaces status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusInternalServerError, httpErrorBody{Error: "boom"})
+		}))
+		defer server.Close()
+
+		storage, err := NewHTTPStorage(HTTPStorageConfig{BaseURL: server.URL})
+		require.NoError(t, err)
+		_, err = storage.Get(ctx, "x")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMsgHTTPStorageUnexpectedCode)
+		assert.Contains(t, err.Error(), "500 boom")
+	})
+
+	t.Run("headers are sent", func(t *testing.T) {
+		var auth string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth = r.Header.Get("Authorization")
+			writeJSON(w, http.StatusOK, []int{1})
+		}))
+		defer server.Close()
+
+		storage, err := NewHTTPStorage(HTTPStorageConfig{
+			BaseURL: server.URL,
+			Header:  http.Header{"Authorization": []string{"Bearer token"}},
+		})
+		require.NoError(t, err)
+		_, err = storage.ListVersions(ctx, "x")
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer token", auth)
+	})
+
+	t.Run("closed storage", func(t *testing.T) {
+		storage, _ := newTestHTTPStorage(t)
+		require.NoError(t, storage.Close())
+		_, err := storage.Get(ctx, "x")
+		require.Error(t, err)
+	})
+
+	t.Run("handler rejects invalid version", func(t *testing.T) {
+		handler := NewStorageHTTPHandler(NewMemoryStorage())
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/templates/x/versions/abc", nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), ErrMsgHTTPStorageInvalidVersion)
+	})
+}
+
+func TestHTTPStorage_WithStorageEngine(t *testing.T) {
+	storage, _ := newTestHTTPStorage(t)
+	ctx := context.Background()
+
+	engine, err := NewStorageEngine(StorageEngineConfig{Storage: storage})
+	require.NoError(t, err)
+	defer engine.Close()
+
+	require.NoError(t, engine.Save(ctx, &StoredTemplate{Name: "greet", Source: `Hi {~prompty.var name="user" /~}`}))
+	result, err := engine.Execute(ctx, "greet", map[string]any{"user": "Ana"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hi Ana", result)
+}
+
+func TestHTTPStorage_ConditionalGet(t *testing.T) {
+	backend := NewMemoryStorage()
+	var statuses []int
+	handler := NewStorageHTTPHandler(backend)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(rec, r)
+		statuses = append(statuses, rec.status)
+	}))
+	t.Cleanup(server.Close)
+	storage, err := NewHTTPStorage(HTTPStorageConfig{BaseURL: server.URL})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "greet", Source: "Hello"}))
+
+	tmpl, modified, err := storage.GetIfNoneMatch(ctx, "greet", "")
+	require.NoError(t, err)
+	require.True(t, modified)
+	etag := TemplateETag(tmpl)
+
+	tmpl, modified, err = storage.GetIfNoneMatch(ctx, "greet", etag)
+	require.NoError(t, err)
+	assert.False(t, modified)
+	assert.Nil(t, tmpl)
+	assert.Equal(t, http.StatusNotModified, statuses[len(statuses)-1])
+
+	require.NoError(t, backend.Save(ctx, &StoredTemplate{Name: "greet", Source: "Hello v2"}))
+	tmpl, modified, err = storage.GetIfNoneMatch(ctx, "greet", etag)
+	require.NoError(t, err)
+	assert.True(t, modified)
+	assert.Equal(t, "Hello v2", tmpl.Source)
+
+	_, _, err = storage.GetIfNoneMatch(ctx, "missing", etag)
+	assert.True(t, cuserr.IsErrorCategory(err, cuserr.ErrorCategoryNotFound))
+
+	// Version responses carry an ETag too
+	req, err := http.NewRequest(http.MethodGet, server.URL+versionPath("greet", 1), nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, etag, resp.Header.Get(HTTPStorageHeaderETag))
+}
+
+func TestHTTPStorage_CachedRevalidation(t *testing.T) {
+	storage, backend := newTestHTTPStorage(t)
+	ctx := context.Background()
+	require.NoError(t, backend.Save(ctx, &StoredTemplate{Name: "greet", Source: "Hello"}))
+
+	cached := NewCachedStorage(storage, CacheConfig{TTL: time.Millisecond})
+	defer cached.Close()
+
+	_, err := cached.Get(ctx, "greet")
+	require.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+
+	// The expired entry is revalidated instead of transferred again
+	tmpl, err := cached.Get(ctx, "greet")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", tmpl.Source)
+	assert.Equal(t, int64(1), cached.Stats().Revalidations)
+
+	time.Sleep(2 * time.Millisecond)
+	require.NoError(t, backend.Save(ctx, &StoredTemplate{Name: "greet", Source: "Hello v2"}))
+	tmpl, err = cached.Get(ctx, "greet")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello v2", tmpl.Source)
+	assert.Equal(t, int64(1), cached.Stats().Revalidations)
+}
+
+// statusRecorder records the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
-	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +18,7 @@ type MemoryStorage struct {
 	templates map[string][]*StoredTemplate         // name -> versions (sorted by version desc)
 	byID      map[TemplateID]*StoredTemplate       // id -> template
 	labels    map[string]map[string]*TemplateLabel // templateName -> (label -> TemplateLabel)
+	index     *TemplateSearchIndex                 // full-text index of all versions
 	closed    bool
 }
 
@@ -41,6 +41,7 @@ func NewMemoryStorage() *MemoryStorage {
 		templates: make(map[string][]*StoredTemplate),
 		byID:      make(map[TemplateID]*StoredTemplate),
 		labels:    make(map[string]map[string]*TemplateLabel),
+		index:     NewTemplateSearchIndex(),
 	}
 }
 
@@ -151,6 +152,7 @@ func (s *MemoryStorage) Save(ctx context.Context, tmpl *StoredTemplate) error {
 		ID:           generateTemplateID(),
 		Name:         tmpl.Name,
 		Source:       tmpl.Source,
+		ContentHash:  ContentHash(tmpl.Source),
 		Version:      nextVersion,
 		Status:       status,
 		Metadata:     copyStringMap(tmpl.Metadata),
@@ -160,10 +162,13 @@ func (s *MemoryStorage) Save(ctx context.Context, tmpl *StoredTemplate) error {
 		CreatedBy:    tmpl.CreatedBy,
 		TenantID:     tmpl.TenantID,
 		Tags:         copyStringSlice(tmpl.Tags),
+		ActiveFrom:   copyTimePtr(tmpl.ActiveFrom),
+		ActiveUntil:  copyTimePtr(tmpl.ActiveUntil),
 	}
 
 	// Update input template with generated values
 	tmpl.ID = stored.ID
+	tmpl.ContentHash = stored.ContentHash
 	tmpl.Version = stored.Version
 	tmpl.Status = stored.Status
 	tmpl.CreatedAt = stored.CreatedAt
@@ -172,6 +177,7 @@ func (s *MemoryStorage) Save(ctx context.Context, tmpl *StoredTemplate) error {
 	// Insert at beginning (newest first)
 	s.templates[tmpl.Name] = append([]*StoredTemplate{stored}, versions...)
 	s.byID[stored.ID] = stored
+	s.index.Add(stored)
 
 	return nil
 }
@@ -197,6 +203,7 @@ func (s *MemoryStorage) Delete(ctx context.Context, name string) error {
 	// Remove all versions from byID index
 	for _, tmpl := range versions {
 		delete(s.byID, tmpl.ID)
+		s.index.Remove(tmpl.ID)
 	}
 
 	delete(s.templates, name)
@@ -226,6 +233,7 @@ func (s *MemoryStorage) DeleteVersion(ctx context.Context, name string, version
 		if tmpl.Version == version {
 			// Remove from byID index
 			delete(s.byID, tmpl.ID)
+			s.index.Remove(tmpl.ID)
 
 			// Remove from versions slice
 			s.templates[name] = append(versions[:i], versions[i+1:]...)
@@ -275,6 +283,17 @@ func (s *MemoryStorage) List(ctx context.Context, query *TemplateQuery) ([]*Stor
 
 	var results []*StoredTemplate
 
+	// Full-text candidates from the search index (nil = no search filter)
+	found := s.index.Search(query.Search)
+	matches := func(tmpl *StoredTemplate) bool {
+		if found != nil {
+			if _, ok := found[tmpl.ID]; !ok {
+				return false
+			}
+		}
+		return matchesTemplateQuery(tmpl, query)
+	}
+
 	// Collect matching templates
 	for name, versions := range s.templates {
 		if !matchesQuery(name, versions, query) {
@@ -283,25 +302,19 @@ func (s *MemoryStorage) List(ctx context.Context, query *TemplateQuery) ([]*Stor
 
 		if query.IncludeAllVersions {
 			for _, tmpl := range versions {
-				if matchesTemplateQuery(tmpl, query) {
+				if matches(tmpl) {
 					results = append(results, copyStoredTemplate(tmpl))
 				}
 			}
 		} else if len(versions) > 0 {
 			// Only include latest version
-			if matchesTemplateQuery(versions[0], query) {
+			if matches(versions[0]) {
 				results = append(results, copyStoredTemplate(versions[0]))
 			}
 		}
 	}
 
-	// Sort by name, then version descending
-	sort.Slice(results, func(i, j int) bool {
-		if results[i].Name != results[j].Name {
-			return results[i].Name < results[j].Name
-		}
-		return results[i].Version > results[j].Version
-	})
+	sortTemplates(results, query)
 
 	// Apply offset and limit
 	if query.Offset > 0 {
@@ -409,9 +422,29 @@ func matchesTemplateQuery(tmpl *StoredTemplate, query *TemplateQuery) bool {
 			return false
 		}
 	}
+	if !query.ExpiredAsOf.IsZero() && !tmpl.IsExpiredAt(query.ExpiredAsOf) {
+		return false
+	}
+	for key, value := range query.Metadata {
+		if v, ok := tmpl.Metadata[key]; !ok || v != value {
+			return false
+		}
+	}
+	if !inTimeRange(tmpl.CreatedAt, query.CreatedAfter, query.CreatedBefore) ||
+		!inTimeRange(tmpl.UpdatedAt, query.UpdatedAfter, query.UpdatedBefore) {
+		return false
+	}
 	return true
 }
 
+// inTimeRange reports whether t is within [after, before]; zero bounds are open.
+func inTimeRange(t, after, before time.Time) bool {
+	if !after.IsZero() && t.Before(after) {
+		return false
+	}
+	return before.IsZero() || !t.After(before)
+}
+
 // containsStatus checks if a slice contains a DeploymentStatus.
 func containsStatus(slice []DeploymentStatus, s DeploymentStatus) bool {
 	for _, item := range slice {
@@ -453,6 +486,7 @@ func copyStoredTemplate(tmpl *StoredTemplate) *StoredTemplate {
 		ID:           tmpl.ID,
 		Name:         tmpl.Name,
 		Source:       tmpl.Source,
+		ContentHash:  tmpl.ContentHash,
 		Version:      tmpl.Version,
 		Status:       tmpl.Status,
 		Metadata:     copyStringMap(tmpl.Metadata),
@@ -462,6 +496,8 @@ func copyStoredTemplate(tmpl *StoredTemplate) *StoredTemplate {
 		CreatedBy:    tmpl.CreatedBy,
 		TenantID:     tmpl.TenantID,
 		Tags:         copyStringSlice(tmpl.Tags),
+		ActiveFrom:   copyTimePtr(tmpl.ActiveFrom),
+		ActiveUntil:  copyTimePtr(tmpl.ActiveUntil),
 	}
 }
 
@@ -487,6 +523,15 @@ func copyStringSlice(s []string) []string {
 	return result
 }
 
+// copyTimePtr creates a copy of a time pointer.
+func copyTimePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
 // -----------------------------------------------------------------------------
 // LabelStorage Implementation
 // -----------------------------------------------------------------------------
@@ -204,7 +204,7 @@ func (s *PostgresStorage) Get(ctx context.Context, name string) (*StoredTemplate
 
 	query := fmt.Sprintf(`
 		SELECT id, name, source, version, status, metadata, prompt_config,
-		       created_at, updated_at, created_by, tenant_id, tags
+		       created_at, updated_at, created_by, tenant_id, tags, active_from, active_until
 		FROM %s
 		WHERE name = $1
 		ORDER BY version DESC
@@ -244,7 +244,7 @@ func (s *PostgresStorage) GetByID(ctx context.Context, id TemplateID) (*StoredTe
 
 	query := fmt.Sprintf(`
 		SELECT id, name, source, version, status, metadata, prompt_config,
-		       created_at, updated_at, created_by, tenant_id, tags
+		       created_at, updated_at, created_by, tenant_id, tags, active_from, active_until
 		FROM %s
 		WHERE id = $1`, s.tableName())
 
@@ -282,7 +282,7 @@ func (s *PostgresStorage) GetVersion(ctx context.Context, name string, version i
 
 	query := fmt.Sprintf(`
 		SELECT id, name, source, version, status, metadata, prompt_config,
-		       created_at, updated_at, created_by, tenant_id, tags
+		       created_at, updated_at, created_by, tenant_id, tags, active_from, active_until
 		FROM %s
 		WHERE name = $1 AND version = $2`, s.tableName())
 
@@ -397,14 +397,15 @@ func (s *PostgresStorage) Save(ctx context.Context, tmpl *StoredTemplate) error
 	insertQuery := fmt.Sprintf(`
 		INSERT INTO %s
 		(id, name, source, version, status, metadata, prompt_config,
-		 created_at, updated_at, created_by, tenant_id, tags)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		 created_at, updated_at, created_by, tenant_id, tags, active_from, active_until)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
 		s.tableName())
 
 	_, err = tx.ExecContext(ctx, insertQuery,
 		string(newID), tmpl.Name, tmpl.Source, nextVersion, string(status),
 		metadataJSON, promptConfigJSON,
-		now, now, nullString(tmpl.CreatedBy), nullString(tmpl.TenantID), tagsJSON)
+		now, now, nullString(tmpl.CreatedBy), nullString(tmpl.TenantID), tagsJSON,
+		nullTime(tmpl.ActiveFrom), nullTime(tmpl.ActiveUntil))
 	if err != nil {
 		return &StorageError{
 			Message: ErrMsgPostgresQueryFailed,
@@ -424,6 +425,7 @@ func (s *PostgresStorage) Save(ctx context.Context, tmpl *StoredTemplate) error
 
 	// Update input template with generated values
 	tmpl.ID = newID
+	tmpl.ContentHash = ContentHash(tmpl.Source)
 	tmpl.Version = nextVersion
 	tmpl.Status = status
 	tmpl.CreatedAt = now
@@ -587,7 +589,49 @@ func (s *PostgresStorage) List(ctx context.Context, query *TemplateQuery) ([]*St
 	} else if query.Status != "" {
 		conditions = append(conditions, fmt.Sprintf("status = $%d", argIdx))
 		args = append(args, string(query.Status))
-		// argIdx not needed after this point
+		argIdx++
+	}
+
+	if !query.ExpiredAsOf.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("active_until <= $%d", argIdx))
+		args = append(args, query.ExpiredAsOf)
+		argIdx++
+	}
+
+	// Full-text search over source and prompt description
+	if query.Search != "" {
+		conditions = append(conditions, fmt.Sprintf(
+			"to_tsvector('simple', source || ' ' || COALESCE(prompt_config->>'description', '')) @@ plainto_tsquery('simple', $%d)",
+			argIdx))
+		args = append(args, query.Search)
+		argIdx++
+	}
+
+	// Metadata filter - ALL key/value pairs must match
+	if len(query.Metadata) > 0 {
+		metadataJSON, _ := json.Marshal(query.Metadata)
+		conditions = append(conditions, fmt.Sprintf("metadata @> $%d::jsonb", argIdx))
+		args = append(args, string(metadataJSON))
+		argIdx++
+	}
+
+	// Time ranges
+	for _, bound := range []struct {
+		column string
+		op     string
+		value  time.Time
+	}{
+		{"created_at", ">=", query.CreatedAfter},
+		{"created_at", "<=", query.CreatedBefore},
+		{"updated_at", ">=", query.UpdatedAfter},
+		{"updated_at", "<=", query.UpdatedBefore},
+	} {
+		if bound.value.IsZero() {
+			continue
+		}
+		conditions = append(conditions, fmt.Sprintf("%s %s $%d", bound.column, bound.op, argIdx))
+		args = append(args, bound.value)
+		argIdx++
 	}
 
 	// Build WHERE clause
@@ -601,20 +645,23 @@ func (s *PostgresStorage) List(ctx context.Context, query *TemplateQuery) ([]*St
 	if query.IncludeAllVersions {
 		sqlQuery = fmt.Sprintf(`
 			SELECT id, name, source, version, status, metadata, prompt_config,
-			       created_at, updated_at, created_by, tenant_id, tags
+			       created_at, updated_at, created_by, tenant_id, tags, active_from, active_until
 			FROM %s
 			%s
-			ORDER BY name ASC, version DESC`,
-			s.tableName(), whereClause)
+			ORDER BY %s`,
+			s.tableName(), whereClause, postgresOrderBy(query))
 	} else {
-		// Only latest version per name using DISTINCT ON
+		// Only latest version per name using DISTINCT ON, then sorted
 		sqlQuery = fmt.Sprintf(`
-			SELECT DISTINCT ON (name) id, name, source, version, status, metadata, prompt_config,
-			       created_at, updated_at, created_by, tenant_id, tags
-			FROM %s
-			%s
-			ORDER BY name ASC, version DESC`,
-			s.tableName(), whereClause)
+			SELECT * FROM (
+				SELECT DISTINCT ON (name) id, name, source, version, status, metadata, prompt_config,
+				       created_at, updated_at, created_by, tenant_id, tags, active_from, active_until
+				FROM %s
+				%s
+				ORDER BY name ASC, version DESC
+			) latest
+			ORDER BY %s`,
+			s.tableName(), whereClause, postgresOrderBy(query))
 	}
 
 	// Add LIMIT and OFFSET
@@ -992,6 +1039,20 @@ func (s *PostgresStorage) getMigrations() []postgresMigration {
 				s.tableName(),
 			),
 		},
+		{
+			Version:     5,
+			Description: "Add activation schedule columns",
+			SQL: fmt.Sprintf(`
+				ALTER TABLE %s
+				ADD COLUMN IF NOT EXISTS active_from TIMESTAMP WITH TIME ZONE,
+				ADD COLUMN IF NOT EXISTS active_until TIMESTAMP WITH TIME ZONE;
+
+				CREATE INDEX IF NOT EXISTS idx_%s_active_until ON %s(active_until) WHERE active_until IS NOT NULL;
+			`,
+				s.tableName(),
+				s.config.TablePrefix+"templates", s.tableName(),
+			),
+		},
 	}
 }
 
@@ -1010,16 +1071,18 @@ func (s *PostgresStorage) scanTemplate(row *sql.Row) (*StoredTemplate, error) {
 		createdBy           sql.NullString
 		tenantID            sql.NullString
 		tagsJSON            []byte
+		activeFrom          sql.NullTime
+		activeUntil         sql.NullTime
 	)
 
 	err := row.Scan(&id, &name, &source, &version, &status, &metadataJSON, &promptConfigJSONStr,
-		&createdAt, &updatedAt, &createdBy, &tenantID, &tagsJSON)
+		&createdAt, &updatedAt, &createdBy, &tenantID, &tagsJSON, &activeFrom, &activeUntil)
 	if err != nil {
 		return nil, err
 	}
 
 	return s.unmarshalTemplate(id, name, source, version, status, metadataJSON, promptConfigJSONStr,
-		createdAt, updatedAt, createdBy, tenantID, tagsJSON)
+		createdAt, updatedAt, createdBy, tenantID, tagsJSON, activeFrom, activeUntil)
 }
 
 // scanTemplateRow scans a rows result into a StoredTemplate.
@@ -1037,23 +1100,25 @@ func (s *PostgresStorage) scanTemplateRow(rows *sql.Rows) (*StoredTemplate, erro
 		createdBy           sql.NullString
 		tenantID            sql.NullString
 		tagsJSON            []byte
+		activeFrom          sql.NullTime
+		activeUntil         sql.NullTime
 	)
 
 	err := rows.Scan(&id, &name, &source, &version, &status, &metadataJSON, &promptConfigJSONStr,
-		&createdAt, &updatedAt, &createdBy, &tenantID, &tagsJSON)
+		&createdAt, &updatedAt, &createdBy, &tenantID, &tagsJSON, &activeFrom, &activeUntil)
 	if err != nil {
 		return nil, err
 	}
 
 	return s.unmarshalTemplate(id, name, source, version, status, metadataJSON, promptConfigJSONStr,
-		createdAt, updatedAt, createdBy, tenantID, tagsJSON)
+		createdAt, updatedAt, createdBy, tenantID, tagsJSON, activeFrom, activeUntil)
 }
 
 // unmarshalTemplate converts scanned values into a StoredTemplate.
 func (s *PostgresStorage) unmarshalTemplate(id, name, source string, version int,
 	status sql.NullString, metadataJSON []byte, promptConfigJSONStr sql.NullString,
 	createdAt, updatedAt time.Time, createdBy, tenantID sql.NullString,
-	tagsJSON []byte) (*StoredTemplate, error) {
+	tagsJSON []byte, activeFrom, activeUntil sql.NullTime) (*StoredTemplate, error) {
 
 	tmpl := &StoredTemplate{
 		ID:        TemplateID(id),
@@ -1109,9 +1174,46 @@ func (s *PostgresStorage) unmarshalTemplate(id, name, source string, version int
 		tmpl.TenantID = tenantID.String
 	}
 
+	// Handle activation schedule
+	if activeFrom.Valid {
+		tmpl.ActiveFrom = &activeFrom.Time
+	}
+	if activeUntil.Valid {
+		tmpl.ActiveUntil = &activeUntil.Time
+	}
+
+	// Derived from the source rather than stored in a column
+	tmpl.ContentHash = ContentHash(tmpl.Source)
+
 	return tmpl, nil
 }
 
+// postgresOrderBy returns the ORDER BY clause of a List query. Ties are
+// ordered by name and version descending.
+func postgresOrderBy(query *TemplateQuery) string {
+	direction := "ASC"
+	if query.SortDescending {
+		direction = "DESC"
+	}
+	switch query.SortBy {
+	case TemplateSortCreatedAt, TemplateSortUpdatedAt, TemplateSortVersion:
+		return string(query.SortBy) + " " + direction + ", name ASC, version DESC"
+	default:
+		if query.SortDescending {
+			return "name DESC, version ASC"
+		}
+		return "name ASC, version DESC"
+	}
+}
+
+// nullTime converts a nil time to sql.NullTime.
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
 // nullString converts an empty string to sql.NullString.
 func nullString(s string) sql.NullString {
 	if s == "" {
@@ -1249,7 +1351,7 @@ func (s *PostgresStorage) GetByLabel(ctx context.Context, templateName, label st
 	// Get template by version
 	query := fmt.Sprintf(`
 		SELECT id, name, source, version, status, metadata, prompt_config,
-		       created_at, updated_at, created_by, tenant_id, tags
+		       created_at, updated_at, created_by, tenant_id, tags, active_from, active_until
 		FROM %s
 		WHERE name = $1 AND version = $2`, s.tableName())
 
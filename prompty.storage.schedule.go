@@ -0,0 +1,63 @@
+package prompty
+
+import (
+	"context"
+	"time"
+)
+
+// IsActiveAt reports whether the template is inside its activation schedule
+// at the given time: not before ActiveFrom and before ActiveUntil. Templates
+// without a schedule are always active.
+func (t *StoredTemplate) IsActiveAt(at time.Time) bool {
+	if t.ActiveFrom != nil && at.Before(*t.ActiveFrom) {
+		return false
+	}
+	return !t.IsExpiredAt(at)
+}
+
+// IsExpiredAt reports whether the template's ActiveUntil is at or before
+// the given time.
+func (t *StoredTemplate) IsExpiredAt(at time.Time) bool {
+	return t.ActiveUntil != nil && !at.Before(*t.ActiveUntil)
+}
+
+// now returns the current time of the engine's clock (see WithClock).
+func (se *StorageEngine) now() time.Time {
+	if se.engine.config.clock != nil {
+		return se.engine.config.clock()
+	}
+	return time.Now()
+}
+
+// activeVersion returns latest if it is active now, or else the newest
+// active version of the template. It returns an error if no version is
+// active. The older versions are loaded with a single List call, so a
+// template outside its schedule costs one extra storage round-trip.
+func (se *StorageEngine) activeVersion(ctx context.Context, latest *StoredTemplate) (*StoredTemplate, error) {
+	now := se.now()
+	if latest.IsActiveAt(now) {
+		return latest, nil
+	}
+
+	candidates, err := se.storage.List(ctx, &TemplateQuery{
+		NamePrefix:         latest.Name,
+		IncludeAllVersions: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var active *StoredTemplate
+	for _, stored := range candidates {
+		// The prefix also matches longer names
+		if stored.Name != latest.Name || stored.Version >= latest.Version || !stored.IsActiveAt(now) {
+			continue
+		}
+		if active == nil || stored.Version > active.Version {
+			active = stored
+		}
+	}
+	if active == nil {
+		return nil, NewStorageNoActiveVersionError(latest.Name)
+	}
+	return active, nil
+}
@@ -0,0 +1,149 @@
+package prompty
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoredTemplate_IsActiveAt(t *testing.T) {
+	from := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2025, 12, 27, 0, 0, 0, 0, time.UTC)
+	tmpl := &StoredTemplate{ActiveFrom: &from, ActiveUntil: &until}
+
+	assert.False(t, tmpl.IsActiveAt(from.Add(-time.Second)))
+	assert.True(t, tmpl.IsActiveAt(from))
+	assert.True(t, tmpl.IsActiveAt(until.Add(-time.Second)))
+	assert.False(t, tmpl.IsActiveAt(until))
+
+	assert.False(t, tmpl.IsExpiredAt(from))
+	assert.True(t, tmpl.IsExpiredAt(until))
+
+	unscheduled := &StoredTemplate{}
+	assert.True(t, unscheduled.IsActiveAt(time.Now()))
+	assert.False(t, unscheduled.IsExpiredAt(time.Now()))
+}
+
+func TestStorageEngine_ScheduledActivation(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 12, 10, 12, 0, 0, 0, time.UTC)
+	yesterday := now.Add(-24 * time.Hour)
+	tomorrow := now.Add(24 * time.Hour)
+
+	newEngine := func(t *testing.T) *StorageEngine {
+		se := MustNewStorageEngine(StorageEngineConfig{
+			Storage: NewMemoryStorage(),
+			Engine:  MustNew(WithClock(func() time.Time { return now })),
+		})
+		t.Cleanup(func() { _ = se.Close() })
+		return se
+	}
+
+	t.Run("falls back to the newest active version", func(t *testing.T) {
+		se := newEngine(t)
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "promo", Source: "regular"}))
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "promo", Source: "holiday", ActiveFrom: &yesterday, ActiveUntil: &tomorrow}))
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "promo", Source: "new year", ActiveFrom: &tomorrow}))
+
+		result, err := se.Execute(ctx, "promo", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "holiday", result)
+
+		// Explicit versions ignore the schedule
+		result, err = se.ExecuteVersion(ctx, "promo", 3, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "new year", result)
+	})
+
+	t.Run("skips expired versions", func(t *testing.T) {
+		se := newEngine(t)
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "promo", Source: "regular"}))
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "promo", Source: "campaign", ActiveUntil: &yesterday}))
+
+		result, err := se.Execute(ctx, "promo", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "regular", result)
+	})
+
+	t.Run("loads older versions in one storage call", func(t *testing.T) {
+		storage := &countingStorage{TemplateStorage: NewMemoryStorage()}
+		se := MustNewStorageEngine(StorageEngineConfig{
+			Storage: storage,
+			Engine:  MustNew(WithClock(func() time.Time { return now })),
+		})
+		t.Cleanup(func() { _ = se.Close() })
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "promo-extra", Source: "other"}))
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "promo", Source: "regular"}))
+		for i := 0; i < 5; i++ {
+			require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "promo", Source: "later", ActiveFrom: &tomorrow}))
+		}
+		storage.lists, storage.versionGets = 0, 0
+
+		result, err := se.Execute(ctx, "promo", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "regular", result)
+		assert.Equal(t, 1, storage.lists)
+		assert.Zero(t, storage.versionGets)
+	})
+
+	t.Run("errors when no version is active", func(t *testing.T) {
+		se := newEngine(t)
+		require.NoError(t, se.Save(ctx, &StoredTemplate{Name: "promo", Source: "later", ActiveFrom: &tomorrow}))
+
+		_, err := se.Execute(ctx, "promo", nil)
+		require.Error(t, err)
+		var storageErr *StorageError
+		require.True(t, errors.As(err, &storageErr))
+		assert.Equal(t, ErrMsgNoActiveVersion, storageErr.Message)
+		assert.Equal(t, "promo", storageErr.Name)
+	})
+}
+
+// countingStorage counts the storage calls made while resolving a schedule.
+type countingStorage struct {
+	TemplateStorage
+	lists       int
+	versionGets int
+}
+
+func (s *countingStorage) List(ctx context.Context, query *TemplateQuery) ([]*StoredTemplate, error) {
+	s.lists++
+	return s.TemplateStorage.List(ctx, query)
+}
+
+func (s *countingStorage) GetVersion(ctx context.Context, name string, version int) (*StoredTemplate, error) {
+	s.versionGets++
+	return s.TemplateStorage.GetVersion(ctx, name, version)
+}
+
+func TestMemoryStorage_ExpiredQuery(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	storage := NewMemoryStorage()
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "expired", Source: "x", ActiveUntil: &past}))
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "running", Source: "x", ActiveUntil: &future}))
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "forever", Source: "x"}))
+
+	results, err := storage.List(ctx, &TemplateQuery{ExpiredAsOf: now})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "expired", results[0].Name)
+
+	stored, err := storage.Get(ctx, "running")
+	require.NoError(t, err)
+	require.NotNil(t, stored.ActiveUntil)
+	assert.True(t, future.Equal(*stored.ActiveUntil))
+}
+
+func TestTemplateQuery_ExpiredAsOfRoundTrip(t *testing.T) {
+	asOf := time.Date(2025, 12, 10, 12, 0, 0, 0, time.UTC)
+	query := decodeTemplateQuery(encodeTemplateQuery(&TemplateQuery{ExpiredAsOf: asOf}))
+	assert.True(t, asOf.Equal(query.ExpiredAsOf))
+}
@@ -0,0 +1,197 @@
+package prompty
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// TemplateSortField selects the order of List results.
+type TemplateSortField string
+
+// Template sort fields.
+const (
+	// TemplateSortName orders by name, then version descending (the default).
+	TemplateSortName TemplateSortField = "name"
+
+	// TemplateSortCreatedAt orders by creation time.
+	TemplateSortCreatedAt TemplateSortField = "created_at"
+
+	// TemplateSortUpdatedAt orders by last update time.
+	TemplateSortUpdatedAt TemplateSortField = "updated_at"
+
+	// TemplateSortVersion orders by version number.
+	TemplateSortVersion TemplateSortField = "version"
+)
+
+// searchTokens splits text into lowercase words of letters and digits,
+// without duplicates.
+func searchTokens(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	seen := make(map[string]bool, len(words))
+	tokens := words[:0]
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			tokens = append(tokens, w)
+		}
+	}
+	return tokens
+}
+
+// templateSearchText returns the text searched by TemplateQuery.Search:
+// the source and the prompt description.
+func templateSearchText(tmpl *StoredTemplate) string {
+	if tmpl.PromptConfig != nil && tmpl.PromptConfig.Description != "" {
+		return tmpl.Source + "\n" + tmpl.PromptConfig.Description
+	}
+	return tmpl.Source
+}
+
+// matchesSearch reports whether the template contains every word of search.
+// Storage drivers without an index use it to filter templates while scanning.
+func matchesSearch(tmpl *StoredTemplate, search string) bool {
+	terms := searchTokens(search)
+	if len(terms) == 0 {
+		return true
+	}
+	words := make(map[string]bool)
+	for _, w := range searchTokens(templateSearchText(tmpl)) {
+		words[w] = true
+	}
+	for _, term := range terms {
+		if !words[term] {
+			return false
+		}
+	}
+	return true
+}
+
+// TemplateSearchIndex is an in-memory inverted index from words of the
+// source and description of stored templates to their IDs. MemoryStorage
+// maintains one; custom drivers can use it the same way. It is safe for
+// concurrent use.
+type TemplateSearchIndex struct {
+	mu    sync.RWMutex
+	words map[string]map[TemplateID]struct{}
+	docs  map[TemplateID][]string // words of each template, for removal
+}
+
+// NewTemplateSearchIndex creates an empty search index.
+func NewTemplateSearchIndex() *TemplateSearchIndex {
+	return &TemplateSearchIndex{
+		words: make(map[string]map[TemplateID]struct{}),
+		docs:  make(map[TemplateID][]string),
+	}
+}
+
+// Add indexes a template version, replacing an earlier entry with its ID.
+func (idx *TemplateSearchIndex) Add(tmpl *StoredTemplate) {
+	tokens := searchTokens(templateSearchText(tmpl))
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.removeLocked(tmpl.ID)
+	for _, token := range tokens {
+		ids, ok := idx.words[token]
+		if !ok {
+			ids = make(map[TemplateID]struct{})
+			idx.words[token] = ids
+		}
+		ids[tmpl.ID] = struct{}{}
+	}
+	idx.docs[tmpl.ID] = tokens
+}
+
+// Remove removes a template version from the index.
+func (idx *TemplateSearchIndex) Remove(id TemplateID) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(id)
+}
+
+// removeLocked removes a template version; the caller holds the lock.
+func (idx *TemplateSearchIndex) removeLocked(id TemplateID) {
+	for _, token := range idx.docs[id] {
+		ids := idx.words[token]
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(idx.words, token)
+		}
+	}
+	delete(idx.docs, id)
+}
+
+// Search returns the IDs of the template versions containing every word of
+// search. It returns nil when search has no words, meaning no filter.
+func (idx *TemplateSearchIndex) Search(search string) map[TemplateID]struct{} {
+	terms := searchTokens(search)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	// Intersect starting from the rarest word
+	sort.Slice(terms, func(i, j int) bool {
+		return len(idx.words[terms[i]]) < len(idx.words[terms[j]])
+	})
+	result := make(map[TemplateID]struct{}, len(idx.words[terms[0]]))
+	for id := range idx.words[terms[0]] {
+		result[id] = struct{}{}
+	}
+	for _, term := range terms[1:] {
+		ids := idx.words[term]
+		for id := range result {
+			if _, ok := ids[id]; !ok {
+				delete(result, id)
+			}
+		}
+	}
+	return result
+}
+
+// Len returns the number of indexed template versions.
+func (idx *TemplateSearchIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// sortTemplates orders List results by the query's sort field. Ties, and
+// the default order, are by name and then version descending.
+func sortTemplates(results []*StoredTemplate, query *TemplateQuery) {
+	byName := func(a, b *StoredTemplate) bool {
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Version > b.Version
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		var less, equal bool
+		switch query.SortBy {
+		case TemplateSortCreatedAt:
+			less, equal = a.CreatedAt.Before(b.CreatedAt), a.CreatedAt.Equal(b.CreatedAt)
+		case TemplateSortUpdatedAt:
+			less, equal = a.UpdatedAt.Before(b.UpdatedAt), a.UpdatedAt.Equal(b.UpdatedAt)
+		case TemplateSortVersion:
+			less, equal = a.Version < b.Version, a.Version == b.Version
+		default:
+			less, equal = byName(a, b), false
+		}
+		if equal {
+			return byName(a, b)
+		}
+		if query.SortDescending {
+			return !less
+		}
+		return less
+	})
+}
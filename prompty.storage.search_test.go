@@ -0,0 +1,145 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateSearchIndex(t *testing.T) {
+	idx := NewTemplateSearchIndex()
+	idx.Add(&StoredTemplate{ID: "a", Source: "Summarize the customer email."})
+	idx.Add(&StoredTemplate{ID: "b", Source: "Translate the email", PromptConfig: &Prompt{Description: "Customer support translation"}})
+	idx.Add(&StoredTemplate{ID: "c", Source: "Write a poem"})
+	assert.Equal(t, 3, idx.Len())
+
+	assert.Nil(t, idx.Search("  "))
+	assert.Equal(t, map[TemplateID]struct{}{"a": {}, "b": {}}, idx.Search("CUSTOMER email"))
+	assert.Equal(t, map[TemplateID]struct{}{"b": {}}, idx.Search("support"))
+	assert.Empty(t, idx.Search("poem email"))
+
+	// Re-adding replaces the indexed words
+	idx.Add(&StoredTemplate{ID: "c", Source: "Reply to the customer email"})
+	assert.Len(t, idx.Search("customer"), 3)
+	assert.Empty(t, idx.Search("poem"))
+
+	idx.Remove("a")
+	assert.Equal(t, 2, idx.Len())
+	assert.Equal(t, map[TemplateID]struct{}{"b": {}, "c": {}}, idx.Search("email"))
+}
+
+// seedSearchStorage saves templates for search tests with increasing
+// creation times.
+func seedSearchStorage(t *testing.T, storage TemplateStorage) {
+	t.Helper()
+	ctx := context.Background()
+	for _, tmpl := range []*StoredTemplate{
+		{Name: "summarize", Source: "Summarize the customer email", Metadata: map[string]string{"team": "support", "lang": "en"}},
+		{Name: "translate", Source: "Translate the text", Metadata: map[string]string{"team": "support", "lang": "de"}, PromptConfig: &Prompt{Description: "Customer email translation"}},
+		{Name: "poem", Source: "Write a poem", Metadata: map[string]string{"team": "marketing"}},
+	} {
+		require.NoError(t, storage.Save(ctx, tmpl))
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+func templateNames(results []*StoredTemplate) []string {
+	names := make([]string, len(results))
+	for i, r := range results {
+		names[i] = r.Name
+	}
+	return names
+}
+
+func TestStorage_Search(t *testing.T) {
+	ctx := context.Background()
+
+	storages := map[string]func(t *testing.T) TemplateStorage{
+		"memory": func(t *testing.T) TemplateStorage { return NewMemoryStorage() },
+		"filesystem": func(t *testing.T) TemplateStorage {
+			fs, err := NewFilesystemStorage(t.TempDir())
+			require.NoError(t, err)
+			return fs
+		},
+	}
+
+	for name, newStorage := range storages {
+		t.Run(name, func(t *testing.T) {
+			storage := newStorage(t)
+			defer storage.Close()
+			seedSearchStorage(t, storage)
+
+			results, err := storage.List(ctx, &TemplateQuery{Search: "customer email"})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"summarize", "translate"}, templateNames(results))
+
+			results, err = storage.List(ctx, &TemplateQuery{Metadata: map[string]string{"team": "support", "lang": "de"}})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"translate"}, templateNames(results))
+
+			results, err = storage.List(ctx, &TemplateQuery{Search: "customer", Metadata: map[string]string{"team": "marketing"}})
+			require.NoError(t, err)
+			assert.Empty(t, results)
+
+			all, err := storage.List(ctx, &TemplateQuery{SortBy: TemplateSortCreatedAt, SortDescending: true})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"poem", "translate", "summarize"}, templateNames(all))
+
+			results, err = storage.List(ctx, &TemplateQuery{CreatedAfter: all[1].CreatedAt, SortBy: TemplateSortCreatedAt})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"translate", "poem"}, templateNames(results))
+
+			results, err = storage.List(ctx, &TemplateQuery{UpdatedBefore: all[1].UpdatedAt})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"summarize", "translate"}, templateNames(results))
+		})
+	}
+}
+
+func TestMemoryStorage_SearchAfterDelete(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "greet", Source: "hello world"}))
+	require.NoError(t, storage.Save(ctx, &StoredTemplate{Name: "greet", Source: "goodbye world"}))
+
+	results, err := storage.List(ctx, &TemplateQuery{Search: "hello", IncludeAllVersions: true})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, 1, results[0].Version)
+
+	require.NoError(t, storage.DeleteVersion(ctx, "greet", 1))
+	results, err = storage.List(ctx, &TemplateQuery{Search: "hello", IncludeAllVersions: true})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	require.NoError(t, storage.Delete(ctx, "greet"))
+	results, err = storage.List(ctx, &TemplateQuery{Search: "world"})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestTemplateQuery_SearchRoundTrip(t *testing.T) {
+	ts := time.Date(2025, 12, 10, 12, 0, 0, 0, time.UTC)
+	query := &TemplateQuery{
+		Search:         "customer email",
+		Metadata:       map[string]string{"team": "support", "expr": "a=b"},
+		CreatedAfter:   ts,
+		CreatedBefore:  ts.Add(time.Hour),
+		UpdatedAfter:   ts.Add(2 * time.Hour),
+		UpdatedBefore:  ts.Add(3 * time.Hour),
+		SortBy:         TemplateSortUpdatedAt,
+		SortDescending: true,
+	}
+	decoded := decodeTemplateQuery(encodeTemplateQuery(query))
+	assert.Equal(t, query.Search, decoded.Search)
+	assert.Equal(t, query.Metadata, decoded.Metadata)
+	assert.True(t, query.CreatedAfter.Equal(decoded.CreatedAfter))
+	assert.True(t, query.CreatedBefore.Equal(decoded.CreatedBefore))
+	assert.True(t, query.UpdatedAfter.Equal(decoded.UpdatedAfter))
+	assert.True(t, query.UpdatedBefore.Equal(decoded.UpdatedBefore))
+	assert.Equal(t, TemplateSortUpdatedAt, decoded.SortBy)
+	assert.True(t, decoded.SortDescending)
+}
@@ -0,0 +1,250 @@
+package prompty
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2/ast"
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// strictIssueFormat formats a strict mode violation: message at line, column
+const strictIssueFormat = "%s at line %d, column %d"
+
+// WithStrict makes Parse fail, and Validate report errors, for mistakes
+// that otherwise degrade silently at runtime:
+//   - attributes a tag does not accept, e.g. a misspelled default
+//   - a default on prompty.var for a required input, which never applies
+//   - references to inputs the frontmatter does not declare, when it
+//     declares inputs
+//
+// Included templates keep their attributes as child variables and are not
+// checked for unknown attributes; custom resolvers are checked when they
+// implement AttributeDeclarer. Use it in CI to fail loudly before deploying.
+// Default: disabled
+func WithStrict() Option {
+	return func(c *engineConfig) {
+		c.strict = true
+	}
+}
+
+// AttributeDeclarer is implemented by custom resolvers that declare the
+// attributes their tag accepts, so that strict mode (WithStrict) rejects
+// others. The onerror, cache and cache_key attributes are always accepted.
+type AttributeDeclarer interface {
+	KnownAttributes() []string
+}
+
+// builtinTagAttributes lists the attributes accepted by each built-in tag,
+// besides onerror, cache and cache_key on resolver tags. Tags missing here
+// (prompty.include, prompty.config) are not checked.
+var builtinTagAttributes = map[string][]string{
+	TagNameVar:             {AttrName, AttrDefault, AttrEscape},
+	TagNameRaw:             {},
+	TagNameComment:         {},
+	TagNameIf:              {AttrEval},
+	TagNameElseIf:          {AttrEval},
+	TagNameElse:            {},
+	TagNameFor:             {AttrItem, AttrIn, AttrIndex, AttrLimit},
+	TagNameSwitch:          {AttrEval},
+	TagNameCase:            {AttrValue, AttrEval, AttrFallthrough},
+	TagNameCaseDefault:     {},
+	TagNameDefault:         {},
+	TagNameEnv:             {AttrName, AttrDefault, AttrRequired, AttrEscape},
+	TagNameExtends:         {AttrTemplate},
+	TagNameBlock:           {AttrName},
+	TagNameParent:          {},
+	TagNameImport:          {AttrTemplate, AttrAs},
+	TagNameUse:             {AttrBlock},
+	TagNameChoice:          {AttrFrom, AttrDefault, AttrEscape},
+	TagNameShuffle:         {AttrIn, AttrItem, AttrIndex, AttrLimit},
+	TagNameHistory:         {AttrIn, AttrLimit, AttrWindow, AttrFormat},
+	TagNameImage:           {AttrURL, AttrFrom, AttrDetail, AttrMediaType},
+	TagNameFile:            {AttrName, AttrURL, AttrFrom, AttrID, AttrMediaType},
+	TagNameCacheBreakpoint: {},
+	TagNameToolResults:     {AttrIn, AttrFormat},
+	TagNameEscape:          {AttrMode},
+	TagNameMessage:         {AttrRole, AttrCache},
+	TagNameRef:             {AttrSlug, AttrVersion},
+	TagNameSkillsCatalog:   {AttrFormat, AttrSelection, AttrQueryFrom, AttrLimit},
+	TagNameToolsCatalog:    {AttrFormat},
+}
+
+// strictContextKeys are data keys provided by agent compilation, which
+// templates may reference without declaring them as inputs.
+var strictContextKeys = map[string]bool{
+	ContextKeyInput:        true,
+	ContextKeyMeta:         true,
+	ContextKeyContext:      true,
+	ContextKeyConstraints:  true,
+	ContextKeySkills:       true,
+	ContextKeyTools:        true,
+	ContextKeySelfBody:     true,
+	ContextKeyConversation: true,
+	ContextKeyToolResults:  true,
+}
+
+// strictIssues returns the strict mode violations of a parsed template, with
+// positions in its source.
+func (e *Engine) strictIssues(t *Template) []ValidationIssue {
+	var issues []ValidationIssue
+	issues = append(issues, e.unknownAttributeIssues(t.templateBody)...)
+	issues = append(issues, unusedDefaultIssues(t)...)
+	issues = append(issues, undeclaredInputIssues(t)...)
+
+	// Positions are relative to the body; shift them past the frontmatter
+	if bodyOffset := len(t.source) - len(t.templateBody); bodyOffset > 0 && strings.HasSuffix(t.source, t.templateBody) {
+		lineOffset := strings.Count(t.source[:bodyOffset], "\n")
+		for i := range issues {
+			issues[i].Position.Offset += bodyOffset
+			issues[i].Position.Line += lineOffset
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Position.Line != issues[j].Position.Line {
+			return issues[i].Position.Line < issues[j].Position.Line
+		}
+		return issues[i].Position.Column < issues[j].Position.Column
+	})
+	return issues
+}
+
+// unknownAttributeIssues reports attributes that their tag does not accept.
+// Tokens are inspected rather than the syntax tree, which keeps no
+// attributes of control flow tags.
+func (e *Engine) unknownAttributeIssues(body string) []ValidationIssue {
+	tokens, err := internal.NewLexerWithConfig(body, e.config.lexerConfig(), e.logger).Tokenize()
+	if err != nil {
+		return nil
+	}
+
+	var issues []ValidationIssue
+	var tagName string
+	var known map[string]bool
+	for i, tok := range tokens {
+		switch tok.Type {
+		case internal.TokenTypeTagName:
+			if i > 0 && tokens[i-1].Type == internal.TokenTypeOpenTag {
+				tagName = tok.Value
+				known = e.knownAttributes(tagName)
+			}
+		case internal.TokenTypeAttrName:
+			if known != nil && !known[tok.Value] {
+				issues = append(issues, ValidationIssue{
+					Code:     IssueCodeUnknownAttribute,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf(lintDetailFormat, ErrMsgStrictUnknownAttribute, tok.Value),
+					Position: e.internalPosToPublic(tok.Position),
+					TagName:  tagName,
+					Params:   map[string]string{MetaKeyAttribute: tok.Value},
+				})
+			}
+		case internal.TokenTypeCloseTag, internal.TokenTypeSelfClose, internal.TokenTypeBlockClose:
+			tagName, known = "", nil
+		}
+	}
+	return issues
+}
+
+// knownAttributes returns the attributes accepted by a tag, or nil if the
+// tag's attributes are not checked.
+func (e *Engine) knownAttributes(tagName string) map[string]bool {
+	attrs, builtin := builtinTagAttributes[tagName]
+	if !builtin {
+		resolver, ok := e.registry.GetUnwrapped(tagName)
+		if !ok {
+			return nil
+		}
+		adapter, ok := resolver.(*resolverAdapter)
+		if !ok {
+			return nil
+		}
+		declarer, ok := adapter.resolver.(AttributeDeclarer)
+		if !ok {
+			return nil
+		}
+		attrs = declarer.KnownAttributes()
+	}
+
+	known := make(map[string]bool, len(attrs)+3)
+	for _, attr := range attrs {
+		known[attr] = true
+	}
+	// Resolver tags take an error strategy and, except for message
+	// content, per-tag caching
+	if e.registry.Has(tagName) {
+		known[AttrOnError] = true
+		if internal.IsCacheableTag(tagName) {
+			known[AttrCache] = true
+			known[AttrCacheKey] = true
+		}
+	}
+	return known
+}
+
+// unusedDefaultIssues reports prompty.var defaults for required inputs,
+// which are always present when inputs are validated.
+func unusedDefaultIssues(t *Template) []ValidationIssue {
+	if !t.prompt.HasInputs() {
+		return nil
+	}
+
+	var issues []ValidationIssue
+	ast.Inspect(t.AST(), func(node ast.Node) bool {
+		tag, ok := node.(*ast.Tag)
+		if !ok || tag.Name != TagNameVar {
+			return true
+		}
+		if _, hasDefault := tag.Attributes[AttrDefault]; !hasDefault {
+			return true
+		}
+		name := strings.TrimPrefix(tag.Attributes[AttrName], ContextKeyInput+".")
+		if input := t.prompt.Inputs[name]; input != nil && input.Required {
+			issues = append(issues, ValidationIssue{
+				Code:     IssueCodeUnusedDefault,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf(lintDetailFormat, ErrMsgStrictUnusedDefault, name),
+				Position: Position{Offset: tag.Position.Offset, Line: tag.Position.Line, Column: tag.Position.Column},
+				TagName:  tag.Name,
+				Params:   map[string]string{MetaKeyInputName: name},
+			})
+		}
+		return true
+	})
+	return issues
+}
+
+// undeclaredInputIssues reports data references, in tags and expressions,
+// whose root is not a declared input, when the template declares inputs.
+// Loop variables and the data added by agent compilation are allowed.
+func undeclaredInputIssues(t *Template) []ValidationIssue {
+	if !t.prompt.HasInputs() {
+		return nil
+	}
+
+	var issues []ValidationIssue
+	for _, ref := range t.DryRun(context.Background(), nil).Variables {
+		if ref.InData || ref.Name == "" {
+			continue // Bound by a loop
+		}
+		root, rest, _ := strings.Cut(ref.Name, ".")
+		if root == ContextKeyInput && rest != "" {
+			root, _, _ = strings.Cut(rest, ".")
+		} else if strictContextKeys[root] {
+			continue
+		}
+		if _, declared := t.prompt.Inputs[root]; declared {
+			continue
+		}
+		issues = append(issues, ValidationIssue{
+			Code:     IssueCodeUndeclaredInput,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf(lintDetailFormat, ErrMsgStrictUndeclaredInput, ref.Name),
+			Position: Position{Line: ref.Line, Column: ref.Column},
+			Params:   map[string]string{MetaKeyVariable: ref.Name},
+		})
+	}
+	return issues
+}
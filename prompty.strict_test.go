@@ -0,0 +1,97 @@
+package prompty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// attrResolver is a custom resolver that declares its attributes.
+type attrResolver struct{}
+
+func (attrResolver) TagName() string { return "shout" }
+func (attrResolver) Resolve(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+	return attrs.GetDefault("text", ""), nil
+}
+func (attrResolver) Validate(attrs Attributes) error { return nil }
+func (attrResolver) KnownAttributes() []string       { return []string{"text"} }
+
+func TestWithStrict_UnknownAttributes(t *testing.T) {
+	engine := MustNew(WithStrict())
+	engine.MustRegister(attrResolver{})
+
+	valid := []string{
+		`{~prompty.var name="a" default="x" onerror="remove" cache="1m" /~}`,
+		`{~prompty.for item="x" in="items" index="i" limit="3"~}{~prompty.var name="x" /~}{~/prompty.for~}`,
+		`{~prompty.if eval="a"~}yes{~prompty.else~}no{~/prompty.if~}`,
+		`{~prompty.include template="other" who="Ada" /~}`,
+		`{~prompty.message role="user" cache="ephemeral"~}Hi{~/prompty.message~}`,
+		`{~shout text="hi" onerror="remove" /~}`,
+		`{~prompty.raw~}{~prompty.var nme="a" /~}{~/prompty.raw~}`,
+	}
+	for _, source := range valid {
+		_, err := engine.Parse(source)
+		assert.NoError(t, err, source)
+	}
+
+	invalid := map[string]string{
+		`{~prompty.var name="a" defualt="x" /~}`:                                                           "defualt",
+		`{~prompty.for item="x" in="items" list="items"~}{~/prompty.for~}`:                                 "list",
+		`{~prompty.switch eval="a" on="b"~}{~prompty.case value="1"~}{~/prompty.case~}{~/prompty.switch~}`: "on",
+		`{~prompty.message role="user" cache_key="a"~}Hi{~/prompty.message~}`:                              "cache_key",
+		`{~shout txt="hi" /~}`: "txt",
+	}
+	for source, attr := range invalid {
+		_, err := engine.Parse(source)
+		require.Error(t, err, source)
+		assert.ErrorContains(t, err, ErrMsgStrictUnknownAttribute, source)
+		assert.ErrorContains(t, err, attr, source)
+	}
+
+	// Without strict mode unknown attributes are ignored
+	_, err := MustNew().Parse(`{~prompty.var name="a" defualt="x" /~}`)
+	assert.NoError(t, err)
+}
+
+func TestWithStrict_Inputs(t *testing.T) {
+	engine := MustNew(WithStrict())
+	frontmatter := "---\nname: support\ndescription: Answers support questions\ninputs:\n  query:\n    type: string\n    required: true\n  tone:\n    type: string\n---\n"
+
+	_, err := engine.Parse(frontmatter +
+		`{~prompty.var name="query" /~} {~prompty.var name="tone" default="friendly" /~} {~prompty.var name="input.query" /~} {~prompty.var name="meta.name" /~}` +
+		`{~prompty.for item="doc" in="query"~}{~prompty.var name="doc.title" /~}{~/prompty.for~}`)
+	assert.NoError(t, err)
+
+	_, err = engine.Parse(frontmatter + `{~prompty.var name="query" default="anything" /~}`)
+	assert.ErrorContains(t, err, ErrMsgStrictUnusedDefault)
+
+	_, err = engine.Parse(frontmatter + "Hi\n" + `{~prompty.if eval="len(usr.name) > 0"~}x{~/prompty.if~}`)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, ErrMsgStrictUndeclaredInput)
+	assert.ErrorContains(t, err, "usr.name")
+	assert.ErrorContains(t, err, "line 12")
+
+	// Without an inputs schema references are not checked
+	_, err = engine.Parse(`{~prompty.var name="anything" default="x" /~}`)
+	assert.NoError(t, err)
+}
+
+func TestWithStrict_Validate(t *testing.T) {
+	source := "---\nname: support\ndescription: Answers support questions\ninputs:\n  query:\n    type: string\n---\n" +
+		`{~prompty.var name="query" nme="x" /~}{~prompty.var name="user" /~}`
+
+	result, err := MustNew().Validate(source)
+	require.NoError(t, err)
+	assert.True(t, result.IsValid())
+
+	result, err = MustNew(WithStrict()).Validate(source)
+	require.NoError(t, err)
+	errs := result.Errors()
+	require.Len(t, errs, 2)
+	assert.Contains(t, errs[0].Message, ErrMsgStrictUnknownAttribute)
+	assert.Equal(t, TagNameVar, errs[0].TagName)
+	assert.Equal(t, 8, errs[0].Position.Line)
+	assert.Contains(t, errs[1].Message, ErrMsgStrictUndeclaredInput)
+}
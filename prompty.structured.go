@@ -47,7 +47,7 @@ func validateSchemaRecursive(schema map[string]any, path string, result *SchemaV
 		validateObjectSchema(schema, path, result)
 	case SchemaTypeArray:
 		validateArraySchema(schema, path, result)
-	case SchemaTypeString, SchemaTypeNumber, SchemaTypeBoolean:
+	case SchemaTypeString, SchemaTypeNumber, SchemaTypeInteger, SchemaTypeBoolean:
 		// Primitive types are valid
 	default:
 		addWarning(result, path, "unknown type: "+typeStr)
@@ -247,23 +247,19 @@ func ValidateGuidedDecoding(gd *GuidedDecoding) *SchemaValidationResult {
 		return result
 	}
 
-	// Count how many constraints are set
-	constraintCount := 0
-	if gd.JSON != nil {
-		constraintCount++
-	}
-	if gd.Regex != "" {
-		constraintCount++
-	}
-	if len(gd.Choice) > 0 {
-		constraintCount++
-	}
-	if gd.Grammar != "" {
-		constraintCount++
+	if gd.constraintCount() > 1 {
+		addError(result, "", ErrMsgGuidedDecodingConflict)
 	}
 
-	if constraintCount > 1 {
-		addError(result, "", ErrMsgGuidedDecodingConflict)
+	// Validate grammar syntax if present
+	if gd.Grammar != "" || gd.GrammarFormat != "" {
+		if _, err := grammarSyntaxFor(gd.GrammarFormat); err != nil {
+			addError(result, "grammar_format", ErrMsgGrammarUnknownFormat+": "+gd.GrammarFormat)
+		} else if gd.Grammar != "" {
+			if err := ValidateGrammar(gd.Grammar, gd.GrammarFormat); err != nil {
+				addError(result, "grammar", err.Error())
+			}
+		}
 	}
 
 	// Validate backend if specified
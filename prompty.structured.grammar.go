@@ -0,0 +1,388 @@
+package prompty
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// grammarSyntax holds the lexical rules of a grammar format.
+type grammarSyntax struct {
+	root       string
+	define     string
+	comment    string
+	ruleFormat string
+	// lark enables Lark syntax: regex literals, [optional] groups, aliases,
+	// rule priorities and %directives. GBNF has character classes,
+	// <token> literals and {m,n} repetitions instead.
+	lark bool
+}
+
+var (
+	grammarSyntaxGBNF = grammarSyntax{
+		root:       GrammarRootRuleGBNF,
+		define:     "::=",
+		comment:    "#",
+		ruleFormat: "%s ::= %s\n",
+	}
+	grammarSyntaxLark = grammarSyntax{
+		root:       GrammarRootRuleLark,
+		define:     ":",
+		comment:    "//",
+		ruleFormat: "%s: %s\n",
+		lark:       true,
+	}
+)
+
+// grammarSyntaxFor returns the syntax of a grammar format ("" is GBNF).
+func grammarSyntaxFor(format string) (grammarSyntax, error) {
+	switch format {
+	case "", GrammarFormatGBNF:
+		return grammarSyntaxGBNF, nil
+	case GrammarFormatLark:
+		return grammarSyntaxLark, nil
+	default:
+		return grammarSyntax{}, NewGrammarError(ErrMsgGrammarUnknownFormat, "", 0)
+	}
+}
+
+// grammarTokenKind classifies grammar tokens.
+type grammarTokenKind int
+
+const (
+	grammarTokenName grammarTokenKind = iota
+	grammarTokenDefine
+	grammarTokenLiteral
+	grammarTokenOpen
+	grammarTokenClose
+	grammarTokenAlias
+	grammarTokenOperator
+	grammarTokenDirective
+)
+
+// grammarToken is a lexical token of a grammar.
+type grammarToken struct {
+	kind grammarTokenKind
+	text string
+	line int
+}
+
+// ValidateGrammar checks the syntax of a guided decoding grammar in format
+// (GrammarFormatGBNF when empty): literals are terminated, brackets are
+// balanced, every referenced rule is defined and the start rule ("root" for
+// GBNF, "start" for Lark) exists. It is a structural check, not a full
+// parse; the inference server remains the final judge. Errors name the rule
+// and line of the problem.
+func ValidateGrammar(grammar, format string) error {
+	syntax, err := grammarSyntaxFor(format)
+	if err != nil {
+		return err
+	}
+	tokens, err := scanGrammar(grammar, syntax)
+	if err != nil {
+		return err
+	}
+	return checkGrammarRules(tokens, syntax)
+}
+
+// GrammarFromChoices builds a grammar in format (GrammarFormatGBNF when
+// empty) that accepts exactly one of choices, e.g. for llama.cpp servers
+// that take no choice list. Pass ResponseFormat.Enum.Values or
+// GuidedDecoding.Choice to constrain a reply to an enum.
+func GrammarFromChoices(format string, choices []string) (string, error) {
+	syntax, err := grammarSyntaxFor(format)
+	if err != nil {
+		return "", err
+	}
+	if len(choices) == 0 {
+		return "", NewGrammarError(ErrMsgGrammarNoChoices, "", 0)
+	}
+	alternatives := make([]string, len(choices))
+	for i, choice := range choices {
+		alternatives[i] = strconv.Quote(choice)
+	}
+	return fmt.Sprintf(syntax.ruleFormat, syntax.root, strings.Join(alternatives, " | ")), nil
+}
+
+// scanGrammar splits a grammar into tokens, dropping whitespace and
+// comments.
+func scanGrammar(grammar string, syntax grammarSyntax) ([]grammarToken, error) {
+	var tokens []grammarToken
+	line := 1
+	lineStart := true
+	emit := func(kind grammarTokenKind, text string) {
+		tokens = append(tokens, grammarToken{kind: kind, text: text, line: line})
+		lineStart = false
+	}
+
+	for i := 0; i < len(grammar); {
+		c := grammar[i]
+		switch {
+		case c == '\n':
+			line++
+			lineStart = true
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(grammar[i:], syntax.comment):
+			i = skipToLineEnd(grammar, i)
+		case strings.HasPrefix(grammar[i:], syntax.define):
+			emit(grammarTokenDefine, syntax.define)
+			i += len(syntax.define)
+		case c == '"':
+			end, err := scanDelimited(grammar, i, '"', line)
+			if err != nil {
+				return nil, err
+			}
+			if syntax.lark {
+				end = skipLetters(grammar, end) // flags: "select"i
+			}
+			emit(grammarTokenLiteral, grammar[i:end])
+			i = end
+		case c == '/' && syntax.lark:
+			end, err := scanDelimited(grammar, i, '/', line)
+			if err != nil {
+				return nil, err
+			}
+			end = skipLetters(grammar, end)
+			emit(grammarTokenLiteral, grammar[i:end])
+			i = end
+		case c == '[' && !syntax.lark:
+			end, err := scanDelimited(grammar, i, ']', line)
+			if err != nil {
+				return nil, err
+			}
+			emit(grammarTokenLiteral, grammar[i:end])
+			i = end
+		case c == '<' && !syntax.lark:
+			end, err := scanDelimited(grammar, i, '>', line)
+			if err != nil {
+				return nil, err
+			}
+			emit(grammarTokenLiteral, grammar[i:end])
+			i = end
+		case c == '{' && !syntax.lark:
+			end := strings.IndexByte(grammar[i:], '}')
+			if end < 0 || strings.Trim(grammar[i+1:i+end], "0123456789, ") != "" {
+				return nil, NewGrammarError(ErrMsgGrammarUnexpectedChar, "", line)
+			}
+			emit(grammarTokenOperator, grammar[i:i+end+1])
+			i += end + 1
+		case c == '%' && syntax.lark && lineStart:
+			end := skipToLineEnd(grammar, i)
+			emit(grammarTokenDirective, grammar[i:end])
+			i = end
+		case (c == '?' || c == '!') && syntax.lark && lineStart && i+1 < len(grammar) && isGrammarNameChar(grammar[i+1], syntax):
+			i++ // rule modifiers: ?expr, !keyword
+		case c == '(' || (c == '[' && syntax.lark):
+			emit(grammarTokenOpen, string(c))
+			i++
+		case c == ')' || (c == ']' && syntax.lark):
+			emit(grammarTokenClose, string(c))
+			i++
+		case c == '-' && syntax.lark && strings.HasPrefix(grammar[i:], "->"):
+			emit(grammarTokenAlias, "->")
+			i += 2
+		case isGrammarNameChar(c, syntax) && !(syntax.lark && isDigit(c)):
+			end := i
+			for end < len(grammar) && isGrammarNameChar(grammar[end], syntax) {
+				end++
+			}
+			emit(grammarTokenName, grammar[i:end])
+			i = end
+			if syntax.lark && i+1 < len(grammar) && grammar[i] == '.' && isDigit(grammar[i+1]) {
+				for i++; i < len(grammar) && isDigit(grammar[i]); i++ { // priority: NAME.2
+				}
+			}
+		case strings.IndexByte("|*+?.", c) >= 0 || (syntax.lark && (c == '~' || isDigit(c))):
+			emit(grammarTokenOperator, string(c))
+			i++
+		default:
+			return nil, NewGrammarError(ErrMsgGrammarUnexpectedChar, "", line)
+		}
+	}
+	return tokens, nil
+}
+
+// checkGrammarRules checks the rule structure of a token stream.
+func checkGrammarRules(tokens []grammarToken, syntax grammarSyntax) error {
+	type reference struct {
+		name string
+		line int
+	}
+	defined := make(map[string]bool)
+	var references []reference
+
+	rule, ruleLine, body, depth := "", 0, 0, 0
+	endRule := func() error {
+		if rule == "" {
+			return nil
+		}
+		if body == 0 {
+			return NewGrammarError(ErrMsgGrammarEmptyRule, rule, ruleLine)
+		}
+		if depth != 0 {
+			return NewGrammarError(ErrMsgGrammarUnbalanced, rule, ruleLine)
+		}
+		return nil
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if tok.kind == grammarTokenName && i+1 < len(tokens) && tokens[i+1].kind == grammarTokenDefine {
+			if err := endRule(); err != nil {
+				return err
+			}
+			rule, ruleLine, body, depth = tok.text, tok.line, 0, 0
+			defined[rule] = true
+			i++
+			continue
+		}
+
+		switch tok.kind {
+		case grammarTokenDirective:
+			names, refs := larkDirectiveNames(tok.text)
+			for _, name := range names {
+				defined[name] = true
+			}
+			for _, name := range refs {
+				references = append(references, reference{name: name, line: tok.line})
+			}
+			continue
+		case grammarTokenDefine:
+			return NewGrammarError(ErrMsgGrammarUnexpectedChar, rule, tok.line)
+		}
+		if rule == "" {
+			return NewGrammarError(ErrMsgGrammarOutsideRule, "", tok.line)
+		}
+
+		body++
+		switch tok.kind {
+		case grammarTokenOpen:
+			depth++
+		case grammarTokenClose:
+			depth--
+			if depth < 0 {
+				return NewGrammarError(ErrMsgGrammarUnbalanced, rule, tok.line)
+			}
+		case grammarTokenName:
+			if i == 0 || tokens[i-1].kind != grammarTokenAlias {
+				references = append(references, reference{name: tok.text, line: tok.line})
+			}
+		}
+	}
+	if err := endRule(); err != nil {
+		return err
+	}
+
+	if len(defined) == 0 {
+		return NewGrammarError(ErrMsgGrammarEmpty, "", 0)
+	}
+	if !defined[syntax.root] {
+		return NewGrammarError(ErrMsgGrammarMissingRoot, syntax.root, 0)
+	}
+	for _, ref := range references {
+		if !defined[ref.name] {
+			return NewGrammarError(ErrMsgGrammarUndefinedRule, ref.name, ref.line)
+		}
+	}
+	return nil
+}
+
+// larkDirectiveNames returns the names a Lark directive defines and the
+// names it references: %import and %declare define terminals, %ignore
+// references them. Other directives are not checked.
+func larkDirectiveNames(directive string) (defines, references []string) {
+	fields := strings.Fields(strings.NewReplacer("(", " ", ")", " ", ",", " ").Replace(directive))
+	if len(fields) < 2 {
+		return nil, nil
+	}
+	args := fields[1:]
+	switch fields[0] {
+	case "%import":
+		if len(args) >= 3 && args[len(args)-2] == "->" {
+			return args[len(args)-1:], nil // %import common.WS -> SPACE
+		}
+		if len(args) == 1 {
+			name := args[0] // %import common.WS
+			if dot := strings.LastIndexByte(name, '.'); dot >= 0 {
+				name = name[dot+1:]
+			}
+			return []string{name}, nil
+		}
+		return args[1:], nil // %import common (WS, NUMBER)
+	case "%declare":
+		return args, nil
+	case "%ignore":
+		for _, arg := range args {
+			if isGrammarName(arg, grammarSyntaxLark) {
+				references = append(references, arg)
+			}
+		}
+		return nil, references
+	}
+	return nil, nil
+}
+
+// scanDelimited returns the end of the literal opening at start and closed
+// by the first unescaped close on the same line.
+func scanDelimited(grammar string, start int, close byte, line int) (int, error) {
+	for i := start + 1; i < len(grammar); i++ {
+		switch grammar[i] {
+		case '\\':
+			i++
+		case '\n':
+			return 0, NewGrammarError(ErrMsgGrammarUnterminated, "", line)
+		case close:
+			return i + 1, nil
+		}
+	}
+	return 0, NewGrammarError(ErrMsgGrammarUnterminated, "", line)
+}
+
+// skipToLineEnd returns the index of the newline ending the line at i.
+func skipToLineEnd(grammar string, i int) int {
+	if end := strings.IndexByte(grammar[i:], '\n'); end >= 0 {
+		return i + end
+	}
+	return len(grammar)
+}
+
+// skipLetters returns the index after the ASCII letters starting at i.
+func skipLetters(grammar string, i int) int {
+	for i < len(grammar) && (grammar[i]|0x20 >= 'a' && grammar[i]|0x20 <= 'z') {
+		i++
+	}
+	return i
+}
+
+// isGrammarNameChar reports whether c may appear in a rule name: letters,
+// digits and "-" in GBNF, letters, digits and "_" in Lark.
+func isGrammarNameChar(c byte, syntax grammarSyntax) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', isDigit(c):
+		return true
+	case syntax.lark:
+		return c == '_'
+	default:
+		return c == '-'
+	}
+}
+
+// isGrammarName reports whether s is a rule name.
+func isGrammarName(s string, syntax grammarSyntax) bool {
+	if s == "" || (syntax.lark && isDigit(s[0])) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isGrammarNameChar(s[i], syntax) {
+			return false
+		}
+	}
+	return true
+}
+
+// isDigit reports whether c is an ASCII digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,171 @@
+package prompty
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/itsatony/go-cuserr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateGrammar_GBNF(t *testing.T) {
+	valid := `# yes/no answer with a reason
+root   ::= answer " because " reason
+answer ::= ("yes" | "no")
+reason ::= [a-z \]]+ "."? [0-9]{1,3}
+  | <think> .*
+`
+	assert.NoError(t, ValidateGrammar(valid, ""))
+	assert.NoError(t, ValidateGrammar(valid, GrammarFormatGBNF))
+
+	tests := []struct {
+		name     string
+		grammar  string
+		wantErr  string
+		wantRule string
+	}{
+		{"empty", "# nothing\n", ErrMsgGrammarEmpty, ""},
+		{"no root", `answer ::= "yes"`, ErrMsgGrammarMissingRoot, GrammarRootRuleGBNF},
+		{"undefined rule", `root ::= answer`, ErrMsgGrammarUndefinedRule, "answer"},
+		{"unterminated string", "root ::= \"yes\n", ErrMsgGrammarUnterminated, ""},
+		{"unterminated class", `root ::= [a-z`, ErrMsgGrammarUnterminated, ""},
+		{"unbalanced", `root ::= ("yes" | "no"`, ErrMsgGrammarUnbalanced, "root"},
+		{"extra close", `root ::= "yes")`, ErrMsgGrammarUnbalanced, "root"},
+		{"empty rule", "root ::=\nother ::= \"x\"", ErrMsgGrammarEmptyRule, "root"},
+		{"outside rule", `"yes" root ::= "no"`, ErrMsgGrammarOutsideRule, ""},
+		{"bad repetition", `root ::= "a"{x}`, ErrMsgGrammarUnexpectedChar, ""},
+		{"lark syntax", `start: "yes"`, ErrMsgGrammarUnexpectedChar, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGrammar(tt.grammar, GrammarFormatGBNF)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			if tt.wantRule != "" {
+				var customErr *cuserr.CustomError
+				require.True(t, errors.As(err, &customErr))
+				rule, ok := customErr.GetMetadata(MetaKeyRule)
+				assert.True(t, ok)
+				assert.Equal(t, tt.wantRule, rule)
+			}
+		})
+	}
+
+	err := ValidateGrammar("root ::= a\n\na ::= b", "")
+	var customErr *cuserr.CustomError
+	require.True(t, errors.As(err, &customErr))
+	line, _ := customErr.GetMetadata(MetaKeyLine)
+	assert.Equal(t, "3", line)
+}
+
+func TestValidateGrammar_Lark(t *testing.T) {
+	valid := `// arithmetic
+?start: sum
+?sum: product
+    | sum "+" product   -> add
+!product: atom ("*" atom)*
+atom: NUMBER | "(" sum ")" | [SIGN] NAME~1..3
+SIGN.2: "-" | "+"i
+NAME: /[a-z_]\/+/i | "a".."z"
+
+%import common.NUMBER
+%import common (WS, LETTER)
+%import common.CNAME -> IDENT
+%declare UNUSED
+%ignore WS
+`
+	assert.NoError(t, ValidateGrammar(valid, GrammarFormatLark))
+
+	tests := []struct {
+		name    string
+		grammar string
+		wantErr string
+	}{
+		{"no start", `root: "yes"`, ErrMsgGrammarMissingRoot},
+		{"undefined rule", `start: answer`, ErrMsgGrammarUndefinedRule},
+		{"undefined ignore", "start: \"a\"\n%ignore WS", ErrMsgGrammarUndefinedRule},
+		{"unterminated regex", `start: /abc`, ErrMsgGrammarUnterminated},
+		{"unbalanced", `start: ["a" "b"`, ErrMsgGrammarUnbalanced},
+		{"gbnf syntax", `root ::= "yes"`, ErrMsgGrammarUnexpectedChar},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGrammar(tt.grammar, GrammarFormatLark)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	err := ValidateGrammar(`start: "a"`, "peg")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgGrammarUnknownFormat)
+}
+
+func TestGrammarFromChoices(t *testing.T) {
+	grammar, err := GrammarFromChoices("", []string{"yes", "no", `say "hi"`})
+	require.NoError(t, err)
+	assert.Equal(t, `root ::= "yes" | "no" | "say \"hi\""`+"\n", grammar)
+	assert.NoError(t, ValidateGrammar(grammar, GrammarFormatGBNF))
+
+	grammar, err = GrammarFromChoices(GrammarFormatLark, []string{"positive", "negative"})
+	require.NoError(t, err)
+	assert.Equal(t, `start: "positive" | "negative"`+"\n", grammar)
+	assert.NoError(t, ValidateGrammar(grammar, GrammarFormatLark))
+
+	_, err = GrammarFromChoices("", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgGrammarNoChoices)
+	_, err = GrammarFromChoices("peg", []string{"a"})
+	assert.Error(t, err)
+}
+
+func TestGuidedDecoding_Grammar(t *testing.T) {
+	t.Run("validate", func(t *testing.T) {
+		assert.NoError(t, (&GuidedDecoding{Grammar: `start: "a"`, GrammarFormat: GrammarFormatLark}).Validate())
+		assert.Error(t, (&GuidedDecoding{Grammar: `start: "a"`}).Validate(), "gbnf by default")
+		assert.Error(t, (&GuidedDecoding{Regex: "a+", Choice: []string{"a"}}).Validate())
+		assert.Error(t, (&GuidedDecoding{GrammarFormat: "peg"}).Validate())
+
+		config := &ExecutionConfig{GuidedDecoding: &GuidedDecoding{Grammar: `root ::= answer`}}
+		err := config.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ErrMsgGrammarUndefinedRule)
+
+		result := ValidateGuidedDecoding(config.GuidedDecoding)
+		assert.False(t, result.Valid)
+		assert.Contains(t, result.Errors[0], ErrMsgGrammarUndefinedRule)
+	})
+
+	t.Run("vllm", func(t *testing.T) {
+		gd := &GuidedDecoding{Grammar: `start: "a"`, GrammarFormat: GrammarFormatLark}
+		assert.Equal(t, map[string]any{GuidedKeyGrammar: `start: "a"`}, gd.ToVLLM())
+		assert.Equal(t, GrammarFormatLark, cloneGuidedDecoding(gd).GrammarFormat)
+	})
+
+	t.Run("llama.cpp", func(t *testing.T) {
+		config := &ExecutionConfig{Model: "model.gguf", GuidedDecoding: &GuidedDecoding{Choice: []string{"yes", "no"}}}
+		assert.Equal(t, `root ::= "yes" | "no"`+"\n", config.ToLlamaCpp()[LlamaCppKeyGrammar])
+
+		config.GuidedDecoding = &GuidedDecoding{Grammar: `start: "a"`, GrammarFormat: GrammarFormatLark}
+		assert.NotContains(t, config.ToLlamaCpp(), LlamaCppKeyGrammar)
+	})
+
+	t.Run("compatibility", func(t *testing.T) {
+		config := &ExecutionConfig{GuidedDecoding: &GuidedDecoding{Grammar: `start: "a"`, GrammarFormat: GrammarFormatLark}}
+		assert.Nil(t, CheckCompatibility(config, ProviderVLLM))
+		result := CheckCompatibility(config, ProviderLlamaCpp)
+		require.Len(t, result, 1)
+		assert.Equal(t, CapabilityGuidedLark, result[0].Field)
+		assert.Equal(t, SeverityError, result[0].Severity)
+
+		config.GuidedDecoding = &GuidedDecoding{Regex: "[0-9]+"}
+		result = CheckCompatibility(config, ProviderLlamaCpp)
+		require.Len(t, result, 1)
+		assert.Equal(t, CapabilityGuidedRegex, result[0].Field)
+
+		result = CheckCompatibility(config, ProviderOpenAI)
+		require.Len(t, result, 1)
+		assert.Equal(t, CapabilityGuidedDecoding, result[0].Field, "covered by the section")
+	})
+}
@@ -0,0 +1,420 @@
+package prompty
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Reflected types with a fixed schema
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+	byteSliceType  = reflect.TypeOf([]byte(nil))
+)
+
+// responseCodeFence opens and closes a markdown code block around a reply.
+const responseCodeFence = "```"
+
+// SchemaFromType builds a JSON schema for structured output from the Go type
+// T, to drop into ResponseFormat.JSONSchema or GuidedDecoding.JSON. The
+// schema name is the snake_case type name.
+//
+// Struct fields are named by their json tags; fields tagged `json:"-"` and
+// unexported fields are skipped, and embedded structs are flattened as
+// encoding/json does. A field is required unless it is a pointer or tagged
+// omitempty; `validate:"required"` makes any field required. The validate
+// rules oneof, min and max become enum, minimum/maximum, minLength/maxLength
+// or minItems/maxItems, and a `description` tag describes the property.
+// Objects disallow additional properties. time.Time is a date-time string,
+// []byte a string and interfaces accept any value; their schema has no
+// type, which strict mode providers reject.
+//
+// Example:
+//
+//	type Verdict struct {
+//	    Label      string   `json:"label" validate:"oneof=spam ham"`
+//	    Confidence float64  `json:"confidence" validate:"min=0,max=1"`
+//	    Reasons    []string `json:"reasons,omitempty" description:"Evidence for the label"`
+//	}
+//	spec, err := prompty.SchemaFromType[Verdict]()
+//	config.ResponseFormat = &prompty.ResponseFormat{Type: prompty.ResponseFormatJSONSchema, JSONSchema: spec}
+func SchemaFromType[T any]() (*JSONSchemaSpec, error) {
+	t := reflect.TypeFor[T]()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct && t != timeType {
+		schema, ordering, err := schemaForStruct(t, []reflect.Type{t})
+		if err != nil {
+			return nil, err
+		}
+		return &JSONSchemaSpec{
+			Name:                 toSnakeCase(t.Name()),
+			Schema:               schema,
+			AdditionalProperties: new(bool),
+			PropertyOrdering:     ordering,
+		}, nil
+	}
+
+	schema, err := schemaForType(t, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONSchemaSpec{Name: toSnakeCase(t.Name()), Schema: schema}, nil
+}
+
+// MustSchemaFromType is like SchemaFromType but panics on error.
+func MustSchemaFromType[T any]() *JSONSchemaSpec {
+	spec, err := SchemaFromType[T]()
+	if err != nil {
+		panic(err)
+	}
+	return spec
+}
+
+// UnmarshalResponse decodes a structured output reply into T after
+// validating it against SchemaFromType[T]: required fields must be present,
+// values must have the schema's types, enums and ranges, and unknown fields
+// are rejected. A reply wrapped in a markdown code block is unwrapped first.
+// Errors carry the path of the offending value.
+func UnmarshalResponse[T any](data []byte) (T, error) {
+	var result T
+	spec, err := SchemaFromType[T]()
+	if err != nil {
+		return result, err
+	}
+
+	data = stripCodeFence(data)
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return result, NewSchemaValidationError(ErrMsgResponseInvalidJSON, "")
+	}
+	if err := validateResponseValue(spec.Schema, value, ""); err != nil {
+		return result, err
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, NewSchemaValidationError(ErrMsgResponseWrongType, "")
+	}
+	return result, nil
+}
+
+// schemaForType returns the schema of t. seen holds the struct types being
+// built, to reject recursion.
+func schemaForType(t reflect.Type, seen []reflect.Type) (map[string]any, error) {
+	switch t {
+	case timeType:
+		return map[string]any{SchemaKeyType: SchemaTypeString, SchemaKeyFormat: SchemaFormatDateTime}, nil
+	case rawMessageType:
+		return map[string]any{}, nil
+	case byteSliceType:
+		return map[string]any{SchemaKeyType: SchemaTypeString}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaForType(t.Elem(), seen)
+	case reflect.String:
+		return map[string]any{SchemaKeyType: SchemaTypeString}, nil
+	case reflect.Bool:
+		return map[string]any{SchemaKeyType: SchemaTypeBoolean}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{SchemaKeyType: SchemaTypeInteger}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{SchemaKeyType: SchemaTypeNumber}, nil
+	case reflect.Interface:
+		return map[string]any{}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := schemaForType(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{SchemaKeyType: SchemaTypeArray, SchemaKeyItems: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, NewSchemaValidationError(ErrMsgSchemaUnsupportedGoType, t.String())
+		}
+		values, err := schemaForType(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{SchemaKeyType: SchemaTypeObject, SchemaKeyAdditionalProperties: values}, nil
+	case reflect.Struct:
+		if slices.Contains(seen, t) {
+			return nil, NewSchemaValidationError(ErrMsgSchemaRecursiveGoType, t.String())
+		}
+		schema, _, err := schemaForStruct(t, append(seen, t))
+		return schema, err
+	default:
+		return nil, NewSchemaValidationError(ErrMsgSchemaUnsupportedGoType, t.String())
+	}
+}
+
+// schemaForStruct returns the object schema of struct type t and its
+// property names in field order.
+func schemaForStruct(t reflect.Type, seen []reflect.Type) (map[string]any, []string, error) {
+	properties := make(map[string]any)
+	required := []string{}
+	var ordering []string
+
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get(StructTagJSON)
+		if tag == StructTagIgnore {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, StructTagSeparator)
+		if f.Anonymous && name == "" && derefType(f.Type).Kind() == reflect.Struct {
+			continue // promoted fields are visited on their own
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if _, taken := properties[name]; taken {
+			continue // the first field of a name wins
+		}
+
+		property, err := schemaForType(f.Type, seen)
+		if err != nil {
+			return nil, nil, err
+		}
+		rules := parseValidateTag(f.Tag.Get(StructTagValidate))
+		applyValidateRules(property, rules)
+		if description := f.Tag.Get(StructTagDescription); description != "" {
+			property[SchemaKeyDescription] = description
+		}
+
+		properties[name] = property
+		ordering = append(ordering, name)
+		_, mustHave := rules[ValidateRuleRequired]
+		optional := f.Type.Kind() == reflect.Pointer || slices.Contains(strings.Split(options, StructTagSeparator), StructTagOmitEmpty)
+		if mustHave || !optional {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]any{
+		SchemaKeyType:                 SchemaTypeObject,
+		SchemaKeyProperties:           properties,
+		SchemaKeyRequired:             required,
+		SchemaKeyAdditionalProperties: false,
+	}, ordering, nil
+}
+
+// derefType returns the element type of pointer types.
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// parseValidateTag parses a validate tag into rule names and arguments.
+func parseValidateTag(tag string) map[string]string {
+	rules := make(map[string]string)
+	for _, rule := range strings.Split(tag, ValidateRuleSeparator) {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), ValidateRuleAssign)
+		if name != "" {
+			rules[name] = arg
+		}
+	}
+	return rules
+}
+
+// applyValidateRules adds the enum and range constraints of rules to a
+// property schema.
+func applyValidateRules(property map[string]any, rules map[string]string) {
+	if values, ok := rules[ValidateRuleOneOf]; ok {
+		enum := make([]any, 0)
+		for _, v := range strings.Fields(values) {
+			enum = append(enum, enumValue(property[SchemaKeyType], v))
+		}
+		property[SchemaKeyEnum] = enum
+	}
+
+	minKey, maxKey := SchemaKeyMinimum, SchemaKeyMaximum
+	switch property[SchemaKeyType] {
+	case SchemaTypeString:
+		minKey, maxKey = SchemaKeyMinLength, SchemaKeyMaxLength
+	case SchemaTypeArray:
+		minKey, maxKey = SchemaKeyMinItems, SchemaKeyMaxItems
+	case SchemaTypeInteger, SchemaTypeNumber:
+	default:
+		return
+	}
+	for rule, key := range map[string]string{ValidateRuleMin: minKey, ValidateRuleMax: maxKey} {
+		if arg, ok := rules[rule]; ok {
+			if n, err := strconv.ParseFloat(arg, 64); err == nil {
+				property[key] = n
+			}
+		}
+	}
+}
+
+// enumValue converts a oneof value to the property's type.
+func enumValue(schemaType any, v string) any {
+	switch schemaType {
+	case SchemaTypeInteger:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case SchemaTypeNumber:
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	case SchemaTypeBoolean:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// stripCodeFence removes a markdown code block around data, e.g. ```json ... ```.
+func stripCodeFence(data []byte) []byte {
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(trimmed, []byte(responseCodeFence)) || !bytes.HasSuffix(trimmed, []byte(responseCodeFence)) || len(trimmed) < 2*len(responseCodeFence) {
+		return data
+	}
+	inner := trimmed[len(responseCodeFence) : len(trimmed)-len(responseCodeFence)]
+	if newline := bytes.IndexByte(inner, '\n'); newline >= 0 {
+		inner = inner[newline+1:] // drop the language tag line
+	}
+	return inner
+}
+
+// validateResponseValue checks a decoded JSON value (numbers as
+// json.Number) against a schema built by SchemaFromType.
+func validateResponseValue(schema map[string]any, value any, path string) error {
+	schemaType, _ := schema[SchemaKeyType].(string)
+	if schemaType == "" {
+		return nil // any value
+	}
+	if value == nil {
+		return NewSchemaValidationError(ErrMsgResponseWrongType, path)
+	}
+
+	switch schemaType {
+	case SchemaTypeObject:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return NewSchemaValidationError(ErrMsgResponseWrongType, path)
+		}
+		return validateResponseObject(schema, object, path)
+	case SchemaTypeArray:
+		array, ok := value.([]any)
+		if !ok {
+			return NewSchemaValidationError(ErrMsgResponseWrongType, path)
+		}
+		if !inRange(schema, SchemaKeyMinItems, SchemaKeyMaxItems, float64(len(array))) {
+			return NewSchemaValidationError(ErrMsgResponseOutOfRange, path)
+		}
+		items, _ := schema[SchemaKeyItems].(map[string]any)
+		for i, item := range array {
+			if err := validateResponseValue(items, item, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+		return nil
+	case SchemaTypeString:
+		s, ok := value.(string)
+		if !ok {
+			return NewSchemaValidationError(ErrMsgResponseWrongType, path)
+		}
+		if !inRange(schema, SchemaKeyMinLength, SchemaKeyMaxLength, float64(utf8.RuneCountInString(s))) {
+			return NewSchemaValidationError(ErrMsgResponseOutOfRange, path)
+		}
+		return validateResponseEnum(schema, s, path)
+	case SchemaTypeBoolean:
+		b, ok := value.(bool)
+		if !ok {
+			return NewSchemaValidationError(ErrMsgResponseWrongType, path)
+		}
+		return validateResponseEnum(schema, b, path)
+	case SchemaTypeInteger, SchemaTypeNumber:
+		number, ok := value.(json.Number)
+		if !ok {
+			return NewSchemaValidationError(ErrMsgResponseWrongType, path)
+		}
+		n, err := number.Float64()
+		if err != nil || (schemaType == SchemaTypeInteger && n != math.Trunc(n)) {
+			return NewSchemaValidationError(ErrMsgResponseWrongType, path)
+		}
+		if !inRange(schema, SchemaKeyMinimum, SchemaKeyMaximum, n) {
+			return NewSchemaValidationError(ErrMsgResponseOutOfRange, path)
+		}
+		return validateResponseEnum(schema, n, path)
+	}
+	return nil
+}
+
+// validateResponseObject checks the fields of an object value.
+func validateResponseObject(schema map[string]any, object map[string]any, path string) error {
+	properties, _ := schema[SchemaKeyProperties].(map[string]any)
+	required, _ := schema[SchemaKeyRequired].([]string)
+	for _, name := range required {
+		if _, ok := object[name]; !ok {
+			return NewSchemaValidationError(ErrMsgResponseMissingField, joinPath(path, name))
+		}
+	}
+
+	for name, v := range object {
+		fieldPath := joinPath(path, name)
+		property, ok := properties[name].(map[string]any)
+		if !ok {
+			additional, isSchema := schema[SchemaKeyAdditionalProperties].(map[string]any)
+			if !isSchema {
+				return NewSchemaValidationError(ErrMsgResponseUnknownField, fieldPath)
+			}
+			property = additional
+		}
+		// Optional fields may be null, as encoding/json leaves them unset
+		if v == nil && !slices.Contains(required, name) {
+			continue
+		}
+		if err := validateResponseValue(property, v, fieldPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateResponseEnum checks value against the schema's enum, if any.
+func validateResponseEnum(schema map[string]any, value any, path string) error {
+	enum, ok := schema[SchemaKeyEnum].([]any)
+	if !ok {
+		return nil
+	}
+	for _, allowed := range enum {
+		if allowed == value {
+			return nil
+		}
+		if n, isInt := allowed.(int64); isInt && float64(n) == value {
+			return nil
+		}
+	}
+	return NewSchemaValidationError(ErrMsgResponseNotInEnum, path)
+}
+
+// inRange checks n against the schema's bounds under minKey and maxKey.
+func inRange(schema map[string]any, minKey, maxKey string, n float64) bool {
+	if lower, ok := schema[minKey].(float64); ok && n < lower {
+		return false
+	}
+	if upper, ok := schema[maxKey].(float64); ok && n > upper {
+		return false
+	}
+	return true
+}
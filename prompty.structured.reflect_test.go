@@ -0,0 +1,188 @@
+package prompty
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/itsatony/go-cuserr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testAuditMeta struct {
+	Source string `json:"source"`
+}
+
+type testVerdict struct {
+	testAuditMeta
+	Label      string            `json:"label" validate:"oneof=spam ham"`
+	Confidence float64           `json:"confidence" validate:"min=0,max=1"`
+	Reasons    []string          `json:"reasons,omitempty" description:"Evidence for the label" validate:"max=3"`
+	Priority   int               `json:"priority" validate:"oneof=1 2 3"`
+	Reviewer   *string           `json:"reviewer"`
+	Escalate   *bool             `json:"escalate" validate:"required"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	Seen       time.Time         `json:"seen,omitempty"`
+	Internal   string            `json:"-"`
+	NoTag      bool
+	hidden     string //nolint:unused // unexported fields are skipped
+}
+
+type testRecursive struct {
+	Children []testRecursive `json:"children"`
+}
+
+func TestSchemaFromType(t *testing.T) {
+	spec, err := SchemaFromType[testVerdict]()
+	require.NoError(t, err)
+	assert.Equal(t, "test_verdict", spec.Name)
+	require.NotNil(t, spec.AdditionalProperties)
+	assert.False(t, *spec.AdditionalProperties)
+	assert.Equal(t, []string{"source", "label", "confidence", "reasons", "priority", "reviewer", "escalate", "tags", "seen", "NoTag"}, spec.PropertyOrdering)
+
+	schema := spec.Schema
+	assert.Equal(t, SchemaTypeObject, schema[SchemaKeyType])
+	assert.Equal(t, false, schema[SchemaKeyAdditionalProperties])
+	assert.Equal(t, []string{"source", "label", "confidence", "priority", "escalate", "NoTag"}, schema[SchemaKeyRequired])
+
+	props := schema[SchemaKeyProperties].(map[string]any)
+	assert.Len(t, props, 10)
+	assert.Equal(t, map[string]any{SchemaKeyType: SchemaTypeString, SchemaKeyEnum: []any{"spam", "ham"}}, props["label"])
+	assert.Equal(t, map[string]any{SchemaKeyType: SchemaTypeNumber, SchemaKeyMinimum: 0.0, SchemaKeyMaximum: 1.0}, props["confidence"])
+	assert.Equal(t, map[string]any{
+		SchemaKeyType:        SchemaTypeArray,
+		SchemaKeyItems:       map[string]any{SchemaKeyType: SchemaTypeString},
+		SchemaKeyDescription: "Evidence for the label",
+		SchemaKeyMaxItems:    3.0,
+	}, props["reasons"])
+	assert.Equal(t, map[string]any{SchemaKeyType: SchemaTypeInteger, SchemaKeyEnum: []any{int64(1), int64(2), int64(3)}}, props["priority"])
+	assert.Equal(t, map[string]any{SchemaKeyType: SchemaTypeBoolean}, props["escalate"])
+	assert.Equal(t, map[string]any{SchemaKeyType: SchemaTypeObject, SchemaKeyAdditionalProperties: map[string]any{SchemaKeyType: SchemaTypeString}}, props["tags"])
+	assert.Equal(t, map[string]any{SchemaKeyType: SchemaTypeString, SchemaKeyFormat: SchemaFormatDateTime}, props["seen"])
+
+	result := ValidateForProvider(spec.Schema, ProviderOpenAI)
+	assert.False(t, result.Valid, "map fields cannot be strict")
+	assert.Len(t, result.Errors, 1)
+
+	config := &ExecutionConfig{ResponseFormat: &ResponseFormat{Type: ResponseFormatJSONSchema, JSONSchema: spec}}
+	assert.NoError(t, config.Validate())
+}
+
+func TestSchemaFromType_NestedAndScalars(t *testing.T) {
+	type line struct {
+		SKU string `json:"sku"`
+		Qty int    `json:"qty" validate:"min=1"`
+	}
+	type order struct {
+		Lines []line `json:"lines" validate:"min=1"`
+		Note  *line  `json:"note"`
+	}
+
+	spec, err := SchemaFromType[*order]()
+	require.NoError(t, err)
+	props := spec.Schema[SchemaKeyProperties].(map[string]any)
+	lines := props["lines"].(map[string]any)
+	assert.Equal(t, 1.0, lines[SchemaKeyMinItems])
+	item := lines[SchemaKeyItems].(map[string]any)
+	assert.Equal(t, []string{"sku", "qty"}, item[SchemaKeyRequired])
+	assert.NotContains(t, item, SchemaKeyPropertyOrdering, "ordering is only set on the spec")
+	result := ValidateForProvider(spec.Schema, ProviderOpenAI)
+	assert.True(t, result.Valid, result.Errors)
+
+	spec, err = SchemaFromType[struct {
+		Extra any `json:"extra"`
+	}]()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{}, spec.Schema[SchemaKeyProperties].(map[string]any)["extra"])
+
+	spec, err = SchemaFromType[[]string]()
+	require.NoError(t, err)
+	assert.Equal(t, SchemaTypeArray, spec.Schema[SchemaKeyType])
+	assert.Nil(t, spec.AdditionalProperties)
+
+	_, err = SchemaFromType[testRecursive]()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgSchemaRecursiveGoType)
+
+	_, err = SchemaFromType[map[int]string]()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgSchemaUnsupportedGoType)
+
+	_, err = SchemaFromType[struct{ C chan int }]()
+	assert.Error(t, err)
+	assert.Panics(t, func() { MustSchemaFromType[func()]() })
+}
+
+func TestUnmarshalResponse(t *testing.T) {
+	valid := `{"source": "inbox", "label": "spam", "confidence": 0.9, "priority": 2, "reviewer": null, "escalate": false, "NoTag": true}`
+
+	got, err := UnmarshalResponse[testVerdict]([]byte(valid))
+	require.NoError(t, err)
+	assert.Equal(t, "spam", got.Label)
+	assert.Equal(t, "inbox", got.Source)
+	assert.Equal(t, 2, got.Priority)
+	require.NotNil(t, got.Escalate)
+	assert.False(t, *got.Escalate)
+
+	fenced := "```json\n" + valid + "\n```"
+	got, err = UnmarshalResponse[testVerdict]([]byte(fenced))
+	require.NoError(t, err)
+	assert.Equal(t, 0.9, got.Confidence)
+
+	tests := []struct {
+		name     string
+		data     string
+		wantErr  string
+		wantPath string
+	}{
+		{"invalid json", `{"label":`, ErrMsgResponseInvalidJSON, ""},
+		{"missing field", `{"source": "x", "label": "spam", "confidence": 0.5, "priority": 1, "NoTag": true}`, ErrMsgResponseMissingField, "escalate"},
+		{"wrong type", `{"source": "x", "label": "spam", "confidence": "high", "priority": 1, "escalate": true, "NoTag": true}`, ErrMsgResponseWrongType, "confidence"},
+		{"not in enum", `{"source": "x", "label": "eggs", "confidence": 0.5, "priority": 1, "escalate": true, "NoTag": true}`, ErrMsgResponseNotInEnum, "label"},
+		{"integer enum", `{"source": "x", "label": "ham", "confidence": 0.5, "priority": 7, "escalate": true, "NoTag": true}`, ErrMsgResponseNotInEnum, "priority"},
+		{"fractional integer", `{"source": "x", "label": "ham", "confidence": 0.5, "priority": 1.5, "escalate": true, "NoTag": true}`, ErrMsgResponseWrongType, "priority"},
+		{"out of range", `{"source": "x", "label": "ham", "confidence": 1.5, "priority": 1, "escalate": true, "NoTag": true}`, ErrMsgResponseOutOfRange, "confidence"},
+		{"too many items", `{"source": "x", "label": "ham", "confidence": 0.5, "priority": 1, "escalate": true, "NoTag": true, "reasons": ["a", "b", "c", "d"]}`, ErrMsgResponseOutOfRange, "reasons"},
+		{"wrong item type", `{"source": "x", "label": "ham", "confidence": 0.5, "priority": 1, "escalate": true, "NoTag": true, "reasons": [1]}`, ErrMsgResponseWrongType, "reasons[0]"},
+		{"unknown field", `{"source": "x", "label": "ham", "confidence": 0.5, "priority": 1, "escalate": true, "NoTag": true, "mood": "happy"}`, ErrMsgResponseUnknownField, "mood"},
+		{"required null", `{"source": "x", "label": "ham", "confidence": 0.5, "priority": 1, "escalate": null, "NoTag": true}`, ErrMsgResponseWrongType, "escalate"},
+		{"not an object", `[1, 2]`, ErrMsgResponseWrongType, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UnmarshalResponse[testVerdict]([]byte(tt.data))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			if tt.wantPath != "" {
+				assert.Equal(t, tt.wantPath, responseErrorPath(t, err))
+			}
+		})
+	}
+}
+
+func TestUnmarshalResponse_MapValues(t *testing.T) {
+	type scores struct {
+		Scores map[string]int  `json:"scores"`
+		Raw    json.RawMessage `json:"raw,omitempty"`
+	}
+
+	got, err := UnmarshalResponse[scores]([]byte(`{"scores": {"a": 1, "b": 2}, "raw": {"any": ["thing"]}}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, got.Scores)
+	assert.JSONEq(t, `{"any": ["thing"]}`, string(got.Raw))
+
+	_, err = UnmarshalResponse[scores]([]byte(`{"scores": {"a": "one"}}`))
+	require.Error(t, err)
+	assert.Equal(t, "scores.a", responseErrorPath(t, err))
+}
+
+func responseErrorPath(t *testing.T, err error) string {
+	t.Helper()
+	var customErr *cuserr.CustomError
+	require.True(t, errors.As(err, &customErr))
+	path, ok := customErr.GetMetadata(MetaKeyPath)
+	require.True(t, ok)
+	return path
+}
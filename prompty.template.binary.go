@@ -0,0 +1,85 @@
+package prompty
+
+import (
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// MarshalBinary encodes the parsed template as a compact binary artifact
+// that LoadCompiledTemplate turns back into a Template without tokenizing
+// or parsing. It implements encoding.BinaryMarshaler.
+//
+// The artifact holds the source, the body AST and the delimiters it was
+// parsed with. Frontmatter is stored as written and parsed on load, so
+// prompty.env values resolve in the loading environment, as with Parse.
+// Artifacts are tied to the format version of the library that wrote them.
+func (t *Template) MarshalBinary() ([]byte, error) {
+	lexerConfig := t.config.lexerConfig()
+
+	var frontmatter string
+	if configResult, err := internal.ExtractConfigBlock(t.source, lexerConfig); err == nil && configResult.HasFrontmatter {
+		frontmatter = configResult.FrontmatterYAML
+	}
+
+	data, err := internal.MarshalCompiledTemplate(&internal.CompiledTemplate{
+		OpenDelim:   lexerConfig.OpenDelim,
+		CloseDelim:  lexerConfig.CloseDelim,
+		Source:      t.source,
+		Body:        t.templateBody,
+		Frontmatter: frontmatter,
+		AST:         t.ast,
+	})
+	if err != nil {
+		return nil, NewCompiledTemplateError(ErrMsgCompiledTemplateEncode, err)
+	}
+	return data, nil
+}
+
+// LoadCompiledTemplate loads a template written by Template.MarshalBinary
+// into a new engine with default options and the artifact's delimiters.
+// Use Engine.LoadCompiledTemplate when the template needs custom resolvers,
+// functions or registered templates.
+func LoadCompiledTemplate(data []byte) (*Template, error) {
+	ct, err := decodeCompiledTemplate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	engine, err := New(WithDelimiters(ct.OpenDelim, ct.CloseDelim))
+	if err != nil {
+		return nil, err
+	}
+	return engine.newCompiledTemplate(ct)
+}
+
+// LoadCompiledTemplate loads a template written by Template.MarshalBinary
+// and binds it to this engine, as if it had been returned by Parse.
+// The artifact must have been parsed with the engine's delimiters.
+func (e *Engine) LoadCompiledTemplate(data []byte) (*Template, error) {
+	ct, err := decodeCompiledTemplate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if ct.OpenDelim != e.config.openDelim || ct.CloseDelim != e.config.closeDelim {
+		return nil, NewDelimiterError(ErrMsgCompiledDelimiterMismatch, ct.OpenDelim, ct.CloseDelim)
+	}
+	return e.newCompiledTemplate(ct)
+}
+
+// decodeCompiledTemplate decodes a compiled template artifact.
+func decodeCompiledTemplate(data []byte) (*internal.CompiledTemplate, error) {
+	ct, err := internal.UnmarshalCompiledTemplate(data)
+	if err != nil {
+		return nil, NewCompiledTemplateDecodeError(err)
+	}
+	return ct, nil
+}
+
+// newCompiledTemplate builds a Template from a decoded artifact.
+func (e *Engine) newCompiledTemplate(ct *internal.CompiledTemplate) (*Template, error) {
+	prompt, err := e.parseFrontmatter(ct.Frontmatter)
+	if err != nil {
+		return nil, err
+	}
+	return newTemplateWithConfig(ct.Source, ct.Body, ct.AST, e.executor, e.config, e, prompt), nil
+}
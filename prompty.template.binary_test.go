@@ -0,0 +1,175 @@
+package prompty
+
+import (
+	"context"
+	"encoding"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+var _ encoding.BinaryMarshaler = (*Template)(nil)
+
+func TestTemplate_MarshalBinary_RoundTrip(t *testing.T) {
+	source := `---
+name: greeting
+description: Greets a user
+execution:
+  model: gpt-4o
+  temperature: 0.3
+---
+Hello {~prompty.var name="user" /~}!
+{~prompty.for item="t" in="tags"~}#{~prompty.var name="t" /~} {~/prompty.for~}
+{~prompty.if eval="admin"~}(admin){~prompty.else~}(user){~/prompty.if~}`
+	data := map[string]any{"user": "Ada", "tags": []any{"a", "b"}, "admin": true}
+
+	tmpl, err := MustNew().Parse(source)
+	require.NoError(t, err)
+	compiled, err := tmpl.MarshalBinary()
+	require.NoError(t, err)
+
+	loaded, err := LoadCompiledTemplate(compiled)
+	require.NoError(t, err)
+
+	assert.Equal(t, tmpl.Source(), loaded.Source())
+	assert.Equal(t, tmpl.TemplateBody(), loaded.TemplateBody())
+	require.True(t, loaded.HasPrompt())
+	assert.Equal(t, "greeting", loaded.Prompt().Name)
+	assert.Equal(t, "gpt-4o", loaded.Prompt().Execution.Model)
+
+	want, err := tmpl.Execute(context.Background(), data)
+	require.NoError(t, err)
+	got, err := loaded.Execute(context.Background(), data)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.Equal(t, "Hello Ada!\n#a #b \n(admin)", got)
+}
+
+func TestTemplate_MarshalBinary_FrontmatterEnvResolvedOnLoad(t *testing.T) {
+	t.Setenv("COMPILED_TEST_MODEL", "build-model")
+	tmpl, err := MustNew().Parse("---\nname: env\ndescription: env test\nexecution:\n  model: '{~prompty.env name=\"COMPILED_TEST_MODEL\" /~}'\n---\nBody")
+	require.NoError(t, err)
+	compiled, err := tmpl.MarshalBinary()
+	require.NoError(t, err)
+	assert.NotContains(t, string(compiled), "build-model")
+
+	t.Setenv("COMPILED_TEST_MODEL", "runtime-model")
+	loaded, err := LoadCompiledTemplate(compiled)
+	require.NoError(t, err)
+	assert.Equal(t, "runtime-model", loaded.Prompt().Execution.Model)
+}
+
+func TestEngine_LoadCompiledTemplate(t *testing.T) {
+	// Compile offline with a bare engine
+	tmpl, err := MustNew().Parse(`{~prompty.extends template="base" /~}{~prompty.block name="body"~}{~prompty.include template="sig" /~}{~/prompty.block~}`)
+	require.NoError(t, err)
+	compiled, err := tmpl.MarshalBinary()
+	require.NoError(t, err)
+
+	// Load into an engine with the registered templates
+	engine := MustNew()
+	engine.MustRegisterTemplate("base", `[{~prompty.block name="body"~}default{~/prompty.block~}]`)
+	engine.MustRegisterTemplate("sig", `-- signed`)
+
+	loaded, err := engine.LoadCompiledTemplate(compiled)
+	require.NoError(t, err)
+	out, err := loaded.Execute(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[-- signed]", out)
+}
+
+func TestLoadCompiledTemplate_Delimiters(t *testing.T) {
+	engine := MustNew(WithDelimiters("<<", ">>"))
+	tmpl, err := engine.Parse(`Hi <<prompty.var name="n" />>`)
+	require.NoError(t, err)
+	compiled, err := tmpl.MarshalBinary()
+	require.NoError(t, err)
+
+	// The package-level loader adopts the artifact's delimiters
+	loaded, err := LoadCompiledTemplate(compiled)
+	require.NoError(t, err)
+	out, err := loaded.Execute(context.Background(), map[string]any{"n": "Bo"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hi Bo", out)
+
+	_, err = MustNew(WithDelimiters("<<", ">>")).LoadCompiledTemplate(compiled)
+	require.NoError(t, err)
+
+	_, err = MustNew().LoadCompiledTemplate(compiled)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgCompiledDelimiterMismatch)
+}
+
+func TestLoadCompiledTemplate_Invalid(t *testing.T) {
+	tmpl, err := MustNew().Parse(`Hello {~prompty.var name="x" /~}`)
+	require.NoError(t, err)
+	compiled, err := tmpl.MarshalBinary()
+	require.NoError(t, err)
+
+	future := append([]byte(internal.CompiledMagic), internal.CompiledFormatVersion+1)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		code    string
+		version string
+	}{
+		{name: "empty", data: nil, code: ErrCodeCompiledBadMagic},
+		{name: "template source", data: []byte(`Hello {~prompty.var name="x" /~}`), code: ErrCodeCompiledBadMagic},
+		{name: "future version", data: future, code: ErrCodeCompiledVersion, version: strconv.Itoa(internal.CompiledFormatVersion + 1)},
+		{name: "truncated", data: compiled[:len(compiled)-3], code: ErrCodeCompiledCorrupt, version: strconv.Itoa(internal.CompiledFormatVersion)},
+		{name: "trailing data", data: append(append([]byte{}, compiled...), 0), code: ErrCodeCompiledTrailing, version: strconv.Itoa(internal.CompiledFormatVersion)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadCompiledTemplate(tt.data)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), ErrMsgCompiledTemplateDecode)
+			assert.ErrorIs(t, err, ErrValidation)
+
+			details := ErrorDetailsOf(err)
+			assert.Equal(t, tt.code, details.Code)
+			assert.Contains(t, details.Metadata, MetaKeyOffset)
+			assert.Equal(t, tt.version, details.Metadata[MetaKeyVersion])
+		})
+	}
+}
+
+func BenchmarkParse_Large(b *testing.B) {
+	engine := MustNew()
+	source := strings.Repeat(largeCompiledSection, 200)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = engine.Parse(source)
+	}
+}
+
+func BenchmarkLoadCompiledTemplate_Large(b *testing.B) {
+	engine := MustNew()
+	tmpl, err := engine.Parse(strings.Repeat(largeCompiledSection, 200))
+	if err != nil {
+		b.Fatal(err)
+	}
+	compiled, err := tmpl.MarshalBinary()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = engine.LoadCompiledTemplate(compiled)
+	}
+}
+
+const largeCompiledSection = `## Section {~prompty.var name="title" /~}
+{~prompty.if eval="verbose"~}Details for {~prompty.var name="user.name" default="n/a" /~}.{~/prompty.if~}
+{~prompty.for item="item" in="items"~}- {~prompty.var name="item" /~}
+{~/prompty.for~}
+`
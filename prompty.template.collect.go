@@ -0,0 +1,100 @@
+package prompty
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// Collected error messages
+const (
+	ErrMsgTagsFailed  = "template tags failed"
+	tagErrorSeparator = "; "
+)
+
+// TagError is a tag failure collected under ErrorStrategyCollect.
+type TagError struct {
+	// TagName is the name of the failed tag.
+	TagName string
+	// Position is the location of the tag in its template.
+	Position Position
+	// Err is the failure, matching the sentinel errors with errors.Is.
+	Err error
+}
+
+// Error implements the error interface. Tag failures already carry their
+// tag name and position.
+func (e *TagError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the failure.
+func (e *TagError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalJSON encodes the tag error with the ErrorDetails of its failure.
+func (e *TagError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Tag    string        `json:"tag"`
+		Line   int           `json:"line"`
+		Column int           `json:"column"`
+		Error  *ErrorDetails `json:"error"`
+	}{e.TagName, e.Position.Line, e.Position.Column, ErrorDetailsOf(e.Err)})
+}
+
+// ExecutionErrors is returned together with the output by executions under
+// ErrorStrategyCollect in which tags failed. The output renders failed tags
+// with their default attribute, or as nothing. errors.Is and errors.As
+// match the errors of every failed tag.
+type ExecutionErrors struct {
+	// Errors lists the failed tags in execution order, including those of
+	// included templates.
+	Errors []*TagError `json:"errors"`
+}
+
+// Error implements the error interface.
+func (e *ExecutionErrors) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, tagErr := range e.Errors {
+		messages[i] = tagErr.Error()
+	}
+	return ErrMsgTagsFailed + " (" + strconv.Itoa(len(e.Errors)) + "): " + strings.Join(messages, tagErrorSeparator)
+}
+
+// Unwrap returns the errors of the failed tags.
+func (e *ExecutionErrors) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, tagErr := range e.Errors {
+		errs[i] = tagErr
+	}
+	return errs
+}
+
+// executeCollecting executes ast collecting the tag failures of the
+// execution and of the templates it includes, and returns them as
+// ExecutionErrors along with the output.
+func (t *Template) executeCollecting(ctx context.Context, ast *internal.RootNode, execCtx *Context) (string, error) {
+	ctx, collector := internal.WithErrorCollector(ctx)
+	output, err := t.executor.Execute(ctx, ast, execCtx)
+	if err != nil {
+		return "", err
+	}
+
+	collected := collector.Errors()
+	if len(collected) == 0 {
+		return output, nil
+	}
+	errs := &ExecutionErrors{Errors: make([]*TagError, len(collected))}
+	for i, c := range collected {
+		errs.Errors[i] = &TagError{
+			TagName:  c.TagName,
+			Position: publicPosition(c.Pos),
+			Err:      c.Err,
+		}
+	}
+	return output, errs
+}
@@ -0,0 +1,105 @@
+package prompty
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorStrategyCollect(t *testing.T) {
+	ctx := context.Background()
+	engine := MustNew(WithErrorStrategy(ErrorStrategyCollect))
+
+	t.Run("returns output and every failure", func(t *testing.T) {
+		source := "Hi {~prompty.var name=\"name\" /~}!\n" +
+			"{~prompty.var name=\"title\" default=\"Guest\" /~} {~prompty.unknown /~}"
+		output, err := engine.Execute(ctx, source, nil)
+		assert.Equal(t, "Hi !\nGuest ", output)
+
+		var errs *ExecutionErrors
+		require.ErrorAs(t, err, &errs)
+		require.Len(t, errs.Errors, 2)
+		assert.Equal(t, TagNameVar, errs.Errors[0].TagName)
+		assert.Equal(t, 1, errs.Errors[0].Position.Line)
+		assert.Equal(t, 4, errs.Errors[0].Position.Column)
+		assert.Equal(t, "prompty.unknown", errs.Errors[1].TagName)
+		assert.Equal(t, 2, errs.Errors[1].Position.Line)
+
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Contains(t, err.Error(), ErrMsgTagsFailed+" (2)")
+	})
+
+	t.Run("no failures", func(t *testing.T) {
+		output, err := engine.Execute(ctx, `Hi {~prompty.var name="name" /~}`, map[string]any{"name": "Ada"})
+		require.NoError(t, err)
+		assert.Equal(t, "Hi Ada", output)
+	})
+
+	t.Run("collects included templates", func(t *testing.T) {
+		engine := MustNew(WithErrorStrategy(ErrorStrategyCollect))
+		require.NoError(t, engine.RegisterTemplate("footer", `[{~prompty.var name="missing" /~}]`))
+
+		output, err := engine.Execute(ctx, `{~prompty.var name="a" /~}-{~prompty.include template="footer" /~}`, nil)
+		assert.Equal(t, "-[]", output)
+
+		var errs *ExecutionErrors
+		require.ErrorAs(t, err, &errs)
+		require.Len(t, errs.Errors, 2)
+		assert.Equal(t, 1, errs.Errors[1].Position.Line)
+		assert.Equal(t, 2, errs.Errors[1].Position.Column)
+	})
+
+	t.Run("per-tag strategy wins", func(t *testing.T) {
+		output, err := engine.Execute(ctx, `{~prompty.var name="a" onerror="throw" /~}`, nil)
+		assert.Empty(t, output)
+		assert.ErrorIs(t, err, ErrNotFound)
+		var errs *ExecutionErrors
+		assert.False(t, errors.As(err, &errs))
+	})
+
+	t.Run("per-tag collect without a collecting execution", func(t *testing.T) {
+		output, err := MustNew().Execute(ctx, `a{~prompty.var name="x" onerror="collect" default="b" /~}`, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "ab", output)
+	})
+
+	t.Run("batch keeps the output", func(t *testing.T) {
+		results, err := engine.ExecuteBatch(ctx, `{~prompty.var name="a" /~}{~prompty.var name="b" /~}`,
+			[]map[string]any{{"a": "1", "b": "2"}, {"a": "1"}}, BatchOptions{})
+		require.NoError(t, err)
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, "1", results[1].Output)
+		assert.Error(t, results[1].Err)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		_, err := engine.Execute(ctx, `{~prompty.var name="name" /~}`, nil)
+		encoded, jsonErr := json.Marshal(err)
+		require.NoError(t, jsonErr)
+
+		var decoded struct {
+			Errors []struct {
+				Tag    string        `json:"tag"`
+				Line   int           `json:"line"`
+				Column int           `json:"column"`
+				Error  *ErrorDetails `json:"error"`
+			} `json:"errors"`
+		}
+		require.NoError(t, json.Unmarshal(encoded, &decoded))
+		require.Len(t, decoded.Errors, 1)
+		assert.Equal(t, TagNameVar, decoded.Errors[0].Tag)
+		assert.Equal(t, 1, decoded.Errors[0].Line)
+		assert.Equal(t, ErrorKindNotFound, decoded.Errors[0].Error.Kind)
+		assert.Equal(t, "name", decoded.Errors[0].Error.Metadata[MetaKeyPath])
+	})
+
+	t.Run("parse strategy name", func(t *testing.T) {
+		assert.Equal(t, ErrorStrategyCollect, ParseErrorStrategy(ErrorStrategyNameCollect))
+		assert.Equal(t, ErrorStrategyNameCollect, ErrorStrategyCollect.String())
+		assert.True(t, IsValidErrorStrategy(ErrorStrategyNameCollect))
+	})
+}
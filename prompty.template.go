@@ -8,6 +8,7 @@ import (
 
 // Template represents a parsed template that can be executed multiple times.
 type Template struct {
+	name            string // Registered name; empty for templates parsed directly
 	source          string
 	templateBody    string // Template body without config block
 	ast             *internal.RootNode
@@ -16,6 +17,7 @@ type Template struct {
 	engine          TemplateExecutor          // Engine reference for nested template execution
 	prompt          *Prompt                   // Parsed prompt configuration from frontmatter
 	inheritanceInfo *internal.InheritanceInfo // Inheritance info (nil if no extends)
+	selection       *renderSelection          // Part rendered by ExecuteBlock or ExecuteMessagesFiltered (nil for all)
 }
 
 // newTemplateWithConfig creates a new template with prompt configuration (internal use).
@@ -38,7 +40,24 @@ func newTemplateWithConfig(source, templateBody string, ast *internal.RootNode,
 // Execute renders the template with the given data.
 // This is a convenience method that creates a Context from the data map.
 func (t *Template) Execute(ctx context.Context, data map[string]any) (string, error) {
+	return t.ExecuteWithContext(ctx, t.newContext(data))
+}
+
+// newContext creates an execution context for data with the engine's error
+// strategy and field matching policy.
+func (t *Template) newContext(data map[string]any) *Context {
 	execCtx := NewContextWithStrategy(data, t.config.errorStrategy)
+	execCtx.fieldMatch = t.config.fieldMatch
+	return execCtx
+}
+
+// ExecuteWithProvider renders the template with data fetched lazily from
+// provider, using the engine's error strategy and field matching policy. Lookups are memoized for
+// this execution only.
+func (t *Template) ExecuteWithProvider(ctx context.Context, provider DataProvider) (string, error) {
+	execCtx := NewContextWithProvider(provider)
+	execCtx.errorStrat = t.config.errorStrategy
+	execCtx.fieldMatch = t.config.fieldMatch
 	return t.ExecuteWithContext(ctx, execCtx)
 }
 
@@ -46,35 +65,88 @@ func (t *Template) Execute(ctx context.Context, data map[string]any) (string, er
 // Use this when you need more control over the context (e.g., parent scoping).
 // The engine reference is injected into the context for nested template support.
 // If the template uses extends (template inheritance), inheritance is resolved before execution.
+// Execute hooks registered on the engine run around top-level executions.
 func (t *Template) ExecuteWithContext(ctx context.Context, execCtx *Context) (string, error) {
 	// Inject engine reference into context for nested template resolution
 	if t.engine != nil && execCtx.Engine() == nil {
 		execCtx = execCtx.WithEngine(t.engine)
 	}
 
-	// Resolve inheritance if the template extends another template
+	// Run engine hooks once per top-level execution
+	if _, nested := ctx.Value(hookScopeKey{}).(string); !nested && t.config.hooks.hasExecutionHooks() {
+		return t.executeWithHooks(ctx, execCtx)
+	}
+	return t.executeRecorded(ctx, execCtx)
+}
+
+// execute resolves block imports and inheritance and renders the template.
+func (t *Template) execute(ctx context.Context, execCtx *Context) (string, error) {
 	astToExecute := t.ast
-	if t.inheritanceInfo != nil && t.engine != nil {
+	inheritanceInfo := t.inheritanceInfo
+	if t.engine != nil && internal.HasBlockImports(t.ast) {
+		// Expand used blocks before inheritance, so overriding blocks may use them
+		resolvedAST, err := t.resolveBlockImports(ctx)
+		if err != nil {
+			return "", err
+		}
+		astToExecute = resolvedAST
+		if inheritanceInfo != nil {
+			if inheritanceInfo, err = internal.ExtractInheritanceInfo(astToExecute); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	// Resolve inheritance if the template extends another template
+	if inheritanceInfo != nil && t.engine != nil {
 		// Create an adapter that wraps the engine for TemplateSourceResolver interface
-		sourceResolver := &engineSourceAdapter{engine: t.engine}
-		resolver := internal.NewInheritanceResolver(nil, sourceResolver, t.config.maxDepth)
-		resolvedAST, err := resolver.ResolveInheritance(ctx, t.ast, t.inheritanceInfo, 0)
+		sourceResolver := &engineSourceAdapter{engine: t.engine, recorder: recorderFrom(ctx)}
+		resolver := internal.NewInheritanceResolver(nil, sourceResolver, t.config.maxDepth).
+			WithLexerConfig(t.config.lexerConfig())
+		resolvedAST, err := resolver.ResolveInheritance(ctx, astToExecute, inheritanceInfo, 0)
 		if err != nil {
 			return "", err
 		}
 		astToExecute = resolvedAST
 	}
 
+	if t.selection != nil {
+		selected, err := t.selection.apply(astToExecute)
+		if err != nil {
+			return "", err
+		}
+		astToExecute = selected
+	}
+
+	// Collect the failures of the outermost execution, nested ones add theirs
+	if execCtx.errorStrat == ErrorStrategyCollect && internal.ErrorCollectorFrom(ctx) == nil {
+		return t.executeCollecting(ctx, astToExecute, execCtx)
+	}
 	return t.executor.Execute(ctx, astToExecute, execCtx)
 }
 
-// engineSourceAdapter adapts TemplateExecutor to TemplateSourceResolver
+// resolveBlockImports returns the template's AST with the blocks of its
+// imported templates expanded in place of prompty.use tags.
+func (t *Template) resolveBlockImports(ctx context.Context) (*internal.RootNode, error) {
+	sourceResolver := &engineSourceAdapter{engine: t.engine, recorder: recorderFrom(ctx)}
+	return internal.NewBlockImportResolver(sourceResolver, t.config.maxDepth).
+		WithLexerConfig(t.config.lexerConfig()).
+		Resolve(t.ast)
+}
+
+// engineSourceAdapter adapts TemplateExecutor to TemplateSourceResolver,
+// recording the sources it returns if the execution is being recorded.
 type engineSourceAdapter struct {
-	engine TemplateExecutor
+	engine   TemplateExecutor
+	recorder *ExecutionRecorder
 }
 
 func (a *engineSourceAdapter) GetTemplateSource(name string) (string, bool) {
-	return a.engine.GetTemplateSource(name)
+	source, ok := a.engine.GetTemplateSource(name)
+	if ok && a.recorder != nil {
+		a.recorder.addTemplate(name, source)
+	}
+	return source, ok
 }
 
 // Source returns the original template source string (including config block if present).
@@ -143,6 +215,7 @@ func ExtractMessagesFromOutput(output string) []Message {
 			Role:    m.Role,
 			Content: m.Content,
 			Cache:   m.Cache,
+			Parts:   contentPartsFromInternal(m.Parts),
 		}
 	}
 	return messages
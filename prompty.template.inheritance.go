@@ -0,0 +1,252 @@
+package prompty
+
+import (
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// InheritanceBlock describes a block defined at one level of an inheritance chain.
+type InheritanceBlock struct {
+	Name     string
+	Position Position
+	// Overrides reports whether an ancestor defines the block, so this
+	// level's content replaces the ancestor's.
+	Overrides bool
+	// Overridden reports whether a descendant replaces the block. Its content
+	// then renders only where the descendant calls prompty.parent.
+	Overridden bool
+	// ParentCalls lists where the block inserts the ancestor's content with
+	// prompty.parent, in source order.
+	ParentCalls []Position
+}
+
+// CallsParent reports whether the block uses prompty.parent.
+func (b InheritanceBlock) CallsParent() bool {
+	return len(b.ParentCalls) > 0
+}
+
+// InheritanceLevel describes one template of an inheritance chain.
+type InheritanceLevel struct {
+	Name    string // Template name; empty for an unregistered template
+	Extends string // Name of the parent template; empty for the base template
+	// Blocks lists the blocks this level defines, in source order. Levels
+	// that extend a parent contribute only their top-level blocks; the base
+	// template defines the layout, so its nested blocks are listed as well.
+	Blocks []InheritanceBlock
+}
+
+// Block returns the block with the given name defined at this level.
+func (l InheritanceLevel) Block(name string) (InheritanceBlock, bool) {
+	for _, block := range l.Blocks {
+		if block.Name == name {
+			return block, true
+		}
+	}
+	return InheritanceBlock{}, false
+}
+
+// InheritanceTree describes the resolved inheritance chain of a template.
+type InheritanceTree struct {
+	// Levels is ordered from the inspected template to the base template.
+	Levels []InheritanceLevel
+}
+
+// Chain returns the template names of the tree, from the inspected template
+// to the base template.
+func (t *InheritanceTree) Chain() []string {
+	names := make([]string, len(t.Levels))
+	for i, level := range t.Levels {
+		names[i] = level.Name
+	}
+	return names
+}
+
+// RenderedFrom returns the name of the level whose content renders for the
+// named block, i.e. the closest level to the inspected template defining it.
+// It returns false if no level defines the block.
+func (t *InheritanceTree) RenderedFrom(block string) (string, bool) {
+	for _, level := range t.Levels {
+		if _, ok := level.Block(block); ok {
+			return level.Name, true
+		}
+	}
+	return "", false
+}
+
+// InheritanceChain resolves the templates this template extends and returns
+// one level per template, from this template to the base template. Parents
+// are looked up and parsed the same way as during execution. A template
+// without extends yields a single level.
+func (t *Template) InheritanceChain() ([]InheritanceLevel, error) {
+	tree, err := t.inheritanceTree("")
+	if err != nil {
+		return nil, err
+	}
+	return tree.Levels, nil
+}
+
+// ResolveInheritanceTree resolves the inheritance chain of the registered
+// template name. See Template.InheritanceChain.
+func (e *Engine) ResolveInheritanceTree(name string) (*InheritanceTree, error) {
+	tmpl, ok := e.GetTemplate(name)
+	if !ok {
+		return nil, NewTemplateNotFoundError(name)
+	}
+	return tmpl.inheritanceTree(name)
+}
+
+// inheritanceTree resolves the inheritance chain of the template, naming its
+// own level name.
+func (t *Template) inheritanceTree(name string) (*InheritanceTree, error) {
+	maxDepth := t.config.maxDepth
+	if maxDepth <= 0 {
+		maxDepth = internal.DefaultMaxInheritanceDepth
+	}
+
+	roots := []*internal.RootNode{t.ast}
+	levels := []InheritanceLevel{{Name: name}}
+	info := t.inheritanceInfo
+	chain := []string{name}
+
+	for info != nil {
+		parent := info.ParentTemplate
+		levels[len(levels)-1].Extends = parent
+
+		for _, seen := range chain {
+			if seen == parent {
+				return nil, NewInheritanceCycleError(parent, append([]string(nil), chain...))
+			}
+		}
+		if depth := len(levels) - 1; depth > maxDepth {
+			return nil, NewInheritanceDepthError(depth, maxDepth)
+		}
+		if t.engine == nil {
+			return nil, NewEngineNotAvailableError()
+		}
+		source, ok := t.engine.GetTemplateSource(parent)
+		if !ok {
+			return nil, NewTemplateNotFoundError(parent)
+		}
+
+		root, err := t.parseInheritanceLevel(source)
+		if err != nil {
+			return nil, err
+		}
+		if info, err = internal.ExtractInheritanceInfo(root); err != nil {
+			return nil, err
+		}
+
+		roots = append(roots, root)
+		levels = append(levels, InheritanceLevel{Name: parent})
+		chain = append(chain, parent)
+	}
+
+	base := len(levels) - 1
+	for i, root := range roots {
+		levels[i].Blocks = inheritanceBlocks(root.Children, i == base)
+	}
+	markOverrides(levels)
+
+	return &InheritanceTree{Levels: levels}, nil
+}
+
+// parseInheritanceLevel parses a parent template with the engine's delimiters,
+// as the inheritance resolver does during execution.
+func (t *Template) parseInheritanceLevel(source string) (*internal.RootNode, error) {
+	lexerConfig := t.config.lexerConfig()
+	tokens, err := internal.NewLexerWithConfig(source, lexerConfig, nil).Tokenize()
+	if err != nil {
+		return nil, err
+	}
+	return internal.NewParserWithConfig(tokens, source, lexerConfig, nil).Parse()
+}
+
+// markOverrides sets Overrides and Overridden by comparing the block names of
+// each level with its ancestors and descendants.
+func markOverrides(levels []InheritanceLevel) {
+	for i := range levels {
+		for j := range levels[i].Blocks {
+			block := &levels[i].Blocks[j]
+			for k := range levels {
+				if k == i {
+					continue
+				}
+				if _, ok := levels[k].Block(block.Name); !ok {
+					continue
+				}
+				if k > i {
+					block.Overrides = true
+				} else {
+					block.Overridden = true
+				}
+			}
+		}
+	}
+}
+
+// inheritanceBlocks returns the blocks among nodes in source order. With
+// nested set, blocks inside other nodes are included as well.
+func inheritanceBlocks(nodes []internal.Node, nested bool) []InheritanceBlock {
+	var blocks []InheritanceBlock
+	for _, node := range nodes {
+		block, ok := node.(*internal.BlockNode)
+		if ok {
+			blocks = append(blocks, InheritanceBlock{
+				Name:        block.Name,
+				Position:    publicPosition(block.Pos()),
+				ParentCalls: parentCalls(block.Children, nil),
+			})
+		}
+		if nested {
+			blocks = append(blocks, inheritanceBlocks(childNodes(node), true)...)
+		}
+	}
+	return blocks
+}
+
+// parentCalls appends the positions of prompty.parent tags among nodes to
+// calls. Nested blocks are skipped, since they are reported on their own.
+func parentCalls(nodes []internal.Node, calls []Position) []Position {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *internal.BlockNode:
+			continue
+		case *internal.TagNode:
+			if n.Name == TagNameParent {
+				calls = append(calls, publicPosition(n.Pos()))
+				continue
+			}
+		}
+		calls = parentCalls(childNodes(node), calls)
+	}
+	return calls
+}
+
+// childNodes returns the nodes nested directly inside node.
+func childNodes(node internal.Node) []internal.Node {
+	switch n := node.(type) {
+	case *internal.BlockNode:
+		return n.Children
+	case *internal.TagNode:
+		return n.Children
+	case *internal.ForNode:
+		return n.Children
+	case *internal.ConditionalNode:
+		var children []internal.Node
+		for _, branch := range n.Branches {
+			children = append(children, branch.Children...)
+		}
+		return children
+	case *internal.SwitchNode:
+		var children []internal.Node
+		for _, c := range n.Cases {
+			children = append(children, c.Children...)
+		}
+		return children
+	}
+	return nil
+}
+
+// publicPosition converts an internal source position.
+func publicPosition(pos internal.Position) Position {
+	return Position{Offset: pos.Offset, Line: pos.Line, Column: pos.Column}
+}
@@ -0,0 +1,126 @@
+package prompty
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newInheritanceTreeEngine(t *testing.T) *Engine {
+	t.Helper()
+	engine := MustNew()
+	engine.MustRegisterTemplate("base", `{~prompty.block name="header"~}Base header{~/prompty.block~}
+{~prompty.if eval="show"~}{~prompty.block name="body"~}Base body{~/prompty.block~}{~/prompty.if~}
+{~prompty.block name="footer"~}Base footer{~/prompty.block~}`)
+	engine.MustRegisterTemplate("layout", `{~prompty.extends template="base" /~}
+{~prompty.block name="header"~}Layout / {~prompty.parent /~}{~/prompty.block~}
+{~prompty.block name="sidebar"~}Unused{~/prompty.block~}`)
+	engine.MustRegisterTemplate("page", `{~prompty.extends template="layout" /~}
+{~prompty.block name="header"~}Page / {~prompty.parent /~}{~/prompty.block~}
+{~prompty.block name="body"~}Page body{~/prompty.block~}`)
+	return engine
+}
+
+func TestEngine_ResolveInheritanceTree(t *testing.T) {
+	engine := newInheritanceTreeEngine(t)
+
+	tree, err := engine.ResolveInheritanceTree("page")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"page", "layout", "base"}, tree.Chain())
+
+	page, layout, base := tree.Levels[0], tree.Levels[1], tree.Levels[2]
+	assert.Equal(t, "layout", page.Extends)
+	assert.Equal(t, "base", layout.Extends)
+	assert.Empty(t, base.Extends)
+
+	header, ok := page.Block("header")
+	require.True(t, ok)
+	assert.True(t, header.Overrides)
+	assert.False(t, header.Overridden)
+	require.Len(t, header.ParentCalls, 1)
+	assert.Equal(t, 2, header.ParentCalls[0].Line)
+	assert.Equal(t, Position{Offset: 40, Line: 2, Column: 1}, header.Position)
+
+	layoutHeader, _ := layout.Block("header")
+	assert.True(t, layoutHeader.Overrides)
+	assert.True(t, layoutHeader.Overridden)
+	assert.True(t, layoutHeader.CallsParent())
+
+	sidebar, _ := layout.Block("sidebar")
+	assert.False(t, sidebar.Overrides)
+	assert.False(t, sidebar.Overridden)
+
+	// The base lists its nested blocks in source order
+	names := make([]string, 0, len(base.Blocks))
+	for _, block := range base.Blocks {
+		names = append(names, block.Name)
+		assert.True(t, block.Overridden || block.Name == "footer", block.Name)
+		assert.False(t, block.Overrides)
+	}
+	assert.Equal(t, []string{"header", "body", "footer"}, names)
+
+	from, ok := tree.RenderedFrom("footer")
+	require.True(t, ok)
+	assert.Equal(t, "base", from)
+	from, _ = tree.RenderedFrom("body")
+	assert.Equal(t, "page", from)
+	_, ok = tree.RenderedFrom("missing")
+	assert.False(t, ok)
+}
+
+func TestTemplate_InheritanceChain(t *testing.T) {
+	engine := newInheritanceTreeEngine(t)
+
+	t.Run("unregistered child", func(t *testing.T) {
+		tmpl, err := engine.Parse(`{~prompty.extends template="layout" /~}{~prompty.block name="footer"~}Mine{~/prompty.block~}`)
+		require.NoError(t, err)
+
+		levels, err := tmpl.InheritanceChain()
+		require.NoError(t, err)
+		require.Len(t, levels, 3)
+		assert.Empty(t, levels[0].Name)
+		assert.Equal(t, "layout", levels[0].Extends)
+
+		footer, ok := levels[0].Block("footer")
+		require.True(t, ok)
+		assert.True(t, footer.Overrides)
+		assert.False(t, footer.CallsParent())
+	})
+
+	t.Run("template without extends", func(t *testing.T) {
+		tmpl, err := engine.Parse(`Hello {~prompty.block name="greeting"~}there{~/prompty.block~}`)
+		require.NoError(t, err)
+
+		levels, err := tmpl.InheritanceChain()
+		require.NoError(t, err)
+		require.Len(t, levels, 1)
+		assert.Empty(t, levels[0].Extends)
+		require.Len(t, levels[0].Blocks, 1)
+		assert.Equal(t, "greeting", levels[0].Blocks[0].Name)
+	})
+}
+
+func TestEngine_ResolveInheritanceTree_Errors(t *testing.T) {
+	engine := MustNew()
+	engine.MustRegisterTemplate("loop-a", `{~prompty.extends template="loop-b" /~}`)
+	engine.MustRegisterTemplate("loop-b", `{~prompty.extends template="loop-a" /~}`)
+	engine.MustRegisterTemplate("orphan", `{~prompty.extends template="missing" /~}`)
+
+	_, err := engine.ResolveInheritanceTree("nope")
+	assert.ErrorContains(t, err, ErrMsgTemplateNotFound)
+
+	_, err = engine.ResolveInheritanceTree("orphan")
+	assert.ErrorContains(t, err, ErrMsgTemplateNotFound)
+
+	_, err = engine.ResolveInheritanceTree("loop-a")
+	assert.ErrorContains(t, err, ErrMsgInheritanceCycle)
+
+	shallow := MustNew(WithMaxDepth(1))
+	shallow.MustRegisterTemplate("a", `{~prompty.extends template="b" /~}`)
+	shallow.MustRegisterTemplate("b", `{~prompty.extends template="c" /~}`)
+	shallow.MustRegisterTemplate("c", `{~prompty.extends template="d" /~}`)
+	shallow.MustRegisterTemplate("d", `base`)
+	_, err = shallow.ResolveInheritanceTree("a")
+	assert.ErrorContains(t, err, ErrMsgInheritanceDepthExceeded)
+}
@@ -0,0 +1,87 @@
+package prompty
+
+import (
+	"context"
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// renderSelection narrows an execution to part of a template.
+type renderSelection struct {
+	block string          // Render only this block, if set
+	roles map[string]bool // Render only messages with these roles, if set
+}
+
+// apply returns the part of root the selection renders.
+func (s *renderSelection) apply(root *internal.RootNode) (*internal.RootNode, error) {
+	if s.block != "" {
+		block, ok := internal.FindBlock(root.Children, s.block)
+		if !ok {
+			return nil, NewBlockNotFoundError(s.block)
+		}
+		root = &internal.RootNode{Children: []internal.Node{block}}
+	}
+	if s.roles != nil {
+		root = &internal.RootNode{Children: internal.FilterNodes(root.Children, s.dropsMessage)}
+	}
+	return root, nil
+}
+
+// dropsMessage reports whether node is a message of an unselected role.
+func (s *renderSelection) dropsMessage(node internal.Node) bool {
+	tag, ok := node.(*internal.TagNode)
+	if !ok || tag.Name != TagNameMessage {
+		return false
+	}
+	role, _ := tag.Attributes.Get(AttrRole)
+	return !s.roles[strings.ToLower(role)]
+}
+
+// withSelection returns a copy of the template that renders only sel.
+func (t *Template) withSelection(sel *renderSelection) *Template {
+	selected := *t
+	selected.selection = sel
+	return &selected
+}
+
+// ExecuteBlock renders only the prompty.block named name, after resolving
+// inheritance and block imports, so a caller can re-render one block (such
+// as the system prompt) while caching the rest of the output. The block
+// renders with data at the top level: variables set by enclosing tags and
+// loops, and the content around the block, are not rendered. Returns an
+// error with ErrMsgBlockNotFound if the template has no such block.
+func (t *Template) ExecuteBlock(ctx context.Context, name string, data map[string]any) (string, error) {
+	return t.withSelection(&renderSelection{block: name}).Execute(ctx, data)
+}
+
+// ExecuteMessagesFiltered renders the template and extracts the messages
+// with the given roles, like ExecuteAndExtractMessages. prompty.message
+// tags of other roles are skipped without being rendered, so their
+// resolvers and includes do not run; messages produced otherwise (such as
+// by prompty.history) are filtered after rendering. Roles match
+// case-insensitively; without roles, all messages are returned.
+func (t *Template) ExecuteMessagesFiltered(ctx context.Context, data map[string]any, roles ...string) ([]Message, error) {
+	if len(roles) == 0 {
+		return t.ExecuteAndExtractMessages(ctx, data)
+	}
+	sel := &renderSelection{roles: make(map[string]bool, len(roles))}
+	for _, role := range roles {
+		sel.roles[strings.ToLower(role)] = true
+	}
+
+	messages, err := t.withSelection(sel).ExecuteAndExtractMessages(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	filtered := messages[:0]
+	for _, msg := range messages {
+		if sel.roles[strings.ToLower(msg.Role)] {
+			filtered = append(filtered, msg)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil, nil
+	}
+	return filtered, nil
+}
@@ -0,0 +1,115 @@
+package prompty
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingResolver returns output and counts its invocations
+func countingResolver(tagName, output string, calls *atomic.Int32) Resolver {
+	return NewResolverFunc(tagName, func(ctx context.Context, execCtx *Context, attrs Attributes) (string, error) {
+		calls.Add(1)
+		return output, nil
+	}, nil)
+}
+
+func TestTemplate_ExecuteBlock(t *testing.T) {
+	ctx := context.Background()
+	var calls atomic.Int32
+	engine := MustNew()
+	engine.MustRegister(countingResolver("Expensive", "costly", &calls))
+
+	tmpl, err := engine.Parse(`{~Expensive /~}
+{~prompty.if eval="true"~}{~prompty.block name="system"~}You help {~prompty.var name="user" /~}.{~/prompty.block~}{~/prompty.if~}
+{~prompty.block name="footer"~}{~Expensive /~}{~/prompty.block~}`)
+	require.NoError(t, err)
+
+	output, err := tmpl.ExecuteBlock(ctx, "system", map[string]any{"user": "Ana"})
+	require.NoError(t, err)
+	assert.Equal(t, "You help Ana.", output)
+	assert.Equal(t, int32(0), calls.Load())
+
+	output, err = tmpl.ExecuteBlock(ctx, "footer", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "costly", output)
+
+	_, err = tmpl.ExecuteBlock(ctx, "missing", nil)
+	assert.ErrorContains(t, err, ErrMsgBlockNotFound)
+
+	// The template itself still renders in full
+	output, err = tmpl.Execute(ctx, map[string]any{"user": "Ana"})
+	require.NoError(t, err)
+	assert.Equal(t, "costly\nYou help Ana.\ncostly", output)
+}
+
+func TestTemplate_ExecuteBlock_Inheritance(t *testing.T) {
+	engine := MustNew()
+	engine.MustRegisterTemplate("base", `[{~prompty.block name="system"~}Base system{~/prompty.block~}|{~prompty.block name="body"~}Base body{~/prompty.block~}]`)
+	tmpl, err := engine.Parse(`{~prompty.extends template="base" /~}{~prompty.block name="system"~}Child system, {~prompty.parent /~}{~/prompty.block~}`)
+	require.NoError(t, err)
+
+	output, err := tmpl.ExecuteBlock(context.Background(), "system", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Child system, Base system", output)
+
+	output, err = tmpl.ExecuteBlock(context.Background(), "body", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Base body", output)
+}
+
+func TestTemplate_ExecuteMessagesFiltered(t *testing.T) {
+	ctx := context.Background()
+	var calls atomic.Int32
+	engine := MustNew()
+	engine.MustRegister(countingResolver("Retrieve", "docs", &calls))
+
+	tmpl, err := engine.Parse(`{~prompty.message role="system"~}Context: {~Retrieve /~}{~/prompty.message~}
+{~prompty.for item="turn" in="turns"~}{~prompty.message role="user"~}{~prompty.var name="turn" /~}{~/prompty.message~}{~prompty.message role="assistant"~}ok{~/prompty.message~}{~/prompty.for~}
+{~prompty.if eval="final"~}{~prompty.message role="user"~}Last question{~/prompty.message~}{~/prompty.if~}`)
+	require.NoError(t, err)
+	data := map[string]any{"turns": []any{"hi", "more"}, "final": true}
+
+	messages, err := tmpl.ExecuteMessagesFiltered(ctx, data, "USER")
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	assert.Equal(t, "hi", messages[0].Content)
+	assert.Equal(t, "more", messages[1].Content)
+	assert.Equal(t, "Last question", messages[2].Content)
+	for _, msg := range messages {
+		assert.Equal(t, RoleUser, msg.Role)
+	}
+	assert.Equal(t, int32(0), calls.Load())
+
+	messages, err = tmpl.ExecuteMessagesFiltered(ctx, data, "system", "assistant")
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	assert.Equal(t, "Context: docs", messages[0].Content)
+	assert.Equal(t, int32(1), calls.Load())
+
+	all, err := tmpl.ExecuteMessagesFiltered(ctx, data)
+	require.NoError(t, err)
+	assert.Len(t, all, 6)
+
+	none, err := tmpl.ExecuteMessagesFiltered(ctx, data, "tool")
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+func TestTemplate_ExecuteMessagesFiltered_History(t *testing.T) {
+	tmpl, err := MustNew().Parse(`{~prompty.message role="system"~}Be brief{~/prompty.message~}{~prompty.history in="history" /~}`)
+	require.NoError(t, err)
+
+	messages, err := tmpl.ExecuteMessagesFiltered(context.Background(), map[string]any{
+		"history": []any{
+			map[string]any{"role": "user", "content": "hi"},
+			map[string]any{"role": "assistant", "content": "hello"},
+		},
+	}, "assistant")
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "hello", messages[0].Content)
+}
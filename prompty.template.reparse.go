@@ -0,0 +1,88 @@
+package prompty
+
+import (
+	"strings"
+
+	"github.com/itsatony/go-prompty/v2/internal"
+)
+
+// Edit is a text edit of a template source: the Length bytes at Offset are
+// replaced by Text. Offsets are bytes into the full source, including any
+// frontmatter, as editors track them.
+type Edit struct {
+	Offset int
+	Length int
+	Text   string
+}
+
+// Apply returns source with the edit applied.
+func (e Edit) Apply(source string) (string, error) {
+	if e.Offset < 0 || e.Length < 0 || e.Offset+e.Length > len(source) {
+		return "", NewParseError(ErrMsgEditOutOfRange, Position{Offset: e.Offset}, nil)
+	}
+	return source[:e.Offset] + e.Text + source[e.Offset+e.Length:], nil
+}
+
+// Reparse returns the template with edit applied to its source, re-parsing
+// only the top-level nodes around the edit and reusing the rest of the AST.
+// It is meant for editors that re-parse on every keystroke, where parsing
+// large templates in full makes feedback lag. The template itself is not
+// modified, so it remains safe to execute concurrently.
+//
+// Reparse falls back to a full parse, with the same result and errors as
+// Engine.Parse, when the edit touches the frontmatter, when the edited
+// region does not parse on its own (e.g. a block tag was opened or closed),
+// and when the engine has parse hooks, which must see the complete source.
+func (t *Template) Reparse(edit Edit) (*Template, error) {
+	source, err := edit.Apply(t.source)
+	if err != nil {
+		return nil, err
+	}
+	e, ok := t.engine.(*Engine)
+	if !ok {
+		return nil, NewParseError(ErrMsgReparseNoEngine, Position{}, nil)
+	}
+
+	tmpl, ok := t.reparseIncremental(e, edit, source)
+	if !ok {
+		if tmpl, err = e.parseWithHooks(t.name, source); err != nil {
+			return nil, err
+		}
+	} else if e.config.strict {
+		if issues := e.strictIssues(tmpl); len(issues) > 0 {
+			return nil, NewStrictModeError(issues)
+		}
+	}
+	tmpl.name = t.name
+	return tmpl, nil
+}
+
+// reparseIncremental re-parses the body region affected by edit, reporting
+// false if the template must be parsed in full.
+func (t *Template) reparseIncremental(e *Engine, edit Edit, source string) (*Template, bool) {
+	hooks := e.config.hooks
+	if hooks.HasHooks(HookBeforeParse) || hooks.HasHooks(HookAfterParse) {
+		return nil, false
+	}
+
+	// The frontmatter, and what separates it from the body, must be unchanged
+	prefixLen := len(t.source) - len(t.templateBody)
+	if !strings.HasSuffix(t.source, t.templateBody) || edit.Offset < prefixLen {
+		return nil, false
+	}
+	lexerConfig := e.config.lexerConfig()
+	configResult, err := internal.ExtractConfigBlock(source, lexerConfig)
+	if err != nil || len(source)-len(configResult.TemplateBody) != prefixLen {
+		return nil, false
+	}
+	body := configResult.TemplateBody
+
+	bodyEdit := internal.BodyEdit{Start: edit.Offset - prefixLen}
+	bodyEdit.OldEnd = bodyEdit.Start + edit.Length
+	bodyEdit.NewEnd = bodyEdit.Start + len(edit.Text)
+	ast, ok := internal.ReparseIncremental(t.ast, t.templateBody, body, bodyEdit, lexerConfig, e.logger)
+	if !ok {
+		return nil, false
+	}
+	return newTemplateWithConfig(source, body, ast, t.executor, t.config, t.engine, t.prompt), true
+}
@@ -0,0 +1,82 @@
+package prompty
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const reparseSource = `---
+name: reparse
+description: Reparse fixture
+---
+Hello {~prompty.var name="user" default="there" /~}!
+{~prompty.if eval="vip"~}VIP {~prompty.var name="tier" /~}{~prompty.else~}regular{~/prompty.if~}
+{~prompty.comment~}ignored {~ here{~/prompty.comment~}Items: {~prompty.for item="x" in="items"~}[{~prompty.var name="x" /~}]{~/prompty.for~}
+\{~ literal {~prompty.switch eval="mode"~}{~prompty.case value="a"~}A{~/prompty.case~}{~prompty.default~}other{~/prompty.default~}{~/prompty.switch~}
+Bye.`
+
+func TestTemplate_Reparse(t *testing.T) {
+	engine := MustNew()
+	tmpl, err := engine.Parse(reparseSource)
+	require.NoError(t, err)
+
+	// Replace "Bye." at the end: every node before the region is reused
+	offset := len(reparseSource) - len("Bye.")
+	edited, err := tmpl.Reparse(Edit{Offset: offset, Length: 3, Text: "Farewell"})
+	require.NoError(t, err)
+	assert.Equal(t, reparseSource[:offset]+"Farewell.", edited.Source())
+	n := len(tmpl.ast.Children)
+	require.Len(t, edited.ast.Children, n)
+	for i := 0; i < n-2; i++ {
+		assert.Same(t, tmpl.ast.Children[i], edited.ast.Children[i])
+	}
+	assert.NotNil(t, edited.Prompt())
+
+	output, err := edited.Execute(context.Background(), map[string]any{"user": "Ada", "items": []any{1}, "mode": "a"})
+	require.NoError(t, err)
+	assert.Contains(t, output, "Hello Ada!")
+	assert.Contains(t, output, "Farewell.")
+
+	// The original template is unchanged
+	assert.Equal(t, reparseSource, tmpl.Source())
+
+	// Edits that unbalance a block fall back to a full parse and its error
+	open := len(reparseSource) - len("\nBye.")
+	_, err = tmpl.Reparse(Edit{Offset: open, Text: `{~prompty.if eval="x"~}`})
+	assert.Error(t, err)
+
+	_, err = tmpl.Reparse(Edit{Offset: len(reparseSource), Length: 1})
+	assert.ErrorContains(t, err, ErrMsgEditOutOfRange)
+}
+
+// TestTemplate_Reparse_MatchesFullParse applies random edits and checks that
+// every re-parse yields the AST, or the failure, of a full parse.
+func TestTemplate_Reparse_MatchesFullParse(t *testing.T) {
+	engine := MustNew()
+	rng := rand.New(rand.NewSource(1))
+	fragments := []string{"", "x", "\n", "{", "~", "}", "/", "\\", "{~", "~}", "/~}", `{~prompty.var name="y" /~}`, "{~/prompty.if~}", "\n\n  "}
+
+	tmpl, err := engine.Parse(reparseSource)
+	require.NoError(t, err)
+	for i := 0; i < 2000; i++ {
+		source := tmpl.Source()
+		offset := rng.Intn(len(source) + 1)
+		length := rng.Intn(min(4, len(source)-offset) + 1)
+		edit := Edit{Offset: offset, Length: length, Text: fragments[rng.Intn(len(fragments))]}
+
+		edited, err := tmpl.Reparse(edit)
+		newSource, _ := edit.Apply(source)
+		full, fullErr := engine.Parse(newSource)
+		if fullErr != nil {
+			require.Error(t, err, "edit %d %+v", i, edit)
+			continue
+		}
+		require.NoError(t, err, "edit %d %+v", i, edit)
+		require.Equal(t, full.ast, edited.ast, "edit %d %+v", i, edit)
+		tmpl = edited
+	}
+}
@@ -488,3 +488,80 @@ func TestExecuteAndExtractMessages_ReusableTemplate(t *testing.T) {
 	require.Len(t, msgs2, 1)
 	assert.Equal(t, "second", msgs2[0].Content)
 }
+
+func TestExecuteAndExtractMessages_History(t *testing.T) {
+	source := `{~prompty.message role="system"~}Be brief.{~/prompty.message~}` +
+		`{~prompty.history in="history" limit="2" /~}` +
+		`{~prompty.message role="user"~}{~prompty.var name="query" /~}{~/prompty.message~}`
+
+	engine := MustNew()
+	tmpl, err := engine.Parse(source)
+	require.NoError(t, err)
+
+	history := []Message{
+		{Role: RoleUser, Content: "dropped"},
+		{Role: RoleUser, Content: "What is Go?"},
+		{Role: RoleAssistant, Content: "A programming language."},
+	}
+	messages, err := tmpl.ExecuteAndExtractMessages(context.Background(), map[string]any{
+		"history": history,
+		"query":   "Who made it?",
+	})
+	require.NoError(t, err)
+	require.Len(t, messages, 4)
+
+	assert.Equal(t, RoleSystem, messages[0].Role)
+	assert.Equal(t, "What is Go?", messages[1].Content)
+	assert.Equal(t, RoleAssistant, messages[2].Role)
+	assert.Equal(t, "Who made it?", messages[3].Content)
+}
+
+func TestExecuteAndExtractMessages_ContentParts(t *testing.T) {
+	source := `{~prompty.message role="user"~}What is in this chart?` +
+		`{~prompty.image from="chart" detail="low" /~}{~prompty.file name="report.pdf" id="file-1" /~}{~/prompty.message~}`
+
+	engine := MustNew()
+	tmpl, err := engine.Parse(source)
+	require.NoError(t, err)
+
+	messages, err := tmpl.ExecuteAndExtractMessages(context.Background(), map[string]any{
+		"chart": "https://example.com/chart.png",
+	})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+
+	assert.Equal(t, "What is in this chart?", messages[0].Content)
+	assert.Equal(t, []ContentPart{
+		{Type: ContentPartTypeText, Text: "What is in this chart?"},
+		{Type: ContentPartTypeImage, URL: "https://example.com/chart.png", Detail: ImageDetailLow, MediaType: "image/png"},
+		{Type: ContentPartTypeFile, Name: "report.pdf", FileID: "file-1", MediaType: "application/pdf"},
+	}, messages[0].Parts)
+}
+
+func TestExecute_ContentPartOutsideMessage(t *testing.T) {
+	engine := MustNew()
+	_, err := engine.Execute(context.Background(), `{~prompty.image url="https://example.com/a.png" /~}`, nil)
+	assert.Error(t, err)
+}
+
+func TestExecuteAndExtractMessages_CacheEphemeral(t *testing.T) {
+	source := `{~prompty.message role="system" cache="ephemeral"~}Rules{~/prompty.message~}` +
+		`{~prompty.message role="user"~}Context{~prompty.cache_breakpoint /~} Question{~/prompty.message~}` +
+		`{~prompty.cache_breakpoint /~}`
+
+	engine := MustNew()
+	tmpl, err := engine.Parse(source)
+	require.NoError(t, err)
+
+	messages, err := tmpl.ExecuteAndExtractMessages(context.Background(), nil)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+
+	assert.True(t, messages[0].Cache)
+	assert.Nil(t, messages[0].Parts)
+	assert.Equal(t, "Context Question", messages[1].Content)
+	assert.Equal(t, []ContentPart{
+		{Type: ContentPartTypeText, Text: "Context", Cache: true},
+		{Type: ContentPartTypeText, Text: "Question"},
+	}, messages[1].Parts)
+}
@@ -0,0 +1,248 @@
+package prompty
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ModelPricing is the price of one model in USD per million tokens.
+type ModelPricing struct {
+	// Provider the price applies to; empty matches any provider
+	Provider string `json:"provider,omitempty"`
+
+	// Model name or prefix; the longest matching prefix wins, so
+	// "claude-sonnet-4" prices "claude-sonnet-4-5-20250929"
+	Model string `json:"model"`
+
+	// InputPerMillion is the price of one million input tokens
+	InputPerMillion float64 `json:"input_per_million"`
+
+	// OutputPerMillion is the price of one million output tokens
+	OutputPerMillion float64 `json:"output_per_million"`
+}
+
+// PricingTable is the JSON document read by CostEstimator.LoadJSON.
+type PricingTable struct {
+	Models []ModelPricing `json:"models"`
+}
+
+// CostBreakdown is the estimated cost of one request to a model, in USD.
+type CostBreakdown struct {
+	Provider     string
+	Model        string
+	InputTokens  int
+	OutputTokens int
+	InputCost    float64
+	OutputCost   float64
+	TotalCost    float64
+}
+
+// Cost estimation constants
+const (
+	// TokensPerMillion is the pricing unit of ModelPricing
+	TokensPerMillion = 1_000_000.0
+)
+
+// DefaultModelPricing is the built-in pricing table of NewCostEstimator
+// (list prices in USD per million tokens, as of 2025). Prices change; load
+// current ones with CostEstimator.LoadJSON.
+var DefaultModelPricing = []ModelPricing{
+	{Provider: ProviderOpenAI, Model: "gpt-4o", InputPerMillion: 2.50, OutputPerMillion: 10.00},
+	{Provider: ProviderOpenAI, Model: "gpt-4o-mini", InputPerMillion: 0.15, OutputPerMillion: 0.60},
+	{Provider: ProviderOpenAI, Model: "gpt-4.1", InputPerMillion: 2.00, OutputPerMillion: 8.00},
+	{Provider: ProviderOpenAI, Model: "gpt-4.1-mini", InputPerMillion: 0.40, OutputPerMillion: 1.60},
+	{Provider: ProviderOpenAI, Model: "gpt-4.1-nano", InputPerMillion: 0.10, OutputPerMillion: 0.40},
+	{Provider: ProviderOpenAI, Model: "o3", InputPerMillion: 2.00, OutputPerMillion: 8.00},
+	{Provider: ProviderOpenAI, Model: "o4-mini", InputPerMillion: 1.10, OutputPerMillion: 4.40},
+	{Provider: ProviderAnthropic, Model: "claude-opus-4", InputPerMillion: 15.00, OutputPerMillion: 75.00},
+	{Provider: ProviderAnthropic, Model: "claude-sonnet-4", InputPerMillion: 3.00, OutputPerMillion: 15.00},
+	{Provider: ProviderAnthropic, Model: "claude-3-7-sonnet", InputPerMillion: 3.00, OutputPerMillion: 15.00},
+	{Provider: ProviderAnthropic, Model: "claude-3-5-haiku", InputPerMillion: 0.80, OutputPerMillion: 4.00},
+	{Provider: ProviderAnthropic, Model: "claude-haiku-4", InputPerMillion: 1.00, OutputPerMillion: 5.00},
+	{Provider: ProviderGemini, Model: "gemini-2.5-pro", InputPerMillion: 1.25, OutputPerMillion: 10.00},
+	{Provider: ProviderGemini, Model: "gemini-2.5-flash", InputPerMillion: 0.30, OutputPerMillion: 2.50},
+	{Provider: ProviderMistral, Model: "mistral-large", InputPerMillion: 2.00, OutputPerMillion: 6.00},
+	{Provider: ProviderMistral, Model: "mistral-small", InputPerMillion: 0.10, OutputPerMillion: 0.30},
+}
+
+// pricingProviderAliases maps providers to the provider whose prices they
+// share.
+var pricingProviderAliases = map[string]string{
+	ProviderAzure:  ProviderOpenAI,
+	ProviderGoogle: ProviderGemini,
+	ProviderVertex: ProviderGemini,
+}
+
+// CostEstimator prices requests from a table of model prices. It is safe
+// for concurrent use.
+type CostEstimator struct {
+	mu      sync.RWMutex
+	pricing map[string]ModelPricing // keyed by provider + "/" + model
+}
+
+var (
+	defaultCostEstimator     *CostEstimator
+	defaultCostEstimatorOnce sync.Once
+)
+
+// NewCostEstimator creates a cost estimator with DefaultModelPricing.
+func NewCostEstimator() *CostEstimator {
+	c := &CostEstimator{pricing: make(map[string]ModelPricing, len(DefaultModelPricing))}
+	for _, p := range DefaultModelPricing {
+		c.SetPricing(p)
+	}
+	return c
+}
+
+// DefaultCostEstimator returns the estimator shared by engines without
+// WithCostEstimator. Prices loaded into it apply to all of them.
+func DefaultCostEstimator() *CostEstimator {
+	defaultCostEstimatorOnce.Do(func() {
+		defaultCostEstimator = NewCostEstimator()
+	})
+	return defaultCostEstimator
+}
+
+// SetPricing adds or replaces the price of a model.
+func (c *CostEstimator) SetPricing(pricing ModelPricing) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pricing[pricingKey(pricing.Provider, pricing.Model)] = pricing
+}
+
+// LoadJSON adds or replaces prices from a PricingTable document:
+//
+//	{"models": [{"provider": "openai", "model": "gpt-4o", "input_per_million": 2.5, "output_per_million": 10}]}
+func (c *CostEstimator) LoadJSON(r io.Reader) error {
+	var table PricingTable
+	if err := json.NewDecoder(r).Decode(&table); err != nil {
+		return NewCostPricingError("", err)
+	}
+	for _, p := range table.Models {
+		if p.Model == "" || p.InputPerMillion < 0 || p.OutputPerMillion < 0 {
+			return NewCostPricingError(p.Model, nil)
+		}
+	}
+	for _, p := range table.Models {
+		c.SetPricing(p)
+	}
+	return nil
+}
+
+// Models returns the price table, sorted by provider and model.
+func (c *CostEstimator) Models() []ModelPricing {
+	c.mu.RLock()
+	models := make([]ModelPricing, 0, len(c.pricing))
+	for _, p := range c.pricing {
+		models = append(models, p)
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(models, func(i, j int) bool {
+		return pricingKey(models[i].Provider, models[i].Model) < pricingKey(models[j].Provider, models[j].Model)
+	})
+	return models
+}
+
+// Pricing returns the price of the longest model prefix matching model,
+// preferring entries of provider (or its pricing alias, e.g. azure uses
+// openai prices) over provider-less entries.
+func (c *CostEstimator) Pricing(provider, model string) (ModelPricing, bool) {
+	provider = strings.ToLower(provider)
+	if alias, ok := pricingProviderAliases[provider]; ok {
+		provider = alias
+	}
+	model = strings.ToLower(model)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var best ModelPricing
+	found := false
+	for _, p := range c.pricing {
+		if p.Provider != "" && provider != "" && !strings.EqualFold(p.Provider, provider) {
+			continue
+		}
+		if !strings.HasPrefix(model, strings.ToLower(p.Model)) {
+			continue
+		}
+		if !found || len(p.Model) > len(best.Model) || (len(p.Model) == len(best.Model) && p.Provider != "") {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
+
+// EstimateCost prices a request of inputTokens and outputTokens to the model
+// of config, whose provider is detected with GetEffectiveProvider.
+func (c *CostEstimator) EstimateCost(config *ExecutionConfig, inputTokens, outputTokens int) (*CostBreakdown, error) {
+	provider := config.GetEffectiveProvider()
+	model := config.GetModel()
+	pricing, ok := c.Pricing(provider, model)
+	if !ok {
+		return nil, NewCostUnknownModelError(provider, model)
+	}
+
+	inputCost := float64(inputTokens) / TokensPerMillion * pricing.InputPerMillion
+	outputCost := float64(outputTokens) / TokensPerMillion * pricing.OutputPerMillion
+	return &CostBreakdown{
+		Provider:     provider,
+		Model:        model,
+		InputTokens:  inputTokens,
+		OutputTokens: outputTokens,
+		InputCost:    inputCost,
+		OutputCost:   outputCost,
+		TotalCost:    inputCost + outputCost,
+	}, nil
+}
+
+// EstimateCost renders the template and prices it with the model of its
+// execution config. Input tokens are estimated from the rendered messages
+// (or output) for the provider's tokenizer family; output tokens are the
+// configured max_tokens, making the estimate an upper bound, or 0 if unset.
+// Prices come from WithCostEstimator or DefaultCostEstimator.
+func (t *Template) EstimateCost(ctx context.Context, data map[string]any) (*CostBreakdown, error) {
+	if t.prompt == nil || t.prompt.Execution == nil {
+		return nil, NewCostNoExecutionError()
+	}
+	output, err := t.Execute(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Message markers are not sent to the model; count the contents
+	text := output
+	if messages := ExtractMessagesFromOutput(output); len(messages) > 0 {
+		contents := make([]string, len(messages))
+		for i, msg := range messages {
+			contents[i] = msg.Content
+		}
+		text = strings.Join(contents, "\n")
+	}
+
+	config := t.prompt.Execution
+	estimate := EstimateTokens(text)
+	inputTokens := estimate.EstimatedGeneric
+	switch config.GetEffectiveProvider() {
+	case ProviderOpenAI, ProviderAzure:
+		inputTokens = estimate.EstimatedGPT
+	case ProviderAnthropic:
+		inputTokens = estimate.EstimatedClaude
+	}
+	outputTokens, _ := config.GetMaxTokens()
+
+	estimator := DefaultCostEstimator()
+	if t.config != nil && t.config.costEstimator != nil {
+		estimator = t.config.costEstimator
+	}
+	return estimator.EstimateCost(config, inputTokens, outputTokens)
+}
+
+// pricingKey returns the table key of a model price.
+func pricingKey(provider, model string) string {
+	return strings.ToLower(provider) + "/" + strings.ToLower(model)
+}
@@ -0,0 +1,83 @@
+package prompty
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCostEstimator_Pricing(t *testing.T) {
+	c := NewCostEstimator()
+
+	p, ok := c.Pricing(ProviderAnthropic, "claude-sonnet-4-5-20250929")
+	require.True(t, ok)
+	assert.Equal(t, "claude-sonnet-4", p.Model)
+
+	p, ok = c.Pricing(ProviderOpenAI, "gpt-4o-mini-2024-07-18")
+	require.True(t, ok)
+	assert.Equal(t, "gpt-4o-mini", p.Model, "the longest prefix wins")
+
+	p, ok = c.Pricing(ProviderAzure, "gpt-4o")
+	require.True(t, ok, "azure uses openai prices")
+	assert.Equal(t, ProviderOpenAI, p.Provider)
+
+	_, ok = c.Pricing(ProviderAnthropic, "gpt-4o")
+	assert.False(t, ok)
+}
+
+func TestCostEstimator_EstimateCost(t *testing.T) {
+	c := NewCostEstimator()
+	config := &ExecutionConfig{Provider: ProviderAnthropic, Model: "claude-sonnet-4"}
+
+	cost, err := c.EstimateCost(config, 1_000_000, 100_000)
+	require.NoError(t, err)
+	assert.InDelta(t, 3.0, cost.InputCost, 1e-9)
+	assert.InDelta(t, 1.5, cost.OutputCost, 1e-9)
+	assert.InDelta(t, 4.5, cost.TotalCost, 1e-9)
+
+	_, err = c.EstimateCost(&ExecutionConfig{Provider: ProviderVLLM, Model: "my-llama"}, 10, 10)
+	assert.ErrorContains(t, err, ErrMsgCostUnknownModel)
+}
+
+func TestCostEstimator_LoadJSON(t *testing.T) {
+	c := NewCostEstimator()
+	err := c.LoadJSON(strings.NewReader(`{"models": [
+		{"model": "my-llama", "input_per_million": 0.2, "output_per_million": 0.4},
+		{"provider": "anthropic", "model": "claude-sonnet-4", "input_per_million": 2, "output_per_million": 10}
+	]}`))
+	require.NoError(t, err)
+
+	p, ok := c.Pricing(ProviderVLLM, "my-llama-3-8b")
+	require.True(t, ok, "provider-less prices match any provider")
+	assert.Equal(t, 0.2, p.InputPerMillion)
+	p, _ = c.Pricing(ProviderAnthropic, "claude-sonnet-4")
+	assert.Equal(t, 2.0, p.InputPerMillion)
+	assert.Len(t, c.Models(), len(DefaultModelPricing)+1)
+
+	assert.Error(t, c.LoadJSON(strings.NewReader(`{"models": [{"model": ""}]}`)))
+	assert.Error(t, c.LoadJSON(strings.NewReader(`not json`)))
+}
+
+func TestTemplate_EstimateCost(t *testing.T) {
+	estimator := NewCostEstimator()
+	estimator.SetPricing(ModelPricing{Model: "test-model", InputPerMillion: 1_000_000, OutputPerMillion: 2_000_000})
+
+	engine := MustNew(WithCostEstimator(estimator))
+	tmpl, err := engine.Parse("---\nname: priced\ndescription: d\nexecution:\n  provider: openai\n  model: test-model\n  max_tokens: 10\n---\n" +
+		`{~prompty.message role="user"~}{~prompty.var name="q" /~}{~/prompty.message~}`)
+	require.NoError(t, err)
+
+	cost, err := tmpl.EstimateCost(context.Background(), map[string]any{"q": "12345678"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, cost.InputTokens, "message markers are not counted")
+	assert.Equal(t, 10, cost.OutputTokens)
+	assert.InDelta(t, 22.0, cost.TotalCost, 1e-9)
+
+	plain, err := engine.Parse("no config")
+	require.NoError(t, err)
+	_, err = plain.EstimateCost(context.Background(), nil)
+	assert.ErrorContains(t, err, ErrMsgCostNoExecution)
+}
@@ -0,0 +1,189 @@
+package prompty
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// MCP manifest keys
+const (
+	mcpKeyResult       = "result"
+	mcpKeyServerInfo   = "serverInfo"
+	mcpKeyName         = "name"
+	mcpKeyTitle        = "title"
+	mcpKeyDescription  = "description"
+	mcpKeyURL          = "url"
+	mcpKeyTransport    = "transport"
+	mcpKeyType         = "type"
+	mcpKeyRemotes      = "remotes"
+	mcpKeyTools        = "tools"
+	mcpKeyInputSchema  = "inputSchema"
+	mcpKeyOutputSchema = "outputSchema"
+)
+
+// ToolsFromMCPManifest reads the tools of an MCP server from its manifest
+// and returns them as a tools configuration. data is JSON in one of these
+// shapes:
+//   - a tools/list result ({"tools": [...]}), optionally wrapped in a
+//     JSON-RPC response ({"result": {"tools": [...]}})
+//   - a capability manifest combining an initialize result's serverInfo
+//     (or a top-level name), url, transport or MCP registry remotes, and
+//     the tools list
+//
+// The configuration holds one MCPServer listing the tool names, and a
+// FunctionDef per tool carrying its description (or title), inputSchema and
+// outputSchema, so catalogs and function calling describe the tools as the
+// server does. The server URL is empty when the manifest has none; set it
+// before validating the configuration. Use MergeMCPManifest to refresh an
+// existing configuration.
+func ToolsFromMCPManifest(data []byte) (*ToolsConfig, error) {
+	var manifest map[string]any
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, NewToolImportError(ErrMsgToolImportInvalid, "")
+	}
+	if result, ok := manifest[mcpKeyResult].(map[string]any); ok {
+		manifest = result
+	}
+	tools, hasTools := manifest[mcpKeyTools].([]any)
+	if !hasTools {
+		return nil, NewToolImportError(ErrMsgToolImportInvalid, mcpKeyTools)
+	}
+
+	server := &MCPServer{Tools: make([]string, 0, len(tools))}
+	server.Name, _ = manifest[mcpKeyName].(string)
+	if info, ok := manifest[mcpKeyServerInfo].(map[string]any); ok {
+		if name, _ := info[mcpKeyName].(string); name != "" {
+			server.Name = name
+		}
+	}
+	server.URL, _ = manifest[mcpKeyURL].(string)
+	server.Transport = mcpTransport(manifest[mcpKeyTransport])
+	if remotes, ok := manifest[mcpKeyRemotes].([]any); ok && len(remotes) > 0 && server.URL == "" {
+		if remote, ok := remotes[0].(map[string]any); ok {
+			server.URL, _ = remote[mcpKeyURL].(string)
+			if server.Transport == "" {
+				server.Transport, _ = remote[mcpKeyType].(string)
+			}
+		}
+	}
+
+	config := &ToolsConfig{MCPServers: []*MCPServer{server}}
+	for i, entry := range tools {
+		fn, err := mcpFunction(entry, i)
+		if err != nil {
+			return nil, err
+		}
+		server.Tools = append(server.Tools, fn.Name)
+		config.Functions = append(config.Functions, fn)
+	}
+	return config, nil
+}
+
+// MergeMCPManifest updates the configuration from a configuration read by
+// ToolsFromMCPManifest. Servers are matched by name: a matching server
+// takes the imported tool list, and the URL and transport when the manifest
+// has them; other servers are added. Functions with an imported tool's name
+// are replaced; tools the server no longer lists are removed from the
+// functions along with the server's old tool list.
+func (tc *ToolsConfig) MergeMCPManifest(imported *ToolsConfig) {
+	if tc == nil || imported == nil {
+		return
+	}
+
+	stale := make(map[string]bool)
+	for _, srv := range imported.MCPServers {
+		var existing *MCPServer
+		for _, candidate := range tc.MCPServers {
+			if candidate != nil && candidate.Name == srv.Name {
+				existing = candidate
+				break
+			}
+		}
+		if existing == nil {
+			clone := *srv
+			clone.Tools = append([]string(nil), srv.Tools...)
+			tc.MCPServers = append(tc.MCPServers, &clone)
+			continue
+		}
+		for _, name := range existing.Tools {
+			stale[name] = true
+		}
+		existing.Tools = append([]string(nil), srv.Tools...)
+		if srv.URL != "" {
+			existing.URL = srv.URL
+		}
+		if srv.Transport != "" {
+			existing.Transport = srv.Transport
+		}
+	}
+
+	replacements := make(map[string]*FunctionDef, len(imported.Functions))
+	for _, fn := range imported.Functions {
+		replacements[fn.Name] = fn
+		delete(stale, fn.Name)
+	}
+	functions := make([]*FunctionDef, 0, len(tc.Functions)+len(imported.Functions))
+	for _, fn := range tc.Functions {
+		if fn == nil || stale[fn.Name] {
+			continue
+		}
+		if replacement, ok := replacements[fn.Name]; ok {
+			functions = append(functions, cloneFunctionDef(replacement))
+			delete(replacements, fn.Name)
+			continue
+		}
+		functions = append(functions, fn)
+	}
+	for _, fn := range imported.Functions {
+		if _, ok := replacements[fn.Name]; ok {
+			functions = append(functions, cloneFunctionDef(fn))
+		}
+	}
+	tc.Functions = functions
+}
+
+// mcpFunction converts one tools/list entry, which may also be a bare
+// tool name.
+func mcpFunction(entry any, index int) (*FunctionDef, error) {
+	if name, ok := entry.(string); ok && name != "" {
+		return &FunctionDef{Name: name}, nil
+	}
+	tool, _ := entry.(map[string]any)
+	name, _ := tool[mcpKeyName].(string)
+	if name == "" {
+		return nil, NewToolImportError(ErrMsgToolImportUnnamed, mcpKeyTools+"["+strconv.Itoa(index)+"]")
+	}
+
+	fn := &FunctionDef{Name: name}
+	fn.Description, _ = tool[mcpKeyDescription].(string)
+	if fn.Description == "" {
+		fn.Description, _ = tool[mcpKeyTitle].(string)
+	}
+	if schema, ok := tool[mcpKeyInputSchema].(map[string]any); ok {
+		fn.Parameters = schema
+	}
+	if schema, ok := tool[mcpKeyOutputSchema].(map[string]any); ok {
+		fn.Returns = schema
+	}
+	return fn, nil
+}
+
+// mcpTransport reads a transport given as a string or as {"type": ...}.
+func mcpTransport(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]any:
+		transport, _ := v[mcpKeyType].(string)
+		return transport
+	}
+	return ""
+}
+
+// cloneFunctionDef returns a deep copy of a function definition.
+func cloneFunctionDef(fn *FunctionDef) *FunctionDef {
+	clone := *fn
+	clone.Parameters = copySchema(fn.Parameters)
+	clone.Returns = copySchema(fn.Returns)
+	return &clone
+}
@@ -0,0 +1,107 @@
+package prompty
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMCPManifest = `{
+  "serverInfo": {"name": "docs", "version": "1.2.0"},
+  "remotes": [{"type": "streamable-http", "url": "https://mcp.example.com/docs"}],
+  "tools": [
+    {
+      "name": "search_docs",
+      "title": "Search",
+      "description": "Search the documentation",
+      "inputSchema": {"type": "object", "properties": {"query": {"type": "string"}}, "required": ["query"]},
+      "outputSchema": {"type": "array"}
+    },
+    {"name": "get_page", "title": "Get page"}
+  ]
+}`
+
+func TestToolsFromMCPManifest(t *testing.T) {
+	config, err := ToolsFromMCPManifest([]byte(testMCPManifest))
+	require.NoError(t, err)
+
+	require.Len(t, config.MCPServers, 1)
+	assert.Equal(t, &MCPServer{
+		Name:      "docs",
+		URL:       "https://mcp.example.com/docs",
+		Transport: "streamable-http",
+		Tools:     []string{"search_docs", "get_page"},
+	}, config.MCPServers[0])
+
+	require.Len(t, config.Functions, 2)
+	search := config.Functions[0]
+	assert.Equal(t, "Search the documentation", search.Description)
+	assert.Equal(t, []any{"query"}, search.Parameters[SchemaKeyRequired])
+	assert.Equal(t, SchemaTypeArray, search.Returns[SchemaKeyType])
+	assert.Equal(t, "Get page", config.Functions[1].Description, "title used without a description")
+	assert.NoError(t, config.Validate())
+}
+
+func TestToolsFromMCPManifest_ToolsList(t *testing.T) {
+	config, err := ToolsFromMCPManifest([]byte(`{"jsonrpc": "2.0", "id": 1, "result": {"tools": [{"name": "ping"}, "echo"]}}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ping", "echo"}, config.MCPServers[0].Tools)
+	assert.Empty(t, config.MCPServers[0].Name)
+	assert.Empty(t, config.MCPServers[0].URL)
+}
+
+func TestToolsFromMCPManifest_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"not json", "tools: []", ErrMsgToolImportInvalid},
+		{"no tools", `{"serverInfo": {"name": "docs"}}`, ErrMsgToolImportInvalid},
+		{"unnamed tool", `{"tools": [{"description": "x"}]}`, ErrMsgToolImportUnnamed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ToolsFromMCPManifest([]byte(tt.data))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestToolsConfig_MergeMCPManifest(t *testing.T) {
+	config := &ToolsConfig{
+		Functions: []*FunctionDef{
+			{Name: "local_tool", Description: "Kept"},
+			{Name: "search_docs", Description: "Outdated"},
+			{Name: "removed_tool", Description: "No longer served"},
+		},
+		MCPServers: []*MCPServer{
+			{Name: "docs", URL: "http://localhost:8080", Transport: "sse", Tools: []string{"search_docs", "removed_tool"}},
+		},
+	}
+	imported, err := ToolsFromMCPManifest([]byte(`{"serverInfo": {"name": "docs"}, "tools": [
+		{"name": "search_docs", "description": "Search the documentation"},
+		{"name": "get_page", "description": "Fetch a page"}
+	]}`))
+	require.NoError(t, err)
+
+	config.MergeMCPManifest(imported)
+
+	require.Len(t, config.MCPServers, 1)
+	assert.Equal(t, &MCPServer{
+		Name:      "docs",
+		URL:       "http://localhost:8080",
+		Transport: "sse",
+		Tools:     []string{"search_docs", "get_page"},
+	}, config.MCPServers[0], "hand-set URL and transport kept")
+
+	names := make([]string, 0, len(config.Functions))
+	for _, fn := range config.Functions {
+		names = append(names, fn.Name)
+	}
+	assert.Equal(t, []string{"local_tool", "search_docs", "get_page"}, names)
+	assert.Equal(t, "Search the documentation", config.Functions[1].Description)
+	assert.NoError(t, config.Validate())
+}
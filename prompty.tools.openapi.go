@@ -0,0 +1,460 @@
+package prompty
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPI document keys and values
+const (
+	openAPIKeyVersion     = "openapi"
+	openAPIKeyInfo        = "info"
+	openAPIKeyTitle       = "title"
+	openAPIKeyInfoVersion = "version"
+	openAPIKeyPaths       = "paths"
+	openAPIKeyOperationID = "operationId"
+	openAPIKeySummary     = "summary"
+	openAPIKeyParameters  = "parameters"
+	openAPIKeyRequestBody = "requestBody"
+	openAPIKeyResponses   = "responses"
+	openAPIKeyContent     = "content"
+	openAPIKeyRef         = "$ref"
+	openAPIKeyIn          = "in"
+	openAPIKeyName        = "name"
+	openAPIKeyTags        = "tags"
+	openAPIKeyDeprecated  = "deprecated"
+	openAPIKeyNullable    = "nullable"
+
+	openAPIVersionPrefix = "3."
+	openAPIExportVersion = "3.1.0"
+	openAPIRefPrefix     = "#/"
+	openAPIMediaJSON     = "application/json"
+	openAPIMediaJSONPart = "json"
+	openAPIParamInPath   = "path"
+	openAPIParamInHeader = "header"
+	openAPIBodyProperty  = "body"
+	openAPIStatusOK      = "200"
+	openAPIStatusPrefix  = "2"
+	openAPITypeNull      = "null"
+	openAPIMethodPost    = "post"
+	openAPINameSeparator = "_"
+	openAPIMaxNameLength = 64
+)
+
+var (
+	// openAPIMethods are the operation keys of a path item, in import order.
+	openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+	// openAPIIgnoredHeaders are header parameters that OpenAPI itself
+	// ignores, as they are controlled by the HTTP client.
+	openAPIIgnoredHeaders = map[string]bool{"accept": true, "authorization": true, "content-type": true}
+	// openAPISchemaOnlyKeys are OpenAPI schema keywords without meaning for
+	// tool parameters; they are dropped from imported schemas.
+	openAPISchemaOnlyKeys = map[string]bool{
+		"example": true, "xml": true, "externalDocs": true, "discriminator": true,
+		"readOnly": true, "writeOnly": true, openAPIKeyDeprecated: true,
+	}
+	// openAPISchemaMaps are schema keywords whose values map names to schemas.
+	openAPISchemaMaps = map[string]bool{
+		SchemaKeyProperties: true, "patternProperties": true, "$defs": true, "definitions": true,
+	}
+	// openAPINameInvalid matches characters not allowed in tool names.
+	openAPINameInvalid = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+)
+
+// OpenAPIOptions selects and shapes the tools created by ToolsFromOpenAPI.
+type OpenAPIOptions struct {
+	// Operations limits the import to these operation IDs (or derived
+	// names); all operations are imported when empty.
+	Operations []string
+	// Tags limits the import to operations carrying one of these tags.
+	Tags []string
+	// IncludeDeprecated imports operations marked deprecated.
+	IncludeDeprecated bool
+	// Strict sets FunctionDef.Strict on every imported function.
+	Strict bool
+}
+
+// ToolsFromOpenAPI converts the operations of an OpenAPI 3 document, in
+// JSON or YAML, into function definitions for ToolsConfig.Functions.
+//
+// The function name is the operationId, or the method and path when there
+// is none, restricted to the characters tool APIs accept. Path, query,
+// header and cookie parameters become properties of the parameters schema;
+// the properties of a JSON object request body are merged in, and any
+// other request body becomes a "body" property. The JSON schema of the
+// first 2xx response becomes Returns. References into the document are
+// inlined, with recursive references cut off as plain objects.
+func ToolsFromOpenAPI(spec []byte, opts *OpenAPIOptions) ([]*FunctionDef, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(spec, &doc); err != nil || doc == nil {
+		return nil, NewToolImportError(ErrMsgToolImportInvalid, "")
+	}
+	version, _ := doc[openAPIKeyVersion].(string)
+	if !strings.HasPrefix(version, openAPIVersionPrefix) {
+		return nil, NewToolImportError(ErrMsgToolImportVersion, openAPIKeyVersion)
+	}
+	if opts == nil {
+		opts = &OpenAPIOptions{}
+	}
+
+	r := &openAPIResolver{doc: doc, active: map[string]bool{}}
+	paths, _ := doc[openAPIKeyPaths].(map[string]any)
+	functions := make([]*FunctionDef, 0)
+	names := make(map[string]string)
+
+	for _, path := range sortedKeys(paths) {
+		item, _ := r.object(paths[path]).(map[string]any)
+		for _, method := range openAPIMethods {
+			op, ok := item[method].(map[string]any)
+			if !ok || !opts.includes(op, method, path) {
+				continue
+			}
+			fn := r.function(op, method, path, item)
+			if r.err != nil {
+				return nil, r.err
+			}
+			fn.Strict = opts.Strict
+			location := path + " " + method
+			if previous, ok := names[fn.Name]; ok {
+				return nil, NewToolImportError(ErrMsgToolImportDuplicateName, previous+", "+location)
+			}
+			names[fn.Name] = location
+			functions = append(functions, fn)
+		}
+	}
+	return functions, nil
+}
+
+// ToolsToOpenAPI describes function definitions as an OpenAPI 3.1 JSON
+// document, the reverse of ToolsFromOpenAPI: each function is a POST
+// operation on "/<name>" whose JSON request body takes the parameters and
+// whose 200 response returns Returns. title and version fill the info
+// section.
+func ToolsToOpenAPI(functions []*FunctionDef, title, version string) ([]byte, error) {
+	paths := make(map[string]any, len(functions))
+	for i, fn := range functions {
+		if fn == nil || fn.Name == "" {
+			return nil, NewToolImportError(ErrMsgToolExportNoName, strconv.Itoa(i))
+		}
+		op := map[string]any{openAPIKeyOperationID: fn.Name}
+		if fn.Description != "" {
+			op[SchemaKeyDescription] = fn.Description
+		}
+		if fn.Parameters != nil {
+			op[openAPIKeyRequestBody] = map[string]any{
+				SchemaKeyRequired: true,
+				openAPIKeyContent: map[string]any{
+					openAPIMediaJSON: map[string]any{SchemaKeySchema: copySchema(fn.Parameters)},
+				},
+			}
+		}
+		response := map[string]any{SchemaKeyDescription: fn.Name + " result"}
+		if fn.Returns != nil {
+			response[openAPIKeyContent] = map[string]any{
+				openAPIMediaJSON: map[string]any{SchemaKeySchema: copySchema(fn.Returns)},
+			}
+		}
+		op[openAPIKeyResponses] = map[string]any{openAPIStatusOK: response}
+		paths["/"+fn.Name] = map[string]any{openAPIMethodPost: op}
+	}
+
+	doc := map[string]any{
+		openAPIKeyVersion: openAPIExportVersion,
+		openAPIKeyInfo:    map[string]any{openAPIKeyTitle: title, openAPIKeyInfoVersion: version},
+		openAPIKeyPaths:   paths,
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// includes reports whether an operation passes the options' filters.
+func (o *OpenAPIOptions) includes(op map[string]any, method, path string) bool {
+	if deprecated, _ := op[openAPIKeyDeprecated].(bool); deprecated && !o.IncludeDeprecated {
+		return false
+	}
+	if len(o.Operations) > 0 {
+		id, _ := op[openAPIKeyOperationID].(string)
+		name := openAPIFunctionName(id, method, path)
+		if !containsString(o.Operations, id) && !containsString(o.Operations, name) {
+			return false
+		}
+	}
+	if len(o.Tags) > 0 {
+		for _, tag := range stringSlice(op[openAPIKeyTags]) {
+			if containsString(o.Tags, tag) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// openAPIResolver inlines references of an OpenAPI document. The first
+// unresolved reference is kept in err.
+type openAPIResolver struct {
+	doc    map[string]any
+	active map[string]bool // references being inlined, to cut cycles
+	err    error
+}
+
+// function converts one operation.
+func (r *openAPIResolver) function(op map[string]any, method, path string, item map[string]any) *FunctionDef {
+	id, _ := op[openAPIKeyOperationID].(string)
+	fn := &FunctionDef{Name: openAPIFunctionName(id, method, path)}
+
+	var description []string
+	for _, key := range []string{openAPIKeySummary, SchemaKeyDescription} {
+		if text, _ := op[key].(string); strings.TrimSpace(text) != "" {
+			description = append(description, strings.TrimSpace(text))
+		}
+	}
+	fn.Description = strings.Join(description, "\n\n")
+
+	properties := map[string]any{}
+	var required []any
+	for _, param := range r.parameters(item, op) {
+		name, _ := param[openAPIKeyName].(string)
+		schema := r.parameterSchema(param)
+		if text, ok := param[SchemaKeyDescription].(string); ok && schema[SchemaKeyDescription] == nil {
+			schema[SchemaKeyDescription] = text
+		}
+		properties[name] = schema
+		if isRequired, _ := param[SchemaKeyRequired].(bool); isRequired || param[openAPIKeyIn] == openAPIParamInPath {
+			required = append(required, name)
+		}
+	}
+
+	if body, ok := r.object(op[openAPIKeyRequestBody]).(map[string]any); ok {
+		if schema := r.contentSchema(body); schema != nil {
+			bodyProps, isObject := schema[SchemaKeyProperties].(map[string]any)
+			if isObject && !anyKeyIn(bodyProps, properties) {
+				for name, prop := range bodyProps {
+					properties[name] = prop
+				}
+				if names, ok := schema[SchemaKeyRequired].([]any); ok {
+					required = append(required, names...)
+				}
+			} else {
+				properties[openAPIBodyProperty] = schema
+				if isRequired, _ := body[SchemaKeyRequired].(bool); isRequired {
+					required = append(required, openAPIBodyProperty)
+				}
+			}
+		}
+	}
+
+	fn.Parameters = map[string]any{SchemaKeyType: SchemaTypeObject, SchemaKeyProperties: properties}
+	if len(required) > 0 {
+		fn.Parameters[SchemaKeyRequired] = required
+	}
+	fn.Returns = r.returns(op)
+	return fn
+}
+
+// parameters returns the path item and operation parameters, the latter
+// overriding the former, without the headers OpenAPI ignores.
+func (r *openAPIResolver) parameters(item, op map[string]any) []map[string]any {
+	var params []map[string]any
+	index := map[string]int{}
+	for _, source := range []any{item[openAPIKeyParameters], op[openAPIKeyParameters]} {
+		list, _ := source.([]any)
+		for _, entry := range list {
+			param, ok := r.object(entry).(map[string]any)
+			if !ok {
+				continue
+			}
+			name, _ := param[openAPIKeyName].(string)
+			in, _ := param[openAPIKeyIn].(string)
+			if name == "" || (in == openAPIParamInHeader && openAPIIgnoredHeaders[strings.ToLower(name)]) {
+				continue
+			}
+			key := in + "/" + name
+			if i, ok := index[key]; ok {
+				params[i] = param
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// parameterSchema returns the schema of a parameter, defaulting to string.
+func (r *openAPIResolver) parameterSchema(param map[string]any) map[string]any {
+	if schema, ok := r.schema(param[SchemaKeySchema]).(map[string]any); ok {
+		return schema
+	}
+	if schema := r.contentSchema(param); schema != nil {
+		return schema
+	}
+	return map[string]any{SchemaKeyType: SchemaTypeString}
+}
+
+// contentSchema returns the schema of the preferred media type of a
+// request body, response or parameter content map: JSON first, then any
+// other media type in name order.
+func (r *openAPIResolver) contentSchema(holder map[string]any) map[string]any {
+	content, _ := holder[openAPIKeyContent].(map[string]any)
+	types := sortedKeys(content)
+	sort.SliceStable(types, func(i, j int) bool {
+		return strings.Contains(types[i], openAPIMediaJSONPart) && !strings.Contains(types[j], openAPIMediaJSONPart)
+	})
+	for _, mediaType := range types {
+		media, _ := content[mediaType].(map[string]any)
+		if schema, ok := r.schema(media[SchemaKeySchema]).(map[string]any); ok {
+			return schema
+		}
+	}
+	return nil
+}
+
+// returns returns the JSON schema of the first successful response.
+func (r *openAPIResolver) returns(op map[string]any) map[string]any {
+	responses, _ := op[openAPIKeyResponses].(map[string]any)
+	for _, status := range sortedKeys(responses) {
+		if !strings.HasPrefix(status, openAPIStatusPrefix) {
+			continue
+		}
+		response, _ := r.object(responses[status]).(map[string]any)
+		content, _ := response[openAPIKeyContent].(map[string]any)
+		for _, mediaType := range sortedKeys(content) {
+			if !strings.Contains(mediaType, openAPIMediaJSONPart) {
+				continue
+			}
+			media, _ := content[mediaType].(map[string]any)
+			if schema, ok := r.schema(media[SchemaKeySchema]).(map[string]any); ok {
+				return schema
+			}
+		}
+	}
+	return nil
+}
+
+// object follows a reference to a parameter, request body, response or
+// path item object.
+func (r *openAPIResolver) object(node any) any {
+	m, ok := node.(map[string]any)
+	if !ok {
+		return node
+	}
+	ref, ok := m[openAPIKeyRef].(string)
+	if !ok || r.active[ref] {
+		return node
+	}
+	r.active[ref] = true
+	defer delete(r.active, ref)
+	return r.object(r.lookup(ref))
+}
+
+// schema returns a copy of a schema with references inlined, OpenAPI-only
+// keywords dropped and nullable types turned into JSON Schema type lists.
+func (r *openAPIResolver) schema(node any) any {
+	switch v := node.(type) {
+	case map[string]any:
+		if ref, ok := v[openAPIKeyRef].(string); ok {
+			if r.active[ref] {
+				return map[string]any{SchemaKeyType: SchemaTypeObject}
+			}
+			r.active[ref] = true
+			defer delete(r.active, ref)
+			resolved, ok := r.schema(r.lookup(ref)).(map[string]any)
+			if !ok {
+				return nil
+			}
+			if text, ok := v[SchemaKeyDescription].(string); ok {
+				resolved[SchemaKeyDescription] = text
+			}
+			return resolved
+		}
+
+		out := make(map[string]any, len(v))
+		for key, value := range v {
+			if openAPISchemaOnlyKeys[key] || key == openAPIKeyNullable {
+				continue
+			}
+			if named, ok := value.(map[string]any); ok && openAPISchemaMaps[key] {
+				schemas := make(map[string]any, len(named))
+				for name, schema := range named {
+					schemas[name] = r.schema(schema)
+				}
+				out[key] = schemas
+				continue
+			}
+			out[key] = r.schema(value)
+		}
+		if nullable, _ := v[openAPIKeyNullable].(bool); nullable {
+			if typ, ok := out[SchemaKeyType].(string); ok {
+				out[SchemaKeyType] = []any{typ, openAPITypeNull}
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = r.schema(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// lookup resolves a local JSON pointer reference such as
+// "#/components/schemas/Pet".
+func (r *openAPIResolver) lookup(ref string) any {
+	if !strings.HasPrefix(ref, openAPIRefPrefix) {
+		r.fail(ref)
+		return nil
+	}
+	var node any = r.doc
+	for _, token := range strings.Split(strings.TrimPrefix(ref, openAPIRefPrefix), "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		m, ok := node.(map[string]any)
+		if !ok {
+			r.fail(ref)
+			return nil
+		}
+		if node, ok = m[token]; !ok {
+			r.fail(ref)
+			return nil
+		}
+	}
+	return node
+}
+
+func (r *openAPIResolver) fail(ref string) {
+	if r.err == nil {
+		r.err = NewToolImportError(ErrMsgToolImportUnresolvedRef, ref)
+	}
+}
+
+// openAPIFunctionName returns the tool name of an operation: its
+// operationId, or the method and path, restricted to the characters and
+// length tool APIs accept.
+func openAPIFunctionName(operationID, method, path string) string {
+	name := operationID
+	if name == "" {
+		name = method + openAPINameSeparator + strings.TrimPrefix(path, "/")
+	}
+	name = strings.Trim(openAPINameInvalid.ReplaceAllString(name, openAPINameSeparator), openAPINameSeparator)
+	if len(name) > openAPIMaxNameLength {
+		name = name[:openAPIMaxNameLength]
+	}
+	return name
+}
+
+// anyKeyIn reports whether a and b share a key.
+func anyKeyIn(a, b map[string]any) bool {
+	for key := range a {
+		if _, ok := b[key]; ok {
+			return true
+		}
+	}
+	return false
+}
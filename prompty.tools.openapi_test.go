@@ -0,0 +1,166 @@
+package prompty
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOpenAPISpec = `
+openapi: 3.0.3
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets/{petId}:
+    parameters:
+      - $ref: '#/components/parameters/PetId'
+    get:
+      operationId: getPet
+      summary: Get a pet
+      description: Returns a single pet.
+      tags: [read]
+      parameters:
+        - {name: fields, in: query, description: Fields to return, schema: {type: string, nullable: true}}
+        - {name: Authorization, in: header, schema: {type: string}}
+      responses:
+        "200":
+          description: The pet
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/Pet'}
+    delete:
+      operationId: deletePet
+      deprecated: true
+      responses: {"204": {description: Deleted}}
+  /pets:
+    post:
+      tags: [write]
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/Pet'}
+      responses: {"201": {description: Created}}
+  /pets/{petId}/photo:
+    put:
+      operationId: upload.photo
+      parameters:
+        - $ref: '#/components/parameters/PetId'
+      requestBody:
+        content:
+          application/octet-stream:
+            schema: {type: string, format: binary}
+      responses: {"204": {description: Stored}}
+components:
+  parameters:
+    PetId: {name: petId, in: path, required: true, schema: {type: integer}}
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string, example: Rex}
+        example: {type: string}
+        parent: {$ref: '#/components/schemas/Pet'}
+`
+
+func TestToolsFromOpenAPI(t *testing.T) {
+	functions, err := ToolsFromOpenAPI([]byte(testOpenAPISpec), nil)
+	require.NoError(t, err)
+	require.Len(t, functions, 3, "deprecated operations are skipped")
+
+	post := functions[0]
+	assert.Equal(t, "post_pets", post.Name, "name derived from method and path")
+	assert.Equal(t, []any{"name"}, post.Parameters[SchemaKeyRequired])
+	properties := post.Parameters[SchemaKeyProperties].(map[string]any)
+	assert.Equal(t, map[string]any{SchemaKeyType: SchemaTypeString}, properties["name"], "example keyword dropped")
+	assert.Contains(t, properties, "example", "properties named like keywords are kept")
+	assert.Equal(t, map[string]any{SchemaKeyType: SchemaTypeObject}, properties["parent"], "recursive reference cut off")
+
+	get := functions[1]
+	assert.Equal(t, "getPet", get.Name)
+	assert.Equal(t, "Get a pet\n\nReturns a single pet.", get.Description)
+	assert.Equal(t, map[string]any{
+		SchemaKeyType: SchemaTypeObject,
+		SchemaKeyProperties: map[string]any{
+			"petId":  map[string]any{SchemaKeyType: SchemaTypeInteger},
+			"fields": map[string]any{SchemaKeyType: []any{SchemaTypeString, "null"}, SchemaKeyDescription: "Fields to return"},
+		},
+		SchemaKeyRequired: []any{"petId"},
+	}, get.Parameters, "path item parameters are merged and ignored headers skipped")
+	assert.Equal(t, SchemaTypeObject, get.Returns[SchemaKeyType])
+
+	upload := functions[2]
+	assert.Equal(t, "upload_photo", upload.Name)
+	uploadProps := upload.Parameters[SchemaKeyProperties].(map[string]any)
+	assert.Equal(t, map[string]any{SchemaKeyType: SchemaTypeString, SchemaKeyFormat: "binary"}, uploadProps[openAPIBodyProperty])
+	assert.Equal(t, []any{"petId"}, upload.Parameters[SchemaKeyRequired], "optional body is not required")
+}
+
+func TestToolsFromOpenAPI_Options(t *testing.T) {
+	functions, err := ToolsFromOpenAPI([]byte(testOpenAPISpec), &OpenAPIOptions{Tags: []string{"write"}, Strict: true})
+	require.NoError(t, err)
+	require.Len(t, functions, 1)
+	assert.Equal(t, "post_pets", functions[0].Name)
+	assert.True(t, functions[0].Strict)
+
+	functions, err = ToolsFromOpenAPI([]byte(testOpenAPISpec), &OpenAPIOptions{
+		Operations:        []string{"deletePet", "upload.photo"},
+		IncludeDeprecated: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, functions, 2)
+	assert.Equal(t, "deletePet", functions[0].Name)
+	assert.Equal(t, "upload_photo", functions[1].Name)
+}
+
+func TestToolsFromOpenAPI_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr string
+	}{
+		{"not a document", "- a\n- b", ErrMsgToolImportInvalid},
+		{"swagger 2", `{"swagger": "2.0", "paths": {}}`, ErrMsgToolImportVersion},
+		{"unresolved ref", `{"openapi": "3.1.0", "paths": {"/a": {"get": {"parameters": [{"$ref": "#/components/parameters/Missing"}]}}}}`, ErrMsgToolImportUnresolvedRef},
+		{"duplicate", `{"openapi": "3.1.0", "paths": {"/a": {"get": {"operationId": "x"}}, "/b": {"get": {"operationId": "x"}}}}`, ErrMsgToolImportDuplicateName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ToolsFromOpenAPI([]byte(tt.spec), nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestToolsToOpenAPI_RoundTrip(t *testing.T) {
+	functions := []*FunctionDef{
+		{
+			Name:        "search_docs",
+			Description: "Search the documentation",
+			Parameters: map[string]any{
+				SchemaKeyType:       SchemaTypeObject,
+				SchemaKeyProperties: map[string]any{"query": map[string]any{SchemaKeyType: SchemaTypeString}},
+				SchemaKeyRequired:   []any{"query"},
+			},
+			Returns: map[string]any{SchemaKeyType: SchemaTypeArray},
+		},
+		{Name: "ping", Parameters: map[string]any{SchemaKeyType: SchemaTypeObject, SchemaKeyProperties: map[string]any{}}},
+	}
+
+	spec, err := ToolsToOpenAPI(functions, "Docs tools", "1.0.0")
+	require.NoError(t, err)
+	assert.Contains(t, string(spec), `"openapi": "3.1.0"`)
+	assert.Contains(t, string(spec), `"/search_docs"`)
+
+	imported, err := ToolsFromOpenAPI(spec, nil)
+	require.NoError(t, err)
+	require.Len(t, imported, 2)
+	assert.Equal(t, functions[1], imported[0])
+	assert.Equal(t, functions[0], imported[1])
+
+	_, err = ToolsToOpenAPI([]*FunctionDef{{Description: "unnamed"}}, "x", "1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrMsgToolExportNoName)
+}
@@ -61,13 +61,15 @@ type GuidedDecoding struct {
 	Choice []string `yaml:"choice,omitempty" json:"choice,omitempty"`
 	// Grammar is a context-free grammar constraint
 	Grammar string `yaml:"grammar,omitempty" json:"grammar,omitempty"`
+	// GrammarFormat is the syntax of Grammar: "gbnf" (default) or "lark"
+	GrammarFormat string `yaml:"grammar_format,omitempty" json:"grammar_format,omitempty"`
 	// WhitespacePattern controls whitespace handling
 	WhitespacePattern string `yaml:"whitespace_pattern,omitempty" json:"whitespace_pattern,omitempty"`
 }
 
 // InputDef defines an expected input parameter.
 type InputDef struct {
-	// Type: "string", "number", "boolean", "array", "object"
+	// Type: "string", "number", "integer", "boolean", "array", "object"
 	Type string `yaml:"type" json:"type"`
 	// Description of the input
 	Description string `yaml:"description,omitempty" json:"description,omitempty"`
@@ -75,11 +77,28 @@ type InputDef struct {
 	Required bool `yaml:"required,omitempty" json:"required,omitempty"`
 	// Default value if not provided
 	Default any `yaml:"default,omitempty" json:"default,omitempty"`
+	// Enum lists the allowed values (optional)
+	Enum []any `yaml:"enum,omitempty" json:"enum,omitempty"`
+	// Properties describes the fields of an "object" input (optional)
+	Properties map[string]*InputDef `yaml:"properties,omitempty" json:"properties,omitempty"`
+	// Items describes the elements of an "array" input (optional)
+	Items *InputDef `yaml:"items,omitempty" json:"items,omitempty"`
+	// Minimum and Maximum bound a "number" or "integer" input (optional)
+	Minimum *float64 `yaml:"minimum,omitempty" json:"minimum,omitempty"`
+	Maximum *float64 `yaml:"maximum,omitempty" json:"maximum,omitempty"`
+	// MinLength and MaxLength bound the length of a "string" input in runes (optional)
+	MinLength *int `yaml:"min_length,omitempty" json:"min_length,omitempty"`
+	MaxLength *int `yaml:"max_length,omitempty" json:"max_length,omitempty"`
+	// MinItems and MaxItems bound the length of an "array" input (optional)
+	MinItems *int `yaml:"min_items,omitempty" json:"min_items,omitempty"`
+	MaxItems *int `yaml:"max_items,omitempty" json:"max_items,omitempty"`
+	// Pattern is a regular expression a "string" input must match (optional)
+	Pattern string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
 }
 
 // OutputDef defines an expected output.
 type OutputDef struct {
-	// Type: "string", "number", "boolean", "array", "object"
+	// Type: "string", "number", "integer", "boolean", "array", "object"
 	Type string `yaml:"type" json:"type"`
 	// Description of the output
 	Description string `yaml:"description,omitempty" json:"description,omitempty"`
@@ -93,6 +112,32 @@ type Message struct {
 	Content string `yaml:"content" json:"content"`
 	// Cache indicates whether this message should be cached
 	Cache bool `yaml:"cache,omitempty" json:"cache,omitempty"`
+	// Parts lists the text, image and file parts in order when the message
+	// holds images, files or cache breakpoints (prompty.image, prompty.file,
+	// prompty.cache_breakpoint). Content keeps the text alone.
+	Parts []ContentPart `yaml:"parts,omitempty" json:"parts,omitempty"`
+}
+
+// ContentPart is one part of a multimodal message. Images and files are
+// located by URL (http(s) or a base64 data URL) or by a provider file ID.
+type ContentPart struct {
+	// Type: "text", "image" or "file"
+	Type string `yaml:"type" json:"type"`
+	// Text of a text part
+	Text string `yaml:"text,omitempty" json:"text,omitempty"`
+	// URL of an image or file
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+	// Detail level of an image: "low", "high" or "auto"
+	Detail string `yaml:"detail,omitempty" json:"detail,omitempty"`
+	// Name is the file name of a file
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// FileID is a file ID issued by the provider's files API
+	FileID string `yaml:"file_id,omitempty" json:"file_id,omitempty"`
+	// MediaType is the MIME type, e.g. "image/png" or "application/pdf"
+	MediaType string `yaml:"media_type,omitempty" json:"media_type,omitempty"`
+	// Cache marks a prompt-cache breakpoint after this part
+	// ({~prompty.cache_breakpoint /~})
+	Cache bool `yaml:"cache,omitempty" json:"cache,omitempty"`
 }
 
 // ToOpenAI converts the response format to OpenAI API format.
@@ -138,6 +183,23 @@ func (rf *ResponseFormat) ToOpenAI() map[string]any {
 	return result
 }
 
+// ToOpenAIResponses converts to the OpenAI Responses API text.format, where
+// the JSON schema fields sit next to the type instead of under json_schema.
+func (rf *ResponseFormat) ToOpenAIResponses() map[string]any {
+	if rf == nil {
+		return nil
+	}
+
+	result := rf.ToOpenAI()
+	if nested, ok := result[SchemaKeyJSONSchema].(map[string]any); ok {
+		delete(result, SchemaKeyJSONSchema)
+		for k, v := range nested {
+			result[k] = v
+		}
+	}
+	return result
+}
+
 // ToAnthropic converts to Anthropic output_format structure.
 // Returns nil if the response format is not configured.
 func (rf *ResponseFormat) ToAnthropic() map[string]any {
@@ -229,6 +291,64 @@ func (gd *GuidedDecoding) ToVLLM() map[string]any {
 	return result
 }
 
+// Validate checks that at most one constraint is set and that the grammar
+// is well formed in its format.
+func (gd *GuidedDecoding) Validate() error {
+	if gd == nil {
+		return nil
+	}
+	if gd.constraintCount() > 1 {
+		return NewPromptValidationError(ErrMsgGuidedDecodingConflict, "")
+	}
+	if _, err := grammarSyntaxFor(gd.GrammarFormat); err != nil {
+		return err
+	}
+	if gd.Grammar != "" {
+		return ValidateGrammar(gd.Grammar, gd.GrammarFormat)
+	}
+	return nil
+}
+
+// constraintCount returns the number of constraints set.
+func (gd *GuidedDecoding) constraintCount() int {
+	count := 0
+	if gd.JSON != nil {
+		count++
+	}
+	if gd.Regex != "" {
+		count++
+	}
+	if len(gd.Choice) > 0 {
+		count++
+	}
+	if gd.Grammar != "" {
+		count++
+	}
+	return count
+}
+
+// isLark reports whether the grammar is in Lark syntax.
+func (gd *GuidedDecoding) isLark() bool {
+	return gd != nil && gd.Grammar != "" && gd.GrammarFormat == GrammarFormatLark
+}
+
+// llamaCppGrammar returns the GBNF grammar for llama.cpp: Grammar when it is
+// GBNF, else one derived from Choice. Lark grammars and regexes have no
+// llama.cpp equivalent.
+func (gd *GuidedDecoding) llamaCppGrammar() string {
+	if gd.Grammar != "" {
+		if gd.isLark() {
+			return ""
+		}
+		return gd.Grammar
+	}
+	if len(gd.Choice) > 0 {
+		grammar, _ := GrammarFromChoices(GrammarFormatGBNF, gd.Choice)
+		return grammar
+	}
+	return ""
+}
+
 // ToAnthropic converts OutputFormat to Anthropic API format.
 func (of *OutputFormat) ToAnthropic() map[string]any {
 	if of == nil || of.Format == nil {
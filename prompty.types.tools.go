@@ -169,6 +169,22 @@ func (f *FunctionDef) ToOpenAITool() map[string]any {
 	}
 }
 
+// ToOpenAIResponsesTool converts FunctionDef to OpenAI Responses API tool
+// format, where the function fields sit next to the type.
+func (f *FunctionDef) ToOpenAIResponsesTool() map[string]any {
+	if f == nil {
+		return nil
+	}
+
+	tool := f.ToOpenAITool()
+	funcDef := tool["function"].(map[string]any)
+	delete(tool, "function")
+	for k, v := range funcDef {
+		tool[k] = v
+	}
+	return tool
+}
+
 // ToAnthropicTool converts FunctionDef to Anthropic tool use format.
 func (f *FunctionDef) ToAnthropicTool() map[string]any {
 	if f == nil {
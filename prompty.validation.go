@@ -11,10 +11,16 @@ type ValidationResult struct {
 
 // ValidationIssue represents a single validation finding.
 type ValidationIssue struct {
+	// Code identifies the kind of issue (one of the IssueCode constants)
+	// independently of the message's wording and locale.
+	Code     string
 	Severity ValidationSeverity
 	Message  string
 	Position Position
 	TagName  string
+	// Params holds the values in the message, such as the missing template
+	// name, for localized messages (see Engine.LocalizeIssue).
+	Params map[string]string
 }
 
 // Issues returns all validation issues found.
@@ -78,31 +84,32 @@ func (e *Engine) Validate(source string) (*ValidationResult, error) {
 	}
 
 	// Create lexer with configured delimiters
-	lexerConfig := internal.LexerConfig{
-		OpenDelim:  e.config.openDelim,
-		CloseDelim: e.config.closeDelim,
-	}
+	lexerConfig := e.config.lexerConfig()
 	lexer := internal.NewLexerWithConfig(source, lexerConfig, e.logger)
 
 	// Tokenize
 	tokens, err := lexer.Tokenize()
 	if err != nil {
 		result.issues = append(result.issues, ValidationIssue{
+			Code:     IssueCodeParseFailed,
 			Severity: SeverityError,
 			Message:  ErrMsgParseFailed + ": " + err.Error(),
 			Position: Position{},
+			Params:   map[string]string{MetaKeyReason: err.Error()},
 		})
 		return result, nil
 	}
 
 	// Parse with source for validation
-	parser := internal.NewParserWithSource(tokens, source, e.logger)
+	parser := internal.NewParserWithConfig(tokens, source, lexerConfig, e.logger)
 	ast, err := parser.Parse()
 	if err != nil {
 		result.issues = append(result.issues, ValidationIssue{
+			Code:     IssueCodeParseFailed,
 			Severity: SeverityError,
 			Message:  ErrMsgParseFailed + ": " + err.Error(),
 			Position: Position{},
+			Params:   map[string]string{MetaKeyReason: err.Error()},
 		})
 		return result, nil
 	}
@@ -110,6 +117,22 @@ func (e *Engine) Validate(source string) (*ValidationResult, error) {
 	// Validate AST nodes
 	e.validateNodes(ast.Children, result)
 
+	// Strict mode checks need the frontmatter's input declarations
+	if e.config.strict {
+		tmpl, err := e.parseSource(source)
+		if err != nil {
+			result.issues = append(result.issues, ValidationIssue{
+				Code:     IssueCodeParseFailed,
+				Severity: SeverityError,
+				Message:  err.Error(),
+				Position: Position{},
+				Params:   map[string]string{MetaKeyReason: err.Error()},
+			})
+			return result, nil
+		}
+		result.issues = append(result.issues, e.strictIssues(tmpl)...)
+	}
+
 	return result, nil
 }
 
@@ -151,6 +174,7 @@ func (e *Engine) validateTagNode(tag *internal.TagNode, result *ValidationResult
 	// Check if tag has a registered resolver
 	if !e.registry.Has(tag.Name) {
 		result.issues = append(result.issues, ValidationIssue{
+			Code:     IssueCodeUnknownTag,
 			Severity: SeverityWarning,
 			Message:  ErrMsgUnknownTagInTemplate,
 			Position: e.internalPosToPublic(tag.Pos()),
@@ -161,10 +185,12 @@ func (e *Engine) validateTagNode(tag *internal.TagNode, result *ValidationResult
 		resolver, _ := e.registry.Get(tag.Name)
 		if err := resolver.Validate(tag.Attributes); err != nil {
 			result.issues = append(result.issues, ValidationIssue{
+				Code:     IssueCodeInvalidAttributes,
 				Severity: SeverityError,
 				Message:  err.Error(),
 				Position: e.internalPosToPublic(tag.Pos()),
 				TagName:  tag.Name,
+				Params:   map[string]string{MetaKeyReason: err.Error()},
 			})
 		}
 	}
@@ -173,28 +199,71 @@ func (e *Engine) validateTagNode(tag *internal.TagNode, result *ValidationResult
 	if onErrorStr, hasOnError := tag.Attributes.Get(AttrOnError); hasOnError {
 		if !IsValidErrorStrategy(onErrorStr) {
 			result.issues = append(result.issues, ValidationIssue{
+				Code:     IssueCodeInvalidOnError,
 				Severity: SeverityError,
 				Message:  ErrMsgInvalidOnErrorAttr,
 				Position: e.internalPosToPublic(tag.Pos()),
 				TagName:  tag.Name,
+				Params:   map[string]string{MetaKeyValue: onErrorStr},
+			})
+		} else if fallback, ok := internal.ErrorFallbackTemplate(onErrorStr); ok && !e.HasTemplate(fallback) {
+			result.issues = append(result.issues, ValidationIssue{
+				Code:     IssueCodeMissingFallback,
+				Severity: SeverityWarning,
+				Message:  ErrMsgMissingFallback,
+				Position: e.internalPosToPublic(tag.Pos()),
+				TagName:  tag.Name,
+				Params:   map[string]string{MetaKeyTemplateName: fallback},
+			})
+		}
+	}
+
+	// Validate cache attribute if present
+	if internal.IsTagCached(tag) {
+		if _, ok := internal.ParseTagCacheTTL(tag.Attributes.GetDefault(AttrCache, "")); !ok {
+			result.issues = append(result.issues, ValidationIssue{
+				Code:     IssueCodeInvalidCacheTTL,
+				Severity: SeverityError,
+				Message:  ErrMsgInvalidTagCacheTTL,
+				Position: e.internalPosToPublic(tag.Pos()),
+				TagName:  tag.Name,
+				Params:   map[string]string{MetaKeyValue: tag.Attributes.GetDefault(AttrCache, "")},
 			})
 		}
 	}
 
 	// Validate prompty.include references
 	if tag.Name == TagNameInclude {
-		if templateName, hasTemplate := tag.Attributes.Get(AttrTemplate); hasTemplate {
+		source, _ := tag.Attributes.Get(AttrSource)
+		// Stored templates are resolved at execution time by the StorageEngine
+		if templateName, hasTemplate := tag.Attributes.Get(AttrTemplate); hasTemplate && source != AttrValueStorage {
 			if !e.HasTemplate(templateName) {
 				result.issues = append(result.issues, ValidationIssue{
+					Code:     IssueCodeMissingInclude,
 					Severity: SeverityWarning,
 					Message:  ErrMsgMissingIncludeTarget,
 					Position: e.internalPosToPublic(tag.Pos()),
 					TagName:  tag.Name,
+					Params:   map[string]string{MetaKeyTemplateName: templateName},
 				})
 			}
 		}
 	}
 
+	// Validate prompty.import references
+	if tag.Name == TagNameImport {
+		if templateName, hasTemplate := tag.Attributes.Get(AttrTemplate); hasTemplate && !e.HasTemplate(templateName) {
+			result.issues = append(result.issues, ValidationIssue{
+				Code:     IssueCodeMissingImport,
+				Severity: SeverityWarning,
+				Message:  ErrMsgMissingImportTarget,
+				Position: e.internalPosToPublic(tag.Pos()),
+				TagName:  tag.Name,
+				Params:   map[string]string{MetaKeyTemplateName: templateName},
+			})
+		}
+	}
+
 	// Validate children recursively
 	if len(tag.Children) > 0 {
 		e.validateNodes(tag.Children, result)
@@ -214,6 +283,7 @@ func (e *Engine) validateForNode(forNode *internal.ForNode, result *ValidationRe
 	// Check required item variable
 	if forNode.ItemVar == "" {
 		result.issues = append(result.issues, ValidationIssue{
+			Code:     IssueCodeForMissingItem,
 			Severity: SeverityError,
 			Message:  ErrMsgForMissingItem,
 			Position: e.internalPosToPublic(forNode.Pos()),
@@ -224,6 +294,7 @@ func (e *Engine) validateForNode(forNode *internal.ForNode, result *ValidationRe
 	// Check required source path
 	if forNode.Source == "" {
 		result.issues = append(result.issues, ValidationIssue{
+			Code:     IssueCodeForMissingIn,
 			Severity: SeverityError,
 			Message:  ErrMsgForMissingIn,
 			Position: e.internalPosToPublic(forNode.Pos()),
@@ -234,6 +305,7 @@ func (e *Engine) validateForNode(forNode *internal.ForNode, result *ValidationRe
 	// Check for negative limit
 	if forNode.Limit < 0 {
 		result.issues = append(result.issues, ValidationIssue{
+			Code:     IssueCodeForInvalidLimit,
 			Severity: SeverityError,
 			Message:  ErrMsgForInvalidLimit,
 			Position: e.internalPosToPublic(forNode.Pos()),
@@ -250,6 +322,7 @@ func (e *Engine) validateSwitchNode(switchNode *internal.SwitchNode, result *Val
 	// Check required expression
 	if switchNode.Expression == "" {
 		result.issues = append(result.issues, ValidationIssue{
+			Code:     IssueCodeSwitchMissingEval,
 			Severity: SeverityError,
 			Message:  ErrMsgSwitchMissingEval,
 			Position: e.internalPosToPublic(switchNode.Pos()),
@@ -262,6 +335,7 @@ func (e *Engine) validateSwitchNode(switchNode *internal.SwitchNode, result *Val
 		// Check that case has either value or eval
 		if caseNode.Value == "" && caseNode.Eval == "" {
 			result.issues = append(result.issues, ValidationIssue{
+				Code:     IssueCodeCaseMissingValue,
 				Severity: SeverityError,
 				Message:  ErrMsgSwitchMissingValue,
 				Position: e.internalPosToPublic(caseNode.Pos),
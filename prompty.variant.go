@@ -0,0 +1,271 @@
+package prompty
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"strings"
+)
+
+// PromptVariant is a named variation of a prompt, defined in the variants
+// section of its frontmatter so near-identical copies need not be kept as
+// separate files:
+//
+//	variants:
+//	  control:
+//	    weight: 50
+//	  concise:
+//	    weight: 50
+//	    description: Shorter answers
+//	    blocks:
+//	      tone: Answer in one sentence.
+//	    patches:
+//	      - find: "You are a helpful assistant."
+//	        replace: "You are a terse assistant."
+//
+// A variant without changes, like control above, selects the prompt as is.
+type PromptVariant struct {
+	// Description documents the variant.
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+
+	// Weight is the variant's share of traffic in AssignVariant, relative
+	// to the other variants' weights.
+	Weight float64 `yaml:"weight,omitempty" json:"weight,omitempty"`
+
+	// Body replaces the whole body when set.
+	Body string `yaml:"body,omitempty" json:"body,omitempty"`
+
+	// Blocks replaces the content of prompty.block tags by block name.
+	Blocks map[string]string `yaml:"blocks,omitempty" json:"blocks,omitempty"`
+
+	// Patches replaces text of the body, applied in order after Body and
+	// Blocks.
+	Patches []VariantPatch `yaml:"patches,omitempty" json:"patches,omitempty"`
+
+	// Execution is merged over the prompt's execution config.
+	Execution *ExecutionConfig `yaml:"execution,omitempty" json:"execution,omitempty"`
+
+	// Metadata is merged over the prompt's metadata by key.
+	Metadata map[string]any `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+}
+
+// VariantPatch replaces every occurrence of Find in the body with Replace.
+type VariantPatch struct {
+	Find    string `yaml:"find" json:"find"`
+	Replace string `yaml:"replace" json:"replace"`
+}
+
+// PromptVariants maps variant names to variants.
+type PromptVariants map[string]*PromptVariant
+
+// Validate checks the weights and patches of the variants.
+func (v PromptVariants) Validate() error {
+	for _, name := range v.Names() {
+		variant := v[name]
+		if variant == nil {
+			continue
+		}
+		if variant.Weight < 0 {
+			return NewVariantError(ErrMsgVariantInvalidWeight, name)
+		}
+		for _, patch := range variant.Patches {
+			if patch.Find == "" {
+				return NewVariantError(ErrMsgVariantEmptyPatch, name)
+			}
+		}
+		if variant.Execution != nil {
+			if err := variant.Execution.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Names returns the variant names in sorted order.
+func (v PromptVariants) Names() []string {
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SelectVariant returns a copy of the prompt with the named variant
+// applied: its body replacement, block overrides and patches, then its
+// execution config and metadata. The copy has no variants section. An empty
+// name returns the prompt unchanged.
+func (p *Prompt) SelectVariant(name string) (*Prompt, error) {
+	if p == nil || name == "" {
+		return p, nil
+	}
+	variant, ok := p.Variants[name]
+	if !ok {
+		return nil, NewVariantNotFoundError(name)
+	}
+
+	selected := p.Clone()
+	selected.Variants = nil
+	if variant == nil {
+		return selected, nil
+	}
+
+	if variant.Body != "" {
+		selected.Body = variant.Body
+	}
+	blocks := make([]string, 0, len(variant.Blocks))
+	for block := range variant.Blocks {
+		blocks = append(blocks, block)
+	}
+	sort.Strings(blocks)
+	for _, block := range blocks {
+		body, ok := replaceBlockContent(selected.Body, block, variant.Blocks[block])
+		if !ok {
+			return nil, NewVariantBlockNotFoundError(name, block)
+		}
+		selected.Body = body
+	}
+	for _, patch := range variant.Patches {
+		if !strings.Contains(selected.Body, patch.Find) {
+			return nil, NewVariantError(ErrMsgVariantPatchNoMatch, name)
+		}
+		selected.Body = strings.ReplaceAll(selected.Body, patch.Find, patch.Replace)
+	}
+
+	if variant.Execution != nil {
+		selected.Execution = selected.Execution.Merge(variant.Execution)
+	}
+	if len(variant.Metadata) > 0 {
+		if selected.Metadata == nil {
+			selected.Metadata = make(map[string]any, len(variant.Metadata))
+		}
+		for k, v := range variant.Metadata {
+			selected.Metadata[k] = v
+		}
+	}
+	return selected, nil
+}
+
+// AssignVariant deterministically assigns a variant to a key such as a user
+// or session ID, weighted by the variants' weights, for A/B experiments:
+// the same prompt and key always get the same variant. When no variant has
+// a weight, all are equally likely. Returns "" when the prompt has no
+// variants.
+func (p *Prompt) AssignVariant(key string) string {
+	if p == nil || len(p.Variants) == 0 {
+		return ""
+	}
+	names := p.Variants.Names()
+	weights := make([]float64, len(names))
+	total := 0.0
+	for i, name := range names {
+		if variant := p.Variants[name]; variant != nil {
+			weights[i] = variant.Weight
+		}
+		total += weights[i]
+	}
+	if total == 0 {
+		for i := range weights {
+			weights[i] = 1
+		}
+		total = float64(len(weights))
+	}
+
+	sum := sha256.Sum256([]byte(p.Name + ":" + key))
+	point := float64(binary.BigEndian.Uint64(sum[:8])) / (1 << 64) * total
+	for i, name := range names {
+		if point < weights[i] {
+			return name
+		}
+		point -= weights[i]
+	}
+	// Rounding may leave the point just past the last weighted variant
+	for i := len(names) - 1; i >= 0; i-- {
+		if weights[i] > 0 {
+			return names[i]
+		}
+	}
+	return names[len(names)-1]
+}
+
+// clone returns a deep copy of the variants.
+func (v PromptVariants) clone() PromptVariants {
+	if v == nil {
+		return nil
+	}
+	clone := make(PromptVariants, len(v))
+	for name, variant := range v {
+		if variant == nil {
+			clone[name] = nil
+			continue
+		}
+		c := *variant
+		if variant.Blocks != nil {
+			c.Blocks = make(map[string]string, len(variant.Blocks))
+			for k, b := range variant.Blocks {
+				c.Blocks[k] = b
+			}
+		}
+		c.Patches = append([]VariantPatch(nil), variant.Patches...)
+		c.Execution = variant.Execution.Clone()
+		if variant.Metadata != nil {
+			c.Metadata = deepCopyMap(variant.Metadata)
+		}
+		clone[name] = &c
+	}
+	return clone
+}
+
+// replaceBlockContent replaces the content of the first prompty.block named
+// name in body, including nested blocks. It reports whether the block was found.
+func replaceBlockContent(body, name, content string) (string, bool) {
+	tokens := Tokenize(body)
+	depth := 0
+	start, target := -1, 0
+	for i := 1; i < len(tokens); i++ {
+		if tokens[i].Kind != TokenKindTagName || tokens[i].Value != TagNameBlock {
+			continue
+		}
+		if tokens[i-1].Value == DefaultOpenDelim+DelimiterSlash {
+			depth--
+			if start >= 0 && depth == target {
+				return body[:start] + content + body[tokens[i-1].Start.Offset:], true
+			}
+			continue
+		}
+
+		blockName, end, selfClose := blockTagName(tokens, i+1)
+		if selfClose {
+			continue
+		}
+		if start < 0 && blockName == name {
+			start, target = tokens[end].End.Offset, depth
+		}
+		depth++
+		i = end
+	}
+	return body, false
+}
+
+// blockTagName scans the attributes of a tag from tokens[start] and returns
+// the value of its name attribute, the index of the tag's close token and
+// whether the tag is self-closing.
+func blockTagName(tokens []Token, start int) (string, int, bool) {
+	name := ""
+	for j := start; j < len(tokens); j++ {
+		switch tokens[j].Kind {
+		case TokenKindTagClose:
+			return name, j, tokens[j].Value == DefaultSelfClose
+		case TokenKindAttribute:
+			if tokens[j].Value == AttrName && j+2 < len(tokens) && tokens[j+2].Kind == TokenKindString {
+				value := tokens[j+2].Value
+				if len(value) >= 2 {
+					value = value[1 : len(value)-1]
+				}
+				name = value
+			}
+		}
+	}
+	return name, len(tokens) - 1, false
+}
@@ -0,0 +1,143 @@
+package prompty
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testVariantPrompt = `---
+name: greeter
+description: Greets users
+execution:
+  provider: openai
+  model: gpt-4o-mini
+variants:
+  control:
+    weight: 80
+  concise:
+    weight: 20
+    description: Shorter greeting
+    blocks:
+      tone: Be brief.
+    patches:
+      - find: "Hello"
+        replace: "Hi"
+    execution:
+      temperature: 0.1
+    metadata:
+      owner: growth
+  rewrite:
+    body: Welcome!
+---
+Hello {~prompty.var name="user" /~}. {~prompty.block name="tone"~}Be friendly and {~prompty.block name="detail"~}thorough{~/prompty.block~}.{~/prompty.block~}`
+
+func TestPrompt_SelectVariant(t *testing.T) {
+	p, err := Parse([]byte(testVariantPrompt))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"concise", "control", "rewrite"}, p.Variants.Names())
+
+	concise, err := p.SelectVariant("concise")
+	require.NoError(t, err)
+	assert.Equal(t, `Hi {~prompty.var name="user" /~}. {~prompty.block name="tone"~}Be brief.{~/prompty.block~}`, concise.Body)
+	assert.Equal(t, 0.1, *concise.Execution.Temperature)
+	assert.Equal(t, "gpt-4o-mini", concise.Execution.Model)
+	assert.Equal(t, "growth", concise.Metadata["owner"])
+	assert.Nil(t, concise.Variants)
+	assert.Contains(t, p.Body, "Hello", "original is not modified")
+
+	control, err := p.SelectVariant("control")
+	require.NoError(t, err)
+	assert.Equal(t, p.Body, control.Body)
+
+	rewrite, err := p.SelectVariant("rewrite")
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome!", rewrite.Body)
+
+	same, err := p.SelectVariant("")
+	require.NoError(t, err)
+	assert.Same(t, p, same)
+
+	_, err = p.SelectVariant("missing")
+	assert.Error(t, err)
+
+	// Nested blocks can be overridden on their own
+	p.Variants["nested"] = &PromptVariant{Blocks: map[string]string{"detail": "brief"}}
+	nested, err := p.SelectVariant("nested")
+	require.NoError(t, err)
+	assert.Contains(t, nested.Body, `{~prompty.block name="detail"~}brief{~/prompty.block~}.{~/prompty.block~}`)
+
+	p.Variants["broken"] = &PromptVariant{Blocks: map[string]string{"unknown": "x"}}
+	_, err = p.SelectVariant("broken")
+	assert.Error(t, err)
+
+	p.Variants["broken"] = &PromptVariant{Patches: []VariantPatch{{Find: "absent", Replace: "x"}}}
+	_, err = p.SelectVariant("broken")
+	assert.Error(t, err)
+}
+
+func TestPromptVariants_Validate(t *testing.T) {
+	assert.NoError(t, PromptVariants{"a": {Weight: 1}, "b": nil}.Validate())
+	assert.Error(t, PromptVariants{"a": {Weight: -1}}.Validate())
+	assert.Error(t, PromptVariants{"a": {Patches: []VariantPatch{{Replace: "x"}}}}.Validate())
+
+	_, err := Parse([]byte("---\nname: bad\ndescription: d\nvariants:\n  a:\n    weight: -2\n---\nbody"))
+	assert.Error(t, err)
+}
+
+func TestPrompt_AssignVariant(t *testing.T) {
+	p, err := Parse([]byte(testVariantPrompt))
+	require.NoError(t, err)
+	delete(p.Variants, "rewrite")
+
+	counts := make(map[string]int)
+	for i := 0; i < 2000; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		variant := p.AssignVariant(key)
+		assert.Equal(t, variant, p.AssignVariant(key), "assignment is sticky")
+		counts[variant]++
+	}
+	assert.Len(t, counts, 2)
+	assert.InDelta(t, 1600, counts["control"], 150)
+	assert.InDelta(t, 400, counts["concise"], 150)
+
+	// Unweighted variants are equally likely
+	p.Variants = PromptVariants{"a": {}, "b": {}}
+	counts = make(map[string]int)
+	for i := 0; i < 2000; i++ {
+		counts[p.AssignVariant(fmt.Sprintf("user-%d", i))]++
+	}
+	assert.InDelta(t, 1000, counts["a"], 150)
+
+	p.Variants = nil
+	assert.Empty(t, p.AssignVariant("user-1"))
+}
+
+func TestCompile_Variant(t *testing.T) {
+	ctx := context.Background()
+	p, err := Parse([]byte(testVariantPrompt))
+	require.NoError(t, err)
+	input := map[string]any{"user": "Ada"}
+
+	out, err := p.Compile(ctx, input, NewCompileOptions(WithCompileVariant("concise")))
+	require.NoError(t, err)
+	assert.Equal(t, "Hi Ada. Be brief.", out)
+
+	out, err = p.Compile(ctx, input, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello Ada. Be friendly and thorough.", out)
+
+	_, err = p.Compile(ctx, input, NewCompileOptions(WithCompileVariant("missing")))
+	assert.Error(t, err)
+
+	agent := p.Clone()
+	agent.Type = DocumentTypeAgent
+	compiled, err := agent.CompileAgent(ctx, input, NewCompileOptions(WithCompileVariant("concise")))
+	require.NoError(t, err)
+	assert.Equal(t, "concise", compiled.Variant)
+	assert.Equal(t, "Hi Ada. Be brief.", compiled.Messages[0].Content)
+	assert.Equal(t, 0.1, *compiled.Execution.Temperature)
+}
@@ -15,6 +15,7 @@ type VersionInfo struct {
 	CreatedBy     string
 	Source        string
 	SourceLen     int
+	ContentHash   string // Canonical content hash of Source
 	Tags          []string
 	Metadata      map[string]string
 	IsCurrent     bool
@@ -109,6 +110,7 @@ func (se *StorageEngine) GetVersionHistory(ctx context.Context, name string) (*V
 			CreatedBy:     tmpl.CreatedBy,
 			Source:        tmpl.Source,
 			SourceLen:     len(tmpl.Source),
+			ContentHash:   storedContentHash(tmpl),
 			Tags:          tmpl.Tags,
 			Metadata:      tmpl.Metadata,
 			IsCurrent:     tmpl.Version == current.Version,
@@ -179,6 +181,7 @@ func (se *StorageEngine) RollbackToVersion(ctx context.Context, name string, tar
 	if err != nil {
 		return nil, NewVersioningError(ErrMsgVersionSaveRollback, err)
 	}
+	se.updateEmbedding(ctx, newTmpl.Name)
 
 	return newTmpl, nil
 }
@@ -219,6 +222,7 @@ func (se *StorageEngine) CloneVersion(ctx context.Context, sourceName string, so
 	if err != nil {
 		return nil, NewVersioningError(ErrMsgVersionSaveClone, err)
 	}
+	se.updateEmbedding(ctx, clone.Name)
 
 	return clone, nil
 }
@@ -389,6 +393,9 @@ func (h *VersionHistory) String() string {
 			sb.WriteString(fmt.Sprintf("  Labels: %s\n", strings.Join(v.Labels, ", ")))
 		}
 		sb.WriteString(fmt.Sprintf("  Size: %d chars (~%d tokens)\n", v.SourceLen, v.TokenEstimate.EstimatedGeneric))
+		if v.ContentHash != "" {
+			sb.WriteString(fmt.Sprintf("  Hash: %s\n", v.ContentHash))
+		}
 		if len(v.Tags) > 0 {
 			sb.WriteString(fmt.Sprintf("  Tags: %s\n", strings.Join(v.Tags, ", ")))
 		}
@@ -92,6 +92,28 @@ func TestE2E_RawBlockPreservesPromptyTags(t *testing.T) {
 	assert.Equal(t, `{~prompty.var name="x" /~}`, result)
 }
 
+func TestE2E_RawBlockVerbatimContent(t *testing.T) {
+	engine := prompty.MustNew()
+
+	source := "Docs: {~prompty.raw~}Use {~prompty.var  name='x' /~} or {~ on its own.\n{~/prompty.if~}{~/prompty.raw~} done"
+	result, err := engine.Execute(context.Background(), source, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "Docs: Use {~prompty.var  name='x' /~} or {~ on its own.\n{~/prompty.if~} done", result)
+}
+
+func TestE2E_CommentBlockWithMalformedTags(t *testing.T) {
+	engine := prompty.MustNew()
+
+	result, err := engine.Execute(context.Background(),
+		`A{~prompty.comment~}TODO: {~prompty.var name="x"{~/prompty.comment~}B{~prompty.comment /~}C`,
+		nil,
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, "ABC", result)
+}
+
 func TestE2E_EscapeSequence(t *testing.T) {
 	engine := prompty.MustNew()
 
@@ -223,6 +245,68 @@ func TestE2E_CustomDelimiters(t *testing.T) {
 	assert.Equal(t, "Hello, Alice!", result)
 }
 
+func TestE2E_CustomDelimiters_FullSyntax(t *testing.T) {
+	engine := prompty.MustNew(prompty.WithDelimiters("[[~", "~]]"))
+	engine.MustRegisterTemplate("base", `<[[~prompty.block name="body"~]]base[[~/prompty.block~]]>`)
+
+	source := `[[~prompty.extends template="base" /~]][[~prompty.block name="body"~]]` +
+		`[[~prompty.if eval="count > 1"~]]many[[~prompty.else~]]one[[~/prompty.if~]] ` +
+		`[[~prompty.raw~]][[~x~]]{~y~}[[~/prompty.raw~]] \[[~ {~prompty.var name="count" /~}` +
+		`[[~/prompty.block~]]`
+	result, err := engine.Execute(context.Background(), source, map[string]any{"count": 2})
+
+	require.NoError(t, err)
+	assert.Equal(t, `<many [[~x~]]{~y~} [[~ {~prompty.var name="count" /~}>`, result)
+}
+
+func TestE2E_CustomDelimiters_KeepRawPreservesSource(t *testing.T) {
+	engine := prompty.MustNew(
+		prompty.WithDelimiters("[[~", "~]]"),
+		prompty.WithErrorStrategy(prompty.ErrorStrategyKeepRaw),
+	)
+
+	result, err := engine.Execute(context.Background(),
+		`A [[~unknown a="1" /~]] B [[~other~]]body[[~/other~]] C`,
+		nil,
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, `A [[~unknown a="1" /~]] B [[~other~]]body[[~/other~]] C`, result)
+}
+
+func TestE2E_CustomDelimiters_ErrorPosition(t *testing.T) {
+	engine := prompty.MustNew(prompty.WithDelimiters("[[~", "~]]"))
+
+	_, err := engine.Parse("line one\n  [[~prompty.var name=\"x\" ~]]")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+}
+
+func TestE2E_CustomDelimiters_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		open   string
+		close  string
+		errMsg string
+	}{
+		{"identical", "%%", "%%", prompty.ErrMsgDelimitersIdentical},
+		{"whitespace", "{ ~", "~}", prompty.ErrMsgDelimiterInvalidChar},
+		{"quote", "<\"", "%>", prompty.ErrMsgDelimiterInvalidChar},
+		{"equals", "<%", "=%>", prompty.ErrMsgDelimiterInvalidChar},
+		{"backslash", "\\<", ">", prompty.ErrMsgDelimiterInvalidChar},
+		{"leading slash", "<%", "/>", prompty.ErrMsgDelimiterInvalidChar},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := prompty.New(prompty.WithDelimiters(tt.open, tt.close))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
 func TestE2E_NumericValues(t *testing.T) {
 	engine := prompty.MustNew()
 
@@ -431,6 +515,31 @@ func TestE2E_NestedTemplate_RegisterDuplicate(t *testing.T) {
 	assert.Contains(t, err.Error(), "already registered")
 }
 
+func TestE2E_NestedTemplate_RegisterTemplates(t *testing.T) {
+	engine := prompty.MustNew()
+	engine.MustRegisterTemplate("footer", "Copyright 2024")
+
+	err := engine.RegisterTemplates(map[string]string{
+		"header": "Welcome",
+		"page":   `{~prompty.include template="header" /~} | {~prompty.include template="footer" /~}`,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"footer", "header", "page"}, engine.ListTemplates())
+
+	output, err := engine.ExecuteTemplate(context.Background(), "page", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome | Copyright 2024", output)
+
+	// A failing template leaves the others unregistered
+	err = engine.RegisterTemplates(map[string]string{"extra": "More", "footer": "Different content"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already registered")
+	err = engine.RegisterTemplates(map[string]string{"extra": "More", "broken": `{~prompty.if eval="x" ~}`})
+	require.Error(t, err)
+	assert.False(t, engine.HasTemplate("extra"))
+	assert.Equal(t, 3, engine.TemplateCount())
+}
+
 func TestE2E_NestedTemplate_RegisterReservedName(t *testing.T) {
 	engine := prompty.MustNew()
 
@@ -543,6 +652,106 @@ func TestE2E_NestedTemplate_ContextOverride(t *testing.T) {
 	assert.Equal(t, "Hello, Bob!", result) // Should use override from attribute
 }
 
+func TestE2E_NestedTemplate_WithMapping(t *testing.T) {
+	engine := prompty.MustNew()
+	engine.MustRegisterFunc(&prompty.Func{
+		Name:    "shout",
+		MinArgs: 1,
+		MaxArgs: 1,
+		Fn: func(args []any) (any, error) {
+			s, _ := args[0].(string)
+			return strings.ToUpper(s), nil
+		},
+	})
+	engine.MustRegisterTemplate("card", `{~prompty.var name="name" /~} ({~prompty.var name="count" /~} items, vip={~prompty.var name="vip" /~}){~prompty.var name="email" default="" /~}`)
+
+	result, err := engine.Execute(context.Background(),
+		`{~prompty.include template="card" with="name=shout(customer.name), count=len(cart), vip=customer.tier == 'gold'" /~}`,
+		map[string]any{
+			"customer": map[string]any{"name": "Ada", "tier": "gold", "email": "ada@example.com"},
+			"cart":     []any{"a", "b", "c"},
+		},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, "ADA (3 items, vip=true)", result)
+}
+
+func TestE2E_NestedTemplate_WithMappingInvalid(t *testing.T) {
+	engine := prompty.MustNew()
+	engine.MustRegisterTemplate("card", "x")
+
+	source := `{~prompty.include template="card" with="name=,x=y" /~}`
+	result, err := engine.Validate(source)
+	require.NoError(t, err)
+	assert.True(t, result.HasErrors())
+
+	_, err = engine.Execute(context.Background(), source, nil)
+	require.Error(t, err)
+}
+
+func TestE2E_NestedTemplate_TemplateExpr(t *testing.T) {
+	engine := prompty.MustNew(prompty.WithDynamicIncludeAllowlist("intro-*"))
+	engine.MustRegisterTemplate("intro-en", `Hello {~prompty.var name="who" /~}`)
+	engine.MustRegisterTemplate("intro-de", `Hallo {~prompty.var name="who" /~}`)
+	engine.MustRegisterTemplate("admin-panel", "secret")
+
+	source := `{~prompty.include template_expr="'intro-' + lang" who="Ada" /~}`
+	result, err := engine.Execute(context.Background(), source, map[string]any{"lang": "de"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hallo Ada", result)
+
+	result, err = engine.Execute(context.Background(), source, map[string]any{"lang": "en"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello Ada", result)
+
+	// Names outside the allowlist are rejected even when registered
+	_, err = engine.Execute(context.Background(), `{~prompty.include template_expr="page" /~}`, map[string]any{"page": "admin-panel"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not allowed")
+
+	// Literal names are not restricted
+	result, err = engine.Execute(context.Background(), `{~prompty.include template="admin-panel" /~}`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", result)
+}
+
+func TestE2E_WithoutBuiltins(t *testing.T) {
+	t.Setenv("PROMPTY_TEST_SECRET", "s3cr3t")
+	source := `{~prompty.env name="PROMPTY_TEST_SECRET" /~}`
+
+	result, err := prompty.MustNew().Execute(context.Background(), source, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", result)
+
+	engine := prompty.MustNew(prompty.WithoutBuiltins(prompty.TagNameEnv))
+	assert.False(t, engine.HasResolver(prompty.TagNameEnv))
+	_, err = engine.Execute(context.Background(), source, nil)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "s3cr3t")
+
+	// Other built-ins stay available
+	result, err = engine.Execute(context.Background(), `{~prompty.var name="x" /~}`, map[string]any{"x": "ok"})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result)
+}
+
+func TestE2E_NestedTemplate_TemplateExprInvalid(t *testing.T) {
+	_, err := prompty.New(prompty.WithDynamicIncludeAllowlist("intro-["))
+	require.Error(t, err)
+
+	engine := prompty.MustNew()
+	result, err := engine.Validate(`{~prompty.include template_expr="'intro-' +" /~}`)
+	require.NoError(t, err)
+	assert.True(t, result.HasErrors())
+
+	// Without an allowlist any registered template can be selected
+	engine.MustRegisterTemplate("x", "ok")
+	out, err := engine.Execute(context.Background(), `{~prompty.include template_expr="name" /~}`, map[string]any{"name": "x"})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", out)
+}
+
 func TestE2E_NestedTemplate_NotFound(t *testing.T) {
 	engine := prompty.MustNew()
 
@@ -571,11 +780,14 @@ func TestE2E_NestedTemplate_MaxDepthExceeded(t *testing.T) {
 	// Use a small max depth for testing
 	engine := prompty.MustNew(prompty.WithMaxDepth(3))
 
-	// Register a template that includes itself
-	engine.MustRegisterTemplate("recursive", `X{~prompty.include template="recursive" /~}`)
+	// Register a chain of templates nested deeper than the limit
+	engine.MustRegisterTemplate("level1", `1{~prompty.include template="level2" /~}`)
+	engine.MustRegisterTemplate("level2", `2{~prompty.include template="level3" /~}`)
+	engine.MustRegisterTemplate("level3", `3{~prompty.include template="level4" /~}`)
+	engine.MustRegisterTemplate("level4", `4`)
 
 	_, err := engine.Execute(context.Background(),
-		`{~prompty.include template="recursive" /~}`,
+		`{~prompty.include template="level1" /~}`,
 		nil,
 	)
 
@@ -583,6 +795,29 @@ func TestE2E_NestedTemplate_MaxDepthExceeded(t *testing.T) {
 	assert.Contains(t, err.Error(), "depth")
 }
 
+func TestE2E_NestedTemplate_IncludeCycle(t *testing.T) {
+	engine := prompty.MustNew()
+
+	engine.MustRegisterTemplate("recursive", `X{~prompty.include template="recursive" /~}`)
+	engine.MustRegisterTemplate("ping", `{~prompty.include template="pong" /~}`)
+	engine.MustRegisterTemplate("pong", `{~prompty.include template="ping" /~}`)
+
+	_, err := engine.Execute(context.Background(), `{~prompty.include template="recursive" /~}`, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), prompty.ErrMsgTemplateIncludeCycle)
+
+	_, err = engine.ExecuteTemplate(context.Background(), "ping", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), prompty.ErrMsgTemplateIncludeCycle)
+
+	// Including the same template twice side by side is not a cycle
+	engine.MustRegisterTemplate("twice", `{~prompty.include template="level" /~}{~prompty.include template="level" /~}`)
+	engine.MustRegisterTemplate("level", `L`)
+	out, err := engine.ExecuteTemplate(context.Background(), "twice", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "LL", out)
+}
+
 func TestE2E_NestedTemplate_MultiLevelNesting(t *testing.T) {
 	engine := prompty.MustNew()
 
@@ -2409,6 +2644,62 @@ func TestE2E_Switch_Error_DuplicateDefault(t *testing.T) {
 	assert.Contains(t, err.Error(), "one default")
 }
 
+func TestE2E_Switch_DefaultAlias(t *testing.T) {
+	engine := prompty.MustNew()
+
+	result, err := engine.Execute(context.Background(),
+		`{~prompty.switch eval="status"~}{~prompty.case value="active"~}Active{~/prompty.case~}{~prompty.default~}Unknown{~/prompty.default~}{~/prompty.switch~}`,
+		map[string]any{"status": "archived"},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, "Unknown", result)
+}
+
+func TestE2E_Switch_Fallthrough(t *testing.T) {
+	engine := prompty.MustNew()
+
+	tmpl, err := engine.Parse(`{~prompty.switch eval="tier"~}{~prompty.case value="gold" fallthrough="true"~}Priority support. {~/prompty.case~}{~prompty.case value="silver" fallthrough="true"~}Email support. {~/prompty.case~}{~prompty.case value="bronze"~}Docs access.{~/prompty.case~}{~prompty.default~}No plan.{~/prompty.default~}{~/prompty.switch~}`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		tier     string
+		expected string
+	}{
+		{"gold", "Priority support. Email support. Docs access."},
+		{"silver", "Email support. Docs access."},
+		{"bronze", "Docs access."},
+		{"none", "No plan."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tier, func(t *testing.T) {
+			result, err := tmpl.Execute(context.Background(), map[string]any{"tier": tt.tier})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestE2E_Switch_Error_ValueAndEval(t *testing.T) {
+	engine := prompty.MustNew()
+
+	_, err := engine.Parse(`{~prompty.switch eval="x"~}{~prompty.case value="1" eval="x > 0"~}One{~/prompty.case~}{~/prompty.switch~}`)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), prompty.ErrMsgSwitchValueAndEval)
+}
+
+func TestE2E_Switch_Validation_CaseOutsideSwitch(t *testing.T) {
+	engine := prompty.MustNew()
+
+	result, err := engine.Validate(`{~prompty.case value="a"~}A{~/prompty.case~}`)
+
+	require.NoError(t, err)
+	require.True(t, result.HasErrors())
+	assert.Contains(t, result.Errors()[0].Message, prompty.ErrMsgSwitchCaseOutsideSwitch)
+}
+
 func TestE2E_Switch_Validation_Valid(t *testing.T) {
 	engine := prompty.MustNew()
 